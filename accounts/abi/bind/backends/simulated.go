@@ -847,6 +847,7 @@ func (m callMsg) Mint() *uint256.Int                    { return nil }
 func (m callMsg) RollupCostData() types2.RollupCostData { return types2.RollupCostData{} }
 func (m callMsg) IsDepositTx() bool                     { return false }
 func (m callMsg) IsSystemTx() bool                      { return false }
+func (m callMsg) BobaTuring() []byte                    { return nil }
 
 func (m callMsg) BlobGas() uint64                { return misc.GetBlobGasUsed(len(m.CallMsg.BlobHashes)) }
 func (m callMsg) MaxFeePerBlobGas() *uint256.Int { return m.CallMsg.MaxFeePerBlobGas }
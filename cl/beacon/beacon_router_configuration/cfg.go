@@ -27,6 +27,10 @@ type RouterConfiguration struct {
 	Node       bool
 	Validator  bool
 	Lighthouse bool
+	// Checkpoint - serve the finalized state for checkpoint sync of other nodes
+	Checkpoint bool
+	// CheckpointRateLimit - finalized states served per second, 0 - unlimited
+	CheckpointRateLimit float64
 }
 
 func (r *RouterConfiguration) UnwrapEndpointsList(l []string) error {
@@ -51,6 +55,8 @@ func (r *RouterConfiguration) UnwrapEndpointsList(l []string) error {
 			r.Validator = true
 		case "lighthouse":
 			r.Lighthouse = true
+		case "checkpoint":
+			r.Checkpoint = true
 		default:
 			r.Active = false
 			r.Beacon = false
@@ -61,7 +67,8 @@ func (r *RouterConfiguration) UnwrapEndpointsList(l []string) error {
 			r.Node = false
 			r.Validator = false
 			r.Lighthouse = false
-			return fmt.Errorf("unknown endpoint for beacon.api: %s. known endpoints: beacon, builder, config, debug, events, node, validator, lighthouse", v)
+			r.Checkpoint = false
+			return fmt.Errorf("unknown endpoint for beacon.api: %s. known endpoints: beacon, builder, config, debug, events, node, validator, lighthouse, checkpoint", v)
 		}
 	}
 	return nil
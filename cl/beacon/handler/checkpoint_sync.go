@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"golang.org/x/time/rate"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/metrics"
+	"github.com/erigontech/erigon/cl/beacon/beaconhttp"
+	"github.com/erigontech/erigon/cl/clparams"
+)
+
+var errCheckpointRateLimited = errors.New("checkpoint sync rate limit exceeded, retry later")
+
+const (
+	// checkpointStateEncodeTimeout - write deadline of the response while the state is read and encoded
+	checkpointStateEncodeTimeout = 2 * time.Minute
+	// checkpointStateMinRate - bytes per second a client has to read at least, the write deadline of the state is
+	// by its size, the write timeout of the server is much shorter than sending hundreds of megabytes
+	checkpointStateMinRate = 1 << 20
+)
+
+var (
+	checkpointStatesServed  = metrics.GetOrCreateCounter("beacon_checkpoint_states_served")
+	checkpointStatesLimited = metrics.GetOrCreateCounter("beacon_checkpoint_states_rate_limited")
+)
+
+// checkpointState - the finalized state encoded for checkpoint sync. The state is hundreds of megabytes,
+// so it's encoded (and compressed) once per finalized checkpoint and then served from memory.
+type checkpointState struct {
+	lock    sync.Mutex
+	limiter *rate.Limiter
+
+	root    libcommon.Hash // block root of the finalized checkpoint
+	version clparams.StateVersion
+	encoded []byte
+	gzipped []byte
+}
+
+func newCheckpointState(limit float64) *checkpointState {
+	c := &checkpointState{limiter: rate.NewLimiter(rate.Inf, 0)}
+	if limit > 0 {
+		c.limiter = rate.NewLimiter(rate.Limit(limit), max(1, int(limit)))
+	}
+	return c
+}
+
+// GetEthV2DebugBeaconStates serves /eth/v2/debug/beacon/states/{state_id}: the finalized state by the checkpoint
+// endpoint group, other states by the debug one.
+func (a *ApiHandler) GetEthV2DebugBeaconStates(w http.ResponseWriter, r *http.Request) {
+	if a.routerCfg.Checkpoint && chi.URLParam(r, "state_id") == "finalized" {
+		a.GetEthV2DebugBeaconStatesFinalized(w, r)
+		return
+	}
+	if !a.routerCfg.Debug {
+		beaconhttp.NewEndpointError(http.StatusNotFound, errors.New("only the finalized state is served, enable the debug API for other states")).WriteTo(w)
+		return
+	}
+	beaconhttp.HandleEndpointFunc(a.getFullState)(w, r)
+}
+
+// GetEthV2DebugBeaconStatesFinalized serves /eth/v2/debug/beacon/states/finalized for checkpoint sync of other nodes.
+// SSZ is served from cache and gzipped if the client accepts it, JSON by getFullState.
+func (a *ApiHandler) GetEthV2DebugBeaconStatesFinalized(w http.ResponseWriter, r *http.Request) {
+	if !a.checkpointState.limiter.Allow() {
+		checkpointStatesLimited.Inc()
+		w.Header().Set("Retry-After", "1")
+		beaconhttp.NewEndpointError(http.StatusTooManyRequests, errCheckpointRateLimited).WriteTo(w)
+		return
+	}
+	if !strings.Contains(r.Header.Get("Accept"), "application/octet-stream") {
+		beaconhttp.HandleEndpointFunc(a.getFullState)(w, r)
+		return
+	}
+	rc := http.NewResponseController(w)
+	a.setCheckpointWriteDeadline(rc, r, checkpointStateEncodeTimeout)
+	useGzip := strings.Contains(r.Header.Get("Accept-Encoding"), "gzip")
+	version, encoded, err := a.encodedCheckpointState(r, useGzip)
+	if err != nil {
+		beaconhttp.WrapEndpointError(err).WriteTo(w)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Eth-Consensus-Version", clparams.ClVersionToString(version))
+	if useGzip {
+		w.Header().Set("Content-Encoding", "gzip")
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(encoded)))
+	checkpointStatesServed.Inc()
+	a.setCheckpointWriteDeadline(rc, r, checkpointStateEncodeTimeout+time.Duration(len(encoded)/checkpointStateMinRate)*time.Second)
+	if _, err := w.Write(encoded); err != nil {
+		a.logger.Debug("[Beacon API] checkpoint state not sent", "remote", r.RemoteAddr, "err", err)
+	}
+}
+
+func (a *ApiHandler) setCheckpointWriteDeadline(rc *http.ResponseController, r *http.Request, timeout time.Duration) {
+	if err := rc.SetWriteDeadline(time.Now().Add(timeout)); err != nil {
+		a.logger.Debug("[Beacon API] write deadline of checkpoint state not set", "remote", r.RemoteAddr, "err", err)
+	}
+}
+
+// encodedCheckpointState returns SSZ of the current finalized state, encoding it if the finalized checkpoint moved.
+// Concurrent requests wait for one encoding instead of doing their own.
+func (a *ApiHandler) encodedCheckpointState(r *http.Request, gzipped bool) (clparams.StateVersion, []byte, error) {
+	c := a.checkpointState
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	root := a.forkchoiceStore.FinalizedCheckpoint().BlockRoot()
+	if root != c.root || c.encoded == nil {
+		ctx := r.Context()
+		tx, err := a.indiciesDB.BeginRo(ctx)
+		if err != nil {
+			return 0, nil, err
+		}
+		defer tx.Rollback()
+		st, _, err := a.readFullState(ctx, tx, root)
+		if err != nil {
+			return 0, nil, err
+		}
+		encoded, err := st.EncodeSSZ(nil)
+		if err != nil {
+			return 0, nil, err
+		}
+		c.root, c.version, c.encoded, c.gzipped = root, st.Version(), encoded, nil
+		a.logger.Debug("[Beacon API] encoded checkpoint state", "slot", st.Slot(), "root", root, "size", len(encoded))
+	}
+	if !gzipped {
+		return c.version, c.encoded, nil
+	}
+	if c.gzipped == nil {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		if _, err := gz.Write(c.encoded); err != nil {
+			return 0, nil, err
+		}
+		if err := gz.Close(); err != nil {
+			return 0, nil, err
+		}
+		c.gzipped = buf.Bytes()
+	}
+	return c.version, c.gzipped, nil
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/cl/clparams"
+	"github.com/erigontech/erigon/cl/cltypes/solid"
+	"github.com/erigontech/erigon/cl/phase1/core/state"
+)
+
+func TestGetStateFinalizedCheckpointSync(t *testing.T) {
+	_, blocks, _, _, postState, handler, _, _, fcu, _ := setupTestingHandler(t, clparams.Phase0Version, log.Root())
+
+	var err error
+	fcu.HeadVal, err = blocks[len(blocks)-1].Block.HashSSZ()
+	require.NoError(t, err)
+	fcu.HeadSlotVal = blocks[len(blocks)-1].Block.Slot
+	fcu.FinalizedCheckpointVal = solid.NewCheckpointFromParameters(fcu.HeadVal, fcu.HeadSlotVal/32)
+	fcu.StateAtBlockRootVal[fcu.HeadVal] = postState
+
+	handler.routerCfg.Debug = false
+	handler.routerCfg.Checkpoint = true
+	handler.checkpointState = newCheckpointState(2)
+	handler.init()
+	server := httptest.NewServer(handler.mux)
+	defer server.Close()
+
+	get := func(encoding string) *http.Response {
+		req, err := http.NewRequest("GET", server.URL+"/eth/v2/debug/beacon/states/finalized", nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept", "application/octet-stream")
+		// set explicitly, so the client doesn't decompress the body on its own
+		req.Header.Set("Accept-Encoding", encoding)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := get("identity")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Empty(t, resp.Header.Get("Content-Encoding"))
+	require.Equal(t, "phase0", resp.Header.Get("Eth-Consensus-Version"))
+	plain, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	other := state.New(&clparams.MainnetBeaconConfig)
+	require.NoError(t, other.DecodeSSZ(plain, int(clparams.Phase0Version)))
+	require.Equal(t, postState.Slot(), other.Slot())
+
+	resp = get("gzip")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+	gz, err := gzip.NewReader(resp.Body)
+	require.NoError(t, err)
+	decompressed, err := io.ReadAll(gz)
+	require.NoError(t, err)
+	require.Equal(t, plain, decompressed)
+
+	// the burst is used up
+	resp = get("gzip")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+
+	// other states are served only by the debug endpoint
+	req, err := http.NewRequest("GET", server.URL+"/eth/v2/debug/beacon/states/head", nil)
+	require.NoError(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// with the debug API too: JSON of the finalized state, other states by the debug endpoint
+	handler.routerCfg.Debug = true
+	handler.checkpointState = newCheckpointState(0)
+	handler.init()
+	debugServer := httptest.NewServer(handler.mux)
+	defer debugServer.Close()
+	for _, stateId := range []string{"finalized", "head"} {
+		resp, err = http.Get(debugServer.URL + "/eth/v2/debug/beacon/states/" + stateId)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode, stateId)
+	}
+}
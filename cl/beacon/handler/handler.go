@@ -64,6 +64,7 @@ type ApiHandler struct {
 
 	// caches
 	lighthouseInclusionCache sync.Map
+	checkpointState          *checkpointState
 	emitters                 *beaconevents.Emitters
 
 	routerCfg *beacon_router_configuration.RouterConfiguration
@@ -147,6 +148,7 @@ func NewApiHandler(
 		caplinSnapshots:                  caplinSnapshots,
 		attestationProducer:              attestationProducer,
 		blobBundles:                      blobBundles,
+		checkpointState:                  newCheckpointState(routerCfg.CheckpointRateLimit),
 		engine:                           engine,
 		syncMessagePool:                  syncMessagePool,
 		committeeSub:                     committeeSub,
@@ -284,11 +286,13 @@ func (a *ApiHandler) init() {
 
 		})
 		r.Route("/v2", func(r chi.Router) {
-			if a.routerCfg.Debug {
+			if a.routerCfg.Debug || a.routerCfg.Checkpoint {
 				r.Route("/debug", func(r chi.Router) {
 					r.Route("/beacon", func(r chi.Router) {
-						r.Get("/states/{state_id}", beaconhttp.HandleEndpointFunc(a.getFullState))
-						r.Get("/heads", beaconhttp.HandleEndpointFunc(a.GetEthV2DebugBeaconHeads))
+						r.Get("/states/{state_id}", a.GetEthV2DebugBeaconStates)
+						if a.routerCfg.Debug {
+							r.Get("/heads", beaconhttp.HandleEndpointFunc(a.GetEthV2DebugBeaconHeads))
+						}
 					})
 				})
 			}
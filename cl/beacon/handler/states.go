@@ -14,6 +14,7 @@ import (
 	"github.com/erigontech/erigon/cl/cltypes/solid"
 	"github.com/erigontech/erigon/cl/persistence/beacon_indicies"
 	state_accessors "github.com/erigontech/erigon/cl/persistence/state"
+	"github.com/erigontech/erigon/cl/phase1/core/state"
 	"github.com/erigontech/erigon/cl/utils"
 )
 
@@ -171,37 +172,46 @@ func (a *ApiHandler) getFullState(w http.ResponseWriter, r *http.Request) (*beac
 		return nil, beaconhttp.NewEndpointError(httpStatus, err)
 	}
 
-	state, err := a.forkchoiceStore.GetStateAtBlockRoot(blockRoot, true)
+	st, finalized, err := a.readFullState(ctx, tx, blockRoot)
 	if err != nil {
-		return nil, beaconhttp.NewEndpointError(http.StatusBadRequest, err)
-	}
-	if state == nil {
-		slot, err := beacon_indicies.ReadBlockSlotByBlockRoot(tx, blockRoot)
-		if err != nil {
-			return nil, err
-		}
-		// Sanity checks slot and canonical data.
-		if slot == nil {
-			return nil, beaconhttp.NewEndpointError(http.StatusNotFound, fmt.Errorf("could not read block slot: %x", blockRoot))
-		}
-		canonicalRoot, err := beacon_indicies.ReadCanonicalBlockRoot(tx, *slot)
-		if err != nil {
-			return nil, err
-		}
-		if canonicalRoot != blockRoot {
-			return nil, beaconhttp.NewEndpointError(http.StatusNotFound, fmt.Errorf("could not read state: %x", blockRoot))
-		}
-		state, err := a.stateReader.ReadHistoricalState(ctx, tx, *slot)
-		if err != nil {
-			return nil, err
-		}
-		if state == nil {
-			return nil, beaconhttp.NewEndpointError(http.StatusNotFound, fmt.Errorf("could not read state: %x", blockRoot))
-		}
-		return newBeaconResponse(state).WithFinalized(true).WithVersion(state.Version()), nil
+		return nil, err
 	}
+	return newBeaconResponse(st).WithFinalized(finalized).WithVersion(st.Version()), nil
+}
 
-	return newBeaconResponse(state).WithFinalized(false).WithVersion(state.Version()), nil
+// readFullState returns the state after the block, from forkchoice or, if it's not there, from historical states.
+// Historical states are read only for canonical blocks and are reported as finalized.
+func (a *ApiHandler) readFullState(ctx context.Context, tx kv.Tx, blockRoot libcommon.Hash) (*state.CachingBeaconState, bool, error) {
+	st, err := a.forkchoiceStore.GetStateAtBlockRoot(blockRoot, true)
+	if err != nil {
+		return nil, false, beaconhttp.NewEndpointError(http.StatusBadRequest, err)
+	}
+	if st != nil {
+		return st, false, nil
+	}
+	slot, err := beacon_indicies.ReadBlockSlotByBlockRoot(tx, blockRoot)
+	if err != nil {
+		return nil, false, err
+	}
+	// Sanity checks slot and canonical data.
+	if slot == nil {
+		return nil, false, beaconhttp.NewEndpointError(http.StatusNotFound, fmt.Errorf("could not read block slot: %x", blockRoot))
+	}
+	canonicalRoot, err := beacon_indicies.ReadCanonicalBlockRoot(tx, *slot)
+	if err != nil {
+		return nil, false, err
+	}
+	if canonicalRoot != blockRoot {
+		return nil, false, beaconhttp.NewEndpointError(http.StatusNotFound, fmt.Errorf("could not read state: %x", blockRoot))
+	}
+	st, err = a.stateReader.ReadHistoricalState(ctx, tx, *slot)
+	if err != nil {
+		return nil, false, err
+	}
+	if st == nil {
+		return nil, false, beaconhttp.NewEndpointError(http.StatusNotFound, fmt.Errorf("could not read state: %x", blockRoot))
+	}
+	return st, true, nil
 }
 
 type finalityCheckpointsResponse struct {
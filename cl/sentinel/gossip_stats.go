@@ -0,0 +1,246 @@
+package sentinel
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+
+	"github.com/erigontech/erigon-lib/metrics"
+)
+
+// peerScoreInspectInterval - how often peer scores are snapshotted and topic rates are recomputed
+const peerScoreInspectInterval = 10 * time.Second
+
+var (
+	peerScoreMin    = metrics.GetOrCreateGauge("sentinel_peer_score_min")
+	peerScoreMax    = metrics.GetOrCreateGauge("sentinel_peer_score_max")
+	peerScoreAvg    = metrics.GetOrCreateGauge("sentinel_peer_score_avg")
+	peersNegative   = metrics.GetOrCreateGauge("sentinel_peers_negative_score")
+	peersGraylisted = metrics.GetOrCreateGauge("sentinel_peers_graylisted")
+)
+
+// TopicStats - gossip counters of one topic since start
+type TopicStats struct {
+	Topic      string `json:"topic"`
+	Delivered  uint64 `json:"delivered"`
+	Duplicates uint64 `json:"duplicates"`
+	// Rejected - messages which failed validation, by reason
+	Rejected map[string]uint64 `json:"rejected,omitempty"`
+	// DeliveredRate - delivered messages per second, over the last peerScoreInspectInterval
+	DeliveredRate float64 `json:"deliveredRate"`
+}
+
+// PeerScore - gossipsub score of the peer and its components
+type PeerScore struct {
+	Pid              string  `json:"pid"`
+	Score            float64 `json:"score"`
+	AppSpecific      float64 `json:"appSpecific"`
+	IPColocation     float64 `json:"ipColocation"`
+	BehaviourPenalty float64 `json:"behaviourPenalty"`
+	// InvalidDeliveries - decayed count of invalid messages delivered by the peer, by topic
+	InvalidDeliveries map[string]float64 `json:"invalidDeliveries,omitempty"`
+}
+
+type topicCounters struct {
+	delivered, duplicates metrics.Counter
+	rejected              map[string]metrics.Counter
+
+	deliveredCount, duplicatesCount uint64
+	rejectedCount                   map[string]uint64
+	lastDelivered                   uint64
+	rate                            float64
+}
+
+// gossipStats collects per-topic message counters as pubsub.RawTracer and peer scores by pubsub score inspection
+type gossipStats struct {
+	lock        sync.Mutex
+	topics      map[string]*topicCounters
+	lastInspect time.Time
+
+	scores atomic.Pointer[[]PeerScore]
+
+	graylistThreshold float64
+}
+
+var _ pubsub.RawTracer = (*gossipStats)(nil)
+
+func newGossipStats(graylistThreshold float64) *gossipStats {
+	return &gossipStats{
+		topics:            map[string]*topicCounters{},
+		lastInspect:       time.Now(),
+		graylistThreshold: graylistThreshold,
+	}
+}
+
+// gossipTopicName - short name of the topic, e.g. blob_sidecar_3 of /eth2/d31f6191/blob_sidecar_3/ssz_snappy
+func gossipTopicName(topic string) string {
+	parts := strings.Split(topic, "/")
+	if len(parts) < 4 {
+		return topic
+	}
+	return parts[3]
+}
+
+// counters returns counters of the topic, must be called under lock
+func (g *gossipStats) counters(topic string) *topicCounters {
+	name := gossipTopicName(topic)
+	c, ok := g.topics[name]
+	if !ok {
+		c = &topicCounters{
+			delivered:     metrics.GetOrCreateCounter(fmt.Sprintf(`sentinel_gossip_messages{topic="%s",result="delivered"}`, name)),
+			duplicates:    metrics.GetOrCreateCounter(fmt.Sprintf(`sentinel_gossip_messages{topic="%s",result="duplicate"}`, name)),
+			rejected:      map[string]metrics.Counter{},
+			rejectedCount: map[string]uint64{},
+		}
+		g.topics[name] = c
+	}
+	return c
+}
+
+func (g *gossipStats) DeliverMessage(msg *pubsub.Message) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	c := g.counters(msg.GetTopic())
+	c.deliveredCount++
+	c.delivered.Inc()
+}
+
+func (g *gossipStats) DuplicateMessage(msg *pubsub.Message) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	c := g.counters(msg.GetTopic())
+	c.duplicatesCount++
+	c.duplicates.Inc()
+}
+
+func (g *gossipStats) RejectMessage(msg *pubsub.Message, reason string) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	c := g.counters(msg.GetTopic())
+	counter, ok := c.rejected[reason]
+	if !ok {
+		counter = metrics.GetOrCreateCounter(fmt.Sprintf(`sentinel_gossip_rejected{topic="%s",reason="%s"}`, gossipTopicName(msg.GetTopic()), reason))
+		c.rejected[reason] = counter
+	}
+	c.rejectedCount[reason]++
+	counter.Inc()
+}
+
+func (g *gossipStats) AddPeer(peer.ID, protocol.ID)         {}
+func (g *gossipStats) RemovePeer(peer.ID)                   {}
+func (g *gossipStats) Join(string)                          {}
+func (g *gossipStats) Leave(string)                         {}
+func (g *gossipStats) Graft(peer.ID, string)                {}
+func (g *gossipStats) Prune(peer.ID, string)                {}
+func (g *gossipStats) ValidateMessage(*pubsub.Message)      {}
+func (g *gossipStats) ThrottlePeer(peer.ID)                 {}
+func (g *gossipStats) RecvRPC(*pubsub.RPC)                  {}
+func (g *gossipStats) SendRPC(*pubsub.RPC, peer.ID)         {}
+func (g *gossipStats) DropRPC(*pubsub.RPC, peer.ID)         {}
+func (g *gossipStats) UndeliverableMessage(*pubsub.Message) {}
+
+// inspectPeerScores is called by pubsub every peerScoreInspectInterval, it also recomputes topic rates
+func (g *gossipStats) inspectPeerScores(snapshots map[peer.ID]*pubsub.PeerScoreSnapshot) {
+	scores := make([]PeerScore, 0, len(snapshots))
+	minScore, maxScore, sum := math.Inf(1), math.Inf(-1), 0.0
+	negative, graylisted := 0, 0
+	for pid, snapshot := range snapshots {
+		score := PeerScore{
+			Pid:              pid.String(),
+			Score:            snapshot.Score,
+			AppSpecific:      snapshot.AppSpecificScore,
+			IPColocation:     snapshot.IPColocationFactor,
+			BehaviourPenalty: snapshot.BehaviourPenalty,
+		}
+		for topic, t := range snapshot.Topics {
+			if t.InvalidMessageDeliveries == 0 {
+				continue
+			}
+			if score.InvalidDeliveries == nil {
+				score.InvalidDeliveries = map[string]float64{}
+			}
+			score.InvalidDeliveries[gossipTopicName(topic)] = t.InvalidMessageDeliveries
+		}
+		scores = append(scores, score)
+
+		minScore, maxScore, sum = min(minScore, snapshot.Score), max(maxScore, snapshot.Score), sum+snapshot.Score
+		if snapshot.Score < 0 {
+			negative++
+		}
+		if snapshot.Score < g.graylistThreshold {
+			graylisted++
+		}
+	}
+	slices.SortFunc(scores, func(a, b PeerScore) int { return strings.Compare(a.Pid, b.Pid) })
+	g.scores.Store(&scores)
+
+	if len(scores) == 0 {
+		minScore, maxScore = 0, 0
+	} else {
+		peerScoreAvg.Set(sum / float64(len(scores)))
+	}
+	peerScoreMin.Set(minScore)
+	peerScoreMax.Set(maxScore)
+	peersNegative.SetInt(negative)
+	peersGraylisted.SetInt(graylisted)
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	now := time.Now()
+	elapsed := now.Sub(g.lastInspect).Seconds()
+	g.lastInspect = now
+	for _, c := range g.topics {
+		if elapsed > 0 {
+			c.rate = float64(c.deliveredCount-c.lastDelivered) / elapsed
+		}
+		c.lastDelivered = c.deliveredCount
+	}
+}
+
+func (g *gossipStats) topicStats() []TopicStats {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	res := make([]TopicStats, 0, len(g.topics))
+	for name, c := range g.topics {
+		stats := TopicStats{
+			Topic:         name,
+			Delivered:     c.deliveredCount,
+			Duplicates:    c.duplicatesCount,
+			DeliveredRate: c.rate,
+		}
+		if len(c.rejectedCount) > 0 {
+			stats.Rejected = make(map[string]uint64, len(c.rejectedCount))
+			for reason, n := range c.rejectedCount {
+				stats.Rejected[reason] = n
+			}
+		}
+		res = append(res, stats)
+	}
+	slices.SortFunc(res, func(a, b TopicStats) int { return strings.Compare(a.Topic, b.Topic) })
+	return res
+}
+
+func (g *gossipStats) peerScores() []PeerScore {
+	if scores := g.scores.Load(); scores != nil {
+		return *scores
+	}
+	return nil
+}
+
+// GossipStats returns message counters of gossip topics, sorted by topic
+func (s *Sentinel) GossipStats() []TopicStats {
+	return s.gossipStats.topicStats()
+}
+
+// PeerScores returns gossipsub scores of peers as of the last inspection, sorted by peer ID
+func (s *Sentinel) PeerScores() []PeerScore {
+	return s.gossipStats.peerScores()
+}
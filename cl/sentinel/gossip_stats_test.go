@@ -0,0 +1,77 @@
+package sentinel
+
+import (
+	"testing"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	pb "github.com/libp2p/go-libp2p-pubsub/pb"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+)
+
+func gossipMessage(topic string) *pubsub.Message {
+	return &pubsub.Message{Message: &pb.Message{Topic: &topic}}
+}
+
+func TestGossipStatsTopics(t *testing.T) {
+	g := newGossipStats(graylistThreshold)
+	block, blob := "/eth2/d31f6191/beacon_block/ssz_snappy", "/eth2/d31f6191/blob_sidecar_3/ssz_snappy"
+	for i := 0; i < 3; i++ {
+		g.DeliverMessage(gossipMessage(block))
+	}
+	g.DuplicateMessage(gossipMessage(block))
+	g.DeliverMessage(gossipMessage(blob))
+	g.RejectMessage(gossipMessage(blob), pubsub.RejectValidationFailed)
+	g.RejectMessage(gossipMessage(blob), pubsub.RejectValidationFailed)
+	g.RejectMessage(gossipMessage(blob), pubsub.RejectValidationThrottled)
+
+	g.lastInspect = time.Now().Add(-10 * time.Second)
+	g.inspectPeerScores(nil)
+
+	stats := g.topicStats()
+	require.Len(t, stats, 2)
+	require.Equal(t, "beacon_block", stats[0].Topic)
+	require.Equal(t, uint64(3), stats[0].Delivered)
+	require.Equal(t, uint64(1), stats[0].Duplicates)
+	require.Empty(t, stats[0].Rejected)
+	require.InDelta(t, 0.3, stats[0].DeliveredRate, 0.01)
+
+	require.Equal(t, "blob_sidecar_3", stats[1].Topic)
+	require.Equal(t, uint64(1), stats[1].Delivered)
+	require.Equal(t, map[string]uint64{pubsub.RejectValidationFailed: 2, pubsub.RejectValidationThrottled: 1}, stats[1].Rejected)
+
+	// rate is over the last interval only
+	g.lastInspect = time.Now().Add(-10 * time.Second)
+	g.inspectPeerScores(nil)
+	require.Zero(t, g.topicStats()[0].DeliveredRate)
+}
+
+func TestGossipStatsPeerScores(t *testing.T) {
+	g := newGossipStats(graylistThreshold)
+	require.Empty(t, g.peerScores())
+
+	good, bad := peer.ID("good"), peer.ID("bad")
+	g.inspectPeerScores(map[peer.ID]*pubsub.PeerScoreSnapshot{
+		good: {Score: 12.5, Topics: map[string]*pubsub.TopicScoreSnapshot{
+			"/eth2/d31f6191/beacon_block/ssz_snappy": {FirstMessageDeliveries: 3},
+		}},
+		bad: {Score: -20000, BehaviourPenalty: 4, Topics: map[string]*pubsub.TopicScoreSnapshot{
+			"/eth2/d31f6191/beacon_block/ssz_snappy": {InvalidMessageDeliveries: 2},
+		}},
+	})
+	scores := map[string]PeerScore{}
+	for _, score := range g.peerScores() {
+		scores[score.Pid] = score
+	}
+	require.Len(t, scores, 2)
+	require.Equal(t, -20000.0, scores[bad.String()].Score)
+	require.Equal(t, 4.0, scores[bad.String()].BehaviourPenalty)
+	require.Equal(t, map[string]float64{"beacon_block": 2}, scores[bad.String()].InvalidDeliveries)
+	require.Equal(t, 12.5, scores[good.String()].Score)
+	require.Nil(t, scores[good.String()].InvalidDeliveries)
+
+	require.Equal(t, float64(1), peersGraylisted.GetValue())
+	require.Equal(t, float64(1), peersNegative.GetValue())
+	require.Equal(t, 12.5, peerScoreMax.GetValue())
+}
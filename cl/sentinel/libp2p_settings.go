@@ -15,11 +15,14 @@ func (s *Sentinel) scoreDecay(totalDurationDecay time.Duration) float64 {
 	return math.Pow(decayToZero, 1/float64(numOfTimes))
 }
 
+// graylistThreshold - gossip from peers with score below it is ignored
+const graylistThreshold = -16000
+
 func (s *Sentinel) pubsubOptions() []pubsub.Option {
 	thresholds := &pubsub.PeerScoreThresholds{
 		GossipThreshold:             -4000,
 		PublishThreshold:            -8000,
-		GraylistThreshold:           -16000,
+		GraylistThreshold:           graylistThreshold,
 		AcceptPXThreshold:           100,
 		OpportunisticGraftThreshold: 5,
 	}
@@ -49,6 +52,8 @@ func (s *Sentinel) pubsubOptions() []pubsub.Option {
 		pubsub.WithMaxMessageSize(int(s.cfg.NetworkConfig.GossipMaxSizeBellatrix)),
 		pubsub.WithValidateQueueSize(pubsubQueueSize),
 		pubsub.WithPeerScore(scoreParams, thresholds),
+		pubsub.WithPeerScoreInspect(pubsub.ExtendedPeerScoreInspectFn(s.gossipStats.inspectPeerScores), peerScoreInspectInterval),
+		pubsub.WithRawTracer(s.gossipStats),
 		pubsub.WithGossipSubParams(pubsubGossipParam()),
 	}
 	return psOpts
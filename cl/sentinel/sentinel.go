@@ -87,6 +87,7 @@ type Sentinel struct {
 	discoverConfig   discover.Config
 	pubsub           *pubsub.PubSub
 	subManager       *GossipManager
+	gossipStats      *gossipStats
 	metrics          bool
 	logger           log.Logger
 	forkChoiceReader forkchoice.ForkChoiceStorageReader
@@ -202,6 +203,7 @@ func New(
 		forkChoiceReader: forkChoiceReader,
 		blobStorage:      blobStorage,
 		ethClock:         ethClock,
+		gossipStats:      newGossipStats(graylistThreshold),
 	}
 
 	// Setup discovery
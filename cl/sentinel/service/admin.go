@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	sentinelrpc "github.com/erigontech/erigon-lib/gointerfaces/sentinel"
+	"github.com/erigontech/erigon-lib/log/v3"
+)
+
+// AdminHandler serves the dashboard of the sentinel and peer management:
+//
+//	GET  /gossip/topics      - message counters and rates of gossip topics
+//	GET  /peers/scores       - gossipsub scores of peers
+//	POST /peers/{pid}/ban    - disconnect and ban the peer
+//	POST /peers/{pid}/unban  - allow the peer to connect again
+func (s *SentinelServer) AdminHandler() http.Handler {
+	mux := chi.NewRouter()
+	mux.Get("/gossip/topics", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, s.sentinel.GossipStats())
+	})
+	mux.Get("/peers/scores", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, s.sentinel.PeerScores())
+	})
+	mux.Post("/peers/{pid}/ban", func(w http.ResponseWriter, r *http.Request) {
+		if _, err := s.BanPeer(r.Context(), &sentinelrpc.Peer{Pid: chi.URLParam(r, "pid")}); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.logger.Info("[Sentinel] peer banned by admin", "peer", chi.URLParam(r, "pid"))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.Post("/peers/{pid}/unban", func(w http.ResponseWriter, r *http.Request) {
+		if _, err := s.UnbanPeer(r.Context(), &sentinelrpc.Peer{Pid: chi.URLParam(r, "pid")}); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.logger.Info("[Sentinel] peer unbanned by admin", "peer", chi.URLParam(r, "pid"))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// StartAdmin serves AdminHandler on addr until ctx is done
+func StartAdmin(ctx context.Context, server *SentinelServer, addr string, logger log.Logger) {
+	srv := &http.Server{Addr: addr, Handler: server.AdminHandler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+	logger.Info("[Sentinel] admin endpoint started", "addr", addr)
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		logger.Warn("[Sentinel] could not serve admin endpoint", "reason", err)
+	}
+}
@@ -81,6 +81,15 @@ func (s *SentinelServer) BanPeer(_ context.Context, p *sentinelrpc.Peer) (*senti
 	return &sentinelrpc.EmptyMessage{}, nil
 }
 
+func (s *SentinelServer) UnbanPeer(_ context.Context, p *sentinelrpc.Peer) (*sentinelrpc.EmptyMessage, error) {
+	var pid peer.ID
+	if err := pid.UnmarshalText([]byte(p.Pid)); err != nil {
+		return nil, err
+	}
+	s.sentinel.Peers().SetBanStatus(pid, false)
+	return &sentinelrpc.EmptyMessage{}, nil
+}
+
 func (s *SentinelServer) PublishGossip(_ context.Context, msg *sentinelrpc.GossipData) (*sentinelrpc.EmptyMessage, error) {
 	manager := s.sentinel.GossipManager()
 	// Snappify payload before sending it to gossip
@@ -30,6 +30,8 @@ type ServerConfig struct {
 	Creds         credentials.TransportCredentials
 	Validator     bool
 	InitialStatus *cltypes.Status
+	// AdminAddr - address of the dashboard and peer management endpoint, empty - disabled. See SentinelServer.AdminHandler
+	AdminAddr string
 }
 
 func generateSubnetsTopics(template string, maxIds int) []sentinel.GossipTopic {
@@ -154,6 +156,9 @@ func StartSentinelService(
 	}
 	server := NewSentinelServer(ctx, sent, logger)
 	go StartServe(server, srvCfg, srvCfg.Creds)
+	if srvCfg.AdminAddr != "" {
+		go StartAdmin(ctx, server, srvCfg.AdminAddr, logger)
+	}
 
 	return direct.NewSentinelClientDirect(server), nil
 }
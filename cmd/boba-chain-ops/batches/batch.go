@@ -0,0 +1,146 @@
+package batches
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/andybalholm/brotli"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/rlp"
+)
+
+const (
+	SingularBatchType = 0
+	SpanBatchType     = 1
+
+	channelVersionBrotli = 1
+	// same limit of decompressed channel data as op-node after Fjord
+	maxRLPBytesPerChannel = 100_000_000
+)
+
+var ErrUnsupportedCompression = errors.New("unsupported channel compression")
+
+// RollupConfig - fields of rollup.json of op-node needed to place batches on L2
+type RollupConfig struct {
+	Genesis struct {
+		L2 struct {
+			Hash   libcommon.Hash `json:"hash"`
+			Number uint64         `json:"number"`
+		} `json:"l2"`
+		L2Time uint64 `json:"l2_time"`
+	} `json:"genesis"`
+	BlockTime uint64   `json:"block_time"`
+	L2ChainID *big.Int `json:"l2_chain_id"`
+}
+
+// BlockNumber - number of the L2 block with timestamp
+func (c *RollupConfig) BlockNumber(timestamp uint64) (uint64, error) {
+	if c.BlockTime == 0 {
+		return 0, errors.New("block_time is 0")
+	}
+	if timestamp < c.Genesis.L2Time {
+		return 0, fmt.Errorf("timestamp %d before genesis %d", timestamp, c.Genesis.L2Time)
+	}
+	return c.Genesis.L2.Number + (timestamp-c.Genesis.L2Time)/c.BlockTime, nil
+}
+
+// Block - skeleton of an L2 block derived from a batch: the block without deposits
+type Block struct {
+	Timestamp    uint64
+	EpochNum     uint64         // L1 origin
+	EpochHash    libcommon.Hash // singular batches only
+	Transactions []types.Transaction
+}
+
+// Batch - a singular batch is a batch of one block
+type Batch struct {
+	Type          byte
+	ParentHash    libcommon.Hash // singular batches only
+	ParentCheck   [20]byte       // span batches only: prefix of the parent hash of the first block
+	L1OriginCheck [20]byte       // span batches only: prefix of the L1 origin hash of the last block
+	Blocks        []Block
+}
+
+// ReadBatches - batches of channel data as it is assembled from frames
+func ReadBatches(channelData []byte, cfg *RollupConfig) ([]*Batch, error) {
+	if len(channelData) == 0 {
+		return nil, errors.New("empty channel")
+	}
+	var r io.Reader
+	switch {
+	case channelData[0]&0x0F == 8 || channelData[0]&0x0F == 15:
+		zr, err := zlib.NewReader(bytes.NewReader(channelData))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		r = zr
+	case channelData[0] == channelVersionBrotli:
+		r = brotli.NewReader(bytes.NewReader(channelData[1:]))
+	default:
+		return nil, fmt.Errorf("%w: first byte %#x", ErrUnsupportedCompression, channelData[0])
+	}
+
+	s := rlp.NewStream(r, maxRLPBytesPerChannel)
+	var batches []*Batch
+	for {
+		data, err := s.Bytes()
+		if errors.Is(err, io.EOF) {
+			return batches, nil
+		}
+		if err != nil {
+			// a truncated channel still has batches before the truncation
+			return batches, fmt.Errorf("batch %d: %w", len(batches), err)
+		}
+		batch, err := decodeBatch(data, cfg)
+		if err != nil {
+			return batches, fmt.Errorf("batch %d: %w", len(batches), err)
+		}
+		batches = append(batches, batch)
+	}
+}
+
+func decodeBatch(data []byte, cfg *RollupConfig) (*Batch, error) {
+	if len(data) == 0 {
+		return nil, errors.New("empty batch")
+	}
+	switch data[0] {
+	case SingularBatchType:
+		return decodeSingularBatch(data[1:])
+	case SpanBatchType:
+		return decodeSpanBatch(data[1:], cfg)
+	default:
+		return nil, fmt.Errorf("unknown batch type %d", data[0])
+	}
+}
+
+type singularBatch struct {
+	ParentHash   libcommon.Hash
+	EpochNum     uint64
+	EpochHash    libcommon.Hash
+	Timestamp    uint64
+	Transactions [][]byte
+}
+
+func decodeSingularBatch(data []byte) (*Batch, error) {
+	var sb singularBatch
+	if err := rlp.DecodeBytes(data, &sb); err != nil {
+		return nil, fmt.Errorf("singular batch: %w", err)
+	}
+	block := Block{Timestamp: sb.Timestamp, EpochNum: sb.EpochNum, EpochHash: sb.EpochHash}
+	for i, enc := range sb.Transactions {
+		txn, err := types.DecodeTransaction(enc)
+		if err != nil {
+			return nil, fmt.Errorf("singular batch: tx %d: %w", i, err)
+		}
+		block.Transactions = append(block.Transactions, txn)
+	}
+	return &Batch{Type: SingularBatchType, ParentHash: sb.ParentHash, Blocks: []Block{block}}, nil
+}
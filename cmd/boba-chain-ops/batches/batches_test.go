@@ -0,0 +1,236 @@
+package batches
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"math/big"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/rlp"
+)
+
+func encodeFrame(id ChannelID, num uint16, data []byte, last bool) []byte {
+	buf := append([]byte{}, id[:]...)
+	buf = binary.BigEndian.AppendUint16(buf, num)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(data)))
+	buf = append(buf, data...)
+	if last {
+		return append(buf, 1)
+	}
+	return append(buf, 0)
+}
+
+func compressBatches(t *testing.T, batches ...[]byte) []byte {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	for _, b := range batches {
+		require.NoError(t, rlp.Encode(w, b))
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+// brotliBatches - channel data of the batches compressed with brotli, as since Fjord
+func brotliBatches(t *testing.T, batches ...[]byte) []byte {
+	buf := bytes.NewBuffer([]byte{channelVersionBrotli})
+	w := brotli.NewWriter(buf)
+	for _, b := range batches {
+		require.NoError(t, rlp.Encode(w, b))
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestChannelBank(t *testing.T) {
+	t.Parallel()
+
+	id := ChannelID{1, 2, 3}
+	payload := []byte("channel data of three frames")
+	first := append([]byte{DerivationVersion0}, encodeFrame(id, 0, payload[:10], false)...)
+	rest := append([]byte{DerivationVersion0}, encodeFrame(id, 2, payload[20:], true)...)
+	rest = append(rest, encodeFrame(id, 1, payload[10:20], false)...)
+
+	bank := NewChannelBank()
+	frames, err := ParseFrames(rest)
+	require.NoError(t, err)
+	require.Len(t, frames, 2)
+	require.Empty(t, bank.Add(frames))
+	require.Empty(t, bank.Add(frames)) // duplicates are ignored
+	require.Equal(t, []ChannelID{id}, bank.Incomplete())
+
+	frames, err = ParseFrames(first)
+	require.NoError(t, err)
+	ready := bank.Add(frames)
+	require.Equal(t, []Channel{{ID: id, Data: payload}}, ready)
+	require.Empty(t, bank.Incomplete())
+
+	// a closing frame prunes the frames above it, a second one is ignored
+	other := ChannelID{4, 5, 6}
+	require.Empty(t, bank.Add([]Frame{
+		{ID: other, FrameNumber: 2, Data: []byte("pruned")},
+		{ID: other, FrameNumber: 1, Data: []byte("b"), IsLast: true},
+		{ID: other, FrameNumber: 0, Data: []byte("ignored"), IsLast: true},
+	}))
+	ready = bank.Add([]Frame{{ID: other, FrameNumber: 0, Data: []byte("a")}})
+	require.Equal(t, []Channel{{ID: other, Data: []byte("ab")}}, ready)
+
+	_, err = ParseFrames([]byte{1})
+	require.ErrorIs(t, err, ErrInvalidFrame)
+	_, err = ParseFrames(first[:len(first)-1])
+	require.ErrorIs(t, err, ErrInvalidFrame)
+}
+
+func testRollupConfig() *RollupConfig {
+	cfg := &RollupConfig{BlockTime: 2, L2ChainID: big.NewInt(288)}
+	cfg.Genesis.L2.Number = 1000
+	cfg.Genesis.L2Time = 1_700_000_000
+	return cfg
+}
+
+func TestSingularBatch(t *testing.T) {
+	t.Parallel()
+
+	cfg := testRollupConfig()
+	to := libcommon.HexToAddress("0x4200000000000000000000000000000000000016")
+	txn := &types.LegacyTx{CommonTx: types.CommonTx{Nonce: 7, Gas: 21000, To: &to, Value: uint256.NewInt(1)}, GasPrice: uint256.NewInt(5)}
+	txn.V.SetUint64(27)
+	var enc bytes.Buffer
+	require.NoError(t, txn.MarshalBinary(&enc))
+
+	sb := singularBatch{
+		ParentHash:   libcommon.HexToHash("0x01"),
+		EpochNum:     17,
+		EpochHash:    libcommon.HexToHash("0x02"),
+		Timestamp:    cfg.Genesis.L2Time + 10,
+		Transactions: [][]byte{enc.Bytes()},
+	}
+	data, err := rlp.EncodeToBytes(&sb)
+	require.NoError(t, err)
+
+	bs, err := ReadBatches(compressBatches(t, append([]byte{SingularBatchType}, data...)), cfg)
+	require.NoError(t, err)
+	require.Len(t, bs, 1)
+	require.Equal(t, sb.ParentHash, bs[0].ParentHash)
+	require.Len(t, bs[0].Blocks, 1)
+	block := bs[0].Blocks[0]
+	require.Equal(t, sb.Timestamp, block.Timestamp)
+	require.Equal(t, sb.EpochNum, block.EpochNum)
+	require.Equal(t, sb.EpochHash, block.EpochHash)
+	require.Len(t, block.Transactions, 1)
+	require.Equal(t, txn.Hash(), block.Transactions[0].Hash())
+
+	num, err := cfg.BlockNumber(block.Timestamp)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1005), num)
+
+	bs, err = ReadBatches(brotliBatches(t, append([]byte{SingularBatchType}, data...)), cfg)
+	require.NoError(t, err)
+	require.Len(t, bs, 1)
+	require.Equal(t, txn.Hash(), bs[0].Blocks[0].Transactions[0].Hash())
+
+	_, err = ReadBatches([]byte{2, 0}, cfg)
+	require.ErrorIs(t, err, ErrUnsupportedCompression)
+}
+
+func TestSpanBatch(t *testing.T) {
+	t.Parallel()
+
+	cfg := testRollupConfig()
+	var buf []byte
+	buf = binary.AppendUvarint(buf, 20) // rel timestamp
+	buf = binary.AppendUvarint(buf, 31) // l1 origin num
+	parentCheck := [20]byte{0xaa}
+	l1OriginCheck := [20]byte{0xbb}
+	buf = append(buf, parentCheck[:]...)
+	buf = append(buf, l1OriginCheck[:]...)
+	buf = binary.AppendUvarint(buf, 3) // block count
+	buf = append(buf, 0b100)           // origin of the 3rd block is the next one
+	buf = binary.AppendUvarint(buf, 0)
+	buf = binary.AppendUvarint(buf, 2)
+	buf = binary.AppendUvarint(buf, 0)
+
+	// a protected legacy tx and a contract creating dynamic fee tx
+	buf = append(buf, 0b10) // contract creation bits
+	buf = append(buf, 0b01) // y parity bits
+	for i := 0; i < 2; i++ {
+		buf = append(buf, bytes.Repeat([]byte{byte(i + 1)}, 64)...)
+	}
+	to := libcommon.HexToAddress("0x1234")
+	buf = append(buf, to[:]...)
+	legacyData, err := rlp.EncodeToBytes([]interface{}{big.NewInt(3), big.NewInt(5), []byte{0xde, 0xad}})
+	require.NoError(t, err)
+	buf = append(buf, legacyData...)
+	dynamicData, err := rlp.EncodeToBytes([]interface{}{big.NewInt(0), big.NewInt(1), big.NewInt(9), []byte{0x60}, []interface{}{}})
+	require.NoError(t, err)
+	buf = append(buf, types.DynamicFeeTxType)
+	buf = append(buf, dynamicData...)
+	buf = binary.AppendUvarint(buf, 7) // nonces
+	buf = binary.AppendUvarint(buf, 8)
+	buf = binary.AppendUvarint(buf, 21000) // gases
+	buf = binary.AppendUvarint(buf, 100000)
+	buf = append(buf, 0b1) // protected bits
+
+	bs, err := ReadBatches(compressBatches(t, append([]byte{SpanBatchType}, buf...)), cfg)
+	require.NoError(t, err)
+	require.Len(t, bs, 1)
+	b := bs[0]
+	require.Equal(t, parentCheck, b.ParentCheck)
+	require.Equal(t, l1OriginCheck, b.L1OriginCheck)
+	require.Len(t, b.Blocks, 3)
+	for i, want := range []struct {
+		epoch uint64
+		txs   int
+	}{{30, 0}, {30, 2}, {31, 0}} {
+		require.Equal(t, cfg.Genesis.L2Time+20+uint64(i)*cfg.BlockTime, b.Blocks[i].Timestamp)
+		require.Equal(t, want.epoch, b.Blocks[i].EpochNum)
+		require.Len(t, b.Blocks[i].Transactions, want.txs)
+	}
+
+	legacy, ok := b.Blocks[1].Transactions[0].(*types.LegacyTx)
+	require.True(t, ok)
+	require.Equal(t, uint64(7), legacy.Nonce)
+	require.Equal(t, to, *legacy.To)
+	require.Equal(t, []byte{0xde, 0xad}, legacy.Data)
+	require.Equal(t, uint64(288*2+35+1), legacy.V.Uint64())
+	require.Equal(t, uint64(5), legacy.GasPrice.Uint64())
+
+	dynamic, ok := b.Blocks[1].Transactions[1].(*types.DynamicFeeTransaction)
+	require.True(t, ok)
+	require.Nil(t, dynamic.To)
+	require.Equal(t, uint64(100000), dynamic.Gas)
+	require.Equal(t, uint64(0), dynamic.V.Uint64())
+	require.Equal(t, uint64(9), dynamic.FeeCap.Uint64())
+	require.Equal(t, uint64(288), dynamic.ChainID.Uint64())
+
+	// truncated batch
+	_, err = ReadBatches(compressBatches(t, append([]byte{SpanBatchType}, buf[:len(buf)-1]...)), cfg)
+	require.Error(t, err)
+}
+
+func TestDecodeBlob(t *testing.T) {
+	t.Parallel()
+
+	blob := make([]byte, blobSize)
+	data := []byte("hello")
+	blob[1] = blobEncodingVersion
+	blob[4] = byte(len(data))
+	copy(blob[5:], data)
+	decoded, err := DecodeBlob(blob)
+	require.NoError(t, err)
+	require.Equal(t, data, decoded)
+
+	blob[5+len(data)] = 1
+	_, err = DecodeBlob(blob)
+	require.ErrorIs(t, err, ErrInvalidBlob)
+
+	_, err = DecodeBlob(blob[:100])
+	require.ErrorIs(t, err, ErrInvalidBlob)
+}
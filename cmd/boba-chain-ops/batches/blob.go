@@ -0,0 +1,86 @@
+package batches
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	blobSize            = 4096 * 32
+	blobEncodingVersion = 0
+	blobRounds          = 1024
+	// MaxBlobDataSize - 4 field elements of 31 bytes and 3 bytes from their high bits per round, less the header
+	MaxBlobDataSize = (4*31+3)*blobRounds - 4
+)
+
+var ErrInvalidBlob = errors.New("invalid blob")
+
+// DecodeBlob - data of a blob in the encoding of OP Stack batchers: 127 bytes in every 4 field elements, the high
+// 6 bits of the first byte of each element are 3 bytes of data. The first element starts with encoding version
+// and 3-byte data length.
+func DecodeBlob(blob []byte) ([]byte, error) {
+	if len(blob) != blobSize {
+		return nil, fmt.Errorf("%w: size %d", ErrInvalidBlob, len(blob))
+	}
+	if blob[1] != blobEncodingVersion {
+		return nil, fmt.Errorf("%w: encoding version %d", ErrInvalidBlob, blob[1])
+	}
+	outputLen := int(blob[2])<<16 | int(blob[3])<<8 | int(blob[4])
+	if outputLen > MaxBlobDataSize {
+		return nil, fmt.Errorf("%w: data length %d", ErrInvalidBlob, outputLen)
+	}
+
+	output := make([]byte, MaxBlobDataSize)
+	// first element of round 0 has 27 bytes of data after version and length
+	copy(output[0:27], blob[5:32])
+	opos, ipos := 28, 32
+	var encodedByte [4]byte
+	encodedByte[0] = blob[0]
+	var err error
+	for i := 1; i < 4; i++ {
+		if encodedByte[i], opos, ipos, err = decodeFieldElement(blob, opos, ipos, output); err != nil {
+			return nil, err
+		}
+	}
+	opos = reassembleBytes(opos, encodedByte, output)
+	for round := 1; round < blobRounds && opos < outputLen; round++ {
+		for i := 0; i < 4; i++ {
+			if encodedByte[i], opos, ipos, err = decodeFieldElement(blob, opos, ipos, output); err != nil {
+				return nil, err
+			}
+		}
+		opos = reassembleBytes(opos, encodedByte, output)
+	}
+	for i := outputLen; i < len(output); i++ {
+		if output[i] != 0 {
+			return nil, fmt.Errorf("%w: data after length in field element %d", ErrInvalidBlob, i/32)
+		}
+	}
+	for ; ipos < blobSize; ipos++ {
+		if blob[ipos] != 0 {
+			return nil, fmt.Errorf("%w: data after length at byte %d", ErrInvalidBlob, ipos)
+		}
+	}
+	return output[:outputLen], nil
+}
+
+func decodeFieldElement(blob []byte, opos, ipos int, output []byte) (byte, int, int, error) {
+	// 2 highest bits must be 0 for the element to be below the field modulus
+	if blob[ipos]&0b1100_0000 != 0 {
+		return 0, 0, 0, fmt.Errorf("%w: field element at byte %d", ErrInvalidBlob, ipos)
+	}
+	copy(output[opos:], blob[ipos+1:ipos+32])
+	return blob[ipos], opos + 32, ipos + 32, nil
+}
+
+// reassembleBytes - 4 6-bit chunks of the first bytes of the elements are 3 bytes between them
+func reassembleBytes(opos int, encodedByte [4]byte, output []byte) int {
+	opos-- // 4 elements are 127 bytes, not 128
+	x := (encodedByte[0] & 0b0011_1111) | ((encodedByte[1] & 0b0011_0000) << 2)
+	y := (encodedByte[1] & 0b0000_1111) | ((encodedByte[3] & 0b0000_1111) << 4)
+	z := (encodedByte[2] & 0b0011_1111) | ((encodedByte[3] & 0b0011_0000) << 2)
+	output[opos-32] = z
+	output[opos-32*2] = y
+	output[opos-32*3] = x
+	return opos
+}
@@ -0,0 +1,130 @@
+// Package batches decodes batcher data of OP Stack chains posted to L1 (calldata of batcher transactions and blobs)
+// into channels, batches and the L2 block skeletons derived from them, as op-node derivation does. Used for
+// forensics: nothing here checks batches against L1 inclusion windows or sequencing rules.
+package batches
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+const (
+	// DerivationVersion0 - version byte of batcher data, followed by frames
+	DerivationVersion0 = 0
+
+	frameOverhead = 16 + 2 + 4 + 1 // channel id, frame number, data length, is last
+	maxFrameLen   = 1_000_000
+)
+
+var ErrInvalidFrame = errors.New("invalid frame")
+
+type ChannelID [16]byte
+
+func (id ChannelID) String() string { return fmt.Sprintf("%x", id[:]) }
+
+type Frame struct {
+	ID          ChannelID
+	FrameNumber uint16
+	Data        []byte
+	IsLast      bool
+}
+
+// ParseFrames - frames of batcher data: calldata of a batcher transaction or data of a blob
+func ParseFrames(data []byte) ([]Frame, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: empty data", ErrInvalidFrame)
+	}
+	if data[0] != DerivationVersion0 {
+		return nil, fmt.Errorf("%w: derivation version %d", ErrInvalidFrame, data[0])
+	}
+	var frames []Frame
+	for buf := data[1:]; len(buf) > 0; {
+		if len(buf) < frameOverhead {
+			return nil, fmt.Errorf("%w: %d bytes left", ErrInvalidFrame, len(buf))
+		}
+		var f Frame
+		copy(f.ID[:], buf[:16])
+		f.FrameNumber = binary.BigEndian.Uint16(buf[16:18])
+		dataLen := binary.BigEndian.Uint32(buf[18:22])
+		if dataLen > maxFrameLen || int(dataLen) > len(buf)-frameOverhead {
+			return nil, fmt.Errorf("%w: data length %d", ErrInvalidFrame, dataLen)
+		}
+		f.Data = buf[22 : 22+dataLen]
+		switch isLast := buf[22+dataLen]; isLast {
+		case 0, 1:
+			f.IsLast = isLast == 1
+		default:
+			return nil, fmt.Errorf("%w: is_last %d", ErrInvalidFrame, isLast)
+		}
+		frames = append(frames, f)
+		buf = buf[frameOverhead+dataLen:]
+	}
+	return frames, nil
+}
+
+// ChannelBank - assembles channels from frames, which may come in any order and over many L1 transactions
+type ChannelBank struct {
+	pending map[ChannelID]*pendingChannel
+}
+
+type pendingChannel struct {
+	frames map[uint16]Frame
+	last   int // number of the last frame, -1 - not seen yet
+}
+
+func NewChannelBank() *ChannelBank {
+	return &ChannelBank{pending: map[ChannelID]*pendingChannel{}}
+}
+
+// Channel - data of all frames of a channel, compressed
+type Channel struct {
+	ID   ChannelID
+	Data []byte
+}
+
+// Add - adds frames, returns channels completed by them. As by op-node, duplicated frames, frames above the closing
+// one and a second closing frame are ignored, and a closing frame prunes the frames above it received before.
+func (b *ChannelBank) Add(frames []Frame) []Channel {
+	var ready []Channel
+	for _, f := range frames {
+		ch, ok := b.pending[f.ID]
+		if !ok {
+			ch = &pendingChannel{frames: map[uint16]Frame{}, last: -1}
+			b.pending[f.ID] = ch
+		}
+		if _, dup := ch.frames[f.FrameNumber]; dup || (ch.last >= 0 && (f.IsLast || int(f.FrameNumber) > ch.last)) {
+			continue
+		}
+		ch.frames[f.FrameNumber] = f
+		if f.IsLast {
+			ch.last = int(f.FrameNumber)
+			for num := range ch.frames {
+				if num > f.FrameNumber {
+					delete(ch.frames, num)
+				}
+			}
+		}
+		if ch.last < 0 || len(ch.frames) != ch.last+1 {
+			continue
+		}
+		var data []byte
+		for i := 0; i <= ch.last; i++ {
+			data = append(data, ch.frames[uint16(i)].Data...)
+		}
+		ready = append(ready, Channel{ID: f.ID, Data: data})
+		delete(b.pending, f.ID)
+	}
+	return ready
+}
+
+// Incomplete - channels still missing frames
+func (b *ChannelBank) Incomplete() []ChannelID {
+	ids := make([]ChannelID, 0, len(b.pending))
+	for id := range b.pending {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].String() < ids[j].String() })
+	return ids
+}
@@ -0,0 +1,331 @@
+package batches
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/holiman/uint256"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	types2 "github.com/erigontech/erigon-lib/types"
+
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/rlp"
+)
+
+// same limits as op-node
+const maxSpanBatchElementCount = 10_000_000
+
+// rawSpanBatch - span batch as encoded: prefix, then blocks and columns of their transactions
+type rawSpanBatch struct {
+	relTimestamp  uint64
+	l1OriginNum   uint64
+	parentCheck   [20]byte
+	l1OriginCheck [20]byte
+
+	blockCount    uint64
+	originBits    *big.Int
+	blockTxCounts []uint64
+
+	txs spanBatchTxs
+}
+
+type spanBatchTxs struct {
+	count                uint64
+	contractCreationBits *big.Int
+	yParityBits          *big.Int
+	sigs                 []spanBatchSig
+	tos                  []libcommon.Address
+	datas                []spanBatchTxData
+	nonces               []uint64
+	gases                []uint64
+	protectedBits        *big.Int // legacy transactions only
+}
+
+type spanBatchSig struct {
+	r, s uint256.Int
+}
+
+// spanBatchTxData - fields of a transaction which are not in columns
+type spanBatchTxData struct {
+	txType     byte
+	value      *big.Int
+	gasPrice   *big.Int // legacy and access list transactions
+	tip        *big.Int // dynamic fee transactions
+	feeCap     *big.Int
+	data       []byte
+	accessList types2.AccessList
+}
+
+func decodeSpanBatch(data []byte, cfg *RollupConfig) (*Batch, error) {
+	var b rawSpanBatch
+	if err := b.decode(bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("span batch: %w", err)
+	}
+	return b.derive(cfg)
+}
+
+func (b *rawSpanBatch) decode(r *bytes.Reader) (err error) {
+	if b.relTimestamp, err = binary.ReadUvarint(r); err != nil {
+		return fmt.Errorf("rel timestamp: %w", err)
+	}
+	if b.l1OriginNum, err = binary.ReadUvarint(r); err != nil {
+		return fmt.Errorf("l1 origin num: %w", err)
+	}
+	if _, err = io.ReadFull(r, b.parentCheck[:]); err != nil {
+		return fmt.Errorf("parent check: %w", err)
+	}
+	if _, err = io.ReadFull(r, b.l1OriginCheck[:]); err != nil {
+		return fmt.Errorf("l1 origin check: %w", err)
+	}
+	if b.blockCount, err = readCount(r); err != nil {
+		return fmt.Errorf("block count: %w", err)
+	}
+	if b.blockCount == 0 {
+		return errors.New("no blocks")
+	}
+	if b.originBits, err = readBits(r, b.blockCount); err != nil {
+		return fmt.Errorf("origin bits: %w", err)
+	}
+	b.blockTxCounts = make([]uint64, b.blockCount)
+	for i := range b.blockTxCounts {
+		if b.blockTxCounts[i], err = readCount(r); err != nil {
+			return fmt.Errorf("block tx counts: %w", err)
+		}
+		b.txs.count += b.blockTxCounts[i]
+		if b.txs.count > maxSpanBatchElementCount {
+			return errors.New("too many transactions")
+		}
+	}
+	if err = b.txs.decode(r); err != nil {
+		return err
+	}
+	if r.Len() != 0 {
+		return fmt.Errorf("%d trailing bytes", r.Len())
+	}
+	return nil
+}
+
+func (t *spanBatchTxs) decode(r *bytes.Reader) (err error) {
+	if t.contractCreationBits, err = readBits(r, t.count); err != nil {
+		return fmt.Errorf("contract creation bits: %w", err)
+	}
+	if t.yParityBits, err = readBits(r, t.count); err != nil {
+		return fmt.Errorf("y parity bits: %w", err)
+	}
+	t.sigs = make([]spanBatchSig, t.count)
+	var word [32]byte
+	for i := range t.sigs {
+		if _, err = io.ReadFull(r, word[:]); err != nil {
+			return fmt.Errorf("tx sig r: %w", err)
+		}
+		t.sigs[i].r.SetBytes32(word[:])
+		if _, err = io.ReadFull(r, word[:]); err != nil {
+			return fmt.Errorf("tx sig s: %w", err)
+		}
+		t.sigs[i].s.SetBytes32(word[:])
+	}
+	var creations uint64
+	for i := 0; i < int(t.count); i++ {
+		creations += uint64(t.contractCreationBits.Bit(i))
+	}
+	t.tos = make([]libcommon.Address, t.count-creations)
+	for i := range t.tos {
+		if _, err = io.ReadFull(r, t.tos[i][:]); err != nil {
+			return fmt.Errorf("tx tos: %w", err)
+		}
+	}
+	t.datas = make([]spanBatchTxData, t.count)
+	var legacyCount uint64
+	for i := range t.datas {
+		if t.datas[i], err = readTxData(r); err != nil {
+			return fmt.Errorf("tx data %d: %w", i, err)
+		}
+		if t.datas[i].txType == types.LegacyTxType {
+			legacyCount++
+		}
+	}
+	t.nonces = make([]uint64, t.count)
+	for i := range t.nonces {
+		if t.nonces[i], err = binary.ReadUvarint(r); err != nil {
+			return fmt.Errorf("tx nonces: %w", err)
+		}
+	}
+	t.gases = make([]uint64, t.count)
+	for i := range t.gases {
+		if t.gases[i], err = binary.ReadUvarint(r); err != nil {
+			return fmt.Errorf("tx gases: %w", err)
+		}
+	}
+	if t.protectedBits, err = readBits(r, legacyCount); err != nil {
+		return fmt.Errorf("protected bits: %w", err)
+	}
+	return nil
+}
+
+// readTxData - type byte of typed transactions, then rlp list of the fields
+func readTxData(r *bytes.Reader) (spanBatchTxData, error) {
+	d := spanBatchTxData{txType: types.LegacyTxType}
+	first, err := r.ReadByte()
+	if err != nil {
+		return d, err
+	}
+	if first <= 0x7F {
+		d.txType = first
+	} else if err = r.UnreadByte(); err != nil {
+		return d, err
+	}
+	s := rlp.NewStream(r, maxSpanBatchElementCount)
+	if kind, _, err := s.Kind(); err != nil {
+		return d, err
+	} else if kind != rlp.List {
+		return d, errors.New("tx data is not a list")
+	}
+	raw, err := s.Raw()
+	if err != nil {
+		return d, err
+	}
+	switch d.txType {
+	case types.LegacyTxType:
+		var f struct {
+			Value    *big.Int
+			GasPrice *big.Int
+			Data     []byte
+		}
+		err = rlp.DecodeBytes(raw, &f)
+		d.value, d.gasPrice, d.data = f.Value, f.GasPrice, f.Data
+	case types.AccessListTxType:
+		var f struct {
+			Value      *big.Int
+			GasPrice   *big.Int
+			Data       []byte
+			AccessList types2.AccessList
+		}
+		err = rlp.DecodeBytes(raw, &f)
+		d.value, d.gasPrice, d.data, d.accessList = f.Value, f.GasPrice, f.Data, f.AccessList
+	case types.DynamicFeeTxType:
+		var f struct {
+			Value      *big.Int
+			GasTipCap  *big.Int
+			GasFeeCap  *big.Int
+			Data       []byte
+			AccessList types2.AccessList
+		}
+		err = rlp.DecodeBytes(raw, &f)
+		d.value, d.tip, d.feeCap, d.data, d.accessList = f.Value, f.GasTipCap, f.GasFeeCap, f.Data, f.AccessList
+	default:
+		return d, fmt.Errorf("tx type %d is not supported in span batches", d.txType)
+	}
+	return d, err
+}
+
+// derive - blocks of the batch, transactions are reassembled from columns
+func (b *rawSpanBatch) derive(cfg *RollupConfig) (*Batch, error) {
+	if cfg.L2ChainID == nil {
+		return nil, errors.New("span batch: l2_chain_id is not set")
+	}
+	chainID := uint256.MustFromBig(cfg.L2ChainID)
+	txs, err := b.txs.fullTxs(chainID)
+	if err != nil {
+		return nil, fmt.Errorf("span batch: %w", err)
+	}
+
+	// origin bit of a block is 1 if its L1 origin is the next one of the previous block
+	origins := make([]uint64, b.blockCount)
+	origin := b.l1OriginNum
+	for i := int(b.blockCount) - 1; i >= 0; i-- {
+		origins[i] = origin
+		if b.originBits.Bit(i) == 1 && i > 0 {
+			origin--
+		}
+	}
+
+	batch := &Batch{Type: SpanBatchType, ParentCheck: b.parentCheck, L1OriginCheck: b.l1OriginCheck, Blocks: make([]Block, b.blockCount)}
+	for i := range batch.Blocks {
+		batch.Blocks[i] = Block{
+			Timestamp:    cfg.Genesis.L2Time + b.relTimestamp + cfg.BlockTime*uint64(i),
+			EpochNum:     origins[i],
+			Transactions: txs[:b.blockTxCounts[i]],
+		}
+		txs = txs[b.blockTxCounts[i]:]
+	}
+	return batch, nil
+}
+
+func (t *spanBatchTxs) fullTxs(chainID *uint256.Int) ([]types.Transaction, error) {
+	txs := make([]types.Transaction, t.count)
+	var toIdx, protectedIdx int
+	for i := range txs {
+		d := t.datas[i]
+		common := types.CommonTx{
+			Nonce: t.nonces[i],
+			Gas:   t.gases[i],
+			Value: uint256.MustFromBig(d.value),
+			Data:  d.data,
+			R:     t.sigs[i].r,
+			S:     t.sigs[i].s,
+		}
+		if t.contractCreationBits.Bit(i) == 0 {
+			to := t.tos[toIdx]
+			common.To = &to
+			toIdx++
+		}
+		yParity := uint64(t.yParityBits.Bit(i))
+		switch d.txType {
+		case types.LegacyTxType:
+			if t.protectedBits.Bit(protectedIdx) == 0 {
+				common.V.SetUint64(27 + yParity)
+			} else {
+				// chainID*2 + 35 + yParity
+				common.V.Add(new(uint256.Int).Lsh(chainID, 1), uint256.NewInt(35+yParity))
+			}
+			protectedIdx++
+			txs[i] = &types.LegacyTx{CommonTx: common, GasPrice: uint256.MustFromBig(d.gasPrice)}
+		case types.AccessListTxType:
+			common.V.SetUint64(yParity)
+			txs[i] = &types.AccessListTx{
+				LegacyTx:   types.LegacyTx{CommonTx: common, GasPrice: uint256.MustFromBig(d.gasPrice)},
+				ChainID:    chainID.Clone(),
+				AccessList: d.accessList,
+			}
+		case types.DynamicFeeTxType:
+			common.V.SetUint64(yParity)
+			txs[i] = &types.DynamicFeeTransaction{
+				CommonTx:   common,
+				ChainID:    chainID.Clone(),
+				Tip:        uint256.MustFromBig(d.tip),
+				FeeCap:     uint256.MustFromBig(d.feeCap),
+				AccessList: d.accessList,
+			}
+		}
+	}
+	return txs, nil
+}
+
+func readCount(r *bytes.Reader) (uint64, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return 0, err
+	}
+	if n > maxSpanBatchElementCount {
+		return 0, fmt.Errorf("count %d is too large", n)
+	}
+	return n, nil
+}
+
+// readBits - bitlist of n bits, big-endian: bit i of the list is bit i of the number
+func readBits(r *bytes.Reader, n uint64) (*big.Int, error) {
+	buf := make([]byte, (n+7)/8)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+	bits := new(big.Int).SetBytes(buf)
+	if uint64(bits.BitLen()) > n {
+		return nil, fmt.Errorf("bitlist is longer than %d bits", n)
+	}
+	return bits, nil
+}
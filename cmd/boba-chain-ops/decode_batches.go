@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/common/hexutility"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/cmd/boba-chain-ops/batches"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/rpc"
+)
+
+var (
+	rollupConfigFlag = cli.StringFlag{
+		Name:     "rollup.config",
+		Usage:    "rollup.json of the chain, as used by op-node",
+		Required: true,
+	}
+	calldataFlag = cli.StringSliceFlag{
+		Name:  "calldata",
+		Usage: "Files with input of batcher transactions, one hex string per line",
+	}
+	blobsFlag = cli.StringSliceFlag{
+		Name:  "blobs",
+		Usage: "Files with blobs of batcher transactions, one hex string per line",
+	}
+	l2RpcFlag = cli.StringFlag{
+		Name:  "l2.rpc",
+		Usage: "JSON-RPC endpoint of the local L2 node to validate derived blocks against its canonical chain",
+	}
+)
+
+var decodeBatchesCommand = cli.Command{
+	Name:  "decode-batches",
+	Usage: "Reassembles channels of L1 batcher data and prints the L2 blocks derived from their batches",
+	Description: `Frames of all inputs go to one channel bank, so the files may hold data of many batcher transactions in any order.
+Only deposits are missing from derived blocks, which is why they are compared with non-deposit transactions of the canonical ones.`,
+	Flags: []cli.Flag{
+		&rollupConfigFlag,
+		&calldataFlag,
+		&blobsFlag,
+		&l2RpcFlag,
+	},
+	Action: decodeBatches,
+}
+
+func decodeBatches(cliCtx *cli.Context) error {
+	cfg, err := readRollupConfig(cliCtx.String(rollupConfigFlag.Name))
+	if err != nil {
+		return err
+	}
+	var l2 *rpc.Client
+	if url := cliCtx.String(l2RpcFlag.Name); url != "" {
+		if l2, err = rpc.Dial(url, log.Root()); err != nil {
+			return fmt.Errorf("dial %s: %w", url, err)
+		}
+		defer l2.Close()
+	}
+	out := cliCtx.App.Writer
+
+	bank := batches.NewChannelBank()
+	var mismatches int
+	addData := func(source string, data []byte) error {
+		frames, err := batches.ParseFrames(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", source, err)
+		}
+		for _, ch := range bank.Add(frames) {
+			n, err := printChannel(cliCtx.Context, out, ch, cfg, l2)
+			if err != nil {
+				return err
+			}
+			mismatches += n
+		}
+		return nil
+	}
+	for _, file := range cliCtx.StringSlice(calldataFlag.Name) {
+		if err := forEachHexLine(file, addData); err != nil {
+			return err
+		}
+	}
+	for _, file := range cliCtx.StringSlice(blobsFlag.Name) {
+		err := forEachHexLine(file, func(source string, blob []byte) error {
+			data, err := batches.DecodeBlob(blob)
+			if err != nil {
+				return fmt.Errorf("%s: %w", source, err)
+			}
+			return addData(source, data)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, id := range bank.Incomplete() {
+		fmt.Fprintf(out, "incomplete channel %s\n", id)
+	}
+	if mismatches > 0 {
+		return fmt.Errorf("%d derived blocks do not match the canonical chain", mismatches)
+	}
+	return nil
+}
+
+func readRollupConfig(path string) (*batches.RollupConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg batches.RollupConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// forEachHexLine - calls f with the decoded hex string of every non-empty line of the file
+func forEachHexLine(path string, f func(source string, data []byte) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	r := bufio.NewReader(file)
+	for lineNum := 1; ; lineNum++ {
+		line, err := r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			data, decodeErr := hexutil.Decode(ensure0x(line))
+			if decodeErr != nil {
+				return fmt.Errorf("%s:%d: %w", path, lineNum, decodeErr)
+			}
+			if err := f(fmt.Sprintf("%s:%d", path, lineNum), data); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+func ensure0x(s string) string {
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		return s
+	}
+	return "0x" + s
+}
+
+// printChannel - prints blocks of all batches of the channel, returns the number of blocks not matching the L2 chain
+func printChannel(ctx context.Context, out io.Writer, ch batches.Channel, cfg *batches.RollupConfig, l2 *rpc.Client) (int, error) {
+	fmt.Fprintf(out, "channel %s: %d bytes\n", ch.ID, len(ch.Data))
+	bs, readErr := batches.ReadBatches(ch.Data, cfg)
+	var mismatches int
+	for i, b := range bs {
+		fmt.Fprintf(out, "  batch %d: type %d, %d blocks\n", i, b.Type, len(b.Blocks))
+		for j, block := range b.Blocks {
+			num, err := cfg.BlockNumber(block.Timestamp)
+			if err != nil {
+				return mismatches, err
+			}
+			fmt.Fprintf(out, "    block %d: timestamp %d, epoch %d, %d txs\n", num, block.Timestamp, block.EpochNum, len(block.Transactions))
+			for _, txn := range block.Transactions {
+				fmt.Fprintf(out, "      %x\n", txn.Hash())
+			}
+			if l2 == nil {
+				continue
+			}
+			problems, err := validateBlock(ctx, l2, num, b, j)
+			if err != nil {
+				return mismatches, err
+			}
+			for _, p := range problems {
+				fmt.Fprintf(out, "      MISMATCH: %s\n", p)
+			}
+			if len(problems) > 0 {
+				mismatches++
+			}
+		}
+	}
+	if readErr != nil {
+		// batches are still printed up to the broken one
+		fmt.Fprintf(out, "  ERROR: %v\n", readErr)
+	}
+	return mismatches, nil
+}
+
+// rpcBlock - fields of eth_getBlockByNumber with full transactions needed to compare with derived blocks
+type rpcBlock struct {
+	Hash         libcommon.Hash `json:"hash"`
+	ParentHash   libcommon.Hash `json:"parentHash"`
+	Timestamp    hexutil.Uint64 `json:"timestamp"`
+	Transactions []struct {
+		Hash  libcommon.Hash   `json:"hash"`
+		Type  hexutil.Uint64   `json:"type"`
+		Input hexutility.Bytes `json:"input"`
+	} `json:"transactions"`
+}
+
+// validateBlock - differences of the idx-th block of the batch and the canonical block num
+func validateBlock(ctx context.Context, l2 *rpc.Client, num uint64, b *batches.Batch, idx int) ([]string, error) {
+	var canonical *rpcBlock
+	if err := l2.CallContext(ctx, &canonical, "eth_getBlockByNumber", hexutil.Uint64(num), true); err != nil {
+		return nil, fmt.Errorf("block %d: %w", num, err)
+	}
+	if canonical == nil {
+		return []string{"not in the canonical chain"}, nil
+	}
+	block := b.Blocks[idx]
+	var problems []string
+	if uint64(canonical.Timestamp) != block.Timestamp {
+		problems = append(problems, fmt.Sprintf("timestamp %d, canonical %d", block.Timestamp, canonical.Timestamp))
+	}
+	switch {
+	case b.Type == batches.SingularBatchType && canonical.ParentHash != b.ParentHash:
+		problems = append(problems, fmt.Sprintf("parent %x, canonical %x", b.ParentHash, canonical.ParentHash))
+	case b.Type == batches.SpanBatchType && idx == 0 && !bytes.Equal(canonical.ParentHash[:20], b.ParentCheck[:]):
+		problems = append(problems, fmt.Sprintf("parent check %x, canonical parent %x", b.ParentCheck, canonical.ParentHash))
+	}
+
+	if len(canonical.Transactions) == 0 || canonical.Transactions[0].Type != types.DepositTxType {
+		problems = append(problems, types.ErrNoL1InfoDeposit.Error())
+	} else if info, err := types.ParseL1BlockInfo(canonical.Transactions[0].Input); err != nil {
+		problems = append(problems, err.Error())
+	} else {
+		if info.Number != block.EpochNum {
+			problems = append(problems, fmt.Sprintf("epoch %d, canonical %d", block.EpochNum, info.Number))
+		}
+		switch {
+		case b.Type == batches.SingularBatchType && info.BlockHash != block.EpochHash:
+			problems = append(problems, fmt.Sprintf("epoch hash %x, canonical %x", block.EpochHash, info.BlockHash))
+		case b.Type == batches.SpanBatchType && idx == len(b.Blocks)-1 && !bytes.Equal(info.BlockHash[:20], b.L1OriginCheck[:]):
+			problems = append(problems, fmt.Sprintf("L1 origin check %x, canonical epoch hash %x", b.L1OriginCheck, info.BlockHash))
+		}
+	}
+
+	var canonicalTxs []libcommon.Hash
+	for _, txn := range canonical.Transactions {
+		if txn.Type != types.DepositTxType {
+			canonicalTxs = append(canonicalTxs, txn.Hash)
+		}
+	}
+	if len(canonicalTxs) != len(block.Transactions) {
+		problems = append(problems, fmt.Sprintf("%d txs, canonical %d", len(block.Transactions), len(canonicalTxs)))
+	} else {
+		for i, txn := range block.Transactions {
+			if txn.Hash() != canonicalTxs[i] {
+				problems = append(problems, fmt.Sprintf("tx %d %x, canonical %x", i, txn.Hash(), canonicalTxs[i]))
+			}
+		}
+	}
+	return problems, nil
+}
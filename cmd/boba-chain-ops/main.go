@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/erigontech/erigon/params"
+)
+
+func main() {
+	app := cli.NewApp()
+	app.Name = "boba-chain-ops"
+	app.Version = params.VersionWithCommit(params.GitCommit)
+	app.Usage = "Operational and forensic tools for Boba chains"
+	app.Commands = []*cli.Command{
+		&decodeBatchesCommand,
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
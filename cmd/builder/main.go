@@ -0,0 +1,140 @@
+// Builder - block building (engine_forkchoiceUpdated with payload attributes, engine_getPayload) of an Erigon node in
+// a dedicated process, so heavy payload building of a sequencer can't starve RPC and sync of the node. It reads state
+// and headers by remote kv and pending transactions from the txpool of the node, same as rpcdaemon, and serves the
+// execution gRPC service the node is pointed to by --builder.remote.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/erigontech/erigon-lib/chain"
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/datadir"
+	"github.com/erigontech/erigon-lib/gointerfaces/execution"
+	"github.com/erigontech/erigon-lib/gointerfaces/grpcutil"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/kvcfg"
+
+	"github.com/erigontech/erigon/cmd/rpcdaemon/cli"
+	"github.com/erigontech/erigon/consensus"
+	"github.com/erigontech/erigon/core/rawdb"
+	"github.com/erigontech/erigon/eth/ethconfig"
+	"github.com/erigontech/erigon/eth/stagedsync"
+	"github.com/erigontech/erigon/turbo/builder"
+	"github.com/erigontech/erigon/turbo/debug"
+	"github.com/erigontech/erigon/turbo/execution/eth1"
+	stages2 "github.com/erigontech/erigon/turbo/stages"
+
+	_ "github.com/erigontech/erigon/core/snaptype"        //hack
+	_ "github.com/erigontech/erigon/polygon/bor/snaptype" //hack
+)
+
+func main() {
+	cmd, cfg := cli.RootCommand()
+	cmd.Use = "builder"
+	cmd.Short = "Builds payloads for an Erigon node started with --builder.remote"
+	var builderAddr string
+	cmd.Flags().StringVar(&builderAddr, "builder.addr", "127.0.0.1:9095", "Address the execution gRPC service of the builder listens on, the node connects to it by --builder.remote")
+	// same flags as of the node, payloads must not depend on which process builds them
+	miningConfig := ethconfig.Defaults.Miner
+	miningConfig.EnabledPOS = true
+	var extraData string
+	cmd.Flags().Uint64Var(&miningConfig.GasLimit, "miner.gaslimit", ethconfig.Defaults.Miner.GasLimit, "Target gas limit for mined blocks")
+	cmd.Flags().StringVar(&extraData, "miner.extradata", "", "Block extra data set by the miner (default = client version)")
+	cmd.Flags().DurationVar(&miningConfig.BuilderDeadline, "builder.deadline", 0, "Stop packing transactions into a payload after given time since engine_forkchoiceUpdated, the payload gets the best transactions by then. 0 - on Optimism chains until the payload timestamp (block time)")
+
+	rootCtx, rootCancel := common.RootContext()
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		logger := debug.SetupCobra(cmd, "builder")
+		db, _, txPool, _, _, blockReader, engineReader, _, agg, err := cli.RemoteServices(ctx, cfg, logger, rootCancel)
+		if err != nil {
+			if !errors.Is(err, context.Canceled) {
+				logger.Error("Could not connect to DB", "err", err)
+			}
+			return nil
+		}
+		defer db.Close()
+		defer engineReader.Close()
+
+		engine, ok := engineReader.(consensus.Engine)
+		if !ok {
+			return fmt.Errorf("consensus engine %T can't build blocks", engineReader)
+		}
+		// block building never commits: it runs in a memory batch on top of a read tx
+		rwDB, ok := db.(kv.RwDB)
+		if !ok {
+			return fmt.Errorf("db %T is not supported", db)
+		}
+		var chainConfig *chain.Config
+		var historyV3 bool
+		if err = db.View(ctx, func(tx kv.Tx) error {
+			genesisHash, err := rawdb.ReadCanonicalHash(tx, 0)
+			if err != nil {
+				return err
+			}
+			if chainConfig, err = rawdb.ReadChainConfig(tx, genesisHash); err != nil {
+				return err
+			}
+			historyV3, err = kvcfg.HistoryV3.Enabled(tx)
+			return err
+		}); err != nil {
+			return err
+		}
+		if chainConfig == nil {
+			return errors.New("chain config not found in db")
+		}
+
+		dirs := cfg.Dirs
+		if !cfg.WithDatadir {
+			dirs = datadir.New(filepath.Join(os.TempDir(), "erigon-builder"))
+		}
+		if extraData != "" {
+			miningConfig.ExtraData = []byte(extraData)
+		}
+		build := stages2.NewBlockBuilderPOS(ctx, stages2.BlockBuilderPOSCfg{
+			DB:          rwDB,
+			ChainConfig: chainConfig,
+			Engine:      engine,
+			BlockReader: blockReader,
+			ChainReader: stagedsync.NewSharedChainReader(chainConfig, blockReader, logger),
+			Miner:       miningConfig,
+			// a mining cycle reads nothing of the sync config but the skipped stages, and mining stages are never skipped
+			Sync:             ethconfig.Defaults.Sync,
+			Dirs:             dirs,
+			HistoryV3:        historyV3,
+			Agg:              agg,
+			TxPool:           builder.NewRemoteTxPool(ctx, txPool),
+			TxPoolDB:         rwDB,
+			LatestBlockBuilt: builder.NewLatestBlockBuiltStore(),
+		}, logger)
+
+		lis, err := net.Listen("tcp", builderAddr)
+		if err != nil {
+			return fmt.Errorf("could not create builder listener: %w, addr=%s", err, builderAddr)
+		}
+		grpcServer := grpcutil.NewServer(0, nil)
+		execution.RegisterExecutionServer(grpcServer, eth1.NewBlockBuilderServer(chainConfig, build, logger))
+		go func() {
+			<-ctx.Done()
+			grpcServer.GracefulStop()
+		}()
+		logger.Info("Builder started", "addr", builderAddr, "chain", chainConfig.ChainName)
+		if err := grpcServer.Serve(lis); err != nil {
+			logger.Error("Builder stopped", "err", err)
+		}
+		return nil
+	}
+
+	if err := cmd.ExecuteContext(rootCtx); err != nil {
+		fmt.Printf("ExecuteContext: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -197,6 +197,30 @@ func (n *BlockConsumer) Account() *accounts.Account {
 	return nil
 }
 
+// L2Node is erigon in OP-stack mode. It doesn't produce blocks on its own, they are built over the Engine API
+// by the op driver of the devnet, which also feeds them to the other L2 nodes.
+type L2Node struct {
+	NodeArgs
+	HttpApi     string `arg:"--http.api" default:"admin,eth,debug,net,trace,web3,erigon,txpool" json:"http.api"`
+	JWTSecret   string `arg:"--authrpc.jwtsecret" json:"authrpc.jwtsecret"`
+	TorrentPort string `arg:"--torrent.port" json:"torrent.port"`
+	NoDiscover  string `arg:"--nodiscover" flag:"" default:"true" json:"nodiscover"`
+	// L2ChainID overrides the chain id of --chain, the L2 genesis is supplied by the network
+	L2ChainID *big.Int `arg:"-" json:"-"`
+}
+
+func (n *L2Node) ChainID() *big.Int {
+	return n.L2ChainID
+}
+
+func (n *L2Node) IsBlockProducer() bool {
+	return false
+}
+
+func (n *L2Node) Account() *accounts.Account {
+	return nil
+}
+
 func portFromBase(baseAddr string, increment int, portCount int) (string, int, error) {
 	apiHost, apiPort, err := net.SplitHostPort(baseAddr)
 
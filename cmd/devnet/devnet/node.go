@@ -58,6 +58,21 @@ func HTTPHost(n Node) string {
 	return ""
 }
 
+// AuthRPCHost returns host:port of the Engine API of the node
+func AuthRPCHost(n Node) string {
+	if n, ok := n.(*devnetNode); ok {
+		host := n.nodeCfg.Http.AuthRpcHTTPListenAddress
+
+		if host == "" {
+			host = "localhost"
+		}
+
+		return fmt.Sprintf("%s:%d", host, n.nodeCfg.Http.AuthRpcPort)
+	}
+
+	return ""
+}
+
 type devnetNode struct {
 	sync.Mutex
 	requests.RequestGenerator
@@ -168,7 +183,14 @@ func (n *devnetNode) run(ctx *cli.Context) error {
 	n.nodeCfg.MdbxGrowthStep = 32 * datasize.MB
 	n.nodeCfg.MdbxDBSizeLimit = 512 * datasize.MB
 
-	if n.network.Genesis != nil {
+	if n.network.Genesis != nil && n.network.Genesis.Config != nil {
+		// networks with a chain config of their own (e.g. the OP-stack L2) replace the genesis of --chain altogether
+		n.ethCfg.Genesis = n.network.Genesis
+		n.ethCfg.NetworkID = n.network.Genesis.Config.ChainID.Uint64()
+		if n.network.Genesis.Config.IsOptimism() {
+			n.ethCfg.TxPool.OptimismFjordTime = n.network.Genesis.Config.FjordTime
+		}
+	} else if n.network.Genesis != nil {
 		for addr, account := range n.network.Genesis.Alloc {
 			n.ethCfg.Genesis.Alloc[addr] = account
 		}
@@ -26,6 +26,7 @@ import (
 	"github.com/erigontech/erigon/cmd/devnet/requests"
 	"github.com/erigontech/erigon/cmd/devnet/scenarios"
 	"github.com/erigontech/erigon/cmd/devnet/services"
+	_ "github.com/erigontech/erigon/cmd/devnet/services/optimism/steps"
 	"github.com/erigontech/erigon/cmd/devnet/services/polygon"
 	"github.com/erigontech/erigon/cmd/utils/flags"
 	"github.com/erigontech/erigon/params"
@@ -44,7 +45,7 @@ var (
 
 	ChainFlag = cli.StringFlag{
 		Name:  "chain",
-		Usage: "The devnet chain to run (dev,bor-devnet)",
+		Usage: "The devnet chain to run (dev,bor-devnet,op-devnet)",
 		Value: networkname.DevChainName,
 	}
 
@@ -335,6 +336,16 @@ func allScenarios(cliCtx *cli.Context, runCtx devnet.Context) scenarios.Scenario
 				//{Text: "BatchProcessTransfers", Args: []any{"child-funder", 1, 10, 2, 2}},
 			},
 		},
+		"op-stack": {
+			Context: runCtx.WithCurrentNetwork(networks.OPDevnetL2ChainID),
+			Steps: []*scenarios.Step{
+				{Text: "CreateAccount", Args: []any{"op-user"}},
+				{Text: "AwaitL2Blocks", Args: []any{uint64(3)}},
+				{Text: "DepositToL2", Args: []any{"op-user", 1.0}},
+				{Text: "CheckNoTxPool", Args: []any{"op-user", uint64(3)}},
+				{Text: "ReorgL2", Args: []any{uint64(4)}},
+			},
+		},
 		"block-production": {
 			Steps: []*scenarios.Step{
 				{Text: "SendTxLoad", Args: []any{recipientAddress, accounts.DevAddress, sendValue, cliCtx.Uint(txCountFlag.Name)}},
@@ -418,6 +429,9 @@ func initDevnet(ctx *cli.Context, logger log.Logger) (devnet.Devnet, error) {
 	case networkname.DevChainName:
 		return networks.NewDevDevnet(dataDir, baseRpcHost, baseRpcPort, producerCount, gasLimit, logger, consoleLogLevel, dirLogLevel), nil
 
+	case networks.OPDevnetChainName:
+		return networks.NewOPDevnet(dataDir, baseRpcHost, baseRpcPort, gasLimit, logger, consoleLogLevel, dirLogLevel), nil
+
 	default:
 		return nil, fmt.Errorf("unknown network: '%s'", chainName)
 	}
@@ -0,0 +1,165 @@
+package networks
+
+import (
+	"math/big"
+	"path/filepath"
+	"strconv"
+
+	"github.com/erigontech/erigon-lib/chain"
+	"github.com/erigontech/erigon-lib/chain/networkname"
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/cmd/devnet/accounts"
+	"github.com/erigontech/erigon/cmd/devnet/args"
+	"github.com/erigontech/erigon/cmd/devnet/devnet"
+	account_services "github.com/erigontech/erigon/cmd/devnet/services/accounts"
+	"github.com/erigontech/erigon/cmd/devnet/services/optimism"
+	"github.com/erigontech/erigon/core/types"
+)
+
+const (
+	OPDevnetChainName = "op-devnet"
+	// opDevnetGenesisTime is fixed, so the L2 chain doesn't depend on when the devnet is started
+	opDevnetGenesisTime = 1_700_000_000
+)
+
+var OPDevnetL2ChainID = big.NewInt(901)
+
+// NewOPDevnet is a dev L1 and an OP-stack L2 of erigon nodes. L2 blocks are built by a stub driver over
+// the Engine API (see optimism.Driver), so there is no op-node, batcher or L1 contracts: deposits are
+// queued to the driver directly, and there are no predeploys on L2.
+func NewOPDevnet(
+	dataDir string,
+	baseRpcHost string,
+	baseRpcPort int,
+	gasLimit uint64,
+	logger log.Logger,
+	consoleLogLevel log.Lvl,
+	dirLogLevel log.Lvl,
+) devnet.Devnet {
+	faucetSource := accounts.NewAccount("faucet-source")
+	l2DataDir := filepath.Join(dataDir, "l2")
+	jwtSecretPath := filepath.Join(l2DataDir, "jwt.hex")
+
+	driver := optimism.NewDriver(optimism.DriverConfig{
+		JWTSecretPath:     jwtSecretPath,
+		GenesisTime:       opDevnetGenesisTime,
+		BlockTime:         2,
+		EpochLength:       6,
+		GasLimit:          gasLimit,
+		SafeDistance:      4,
+		FinalizedDistance: 16,
+		BaseFeeScalar:     1368,
+		BlobBaseFeeScalar: 810949,
+	}, logger)
+
+	l1Network := devnet.Network{
+		DataDir:            dataDir,
+		Chain:              networkname.DevChainName,
+		Logger:             logger,
+		BasePort:           30403,
+		BasePrivateApiAddr: "localhost:10090",
+		BaseRPCHost:        baseRpcHost,
+		BaseRPCPort:        baseRpcPort,
+		Genesis: &types.Genesis{
+			Alloc: types.GenesisAlloc{
+				faucetSource.Address: {Balance: accounts.EtherAmount(200_000)},
+			},
+			GasLimit: gasLimit,
+		},
+		Services: []devnet.Service{
+			driver,
+			account_services.NewFaucet(networkname.DevChainName, faucetSource),
+		},
+		Nodes: []devnet.Node{
+			&args.BlockProducer{
+				NodeArgs: args.NodeArgs{
+					ConsoleVerbosity: strconv.Itoa(int(consoleLogLevel)),
+					DirVerbosity:     strconv.Itoa(int(dirLogLevel)),
+				},
+				// faster than the L2 epochs, so the driver rarely waits for an L1 origin
+				DevPeriod:    2,
+				AccountSlots: 200,
+			},
+		},
+	}
+
+	l2NodeArgs := args.NodeArgs{
+		ConsoleVerbosity: strconv.Itoa(int(consoleLogLevel)),
+		DirVerbosity:     strconv.Itoa(int(dirLogLevel)),
+	}
+	sequencerArgs, followerArgs := l2NodeArgs, l2NodeArgs
+	sequencerArgs.Name = optimism.SequencerName
+	followerArgs.Name = optimism.L2NodePrefix + "-follower-0"
+
+	l2Network := devnet.Network{
+		DataDir:            l2DataDir,
+		Chain:              networkname.DevChainName,
+		Logger:             logger,
+		BasePort:           40303,
+		BasePrivateApiAddr: "localhost:10190",
+		BaseRPCHost:        baseRpcHost,
+		BaseRPCPort:        baseRpcPort + 1000,
+		Genesis: &types.Genesis{
+			Config:     opDevnetChainConfig(OPDevnetL2ChainID),
+			Timestamp:  opDevnetGenesisTime,
+			GasLimit:   gasLimit,
+			Difficulty: common.Big0,
+			Alloc:      types.GenesisAlloc{},
+		},
+		Services: []devnet.Service{driver},
+		Nodes: []devnet.Node{
+			&args.L2Node{
+				NodeArgs:    sequencerArgs,
+				JWTSecret:   jwtSecretPath,
+				TorrentPort: "42071",
+				L2ChainID:   OPDevnetL2ChainID,
+			},
+			&args.L2Node{
+				NodeArgs:    followerArgs,
+				JWTSecret:   jwtSecretPath,
+				TorrentPort: "42072",
+				L2ChainID:   OPDevnetL2ChainID,
+			},
+		},
+	}
+
+	return devnet.Devnet{&l1Network, &l2Network}
+}
+
+// opDevnetChainConfig has all the forks up to Granite active at genesis. Holocene is left out, as it
+// requires EIP-1559 parameters in the payload attributes.
+func opDevnetChainConfig(chainID *big.Int) *chain.Config {
+	return &chain.Config{
+		ChainName:                     OPDevnetChainName,
+		ChainID:                       chainID,
+		HomesteadBlock:                common.Big0,
+		TangerineWhistleBlock:         common.Big0,
+		SpuriousDragonBlock:           common.Big0,
+		ByzantiumBlock:                common.Big0,
+		ConstantinopleBlock:           common.Big0,
+		PetersburgBlock:               common.Big0,
+		IstanbulBlock:                 common.Big0,
+		MuirGlacierBlock:              common.Big0,
+		BerlinBlock:                   common.Big0,
+		LondonBlock:                   common.Big0,
+		ArrowGlacierBlock:             common.Big0,
+		GrayGlacierBlock:              common.Big0,
+		MergeNetsplitBlock:            common.Big0,
+		ShanghaiTime:                  common.Big0,
+		CancunTime:                    common.Big0,
+		BedrockBlock:                  common.Big0,
+		RegolithTime:                  common.Big0,
+		CanyonTime:                    common.Big0,
+		EcotoneTime:                   common.Big0,
+		FjordTime:                     common.Big0,
+		GraniteTime:                   common.Big0,
+		TerminalTotalDifficulty:       common.Big0,
+		TerminalTotalDifficultyPassed: true,
+		Optimism: &chain.OptimismConfig{
+			EIP1559Elasticity:        6,
+			EIP1559Denominator:       50,
+			EIP1559DenominatorCanyon: 250,
+		},
+	}
+}
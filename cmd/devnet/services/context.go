@@ -5,6 +5,7 @@ import (
 
 	"github.com/erigontech/erigon/cmd/devnet/devnet"
 	"github.com/erigontech/erigon/cmd/devnet/services/accounts"
+	"github.com/erigontech/erigon/cmd/devnet/services/optimism"
 	"github.com/erigontech/erigon/cmd/devnet/services/polygon"
 )
 
@@ -49,3 +50,15 @@ func ProofGenerator(ctx context.Context) *polygon.ProofGenerator {
 
 	return nil
 }
+
+func OPDriver(ctx context.Context) *optimism.Driver {
+	if network := devnet.CurrentNetwork(ctx); network != nil {
+		for _, service := range network.Services {
+			if driver, ok := service.(*optimism.Driver); ok {
+				return driver
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,541 @@
+package optimism
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/holiman/uint256"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/common/hexutility"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/cl/phase1/execution_client/rpc_helper"
+	"github.com/erigontech/erigon/cmd/devnet/devnet"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/crypto"
+	"github.com/erigontech/erigon/rpc"
+	"github.com/erigontech/erigon/turbo/engineapi/engine_types"
+)
+
+const (
+	// L2NodePrefix - the names of the L2 nodes driven over the Engine API start with it
+	L2NodePrefix = "op-l2"
+	// SequencerName - the L2 node which builds the blocks, the other L2 nodes only import them
+	SequencerName = "op-l2-sequencer"
+
+	engineGetPayloadV3 = "engine_getPayloadV3"
+)
+
+var errL1OriginNotReady = errors.New("L1 origin is not produced yet")
+
+type DriverConfig struct {
+	// JWTSecretPath - the driver writes the Engine API secret there on start, the L2 nodes are configured to read it
+	JWTSecretPath string
+	// GenesisTime - timestamp of the L2 genesis, the timestamp of block n is GenesisTime + n*BlockTime
+	GenesisTime uint64
+	// BlockTime - seconds between L2 blocks, both in timestamps and in wall-clock
+	BlockTime uint64
+	// EpochLength - the number of L2 blocks with the same L1 origin, L2 block n is derived from L1 block (n-1)/EpochLength
+	EpochLength uint64
+	GasLimit    uint64
+	// SafeDistance, FinalizedDistance - how far the safe and the finalized blocks are behind the head
+	SafeDistance      uint64
+	FinalizedDistance uint64
+
+	BaseFeeScalar     uint32
+	BlobBaseFeeScalar uint32
+}
+
+// Deposit is a user deposit as if it was emitted by the portal on L1
+type Deposit struct {
+	From  libcommon.Address
+	To    *libcommon.Address
+	Mint  *uint256.Int
+	Value *uint256.Int
+	Gas   uint64
+	Data  []byte
+}
+
+type pendingDeposit struct {
+	Deposit
+	included chan libcommon.Hash
+}
+
+// Driver is a stub of op-node for the devnet. Instead of deriving L2 from batches it builds L2 blocks on
+// the sequencer over the Engine API every BlockTime, with the L1 attributes of a deterministic L1 origin and
+// the queued deposits, and then imports them to the other L2 nodes. Timestamps and prevRandao depend only on
+// the block number (and the count of reorgs), so the same actions produce the same L2 chain.
+//
+// The driver is a service of both the L1 and the L2 network: it reads the L1 origins from the L1 node.
+type Driver struct {
+	sync.Mutex
+	config    DriverConfig
+	logger    log.Logger
+	jwtSecret []byte
+
+	ctx        context.Context
+	cancelFunc context.CancelFunc
+	headWaiter *sync.Cond
+
+	l1        devnet.Node
+	sequencer *engineClient
+	followers []*engineClient
+
+	hashes   []libcommon.Hash // canonical L2 block hashes, by number
+	origin   *L1Origin        // origin of the last built block
+	pending  []*pendingDeposit
+	noTxPool bool
+	reorgs   uint64
+}
+
+func NewDriver(config DriverConfig, logger log.Logger) *Driver {
+	d := &Driver{
+		config:    config,
+		logger:    logger,
+		jwtSecret: crypto.Keccak256([]byte("erigon devnet op driver")),
+	}
+	d.headWaiter = sync.NewCond(d)
+	return d
+}
+
+func (d *Driver) Start(ctx context.Context) error {
+	d.Lock()
+	defer d.Unlock()
+
+	if d.cancelFunc != nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(d.config.JWTSecretPath), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(d.config.JWTSecretPath, []byte(hex.EncodeToString(d.jwtSecret)), 0600); err != nil {
+		return fmt.Errorf("can't write JWT secret: %w", err)
+	}
+	d.ctx, d.cancelFunc = context.WithCancel(ctx)
+	return nil
+}
+
+func (d *Driver) Stop() {
+	var cancel context.CancelFunc
+
+	d.Lock()
+	if d.cancelFunc != nil {
+		cancel = d.cancelFunc
+		d.cancelFunc = nil
+	}
+	d.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	d.headWaiter.Broadcast()
+}
+
+func (d *Driver) NodeCreated(ctx context.Context, node devnet.Node) {
+}
+
+func (d *Driver) NodeStarted(ctx context.Context, node devnet.Node) {
+	if !strings.HasPrefix(node.GetName(), L2NodePrefix) {
+		if node.IsBlockProducer() {
+			d.Lock()
+			d.l1 = node
+			d.Unlock()
+		}
+		return
+	}
+
+	engine, err := dialEngine(node, d.jwtSecret, d.logger)
+	if err != nil {
+		d.logger.Error("[op-driver] can't dial the Engine API", "node", node.GetName(), "err", err)
+		return
+	}
+
+	if node.GetName() != SequencerName {
+		d.Lock()
+		d.followers = append(d.followers, engine)
+		d.Unlock()
+		return
+	}
+
+	genesis, err := node.GetBlockByNumber(ctx, rpc.BlockNumber(0), false)
+	if err != nil {
+		d.logger.Error("[op-driver] can't read the L2 genesis", "node", node.GetName(), "err", err)
+		return
+	}
+
+	d.Lock()
+	d.sequencer = engine
+	d.hashes = []libcommon.Hash{genesis.Hash}
+	runCtx := d.ctx
+	d.Unlock()
+
+	if runCtx != nil {
+		go d.run(runCtx)
+	}
+}
+
+func (d *Driver) run(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(d.config.BlockTime) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := d.buildBlock(ctx); err != nil {
+			if errors.Is(err, errL1OriginNotReady) {
+				d.logger.Debug("[op-driver] waiting for L1", "err", err)
+				continue
+			}
+			d.logger.Warn("[op-driver] block building failed", "err", err)
+		}
+	}
+}
+
+// Head is the number of the latest L2 block, 0 until the sequencer is started
+func (d *Driver) Head() uint64 {
+	d.Lock()
+	defer d.Unlock()
+	if len(d.hashes) == 0 {
+		return 0
+	}
+	return uint64(len(d.hashes)) - 1
+}
+
+// BlockHash is the hash of the canonical L2 block, as seen by the driver
+func (d *Driver) BlockHash(number uint64) (libcommon.Hash, bool) {
+	d.Lock()
+	defer d.Unlock()
+	if number >= uint64(len(d.hashes)) {
+		return libcommon.Hash{}, false
+	}
+	return d.hashes[number], true
+}
+
+// SetNoTxPool sets noTxPool of the payload attributes, with it the blocks contain only the deposits
+func (d *Driver) SetNoTxPool(noTxPool bool) {
+	d.Lock()
+	defer d.Unlock()
+	d.noTxPool = noTxPool
+}
+
+// Deposit queues the deposit for the first block of the next epoch and waits until it's included.
+// Returns the hash of the deposit transaction on L2.
+func (d *Driver) Deposit(ctx context.Context, deposit Deposit) (libcommon.Hash, error) {
+	pending := &pendingDeposit{Deposit: deposit, included: make(chan libcommon.Hash, 1)}
+
+	d.Lock()
+	d.pending = append(d.pending, pending)
+	d.Unlock()
+
+	select {
+	case hash := <-pending.included:
+		return hash, nil
+	case <-ctx.Done():
+		return libcommon.Hash{}, ctx.Err()
+	}
+}
+
+// AwaitBlock waits until the sequencer head reaches the block number
+func (d *Driver) AwaitBlock(ctx context.Context, number uint64) error {
+	d.Lock()
+	defer d.Unlock()
+
+	if ctx.Done() != nil {
+		go func() {
+			defer d.headWaiter.Broadcast()
+			<-ctx.Done()
+		}()
+	}
+
+	for uint64(len(d.hashes)) <= number {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		d.headWaiter.Wait()
+	}
+
+	return nil
+}
+
+// Reorg unwinds the head of the L2 nodes by depth blocks with a forkchoice update to an ancestor of the head.
+// The blocks built after it get a different prevRandao, so the unwound blocks are replaced, not rebuilt.
+// Deposits of the unwound blocks are lost.
+func (d *Driver) Reorg(ctx context.Context, depth uint64) error {
+	d.Lock()
+	defer d.Unlock()
+
+	if d.sequencer == nil {
+		return errors.New("sequencer is not started")
+	}
+	head := uint64(len(d.hashes)) - 1
+	if depth == 0 || depth > d.config.FinalizedDistance || depth > head {
+		return fmt.Errorf("can't reorg %d blocks: head %d, finalized distance %d", depth, head, d.config.FinalizedDistance)
+	}
+
+	state := d.forkchoiceState(head - depth)
+	if err := d.sequencer.forkchoiceUpdated(ctx, state); err != nil {
+		return fmt.Errorf("sequencer: %w", err)
+	}
+	for _, follower := range d.followers {
+		if err := follower.forkchoiceUpdated(ctx, state); err != nil {
+			d.logger.Warn("[op-driver] follower didn't unwind", "node", follower.name, "err", err)
+		}
+	}
+
+	d.hashes = d.hashes[:head-depth+1]
+	d.origin = nil
+	d.reorgs++
+	d.logger.Info("[op-driver] reorg", "from", head, "to", head-depth)
+	return nil
+}
+
+func (d *Driver) buildBlock(ctx context.Context) error {
+	d.Lock()
+	defer d.Unlock()
+
+	head := uint64(len(d.hashes)) - 1
+	number := head + 1
+	seqNumber := (number - 1) % d.config.EpochLength
+
+	origin, err := d.l1Origin(ctx, (number-1)/d.config.EpochLength)
+	if err != nil {
+		return err
+	}
+
+	l1Info, err := encodeTx(l1InfoDeposit(origin, seqNumber, d.config.BaseFeeScalar, d.config.BlobBaseFeeScalar, libcommon.Hash{}))
+	if err != nil {
+		return err
+	}
+	txs := []hexutility.Bytes{l1Info}
+
+	var included []*pendingDeposit
+	var depositHashes []libcommon.Hash
+	if seqNumber == 0 {
+		included = d.pending
+		for i, deposit := range included {
+			tx := &types.DepositTx{
+				SourceHash: depositSourceHash(userDepositSourceDomain, origin.Hash, uint64(i)),
+				From:       deposit.From,
+				To:         deposit.To,
+				Mint:       deposit.Mint,
+				Value:      deposit.Value,
+				Gas:        deposit.Gas,
+				Data:       deposit.Data,
+			}
+			encoded, err := encodeTx(tx)
+			if err != nil {
+				return err
+			}
+			txs = append(txs, encoded)
+			depositHashes = append(depositHashes, tx.Hash())
+		}
+	}
+
+	gasLimit := hexutil.Uint64(d.config.GasLimit)
+	attributes := &engine_types.PayloadAttributes{
+		Timestamp:             hexutil.Uint64(d.config.GenesisTime + number*d.config.BlockTime),
+		PrevRandao:            prevRandao(number, d.reorgs),
+		SuggestedFeeRecipient: SequencerFeeVaultAddress,
+		Withdrawals:           []*types.Withdrawal{},
+		ParentBeaconBlockRoot: &origin.ParentBeaconRoot,
+		Transactions:          txs,
+		NoTxPool:              d.noTxPool,
+		GasLimit:              &gasLimit,
+	}
+
+	payloadId, err := d.sequencer.startBuilding(ctx, d.forkchoiceState(head), attributes)
+	if err != nil {
+		return fmt.Errorf("block %d: %w", number, err)
+	}
+	payload, err := d.sequencer.getPayload(ctx, payloadId)
+	if err != nil {
+		return fmt.Errorf("block %d: %w", number, err)
+	}
+	if err := d.sequencer.newPayload(ctx, payload, origin.ParentBeaconRoot); err != nil {
+		return fmt.Errorf("block %d: %w", number, err)
+	}
+
+	d.hashes = append(d.hashes, payload.BlockHash)
+	state := d.forkchoiceState(number)
+	if err := d.sequencer.forkchoiceUpdated(ctx, state); err != nil {
+		d.hashes = d.hashes[:number]
+		return fmt.Errorf("block %d: %w", number, err)
+	}
+
+	for _, follower := range d.followers {
+		if err := follower.newPayload(ctx, payload, origin.ParentBeaconRoot); err != nil {
+			d.logger.Warn("[op-driver] follower didn't import the block", "node", follower.name, "block", number, "err", err)
+			continue
+		}
+		if err := follower.forkchoiceUpdated(ctx, state); err != nil {
+			d.logger.Warn("[op-driver] follower didn't update forkchoice", "node", follower.name, "block", number, "err", err)
+		}
+	}
+
+	d.pending = d.pending[len(included):]
+	for i, deposit := range included {
+		deposit.included <- depositHashes[i]
+	}
+	d.origin = origin
+	d.headWaiter.Broadcast()
+
+	d.logger.Debug("[op-driver] built block", "number", number, "hash", payload.BlockHash,
+		"txs", len(payload.Transactions), "deposits", len(included), "l1origin", origin.Number)
+	return nil
+}
+
+// l1Origin reads the L1 block, the caller must wait for it to be produced if it returns errL1OriginNotReady
+func (d *Driver) l1Origin(ctx context.Context, number uint64) (*L1Origin, error) {
+	if d.origin != nil && d.origin.Number == number {
+		return d.origin, nil
+	}
+	if d.l1 == nil {
+		return nil, errL1OriginNotReady
+	}
+	latest, err := d.l1.BlockNumber()
+	if err != nil {
+		return nil, fmt.Errorf("L1 head: %w", err)
+	}
+	if latest < number {
+		return nil, fmt.Errorf("%w: %d, L1 head %d", errL1OriginNotReady, number, latest)
+	}
+
+	block, err := d.l1.GetBlockByNumber(ctx, rpc.BlockNumber(number), false)
+	if err != nil {
+		return nil, fmt.Errorf("L1 block %d: %w", number, err)
+	}
+	if block.Header == nil {
+		return nil, fmt.Errorf("L1 block %d not found", number)
+	}
+
+	origin := &L1Origin{
+		Number: number,
+		Time:   block.Time,
+		Hash:   block.Hash,
+		// the dev chain doesn't carry blobs, the blob base fee is the minimal one
+		BaseFee:     big.NewInt(1),
+		BlobBaseFee: big.NewInt(1),
+	}
+	if block.BaseFee != nil {
+		origin.BaseFee = block.BaseFee
+	}
+	if block.ParentBeaconBlockRoot != nil {
+		origin.ParentBeaconRoot = *block.ParentBeaconBlockRoot
+	}
+	return origin, nil
+}
+
+func (d *Driver) forkchoiceState(head uint64) *engine_types.ForkChoiceState {
+	behind := func(distance uint64) libcommon.Hash {
+		if distance > head {
+			return d.hashes[0]
+		}
+		return d.hashes[head-distance]
+	}
+
+	return &engine_types.ForkChoiceState{
+		HeadHash:           d.hashes[head],
+		SafeBlockHash:      behind(d.config.SafeDistance),
+		FinalizedBlockHash: behind(d.config.FinalizedDistance),
+	}
+}
+
+func prevRandao(number, reorgs uint64) libcommon.Hash {
+	var buf [16]byte
+	binary.BigEndian.PutUint64(buf[:8], number)
+	binary.BigEndian.PutUint64(buf[8:], reorgs)
+	return crypto.Keccak256Hash(buf[:])
+}
+
+func encodeTx(tx types.Transaction) (hexutility.Bytes, error) {
+	var buf bytes.Buffer
+	if err := tx.MarshalBinary(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type engineClient struct {
+	name   string
+	client *rpc.Client
+}
+
+func dialEngine(node devnet.Node, jwtSecret []byte, logger log.Logger) (*engineClient, error) {
+	httpClient := &http.Client{Timeout: 30 * time.Second, Transport: rpc_helper.NewJWTRoundTripper(jwtSecret)}
+	client, err := rpc.DialHTTPWithClient("http://"+devnet.AuthRPCHost(node), httpClient, logger)
+	if err != nil {
+		return nil, err
+	}
+	return &engineClient{name: node.GetName(), client: client}, nil
+}
+
+func (e *engineClient) startBuilding(ctx context.Context, state *engine_types.ForkChoiceState, attributes *engine_types.PayloadAttributes) (hexutility.Bytes, error) {
+	var resp engine_types.ForkChoiceUpdatedResponse
+	if err := e.client.CallContext(ctx, &resp, rpc_helper.ForkChoiceUpdatedV3, state, attributes); err != nil {
+		return nil, err
+	}
+	if err := checkPayloadStatus(resp.PayloadStatus); err != nil {
+		return nil, err
+	}
+	if resp.PayloadId == nil {
+		return nil, errors.New("no payload id")
+	}
+	return *resp.PayloadId, nil
+}
+
+func (e *engineClient) forkchoiceUpdated(ctx context.Context, state *engine_types.ForkChoiceState) error {
+	var resp engine_types.ForkChoiceUpdatedResponse
+	if err := e.client.CallContext(ctx, &resp, rpc_helper.ForkChoiceUpdatedV3, state, nil); err != nil {
+		return err
+	}
+	return checkPayloadStatus(resp.PayloadStatus)
+}
+
+func (e *engineClient) getPayload(ctx context.Context, payloadId hexutility.Bytes) (*engine_types.ExecutionPayload, error) {
+	var resp engine_types.GetPayloadResponse
+	if err := e.client.CallContext(ctx, &resp, engineGetPayloadV3, payloadId); err != nil {
+		return nil, err
+	}
+	if resp.ExecutionPayload == nil {
+		return nil, errors.New("empty payload")
+	}
+	return resp.ExecutionPayload, nil
+}
+
+func (e *engineClient) newPayload(ctx context.Context, payload *engine_types.ExecutionPayload, parentBeaconRoot libcommon.Hash) error {
+	var status engine_types.PayloadStatus
+	if err := e.client.CallContext(ctx, &status, rpc_helper.EngineNewPayloadV3, payload, []libcommon.Hash{}, parentBeaconRoot); err != nil {
+		return err
+	}
+	return checkPayloadStatus(&status)
+}
+
+func checkPayloadStatus(status *engine_types.PayloadStatus) error {
+	if status == nil {
+		return errors.New("empty payload status")
+	}
+	if status.Status != engine_types.ValidStatus {
+		if status.ValidationError != nil && status.ValidationError.Error() != nil {
+			return fmt.Errorf("payload status %s: %w", status.Status, status.ValidationError.Error())
+		}
+		return fmt.Errorf("payload status %s", status.Status)
+	}
+	return nil
+}
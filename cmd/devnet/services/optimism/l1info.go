@@ -0,0 +1,77 @@
+package optimism
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"github.com/holiman/uint256"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/opstack"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/crypto"
+)
+
+var (
+	// L1InfoDepositerAddress is the sender of the L1 attributes transaction
+	L1InfoDepositerAddress = libcommon.HexToAddress("0xdeaddeaddeaddeaddeaddeaddeaddeaddead0001")
+	// SequencerFeeVaultAddress receives the L2 execution fees
+	SequencerFeeVaultAddress = libcommon.HexToAddress("0x4200000000000000000000000000000000000011")
+)
+
+const (
+	l1InfoDepositGas = 1_000_000
+
+	userDepositSourceDomain = 0
+	l1InfoSourceDomain      = 1
+)
+
+// L1Origin is the L1 block an L2 block is derived from
+type L1Origin struct {
+	Number      uint64
+	Time        uint64
+	Hash        libcommon.Hash
+	BaseFee     *big.Int
+	BlobBaseFee *big.Int
+	// ParentBeaconRoot is the parent beacon block root of the L1 block, zero if L1 is pre-Cancun
+	ParentBeaconRoot libcommon.Hash
+}
+
+// depositSourceHash is the source hash of the deposit: keccak256(domain ++ keccak256(l1BlockHash ++ index)).
+// For user deposits index is the log index of the deposit, for L1 attributes - the sequence number.
+func depositSourceHash(domain uint64, l1BlockHash libcommon.Hash, index uint64) libcommon.Hash {
+	var domainBytes, indexBytes [32]byte
+	binary.BigEndian.PutUint64(domainBytes[24:], domain)
+	binary.BigEndian.PutUint64(indexBytes[24:], index)
+	depositIdHash := crypto.Keccak256Hash(l1BlockHash[:], indexBytes[:])
+	return crypto.Keccak256Hash(domainBytes[:], depositIdHash[:])
+}
+
+// l1InfoData is the Ecotone calldata of the L1 attributes transaction, see opstack.extractL1GasParamsPostEcotone
+func l1InfoData(origin *L1Origin, seqNumber uint64, baseFeeScalar, blobBaseFeeScalar uint32, batcherHash libcommon.Hash) []byte {
+	data := make([]byte, opstack.EcotoneL1InfoBytes)
+	copy(data[0:4], opstack.EcotoneL1AttributesSelector)
+	binary.BigEndian.PutUint32(data[4:8], baseFeeScalar)
+	binary.BigEndian.PutUint32(data[8:12], blobBaseFeeScalar)
+	binary.BigEndian.PutUint64(data[12:20], seqNumber)
+	binary.BigEndian.PutUint64(data[20:28], origin.Time)
+	binary.BigEndian.PutUint64(data[28:36], origin.Number)
+	origin.BaseFee.FillBytes(data[36:68])
+	origin.BlobBaseFee.FillBytes(data[68:100])
+	copy(data[100:132], origin.Hash[:])
+	copy(data[132:164], batcherHash[:])
+	return data
+}
+
+// l1InfoDeposit is the system deposit which is the first transaction of every L2 block
+func l1InfoDeposit(origin *L1Origin, seqNumber uint64, baseFeeScalar, blobBaseFeeScalar uint32, batcherHash libcommon.Hash) *types.DepositTx {
+	to := opstack.L1BlockAddr
+	return &types.DepositTx{
+		SourceHash: depositSourceHash(l1InfoSourceDomain, origin.Hash, seqNumber),
+		From:       L1InfoDepositerAddress,
+		To:         &to,
+		Value:      uint256.NewInt(0),
+		Gas:        l1InfoDepositGas,
+		Data:       l1InfoData(origin, seqNumber, baseFeeScalar, blobBaseFeeScalar, batcherHash),
+	}
+}
@@ -0,0 +1,40 @@
+package optimism
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/chain"
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/opstack"
+)
+
+func TestL1InfoDeposit(t *testing.T) {
+	origin := &L1Origin{
+		Number:      7,
+		Time:        1_700_000_084,
+		Hash:        libcommon.HexToHash("0x01"),
+		BaseFee:     big.NewInt(875_000_000),
+		BlobBaseFee: big.NewInt(1),
+	}
+	deposit := l1InfoDeposit(origin, 3, 1368, 810949, libcommon.Hash{})
+
+	require.Equal(t, opstack.L1BlockAddr, *deposit.To)
+	require.Equal(t, L1InfoDepositerAddress, deposit.From)
+	require.Len(t, deposit.Data, opstack.EcotoneL1InfoBytes)
+	require.Equal(t, opstack.EcotoneL1AttributesSelector, deposit.Data[:4])
+
+	config := &chain.Config{EcotoneTime: big.NewInt(0)}
+	params, err := opstack.ExtractL1GasParams(config, origin.Time, deposit.Data)
+	require.NoError(t, err)
+	require.Equal(t, uint64(875_000_000), params.L1BaseFee.Uint64())
+	require.Equal(t, uint64(1), params.L1BlobBaseFee.Uint64())
+	require.Equal(t, uint64(1368), params.L1BaseFeeScalar.Uint64())
+	require.Equal(t, uint64(810949), params.L1BlobBaseFeeScalar.Uint64())
+
+	// the source hash is unique per L2 block of the epoch
+	require.NotEqual(t, deposit.SourceHash, l1InfoDeposit(origin, 4, 1368, 810949, libcommon.Hash{}).SourceHash)
+	require.NotEqual(t, deposit.SourceHash, depositSourceHash(userDepositSourceDomain, origin.Hash, 3))
+}
@@ -0,0 +1,282 @@
+package optimism_steps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/holiman/uint256"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon/cmd/devnet/accounts"
+	"github.com/erigontech/erigon/cmd/devnet/devnet"
+	"github.com/erigontech/erigon/cmd/devnet/networks"
+	"github.com/erigontech/erigon/cmd/devnet/scenarios"
+	"github.com/erigontech/erigon/cmd/devnet/services"
+	"github.com/erigontech/erigon/cmd/devnet/services/optimism"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/params"
+	"github.com/erigontech/erigon/rpc"
+)
+
+var errNoDriver = errors.New("no op driver in the devnet")
+
+func init() {
+	scenarios.MustRegisterStepHandlers(
+		scenarios.StepHandler(AwaitL2Blocks),
+		scenarios.StepHandler(DepositToL2),
+		scenarios.StepHandler(CheckNoTxPool),
+		scenarios.StepHandler(ReorgL2),
+	)
+}
+
+func l2Context(ctx context.Context) (devnet.Context, *optimism.Driver, error) {
+	l2Ctx := devnet.WithCurrentNetwork(ctx, networks.OPDevnetL2ChainID)
+	driver := services.OPDriver(l2Ctx)
+
+	if driver == nil {
+		return nil, nil, errNoDriver
+	}
+
+	return l2Ctx, driver, nil
+}
+
+// AwaitL2Blocks waits for count more L2 blocks and checks that all the L2 nodes have them
+func AwaitL2Blocks(ctx context.Context, count uint64) error {
+	l2Ctx, driver, err := l2Context(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	target := driver.Head() + count
+
+	if err := driver.AwaitBlock(l2Ctx, target); err != nil {
+		return err
+	}
+
+	return checkL2NodesAgree(l2Ctx, driver, target)
+}
+
+// DepositToL2 mints ethAmount to the account on L2 with a user deposit and checks the balance change
+func DepositToL2(ctx context.Context, name string, ethAmount float64) (libcommon.Hash, error) {
+	l2Ctx, driver, err := l2Context(ctx)
+
+	if err != nil {
+		return libcommon.Hash{}, err
+	}
+
+	account := accounts.GetAccount(name)
+
+	if account == nil {
+		return libcommon.Hash{}, fmt.Errorf("Unknown account: %s", name)
+	}
+
+	amount, overflow := uint256.FromBig(accounts.EtherAmount(ethAmount))
+
+	if overflow {
+		return libcommon.Hash{}, fmt.Errorf("deposit amount overflow: %f", ethAmount)
+	}
+
+	node := devnet.SelectNode(l2Ctx)
+
+	balanceBefore, err := node.GetBalance(account.Address, rpc.LatestBlock)
+
+	if err != nil {
+		return libcommon.Hash{}, fmt.Errorf("failed to get balance of %s: %w", name, err)
+	}
+
+	hash, err := driver.Deposit(l2Ctx, optimism.Deposit{
+		From:  account.Address,
+		To:    &account.Address,
+		Mint:  amount,
+		Value: uint256.NewInt(0),
+		Gas:   params.TxGas,
+	})
+
+	if err != nil {
+		return libcommon.Hash{}, err
+	}
+
+	receipt, err := node.GetTransactionReceipt(l2Ctx, hash)
+
+	if err != nil {
+		return libcommon.Hash{}, fmt.Errorf("failed to get deposit receipt %s: %w", hash, err)
+	}
+
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return libcommon.Hash{}, fmt.Errorf("deposit %s failed in block %d", hash, receipt.BlockNumber)
+	}
+
+	balanceAfter, err := node.GetBalance(account.Address, rpc.LatestBlock)
+
+	if err != nil {
+		return libcommon.Hash{}, fmt.Errorf("failed to get balance of %s: %w", name, err)
+	}
+
+	if minted := new(big.Int).Sub(balanceAfter, balanceBefore); minted.Cmp(amount.ToBig()) != 0 {
+		return libcommon.Hash{}, fmt.Errorf("unexpected balance change of %s: got %s, want %s", name, minted, amount)
+	}
+
+	devnet.Logger(ctx).Info("Deposit included", "account", name, "hash", hash, "block", receipt.BlockNumber)
+
+	return hash, nil
+}
+
+// CheckNoTxPool sends an L2 transaction from the account while the driver builds blocks with noTxPool set.
+// The blocks must contain only deposits, and the transaction must be included once noTxPool is cleared.
+func CheckNoTxPool(ctx context.Context, name string, blocks uint64) error {
+	l2Ctx, driver, err := l2Context(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	account := accounts.GetAccount(name)
+
+	if account == nil {
+		return fmt.Errorf("Unknown account: %s", name)
+	}
+
+	node := devnet.SelectNode(l2Ctx)
+
+	driver.SetNoTxPool(true)
+	defer driver.SetNoTxPool(false)
+
+	nonce, err := node.GetTransactionCount(account.Address, rpc.PendingBlock)
+
+	if err != nil {
+		return fmt.Errorf("failed to get transaction count for address 0x%x: %w", account.Address, err)
+	}
+
+	latest, err := node.GetBlockByNumber(l2Ctx, rpc.LatestBlockNumber, false)
+
+	if err != nil {
+		return fmt.Errorf("failed to get latest block: %w", err)
+	}
+
+	feeCap, _ := uint256.FromBig(new(big.Int).Mul(latest.BaseFee, big.NewInt(2)))
+	chainId, _ := uint256.FromBig(node.ChainID())
+	transaction := types.NewEIP1559Transaction(*chainId, nonce.Uint64(), account.Address, uint256.NewInt(1), params.TxGas, nil, uint256.NewInt(1), feeCap, nil)
+
+	signedTx, err := types.SignTx(transaction, *types.LatestSignerForChainID(node.ChainID()), account.SigKey())
+
+	if err != nil {
+		return err
+	}
+
+	hash, err := node.SendTransaction(signedTx)
+
+	if err != nil {
+		return fmt.Errorf("failed to send transaction: %w", err)
+	}
+
+	from := driver.Head() + 1
+
+	if err := driver.AwaitBlock(l2Ctx, from+blocks-1); err != nil {
+		return err
+	}
+
+	for number := from; number < from+blocks; number++ {
+		block, err := node.GetBlockByNumber(l2Ctx, rpc.BlockNumber(number), true)
+
+		if err != nil {
+			return fmt.Errorf("failed to get block %d: %w", number, err)
+		}
+
+		for _, tx := range block.Transactions {
+			if uint64(tx.Type) != types.DepositTxType {
+				return fmt.Errorf("block %d has transaction %s of type %d with noTxPool", number, tx.Hash, tx.Type)
+			}
+		}
+	}
+
+	driver.SetNoTxPool(false)
+
+	if err := awaitReceipt(l2Ctx, node, hash); err != nil {
+		return fmt.Errorf("transaction %s not included after noTxPool is cleared: %w", hash, err)
+	}
+
+	return nil
+}
+
+// ReorgL2 unwinds the L2 head by depth blocks, waits for the new blocks and checks they replaced the old ones
+func ReorgL2(ctx context.Context, depth uint64) error {
+	l2Ctx, driver, err := l2Context(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	head := driver.Head()
+	replaced, _ := driver.BlockHash(head)
+
+	if err := driver.Reorg(l2Ctx, depth); err != nil {
+		return err
+	}
+
+	if err := driver.AwaitBlock(l2Ctx, head); err != nil {
+		return err
+	}
+
+	if hash, _ := driver.BlockHash(head); hash == replaced {
+		return fmt.Errorf("block %d was rebuilt with the same hash %s", head, hash)
+	}
+
+	return checkL2NodesAgree(l2Ctx, driver, head)
+}
+
+// checkL2NodesAgree waits for every node of the L2 network to have the block of the driver at the height
+func checkL2NodesAgree(ctx context.Context, driver *optimism.Driver, number uint64) error {
+	expected, ok := driver.BlockHash(number)
+
+	if !ok {
+		return fmt.Errorf("block %d is not built", number)
+	}
+
+	for _, node := range devnet.CurrentNetwork(ctx).Nodes {
+		var actual libcommon.Hash
+
+		for attempt := 0; attempt < 30; attempt++ {
+			if block, err := node.GetBlockByNumber(ctx, rpc.BlockNumber(number), false); err == nil && block.Header != nil {
+				if actual = block.Hash; actual == expected {
+					break
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Second):
+			}
+		}
+
+		if actual != expected {
+			return fmt.Errorf("node %s has block %d %s, expected %s", node.GetName(), number, actual, expected)
+		}
+	}
+
+	return nil
+}
+
+func awaitReceipt(ctx context.Context, node devnet.Node, hash libcommon.Hash) error {
+	for attempt := 0; attempt < 30; attempt++ {
+		if receipt, err := node.GetTransactionReceipt(ctx, hash); err == nil && receipt != nil {
+			if receipt.Status != types.ReceiptStatusSuccessful {
+				return fmt.Errorf("transaction failed in block %d", receipt.BlockNumber)
+			}
+
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+
+	return errors.New("no receipt")
+}
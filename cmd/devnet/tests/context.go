@@ -30,6 +30,9 @@ func initDevnet(chainName string, dataDir string, producerCount int, gasLimit ui
 	case networkname.DevChainName:
 		return networks.NewDevDevnet(dataDir, baseRpcHost, baseRpcPort, producerCount, gasLimit, logger, consoleLogLevel, dirLogLevel), nil
 
+	case networks.OPDevnetChainName:
+		return networks.NewOPDevnet(dataDir, baseRpcHost, baseRpcPort, gasLimit, logger, consoleLogLevel, dirLogLevel), nil
+
 	case "":
 		envChainName, _ := os.LookupEnv("DEVNET_CHAIN")
 		if envChainName == "" {
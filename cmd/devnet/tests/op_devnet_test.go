@@ -0,0 +1,35 @@
+//go:build integration
+
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon/cmd/devnet/accounts"
+	"github.com/erigontech/erigon/cmd/devnet/networks"
+	optimism_steps "github.com/erigontech/erigon/cmd/devnet/services/optimism/steps"
+)
+
+func TestOPDevnet(t *testing.T) {
+	runCtx, err := ContextStart(t, networks.OPDevnetChainName)
+	require.Nil(t, err)
+	ctx := runCtx.WithCurrentNetwork(networks.OPDevnetL2ChainID)
+
+	accounts.NewAccount("op-user")
+
+	t.Run("AwaitL2Blocks", func(t *testing.T) {
+		require.Nil(t, optimism_steps.AwaitL2Blocks(ctx, 3))
+	})
+	t.Run("DepositToL2", func(t *testing.T) {
+		_, err := optimism_steps.DepositToL2(ctx, "op-user", 1)
+		require.Nil(t, err)
+	})
+	t.Run("CheckNoTxPool", func(t *testing.T) {
+		require.Nil(t, optimism_steps.CheckNoTxPool(ctx, "op-user", 3))
+	})
+	t.Run("ReorgL2", func(t *testing.T) {
+		require.Nil(t, optimism_steps.ReorgL2(ctx, 4))
+	})
+}
@@ -50,7 +50,6 @@ import (
 	"github.com/erigontech/erigon/eth/ethconfig"
 	"github.com/erigontech/erigon/eth/stagedsync/stages"
 	"github.com/erigontech/erigon/ethdb"
-	"github.com/erigontech/erigon/ethdb/cbor"
 	"github.com/erigontech/erigon/params"
 	"github.com/erigontech/erigon/rlp"
 	"github.com/erigontech/erigon/turbo/debug"
@@ -1041,6 +1040,9 @@ func scanReceipts2(chaindata string) error {
 	br, _ := blocksIO(dbdb)
 
 	defer tx.Rollback()
+	if err := rawdb.LoadReceiptsCompression(tx); err != nil {
+		return err
+	}
 	blockNum, err := historyv2.AvailableFrom(tx)
 	if err != nil {
 		return err
@@ -1068,7 +1070,7 @@ func scanReceipts2(chaindata string) error {
 			return err
 		}
 		var receipts types.Receipts
-		if err = cbor.Unmarshal(&receipts, bytes.NewReader(v)); err == nil {
+		if receipts, err = types.DecodeReceiptsForStorage(v); err == nil {
 			broken := false
 			for _, receipt := range receipts {
 				if receipt.CumulativeGasUsed < 10000 {
@@ -1204,13 +1206,15 @@ func findLogs(chaindata string, block uint64, blockTotal uint64) error {
 		return txErr
 	}
 	defer tx.Rollback()
+	if err := rawdb.LoadReceiptsCompression(tx); err != nil {
+		return err
+	}
 	logs, err := tx.Cursor(kv.Log)
 	if err != nil {
 		return err
 	}
 	defer logs.Close()
 
-	reader := bytes.NewReader(nil)
 	addrs := map[libcommon.Address]int{}
 	topics := map[string]int{}
 
@@ -1224,9 +1228,8 @@ func findLogs(chaindata string, block uint64, blockTotal uint64) error {
 			break
 		}
 
-		var ll types.Logs
-		reader.Reset(v)
-		if err := cbor.Unmarshal(&ll, reader); err != nil {
+		ll, err := types.DecodeLogsForStorage(v)
+		if err != nil {
 			return fmt.Errorf("receipt unmarshal failed: %w, blocl=%d", err, blockNum)
 		}
 
@@ -0,0 +1,213 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/erigontech/erigon-lib/chain"
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutility"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/kvcfg"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/cmd/hack/tool/fromdb"
+	"github.com/erigontech/erigon/core/rawdb"
+	"github.com/erigontech/erigon/core/state"
+	"github.com/erigontech/erigon/core/systemcontracts"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/eth/stagedsync/stages"
+	"github.com/erigontech/erigon/ethdb/prune"
+	"github.com/erigontech/erigon/turbo/debug"
+	"github.com/erigontech/erigon/turbo/services"
+)
+
+var (
+	repairFromBlock uint64
+	repairToBlock   uint64
+	repairDryRun    bool
+)
+
+// blocks per write transaction of repair_deposit_receipts
+const repairReceiptsBatch = 10_000
+
+var cmdRepairDepositReceipts = &cobra.Command{
+	Use:   "repair_deposit_receipts",
+	Short: "Recompute DepositNonce/DepositReceiptVersion of stored receipts per Regolith/Canyon rules and verify receipts roots",
+	Long: `Scans stored receipts of the block range. Receipts whose DepositNonce or DepositReceiptVersion don't match the
+forks active at their block are recomputed; they are rewritten only if the receipts root of the block then matches
+the header. Blocks whose receipts root doesn't match either way are reported.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logger := debug.SetupCobra(cmd, "integration")
+		ctx, _ := libcommon.RootContext()
+		db, err := openDB(dbCfg(kv.ChainDB, chaindata), true, logger)
+		if err != nil {
+			logger.Error("Opening DB", "error", err)
+			return err
+		}
+		defer db.Close()
+		if err := repairDepositReceipts(ctx, db, logger); err != nil {
+			if !errors.Is(err, context.Canceled) {
+				logger.Error(err.Error())
+			}
+			return err
+		}
+		return nil
+	},
+}
+
+func init() {
+	withDataDir(cmdRepairDepositReceipts)
+	withChain(cmdRepairDepositReceipts)
+	cmdRepairDepositReceipts.Flags().Uint64Var(&repairFromBlock, "from", 0, "first block to check, default - Bedrock block")
+	cmdRepairDepositReceipts.Flags().Uint64Var(&repairToBlock, "to", 0, "last block to check, default - progress of the Execution stage")
+	cmdRepairDepositReceipts.Flags().BoolVar(&repairDryRun, "dry-run", false, "only report blocks which would be repaired")
+	rootCmd.AddCommand(cmdRepairDepositReceipts)
+}
+
+type receiptsRepairStats struct {
+	checked, missing, undecodable, repaired, rootMismatch int
+}
+
+func repairDepositReceipts(ctx context.Context, db kv.RwDB, logger log.Logger) error {
+	if kvcfg.HistoryV3.FromDB(db) {
+		return errors.New("receipts of HistoryV3 datasets are not stored, nothing to repair")
+	}
+	br, _ := blocksIO(db, logger)
+	config := fromdb.ChainConfig(db)
+	if !config.IsOptimism() {
+		return fmt.Errorf("chain %s is not an OP-stack chain", chain)
+	}
+	from, to := repairFromBlock, repairToBlock
+	if from == 0 && config.BedrockBlock != nil {
+		from = config.BedrockBlock.Uint64()
+	}
+	var historyPrunedTo uint64
+	if err := db.View(ctx, func(tx kv.Tx) error {
+		progress, err := stages.GetStageProgress(tx, stages.Execution)
+		if err != nil {
+			return err
+		}
+		if to == 0 {
+			to = progress
+		}
+		pm, err := prune.Get(tx)
+		if err != nil {
+			return err
+		}
+		if pm.History.Enabled() {
+			historyPrunedTo = pm.History.PruneTo(progress)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	// the nonces of the deposit senders are read from the state history at the blocks
+	if from < historyPrunedTo {
+		return fmt.Errorf("state history before block %d is pruned, nonces of the deposits can't be recomputed from block %d, set --from", historyPrunedTo, from)
+	}
+
+	var stats receiptsRepairStats
+	for batchFrom := from; batchFrom <= to; batchFrom += repairReceiptsBatch {
+		batchTo := min(batchFrom+repairReceiptsBatch-1, to)
+		if err := db.Update(ctx, func(tx kv.RwTx) error {
+			for num := batchFrom; num <= batchTo; num++ {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				if err := repairBlockReceipts(ctx, tx, br, config, num, &stats, logger); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		logger.Info("[repair_deposit_receipts] progress", "block", batchTo, "to", to, "checked", stats.checked,
+			"repaired", stats.repaired, "rootMismatch", stats.rootMismatch, "missing", stats.missing, "undecodable", stats.undecodable)
+	}
+	if repairDryRun {
+		logger.Info("[repair_deposit_receipts] dry run, nothing was written")
+	}
+	if stats.rootMismatch > 0 || stats.undecodable > 0 {
+		return fmt.Errorf("receipts root of %d blocks doesn't match their headers, receipts of %d blocks can't be decoded", stats.rootMismatch, stats.undecodable)
+	}
+	return nil
+}
+
+func repairBlockReceipts(ctx context.Context, tx kv.RwTx, br services.FullBlockReader, config *chain.Config, num uint64, stats *receiptsRepairStats, logger log.Logger) error {
+	data, err := tx.GetOne(kv.Receipts, hexutility.EncodeTs(num))
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		stats.missing++
+		return nil
+	}
+	receipts := rawdb.ReadRawReceipts(tx, num)
+	if receipts == nil {
+		// stored, but the receipts or their logs don't decode
+		stats.undecodable++
+		logger.Warn("[repair_deposit_receipts] receipts can't be decoded", "block", num)
+		return nil
+	}
+	hash, err := br.CanonicalHash(ctx, tx, num)
+	if err != nil {
+		return err
+	}
+	block, senders, err := br.BlockWithSenders(ctx, tx, hash, num)
+	if err != nil {
+		return err
+	}
+	if block == nil {
+		return fmt.Errorf("block %d is not found", num)
+	}
+	if len(senders) != block.Transactions().Len() {
+		signer := types.MakeSigner(config, num, block.Time())
+		senders = make([]libcommon.Address, 0, block.Transactions().Len())
+		for i, txn := range block.Transactions() {
+			sender, err := txn.Sender(*signer)
+			if err != nil {
+				return fmt.Errorf("block %d tx %d: %w", num, i, err)
+			}
+			senders = append(senders, sender)
+		}
+	}
+	stats.checked++
+
+	// nonces at the start of the block
+	reader := state.NewPlainState(tx, num, systemcontracts.SystemContractCodeLookup[chain])
+	nonceAt := func(addr libcommon.Address) (uint64, error) {
+		acc, err := reader.ReadAccountData(addr)
+		if err != nil || acc == nil {
+			return 0, err
+		}
+		return acc.Nonce, nil
+	}
+	changed, err := rawdb.RepairDepositReceipts(config, block, senders, receipts, nonceAt)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range receipts {
+		r.Bloom = types.CreateBloom(types.Receipts{r})
+	}
+	if root := types.DeriveSha(receipts); root != block.ReceiptHash() {
+		stats.rootMismatch++
+		logger.Warn("[repair_deposit_receipts] receipts root mismatch", "block", num, "hash", hash,
+			"recomputed", root, "header", block.ReceiptHash(), "repaired", changed)
+		return nil
+	}
+	if !changed {
+		return nil
+	}
+	stats.repaired++
+	logger.Debug("[repair_deposit_receipts] repaired", "block", num, "hash", hash)
+	if repairDryRun {
+		return nil
+	}
+	return rawdb.WriteReceipts(tx, num, receipts)
+}
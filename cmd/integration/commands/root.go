@@ -17,6 +17,7 @@ import (
 	kv2 "github.com/erigontech/erigon-lib/kv/mdbx"
 
 	"github.com/erigontech/erigon/cmd/utils"
+	"github.com/erigontech/erigon/core/rawdb"
 	"github.com/erigontech/erigon/migrations"
 	"github.com/erigontech/erigon/turbo/debug"
 	"github.com/erigontech/erigon/turbo/logging"
@@ -92,6 +93,10 @@ func openDB(opts kv2.MdbxOpts, applyMigrations bool, logger log.Logger) (kv.RwDB
 	}
 
 	if opts.GetLabel() == kv.ChainDB {
+		// receipts and logs compressed by erigon are decoded with the dictionary of the db
+		if err := rawdb.WatchReceiptsCompression(context.Background(), db, logger); err != nil {
+			return nil, err
+		}
 		var h3 bool
 		var err error
 		if err := db.View(context.Background(), func(tx kv.Tx) error {
@@ -11,9 +11,11 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/c2h5oh/datasize"
 	"github.com/erigontech/erigon-lib/config3"
 	"github.com/erigontech/erigon-lib/kv/temporal"
 	"github.com/erigontech/erigon-lib/log/v3"
@@ -57,6 +59,7 @@ import (
 	"github.com/erigontech/erigon/core/rawdb"
 	"github.com/erigontech/erigon/core/state"
 	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/core/vm"
 	"github.com/erigontech/erigon/core/vm/evmtypes"
 	"github.com/erigontech/erigon/eth/ethconfig"
 	"github.com/erigontech/erigon/node"
@@ -83,7 +86,8 @@ var rootCmd = &cobra.Command{
 }
 
 var (
-	stateCacheStr string
+	stateCacheStr        string
+	codeAnalysisCacheStr string
 )
 
 func RootCommand() (*cobra.Command, *httpcfg.HttpCfg) {
@@ -106,6 +110,7 @@ func RootCommand() (*cobra.Command, *httpcfg.HttpCfg) {
 	rootCmd.PersistentFlags().BoolVar(&cfg.Sync.UseSnapshots, "snapshot", true, utils.SnapshotFlag.Usage)
 
 	rootCmd.PersistentFlags().StringVar(&stateCacheStr, "state.cache", "0MB", "Amount of data to store in StateCache (enabled if no --datadir set). Set 0 to disable StateCache. Defaults to 0MB RAM")
+	rootCmd.PersistentFlags().StringVar(&codeAnalysisCacheStr, utils.CodeAnalysisCacheFlag.Name, utils.CodeAnalysisCacheFlag.Value, utils.CodeAnalysisCacheFlag.Usage)
 	rootCmd.PersistentFlags().BoolVar(&cfg.GRPCServerEnabled, "grpc", false, "Enable GRPC server")
 	rootCmd.PersistentFlags().StringVar(&cfg.GRPCListenAddress, "grpc.addr", nodecfg.DefaultGRPCHost, "GRPC server listening interface")
 	rootCmd.PersistentFlags().IntVar(&cfg.GRPCPort, "grpc.port", nodecfg.DefaultGRPCPort, "GRPC server listening port")
@@ -141,7 +146,9 @@ func RootCommand() (*cobra.Command, *httpcfg.HttpCfg) {
 	rootCmd.PersistentFlags().DurationVar(&cfg.HTTPTimeouts.ReadTimeout, "http.timeouts.read", rpccfg.DefaultHTTPTimeouts.ReadTimeout, "Maximum duration for reading the entire request, including the body.")
 	rootCmd.PersistentFlags().DurationVar(&cfg.HTTPTimeouts.WriteTimeout, "http.timeouts.write", rpccfg.DefaultHTTPTimeouts.WriteTimeout, "Maximum duration before timing out writes of the response. It is reset whenever a new request's header is read")
 	rootCmd.PersistentFlags().DurationVar(&cfg.HTTPTimeouts.IdleTimeout, "http.timeouts.idle", rpccfg.DefaultHTTPTimeouts.IdleTimeout, "Maximum amount of time to wait for the next request when keep-alives are enabled. If http.timeouts.idle is zero, the value of http.timeouts.read is used")
-	rootCmd.PersistentFlags().DurationVar(&cfg.EvmCallTimeout, "rpc.evmtimeout", rpccfg.DefaultEvmCallTimeout, "Maximum amount of time to wait for the answer from EVM call.")
+	rootCmd.PersistentFlags().DurationVar(&cfg.EvmCallTimeout, "rpc.evm-timeout", rpccfg.DefaultEvmCallTimeout, "Maximum time the EVM may run a single transaction of eth_call, eth_estimateGas, tracers and trace_*; the execution is interrupted after it (0 - no limit).")
+	rootCmd.PersistentFlags().DurationVar(&cfg.EvmCallTimeout, "rpc.evmtimeout", rpccfg.DefaultEvmCallTimeout, "Alias of --rpc.evm-timeout.")
+	_ = rootCmd.PersistentFlags().MarkDeprecated("rpc.evmtimeout", "use --rpc.evm-timeout")
 	rootCmd.PersistentFlags().DurationVar(&cfg.OverlayGetLogsTimeout, "rpc.overlay.getlogstimeout", rpccfg.DefaultOverlayGetLogsTimeout, "Maximum amount of time to wait for the answer from the overlay_getLogs call.")
 	rootCmd.PersistentFlags().DurationVar(&cfg.OverlayReplayBlockTimeout, "rpc.overlay.replayblocktimeout", rpccfg.DefaultOverlayReplayBlockTimeout, "Maximum amount of time to wait for the answer to replay a single block when called from an overlay_getLogs call.")
 	rootCmd.PersistentFlags().IntVar(&cfg.RpcFiltersConfig.RpcSubscriptionFiltersMaxLogs, "rpc.subscription.filters.maxlogs", rpchelper.DefaultFiltersConfig.RpcSubscriptionFiltersMaxLogs, "Maximum number of logs to store per subscription.")
@@ -149,17 +156,34 @@ func RootCommand() (*cobra.Command, *httpcfg.HttpCfg) {
 	rootCmd.PersistentFlags().IntVar(&cfg.RpcFiltersConfig.RpcSubscriptionFiltersMaxTxs, "rpc.subscription.filters.maxtxs", rpchelper.DefaultFiltersConfig.RpcSubscriptionFiltersMaxTxs, "Maximum number of transactions to store per subscription.")
 	rootCmd.PersistentFlags().IntVar(&cfg.RpcFiltersConfig.RpcSubscriptionFiltersMaxAddresses, "rpc.subscription.filters.maxaddresses", rpchelper.DefaultFiltersConfig.RpcSubscriptionFiltersMaxAddresses, "Maximum number of addresses per subscription to filter logs by.")
 	rootCmd.PersistentFlags().IntVar(&cfg.RpcFiltersConfig.RpcSubscriptionFiltersMaxTopics, "rpc.subscription.filters.maxtopics", rpchelper.DefaultFiltersConfig.RpcSubscriptionFiltersMaxTopics, "Maximum number of topics per subscription to filter logs by.")
+	rootCmd.PersistentFlags().DurationVar(&cfg.RpcFiltersConfig.RpcFiltersTimeout, "rpc.filters.timeout", rpchelper.DefaultFiltersConfig.RpcFiltersTimeout, "Filters of eth_getFilterChanges not polled for this long are uninstalled, 0 - never.")
+	rootCmd.PersistentFlags().IntVar(&cfg.RpcFiltersConfig.RpcFiltersMaxPerClient, "rpc.filters.maxperclient", rpchelper.DefaultFiltersConfig.RpcFiltersMaxPerClient, "Maximum number of filters of eth_getFilterChanges installed by a client (remote address, or X-Forwarded-For behind rpc.filters.trustedproxies).")
+	rootCmd.PersistentFlags().StringSliceVar(&cfg.RpcFiltersConfig.RpcFiltersTrustedProxies, "rpc.filters.trustedproxies", rpchelper.DefaultFiltersConfig.RpcFiltersTrustedProxies, "Comma separated IPs of the proxies whose X-Forwarded-For identifies the client for rpc.filters.maxperclient.")
+	rootCmd.PersistentFlags().StringVar(&cfg.RpcFiltersConfig.RpcFiltersPersistFile, "rpc.filters.persist", rpchelper.DefaultFiltersConfig.RpcFiltersPersistFile, "File the log filters of eth_getFilterChanges are saved to, they are restored from it with the same IDs on restart.")
 	rootCmd.PersistentFlags().IntVar(&cfg.BatchLimit, utils.RpcBatchLimit.Name, utils.RpcBatchLimit.Value, utils.RpcBatchLimit.Usage)
+	rootCmd.PersistentFlags().IntVar(&cfg.BatchResponseLimit, utils.RpcBatchResponseLimit.Name, utils.RpcBatchResponseLimit.Value, utils.RpcBatchResponseLimit.Usage)
+	rootCmd.PersistentFlags().StringVar(&cfg.MethodConcurrency, utils.RpcMethodConcurrency.Name, utils.RpcMethodConcurrency.Value, utils.RpcMethodConcurrency.Usage)
+	rootCmd.PersistentFlags().StringVar(&cfg.MethodGasCap, utils.RpcMethodGasCap.Name, utils.RpcMethodGasCap.Value, utils.RpcMethodGasCap.Usage)
+	rootCmd.PersistentFlags().StringVar(&cfg.MethodResultLimit, utils.RpcMethodResultLimit.Name, utils.RpcMethodResultLimit.Value, utils.RpcMethodResultLimit.Usage)
 	rootCmd.PersistentFlags().IntVar(&cfg.ReturnDataLimit, utils.RpcReturnDataLimit.Name, utils.RpcReturnDataLimit.Value, utils.RpcReturnDataLimit.Usage)
 
 	rootCmd.PersistentFlags().StringVar(&cfg.RollupSequencerHTTP, utils.RollupSequencerHTTPFlag.Name, "", "HTTP endpoint for the sequencer mempool")
+	rootCmd.PersistentFlags().IntVar(&cfg.RollupSequencerRetries, utils.RollupSequencerRetriesFlag.Name, utils.RollupSequencerRetriesFlag.Value, utils.RollupSequencerRetriesFlag.Usage)
+	rootCmd.PersistentFlags().DurationVar(&cfg.RollupSequencerRetryBackoff, utils.RollupSequencerRetryBackoffFlag.Name, utils.RollupSequencerRetryBackoffFlag.Value, utils.RollupSequencerRetryBackoffFlag.Usage)
+	rootCmd.PersistentFlags().BoolVar(&cfg.RollupSequencerLocalTxPool, utils.RollupSequencerLocalTxPoolFlag.Name, false, utils.RollupSequencerLocalTxPoolFlag.Usage)
 	rootCmd.PersistentFlags().StringVar(&cfg.RollupHistoricalRPC, utils.RollupHistoricalRPCFlag.Name, "", "RPC endpoint for historical data")
 	rootCmd.PersistentFlags().DurationVar(&cfg.RollupHistoricalRPCTimeout, utils.RollupHistoricalRPCTimeoutFlag.Name, rpccfg.DefaultHistoricalRPCTimeout, "Timeout for historical RPC requests")
+	rootCmd.PersistentFlags().StringVar(&cfg.RollupArchiveRPC, utils.RollupArchiveRPCFlag.Name, "", utils.RollupArchiveRPCFlag.Usage)
+	rootCmd.PersistentFlags().IntVar(&cfg.RollupArchiveRPCCacheSize, utils.RollupArchiveRPCCacheSizeFlag.Name, utils.RollupArchiveRPCCacheSizeFlag.Value, utils.RollupArchiveRPCCacheSizeFlag.Usage)
 
 	rootCmd.PersistentFlags().BoolVar(&cfg.AllowUnprotectedTxs, utils.AllowUnprotectedTxs.Name, utils.AllowUnprotectedTxs.Value, utils.AllowUnprotectedTxs.Usage)
+	rootCmd.PersistentFlags().BoolVar(&cfg.TxnNotIndexedError, utils.RpcTxnNotIndexedErrorFlag.Name, false, utils.RpcTxnNotIndexedErrorFlag.Usage)
 	rootCmd.PersistentFlags().IntVar(&cfg.MaxGetProofRewindBlockCount, utils.RpcMaxGetProofRewindBlockCount.Name, utils.RpcMaxGetProofRewindBlockCount.Value, utils.RpcMaxGetProofRewindBlockCount.Usage)
 	rootCmd.PersistentFlags().Uint64Var(&cfg.OtsMaxPageSize, utils.OtsSearchMaxCapFlag.Name, utils.OtsSearchMaxCapFlag.Value, utils.OtsSearchMaxCapFlag.Usage)
 	rootCmd.PersistentFlags().DurationVar(&cfg.RPCSlowLogThreshold, utils.RPCSlowFlag.Name, utils.RPCSlowFlag.Value, utils.RPCSlowFlag.Usage)
+	rootCmd.PersistentFlags().StringVar(&cfg.RPCAccessLog, utils.RPCAccessLogFlag.Name, "", utils.RPCAccessLogFlag.Usage)
+	rootCmd.PersistentFlags().Float64Var(&cfg.RPCAccessLogSample, utils.RPCAccessLogSampleFlag.Name, utils.RPCAccessLogSampleFlag.Value, utils.RPCAccessLogSampleFlag.Usage)
+	rootCmd.PersistentFlags().DurationVar(&cfg.RPCAccessLogSlow, utils.RPCAccessLogSlowFlag.Name, utils.RPCAccessLogSlowFlag.Value, utils.RPCAccessLogSlowFlag.Usage)
 	rootCmd.PersistentFlags().IntVar(&cfg.WebsocketSubscribeLogsChannelSize, utils.WSSubscribeLogsChannelSize.Name, utils.WSSubscribeLogsChannelSize.Value, utils.WSSubscribeLogsChannelSize.Usage)
 
 	if err := rootCmd.MarkPersistentFlagFilename("rpc.accessList", "json"); err != nil {
@@ -181,6 +205,12 @@ func RootCommand() (*cobra.Command, *httpcfg.HttpCfg) {
 			return fmt.Errorf("state.cache value of %v is not valid", stateCacheStr)
 		}
 
+		var codeAnalysisCache datasize.ByteSize
+		if err := codeAnalysisCache.UnmarshalText([]byte(codeAnalysisCacheStr)); err != nil {
+			return fmt.Errorf("%s value of %v is not valid", utils.CodeAnalysisCacheFlag.Name, codeAnalysisCacheStr)
+		}
+		vm.SetCodeAnalysisCacheSize(codeAnalysisCache)
+
 		cfg.WithDatadir = cfg.DataDir != ""
 		if cfg.WithDatadir {
 			if cfg.DataDir == "" {
@@ -209,7 +239,9 @@ type StateChangesClient interface {
 	StateChanges(ctx context.Context, in *remote.StateChangeRequest, opts ...grpc.CallOption) (remote.KV_StateChangesClient, error)
 }
 
-func subscribeToStateChangesLoop(ctx context.Context, client StateChangesClient, cache kvcache.Cache) {
+// subscribeToStateChangesLoop - feeds the state changes of new blocks to the cache. onStateVersion, if set, gets the
+// state version of each block, see remotedb.DB.EnsureStateVersion.
+func subscribeToStateChangesLoop(ctx context.Context, client StateChangesClient, cache kvcache.Cache, onStateVersion func(uint64)) {
 	go func() {
 		for {
 			select {
@@ -217,7 +249,7 @@ func subscribeToStateChangesLoop(ctx context.Context, client StateChangesClient,
 				return
 			default:
 			}
-			if err := subscribeToStateChanges(ctx, client, cache); err != nil {
+			if err := subscribeToStateChanges(ctx, client, cache, onStateVersion); err != nil {
 				if grpcutil.IsRetryLater(err) || grpcutil.IsEndOfStream(err) {
 					time.Sleep(3 * time.Second)
 					continue
@@ -228,7 +260,7 @@ func subscribeToStateChangesLoop(ctx context.Context, client StateChangesClient,
 	}()
 }
 
-func subscribeToStateChanges(ctx context.Context, client StateChangesClient, cache kvcache.Cache) error {
+func subscribeToStateChanges(ctx context.Context, client StateChangesClient, cache kvcache.Cache, onStateVersion func(uint64)) error {
 	streamCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
 	stream, err := client.StateChanges(streamCtx, &remote.StateChangeRequest{WithStorage: true, WithTransactions: false}, grpc.WaitForReady(true))
@@ -244,6 +276,9 @@ func subscribeToStateChanges(ctx context.Context, client StateChangesClient, cac
 		}
 
 		cache.OnNewBlock(req)
+		if onStateVersion != nil {
+			onStateVersion(req.StateVersionId)
+		}
 	}
 }
 
@@ -298,7 +333,7 @@ func EmbeddedServices(ctx context.Context,
 		stateCache = kvcache.NewDummy()
 	}
 
-	subscribeToStateChangesLoop(ctx, stateDiffClient, stateCache)
+	subscribeToStateChangesLoop(ctx, stateDiffClient, stateCache, nil)
 
 	directClient := direct.NewEthBackendClientDirect(ethBackendServer)
 
@@ -357,7 +392,8 @@ func RemoteServices(ctx context.Context, cfg *httpcfg.HttpCfg, logger log.Logger
 		dir.MustExist(cfg.Dirs.SnapHistory)
 		logger.Warn("Opening chain db", "path", cfg.Dirs.Chaindata)
 		limiter := semaphore.NewWeighted(int64(cfg.DBReadConcurrency))
-		rwKv, err = kv2.NewMDBX(logger).RoTxsLimiter(limiter).Path(cfg.Dirs.Chaindata).Accede().Open(ctx)
+		// the bor tables are opened only if chaindata has them: Erigon doesn't create them with --bor.disable
+		rwKv, err = kv2.NewMDBX(logger).RoTxsLimiter(limiter).Path(cfg.Dirs.Chaindata).Accede().WithTableCfg(kv2.WithoutBorTables).Open(ctx)
 		if err != nil {
 			return nil, nil, nil, nil, nil, nil, nil, ff, nil, err
 		}
@@ -376,13 +412,13 @@ func RemoteServices(ctx context.Context, cfg *httpcfg.HttpCfg, logger log.Logger
 				return err
 			}
 			cfg.Snap.Enabled, err = snap.Enabled(tx)
-			if err != nil {
-				return err
-			}
-			return nil
+			return err
 		}); err != nil {
 			return nil, nil, nil, nil, nil, nil, nil, ff, nil, err
 		}
+		if err := rawdb.WatchReceiptsCompression(ctx, db, logger); err != nil {
+			return nil, nil, nil, nil, nil, nil, nil, ff, nil, err
+		}
 		if cc == nil {
 			return nil, nil, nil, nil, nil, nil, nil, ff, nil, fmt.Errorf("chain config not found in db. Need start erigon at least once on this db")
 		}
@@ -477,8 +513,14 @@ func RemoteServices(ctx context.Context, cfg *httpcfg.HttpCfg, logger log.Logger
 		stateCache = kvcache.NewDummy()
 	}
 	// If DB can't be configured - used PrivateApiAddr as remote DB
+	var onStateVersion func(uint64)
 	if db == nil {
 		db = remoteKv
+		// reads after a block import see at least its state
+		onStateVersion = remoteKv.EnsureStateVersion
+		if err := rawdb.WatchReceiptsCompression(ctx, db, logger); err != nil {
+			logger.Warn("[rpc] zstd dictionary of receipts not loaded, compressed receipts can't be read", "err", err)
+		}
 	}
 
 	if !cfg.WithDatadir {
@@ -490,7 +532,7 @@ func RemoteServices(ctx context.Context, cfg *httpcfg.HttpCfg, logger log.Logger
 		logger.Info("if you run RPCDaemon on same machine with Erigon add --datadir option")
 	}
 
-	subscribeToStateChangesLoop(ctx, remoteKvClient, stateCache)
+	subscribeToStateChangesLoop(ctx, remoteKvClient, stateCache, onStateVersion)
 
 	txpoolConn := conn
 	if cfg.TxPoolApiAddr != cfg.PrivateApiAddr {
@@ -537,6 +579,10 @@ func RemoteServices(ctx context.Context, cfg *httpcfg.HttpCfg, logger log.Logger
 					bor.NewChainSpanner(bor.GenesisContractValidatorSetABI(), cc, true, logger),
 					bor.NewGenesisContractsClient(cc, borConfig.ValidatorContract, borConfig.StateReceiverContract, logger), logger)
 
+			case cc.TerminalTotalDifficulty != nil:
+				// same as the engine of Erigon: post-merge chains (e.g. OP Stack) must not get PoW rewards
+				// in blocks re-executed for receipts and traces
+				engine = merge.New(ethash.NewFaker())
 			default:
 				engine = ethash.NewFaker()
 			}
@@ -597,16 +643,14 @@ func StartRpcServerWithJwtAuthentication(ctx context.Context, cfg *httpcfg.HttpC
 func startRegularRpcServer(ctx context.Context, cfg *httpcfg.HttpCfg, rpcAPI []rpc.API, logger log.Logger) error {
 	// register apis and create handler stack
 	srv := rpc.NewServer(cfg.RpcBatchConcurrency, cfg.TraceRequests, cfg.DebugSingleRequest, cfg.RpcStreamingDisable, logger, cfg.RPCSlowLogThreshold)
+	defer srv.Stop()
 
-	allowListForRPC, err := parseAllowListForRPC(cfg.RpcAllowListFilePath)
-	if err != nil {
-		return err
+	if cfg.RPCAccessLog != "" {
+		accessLog := rpc.NewAccessLog(cfg.RPCAccessLog, cfg.RPCAccessLogSample, cfg.RPCAccessLogSlow)
+		srv.SetAccessLog(accessLog)
+		defer accessLog.Close()
+		logger.Info("[rpc] Access log enabled", "dest", cfg.RPCAccessLog, "sample", cfg.RPCAccessLogSample, "slow", cfg.RPCAccessLogSlow)
 	}
-	srv.SetAllowList(allowListForRPC)
-
-	srv.SetBatchLimit(cfg.BatchLimit)
-
-	defer srv.Stop()
 
 	var defaultAPIList []rpc.API
 
@@ -626,6 +670,17 @@ func startRegularRpcServer(ctx context.Context, cfg *httpcfg.HttpCfg, rpcAPI []r
 	if err := node.RegisterApisFromWhitelist(defaultAPIList, apiFlags, srv, false, logger); err != nil {
 		return fmt.Errorf("could not start register RPC apis: %w", err)
 	}
+	// after the apis are registered: the methods of the policy are validated against them
+	policy, err := newRpcPolicy(cfg, srv, logger)
+	if err != nil {
+		return err
+	}
+	go policy.reloadOnSignal(ctx)
+	if slices.Contains(apiFlags, "admin") {
+		if err := srv.RegisterName("admin", &rpcPolicyAPI{policy: policy}); err != nil {
+			return fmt.Errorf("could not start register RPC apis: %w", err)
+		}
+	}
 
 	info := []interface{}{
 		"ws", cfg.WebsocketEnabled,
@@ -654,13 +709,12 @@ func startRegularRpcServer(ctx context.Context, cfg *httpcfg.HttpCfg, rpcAPI []r
 		logger.Info("Socket Endpoint opened", "url", socketUrl)
 	}
 
-	httpHandler := node.NewHTTPHandlerStack(srv, cfg.HttpCORSDomain, cfg.HttpVirtualHost, cfg.HttpCompression)
 	var wsHandler http.Handler
 	if cfg.WebsocketEnabled {
 		wsHandler = srv.WebsocketHandler([]string{"*"}, nil, cfg.WebsocketCompression, logger)
 	}
 	graphQLHandler := graphql.CreateHandler(defaultAPIList)
-	apiHandler, err := createHandler(cfg, defaultAPIList, httpHandler, wsHandler, graphQLHandler, nil)
+	apiHandler, err := createHandler(cfg, defaultAPIList, policy, wsHandler, graphQLHandler, nil)
 	if err != nil {
 		return err
 	}
@@ -82,18 +82,32 @@ type HttpCfg struct {
 	LogDirVerbosity string
 	LogDirPath      string
 
-	BatchLimit                  int  // Maximum number of requests in a batch
-	ReturnDataLimit             int  // Maximum number of bytes returned from calls (like eth_call)
-	AllowUnprotectedTxs         bool // Whether to allow non EIP-155 protected transactions  txs over RPC
-	MaxGetProofRewindBlockCount int  //Max GetProof rewind block count
+	BatchLimit                  int    // Maximum number of requests in a batch
+	BatchResponseLimit          int    // Maximum size of a batch response in bytes
+	MethodConcurrency           string // Maximum concurrent calls per method: "eth_getLogs=8,trace_filter=2"
+	MethodGasCap                string // Gas cap per method: "eth_estimateGas=25000000"
+	MethodResultLimit           string // Maximum result size in bytes per method: "eth_getLogs=10000000"
+	ReturnDataLimit             int    // Maximum number of bytes returned from calls (like eth_call)
+	AllowUnprotectedTxs         bool   // Whether to allow non EIP-155 protected transactions  txs over RPC
+	MaxGetProofRewindBlockCount int    //Max GetProof rewind block count
+	TxnNotIndexedError          bool   // unknown transactions fail with ErrTxnNotIndexed while TxLookup doesn't index all the blocks
 
 	// Optimism
-	RollupSequencerHTTP        string
-	RollupHistoricalRPC        string
-	RollupHistoricalRPCTimeout time.Duration
+	RollupSequencerHTTP         string
+	RollupSequencerRetries      int           // of the transactions forwarded to RollupSequencerHTTP, when it can't be reached
+	RollupSequencerRetryBackoff time.Duration // before the first retry, doubled by each next one
+	RollupSequencerLocalTxPool  bool          // the forwarded transactions are also added to the local txpool
+	RollupHistoricalRPC         string
+	RollupHistoricalRPCTimeout  time.Duration
+	RollupArchiveRPC            string
+	RollupArchiveRPCCacheSize   int
 
 	// Ots API
 	OtsMaxPageSize uint64
 
 	RPCSlowLogThreshold time.Duration
+
+	RPCAccessLog       string // stdout or file, empty - disabled
+	RPCAccessLogSample float64
+	RPCAccessLogSlow   time.Duration
 }
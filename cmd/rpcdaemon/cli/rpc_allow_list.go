@@ -11,12 +11,31 @@ import (
 
 type allowListFile struct {
 	Allow rpc.AllowList `json:"allow"`
+
+	// Optional, override --http.corsdomain, --http.vhosts, --rpc.batch.limit, --rpc.batch.concurrency,
+	// --rpc.batch.responselimit, --rpc.methods.concurrency, --rpc.methods.gascap and --rpc.methods.resultlimit.
+	// Unlike flags, they are applied again when the file is reloaded
+	CORSDomain         []string          `json:"corsdomain,omitempty"`
+	VirtualHost        []string          `json:"vhosts,omitempty"`
+	BatchLimit         *int              `json:"batchLimit,omitempty"`
+	BatchConcurrency   *uint             `json:"batchConcurrency,omitempty"`
+	BatchResponseLimit *int              `json:"batchResponseLimit,omitempty"`
+	MethodConcurrency  map[string]uint   `json:"methodConcurrency,omitempty"`
+	MethodGasCap       map[string]uint64 `json:"methodGasCap,omitempty"`
+	MethodResultLimit  map[string]uint64 `json:"methodResultLimit,omitempty"`
+
+	// Optional, per-method control over the namespaces enabled by --http.api: methods answered as not found (a whole
+	// namespace as "debug_*"), timeouts of the calls of methods ({"eth_call": "5s"}) and how long the responses of
+	// methods are reused for the same params ({"eth_chainId": "1m"}). All the methods must be served.
+	Deny          []string          `json:"deny,omitempty"`
+	MethodTimeout map[string]string `json:"methodTimeout,omitempty"`
+	MethodCache   map[string]string `json:"methodCache,omitempty"`
 }
 
-func parseAllowListForRPC(path string) (rpc.AllowList, error) {
+func parseAllowListFile(path string) (*allowListFile, error) {
 	path = strings.TrimSpace(path)
 	if path == "" { // no file is provided
-		return nil, nil
+		return &allowListFile{}, nil
 	}
 
 	file, err := os.Open(path)
@@ -39,5 +58,5 @@ func parseAllowListForRPC(path string) (rpc.AllowList, error) {
 		return nil, err
 	}
 
-	return allowListFileObj.Allow, nil
+	return &allowListFileObj, nil
 }
@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/cmd/rpcdaemon/cli/httpcfg"
+	"github.com/erigontech/erigon/node"
+	"github.com/erigontech/erigon/rpc"
+)
+
+// rpcPolicy - access policy of the regular RPC server: CORS, vhosts, method allowlist and batch limits.
+// They don't require rebinding sockets, so --rpc.accessList file can be reloaded (on SIGHUP or by admin_reloadRpcConfig)
+// without dropping connections and websocket subscriptions.
+type rpcPolicy struct {
+	cfg     *httpcfg.HttpCfg
+	srv     *rpc.Server
+	lock    sync.Mutex
+	handler atomic.Pointer[http.Handler] // http handler stack of the current policy
+	logger  log.Logger
+}
+
+func newRpcPolicy(cfg *httpcfg.HttpCfg, srv *rpc.Server, logger log.Logger) (*rpcPolicy, error) {
+	p := &rpcPolicy{cfg: cfg, srv: srv, logger: logger}
+	if err := p.load(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *rpcPolicy) load() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	file, err := parseAllowListFile(p.cfg.RpcAllowListFilePath)
+	if err != nil {
+		return err
+	}
+
+	cors, vhosts, batchLimit, batchConcurrency := p.cfg.HttpCORSDomain, p.cfg.HttpVirtualHost, p.cfg.BatchLimit, p.cfg.RpcBatchConcurrency
+	if file.CORSDomain != nil {
+		cors = file.CORSDomain
+	}
+	if file.VirtualHost != nil {
+		vhosts = file.VirtualHost
+	}
+	if file.BatchLimit != nil {
+		batchLimit = *file.BatchLimit
+	}
+	if file.BatchConcurrency != nil {
+		batchConcurrency = *file.BatchConcurrency
+	}
+	batchResponseLimit := p.cfg.BatchResponseLimit
+	if file.BatchResponseLimit != nil {
+		batchResponseLimit = *file.BatchResponseLimit
+	}
+	methodConcurrency, err := rpc.ParseMethodConcurrency(p.cfg.MethodConcurrency)
+	if err != nil {
+		return err
+	}
+	if file.MethodConcurrency != nil {
+		methodConcurrency = file.MethodConcurrency
+	}
+	methodGasCap, err := rpc.ParseMethodCaps(p.cfg.MethodGasCap)
+	if err != nil {
+		return err
+	}
+	if file.MethodGasCap != nil {
+		methodGasCap = file.MethodGasCap
+	}
+	methodResultLimit, err := rpc.ParseMethodCaps(p.cfg.MethodResultLimit)
+	if err != nil {
+		return err
+	}
+	if file.MethodResultLimit != nil {
+		methodResultLimit = file.MethodResultLimit
+	}
+	methodTimeout, err := rpc.ParseMethodDurations(file.MethodTimeout)
+	if err != nil {
+		return fmt.Errorf("methodTimeout: %w", err)
+	}
+	methodCache, err := rpc.ParseMethodDurations(file.MethodCache)
+	if err != nil {
+		return fmt.Errorf("methodCache: %w", err)
+	}
+	methods := append([]string{}, file.Deny...)
+	for method := range methodTimeout {
+		methods = append(methods, method)
+	}
+	for method := range methodCache {
+		methods = append(methods, method)
+	}
+	for _, method := range methods {
+		if !p.srv.HasMethod(method) {
+			return fmt.Errorf("method %s of the access policy is not served, check --http.api", method)
+		}
+	}
+
+	p.srv.SetAllowList(file.Allow)
+	p.srv.SetBatchLimit(batchLimit)
+	p.srv.SetBatchConcurrency(batchConcurrency)
+	p.srv.SetBatchResponseLimit(batchResponseLimit)
+	p.srv.SetMethodConcurrency(methodConcurrency)
+	p.srv.SetMethodCaps(methodGasCap, methodResultLimit)
+	p.srv.SetMethodPolicy(file.Deny, methodTimeout, methodCache)
+	handler := node.NewHTTPHandlerStack(p.srv, cors, vhosts, p.cfg.HttpCompression)
+	p.handler.Store(&handler)
+	p.logger.Debug("[rpc] access policy", "allow", len(file.Allow), "corsdomain", cors, "vhosts", vhosts, "batchLimit", batchLimit, "batchConcurrency", batchConcurrency,
+		"batchResponseLimit", batchResponseLimit, "methodConcurrency", p.srv.MethodConcurrency(),
+		"methodGasCap", methodGasCap, "methodResultLimit", methodResultLimit,
+		"deny", file.Deny, "methodTimeout", file.MethodTimeout, "methodCache", file.MethodCache)
+	return nil
+}
+
+// Reload re-reads --rpc.accessList file. On error the current policy is kept
+func (p *rpcPolicy) Reload() error {
+	if err := p.load(); err != nil {
+		p.logger.Warn("[rpc] failed to reload access policy, keeping current one", "file", p.cfg.RpcAllowListFilePath, "err", err)
+		return err
+	}
+	p.logger.Info("[rpc] access policy reloaded", "file", p.cfg.RpcAllowListFilePath)
+	return nil
+}
+
+func (p *rpcPolicy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	(*p.handler.Load()).ServeHTTP(w, r)
+}
+
+// reloadOnSignal - reloads policy on SIGHUP until ctx is done
+func (p *rpcPolicy) reloadOnSignal(ctx context.Context) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGHUP)
+	defer signal.Stop(ch)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ch:
+			_ = p.Reload()
+		}
+	}
+}
+
+// rpcPolicyAPI - registered in admin namespace, if it's enabled
+type rpcPolicyAPI struct {
+	policy *rpcPolicy
+}
+
+// ReloadRpcConfig implements admin_reloadRpcConfig. Re-reads --rpc.accessList file and applies it to new requests
+func (api *rpcPolicyAPI) ReloadRpcConfig() (bool, error) {
+	if err := api.policy.Reload(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
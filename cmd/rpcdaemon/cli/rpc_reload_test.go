@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/cmd/rpcdaemon/cli/httpcfg"
+	"github.com/erigontech/erigon/rpc"
+)
+
+type reloadTestService struct{}
+
+func (reloadTestService) Echo(s string) string { return s }
+
+func TestRpcPolicyReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.json")
+	writeFile := func(content string) {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+	writeFile(`{}`)
+
+	srv := rpc.NewServer(50, false, false, false, log.New(), 0)
+	require.NoError(t, srv.RegisterName("test", reloadTestService{}))
+	cfg := &httpcfg.HttpCfg{HttpVirtualHost: []string{"localhost"}, RpcAllowListFilePath: path}
+	policy, err := newRpcPolicy(cfg, srv, log.New())
+	require.NoError(t, err)
+
+	call := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "http://rpc.example.com", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"test_echo","params":["hi"]}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Origin", "https://app.example.com")
+		w := httptest.NewRecorder()
+		policy.ServeHTTP(w, req)
+		return w
+	}
+
+	// flags: the host isn't allowed, no CORS
+	require.Equal(t, http.StatusForbidden, call().Code)
+
+	// the file overrides the flags
+	writeFile(`{"vhosts":["rpc.example.com"],"corsdomain":["https://app.example.com"]}`)
+	require.NoError(t, policy.Reload())
+	w := call()
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	require.Contains(t, w.Body.String(), `"result":"hi"`)
+
+	// the denied method isn't found
+	writeFile(`{"vhosts":["rpc.example.com"],"deny":["test_echo"]}`)
+	require.NoError(t, policy.Reload())
+	w = call()
+	require.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	require.Contains(t, w.Body.String(), "does not exist")
+
+	// a broken file or a method which isn't served keep the current policy
+	writeFile(`{"vhosts":`)
+	require.Error(t, policy.Reload())
+	writeFile(`{"deny":["test_unknown"]}`)
+	require.Error(t, policy.Reload())
+	w = call()
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), "does not exist")
+}
@@ -4,15 +4,21 @@ import (
 	"encoding/hex"
 	"fmt"
 	hexutil2 "github.com/erigontech/erigon-lib/common/hexutil"
+	"math/big"
 	"reflect"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/holiman/uint256"
 
 	libcommon "github.com/erigontech/erigon-lib/common"
 	"github.com/erigontech/erigon-lib/common/hexutility"
 
+	"github.com/erigontech/erigon/cmd/rpcdaemon/graphql/graph/model"
 	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/rpc"
+	"github.com/erigontech/erigon/turbo/adapter/ethapi"
 )
 
 func convertDataToStringP(abstractMap map[string]interface{}, field string) *string {
@@ -134,3 +140,232 @@ func convertStrHexToDec(hexString *string) *string {
 
 	return &result
 }
+
+// convertBlock converts the result of GraphQLAPI.GetBlockDetails. Transactions, logs and accounts are linked to
+// each other and to the block, so nested selections don't need further lookups.
+func convertBlock(res map[string]interface{}) *model.Block {
+	block := &model.Block{}
+	absBlk := res["block"]
+
+	if absBlk != nil {
+		blk := absBlk.(map[string]interface{})
+
+		block.Difficulty = *convertDataToStringP(blk, "difficulty")
+		block.ExtraData = *convertDataToStringP(blk, "extraData")
+		block.GasLimit = uint64(*convertDataToUint64P(blk, "gasLimit"))
+		block.GasUsed = *convertDataToUint64P(blk, "gasUsed")
+		// the pending block has no hash, nonce, miner and total difficulty
+		if blk["hash"] != nil {
+			block.Hash = *convertDataToStringP(blk, "hash")
+		}
+		block.Number = *convertDataToUint64P(blk, "number")
+		block.Miner = newAccount("", block.Hash)
+		if blk["miner"] != nil {
+			block.Miner.Address = strings.ToLower(*convertDataToStringP(blk, "miner"))
+		}
+		mixHash := convertDataToStringP(blk, "mixHash")
+		if mixHash != nil {
+			block.MixHash = *mixHash
+		}
+		if blk["nonce"] != nil {
+			block.Nonce = *convertDataToStringP(blk, "nonce")
+		}
+		block.Ommers = []*model.Block{}
+		block.Parent = &model.Block{}
+		block.Parent.Hash = *convertDataToStringP(blk, "parentHash")
+		block.ReceiptsRoot = *convertDataToStringP(blk, "receiptsRoot")
+		block.StateRoot = *convertDataToStringP(blk, "stateRoot")
+		block.Timestamp = *convertDataToStringP(blk, "timestamp")
+		block.TransactionCount = convertDataToIntP(blk, "transactionCount")
+		block.TransactionsRoot = *convertDataToStringP(blk, "transactionsRoot")
+		if td := convertDataToStringP(blk, "totalDifficulty"); td != nil {
+			block.TotalDifficulty = *td
+		}
+		block.BaseFeePerGas = convertDataToStringP(blk, "baseFeePerGas")
+		block.Transactions = []*model.Transaction{}
+
+		block.LogsBloom = "0x" + *convertDataToStringP(blk, "logsBloom")
+		block.OmmerHash = *convertDataToStringP(blk, "sha3Uncles")
+
+		absRcp := res["receipts"]
+		rcp := absRcp.([]map[string]interface{})
+		for _, transReceipt := range rcp {
+			trans := &model.Transaction{}
+			trans.CumulativeGasUsed = convertDataToUint64P(transReceipt, "cumulativeGasUsed")
+			trans.InputData = *convertDataToStringP(transReceipt, "data")
+			trans.EffectiveGasPrice = convertDataToStringP(transReceipt, "effectiveGasPrice")
+			trans.GasPrice = *convertDataToStringP(transReceipt, "effectiveGasPrice")
+			trans.GasUsed = convertDataToUint64P(transReceipt, "gasUsed")
+			trans.Hash = *convertDataToStringP(transReceipt, "transactionHash")
+			trans.Index = convertDataToIntP(transReceipt, "transactionIndex")
+			transNonce := convertDataToStringP(transReceipt, "nonce")
+			if transNonce != nil {
+				trans.Nonce = *transNonce
+			}
+			trans.Status = convertDataToUint64P(transReceipt, "status")
+			trans.Type = convertDataToIntP(transReceipt, "type")
+			trans.Value = *convertDataToStringP(transReceipt, "value")
+			trans.Gas = *convertDataToUint64P(transReceipt, "gas")
+			trans.Block = block
+
+			trans.Logs = make([]*model.Log, 0)
+			for _, rlog := range transReceipt["logs"].(types.Logs) {
+				tlog := model.Log{
+					Index: int(rlog.Index),
+					Data:  "0x" + hex.EncodeToString(rlog.Data),
+				}
+				tlog.Account = newAccount(strings.ToLower(rlog.Address.String()), block.Hash)
+				tlog.Transaction = trans
+
+				for _, rtopic := range rlog.Topics {
+					tlog.Topics = append(tlog.Topics, rtopic.String())
+				}
+
+				trans.Logs = append(trans.Logs, &tlog)
+			}
+
+			trans.From = newAccount(strings.ToLower(*convertDataToStringP(transReceipt, "from")), block.Hash)
+
+			address := convertDataToStringP(transReceipt, "to")
+			// To address could be nil in case of contract creation
+			if address != nil {
+				trans.To = newAccount(strings.ToLower(*address), block.Hash)
+			}
+			if created, ok := transReceipt["contractAddress"].(libcommon.Address); ok {
+				trans.CreatedContract = newAccount(strings.ToLower(created.String()), block.Hash)
+			}
+
+			txn, _ := transReceipt["txn"].(types.Transaction)
+			receipt, _ := transReceipt["receipt"].(*types.Receipt)
+			if txn != nil && receipt != nil {
+				convertOptimismFields(trans, txn, receipt)
+			}
+
+			block.Transactions = append(block.Transactions, trans)
+		}
+	}
+
+	return block
+}
+
+// convertOptimismFields sets the OP-stack extensions of the transaction; fields which don't apply stay nil.
+func convertOptimismFields(trans *model.Transaction, txn types.Transaction, receipt *types.Receipt) {
+	if deposit, ok := txn.(*types.DepositTx); ok {
+		sourceHash := deposit.SourceHash.String()
+		trans.SourceHash = &sourceHash
+		if deposit.Mint != nil {
+			mint := deposit.Mint.Hex()
+			trans.Mint = &mint
+		}
+		isSystemTx := deposit.IsSystemTransaction
+		trans.IsSystemTx = &isSystemTx
+	}
+	trans.L1GasPrice = convertBigToStringP(receipt.L1GasPrice)
+	trans.L1GasUsed = convertBigToStringP(receipt.L1GasUsed)
+	trans.L1Fee = convertBigToStringP(receipt.L1Fee)
+	if receipt.FeeScalar != nil {
+		feeScalar := receipt.FeeScalar.Text('f', -1)
+		trans.L1FeeScalar = &feeScalar
+	}
+	trans.L1BaseFeeScalar = receipt.L1BaseFeeScalar
+	trans.L1BlobBaseFee = convertBigToStringP(receipt.L1BlobBaseFee)
+	trans.L1BlobBaseFeeScalar = receipt.L1BlobBaseFeeScalar
+	trans.DepositNonce = receipt.DepositNonce
+	trans.DepositReceiptVersion = receipt.DepositReceiptVersion
+}
+
+func convertBigToStringP(v *big.Int) *string {
+	if v == nil {
+		return nil
+	}
+	result := hexutil2.EncodeBig(v)
+	return &result
+}
+
+func newAccount(address string, blockHash string) *model.Account {
+	return &model.Account{Address: address, BlockHash: blockHash}
+}
+
+// accountBlock is the block at whose state the fields of the account are read. It's taken by hash, so the state
+// of a block which is reorged out isn't read at the block of the same number on the new chain.
+func accountBlock(account *model.Account) rpc.BlockNumberOrHash {
+	if account.BlockHash == "" {
+		return rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)
+	}
+	return rpc.BlockNumberOrHashWithHash(libcommon.HexToHash(account.BlockHash), false)
+}
+
+// convertCallData converts the call arguments, numbers are either hex with the 0x prefix or decimal
+func convertCallData(data model.CallData) (ethapi.CallArgs, error) {
+	args := ethapi.CallArgs{}
+	if data.From != nil {
+		from := libcommon.HexToAddress(*data.From)
+		args.From = &from
+	}
+	if data.To != nil {
+		to := libcommon.HexToAddress(*data.To)
+		args.To = &to
+	}
+	if data.Gas != nil {
+		args.Gas = (*hexutil2.Uint64)(data.Gas)
+	}
+	for _, field := range []struct {
+		value *string
+		arg   **hexutil2.Big
+		name  string
+	}{
+		{data.GasPrice, &args.GasPrice, "gasPrice"},
+		{data.MaxFeePerGas, &args.MaxFeePerGas, "maxFeePerGas"},
+		{data.MaxPriorityFeePerGas, &args.MaxPriorityFeePerGas, "maxPriorityFeePerGas"},
+		{data.Value, &args.Value, "value"},
+	} {
+		if field.value == nil {
+			continue
+		}
+		value, err := parseBigInt(*field.value)
+		if err != nil {
+			return args, fmt.Errorf("invalid %s: %w", field.name, err)
+		}
+		*field.arg = (*hexutil2.Big)(value)
+	}
+	if data.Data != nil {
+		input, err := hexutil2.Decode(*data.Data)
+		if err != nil {
+			return args, fmt.Errorf("invalid data: %w", err)
+		}
+		args.Data = (*hexutility.Bytes)(&input)
+	}
+	return args, nil
+}
+
+func parseBigInt(value string) (*big.Int, error) {
+	if strings.HasPrefix(value, "0x") {
+		return hexutil2.DecodeBig(value)
+	}
+	result, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		return nil, fmt.Errorf("%q is not a number", value)
+	}
+	return result, nil
+}
+
+// logMatches reports whether the log passes the address and topic filters, empty filters match any log.
+// Topics are matched by position, an empty position matches any topic.
+func logMatches(log *model.Log, addresses []string, topics [][]string) bool {
+	if len(addresses) > 0 && !slices.ContainsFunc(addresses, func(address string) bool {
+		return strings.EqualFold(address, log.Account.Address)
+	}) {
+		return false
+	}
+	if len(topics) > len(log.Topics) {
+		return false
+	}
+	for i, position := range topics {
+		if len(position) > 0 && !slices.ContainsFunc(position, func(topic string) bool {
+			return strings.EqualFold(topic, log.Topics[i])
+		}) {
+			return false
+		}
+	}
+	return true
+}
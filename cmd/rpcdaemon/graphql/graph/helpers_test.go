@@ -0,0 +1,110 @@
+package graph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+
+	"github.com/erigontech/erigon/cmd/rpcdaemon/graphql/graph/model"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/rpc"
+)
+
+func TestBlockLogs(t *testing.T) {
+	t.Parallel()
+
+	addrA := libcommon.HexToAddress("0xaa")
+	addrB := libcommon.HexToAddress("0xbb")
+	topic1 := libcommon.HexToHash("0x01")
+	topic2 := libcommon.HexToHash("0x02")
+	to := libcommon.HexToAddress("0xcc")
+	nonce := uint64(7)
+	res := map[string]interface{}{
+		"block": map[string]interface{}{"number": uint64(10), "hash": libcommon.HexToHash("0x0a")},
+		"receipts": []map[string]interface{}{{
+			"transactionHash": libcommon.HexToHash("0x1234"),
+			"from":            addrA,
+			"to":              &to,
+			"gas":             uint64(21000),
+			"logs": types.Logs{
+				{Address: addrA, Topics: []libcommon.Hash{topic1, topic2}, Index: 0},
+				{Address: addrB, Topics: []libcommon.Hash{topic2}, Index: 1},
+			},
+			"txn":     &types.DepositTx{SourceHash: libcommon.HexToHash("0x55"), IsSystemTransaction: true},
+			"receipt": &types.Receipt{DepositNonce: &nonce},
+		}},
+	}
+	block := convertBlock(res)
+	require.Len(t, block.Transactions, 1)
+	trans := block.Transactions[0]
+	require.Same(t, block, trans.Block)
+	require.Equal(t, libcommon.HexToHash("0x0a").String(), trans.From.BlockHash)
+	require.Equal(t, rpc.BlockNumberOrHashWithHash(libcommon.HexToHash("0x0a"), false), accountBlock(trans.From))
+	require.Equal(t, &nonce, trans.DepositNonce)
+	require.Equal(t, libcommon.HexToHash("0x55").String(), *trans.SourceHash)
+	require.True(t, *trans.IsSystemTx)
+	require.Nil(t, trans.L1Fee)
+
+	resolver := &blockResolver{&Resolver{}}
+	for _, tt := range []struct {
+		filter model.BlockFilterCriteria
+		want   []int
+	}{
+		{filter: model.BlockFilterCriteria{}, want: []int{0, 1}},
+		{filter: model.BlockFilterCriteria{Addresses: []string{addrB.String()}}, want: []int{1}},
+		{filter: model.BlockFilterCriteria{Topics: [][]string{{topic1.Hex(), topic2.Hex()}}}, want: []int{0, 1}},
+		{filter: model.BlockFilterCriteria{Topics: [][]string{{}, {topic2.Hex()}}}, want: []int{0}},
+		{filter: model.BlockFilterCriteria{Addresses: []string{addrB.String()}, Topics: [][]string{{topic1.Hex()}}}},
+	} {
+		logs, err := resolver.Logs(context.Background(), block, tt.filter)
+		require.NoError(t, err)
+		got := []int{}
+		for _, l := range logs {
+			require.Same(t, trans, l.Transaction)
+			got = append(got, l.Index)
+		}
+		if tt.want == nil {
+			tt.want = []int{}
+		}
+		require.Equal(t, tt.want, got, "%+v", tt.filter)
+	}
+}
+
+func TestPendingBlock(t *testing.T) {
+	t.Parallel()
+
+	// the pending block has no hash, miner, nonce and total difficulty
+	res := map[string]interface{}{
+		"block":    map[string]interface{}{"number": uint64(11), "hash": nil, "miner": nil, "nonce": nil, "totalDifficulty": (*hexutil.Big)(nil)},
+		"receipts": []map[string]interface{}{},
+	}
+	block := convertBlock(res)
+	require.Empty(t, block.Hash)
+	require.Equal(t, rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber), accountBlock(block.Miner))
+}
+
+func TestConvertCallData(t *testing.T) {
+	t.Parallel()
+
+	to := "0x00000000000000000000000000000000000000cc"
+	gas := uint64(50000)
+	value := "1000"
+	gasPrice := "0x10"
+	input := "0x1234"
+	args, err := convertCallData(model.CallData{To: &to, Gas: &gas, Value: &value, GasPrice: &gasPrice, Data: &input})
+	require.NoError(t, err)
+	require.Nil(t, args.From)
+	require.Equal(t, libcommon.HexToAddress(to), *args.To)
+	require.Equal(t, gas, uint64(*args.Gas))
+	require.Equal(t, int64(1000), args.Value.ToInt().Int64())
+	require.Equal(t, int64(16), args.GasPrice.ToInt().Int64())
+	require.Equal(t, []byte{0x12, 0x34}, []byte(*args.Data))
+
+	invalid := "ten"
+	_, err = convertCallData(model.CallData{Value: &invalid})
+	require.ErrorContains(t, err, "invalid value")
+}
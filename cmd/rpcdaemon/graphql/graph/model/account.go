@@ -0,0 +1,8 @@
+package model
+
+// Account is an account at the state of the block BlockHash, or of the pending block if it's empty. Only the address
+// is known up front, the state fields are read on demand by the AccountResolver.
+type Account struct {
+	Address   string `json:"address"`
+	BlockHash string `json:"-"`
+}
@@ -7,14 +7,6 @@ type AccessTuple struct {
 	StorageKeys []string `json:"storageKeys"`
 }
 
-type Account struct {
-	Address          string `json:"address"`
-	Balance          string `json:"balance"`
-	TransactionCount uint64 `json:"transactionCount"`
-	Code             string `json:"code"`
-	Storage          string `json:"storage"`
-}
-
 type Block struct {
 	Number            uint64         `json:"number"`
 	Hash              string         `json:"hash"`
@@ -40,11 +32,6 @@ type Block struct {
 	OmmerAt           *Block         `json:"ommerAt,omitempty"`
 	OmmerHash         string         `json:"ommerHash"`
 	Transactions      []*Transaction `json:"transactions,omitempty"`
-	TransactionAt     *Transaction   `json:"transactionAt,omitempty"`
-	Logs              []*Log         `json:"logs"`
-	Account           *Account       `json:"account"`
-	Call              *CallResult    `json:"call,omitempty"`
-	EstimateGas       uint64         `json:"estimateGas"`
 	RawHeader         string         `json:"rawHeader"`
 	Raw               string         `json:"raw"`
 }
@@ -89,9 +76,6 @@ type Log struct {
 type Pending struct {
 	TransactionCount int            `json:"transactionCount"`
 	Transactions     []*Transaction `json:"transactions,omitempty"`
-	Account          *Account       `json:"account"`
-	Call             *CallResult    `json:"call,omitempty"`
-	EstimateGas      uint64         `json:"estimateGas"`
 }
 
 type SyncState struct {
@@ -101,30 +85,42 @@ type SyncState struct {
 }
 
 type Transaction struct {
-	Hash                 string         `json:"hash"`
-	Nonce                string         `json:"nonce"`
-	Index                *int           `json:"index,omitempty"`
-	From                 *Account       `json:"from"`
-	To                   *Account       `json:"to,omitempty"`
-	Value                string         `json:"value"`
-	GasPrice             string         `json:"gasPrice"`
-	MaxFeePerGas         *string        `json:"maxFeePerGas,omitempty"`
-	MaxPriorityFeePerGas *string        `json:"maxPriorityFeePerGas,omitempty"`
-	EffectiveTip         *string        `json:"effectiveTip,omitempty"`
-	Gas                  uint64         `json:"gas"`
-	InputData            string         `json:"inputData"`
-	Block                *Block         `json:"block,omitempty"`
-	Status               *uint64        `json:"status,omitempty"`
-	GasUsed              *uint64        `json:"gasUsed,omitempty"`
-	CumulativeGasUsed    *uint64        `json:"cumulativeGasUsed,omitempty"`
-	EffectiveGasPrice    *string        `json:"effectiveGasPrice,omitempty"`
-	CreatedContract      *Account       `json:"createdContract,omitempty"`
-	Logs                 []*Log         `json:"logs,omitempty"`
-	R                    string         `json:"r"`
-	S                    string         `json:"s"`
-	V                    string         `json:"v"`
-	Type                 *int           `json:"type,omitempty"`
-	AccessList           []*AccessTuple `json:"accessList,omitempty"`
-	Raw                  string         `json:"raw"`
-	RawReceipt           string         `json:"rawReceipt"`
+	Hash                  string         `json:"hash"`
+	Nonce                 string         `json:"nonce"`
+	Index                 *int           `json:"index,omitempty"`
+	From                  *Account       `json:"from"`
+	To                    *Account       `json:"to,omitempty"`
+	Value                 string         `json:"value"`
+	GasPrice              string         `json:"gasPrice"`
+	MaxFeePerGas          *string        `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas  *string        `json:"maxPriorityFeePerGas,omitempty"`
+	EffectiveTip          *string        `json:"effectiveTip,omitempty"`
+	Gas                   uint64         `json:"gas"`
+	InputData             string         `json:"inputData"`
+	Block                 *Block         `json:"block,omitempty"`
+	Status                *uint64        `json:"status,omitempty"`
+	GasUsed               *uint64        `json:"gasUsed,omitempty"`
+	CumulativeGasUsed     *uint64        `json:"cumulativeGasUsed,omitempty"`
+	EffectiveGasPrice     *string        `json:"effectiveGasPrice,omitempty"`
+	CreatedContract       *Account       `json:"createdContract,omitempty"`
+	Logs                  []*Log         `json:"logs,omitempty"`
+	R                     string         `json:"r"`
+	S                     string         `json:"s"`
+	V                     string         `json:"v"`
+	Type                  *int           `json:"type,omitempty"`
+	AccessList            []*AccessTuple `json:"accessList,omitempty"`
+	Raw                   string         `json:"raw"`
+	RawReceipt            string         `json:"rawReceipt"`
+	SourceHash            *string        `json:"sourceHash,omitempty"`
+	Mint                  *string        `json:"mint,omitempty"`
+	IsSystemTx            *bool          `json:"isSystemTx,omitempty"`
+	L1GasPrice            *string        `json:"l1GasPrice,omitempty"`
+	L1GasUsed             *string        `json:"l1GasUsed,omitempty"`
+	L1Fee                 *string        `json:"l1Fee,omitempty"`
+	L1FeeScalar           *string        `json:"l1FeeScalar,omitempty"`
+	L1BaseFeeScalar       *uint64        `json:"l1BaseFeeScalar,omitempty"`
+	L1BlobBaseFee         *string        `json:"l1BlobBaseFee,omitempty"`
+	L1BlobBaseFeeScalar   *uint64        `json:"l1BlobBaseFeeScalar,omitempty"`
+	DepositNonce          *uint64        `json:"depositNonce,omitempty"`
+	DepositReceiptVersion *uint64        `json:"depositReceiptVersion,omitempty"`
 }
@@ -1,7 +1,12 @@
 package graph
 
 import (
+	"context"
+
+	"github.com/erigontech/erigon-lib/common/hexutility"
 	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon/cmd/rpcdaemon/graphql/graph/model"
+	"github.com/erigontech/erigon/rpc"
 	"github.com/erigontech/erigon/turbo/jsonrpc"
 	"github.com/erigontech/erigon/turbo/rpchelper"
 	"github.com/erigontech/erigon/turbo/services"
@@ -17,3 +22,36 @@ type Resolver struct {
 	filters     *rpchelper.Filters
 	blockReader services.FullBlockReader
 }
+
+// call executes the call at the state of the block, a failed call is a result with status 0
+func (r *Resolver) call(ctx context.Context, data model.CallData, blockNrOrHash rpc.BlockNumberOrHash) (*model.CallResult, error) {
+	args, err := convertCallData(data)
+	if err != nil {
+		return nil, err
+	}
+	result, err := r.GraphQLAPI.Call(ctx, args, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	status := uint64(1)
+	if result.Failed() {
+		status = 0
+	}
+	return &model.CallResult{
+		Data:    hexutility.Bytes(result.ReturnData).String(),
+		GasUsed: result.UsedGas,
+		Status:  status,
+	}, nil
+}
+
+func (r *Resolver) estimateGas(ctx context.Context, data model.CallData, blockNrOrHash rpc.BlockNumberOrHash) (uint64, error) {
+	args, err := convertCallData(data)
+	if err != nil {
+		return 0, err
+	}
+	gas, err := r.GraphQLAPI.EstimateGas(ctx, args, blockNrOrHash)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(gas), nil
+}
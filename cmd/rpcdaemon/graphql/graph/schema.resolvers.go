@@ -6,20 +6,113 @@ package graph
 
 import (
 	"context"
-	"encoding/hex"
+	"errors"
 	"fmt"
+	"math/big"
 	"strconv"
 	"strings"
 
+	libcommon "github.com/erigontech/erigon-lib/common"
 	"github.com/erigontech/erigon-lib/common/hexutil"
 	"github.com/erigontech/erigon/cmd/rpcdaemon/graphql/graph/model"
-	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/eth/filters"
 	"github.com/erigontech/erigon/rpc"
 )
 
+// Balance is the resolver for the balance field.
+func (r *accountResolver) Balance(ctx context.Context, obj *model.Account) (string, error) {
+	balance, err := r.GraphQLAPI.GetBalance(ctx, libcommon.HexToAddress(obj.Address), accountBlock(obj))
+	if err != nil {
+		return "", err
+	}
+	return balance.String(), nil
+}
+
+// TransactionCount is the resolver for the transactionCount field.
+func (r *accountResolver) TransactionCount(ctx context.Context, obj *model.Account) (uint64, error) {
+	nonce, err := r.GraphQLAPI.GetTransactionCount(ctx, libcommon.HexToAddress(obj.Address), accountBlock(obj))
+	if err != nil {
+		return 0, err
+	}
+	return uint64(*nonce), nil
+}
+
+// Code is the resolver for the code field.
+func (r *accountResolver) Code(ctx context.Context, obj *model.Account) (string, error) {
+	code, err := r.GraphQLAPI.GetCode(ctx, libcommon.HexToAddress(obj.Address), accountBlock(obj))
+	if err != nil {
+		return "", err
+	}
+	return code.String(), nil
+}
+
+// Storage is the resolver for the storage field.
+func (r *accountResolver) Storage(ctx context.Context, obj *model.Account, slot string) (string, error) {
+	return r.GraphQLAPI.GetStorageAt(ctx, libcommon.HexToAddress(obj.Address), slot, accountBlock(obj))
+}
+
+// TransactionAt is the resolver for the transactionAt field.
+func (r *blockResolver) TransactionAt(ctx context.Context, obj *model.Block, index int) (*model.Transaction, error) {
+	if index < 0 || index >= len(obj.Transactions) {
+		return nil, nil
+	}
+	return obj.Transactions[index], nil
+}
+
+// Logs is the resolver for the logs field.
+func (r *blockResolver) Logs(ctx context.Context, obj *model.Block, filter model.BlockFilterCriteria) ([]*model.Log, error) {
+	logs := []*model.Log{}
+	for _, trans := range obj.Transactions {
+		for _, tlog := range trans.Logs {
+			if logMatches(tlog, filter.Addresses, filter.Topics) {
+				logs = append(logs, tlog)
+			}
+		}
+	}
+	return logs, nil
+}
+
+// Account is the resolver for the account field.
+func (r *blockResolver) Account(ctx context.Context, obj *model.Block, address string) (*model.Account, error) {
+	return newAccount(strings.ToLower(libcommon.HexToAddress(address).String()), obj.Hash), nil
+}
+
+// Call is the resolver for the call field.
+func (r *blockResolver) Call(ctx context.Context, obj *model.Block, data model.CallData) (*model.CallResult, error) {
+	return r.call(ctx, data, rpc.BlockNumberOrHashWithHash(libcommon.HexToHash(obj.Hash), false))
+}
+
+// EstimateGas is the resolver for the estimateGas field.
+func (r *blockResolver) EstimateGas(ctx context.Context, obj *model.Block, data model.CallData) (uint64, error) {
+	return r.estimateGas(ctx, data, rpc.BlockNumberOrHashWithHash(libcommon.HexToHash(obj.Hash), false))
+}
+
 // SendRawTransaction is the resolver for the sendRawTransaction field.
 func (r *mutationResolver) SendRawTransaction(ctx context.Context, data string) (string, error) {
-	panic(fmt.Errorf("not implemented: SendRawTransaction - sendRawTransaction"))
+	encodedTx, err := hexutil.Decode(data)
+	if err != nil {
+		return "", err
+	}
+	hash, err := r.GraphQLAPI.SendRawTransaction(ctx, encodedTx)
+	if err != nil {
+		return "", err
+	}
+	return hash.String(), nil
+}
+
+// Account is the resolver for the account field.
+func (r *pendingResolver) Account(ctx context.Context, obj *model.Pending, address string) (*model.Account, error) {
+	return newAccount(strings.ToLower(libcommon.HexToAddress(address).String()), ""), nil
+}
+
+// Call is the resolver for the call field.
+func (r *pendingResolver) Call(ctx context.Context, obj *model.Pending, data model.CallData) (*model.CallResult, error) {
+	return r.call(ctx, data, rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber))
+}
+
+// EstimateGas is the resolver for the estimateGas field.
+func (r *pendingResolver) EstimateGas(ctx context.Context, obj *model.Pending, data model.CallData) (uint64, error) {
+	return r.estimateGas(ctx, data, rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber))
 }
 
 // Block is the resolver for the block field.
@@ -42,12 +135,6 @@ func (r *queryResolver) Block(ctx context.Context, number *string, hash *string)
 				return nil, err
 			}
 		}
-	} else {
-		if hash != nil {
-			blockHash, _ := hexutil.DecodeBig(*hash)
-			fmt.Println("TODO/GraphQL/Implement me, get Block by hash=", blockHash)
-			hash = nil
-		}
 	}
 
 	if number == nil && hash == nil {
@@ -62,102 +149,22 @@ func (r *queryResolver) Block(ctx context.Context, number *string, hash *string)
 		blockNumber = rpc.LatestBlockNumber
 	}
 
-	res, err := r.GraphQLAPI.GetBlockDetails(ctx, blockNumber)
+	var res map[string]interface{}
+	var err error
+	if number == nil && hash != nil {
+		res, err = r.GraphQLAPI.GetBlockDetailsByHash(ctx, libcommon.HexToHash(*hash))
+	} else {
+		res, err = r.GraphQLAPI.GetBlockDetails(ctx, blockNumber)
+	}
 	if err != nil {
 		fmt.Println(err)
 		return nil, err
 	}
-
-	block := &model.Block{}
-	absBlk := res["block"]
-
-	if absBlk != nil {
-		blk := absBlk.(map[string]interface{})
-
-		block.Difficulty = *convertDataToStringP(blk, "difficulty")
-		block.ExtraData = *convertDataToStringP(blk, "extraData")
-		block.GasLimit = uint64(*convertDataToUint64P(blk, "gasLimit"))
-		block.GasUsed = *convertDataToUint64P(blk, "gasUsed")
-		block.Hash = *convertDataToStringP(blk, "hash")
-		block.Miner = &model.Account{}
-		address := convertDataToStringP(blk, "miner")
-		if address != nil {
-			block.Miner.Address = strings.ToLower(*address)
-		}
-		mixHash := convertDataToStringP(blk, "mixHash")
-		if mixHash != nil {
-			block.MixHash = *mixHash
-		}
-		blockNonce := convertDataToStringP(blk, "nonce")
-		if blockNonce != nil {
-			block.Nonce = *blockNonce
-		}
-		block.Number = *convertDataToUint64P(blk, "number")
-		block.Ommers = []*model.Block{}
-		block.Parent = &model.Block{}
-		block.Parent.Hash = *convertDataToStringP(blk, "parentHash")
-		block.ReceiptsRoot = *convertDataToStringP(blk, "receiptsRoot")
-		block.StateRoot = *convertDataToStringP(blk, "stateRoot")
-		block.Timestamp = *convertDataToStringP(blk, "timestamp")
-		block.TransactionCount = convertDataToIntP(blk, "transactionCount")
-		block.TransactionsRoot = *convertDataToStringP(blk, "transactionsRoot")
-		block.TotalDifficulty = *convertDataToStringP(blk, "totalDifficulty")
-		block.BaseFeePerGas = convertDataToStringP(blk, "baseFeePerGas")
-		block.Transactions = []*model.Transaction{}
-
-		block.LogsBloom = "0x" + *convertDataToStringP(blk, "logsBloom")
-		block.OmmerHash = *convertDataToStringP(blk, "sha3Uncles")
-
-		absRcp := res["receipts"]
-		rcp := absRcp.([]map[string]interface{})
-		for _, transReceipt := range rcp {
-			trans := &model.Transaction{}
-			trans.CumulativeGasUsed = convertDataToUint64P(transReceipt, "cumulativeGasUsed")
-			trans.InputData = *convertDataToStringP(transReceipt, "data")
-			trans.EffectiveGasPrice = convertDataToStringP(transReceipt, "effectiveGasPrice")
-			trans.GasPrice = *convertDataToStringP(transReceipt, "effectiveGasPrice")
-			trans.GasUsed = convertDataToUint64P(transReceipt, "gasUsed")
-			trans.Hash = *convertDataToStringP(transReceipt, "transactionHash")
-			trans.Index = convertDataToIntP(transReceipt, "transactionIndex")
-			transNonce := convertDataToStringP(transReceipt, "nonce")
-			if transNonce != nil {
-				trans.Nonce = *transNonce
-			}
-			trans.Status = convertDataToUint64P(transReceipt, "status")
-			trans.Type = convertDataToIntP(transReceipt, "type")
-			trans.Value = *convertDataToStringP(transReceipt, "value")
-
-			trans.Logs = make([]*model.Log, 0)
-			for _, rlog := range transReceipt["logs"].(types.Logs) {
-				tlog := model.Log{
-					Index: int(rlog.Index),
-					Data:  "0x" + hex.EncodeToString(rlog.Data),
-				}
-				tlog.Account = &model.Account{}
-				tlog.Account.Address = strings.ToLower(rlog.Address.String())
-
-				for _, rtopic := range rlog.Topics {
-					tlog.Topics = append(tlog.Topics, rtopic.String())
-				}
-
-				trans.Logs = append(trans.Logs, &tlog)
-			}
-
-			trans.From = &model.Account{}
-			trans.From.Address = strings.ToLower(*convertDataToStringP(transReceipt, "from"))
-
-			trans.To = &model.Account{}
-			address := convertDataToStringP(transReceipt, "to")
-			// To address could be nil in case of contract creation
-			if address != nil {
-				trans.To.Address = strings.ToLower(*convertDataToStringP(transReceipt, "to"))
-			}
-
-			block.Transactions = append(block.Transactions, trans)
-		}
+	if res == nil {
+		return nil, ctx.Err()
 	}
 
-	return block, ctx.Err()
+	return convertBlock(res), ctx.Err()
 }
 
 // Blocks is the resolver for the blocks field.
@@ -166,8 +173,20 @@ func (r *queryResolver) Blocks(ctx context.Context, from *uint64, to *uint64) ([
 
 	const maxBlocks = 25
 
+	if from == nil {
+		return nil, errors.New("from block number must be specified")
+	}
 	fromBlockNumber := *from
-	toBlockNumber := *to
+	var toBlockNumber uint64
+	if to != nil {
+		toBlockNumber = *to
+	} else {
+		latest, err := r.Block(ctx, nil, nil)
+		if err != nil || latest == nil {
+			return nil, err
+		}
+		toBlockNumber = latest.Number
+	}
 
 	if toBlockNumber >= fromBlockNumber && (toBlockNumber-fromBlockNumber+1) < maxBlocks {
 
@@ -185,32 +204,120 @@ func (r *queryResolver) Blocks(ctx context.Context, from *uint64, to *uint64) ([
 
 // Pending is the resolver for the pending field.
 func (r *queryResolver) Pending(ctx context.Context) (*model.Pending, error) {
-	panic(fmt.Errorf("not implemented: Pending - pending"))
+	res, err := r.GraphQLAPI.GetBlockDetails(ctx, rpc.PendingBlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	// without a pending block nothing is pending, the state is the latest one
+	pending := &model.Pending{Transactions: []*model.Transaction{}}
+	if res != nil {
+		pending.Transactions = convertBlock(res).Transactions
+	}
+	pending.TransactionCount = len(pending.Transactions)
+	return pending, ctx.Err()
 }
 
 // Transaction is the resolver for the transaction field.
 func (r *queryResolver) Transaction(ctx context.Context, hash string) (*model.Transaction, error) {
-	panic(fmt.Errorf("not implemented: Transaction - transaction"))
+	res, err := r.GraphQLAPI.GetTransactionDetails(ctx, libcommon.HexToHash(hash))
+	if err != nil || res == nil {
+		return nil, err
+	}
+	transactions := convertBlock(res).Transactions
+	if len(transactions) == 0 {
+		return nil, nil
+	}
+	return transactions[0], nil
 }
 
 // Logs is the resolver for the logs field.
 func (r *queryResolver) Logs(ctx context.Context, filter model.FilterCriteria) ([]*model.Log, error) {
-	panic(fmt.Errorf("not implemented: Logs - logs"))
+	crit := filters.FilterCriteria{}
+	if filter.FromBlock != nil {
+		crit.FromBlock = new(big.Int).SetUint64(*filter.FromBlock)
+	}
+	if filter.ToBlock != nil {
+		crit.ToBlock = new(big.Int).SetUint64(*filter.ToBlock)
+	}
+	for _, address := range filter.Addresses {
+		crit.Addresses = append(crit.Addresses, libcommon.HexToAddress(address))
+	}
+	for _, topics := range filter.Topics {
+		position := make([]libcommon.Hash, 0, len(topics))
+		for _, topic := range topics {
+			position = append(position, libcommon.HexToHash(topic))
+		}
+		crit.Topics = append(crit.Topics, position)
+	}
+
+	rlogs, err := r.GraphQLAPI.GetLogs(ctx, crit)
+	if err != nil {
+		return nil, err
+	}
+
+	// logs are linked to their transactions, so they are taken from the transactions they are in
+	logs := make([]*model.Log, 0, len(rlogs))
+	transactions := map[libcommon.Hash]*model.Transaction{}
+	for _, rlog := range rlogs {
+		trans, ok := transactions[rlog.TxHash]
+		if !ok {
+			res, err := r.GraphQLAPI.GetTransactionDetails(ctx, rlog.TxHash)
+			if err != nil {
+				return nil, err
+			}
+			var found []*model.Transaction
+			if res != nil {
+				found = convertBlock(res).Transactions
+			}
+			if len(found) == 0 {
+				return nil, fmt.Errorf("transaction %x of log is not found", rlog.TxHash)
+			}
+			trans = found[0]
+			transactions[rlog.TxHash] = trans
+		}
+		for _, tlog := range trans.Logs {
+			if tlog.Index == int(rlog.Index) {
+				logs = append(logs, tlog)
+				break
+			}
+		}
+	}
+	return logs, ctx.Err()
 }
 
 // GasPrice is the resolver for the gasPrice field.
 func (r *queryResolver) GasPrice(ctx context.Context) (string, error) {
-	panic(fmt.Errorf("not implemented: GasPrice - gasPrice"))
+	price, err := r.GraphQLAPI.GasPrice(ctx)
+	if err != nil {
+		return "", err
+	}
+	return price.String(), nil
 }
 
 // MaxPriorityFeePerGas is the resolver for the maxPriorityFeePerGas field.
 func (r *queryResolver) MaxPriorityFeePerGas(ctx context.Context) (string, error) {
-	panic(fmt.Errorf("not implemented: MaxPriorityFeePerGas - maxPriorityFeePerGas"))
+	tip, err := r.GraphQLAPI.MaxPriorityFeePerGas(ctx)
+	if err != nil {
+		return "", err
+	}
+	return tip.String(), nil
 }
 
 // Syncing is the resolver for the syncing field.
 func (r *queryResolver) Syncing(ctx context.Context) (*model.SyncState, error) {
-	panic(fmt.Errorf("not implemented: Syncing - syncing"))
+	res, err := r.GraphQLAPI.Syncing(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// not syncing is reported as false
+	progress, ok := res.(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	return &model.SyncState{
+		CurrentBlock: *convertDataToUint64P(progress, "currentBlock"),
+		HighestBlock: *convertDataToUint64P(progress, "highestBlock"),
+	}, nil
 }
 
 // ChainID is the resolver for the chainID field.
@@ -220,11 +327,23 @@ func (r *queryResolver) ChainID(ctx context.Context) (string, error) {
 	return "0x" + strconv.FormatUint(chainID.Uint64(), 16), err
 }
 
+// Account returns AccountResolver implementation.
+func (r *Resolver) Account() AccountResolver { return &accountResolver{r} }
+
+// Block returns BlockResolver implementation.
+func (r *Resolver) Block() BlockResolver { return &blockResolver{r} }
+
 // Mutation returns MutationResolver implementation.
 func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
 
+// Pending returns PendingResolver implementation.
+func (r *Resolver) Pending() PendingResolver { return &pendingResolver{r} }
+
 // Query returns QueryResolver implementation.
 func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
 
+type accountResolver struct{ *Resolver }
+type blockResolver struct{ *Resolver }
 type mutationResolver struct{ *Resolver }
+type pendingResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"time"
 
 	"github.com/erigontech/erigon-lib/common"
 	"github.com/erigontech/erigon/cmd/rpcdaemon/cli"
@@ -34,32 +33,15 @@ func main() {
 		defer db.Close()
 		defer engine.Close()
 
-		var seqRPCService *rpc.Client
-		var historicalRPCService *rpc.Client
-
-		// Setup sequencer and hsistorical RPC relay services
-		if cfg.RollupSequencerHTTP != "" {
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			client, err := rpc.DialContext(ctx, cfg.RollupSequencerHTTP, logger)
-			cancel()
-			if err != nil {
-				logger.Error(err.Error())
-				return nil
-			}
-			seqRPCService = client
-		}
-		if cfg.RollupHistoricalRPC != "" {
-			ctx, cancel := context.WithTimeout(context.Background(), cfg.RollupHistoricalRPCTimeout)
-			client, err := rpc.DialContext(ctx, cfg.RollupHistoricalRPC, logger)
-			cancel()
-			if err != nil {
-				logger.Error(err.Error())
-				return nil
-			}
-			historicalRPCService = client
+		// Setup sequencer and historical RPC relay services
+		rollupClients, err := jsonrpc.DialRollupClients(cfg.RollupSequencerHTTP, cfg.RollupHistoricalRPC, cfg.RollupHistoricalRPCTimeout, cfg.RollupArchiveRPC, logger)
+		if err != nil {
+			logger.Error(err.Error())
+			return nil
 		}
+		defer rollupClients.Close()
 
-		apiList := jsonrpc.APIList(db, backend, txPool, mining, ff, stateCache, blockReader, agg, cfg, engine, seqRPCService, historicalRPCService, logger)
+		apiList := jsonrpc.APIList(db, backend, txPool, mining, ff, stateCache, blockReader, agg, cfg, engine, rollupClients.Sequencer, rollupClients.Historical, rollupClients.Archive, logger)
 		rpc.PreAllocateRPCMetricLabels(apiList)
 		if err := cli.StartRpcServer(ctx, cfg, apiList, logger); err != nil {
 			logger.Error(err.Error())
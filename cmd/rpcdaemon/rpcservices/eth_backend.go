@@ -246,6 +246,9 @@ func (back *RemoteBackend) SubscribeLogs(ctx context.Context, onNewLogs func(rep
 func (back *RemoteBackend) TxnLookup(ctx context.Context, tx kv.Getter, txnHash common.Hash) (uint64, bool, error) {
 	return back.blockReader.TxnLookup(ctx, tx, txnHash)
 }
+func (back *RemoteBackend) TxnLookupWithIndex(ctx context.Context, tx kv.Getter, txnHash common.Hash) (uint64, int, bool, error) {
+	return back.blockReader.TxnLookupWithIndex(ctx, tx, txnHash)
+}
 func (back *RemoteBackend) HasSenders(ctx context.Context, tx kv.Getter, hash common.Hash, blockNum uint64) (bool, error) {
 	panic("HasSenders is low-level method, don't use it in RPCDaemon")
 }
@@ -25,6 +25,7 @@ type SentinelCliCfg struct {
 	ServerAddr     string `json:"server_addr"`
 	ServerProtocol string `json:"server_protocol"`
 	ServerTcpPort  uint   `json:"server_tcp_port"`
+	AdminAddr      string `json:"admin_addr"`
 	LogLvl         uint   `json:"log_level"`
 	NoDiscovery    bool   `json:"no_discovery"`
 	LocalDiscovery bool   `json:"local_discovery"`
@@ -50,6 +51,7 @@ func SetupSentinelCli(ctx *cli.Context) (*SentinelCliCfg, error) {
 	}
 	cfg.ServerAddr = fmt.Sprintf("%s:%d", ctx.String(sentinelflags.SentinelServerAddr.Name), ctx.Int(sentinelflags.SentinelServerPort.Name))
 	cfg.ServerProtocol = "tcp"
+	cfg.AdminAddr = ctx.String(sentinelflags.SentinelAdminAddrFlag.Name)
 
 	cfg.Port = uint(ctx.Int(sentinelflags.SentinelDiscoveryPort.Name))
 	cfg.Addr = ctx.String(sentinelflags.SentinelDiscoveryAddr.Name)
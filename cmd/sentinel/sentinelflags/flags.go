@@ -18,6 +18,7 @@ var CliFlags = []cli.Flag{
 	&BeaconConfigFlag,
 	&GenesisSSZFlag,
 	&SentinelStaticPeersFlag,
+	&SentinelAdminAddrFlag,
 }
 
 var (
@@ -71,6 +72,11 @@ var (
 		Usage: "Path to genesis ssz",
 		Value: "",
 	}
+	SentinelAdminAddrFlag = cli.StringFlag{
+		Name:  "sentinel.admin.addr",
+		Usage: "sets the address of the sentinel dashboard (gossip topic stats, peer scores) and peer ban/unban endpoint, disabled if empty",
+		Value: "",
+	}
 	SentinelStaticPeersFlag = cli.StringFlag{
 		Name:  "sentinel.staticpeers",
 		Usage: "connect to comma-separated Consensus static peers",
@@ -50,7 +50,8 @@ type TxTask struct {
 	TraceFroms         map[libcommon.Address]struct{}
 	TraceTos           map[libcommon.Address]struct{}
 
-	UsedGas uint64
+	UsedGas     uint64
+	ContractGas map[libcommon.Address]uint64 // gas used by the code of the contracts, if metered, see gasmeter.HotContracts
 }
 
 // TxTaskQueue non-thread-safe priority-queue
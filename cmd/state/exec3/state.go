@@ -22,6 +22,7 @@ import (
 	"github.com/erigontech/erigon/core/types"
 	"github.com/erigontech/erigon/core/vm"
 	"github.com/erigontech/erigon/core/vm/evmtypes"
+	"github.com/erigontech/erigon/eth/gasmeter"
 	"github.com/erigontech/erigon/rlp"
 	"github.com/erigontech/erigon/turbo/services"
 )
@@ -46,6 +47,7 @@ type Worker struct {
 	chain    ChainReader
 
 	callTracer  *CallTracer
+	gasMeter    *gasmeter.GasMeter // nil unless hot contracts are metered
 	taskGasPool *core.GasPool
 
 	evm *vm.EVM
@@ -82,6 +84,9 @@ func NewWorker(lock sync.Locker, ctx context.Context, background bool, chainDb k
 	}
 
 	w.ibs = state.New(w.stateReader)
+	if gasmeter.Hot() != nil {
+		w.gasMeter = gasmeter.New(w.callTracer)
+	}
 
 	return w
 }
@@ -198,6 +203,10 @@ func (rw *Worker) RunTxTaskNoLock(txTask *exec22.TxTask) {
 		rw.callTracer.Reset()
 
 		vmConfig := vm.Config{Debug: true, Tracer: rw.callTracer, SkipAnalysis: txTask.SkipAnalysis}
+		if rw.gasMeter != nil {
+			rw.gasMeter.Reset()
+			vmConfig.Tracer = rw.gasMeter
+		}
 		ibs.SetTxContext(txHash, txTask.BlockHash, txTask.TxIndex)
 		msg := txTask.TxAsMessage
 
@@ -221,6 +230,9 @@ func (rw *Worker) RunTxTaskNoLock(txTask *exec22.TxTask) {
 			txTask.Logs = ibs.GetLogs(txHash)
 			txTask.TraceFroms = rw.callTracer.Froms()
 			txTask.TraceTos = rw.callTracer.Tos()
+			if rw.gasMeter != nil {
+				txTask.ContractGas = rw.gasMeter.Contracts()
+			}
 		}
 
 	}
@@ -42,6 +42,7 @@ import (
 	libkzg "github.com/erigontech/erigon-lib/crypto/kzg"
 	"github.com/erigontech/erigon-lib/direct"
 	downloadercfg2 "github.com/erigontech/erigon-lib/downloader/downloadercfg"
+	"github.com/erigontech/erigon-lib/seg"
 	"github.com/erigontech/erigon-lib/txpool/txpoolcfg"
 
 	"github.com/erigontech/erigon/cl/clparams"
@@ -51,6 +52,7 @@ import (
 	"github.com/erigontech/erigon/common/paths"
 	"github.com/erigontech/erigon/consensus/ethash/ethashcfg"
 	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/core/vm"
 	"github.com/erigontech/erigon/crypto"
 	"github.com/erigontech/erigon/eth/ethconfig"
 	"github.com/erigontech/erigon/eth/gasprice/gaspricecfg"
@@ -300,6 +302,21 @@ var (
 		Usage: "Time interval to recreate the block being mined",
 		Value: ethconfig.Defaults.Miner.Recommit,
 	}
+	MinerPayloadHistoryFlag = cli.DurationFlag{
+		Name:  "miner.payloadhistory",
+		Usage: "Keep payload attributes received by engine_forkchoiceUpdated and outcomes of block building for given period (available via debug_getPayloadAttributes). 0 - disabled",
+		Value: 0,
+	}
+	BuilderDeadlineFlag = cli.DurationFlag{
+		Name:  "builder.deadline",
+		Usage: "Stop packing transactions into a payload after given time since engine_forkchoiceUpdated, the payload gets the best transactions by then. 0 - on Optimism chains until the payload timestamp (block time)",
+		Value: 0,
+	}
+	BuilderRemoteFlag = cli.StringFlag{
+		Name:  "builder.remote",
+		Usage: "Build payloads of engine_forkchoiceUpdated in a builder process (cmd/builder) listening on given gRPC address, instead of the node, so building can't starve sync and RPC. Example: 127.0.0.1:9095",
+		Value: "",
+	}
 	MinerNoVerfiyFlag = cli.BoolFlag{
 		Name:  "miner.noverify",
 		Usage: "Disable remote sealing verification",
@@ -328,6 +345,20 @@ var (
 		Usage: "Reporting URL of a ethstats service (nodename:secret@host:port)",
 		Value: "",
 	}
+	ExportURLFlag = cli.StringFlag{
+		Name:  "export.url",
+		Usage: "Publish canonical block headers, receipts and reorg markers to Kafka REST proxy (http(s)://host:port) or NATS server (nats://[user:pass@]host:port)",
+		Value: "",
+	}
+	ExportTopicFlag = cli.StringFlag{
+		Name:  "export.topic",
+		Usage: "Kafka topic or NATS subject of exported chain events. Also identifies resume offset stored in the db",
+		Value: "erigon.chain",
+	}
+	ExportResumeFlag = cli.StringFlag{
+		Name:  "export.resume",
+		Usage: "Resume token of an exported event (its \"token\" field, number:hash of the block). Events after it are published again, e.g. to rebuild an indexer from the last event it processed",
+	}
 	FakePoWFlag = cli.BoolFlag{
 		Name:  "fakepow",
 		Usage: "Disables proof-of-work verification",
@@ -425,6 +456,26 @@ var (
 		Usage: "Maximum number of requests in a batch",
 		Value: 100,
 	}
+	RpcBatchResponseLimit = cli.IntFlag{
+		Name:  "rpc.batch.responselimit",
+		Usage: "Maximum size of a batch response in bytes, the requests after the limit get an error instead of the result. 0 - no limit",
+		Value: 0,
+	}
+	RpcMethodConcurrency = cli.StringFlag{
+		Name:  "rpc.methods.concurrency",
+		Usage: "Maximum number of concurrent calls of a method over all connections, the calls above it get an error. Example: eth_getLogs=8,trace_filter=2",
+		Value: "",
+	}
+	RpcMethodGasCap = cli.StringFlag{
+		Name:  "rpc.methods.gascap",
+		Usage: "Gas cap of a method executing calls, lower than --rpc.gascap. Example: eth_estimateGas=25000000,debug_traceCall=10000000",
+		Value: "",
+	}
+	RpcMethodResultLimit = cli.StringFlag{
+		Name:  "rpc.methods.resultlimit",
+		Usage: "Maximum size of the result of a method in bytes, a larger result is replaced by an error. Example: eth_getLogs=10000000,trace_filter=50000000",
+		Value: "",
+	}
 	RpcReturnDataLimit = cli.IntFlag{
 		Name:  "rpc.returndata.limit",
 		Usage: "Maximum number of bytes returned from eth_call or similar invocations",
@@ -539,6 +590,10 @@ var (
 		Name:  "rpc.allow-unprotected-txs",
 		Usage: "Allow for unprotected (non-EIP155 signed) transactions to be submitted via RPC",
 	}
+	RpcTxnNotIndexedErrorFlag = cli.BoolFlag{
+		Name:  "rpc.txn-not-indexed-error",
+		Usage: "Fail lookups of unknown transactions with \"transaction not indexed\" instead of returning null, while --prune.t.before leaves non-frozen blocks not indexed by TxLookup",
+	}
 	// Careful! Because we must rewind the hash state
 	// and re-compute the state trie, the further back in time the request, the more
 	// computationally intensive the operation becomes.
@@ -548,6 +603,11 @@ var (
 		Usage: "Max GetProof rewind block count",
 		Value: 100_000,
 	}
+	CodeAnalysisCacheFlag = cli.StringFlag{
+		Name:  "vm.code-analysis-cache",
+		Value: vm.DefaultCodeAnalysisCacheSize.String(),
+		Usage: "Amount of memory for the JUMPDEST analyses of contract codes, shared by execution, mining and RPC calls, so the hot contracts are not analysed by each transaction calling them. Set 0 to disable",
+	}
 	StateCacheFlag = cli.StringFlag{
 		Name:  "state.cache",
 		Value: "0MB",
@@ -686,6 +746,20 @@ var (
 		Usage:   "HTTP endpoint for the sequencer mempool",
 		EnvVars: []string{"ROLLUP_SEQUENCER_HTTP_ENDPOINT"},
 	}
+	RollupSequencerRetriesFlag = cli.IntFlag{
+		Name:  "rollup.sequencerretries",
+		Usage: "How many times a transaction is forwarded to --rollup.sequencerhttp again when the sequencer can't be reached, transactions rejected by the sequencer are not retried",
+		Value: rpccfg.DefaultSequencerRetries,
+	}
+	RollupSequencerRetryBackoffFlag = cli.DurationFlag{
+		Name:  "rollup.sequencerretrybackoff",
+		Usage: "Delay before the first retry of forwarding a transaction to --rollup.sequencerhttp, doubled by each next retry",
+		Value: rpccfg.DefaultSequencerRetryBackoff,
+	}
+	RollupSequencerLocalTxPoolFlag = cli.BoolFlag{
+		Name:  "rollup.sequencerlocaltxpool",
+		Usage: "Also add the transactions forwarded to --rollup.sequencerhttp to the local txpool, so txpool_* and pending queries of the node see them",
+	}
 	RollupHistoricalRPCFlag = cli.StringFlag{
 		Name:    "rollup.historicalrpc",
 		Usage:   "RPC endpoint for historical data.",
@@ -696,11 +770,122 @@ var (
 		Usage: "Timeout for historical RPC requests.",
 		Value: "5s",
 	}
+	RollupArchiveRPCFlag = cli.StringFlag{
+		Name:    "rollup.archiverpc",
+		Usage:   "RPC endpoint of archive node, eth_call and eth_estimateGas at post-Bedrock blocks with locally pruned state history are forwarded to it",
+		EnvVars: []string{"ROLLUP_ARCHIVE_RPC_ENDPOINT"},
+	}
+	RollupArchiveRPCCacheSizeFlag = cli.IntFlag{
+		Name:  "rollup.archiverpccache",
+		Usage: "Amount of results of requests forwarded to --rollup.archiverpc kept in cache, 0 - no cache",
+		Value: 4096,
+	}
+	RollupProbeFlag = cli.BoolFlag{
+		Name:  "rollup.probe",
+		Usage: "Probe derivation health (L2 unsafe, safe and finalized heights) of --rollup.opnoderpc or --rollup.sequencerhttp, export it by metrics and rollup_syncStatus RPC",
+	}
+	RollupOpNodeRPCFlag = cli.StringFlag{
+		Name:    "rollup.opnoderpc",
+		Usage:   "RPC endpoint of op-node, queried by optimism_syncStatus when --rollup.probe is enabled",
+		EnvVars: []string{"ROLLUP_OP_NODE_RPC_ENDPOINT"},
+	}
+	RollupProbeIntervalFlag = cli.DurationFlag{
+		Name:  "rollup.probeinterval",
+		Usage: "How often derivation health is probed and the L1 view of --rollup.l1view is updated, must be positive",
+		Value: ethconfig.Defaults.RollupProbeInterval,
+	}
+	RollupL1RPCFlag = cli.StringFlag{
+		Name:    "rollup.l1rpc",
+		Usage:   "RPC endpoint of L1 execution node, shared by modules which read L1 headers and receipts",
+		EnvVars: []string{"ROLLUP_L1_RPC_ENDPOINT"},
+	}
+	RollupL1BeaconRPCFlag = cli.StringFlag{
+		Name:    "rollup.l1beacon",
+		Usage:   "Beacon API endpoint of L1 consensus node, blobs are read from it. Requires --rollup.l1rpc",
+		EnvVars: []string{"ROLLUP_L1_BEACON_ENDPOINT"},
+	}
+	RollupL1CacheSizeFlag = cli.IntFlag{
+		Name:  "rollup.l1cache",
+		Usage: "Amount of L1 blocks whose headers and receipts read from --rollup.l1rpc are kept in cache",
+		Value: ethconfig.Defaults.RollupL1CacheSize,
+	}
+	RollupL1ViewFlag = cli.BoolFlag{
+		Name:  "rollup.l1view",
+		Usage: "Follow L1 headers over --rollup.l1rpc next to L2, rollup status RPCs check L1 origins of L2 blocks against them. Enables rollup_syncStatus without op-node and the sequencer",
+	}
+	RollupL1ViewContractsFlag = cli.StringFlag{
+		Name:  "rollup.l1view.contracts",
+		Usage: "Comma separated L1 contracts whose logs are kept by --rollup.l1view, e.g. OptimismPortal and SystemConfig",
+	}
+	RollupL1ViewDepthFlag = cli.IntFlag{
+		Name:  "rollup.l1view.depth",
+		Usage: "Amount of last L1 blocks kept by --rollup.l1view",
+		Value: ethconfig.Defaults.RollupL1ViewDepth,
+	}
 	RollupHaltOnIncompatibleProtocolVersionFlag = cli.StringFlag{
 		Name:  "rollup.halt",
 		Usage: "Opt-in option to halt on incompatible protocol version requirements of the given level (major/minor/patch/none), as signaled through the Engine API by the rollup node",
 	}
 
+	// Backup flags
+	BackupDirFlag = flags.DirectoryFlag{
+		Name:  "backup.dir",
+		Usage: "Directory for consistent copies of chaindata taken while the node is running, enables admin_backup RPC",
+	}
+	BackupIntervalFlag = cli.DurationFlag{
+		Name:  "backup.interval",
+		Usage: "How often backup is taken, 0 - only on demand by admin_backup",
+		Value: ethconfig.Defaults.Backup.Interval,
+	}
+	BackupKeepFlag = cli.IntFlag{
+		Name:  "backup.keep",
+		Usage: "How many latest backups are kept locally and on --backup.remote, 0 - all",
+		Value: ethconfig.Defaults.Backup.Keep,
+	}
+	BackupRemoteFlag = cli.StringFlag{
+		Name:  "backup.remote",
+		Usage: "rclone remote which backups are uploaded to, for example S3-compatible storage: 's3:bucket/node1'. Requires rclone in PATH",
+	}
+	BackupCompactFlag = cli.BoolFlag{
+		Name:  "backup.compact",
+		Usage: "Omit free pages from backups: smaller backups, slower copying",
+	}
+	BackupVerifyFlag = cli.BoolFlag{
+		Name:  "backup.verify",
+		Usage: "Walk every table of the copy before accepting the backup",
+		Value: ethconfig.Defaults.Backup.Verify,
+	}
+
+	// Sequencer leadership flags
+	SequencerLockFlag = cli.StringFlag{
+		Name:  "sequencer.lock",
+		Usage: "Lease file shared by sequencer instances of the chain (e.g. on shared volume): only its holder builds payloads, engine_forkchoiceUpdated with attributes fails on other instances",
+	}
+	SequencerLockTTLFlag = cli.DurationFlag{
+		Name:  "sequencer.lock.ttl",
+		Usage: "How long the lease is valid without renewal, other instance takes over at most this long after the holder stopped",
+		Value: ethconfig.Defaults.SequencerLock.TTL,
+	}
+	SequencerLockIDFlag = cli.StringFlag{
+		Name:  "sequencer.lock.id",
+		Usage: "ID of this instance in the lease, default - hostname and pid",
+	}
+
+	// Engine API flags
+	EngineFcuTimeoutFlag = cli.DurationFlag{
+		Name:  "engine.fcu-timeout",
+		Usage: "How long engine_forkchoiceUpdated waits for its outcome before answering SYNCING (or an error on Optimism). 0 - timeout of the request, 5s on Optimism",
+		Value: 0,
+	}
+	EngineFcuBusyRetryFlag = cli.BoolFlag{
+		Name:  "engine.fcu-busy-retry",
+		Usage: "Wait for the previous engine_forkchoiceUpdated to finish (up to --engine.fcu-timeout) instead of answering immediately that execution service is busy",
+	}
+	EngineFcuAsyncFlag = cli.BoolFlag{
+		Name:  "engine.fcu-async",
+		Usage: "Optimism: answer SYNCING when engine_forkchoiceUpdated takes too long or execution service is busy, instead of an error. Only for op-node versions handling SYNCING as asynchronous forkchoiceUpdated",
+	}
+
 	// Metrics flags
 	MetricsEnabledFlag = cli.BoolFlag{
 		Name:  "metrics",
@@ -747,10 +932,24 @@ var (
 		Name:  ethconfig.FlagSnapKeepBlocks,
 		Usage: "Keep ancient blocks in db (useful for debug)",
 	}
+	SnapFreezeDistanceFlag = cli.Uint64Flag{
+		Name:  ethconfig.FlagSnapFreezeDistance,
+		Usage: "Amount of recent blocks (headers, bodies, transactions) to keep in db before freezing them into snapshots. Sequencers may keep bigger hot window, verifiers may freeze aggressively. At least 1024",
+		Value: params.FullImmutabilityThreshold,
+	}
+	SnapBorFreezeDistanceFlag = cli.Uint64Flag{
+		Name:  ethconfig.FlagSnapBorFreezeDistance,
+		Usage: "Amount of recent blocks of bor events and spans to keep in db before freezing them into snapshots. At least 1024",
+		Value: params.FullImmutabilityThreshold,
+	}
 	SnapStopFlag = cli.BoolFlag{
 		Name:  ethconfig.FlagSnapStop,
 		Usage: "Workaround to stop producing new snapshots, if you meet some snapshots-related critical bug. It will stop move historical data from DB to new immutable snapshots. DB will grow and may slightly slow-down - and removing this flag in future will not fix this effect (db size will not greatly reduce).",
 	}
+	SnapEncryptionKeyFileFlag = cli.PathFlag{
+		Name:  "snap.encryption.keyfile",
+		Usage: "File with hex-encoded 32-byte master key. New block snapshot segments are encrypted at rest with AES-256-GCM, encrypted ones are decrypted in memory when opened. Requires --no-downloader: download snapshots first, then encrypt them by `erigon snapshots encrypt`",
+	}
 	TorrentVerbosityFlag = cli.IntFlag{
 		Name:  "torrent.verbosity",
 		Value: 2,
@@ -820,6 +1019,10 @@ var (
 		Usage: "Runtime limit of chaindata db size. You can change value of this flag at any time.",
 		Value: (12 * datasize.TB).String(),
 	}
+	DbReceiptsCompressionFlag = cli.BoolFlag{
+		Name:  "db.receipts.compression",
+		Usage: "Compress receipts and logs with zstd, using a dictionary trained on the receipts of the datadir (on the first start with enough of them). Receipts written before stay uncompressed. Can be turned off at any time, but the datadir can't be read by older versions anymore",
+	}
 	ForcePartialCommitFlag = cli.BoolFlag{
 		Name:  "force.partial.commit",
 		Usage: "Force data commit after each stage (or even do multiple commits per 1 stage - to save it's progress). Don't use this flag if node is synced. Meaning: readers (users of RPC) would like to see 'fully consistent' data (block is executed and all indices are updated). Erigon guarantee this level of data-consistency. But 1 downside: after restore node from backup - it can't save partial progress (non-committed progress will be lost at restart). This flag will be removed in future if we can find automatic way to detect corner-cases.",
@@ -859,6 +1062,11 @@ var (
 		Usage: "Ignore the bor block period and wait for 'blocksize' transactions (for testing purposes)",
 	}
 
+	WithoutBorFlag = cli.BoolFlag{
+		Name:  "bor.disable",
+		Usage: "For the chains without bor (e.g. OP chains): new chaindata doesn't get the bor tables. Fails on a bor chain",
+	}
+
 	WithHeimdallMilestones = cli.BoolFlag{
 		Name:  "bor.milestone",
 		Usage: "Enabling bor milestone processing",
@@ -941,6 +1149,10 @@ var (
 		Name:  "silkworm.sentry",
 		Usage: "Enable embedded Silkworm Sentry service",
 	}
+	SilkwormAuditFlag = cli.Uint64Flag{
+		Name:  "silkworm.audit",
+		Usage: "Every N-th block executed by Silkworm is executed by the Go EVM too, their state changes and receipts are compared. On a mismatch the block is unwound and Silkworm execution is off until restart (0 - no audit)",
+	}
 	SilkwormVerbosityFlag = cli.StringFlag{
 		Name:  "silkworm.verbosity",
 		Usage: "Set the log level for Silkworm console logs",
@@ -984,7 +1196,7 @@ var (
 
 	BeaconAPIFlag = cli.StringSliceFlag{
 		Name:  "beacon.api",
-		Usage: "Enable beacon API (avaiable endpoints: beacon, builder, config, debug, events, node, validator, lighthouse)",
+		Usage: "Enable beacon API (avaiable endpoints: beacon, builder, config, debug, events, node, validator, lighthouse, checkpoint)",
 	}
 	BeaconApiProtocolFlag = cli.StringFlag{
 		Name:  "beacon.api.protocol",
@@ -1016,11 +1228,30 @@ var (
 		Usage: "sets the port to listen for beacon api requests",
 		Value: 5555,
 	}
+	BeaconApiCheckpointRateLimitFlag = cli.Float64Flag{
+		Name:  "beacon.api.checkpoint.ratelimit",
+		Usage: "Finalized states served per second by the 'checkpoint' endpoint of the beacon api, 0 - unlimited. Serving a state takes longer than the default --beacon.api.write.timeout",
+		Value: 0.5,
+	}
 	RPCSlowFlag = cli.DurationFlag{
 		Name:  "rpc.slow",
 		Usage: "Print in logs RPC requests slower than given threshold: 100ms, 1s, 1m. Exluded methods: " + strings.Join(rpccfg.SlowLogBlackList, ","),
 		Value: 0,
 	}
+	RPCAccessLogFlag = cli.StringFlag{
+		Name:  "rpc.accesslog",
+		Usage: "Write JSON record of every served RPC call (method, params size, duration, error code, client, bytes out) to 'stdout' or to the file, rotated every 100MB",
+	}
+	RPCAccessLogSampleFlag = cli.Float64Flag{
+		Name:  "rpc.accesslog.sample",
+		Usage: "Fraction of successful calls written to --rpc.accesslog, failed calls are always written",
+		Value: 1,
+	}
+	RPCAccessLogSlowFlag = cli.DurationFlag{
+		Name:  "rpc.accesslog.slow",
+		Usage: "Calls slower than given threshold are written to --rpc.accesslog regardless of --rpc.accesslog.sample, 0 - disabled",
+		Value: 0,
+	}
 	CaplinBackfillingFlag = cli.BoolFlag{
 		Name:  "caplin.backfilling",
 		Usage: "sets whether backfilling is enabled for caplin",
@@ -1428,6 +1659,7 @@ func SetNodeConfig(ctx *cli.Context, cfg *nodecfg.Config, logger log.Logger) {
 	SetP2PConfig(ctx, &cfg.P2P, cfg.NodeName(), cfg.Dirs.DataDir, logger)
 
 	cfg.SentryLogPeerInfo = ctx.IsSet(SentryLogPeerInfoFlag.Name)
+	cfg.WithoutBor = ctx.Bool(WithoutBorFlag.Name)
 }
 
 func SetNodeConfigCobra(cmd *cobra.Command, cfg *nodecfg.Config) {
@@ -1682,6 +1914,9 @@ func setMiner(ctx *cli.Context, cfg *params.MiningConfig) {
 	if ctx.IsSet(MinerNoVerfiyFlag.Name) {
 		cfg.Noverify = ctx.Bool(MinerNoVerfiyFlag.Name)
 	}
+	cfg.PayloadHistoryRetention = ctx.Duration(MinerPayloadHistoryFlag.Name)
+	cfg.BuilderDeadline = ctx.Duration(BuilderDeadlineFlag.Name)
+	cfg.RemoteBuilder = ctx.String(BuilderRemoteFlag.Name)
 }
 
 func setWhitelist(ctx *cli.Context, cfg *ethconfig.Config) {
@@ -1721,6 +1956,7 @@ func setBeaconAPI(ctx *cli.Context, cfg *ethconfig.Config) error {
 	cfg.BeaconRouter.AllowedMethods = ctx.StringSlice(BeaconApiAllowMethodsFlag.Name)
 	cfg.BeaconRouter.AllowedOrigins = ctx.StringSlice(BeaconApiAllowOriginsFlag.Name)
 	cfg.BeaconRouter.AllowCredentials = ctx.Bool(BeaconApiAllowCredentialsFlag.Name)
+	cfg.BeaconRouter.CheckpointRateLimit = ctx.Float64(BeaconApiCheckpointRateLimitFlag.Name)
 	return nil
 }
 
@@ -1735,6 +1971,7 @@ func setCaplin(ctx *cli.Context, cfg *ethconfig.Config) {
 
 func setSilkworm(ctx *cli.Context, cfg *ethconfig.Config) {
 	cfg.SilkwormExecution = ctx.Bool(SilkwormExecutionFlag.Name)
+	cfg.Sync.SilkwormAudit = ctx.Uint64(SilkwormAuditFlag.Name)
 	cfg.SilkwormRpcDaemon = ctx.Bool(SilkwormRpcDaemonFlag.Name)
 	cfg.SilkwormSentry = ctx.Bool(SilkwormSentryFlag.Name)
 	cfg.SilkwormVerbosity = ctx.String(SilkwormVerbosityFlag.Name)
@@ -1797,6 +2034,12 @@ func SetEthConfig(ctx *cli.Context, nodeConfig *nodecfg.Config, cfg *ethconfig.C
 	cfg.SentinelAddr = ctx.String(SentinelAddrFlag.Name)
 	cfg.SentinelPort = ctx.Uint64(SentinelPortFlag.Name)
 	cfg.ForcePartialCommit = ctx.Bool(ForcePartialCommitFlag.Name)
+	cfg.ReceiptsCompression = ctx.Bool(DbReceiptsCompressionFlag.Name)
+	var codeAnalysisCache datasize.ByteSize
+	if err := codeAnalysisCache.UnmarshalText([]byte(ctx.String(CodeAnalysisCacheFlag.Name))); err != nil {
+		Fatalf("Invalid size provided in %s: %v", CodeAnalysisCacheFlag.Name, err)
+	}
+	vm.SetCodeAnalysisCacheSize(codeAnalysisCache)
 
 	chain := ctx.String(ChainFlag.Name) // mainnet by default
 	if ctx.IsSet(NetworkIdFlag.Name) {
@@ -1816,6 +2059,20 @@ func SetEthConfig(ctx *cli.Context, nodeConfig *nodecfg.Config, cfg *ethconfig.C
 	cfg.Dirs = nodeConfig.Dirs
 	cfg.Snapshot.KeepBlocks = ctx.Bool(SnapKeepBlocksFlag.Name)
 	cfg.Snapshot.Produce = !ctx.Bool(SnapStopFlag.Name)
+	keyProvider, err := SnapKeyProvider(ctx)
+	if err != nil {
+		Fatalf("%v", err)
+	}
+	cfg.Snapshot.KeyProvider = keyProvider
+	// downloader would find encrypted files corrupted (their hashes differ from published ones) and download them again
+	if ctx.String(SnapEncryptionKeyFileFlag.Name) != "" && !ctx.Bool(NoDownloaderFlag.Name) {
+		Fatalf("--%s requires --%s", SnapEncryptionKeyFileFlag.Name, NoDownloaderFlag.Name)
+	}
+	cfg.Snapshot.FreezeDistance = ctx.Uint64(SnapFreezeDistanceFlag.Name)
+	cfg.Snapshot.BorFreezeDistance = ctx.Uint64(SnapBorFreezeDistanceFlag.Name)
+	if err := cfg.Snapshot.CheckFreezeDistance(); err != nil {
+		Fatalf("%v", err)
+	}
 	cfg.Snapshot.NoDownloader = ctx.Bool(NoDownloaderFlag.Name)
 	cfg.Snapshot.Verify = ctx.Bool(DownloaderVerifyFlag.Name)
 	cfg.Snapshot.DownloaderAddr = strings.TrimSpace(ctx.String(DownloaderAddrFlag.Name))
@@ -1872,6 +2129,9 @@ func SetEthConfig(ctx *cli.Context, nodeConfig *nodecfg.Config, cfg *ethconfig.C
 	setCaplin(ctx, cfg)
 
 	cfg.Ethstats = ctx.String(EthStatsURLFlag.Name)
+	cfg.ExportURL = ctx.String(ExportURLFlag.Name)
+	cfg.ExportTopic = ctx.String(ExportTopicFlag.Name)
+	cfg.ExportResume = ctx.String(ExportResumeFlag.Name)
 
 	if ctx.IsSet(RPCGlobalGasCapFlag.Name) {
 		cfg.RPCGasCap = ctx.Uint64(RPCGlobalGasCapFlag.Name)
@@ -1902,6 +2162,58 @@ func SetEthConfig(ctx *cli.Context, nodeConfig *nodecfg.Config, cfg *ethconfig.C
 		cfg.RollupHistoricalRPC = ctx.String(RollupHistoricalRPCFlag.Name)
 	}
 	cfg.RollupHistoricalRPCTimeout = ctx.Duration(RollupHistoricalRPCTimeoutFlag.Name)
+	cfg.RollupArchiveRPC = ctx.String(RollupArchiveRPCFlag.Name)
+	cfg.RollupProbe = ctx.Bool(RollupProbeFlag.Name)
+	cfg.RollupOpNodeRPC = ctx.String(RollupOpNodeRPCFlag.Name)
+	cfg.RollupProbeInterval = ctx.Duration(RollupProbeIntervalFlag.Name)
+	if cfg.RollupProbeInterval <= 0 {
+		Fatalf("--%s must be positive, got %s", RollupProbeIntervalFlag.Name, cfg.RollupProbeInterval)
+	}
+	cfg.RollupL1RPC = ctx.String(RollupL1RPCFlag.Name)
+	cfg.RollupL1BeaconRPC = ctx.String(RollupL1BeaconRPCFlag.Name)
+	cfg.RollupL1CacheSize = ctx.Int(RollupL1CacheSizeFlag.Name)
+	if cfg.RollupL1BeaconRPC != "" && cfg.RollupL1RPC == "" {
+		Fatalf("--%s requires --%s", RollupL1BeaconRPCFlag.Name, RollupL1RPCFlag.Name)
+	}
+	cfg.RollupL1View = ctx.Bool(RollupL1ViewFlag.Name)
+	if cfg.RollupL1View && cfg.RollupL1RPC == "" {
+		Fatalf("--%s requires --%s", RollupL1ViewFlag.Name, RollupL1RPCFlag.Name)
+	}
+	for _, contract := range libcommon.CliString2Array(ctx.String(RollupL1ViewContractsFlag.Name)) {
+		if !libcommon.IsHexAddress(contract) {
+			Fatalf("Invalid address in --%s: %s", RollupL1ViewContractsFlag.Name, contract)
+		}
+		cfg.RollupL1ViewContracts = append(cfg.RollupL1ViewContracts, libcommon.HexToAddress(contract))
+	}
+	cfg.RollupL1ViewDepth = ctx.Int(RollupL1ViewDepthFlag.Name)
+	cfg.Backup = ethconfig.Backup{
+		Dir:      ctx.String(BackupDirFlag.Name),
+		Interval: ctx.Duration(BackupIntervalFlag.Name),
+		Keep:     ctx.Int(BackupKeepFlag.Name),
+		Remote:   ctx.String(BackupRemoteFlag.Name),
+		Compact:  ctx.Bool(BackupCompactFlag.Name),
+		Verify:   ctx.Bool(BackupVerifyFlag.Name),
+	}
+	if cfg.Backup.Dir == "" && (ctx.IsSet(BackupIntervalFlag.Name) || ctx.IsSet(BackupRemoteFlag.Name)) {
+		Fatalf("--%s and --%s require --%s", BackupIntervalFlag.Name, BackupRemoteFlag.Name, BackupDirFlag.Name)
+	}
+	cfg.SequencerLock = ethconfig.SequencerLock{
+		Path: ctx.String(SequencerLockFlag.Name),
+		TTL:  ctx.Duration(SequencerLockTTLFlag.Name),
+		ID:   ctx.String(SequencerLockIDFlag.Name),
+	}
+	if cfg.SequencerLock.Path != "" {
+		if cfg.SequencerLock.TTL <= 0 {
+			Fatalf("--%s must be positive", SequencerLockTTLFlag.Name)
+		}
+		if cfg.SequencerLock.ID == "" {
+			hostname, _ := os.Hostname()
+			cfg.SequencerLock.ID = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+		}
+	}
+	cfg.Forkchoice.Timeout = ctx.Duration(EngineFcuTimeoutFlag.Name)
+	cfg.Forkchoice.BusyRetry = ctx.Bool(EngineFcuBusyRetryFlag.Name)
+	cfg.Forkchoice.Async = ctx.Bool(EngineFcuAsyncFlag.Name)
 
 	// Override any default configs for hard coded networks.
 	switch chain {
@@ -2061,3 +2373,16 @@ func CobraFlags(cmd *cobra.Command, urfaveCliFlagsLists ...[]cli.Flag) {
 		}
 	}
 }
+
+// SnapKeyProvider - key provider of block snapshot segments, nil if --snap.encryption.keyfile isn't given
+func SnapKeyProvider(ctx *cli.Context) (seg.KeyProvider, error) {
+	keyFile := ctx.String(SnapEncryptionKeyFileFlag.Name)
+	if keyFile == "" {
+		return nil, nil
+	}
+	p, err := seg.NewStaticKeyProviderFromFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("--%s: %w", SnapEncryptionKeyFileFlag.Name, err)
+	}
+	return p, nil
+}
@@ -63,27 +63,20 @@ func ForRange(db kv.Tx, bucket string, from, to uint64, walker func(blockN uint6
 // RewindDataPlain generates rewind data for all plain buckets between the timestamp
 // timestapSrc is the current timestamp, and timestamp Dst is where we rewind
 func RewindData(db kv.Tx, timestampSrc, timestampDst uint64, changes *etl.Collector, quit <-chan struct{}) error {
-	if err := walkAndCollect(
-		changes.Collect,
-		db, kv.AccountChangeSet,
-		timestampDst+1, timestampSrc,
-		quit,
-	); err != nil {
+	if err := RewindBucketData(db, kv.AccountChangeSet, timestampSrc, timestampDst, changes, quit); err != nil {
 		return err
 	}
-
-	if err := walkAndCollect(
-		changes.Collect,
-		db, kv.StorageChangeSet,
-		timestampDst+1, timestampSrc,
-		quit,
-	); err != nil {
+	if err := RewindBucketData(db, kv.StorageChangeSet, timestampSrc, timestampDst, changes, quit); err != nil {
 		return err
 	}
-
 	return nil
 }
 
+// RewindBucketData - RewindData of one changeset bucket: kv.AccountChangeSet or kv.StorageChangeSet
+func RewindBucketData(db kv.Tx, bucket string, timestampSrc, timestampDst uint64, changes *etl.Collector, quit <-chan struct{}) error {
+	return walkAndCollect(changes.Collect, db, bucket, timestampDst+1, timestampSrc, quit)
+}
+
 func walkAndCollect(collectorFunc func([]byte, []byte) error, db kv.Tx, bucket string, timestampDst, timestampSrc uint64, quit <-chan struct{}) error {
 	return ForRange(db, bucket, timestampDst, timestampSrc+1, func(bl uint64, k, v []byte) error {
 		if err := common2.Stopped(quit); err != nil {
@@ -0,0 +1,150 @@
+package misc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/erigontech/erigon-lib/chain"
+	libcommon "github.com/erigontech/erigon-lib/common"
+
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/crypto"
+)
+
+// Network upgrade transactions: the deposits the rollup node appends to the deposits of the first block of
+// a hardfork, to deploy and activate the new versions of the L1Block and GasPriceOracle predeploys and the
+// EIP-4788 beacon roots contract. Granite and Holocene have none.
+// See https://specs.optimism.io/protocol/ecotone/derivation.html#network-upgrade-automation-transactions
+
+var ErrInvalidUpgradeTxs = errors.New("invalid network upgrade transactions")
+
+var (
+	l1BlockProxyAddress        = libcommon.HexToAddress("0x4200000000000000000000000000000000000015")
+	gasPriceOracleProxyAddress = libcommon.HexToAddress("0x420000000000000000000000000000000000000F")
+	l1InfoDepositerAddress     = libcommon.HexToAddress("0xdeaddeaddeaddeaddeaddeaddeaddeaddead0001")
+
+	ecotoneL1BlockDeployer        = libcommon.HexToAddress("0x4210000000000000000000000000000000000000")
+	ecotoneGasPriceOracleDeployer = libcommon.HexToAddress("0x4210000000000000000000000000000000000001")
+	fjordGasPriceOracleDeployer   = libcommon.HexToAddress("0x4210000000000000000000000000000000000002")
+	beaconRootsDeployer           = libcommon.HexToAddress("0x0B799C86a49DEeb90402691F1041aa3AF2d3C875")
+
+	// EIP-4788 contract creation code, deployed to 0x000F3df6D732807Ef1319fB7B8bB8522d0Beac02
+	beaconRootsCode, _ = hex.DecodeString("60618060095f395ff33373fffffffffffffffffffffffffffffffffffffffe14604d57602036146024575f5ffd5b5f35801560495762001fff810690815414603c575f5ffd5b62001fff01545f5260205ff35b5f5ffd5b62001fff42064281555f359062001fff015500")
+)
+
+// upgradeDeposit - expected fields of an upgrade transaction. data is nil for the deployments of the predeploy
+// implementations: their init code comes with the rollup node, only the deployer, the gas and the source hash
+// are checked, the resulting address is fixed by the deployer's nonce.
+type upgradeDeposit struct {
+	intent string
+	from   libcommon.Address
+	to     *libcommon.Address
+	gas    uint64
+	data   []byte
+}
+
+var ecotoneUpgradeDeposits = []upgradeDeposit{
+	{intent: "Ecotone: L1 Block Deployment", from: ecotoneL1BlockDeployer, gas: 375_000},
+	{intent: "Ecotone: Gas Price Oracle Deployment", from: ecotoneGasPriceOracleDeployer, gas: 1_000_000},
+	{intent: "Ecotone: L1 Block Proxy Update", to: &l1BlockProxyAddress, gas: 50_000,
+		data: upgradeToCalldata(crypto.CreateAddress(ecotoneL1BlockDeployer, 0))},
+	{intent: "Ecotone: Gas Price Oracle Proxy Update", to: &gasPriceOracleProxyAddress, gas: 50_000,
+		data: upgradeToCalldata(crypto.CreateAddress(ecotoneGasPriceOracleDeployer, 0))},
+	{intent: "Ecotone: Gas Price Oracle Set Ecotone", from: l1InfoDepositerAddress, to: &gasPriceOracleProxyAddress, gas: 80_000,
+		data: crypto.Keccak256([]byte("setEcotone()"))[:4]},
+	{intent: "Ecotone: beacon block roots contract deployment", from: beaconRootsDeployer, gas: 250_000,
+		data: beaconRootsCode},
+}
+
+var fjordUpgradeDeposits = []upgradeDeposit{
+	{intent: "Fjord: Gas Price Oracle Deployment", from: fjordGasPriceOracleDeployer, gas: 1_450_000},
+	{intent: "Fjord: Gas Price Oracle Proxy Update", to: &gasPriceOracleProxyAddress, gas: 50_000,
+		data: upgradeToCalldata(crypto.CreateAddress(fjordGasPriceOracleDeployer, 0))},
+	{intent: "Fjord: Gas Price Oracle Set Fjord", from: l1InfoDepositerAddress, to: &gasPriceOracleProxyAddress, gas: 90_000,
+		data: crypto.Keccak256([]byte("setFjord()"))[:4]},
+}
+
+func upgradeToCalldata(impl libcommon.Address) []byte {
+	data := make([]byte, 4+32)
+	copy(data, crypto.Keccak256([]byte("upgradeTo(address)"))[:4])
+	copy(data[4+12:], impl[:])
+	return data
+}
+
+// UpgradeDepositSource - source hash of an upgrade transaction: keccak256(bytes32(2) ++ keccak256(intent))
+func UpgradeDepositSource(intent string) libcommon.Hash {
+	var input [64]byte
+	input[31] = 2 // upgrade deposit domain
+	copy(input[32:], crypto.Keccak256([]byte(intent)))
+	return crypto.Keccak256Hash(input[:])
+}
+
+func upgradeDeposits(c *chain.Config, parentTime, time uint64) []upgradeDeposit {
+	if !c.IsOptimism() {
+		return nil
+	}
+	var deposits []upgradeDeposit
+	if c.IsEcotone(time) && !c.IsEcotone(parentTime) {
+		deposits = append(deposits, ecotoneUpgradeDeposits...)
+	}
+	if c.IsFjord(time) && !c.IsFjord(parentTime) {
+		deposits = append(deposits, fjordUpgradeDeposits...)
+	}
+	return deposits
+}
+
+// VerifyUpgradeTxs - checks that the first block of a hardfork (the parent is before it) has the upgrade
+// transactions of the hardfork as its last deposits, in the order the rollup node inserts them
+func VerifyUpgradeTxs(c *chain.Config, parentTime, time uint64, txs types.Transactions) error {
+	expected := upgradeDeposits(c, parentTime, time)
+	if len(expected) == 0 {
+		return nil
+	}
+	deposits := 0
+	for deposits < len(txs) && txs[deposits].Type() == types.DepositTxType {
+		deposits++
+	}
+	// the L1 attributes deposit comes first
+	if deposits < len(expected)+1 {
+		return fmt.Errorf("%w: %d expected, block has %d deposits", ErrInvalidUpgradeTxs, len(expected), deposits)
+	}
+	upgradeTxs := txs[deposits-len(expected) : deposits]
+	for i, want := range expected {
+		tx, ok := upgradeTxs[i].(*types.DepositTx)
+		if !ok {
+			return fmt.Errorf("%w: unexpected deposit type %T", ErrInvalidUpgradeTxs, upgradeTxs[i])
+		}
+		if err := want.verify(tx); err != nil {
+			return fmt.Errorf("%w: %q (tx %d): %w", ErrInvalidUpgradeTxs, want.intent, deposits-len(expected)+i, err)
+		}
+	}
+	return nil
+}
+
+func (want *upgradeDeposit) verify(tx *types.DepositTx) error {
+	if source := UpgradeDepositSource(want.intent); tx.SourceHash != source {
+		return fmt.Errorf("source hash %x, expected %x", tx.SourceHash, source)
+	}
+	if tx.From != want.from {
+		return fmt.Errorf("from %x, expected %x", tx.From, want.from)
+	}
+	if (tx.To == nil) != (want.to == nil) || (tx.To != nil && *tx.To != *want.to) {
+		return fmt.Errorf("to %v, expected %v", tx.To, want.to)
+	}
+	if tx.Gas != want.gas {
+		return fmt.Errorf("gas %d, expected %d", tx.Gas, want.gas)
+	}
+	if (tx.Mint != nil && !tx.Mint.IsZero()) || (tx.Value != nil && !tx.Value.IsZero()) || tx.IsSystemTransaction {
+		return errors.New("mint, value and system flag must be unset")
+	}
+	if want.data == nil {
+		if len(tx.Data) == 0 {
+			return errors.New("empty init code")
+		}
+	} else if !bytes.Equal(tx.Data, want.data) {
+		return fmt.Errorf("data %x, expected %x", tx.Data, want.data)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package misc
+
+import (
+	"math/big"
+	"slices"
+	"testing"
+
+	"github.com/erigontech/erigon-lib/chain"
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/crypto"
+	"github.com/erigontech/erigon/params"
+)
+
+func TestUpgradeDepositSource(t *testing.T) {
+	// from the Ecotone and Fjord specs
+	require.Equal(t, libcommon.HexToHash("0x877a6077205782ea15a6dc8699fa5ebcec5e0f4389f09cb8eda09488231346f8"), UpgradeDepositSource("Ecotone: L1 Block Deployment"))
+	require.Equal(t, libcommon.HexToHash("0x69b763c48478b9dc2f65ada09b3d92133ec592ea715ec65ad6e7f3dc519dc00c"), UpgradeDepositSource("Ecotone: beacon block roots contract deployment"))
+	require.Equal(t, libcommon.HexToHash("0xbac7bb0d5961cad209a345408b0280a0d4686b1b20665e1b0f9cdafd73b19b6b"), UpgradeDepositSource("Fjord: Gas Price Oracle Set Fjord"))
+
+	require.Equal(t, libcommon.HexToAddress("0x07dbe8500fc591d1852B76feE44d5a05e13097Ff"), crypto.CreateAddress(ecotoneL1BlockDeployer, 0))
+	require.Equal(t, libcommon.HexToAddress("0xa919894851548179A0750865e7974DA599C0Fac7"), crypto.CreateAddress(fjordGasPriceOracleDeployer, 0))
+	require.Equal(t, params.BeaconRootsAddress, crypto.CreateAddress(beaconRootsDeployer, 0))
+}
+
+// specUpgradeTx - an upgrade transaction with the values listed by the Ecotone and Fjord specs, so that the blocks
+// of the test don't come from the table they are verified against
+type specUpgradeTx struct {
+	source string
+	from   string
+	to     string // empty for a deployment
+	gas    uint64
+	data   string // empty for the init code of a predeploy, which comes with the rollup node
+}
+
+var (
+	ecotoneSpecTxs = []specUpgradeTx{
+		{source: "0x877a6077205782ea15a6dc8699fa5ebcec5e0f4389f09cb8eda09488231346f8", from: "0x4210000000000000000000000000000000000000", gas: 375_000},
+		{source: "0xa312b4510adf943510f05fcc8f15f86995a5066bd83ce11384688ae20e6ecf42", from: "0x4210000000000000000000000000000000000001", gas: 1_000_000},
+		{source: "0x18acb38c5ff1c238a7460ebc1b421fa49ec4874bdf1e0a530d234104e5e67dbc", to: "0x4200000000000000000000000000000000000015", gas: 50_000,
+			data: "3659cfe600000000000000000000000007dbe8500fc591d1852b76fee44d5a05e13097ff"},
+		{source: "0xee4f9385eceef498af0be7ec5862229f426dec41c8d42397c7257a5117d9230a", to: "0x420000000000000000000000000000000000000F", gas: 50_000,
+			data: "3659cfe6000000000000000000000000b528d11cc114e026f138fe568744c6d45ce6da7a"},
+		{source: "0x0c1cb38e99dbc9cbfab3bb80863380b0905290b37eb3d6ab18dc01c1f3e75f93", from: "0xDeaDDEaDDeAdDeAdDEAdDEaddeAddEAdDEAd0001", to: "0x420000000000000000000000000000000000000F", gas: 80_000,
+			data: "22b90ab3"},
+		{source: "0x69b763c48478b9dc2f65ada09b3d92133ec592ea715ec65ad6e7f3dc519dc00c", from: "0x0B799C86a49DEeb90402691F1041aa3AF2d3C875", gas: 250_000,
+			data: "60618060095f395ff33373fffffffffffffffffffffffffffffffffffffffe14604d57602036146024575f5ffd5b5f35801560495762001fff810690815414603c575f5ffd5b62001fff01545f5260205ff35b5f5ffd5b62001fff42064281555f359062001fff015500"},
+	}
+	fjordSpecTxs = []specUpgradeTx{
+		{source: "0x86122c533fdcb89b16d8713174625e44578a89751d96c098ec19ab40a51a8ea3", from: "0x4210000000000000000000000000000000000002", gas: 1_450_000},
+		{source: "0x1e6bb0c28bfab3dc9b36ffb0f721f00d6937f33577606325692db0965a7d58c6", to: "0x420000000000000000000000000000000000000F", gas: 50_000,
+			data: "3659cfe6000000000000000000000000a919894851548179a0750865e7974da599c0fac7"},
+		{source: "0xbac7bb0d5961cad209a345408b0280a0d4686b1b20665e1b0f9cdafd73b19b6b", from: "0xDeaDDEaDDeAdDeAdDEAdDEaddeAddEAdDEAd0001", to: "0x420000000000000000000000000000000000000F", gas: 90_000,
+			data: "8e98b106"},
+	}
+)
+
+// upgradeBlockTxs - transactions of a block: the L1 attributes deposit, the upgrade transactions, a user transaction
+func upgradeBlockTxs(upgrades ...[]specUpgradeTx) types.Transactions {
+	txs := types.Transactions{&types.DepositTx{From: l1InfoDepositerAddress, To: &l1BlockProxyAddress, Gas: 1_000_000, Data: []byte{0x44}}}
+	for _, specTxs := range upgrades {
+		for _, spec := range specTxs {
+			tx := &types.DepositTx{
+				SourceHash: libcommon.HexToHash(spec.source),
+				From:       libcommon.HexToAddress(spec.from),
+				Mint:       new(uint256.Int),
+				Value:      new(uint256.Int),
+				Gas:        spec.gas,
+				Data:       libcommon.FromHex(spec.data),
+			}
+			if spec.to != "" {
+				to := libcommon.HexToAddress(spec.to)
+				tx.To = &to
+			}
+			if spec.data == "" {
+				tx.Data = []byte{0x60, 0x80}
+			}
+			txs = append(txs, tx)
+		}
+	}
+	return append(txs, types.NewTransaction(0, libcommon.Address{1}, uint256.NewInt(1), 21_000, uint256.NewInt(1), nil))
+}
+
+func TestVerifyUpgradeTxs(t *testing.T) {
+	const forkTime = 1000
+	cfg := &chain.Config{
+		ChainID:     big.NewInt(params.OPMainnetChainID),
+		Optimism:    &chain.OptimismConfig{},
+		EcotoneTime: big.NewInt(forkTime),
+		FjordTime:   big.NewInt(forkTime + 10),
+	}
+	ecotoneTxs, fjordTxs := upgradeBlockTxs(ecotoneSpecTxs), upgradeBlockTxs(fjordSpecTxs)
+
+	require.NoError(t, VerifyUpgradeTxs(cfg, forkTime-2, forkTime, ecotoneTxs))
+	require.NoError(t, VerifyUpgradeTxs(cfg, forkTime+8, forkTime+10, fjordTxs))
+	// not an activation block
+	require.NoError(t, VerifyUpgradeTxs(cfg, forkTime, forkTime+2, upgradeBlockTxs()))
+	require.ErrorIs(t, VerifyUpgradeTxs(cfg, forkTime-2, forkTime, upgradeBlockTxs()), ErrInvalidUpgradeTxs)
+	require.ErrorIs(t, VerifyUpgradeTxs(cfg, forkTime-2, forkTime, fjordTxs), ErrInvalidUpgradeTxs)
+
+	// a missing upgrade transaction: the beacon roots deployment, Set Ecotone
+	require.ErrorIs(t, VerifyUpgradeTxs(cfg, forkTime-2, forkTime, upgradeBlockTxs(ecotoneSpecTxs[:5])), ErrInvalidUpgradeTxs)
+	require.ErrorIs(t, VerifyUpgradeTxs(cfg, forkTime-2, forkTime, upgradeBlockTxs(append(slices.Clone(ecotoneSpecTxs[:4]), ecotoneSpecTxs[5]))), ErrInvalidUpgradeTxs)
+	// out of order
+	swapped := slices.Clone(fjordSpecTxs)
+	swapped[1], swapped[2] = swapped[2], swapped[1]
+	require.ErrorIs(t, VerifyUpgradeTxs(cfg, forkTime+8, forkTime+10, upgradeBlockTxs(swapped)), ErrInvalidUpgradeTxs)
+	// followed by another deposit
+	followed := upgradeBlockTxs(fjordSpecTxs)
+	followed = append(followed[:len(followed)-1], &types.DepositTx{From: libcommon.Address{1}, To: &libcommon.Address{2}, Gas: 21_000})
+	require.ErrorIs(t, VerifyUpgradeTxs(cfg, forkTime+8, forkTime+10, followed), ErrInvalidUpgradeTxs)
+
+	// an altered upgrade transaction
+	for name, alter := range map[string]func(tx *types.DepositTx){
+		"gas":         func(tx *types.DepositTx) { tx.Gas++ },
+		"source hash": func(tx *types.DepositTx) { tx.SourceHash[0]++ },
+		"from":        func(tx *types.DepositTx) { tx.From[19]++ },
+		"to":          func(tx *types.DepositTx) { tx.To = &libcommon.Address{1} },
+		"data":        func(tx *types.DepositTx) { tx.Data[len(tx.Data)-1]++ },
+		"mint":        func(tx *types.DepositTx) { tx.Mint = uint256.NewInt(1) },
+		"system":      func(tx *types.DepositTx) { tx.IsSystemTransaction = true },
+	} {
+		for i, spec := range fjordSpecTxs {
+			if name == "data" && spec.data == "" { // any init code
+				continue
+			}
+			altered := upgradeBlockTxs(fjordSpecTxs)
+			alter(altered[1+i].(*types.DepositTx))
+			require.ErrorIs(t, VerifyUpgradeTxs(cfg, forkTime+8, forkTime+10, altered), ErrInvalidUpgradeTxs, "%s of %d", name, i)
+		}
+	}
+	altered := upgradeBlockTxs(ecotoneSpecTxs)
+	altered[1].(*types.DepositTx).Data = nil // no init code
+	require.ErrorIs(t, VerifyUpgradeTxs(cfg, forkTime-2, forkTime, altered), ErrInvalidUpgradeTxs)
+
+	// both at once, Ecotone's first
+	cfg.FjordTime = big.NewInt(forkTime)
+	require.NoError(t, VerifyUpgradeTxs(cfg, forkTime-2, forkTime, upgradeBlockTxs(ecotoneSpecTxs, fjordSpecTxs)))
+	require.ErrorIs(t, VerifyUpgradeTxs(cfg, forkTime-2, forkTime, upgradeBlockTxs(fjordSpecTxs, ecotoneSpecTxs)), ErrInvalidUpgradeTxs)
+
+	cfg.Optimism = nil
+	require.NoError(t, VerifyUpgradeTxs(cfg, forkTime-2, forkTime, upgradeBlockTxs()))
+}
@@ -0,0 +1,59 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/kv"
+
+	"github.com/erigontech/erigon/core/rawdb"
+	"github.com/erigontech/erigon/core/types"
+)
+
+// InvalidTxError - a transaction of the block could not be applied
+type InvalidTxError struct {
+	Index    int
+	BlockNum uint64
+	Hash     libcommon.Hash
+	Err      error
+}
+
+func (e *InvalidTxError) Error() string {
+	return fmt.Sprintf("could not apply tx %d from block %d [%v]: %v", e.Index, e.BlockNum, e.Hash.Hex(), e.Err)
+}
+
+func (e *InvalidTxError) Unwrap() error { return e.Err }
+
+// ReportBadBlock - keeps the rejected block for debug_getBadBlocks, together with the offending transaction if the
+// reason is an InvalidTxError. If only the header is known, block has no body and headerOnly is set.
+func ReportBadBlock(tx kv.RwTx, block *types.Block, headerOnly bool, reason error) error {
+	var buf bytes.Buffer
+	var err error
+	if headerOnly {
+		err = block.HeaderNoCopy().EncodeRLP(&buf)
+	} else {
+		err = block.EncodeRLP(&buf)
+	}
+	if err != nil {
+		return err
+	}
+	rec := &rawdb.BadBlockRecord{
+		Hash:       block.Hash(),
+		ParentHash: block.ParentHash(),
+		Number:     hexutil.Uint64(block.NumberU64()),
+		Reason:     reason.Error(),
+		ReportedAt: uint64(time.Now().UnixMilli()),
+		RLP:        buf.Bytes(),
+		HeaderOnly: headerOnly,
+	}
+	var txErr *InvalidTxError
+	if errors.As(reason, &txErr) {
+		txIndex := hexutil.Uint64(txErr.Index)
+		rec.TxIndex = &txIndex
+	}
+	return rawdb.WriteBadBlock(tx, rec)
+}
@@ -92,6 +92,7 @@ func ExecuteBlockEphemerally(
 	defer blockExecutionTimer.ObserveDuration(time.Now())
 	block.Uncles()
 	ibs := state.New(stateReader)
+	defer ibs.Release()
 	header := block.Header()
 
 	usedGas := new(uint64)
@@ -108,8 +109,17 @@ func ExecuteBlockEphemerally(
 	receipts := make(types.Receipts, 0, block.Transactions().Len())
 	// Optimism Canyon
 	misc.EnsureCreate2Deployer(chainConfig, header.Time, ibs)
+	if chainConfig.IsOptimism() && chainReader != nil {
+		if parent := chainReader.GetHeader(header.ParentHash, header.Number.Uint64()-1); parent != nil {
+			if err := misc.VerifyUpgradeTxs(chainConfig, parent.Time, header.Time, block.Transactions()); err != nil {
+				return nil, err
+			}
+		}
+	}
 
 	noop := state.NewNoopWriter()
+	signer := types.MakeSigner(chainConfig, header.Number.Uint64(), header.Time)
+	depositNonces := newDepositNonces(vmConfig.VerifyDepositNonces && chainConfig.IsOptimismRegolith(header.Time), stateReader, signer)
 	for i, tx := range block.Transactions() {
 		ibs.SetTxContext(tx.Hash(), block.Hash(), i)
 		writeTrace := false
@@ -131,10 +141,13 @@ func ExecuteBlockEphemerally(
 		}
 		if err != nil {
 			if !vmConfig.StatelessExec {
-				return nil, fmt.Errorf("could not apply tx %d from block %d [%v]: %w", i, block.NumberU64(), tx.Hash().Hex(), err)
+				return nil, &InvalidTxError{Index: i, BlockNum: block.NumberU64(), Hash: tx.Hash(), Err: err}
 			}
 			rejectedTxs = append(rejectedTxs, &RejectedTx{i, err.Error()})
 		} else {
+			if err := depositNonces.verify(ibs, i, tx, receipt); err != nil {
+				return nil, err
+			}
 			includedTxs = append(includedTxs, tx)
 			if !vmConfig.NoReceipts {
 				receipts = append(receipts, receipt)
@@ -0,0 +1,118 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/holiman/uint256"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+
+	"github.com/erigontech/erigon/common/u256"
+	"github.com/erigontech/erigon/core/state"
+	"github.com/erigontech/erigon/core/vm"
+	"github.com/erigontech/erigon/crypto"
+)
+
+// Boba fee token mode (experimental), see chain.BobaFeeTokenConfig
+
+// bobaFeeTokenCallGas - gas limit of a call of the fee token contract, taken from the gas of the transaction
+const bobaFeeTokenCallGas = 100_000
+
+var (
+	bobaCollectFeeSelector = crypto.Keccak256([]byte("collectFee(address,uint256)"))[:4]
+	bobaRefundFeeSelector  = crypto.Keccak256([]byte("refundFee(address,uint256)"))[:4]
+)
+
+// buyGasFeeToken - buyGas of the fee token mode: the tip is paid in ETH, the base fee and the L1 fee are collected
+// in the token. st.gasPrice is lowered to the tip, so that refundGas returns the ETH at the rate it was paid. The gas
+// used by the collection is used by the transaction, before its intrinsic gas, and the gas of the refund call is
+// reserved after it: the execution can't leave the refund without gas.
+func (st *StateTransition) buyGasFeeToken(contract libcommon.Address, gasBailout bool) error {
+	baseFee := st.evm.Context.BaseFee
+	tipPrice := new(uint256.Int)
+	if st.gasPrice.Gt(baseFee) {
+		tipPrice.Sub(st.gasPrice, baseFee)
+	}
+	gasVal, overflow := new(uint256.Int).MulOverflow(uint256.NewInt(st.msg.Gas()), tipPrice)
+	if overflow {
+		return fmt.Errorf("%w: address %v", ErrInsufficientFunds, st.msg.From().Hex())
+	}
+	tokenVal, overflow := new(uint256.Int).MulOverflow(uint256.NewInt(st.msg.Gas()), baseFee)
+	if overflow {
+		return fmt.Errorf("%w: address %v", ErrInsufficientFunds, st.msg.From().Hex())
+	}
+	if fn := st.evm.Context.L1CostFunc; fn != nil {
+		if l1Cost := fn(st.msg.RollupCostData(), st.evm.Context.Time); l1Cost != nil {
+			if tokenVal, overflow = tokenVal.AddOverflow(tokenVal, l1Cost); overflow {
+				return fmt.Errorf("%w: address %v", ErrInsufficientFunds, st.msg.From().Hex())
+			}
+		}
+	}
+
+	if !gasBailout {
+		balanceCheck, overflow := new(uint256.Int).AddOverflow(gasVal, st.value)
+		if overflow {
+			return fmt.Errorf("%w: address %v", ErrInsufficientFunds, st.msg.From().Hex())
+		}
+		if have, want := st.state.GetBalance(st.msg.From()), balanceCheck; have.Cmp(want) < 0 {
+			return fmt.Errorf("%w: address %v have %v want %v", ErrInsufficientFunds, st.msg.From().Hex(), have, want)
+		}
+		st.state.SubBalance(st.msg.From(), gasVal)
+	}
+	if err := st.gp.SubGas(st.msg.Gas()); err != nil {
+		return err
+	}
+	st.gasRemaining += st.msg.Gas()
+	st.initialGas = st.msg.Gas()
+	st.gasPrice = tipPrice
+	st.evm.BlobFee = new(uint256.Int)
+	if gasBailout {
+		return nil // nothing collected, nothing to refund
+	}
+	callGas := min(bobaFeeTokenCallGas, st.gasRemaining)
+	leftOverGas, err := st.feeTokenCall(contract, bobaCollectFeeSelector, tokenVal, callGas)
+	if err != nil {
+		return fmt.Errorf("%w: address %v fee token %v want %v: %v", ErrInsufficientFunds, st.msg.From().Hex(), contract, tokenVal, err)
+	}
+	st.gasRemaining -= callGas - leftOverGas
+	st.feeToken = &contract
+	st.feeTokenRefundGas = min(bobaFeeTokenCallGas, st.gasRemaining)
+	st.gasRemaining -= st.feeTokenRefundGas
+	return nil
+}
+
+// refundFeeToken - returns in the token the base fee of the gas refundGas returns, to be called before it. The
+// refund call runs on the gas reserved by buyGasFeeToken and the gas it leaves goes back to the transaction. The
+// amount is passed to the call, so it can't depend on the gas the call uses: the base fee of the whole reserve is
+// refunded, the gas the refund call uses is paid at the tip only. A failed refund call (the token reverts, or is
+// paused) doesn't fail the transaction: nothing is refunded in the token, and the gas stays charged.
+func (st *StateTransition) refundFeeToken(refundQuotient uint64) {
+	callGas := st.feeTokenRefundGas
+	refund := min(st.gasUsed()/refundQuotient, st.state.GetRefund())
+	amount := new(uint256.Int).Mul(uint256.NewInt(st.gasRemaining+callGas+refund), st.evm.Context.BaseFee)
+	leftOverGas, _ := st.feeTokenCall(*st.feeToken, bobaRefundFeeSelector, amount, callGas)
+	st.gasRemaining += leftOverGas
+}
+
+// feeTokenCall - calls the fee token contract with (sender, amount) from the system address, in the EVM of the
+// transaction: its tracer sees the call, and the gas is metered like the one of the transaction. The refund counter
+// of the transaction is kept as it was. Returns the gas left.
+func (st *StateTransition) feeTokenCall(contract libcommon.Address, selector []byte, amount *uint256.Int, gas uint64) (uint64, error) {
+	if amount.IsZero() {
+		return gas, nil
+	}
+	from := st.msg.From()
+	data := make([]byte, 4+32+32)
+	copy(data, selector)
+	copy(data[4+12:4+32], from[:])
+	amount.WriteToSlice(data[4+32:])
+
+	refund := st.state.GetRefund()
+	_, leftOverGas, err := st.evm.Call(vm.AccountRef(state.SystemAddress), contract, data, gas, u256.Num0, false)
+	if after := st.state.GetRefund(); after > refund {
+		st.state.SubRefund(after - refund)
+	} else if after < refund {
+		st.state.AddRefund(refund - after)
+	}
+	return leftOverGas, err
+}
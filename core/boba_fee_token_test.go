@@ -0,0 +1,121 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/chain"
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/kv/memdb"
+	types2 "github.com/erigontech/erigon-lib/types"
+
+	"github.com/erigontech/erigon/core/state"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/core/vm"
+	"github.com/erigontech/erigon/params"
+)
+
+// bobaFeeTokenTestCode - fee token keeping the balance of the payer at the slot of its address: refundFee adds the
+// amount, any other call subtracts it and reverts if the balance is lower. With refundReverts, refundFee reverts.
+func bobaFeeTokenTestCode(refundReverts bool) []byte {
+	refund := byte(0x22)
+	if refundReverts {
+		refund = 0x27
+	}
+	code := []byte{
+		0x60, 0x04, 0x35, // payer
+		0x80, 0x54, // balance
+		0x60, 0x24, 0x35, // amount
+		0x60, 0x00, 0x35, 0x60, 0xe0, 0x1c, // selector
+		0x63, // PUSH4 refundFee
+	}
+	code = append(code, bobaRefundFeeSelector...)
+	return append(code,
+		0x14, 0x60, refund, 0x57, // to refund if the selector is refundFee
+		0x81, 0x81, 0x11, 0x60, 0x27, 0x57, // to revert if amount > balance
+		0x90, 0x03, 0x90, 0x55, 0x00, // balance - amount
+		0x5b, 0x01, 0x90, 0x55, 0x00, // refund: balance + amount
+		0x5b, 0x60, 0x00, 0x80, 0xfd, // revert
+	)
+}
+
+func TestBobaFeeToken(t *testing.T) {
+	token := libcommon.HexToAddress("0x4200000000000000000000000000000000000f00")
+	from, poor, to, coinbase, burner := libcommon.Address{1}, libcommon.Address{2}, libcommon.Address{3}, libcommon.Address{4}, libcommon.Address{5}
+	config := *params.OptimismTestConfig
+	optimism := *config.Optimism
+	optimism.BobaFeeToken = &chain.BobaFeeTokenConfig{Contract: token, Time: big.NewInt(0)}
+	config.Optimism = &optimism
+
+	_, tx := memdb.NewTestTx(t)
+	ibs := state.New(state.NewPlainStateReader(tx))
+	ibs.SetCode(token, bobaFeeTokenTestCode(false))
+	// burner clears its slot 0, then loops until it's left with less than 0x1000 gas
+	ibs.SetCode(burner, []byte{0x60, 0x00, 0x60, 0x00, 0x55, 0x5b, 0x5a, 0x61, 0x10, 0x00, 0x10, 0x60, 0x05, 0x57, 0x00})
+	ibs.SetState(burner, &libcommon.Hash{}, *uint256.NewInt(1))
+	slot := func(addr libcommon.Address) *libcommon.Hash {
+		h := libcommon.BytesToHash(addr[:])
+		return &h
+	}
+	tokenBalance := func(addr libcommon.Address) uint64 {
+		var v uint256.Int
+		ibs.GetState(token, slot(addr), &v)
+		return v.Uint64()
+	}
+	ibs.SetState(token, slot(from), *uint256.NewInt(1_000_000_000))
+	ibs.SetState(token, slot(poor), *uint256.NewInt(1_000))
+	ibs.AddBalance(from, uint256.NewInt(10_000_000))
+	ibs.AddBalance(poor, uint256.NewInt(10_000_000))
+
+	header := &types.Header{Number: big.NewInt(10), Difficulty: big.NewInt(1), GasLimit: 30_000_000, BaseFee: big.NewInt(1_000)}
+	blockCtx := NewEVMBlockContext(header, func(uint64) libcommon.Hash { return libcommon.Hash{} }, nil, &coinbase)
+	blockCtx.L1CostFunc = func(types2.RollupCostData, uint64) *uint256.Int { return uint256.NewInt(5_000) }
+	apply := func(sender, to libcommon.Address, gas uint64) (*uint64, error) {
+		msg := types.NewMessage(sender, &to, ibs.GetNonce(sender), uint256.NewInt(0), gas, uint256.NewInt(1_010), uint256.NewInt(2_000), uint256.NewInt(10), nil, nil, true, false, nil)
+		evm := vm.NewEVM(blockCtx, NewEVMTxContext(msg), ibs, &config, vm.Config{})
+		res, err := ApplyMessage(evm, msg, new(GasPool).AddGas(header.GasLimit), true, false)
+		if err != nil {
+			return nil, err
+		}
+		require.NoError(t, res.Err)
+		return &res.UsedGas, nil
+	}
+
+	// the transaction pays the gas of both calls of the fee token, the one of the refund call at the tip only
+	usedGas, err := apply(from, to, 200_000)
+	require.NoError(t, err)
+	require.Greater(t, *usedGas, params.TxGas)
+	require.Less(t, *usedGas, params.TxGas+bobaFeeTokenCallGas)
+	refundCallGas := (tokenBalance(from) + *usedGas*1_000 + 5_000 - 1_000_000_000) / 1_000
+	require.Positive(t, refundCallGas)
+	require.Less(t, refundCallGas, bobaFeeTokenCallGas)
+	require.Equal(t, uint256.NewInt(10_000_000-*usedGas*10), ibs.GetBalance(from))
+	require.Equal(t, uint256.NewInt(*usedGas*10), ibs.GetBalance(coinbase))
+	require.True(t, ibs.GetBalance(params.OptimismBaseFeeRecipient).IsZero())
+	require.True(t, ibs.GetBalance(params.OptimismL1FeeRecipient).IsZero())
+	require.Zero(t, ibs.GetRefund())
+
+	// the fee token reverts the collection of a payer who can't afford it
+	_, err = apply(poor, to, 200_000)
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+	require.Equal(t, uint64(1_000), tokenBalance(poor))
+
+	// the execution can't take the gas of the refund call: the reserve and the refund counter (19_900 of the
+	// cleared slot) are refunded in the token
+	balance := tokenBalance(from)
+	usedGas, err = apply(from, burner, 300_000)
+	require.NoError(t, err)
+	require.Greater(t, *usedGas, bobaFeeTokenCallGas)
+	require.LessOrEqual(t, balance-(300_000-bobaFeeTokenCallGas-19_900)*1_000-5_000, tokenBalance(from))
+
+	// a reverted refund doesn't fail the transaction, the gas stays charged in the token
+	ibs.SetCode(token, bobaFeeTokenTestCode(true))
+	balance, eth := tokenBalance(from), ibs.GetBalance(from).Uint64()
+	usedGas, err = apply(from, to, 200_000)
+	require.NoError(t, err)
+	require.Equal(t, balance-200_000*1_000-5_000, tokenBalance(from))
+	require.Equal(t, eth-*usedGas*10, ibs.GetBalance(from).Uint64())
+}
@@ -0,0 +1,103 @@
+package core
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/erigontech/erigon-lib/chain"
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/kv"
+
+	"github.com/erigontech/erigon/core/rawdb"
+)
+
+// MigrateChainConfig replaces the stored chain config by the updated one (e.g. from the superchain registry, which
+// schedules new forks). Returns the changes, nothing is written if apply is false or there are no changes.
+// Fails if the head is past a block or time fork which the updated config moves, as the chain would have to be
+// rewound, unless force.
+func MigrateChainConfig(tx kv.RwTx, updated *chain.Config, apply, force bool) ([]chain.ConfigChange, error) {
+	genesisHash, err := rawdb.ReadCanonicalHash(tx, 0)
+	if err != nil {
+		return nil, err
+	}
+	if genesisHash == (libcommon.Hash{}) {
+		return nil, fmt.Errorf("no genesis block in the db")
+	}
+	stored, err := rawdb.ReadChainConfig(tx, genesisHash)
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil {
+		return nil, fmt.Errorf("no chain config of genesis %x in the db", genesisHash)
+	}
+	if stored.ChainID == nil || updated.ChainID == nil || stored.ChainID.Cmp(updated.ChainID) != 0 {
+		return nil, fmt.Errorf("chain id mismatch: stored %v, updated %v", stored.ChainID, updated.ChainID)
+	}
+	if err := updated.CheckConfigForkOrder(); err != nil {
+		return nil, err
+	}
+
+	changes, err := chain.ConfigChanges(stored, updated)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	headHash := rawdb.ReadHeadHeaderHash(tx)
+	if height := rawdb.ReadHeaderNumber(tx, headHash); height != nil && *height != 0 && !force {
+		// RewindTo is 0 for the forks at genesis too: the whole chain would have to be rewound
+		if compatErr := stored.CheckCompatible(updated, *height); compatErr != nil {
+			compatErr.Diff = changes
+			return changes, compatErr
+		}
+		if head := rawdb.ReadHeader(tx, headHash, *height); head != nil {
+			if err := checkTimeForksCompatible(stored, updated, head.Time); err != nil {
+				return changes, err
+			}
+		}
+	}
+	if !apply {
+		return changes, nil
+	}
+	return changes, rawdb.WriteChainConfig(tx, genesisHash, updated)
+}
+
+// checkTimeForksCompatible fails if a time fork is moved while the head is past it (at the old or new time)
+func checkTimeForksCompatible(stored, updated *chain.Config, headTime uint64) error {
+	storedForks, updatedForks := timeForks(stored), timeForks(updated)
+	for i, fork := range storedForks {
+		s, u := fork.time, updatedForks[i].time
+		if (s == nil) == (u == nil) && (s == nil || s.Cmp(u) == 0) {
+			continue
+		}
+		if isTimeForked(s, headTime) || isTimeForked(u, headTime) {
+			return fmt.Errorf("mismatching %s in database (have %v, want %v), the head at time %d is past it", fork.name, s, u, headTime)
+		}
+	}
+	return nil
+}
+
+type timeFork struct {
+	name string
+	time *big.Int
+}
+
+func timeForks(c *chain.Config) []timeFork {
+	return []timeFork{
+		{"shanghaiTime", c.ShanghaiTime},
+		{"cancunTime", c.CancunTime},
+		{"pragueTime", c.PragueTime},
+		{"osakaTime", c.OsakaTime},
+		{"regolithTime", c.RegolithTime},
+		{"canyonTime", c.CanyonTime},
+		{"ecotoneTime", c.EcotoneTime},
+		{"fjordTime", c.FjordTime},
+		{"graniteTime", c.GraniteTime},
+		{"holoceneTime", c.HoloceneTime},
+	}
+}
+
+func isTimeForked(forkTime *big.Int, headTime uint64) bool {
+	return forkTime != nil && forkTime.IsUint64() && forkTime.Uint64() <= headTime
+}
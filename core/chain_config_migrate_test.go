@@ -0,0 +1,83 @@
+package core_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/chain"
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/kv/memdb"
+
+	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/core/rawdb"
+	"github.com/erigontech/erigon/core/types"
+)
+
+func TestMigrateChainConfig(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+
+	stored := &chain.Config{
+		ChainID:        big.NewInt(288),
+		HomesteadBlock: big.NewInt(0),
+		FjordTime:      big.NewInt(1_000),
+		Optimism:       &chain.OptimismConfig{EIP1559Elasticity: 6, EIP1559Denominator: 50, EIP1559DenominatorCanyon: 250},
+	}
+	genesisHash := libcommon.HexToHash("0x01")
+	require.NoError(t, rawdb.WriteCanonicalHash(tx, genesisHash, 0))
+	require.NoError(t, rawdb.WriteChainConfig(tx, genesisHash, stored))
+
+	registry := &chain.Config{
+		ChainID:        big.NewInt(288),
+		HomesteadBlock: big.NewInt(0),
+		FjordTime:      big.NewInt(1_000),
+		GraniteTime:    big.NewInt(2_000),
+		Optimism:       &chain.OptimismConfig{EIP1559Elasticity: 6, EIP1559Denominator: 250, EIP1559DenominatorCanyon: 250},
+	}
+
+	changes, err := core.MigrateChainConfig(tx, registry, false, false)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	require.Equal(t, "graniteTime: <nil> -> 2000", changes[0].String())
+	require.Equal(t, "optimism.eip1559Denominator: 50 -> 250", changes[1].String())
+	cfg, err := rawdb.ReadChainConfig(tx, genesisHash)
+	require.NoError(t, err)
+	require.Nil(t, cfg.GraniteTime) // dry run
+
+	changes, err = core.MigrateChainConfig(tx, registry, true, false)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	cfg, err = rawdb.ReadChainConfig(tx, genesisHash)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(2_000), cfg.GraniteTime)
+
+	changes, err = core.MigrateChainConfig(tx, registry, true, false)
+	require.NoError(t, err)
+	require.Empty(t, changes)
+
+	other := *registry
+	other.ChainID = big.NewInt(10)
+	_, err = core.MigrateChainConfig(tx, &other, true, false)
+	require.Error(t, err)
+
+	// the head is past the moved fork
+	head := &types.Header{Number: big.NewInt(10), Time: 1_500}
+	require.NoError(t, rawdb.WriteHeader(tx, head))
+	require.NoError(t, rawdb.WriteHeadHeaderHash(tx, head.Hash()))
+	moved := *registry
+	moved.FjordTime = big.NewInt(1_200)
+	_, err = core.MigrateChainConfig(tx, &moved, true, false)
+	require.ErrorContains(t, err, "fjordTime")
+	// not yet past it
+	moved.GraniteTime = big.NewInt(3_000)
+	moved.FjordTime = registry.FjordTime
+	_, err = core.MigrateChainConfig(tx, &moved, false, false)
+	require.NoError(t, err)
+	// a block fork at genesis
+	moved.HomesteadBlock = big.NewInt(1)
+	_, err = core.MigrateChainConfig(tx, &moved, false, false)
+	require.Error(t, err)
+	_, err = core.MigrateChainConfig(tx, &moved, false, true)
+	require.NoError(t, err)
+}
@@ -0,0 +1,73 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+
+	"github.com/erigontech/erigon/core/state"
+	"github.com/erigontech/erigon/core/types"
+)
+
+var ErrDepositNonceMismatch = errors.New("deposit nonce mismatch")
+
+// depositNonces - the verification of vm.Config.VerifyDepositNonces. The nonce a Regolith deposit should get is
+// derived from the block alone: the nonce of its sender in the parent state, plus one per transaction of the sender
+// before it in the block. The execution has to record that nonce in the receipt and increment it. A deposit dropped
+// or reordered by the execution breaks that, which otherwise shows up as a state root mismatch far from the cause.
+type depositNonces struct {
+	parent state.StateReader // state before the block, not changed by the execution of the block
+	signer *types.Signer
+	next   map[libcommon.Address]uint64 // nonce of the next transaction of the senders seen in the block
+}
+
+// newDepositNonces - nil if the deposits of the block aren't verified
+func newDepositNonces(enabled bool, parent state.StateReader, signer *types.Signer) *depositNonces {
+	if !enabled {
+		return nil
+	}
+	return &depositNonces{parent: parent, signer: signer, next: map[libcommon.Address]uint64{}}
+}
+
+// expected - the nonce the next transaction of from should have
+func (d *depositNonces) expected(from libcommon.Address) (uint64, error) {
+	if nonce, ok := d.next[from]; ok {
+		return nonce, nil
+	}
+	acc, err := d.parent.ReadAccountData(from)
+	if err != nil || acc == nil {
+		return 0, err
+	}
+	return acc.Nonce, nil
+}
+
+// verify - called for each transaction included in the block, in order, after its execution
+func (d *depositNonces) verify(ibs *state.IntraBlockState, txIndex int, txn types.Transaction, receipt *types.Receipt) error {
+	if d == nil {
+		return nil
+	}
+	from, err := txn.Sender(*d.signer)
+	if err != nil {
+		return err
+	}
+	if txn.Type() != types.DepositTxType {
+		d.next[from] = txn.GetNonce() + 1
+		return nil
+	}
+	expected, err := d.expected(from)
+	if err != nil {
+		return err
+	}
+	d.next[from] = expected + 1
+	if receipt == nil {
+		return nil
+	}
+	if receipt.DepositNonce == nil {
+		return fmt.Errorf("%w: deposit %d (%x) from %x has no nonce in its receipt, expected %d", ErrDepositNonceMismatch, txIndex, txn.Hash(), from, expected)
+	}
+	if after := ibs.GetNonce(from); *receipt.DepositNonce != expected || after != expected+1 {
+		return fmt.Errorf("%w: deposit %d (%x) from %x has nonce %d in its receipt and %d after execution, expected %d", ErrDepositNonceMismatch, txIndex, txn.Hash(), from, *receipt.DepositNonce, after, expected)
+	}
+	return nil
+}
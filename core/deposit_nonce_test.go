@@ -0,0 +1,55 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/chain"
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/kv/memdb"
+
+	"github.com/erigontech/erigon/core/state"
+	"github.com/erigontech/erigon/core/types"
+)
+
+func TestDepositNonces(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	from := libcommon.HexToAddress("0xdead")
+	parentState := state.New(state.NewPlainStateReader(tx))
+	parentState.SetNonce(from, 7)
+	require.NoError(t, parentState.CommitBlock(&chain.Rules{}, state.NewPlainStateWriter(tx, tx, 0)))
+
+	parent := state.NewPlainStateReader(tx)
+	signer := types.LatestSignerForChainID(nil)
+	deposit := &types.DepositTx{From: from, Value: new(uint256.Int)}
+	receipt := func(nonce uint64) *types.Receipt {
+		return &types.Receipt{DepositNonce: &nonce}
+	}
+
+	// only deposits from Regolith are checked
+	require.Nil(t, newDepositNonces(false, parent, signer))
+
+	// the first deposit of the sender follows the parent state, the next ones the deposits before them
+	d := newDepositNonces(true, parent, signer)
+	ibs := state.New(parent)
+	ibs.SetNonce(from, 8)
+	require.NoError(t, d.verify(ibs, 0, deposit, receipt(7)))
+	ibs.SetNonce(from, 9)
+	require.NoError(t, d.verify(ibs, 1, deposit, receipt(8)))
+
+	// the execution recorded the nonce of the sender, but a deposit before was dropped
+	d = newDepositNonces(true, parent, signer)
+	err := d.verify(ibs, 0, deposit, receipt(8))
+	require.ErrorIs(t, err, ErrDepositNonceMismatch)
+	require.Contains(t, err.Error(), "has nonce 8 in its receipt and 9 after execution, expected 7")
+
+	// the deposit didn't increment the nonce of its sender
+	d = newDepositNonces(true, parent, signer)
+	ibs = state.New(parent)
+	require.ErrorIs(t, d.verify(ibs, 0, deposit, receipt(7)), ErrDepositNonceMismatch)
+
+	d = newDepositNonces(true, parent, signer)
+	require.ErrorIs(t, d.verify(ibs, 0, deposit, &types.Receipt{}), ErrDepositNonceMismatch)
+}
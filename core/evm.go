@@ -91,6 +91,7 @@ func NewEVMTxContext(msg Message) evmtypes.TxContext {
 		Origin:     msg.From(),
 		GasPrice:   msg.GasPrice(),
 		BlobHashes: msg.BlobHashes(),
+		BobaTuring: msg.BobaTuring(),
 	}
 }
 
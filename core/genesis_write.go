@@ -49,6 +49,7 @@ import (
 	"github.com/erigontech/erigon/core/rawdb"
 	"github.com/erigontech/erigon/core/state"
 	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/core/vm"
 	"github.com/erigontech/erigon/crypto"
 	"github.com/erigontech/erigon/params"
 	"github.com/erigontech/erigon/turbo/trie"
@@ -205,6 +206,9 @@ func WriteGenesisBlock(tx kv.RwTx, genesis *types.Genesis, overrides *ChainOverr
 	if err := newCfg.CheckConfigForkOrder(); err != nil {
 		return newCfg, nil, err
 	}
+	if err := vm.CheckPrecompiles(newCfg); err != nil {
+		return newCfg, nil, err
+	}
 	storedCfg, storedErr := rawdb.ReadChainConfig(tx, storedHash)
 	if storedErr != nil && newCfg.Bor == nil {
 		return newCfg, nil, storedErr
@@ -226,12 +230,28 @@ func WriteGenesisBlock(tx kv.RwTx, genesis *types.Genesis, overrides *ChainOverr
 	}
 
 	if newCfg.IsOptimism() {
-		if !reflect.DeepEqual(newCfg, storedCfg) {
-			log.Info("Update latest chain config from superchain registry")
+		// the superchain registry schedules new forks: they are applied by `erigon db migrate-chainconfig`, only the overrides are applied here
+		registryCfg := newCfg
+		if newCfg, storedErr = rawdb.ReadChainConfig(tx, storedHash); storedErr != nil {
+			return registryCfg, nil, storedErr
 		}
-		// rewrite using superchain config just in case
-		if err := rawdb.WriteChainConfig(tx, storedHash, newCfg); err != nil {
+		applyOverrides(newCfg)
+		if newCfg.Optimism != nil && newCfg.Optimism.BobaLegacy == nil && registryCfg.Optimism != nil {
+			// configs stored before the Boba networks were marked by it in the config
+			newCfg.Optimism.BobaLegacy = registryCfg.Optimism.BobaLegacy
+		}
+		if changes, err := chain.ConfigChanges(newCfg, registryCfg); err != nil {
 			return newCfg, nil, err
+		} else if len(changes) > 0 {
+			logger.Warn("Stored chain config differs from superchain registry, run `erigon db migrate-chainconfig` to apply it", "changes", len(changes))
+			for _, change := range changes {
+				logger.Warn("[chain config] " + change.String())
+			}
+		}
+		if !reflect.DeepEqual(newCfg, storedCfg) {
+			if err := rawdb.WriteChainConfig(tx, storedHash, newCfg); err != nil {
+				return newCfg, nil, err
+			}
 		}
 		return newCfg, storedBlock, nil
 	}
@@ -242,7 +262,7 @@ func WriteGenesisBlock(tx kv.RwTx, genesis *types.Genesis, overrides *ChainOverr
 	if height != nil {
 		compatibilityErr := storedCfg.CheckCompatible(newCfg, *height)
 		if compatibilityErr != nil && *height != 0 && compatibilityErr.RewindTo != 0 {
-			return newCfg, storedBlock, compatibilityErr
+			return newCfg, storedBlock, compatibilityErr.WithDiff(storedCfg, newCfg)
 		}
 	}
 	if err := rawdb.WriteChainConfig(tx, storedHash, newCfg); err != nil {
@@ -331,6 +351,9 @@ func write(tx kv.RwTx, g *types.Genesis, tmpDir string, logger log.Logger) (*typ
 	if err := config.CheckConfigForkOrder(); err != nil {
 		return nil, nil, err
 	}
+	if err := vm.CheckPrecompiles(config); err != nil {
+		return nil, nil, err
+	}
 
 	if err := rawdb.WriteBlock(tx, block); err != nil {
 		return nil, nil, err
@@ -338,7 +361,7 @@ func write(tx kv.RwTx, g *types.Genesis, tmpDir string, logger log.Logger) (*typ
 	if err := rawdb.WriteTd(tx, block.Hash(), block.NumberU64(), g.Difficulty); err != nil {
 		return nil, nil, err
 	}
-	if err := rawdbv3.TxNums.WriteForGenesis(tx, 1); err != nil {
+	if err := rawdbv3.TxNumsWriter.WriteForGenesis(tx, 1); err != nil {
 		return nil, nil, err
 	}
 	if err := rawdb.WriteReceipts(tx, block.NumberU64(), nil); err != nil {
@@ -0,0 +1,89 @@
+package rawdb
+
+import (
+	"encoding/binary"
+	"encoding/json"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/common/hexutility"
+	"github.com/erigontech/erigon-lib/kv"
+)
+
+// MaxBadBlocks - how many of the recently rejected blocks are kept (same as geth)
+const MaxBadBlocks = 10
+
+// BadBlockRecord - a block rejected by validation or execution. Stored in kv.BadBlocks in JSON encoding.
+type BadBlockRecord struct {
+	Hash       libcommon.Hash  `json:"hash"`
+	ParentHash libcommon.Hash  `json:"parentHash"`
+	Number     hexutil.Uint64  `json:"number"`
+	Reason     string          `json:"reason"`
+	TxIndex    *hexutil.Uint64 `json:"txIndex,omitempty"` // the offending transaction, if the reason is one
+	ReportedAt uint64          `json:"reportedAt"`        // unix milliseconds
+	// RLP of the block, of the header only if the body is unknown
+	RLP        hexutility.Bytes `json:"rlp"`
+	HeaderOnly bool             `json:"headerOnly,omitempty"`
+}
+
+// WriteBadBlock - appends the record to the ring buffer of the last MaxBadBlocks records. A block which is already
+// there is not added again.
+func WriteBadBlock(tx kv.RwTx, rec *BadBlockRecord) error {
+	c, err := tx.RwCursor(kv.BadBlocks)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	var nextSeq uint64
+	var count int
+	for k, v, err := c.First(); k != nil; k, v, err = c.Next() {
+		if err != nil {
+			return err
+		}
+		var existing BadBlockRecord
+		if err := json.Unmarshal(v, &existing); err != nil {
+			return err
+		}
+		if existing.Hash == rec.Hash {
+			return nil
+		}
+		nextSeq = binary.BigEndian.Uint64(k) + 1
+		count++
+	}
+	for ; count >= MaxBadBlocks; count-- {
+		if _, _, err := c.First(); err != nil {
+			return err
+		}
+		if err := c.DeleteCurrent(); err != nil {
+			return err
+		}
+	}
+
+	v, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint64(k, nextSeq)
+	return tx.Put(kv.BadBlocks, k, v)
+}
+
+// ReadBadBlocks - the recently rejected blocks, the last reported first
+func ReadBadBlocks(tx kv.Tx) ([]*BadBlockRecord, error) {
+	var res []*BadBlockRecord
+	if err := tx.ForEach(kv.BadBlocks, nil, func(k, v []byte) error {
+		rec := &BadBlockRecord{}
+		if err := json.Unmarshal(v, rec); err != nil {
+			return err
+		}
+		res = append(res, rec)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(res)-1; i < j; i, j = i+1, j-1 {
+		res[i], res[j] = res[j], res[i]
+	}
+	return res, nil
+}
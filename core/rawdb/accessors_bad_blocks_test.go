@@ -0,0 +1,36 @@
+package rawdb_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/kv/memdb"
+
+	"github.com/erigontech/erigon/core/rawdb"
+)
+
+func TestBadBlocks(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+
+	records, err := rawdb.ReadBadBlocks(tx)
+	require.NoError(t, err)
+	require.Empty(t, records)
+
+	for i := 1; i <= rawdb.MaxBadBlocks+2; i++ {
+		require.NoError(t, rawdb.WriteBadBlock(tx, &rawdb.BadBlockRecord{Hash: libcommon.BytesToHash([]byte{byte(i)}), Number: hexutil.Uint64(i)}))
+	}
+	// already there
+	require.NoError(t, rawdb.WriteBadBlock(tx, &rawdb.BadBlockRecord{Hash: libcommon.BytesToHash([]byte{5}), Number: 5, Reason: "again"}))
+
+	records, err = rawdb.ReadBadBlocks(tx)
+	require.NoError(t, err)
+	require.Len(t, records, rawdb.MaxBadBlocks)
+	require.Equal(t, hexutil.Uint64(rawdb.MaxBadBlocks+2), records[0].Number)
+	require.Equal(t, hexutil.Uint64(3), records[len(records)-1].Number)
+	for _, rec := range records {
+		require.Empty(t, rec.Reason)
+	}
+}
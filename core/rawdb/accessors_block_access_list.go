@@ -0,0 +1,40 @@
+package rawdb
+
+import (
+	"encoding/json"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutility"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/dbutils"
+
+	"github.com/erigontech/erigon/core/types"
+)
+
+func WriteBlockAccessList(tx kv.Putter, hash libcommon.Hash, number uint64, bal *types.BlockAccessList) error {
+	v, err := json.Marshal(bal)
+	if err != nil {
+		return err
+	}
+	return tx.Put(kv.BlockAccessList, dbutils.BlockBodyKey(number, hash), v)
+}
+
+// ReadBlockAccessList - nil if access list of the block wasn't collected
+func ReadBlockAccessList(tx kv.Getter, hash libcommon.Hash, number uint64) (*types.BlockAccessList, error) {
+	v, err := tx.GetOne(kv.BlockAccessList, dbutils.BlockBodyKey(number, hash))
+	if err != nil || v == nil {
+		return nil, err
+	}
+	bal := &types.BlockAccessList{}
+	if err := json.Unmarshal(v, bal); err != nil {
+		return nil, err
+	}
+	return bal, nil
+}
+
+// TruncateBlockAccessLists deletes access lists of blocks from `number`
+func TruncateBlockAccessLists(tx kv.RwTx, number uint64) error {
+	return tx.ForEach(kv.BlockAccessList, hexutility.EncodeTs(number), func(k, _ []byte) error {
+		return tx.Delete(kv.BlockAccessList, k)
+	})
+}
@@ -30,7 +30,6 @@ import (
 	"github.com/erigontech/erigon-lib/kv/dbutils"
 	"github.com/erigontech/erigon/core/rawdb/utils"
 	"github.com/erigontech/erigon/core/types"
-	"github.com/erigontech/erigon/ethdb/cbor"
 	"github.com/erigontech/erigon/rlp"
 
 	"github.com/erigontech/erigon-lib/log/v3"
@@ -656,11 +655,27 @@ func ReadSenders(db kv.Getter, hash common.Hash, number uint64) ([]common.Addres
 	if err != nil {
 		return nil, fmt.Errorf("readSenders failed: %w", err)
 	}
+	return decodeSenders(data), nil
+}
+
+// ReadFrozenSenders - senders of frozen block which segment has no senders, nil if they were not backfilled
+func ReadFrozenSenders(db kv.Getter, hash common.Hash, number uint64) ([]common.Address, error) {
+	data, err := db.GetOne(kv.FrozenSenders, dbutils.BlockBodyKey(number, hash))
+	if err != nil {
+		return nil, fmt.Errorf("readFrozenSenders failed: %w", err)
+	}
+	if data == nil {
+		return nil, nil
+	}
+	return decodeSenders(data), nil
+}
+
+func decodeSenders(data []byte) []common.Address {
 	senders := make([]common.Address, len(data)/length.Addr)
 	for i := 0; i < len(senders); i++ {
 		copy(senders[i][:], data[i*length.Addr:])
 	}
-	return senders, nil
+	return senders
 }
 
 func WriteRawBodyIfNotExists(db kv.RwTx, hash common.Hash, number uint64, body *types.RawBody) (ok bool, err error) {
@@ -728,6 +743,17 @@ func WriteSenders(db kv.Putter, hash common.Hash, number uint64, senders []commo
 	return nil
 }
 
+func WriteFrozenSenders(db kv.Putter, hash common.Hash, number uint64, senders []common.Address) error {
+	data := make([]byte, length.Addr*len(senders))
+	for i, sender := range senders {
+		copy(data[i*length.Addr:], sender[:])
+	}
+	if err := db.Put(kv.FrozenSenders, dbutils.BlockBodyKey(number, hash), data); err != nil {
+		return fmt.Errorf("failed to store frozen block senders: %w", err)
+	}
+	return nil
+}
+
 // DeleteBody removes all block body data associated with a hash.
 func DeleteBody(db kv.Deleter, hash common.Hash, number uint64) {
 	if err := db.Delete(kv.BlockBody, dbutils.BlockBodyKey(number, hash)); err != nil {
@@ -735,6 +761,32 @@ func DeleteBody(db kv.Deleter, hash common.Hash, number uint64) {
 	}
 }
 
+// DeleteBlock removes the header, the body with its transactions and the senders of a non-canonical block
+func DeleteBlock(tx kv.RwTx, hash common.Hash, number uint64) error {
+	k := dbutils.BlockBodyKey(number, hash)
+	b, err := ReadBodyForStorageByKey(tx, k)
+	if err != nil {
+		return err
+	}
+	if b != nil {
+		txIDBytes := make([]byte, 8)
+		for txID := b.BaseTxId; txID < b.BaseTxId+uint64(b.TxAmount); txID++ {
+			binary.BigEndian.PutUint64(txIDBytes, txID)
+			if err = tx.Delete(kv.EthTx, txIDBytes); err != nil {
+				return err
+			}
+		}
+	}
+	if err := tx.Delete(kv.Senders, k); err != nil {
+		return err
+	}
+	if err := tx.Delete(kv.BlockBody, k); err != nil {
+		return err
+	}
+	DeleteHeader(tx, hash, number)
+	return nil
+}
+
 func AppendCanonicalTxNums(tx kv.RwTx, from uint64) (err error) {
 	nextBaseTxNum := -1
 	if from > 0 {
@@ -764,7 +816,7 @@ func AppendCanonicalTxNums(tx kv.RwTx, from uint64) (err error) {
 		}
 
 		nextBaseTxNum += int(bodyForStorage.TxAmount)
-		err = rawdbv3.TxNums.Append(tx, blockNum, uint64(nextBaseTxNum-1))
+		err = rawdbv3.TxNumsWriter.Append(tx, blockNum, uint64(nextBaseTxNum-1))
 		if err != nil {
 			return err
 		}
@@ -772,6 +824,30 @@ func AppendCanonicalTxNums(tx kv.RwTx, from uint64) (err error) {
 	return nil
 }
 
+// txNumsVerifyWindow - how many last blocks VerifyTxNums checks
+const txNumsVerifyWindow = 1_000
+
+// VerifyTxNums checks invariants of kv.MaxTxNum of blocks from `from-txNumsVerifyWindow`, against bodies
+// stored in db. For dbg.AssertTxNums after unwinds.
+func VerifyTxNums(tx kv.Tx, from uint64) error {
+	from -= min(from, txNumsVerifyWindow)
+	return rawdbv3.TxNumsWriter.Verify(tx, from, func(blockNum uint64) (uint64, bool, error) {
+		h, err := ReadCanonicalHash(tx, blockNum)
+		if err != nil || h == (common.Hash{}) {
+			return 0, false, err
+		}
+		data := ReadStorageBodyRLP(tx, h, blockNum)
+		if len(data) == 0 {
+			return 0, false, nil
+		}
+		var bodyForStorage types.BodyForStorage
+		if err := rlp.DecodeBytes(data, &bodyForStorage); err != nil {
+			return 0, false, err
+		}
+		return uint64(bodyForStorage.TxAmount), true, nil
+	})
+}
+
 // ReadTd retrieves a block's total difficulty corresponding to the hash.
 func ReadTd(db kv.Getter, hash common.Hash, number uint64) (*big.Int, error) {
 	data, err := db.GetOne(kv.HeaderTD, dbutils.HeaderKey(number, hash))
@@ -830,8 +906,8 @@ func ReadRawReceipts(db kv.Tx, blockNum uint64) types.Receipts {
 	if len(data) == 0 {
 		return nil
 	}
-	var receipts types.Receipts
-	if err := cbor.Unmarshal(&receipts, bytes.NewReader(data)); err != nil {
+	receipts, err := types.DecodeReceiptsForStorage(data)
+	if err != nil {
 		log.Error("receipt unmarshal failed", "err", err)
 		return nil
 	}
@@ -855,8 +931,8 @@ func ReadRawReceipts(db kv.Tx, blockNum uint64) types.Receipts {
 			log.Error("logs fetching failed", "err", err)
 			return nil
 		}
-		var logs types.Logs
-		if err := cbor.Unmarshal(&logs, bytes.NewReader(v)); err != nil {
+		logs, err := types.DecodeLogsForStorage(v)
+		if err != nil {
 			err = fmt.Errorf("receipt unmarshal failed:  %w", err)
 			log.Error("logs fetching failed", "err", err)
 			return nil
@@ -885,7 +961,12 @@ func ReadReceipts(config *chain.Config, db kv.Tx, block *types.Block, senders []
 		return nil
 	}
 	// We're deriving many fields from the block body, retrieve beside the receipt
-	receipts := ReadRawReceipts(db, block.NumberU64())
+	return DeriveReceipts(config, block, senders, ReadRawReceipts(db, block.NumberU64()))
+}
+
+// DeriveReceipts populates the metadata fields of the raw receipts of the block, see ReadReceipts.
+// Returns nil if there are no receipts or it is unable to populate the fields.
+func DeriveReceipts(config *chain.Config, block *types.Block, senders []common.Address, receipts types.Receipts) types.Receipts {
 	if receipts == nil {
 		return nil
 	}
@@ -902,31 +983,29 @@ func ReadReceipts(config *chain.Config, db kv.Tx, block *types.Block, senders []
 }
 
 // WriteReceipts stores all the transaction receipts belonging to a block.
+// Receipts and logs are written in storage encoding v2 (see types.EncodeReceiptsForStorage).
 func WriteReceipts(tx kv.Putter, number uint64, receipts types.Receipts) error {
-	buf := bytes.NewBuffer(make([]byte, 0, 1024))
 	for txId, r := range receipts {
 		if len(r.Logs) == 0 {
 			continue
 		}
 
-		buf.Reset()
-		err := cbor.Marshal(buf, r.Logs)
+		logs, err := types.EncodeLogsForStorage(r.Logs)
 		if err != nil {
 			return fmt.Errorf("encode block logs for block %d: %w", number, err)
 		}
 
-		if err = tx.Put(kv.Log, dbutils.LogKey(number, uint32(txId)), buf.Bytes()); err != nil {
+		if err = tx.Put(kv.Log, dbutils.LogKey(number, uint32(txId)), logs); err != nil {
 			return fmt.Errorf("writing logs for block %d: %w", number, err)
 		}
 	}
 
-	buf.Reset()
-	err := cbor.Marshal(buf, receipts)
+	v, err := types.EncodeReceiptsForStorage(receipts)
 	if err != nil {
 		return fmt.Errorf("encode block receipts for block %d: %w", number, err)
 	}
 
-	if err = tx.Put(kv.Receipts, hexutility.EncodeTs(number), buf.Bytes()); err != nil {
+	if err = tx.Put(kv.Receipts, hexutility.EncodeTs(number), v); err != nil {
 		return fmt.Errorf("writing receipts for block %d: %w", number, err)
 	}
 	return nil
@@ -934,33 +1013,37 @@ func WriteReceipts(tx kv.Putter, number uint64, receipts types.Receipts) error {
 
 // AppendReceipts stores all the transaction receipts belonging to a block.
 func AppendReceipts(tx kv.StatelessWriteTx, blockNumber uint64, receipts types.Receipts) error {
-	buf := bytes.NewBuffer(make([]byte, 0, 1024))
+	if err := AppendLogs(tx, blockNumber, receipts); err != nil {
+		return err
+	}
+
+	v, err := types.EncodeReceiptsForStorage(receipts)
+	if err != nil {
+		return fmt.Errorf("encode block receipts for block %d: %w", blockNumber, err)
+	}
+
+	if err = tx.Append(kv.Receipts, hexutility.EncodeTs(blockNumber), v); err != nil {
+		return fmt.Errorf("writing receipts for block %d: %w", blockNumber, err)
+	}
+	return nil
+}
 
+// AppendLogs stores only the logs of the transaction receipts belonging to a block, for the log index.
+func AppendLogs(tx kv.StatelessWriteTx, blockNumber uint64, receipts types.Receipts) error {
 	for txId, r := range receipts {
 		if len(r.Logs) == 0 {
 			continue
 		}
 
-		buf.Reset()
-		err := cbor.Marshal(buf, r.Logs)
+		logs, err := types.EncodeLogsForStorage(r.Logs)
 		if err != nil {
 			return fmt.Errorf("encode block receipts for block %d: %w", blockNumber, err)
 		}
 
-		if err = tx.Append(kv.Log, dbutils.LogKey(blockNumber, uint32(txId)), buf.Bytes()); err != nil {
+		if err = tx.Append(kv.Log, dbutils.LogKey(blockNumber, uint32(txId)), logs); err != nil {
 			return fmt.Errorf("writing receipts for block %d: %w", blockNumber, err)
 		}
 	}
-
-	buf.Reset()
-	err := cbor.Marshal(buf, receipts)
-	if err != nil {
-		return fmt.Errorf("encode block receipts for block %d: %w", blockNumber, err)
-	}
-
-	if err = tx.Append(kv.Receipts, hexutility.EncodeTs(blockNumber), buf.Bytes()); err != nil {
-		return fmt.Errorf("writing receipts for block %d: %w", blockNumber, err)
-	}
 	return nil
 }
 
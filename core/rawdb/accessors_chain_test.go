@@ -207,9 +207,13 @@ func TestBlockStorage(t *testing.T) {
 	if err := rawdb.TruncateBlocks(context.Background(), tx, block.NumberU64()); err != nil {
 		t.Fatal(err)
 	}
-	//if err := DeleteBlock(tx, block.Hash(), block.NumberU64()); err != nil {
-	//	t.Fatalf("Could not delete block: %v", err)
-	//}
+	if entry, _, _ := br.BlockWithSenders(ctx, tx, block.Hash(), block.NumberU64()); entry != nil {
+		t.Fatalf("Deleted block returned: %v", entry)
+	}
+	require.NoError(rawdb.WriteBlock(tx, block))
+	if err := rawdb.DeleteBlock(tx, block.Hash(), block.NumberU64()); err != nil {
+		t.Fatalf("Could not delete block: %v", err)
+	}
 	if entry, _, _ := br.BlockWithSenders(ctx, tx, block.Hash(), block.NumberU64()); entry != nil {
 		t.Fatalf("Deleted block returned: %v", entry)
 	}
@@ -0,0 +1,133 @@
+package rawdb
+
+import (
+	"encoding/binary"
+	"encoding/json"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/common/hexutility"
+	"github.com/erigontech/erigon-lib/kv"
+
+	"github.com/erigontech/erigon/core/types"
+)
+
+// PayloadAttributesRecord - payload attributes received from consensus layer by engine_forkchoiceUpdated
+// together with outcome of block building. Stored in kv.PayloadAttributes in JSON encoding.
+type PayloadAttributesRecord struct {
+	PayloadId  hexutil.Uint64 `json:"payloadId"`
+	ReceivedAt uint64         `json:"receivedAt"` // unix milliseconds
+
+	ParentHash            libcommon.Hash      `json:"parentHash"`
+	Timestamp             hexutil.Uint64      `json:"timestamp"`
+	PrevRandao            libcommon.Hash      `json:"prevRandao"`
+	SuggestedFeeRecipient libcommon.Address   `json:"suggestedFeeRecipient"`
+	Withdrawals           []*types.Withdrawal `json:"withdrawals,omitempty"`
+	ParentBeaconBlockRoot *libcommon.Hash     `json:"parentBeaconBlockRoot,omitempty"`
+	Transactions          []hexutility.Bytes  `json:"transactions"`
+	NoTxPool              bool                `json:"noTxPool"`
+	GasLimit              *hexutil.Uint64     `json:"gasLimit,omitempty"`
+	EIP1559Params         hexutility.Bytes    `json:"eip1559Params,omitempty"`
+
+	// Outcome of block building, empty while block is being built
+	BlockHash   *libcommon.Hash `json:"blockHash,omitempty"`
+	BlockNumber *hexutil.Uint64 `json:"blockNumber,omitempty"`
+	BuildTimeMs uint64          `json:"buildTimeMs,omitempty"`
+	Error       string          `json:"error,omitempty"`
+}
+
+// payloadAttributesKey - received_at_ms_u64 + payload_id_u64. Payload ids are reset on restart, so they are not unique
+func payloadAttributesKey(receivedAt, payloadId uint64) []byte {
+	k := make([]byte, 16)
+	binary.BigEndian.PutUint64(k, receivedAt)
+	binary.BigEndian.PutUint64(k[8:], payloadId)
+	return k
+}
+
+// payloadAttributesIndexKey - payload_id_u64 + received_at_ms_u64, key of kv.PayloadAttributesIndex
+func payloadAttributesIndexKey(payloadId, receivedAt uint64) []byte {
+	return payloadAttributesKey(payloadId, receivedAt)
+}
+
+// WritePayloadAttributes - stores record, overwrites previous version of the same record
+func WritePayloadAttributes(tx kv.Putter, rec *PayloadAttributesRecord) error {
+	v, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if err := tx.Put(kv.PayloadAttributes, payloadAttributesKey(rec.ReceivedAt, uint64(rec.PayloadId)), v); err != nil {
+		return err
+	}
+	return tx.Put(kv.PayloadAttributesIndex, payloadAttributesIndexKey(uint64(rec.PayloadId), rec.ReceivedAt), nil)
+}
+
+// ReadPayloadAttributes - all records with given payload id, oldest first
+func ReadPayloadAttributes(tx kv.Tx, payloadId uint64) ([]*PayloadAttributesRecord, error) {
+	prefix := make([]byte, 8)
+	binary.BigEndian.PutUint64(prefix, payloadId)
+	var res []*PayloadAttributesRecord
+	if err := tx.ForPrefix(kv.PayloadAttributesIndex, prefix, func(k, _ []byte) error {
+		v, err := tx.GetOne(kv.PayloadAttributes, payloadAttributesKey(binary.BigEndian.Uint64(k[8:]), payloadId))
+		if err != nil || v == nil {
+			return err
+		}
+		rec := &PayloadAttributesRecord{}
+		if err := json.Unmarshal(v, rec); err != nil {
+			return err
+		}
+		res = append(res, rec)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ReadPayloadAttributesRange - records received in [from, to) unix milliseconds, oldest first, at most limit records
+func ReadPayloadAttributesRange(tx kv.Tx, from, to uint64, limit int) ([]*PayloadAttributesRecord, error) {
+	c, err := tx.Cursor(kv.PayloadAttributes)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	var res []*PayloadAttributesRecord
+	for k, v, err := c.Seek(payloadAttributesKey(from, 0)); k != nil && len(res) < limit; k, v, err = c.Next() {
+		if err != nil {
+			return nil, err
+		}
+		if binary.BigEndian.Uint64(k) >= to {
+			break
+		}
+		rec := &PayloadAttributesRecord{}
+		if err := json.Unmarshal(v, rec); err != nil {
+			return nil, err
+		}
+		res = append(res, rec)
+	}
+	return res, nil
+}
+
+// PrunePayloadAttributes - deletes records received before given unix milliseconds
+func PrunePayloadAttributes(tx kv.RwTx, before uint64) error {
+	c, err := tx.RwCursor(kv.PayloadAttributes)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	for k, _, err := c.First(); k != nil; k, _, err = c.Next() {
+		if err != nil {
+			return err
+		}
+		if binary.BigEndian.Uint64(k) >= before {
+			break
+		}
+		if err := tx.Delete(kv.PayloadAttributesIndex, payloadAttributesIndexKey(binary.BigEndian.Uint64(k[8:]), binary.BigEndian.Uint64(k))); err != nil {
+			return err
+		}
+		if err := c.DeleteCurrent(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
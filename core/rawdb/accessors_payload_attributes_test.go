@@ -0,0 +1,59 @@
+package rawdb_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/common/hexutility"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/memdb"
+
+	"github.com/erigontech/erigon/core/rawdb"
+)
+
+func TestPayloadAttributes(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	_, tx := memdb.NewTestTx(t)
+
+	// payload ids are reset on restart - 1 is used twice
+	for i, receivedAt := range []uint64{1_000, 2_000, 3_000} {
+		rec := &rawdb.PayloadAttributesRecord{PayloadId: hexutil.Uint64(i%2 + 1), ReceivedAt: receivedAt, Transactions: []hexutility.Bytes{{1}}}
+		require.NoError(rawdb.WritePayloadAttributes(tx, rec))
+	}
+	// outcome overwrites the same record
+	hash := libcommon.HexToHash("0x01")
+	require.NoError(rawdb.WritePayloadAttributes(tx, &rawdb.PayloadAttributesRecord{PayloadId: 1, ReceivedAt: 1_000, BlockHash: &hash}))
+
+	recs, err := rawdb.ReadPayloadAttributes(tx, 1)
+	require.NoError(err)
+	require.Len(recs, 2)
+	require.Equal(hash, *recs[0].BlockHash)
+	require.Equal(uint64(3_000), recs[1].ReceivedAt)
+
+	recs, err = rawdb.ReadPayloadAttributesRange(tx, 1_500, 3_000, 10)
+	require.NoError(err)
+	require.Len(recs, 1)
+	require.Equal(hexutil.Uint64(2), recs[0].PayloadId)
+
+	require.NoError(rawdb.PrunePayloadAttributes(tx, 2_500))
+	recs, err = rawdb.ReadPayloadAttributesRange(tx, 0, 10_000, 10)
+	require.NoError(err)
+	require.Len(recs, 1)
+	require.Equal(uint64(3_000), recs[0].ReceivedAt)
+
+	// pruning drops the records from the index by payload id too
+	recs, err = rawdb.ReadPayloadAttributes(tx, 1)
+	require.NoError(err)
+	require.Len(recs, 1)
+	require.Equal(uint64(3_000), recs[0].ReceivedAt)
+	c, err := tx.Cursor(kv.PayloadAttributesIndex)
+	require.NoError(err)
+	defer c.Close()
+	n, err := c.Count()
+	require.NoError(err)
+	require.Equal(uint64(1), n)
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/erigontech/erigon-lib/kv/dbutils"
 
 	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/dbg"
 	"github.com/erigontech/erigon-lib/common/hexutility"
 	"github.com/erigontech/erigon-lib/etl"
 	"github.com/erigontech/erigon-lib/kv"
@@ -66,9 +67,14 @@ func (w *BlockWriter) MakeBodiesCanonical(tx kv.RwTx, from uint64) error {
 }
 func (w *BlockWriter) MakeBodiesNonCanonical(tx kv.RwTx, from uint64) error {
 	if w.historyV3 {
-		if err := rawdbv3.TxNums.Truncate(tx, from); err != nil {
+		if err := rawdbv3.TxNumsWriter.Truncate(tx, from); err != nil {
 			return err
 		}
+		if dbg.AssertTxNums {
+			if err := rawdb.VerifyTxNums(tx, from); err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
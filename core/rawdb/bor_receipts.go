@@ -39,6 +39,17 @@ func ReadRawBorReceipt(db kv.Tx, number uint64) (*types.Receipt, bool, error) {
 		return nil, false, nil
 	}
 
+	if types.IsReceiptsStorageV2(data) {
+		receipts, err := types.DecodeReceiptsForStorage(data)
+		if err != nil {
+			return nil, false, fmt.Errorf("ReadBorReceipt failed decoding bor receipt with blockNumber=%d, err=%w", number, err)
+		}
+		if len(receipts) != 1 {
+			return nil, false, fmt.Errorf("ReadBorReceipt: %d bor receipts stored for blockNumber=%d", len(receipts), number)
+		}
+		return receipts[0], false, nil
+	}
+
 	var borReceipt *types.Receipt
 	err = cbor.Unmarshal(&borReceipt, bytes.NewReader(data))
 	if err == nil {
@@ -71,8 +82,8 @@ func ReadBorReceipt(db kv.Tx, blockHash libcommon.Hash, blockNumber uint64, rece
 			return nil, fmt.Errorf("ReadBorReceipt failed getting bor logs with blockNumber=%d, err=%s", blockNumber, err)
 		}
 		if logsData != nil {
-			var logs types.Logs
-			if err = cbor.Unmarshal(&logs, bytes.NewReader(logsData)); err != nil {
+			logs, err := types.DecodeLogsForStorage(logsData)
+			if err != nil {
 				return nil, fmt.Errorf("logs unmarshal failed:  %w", err)
 			}
 			borReceipt.Logs = logs
@@ -87,21 +98,20 @@ func ReadBorReceipt(db kv.Tx, blockHash libcommon.Hash, blockNumber uint64, rece
 // WriteBorReceipt stores all the bor receipt belonging to a block (storing the state sync receipt and log).
 func WriteBorReceipt(tx kv.RwTx, number uint64, borReceipt *types.Receipt) error {
 	// Convert the bor receipt into their storage form and serialize them
-	buf := bytes.NewBuffer(make([]byte, 0, 1024))
-	if err := cbor.Marshal(buf, borReceipt.Logs); err != nil {
+	logs, err := types.EncodeLogsForStorage(borReceipt.Logs)
+	if err != nil {
 		return err
 	}
-	if err := tx.Append(kv.Log, dbutils.LogKey(number, uint32(borReceipt.TransactionIndex)), buf.Bytes()); err != nil {
+	if err := tx.Append(kv.Log, dbutils.LogKey(number, uint32(borReceipt.TransactionIndex)), logs); err != nil {
 		return err
 	}
 
-	buf.Reset()
-	err := cbor.Marshal(buf, borReceipt)
+	// Stored in the same encoding as kv.Receipts, without logs
+	v, err := types.EncodeReceiptsForStorage(types.Receipts{borReceipt})
 	if err != nil {
 		return err
 	}
-	// Store the flattened receipt slice
-	if err := tx.Append(kv.BorReceipts, borReceiptKey(number), buf.Bytes()); err != nil {
+	if err := tx.Append(kv.BorReceipts, borReceiptKey(number), v); err != nil {
 		return err
 	}
 
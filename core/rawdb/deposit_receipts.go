@@ -0,0 +1,67 @@
+package rawdb
+
+import (
+	"fmt"
+
+	"github.com/erigontech/erigon-lib/chain"
+	libcommon "github.com/erigontech/erigon-lib/common"
+
+	"github.com/erigontech/erigon/core/types"
+)
+
+// RepairDepositReceipts brings OP-stack fields of the stored receipts of the block in line with the forks active
+// at it: deposit receipts have DepositNonce since Regolith and DepositReceiptVersion since Canyon, other receipts
+// have neither. Datasets migrated from older forks may lack them or have them where they don't belong, which
+// breaks the receipts root. nonceAt returns the nonce of an account at the start of the block; the deposit nonce
+// is it plus the number of preceding transactions of the sender in the block (accounts without code, which all
+// deposit senders on L2 are, only change their nonce by sending transactions).
+// Returns whether any receipt was changed.
+func RepairDepositReceipts(config *chain.Config, block *types.Block, senders []libcommon.Address, receipts types.Receipts, nonceAt func(libcommon.Address) (uint64, error)) (bool, error) {
+	txs := block.Transactions()
+	if len(receipts) != len(txs) || len(senders) != len(txs) {
+		return false, fmt.Errorf("block %d: %d txs, %d senders, %d receipts", block.NumberU64(), len(txs), len(senders), len(receipts))
+	}
+	regolith, canyon := config.IsOptimismRegolith(block.Time()), config.IsOptimismCanyon(block.Time())
+
+	var changed bool
+	sent := map[libcommon.Address]uint64{}
+	for i, txn := range txs {
+		r := receipts[i]
+		sender := senders[i]
+		var wantNonce, wantVersion *uint64
+		if txn.Type() == types.DepositTxType && regolith {
+			nonce, err := nonceAt(sender)
+			if err != nil {
+				return false, fmt.Errorf("block %d tx %d: nonce of %x: %w", block.NumberU64(), i, sender, err)
+			}
+			nonce += sent[sender]
+			wantNonce = &nonce
+			if canyon {
+				version := types.CanyonDepositReceiptVersion
+				wantVersion = &version
+			}
+		}
+		sent[sender]++
+
+		if r.Type != txn.Type() {
+			r.Type = txn.Type()
+			changed = true
+		}
+		if !equalOptional(r.DepositNonce, wantNonce) {
+			r.DepositNonce = wantNonce
+			changed = true
+		}
+		if !equalOptional(r.DepositReceiptVersion, wantVersion) {
+			r.DepositReceiptVersion = wantVersion
+			changed = true
+		}
+	}
+	return changed, nil
+}
+
+func equalOptional(a, b *uint64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,90 @@
+package rawdb_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/chain"
+	libcommon "github.com/erigontech/erigon-lib/common"
+
+	"github.com/erigontech/erigon/core/rawdb"
+	"github.com/erigontech/erigon/core/types"
+)
+
+func TestRepairDepositReceipts(t *testing.T) {
+	t.Parallel()
+
+	config := &chain.Config{
+		ChainID:      big.NewInt(288),
+		BedrockBlock: big.NewInt(0),
+		RegolithTime: big.NewInt(10),
+		CanyonTime:   big.NewInt(20),
+		Optimism:     &chain.OptimismConfig{EIP1559Elasticity: 6, EIP1559Denominator: 50},
+	}
+	depositor := libcommon.HexToAddress("0xdeaddeaddeaddeaddeaddeaddeaddeaddead0001")
+	user := libcommon.HexToAddress("0x1111")
+	to := libcommon.HexToAddress("0x4200000000000000000000000000000000000015")
+	txs := []types.Transaction{
+		&types.DepositTx{From: depositor, To: &to, Value: uint256.NewInt(0)},
+		&types.DepositTx{From: user, To: &to, Value: uint256.NewInt(0)},
+		&types.LegacyTx{CommonTx: types.CommonTx{To: &to, Value: uint256.NewInt(0)}, GasPrice: uint256.NewInt(1)},
+		&types.DepositTx{From: user, To: &to, Value: uint256.NewInt(0)},
+	}
+	senders := []libcommon.Address{depositor, user, user, user}
+	nonces := map[libcommon.Address]uint64{depositor: 100, user: 5}
+	nonceAt := func(addr libcommon.Address) (uint64, error) { return nonces[addr], nil }
+	newReceipts := func() types.Receipts {
+		receipts := make(types.Receipts, len(txs))
+		for i, txn := range txs {
+			receipts[i] = &types.Receipt{Type: txn.Type(), Status: types.ReceiptStatusSuccessful, CumulativeGasUsed: uint64(i+1) * 21000}
+		}
+		return receipts
+	}
+	block := func(time uint64) *types.Block {
+		return types.NewBlock(&types.Header{Number: big.NewInt(1), Time: time}, txs, nil, nil, nil)
+	}
+	u64 := func(v uint64) *uint64 { return &v }
+
+	// pre-Regolith: nothing to add
+	receipts := newReceipts()
+	changed, err := rawdb.RepairDepositReceipts(config, block(5), senders, receipts, nonceAt)
+	require.NoError(t, err)
+	require.False(t, changed)
+
+	// a deposit nonce where it doesn't belong is dropped
+	receipts[0].DepositNonce = u64(100)
+	changed, err = rawdb.RepairDepositReceipts(config, block(5), senders, receipts, nonceAt)
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Nil(t, receipts[0].DepositNonce)
+
+	// Regolith: deposit nonces, counting preceding transactions of the sender
+	receipts = newReceipts()
+	changed, err = rawdb.RepairDepositReceipts(config, block(15), senders, receipts, nonceAt)
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Equal(t, u64(100), receipts[0].DepositNonce)
+	require.Equal(t, u64(5), receipts[1].DepositNonce)
+	require.Nil(t, receipts[2].DepositNonce)
+	require.Equal(t, u64(7), receipts[3].DepositNonce)
+	for _, r := range receipts {
+		require.Nil(t, r.DepositReceiptVersion)
+	}
+	changed, err = rawdb.RepairDepositReceipts(config, block(15), senders, receipts, nonceAt)
+	require.NoError(t, err)
+	require.False(t, changed, "repaired receipts stay as they are")
+
+	// Canyon: and deposit receipt versions
+	changed, err = rawdb.RepairDepositReceipts(config, block(25), senders, receipts, nonceAt)
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Equal(t, u64(types.CanyonDepositReceiptVersion), receipts[0].DepositReceiptVersion)
+	require.Nil(t, receipts[2].DepositReceiptVersion)
+	require.Equal(t, u64(types.CanyonDepositReceiptVersion), receipts[3].DepositReceiptVersion)
+
+	_, err = rawdb.RepairDepositReceipts(config, block(25), senders[:1], receipts, nonceAt)
+	require.Error(t, err)
+}
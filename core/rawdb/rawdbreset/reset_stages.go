@@ -100,14 +100,14 @@ func ResetBlocks(tx kv.RwTx, db kv.RoDB, agg *state.Aggregator,
 	return nil
 }
 func ResetBorHeimdall(ctx context.Context, tx kv.RwTx) error {
-	if err := tx.ClearBucket(kv.BorEventNums); err != nil {
-		return err
-	}
-	if err := tx.ClearBucket(kv.BorEvents); err != nil {
+	tables, err := existingTables(tx, []string{kv.BorEventNums, kv.BorEvents, kv.BorSpans})
+	if err != nil {
 		return err
 	}
-	if err := tx.ClearBucket(kv.BorSpans); err != nil {
-		return err
+	for _, table := range tables {
+		if err := tx.ClearBucket(table); err != nil {
+			return err
+		}
 	}
 	return clearStageProgress(tx, stages.BorHeimdall)
 }
@@ -119,7 +119,14 @@ func ResetSenders(ctx context.Context, db kv.RwDB, tx kv.RwTx) error {
 }
 
 func WarmupExec(ctx context.Context, db kv.RwDB) (err error) {
-	for _, tbl := range stateBuckets {
+	var tables []string
+	if err := db.View(ctx, func(tx kv.Tx) (err error) {
+		tables, err = existingTables(tx, stateBuckets)
+		return err
+	}); err != nil {
+		return err
+	}
+	for _, tbl := range tables {
 		backup.WarmupTable(ctx, db, tbl, log.LvlInfo, backup.ReadAheadThreads)
 	}
 	historyV3 := kvcfg.HistoryV3.FromDB(db)
@@ -143,10 +150,14 @@ func ResetExec(ctx context.Context, db kv.RwDB, chain string, tmpDir string, log
 			return err
 		}
 
-		if err := backup.ClearTables(ctx, db, tx, stateBuckets...); err != nil {
+		tables, err := existingTables(tx, stateBuckets)
+		if err != nil {
+			return err
+		}
+		if err := backup.ClearTables(ctx, db, tx, tables...); err != nil {
 			return nil
 		}
-		for _, b := range stateBuckets {
+		for _, b := range tables {
 			if err := tx.ClearBucket(b); err != nil {
 				return err
 			}
@@ -217,6 +228,25 @@ var stateHistoryV4Buckets = []string{
 	kv.TblCommitmentKeys, kv.TblCommitmentVals, kv.TblCommitmentHistoryKeys, kv.TblCommitmentHistoryVals, kv.TblCommitmentIdx,
 }
 
+// existingTables - tables of the list the db has: chaindata of OP chains has no bor tables, see mdbx.WithoutBorTables
+func existingTables(tx kv.Tx, tables []string) ([]string, error) {
+	migrator, ok := tx.(kv.BucketMigrator)
+	if !ok {
+		return tables, nil
+	}
+	existing := make([]string, 0, len(tables))
+	for _, table := range tables {
+		exists, err := migrator.ExistsBucket(table)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			existing = append(existing, table)
+		}
+	}
+	return existing, nil
+}
+
 func clearStageProgress(tx kv.RwTx, stagesList ...stages.SyncStage) error {
 	for _, stage := range stagesList {
 		if err := stages.SaveStageProgress(tx, stage, 0); err != nil {
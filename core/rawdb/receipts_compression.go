@@ -0,0 +1,159 @@
+package rawdb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/klauspost/compress/dict"
+	"github.com/klauspost/compress/zstd"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/core/types"
+)
+
+// receiptsZstdDictKey - zstd dictionary of kv.Receipts and kv.Log values of the datadir, in kv.DatabaseInfo.
+// Written once: values compressed with it can't be read without it.
+var receiptsZstdDictKey = []byte("receipts.zstd.dict")
+
+const (
+	receiptsDictSamples    = 50_000 // latest values of each table
+	receiptsDictMinSamples = 1_000  // less is not worth a dictionary, values are compressed without it meanwhile
+	receiptsDictMaxSize    = 112 * 1024
+
+	receiptsDictPollInterval = time.Minute // see WatchReceiptsCompression
+)
+
+// ReadReceiptsZstdDict - dictionary of the datadir, nil if it's not trained yet
+func ReadReceiptsZstdDict(tx kv.Getter) ([]byte, error) {
+	v, err := tx.GetOne(kv.DatabaseInfo, receiptsZstdDictKey)
+	if err != nil {
+		return nil, err
+	}
+	return libcommon.Copy(v), nil
+}
+
+// SetupReceiptsCompression - loads the dictionary of the datadir for decoding of compressed receipts and logs,
+// with compress new values are compressed too. The dictionary is trained on the latest values of kv.Receipts and
+// kv.Log when there is none yet and enough of them are stored, until then values are compressed without it.
+func SetupReceiptsCompression(tx kv.RwTx, compress bool, logger log.Logger) error {
+	zdict, err := ReadReceiptsZstdDict(tx)
+	if err != nil {
+		return err
+	}
+	if zdict == nil && compress {
+		if zdict, err = trainReceiptsZstdDict(tx, logger); err != nil {
+			return err
+		}
+		if zdict != nil {
+			if err = tx.Put(kv.DatabaseInfo, receiptsZstdDictKey, zdict); err != nil {
+				return err
+			}
+		}
+	}
+	if compress {
+		logger.Info("[receipts] zstd compression of receipts and logs is on", "dictionary", libcommon.ByteCount(uint64(len(zdict))))
+	}
+	return types.SetReceiptsCompression(compress, zdict)
+}
+
+// LoadReceiptsCompression - SetupReceiptsCompression for readers of the db: they only decode values. A dictionary
+// trained by erigon later is not seen, see WatchReceiptsCompression for long-running readers.
+func LoadReceiptsCompression(tx kv.Getter) error {
+	zdict, err := ReadReceiptsZstdDict(tx)
+	if err != nil {
+		return err
+	}
+	return types.SetReceiptsCompression(false, zdict)
+}
+
+// WatchReceiptsCompression - LoadReceiptsCompression, and loads the dictionary again once erigon trains it: erigon
+// trains it when enough receipts are stored, readers started before don't have to be restarted. Until ctx is done.
+func WatchReceiptsCompression(ctx context.Context, db kv.RoDB, logger log.Logger) error {
+	var loaded []byte
+	load := func() error {
+		var zdict []byte
+		if err := db.View(ctx, func(tx kv.Tx) (err error) {
+			zdict, err = ReadReceiptsZstdDict(tx)
+			return err
+		}); err != nil {
+			return err
+		}
+		if bytes.Equal(zdict, loaded) {
+			return nil
+		}
+		if err := types.SetReceiptsCompression(false, zdict); err != nil {
+			return err
+		}
+		loaded = zdict
+		logger.Info("[receipts] Loaded zstd dictionary", "size", libcommon.ByteCount(uint64(len(zdict))))
+		return nil
+	}
+	if err := load(); err != nil {
+		return err
+	}
+	if loaded != nil {
+		return nil // written once
+	}
+	go func() {
+		ticker := time.NewTicker(receiptsDictPollInterval)
+		defer ticker.Stop()
+		for loaded == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := load(); err != nil {
+					logger.Warn("[receipts] Loading of zstd dictionary failed", "err", err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// trainReceiptsZstdDict - nil if there are not enough values to train on
+func trainReceiptsZstdDict(tx kv.Tx, logger log.Logger) ([]byte, error) {
+	var samples [][]byte
+	for _, table := range []string{kv.Receipts, kv.Log} {
+		c, err := tx.Cursor(table)
+		if err != nil {
+			return nil, err
+		}
+		var n int
+		for k, v, err := c.Last(); k != nil && n < receiptsDictSamples; k, v, err = c.Prev() {
+			if err != nil {
+				c.Close()
+				return nil, err
+			}
+			// legacy cbor values are rewritten in storage encoding v2 by the migration, train on what will be written
+			if len(v) == 0 || v[0] != types.ReceiptsStorageV2 {
+				continue
+			}
+			samples = append(samples, libcommon.Copy(v))
+			n++
+		}
+		c.Close()
+	}
+	if len(samples) < receiptsDictMinSamples {
+		logger.Info("[receipts] Not enough receipts to train zstd dictionary yet", "have", len(samples), "need", receiptsDictMinSamples)
+		return nil, nil
+	}
+
+	zdict, err := dict.BuildZstdDict(samples, dict.Options{
+		MaxDictSize: receiptsDictMaxSize,
+		HashBytes:   6,
+		ZstdDictID:  32768 + rand.Uint32N(1<<31-32768), // ids below 32768 are reserved
+		ZstdLevel:   zstd.SpeedBetterCompression,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("train receipts zstd dictionary: %w", err)
+	}
+	logger.Info("[receipts] Trained zstd dictionary", "samples", len(samples), "size", libcommon.ByteCount(uint64(len(zdict))))
+	return zdict, nil
+}
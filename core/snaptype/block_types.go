@@ -141,7 +141,7 @@ var (
 				}()
 				firstBlockNum := sn.From
 
-				bodiesSegment, err := seg.NewDecompressor(sn.As(Bodies).Path)
+				bodiesSegment, err := seg.NewDecompressorWithKeyProvider(sn.As(Bodies).Path, seg.KeyProviderFromContext(ctx))
 				if err != nil {
 					return fmt.Errorf("can't open %s for indexing: %w", sn.As(Bodies).Name(), err)
 				}
@@ -152,7 +152,7 @@ var (
 					return err
 				}
 
-				d, err := seg.NewDecompressor(sn.Path)
+				d, err := seg.NewDecompressorWithKeyProvider(sn.Path, seg.KeyProviderFromContext(ctx))
 				if err != nil {
 					return fmt.Errorf("can't open %s for indexing: %w", sn.Path, err)
 				}
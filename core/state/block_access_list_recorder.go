@@ -0,0 +1,135 @@
+package state
+
+import (
+	"bytes"
+	"slices"
+
+	"github.com/holiman/uint256"
+	"golang.org/x/exp/maps"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/core/types/accounts"
+)
+
+// BlockAccessListRecorder collects types.BlockAccessList of the block: it wraps reader and writer of the
+// block execution. Reads are served by IntraBlockState cache after the first one, so the recorder sees
+// every touched slot once, and writes of CommitBlock are values after the block.
+type BlockAccessListRecorder struct {
+	accounts map[libcommon.Address]*accountAccess
+}
+
+type accountAccess struct {
+	account *accounts.Account // nil - not changed
+	deleted bool
+	writes  map[libcommon.Hash]libcommon.Hash
+	reads   map[libcommon.Hash]struct{}
+}
+
+func NewBlockAccessListRecorder() *BlockAccessListRecorder {
+	return &BlockAccessListRecorder{accounts: map[libcommon.Address]*accountAccess{}}
+}
+
+func (r *BlockAccessListRecorder) touch(address libcommon.Address) *accountAccess {
+	a, ok := r.accounts[address]
+	if !ok {
+		a = &accountAccess{writes: map[libcommon.Hash]libcommon.Hash{}, reads: map[libcommon.Hash]struct{}{}}
+		r.accounts[address] = a
+	}
+	return a
+}
+
+// Reader - reader recording touched accounts and slots
+func (r *BlockAccessListRecorder) Reader(reader StateReader) StateReader {
+	return &balReader{StateReader: reader, r: r}
+}
+
+// Writer - writer recording values after the block
+func (r *BlockAccessListRecorder) Writer(writer WriterWithChangeSets) WriterWithChangeSets {
+	return &balWriter{WriterWithChangeSets: writer, r: r}
+}
+
+// AccessList - collected list, ordered
+func (r *BlockAccessListRecorder) AccessList() *types.BlockAccessList {
+	addresses := maps.Keys(r.accounts)
+	slices.SortFunc(addresses, func(a, b libcommon.Address) int { return bytes.Compare(a[:], b[:]) })
+	compareHashes := func(a, b libcommon.Hash) int { return bytes.Compare(a[:], b[:]) }
+
+	bal := &types.BlockAccessList{Accounts: make([]*types.AccountAccess, 0, len(addresses))}
+	for _, address := range addresses {
+		a := r.accounts[address]
+		res := &types.AccountAccess{Address: address, Deleted: a.deleted}
+		if a.account != nil {
+			nonce, codeHash := hexutil.Uint64(a.account.Nonce), a.account.CodeHash
+			res.Nonce, res.Balance, res.CodeHash = &nonce, (*hexutil.Big)(a.account.Balance.ToBig()), &codeHash
+		}
+		slots := maps.Keys(a.writes)
+		slices.SortFunc(slots, compareHashes)
+		for _, slot := range slots {
+			res.StorageWrites = append(res.StorageWrites, types.StorageWrite{Slot: slot, Value: a.writes[slot]})
+		}
+		for slot := range a.reads {
+			if _, ok := a.writes[slot]; !ok {
+				res.StorageReads = append(res.StorageReads, slot)
+			}
+		}
+		slices.SortFunc(res.StorageReads, compareHashes)
+		bal.Accounts = append(bal.Accounts, res)
+	}
+	return bal
+}
+
+type balReader struct {
+	StateReader
+	r *BlockAccessListRecorder
+}
+
+func (b *balReader) ReadAccountData(address libcommon.Address) (*accounts.Account, error) {
+	b.r.touch(address)
+	return b.StateReader.ReadAccountData(address)
+}
+
+func (b *balReader) ReadAccountStorage(address libcommon.Address, incarnation uint64, key *libcommon.Hash) ([]byte, error) {
+	b.r.touch(address).reads[*key] = struct{}{}
+	return b.StateReader.ReadAccountStorage(address, incarnation, key)
+}
+
+func (b *balReader) ReadAccountCode(address libcommon.Address, incarnation uint64, codeHash libcommon.Hash) ([]byte, error) {
+	b.r.touch(address)
+	return b.StateReader.ReadAccountCode(address, incarnation, codeHash)
+}
+
+func (b *balReader) ReadAccountCodeSize(address libcommon.Address, incarnation uint64, codeHash libcommon.Hash) (int, error) {
+	b.r.touch(address)
+	return b.StateReader.ReadAccountCodeSize(address, incarnation, codeHash)
+}
+
+type balWriter struct {
+	WriterWithChangeSets
+	r *BlockAccessListRecorder
+}
+
+func (b *balWriter) UpdateAccountData(address libcommon.Address, original, account *accounts.Account) error {
+	a := b.r.touch(address)
+	a.account, a.deleted = new(accounts.Account), false
+	a.account.Copy(account)
+	return b.WriterWithChangeSets.UpdateAccountData(address, original, account)
+}
+
+func (b *balWriter) DeleteAccount(address libcommon.Address, original *accounts.Account) error {
+	a := b.r.touch(address)
+	a.account, a.deleted = nil, true
+	return b.WriterWithChangeSets.DeleteAccount(address, original)
+}
+
+func (b *balWriter) WriteAccountStorage(address libcommon.Address, incarnation uint64, key *libcommon.Hash, original, value *uint256.Int) error {
+	b.r.touch(address).writes[*key] = value.Bytes32()
+	return b.WriterWithChangeSets.WriteAccountStorage(address, incarnation, key, original, value)
+}
+
+func (b *balWriter) CreateContract(address libcommon.Address) error {
+	b.r.touch(address)
+	return b.WriterWithChangeSets.CreateContract(address)
+}
@@ -0,0 +1,52 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/chain"
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/kv/memdb"
+
+	"github.com/erigontech/erigon/core/types"
+)
+
+func TestBlockAccessListRecorder(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	reader, writer := NewPlainStateReader(tx), NewPlainStateWriter(tx, tx, 1)
+
+	// state before the block
+	ibs := New(reader)
+	sender, contract := libcommon.Address{2}, libcommon.Address{1}
+	written, read := libcommon.Hash{2}, libcommon.Hash{1}
+	ibs.AddBalance(sender, uint256.NewInt(100))
+	ibs.SetNonce(contract, 1)
+	ibs.SetState(contract, &read, *uint256.NewInt(7))
+	require.NoError(t, ibs.CommitBlock(&chain.Rules{}, writer))
+
+	recorder := NewBlockAccessListRecorder()
+	ibs = New(recorder.Reader(reader))
+	var value uint256.Int
+	ibs.GetState(contract, &read, &value)
+	require.Equal(t, uint64(7), value.Uint64())
+	ibs.GetState(contract, &written, &value)
+	ibs.SetState(contract, &written, *uint256.NewInt(5))
+	ibs.SubBalance(sender, uint256.NewInt(10))
+	ibs.SetNonce(sender, 1)
+	require.NoError(t, ibs.CommitBlock(&chain.Rules{}, recorder.Writer(NewPlainStateWriter(tx, tx, 2))))
+
+	bal := recorder.AccessList()
+	require.Len(t, bal.Accounts, 2)
+	require.Equal(t, contract, bal.Accounts[0].Address)
+	require.Equal(t, []types.StorageWrite{{Slot: written, Value: uint256.NewInt(5).Bytes32()}}, bal.Accounts[0].StorageWrites)
+	require.Equal(t, []libcommon.Hash{read}, bal.Accounts[0].StorageReads)
+
+	nonce := hexutil.Uint64(1)
+	require.Equal(t, sender, bal.Accounts[1].Address)
+	require.Equal(t, &nonce, bal.Accounts[1].Nonce)
+	require.Equal(t, uint64(90), bal.Accounts[1].Balance.ToInt().Uint64())
+	require.Empty(t, bal.Accounts[1].StorageWrites)
+}
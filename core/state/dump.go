@@ -18,6 +18,7 @@ package state
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/erigontech/erigon-lib/kv/dbutils"
@@ -126,6 +127,22 @@ func (d iterativeDump) OnRoot(root libcommon.Hash) {
 	}{root})
 }
 
+// streamDump is an iterativeDump counting the accounts. The root is written once by its user, not for each
+// batch of accounts.
+type streamDump struct {
+	iterativeDump
+	accounts uint64
+}
+
+// OnRoot implements DumpCollector interface
+func (d *streamDump) OnRoot(libcommon.Hash) {}
+
+// OnAccount implements DumpCollector interface
+func (d *streamDump) OnAccount(addr libcommon.Address, account DumpAccount) {
+	d.iterativeDump.OnAccount(addr, account)
+	d.accounts++
+}
+
 func NewDumper(db kv.Tx, blockNumber uint64, historyV3 bool) *Dumper {
 	return &Dumper{
 		db:          db,
@@ -325,6 +342,35 @@ func (d *Dumper) IterativeDump(excludeCode, excludeStorage bool, output *json.En
 	d.DumpToCollector(iterativeDump{output}, excludeCode, excludeStorage, libcommon.Address{}, 0)
 }
 
+// StreamDump writes the state in the line-by-line format of `geth dump --iterative`: the state root followed by
+// one account per line. Accounts are read batchSize at a time, so unlike IterativeDump the memory used doesn't
+// grow with the state. onBatch, if set, is called with the number of accounts written so far after each batch.
+func (d *Dumper) StreamDump(ctx context.Context, root libcommon.Hash, excludeCode, excludeStorage bool, batchSize int, output *json.Encoder, onBatch func(accounts uint64)) (uint64, error) {
+	if err := output.Encode(struct {
+		Root libcommon.Hash `json:"root"`
+	}{root}); err != nil {
+		return 0, err
+	}
+	c := &streamDump{iterativeDump: iterativeDump{output}}
+	var start libcommon.Address
+	for {
+		if err := ctx.Err(); err != nil {
+			return c.accounts, err
+		}
+		next, err := d.DumpToCollector(c, excludeCode, excludeStorage, start, batchSize)
+		if err != nil {
+			return c.accounts, err
+		}
+		if onBatch != nil {
+			onBatch(c.accounts)
+		}
+		if next == nil {
+			return c.accounts, nil
+		}
+		start = libcommon.BytesToAddress(next)
+	}
+}
+
 // IteratorDump dumps out a batch of accounts starts with the given start key
 func (d *Dumper) IteratorDump(excludeCode, excludeStorage bool, start libcommon.Address, maxResults int) (IteratorDump, error) {
 	iterator := &IteratorDump{
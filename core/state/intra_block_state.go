@@ -143,8 +143,14 @@ func (sdb *IntraBlockState) Reset() {
 	sdb.logSize = 0
 }
 
+// Release returns the journal to the pool once the block is executed. The state must not be used afterwards.
+func (sdb *IntraBlockState) Release() {
+	sdb.journal.release()
+	sdb.journal = nil
+}
+
 func (sdb *IntraBlockState) AddLog(log2 *types.Log) {
-	sdb.journal.append(addLogChange{txhash: sdb.thash})
+	sdb.journal.append(journalEntry{kind: addLogChange, key: sdb.thash})
 	log2.TxHash = sdb.thash
 	log2.BlockHash = sdb.bhash
 	log2.TxIndex = uint(sdb.txIndex)
@@ -167,14 +173,14 @@ func (sdb *IntraBlockState) Logs() []*types.Log {
 
 // AddRefund adds gas to the refund counter
 func (sdb *IntraBlockState) AddRefund(gas uint64) {
-	sdb.journal.append(refundChange{prev: sdb.refund})
+	sdb.journal.append(journalEntry{kind: refundChange, prevUint: sdb.refund})
 	sdb.refund += gas
 }
 
 // SubRefund removes gas from the refund counter.
 // This method will panic if the refund counter goes below zero
 func (sdb *IntraBlockState) SubRefund(gas uint64) {
-	sdb.journal.append(refundChange{prev: sdb.refund})
+	sdb.journal.append(journalEntry{kind: refundChange, prevUint: sdb.refund})
 	if gas > sdb.refund {
 		sdb.setErrorUnsafe(fmt.Errorf("refund counter below zero"))
 	}
@@ -350,9 +356,10 @@ func (sdb *IntraBlockState) AddBalance(addr libcommon.Address, amount *uint256.I
 		needAccount = true
 	}
 	if !needAccount {
-		sdb.journal.append(balanceIncrease{
-			account:  &addr,
-			increase: *amount,
+		sdb.journal.append(journalEntry{
+			kind:    balanceIncrease,
+			account: addr,
+			prev:    *amount,
 		})
 		bi, ok := sdb.balanceInc[addr]
 		if !ok {
@@ -449,10 +456,11 @@ func (sdb *IntraBlockState) Selfdestruct(addr libcommon.Address) bool {
 	if stateObject == nil || stateObject.deleted {
 		return false
 	}
-	sdb.journal.append(selfdestructChange{
-		account:     &addr,
-		prev:        stateObject.selfdestructed,
-		prevbalance: *stateObject.Balance(),
+	sdb.journal.append(journalEntry{
+		kind:               selfdestructChange,
+		account:            addr,
+		prevSelfdestructed: stateObject.selfdestructed,
+		prev:               *stateObject.Balance(),
 	})
 	stateObject.markSelfdestructed()
 	stateObject.createdContract = false
@@ -482,10 +490,11 @@ func (sdb *IntraBlockState) SetTransientState(addr libcommon.Address, key libcom
 		return
 	}
 
-	sdb.journal.append(transientStorageChange{
-		account:  &addr,
-		key:      key,
-		prevalue: prev,
+	sdb.journal.append(journalEntry{
+		kind:    transientStorageChange,
+		account: addr,
+		key:     key,
+		prev:    prev,
 	})
 
 	sdb.setTransientState(addr, key, value)
@@ -538,7 +547,7 @@ func (sdb *IntraBlockState) setStateObject(addr libcommon.Address, object *state
 	if bi, ok := sdb.balanceInc[addr]; ok && !bi.transferred {
 		object.data.Balance.Add(&object.data.Balance, &bi.increase)
 		bi.transferred = true
-		sdb.journal.append(balanceIncreaseTransfer{bi: bi})
+		sdb.journal.append(journalEntry{kind: balanceIncreaseTransfer, bi: bi})
 	}
 	sdb.stateObjects[addr] = object
 }
@@ -566,9 +575,9 @@ func (sdb *IntraBlockState) createObject(addr libcommon.Address, previous *state
 	newobj = newObject(sdb, addr, account, original)
 	newobj.setNonce(0) // sets the object to dirty
 	if previous == nil {
-		sdb.journal.append(createObjectChange{account: &addr})
+		sdb.journal.append(journalEntry{kind: createObjectChange, account: addr})
 	} else {
-		sdb.journal.append(resetObjectChange{account: &addr, prev: previous})
+		sdb.journal.append(journalEntry{kind: resetObjectChange, account: addr, prevObject: previous})
 	}
 	newobj.newlyCreated = true
 	sdb.setStateObject(addr, newobj)
@@ -706,7 +715,7 @@ func (sdb *IntraBlockState) FinalizeTx(chainRules *chain.Rules, stateWriter Stat
 			sdb.getStateObject(addr)
 		}
 	}
-	for addr := range sdb.journal.dirties {
+	if err := sdb.journal.dirties.forEach(func(addr libcommon.Address) error {
 		so, exist := sdb.stateObjects[addr]
 		if !exist {
 			// ripeMD is 'touched' at block 1714175, in tx 0x1237f737031e40bcde4a8b7e717b2d15e3ecadfe49bb1bbc71ee9deb09c6fcf2
@@ -715,7 +724,7 @@ func (sdb *IntraBlockState) FinalizeTx(chainRules *chain.Rules, stateWriter Stat
 			// it will persist in the journal even though the journal is reverted. In this special circumstance,
 			// it may exist in `sdb.journal.dirties` but not in `sdb.stateObjects`.
 			// Thus, we can safely ignore it here
-			continue
+			return nil
 		}
 
 		if err := updateAccount(chainRules.IsSpuriousDragon, chainRules.IsAura, stateWriter, addr, so, true); err != nil {
@@ -723,6 +732,9 @@ func (sdb *IntraBlockState) FinalizeTx(chainRules *chain.Rules, stateWriter Stat
 		}
 		so.newlyCreated = false
 		sdb.stateObjectsDirty[addr] = struct{}{}
+		return nil
+	}); err != nil {
+		return err
 	}
 	// Invalidate journal because reverting across transactions is not allowed.
 	sdb.clearJournalAndRefund()
@@ -751,9 +763,10 @@ func (sdb *IntraBlockState) BalanceIncreaseSet() map[libcommon.Address]uint256.I
 }
 
 func (sdb *IntraBlockState) MakeWriteSet(chainRules *chain.Rules, stateWriter StateWriter) error {
-	for addr := range sdb.journal.dirties {
+	_ = sdb.journal.dirties.forEach(func(addr libcommon.Address) error {
 		sdb.stateObjectsDirty[addr] = struct{}{}
-	}
+		return nil
+	})
 	for addr, stateObject := range sdb.stateObjects {
 		_, isDirty := sdb.stateObjectsDirty[addr]
 		if err := updateAccount(chainRules.IsSpuriousDragon, chainRules.IsAura, stateWriter, addr, stateObject, isDirty); err != nil {
@@ -768,7 +781,7 @@ func (sdb *IntraBlockState) MakeWriteSet(chainRules *chain.Rules, stateWriter St
 func (sdb *IntraBlockState) Print(chainRules chain.Rules) {
 	for addr, stateObject := range sdb.stateObjects {
 		_, isDirty := sdb.stateObjectsDirty[addr]
-		_, isDirty2 := sdb.journal.dirties[addr]
+		isDirty2 := sdb.journal.dirties.get(addr) > 0
 
 		printAccount(chainRules.IsSpuriousDragon, addr, stateObject, isDirty || isDirty2)
 	}
@@ -785,7 +798,7 @@ func (sdb *IntraBlockState) SetTxContext(thash, bhash libcommon.Hash, ti int) {
 
 // no not lock
 func (sdb *IntraBlockState) clearJournalAndRefund() {
-	sdb.journal = newJournal()
+	sdb.journal.reset()
 	sdb.validRevisions = sdb.validRevisions[:0]
 	sdb.refund = 0
 }
@@ -852,7 +865,7 @@ func (sdb *IntraBlockState) Prepare(rules *chain.Rules, sender, coinbase libcomm
 func (sdb *IntraBlockState) AddAddressToAccessList(addr libcommon.Address) (addrMod bool) {
 	addrMod = sdb.accessList.AddAddress(addr)
 	if addrMod {
-		sdb.journal.append(accessListAddAccountChange{&addr})
+		sdb.journal.append(journalEntry{kind: accessListAddAccountChange, account: addr})
 	}
 	return addrMod
 }
@@ -865,12 +878,13 @@ func (sdb *IntraBlockState) AddSlotToAccessList(addr libcommon.Address, slot lib
 		// scope of 'address' without having the 'address' become already added
 		// to the access list (via call-variant, create, etc).
 		// Better safe than sorry, though
-		sdb.journal.append(accessListAddAccountChange{&addr})
+		sdb.journal.append(journalEntry{kind: accessListAddAccountChange, account: addr})
 	}
 	if slotMod {
-		sdb.journal.append(accessListAddSlotChange{
-			address: &addr,
-			slot:    &slot,
+		sdb.journal.append(journalEntry{
+			kind:    accessListAddSlotChange,
+			account: addr,
+			key:     slot,
 		})
 	}
 	return addrMod, slotMod
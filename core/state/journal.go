@@ -17,296 +17,321 @@
 package state
 
 import (
+	"sync"
+
 	libcommon "github.com/erigontech/erigon-lib/common"
 	"github.com/holiman/uint256"
 )
 
-// journalEntry is a modification entry in the state change journal that can be
-// reverted on demand.
-type journalEntry interface {
-	// revert undoes the changes introduced by this journal entry.
-	revert(*IntraBlockState)
-
-	// dirtied returns the Ethereum address modified by this journal entry.
-	dirtied() *libcommon.Address
-}
-
-// journal contains the list of state modifications applied since the last state
-// commit. These are tracked to be able to be reverted in case of an execution
-// exception or revertal request.
-type journal struct {
-	entries []journalEntry            // Current changes tracked by the journal
-	dirties map[libcommon.Address]int // Dirty accounts and the number of changes
-}
-
-// newJournal create a new initialized journal.
-func newJournal() *journal {
-	return &journal{
-		dirties: make(map[libcommon.Address]int),
-	}
-}
-
-// append inserts a new modification entry to the end of the change journal.
-func (j *journal) append(entry journalEntry) {
-	j.entries = append(j.entries, entry)
-	if addr := entry.dirtied(); addr != nil {
-		j.dirties[*addr]++
-	}
-}
-
-// revert undoes a batch of journalled modifications along with any reverted
-// dirty handling too.
-func (j *journal) revert(statedb *IntraBlockState, snapshot int) {
-	for i := len(j.entries) - 1; i >= snapshot; i-- {
-		// Undo the changes made by the operation
-		j.entries[i].revert(statedb)
-
-		// Drop any dirty tracking induced by the change
-		if addr := j.entries[i].dirtied(); addr != nil {
-			if j.dirties[*addr]--; j.dirties[*addr] == 0 {
-				delete(j.dirties, *addr)
-			}
-		}
-	}
-	j.entries = j.entries[:snapshot]
-}
-
-// dirty explicitly sets an address to dirty, even if the change entries would
-// otherwise suggest it as clean. This method is an ugly hack to handle the RIPEMD
-// precompile consensus exception.
-func (j *journal) dirty(addr libcommon.Address) {
-	j.dirties[addr]++
-}
-
-// length returns the current number of entries in the journal.
-func (j *journal) length() int {
-	return len(j.entries)
-}
+// journalEntryKind is the type of a modification in the state change journal.
+type journalEntryKind uint8
 
-type (
+const (
 	// Changes to the account trie.
-	createObjectChange struct {
-		account *libcommon.Address
-	}
-	resetObjectChange struct {
-		account *libcommon.Address
-		prev    *stateObject
-	}
-	selfdestructChange struct {
-		account     *libcommon.Address
-		prev        bool // whether account had already selfdestructed
-		prevbalance uint256.Int
-	}
+	createObjectChange journalEntryKind = iota + 1
+	resetObjectChange
+	selfdestructChange
 
 	// Changes to individual accounts.
-	balanceChange struct {
-		account *libcommon.Address
-		prev    uint256.Int
-	}
-	balanceIncrease struct {
-		account  *libcommon.Address
-		increase uint256.Int
-	}
-	balanceIncreaseTransfer struct {
-		bi *BalanceIncrease
-	}
-	nonceChange struct {
-		account *libcommon.Address
-		prev    uint64
-	}
-	storageChange struct {
-		account  *libcommon.Address
-		key      libcommon.Hash
-		prevalue uint256.Int
-	}
-	fakeStorageChange struct {
-		account  *libcommon.Address
-		key      libcommon.Hash
-		prevalue uint256.Int
-	}
-	codeChange struct {
-		account  *libcommon.Address
-		prevcode []byte
-		prevhash libcommon.Hash
-	}
+	balanceChange
+	balanceIncrease
+	balanceIncreaseTransfer
+	nonceChange
+	storageChange
+	fakeStorageChange
+	codeChange
 
 	// Changes to other state values.
-	refundChange struct {
-		prev uint64
-	}
-	addLogChange struct {
-		txhash libcommon.Hash
-	}
-	touchChange struct {
-		account *libcommon.Address
-	}
+	refundChange
+	addLogChange
+	touchChange
 
 	// Changes to the access list
-	accessListAddAccountChange struct {
-		address *libcommon.Address
-	}
-	accessListAddSlotChange struct {
-		address *libcommon.Address
-		slot    *libcommon.Hash
-	}
+	accessListAddAccountChange
+	accessListAddSlotChange
 
-	transientStorageChange struct {
-		account  *libcommon.Address
-		key      libcommon.Hash
-		prevalue uint256.Int
-	}
+	transientStorageChange
 )
 
-func (ch createObjectChange) revert(s *IntraBlockState) {
-	delete(s.stateObjects, *ch.account)
-	delete(s.stateObjectsDirty, *ch.account)
-}
-
-func (ch createObjectChange) dirtied() *libcommon.Address {
-	return ch.account
-}
-
-func (ch resetObjectChange) revert(s *IntraBlockState) {
-	s.setStateObject(*ch.account, ch.prev)
-}
-
-func (ch resetObjectChange) dirtied() *libcommon.Address {
-	return nil
+// journalEntry is a modification entry in the state change journal that can be
+// reverted on demand. Entries are stored by value, so journalling a change does not
+// allocate: the fields are shared by the kinds of changes.
+type journalEntry struct {
+	kind journalEntryKind
+	// selfdestructChange: whether account had already selfdestructed
+	prevSelfdestructed bool
+	account            libcommon.Address
+	// storage or transient storage key, access list slot, log tx hash or previous code hash
+	key libcommon.Hash
+	// previous balance or storage value, or balance increase
+	prev uint256.Int
+	// previous nonce or refund
+	prevUint   uint64
+	prevObject *stateObject
+	prevCode   []byte
+	bi         *BalanceIncrease
+}
+
+// revert undoes the changes introduced by this journal entry.
+func (e *journalEntry) revert(s *IntraBlockState) {
+	switch e.kind {
+	case createObjectChange:
+		delete(s.stateObjects, e.account)
+		delete(s.stateObjectsDirty, e.account)
+	case resetObjectChange:
+		s.setStateObject(e.account, e.prevObject)
+	case selfdestructChange:
+		obj := s.getStateObject(e.account)
+		if obj != nil {
+			obj.selfdestructed = e.prevSelfdestructed
+			obj.setBalance(&e.prev)
+		}
+	case balanceChange:
+		s.getStateObject(e.account).setBalance(&e.prev)
+	case balanceIncrease:
+		if bi, ok := s.balanceInc[e.account]; ok {
+			bi.increase.Sub(&bi.increase, &e.prev)
+			bi.count--
+			if bi.count == 0 {
+				delete(s.balanceInc, e.account)
+			}
+		}
+	case balanceIncreaseTransfer:
+		e.bi.transferred = false
+	case nonceChange:
+		s.getStateObject(e.account).setNonce(e.prevUint)
+	case storageChange:
+		s.getStateObject(e.account).setState(&e.key, e.prev)
+	case fakeStorageChange:
+		s.getStateObject(e.account).fakeStorage[e.key] = e.prev
+	case codeChange:
+		s.getStateObject(e.account).setCode(e.key, e.prevCode)
+	case refundChange:
+		s.refund = e.prevUint
+	case addLogChange:
+		logs := s.logs[e.key]
+		if len(logs) == 1 {
+			delete(s.logs, e.key)
+		} else {
+			s.logs[e.key] = logs[:len(logs)-1]
+		}
+		s.logSize--
+	case touchChange:
+	case accessListAddAccountChange:
+		/*
+			One important invariant here, is that whenever a (addr, slot) is added, if the
+			addr is not already present, the add causes two journal entries:
+			- one for the address,
+			- one for the (address,slot)
+			Therefore, when unrolling the change, we can always blindly delete the
+			(addr) at this point, since no storage adds can remain when come upon
+			a single (addr) change.
+		*/
+		s.accessList.DeleteAddress(e.account)
+	case accessListAddSlotChange:
+		s.accessList.DeleteSlot(e.account, e.key)
+	case transientStorageChange:
+		s.setTransientState(e.account, e.key, e.prev)
+	}
 }
 
-func (ch selfdestructChange) revert(s *IntraBlockState) {
-	obj := s.getStateObject(*ch.account)
-	if obj != nil {
-		obj.selfdestructed = ch.prev
-		obj.setBalance(&ch.prevbalance)
+// dirtied returns whether the entry modifies the Ethereum address e.account.
+func (e *journalEntry) dirtied() bool {
+	switch e.kind {
+	case createObjectChange, selfdestructChange, touchChange,
+		balanceChange, balanceIncrease, nonceChange, storageChange, fakeStorageChange, codeChange:
+		return true
+	default:
+		return false
 	}
 }
 
-func (ch selfdestructChange) dirtied() *libcommon.Address {
-	return ch.account
+// journal contains the list of state modifications applied since the last state
+// commit. These are tracked to be able to be reverted in case of an execution
+// exception or revertal request.
+type journal struct {
+	entries []journalEntry // Current changes tracked by the journal
+	dirties dirtyAccounts  // Dirty accounts and the number of changes
 }
 
-var ripemd = libcommon.HexToAddress("0000000000000000000000000000000000000003")
+// dirtyInline - number of dirty accounts kept without a map, enough for most transactions
+const dirtyInline = 8
 
-func (ch touchChange) revert(s *IntraBlockState) {
+// dirtyAccounts - dirty accounts and the number of their changes. The first dirtyInline accounts are kept in an
+// array; the map is allocated and the array copied into it only by the write of an account over that, and the map
+// is kept on reset, so neither the common transactions nor the later big ones allocate.
+type dirtyAccounts struct {
+	inline [dirtyInline]dirtyAccount
+	n      int                       // accounts in inline, unused once promoted
+	m      map[libcommon.Address]int // all accounts, if promoted
+	inMap  bool                      // promoted: m is in use
 }
 
-func (ch touchChange) dirtied() *libcommon.Address {
-	return ch.account
+type dirtyAccount struct {
+	addr  libcommon.Address
+	count int
 }
 
-func (ch balanceChange) revert(s *IntraBlockState) {
-	s.getStateObject(*ch.account).setBalance(&ch.prev)
+func (d *dirtyAccounts) len() int {
+	if d.inMap {
+		return len(d.m)
+	}
+	return d.n
 }
 
-func (ch balanceChange) dirtied() *libcommon.Address {
-	return ch.account
+// get returns the number of changes of addr, 0 if it's not dirty.
+func (d *dirtyAccounts) get(addr libcommon.Address) int {
+	if d.inMap {
+		return d.m[addr]
+	}
+	for i := 0; i < d.n; i++ {
+		if d.inline[i].addr == addr {
+			return d.inline[i].count
+		}
+	}
+	return 0
 }
 
-func (ch balanceIncrease) revert(s *IntraBlockState) {
-	if bi, ok := s.balanceInc[*ch.account]; ok {
-		bi.increase.Sub(&bi.increase, &ch.increase)
-		bi.count--
-		if bi.count == 0 {
-			delete(s.balanceInc, *ch.account)
+func (d *dirtyAccounts) inc(addr libcommon.Address) {
+	if d.inMap {
+		d.m[addr]++
+		return
+	}
+	for i := 0; i < d.n; i++ {
+		if d.inline[i].addr == addr {
+			d.inline[i].count++
+			return
 		}
 	}
+	if d.n < dirtyInline {
+		d.inline[d.n] = dirtyAccount{addr: addr, count: 1}
+		d.n++
+		return
+	}
+	if d.m == nil {
+		d.m = make(map[libcommon.Address]int, 2*dirtyInline)
+	}
+	for _, a := range d.inline {
+		d.m[a.addr] = a.count
+	}
+	d.m[addr] = 1
+	d.inMap = true
 }
 
-func (ch balanceIncrease) dirtied() *libcommon.Address {
-	return ch.account
+func (d *dirtyAccounts) dec(addr libcommon.Address) {
+	if d.inMap {
+		if d.m[addr]--; d.m[addr] == 0 {
+			delete(d.m, addr)
+		}
+		return
+	}
+	for i := 0; i < d.n; i++ {
+		if d.inline[i].addr != addr {
+			continue
+		}
+		if d.inline[i].count--; d.inline[i].count == 0 {
+			d.n--
+			d.inline[i] = d.inline[d.n]
+		}
+		return
+	}
 }
 
-func (ch balanceIncreaseTransfer) dirtied() *libcommon.Address {
+// forEach calls f for the dirty accounts, which must not be changed meanwhile.
+func (d *dirtyAccounts) forEach(f func(addr libcommon.Address) error) error {
+	if d.inMap {
+		for addr := range d.m {
+			if err := f(addr); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for i := 0; i < d.n; i++ {
+		if err := f(d.inline[i].addr); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func (ch balanceIncreaseTransfer) revert(s *IntraBlockState) {
-	ch.bi.transferred = false
-}
-func (ch nonceChange) revert(s *IntraBlockState) {
-	s.getStateObject(*ch.account).setNonce(ch.prev)
-}
-
-func (ch nonceChange) dirtied() *libcommon.Address {
-	return ch.account
-}
-
-func (ch codeChange) revert(s *IntraBlockState) {
-	s.getStateObject(*ch.account).setCode(ch.prevhash, ch.prevcode)
-}
-
-func (ch codeChange) dirtied() *libcommon.Address {
-	return ch.account
-}
-
-func (ch storageChange) revert(s *IntraBlockState) {
-	s.getStateObject(*ch.account).setState(&ch.key, ch.prevalue)
-}
-
-func (ch storageChange) dirtied() *libcommon.Address {
-	return ch.account
-}
-
-func (ch fakeStorageChange) revert(s *IntraBlockState) {
-	s.getStateObject(*ch.account).fakeStorage[ch.key] = ch.prevalue
+func (d *dirtyAccounts) reset() {
+	if d.inMap {
+		if len(d.m) > maxPooledJournalDirties {
+			d.m = nil // don't keep the buckets of a huge transaction
+		} else {
+			clear(d.m)
+		}
+		d.inMap = false
+	}
+	d.n = 0
 }
 
-func (ch fakeStorageChange) dirtied() *libcommon.Address {
-	return ch.account
-}
+// Journals are reused: reset between transactions and pooled between blocks.
+// The memory grown by huge transactions is not kept.
+const (
+	maxPooledJournalEntries = 1 << 16
+	maxPooledJournalDirties = 1 << 12
+)
 
-func (ch transientStorageChange) revert(s *IntraBlockState) {
-	s.setTransientState(*ch.account, ch.key, ch.prevalue)
-}
+var journalPool = sync.Pool{New: func() any { return &journal{} }}
 
-func (ch transientStorageChange) dirtied() *libcommon.Address {
-	return nil
+// newJournal takes an empty journal from the pool.
+func newJournal() *journal {
+	return journalPool.Get().(*journal)
 }
 
-func (ch refundChange) revert(s *IntraBlockState) {
-	s.refund = ch.prev
+// release returns the journal to the pool, it must not be used afterwards.
+func (j *journal) release() {
+	if cap(j.entries) > maxPooledJournalEntries {
+		return
+	}
+	j.reset()
+	journalPool.Put(j)
 }
 
-func (ch refundChange) dirtied() *libcommon.Address {
-	return nil
+// reset empties the journal keeping the allocated memory.
+func (j *journal) reset() {
+	clear(j.entries) // drop references to state objects and code
+	j.entries = j.entries[:0]
+	j.dirties.reset()
 }
 
-func (ch addLogChange) revert(s *IntraBlockState) {
-	logs := s.logs[ch.txhash]
-	if len(logs) == 1 {
-		delete(s.logs, ch.txhash)
-	} else {
-		s.logs[ch.txhash] = logs[:len(logs)-1]
+// append inserts a new modification entry to the end of the change journal.
+func (j *journal) append(entry journalEntry) {
+	j.entries = append(j.entries, entry)
+	if entry.dirtied() {
+		j.dirty(entry.account)
 	}
-	s.logSize--
 }
 
-func (ch addLogChange) dirtied() *libcommon.Address {
-	return nil
-}
+// revert undoes a batch of journalled modifications along with any reverted
+// dirty handling too.
+func (j *journal) revert(statedb *IntraBlockState, snapshot int) {
+	for i := len(j.entries) - 1; i >= snapshot; i-- {
+		entry := &j.entries[i]
+		// Undo the changes made by the operation
+		entry.revert(statedb)
 
-func (ch accessListAddAccountChange) revert(s *IntraBlockState) {
-	/*
-		One important invariant here, is that whenever a (addr, slot) is added, if the
-		addr is not already present, the add causes two journal entries:
-		- one for the address,
-		- one for the (address,slot)
-		Therefore, when unrolling the change, we can always blindly delete the
-		(addr) at this point, since no storage adds can remain when come upon
-		a single (addr) change.
-	*/
-	s.accessList.DeleteAddress(*ch.address)
+		// Drop any dirty tracking induced by the change
+		if entry.dirtied() {
+			j.dirties.dec(entry.account)
+		}
+	}
+	clear(j.entries[snapshot:])
+	j.entries = j.entries[:snapshot]
 }
 
-func (ch accessListAddAccountChange) dirtied() *libcommon.Address {
-	return nil
+// dirty explicitly sets an address to dirty, even if the change entries would
+// otherwise suggest it as clean. This method is an ugly hack to handle the RIPEMD
+// precompile consensus exception.
+func (j *journal) dirty(addr libcommon.Address) {
+	j.dirties.inc(addr)
 }
 
-func (ch accessListAddSlotChange) revert(s *IntraBlockState) {
-	s.accessList.DeleteSlot(*ch.address, *ch.slot)
+// length returns the current number of entries in the journal.
+func (j *journal) length() int {
+	return len(j.entries)
 }
 
-func (ch accessListAddSlotChange) dirtied() *libcommon.Address {
-	return nil
-}
+var ripemd = libcommon.HexToAddress("0000000000000000000000000000000000000003")
@@ -0,0 +1,94 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/common"
+)
+
+func TestJournalRevert(t *testing.T) {
+	s := New(nil)
+	addr := common.HexToAddress("0x01")
+	s.AddBalance(addr, uint256.NewInt(5))
+	snapshot := s.Snapshot()
+	s.AddBalance(addr, uint256.NewInt(7))
+	s.AddRefund(3)
+	require.Equal(t, 2, s.journal.dirties.get(addr))
+
+	s.RevertToSnapshot(snapshot)
+	require.Equal(t, uint64(0), s.GetRefund())
+	require.Equal(t, *uint256.NewInt(5), s.balanceInc[addr].increase)
+	require.Equal(t, 1, s.journal.dirties.get(addr))
+	require.Equal(t, 1, s.journal.length())
+
+	s.clearJournalAndRefund()
+	require.Zero(t, s.journal.length())
+	require.Zero(t, s.journal.dirties.len())
+}
+
+func TestJournalAllocs(t *testing.T) {
+	j := newJournal()
+	defer j.release()
+	addr := common.HexToAddress("0x01")
+	entry := journalEntry{kind: storageChange, account: addr, key: common.HexToHash("0x02"), prev: *uint256.NewInt(3)}
+	j.append(entry)
+	j.reset()
+
+	allocs := testing.AllocsPerRun(100, func() {
+		j.append(entry)
+		j.append(journalEntry{kind: refundChange, prevUint: 1})
+		j.reset()
+	})
+	require.Zero(t, allocs)
+}
+
+func TestJournalDirtiesPromotion(t *testing.T) {
+	var d dirtyAccounts
+	for i := 0; i <= dirtyInline; i++ {
+		d.inc(common.BytesToAddress([]byte{byte(i + 1)}))
+	}
+	first := common.BytesToAddress([]byte{1})
+	d.inc(first)
+	require.True(t, d.inMap)
+	require.Equal(t, dirtyInline+1, d.len())
+	require.Equal(t, 2, d.get(first))
+
+	d.dec(first)
+	d.dec(first)
+	require.Zero(t, d.get(first))
+	require.Equal(t, dirtyInline, d.len())
+	seen := 0
+	require.NoError(t, d.forEach(func(common.Address) error { seen++; return nil }))
+	require.Equal(t, dirtyInline, seen)
+
+	// the map is kept for the next transaction, which starts inline again
+	d.reset()
+	require.False(t, d.inMap)
+	require.NotNil(t, d.m)
+	require.Zero(t, d.len())
+	d.inc(first)
+	require.Equal(t, 1, d.get(first))
+	require.Empty(t, d.m)
+}
+
+func BenchmarkJournalTx(b *testing.B) {
+	addrs := make([]common.Address, 4)
+	for i := range addrs {
+		addrs[i] = common.BytesToAddress([]byte{byte(i + 1)})
+	}
+	j := newJournal()
+	defer j.release()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, addr := range addrs {
+			j.append(journalEntry{kind: balanceChange, account: addr})
+			j.append(journalEntry{kind: storageChange, account: addr, key: common.Hash{1}})
+		}
+		j.append(journalEntry{kind: refundChange, prevUint: 1})
+		j.reset()
+	}
+}
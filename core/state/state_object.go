@@ -139,8 +139,9 @@ func (so *stateObject) markSelfdestructed() {
 }
 
 func (so *stateObject) touch() {
-	so.db.journal.append(touchChange{
-		account: &so.address,
+	so.db.journal.append(journalEntry{
+		kind:    touchChange,
+		account: so.address,
 	})
 	if so.address == ripemd {
 		// Explicitly put it in the dirty-cache, which is otherwise generated from
@@ -204,10 +205,11 @@ func (so *stateObject) GetCommittedState(key *libcommon.Hash, out *uint256.Int)
 func (so *stateObject) SetState(key *libcommon.Hash, value uint256.Int) {
 	// If the fake storage is set, put the temporary state update here.
 	if so.fakeStorage != nil {
-		so.db.journal.append(fakeStorageChange{
-			account:  &so.address,
-			key:      *key,
-			prevalue: so.fakeStorage[*key],
+		so.db.journal.append(journalEntry{
+			kind:    fakeStorageChange,
+			account: so.address,
+			key:     *key,
+			prev:    so.fakeStorage[*key],
 		})
 		so.fakeStorage[*key] = value
 		return
@@ -219,10 +221,11 @@ func (so *stateObject) SetState(key *libcommon.Hash, value uint256.Int) {
 		return
 	}
 	// New value is different, update and journal the change
-	so.db.journal.append(storageChange{
-		account:  &so.address,
-		key:      *key,
-		prevalue: prev,
+	so.db.journal.append(journalEntry{
+		kind:    storageChange,
+		account: so.address,
+		key:     *key,
+		prev:    prev,
 	})
 	so.setState(key, value)
 }
@@ -293,8 +296,9 @@ func (so *stateObject) SubBalance(amount *uint256.Int) {
 }
 
 func (so *stateObject) SetBalance(amount *uint256.Int) {
-	so.db.journal.append(balanceChange{
-		account: &so.address,
+	so.db.journal.append(journalEntry{
+		kind:    balanceChange,
+		account: so.address,
 		prev:    so.data.Balance,
 	})
 	so.setBalance(amount)
@@ -339,10 +343,11 @@ func (so *stateObject) Code() []byte {
 
 func (so *stateObject) SetCode(codeHash libcommon.Hash, code []byte) {
 	prevcode := so.Code()
-	so.db.journal.append(codeChange{
-		account:  &so.address,
-		prevhash: so.data.CodeHash,
-		prevcode: prevcode,
+	so.db.journal.append(journalEntry{
+		kind:     codeChange,
+		account:  so.address,
+		key:      so.data.CodeHash,
+		prevCode: prevcode,
 	})
 	so.setCode(codeHash, code)
 }
@@ -354,9 +359,10 @@ func (so *stateObject) setCode(codeHash libcommon.Hash, code []byte) {
 }
 
 func (so *stateObject) SetNonce(nonce uint64) {
-	so.db.journal.append(nonceChange{
-		account: &so.address,
-		prev:    so.data.Nonce,
+	so.db.journal.append(journalEntry{
+		kind:     nonceChange,
+		account:  so.address,
+		prevUint: so.data.Nonce,
 	})
 	so.setNonce(nonce)
 }
@@ -19,6 +19,7 @@ package state
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"testing"
 
 	"github.com/erigontech/erigon-lib/chain"
@@ -162,11 +163,11 @@ func (s *StateSuite) TestTouchDelete(c *checker.C) {
 	snapshot := s.state.Snapshot()
 	s.state.AddBalance(common.Address{}, new(uint256.Int))
 
-	if len(s.state.journal.dirties) != 1 {
+	if s.state.journal.dirties.len() != 1 {
 		c.Fatal("expected one dirty state object")
 	}
 	s.state.RevertToSnapshot(snapshot)
-	if len(s.state.journal.dirties) != 0 {
+	if s.state.journal.dirties.len() != 0 {
 		c.Fatal("expected no dirty state object")
 	}
 }
@@ -327,6 +328,77 @@ func compareStateObjects(so0, so1 *stateObject, t *testing.T) {
 func TestDump(t *testing.T) {
 	t.Parallel()
 	_, tx := memdb.NewTestTx(t)
+	writeDumpTestState(t, tx)
+
+	// check that dump contains the state objects that are in trie
+	historyV3, err := kvcfg.HistoryV3.Enabled(tx)
+	if err != nil {
+		panic(err)
+	}
+	got := string(NewDumper(tx, 2, historyV3).DefaultDump())
+	want := `{
+    "root": "0000000000000000000000000000000000000000000000000000000000000000",
+    "accounts": {
+        "0x0000000000000000000000000000000000000001": {
+            "balance": "22",
+            "nonce": 0,
+            "root": "0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421",
+            "codeHash": "0xc5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470"
+        },
+        "0x0000000000000000000000000000000000000002": {
+            "balance": "44",
+            "nonce": 0,
+            "root": "0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421",
+            "codeHash": "0xc5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470"
+        },
+        "0x0000000000000000000000000000000000000102": {
+            "balance": "0",
+            "nonce": 0,
+            "root": "0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421",
+            "codeHash": "0x87874902497a5bb968da31a2998d8f22e949d1ef6214bcdedd8bae24cca4b9e3",
+            "code": "0x03030303030303"
+        }
+    }
+}`
+	if got != want {
+		t.Fatalf("dump mismatch:\ngot: %s\nwant: %s\n", got, want)
+	}
+}
+
+func TestStreamDump(t *testing.T) {
+	t.Parallel()
+	_, tx := memdb.NewTestTx(t)
+	writeDumpTestState(t, tx)
+
+	historyV3, err := kvcfg.HistoryV3.Enabled(tx)
+	if err != nil {
+		panic(err)
+	}
+	var out bytes.Buffer
+	var batches []uint64
+	root := common.HexToHash("0x71edff0130dd2385947095001c73d9e28d862fc286fca2b922ca6f6f3cddfdd2")
+	n, err := NewDumper(tx, 2, historyV3).StreamDump(context.Background(), root, false, false, 2, json.NewEncoder(&out), func(accounts uint64) {
+		batches = append(batches, accounts)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 || len(batches) != 2 || batches[0] != 2 || batches[1] != 3 {
+		t.Fatalf("unexpected progress: %d accounts, batches %v", n, batches)
+	}
+	want := `{"root":"0x71edff0130dd2385947095001c73d9e28d862fc286fca2b922ca6f6f3cddfdd2"}
+{"balance":"22","nonce":0,"root":"0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421","codeHash":"0xc5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470","address":"0x0000000000000000000000000000000000000001"}
+{"balance":"44","nonce":0,"root":"0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421","codeHash":"0xc5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470","address":"0x0000000000000000000000000000000000000002"}
+{"balance":"0","nonce":0,"root":"0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421","codeHash":"0x87874902497a5bb968da31a2998d8f22e949d1ef6214bcdedd8bae24cca4b9e3","code":"0x03030303030303","address":"0x0000000000000000000000000000000000000102"}
+`
+	if got := out.String(); got != want {
+		t.Fatalf("stream dump mismatch:\ngot: %s\nwant: %s\n", got, want)
+	}
+}
+
+// writeDumpTestState writes three accounts, one of them a contract, in block 1
+func writeDumpTestState(t *testing.T, tx kv.RwTx) {
+	t.Helper()
 	w := NewPlainStateWriter(tx, tx, 0)
 	state := New(NewPlainStateReader(tx))
 
@@ -367,38 +439,4 @@ func TestDump(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-
-	// check that dump contains the state objects that are in trie
-	historyV3, err := kvcfg.HistoryV3.Enabled(tx)
-	if err != nil {
-		panic(err)
-	}
-	got := string(NewDumper(tx, 2, historyV3).DefaultDump())
-	want := `{
-    "root": "0000000000000000000000000000000000000000000000000000000000000000",
-    "accounts": {
-        "0x0000000000000000000000000000000000000001": {
-            "balance": "22",
-            "nonce": 0,
-            "root": "0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421",
-            "codeHash": "0xc5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470"
-        },
-        "0x0000000000000000000000000000000000000002": {
-            "balance": "44",
-            "nonce": 0,
-            "root": "0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421",
-            "codeHash": "0xc5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470"
-        },
-        "0x0000000000000000000000000000000000000102": {
-            "balance": "0",
-            "nonce": 0,
-            "root": "0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421",
-            "codeHash": "0x87874902497a5bb968da31a2998d8f22e949d1ef6214bcdedd8bae24cca4b9e3",
-            "code": "0x03030303030303"
-        }
-    }
-}`
-	if got != want {
-		t.Fatalf("dump mismatch:\ngot: %s\nwant: %s\n", got, want)
-	}
 }
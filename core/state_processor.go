@@ -38,7 +38,7 @@ func applyTransaction(config *chain.Config, engine consensus.EngineReader, gp *G
 	stateWriter state.StateWriter, header *types.Header, tx types.Transaction, usedGas, usedBlobGas *uint64,
 	evm *vm.EVM, cfg vm.Config) (*types.Receipt, []byte, error) {
 	rules := evm.ChainRules()
-	msg, err := tx.AsMessage(*types.MakeSigner(config, header.Number.Uint64(), header.Time), header.BaseFee, rules)
+	msg, err := types.AsMessageLegacyAware(config, header.Number.Uint64(), tx, *types.MakeSigner(config, header.Number.Uint64(), header.Time), header.BaseFee, rules)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -74,6 +74,10 @@ type StateTransition struct {
 	state        evmtypes.IntraBlockState
 	evm          *vm.EVM
 
+	// fee token contract in the Boba fee token mode, the base fee and the L1 fee are paid in the token then
+	feeToken          *libcommon.Address
+	feeTokenRefundGas uint64 // gas reserved for the refund call of the fee token
+
 	//some pre-allocated intermediate variables
 	sharedBuyGas        *uint256.Int
 	sharedBuyGasBalance *uint256.Int
@@ -97,6 +101,8 @@ type Message interface {
 	IsSystemTx() bool
 	IsDepositTx() bool
 	RollupCostData() types2.RollupCostData
+	// BobaTuring is the Turing response of a pre-bedrock Boba transaction, nil otherwise
+	BobaTuring() []byte
 
 	Nonce() uint64
 	CheckNonce() bool
@@ -167,7 +173,21 @@ func (st *StateTransition) to() libcommon.Address {
 	return *st.msg.To()
 }
 
+// blobGasPrice - price of blob gas in the block, Cancun must be active
+func (st *StateTransition) blobGasPrice() (*uint256.Int, error) {
+	if st.evm.Context.BlobBaseFee != nil {
+		return st.evm.Context.BlobBaseFee, nil
+	}
+	if st.evm.Context.ExcessBlobGas == nil {
+		return nil, fmt.Errorf("%w: Cancun is active but ExcessBlobGas is nil", ErrInternalFailure)
+	}
+	return misc.GetBlobGasPrice(st.evm.ChainConfig(), *st.evm.Context.ExcessBlobGas)
+}
+
 func (st *StateTransition) buyGas(gasBailout bool) error {
+	if contract := st.evm.ChainConfig().BobaFeeTokenContract(st.evm.Context.Time); contract != nil && st.evm.Context.BaseFee != nil {
+		return st.buyGasFeeToken(*contract, gasBailout)
+	}
 	gasVal := st.sharedBuyGas
 	gasVal.SetUint64(st.msg.Gas())
 	gasVal, overflow := gasVal.MulOverflow(gasVal, st.gasPrice)
@@ -185,10 +205,7 @@ func (st *StateTransition) buyGas(gasBailout bool) error {
 	// compute blob fee for eip-4844 data blobs if any
 	blobGasVal := new(uint256.Int)
 	if st.evm.ChainRules().IsCancun {
-		if st.evm.Context.ExcessBlobGas == nil {
-			return fmt.Errorf("%w: Cancun is active but ExcessBlobGas is nil", ErrInternalFailure)
-		}
-		blobGasPrice, err := misc.GetBlobGasPrice(st.evm.ChainConfig(), *st.evm.Context.ExcessBlobGas)
+		blobGasPrice, err := st.blobGasPrice()
 		if err != nil {
 			return err
 		}
@@ -328,10 +345,7 @@ func (st *StateTransition) preCheck(gasBailout bool) error {
 		}
 	}
 	if st.msg.BlobGas() > 0 && st.evm.ChainRules().IsCancun {
-		if st.evm.Context.ExcessBlobGas == nil {
-			return fmt.Errorf("%w: Cancun is active but ExcessBlobGas is nil", ErrInternalFailure)
-		}
-		blobGasPrice, err := misc.GetBlobGasPrice(st.evm.ChainConfig(), *st.evm.Context.ExcessBlobGas)
+		blobGasPrice, err := st.blobGasPrice()
 		if err != nil {
 			return err
 		}
@@ -556,13 +570,15 @@ func (st *StateTransition) innerTransitionDb(refunds bool, gasBailout bool) (*ev
 	// is always 0 for deposit tx. So calling refundGas will ensure the gasUsed accounting is correct without actually
 	// changing the sender's balance
 	if refunds {
+		refundQuotient := params.RefundQuotient // Before EIP-3529: refunds were capped to gasUsed / 2
 		if rules.IsLondon {
 			// After EIP-3529: refunds are capped to gasUsed / 5
-			st.refundGas(params.RefundQuotientEIP3529)
-		} else {
-			// Before EIP-3529: refunds were capped to gasUsed / 2
-			st.refundGas(params.RefundQuotient)
+			refundQuotient = params.RefundQuotientEIP3529
+		}
+		if st.feeToken != nil {
+			st.refundFeeToken(refundQuotient)
 		}
+		st.refundGas(refundQuotient)
 	}
 	if st.msg.IsDepositTx() && rules.IsOptimismRegolith {
 		// Skip coinbase payments for deposit tx in Regolith
@@ -610,7 +626,8 @@ func (st *StateTransition) innerTransitionDb(refunds bool, gasBailout bool) (*ev
 	}
 
 	// Check that we are post bedrock to be able to create pseudo pre-bedrock blocks (these are pre-bedrock, but don't follow l2 geth rules)
-	if rules.IsOptimismBedrock {
+	// In the fee token mode the base fee and the L1 fee were collected by the fee token contract
+	if rules.IsOptimismBedrock && st.feeToken == nil {
 		st.state.AddBalance(params.OptimismBaseFeeRecipient, new(uint256.Int).Mul(uint256.NewInt(st.gasUsed()), st.evm.Context.BaseFee))
 		if st.evm.Context.L1CostFunc == nil { // Erigon EVM context is used in many unexpected/hacky ways, let's panic if it's misconfigured
 			panic("missing L1 cost func in block context, please configure l1 cost when using optimism config to run EVM")
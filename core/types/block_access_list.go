@@ -0,0 +1,30 @@
+package types
+
+import (
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+)
+
+// BlockAccessList - experimental EIP-7928: accounts and storage slots touched by execution of the block, with
+// their values after the block. Collected by the Execution stage with --experimental.bal, stored in
+// kv.BlockAccessList in JSON encoding.
+type BlockAccessList struct {
+	Accounts []*AccountAccess `json:"accounts"`
+}
+
+// AccountAccess - account touched by the block, ordered by address. Nonce, Balance and CodeHash are set
+// if the block changed the account
+type AccountAccess struct {
+	Address       libcommon.Address `json:"address"`
+	Nonce         *hexutil.Uint64   `json:"nonce,omitempty"`
+	Balance       *hexutil.Big      `json:"balance,omitempty"`
+	CodeHash      *libcommon.Hash   `json:"codeHash,omitempty"`
+	Deleted       bool              `json:"deleted,omitempty"`
+	StorageWrites []StorageWrite    `json:"storageWrites,omitempty"` // ordered by slot
+	StorageReads  []libcommon.Hash  `json:"storageReads,omitempty"`  // slots read but not written, ordered
+}
+
+type StorageWrite struct {
+	Slot  libcommon.Hash `json:"slot"`
+	Value libcommon.Hash `json:"value"`
+}
@@ -0,0 +1,67 @@
+package types
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"github.com/erigontech/erigon-lib/chain"
+	libcommon "github.com/erigontech/erigon-lib/common"
+)
+
+// Pre-bedrock Boba l2geth rewrote calldata of transactions which used Turing (hybrid compute),
+// to carry the off-chain response together with the transaction, so replicas were able to replay it:
+//
+//	version (1 byte, BobaTuringVersion) | len(original calldata) (2 bytes, big-endian) | original calldata | Turing response
+//
+// Signature of such transaction covers only the original calldata.
+const (
+	BobaTuringVersion   = 0x01
+	bobaTuringHeaderLen = 3
+)
+
+// DecodeBobaTuringInput splits calldata of pre-bedrock Boba transaction into the original calldata and Turing response.
+// ok is false if data doesn't carry Turing artifacts.
+func DecodeBobaTuringInput(data []byte) (input []byte, turing []byte, ok bool) {
+	if len(data) <= bobaTuringHeaderLen || data[0] != BobaTuringVersion {
+		return nil, nil, false
+	}
+	inputLen := int(binary.BigEndian.Uint16(data[1:bobaTuringHeaderLen]))
+	if bobaTuringHeaderLen+inputLen >= len(data) {
+		// no room for Turing response - it's just a calldata which happens to start with the version byte
+		return nil, nil, false
+	}
+	return data[bobaTuringHeaderLen : bobaTuringHeaderLen+inputLen], data[bobaTuringHeaderLen+inputLen:], true
+}
+
+// BobaLegacyTuringTx returns copy of pre-bedrock Boba transaction with the Turing response stripped from calldata
+// (it's the transaction which was signed by user) and the response itself. ok is false if txn carries no Turing artifacts.
+// Only legacy transactions existed before bedrock, so other types are never decoded.
+func BobaLegacyTuringTx(txn Transaction) (original Transaction, turing []byte, ok bool) {
+	legacyTx, isLegacy := txn.(*LegacyTx)
+	if !isLegacy {
+		return nil, nil, false
+	}
+	input, turing, ok := DecodeBobaTuringInput(legacyTx.Data)
+	if !ok {
+		return nil, nil, false
+	}
+	cpy := legacyTx.copy()
+	cpy.Data = libcommon.CopyBytes(input)
+	return cpy, libcommon.CopyBytes(turing), true
+}
+
+// AsMessageLegacyAware - txn.AsMessage, but in pre-bedrock Boba blocks a transaction which calldata l2geth rewrote for
+// Turing gives the message of the transaction user signed, carrying the Turing response for the EVM to replay it
+// instead of the off-chain request (see evmtypes.TxContext.BobaTuring).
+func AsMessageLegacyAware(cc *chain.Config, blockNumber uint64, txn Transaction, s Signer, baseFee *big.Int, rules *chain.Rules) (Message, error) {
+	if !cc.IsBobaLegacyBlock(blockNumber) {
+		return txn.AsMessage(s, baseFee, rules)
+	}
+	original, turing, ok := BobaLegacyTuringTx(txn)
+	if !ok {
+		return txn.AsMessage(s, baseFee, rules)
+	}
+	msg, err := original.AsMessage(s, baseFee, rules)
+	msg.bobaTuring = turing
+	return msg, err
+}
@@ -0,0 +1,85 @@
+package types
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/chain"
+
+	"github.com/erigontech/erigon/crypto"
+)
+
+func TestDecodeBobaTuringInput(t *testing.T) {
+	t.Parallel()
+	_, _, ok := DecodeBobaTuringInput(nil)
+	require.False(t, ok)
+	_, _, ok = DecodeBobaTuringInput([]byte{0xa9, 0x05, 0x9c, 0xbb, 0x00})
+	require.False(t, ok)
+	// declared length covers whole calldata - no Turing response
+	_, _, ok = DecodeBobaTuringInput([]byte{BobaTuringVersion, 0x00, 0x02, 0xaa, 0xbb})
+	require.False(t, ok)
+
+	input, turing, ok := DecodeBobaTuringInput([]byte{BobaTuringVersion, 0x00, 0x02, 0xaa, 0xbb, 0xcc, 0xdd})
+	require.True(t, ok)
+	require.Equal(t, []byte{0xaa, 0xbb}, input)
+	require.Equal(t, []byte{0xcc, 0xdd}, turing)
+}
+
+func TestBobaLegacyTuringTxSender(t *testing.T) {
+	t.Parallel()
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	calldata := []byte{0x01, 0x02, 0x03, 0x04}
+	signer := LatestSignerForChainID(big.NewInt(288))
+	signed, err := SignTx(NewTransaction(0, addr, new(uint256.Int), 21_000, new(uint256.Int), calldata), *signer, key)
+	require.NoError(t, err)
+
+	// emulate legacy sequencer, which appended Turing response after signing
+	modified := signed.(*LegacyTx).copy()
+	modified.Data = append([]byte{BobaTuringVersion, 0x00, byte(len(calldata))}, calldata...)
+	modified.Data = append(modified.Data, 0xde, 0xad)
+
+	original, turing, ok := BobaLegacyTuringTx(modified)
+	require.True(t, ok)
+	require.Equal(t, []byte{0xde, 0xad}, turing)
+	require.Equal(t, calldata, original.GetData())
+	require.Equal(t, signed.Hash(), original.Hash())
+
+	from, err := original.Sender(*signer)
+	require.NoError(t, err)
+	require.Equal(t, addr, from)
+
+	_, _, ok = BobaLegacyTuringTx(signed)
+	require.False(t, ok)
+}
+
+func TestAsMessageLegacyAware(t *testing.T) {
+	t.Parallel()
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	calldata := []byte{0x01, 0x02, 0x03, 0x04}
+	signer := LatestSignerForChainID(big.NewInt(288))
+	signed, err := SignTx(NewTransaction(0, addr, new(uint256.Int), 21_000, new(uint256.Int), calldata), *signer, key)
+	require.NoError(t, err)
+	modified := signed.(*LegacyTx).copy()
+	modified.Data = append([]byte{BobaTuringVersion, 0x00, byte(len(calldata))}, calldata...)
+	modified.Data = append(modified.Data, 0xde, 0xad)
+
+	cc := &chain.Config{ChainID: big.NewInt(288), Optimism: &chain.OptimismConfig{BobaLegacy: &chain.BobaLegacyConfig{}}, BedrockBlock: big.NewInt(100)}
+	msg, err := AsMessageLegacyAware(cc, 99, modified, *signer, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, addr, msg.From())
+	require.Equal(t, calldata, msg.Data())
+	require.Equal(t, []byte{0xde, 0xad}, msg.BobaTuring())
+
+	// after bedrock calldata is what it is
+	msg, err = AsMessageLegacyAware(cc, 100, modified, *signer, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, modified.Data, msg.Data())
+	require.Nil(t, msg.BobaTuring())
+}
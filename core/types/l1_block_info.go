@@ -0,0 +1,129 @@
+package types
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/opstack"
+)
+
+var ErrNoL1InfoDeposit = errors.New("first transaction of the block is not the L1 attributes deposit")
+
+// L1BlockInfo - the L1 attributes set by the first (deposit) transaction of every OP Stack L2 block in the
+// L1Block predeploy. Bedrock attributes have no blob base fee and scalars of their own, Ecotone (and later)
+// attributes have no overhead and fee scalar.
+type L1BlockInfo struct {
+	Number         uint64
+	Time           uint64
+	BaseFee        *big.Int
+	BlockHash      libcommon.Hash
+	SequenceNumber uint64
+	BatcherAddr    libcommon.Address
+
+	// Bedrock
+	L1FeeOverhead *big.Int
+	L1FeeScalar   *big.Int
+
+	// Ecotone
+	BlobBaseFee       *big.Int
+	BaseFeeScalar     uint32
+	BlobBaseFeeScalar uint32
+}
+
+// Ecotone - the attributes are in the Ecotone format (setL1BlockValuesEcotone)
+func (info *L1BlockInfo) Ecotone() bool { return info.BlobBaseFee != nil }
+
+// L1BlockInfoFromBlock - decodes the L1 attributes deposit, the first transaction of the block
+func L1BlockInfoFromBlock(block *Block) (*L1BlockInfo, error) {
+	txs := block.Transactions()
+	if len(txs) == 0 || txs[0].Type() != DepositTxType {
+		return nil, ErrNoL1InfoDeposit
+	}
+	if to := txs[0].GetTo(); to == nil || *to != opstack.L1BlockAddr {
+		return nil, ErrNoL1InfoDeposit
+	}
+	return ParseL1BlockInfo(txs[0].GetData())
+}
+
+// ParseL1BlockInfo - decodes the calldata of the L1 attributes deposit, the format is chosen by the function selector
+// (the first Ecotone block still uses the Bedrock one).
+func ParseL1BlockInfo(data []byte) (*L1BlockInfo, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("L1 info calldata too short: %d bytes", len(data))
+	}
+	switch {
+	case bytes.Equal(data[:4], opstack.BedrockL1AttributesSelector):
+		return parseL1BlockInfoBedrock(data)
+	case bytes.Equal(data[:4], opstack.EcotoneL1AttributesSelector):
+		return parseL1BlockInfoEcotone(data)
+	default:
+		return nil, fmt.Errorf("unknown L1 info function selector %x", data[:4])
+	}
+}
+
+// parseL1BlockInfoBedrock - setL1BlockValues(uint64 _number, uint64 _timestamp, uint256 _basefee, bytes32 _hash,
+// uint64 _sequenceNumber, bytes32 _batcherHash, uint256 _l1FeeOverhead, uint256 _l1FeeScalar), ABI-encoded
+func parseL1BlockInfoBedrock(data []byte) (*L1BlockInfo, error) {
+	if len(data) != opstack.LegacyL1InfoBytes {
+		return nil, fmt.Errorf("expected %d Bedrock L1 info bytes, got %d", opstack.LegacyL1InfoBytes, len(data))
+	}
+	arg := func(i int) []byte { return data[4+32*i : 4+32*(i+1)] }
+	uint64Arg := func(i int) (uint64, error) {
+		a := arg(i)
+		if !bytes.Equal(a[:24], make([]byte, 24)) {
+			return 0, fmt.Errorf("L1 info argument %d overflows uint64", i)
+		}
+		return binary.BigEndian.Uint64(a[24:]), nil
+	}
+	info := &L1BlockInfo{
+		BaseFee:       new(big.Int).SetBytes(arg(2)),
+		BlockHash:     libcommon.BytesToHash(arg(3)),
+		BatcherAddr:   libcommon.BytesToAddress(arg(5)),
+		L1FeeOverhead: new(big.Int).SetBytes(arg(6)),
+		L1FeeScalar:   new(big.Int).SetBytes(arg(7)),
+	}
+	var err error
+	if info.Number, err = uint64Arg(0); err != nil {
+		return nil, err
+	}
+	if info.Time, err = uint64Arg(1); err != nil {
+		return nil, err
+	}
+	if info.SequenceNumber, err = uint64Arg(4); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// parseL1BlockInfoEcotone - setL1BlockValuesEcotone, tightly packed:
+// offset type varname
+// 0      <selector>
+// 4      uint32 _baseFeeScalar
+// 8      uint32 _blobBaseFeeScalar
+// 12     uint64 _sequenceNumber
+// 20     uint64 _timestamp
+// 28     uint64 _l1BlockNumber
+// 36     uint256 _baseFee
+// 68     uint256 _blobBaseFee
+// 100    bytes32 _hash
+// 132    bytes32 _batcherHash
+func parseL1BlockInfoEcotone(data []byte) (*L1BlockInfo, error) {
+	if len(data) != opstack.EcotoneL1InfoBytes {
+		return nil, fmt.Errorf("expected %d Ecotone L1 info bytes, got %d", opstack.EcotoneL1InfoBytes, len(data))
+	}
+	return &L1BlockInfo{
+		BaseFeeScalar:     binary.BigEndian.Uint32(data[4:8]),
+		BlobBaseFeeScalar: binary.BigEndian.Uint32(data[8:12]),
+		SequenceNumber:    binary.BigEndian.Uint64(data[12:20]),
+		Time:              binary.BigEndian.Uint64(data[20:28]),
+		Number:            binary.BigEndian.Uint64(data[28:36]),
+		BaseFee:           new(big.Int).SetBytes(data[36:68]),
+		BlobBaseFee:       new(big.Int).SetBytes(data[68:100]),
+		BlockHash:         libcommon.BytesToHash(data[100:132]),
+		BatcherAddr:       libcommon.BytesToAddress(data[132:164]),
+	}, nil
+}
@@ -0,0 +1,78 @@
+package types
+
+import (
+	"encoding/binary"
+	"math/big"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/opstack"
+)
+
+func TestParseL1BlockInfo(t *testing.T) {
+	batcher := libcommon.HexToAddress("0x6887246668a3b87F54DeB3b94Ba47a6f63F32985")
+	l1Hash := libcommon.HexToHash("0xabcd")
+
+	ecotone := make([]byte, opstack.EcotoneL1InfoBytes)
+	copy(ecotone, opstack.EcotoneL1AttributesSelector)
+	binary.BigEndian.PutUint32(ecotone[4:8], 1368)
+	binary.BigEndian.PutUint32(ecotone[8:12], 810949)
+	binary.BigEndian.PutUint64(ecotone[12:20], 3)
+	binary.BigEndian.PutUint64(ecotone[20:28], 1_700_000_084)
+	binary.BigEndian.PutUint64(ecotone[28:36], 7)
+	big.NewInt(875_000_000).FillBytes(ecotone[36:68])
+	big.NewInt(1).FillBytes(ecotone[68:100])
+	copy(ecotone[100:132], l1Hash[:])
+	copy(ecotone[144:164], batcher[:])
+
+	info, err := ParseL1BlockInfo(ecotone)
+	require.NoError(t, err)
+	require.True(t, info.Ecotone())
+	require.Equal(t, &L1BlockInfo{
+		Number:            7,
+		Time:              1_700_000_084,
+		BaseFee:           big.NewInt(875_000_000),
+		BlockHash:         l1Hash,
+		SequenceNumber:    3,
+		BatcherAddr:       batcher,
+		BlobBaseFee:       big.NewInt(1),
+		BaseFeeScalar:     1368,
+		BlobBaseFeeScalar: 810949,
+	}, info)
+
+	bedrock := make([]byte, opstack.LegacyL1InfoBytes)
+	copy(bedrock, opstack.BedrockL1AttributesSelector)
+	for i, arg := range []*big.Int{big.NewInt(7), big.NewInt(1_700_000_084), big.NewInt(875_000_000), new(big.Int).SetBytes(l1Hash[:]),
+		big.NewInt(3), new(big.Int).SetBytes(batcher[:]), big.NewInt(188), big.NewInt(684_000)} {
+		arg.FillBytes(bedrock[4+32*i : 4+32*(i+1)])
+	}
+	info, err = ParseL1BlockInfo(bedrock)
+	require.NoError(t, err)
+	require.False(t, info.Ecotone())
+	require.Equal(t, &L1BlockInfo{
+		Number:         7,
+		Time:           1_700_000_084,
+		BaseFee:        big.NewInt(875_000_000),
+		BlockHash:      l1Hash,
+		SequenceNumber: 3,
+		BatcherAddr:    batcher,
+		L1FeeOverhead:  big.NewInt(188),
+		L1FeeScalar:    big.NewInt(684_000),
+	}, info)
+
+	_, err = ParseL1BlockInfo(ecotone[:100])
+	require.Error(t, err)
+	_, err = ParseL1BlockInfo([]byte{1, 2, 3, 4})
+	require.Error(t, err)
+
+	to := opstack.L1BlockAddr
+	block := NewBlock(&Header{Number: big.NewInt(1)}, []Transaction{&DepositTx{To: &to, Value: uint256.NewInt(0), Data: ecotone}}, nil, nil, nil)
+	info, err = L1BlockInfoFromBlock(block)
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), info.Number)
+	_, err = L1BlockInfoFromBlock(NewBlockWithHeader(&Header{Number: big.NewInt(1)}))
+	require.ErrorIs(t, err, ErrNoL1InfoDeposit)
+}
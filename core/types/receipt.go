@@ -40,6 +40,7 @@ import (
 // however updating the lib has caused us issues in the past, and we don't have good unit test coverage for updating atm
 // we also use this for storing Receipts and Logs in the DB - we won't be doing that in Erigon 3
 // do not regen, more context: https://github.com/erigontech/erigon/pull/10105#pullrequestreview-2027423601
+// new records are written in storage encoding v2 (see receipt_storage.go), cbor is only read for not migrated ones
 // go:generate codecgen -o receipt_codecgen_gen.go -r "^Receipts$|^Receipt$|^Logs$|^Log$" -st "codec" -j=false -nx=true -ta=true -oe=false -d 2 receipt.go log.go
 
 //go:generate gencodec -type Receipt -field-override receiptMarshaling -out gen_receipt_json.go
@@ -0,0 +1,88 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// ReceiptsStorageZstd - first byte of kv.Receipts and kv.Log values compressed with zstd:
+//
+//	ReceiptsStorageZstd | zstd(value in storage encoding v2)
+//
+// The zstd frame references the dictionary by id (see SetReceiptsCompression), frames without dictionary are
+// valid too - they are written before a dictionary is trained.
+const ReceiptsStorageZstd = 0x03
+
+// receiptsCodec - zstd state of the process, replaced as a whole by SetReceiptsCompression
+type receiptsCodec struct {
+	enc *zstd.Encoder // nil - new values are not compressed
+	dec *zstd.Decoder
+}
+
+var receiptsZstd atomic.Pointer[receiptsCodec]
+
+func init() {
+	if err := SetReceiptsCompression(false, nil); err != nil {
+		panic(err)
+	}
+}
+
+// SetReceiptsCompression - configures encoding of kv.Receipts and kv.Log values: with compress new values are
+// compressed with dict (nil - without dictionary). Compressed values are decoded regardless of compress, as long
+// as dict is the dictionary they were written with, so readers of the db (rpcdaemon) must set it too.
+func SetReceiptsCompression(compress bool, dict []byte) error {
+	decOpts := []zstd.DOption{zstd.WithDecoderConcurrency(0)}
+	encOpts := []zstd.EOption{zstd.WithEncoderLevel(zstd.SpeedBetterCompression), zstd.WithEncoderConcurrency(1)}
+	if dict != nil {
+		decOpts = append(decOpts, zstd.WithDecoderDicts(dict))
+		encOpts = append(encOpts, zstd.WithEncoderDict(dict))
+	}
+	codec := &receiptsCodec{}
+	var err error
+	if codec.dec, err = zstd.NewReader(nil, decOpts...); err != nil {
+		return fmt.Errorf("receipts zstd dictionary: %w", err)
+	}
+	if compress {
+		if codec.enc, err = zstd.NewWriter(nil, encOpts...); err != nil {
+			return fmt.Errorf("receipts zstd dictionary: %w", err)
+		}
+	}
+	receiptsZstd.Store(codec)
+	return nil
+}
+
+// compressReceiptsValue - compresses value in storage encoding v2 if compression is on
+func compressReceiptsValue(v []byte) []byte {
+	enc := receiptsZstd.Load().enc
+	if enc == nil {
+		return v
+	}
+	out := make([]byte, 1, len(v)/2+16)
+	out[0] = ReceiptsStorageZstd
+	out = enc.EncodeAll(v, out)
+	if len(out) >= len(v) {
+		return v // tiny values (blocks without txs) don't shrink
+	}
+	return out
+}
+
+// decompressReceiptsValue - value in storage encoding v2 of a compressed one, other values are returned as is
+func decompressReceiptsValue(v []byte) ([]byte, error) {
+	if len(v) == 0 || v[0] != ReceiptsStorageZstd {
+		return v, nil
+	}
+	out, err := receiptsZstd.Load().dec.DecodeAll(v[1:], nil)
+	if err != nil {
+		if errors.Is(err, zstd.ErrUnknownDictionary) {
+			return nil, fmt.Errorf("compressed receipts: %w (dictionary of the datadir is not loaded)", err)
+		}
+		return nil, fmt.Errorf("compressed receipts: %w", err)
+	}
+	if len(out) == 0 || out[0] != ReceiptsStorageV2 {
+		return nil, errors.New("compressed receipts: not in storage encoding v2")
+	}
+	return out, nil
+}
@@ -0,0 +1,305 @@
+package types
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/erigontech/erigon/ethdb/cbor"
+	"github.com/erigontech/erigon/rlp"
+)
+
+// ReceiptsStorageV2 - first byte of receipts (kv.Receipts) and logs (kv.Log) values in storage encoding v2:
+//
+//	ReceiptsStorageV2 | rlp([]storedReceiptV2RLP)
+//	ReceiptsStorageV2 | rlp([]*LogForStorage)
+//
+// Legacy values are codecgen/cbor arrays (or null), their first byte is always in 0x80..0x9f or 0xf6 range,
+// so both encodings can live in the same table while migration is in progress.
+const ReceiptsStorageV2 = 0x02
+
+// Bits of storedReceiptV2RLP.Present - which of optional OP-stack fields are set (non-nil).
+// Needed because RLP can't tell nil from zero: DepositNonce=0 is valid and must not be lost.
+const (
+	storedDepositNonce = 1 << iota
+	storedDepositReceiptVersion
+	storedL1GasPrice
+	storedL1GasUsed
+	storedL1Fee
+	storedFeeScalar
+	storedL1BaseFeeScalar
+	storedL1BlobBaseFeeScalar
+	storedL1BlobBaseFee
+)
+
+// storedReceiptV2RLP is the storage encoding v2 of a receipt. Logs are stored separately (see EncodeLogsForStorage).
+// New fields must be appended to the end and be "optional".
+type storedReceiptV2RLP struct {
+	Type              uint8
+	PostStateOrStatus []byte
+	CumulativeGasUsed uint64
+
+	Present               uint64   `rlp:"optional"`
+	DepositNonce          uint64   `rlp:"optional"`
+	DepositReceiptVersion uint64   `rlp:"optional"`
+	L1GasPrice            *big.Int `rlp:"optional"`
+	L1GasUsed             *big.Int `rlp:"optional"`
+	L1Fee                 *big.Int `rlp:"optional"`
+	FeeScalar             []byte   `rlp:"optional"` // big.Float.GobEncode - keeps precision and mode
+	L1BaseFeeScalar       uint64   `rlp:"optional"`
+	L1BlobBaseFeeScalar   uint64   `rlp:"optional"`
+	L1BlobBaseFee         *big.Int `rlp:"optional"`
+}
+
+// IsReceiptsStorageV2 - value of kv.Receipts or kv.Log is in storage encoding v2, maybe compressed (not legacy cbor)
+func IsReceiptsStorageV2(v []byte) bool {
+	return len(v) > 0 && (v[0] == ReceiptsStorageV2 || v[0] == ReceiptsStorageZstd)
+}
+
+func newStoredReceiptV2RLP(r *Receipt) (*storedReceiptV2RLP, error) {
+	enc := &storedReceiptV2RLP{
+		Type:              r.Type,
+		PostStateOrStatus: r.statusEncoding(),
+		CumulativeGasUsed: r.CumulativeGasUsed,
+	}
+	if r.DepositNonce != nil {
+		enc.Present |= storedDepositNonce
+		enc.DepositNonce = *r.DepositNonce
+	}
+	if r.DepositReceiptVersion != nil {
+		enc.Present |= storedDepositReceiptVersion
+		enc.DepositReceiptVersion = *r.DepositReceiptVersion
+	}
+	if r.L1GasPrice != nil {
+		enc.Present |= storedL1GasPrice
+		enc.L1GasPrice = r.L1GasPrice
+	}
+	if r.L1GasUsed != nil {
+		enc.Present |= storedL1GasUsed
+		enc.L1GasUsed = r.L1GasUsed
+	}
+	if r.L1Fee != nil {
+		enc.Present |= storedL1Fee
+		enc.L1Fee = r.L1Fee
+	}
+	if r.FeeScalar != nil {
+		feeScalar, err := r.FeeScalar.GobEncode()
+		if err != nil {
+			return nil, err
+		}
+		enc.Present |= storedFeeScalar
+		enc.FeeScalar = feeScalar
+	}
+	if r.L1BaseFeeScalar != nil {
+		enc.Present |= storedL1BaseFeeScalar
+		enc.L1BaseFeeScalar = *r.L1BaseFeeScalar
+	}
+	if r.L1BlobBaseFeeScalar != nil {
+		enc.Present |= storedL1BlobBaseFeeScalar
+		enc.L1BlobBaseFeeScalar = *r.L1BlobBaseFeeScalar
+	}
+	if r.L1BlobBaseFee != nil {
+		enc.Present |= storedL1BlobBaseFee
+		enc.L1BlobBaseFee = r.L1BlobBaseFee
+	}
+	return enc, nil
+}
+
+func (stored *storedReceiptV2RLP) toReceipt() (*Receipt, error) {
+	r := &Receipt{
+		Type:              stored.Type,
+		CumulativeGasUsed: stored.CumulativeGasUsed,
+	}
+	if err := r.setStatus(stored.PostStateOrStatus); err != nil {
+		return nil, err
+	}
+	if stored.Present&storedDepositNonce != 0 {
+		depositNonce := stored.DepositNonce
+		r.DepositNonce = &depositNonce
+	}
+	if stored.Present&storedDepositReceiptVersion != 0 {
+		depositReceiptVersion := stored.DepositReceiptVersion
+		r.DepositReceiptVersion = &depositReceiptVersion
+	}
+	if stored.Present&storedL1GasPrice != 0 {
+		r.L1GasPrice = bigOrZero(stored.L1GasPrice)
+	}
+	if stored.Present&storedL1GasUsed != 0 {
+		r.L1GasUsed = bigOrZero(stored.L1GasUsed)
+	}
+	if stored.Present&storedL1Fee != 0 {
+		r.L1Fee = bigOrZero(stored.L1Fee)
+	}
+	if stored.Present&storedFeeScalar != 0 {
+		r.FeeScalar = new(big.Float)
+		if err := r.FeeScalar.GobDecode(stored.FeeScalar); err != nil {
+			return nil, fmt.Errorf("decode FeeScalar: %w", err)
+		}
+	}
+	if stored.Present&storedL1BaseFeeScalar != 0 {
+		l1BaseFeeScalar := stored.L1BaseFeeScalar
+		r.L1BaseFeeScalar = &l1BaseFeeScalar
+	}
+	if stored.Present&storedL1BlobBaseFeeScalar != 0 {
+		l1BlobBaseFeeScalar := stored.L1BlobBaseFeeScalar
+		r.L1BlobBaseFeeScalar = &l1BlobBaseFeeScalar
+	}
+	if stored.Present&storedL1BlobBaseFee != 0 {
+		r.L1BlobBaseFee = bigOrZero(stored.L1BlobBaseFee)
+	}
+	return r, nil
+}
+
+func bigOrZero(v *big.Int) *big.Int {
+	if v == nil {
+		return new(big.Int)
+	}
+	return v
+}
+
+// EncodeReceiptsForStorage - value of kv.Receipts in storage encoding v2, compressed if SetReceiptsCompression
+// enabled it. Logs are not included.
+func EncodeReceiptsForStorage(receipts Receipts) ([]byte, error) {
+	stored := make([]*storedReceiptV2RLP, len(receipts))
+	for i, r := range receipts {
+		enc, err := newStoredReceiptV2RLP(r)
+		if err != nil {
+			return nil, fmt.Errorf("receipt %d: %w", i, err)
+		}
+		stored[i] = enc
+	}
+	var buf bytes.Buffer
+	buf.WriteByte(ReceiptsStorageV2)
+	if err := rlp.Encode(&buf, stored); err != nil {
+		return nil, err
+	}
+	return compressReceiptsValue(buf.Bytes()), nil
+}
+
+// DecodeReceiptsForStorage - decodes value of kv.Receipts. Understands both storage encoding v2 and legacy cbor.
+func DecodeReceiptsForStorage(data []byte) (Receipts, error) {
+	if !IsReceiptsStorageV2(data) {
+		var receipts Receipts
+		if err := cbor.Unmarshal(&receipts, bytes.NewReader(data)); err != nil {
+			return nil, err
+		}
+		return receipts, nil
+	}
+	data, err := decompressReceiptsValue(data)
+	if err != nil {
+		return nil, err
+	}
+	var stored []*storedReceiptV2RLP
+	if err := rlp.DecodeBytes(data[1:], &stored); err != nil {
+		return nil, err
+	}
+	receipts := make(Receipts, len(stored))
+	for i := range stored {
+		r, err := stored[i].toReceipt()
+		if err != nil {
+			return nil, fmt.Errorf("receipt %d: %w", i, err)
+		}
+		receipts[i] = r
+	}
+	return receipts, nil
+}
+
+// EncodeLogsForStorage - value of kv.Log in storage encoding v2, compressed if SetReceiptsCompression enabled it
+func EncodeLogsForStorage(logs Logs) ([]byte, error) {
+	stored := make([]*LogForStorage, len(logs))
+	for i, l := range logs {
+		stored[i] = (*LogForStorage)(l)
+	}
+	var buf bytes.Buffer
+	buf.WriteByte(ReceiptsStorageV2)
+	if err := rlp.Encode(&buf, stored); err != nil {
+		return nil, err
+	}
+	return compressReceiptsValue(buf.Bytes()), nil
+}
+
+// DecodeLogsForStorage - decodes value of kv.Log. Understands both storage encoding v2 and legacy cbor.
+func DecodeLogsForStorage(data []byte) (Logs, error) {
+	if !IsReceiptsStorageV2(data) {
+		var logs Logs
+		if err := cbor.Unmarshal(&logs, bytes.NewReader(data)); err != nil {
+			return nil, err
+		}
+		return logs, nil
+	}
+	data, err := decompressReceiptsValue(data)
+	if err != nil {
+		return nil, err
+	}
+	var stored []*LogForStorage
+	if err := rlp.DecodeBytes(data[1:], &stored); err != nil {
+		return nil, err
+	}
+	logs := make(Logs, len(stored))
+	for i := range stored {
+		logs[i] = (*Log)(stored[i])
+	}
+	return logs, nil
+}
+
+// blockReceiptsV2RLP - receipts of a block with their logs, for the frozen receipts segments
+type blockReceiptsV2RLP struct {
+	Receipts []*storedReceiptV2RLP
+	Logs     [][]*LogForStorage
+}
+
+// EncodeBlockReceiptsForSnapshot - receipts of a block with their logs in storage encoding v2, for the frozen
+// receipts segments. Never compressed with the zstd dictionary: the segments don't depend on the datadir.
+func EncodeBlockReceiptsForSnapshot(receipts Receipts) ([]byte, error) {
+	enc := &blockReceiptsV2RLP{
+		Receipts: make([]*storedReceiptV2RLP, len(receipts)),
+		Logs:     make([][]*LogForStorage, len(receipts)),
+	}
+	for i, r := range receipts {
+		stored, err := newStoredReceiptV2RLP(r)
+		if err != nil {
+			return nil, fmt.Errorf("receipt %d: %w", i, err)
+		}
+		enc.Receipts[i] = stored
+		enc.Logs[i] = make([]*LogForStorage, len(r.Logs))
+		for j, l := range r.Logs {
+			enc.Logs[i][j] = (*LogForStorage)(l)
+		}
+	}
+	var buf bytes.Buffer
+	buf.WriteByte(ReceiptsStorageV2)
+	if err := rlp.Encode(&buf, enc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodeBlockReceiptsForSnapshot - decodes EncodeBlockReceiptsForSnapshot
+func DecodeBlockReceiptsForSnapshot(data []byte) (Receipts, error) {
+	if len(data) == 0 || data[0] != ReceiptsStorageV2 {
+		return nil, errors.New("block receipts: not in storage encoding v2")
+	}
+	var stored blockReceiptsV2RLP
+	if err := rlp.DecodeBytes(data[1:], &stored); err != nil {
+		return nil, err
+	}
+	if len(stored.Logs) != len(stored.Receipts) {
+		return nil, fmt.Errorf("block receipts: %d receipts, logs of %d", len(stored.Receipts), len(stored.Logs))
+	}
+	receipts := make(Receipts, len(stored.Receipts))
+	for i := range stored.Receipts {
+		r, err := stored.Receipts[i].toReceipt()
+		if err != nil {
+			return nil, fmt.Errorf("receipt %d: %w", i, err)
+		}
+		if len(stored.Logs[i]) > 0 {
+			r.Logs = make(Logs, len(stored.Logs[i]))
+			for j, l := range stored.Logs[i] {
+				r.Logs[j] = (*Log)(l)
+			}
+		}
+		receipts[i] = r
+	}
+	return receipts, nil
+}
@@ -0,0 +1,182 @@
+package types
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+
+	"github.com/erigontech/erigon/ethdb/cbor"
+)
+
+func storageTestReceipts() Receipts {
+	depositNonce, depositReceiptVersion := uint64(0), CanyonDepositReceiptVersion
+	baseFeeScalar, blobBaseFeeScalar := uint64(1368), uint64(810949)
+	return Receipts{
+		{
+			Type:              LegacyTxType,
+			Status:            ReceiptStatusSuccessful,
+			CumulativeGasUsed: 21_000,
+			L1GasPrice:        big.NewInt(30_000_000_000),
+			L1GasUsed:         big.NewInt(1_600),
+			L1Fee:             big.NewInt(0),
+			FeeScalar:         big.NewFloat(1.5),
+		},
+		{
+			Type:                  DepositTxType,
+			Status:                ReceiptStatusFailed,
+			CumulativeGasUsed:     60_000,
+			DepositNonce:          &depositNonce,
+			DepositReceiptVersion: &depositReceiptVersion,
+		},
+		{
+			Type:                DynamicFeeTxType,
+			Status:              ReceiptStatusSuccessful,
+			CumulativeGasUsed:   100_000,
+			L1GasPrice:          big.NewInt(7),
+			L1Fee:               big.NewInt(123_456),
+			L1BaseFeeScalar:     &baseFeeScalar,
+			L1BlobBaseFeeScalar: &blobBaseFeeScalar,
+			L1BlobBaseFee:       big.NewInt(1),
+		},
+		{
+			Type:              LegacyTxType,
+			PostState:         libcommon.HexToHash("0x01").Bytes(),
+			CumulativeGasUsed: 121_000,
+		},
+	}
+}
+
+func requireBigEqual(t *testing.T, want, got *big.Int) {
+	t.Helper()
+	if want == nil {
+		require.Nil(t, got)
+		return
+	}
+	require.NotNil(t, got)
+	require.Zero(t, want.Cmp(got), "want %d, got %d", want, got)
+}
+
+func TestReceiptsStorageV2(t *testing.T) {
+	t.Parallel()
+	receipts := storageTestReceipts()
+	enc, err := EncodeReceiptsForStorage(receipts)
+	require.NoError(t, err)
+	require.True(t, IsReceiptsStorageV2(enc))
+
+	dec, err := DecodeReceiptsForStorage(enc)
+	require.NoError(t, err)
+	require.Len(t, dec, len(receipts))
+	for i := range receipts {
+		want, got := receipts[i], dec[i]
+		require.Equal(t, want.Type, got.Type)
+		require.Equal(t, want.Status, got.Status)
+		require.Equal(t, want.PostState, got.PostState)
+		require.Equal(t, want.CumulativeGasUsed, got.CumulativeGasUsed)
+		require.Equal(t, want.DepositNonce, got.DepositNonce)
+		require.Equal(t, want.DepositReceiptVersion, got.DepositReceiptVersion)
+		requireBigEqual(t, want.L1GasPrice, got.L1GasPrice)
+		requireBigEqual(t, want.L1GasUsed, got.L1GasUsed)
+		requireBigEqual(t, want.L1Fee, got.L1Fee)
+		require.Equal(t, want.L1BaseFeeScalar, got.L1BaseFeeScalar)
+		require.Equal(t, want.L1BlobBaseFeeScalar, got.L1BlobBaseFeeScalar)
+		requireBigEqual(t, want.L1BlobBaseFee, got.L1BlobBaseFee)
+		if want.FeeScalar == nil {
+			require.Nil(t, got.FeeScalar)
+		} else {
+			require.Zero(t, want.FeeScalar.Cmp(got.FeeScalar))
+		}
+	}
+	// zero DepositNonce must survive, nil fields must stay nil
+	require.NotNil(t, dec[1].DepositNonce)
+	require.Nil(t, dec[1].L1Fee)
+}
+
+func TestReceiptsStorageV2_ReadsLegacyCbor(t *testing.T) {
+	t.Parallel()
+	receipts := storageTestReceipts()
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, cbor.Marshal(buf, receipts))
+	require.False(t, IsReceiptsStorageV2(buf.Bytes()))
+
+	dec, err := DecodeReceiptsForStorage(buf.Bytes())
+	require.NoError(t, err)
+	require.Len(t, dec, len(receipts))
+	for i := range receipts {
+		require.Equal(t, receipts[i].CumulativeGasUsed, dec[i].CumulativeGasUsed)
+	}
+
+	logs := Logs{{Address: libcommon.HexToAddress("0x1"), Topics: []libcommon.Hash{libcommon.HexToHash("0x2")}, Data: []byte{3}}}
+	buf.Reset()
+	require.NoError(t, cbor.Marshal(buf, logs))
+	decLogs, err := DecodeLogsForStorage(buf.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, logs[0].Address, decLogs[0].Address)
+	require.Equal(t, logs[0].Topics, decLogs[0].Topics)
+	require.Equal(t, logs[0].Data, decLogs[0].Data)
+}
+
+func TestLogsStorageV2(t *testing.T) {
+	t.Parallel()
+	logs := Logs{
+		{Address: libcommon.HexToAddress("0x1"), Topics: []libcommon.Hash{libcommon.HexToHash("0x2"), libcommon.HexToHash("0x3")}, Data: []byte{4, 5}},
+		{Address: libcommon.HexToAddress("0x6"), Topics: []libcommon.Hash{libcommon.HexToHash("0x7")}, Data: []byte{8}},
+	}
+	enc, err := EncodeLogsForStorage(logs)
+	require.NoError(t, err)
+	require.True(t, IsReceiptsStorageV2(enc))
+
+	dec, err := DecodeLogsForStorage(enc)
+	require.NoError(t, err)
+	require.Len(t, dec, len(logs))
+	for i := range logs {
+		require.Equal(t, logs[i].Address, dec[i].Address)
+		require.Equal(t, logs[i].Topics, dec[i].Topics)
+		require.Equal(t, logs[i].Data, dec[i].Data)
+	}
+}
+
+// not parallel: compression is a process-wide setting
+func TestReceiptsStorageZstd(t *testing.T) {
+	plain := make(Logs, 20)
+	for i := range plain {
+		plain[i] = &Log{Address: libcommon.HexToAddress("0x1"), Topics: []libcommon.Hash{libcommon.HexToHash("0x2")}, Data: make([]byte, 64)}
+	}
+	written, err := EncodeLogsForStorage(plain)
+	require.NoError(t, err)
+
+	require.NoError(t, SetReceiptsCompression(true, nil))
+	t.Cleanup(func() { require.NoError(t, SetReceiptsCompression(false, nil)) })
+
+	enc, err := EncodeLogsForStorage(plain)
+	require.NoError(t, err)
+	require.Equal(t, byte(ReceiptsStorageZstd), enc[0])
+	require.True(t, IsReceiptsStorageV2(enc))
+	require.Less(t, len(enc), len(written))
+	for _, v := range [][]byte{enc, written} { // values written before compression was on are read too
+		dec, err := DecodeLogsForStorage(v)
+		require.NoError(t, err)
+		require.Len(t, dec, len(plain))
+		require.Equal(t, plain[19].Data, dec[19].Data)
+	}
+
+	receipts := storageTestReceipts()
+	encReceipts, err := EncodeReceiptsForStorage(receipts)
+	require.NoError(t, err)
+	decReceipts, err := DecodeReceiptsForStorage(encReceipts)
+	require.NoError(t, err)
+	require.Len(t, decReceipts, len(receipts))
+	require.Equal(t, receipts[3].CumulativeGasUsed, decReceipts[3].CumulativeGasUsed)
+
+	// turned off: new values are not compressed, compressed ones are still read
+	require.NoError(t, SetReceiptsCompression(false, nil))
+	enc2, err := EncodeLogsForStorage(plain)
+	require.NoError(t, err)
+	require.Equal(t, written, enc2)
+	dec, err := DecodeLogsForStorage(enc)
+	require.NoError(t, err)
+	require.Len(t, dec, len(plain))
+}
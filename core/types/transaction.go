@@ -474,6 +474,7 @@ type Message struct {
 	isSystemTx bool
 	mint       *uint256.Int
 	l1CostGas  types2.RollupCostData
+	bobaTuring []byte // pre-bedrock Boba only, see AsMessageLegacyAware
 }
 
 func NewMessage(from libcommon.Address, to *libcommon.Address, nonce uint64, amount *uint256.Int, gasLimit uint64,
@@ -549,6 +550,7 @@ func (m Message) IsSystemTx() bool                      { return m.isSystemTx }
 func (m Message) IsDepositTx() bool                     { return m.txType == DepositTxType }
 func (m Message) Mint() *uint256.Int                    { return m.mint }
 func (m Message) RollupCostData() types2.RollupCostData { return m.l1CostGas }
+func (m Message) BobaTuring() []byte                    { return m.bobaTuring }
 
 func (m Message) BlobGas() uint64 { return fixedgas.BlobGasPerBlob * uint64(len(m.blobHashes)) }
 
@@ -0,0 +1,114 @@
+package vm
+
+import (
+	"sync/atomic"
+
+	"github.com/c2h5oh/datasize"
+	lru "github.com/hashicorp/golang-lru/v2"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/metrics"
+)
+
+// codeAnalysisVersion - version of codeBitmap, to be bumped with any change of the analysis, so no cached analysis
+// of another version is used
+const codeAnalysisVersion = 1
+
+// DefaultCodeAnalysisCacheSize - the default limit of the shared code analysis cache, see SetCodeAnalysisCacheSize
+const DefaultCodeAnalysisCacheSize = 64 * datasize.MB
+
+var (
+	codeAnalysisCacheHits   = metrics.GetOrCreateCounter(`vm_code_analysis_cache{result="hit"}`)
+	codeAnalysisCacheMisses = metrics.GetOrCreateCounter(`vm_code_analysis_cache{result="miss"}`)
+	codeAnalysisCacheSize   = metrics.GetOrCreateGauge(`vm_code_analysis_cache_size`)
+)
+
+type codeAnalysisKey struct {
+	codeHash libcommon.Hash
+	version  uint8
+}
+
+// codeAnalysisCache - JUMPDEST analyses of the contract codes, shared by all the EVMs of the process (execution,
+// mining, RPC), so the hot contracts are not analysed again by each transaction calling them. The key is the code
+// hash, the code of an account changing (or unwound) doesn't invalidate anything. Bounded by the bytes of the
+// cached analyses, the least recently used are evicted.
+type codeAnalysisCache struct {
+	analyses *lru.Cache[codeAnalysisKey, []uint64]
+	size     atomic.Int64 // bytes of analyses
+	limit    int64
+}
+
+var sharedCodeAnalysis atomic.Pointer[codeAnalysisCache]
+
+func init() {
+	SetCodeAnalysisCacheSize(DefaultCodeAnalysisCacheSize)
+}
+
+// SetCodeAnalysisCacheSize replaces the shared code analysis cache by an empty one of the limit, disables it if 0
+func SetCodeAnalysisCacheSize(limit datasize.ByteSize) {
+	if limit == 0 {
+		sharedCodeAnalysis.Store(nil)
+	} else {
+		sharedCodeAnalysis.Store(newCodeAnalysisCache(limit))
+	}
+	codeAnalysisCacheSize.SetUint64(0)
+}
+
+func newCodeAnalysisCache(limit datasize.ByteSize) *codeAnalysisCache {
+	c := &codeAnalysisCache{limit: int64(limit.Bytes())}
+	// the number of analyses is bounded by their bytes, each takes at least one word
+	analyses, err := lru.NewWithEvict[codeAnalysisKey, []uint64](int(limit.Bytes()/8)+1, func(_ codeAnalysisKey, analysis []uint64) {
+		c.size.Add(-int64(len(analysis) * 8))
+	})
+	if err != nil {
+		panic(err)
+	}
+	c.analyses = analyses
+	return c
+}
+
+func (c *codeAnalysisCache) get(codeHash libcommon.Hash) ([]uint64, bool) {
+	analysis, ok := c.analyses.Get(codeAnalysisKey{codeHash: codeHash, version: codeAnalysisVersion})
+	if ok {
+		codeAnalysisCacheHits.Inc()
+	} else {
+		codeAnalysisCacheMisses.Inc()
+	}
+	return analysis, ok
+}
+
+func (c *codeAnalysisCache) add(codeHash libcommon.Hash, analysis []uint64) {
+	size := int64(len(analysis) * 8)
+	if size > c.limit {
+		return
+	}
+	// goroutines missing the same code race to add it: only the one inserting it counts its size
+	if found, _ := c.analyses.ContainsOrAdd(codeAnalysisKey{codeHash: codeHash, version: codeAnalysisVersion}, analysis); found {
+		return
+	}
+	c.size.Add(size)
+	for c.size.Load() > c.limit {
+		if _, _, ok := c.analyses.RemoveOldest(); !ok {
+			break
+		}
+	}
+	codeAnalysisCacheSize.SetUint64(uint64(c.size.Load()))
+}
+
+// codeAnalysis - JUMPDEST analysis of the code from the shared cache, done and cached if missing there. The
+// analyses are read only, so shared by the goroutines.
+func codeAnalysis(codeHash libcommon.Hash, code []byte) []uint64 {
+	return sharedCodeAnalysis.Load().analysis(codeHash, code)
+}
+
+func (c *codeAnalysisCache) analysis(codeHash libcommon.Hash, code []byte) []uint64 {
+	if c == nil {
+		return codeBitmap(code)
+	}
+	if analysis, ok := c.get(codeHash); ok {
+		return analysis
+	}
+	analysis := codeBitmap(code)
+	c.add(codeHash, analysis)
+	return analysis
+}
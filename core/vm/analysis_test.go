@@ -21,6 +21,7 @@ import (
 
 	libcommon "github.com/erigontech/erigon-lib/common"
 	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
 
 	"github.com/erigontech/erigon/crypto"
 )
@@ -101,3 +102,38 @@ func BenchmarkJumpDest(b *testing.B) {
 		b.StopTimer()
 	}
 }
+
+func TestCodeAnalysisCache(t *testing.T) {
+	t.Parallel()
+	c := newCodeAnalysisCache(3 * 8)
+	code1, code2 := []byte{byte(PUSH1), 0x01, byte(JUMPDEST)}, []byte{byte(PUSH2), 0x01, 0x01, byte(JUMPDEST)}
+	hash1, hash2 := crypto.Keccak256Hash(code1), crypto.Keccak256Hash(code2)
+
+	analysis := c.analysis(hash1, code1)
+	require.Equal(t, codeBitmap(code1), analysis)
+	_, ok := c.get(hash1)
+	require.True(t, ok)
+	require.Equal(t, int64(len(analysis)*8), c.size.Load())
+
+	// the limit evicts the least recently used
+	c.analysis(hash2, code2)
+	c.analysis(libcommon.Hash{3}, make([]byte, 64))
+	_, ok = c.get(hash1)
+	require.False(t, ok)
+	_, ok = c.get(hash2)
+	require.True(t, ok)
+	require.LessOrEqual(t, c.size.Load(), c.limit)
+
+	// added again by a goroutine racing on the same miss: not counted twice
+	size := c.size.Load()
+	c.add(hash2, codeBitmap(code2))
+	require.Equal(t, size, c.size.Load())
+
+	// too large to be cached
+	c.analysis(libcommon.Hash{4}, make([]byte, 256))
+	_, ok = c.get(libcommon.Hash{4})
+	require.False(t, ok)
+
+	// disabled
+	require.Equal(t, codeBitmap(code1), (*codeAnalysisCache)(nil).analysis(hash1, code1))
+}
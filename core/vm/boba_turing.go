@@ -0,0 +1,34 @@
+package vm
+
+import (
+	"bytes"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+
+	"github.com/erigontech/erigon/crypto"
+)
+
+// Pre-bedrock Boba Turing (hybrid compute): the TuringHelper contract made an off-chain request when called with one of
+// these methods, and l2geth replaced calldata of the call by the response. The response of the sequencer is stored in
+// the transaction (see types.DecodeBobaTuringInput) and replayed on re-execution.
+var bobaTuringSelectors = [][]byte{
+	crypto.Keccak256([]byte("GetResponse(uint32,string,bytes)"))[:4],
+	crypto.Keccak256([]byte("GetRandom(uint32,uint256)"))[:4],
+}
+
+// bobaTuringInput - calldata of the call: the Turing response of the transaction for the first Turing request to a
+// TuringHelper of the chain config (to any contract if it has none), input otherwise. l2geth allowed one request per
+// transaction.
+func (evm *EVM) bobaTuringInput(typ OpCode, addr libcommon.Address, input []byte) []byte {
+	if evm.BobaTuring == nil || typ != CALL || len(input) < 4 || !evm.chainConfig.IsBobaTuringHelper(addr) {
+		return input
+	}
+	for _, selector := range bobaTuringSelectors {
+		if bytes.Equal(input[:4], selector) {
+			response := evm.BobaTuring
+			evm.BobaTuring = nil
+			return response
+		}
+	}
+	return input
+}
@@ -0,0 +1,42 @@
+package vm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/chain"
+	libcommon "github.com/erigontech/erigon-lib/common"
+
+	"github.com/erigontech/erigon/core/vm/evmtypes"
+	"github.com/erigontech/erigon/params"
+)
+
+func TestBobaTuringInput(t *testing.T) {
+	response := []byte{0xde, 0xad}
+	helper, other := libcommon.Address{1}, libcommon.Address{2}
+	evm := &EVM{
+		TxContext:   evmtypes.TxContext{BobaTuring: response},
+		chainConfig: &chain.Config{Optimism: &chain.OptimismConfig{BobaLegacy: &chain.BobaLegacyConfig{TuringHelpers: []libcommon.Address{helper}}}},
+	}
+
+	transfer := []byte{0xa9, 0x05, 0x9c, 0xbb, 0x00}
+	require.Equal(t, transfer, evm.bobaTuringInput(CALL, helper, transfer))
+	getRandom := append(append([]byte{}, bobaTuringSelectors[1]...), make([]byte, 64)...)
+	require.Equal(t, getRandom, evm.bobaTuringInput(STATICCALL, helper, getRandom))
+	// not a TuringHelper
+	require.Equal(t, getRandom, evm.bobaTuringInput(CALL, other, getRandom))
+
+	require.Equal(t, response, evm.bobaTuringInput(CALL, helper, getRandom))
+	// replayed once
+	require.Equal(t, getRandom, evm.bobaTuringInput(CALL, helper, getRandom))
+
+	// Boba networks: the TuringHelpers of dapps aren't listed, the request is replayed at any contract
+	evm = &EVM{TxContext: evmtypes.TxContext{BobaTuring: response}, chainConfig: params.ChainConfigByOpStackChainName("boba-mainnet")}
+	require.Equal(t, transfer, evm.bobaTuringInput(CALL, other, transfer))
+	require.Equal(t, response, evm.bobaTuringInput(CALL, other, getRandom))
+	require.Equal(t, getRandom, evm.bobaTuringInput(CALL, helper, getRandom))
+	// not a Boba network
+	evm = &EVM{TxContext: evmtypes.TxContext{BobaTuring: response}, chainConfig: params.ChainConfigByOpStackChainName("op-mainnet")}
+	require.Equal(t, getRandom, evm.bobaTuringInput(CALL, other, getRandom))
+}
@@ -227,6 +227,10 @@ func init() {
 
 // ActivePrecompiles returns the precompiles enabled with the current configuration.
 func ActivePrecompiles(rules *chain.Rules) []libcommon.Address {
+	return withL2Precompiles(rules, activeStandardPrecompiles(rules))
+}
+
+func activeStandardPrecompiles(rules *chain.Rules) []libcommon.Address {
 	switch {
 	case rules.IsOptimismGranite:
 		return PrecompiledAddressesGranite
@@ -303,6 +303,10 @@ func enable6780(jt *JumpTable) {
 
 // opBlobBaseFee implements the BLOBBASEFEE opcode
 func opBlobBaseFee(pc *uint64, interpreter *EVMInterpreter, callContext *ScopeContext) ([]byte, error) {
+	if blobBaseFee := interpreter.evm.Context.BlobBaseFee; blobBaseFee != nil {
+		callContext.Stack.Push(blobBaseFee.Clone())
+		return nil, nil
+	}
 	excessBlobGas := interpreter.evm.Context.ExcessBlobGas
 	blobBaseFee, err := misc.GetBlobGasPrice(interpreter.evm.ChainConfig(), *excessBlobGas)
 	if err != nil {
@@ -56,8 +56,10 @@ func (evm *EVM) precompile(addr libcommon.Address) (PrecompiledContract, bool) {
 	default:
 		precompiles = PrecompiledContractsHomestead
 	}
-	p, ok := precompiles[addr]
-	return p, ok
+	if p, ok := precompiles[addr]; ok {
+		return p, true
+	}
+	return l2Precompile(evm.chainRules, addr)
 }
 
 // run runs the given contract and takes care of running precompiles with a fallback to the byte code interpreter.
@@ -195,6 +197,7 @@ func (evm *EVM) call(typ OpCode, caller ContractRef, addr libcommon.Address, inp
 	if !isPrecompile {
 		code = evm.intraBlockState.ResolveCode(addr)
 	}
+	input = evm.bobaTuringInput(typ, addr, input)
 
 	snapshot := evm.intraBlockState.Snapshot()
 
@@ -35,6 +35,7 @@ type BlockContext struct {
 	BaseFee       *uint256.Int   // Provides information for BASEFEE
 	PrevRanDao    *common.Hash   // Provides information for PREVRANDAO
 	ExcessBlobGas *uint64        // Provides information for handling data blobs
+	BlobBaseFee   *uint256.Int   // Replaces the blob base fee derived from ExcessBlobGas (RPC block overrides)
 
 	// L1CostFunc returns the L1 cost of the rollup message, the function may be nil, or return nil
 	L1CostFunc opstack.L1CostFunc
@@ -49,6 +50,7 @@ type TxContext struct {
 	GasPrice   *uint256.Int   // Provides information for GASPRICE
 	BlobFee    *uint256.Int   // The fee for blobs(blobGas * blobGasPrice) incurred in the txn
 	BlobHashes []common.Hash  // Provides versioned blob hashes for BLOBHASH
+	BobaTuring []byte         // Pre-bedrock Boba: Turing response replayed instead of the off-chain request, once
 }
 
 // ExecutionResult includes all output after executing given evm
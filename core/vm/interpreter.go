@@ -41,6 +41,8 @@ type Config struct {
 	StatelessExec bool      // true is certain conditions (like state trie root hash matching) need to be relaxed for stateless EVM execution
 	RestoreState  bool      // Revert all changes made to the state (useful for constant system calls)
 
+	VerifyDepositNonces bool // Fail blocks whose deposit receipts don't match the nonces of their senders (OP Regolith)
+
 	ExtraEips []int // Additional EIPS that are to be enabled
 }
 
@@ -0,0 +1,84 @@
+package vm
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/erigontech/erigon-lib/chain"
+	libcommon "github.com/erigontech/erigon-lib/common"
+)
+
+// precompileRegistry - precompiles which the chain config can activate at addresses of its own
+// (chain.OptimismConfig.Precompiles), by name. Only written from init functions.
+var precompileRegistry = map[string]PrecompiledContract{}
+
+func init() {
+	RegisterPrecompile("p256Verify", &p256Verify{})
+	RegisterPrecompile("bls12381G1Add", &bls12381G1Add{})
+	RegisterPrecompile("bls12381G1Mul", &bls12381G1Mul{})
+	RegisterPrecompile("bls12381G1MultiExp", &bls12381G1MultiExp{})
+	RegisterPrecompile("bls12381G2Add", &bls12381G2Add{})
+	RegisterPrecompile("bls12381G2Mul", &bls12381G2Mul{})
+	RegisterPrecompile("bls12381G2MultiExp", &bls12381G2MultiExp{})
+	RegisterPrecompile("bls12381Pairing", &bls12381Pairing{})
+	RegisterPrecompile("bls12381MapFpToG1", &bls12381MapFpToG1{})
+	RegisterPrecompile("bls12381MapFp2ToG2", &bls12381MapFp2ToG2{})
+}
+
+// RegisterPrecompile makes the precompile available to chain configs under name.
+// It must be called from an init function, registering a name twice panics.
+func RegisterPrecompile(name string, p PrecompiledContract) {
+	if name == "" {
+		panic("precompile registered without a name")
+	}
+	if _, ok := precompileRegistry[name]; ok {
+		panic(fmt.Sprintf("precompile %s registered twice", name))
+	}
+	precompileRegistry[name] = p
+}
+
+// CheckPrecompiles checks that all precompiles activated by the chain config are registered
+// and don't shadow standard precompiles.
+func CheckPrecompiles(config *chain.Config) error {
+	if !config.IsOptimism() {
+		return nil
+	}
+	for _, p := range config.Optimism.Precompiles {
+		if _, ok := precompileRegistry[p.Name]; !ok {
+			return fmt.Errorf("precompile %s at %x: not registered", p.Name, p.Address)
+		}
+		if p.Time == nil {
+			return fmt.Errorf("precompile %s at %x: no activation time", p.Name, p.Address)
+		}
+		for _, standard := range []map[libcommon.Address]PrecompiledContract{PrecompiledContractsGranite, PrecompiledContractsPrague, PrecompiledContractsNapoli} {
+			if _, ok := standard[p.Address]; ok {
+				return fmt.Errorf("precompile %s at %x: address of a standard precompile", p.Name, p.Address)
+			}
+		}
+	}
+	return nil
+}
+
+// l2Precompile returns the precompile activated at addr by the chain config
+func l2Precompile(rules *chain.Rules, addr libcommon.Address) (PrecompiledContract, bool) {
+	name, ok := rules.Precompiles[addr]
+	if !ok {
+		return nil, false
+	}
+	p, ok := precompileRegistry[name]
+	return p, ok
+}
+
+// withL2Precompiles returns addresses with the addresses of the precompiles activated by the chain config
+func withL2Precompiles(rules *chain.Rules, addresses []libcommon.Address) []libcommon.Address {
+	if len(rules.Precompiles) == 0 {
+		return addresses
+	}
+	extra := make([]libcommon.Address, 0, len(rules.Precompiles))
+	for addr := range rules.Precompiles {
+		extra = append(extra, addr)
+	}
+	sort.Slice(extra, func(i, j int) bool { return bytes.Compare(extra[i][:], extra[j][:]) < 0 })
+	return append(append(make([]libcommon.Address, 0, len(addresses)+len(extra)), addresses...), extra...)
+}
@@ -0,0 +1,73 @@
+package vm
+
+import (
+	"math/big"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/chain"
+	libcommon "github.com/erigontech/erigon-lib/common"
+
+	"github.com/erigontech/erigon/core/vm/evmtypes"
+	"github.com/erigontech/erigon/params"
+)
+
+func precompileTestConfig(precompiles ...chain.PrecompileConfig) *chain.Config {
+	config := *params.OptimismTestConfig
+	config.Optimism = &chain.OptimismConfig{EIP1559Elasticity: 50, EIP1559Denominator: 10, Precompiles: precompiles}
+	return &config
+}
+
+func TestL2PrecompileActivation(t *testing.T) {
+	t.Parallel()
+
+	addr := libcommon.HexToAddress("0x4200000000000000000000000000000000000b15")
+	config := precompileTestConfig(
+		chain.PrecompileConfig{Name: "bls12381G1Add", Address: addr, Time: big.NewInt(100)},
+		chain.PrecompileConfig{Name: "bls12381G1Mul", Address: addr, Time: big.NewInt(200)},
+	)
+	require.NoError(t, CheckPrecompiles(config))
+
+	for _, tt := range []struct {
+		time uint64
+		want PrecompiledContract
+	}{
+		{time: 99},
+		{time: 100, want: &bls12381G1Add{}},
+		{time: 199, want: &bls12381G1Add{}},
+		{time: 200, want: &bls12381G1Mul{}},
+	} {
+		evm := NewEVM(evmtypes.BlockContext{BlockNumber: 10, Time: tt.time}, evmtypes.TxContext{}, &dummyStatedb{}, config, Config{})
+		p, ok := evm.precompile(addr)
+		require.Equal(t, tt.want != nil, ok, "time %d", tt.time)
+		require.Equal(t, tt.want, p, "time %d", tt.time)
+		_, ok = evm.precompile(libcommon.BytesToAddress([]byte{1}))
+		require.True(t, ok, "standard precompiles stay active")
+
+		active := ActivePrecompiles(config.Rules(10, tt.time))
+		require.Equal(t, tt.want != nil, slices.Contains(active, addr), "time %d", tt.time)
+		require.True(t, slices.Contains(active, libcommon.BytesToAddress([]byte{1})))
+	}
+
+	// not an optimism chain
+	require.Nil(t, params.TestChainConfig.Rules(0, 1000).Precompiles)
+}
+
+func TestCheckPrecompiles(t *testing.T) {
+	t.Parallel()
+
+	addr := libcommon.HexToAddress("0x4200000000000000000000000000000000000b15")
+	require.ErrorContains(t, CheckPrecompiles(precompileTestConfig(
+		chain.PrecompileConfig{Name: "noSuchPrecompile", Address: addr, Time: big.NewInt(0)},
+	)), "not registered")
+	require.ErrorContains(t, CheckPrecompiles(precompileTestConfig(
+		chain.PrecompileConfig{Name: "p256Verify", Address: addr},
+	)), "no activation time")
+	require.ErrorContains(t, CheckPrecompiles(precompileTestConfig(
+		chain.PrecompileConfig{Name: "p256Verify", Address: libcommon.BytesToAddress([]byte{1}), Time: big.NewInt(0)},
+	)), "standard precompile")
+
+	require.Panics(t, func() { RegisterPrecompile("p256Verify", &p256Verify{}) })
+}
@@ -0,0 +1,20 @@
+package dbbackup
+
+import (
+	"context"
+)
+
+// API - admin_ namespace of the scheduler
+type API struct {
+	scheduler *Scheduler
+}
+
+// Backup takes backup of chaindata now and returns it when it's verified and uploaded
+func (api *API) Backup(ctx context.Context) (*Backup, error) {
+	return api.scheduler.Backup(ctx)
+}
+
+// Backups returns local backups, oldest first
+func (api *API) Backups(_ context.Context) ([]*Backup, error) {
+	return api.scheduler.List()
+}
@@ -0,0 +1,346 @@
+// Package dbbackup implements backups of chaindata taken while the node is running: consistent MDBX copies
+// are written to a local directory by schedule or by admin_backup RPC, verified, optionally uploaded to an
+// rclone remote (e.g. S3-compatible storage) and rotated by retention policy.
+package dbbackup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/mdbx"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon-lib/metrics"
+
+	"github.com/erigontech/erigon/eth/ethconfig"
+	"github.com/erigontech/erigon/node"
+	"github.com/erigontech/erigon/rpc"
+)
+
+const (
+	dataFile     = "mdbx.dat"
+	checksumFile = "mdbx.dat.sha256"
+	tmpSuffix    = ".tmp"
+	timeLayout   = "20060102-150405.000"
+)
+
+var (
+	backupsTaken         = metrics.GetOrCreateCounter("backup_taken")
+	backupErrors         = metrics.GetOrCreateCounter("backup_errors")
+	backupDuration       = metrics.GetOrCreateGauge("backup_duration_seconds")
+	backupSize           = metrics.GetOrCreateGauge("backup_size_bytes")
+	backupLastSuccessful = metrics.GetOrCreateGauge("backup_last_successful")
+)
+
+var ErrInProgress = errors.New("backup is already in progress")
+
+// Copier - database which can write its consistent copy, see mdbx.MdbxKV.Copy
+type Copier interface {
+	Copy(dir string, compact bool) error
+}
+
+// Uploader copies backups to remote storage, files are relative to the backups dir. downloader.RCloneSession implements it
+type Uploader interface {
+	Upload(ctx context.Context, files ...string) error
+	Delete(ctx context.Context, files ...string) error
+}
+
+// Backup - one backup: directory Name in the backups dir with the copy of the database and its checksum
+type Backup struct {
+	Name     string    `json:"name"`
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	Sha256   string    `json:"sha256"`
+	Taken    time.Time `json:"taken"`
+	Duration float64   `json:"durationSeconds,omitempty"`
+	Uploaded bool      `json:"uploaded,omitempty"`
+}
+
+// Scheduler takes backups of one database
+type Scheduler struct {
+	db       Copier
+	label    kv.Label
+	cfg      ethconfig.Backup
+	uploader Uploader
+
+	lock sync.Mutex // one backup at a time
+
+	ctx    context.Context
+	logger log.Logger
+}
+
+// New returns scheduler of backups of db with given label. db must be MDBX, possibly wrapped by temporal.DB
+func New(ctx context.Context, node *node.Node, db kv.RoDB, label kv.Label, cfg ethconfig.Backup, uploader Uploader, logger log.Logger) (*Scheduler, error) {
+	if internal, ok := db.(interface{ InternalDB() kv.RwDB }); ok {
+		db = internal.InternalDB()
+	}
+	copier, ok := db.(Copier)
+	if !ok {
+		return nil, fmt.Errorf("backup: %T doesn't support copying", db)
+	}
+	s := newScheduler(ctx, copier, label, cfg, uploader, logger)
+	if node != nil {
+		node.RegisterLifecycle(s)
+	}
+	return s, nil
+}
+
+func newScheduler(ctx context.Context, db Copier, label kv.Label, cfg ethconfig.Backup, uploader Uploader, logger log.Logger) *Scheduler {
+	return &Scheduler{
+		db:       db,
+		label:    label,
+		cfg:      cfg,
+		uploader: uploader,
+		ctx:      ctx,
+		logger:   logger,
+	}
+}
+
+// Start implements node.Lifecycle, starting up the scheduler.
+func (s *Scheduler) Start() error {
+	if err := os.MkdirAll(s.cfg.Dir, 0755); err != nil {
+		return fmt.Errorf("backup dir: %w", err)
+	}
+	s.removeUnfinished()
+	if s.cfg.Interval > 0 {
+		go s.loop()
+	}
+	s.logger.Info("[backup] started", "dir", s.cfg.Dir, "interval", s.cfg.Interval, "keep", s.cfg.Keep, "remote", s.cfg.Remote)
+	return nil
+}
+
+// Stop implements node.Lifecycle, terminating the scheduler. Backup in progress is stopped by the ctx.
+func (s *Scheduler) Stop() error {
+	s.logger.Info("[backup] stopped")
+	return nil
+}
+
+// APIs - admin_backup and admin_backups
+func (s *Scheduler) APIs() []rpc.API {
+	return []rpc.API{{
+		Namespace: "admin",
+		Public:    false,
+		Service:   &API{scheduler: s},
+		Version:   "1.0",
+	}}
+}
+
+func (s *Scheduler) loop() {
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if _, err := s.Backup(s.ctx); err != nil && !errors.Is(err, ErrInProgress) && s.ctx.Err() == nil {
+			s.logger.Warn("[backup] scheduled backup failed", "err", err)
+		}
+	}
+}
+
+// Backup takes backup now. It fails with ErrInProgress if other backup isn't finished yet.
+func (s *Scheduler) Backup(ctx context.Context) (*Backup, error) {
+	if !s.lock.TryLock() {
+		return nil, ErrInProgress
+	}
+	defer s.lock.Unlock()
+
+	b, err := s.backup(ctx)
+	if err != nil {
+		backupErrors.Inc()
+		return nil, err
+	}
+	backupsTaken.Inc()
+	backupDuration.Set(b.Duration)
+	backupSize.SetUint64(uint64(b.Size))
+	backupLastSuccessful.SetUint64(uint64(b.Taken.Unix()))
+	s.logger.Info("[backup] done", "name", b.Name, "size", b.Size, "took", time.Duration(b.Duration*float64(time.Second)), "uploaded", b.Uploaded)
+
+	if err := s.rotate(ctx); err != nil {
+		s.logger.Warn("[backup] removing old backups failed", "err", err)
+	}
+	return b, nil
+}
+
+func (s *Scheduler) backup(ctx context.Context) (*Backup, error) {
+	start := time.Now()
+	name := fmt.Sprintf("%s-%s", s.label, start.UTC().Format(timeLayout))
+	dir, tmpDir := filepath.Join(s.cfg.Dir, name), filepath.Join(s.cfg.Dir, name+tmpSuffix)
+	s.logger.Info("[backup] started", "name", name)
+
+	ok := false
+	defer func() {
+		if !ok {
+			_ = os.RemoveAll(tmpDir)
+		}
+	}()
+	if err := s.db.Copy(tmpDir, s.cfg.Compact); err != nil {
+		return nil, fmt.Errorf("copying database: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if s.cfg.Verify {
+		if err := verify(ctx, tmpDir, s.label, s.logger); err != nil {
+			return nil, fmt.Errorf("verifying copy: %w", err)
+		}
+	}
+	sum, size, err := checksum(filepath.Join(tmpDir, dataFile))
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, checksumFile), []byte(sum+"  "+dataFile+"\n"), 0644); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmpDir, dir); err != nil {
+		return nil, err
+	}
+	ok = true
+
+	b := &Backup{Name: name, Path: dir, Size: size, Sha256: sum, Taken: start}
+	if s.uploader != nil {
+		if err := s.uploader.Upload(ctx, filepath.Join(name, dataFile), filepath.Join(name, checksumFile)); err != nil {
+			return nil, fmt.Errorf("uploading %s: %w", name, err)
+		}
+		b.Uploaded = true
+	}
+	b.Duration = time.Since(start).Seconds()
+	return b, nil
+}
+
+// verify opens the copy read-only and walks every table of it
+func verify(ctx context.Context, dir string, label kv.Label, logger log.Logger) error {
+	db, err := mdbx.NewMDBX(logger).Path(dir).Label(label).
+		WithTableCfg(func(_ kv.TableCfg) kv.TableCfg { return kv.TablesCfgByLabel(label) }).
+		Readonly().Accede().
+		Open(ctx)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.View(ctx, func(tx kv.Tx) error {
+		tables, err := tx.(kv.BucketMigratorRO).ListBuckets()
+		if err != nil {
+			return err
+		}
+		for _, table := range tables {
+			if err := verifyTable(ctx, tx, table); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// verifyTable - reads all the entries of the table. A read error ends the cursor with a nil key, so it's checked
+// after the loop too.
+func verifyTable(ctx context.Context, tx kv.Tx, table string) error {
+	c, err := tx.Cursor(table)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	k, _, err := c.First()
+	for ; k != nil && err == nil; k, _, err = c.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("table %s: %w", table, err)
+	}
+	return nil
+}
+
+func checksum(file string) (sum string, size int64, err error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if size, err = io.Copy(h, f); err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
+
+// List returns finished backups, oldest first
+func (s *Scheduler) List() ([]*Backup, error) {
+	entries, err := os.ReadDir(s.cfg.Dir)
+	if err != nil {
+		return nil, err
+	}
+	prefix := s.label.String() + "-"
+	var res []*Backup
+	for _, e := range entries {
+		name := e.Name()
+		if !e.IsDir() || !strings.HasPrefix(name, prefix) || strings.HasSuffix(name, tmpSuffix) {
+			continue
+		}
+		taken, err := time.Parse(timeLayout, strings.TrimPrefix(name, prefix))
+		if err != nil {
+			continue
+		}
+		b := &Backup{Name: name, Path: filepath.Join(s.cfg.Dir, name), Taken: taken}
+		if info, err := os.Stat(filepath.Join(b.Path, dataFile)); err == nil {
+			b.Size = info.Size()
+		}
+		if sum, err := os.ReadFile(filepath.Join(b.Path, checksumFile)); err == nil {
+			b.Sha256, _, _ = strings.Cut(string(sum), " ")
+		}
+		res = append(res, b)
+	}
+	slices.SortFunc(res, func(a, b *Backup) int { return a.Taken.Compare(b.Taken) })
+	return res, nil
+}
+
+// rotate removes all backups except cfg.Keep latest ones
+func (s *Scheduler) rotate(ctx context.Context) error {
+	if s.cfg.Keep <= 0 {
+		return nil
+	}
+	backups, err := s.List()
+	if err != nil {
+		return err
+	}
+	for len(backups) > s.cfg.Keep {
+		b := backups[0]
+		backups = backups[1:]
+		if s.uploader != nil {
+			if err := s.uploader.Delete(ctx, filepath.Join(b.Name, dataFile), filepath.Join(b.Name, checksumFile)); err != nil {
+				return fmt.Errorf("removing %s from remote: %w", b.Name, err)
+			}
+		}
+		if err := os.RemoveAll(b.Path); err != nil {
+			return err
+		}
+		s.logger.Info("[backup] removed", "name", b.Name)
+	}
+	return nil
+}
+
+// removeUnfinished removes copies left by backups interrupted by shutdown
+func (s *Scheduler) removeUnfinished() {
+	entries, err := os.ReadDir(s.cfg.Dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if e.IsDir() && strings.HasPrefix(e.Name(), s.label.String()+"-") && strings.HasSuffix(e.Name(), tmpSuffix) {
+			_ = os.RemoveAll(filepath.Join(s.cfg.Dir, e.Name()))
+		}
+	}
+}
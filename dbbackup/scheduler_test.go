@@ -0,0 +1,129 @@
+package dbbackup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/mdbx"
+	"github.com/erigontech/erigon-lib/kv/memdb"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/eth/ethconfig"
+)
+
+type fakeUploader struct {
+	lock     sync.Mutex
+	uploaded []string
+	deleted  []string
+}
+
+func (u *fakeUploader) Upload(_ context.Context, files ...string) error {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	u.uploaded = append(u.uploaded, files...)
+	return nil
+}
+
+func (u *fakeUploader) Delete(_ context.Context, files ...string) error {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	u.deleted = append(u.deleted, files...)
+	return nil
+}
+
+func TestBackup(t *testing.T) {
+	ctx, logger := context.Background(), log.New()
+	db := memdb.NewTestDB(t)
+	require.NoError(t, db.Update(ctx, func(tx kv.RwTx) error {
+		return tx.Put(kv.HeaderNumber, []byte("key"), []byte("value"))
+	}))
+
+	cfg := ethconfig.Defaults.Backup
+	cfg.Dir, cfg.Keep = t.TempDir(), 2
+	uploader := &fakeUploader{}
+	s, err := New(ctx, nil, db, kv.ChainDB, cfg, uploader, logger)
+	require.NoError(t, err)
+	require.NoError(t, s.Start())
+
+	b, err := s.Backup(ctx)
+	require.NoError(t, err)
+	require.True(t, b.Uploaded)
+	require.Equal(t, []string{filepath.Join(b.Name, dataFile), filepath.Join(b.Name, checksumFile)}, uploader.uploaded)
+	sum, size, err := checksum(filepath.Join(b.Path, dataFile))
+	require.NoError(t, err)
+	require.Equal(t, sum, b.Sha256)
+	require.Equal(t, size, b.Size)
+
+	// copy is consistent and opens as the database
+	backup := mdbx.NewMDBX(logger).Path(b.Path).Readonly().Accede().MustOpen()
+	require.NoError(t, backup.View(ctx, func(tx kv.Tx) error {
+		v, err := tx.GetOne(kv.HeaderNumber, []byte("key"))
+		require.Equal(t, []byte("value"), v)
+		return err
+	}))
+	backup.Close()
+
+	// retention
+	for i := 0; i < 2; i++ {
+		_, err = s.Backup(ctx)
+		require.NoError(t, err)
+	}
+	backups, err := s.List()
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+	require.NotEqual(t, b.Name, backups[0].Name)
+	require.Equal(t, b.Sha256, backups[0].Sha256)
+	require.Equal(t, []string{filepath.Join(b.Name, dataFile), filepath.Join(b.Name, checksumFile)}, uploader.deleted)
+	_, err = os.Stat(b.Path)
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestBackupRemovesUnfinished(t *testing.T) {
+	cfg := ethconfig.Defaults.Backup
+	cfg.Dir = t.TempDir()
+	unfinished := filepath.Join(cfg.Dir, kv.ChainDB.String()+"-20240101-000000.000"+tmpSuffix)
+	require.NoError(t, os.MkdirAll(unfinished, 0755))
+
+	s, err := New(context.Background(), nil, memdb.NewTestDB(t), kv.ChainDB, cfg, nil, log.New())
+	require.NoError(t, err)
+	require.NoError(t, s.Start())
+	_, err = os.Stat(unfinished)
+	require.True(t, os.IsNotExist(err))
+
+	backups, err := s.List()
+	require.NoError(t, err)
+	require.Empty(t, backups)
+}
+
+func TestBackupInProgress(t *testing.T) {
+	cfg := ethconfig.Defaults.Backup
+	cfg.Dir = t.TempDir()
+	s := newScheduler(context.Background(), nil, kv.ChainDB, cfg, nil, log.New())
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	_, err := s.Backup(context.Background())
+	require.ErrorIs(t, err, ErrInProgress)
+}
+
+func TestVerifyTable(t *testing.T) {
+	ctx := context.Background()
+	db := memdb.NewTestDB(t)
+	require.NoError(t, db.Update(ctx, func(tx kv.RwTx) error {
+		return tx.Put(kv.HeaderNumber, []byte("key"), []byte("value"))
+	}))
+	require.NoError(t, db.View(ctx, func(tx kv.Tx) error {
+		require.NoError(t, verifyTable(ctx, tx, kv.HeaderNumber))
+		require.NoError(t, verifyTable(ctx, tx, kv.Headers)) // empty
+
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		require.ErrorIs(t, verifyTable(cancelled, tx, kv.HeaderNumber), context.Canceled)
+		return nil
+	}))
+}
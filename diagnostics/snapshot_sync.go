@@ -51,6 +51,16 @@ func SetupStagesAccess(metricsMux *http.ServeMux, diag *diaglib.DiagnosticClient
 		w.Header().Set("Content-Type", "application/json")
 		writeSyncStages(w, diag)
 	})
+
+	metricsMux.HandleFunc("/gas-usage", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		writeGasUsage(w, diag)
+	})
+
+	metricsMux.HandleFunc("/silkworm-audit", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		writeSilkwormAudit(w, diag)
+	})
 }
 
 func writeNetworkSpeed(w http.ResponseWriter, diag *diaglib.DiagnosticClient) {
@@ -72,3 +82,11 @@ func writeFilesList(w http.ResponseWriter, diag *diaglib.DiagnosticClient) {
 func writeSyncStages(w http.ResponseWriter, diag *diaglib.DiagnosticClient) {
 	diag.SyncStagesJson(w)
 }
+
+func writeGasUsage(w http.ResponseWriter, diag *diaglib.DiagnosticClient) {
+	diag.GasUsageJson(w)
+}
+
+func writeSilkwormAudit(w http.ResponseWriter, diag *diaglib.DiagnosticClient) {
+	diag.SilkwormAuditJson(w)
+}
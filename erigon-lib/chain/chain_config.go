@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"math/big"
 	"strconv"
+	"strings"
 
 	"github.com/erigontech/erigon-lib/common"
 	"github.com/erigontech/erigon-lib/common/fixedgas"
@@ -111,6 +112,44 @@ type OptimismConfig struct {
 	EIP1559Elasticity        uint64 `json:"eip1559Elasticity"`
 	EIP1559Denominator       uint64 `json:"eip1559Denominator"`
 	EIP1559DenominatorCanyon uint64 `json:"eip1559DenominatorCanyon"`
+
+	BobaFeeToken *BobaFeeTokenConfig `json:"bobaFeeToken,omitempty"` // Experimental, for devnets only
+	BobaLegacy   *BobaLegacyConfig   `json:"bobaLegacy,omitempty"`   // Pre-bedrock history of Boba
+
+	Precompiles []PrecompileConfig `json:"precompiles,omitempty"` // L2-specific precompiles
+}
+
+// PrecompileConfig - activation of an L2-specific precompile: starting at Time, Address runs the precompile
+// registered in core/vm under Name. A later activation at the same address replaces the earlier one, so a
+// precompile can be upgraded at a fork time too.
+type PrecompileConfig struct {
+	Name    string         `json:"name"`
+	Address common.Address `json:"address"`
+	Time    *big.Int       `json:"time"` // nil = never, 0 = from genesis
+}
+
+// BobaFeeTokenConfig - alternative fee token mode. Starting at Time, the base fee and the L1 fee of non-deposit
+// transactions are paid in an ERC-20 token instead of ETH (the tip is still paid in ETH). The fee token system
+// contract is called by the system address:
+//   - collectFee(address payer, uint256 amount) before execution, for the gas limit at the base fee plus the
+//     L1 fee; it must revert if the payer can't afford it, which makes the transaction invalid
+//   - refundFee(address payer, uint256 amount) after execution, for the gas left at the base fee
+//
+// The calls are part of the transaction: it pays their gas, and its tracer sees them.
+// The collected fees stay with the contract, the fee vaults don't receive them.
+type BobaFeeTokenConfig struct {
+	Contract common.Address `json:"contract"`
+	Time     *big.Int       `json:"time"` // nil = never, 0 = from genesis
+}
+
+// BobaLegacyConfig - re-execution of the pre-bedrock blocks of Boba, produced by the legacy l2geth. Set for the Boba
+// networks, see Config.IsBoba
+type BobaLegacyConfig struct {
+	// TuringHelpers - TuringHelper contracts, their Turing calls are answered by the responses l2geth stored in the
+	// transactions (see types.DecodeBobaTuringInput), calls of other contracts are never intercepted. Empty: every
+	// dapp deployed its own TuringHelper, so, as by the l2geth verifier, the Turing request is answered at whichever
+	// contract gets it (only a transaction with a stored response has one)
+	TuringHelpers []common.Address `json:"turingHelpers,omitempty"`
 }
 
 // String implements the stringer interface, returning the optimism fee config details.
@@ -337,11 +376,69 @@ func (c *Config) IsOptimismGranite(time uint64) bool {
 	return c.IsOptimism() && c.IsGranite(time)
 }
 
+// BobaFeeTokenContract returns the fee token system contract if the Boba fee token mode is active, nil otherwise
+func (c *Config) BobaFeeTokenContract(time uint64) *common.Address {
+	if !c.IsOptimism() || c.Optimism.BobaFeeToken == nil || !isForked(c.Optimism.BobaFeeToken.Time, time) {
+		return nil
+	}
+	return &c.Optimism.BobaFeeToken.Contract
+}
+
+// activePrecompiles returns names of the L2-specific precompiles active at time by their addresses, nil if none are
+func (c *Config) activePrecompiles(time uint64) map[common.Address]string {
+	if !c.IsOptimism() || len(c.Optimism.Precompiles) == 0 {
+		return nil
+	}
+	var active map[common.Address]string
+	activatedAt := map[common.Address]uint64{}
+	for _, p := range c.Optimism.Precompiles {
+		if !isForked(p.Time, time) {
+			continue
+		}
+		if at, ok := activatedAt[p.Address]; ok && at > p.Time.Uint64() {
+			continue
+		}
+		if active == nil {
+			active = map[common.Address]string{}
+		}
+		active[p.Address] = p.Name
+		activatedAt[p.Address] = p.Time.Uint64()
+	}
+	return active
+}
+
 // IsOptimismPreBedrock returns true iff this is an optimism node & bedrock is not yet active
 func (c *Config) IsOptimismPreBedrock(num uint64) bool {
 	return c.IsOptimism() && !c.IsBedrock(num)
 }
 
+// IsBoba returns true iff this is a Boba network node: its config has the pre-bedrock history of Boba
+func (c *Config) IsBoba() bool {
+	return c.Optimism != nil && c.Optimism.BobaLegacy != nil
+}
+
+// IsBobaTuringHelper returns true iff addr is a TuringHelper contract of the pre-bedrock Boba history, any contract
+// if the config doesn't restrict them
+func (c *Config) IsBobaTuringHelper(addr common.Address) bool {
+	if c == nil || c.Optimism == nil || c.Optimism.BobaLegacy == nil {
+		return false
+	}
+	if len(c.Optimism.BobaLegacy.TuringHelpers) == 0 {
+		return true
+	}
+	for _, helper := range c.Optimism.BobaLegacy.TuringHelpers {
+		if helper == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// IsBobaLegacyBlock returns true iff this is a Boba network node & block was produced by the legacy (pre-bedrock) l2geth
+func (c *Config) IsBobaLegacyBlock(num uint64) bool {
+	return c.IsBoba() && c.BedrockBlock != nil && !c.IsBedrock(num)
+}
+
 func (c *Config) GetBurntContract(num uint64) *common.Address {
 	if len(c.BurntContract) == 0 {
 		return nil
@@ -552,6 +649,9 @@ type ConfigCompatError struct {
 	StoredConfig, NewConfig *big.Int
 	// the block number to which the local chain must be rewound to correct the error
 	RewindTo uint64
+	// all the fields of the stored and new configs which differ (fork times, 1559 params, burntContract, ...),
+	// set by WithDiff, not by CheckCompatible
+	Diff []ConfigChange
 }
 
 func newCompatError(what string, storedblock, newblock *big.Int) *ConfigCompatError {
@@ -564,15 +664,31 @@ func newCompatError(what string, storedblock, newblock *big.Int) *ConfigCompatEr
 	default:
 		rew = newblock
 	}
-	err := &ConfigCompatError{what, storedblock, newblock, 0}
+	err := &ConfigCompatError{What: what, StoredConfig: storedblock, NewConfig: newblock}
 	if rew != nil && rew.Sign() > 0 {
 		err.RewindTo = rew.Uint64() - 1
 	}
 	return err
 }
 
+// WithDiff sets Diff to the fields of the stored and new configs which differ
+func (err *ConfigCompatError) WithDiff(stored, newcfg *Config) *ConfigCompatError {
+	if diff, diffErr := ConfigChanges(stored, newcfg); diffErr == nil {
+		err.Diff = diff
+	}
+	return err
+}
+
 func (err *ConfigCompatError) Error() string {
-	return fmt.Sprintf("mismatching %s in database (have %d, want %d, rewindto %d)", err.What, err.StoredConfig, err.NewConfig, err.RewindTo)
+	msg := fmt.Sprintf("mismatching %s in database (have %d, want %d, rewindto %d)", err.What, err.StoredConfig, err.NewConfig, err.RewindTo)
+	if len(err.Diff) == 0 {
+		return msg
+	}
+	diff := make([]string, len(err.Diff))
+	for i, change := range err.Diff {
+		diff[i] = change.String()
+	}
+	return msg + ", config diff: " + strings.Join(diff, ", ")
 }
 
 // EthashConfig is the consensus engine configs for proof-of-work based sealing.
@@ -631,6 +747,8 @@ type Rules struct {
 	IsOptimismBedrock, IsOptimismRegolith             bool
 	IsOptimismCanyon, IsOptimismFjord                 bool
 	IsOptimismGranite                                 bool
+
+	Precompiles map[common.Address]string // L2-specific precompiles: registry names by address
 }
 
 // Rules ensures c's ChainID is not nil and returns a new Rules instance
@@ -662,6 +780,7 @@ func (c *Config) Rules(num uint64, time uint64) *Rules {
 		IsOptimismCanyon:   c.IsOptimismCanyon(time),
 		IsOptimismFjord:    c.IsOptimismFjord(time),
 		IsOptimismGranite:  c.IsOptimismGranite(time),
+		Precompiles:        c.activePrecompiles(time),
 	}
 }
 
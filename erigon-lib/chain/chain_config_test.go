@@ -17,6 +17,7 @@
 package chain
 
 import (
+	"math/big"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -66,3 +67,60 @@ func TestBorKeyValueConfigHelper(t *testing.T) {
 	assert.Equal(t, borKeyValueConfigHelper(burntContract, 41874000), address2)
 	assert.Equal(t, borKeyValueConfigHelper(burntContract, 41874000+1), address2)
 }
+
+func TestIsBobaLegacyBlock(t *testing.T) {
+	boba := &Config{ChainID: big.NewInt(288), BedrockBlock: big.NewInt(1_149_019), Optimism: &OptimismConfig{BobaLegacy: &BobaLegacyConfig{}}}
+	assert.True(t, boba.IsBoba())
+	assert.True(t, boba.IsBobaLegacyBlock(1_149_018))
+	assert.False(t, boba.IsBobaLegacyBlock(1_149_019))
+
+	op := &Config{ChainID: big.NewInt(10), BedrockBlock: big.NewInt(105_235_063), Optimism: &OptimismConfig{}}
+	assert.False(t, op.IsBoba())
+	assert.False(t, op.IsBobaLegacyBlock(1))
+
+	l1 := &Config{ChainID: big.NewInt(288), BedrockBlock: big.NewInt(1_149_019)}
+	assert.False(t, l1.IsBoba())
+	assert.False(t, l1.IsBobaLegacyBlock(1))
+}
+
+func TestBobaFeeTokenContract(t *testing.T) {
+	contract := common.HexToAddress("0x4200000000000000000000000000000000000F00")
+	c := &Config{ChainID: big.NewInt(901), Optimism: &OptimismConfig{BobaFeeToken: &BobaFeeTokenConfig{Contract: contract, Time: big.NewInt(100)}}}
+	assert.Nil(t, c.BobaFeeTokenContract(99))
+	assert.Equal(t, &contract, c.BobaFeeTokenContract(100))
+
+	c.Optimism.BobaFeeToken.Time = nil
+	assert.Nil(t, c.BobaFeeTokenContract(100))
+	assert.Nil(t, (&Config{ChainID: big.NewInt(901), Optimism: &OptimismConfig{}}).BobaFeeTokenContract(100))
+	assert.Nil(t, (&Config{ChainID: big.NewInt(1)}).BobaFeeTokenContract(100))
+}
+
+func TestConfigCompatErrorDiff(t *testing.T) {
+	stored := &Config{
+		ChainID:        big.NewInt(288),
+		HomesteadBlock: big.NewInt(0),
+		GraniteTime:    big.NewInt(1_000),
+		BurntContract:  map[string]common.Address{"0": {1}},
+		Optimism:       &OptimismConfig{EIP1559Elasticity: 6, EIP1559Denominator: 50},
+	}
+	updated := &Config{
+		ChainID:        big.NewInt(288),
+		HomesteadBlock: big.NewInt(1),
+		GraniteTime:    big.NewInt(2_000),
+		HoloceneTime:   big.NewInt(3_000),
+		BurntContract:  map[string]common.Address{"0": {1}},
+		Optimism:       &OptimismConfig{EIP1559Elasticity: 6, EIP1559Denominator: 250},
+	}
+	err := stored.CheckCompatible(updated, 10)
+	assert.NotNil(t, err)
+	assert.Nil(t, err.Diff)
+	err = err.WithDiff(stored, updated)
+	assert.Equal(t, []ConfigChange{
+		{Field: "graniteTime", Old: float64(1_000), New: float64(2_000)},
+		{Field: "holoceneTime", New: float64(3_000)},
+		{Field: "homesteadBlock", Old: float64(0), New: float64(1)},
+		{Field: "optimism.eip1559Denominator", Old: float64(50), New: float64(250)},
+	}, err.Diff)
+	assert.Equal(t, "mismatching Homestead fork block in database (have 0, want 1, rewindto 0), config diff: "+
+		"graniteTime: 1000 -> 2000, holoceneTime: <nil> -> 3000, homesteadBlock: 0 -> 1, optimism.eip1559Denominator: 50 -> 250", err.Error())
+}
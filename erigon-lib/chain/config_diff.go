@@ -0,0 +1,97 @@
+package chain
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// ConfigChange - a field of the chain config which differs, named as in the json of the config
+// (e.g. "graniteTime", "optimism.eip1559Denominator"). Old or New is nil if the field is not set.
+type ConfigChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+func (c ConfigChange) String() string {
+	format := func(v interface{}) string {
+		if v == nil {
+			return "<nil>"
+		}
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+	return fmt.Sprintf("%s: %s -> %s", c.Field, format(c.Old), format(c.New))
+}
+
+// ConfigChanges - fields of the configs which differ, sorted by name
+func ConfigChanges(stored, updated *Config) ([]ConfigChange, error) {
+	storedFields, err := configFields(stored)
+	if err != nil {
+		return nil, err
+	}
+	updatedFields, err := configFields(updated)
+	if err != nil {
+		return nil, err
+	}
+	var changes []ConfigChange
+	for field, v := range storedFields {
+		if v2, ok := updatedFields[field]; !ok || !bytes.Equal(v, v2) {
+			changes = append(changes, ConfigChange{Field: field, Old: decodeConfigField(v), New: decodeConfigField(v2)})
+		}
+	}
+	for field, v := range updatedFields {
+		if _, ok := storedFields[field]; !ok {
+			changes = append(changes, ConfigChange{Field: field, New: decodeConfigField(v)})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes, nil
+}
+
+// configFields - the leaves of the json of the config: dotted path -> json of the value
+func configFields(c *Config) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]json.RawMessage{}
+	var walk func(prefix string, v json.RawMessage) error
+	walk = func(prefix string, v json.RawMessage) error {
+		var object map[string]json.RawMessage
+		if len(v) == 0 || v[0] != '{' {
+			fields[prefix] = v
+			return nil
+		}
+		if err := json.Unmarshal(v, &object); err != nil {
+			return err
+		}
+		for k, v := range object {
+			if bytes.Equal(v, []byte("null")) {
+				continue
+			}
+			field := k
+			if prefix != "" {
+				field = prefix + "." + k
+			}
+			if err := walk(field, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fields, walk("", data)
+}
+
+func decodeConfigField(v json.RawMessage) interface{} {
+	if v == nil {
+		return nil
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(v, &decoded); err != nil {
+		return string(v)
+	}
+	return decoded
+}
@@ -43,6 +43,18 @@ func EnvInt(envVarName string, defaultVal int) int {
 	}
 	return defaultVal
 }
+func EnvFloat(envVarName string, defaultVal float64) float64 {
+	v, _ := os.LookupEnv(envVarName)
+	if v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Printf("[dbg] env %s=%g\n", envVarName, f)
+		return f
+	}
+	return defaultVal
+}
 func EnvDataSize(envVarName string, defaultVal datasize.ByteSize) datasize.ByteSize {
 	v, _ := os.LookupEnv(envVarName)
 	if v != "" {
@@ -40,6 +40,20 @@ var (
 
 var StagesOnlyBlocks = EnvBool("STAGES_ONLY_BLOCKS", false)
 
+// ExecProfileMgas - capture a CPU profile of the next log interval of the execution stage when it runs slower
+// than this (Mgas/s). Profiles are written to <datadir>/pprof. 0 - disabled
+var ExecProfileMgas = EnvFloat("EXEC_PROFILE_MGAS", 0)
+
+// AssertTxNums - check invariants of kv.MaxTxNum on appends and after unwinds
+var AssertTxNums = EnvBool("ASSERT_TXNUMS", false)
+
+// VerifyReceipts - rpcdaemon compares receipts read from the db with the values derived from the block (L1 fee
+// fields, gas used) and logs mismatches, to find data written by buggy versions
+var VerifyReceipts = EnvBool("VERIFY_RECEIPTS", false)
+
+// GasMetering - the execution stage sends per-block gas usage by opcode class and top contracts to diagnostics
+var GasMetering = EnvBool("GAS_METERING", false)
+
 var doMemstat = true
 
 func init() {
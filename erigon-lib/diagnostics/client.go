@@ -42,6 +42,8 @@ type DiagnosticClient struct {
 	syncStages          []SyncStage
 	syncStats           SyncStatistics
 	BlockExecution      BlockEexcStatsData
+	gasUsage            GasUsageData
+	silkwormAudit       SilkwormAuditData
 	snapshotFileList    SnapshoFilesList
 	mu                  sync.Mutex
 	headerMutex         sync.Mutex
@@ -115,6 +117,8 @@ func (d *DiagnosticClient) Setup() {
 	d.setupSysInfoDiagnostics()
 	d.setupNetworkDiagnostics(rootCtx)
 	d.setupBlockExecutionDiagnostics(rootCtx)
+	d.setupGasUsageDiagnostics(rootCtx)
+	d.setupSilkwormAuditDiagnostics(rootCtx)
 	d.setupHeadersDiagnostics(rootCtx)
 	d.setupBodiesDiagnostics(rootCtx)
 	d.setupResourcesUsageDiagnostics(rootCtx)
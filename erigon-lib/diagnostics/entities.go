@@ -313,6 +313,14 @@ func (ti BlockExecutionStatistics) Type() Type {
 	return TypeOf(ti)
 }
 
+func (ti BlockGasUsage) Type() Type {
+	return TypeOf(ti)
+}
+
+func (ti SilkwormMismatch) Type() Type {
+	return TypeOf(ti)
+}
+
 func (ti SnapshotDownloadStatistics) Type() Type {
 	return TypeOf(ti)
 }
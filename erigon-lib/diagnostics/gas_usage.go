@@ -0,0 +1,103 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package diagnostics
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/erigontech/erigon-lib/log/v3"
+)
+
+// gasUsageBlocksLimit - number of the most recent blocks kept
+const gasUsageBlocksLimit = 256
+
+// BlockGasUsage - gas charged by the EVM in a block, split by the kind of work. The classes are charged before
+// refunds, so they add up to GasUsed + Refund.
+type BlockGasUsage struct {
+	BlockNumber  uint64             `json:"blockNumber"`
+	GasUsed      uint64             `json:"gasUsed"`
+	Refund       uint64             `json:"refund"`
+	Intrinsic    uint64             `json:"intrinsic"`    // per-tx base cost, calldata and access lists
+	Compute      uint64             `json:"compute"`      // arithmetic, stack, hashing, logs etc.
+	Memory       uint64             `json:"memory"`       // memory loads, stores and copies
+	StorageRead  uint64             `json:"storageRead"`  // SLOAD and reads of other accounts
+	StorageWrite uint64             `json:"storageWrite"` // SSTORE and SELFDESTRUCT
+	Precompile   uint64             `json:"precompile"`
+	Call         uint64             `json:"call"`         // calls and creates: overhead, code deposit, gas burnt by failed frames
+	TopContracts []ContractGasUsage `json:"topContracts"` // contracts which code used the most gas, in descending order
+}
+
+type ContractGasUsage struct {
+	Address string `json:"address"`
+	Gas     uint64 `json:"gas"`
+}
+
+type GasUsageData struct {
+	blocks []BlockGasUsage
+	mu     sync.Mutex
+}
+
+func (g *GasUsageData) add(u BlockGasUsage) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	// re-executed after an unwind
+	for len(g.blocks) > 0 && g.blocks[len(g.blocks)-1].BlockNumber >= u.BlockNumber {
+		g.blocks = g.blocks[:len(g.blocks)-1]
+	}
+	if len(g.blocks) == gasUsageBlocksLimit {
+		g.blocks = append(g.blocks[:0], g.blocks[1:]...)
+	}
+	g.blocks = append(g.blocks, u)
+}
+
+func (g *GasUsageData) Data() []BlockGasUsage {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	d := make([]BlockGasUsage, len(g.blocks))
+	copy(d, g.blocks)
+	return d
+}
+
+func (d *DiagnosticClient) setupGasUsageDiagnostics(rootCtx context.Context) {
+	d.runGasUsageListener(rootCtx)
+}
+
+func (d *DiagnosticClient) runGasUsageListener(rootCtx context.Context) {
+	go func() {
+		ctx, ch, closeChannel := Context[BlockGasUsage](rootCtx, 1)
+		defer closeChannel()
+
+		StartProviders(ctx, TypeOf(BlockGasUsage{}), log.Root())
+		for {
+			select {
+			case <-rootCtx.Done():
+				return
+			case info := <-ch:
+				d.gasUsage.add(info)
+			}
+		}
+	}()
+}
+
+func (d *DiagnosticClient) GasUsageJson(w io.Writer) {
+	if err := json.NewEncoder(w).Encode(d.gasUsage.Data()); err != nil {
+		log.Debug("[diagnostics] GasUsageJson", "err", err)
+	}
+}
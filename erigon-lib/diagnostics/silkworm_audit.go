@@ -0,0 +1,85 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package diagnostics
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/erigontech/erigon-lib/log/v3"
+)
+
+// silkwormMismatchesLimit - number of the most recent mismatches kept
+const silkwormMismatchesLimit = 64
+
+// SilkwormMismatch - a block audited by --silkworm.audit whose state changes or receipts by Silkworm differ from the
+// ones by the Go EVM
+type SilkwormMismatch struct {
+	BlockNumber uint64   `json:"blockNumber"`
+	Diffs       []string `json:"diffs"`
+}
+
+type SilkwormAuditData struct {
+	mismatches []SilkwormMismatch
+	mu         sync.Mutex
+}
+
+func (s *SilkwormAuditData) add(m SilkwormMismatch) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.mismatches) == silkwormMismatchesLimit {
+		s.mismatches = append(s.mismatches[:0], s.mismatches[1:]...)
+	}
+	s.mismatches = append(s.mismatches, m)
+}
+
+func (s *SilkwormAuditData) Data() []SilkwormMismatch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := make([]SilkwormMismatch, len(s.mismatches))
+	copy(d, s.mismatches)
+	return d
+}
+
+func (d *DiagnosticClient) setupSilkwormAuditDiagnostics(rootCtx context.Context) {
+	d.runSilkwormAuditListener(rootCtx)
+}
+
+func (d *DiagnosticClient) runSilkwormAuditListener(rootCtx context.Context) {
+	go func() {
+		ctx, ch, closeChannel := Context[SilkwormMismatch](rootCtx, 1)
+		defer closeChannel()
+
+		StartProviders(ctx, TypeOf(SilkwormMismatch{}), log.Root())
+		for {
+			select {
+			case <-rootCtx.Done():
+				return
+			case info := <-ch:
+				d.silkwormAudit.add(info)
+			}
+		}
+	}()
+}
+
+func (d *DiagnosticClient) SilkwormAuditJson(w io.Writer) {
+	if err := json.NewEncoder(w).Encode(d.silkwormAudit.Data()); err != nil {
+		log.Debug("[diagnostics] SilkwormAuditJson", "err", err)
+	}
+}
@@ -500,6 +500,19 @@ func (c *RCloneSession) Upload(ctx context.Context, files ...string) error {
 	return <-cerr
 }
 
+// Delete removes files from the remote
+func (c *RCloneSession) Delete(ctx context.Context, files ...string) error {
+	for _, file := range files {
+		if _, err := c.cmd(ctx, "operations/deletefile", map[string]string{"fs": c.remoteFs, "remote": file}); err != nil {
+			return fmt.Errorf("can't delete: %s: %w", file, err)
+		}
+		c.Lock()
+		delete(c.files, file)
+		c.Unlock()
+	}
+	return nil
+}
+
 func (c *RCloneSession) Download(ctx context.Context, files ...string) error {
 
 	reqInfo := map[string]*rcloneInfo{}
@@ -147,14 +147,13 @@ func (i Index) HasFile(info FileInfo, logger log.Logger) bool {
 	dir := info.Dir()
 	fName := IdxFileName(info.Version, info.From, info.To, i.Name)
 
-	segment, err := seg.NewDecompressor(info.Path)
+	// the segment isn't opened: it may be encrypted
+	segment, err := os.Stat(info.Path)
 
 	if err != nil {
 		return false
 	}
 
-	defer segment.Close()
-
 	idx, err := recsplit.OpenIndex(filepath.Join(dir, fName))
 
 	if err != nil {
@@ -396,7 +395,7 @@ func BuildIndex(ctx context.Context, info FileInfo, salt uint32, firstDataId uin
 		}
 	}()
 
-	d, err := seg.NewDecompressor(info.Path)
+	d, err := seg.NewDecompressorWithKeyProvider(info.Path, seg.KeyProviderFromContext(ctx))
 
 	if err != nil {
 		return fmt.Errorf("can't open %s for indexing: %w", info.Name(), err)
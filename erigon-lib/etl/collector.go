@@ -24,6 +24,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sync/atomic"
 	"time"
 
 	"github.com/c2h5oh/datasize"
@@ -55,6 +56,10 @@ type Collector struct {
 	//   - if disk is over-loaded - app may have much background threads which waiting for flush - and each thread whill hold own `buf` (can't free RAM until flush is done)
 	//   - enable it only when writing to `etl` is a bottleneck and unlikely to have many parallel collectors (to not overload CPU/Disk)
 	sortAndFlushInBackground bool
+
+	flushSlots chan struct{} // set by MemoryLimit, a slot is taken by each background flush
+	metrics    *collectorMetrics
+	spillFiles atomic.Int32
 }
 
 // NewCollectorFromFiles creates collector from existing files (left over from previous unsuccessful loading)
@@ -127,11 +132,15 @@ func (c *Collector) flushBuffer(canStoreInRam bool) error {
 		var err error
 
 		if c.sortAndFlushInBackground {
+			c.acquireFlushSlot()
 			fullBuf := c.buf // can't `.Reset()` because this `buf` will move to another goroutine
 			prevLen, prevSize := fullBuf.Len(), fullBuf.SizeLimit()
 			c.buf = getBufferByType(c.bufType, datasize.ByteSize(c.buf.SizeLimit()), c.buf)
 
-			provider, err = FlushToDiskAsync(c.logPrefix, fullBuf, c.tmpdir, doFsync, c.logLvl)
+			if c.metrics != nil {
+				c.metrics.flushesInFlight.Inc()
+			}
+			provider, err = flushToDiskAsync(c.logPrefix, fullBuf, c.tmpdir, doFsync, c.logLvl, c.flushDone)
 			if err != nil {
 				return err
 			}
@@ -141,6 +150,7 @@ func (c *Collector) flushBuffer(canStoreInRam bool) error {
 			if err != nil {
 				return err
 			}
+			c.spilled(fileSize(provider.(*fileDataProvider).file))
 			c.buf.Reset()
 		}
 	}
@@ -269,6 +279,9 @@ func (c *Collector) reset() {
 		}
 		c.dataProviders = nil
 	}
+	if c.metrics != nil {
+		c.metrics.spillFiles.Sub(float64(c.spillFiles.Swap(0)))
+	}
 	c.buf.Reset()
 	c.allFlushed = false
 }
@@ -0,0 +1,80 @@
+package etl
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/c2h5oh/datasize"
+
+	"github.com/erigontech/erigon-lib/metrics"
+)
+
+// collectorMetrics - gauges of a named collector, collectors sharing a name add up
+type collectorMetrics struct {
+	flushesInFlight metrics.Gauge   // buffers being sorted and flushed in background, held in RAM
+	spillFiles      metrics.Gauge   // files on disk, removed after Load
+	spilledBytes    metrics.Counter // written to files
+	blockedSeconds  metrics.Counter // time Collect waited for a background flush (backpressure)
+}
+
+func newCollectorMetrics(name string) *collectorMetrics {
+	return &collectorMetrics{
+		flushesInFlight: metrics.GetOrCreateGauge(fmt.Sprintf(`etl_collector_flushes_in_flight{collector="%s"}`, name)),
+		spillFiles:      metrics.GetOrCreateGauge(fmt.Sprintf(`etl_collector_spill_files{collector="%s"}`, name)),
+		spilledBytes:    metrics.GetOrCreateCounter(fmt.Sprintf(`etl_collector_spilled_bytes_total{collector="%s"}`, name)),
+		blockedSeconds:  metrics.GetOrCreateCounter(fmt.Sprintf(`etl_collector_backpressure_seconds_total{collector="%s"}`, name)),
+	}
+}
+
+// Metrics - exports Prometheus metrics of the collector labeled with name, which must be a constant (not a block
+// number etc.) to keep the number of series small
+func (c *Collector) Metrics(name string) { c.metrics = newCollectorMetrics(name) }
+
+// MemoryLimit - streaming mode with bounded RAM: buffers are sorted and flushed to disk in background, but the buffer
+// being filled and the ones being flushed don't take more than limit (at least two buffers). When the limit is
+// reached Collect blocks until a flush is done, so a producer faster than the disk is slowed down instead of
+// growing RAM use.
+func (c *Collector) MemoryLimit(limit datasize.ByteSize) {
+	buffers := int(limit.Bytes()) / c.buf.SizeLimit()
+	if buffers < 2 {
+		buffers = 2
+	}
+	c.sortAndFlushInBackground = true
+	c.flushSlots = make(chan struct{}, buffers-1)
+}
+
+// acquireFlushSlot - blocks while MemoryLimit buffers are held
+func (c *Collector) acquireFlushSlot() {
+	if c.flushSlots == nil {
+		return
+	}
+	select {
+	case c.flushSlots <- struct{}{}:
+	default:
+		start := time.Now()
+		c.flushSlots <- struct{}{}
+		if c.metrics != nil {
+			c.metrics.blockedSeconds.Add(time.Since(start).Seconds())
+		}
+	}
+}
+
+// flushDone - called by the background flush when the buffer was written, it can be collected by GC from now on
+func (c *Collector) flushDone(size int64) {
+	if c.flushSlots != nil {
+		<-c.flushSlots
+	}
+	if c.metrics != nil {
+		c.metrics.flushesInFlight.Dec()
+		c.spilled(size)
+	}
+}
+
+func (c *Collector) spilled(size int64) {
+	if c.metrics == nil || size == 0 {
+		return
+	}
+	c.spillFiles.Add(1)
+	c.metrics.spillFiles.Inc()
+	c.metrics.spilledBytes.Add(float64(size))
+}
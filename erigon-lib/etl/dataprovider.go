@@ -43,16 +43,26 @@ type fileDataProvider struct {
 
 // FlushToDiskAsync - `doFsync` is true only for 'critical' collectors (which should not loose).
 func FlushToDiskAsync(logPrefix string, b Buffer, tmpdir string, doFsync bool, lvl log.Lvl) (dataProvider, error) {
+	return flushToDiskAsync(logPrefix, b, tmpdir, doFsync, lvl, nil)
+}
+
+// flushToDiskAsync - done (if set) is called when the flush is over, with the file size (0 on error)
+func flushToDiskAsync(logPrefix string, b Buffer, tmpdir string, doFsync bool, lvl log.Lvl, done func(size int64)) (dataProvider, error) {
 	if b.Len() == 0 {
 		return nil, nil
 	}
 
 	provider := &fileDataProvider{reader: nil, wg: &errgroup.Group{}}
 	provider.wg.Go(func() (err error) {
+		var size int64
+		if done != nil {
+			defer func() { done(size) }()
+		}
 		provider.file, err = sortAndFlush(b, tmpdir, doFsync)
 		if err != nil {
 			return err
 		}
+		size = fileSize(provider.file)
 		_, fName := filepath.Split(provider.file.Name())
 		log.Log(lvl, fmt.Sprintf("[%s] Flushed buffer file", logPrefix), "name", fName)
 		return nil
@@ -107,6 +117,17 @@ func sortAndFlush(b Buffer, tmpdir string, doFsync bool) (*os.File, error) {
 	return bufferFile, nil
 }
 
+func fileSize(f *os.File) int64 {
+	if f == nil {
+		return 0
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
 func (p *fileDataProvider) Next(keyBuf, valBuf []byte) ([]byte, []byte, error) {
 	if p.reader == nil {
 		_, err := p.file.Seek(0, 0)
@@ -645,3 +645,28 @@ func TestSortable(t *testing.T) {
 	require.Equal([][]byte{{1}, {2}, {3}, {4}, {5}, {6}, {7}, {1}, {20}, nil}, vals)
 
 }
+
+func TestMemoryLimit(t *testing.T) {
+	collector := NewCollector(t.Name(), t.TempDir(), NewSortableBuffer(64), log.New())
+	defer collector.Close()
+	collector.MemoryLimit(3 * 64)
+	collector.Metrics("test_memory_limit")
+	require := require.New(t)
+	require.Equal(2, cap(collector.flushSlots))
+
+	for i := 1000; i > 0; i-- {
+		require.NoError(collector.Collect([]byte(fmt.Sprintf("%04d", i)), []byte{byte(i)}))
+		require.LessOrEqual(len(collector.flushSlots), 2)
+	}
+	var loaded int
+	require.NoError(collector.Load(nil, "", func(k, v []byte, table CurrentTableReader, next LoadNextFunc) error {
+		loaded++
+		require.Equal(fmt.Sprintf("%04d", loaded), string(k))
+		return nil
+	}, TransformArgs{}))
+	require.Equal(1000, loaded)
+	require.Empty(collector.flushSlots)
+	require.Positive(collector.metrics.spilledBytes.GetValue())
+	require.Zero(collector.metrics.flushesInFlight.GetValue())
+	require.Zero(collector.metrics.spillFiles.GetValue())
+}
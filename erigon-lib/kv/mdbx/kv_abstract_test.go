@@ -18,10 +18,12 @@ package mdbx_test
 
 import (
 	"context"
+	"encoding/binary"
 	"fmt"
 	"net"
 	"runtime"
 	"testing"
+	"time"
 
 	"github.com/erigontech/erigon-lib/log/v3"
 	"github.com/stretchr/testify/assert"
@@ -203,6 +205,50 @@ func TestRemoteKvVersion(t *testing.T) {
 	require.True(t, a.EnsureVersionCompatibility())
 }
 
+func TestRemoteKvStateVersion(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fix me on win please")
+	}
+	logger := log.New()
+	ctx, writeDB := context.Background(), memdb.NewTestDB(t)
+	grpcServer, conn := grpc.NewServer(), bufconn.Listen(1024*1024)
+	go func() {
+		remote.RegisterKVServer(grpcServer, remotedbserver.NewKvServer(ctx, writeDB, nil, nil, nil, logger))
+		if err := grpcServer.Serve(conn); err != nil {
+			log.Error("private RPC server fail", "err", err)
+		}
+	}()
+	cc, err := grpc.Dial("", grpc.WithInsecure(), grpc.WithContextDialer(func(ctx context.Context, url string) (net.Conn, error) { return conn.Dial() }))
+	require.NoError(t, err)
+	db, err := remotedb.NewRemote(gointerfaces.VersionFromProto(remotedbserver.KvServiceAPIVersion), logger, remote.NewKVClient(cc)).Open()
+	require.NoError(t, err)
+
+	stateVersion := func() uint64 {
+		var version uint64
+		require.NoError(t, db.View(ctx, func(tx kv.Tx) error {
+			v, err := tx.GetOne(kv.Sequence, kv.PlainStateVersion)
+			if len(v) == 8 {
+				version = binary.BigEndian.Uint64(v)
+			}
+			return err
+		}))
+		return version
+	}
+	require.Zero(t, stateVersion())
+
+	// the client was notified about a block import which the server commits a bit later
+	db.EnsureStateVersion(2)
+	db.EnsureStateVersion(1) // never goes back
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		_ = writeDB.Update(ctx, func(tx kv.RwTx) error {
+			_, err := tx.IncrementSequence(string(kv.PlainStateVersion), 2)
+			return err
+		})
+	}()
+	require.Equal(t, uint64(2), stateVersion())
+}
+
 func TestRemoteKvRange(t *testing.T) {
 	if runtime.GOOS == "windows" {
 		t.Skip("fix me on win please")
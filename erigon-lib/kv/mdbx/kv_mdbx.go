@@ -55,6 +55,21 @@ func WithChaindataTables(defaultBuckets kv.TableCfg) kv.TableCfg {
 	return defaultBuckets
 }
 
+// WithoutBorTables - chaindata of the chains without bor (e.g. OP chains): kv.BorTables aren't created, like
+// deprecated tables they are opened only if the db already has them
+func WithoutBorTables(defaultBuckets kv.TableCfg) kv.TableCfg {
+	buckets := make(kv.TableCfg, len(defaultBuckets))
+	for name, cfg := range defaultBuckets {
+		buckets[name] = cfg
+	}
+	for _, name := range kv.BorTables {
+		cfg := buckets[name]
+		cfg.IsDeprecated = true
+		buckets[name] = cfg
+	}
+	return buckets
+}
+
 type MdbxOpts struct {
 	// must be in the range from 12.5% (almost empty) to 50% (half empty)
 	// which corresponds to the range from 8192 and to 32768 in units respectively
@@ -641,6 +656,19 @@ func (db *MdbxKV) PageSize() uint64 { return db.opts.pageSize }
 func (db *MdbxKV) ReadOnly() bool   { return db.opts.HasFlag(mdbx.Readonly) }
 func (db *MdbxKV) Accede() bool     { return db.opts.HasFlag(mdbx.Accede) }
 
+// Copy writes consistent copy of the database to dir/mdbx.dat (mdbx_env_copy). Copy is taken by read
+// transaction, so writers aren't blocked. compact - omit free pages from the copy.
+func (db *MdbxKV) Copy(dir string, compact bool) error {
+	if err := os.MkdirAll(dir, 0744); err != nil {
+		return err
+	}
+	var flags uint
+	if compact {
+		flags = mdbx.CopyCompact
+	}
+	return db.env.Copy(filepath.Join(dir, "mdbx.dat"), flags)
+}
+
 func (db *MdbxKV) CHandle() unsafe.Pointer {
 	return db.env.CHandle()
 }
@@ -818,6 +818,39 @@ func TestBeginRwWithDoneContext(t *testing.T) {
 	require.ErrorIs(t, err, context.Canceled)
 }
 
+func TestWithoutBorTables(t *testing.T) {
+	logger, ctx := log.New(), context.Background()
+	existsBucket := func(db kv.RwDB, name string) (exists bool) {
+		require.NoError(t, db.View(ctx, func(tx kv.Tx) (err error) {
+			exists, err = tx.(kv.BucketMigrator).ExistsBucket(name)
+			return err
+		}))
+		return exists
+	}
+
+	dir := t.TempDir()
+	db := NewMDBX(logger).Path(dir).WithTableCfg(WithoutBorTables).MustOpen()
+	require.False(t, existsBucket(db, kv.BorReceipts))
+	require.True(t, existsBucket(db, kv.Receipts))
+	db.Close()
+
+	// chaindata made with the bor tables
+	dir = t.TempDir()
+	db = NewMDBX(logger).Path(dir).MustOpen()
+	require.NoError(t, db.Update(ctx, func(tx kv.RwTx) error {
+		return tx.Put(kv.BorReceipts, []byte{1}, []byte{2})
+	}))
+	db.Close()
+	db = NewMDBX(logger).Path(dir).WithTableCfg(WithoutBorTables).MustOpen()
+	defer db.Close()
+	require.True(t, existsBucket(db, kv.BorReceipts))
+	require.NoError(t, db.View(ctx, func(tx kv.Tx) error {
+		v, err := tx.GetOne(kv.BorReceipts, []byte{1})
+		require.Equal(t, []byte{2}, v)
+		return err
+	}))
+}
+
 func testCloseWaitsAfterTxBegin(
 	t *testing.T,
 	count int,
@@ -19,7 +19,6 @@ import (
 	"unsafe"
 
 	"github.com/c2h5oh/datasize"
-	"github.com/erigontech/erigon-lib/common"
 	"github.com/erigontech/erigon-lib/kv/iter"
 	"github.com/erigontech/erigon-lib/kv/order"
 	"github.com/erigontech/erigon-lib/log/v3"
@@ -554,11 +553,75 @@ func (m *MemoryMutation) Flush(tx kv.RwTx) error {
 	return nil
 }
 
+// TableChanges - what a batch would change in a table on Flush, see MemoryMutation.Changes
+type TableChanges struct {
+	Written int  // entries put, new ones and the ones replacing entries of the db
+	Deleted int  // entries deleted
+	Cleared bool // the table is cleared before the entries are written
+}
+
+// Changes - the tables the batch changed, without copying their entries like Diff does
+func (m *MemoryMutation) Changes() (map[string]TableChanges, error) {
+	changes := map[string]TableChanges{}
+	for bucket := range m.clearedTables {
+		c := changes[bucket]
+		c.Cleared = true
+		changes[bucket] = c
+	}
+	for bucket, keys := range m.deletedEntries {
+		c := changes[bucket]
+		c.Deleted += len(keys)
+		changes[bucket] = c
+	}
+	for bucket, keys := range m.deletedDups {
+		c := changes[bucket]
+		for _, values := range keys {
+			c.Deleted += len(values)
+		}
+		changes[bucket] = c
+	}
+	buckets, err := m.memTx.ListBuckets()
+	if err != nil {
+		return nil, err
+	}
+	for _, bucket := range buckets {
+		written, err := func() (uint64, error) {
+			c, err := m.memTx.Cursor(bucket)
+			if err != nil {
+				return 0, err
+			}
+			defer c.Close()
+			return c.Count()
+		}()
+		if err != nil {
+			return nil, err
+		}
+		if written == 0 {
+			continue
+		}
+		c := changes[bucket]
+		c.Written = int(written)
+		changes[bucket] = c
+	}
+	return changes, nil
+}
+
 func (m *MemoryMutation) Diff() (*MemoryDiff, error) {
+	return m.DiffWithLimit(0, "", nil)
+}
+
+// DiffWithLimit - Diff, which streams the entries to a temporary mdbx in tmpDir once their size passes limit, instead
+// of copying them all into the heap first. No limit if 0. The diff must be closed to remove the database.
+func (m *MemoryMutation) DiffWithLimit(limit uint64, tmpDir string, logger log.Logger) (_ *MemoryDiff, err error) {
 	memDiff := &MemoryDiff{
 		diff:           make(map[table][]entry),
 		deletedEntries: make(map[string][]string),
 	}
+	defer func() {
+		if err != nil {
+			memDiff.Close() // removes the spill
+		}
+	}()
 	// Obtain buckets touched.
 	buckets, err := m.memTx.ListBuckets()
 	if err != nil {
@@ -574,6 +637,15 @@ func (m *MemoryMutation) Diff() (*MemoryDiff, error) {
 			memDiff.deletedEntries[bucket] = append(memDiff.deletedEntries[bucket], key)
 		}
 	}
+	add := func(t table, k, v []byte) error {
+		if err := memDiff.add(t, k, v); err != nil {
+			return err
+		}
+		if limit > 0 && memDiff.spilled == nil && memDiff.size > limit {
+			return memDiff.startSpill(tmpDir, logger)
+		}
+		return nil
+	}
 	// Iterate over each bucket and apply changes accordingly.
 	for _, bucket := range buckets {
 		if isTablePurelyDupsort(bucket) {
@@ -591,10 +663,9 @@ func (m *MemoryMutation) Diff() (*MemoryDiff, error) {
 				if err != nil {
 					return nil, err
 				}
-				memDiff.diff[t] = append(memDiff.diff[t], entry{
-					k: common.Copy(k),
-					v: common.Copy(v),
-				})
+				if err := add(t, k, v); err != nil {
+					return nil, err
+				}
 			}
 		} else {
 			cbucket, err := m.memTx.Cursor(bucket)
@@ -610,13 +681,17 @@ func (m *MemoryMutation) Diff() (*MemoryDiff, error) {
 				if err != nil {
 					return nil, err
 				}
-				memDiff.diff[t] = append(memDiff.diff[t], entry{
-					k: common.Copy(k),
-					v: common.Copy(v),
-				})
+				if err := add(t, k, v); err != nil {
+					return nil, err
+				}
 			}
 		}
 	}
+	if memDiff.spillTx != nil {
+		if err := memDiff.finishSpill(); err != nil {
+			return nil, err
+		}
+	}
 	return memDiff, nil
 }
 
@@ -1,6 +1,15 @@
 package membatchwithdb
 
-import "github.com/erigontech/erigon-lib/kv"
+import (
+	"context"
+
+	"github.com/c2h5oh/datasize"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/mdbx"
+)
 
 type entry struct {
 	k []byte
@@ -11,6 +20,13 @@ type MemoryDiff struct {
 	diff              map[table][]entry // god.
 	deletedEntries    map[string][]string
 	clearedTableNames []string
+	size              uint64 // bytes of the keys and values in diff
+
+	// spilled holds the entries of diff after Spill, the tables of the entries are in spilledTables. spillTx is open
+	// while the entries are written to it.
+	spilled       kv.RwDB
+	spillTx       kv.RwTx
+	spilledTables map[table]struct{}
 }
 
 type table struct {
@@ -18,6 +34,79 @@ type table struct {
 	dupsort bool
 }
 
+// add copies the entry to the diff, or writes it to the spill while it's being spilled
+func (m *MemoryDiff) add(t table, k, v []byte) error {
+	m.size += uint64(len(k) + len(v))
+	if m.spillTx != nil {
+		m.spilledTables[t] = struct{}{}
+		return m.spillTx.Put(t.name, k, v)
+	}
+	m.diff[t] = append(m.diff[t], entry{k: common.Copy(k), v: common.Copy(v)})
+	return nil
+}
+
+// Size - bytes of the keys and values of the diff, including the spilled ones
+func (m *MemoryDiff) Size() uint64 {
+	return m.size
+}
+
+// Spilled - whether the entries of the diff were moved out of the heap by Spill
+func (m *MemoryDiff) Spilled() bool {
+	return m.spilled != nil
+}
+
+// Spill moves the entries of the diff out of the heap to a temporary mdbx in tmpDir, which Flush reads them from.
+// The database is removed by Close.
+func (m *MemoryDiff) Spill(tmpDir string, logger log.Logger) error {
+	if m.spilled != nil {
+		return nil
+	}
+	if err := m.startSpill(tmpDir, logger); err != nil {
+		return err
+	}
+	return m.finishSpill()
+}
+
+// startSpill opens the temporary mdbx and moves the entries of the diff there, the entries added until finishSpill
+// are written there too
+func (m *MemoryDiff) startSpill(tmpDir string, logger log.Logger) error {
+	db := mdbx.NewMDBX(logger).InMem(tmpDir).GrowthStep(64 * datasize.MB).MapSize(512 * datasize.GB).MustOpen()
+	tx, err := db.BeginRw(context.Background())
+	if err != nil {
+		db.Close()
+		return err
+	}
+	m.spilled, m.spillTx, m.spilledTables = db, tx, make(map[table]struct{}, len(m.diff))
+	for t, entries := range m.diff {
+		m.spilledTables[t] = struct{}{}
+		for _, e := range entries {
+			if err := tx.Put(t.name, e.k, e.v); err != nil {
+				return err
+			}
+		}
+		delete(m.diff, t)
+	}
+	return nil
+}
+
+func (m *MemoryDiff) finishSpill() error {
+	tx := m.spillTx
+	m.spillTx = nil
+	return tx.Commit()
+}
+
+// Close removes the temporary database of a spilled diff
+func (m *MemoryDiff) Close() {
+	if m.spillTx != nil {
+		m.spillTx.Rollback()
+		m.spillTx = nil
+	}
+	if m.spilled != nil {
+		m.spilled.Close()
+		m.spilled = nil
+	}
+}
+
 func (m *MemoryDiff) Flush(tx kv.RwTx) error {
 	// Obliterate buckets who are to be deleted
 	for _, bucket := range m.clearedTableNames {
@@ -33,6 +122,11 @@ func (m *MemoryDiff) Flush(tx kv.RwTx) error {
 			}
 		}
 	}
+	if m.spilled != nil {
+		if err := m.flushSpilled(tx); err != nil {
+			return err
+		}
+	}
 	// Iterate over each bucket and apply changes accordingly.
 	for bucketInfo, bucketDiff := range m.diff {
 		if bucketInfo.dupsort {
@@ -56,3 +150,45 @@ func (m *MemoryDiff) Flush(tx kv.RwTx) error {
 	}
 	return nil
 }
+
+func (m *MemoryDiff) flushSpilled(tx kv.RwTx) error {
+	return m.spilled.View(context.Background(), func(spilledTx kv.Tx) error {
+		for t := range m.spilledTables {
+			if err := func() error {
+				c, err := spilledTx.Cursor(t.name)
+				if err != nil {
+					return err
+				}
+				defer c.Close()
+				if t.dupsort {
+					dbCursor, err := tx.RwCursorDupSort(t.name)
+					if err != nil {
+						return err
+					}
+					defer dbCursor.Close()
+					for k, v, err := c.First(); k != nil; k, v, err = c.Next() {
+						if err != nil {
+							return err
+						}
+						if err := dbCursor.Put(k, v); err != nil {
+							return err
+						}
+					}
+					return nil
+				}
+				for k, v, err := c.First(); k != nil; k, v, err = c.Next() {
+					if err != nil {
+						return err
+					}
+					if err := tx.Put(t.name, k, v); err != nil {
+						return err
+					}
+				}
+				return nil
+			}(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
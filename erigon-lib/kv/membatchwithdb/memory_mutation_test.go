@@ -154,6 +154,99 @@ func TestFlush(t *testing.T) {
 	require.Equal(t, value, []byte("value5"))
 }
 
+func TestDiffSpill(t *testing.T) {
+	_, rwTx := memdb.NewTestTx(t)
+
+	initializeDbNonDupSort(rwTx)
+	batch := NewMemoryBatch(rwTx, t.TempDir(), log.Root())
+	defer batch.Close()
+	require.NoError(t, batch.Put(kv.HashedAccounts, []byte("BAAA"), []byte("value4")))
+	require.NoError(t, batch.Put(kv.AccountChangeSet, []byte("key1"), []byte("value1")))
+	require.NoError(t, batch.Put(kv.AccountChangeSet, []byte("key1"), []byte("value2")))
+	require.NoError(t, batch.Delete(kv.HashedAccounts, []byte("CAAA")))
+
+	diff, err := batch.Diff()
+	require.NoError(t, err)
+	defer diff.Close()
+	require.Equal(t, uint64(4+6+4+6+4+6), diff.Size())
+	require.NoError(t, diff.Spill(t.TempDir(), log.Root()))
+	require.True(t, diff.Spilled())
+	require.Equal(t, uint64(30), diff.Size())
+
+	require.NoError(t, diff.Flush(rwTx))
+	value, err := rwTx.GetOne(kv.HashedAccounts, []byte("BAAA"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value4"), value)
+	has, err := rwTx.Has(kv.HashedAccounts, []byte("CAAA"))
+	require.NoError(t, err)
+	require.False(t, has)
+	c, err := rwTx.CursorDupSort(kv.AccountChangeSet)
+	require.NoError(t, err)
+	defer c.Close()
+	_, _, err = c.SeekExact([]byte("key1"))
+	require.NoError(t, err)
+	count, err := c.CountDuplicates()
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), count)
+}
+
+func TestDiffWithLimit(t *testing.T) {
+	_, rwTx := memdb.NewTestTx(t)
+
+	initializeDbNonDupSort(rwTx)
+	batch := NewMemoryBatch(rwTx, t.TempDir(), log.Root())
+	defer batch.Close()
+	require.NoError(t, batch.Put(kv.HashedAccounts, []byte("BAAA"), []byte("value4")))
+	require.NoError(t, batch.Put(kv.HashedAccounts, []byte("DAAA"), []byte("value5")))
+	require.NoError(t, batch.Put(kv.AccountChangeSet, []byte("key1"), []byte("value1")))
+	require.NoError(t, batch.Put(kv.AccountChangeSet, []byte("key1"), []byte("value2")))
+
+	// the entries past the limit are streamed to the spill
+	diff, err := batch.DiffWithLimit(15, t.TempDir(), log.Root())
+	require.NoError(t, err)
+	defer diff.Close()
+	require.True(t, diff.Spilled())
+	require.Equal(t, uint64(40), diff.Size())
+
+	require.NoError(t, diff.Flush(rwTx))
+	value, err := rwTx.GetOne(kv.HashedAccounts, []byte("DAAA"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value5"), value)
+	c, err := rwTx.CursorDupSort(kv.AccountChangeSet)
+	require.NoError(t, err)
+	defer c.Close()
+	_, _, err = c.SeekExact([]byte("key1"))
+	require.NoError(t, err)
+	count, err := c.CountDuplicates()
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), count)
+
+	// below the limit the diff stays in the heap
+	small, err := batch.DiffWithLimit(1024, t.TempDir(), log.Root())
+	require.NoError(t, err)
+	defer small.Close()
+	require.False(t, small.Spilled())
+}
+
+func TestChanges(t *testing.T) {
+	_, rwTx := memdb.NewTestTx(t)
+
+	initializeDbNonDupSort(rwTx)
+	batch := NewMemoryBatch(rwTx, "", log.Root())
+	defer batch.Close()
+	require.NoError(t, batch.Put(kv.HashedAccounts, []byte("BAAA"), []byte("value4")))
+	require.NoError(t, batch.Put(kv.HashedAccounts, []byte("AAAA"), []byte("value5")))
+	require.NoError(t, batch.Delete(kv.HashedAccounts, []byte("CBAA")))
+	require.NoError(t, batch.ClearBucket(kv.HashedStorage))
+
+	changes, err := batch.Changes()
+	require.NoError(t, err)
+	require.Equal(t, map[string]TableChanges{
+		kv.HashedAccounts: {Written: 2, Deleted: 1},
+		kv.HashedStorage:  {Cleared: true},
+	}, changes)
+}
+
 func TestForEach(t *testing.T) {
 	_, rwTx := memdb.NewTestTx(t)
 
@@ -18,7 +18,6 @@ package rawdbv3
 
 import (
 	"encoding/binary"
-	"fmt"
 	"sort"
 
 	"github.com/erigontech/erigon-lib/kv"
@@ -26,6 +25,7 @@ import (
 
 type txNums struct{}
 
+// TxNums reads kv.MaxTxNum, TxNumsWriter writes it
 var TxNums txNums
 
 // Min - returns maxTxNum in given block. If block not found - return last available value (`latest`/`pending` state)
@@ -82,51 +82,6 @@ func (txNums) Min(tx kv.Tx, blockNum uint64) (maxTxNum uint64, err error) {
 	return binary.BigEndian.Uint64(v) + 1, nil
 }
 
-func (txNums) Append(tx kv.RwTx, blockNum, maxTxNum uint64) (err error) {
-	lastK, err := LastKey(tx, kv.MaxTxNum)
-	if err != nil {
-		return err
-	}
-	if len(lastK) != 0 {
-		lastBlockNum := binary.BigEndian.Uint64(lastK)
-		if lastBlockNum > 1 && lastBlockNum+1 != blockNum { //allow genesis
-			return fmt.Errorf("append with gap blockNum=%d, but current heigh=%d", blockNum, lastBlockNum)
-		}
-	}
-
-	var k, v [8]byte
-	binary.BigEndian.PutUint64(k[:], blockNum)
-	binary.BigEndian.PutUint64(v[:], maxTxNum)
-	if err := tx.Append(kv.MaxTxNum, k[:], v[:]); err != nil {
-		return err
-	}
-	return nil
-}
-func (txNums) WriteForGenesis(tx kv.RwTx, maxTxNum uint64) (err error) {
-	var k, v [8]byte
-	binary.BigEndian.PutUint64(k[:], 0)
-	binary.BigEndian.PutUint64(v[:], maxTxNum)
-	return tx.Put(kv.MaxTxNum, k[:], v[:])
-}
-func (txNums) Truncate(tx kv.RwTx, blockNum uint64) (err error) {
-	var seek [8]byte
-	binary.BigEndian.PutUint64(seek[:], blockNum)
-	c, err := tx.RwCursor(kv.MaxTxNum)
-	if err != nil {
-		return err
-	}
-	defer c.Close()
-	for k, _, err := c.Seek(seek[:]); k != nil; k, _, err = c.Next() {
-		if err != nil {
-			return err
-		}
-		if err = c.DeleteCurrent(); err != nil {
-			return err
-		}
-
-	}
-	return nil
-}
 func (txNums) FindBlockNum(tx kv.Tx, endTxNumMinimax uint64) (ok bool, blockNum uint64, err error) {
 	var seek [8]byte
 	c, err := tx.Cursor(kv.MaxTxNum)
@@ -0,0 +1,146 @@
+/*
+   Copyright 2024 Erigon contributors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package rawdbv3
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/erigontech/erigon-lib/common/dbg"
+	"github.com/erigontech/erigon-lib/kv"
+)
+
+var ErrTxNumsInvariant = errors.New("txNums invariant violated")
+
+// TxAmountFunc - amount of txs in the block, including system txs. ok=false - block body is unknown
+type TxAmountFunc func(blockNum uint64) (txAmount uint64, ok bool, err error)
+
+type txNumsWriter struct{}
+
+// TxNumsWriter - the only writer of kv.MaxTxNum (blockNum -> maxTxNum of the block), TxNums reads it.
+// Invariants: blocks are consecutive (except genesis) and maxTxNum of the block is maxTxNum of the parent
+// plus amount of txs in the block, which always has 2 system txs. With dbg.AssertTxNums appends are checked.
+var TxNumsWriter txNumsWriter
+
+func (txNumsWriter) Append(tx kv.RwTx, blockNum, maxTxNum uint64) (err error) {
+	lastK, lastV, err := Last(tx, kv.MaxTxNum)
+	if err != nil {
+		return err
+	}
+	if len(lastK) != 0 {
+		lastBlockNum := binary.BigEndian.Uint64(lastK)
+		if lastBlockNum > 1 && lastBlockNum+1 != blockNum { //allow genesis
+			return fmt.Errorf("append with gap blockNum=%d, but current heigh=%d", blockNum, lastBlockNum)
+		}
+		if dbg.AssertTxNums {
+			if lastMaxTxNum := binary.BigEndian.Uint64(lastV); maxTxNum <= lastMaxTxNum {
+				return fmt.Errorf("%w: append blockNum=%d maxTxNum=%d, but maxTxNum=%d at blockNum=%d", ErrTxNumsInvariant, blockNum, maxTxNum, lastMaxTxNum, lastBlockNum)
+			}
+		}
+	}
+
+	var k, v [8]byte
+	binary.BigEndian.PutUint64(k[:], blockNum)
+	binary.BigEndian.PutUint64(v[:], maxTxNum)
+	if err := tx.Append(kv.MaxTxNum, k[:], v[:]); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (txNumsWriter) WriteForGenesis(tx kv.RwTx, maxTxNum uint64) (err error) {
+	var k, v [8]byte
+	binary.BigEndian.PutUint64(k[:], 0)
+	binary.BigEndian.PutUint64(v[:], maxTxNum)
+	return tx.Put(kv.MaxTxNum, k[:], v[:])
+}
+
+// Truncate deletes blockNum and all blocks after it
+func (txNumsWriter) Truncate(tx kv.RwTx, blockNum uint64) (err error) {
+	var seek [8]byte
+	binary.BigEndian.PutUint64(seek[:], blockNum)
+	c, err := tx.RwCursor(kv.MaxTxNum)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	for k, _, err := c.Seek(seek[:]); k != nil; k, _, err = c.Next() {
+		if err != nil {
+			return err
+		}
+		if err = c.DeleteCurrent(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Verify checks invariants of blocks from fromBlock to the last one. If txAmount is set, maxTxNums are also
+// checked against block bodies
+func (txNumsWriter) Verify(tx kv.Tx, fromBlock uint64, txAmount TxAmountFunc) error {
+	c, err := tx.Cursor(kv.MaxTxNum)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	var seek [8]byte
+	var prevBlockNum, prevMaxTxNum uint64
+	hasPrev := false
+	if fromBlock > 0 {
+		binary.BigEndian.PutUint64(seek[:], fromBlock-1)
+		k, v, err := c.SeekExact(seek[:])
+		if err != nil {
+			return err
+		}
+		if k != nil {
+			hasPrev, prevBlockNum, prevMaxTxNum = true, fromBlock-1, binary.BigEndian.Uint64(v)
+		}
+	}
+	binary.BigEndian.PutUint64(seek[:], fromBlock)
+	for k, v, err := c.Seek(seek[:]); k != nil; k, v, err = c.Next() {
+		if err != nil {
+			return err
+		}
+		blockNum, maxTxNum := binary.BigEndian.Uint64(k), binary.BigEndian.Uint64(v)
+		if hasPrev {
+			if prevBlockNum > 1 && prevBlockNum+1 != blockNum { // Append allows gap after genesis
+				return fmt.Errorf("%w: gap between blockNum=%d and blockNum=%d", ErrTxNumsInvariant, prevBlockNum, blockNum)
+			}
+			if maxTxNum <= prevMaxTxNum {
+				return fmt.Errorf("%w: maxTxNum=%d at blockNum=%d, but maxTxNum=%d at blockNum=%d", ErrTxNumsInvariant, maxTxNum, blockNum, prevMaxTxNum, prevBlockNum)
+			}
+		}
+		// amount of txs is known if the parent is known or it's genesis
+		if txAmount != nil && (blockNum == 0 || (hasPrev && prevBlockNum+1 == blockNum)) {
+			amount, ok, err := txAmount(blockNum)
+			if err != nil {
+				return err
+			}
+			var minTxNum uint64
+			if blockNum > 0 {
+				minTxNum = prevMaxTxNum + 1
+			}
+			if ok && maxTxNum+1-minTxNum != amount {
+				return fmt.Errorf("%w: blockNum=%d has %d txs in body, but txNums [%d, %d]", ErrTxNumsInvariant, blockNum, amount, minTxNum, maxTxNum)
+			}
+		}
+		hasPrev, prevBlockNum, prevMaxTxNum = true, blockNum, maxTxNum
+	}
+	return nil
+}
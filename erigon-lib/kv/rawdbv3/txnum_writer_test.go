@@ -0,0 +1,54 @@
+package rawdbv3
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/memdb"
+)
+
+func TestTxNumsWriter(t *testing.T) {
+	tx, err := memdb.NewTestDB(t).BeginRw(context.Background())
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	// every block has 2 system txs and blockNum user txs
+	amounts := map[uint64]uint64{}
+	txAmount := func(blockNum uint64) (uint64, bool, error) {
+		amount, ok := amounts[blockNum]
+		return amount, ok, nil
+	}
+	require.NoError(t, TxNumsWriter.WriteForGenesis(tx, 1))
+	amounts[0] = 2
+	maxTxNum := uint64(1)
+	for blockNum := uint64(1); blockNum <= 10; blockNum++ {
+		amounts[blockNum] = blockNum + 2
+		maxTxNum += blockNum + 2
+		require.NoError(t, TxNumsWriter.Append(tx, blockNum, maxTxNum))
+	}
+	require.NoError(t, TxNumsWriter.Verify(tx, 0, txAmount))
+	require.Error(t, TxNumsWriter.Append(tx, 12, maxTxNum+1))
+
+	require.NoError(t, TxNumsWriter.Truncate(tx, 6))
+	last, _, err := TxNums.Last(tx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), last)
+	require.NoError(t, TxNumsWriter.Verify(tx, 3, txAmount))
+
+	// body doesn't match
+	amounts[4]++
+	require.ErrorIs(t, TxNumsWriter.Verify(tx, 0, txAmount), ErrTxNumsInvariant)
+	require.NoError(t, TxNumsWriter.Verify(tx, 5, txAmount))
+	amounts[4]--
+
+	// maxTxNum goes backwards
+	var k, v [8]byte
+	binary.BigEndian.PutUint64(k[:], 5)
+	binary.BigEndian.PutUint64(v[:], 3)
+	require.NoError(t, tx.Put(kv.MaxTxNum, k[:], v[:]))
+	require.ErrorIs(t, TxNumsWriter.Verify(tx, 5, nil), ErrTxNumsInvariant)
+}
@@ -22,6 +22,8 @@ import (
 	"encoding/binary"
 	"fmt"
 	"runtime"
+	"sync/atomic"
+	"time"
 	"unsafe"
 
 	"github.com/erigontech/erigon-lib/log/v3"
@@ -49,12 +51,17 @@ type remoteOpts struct {
 
 var _ kv.TemporalTx = (*tx)(nil)
 
+// MaxStateVersionWait - how long BeginRo waits for the server to see the state version set by EnsureStateVersion.
+// After that the tx is opened as the server has it.
+const MaxStateVersionWait = 2 * time.Second
+
 type DB struct {
-	remoteKV     remote.KVClient
-	log          log.Logger
-	buckets      kv.TableCfg
-	roTxsLimiter *semaphore.Weighted
-	opts         remoteOpts
+	remoteKV        remote.KVClient
+	log             log.Logger
+	buckets         kv.TableCfg
+	roTxsLimiter    *semaphore.Weighted
+	opts            remoteOpts
+	minStateVersion atomic.Uint64 // see EnsureStateVersion
 }
 
 type tx struct {
@@ -66,6 +73,8 @@ type tx struct {
 	cursors            []*remoteCursor
 	streams            []kv.Closer
 	viewID, id         uint64
+	stateVersion       uint64 // sent by servers from 6.3.0, see stateVersionKnown
+	stateVersionKnown  bool
 	streamingRequested bool
 }
 
@@ -153,7 +162,60 @@ func (db *DB) CHandle() unsafe.Pointer {
 	panic("CHandle not implemented")
 }
 
-func (db *DB) BeginRo(ctx context.Context) (txn kv.Tx, err error) {
+// EnsureStateVersion - txs opened from now on see at least state version v (kv.PlainStateVersion), e.g. of a block
+// import the client was notified about by the StateChanges stream. Reads of `latest` then never go back to the
+// state from before the import, even if the server opens the tx on a db which hasn't caught up yet.
+func (db *DB) EnsureStateVersion(v uint64) {
+	for {
+		current := db.minStateVersion.Load()
+		if v <= current || db.minStateVersion.CompareAndSwap(current, v) {
+			return
+		}
+	}
+}
+
+// BeginRo - opens a tx on the server. If it sees a state version older than set by EnsureStateVersion, it's
+// reopened until it doesn't, for at most MaxStateVersionWait.
+func (db *DB) BeginRo(ctx context.Context) (kv.Tx, error) {
+	minVersion := db.minStateVersion.Load()
+	t, err := db.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if minVersion == 0 {
+		return t, nil
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, MaxStateVersionWait)
+	defer cancel()
+	for {
+		version, err := t.readStateVersion()
+		if err != nil {
+			t.Rollback()
+			return nil, err
+		}
+		if version >= minVersion {
+			return t, nil
+		}
+		t.Rollback()
+		select {
+		case <-waitCtx.Done():
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			db.log.Warn("[remotedb] tx doesn't see the latest state", "version", version, "expected", minVersion, "waited", MaxStateVersionWait)
+			if t, err = db.begin(ctx); err != nil {
+				return nil, err
+			}
+			return t, nil
+		case <-time.After(10 * time.Millisecond):
+		}
+		if t, err = db.begin(ctx); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (db *DB) begin(ctx context.Context) (txn *tx, err error) {
 	select {
 	case <-ctx.Done():
 		return nil, ctx.Err()
@@ -182,7 +244,11 @@ func (db *DB) BeginRo(ctx context.Context) (txn kv.Tx, err error) {
 		streamCancelFn()
 		return nil, err
 	}
-	return &tx{ctx: ctx, db: db, stream: stream, streamCancelFn: streamCancelFn, viewID: msg.ViewId, id: msg.TxId}, nil
+	txn = &tx{ctx: ctx, db: db, stream: stream, streamCancelFn: streamCancelFn, viewID: msg.ViewId, id: msg.TxId}
+	if len(msg.V) == 8 {
+		txn.stateVersion, txn.stateVersionKnown = binary.BigEndian.Uint64(msg.V), true
+	}
+	return txn, nil
 }
 func (db *DB) BeginTemporalRo(ctx context.Context) (kv.TemporalTx, error) {
 	t, err := db.BeginRo(ctx) //nolint:gocritic
@@ -228,6 +294,23 @@ func (db *DB) UpdateNosync(ctx context.Context, f func(tx kv.RwTx) error) (err e
 	return fmt.Errorf("remote db provider doesn't support .UpdateNosync method")
 }
 
+// readStateVersion - the state version the tx sees. Servers before 6.3.0 don't send it when the tx opens, then
+// it's read from the tx.
+func (tx *tx) readStateVersion() (uint64, error) {
+	if tx.stateVersionKnown {
+		return tx.stateVersion, nil
+	}
+	v, err := tx.GetOne(kv.Sequence, kv.PlainStateVersion)
+	if err != nil {
+		return 0, err
+	}
+	if len(v) == 8 {
+		tx.stateVersion = binary.BigEndian.Uint64(v)
+	}
+	tx.stateVersionKnown = true
+	return tx.stateVersion, nil
+}
+
 func (tx *tx) ViewID() uint64  { return tx.viewID }
 func (tx *tx) CollectMetrics() {}
 func (tx *tx) IncrementSequence(bucket string, amount uint64) (uint64, error) {
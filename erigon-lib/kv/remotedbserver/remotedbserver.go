@@ -64,7 +64,8 @@ const MaxTxTTL = 60 * time.Second
 // 6.0.0 - Blocks now have system-txs - in the begin/end of block
 // 6.1.0 - Add methods Range, IndexRange, HistoryGet, HistoryRange
 // 6.2.0 - Add HistoryFiles to reply of Snapshots() method
-var KvServiceAPIVersion = &types.VersionReply{Major: 6, Minor: 2, Patch: 0}
+// 6.3.0 - Server sends the state version (kv.PlainStateVersion) of the tx in V of the first Pair, next to ViewId
+var KvServiceAPIVersion = &types.VersionReply{Major: 6, Minor: 3, Patch: 0}
 
 type KvServer struct {
 	remote.UnimplementedKVServer // must be embedded to have forward compatible implementations.
@@ -221,13 +222,23 @@ func (s *KvServer) Tx(stream remote.KV_TxServer) error {
 	defer s.rollback(id)
 
 	var viewID uint64
+	var stateVersion []byte
 	if err := s.with(id, func(tx kv.Tx) error {
 		viewID = tx.ViewID()
+		v, err := tx.GetOne(kv.Sequence, kv.PlainStateVersion)
+		if err != nil {
+			return err
+		}
+		// the client checks it against the state versions it was notified about, see remotedb.DB.EnsureStateVersion
+		stateVersion = make([]byte, 8)
+		if len(v) == 8 {
+			copy(stateVersion, v)
+		}
 		return nil
 	}); err != nil {
 		return fmt.Errorf("kvserver: %w", err)
 	}
-	if err := stream.Send(&remote.Pair{ViewId: viewID, TxId: id}); err != nil {
+	if err := stream.Send(&remote.Pair{ViewId: viewID, TxId: id, V: stateVersion}); err != nil {
 		return fmt.Errorf("server-side error: %w", err)
 	}
 
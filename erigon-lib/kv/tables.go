@@ -292,6 +292,11 @@ const (
 	// touched by call traces. It is DupSort-ed table
 	// 8-byte BE block number -> account address -> two bits (one for "from", another for "to")
 	CallTraceSet = "CallTraceSet"
+
+	// BlockAccessList - experimental EIP-7928 block access lists, collected with --experimental.bal
+	// 8-byte BE block number + block hash -> JSON of types.BlockAccessList
+	BlockAccessList = "BlockAccessList"
+
 	// Indices for call traces - have the same format as LogTopicIndex and LogAddressIndex
 	// Store bitmap indices - in which block number we saw calls from (CallFromIndex) or to (CallToIndex) some addresses
 	CallFromIndex = "CallFromIndex"
@@ -326,6 +331,9 @@ const (
 
 	// Transaction senders - stored separately from the block bodies
 	Senders = "TxSender" // block_num_u64 + blockHash -> sendersList (no serialization format, every 20 bytes is new sender)
+	// Senders of frozen blocks which segments have no senders (imported from external snapshots or exports),
+	// recovered by SendersBackfill stage. Same format as Senders, but not pruned when blocks are retired
+	FrozenSenders = "FrozenTxSender"
 
 	// headBlockKey tracks the latest know full block's hash.
 	HeadBlockKey = "LastBlock"
@@ -335,6 +343,18 @@ const (
 	// headBlockHash, safeBlockHash, finalizedBlockHash of the latest Engine API forkchoice
 	LastForkchoice = "LastForkchoice"
 
+	// Progress of external chain events exporters (see exporter package): topic -> block_num_u64 + block_hash
+	ExportOffsets = "ExportOffset"
+
+	// Payload attributes received by engine_forkchoiceUpdated with outcome of block building:
+	// received_at_ms_u64 + payload_id_u64 -> record (in JSON encoding)
+	PayloadAttributes = "PayloadAttributes"
+	// Index of PayloadAttributes by payload id: payload_id_u64 + received_at_ms_u64 -> nil
+	PayloadAttributesIndex = "PayloadAttributesIndex"
+
+	// Recently rejected blocks with the reason, the last rawdb.MaxBadBlocks of them: seq_u64 -> record (in JSON encoding)
+	BadBlocks = "BadBlocks"
+
 	// TransitionBlockKey tracks the last proof-of-work block
 	TransitionBlockKey = "TransitionBlock"
 
@@ -508,6 +528,10 @@ const (
 var (
 	//StorageModeTEVM - does not translate EVM to TEVM
 	StorageModeTEVM = []byte("smTEVM")
+	//StorageModeReceiptsIndexOnly - receipts aren't stored, only the log index is kept
+	StorageModeReceiptsIndexOnly = []byte("smReceiptsIndexOnly")
+	//StorageModeKeepDeposits - receipts and call traces of the blocks with OP deposit txs aren't pruned
+	StorageModeKeepDeposits = []byte("smKeepDeposits")
 
 	PruneTypeOlder  = []byte("older")
 	PruneTypeBefore = []byte("before")
@@ -565,13 +589,19 @@ var ChaindataTables = []string{
 	AccountChangeSet,
 	StorageChangeSet,
 	Senders,
+	FrozenSenders,
 	HeadBlockKey,
 	HeadHeaderKey,
 	LastForkchoice,
+	ExportOffsets,
+	PayloadAttributes,
+	PayloadAttributesIndex,
+	BadBlocks,
 	Migrations,
 	LogTopicIndex,
 	LogAddressIndex,
 	CallTraceSet,
+	BlockAccessList,
 	CallFromIndex,
 	CallToIndex,
 	CumulativeGasIndex,
@@ -821,6 +851,21 @@ var AuRaTablesCfg = TableCfg{
 	PendingEpoch: {},
 }
 
+// BorTables - chaindata tables only bor chains use
+var BorTables = []string{
+	BorReceipts,
+	BorFinality,
+	BorTxLookup,
+	BorSeparate,
+	BorEvents,
+	BorEventNums,
+	BorSpans,
+	BorMilestones,
+	BorMilestoneEnds,
+	BorCheckpoints,
+	BorCheckpointEnds,
+}
+
 var BorTablesCfg = TableCfg{
 	BorReceipts:       {Flags: DupSort},
 	BorFinality:       {Flags: DupSort},
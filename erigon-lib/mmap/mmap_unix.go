@@ -64,6 +64,16 @@ func Mmap(f *os.File, size int) ([]byte, *[MaxMapSize]byte, error) {
 	return mmapHandle1, mmapHandle2, nil
 }
 
+// MmapAnon - private anonymous memory, not backed by any file. Use Munmap to release it.
+func MmapAnon(size int) ([]byte, *[MaxMapSize]byte, error) {
+	mmapHandle1, err := unix.Mmap(-1, 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_PRIVATE|syscall.MAP_ANON)
+	if err != nil {
+		return nil, nil, err
+	}
+	mmapHandle2 := (*[MaxMapSize]byte)(unsafe.Pointer(&mmapHandle1[0]))
+	return mmapHandle1, mmapHandle2, nil
+}
+
 func MadviseSequential(mmapHandle1 []byte) error {
 	err := unix.Madvise(mmapHandle1, syscall.MADV_SEQUENTIAL)
 	if err != nil && !errors.Is(err, syscall.ENOSYS) {
@@ -50,6 +50,11 @@ func Mmap(f *os.File, size int) ([]byte, *[MaxMapSize]byte, error) {
 	return mmapHandle2[:size], mmapHandle2, nil
 }
 
+// MmapAnon - on windows it's memory of Go heap, Munmap does nothing and GC releases it
+func MmapAnon(size int) ([]byte, *[MaxMapSize]byte, error) {
+	return make([]byte, size), nil, nil
+}
+
 func MadviseSequential(mmapHandle1 []byte) error { return nil }
 func MadviseNormal(mmapHandle1 []byte) error     { return nil }
 func MadviseWillNeed(mmapHandle1 []byte) error   { return nil }
@@ -219,6 +219,11 @@ func (c *Compressor) Compress() error {
 	if err = cf.Close(); err != nil {
 		return err
 	}
+	if keyProvider := KeyProviderFromContext(c.ctx); keyProvider != nil {
+		if err = EncryptFile(c.ctx, keyProvider, c.tmpOutFilePath); err != nil {
+			return fmt.Errorf("encrypting: %w", err)
+		}
+	}
 	if err := os.Rename(c.tmpOutFilePath, c.outputFile); err != nil {
 		return fmt.Errorf("renaming: %w", err)
 	}
@@ -18,6 +18,7 @@ package seg
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
 	"errors"
 	"fmt"
@@ -130,6 +131,7 @@ type Decompressor struct {
 	modTime         time.Time
 	wordsCount      uint64
 	emptyWordsCount uint64
+	decryptor       *segmentDecryptor // data of encrypted file, nil for plain one
 
 	filePath, fileName string
 }
@@ -172,6 +174,12 @@ func SetDecompressionTableCondensity(fromBitSize int) {
 }
 
 func NewDecompressor(compressedFilePath string) (*Decompressor, error) {
+	return NewDecompressorWithKeyProvider(compressedFilePath, nil)
+}
+
+// NewDecompressorWithKeyProvider - encrypted file is decrypted with the data key unwrapped by p, plain file is opened
+// as by NewDecompressor
+func NewDecompressorWithKeyProvider(compressedFilePath string, p KeyProvider) (*Decompressor, error) {
 	_, fName := filepath.Split(compressedFilePath)
 	var err error
 	var closeDecompressor = true
@@ -213,8 +221,18 @@ func NewDecompressor(compressedFilePath string) (*Decompressor, error) {
 	}
 	// read patterns from file
 	d.data = d.mmapHandle1[:d.size]
+	if IsEncrypted(d.data) {
+		if d.decryptor, err = newSegmentDecryptor(context.Background(), p, d.data); err != nil {
+			return nil, fmt.Errorf("decrypting file: %s, %w", compressedFilePath, err)
+		}
+		d.data = d.decryptor.plain
+		d.size = int64(len(d.data))
+	}
 	defer d.EnableReadAhead().DisableReadAhead() //speedup opening on slow drives
 
+	if err = d.decrypt(0, 24); err != nil {
+		return nil, err
+	}
 	d.wordsCount = binary.BigEndian.Uint64(d.data[:8])
 	d.emptyWordsCount = binary.BigEndian.Uint64(d.data[8:16])
 
@@ -229,6 +247,9 @@ func NewDecompressor(compressedFilePath string) (*Decompressor, error) {
 	}
 
 	// todo awskii: want to move dictionary reading to separate function?
+	if err = d.decrypt(pos, pos+dictSize+8); err != nil { // with size of positions dictionary
+		return nil, err
+	}
 	data := d.data[pos : pos+dictSize]
 
 	var depths []uint64
@@ -284,6 +305,9 @@ func NewDecompressor(compressedFilePath string) (*Decompressor, error) {
 				datasize.ByteSize(dictSize).HR(), datasize.ByteSize(d.size).HR())}
 	}
 
+	if err = d.decrypt(pos, pos+dictSize); err != nil {
+		return nil, err
+	}
 	data = d.data[pos : pos+dictSize]
 
 	var posDepths []uint64
@@ -428,7 +452,19 @@ func buildPosTable(depths []uint64, poss []uint64, table *posTable, code uint16,
 	return b0 + b1, err
 }
 
+// decrypt - data[from:to] can be read, no-op for plain file
+func (d *Decompressor) decrypt(from, to uint64) error {
+	if d.decryptor == nil {
+		return nil
+	}
+	return d.decryptor.decrypt(from, to)
+}
+
+// DataHandle - of encrypted file, the whole file is decrypted: the data is read bypassing getters
 func (d *Decompressor) DataHandle() unsafe.Pointer {
+	if d.decryptor != nil {
+		d.decryptor.ensure(0, uint64(len(d.data)))
+	}
 	return unsafe.Pointer(&d.data[0])
 }
 
@@ -449,6 +485,12 @@ func (d *Decompressor) Close() {
 		if err := mmap.Munmap(d.mmapHandle1, d.mmapHandle2); err != nil {
 			log.Log(dbg.FileCloseLogLevel, "unmap", "err", err, "file", d.FileName(), "stack", dbg.Stack())
 		}
+		if d.decryptor != nil {
+			if err := d.decryptor.close(); err != nil {
+				log.Log(dbg.FileCloseLogLevel, "unmap", "err", err, "file", d.FileName(), "stack", dbg.Stack())
+			}
+			d.decryptor = nil
+		}
 		if err := d.f.Close(); err != nil {
 			log.Log(dbg.FileCloseLogLevel, "close", "err", err, "file", d.FileName(), "stack", dbg.Stack())
 		}
@@ -512,6 +554,15 @@ type Getter struct {
 	dataP       uint64
 	dataBit     int // Value 0..7 - position of the bit
 	trace       bool
+	decryptor   *segmentDecryptor // nil for plain file
+	wordsStart  uint64            // offset of data in the file
+}
+
+// ensure - data[from:to] can be read, no-op for plain file
+func (g *Getter) ensure(from, to uint64) {
+	if g.decryptor != nil {
+		g.decryptor.ensure(g.wordsStart+from, g.wordsStart+to)
+	}
 }
 
 func (g *Getter) Trace(t bool)     { g.trace = t }
@@ -527,6 +578,7 @@ func (g *Getter) nextPos(clean bool) (pos uint64) {
 		return table.pos[0]
 	}
 	for l := byte(0); l == 0; {
+		g.ensure(g.dataP, g.dataP+2)
 		code := uint16(g.data[g.dataP]) >> g.dataBit
 		if 8-g.dataBit < table.bitLen && int(g.dataP)+1 < len(g.data) {
 			code |= uint16(g.data[g.dataP+1]) << (8 - g.dataBit)
@@ -556,6 +608,7 @@ func (g *Getter) nextPattern() []byte {
 	var l byte
 	var pattern []byte
 	for l == 0 {
+		g.ensure(g.dataP, g.dataP+2)
 		code := uint16(g.data[g.dataP]) >> g.dataBit
 		if 8-g.dataBit < table.bitLen && int(g.dataP)+1 < len(g.data) {
 			code |= uint16(g.data[g.dataP+1]) << (8 - g.dataBit)
@@ -616,6 +669,8 @@ func (d *Decompressor) MakeGetter() *Getter {
 		data:        d.data[d.wordsStart:],
 		patternDict: d.dict,
 		fName:       d.fileName,
+		decryptor:   d.decryptor,
+		wordsStart:  d.wordsStart,
 	}
 }
 
@@ -682,6 +737,7 @@ func (g *Getter) Next(buf []byte) ([]byte, uint64) {
 		bufPos += int(pos) - 1 // Positions where to insert patterns are encoded relative to one another
 		if bufPos > lastUncovered {
 			dif := uint64(bufPos - lastUncovered)
+			g.ensure(postLoopPos, postLoopPos+dif)
 			copy(buf[lastUncovered:bufPos], g.data[postLoopPos:postLoopPos+dif])
 			postLoopPos += dif
 		}
@@ -689,6 +745,7 @@ func (g *Getter) Next(buf []byte) ([]byte, uint64) {
 	}
 	if bufOffset+int(wordLen) > lastUncovered {
 		dif := uint64(bufOffset + int(wordLen) - lastUncovered)
+		g.ensure(postLoopPos, postLoopPos+dif)
 		copy(buf[lastUncovered:lastUncovered+int(dif)], g.data[postLoopPos:postLoopPos+dif])
 		postLoopPos += dif
 	}
@@ -714,6 +771,7 @@ func (g *Getter) NextUncompressed() ([]byte, uint64) {
 	}
 	pos := g.dataP
 	g.dataP += wordLen
+	g.ensure(pos, g.dataP)
 	return g.data[pos:g.dataP], g.dataP
 }
 
@@ -816,6 +874,7 @@ func (g *Getter) Match(buf []byte) (bool, uint64) {
 		bufPos += int(pos) - 1
 		if bufPos > lastUncovered {
 			dif := uint64(bufPos - lastUncovered)
+			g.ensure(postLoopPos, postLoopPos+dif)
 			if lenBuf < bufPos || !bytes.Equal(buf[lastUncovered:bufPos], g.data[postLoopPos:postLoopPos+dif]) {
 				g.dataP, g.dataBit = savePos, 0
 				return false, savePos
@@ -826,6 +885,7 @@ func (g *Getter) Match(buf []byte) (bool, uint64) {
 	}
 	if int(wordLen) > lastUncovered {
 		dif := wordLen - uint64(lastUncovered)
+		g.ensure(postLoopPos, postLoopPos+dif)
 		if lenBuf < int(wordLen) || !bytes.Equal(buf[lastUncovered:wordLen], g.data[postLoopPos:postLoopPos+dif]) {
 			g.dataP, g.dataBit = savePos, 0
 			return false, savePos
@@ -900,6 +960,7 @@ func (g *Getter) MatchPrefix(prefix []byte) bool {
 			} else {
 				comparisonLen = int(dif)
 			}
+			g.ensure(postLoopPos, postLoopPos+uint64(comparisonLen))
 			if !bytes.Equal(prefix[lastUncovered:lastUncovered+comparisonLen], g.data[postLoopPos:postLoopPos+uint64(comparisonLen)]) {
 				return false
 			}
@@ -915,6 +976,7 @@ func (g *Getter) MatchPrefix(prefix []byte) bool {
 		} else {
 			comparisonLen = int(dif)
 		}
+		g.ensure(postLoopPos, postLoopPos+uint64(comparisonLen))
 		if !bytes.Equal(prefix[lastUncovered:lastUncovered+comparisonLen], g.data[postLoopPos:postLoopPos+uint64(comparisonLen)]) {
 			return false
 		}
@@ -964,6 +1026,7 @@ func (g *Getter) MatchCmp(buf []byte) int {
 		// fmt.Printf("BUF POS: %d, POS: %d, lastUncovered: %d\n", bufPos, pos, lastUncovered)
 		if bufPos > lastUncovered {
 			dif := uint64(bufPos - lastUncovered)
+			g.ensure(postLoopPos, postLoopPos+dif)
 			copy(decoded[lastUncovered:bufPos], g.data[postLoopPos:postLoopPos+dif])
 			postLoopPos += dif
 		}
@@ -972,6 +1035,7 @@ func (g *Getter) MatchCmp(buf []byte) int {
 
 	if int(wordLen) > lastUncovered {
 		dif := wordLen - uint64(lastUncovered)
+		g.ensure(postLoopPos, postLoopPos+dif)
 		copy(decoded[lastUncovered:wordLen], g.data[postLoopPos:postLoopPos+dif])
 		postLoopPos += dif
 	}
@@ -1029,6 +1093,7 @@ func (g *Getter) MatchPrefixCmp(prefix []byte) int {
 		bufPos += int(pos) - 1
 		if bufPos > lastUncovered {
 			dif := uint64(bufPos - lastUncovered)
+			g.ensure(postLoopPos, postLoopPos+dif)
 			copy(decoded[lastUncovered:bufPos], g.data[postLoopPos:postLoopPos+dif])
 			postLoopPos += dif
 		}
@@ -1036,6 +1101,7 @@ func (g *Getter) MatchPrefixCmp(prefix []byte) int {
 	}
 	if prefixLen > lastUncovered && int(wordLen) > lastUncovered {
 		dif := wordLen - uint64(lastUncovered)
+		g.ensure(postLoopPos, postLoopPos+dif)
 		copy(decoded[lastUncovered:wordLen], g.data[postLoopPos:postLoopPos+dif])
 		// postLoopPos += dif
 	}
@@ -1076,6 +1142,7 @@ func (g *Getter) MatchPrefixUncompressed(prefix []byte) int {
 	// 	// 		word = 'aaa'
 	// }
 
+	g.ensure(g.dataP, g.dataP+wordLen)
 	return bytes.Compare(prefix, g.data[g.dataP:g.dataP+wordLen])
 }
 
@@ -1128,6 +1195,7 @@ func (g *Getter) FastNext(buf []byte) ([]byte, uint64) {
 		bufPos += int(pos) - 1 // Positions where to insert patterns are encoded relative to one another
 		if bufPos > lastUncovered {
 			dif := uint64(bufPos - lastUncovered)
+			g.ensure(postLoopPos, postLoopPos+dif)
 			copy(buf[lastUncovered:bufPos], g.data[postLoopPos:postLoopPos+dif])
 			postLoopPos += dif
 		}
@@ -1135,6 +1203,7 @@ func (g *Getter) FastNext(buf []byte) ([]byte, uint64) {
 	}
 	if int(wordLen) > lastUncovered {
 		dif := wordLen - uint64(lastUncovered)
+		g.ensure(postLoopPos, postLoopPos+dif)
 		copy(buf[lastUncovered:wordLen], g.data[postLoopPos:postLoopPos+dif])
 		postLoopPos += dif
 	}
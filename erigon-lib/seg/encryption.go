@@ -0,0 +1,387 @@
+/*
+   Copyright 2024 Erigon contributors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package seg
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/erigontech/erigon-lib/mmap"
+)
+
+// Encryption at rest of segments. Encrypted file:
+//
+//	magic [4]byte | version byte | chunkSize uint32 | plain size uint64 | nonce prefix [4]byte | wrapped key len uint16 | wrapped key
+//	chunk 0 | chunk 1 | ... - AES-256-GCM of plain file split into chunkSize pieces, nonce = nonce prefix | chunk number uint64
+//
+// Every file has own data key, it's stored wrapped (encrypted) by master key of KeyProvider - envelope
+// encryption of KMS services. Header is authenticated as additional data of every chunk, so
+// chunks can't be reordered, truncated or moved between files.
+//
+// Decompressor maps plain data to anonymous memory and decrypts a chunk when it's read first - getters work with
+// plain data as usual, only the chunks which are read take memory. Plain data never hits the disk, unless the
+// memory is swapped out. Indices (.idx, .efi, ...) are not encrypted: they don't contain data of segments. Only the
+// block segments (and receipts) are encrypted, state snapshots are plain.
+//
+// Key provider is not global: it's a part of the config of block snapshots (ethconfig.BlocksFreezing), the code
+// compressing and indexing segments gets it by context (see WithKeyProvider).
+
+const (
+	encryptionVersion    = 1
+	encryptionChunkSize  = 1 << 20
+	encryptionHeaderSize = 4 + 1 + 4 + 8 + 4 + 2
+	dataKeySize          = 32 // AES-256
+)
+
+var encryptionMagic = [4]byte{'E', 'S', 'E', 'G'}
+
+var ErrNoKeyProvider = errors.New("segment is encrypted, but no key provider is set")
+
+// KeyProvider - source of data keys, compatible with KMS services (GenerateDataKey/Decrypt of AWS KMS,
+// GCP KMS, Vault transit...): master key never leaves the provider.
+type KeyProvider interface {
+	// GenerateDataKey returns new random 32-byte data key and the same key wrapped by master key
+	GenerateDataKey(ctx context.Context) (key, wrapped []byte, err error)
+	// DecryptDataKey unwraps data key returned by GenerateDataKey
+	DecryptDataKey(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+type keyProviderKey struct{}
+
+// WithKeyProvider - Compressor created with the context encrypts new segment, code opening segments passes
+// KeyProviderFromContext to NewDecompressorWithKeyProvider. Nil p disables encryption.
+func WithKeyProvider(ctx context.Context, p KeyProvider) context.Context {
+	return context.WithValue(ctx, keyProviderKey{}, p)
+}
+
+// KeyProviderFromContext - nil if the context has no key provider
+func KeyProviderFromContext(ctx context.Context) KeyProvider {
+	p, _ := ctx.Value(keyProviderKey{}).(KeyProvider)
+	return p
+}
+
+// IsEncrypted - data is the beginning of encrypted segment. Plain segment starts with words count,
+// which never has magic's bytes in the high half.
+func IsEncrypted(data []byte) bool {
+	return len(data) >= len(encryptionMagic) && bytes.Equal(data[:len(encryptionMagic)], encryptionMagic[:])
+}
+
+func IsEncryptedFile(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	var magic [len(encryptionMagic)]byte
+	if _, err = io.ReadFull(f, magic[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+			return false, nil
+		}
+		return false, err
+	}
+	return IsEncrypted(magic[:]), nil
+}
+
+type encryptionHeader struct {
+	chunkSize   uint32
+	size        uint64
+	noncePrefix [4]byte
+	wrappedKey  []byte
+	raw         []byte // additional data of chunks
+}
+
+func (h *encryptionHeader) encode() []byte {
+	buf := make([]byte, 0, encryptionHeaderSize+len(h.wrappedKey))
+	buf = append(buf, encryptionMagic[:]...)
+	buf = append(buf, encryptionVersion)
+	buf = binary.BigEndian.AppendUint32(buf, h.chunkSize)
+	buf = binary.BigEndian.AppendUint64(buf, h.size)
+	buf = append(buf, h.noncePrefix[:]...)
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(h.wrappedKey)))
+	buf = append(buf, h.wrappedKey...)
+	h.raw = buf
+	return buf
+}
+
+func decodeEncryptionHeader(data []byte) (*encryptionHeader, error) {
+	if len(data) < encryptionHeaderSize || !IsEncrypted(data) {
+		return nil, errors.New("no encryption header")
+	}
+	if v := data[4]; v != encryptionVersion {
+		return nil, fmt.Errorf("unsupported encryption version %d", v)
+	}
+	h := &encryptionHeader{
+		chunkSize: binary.BigEndian.Uint32(data[5:9]),
+		size:      binary.BigEndian.Uint64(data[9:17]),
+	}
+	copy(h.noncePrefix[:], data[17:21])
+	keyLen := int(binary.BigEndian.Uint16(data[21:23]))
+	if len(data) < encryptionHeaderSize+keyLen {
+		return nil, errors.New("truncated encryption header")
+	}
+	if h.chunkSize == 0 {
+		return nil, errors.New("zero chunk size")
+	}
+	h.wrappedKey = data[encryptionHeaderSize : encryptionHeaderSize+keyLen]
+	h.raw = data[:encryptionHeaderSize+keyLen]
+	return h, nil
+}
+
+func (h *encryptionHeader) chunks() uint64 {
+	return (h.size + uint64(h.chunkSize) - 1) / uint64(h.chunkSize)
+}
+
+func (h *encryptionHeader) nonce(chunk uint64) []byte {
+	nonce := make([]byte, 0, 12)
+	nonce = append(nonce, h.noncePrefix[:]...)
+	return binary.BigEndian.AppendUint64(nonce, chunk)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != dataKeySize {
+		return nil, fmt.Errorf("data key must be %d bytes, got %d", dataKeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptFile - encrypts plain segment in place: writes encrypted copy next to it and renames it over the original
+func EncryptFile(ctx context.Context, p KeyProvider, path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	stat, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	if encrypted, err := IsEncryptedFile(path); err != nil {
+		return err
+	} else if encrypted {
+		return fmt.Errorf("%s: already encrypted", path)
+	}
+
+	key, wrapped, err := p.GenerateDataKey(ctx)
+	if err != nil {
+		return fmt.Errorf("generating data key: %w", err)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+	h := &encryptionHeader{chunkSize: encryptionChunkSize, size: uint64(stat.Size()), wrappedKey: wrapped}
+	if _, err = rand.Read(h.noncePrefix[:]); err != nil {
+		return err
+	}
+
+	tmpPath := path + ".enc.tmp"
+	dst, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+	defer os.Remove(tmpPath)
+	w := bufio.NewWriterSize(dst, 2*encryptionChunkSize)
+	if _, err = w.Write(h.encode()); err != nil {
+		return err
+	}
+	r := bufio.NewReaderSize(src, encryptionChunkSize)
+	plain := make([]byte, h.chunkSize)
+	sealed := make([]byte, 0, int(h.chunkSize)+aead.Overhead())
+	for chunk := uint64(0); chunk < h.chunks(); chunk++ {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		n := min(uint64(h.chunkSize), h.size-chunk*uint64(h.chunkSize))
+		if _, err = io.ReadFull(r, plain[:n]); err != nil {
+			return fmt.Errorf("reading %s: %w", path, err)
+		}
+		sealed = aead.Seal(sealed[:0], h.nonce(chunk), plain[:n], h.raw)
+		if _, err = w.Write(sealed); err != nil {
+			return err
+		}
+	}
+	if err = w.Flush(); err != nil {
+		return err
+	}
+	if err = dst.Sync(); err != nil {
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// segmentDecryptor - plain data of encrypted segment in anonymous memory, a chunk is decrypted when it's accessed
+// first. Untouched pages of anonymous memory are not allocated, so only the chunks which were read take memory.
+type segmentDecryptor struct {
+	h           *encryptionHeader
+	aead        cipher.AEAD
+	sealed      []byte // chunks of the encrypted file
+	plain       []byte
+	plainHandle *[mmap.MaxMapSize]byte
+	decrypted   []atomic.Bool // by chunk
+	lock        sync.Mutex    // decryption of chunks
+}
+
+func newSegmentDecryptor(ctx context.Context, p KeyProvider, data []byte) (*segmentDecryptor, error) {
+	if p == nil {
+		return nil, ErrNoKeyProvider
+	}
+	h, err := decodeEncryptionHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	key, err := p.DecryptDataKey(ctx, h.wrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting data key: %w", err)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	chunks := h.chunks()
+	sealed := data[len(h.raw):]
+	if uint64(len(sealed)) != h.size+chunks*uint64(aead.Overhead()) {
+		return nil, fmt.Errorf("encrypted size %d doesn't match plain size %d", len(sealed), h.size)
+	}
+	if h.size < compressedMinSize {
+		return nil, fmt.Errorf("invalid plain size %d", h.size)
+	}
+	plain, plainHandle, err := mmap.MmapAnon(int(h.size))
+	if err != nil {
+		return nil, err
+	}
+	return &segmentDecryptor{
+		h:           h,
+		aead:        aead,
+		sealed:      sealed,
+		plain:       plain,
+		plainHandle: plainHandle,
+		decrypted:   make([]atomic.Bool, chunks),
+	}, nil
+}
+
+// decrypt - plain[from:to] can be read
+func (s *segmentDecryptor) decrypt(from, to uint64) error {
+	to = min(to, uint64(len(s.plain)))
+	if from >= to {
+		return nil
+	}
+	chunkSize := uint64(s.h.chunkSize)
+	for chunk := from / chunkSize; chunk <= (to-1)/chunkSize; chunk++ {
+		if s.decrypted[chunk].Load() {
+			continue
+		}
+		if err := s.decryptChunk(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *segmentDecryptor) decryptChunk(chunk uint64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.decrypted[chunk].Load() {
+		return nil
+	}
+	chunkSize := uint64(s.h.chunkSize)
+	sealedChunkSize := chunkSize + uint64(s.aead.Overhead())
+	sealed := s.sealed[chunk*sealedChunkSize : min((chunk+1)*sealedChunkSize, uint64(len(s.sealed)))]
+	if _, err := s.aead.Open(s.plain[chunk*chunkSize:chunk*chunkSize], s.h.nonce(chunk), sealed, s.h.raw); err != nil {
+		return fmt.Errorf("chunk %d: %w", chunk, err)
+	}
+	s.decrypted[chunk].Store(true)
+	return nil
+}
+
+// ensure - like decrypt, but panics: getters don't return errors, and a chunk fails only if the file is corrupted
+func (s *segmentDecryptor) ensure(from, to uint64) {
+	if err := s.decrypt(from, to); err != nil {
+		panic(err)
+	}
+}
+
+func (s *segmentDecryptor) close() error {
+	return mmap.Munmap(s.plain, s.plainHandle)
+}
+
+// staticKeyProvider - master key is known to the node, for operators without KMS
+type staticKeyProvider struct {
+	aead cipher.AEAD
+}
+
+// NewStaticKeyProvider - provider wrapping data keys by 32-byte master key with AES-256-GCM
+func NewStaticKeyProvider(masterKey []byte) (KeyProvider, error) {
+	aead, err := newAEAD(masterKey)
+	if err != nil {
+		return nil, fmt.Errorf("master key: %w", err)
+	}
+	return &staticKeyProvider{aead: aead}, nil
+}
+
+// NewStaticKeyProviderFromFile - master key is hex-encoded in the file
+func NewStaticKeyProviderFromFile(path string) (KeyProvider, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	masterKey, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(string(content)), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("master key file %s: %w", path, err)
+	}
+	return NewStaticKeyProvider(masterKey)
+}
+
+func (p *staticKeyProvider) GenerateDataKey(ctx context.Context) (key, wrapped []byte, err error) {
+	key = make([]byte, dataKeySize)
+	if _, err = rand.Read(key); err != nil {
+		return nil, nil, err
+	}
+	nonce := make([]byte, p.aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+	return key, p.aead.Seal(nonce, nonce, key, nil), nil
+}
+
+func (p *staticKeyProvider) DecryptDataKey(ctx context.Context, wrapped []byte) ([]byte, error) {
+	if len(wrapped) < p.aead.NonceSize() {
+		return nil, errors.New("wrapped key is too short")
+	}
+	nonce, sealed := wrapped[:p.aead.NonceSize()], wrapped[p.aead.NonceSize():]
+	return p.aead.Open(nil, nonce, sealed, nil)
+}
@@ -0,0 +1,161 @@
+/*
+   Copyright 2024 Erigon contributors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package seg
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/log/v3"
+)
+
+func newTestKeyProvider(t *testing.T) KeyProvider {
+	t.Helper()
+	masterKey := make([]byte, dataKeySize)
+	_, err := rand.Read(masterKey)
+	require.NoError(t, err)
+	p, err := NewStaticKeyProvider(masterKey)
+	require.NoError(t, err)
+	return p
+}
+
+func prepareEncryptedLoremDict(t *testing.T, p KeyProvider) string {
+	t.Helper()
+	tmpDir := t.TempDir()
+	file := filepath.Join(tmpDir, "compressed")
+	c, err := NewCompressor(WithKeyProvider(context.Background(), p), t.Name(), file, tmpDir, 1, 2, log.LvlDebug, log.New())
+	require.NoError(t, err)
+	defer c.Close()
+	for k, w := range loremStrings {
+		require.NoError(t, c.AddWord([]byte(fmt.Sprintf("%s %d", w, k))))
+	}
+	require.NoError(t, c.Compress())
+	return file
+}
+
+func TestEncryptedSegment(t *testing.T) {
+	p := newTestKeyProvider(t)
+	file := prepareEncryptedLoremDict(t, p)
+	encrypted, err := IsEncryptedFile(file)
+	require.NoError(t, err)
+	require.True(t, encrypted)
+
+	d, err := NewDecompressorWithKeyProvider(file, p)
+	require.NoError(t, err)
+	defer d.Close()
+	g := d.MakeGetter()
+	for k, w := range loremStrings {
+		require.True(t, g.HasNext())
+		word, _ := g.Next(nil)
+		require.Equal(t, fmt.Sprintf("%s %d", w, k), string(word))
+	}
+	require.False(t, g.HasNext())
+
+	_, err = NewDecompressor(file)
+	require.ErrorIs(t, err, ErrNoKeyProvider)
+	_, err = NewDecompressorWithKeyProvider(file, newTestKeyProvider(t))
+	require.Error(t, err)
+}
+
+func TestEncryptFile(t *testing.T) {
+	ctx := context.Background()
+	p := newTestKeyProvider(t)
+	file := filepath.Join(t.TempDir(), "data")
+	plain := make([]byte, 2*encryptionChunkSize+100) // last chunk isn't full
+	_, err := rand.Read(plain)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(file, plain, 0644))
+
+	require.NoError(t, EncryptFile(ctx, p, file))
+	require.Error(t, EncryptFile(ctx, p, file))
+	encrypted, err := os.ReadFile(file)
+	require.NoError(t, err)
+	require.False(t, bytes.Contains(encrypted, plain[:64]))
+
+	s, err := newSegmentDecryptor(ctx, p, encrypted)
+	require.NoError(t, err)
+	require.NoError(t, s.decrypt(0, uint64(len(plain))))
+	require.Equal(t, plain, s.plain)
+	require.NoError(t, s.close())
+
+	// any modification of header or chunks is detected
+	for _, pos := range []int{10, len(encrypted) - encryptionChunkSize, len(encrypted) - 1} {
+		tampered := bytes.Clone(encrypted)
+		tampered[pos] ^= 1
+		s, err = newSegmentDecryptor(ctx, p, tampered)
+		if err == nil {
+			err = s.decrypt(0, uint64(len(plain)))
+			require.NoError(t, s.close())
+		}
+		require.Error(t, err, pos)
+	}
+	_, err = newSegmentDecryptor(ctx, p, encrypted[:len(encrypted)-1])
+	require.Error(t, err)
+}
+
+func TestDecryptOnRead(t *testing.T) {
+	ctx := context.Background()
+	p := newTestKeyProvider(t)
+	file := filepath.Join(t.TempDir(), "data")
+	plain := make([]byte, 3*encryptionChunkSize)
+	_, err := rand.Read(plain)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(file, plain, 0644))
+	require.NoError(t, EncryptFile(ctx, p, file))
+	encrypted, err := os.ReadFile(file)
+	require.NoError(t, err)
+
+	s, err := newSegmentDecryptor(ctx, p, encrypted)
+	require.NoError(t, err)
+	defer s.close()
+	for i := range s.decrypted {
+		require.False(t, s.decrypted[i].Load())
+	}
+
+	// only chunk of the range is decrypted
+	from := uint64(encryptionChunkSize + 10)
+	require.NoError(t, s.decrypt(from, from+100))
+	require.False(t, s.decrypted[0].Load())
+	require.True(t, s.decrypted[1].Load())
+	require.False(t, s.decrypted[2].Load())
+	require.Equal(t, plain[from:from+100], s.plain[from:from+100])
+
+	// range crossing chunks boundary
+	require.NoError(t, s.decrypt(2*encryptionChunkSize-1, 2*encryptionChunkSize+1))
+	require.True(t, s.decrypted[2].Load())
+	require.False(t, s.decrypted[0].Load())
+}
+
+func TestCompressorNoEncryption(t *testing.T) {
+	tmpDir := t.TempDir()
+	file := filepath.Join(tmpDir, "compressed")
+	c, err := NewCompressor(context.Background(), t.Name(), file, tmpDir, 100, 1, log.LvlDebug, log.New())
+	require.NoError(t, err)
+	defer c.Close()
+	require.NoError(t, c.AddWord([]byte("word")))
+	require.NoError(t, c.Compress())
+	encrypted, err := IsEncryptedFile(file)
+	require.NoError(t, err)
+	require.False(t, encrypted)
+}
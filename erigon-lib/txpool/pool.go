@@ -1861,6 +1861,9 @@ func (p *TxPool) promote(pendingBaseFee uint64, pendingBlobFee uint64, announcem
 // by the peer.
 const txMaxBroadcastSize = 4 * 1024
 
+// shutdownFlushTimeout bounds the last flush of the pool, so it can't hold the node shutdown
+const shutdownFlushTimeout = 10 * time.Second
+
 // MainLoop - does:
 // send pending byHash to p2p:
 //   - new byHash
@@ -1889,7 +1892,7 @@ func MainLoop(ctx context.Context, db kv.RwDB, p *TxPool, newTxs chan types.Anno
 	for {
 		select {
 		case <-ctx.Done():
-			_, _ = p.flush(ctx, db)
+			p.flushOnShutdown(db)
 			return
 		case <-logEvery.C:
 			p.logStats()
@@ -2042,6 +2045,24 @@ func MainLoop(ctx context.Context, db kv.RwDB, p *TxPool, newTxs chan types.Anno
 	}
 }
 
+// flushOnShutdown persists the pool after the main loop ctx is cancelled, so a restart doesn't lose the
+// transactions received since the last commit. With NoGossip (sequencer) nothing would send them again.
+func (p *TxPool) flushOnShutdown(db kv.RwDB) {
+	if db == nil || !p.Started() {
+		return
+	}
+	// ctx of the loop is done already and db refuses to begin a tx with it
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownFlushTimeout)
+	defer cancel()
+	written, err := p.flush(ctx, db)
+	if err != nil {
+		p.logger.Warn("[txpool] flush on shutdown", "err", err)
+		return
+	}
+	pending, baseFee, queued := p.CountContent()
+	p.logger.Info("[txpool] Flushed on shutdown", "pending", pending, "baseFee", baseFee, "queued", queued, "written_kb", written/1024)
+}
+
 func (p *TxPool) flushNoFsync(ctx context.Context, db kv.RwDB) (written uint64, err error) {
 	p.lock.Lock()
 	defer p.lock.Unlock()
@@ -2222,7 +2243,7 @@ func (p *TxPool) fromDB(ctx context.Context, tx kv.Tx, coreTx kv.Tx) error {
 	parseCtx := types.NewTxParseContext(p.chainID)
 	parseCtx.WithSender(false)
 
-	i := 0
+	i, dropped := 0, 0
 	it, err = tx.Range(kv.PoolTransaction, nil, nil)
 	if err != nil {
 		return err
@@ -2249,8 +2270,12 @@ func (p *TxPool) fromDB(ctx context.Context, tx kv.Tx, coreTx kv.Tx) error {
 
 		isLocalTx := p.isLocalLRU.Contains(string(k))
 
+		// the state moved on while the pool was down: drop what became invalid, keep the rest
 		if reason := p.validateTx(txn, isLocalTx, cacheView); reason != txpoolcfg.NotSet && reason != txpoolcfg.Success {
-			return nil // TODO: Clarify - if one of the txs has the wrong reason, no pooled txs!
+			p.logger.Debug("[txpool] fromDB: drop invalid txn", "idHash", fmt.Sprintf("%x", txn.IDHash), "reason", reason)
+			p.deletedTxs = append(p.deletedTxs, newMetaTx(txn, isLocalTx, 0)) // removed from db on next flush
+			dropped++
+			continue
 		}
 		txs.Resize(uint(i + 1))
 		txs.Txs[i] = txn
@@ -2309,6 +2334,9 @@ func (p *TxPool) fromDB(ctx context.Context, tx kv.Tx, coreTx kv.Tx) error {
 	p.pendingBaseFee.Store(pendingBaseFee)
 	p.pendingBlobFee.Store(pendingBlobFee)
 	p.blockGasLimit.Store(blockGasLimit)
+	if i > 0 || dropped > 0 {
+		p.logger.Info("[txpool] Restored from db", "txs", i, "dropped", dropped)
+	}
 	return nil
 }
 
@@ -42,6 +42,7 @@ import (
 	"github.com/erigontech/erigon-lib/kv"
 	"github.com/erigontech/erigon-lib/kv/kvcache"
 	"github.com/erigontech/erigon-lib/kv/memdb"
+	"github.com/erigontech/erigon-lib/rlp"
 	"github.com/erigontech/erigon-lib/txpool/txpoolcfg"
 	"github.com/erigontech/erigon-lib/types"
 )
@@ -1258,3 +1259,78 @@ func TestGasLimitChanged(t *testing.T) {
 
 	assert.Zero(mtx.subPool&NotTooMuchGas, "Should now have block space (again) for the tx")
 }
+
+// legacyTxRlp - unsigned legacy txn of chain 1, parsable without sender recovery
+func legacyTxRlp(nonce, gasPrice, gas uint64) []byte {
+	to := make([]byte, 20)
+	dataLen := rlp.U64Len(nonce) + rlp.U64Len(gasPrice) + rlp.U64Len(gas) + rlp.StringLen(to) +
+		rlp.U64Len(0) + rlp.StringLen(nil) + rlp.U64Len(37) + rlp.U64Len(1) + rlp.U64Len(1)
+	buf := make([]byte, rlp.ListPrefixLen(dataLen)+dataLen)
+	p := rlp.EncodeListPrefix(dataLen, buf)
+	p += rlp.EncodeU64(nonce, buf[p:])
+	p += rlp.EncodeU64(gasPrice, buf[p:])
+	p += rlp.EncodeU64(gas, buf[p:])
+	p += rlp.EncodeString(to, buf[p:])
+	p += rlp.EncodeU64(0, buf[p:])      // value
+	p += rlp.EncodeString(nil, buf[p:]) // data
+	p += rlp.EncodeU64(37, buf[p:])     // v
+	p += rlp.EncodeU64(1, buf[p:])      // r
+	rlp.EncodeU64(1, buf[p:])           // s
+	return buf
+}
+
+func TestRestoreFromDBAtNoGossip(t *testing.T) {
+	require := require.New(t)
+	ch := make(chan types.Announcements, 100)
+	db, coreDB := memdb.NewTestPoolDB(t), memdb.NewTestDB(t)
+	ctx := context.Background()
+
+	var funded, empty [20]byte
+	funded[0], empty[0] = 1, 2
+	require.NoError(coreDB.Update(ctx, func(tx kv.RwTx) error {
+		v := make([]byte, types.EncodeSenderLengthForStorage(2, *uint256.NewInt(common.Ether)))
+		types.EncodeSender(2, *uint256.NewInt(common.Ether), v)
+		return tx.Put(kv.PlainState, funded[:], v)
+	}))
+
+	// txns persisted by the previous run: the sender of the first one has no funds anymore
+	parseCtx := types.NewTxParseContext(*u256.N1)
+	parseCtx.WithSender(false)
+	var hashes [][]byte
+	require.NoError(db.Update(ctx, func(tx kv.RwTx) error {
+		for i, sender := range [][20]byte{empty, funded} {
+			txRlp := legacyTxRlp(uint64(2+i), 1, 21_000)
+			var slot types.TxSlot
+			if _, err := parseCtx.ParseTransaction(txRlp, 0, &slot, nil, false, true, nil); err != nil {
+				return err
+			}
+			hashes = append(hashes, slot.IDHash[:])
+			if err := tx.Put(kv.PoolTransaction, slot.IDHash[:], append(sender[:], txRlp...)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	cfg := txpoolcfg.DefaultConfig
+	cfg.NoGossip = true
+	pool, err := New(ch, coreDB, cfg, kvcache.NewDummy(), *u256.N1, nil, nil, nil, nil, fixedgas.DefaultMaxBlobsPerBlock, nil, log.New())
+	require.NoError(err)
+	require.NoError(pool.Start(ctx, db))
+
+	// the invalid txn doesn't discard the rest
+	require.Len(pool.byHash, 1)
+	require.Contains(pool.byHash, string(hashes[1]))
+
+	_, err = pool.flush(ctx, db)
+	require.NoError(err)
+	require.NoError(db.View(ctx, func(tx kv.Tx) error {
+		has, err := tx.Has(kv.PoolTransaction, hashes[0])
+		require.NoError(err)
+		require.False(has, "dropped txn must be removed from db")
+		has, err = tx.Has(kv.PoolTransaction, hashes[1])
+		require.NoError(err)
+		require.True(has)
+		return nil
+	}))
+}
@@ -59,6 +59,7 @@ import (
 	"github.com/erigontech/erigon-lib/downloader/downloadergrpc"
 	"github.com/erigontech/erigon-lib/downloader/snaptype"
 	protodownloader "github.com/erigontech/erigon-lib/gointerfaces/downloader"
+	"github.com/erigontech/erigon-lib/gointerfaces/execution"
 	"github.com/erigontech/erigon-lib/gointerfaces/grpcutil"
 	"github.com/erigontech/erigon-lib/gointerfaces/remote"
 	rpcsentinel "github.com/erigontech/erigon-lib/gointerfaces/sentinel"
@@ -95,15 +96,19 @@ import (
 	"github.com/erigontech/erigon/core/types"
 	"github.com/erigontech/erigon/core/vm"
 	"github.com/erigontech/erigon/crypto"
+	"github.com/erigontech/erigon/dbbackup"
 	"github.com/erigontech/erigon/eth/ethconfig"
 	"github.com/erigontech/erigon/eth/ethconsensusconfig"
 	"github.com/erigontech/erigon/eth/ethutils"
+	"github.com/erigontech/erigon/eth/gasmeter"
 	"github.com/erigontech/erigon/eth/protocols/eth"
 	"github.com/erigontech/erigon/eth/stagedsync"
 	"github.com/erigontech/erigon/eth/stagedsync/stages"
 	"github.com/erigontech/erigon/ethdb/privateapi"
 	"github.com/erigontech/erigon/ethdb/prune"
 	"github.com/erigontech/erigon/ethstats"
+	"github.com/erigontech/erigon/exporter"
+	"github.com/erigontech/erigon/l1source"
 	"github.com/erigontech/erigon/node"
 	"github.com/erigontech/erigon/p2p"
 	"github.com/erigontech/erigon/p2p/enode"
@@ -115,11 +120,13 @@ import (
 	"github.com/erigontech/erigon/polygon/bor/valset"
 	"github.com/erigontech/erigon/polygon/heimdall"
 	polygonsync "github.com/erigontech/erigon/polygon/sync"
+	"github.com/erigontech/erigon/rollupstatus"
 	"github.com/erigontech/erigon/rpc"
 	"github.com/erigontech/erigon/turbo/builder"
 	"github.com/erigontech/erigon/turbo/engineapi"
 	"github.com/erigontech/erigon/turbo/engineapi/engine_block_downloader"
 	"github.com/erigontech/erigon/turbo/engineapi/engine_helpers"
+	"github.com/erigontech/erigon/turbo/engineapi/sequencerlock"
 	"github.com/erigontech/erigon/turbo/execution/eth1"
 	"github.com/erigontech/erigon/turbo/execution/eth1/eth1_chain_reader.go"
 	"github.com/erigontech/erigon/turbo/jsonrpc"
@@ -144,7 +151,8 @@ type Ethereum struct {
 	chainDB    kv.RwDB
 	privateAPI *grpc.Server
 
-	engine consensus.Engine
+	engine      consensus.Engine
+	chainReader *stagedsync.SharedChainReader // of engine verification, forkchoice and mining
 
 	gasPrice  *uint256.Int
 	etherbase libcommon.Address
@@ -164,8 +172,8 @@ type Ethereum struct {
 	miningRPC          txpoolproto.MiningServer
 	stateChangesClient txpool.StateChangesClient
 
-	seqRPCService        *rpc.Client
-	historicalRPCService *rpc.Client
+	rollupClients *jsonrpc.RollupClients
+	l1Source      *l1source.Source
 
 	miningSealingQuit chan struct{}
 	pendingBlocks     chan *types.Block
@@ -186,9 +194,11 @@ type Ethereum struct {
 
 	notifications      *shards.Notifications
 	unsubscribeEthstat func()
+	unsubscribeExport  func()
 
 	waitForStageLoopStop chan struct{}
 	waitForMiningStop    chan struct{}
+	waitForTxPoolStop    chan struct{}
 
 	txPoolDB                kv.RwDB
 	txPool                  *txpool.TxPool
@@ -262,7 +272,10 @@ func New(ctx context.Context, stack *node.Node, config *ethconfig.Config, logger
 		}
 
 		config.HistoryV3, err = kvcfg.HistoryV3.WriteOnce(tx, config.HistoryV3)
-		return err
+		if err != nil {
+			return err
+		}
+		return rawdb.SetupReceiptsCompression(tx, config.ReceiptsCompression, logger)
 	}); err != nil {
 		return nil, err
 	}
@@ -316,14 +329,19 @@ func New(ctx context.Context, stack *node.Node, config *ethconfig.Config, logger
 			OverridePragueTime:           config.OverridePragueTime,
 		}
 		chainConfig, genesis, genesisErr = core.WriteGenesisBlock(tx, genesisSpec, overrides, tmpdir, logger)
-		if _, ok := genesisErr.(*chain.ConfigCompatError); genesisErr != nil && !ok {
+		if compatErr, ok := genesisErr.(*chain.ConfigCompatError); genesisErr != nil && !ok {
 			return genesisErr
+		} else if ok {
+			logger.Warn("Chain config is incompatible with the stored one, it is not updated", "err", compatErr)
 		}
 
 		return nil
 	}); err != nil {
 		panic(err)
 	}
+	if stack.Config().WithoutBor && chainConfig.Bor != nil {
+		return nil, errors.New("--bor.disable on a bor chain")
+	}
 	backend.chainConfig = chainConfig
 	backend.genesisBlock = genesis
 	backend.genesisHash = genesis.Hash()
@@ -578,6 +596,10 @@ func New(ctx context.Context, stack *node.Node, config *ethconfig.Config, logger
 	}
 
 	backend.engine = ethconsensusconfig.CreateConsensusEngine(ctx, stack.Config(), chainConfig, consensusConfig, config.Miner.Notify, config.Miner.Noverify, heimdallClient, config.WithoutHeimdall, blockReader, false /* readonly */, logger)
+	backend.chainReader = stagedsync.NewSharedChainReader(chainConfig, blockReader, logger)
+	if config.Sync.HotContractsWindow > 0 {
+		gasmeter.EnableHotContracts(config.Sync.HotContractsWindow)
+	}
 
 	inMemoryExecution := func(txc wrap.TxContainer, header *types.Header, body *types.RawBody, unwindPoint uint64, headersChain []*types.Header, bodiesChain []*types.RawBody,
 		notifications *shards.Notifications) error {
@@ -585,8 +607,8 @@ func New(ctx context.Context, stack *node.Node, config *ethconfig.Config, logger
 		terseLogger.SetHandler(log.LvlFilterHandler(log.LvlWarn, log.StderrHandler))
 		// Needs its own notifications to not update RPC daemon and txpool about pending blocks
 		stateSync := stages2.NewInMemoryExecution(backend.sentryCtx, backend.chainDB, config, backend.sentriesClient,
-			dirs, notifications, blockReader, blockWriter, backend.agg, backend.silkworm, terseLogger)
-		chainReader := stagedsync.NewChainReaderImpl(chainConfig, txc.Tx, blockReader, logger)
+			dirs, notifications, blockReader, backend.chainReader, blockWriter, backend.agg, backend.silkworm, terseLogger)
+		chainReader := backend.chainReader.Tx(txc.Tx)
 		// We start the mining step
 		if err := stages2.StateStep(ctx, chainReader, backend.engine, txc, stateSync, header, body, unwindPoint, headersChain, bodiesChain, config.HistoryV3); err != nil {
 			logger.Warn("Could not validate block", "err", err)
@@ -602,6 +624,7 @@ func New(ctx context.Context, stack *node.Node, config *ethconfig.Config, logger
 		return nil
 	}
 	backend.forkValidator = engine_helpers.NewForkValidator(ctx, currentBlockNumber, inMemoryExecution, tmpdir, backend.blockReader)
+	backend.forkValidator.SetMemoryLimit(config.Sync.ForkValidatorMemoryLimit)
 
 	statusDataProvider := sentry.NewStatusDataProvider(
 		chainKv,
@@ -650,24 +673,14 @@ func New(ctx context.Context, stack *node.Node, config *ethconfig.Config, logger
 		return nil, err
 	}
 
-	// Setup sequencer and hsistorical RPC relay services
-	if config.RollupSequencerHTTP != "" {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		client, err := rpc.DialContext(ctx, config.RollupSequencerHTTP, logger)
-		cancel()
-		if err != nil {
-			return nil, err
-		}
-		backend.seqRPCService = client
+	// Setup sequencer and historical RPC relay services
+	if backend.rollupClients, err = jsonrpc.DialRollupClients(config.RollupSequencerHTTP, config.RollupHistoricalRPC, config.RollupHistoricalRPCTimeout, config.RollupArchiveRPC, logger); err != nil {
+		return nil, err
 	}
-	if config.RollupHistoricalRPC != "" {
-		ctx, cancel := context.WithTimeout(context.Background(), config.RollupHistoricalRPCTimeout)
-		client, err := rpc.DialContext(ctx, config.RollupHistoricalRPC, logger)
-		cancel()
-		if err != nil {
+	if config.RollupL1RPC != "" {
+		if backend.l1Source, err = l1source.New(context.Background(), config.RollupL1RPC, config.RollupL1BeaconRPC, config.RollupL1CacheSize, logger); err != nil {
 			return nil, err
 		}
-		backend.historicalRPCService = client
 	}
 	config.TxPool.NoGossip = config.DisableTxPoolGossip
 	var miningRPC txpoolproto.MiningServer
@@ -714,12 +727,12 @@ func New(ctx context.Context, stack *node.Node, config *ethconfig.Config, logger
 	mining := stagedsync.New(
 		config.Sync,
 		stagedsync.MiningStages(backend.sentryCtx,
-			stagedsync.StageMiningCreateBlockCfg(backend.chainDB, miner, *backend.chainConfig, backend.engine, backend.txPoolDB, nil, tmpdir, backend.blockReader),
+			stagedsync.StageMiningCreateBlockCfg(backend.chainDB, miner, *backend.chainConfig, backend.engine, backend.txPoolDB, nil, tmpdir, backend.blockReader, backend.chainReader),
 			stagedsync.StageBorHeimdallCfg(backend.chainDB, snapDb, miner, *backend.chainConfig, heimdallClient, backend.blockReader, nil, nil, nil, recents, signatures, false, nil),
-			stagedsync.StageMiningExecCfg(backend.chainDB, miner, backend.notifications.Events, *backend.chainConfig, backend.engine, &vm.Config{}, tmpdir, nil, 0, backend.txPool, backend.txPoolDB, blockReader),
+			stagedsync.StageMiningExecCfg(backend.chainDB, miner, backend.notifications.Events, *backend.chainConfig, backend.engine, &vm.Config{}, tmpdir, nil, 0, backend.txPool, backend.txPoolDB, blockReader, backend.chainReader),
 			stagedsync.StageHashStateCfg(backend.chainDB, dirs, config.HistoryV3),
 			stagedsync.StageTrieCfg(backend.chainDB, false, true, true, tmpdir, blockReader, nil, config.HistoryV3, backend.agg),
-			stagedsync.StageMiningFinishCfg(backend.chainDB, *backend.chainConfig, backend.engine, miner, backend.miningSealingQuit, backend.blockReader, latestBlockBuiltStore),
+			stagedsync.StageMiningFinishCfg(backend.chainDB, *backend.chainConfig, backend.engine, miner, backend.miningSealingQuit, backend.blockReader, backend.chainReader, latestBlockBuiltStore),
 		), stagedsync.MiningUnwindOrder, stagedsync.MiningPruneOrder,
 		logger)
 
@@ -729,29 +742,24 @@ func New(ctx context.Context, stack *node.Node, config *ethconfig.Config, logger
 	}
 
 	// proof-of-stake mining
-	assembleBlockPOS := func(param *core.BlockBuilderParameters, interrupt *int32) (*types.BlockWithReceipts, error) {
-		miningStatePos := stagedsync.NewMiningState(&config.Miner)
-		miningStatePos.MiningConfig.Etherbase = param.SuggestedFeeRecipient
-		proposingSync := stagedsync.New(
-			config.Sync,
-			stagedsync.MiningStages(backend.sentryCtx,
-				stagedsync.StageMiningCreateBlockCfg(backend.chainDB, miningStatePos, *backend.chainConfig, backend.engine, backend.txPoolDB, param, tmpdir, backend.blockReader),
-				stagedsync.StageBorHeimdallCfg(backend.chainDB, snapDb, miningStatePos, *backend.chainConfig, heimdallClient, backend.blockReader, nil, nil, nil, recents, signatures, false, nil),
-				stagedsync.StageMiningExecCfg(backend.chainDB, miningStatePos, backend.notifications.Events, *backend.chainConfig, backend.engine, &vm.Config{}, tmpdir, interrupt, param.PayloadId, backend.txPool, backend.txPoolDB, blockReader),
-				stagedsync.StageHashStateCfg(backend.chainDB, dirs, config.HistoryV3),
-				stagedsync.StageTrieCfg(backend.chainDB, false, true, true, tmpdir, blockReader, nil, config.HistoryV3, backend.agg),
-				stagedsync.StageMiningFinishCfg(backend.chainDB, *backend.chainConfig, backend.engine, miningStatePos, backend.miningSealingQuit, backend.blockReader, latestBlockBuiltStore),
-			), stagedsync.MiningUnwindOrder, stagedsync.MiningPruneOrder,
-			logger)
-		// We start the mining step
-		log.Debug("Starting assembleBlockPOS mining step", "payloadId", param.PayloadId)
-		if err := stages2.MiningStep(ctx, backend.chainDB, proposingSync, tmpdir, logger); err != nil {
-			return nil, err
-		}
-		log.Debug("Finished assembleBlockPOS mining step", "payloadId", param.PayloadId)
-		block := <-miningStatePos.MiningResultCh
-		return block, nil
-	}
+	assembleBlockPOS := stages2.NewBlockBuilderPOS(backend.sentryCtx, stages2.BlockBuilderPOSCfg{
+		DB:               backend.chainDB,
+		ChainConfig:      backend.chainConfig,
+		Engine:           backend.engine,
+		BlockReader:      blockReader,
+		ChainReader:      backend.chainReader,
+		Miner:            config.Miner,
+		Sync:             config.Sync,
+		Dirs:             dirs,
+		HistoryV3:        config.HistoryV3,
+		Agg:              backend.agg,
+		TxPool:           backend.txPool,
+		TxPoolDB:         backend.txPoolDB,
+		Notifier:         backend.notifications.Events,
+		SealingQuit:      backend.miningSealingQuit,
+		LatestBlockBuilt: latestBlockBuiltStore,
+		Heimdall:         heimdallClient,
+	}, logger)
 
 	// Initialize ethbackend
 	ethBackendRPC := privateapi.NewEthBackendServer(ctx, backend, backend.chainDB, backend.notifications.Events, blockReader, logger, latestBlockBuiltStore)
@@ -799,14 +807,19 @@ func New(ctx context.Context, stack *node.Node, config *ethconfig.Config, logger
 		if casted, ok := backend.txPoolGrpcServer.(*txpool.GrpcServer); ok {
 			newTxsBroadcaster = casted.NewSlotsStreams
 		}
-		go txpool.MainLoop(backend.sentryCtx,
-			backend.txPoolDB, backend.txPool, backend.newTxs, backend.txPoolSend, newTxsBroadcaster,
-			func() {
-				select {
-				case backend.notifyMiningAboutNewTxs <- struct{}{}:
-				default:
-				}
-			})
+		// the pool is flushed when the loop exits, Stop waits for it before closing the pool db
+		backend.waitForTxPoolStop = make(chan struct{})
+		go func() {
+			defer close(backend.waitForTxPoolStop)
+			txpool.MainLoop(backend.sentryCtx,
+				backend.txPoolDB, backend.txPool, backend.newTxs, backend.txPoolSend, newTxsBroadcaster,
+				func() {
+					select {
+					case backend.notifyMiningAboutNewTxs <- struct{}{}:
+					default:
+					}
+				})
+		}()
 	}
 
 	go func() {
@@ -854,7 +867,7 @@ func New(ctx context.Context, stack *node.Node, config *ethconfig.Config, logger
 	backend.ethBackendRPC, backend.miningRPC, backend.stateChangesClient = ethBackendRPC, miningRPC, stateDiffClient
 
 	backend.syncStages = stages2.NewDefaultStages(backend.sentryCtx, backend.chainDB, snapDb, p2pConfig, config, backend.sentriesClient, backend.notifications, backend.downloaderClient,
-		blockReader, blockRetire, backend.agg, backend.silkworm, backend.forkValidator, heimdallClient, recents, signatures, logger)
+		blockReader, backend.chainReader, blockRetire, backend.agg, backend.silkworm, backend.forkValidator, heimdallClient, recents, signatures, logger)
 	backend.syncUnwindOrder = stagedsync.DefaultUnwindOrder
 	backend.syncPruneOrder = stagedsync.DefaultPruneOrder
 	backend.stagedSync = stagedsync.New(config.Sync, backend.syncStages, backend.syncUnwindOrder, backend.syncPruneOrder, logger)
@@ -877,10 +890,35 @@ func New(ctx context.Context, stack *node.Node, config *ethconfig.Config, logger
 	}
 
 	checkStateRoot := true
-	pipelineStages := stages2.NewPipelineStages(ctx, chainKv, config, p2pConfig, backend.sentriesClient, backend.notifications, backend.downloaderClient, blockReader, blockRetire, backend.agg, backend.silkworm, backend.forkValidator, logger, checkStateRoot)
+	pipelineStages := stages2.NewPipelineStages(ctx, chainKv, config, p2pConfig, backend.sentriesClient, backend.notifications, backend.downloaderClient, blockReader, backend.chainReader, blockRetire, backend.agg, backend.silkworm, backend.forkValidator, logger, checkStateRoot)
 	backend.pipelineStagedSync = stagedsync.New(config.Sync, pipelineStages, stagedsync.PipelineUnwindOrder, stagedsync.PipelinePruneOrder, logger)
-	backend.eth1ExecutionServer = eth1.NewEthereumExecutionModule(blockReader, chainKv, backend.pipelineStagedSync, backend.forkValidator, chainConfig, assembleBlockPOS, hook, backend.notifications.Accumulator, backend.notifications.StateChangesConsumer, logger, backend.engine, config.HistoryV3, ctx)
+	payloadHistory := builder.NewPayloadHistory(ctx, chainKv, config.Miner.PayloadHistoryRetention, logger)
+	backend.eth1ExecutionServer = eth1.NewEthereumExecutionModule(blockReader, chainKv, backend.pipelineStagedSync, backend.forkValidator, backend.chainReader, chainConfig, assembleBlockPOS, payloadHistory, hook, backend.notifications.Accumulator, backend.notifications.StateChangesConsumer, logger, backend.engine, config.HistoryV3, config.Forkchoice, ctx)
+	backend.stagedSync.SetSyncPause(backend.eth1ExecutionServer.SyncPause())
+	backend.eth1ExecutionServer.SyncPause().WaitFor(blockRetire.WaitRetireInBackground)
+	backend.eth1ExecutionServer.SetMaxReorgDepth(config.Sync.MaxReorgDepth)
+	if config.Sync.DryRun {
+		backend.stagedSync.SetDryRun(dirs.Tmp)
+		backend.pipelineStagedSync.SetDryRun(dirs.Tmp)
+	}
+	if config.Miner.RemoteBuilder != "" {
+		conn, err := grpcutil.Connect(nil, config.Miner.RemoteBuilder)
+		if err != nil {
+			return nil, fmt.Errorf("could not connect to remote builder: %w", err)
+		}
+		backend.eth1ExecutionServer.SetRemoteBuilder(execution.NewExecutionClient(conn))
+		logger.Info("Payloads are built by remote builder", "addr", config.Miner.RemoteBuilder)
+	}
 	executionRpc := direct.NewExecutionClientDirect(backend.eth1ExecutionServer)
+	var sequencerLock *sequencerlock.Lock
+	if config.SequencerLock.Path != "" {
+		provider, err := sequencerlock.NewFileProvider(config.SequencerLock.Path)
+		if err != nil {
+			return nil, err
+		}
+		sequencerLock = sequencerlock.New(ctx, provider, config.SequencerLock.ID, config.SequencerLock.TTL, logger)
+		stack.RegisterLifecycle(sequencerLock)
+	}
 	engineBackendRPC := engineapi.NewEngineServer(
 		logger,
 		chainConfig,
@@ -893,6 +931,7 @@ func New(ctx context.Context, stack *node.Node, config *ethconfig.Config, logger
 		false,
 		config.Miner.EnabledPOS,
 		config,
+		sequencerLock,
 		stack.Close)
 	backend.engineBackendRPC = engineBackendRPC
 
@@ -1026,8 +1065,57 @@ func (s *Ethereum) Init(stack *node.Node, config *ethconfig.Config, chainConfig
 			return err
 		}
 	}
+	if config.ExportURL != "" {
+		var headCh chan [][]byte
+		headCh, s.unsubscribeExport = s.notifications.Events.AddHeaderSubscription()
+		if err := exporter.New(ctx, stack, chainKv, s.blockReader, s.chainConfig, config.ExportURL, config.ExportTopic, config.ExportResume, headCh, s.logger); err != nil {
+			return err
+		}
+	}
+
+	var l1View *l1source.View
+	if config.RollupL1View {
+		l1View = l1source.NewView(s.l1Source, config.RollupL1ViewContracts, config.RollupL1ViewDepth, config.RollupProbeInterval, s.logger)
+		go l1View.Run(ctx)
+	}
+	var rollupProber *rollupstatus.Prober
+	if config.RollupProbe {
+		if config.RollupOpNodeRPC == "" && config.RollupSequencerHTTP == "" && l1View == nil {
+			return errors.New("--rollup.probe requires --rollup.opnoderpc, --rollup.sequencerhttp or --rollup.l1view")
+		}
+		if rollupProber, err = rollupstatus.New(ctx, stack, chainKv, s.blockReader, config.RollupOpNodeRPC, config.RollupSequencerHTTP, l1View, config.RollupProbeInterval, s.logger); err != nil {
+			return err
+		}
+	}
+
+	var backupScheduler *dbbackup.Scheduler
+	if config.Backup.Dir != "" {
+		var uploader dbbackup.Uploader
+		if config.Backup.Remote != "" {
+			if err := os.MkdirAll(config.Backup.Dir, 0755); err != nil {
+				return fmt.Errorf("backup dir: %w", err)
+			}
+			rclone, err := downloader.NewRCloneClient(s.logger)
+			if err != nil {
+				return fmt.Errorf("backup remote: %w", err)
+			}
+			if uploader, err = rclone.NewSession(ctx, config.Backup.Dir, config.Backup.Remote, nil); err != nil {
+				return fmt.Errorf("backup remote: %w", err)
+			}
+		}
+		if backupScheduler, err = dbbackup.New(ctx, stack, chainKv, kv.ChainDB, config.Backup, uploader, s.logger); err != nil {
+			return err
+		}
+	}
 
-	s.apiList = jsonrpc.APIList(chainKv, ethRpcClient, txPoolRpcClient, miningRpcClient, ff, stateCache, blockReader, s.agg, &httpRpcCfg, s.engine, s.seqRPCService, s.historicalRPCService, s.logger)
+	s.apiList = jsonrpc.APIList(chainKv, ethRpcClient, txPoolRpcClient, miningRpcClient, ff, stateCache, blockReader, s.agg, &httpRpcCfg, s.engine, s.rollupClients.Sequencer, s.rollupClients.Historical, s.rollupClients.Archive, s.logger)
+	if rollupProber != nil {
+		s.apiList = append(s.apiList, rollupProber.APIs()...)
+	}
+	if backupScheduler != nil {
+		s.apiList = append(s.apiList, backupScheduler.APIs()...)
+	}
+	s.apiList = append(s.apiList, s.eth1ExecutionServer.APIs()...)
 
 	if config.SilkwormRpcDaemon && httpRpcCfg.Enabled {
 		interface_log_settings := silkworm.RpcInterfaceLogSettings{
@@ -1061,7 +1149,7 @@ func (s *Ethereum) Init(stack *node.Node, config *ethconfig.Config, chainConfig
 	}
 
 	if chainConfig.Bor == nil {
-		go s.engineBackendRPC.Start(ctx, &httpRpcCfg, s.chainDB, s.blockReader, ff, stateCache, s.agg, s.engine, ethRpcClient, txPoolRpcClient, miningRpcClient, s.seqRPCService, s.historicalRPCService)
+		go s.engineBackendRPC.Start(ctx, &httpRpcCfg, s.chainDB, s.blockReader, ff, stateCache, s.agg, s.engine, ethRpcClient, txPoolRpcClient, miningRpcClient, s.rollupClients.Sequencer, s.rollupClients.Historical)
 	}
 
 	// Register the backend on the node
@@ -1571,6 +1659,9 @@ func (s *Ethereum) Stop() error {
 	if s.unsubscribeEthstat != nil {
 		s.unsubscribeEthstat()
 	}
+	if s.unsubscribeExport != nil {
+		s.unsubscribeExport()
+	}
 	if s.downloader != nil {
 		s.downloader.Close()
 	}
@@ -1597,6 +1688,9 @@ func (s *Ethereum) Stop() error {
 	for _, sentryServer := range s.sentryServers {
 		sentryServer.Close()
 	}
+	if s.waitForTxPoolStop != nil {
+		<-s.waitForTxPoolStop
+	}
 	if s.txPoolDB != nil {
 		s.txPoolDB.Close()
 	}
@@ -1621,11 +1715,11 @@ func (s *Ethereum) Stop() error {
 		}
 	}
 
-	if s.seqRPCService != nil {
-		s.seqRPCService.Close()
+	if s.rollupClients != nil {
+		s.rollupClients.Close()
 	}
-	if s.historicalRPCService != nil {
-		s.historicalRPCService.Close()
+	if s.l1Source != nil {
+		s.l1Source.Close()
 	}
 
 	return nil
@@ -1722,6 +1816,14 @@ func (s *Ethereum) Sentinel() rpcsentinel.SentinelClient {
 	return s.sentinel
 }
 
+// L1Source - shared access to L1, nil unless --rollup.l1rpc is set
+func (s *Ethereum) L1Source() l1source.L1DataSource {
+	if s.l1Source == nil {
+		return nil
+	}
+	return s.l1Source
+}
+
 func (s *Ethereum) DataDir() string {
 	return s.config.Dirs.DataDir
 }
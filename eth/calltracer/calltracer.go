@@ -14,24 +14,52 @@ import (
 	"github.com/erigontech/erigon/core/vm"
 )
 
+// DepositFlag - flag of the CallTraceSet entries of the addresses touched by OP deposit txs (besides 1 - from, 2 - to),
+// their call traces aren't pruned by --prune.keep-deposits
+const DepositFlag = 4
+
 type CallTracer struct {
 	froms map[libcommon.Address]struct{}
 	tos   map[libcommon.Address]bool // address -> isCreated
+
+	depositTxs []bool // depositTxs[i] - tx i of the block is a deposit, see TrackDeposits
+	txIndex    int    // of the tx being executed, CaptureTxStart is called once per tx
+	deposits   map[libcommon.Address]struct{}
 }
 
 func NewCallTracer() *CallTracer {
 	return &CallTracer{
-		froms: make(map[libcommon.Address]struct{}),
-		tos:   make(map[libcommon.Address]bool),
+		froms:    make(map[libcommon.Address]struct{}),
+		tos:      make(map[libcommon.Address]bool),
+		txIndex:  -1,
+		deposits: make(map[libcommon.Address]struct{}),
 	}
 }
 
-func (ct *CallTracer) CaptureTxStart(gasLimit uint64) {}
+// TrackDeposits - the addresses touched by the OP deposit txs (L1 attributes, bridge deposits) of the block to be
+// executed are written with DepositFlag
+func (ct *CallTracer) TrackDeposits(txs types.Transactions) {
+	ct.depositTxs = make([]bool, len(txs))
+	for i, txn := range txs {
+		ct.depositTxs[i] = txn.Type() == types.DepositTxType
+	}
+	ct.txIndex = -1
+}
+
+func (ct *CallTracer) CaptureTxStart(gasLimit uint64) { ct.txIndex++ }
 func (ct *CallTracer) CaptureTxEnd(restGas uint64)    {}
 
+func (ct *CallTracer) inDeposit() bool {
+	return ct.txIndex >= 0 && ct.txIndex < len(ct.depositTxs) && ct.depositTxs[ct.txIndex]
+}
+
 // CaptureStart and CaptureEnter also capture SELFDESTRUCT opcode invocations
 func (ct *CallTracer) captureStartOrEnter(from, to libcommon.Address, create bool, code []byte) {
 	ct.froms[from] = struct{}{}
+	if ct.inDeposit() {
+		ct.deposits[from] = struct{}{}
+		ct.deposits[to] = struct{}{}
+	}
 
 	created, ok := ct.tos[to]
 	if !ok {
@@ -65,16 +93,27 @@ func (ct *CallTracer) WriteToDb(tx kv.StatelessWriteTx, block *types.Block, vmCo
 	for _, uncle := range block.Uncles() {
 		ct.tos[uncle.Coinbase] = false
 	}
-	list := make(common.Addresses, len(ct.froms)+len(ct.tos))
-	i := 0
+	list := make(common.Addresses, 0, len(ct.froms)+len(ct.tos))
 	for addr := range ct.froms {
-		copy(list[i][:], addr[:])
-		i++
+		list = append(list, addr)
 	}
 	for addr := range ct.tos {
-		copy(list[i][:], addr[:])
-		i++
+		list = append(list, addr)
+	}
+	return ct.write(tx, block, list)
+}
+
+// WriteDepositsToDb - WriteToDb of only the addresses touched by the deposit txs (see TrackDeposits), for the blocks
+// below the prune horizon of call traces
+func (ct *CallTracer) WriteDepositsToDb(tx kv.StatelessWriteTx, block *types.Block) error {
+	list := make(common.Addresses, 0, len(ct.deposits))
+	for addr := range ct.deposits {
+		list = append(list, addr)
 	}
+	return ct.write(tx, block, list)
+}
+
+func (ct *CallTracer) write(tx kv.StatelessWriteTx, block *types.Block, list common.Addresses) error {
 	sort.Sort(list)
 	// List may contain duplicates
 	var blockNumEnc [8]byte
@@ -92,6 +131,9 @@ func (ct *CallTracer) WriteToDb(tx kv.StatelessWriteTx, block *types.Block, vmCo
 		if _, ok := ct.tos[addr]; ok {
 			v[length.Addr] |= 2
 		}
+		if _, ok := ct.deposits[addr]; ok {
+			v[length.Addr] |= DepositFlag
+		}
 		if j == 0 {
 			if err := tx.Append(kv.CallTraceSet, blockNumEnc[:], v[:]); err != nil {
 				return err
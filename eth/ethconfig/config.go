@@ -18,6 +18,7 @@
 package ethconfig
 
 import (
+	"fmt"
 	"math/big"
 	"os"
 	"os/user"
@@ -33,6 +34,7 @@ import (
 	"github.com/erigontech/erigon-lib/common"
 	"github.com/erigontech/erigon-lib/common/datadir"
 	"github.com/erigontech/erigon-lib/downloader/downloadercfg"
+	"github.com/erigontech/erigon-lib/seg"
 	"github.com/erigontech/erigon-lib/txpool/txpoolcfg"
 	"github.com/erigontech/erigon/cl/beacon/beacon_router_configuration"
 	"github.com/erigontech/erigon/cl/clparams"
@@ -40,6 +42,7 @@ import (
 	"github.com/erigontech/erigon/core/types"
 	"github.com/erigontech/erigon/eth/ethconfig/estimate"
 	"github.com/erigontech/erigon/eth/gasprice/gaspricecfg"
+	"github.com/erigontech/erigon/eth/stagedsync/stages"
 	"github.com/erigontech/erigon/ethdb/prune"
 	"github.com/erigontech/erigon/params"
 	"github.com/erigontech/erigon/rpc"
@@ -100,6 +103,17 @@ var Defaults = Config{
 	GPO:              FullNodeGPO,
 	RPCTxFeeCap:      1, // 1 ether
 
+	RollupProbeInterval: 12 * time.Second,
+	RollupL1CacheSize:   1024,
+	RollupL1ViewDepth:   256,
+	Backup: Backup{
+		Keep:   3,
+		Verify: true,
+	},
+	SequencerLock: SequencerLock{
+		TTL: 10 * time.Second,
+	},
+
 	ImportMode: false,
 	Snapshot: BlocksFreezing{
 		Enabled:    true,
@@ -141,6 +155,46 @@ type BlocksFreezing struct {
 	NoDownloader   bool // possible to use snapshots without calling Downloader
 	Verify         bool // verify snapshots on startup
 	DownloaderAddr string
+
+	// amount of recent blocks kept in db before freezing them into snapshots (per data kind), at least MinFreezeDistance.
+	// 0 means params.FullImmutabilityThreshold. Sequencers may keep bigger hot window, verifiers may freeze aggressively.
+	FreezeDistance    uint64 // headers, bodies, transactions
+	BorFreezeDistance uint64 // bor events and spans
+
+	// KeyProvider - of the data keys of encrypted segments (--snap.encryption.keyfile): new segments are encrypted,
+	// encrypted ones are decrypted when read. Nil - segments are plain
+	KeyProvider seg.KeyProvider `toml:"-"`
+}
+
+// BlocksFreezeDistance - amount of recent headers/bodies/transactions kept in db
+func (s BlocksFreezing) BlocksFreezeDistance() uint64 {
+	if s.FreezeDistance == 0 {
+		return params.FullImmutabilityThreshold
+	}
+	return s.FreezeDistance
+}
+
+// MinFreezeDistance - lower bound of the freeze distances: unwinds of reorgs and the rewinds of admin_rewindToBlock
+// need the recent blocks in db, frozen ones can't be unwound
+const MinFreezeDistance = 1_024
+
+// CheckFreezeDistance - error if a freeze distance is set below MinFreezeDistance
+func (s BlocksFreezing) CheckFreezeDistance() error {
+	if s.FreezeDistance != 0 && s.FreezeDistance < MinFreezeDistance {
+		return fmt.Errorf("--%s=%d is below the minimum %d", FlagSnapFreezeDistance, s.FreezeDistance, MinFreezeDistance)
+	}
+	if s.BorFreezeDistance != 0 && s.BorFreezeDistance < MinFreezeDistance {
+		return fmt.Errorf("--%s=%d is below the minimum %d", FlagSnapBorFreezeDistance, s.BorFreezeDistance, MinFreezeDistance)
+	}
+	return nil
+}
+
+// BorBlocksFreezeDistance - amount of recent bor events/spans kept in db
+func (s BlocksFreezing) BorBlocksFreezeDistance() uint64 {
+	if s.BorFreezeDistance == 0 {
+		return params.FullImmutabilityThreshold
+	}
+	return s.BorFreezeDistance
 }
 
 func (s BlocksFreezing) String() string {
@@ -154,12 +208,20 @@ func (s BlocksFreezing) String() string {
 	if !s.Produce {
 		out = append(out, "--"+FlagSnapStop+"=true")
 	}
+	if d := s.BlocksFreezeDistance(); d != params.FullImmutabilityThreshold {
+		out = append(out, fmt.Sprintf("--%s=%d", FlagSnapFreezeDistance, d))
+	}
+	if d := s.BorBlocksFreezeDistance(); d != params.FullImmutabilityThreshold {
+		out = append(out, fmt.Sprintf("--%s=%d", FlagSnapBorFreezeDistance, d))
+	}
 	return strings.Join(out, " ")
 }
 
 var (
-	FlagSnapKeepBlocks = "snap.keepblocks"
-	FlagSnapStop       = "snap.stop"
+	FlagSnapKeepBlocks        = "snap.keepblocks"
+	FlagSnapStop              = "snap.stop"
+	FlagSnapFreezeDistance    = "snap.freeze.distance"
+	FlagSnapBorFreezeDistance = "snap.freeze.distance.bor"
 )
 
 func NewSnapCfg(enabled, keepBlocks, produce bool) BlocksFreezing {
@@ -243,6 +305,10 @@ type Config struct {
 
 	// Ethstats service
 	Ethstats string
+	// Exporter of canonical chain events to Kafka REST proxy or NATS
+	ExportURL    string
+	ExportTopic  string
+	ExportResume string // resume token of the event to publish the events after, see exporter.Event
 	// Consensus layer
 	InternalCL                  bool
 	LightClientDiscoveryAddr    string
@@ -253,6 +319,10 @@ type Config struct {
 
 	ForcePartialCommit bool
 
+	// ReceiptsCompression - zstd compression of receipts and logs with a dictionary trained on the datadir,
+	// see rawdb.SetupReceiptsCompression. Values written before stay as they are, both are readable.
+	ReceiptsCompression bool
+
 	OverrideCancunTime   *big.Int `toml:",omitempty"`
 	OverrideShanghaiTime *big.Int `toml:",omitempty"`
 	OverridePragueTime   *big.Int `toml:",omitempty"`
@@ -282,8 +352,76 @@ type Config struct {
 	RollupSequencerHTTP        string
 	RollupHistoricalRPC        string
 	RollupHistoricalRPCTimeout time.Duration
+	// Archive node serving eth_call and eth_estimateGas at blocks with locally pruned state history
+	RollupArchiveRPC string
+
+	// Prober of derivation health, queries op-node or RollupSequencerHTTP
+	RollupProbe         bool
+	RollupOpNodeRPC     string
+	RollupProbeInterval time.Duration
 
 	RollupHaltOnIncompatibleProtocolVersion string
+
+	// L1 execution RPC and beacon API shared by modules which read L1, see l1source
+	RollupL1RPC       string
+	RollupL1BeaconRPC string
+	RollupL1CacheSize int
+
+	// Light view of L1 followed over RollupL1RPC, L1 origins of rollup status RPCs are checked against it
+	RollupL1View          bool
+	RollupL1ViewContracts []common.Address // logs of these contracts are kept
+	RollupL1ViewDepth     int              // amount of L1 blocks kept
+
+	// Handling of engine_forkchoiceUpdated which can't be processed in time
+	Forkchoice Forkchoice
+
+	// Periodic and on-demand (admin_backup) backups of chaindata
+	Backup Backup
+
+	// Leadership lock of the sequencer, see sequencerlock
+	SequencerLock SequencerLock
+}
+
+// OptimismForkchoiceTimeout - op-node doesn't handle SYNCING as asynchronous forkchoiceUpdated,
+// so by default it gets an error after this timeout and retries
+const OptimismForkchoiceTimeout = 5 * time.Second
+
+// Forkchoice - how execution service answers engine_forkchoiceUpdated which can't be processed in time
+type Forkchoice struct {
+	// Timeout - how long forkchoiceUpdated waits for its outcome before answering.
+	// 0 - timeout of the request for L1, OptimismForkchoiceTimeout for Optimism
+	Timeout time.Duration
+	// BusyRetry - wait up to Timeout for the previous forkchoiceUpdated to finish, instead of answering immediately
+	BusyRetry bool
+	// Async - Optimism only: answer SYNCING when forkchoiceUpdated takes too long or execution service is busy,
+	// like L1 does, instead of an error. Only for op-node versions which handle SYNCING as asynchronous forkchoiceUpdated
+	Async bool
+}
+
+// Backup - consistent copies of chaindata taken while the node is running
+type Backup struct {
+	// Dir - where backups are written, empty - backups are disabled
+	Dir string
+	// Interval between scheduled backups, 0 - only on demand
+	Interval time.Duration
+	// Keep - how many latest backups are kept, locally and on Remote
+	Keep int
+	// Remote - rclone remote (e.g. S3-compatible storage) which backups are uploaded to, empty - local only
+	Remote string
+	// Compact - omit free pages from the copy: smaller backup, slower copy
+	Compact bool
+	// Verify - walk every table of the copy before accepting it
+	Verify bool
+}
+
+// SequencerLock - of sequencer instances of one chain only the holder of the lock builds payloads
+type SequencerLock struct {
+	// Path of the lease file shared by the instances, empty - the lock is disabled
+	Path string
+	// TTL of the lease, it's renewed every TTL/3. Other instance takes over at most TTL after the holder stopped
+	TTL time.Duration
+	// ID of this instance in the lease
+	ID string
 }
 
 type Sync struct {
@@ -298,6 +436,21 @@ type Sync struct {
 	PruneLimit                 int //the maximum records to delete from the DB during pruning
 	BreakAfterStage            string
 	LoopBlockLimit             uint
+	SendersBackfill            bool               // recover senders of frozen blocks which segments have without senders
+	SkipStages                 []stages.SyncStage // see stages.Skippable
+	BlockAccessLists           bool               // collect experimental EIP-7928 block access lists during execution
+	BadBlockHalt               bool               // stop sync on a block failing execution or state root check, see stagedsync.BadBlockDump
+	BadBlockDumpDir            string             // where bad block bundles are written, <datadir>/badblocks if empty
+	VerifyDepositNonces        bool               // fail blocks whose deposit receipts don't match the nonces of their senders, see core.ErrDepositNonceMismatch
+	DryRun                     bool               // cycles run on a memory overlay and are reported instead of committed, see stagedsync.DryRun
+	IncrementalTrie            bool               // small batches update the hashed state and the trie at execution, from the state changes accumulator
+	HotContractsWindow         time.Duration      // execution meters gas by contract over the window of block time, see gasmeter.HotContracts; off if 0
+	PrefetchBlocks             uint64             // execution prefetches the slots the called contracts changed in this many previous blocks, see stagedsync.prefetchSlots; erigon2 only, off if 0
+	MaxReorgDepth              uint64             // forkchoice updates unwinding more blocks of the head are refused unless allowed by admin_allowDeepReorg; off if 0
+	ForkValidatorMemoryLimit   datasize.ByteSize  // the extending fork diff of the fork validator above this size is spilled to a temporary mdbx; off if 0
+	SilkwormAudit              uint64             // every N-th block executed by Silkworm is executed by the Go EVM too and compared, see stagedsync.silkwormAudit; off if 0
+	ExecCommitInterval         time.Duration      // execution commits its batch at least this often, see stagedsync.execCommitTrigger; off if 0
+	ExecCommitMaxDirty         datasize.ByteSize  // execution commits its batch once the dirty pages of its db tx reach this size; off if 0
 
 	UploadLocation   string
 	UploadFrom       rpc.BlockNumber
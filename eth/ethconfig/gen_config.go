@@ -57,6 +57,9 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		WithHeimdallWaypointRecording           bool
 		PolygonSync                             bool
 		Ethstats                                string
+		ExportURL                               string
+		ExportTopic                             string
+		ExportResume                            string
 		InternalCL                              bool
 		LightClientDiscoveryAddr                string
 		LightClientDiscoveryPort                uint64
@@ -64,6 +67,7 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		SentinelAddr                            string
 		SentinelPort                            uint64
 		ForcePartialCommit                      bool
+		ReceiptsCompression                     bool
 		OverrideCancunTime                      *big.Int `toml:",omitempty"`
 		OverrideShanghaiTime                    *big.Int `toml:",omitempty"`
 		OverridePragueTime                      *big.Int `toml:",omitempty"`
@@ -88,7 +92,20 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		RollupSequencerHTTP                     string
 		RollupHistoricalRPC                     string
 		RollupHistoricalRPCTimeout              time.Duration
+		RollupArchiveRPC                        string
+		RollupProbe                             bool
+		RollupOpNodeRPC                         string
+		RollupProbeInterval                     time.Duration
 		RollupHaltOnIncompatibleProtocolVersion string
+		RollupL1RPC                             string
+		RollupL1BeaconRPC                       string
+		RollupL1CacheSize                       int
+		RollupL1View                            bool
+		RollupL1ViewContracts                   []common.Address
+		RollupL1ViewDepth                       int
+		Forkchoice                              Forkchoice
+		Backup                                  Backup
+		SequencerLock                           SequencerLock
 	}
 	var enc Config
 	enc.Sync = c.Sync
@@ -123,6 +140,9 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.WithHeimdallWaypointRecording = c.WithHeimdallWaypointRecording
 	enc.PolygonSync = c.PolygonSync
 	enc.Ethstats = c.Ethstats
+	enc.ExportURL = c.ExportURL
+	enc.ExportTopic = c.ExportTopic
+	enc.ExportResume = c.ExportResume
 	enc.InternalCL = c.InternalCL
 	enc.LightClientDiscoveryAddr = c.LightClientDiscoveryAddr
 	enc.LightClientDiscoveryPort = c.LightClientDiscoveryPort
@@ -130,6 +150,7 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.SentinelAddr = c.SentinelAddr
 	enc.SentinelPort = c.SentinelPort
 	enc.ForcePartialCommit = c.ForcePartialCommit
+	enc.ReceiptsCompression = c.ReceiptsCompression
 	enc.OverrideCancunTime = c.OverrideCancunTime
 	enc.OverrideShanghaiTime = c.OverrideShanghaiTime
 	enc.OverridePragueTime = c.OverridePragueTime
@@ -154,7 +175,20 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.RollupSequencerHTTP = c.RollupSequencerHTTP
 	enc.RollupHistoricalRPC = c.RollupHistoricalRPC
 	enc.RollupHistoricalRPCTimeout = c.RollupHistoricalRPCTimeout
+	enc.RollupArchiveRPC = c.RollupArchiveRPC
+	enc.RollupProbe = c.RollupProbe
+	enc.RollupOpNodeRPC = c.RollupOpNodeRPC
+	enc.RollupProbeInterval = c.RollupProbeInterval
 	enc.RollupHaltOnIncompatibleProtocolVersion = c.RollupHaltOnIncompatibleProtocolVersion
+	enc.RollupL1RPC = c.RollupL1RPC
+	enc.RollupL1BeaconRPC = c.RollupL1BeaconRPC
+	enc.RollupL1CacheSize = c.RollupL1CacheSize
+	enc.RollupL1View = c.RollupL1View
+	enc.RollupL1ViewContracts = c.RollupL1ViewContracts
+	enc.RollupL1ViewDepth = c.RollupL1ViewDepth
+	enc.Forkchoice = c.Forkchoice
+	enc.Backup = c.Backup
+	enc.SequencerLock = c.SequencerLock
 	return &enc, nil
 }
 
@@ -193,6 +227,9 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		WithHeimdallWaypointRecording           *bool
 		PolygonSync                             *bool
 		Ethstats                                *string
+		ExportURL                               *string
+		ExportTopic                             *string
+		ExportResume                            *string
 		InternalCL                              *bool
 		LightClientDiscoveryAddr                *string
 		LightClientDiscoveryPort                *uint64
@@ -200,6 +237,7 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		SentinelAddr                            *string
 		SentinelPort                            *uint64
 		ForcePartialCommit                      *bool
+		ReceiptsCompression                     *bool
 		OverrideCancunTime                      *big.Int `toml:",omitempty"`
 		OverrideShanghaiTime                    *big.Int `toml:",omitempty"`
 		OverridePragueTime                      *big.Int `toml:",omitempty"`
@@ -224,7 +262,20 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		RollupSequencerHTTP                     *string
 		RollupHistoricalRPC                     *string
 		RollupHistoricalRPCTimeout              *time.Duration
+		RollupArchiveRPC                        *string
+		RollupProbe                             *bool
+		RollupOpNodeRPC                         *string
+		RollupProbeInterval                     *time.Duration
 		RollupHaltOnIncompatibleProtocolVersion *string
+		RollupL1RPC                             *string
+		RollupL1BeaconRPC                       *string
+		RollupL1CacheSize                       *int
+		RollupL1View                            *bool
+		RollupL1ViewContracts                   []common.Address
+		RollupL1ViewDepth                       *int
+		Forkchoice                              *Forkchoice
+		Backup                                  *Backup
+		SequencerLock                           *SequencerLock
 	}
 	var dec Config
 	if err := unmarshal(&dec); err != nil {
@@ -326,6 +377,15 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.Ethstats != nil {
 		c.Ethstats = *dec.Ethstats
 	}
+	if dec.ExportURL != nil {
+		c.ExportURL = *dec.ExportURL
+	}
+	if dec.ExportTopic != nil {
+		c.ExportTopic = *dec.ExportTopic
+	}
+	if dec.ExportResume != nil {
+		c.ExportResume = *dec.ExportResume
+	}
 	if dec.InternalCL != nil {
 		c.InternalCL = *dec.InternalCL
 	}
@@ -347,6 +407,9 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.ForcePartialCommit != nil {
 		c.ForcePartialCommit = *dec.ForcePartialCommit
 	}
+	if dec.ReceiptsCompression != nil {
+		c.ReceiptsCompression = *dec.ReceiptsCompression
+	}
 	if dec.OverrideCancunTime != nil {
 		c.OverrideCancunTime = dec.OverrideCancunTime
 	}
@@ -419,8 +482,47 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.RollupHistoricalRPCTimeout != nil {
 		c.RollupHistoricalRPCTimeout = *dec.RollupHistoricalRPCTimeout
 	}
+	if dec.RollupArchiveRPC != nil {
+		c.RollupArchiveRPC = *dec.RollupArchiveRPC
+	}
+	if dec.RollupProbe != nil {
+		c.RollupProbe = *dec.RollupProbe
+	}
+	if dec.RollupOpNodeRPC != nil {
+		c.RollupOpNodeRPC = *dec.RollupOpNodeRPC
+	}
+	if dec.RollupProbeInterval != nil {
+		c.RollupProbeInterval = *dec.RollupProbeInterval
+	}
 	if dec.RollupHaltOnIncompatibleProtocolVersion != nil {
 		c.RollupHaltOnIncompatibleProtocolVersion = *dec.RollupHaltOnIncompatibleProtocolVersion
 	}
+	if dec.RollupL1RPC != nil {
+		c.RollupL1RPC = *dec.RollupL1RPC
+	}
+	if dec.RollupL1BeaconRPC != nil {
+		c.RollupL1BeaconRPC = *dec.RollupL1BeaconRPC
+	}
+	if dec.RollupL1CacheSize != nil {
+		c.RollupL1CacheSize = *dec.RollupL1CacheSize
+	}
+	if dec.RollupL1View != nil {
+		c.RollupL1View = *dec.RollupL1View
+	}
+	if dec.RollupL1ViewContracts != nil {
+		c.RollupL1ViewContracts = dec.RollupL1ViewContracts
+	}
+	if dec.RollupL1ViewDepth != nil {
+		c.RollupL1ViewDepth = *dec.RollupL1ViewDepth
+	}
+	if dec.Forkchoice != nil {
+		c.Forkchoice = *dec.Forkchoice
+	}
+	if dec.Backup != nil {
+		c.Backup = *dec.Backup
+	}
+	if dec.SequencerLock != nil {
+		c.SequencerLock = *dec.SequencerLock
+	}
 	return nil
 }
@@ -21,6 +21,13 @@ func MarshalReceipt(
 	txnHash common.Hash,
 	signed bool,
 ) map[string]interface{} {
+	if chainConfig.IsBobaLegacyBlock(header.Number.Uint64()) {
+		if original, _, ok := types.BobaLegacyTuringTx(txn); ok {
+			// user signed calldata without Turing response
+			txn = original
+		}
+	}
+
 	var chainId *big.Int
 	switch t := txn.(type) {
 	case *types.LegacyTx:
@@ -73,7 +80,9 @@ func MarshalReceipt(
 		fields["contractAddress"] = receipt.ContractAddress
 	}
 
-	if chainConfig.IsOptimism() {
+	// legacy Boba l2geth didn't charge L1 fee for some of txs (e.g. L1->L2 enqueued ones), there is nothing to marshal
+	bobaLegacyNoL1Fee := chainConfig.IsBobaLegacyBlock(header.Number.Uint64()) && receipt.L1Fee == nil
+	if chainConfig.IsOptimism() && !bobaLegacyNoL1Fee {
 		if txn.Type() != types.DepositTxType {
 			fields["l1GasPrice"] = hexutil.Big(*receipt.L1GasPrice)
 			fields["l1GasUsed"] = hexutil.Big(*receipt.L1GasUsed)
@@ -0,0 +1,206 @@
+package gasmeter
+
+import (
+	"sort"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/diagnostics"
+	"github.com/holiman/uint256"
+
+	"github.com/erigontech/erigon/core/vm"
+	"github.com/erigontech/erigon/params"
+)
+
+type class int
+
+const (
+	compute class = iota
+	memory
+	storageRead
+	storageWrite
+	precompile
+	call
+	classCount
+)
+
+func classOf(op vm.OpCode) class {
+	switch op {
+	case vm.MLOAD, vm.MSTORE, vm.MSTORE8, vm.MCOPY, vm.CALLDATACOPY, vm.CODECOPY, vm.RETURNDATACOPY:
+		return memory
+	case vm.SLOAD, vm.BALANCE, vm.EXTCODESIZE, vm.EXTCODECOPY, vm.EXTCODEHASH:
+		return storageRead
+	case vm.SSTORE, vm.SELFDESTRUCT:
+		return storageWrite
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL, vm.CREATE, vm.CREATE2:
+		return call
+	default:
+		return compute
+	}
+}
+
+type frame struct {
+	addr       libcommon.Address // the account the code runs for
+	precompile bool
+	used       uint64 // by the opcodes of the frame and by its sub-calls
+}
+
+// GasMeter - EVMLogger aggregating the gas of a block by the kind of work and by contract. It wraps the tracer
+// the block is executed with, which keeps receiving all events.
+//
+// The opcode cost reported by the interpreter is charged to the contract running it, except for the gas passed
+// to sub-calls, which their own opcodes account for. What a frame used beyond its opcodes and sub-calls (code
+// deposit, gas burnt on failure) is charged to calls.
+type GasMeter struct {
+	vm.EVMLogger
+
+	classes   [classCount]uint64
+	contracts map[libcommon.Address]uint64
+	intrinsic uint64
+	charged   uint64 // gas used by the transactions before refunds
+	gasUsed   uint64
+
+	frames      []frame
+	txGasLimit  uint64
+	pendingCall uint64 // cost of the last opcode if it was a call, including the gas passed to the callee
+}
+
+func New(inner vm.EVMLogger) *GasMeter {
+	return &GasMeter{EVMLogger: inner, contracts: make(map[libcommon.Address]uint64)}
+}
+
+func (g *GasMeter) CaptureTxStart(gasLimit uint64) {
+	g.txGasLimit = gasLimit
+	g.EVMLogger.CaptureTxStart(gasLimit)
+}
+
+func (g *GasMeter) CaptureTxEnd(restGas uint64) {
+	g.gasUsed += g.txGasLimit - restGas
+	g.txGasLimit = 0
+	g.EVMLogger.CaptureTxEnd(restGas)
+}
+
+func (g *GasMeter) CaptureStart(env *vm.EVM, from libcommon.Address, to libcommon.Address, precompile bool, create bool, input []byte, gas uint64, value *uint256.Int, code []byte) {
+	if g.txGasLimit > gas {
+		g.intrinsic += g.txGasLimit - gas
+		g.charged += g.txGasLimit - gas
+	}
+	g.frames = append(g.frames[:0], frame{addr: to, precompile: precompile})
+	g.EVMLogger.CaptureStart(env, from, to, precompile, create, input, gas, value, code)
+}
+
+func (g *GasMeter) CaptureEnd(output []byte, usedGas uint64, err error) {
+	g.exit(usedGas)
+	g.charged += usedGas
+	g.EVMLogger.CaptureEnd(output, usedGas, err)
+}
+
+func (g *GasMeter) CaptureEnter(typ vm.OpCode, from libcommon.Address, to libcommon.Address, precompile bool, create bool, input []byte, gas uint64, value *uint256.Int, code []byte) {
+	// the gas passed to the callee is accounted by it, except for the stipend which the caller doesn't pay
+	if !create && len(g.frames) > 0 {
+		passed := gas
+		if (typ == vm.CALL || typ == vm.CALLCODE) && value != nil && !value.IsZero() && passed >= params.CallStipend {
+			passed -= params.CallStipend
+		}
+		passed = min(passed, g.pendingCall)
+		parent := &g.frames[len(g.frames)-1]
+		g.classes[call] -= passed
+		g.contracts[parent.addr] -= passed
+		parent.used -= passed
+	}
+	g.pendingCall = 0
+	addr := to
+	if (typ == vm.DELEGATECALL || typ == vm.CALLCODE) && !precompile {
+		addr = from
+	}
+	g.frames = append(g.frames, frame{addr: addr, precompile: precompile})
+	g.EVMLogger.CaptureEnter(typ, from, to, precompile, create, input, gas, value, code)
+}
+
+func (g *GasMeter) CaptureExit(output []byte, usedGas uint64, err error) {
+	g.exit(usedGas)
+	if len(g.frames) > 0 {
+		g.frames[len(g.frames)-1].used += usedGas
+	}
+	g.EVMLogger.CaptureExit(output, usedGas, err)
+}
+
+func (g *GasMeter) exit(usedGas uint64) {
+	if len(g.frames) == 0 {
+		return
+	}
+	f := g.frames[len(g.frames)-1]
+	g.frames = g.frames[:len(g.frames)-1]
+	if f.precompile {
+		g.classes[precompile] += usedGas
+		g.contracts[f.addr] += usedGas
+	} else if usedGas > f.used {
+		g.classes[call] += usedGas - f.used
+		g.contracts[f.addr] += usedGas - f.used
+	}
+}
+
+func (g *GasMeter) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	// a failed opcode burns the rest of the frame's gas, which exit accounts for
+	if err == nil && len(g.frames) > 0 {
+		c := classOf(op)
+		g.classes[c] += cost
+		g.contracts[g.frames[len(g.frames)-1].addr] += cost
+		g.frames[len(g.frames)-1].used += cost
+		g.pendingCall = 0
+		if c == call {
+			g.pendingCall = cost
+		}
+	}
+	g.EVMLogger.CaptureState(pc, op, gas, cost, scope, rData, depth, err)
+}
+
+// Reset - for the next transaction metered separately, keeping the inner tracer
+func (g *GasMeter) Reset() {
+	g.classes = [classCount]uint64{}
+	clear(g.contracts)
+	g.intrinsic, g.charged, g.gasUsed = 0, 0, 0
+	g.frames = g.frames[:0]
+	g.txGasLimit, g.pendingCall = 0, 0
+}
+
+// Contracts - a copy of the gas used by the code of the contracts since the last Reset
+func (g *GasMeter) Contracts() map[libcommon.Address]uint64 {
+	contracts := make(map[libcommon.Address]uint64, len(g.contracts))
+	for addr, gas := range g.contracts {
+		if gas > 0 {
+			contracts[addr] = gas
+		}
+	}
+	return contracts
+}
+
+// BlockUsage - the gas of the block executed with the meter, with up to topContracts contracts
+func (g *GasMeter) BlockUsage(blockNum uint64, topContracts int) diagnostics.BlockGasUsage {
+	u := diagnostics.BlockGasUsage{
+		BlockNumber:  blockNum,
+		GasUsed:      g.gasUsed,
+		Intrinsic:    g.intrinsic,
+		Compute:      g.classes[compute],
+		Memory:       g.classes[memory],
+		StorageRead:  g.classes[storageRead],
+		StorageWrite: g.classes[storageWrite],
+		Precompile:   g.classes[precompile],
+		Call:         g.classes[call],
+	}
+	if g.charged > g.gasUsed {
+		u.Refund = g.charged - g.gasUsed
+	}
+
+	contracts := make([]diagnostics.ContractGasUsage, 0, len(g.contracts))
+	for addr, gas := range g.contracts {
+		if gas > 0 {
+			contracts = append(contracts, diagnostics.ContractGasUsage{Address: addr.Hex(), Gas: gas})
+		}
+	}
+	sort.Slice(contracts, func(i, j int) bool { return contracts[i].Gas > contracts[j].Gas })
+	if len(contracts) > topContracts {
+		contracts = contracts[:topContracts]
+	}
+	u.TopContracts = contracts
+	return u
+}
@@ -0,0 +1,56 @@
+package gasmeter
+
+import (
+	"math/big"
+	"testing"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon/core/vm"
+	"github.com/erigontech/erigon/core/vm/runtime"
+	"github.com/erigontech/erigon/eth/calltracer"
+	"github.com/erigontech/erigon/params"
+)
+
+func TestGasMeter(t *testing.T) {
+	code := []byte{
+		byte(vm.PUSH1), 1, byte(vm.PUSH1), 0, byte(vm.SSTORE),
+		byte(vm.PUSH1), 0, byte(vm.SLOAD),
+		byte(vm.PUSH1), 0, byte(vm.MSTORE),
+		// identity precompile over the word just stored
+		byte(vm.PUSH1), 0x20, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0x20, byte(vm.PUSH1), 0, byte(vm.PUSH1), 4, byte(vm.GAS), byte(vm.STATICCALL),
+		byte(vm.STOP),
+	}
+	callTracer := calltracer.NewCallTracer()
+	meter := New(callTracer)
+	cfg := &runtime.Config{
+		ChainConfig: params.AllProtocolChanges,
+		Difficulty:  new(big.Int),
+		Time:        new(big.Int),
+		BlockNumber: new(big.Int),
+		GasLimit:    1_000_000,
+		GasPrice:    new(uint256.Int),
+		Value:       new(uint256.Int),
+		EVMConfig:   vm.Config{Debug: true, Tracer: meter},
+	}
+	_, _, err := runtime.Execute(code, nil, cfg, 0)
+	require.NoError(t, err)
+
+	u := meter.BlockUsage(1, 10)
+	require.Positive(t, u.StorageWrite)
+	require.Positive(t, u.StorageRead)
+	require.Positive(t, u.Memory)
+	require.Positive(t, u.Compute)
+	require.Equal(t, params.IdentityBaseGas+params.IdentityPerWordGas, u.Precompile)
+	// the 63/64 of the gas passed to the precompile is not an overhead of the call
+	require.Less(t, u.Call, uint64(1_000))
+	require.Equal(t, meter.charged, u.Compute+u.Memory+u.StorageRead+u.StorageWrite+u.Precompile+u.Call)
+
+	contract := libcommon.BytesToAddress([]byte("contract"))
+	require.Len(t, u.TopContracts, 2)
+	require.Equal(t, contract.Hex(), u.TopContracts[0].Address)
+	require.Equal(t, libcommon.BytesToAddress([]byte{4}).Hex(), u.TopContracts[1].Address)
+	require.Equal(t, meter.charged, u.TopContracts[0].Gas+u.TopContracts[1].Gas)
+}
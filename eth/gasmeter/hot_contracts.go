@@ -0,0 +1,199 @@
+package gasmeter
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/metrics"
+)
+
+const (
+	hotContractsMetered        = 10               // number of the hottest contracts exported as metrics
+	hotContractsReportInterval = 10 * time.Second // the metrics are updated at most once per interval
+)
+
+var (
+	hot atomic.Pointer[HotContracts]
+
+	execGasPerSecond        = metrics.GetOrCreateGauge(`exec_gas_per_second`)
+	hotContractGasPerSecond = metrics.GetOrCreateGaugeVec(`exec_hot_contract_gas_per_second`, []string{"address"}, "Gas per second used by the code of the hottest contracts")
+)
+
+// EnableHotContracts - the execution stage of the process meters gas of the blocks and reports it to HotContracts
+// of the window, which debug_hotContracts and metrics serve
+func EnableHotContracts(window time.Duration) *HotContracts {
+	h := NewHotContracts(window)
+	hot.Store(h)
+	return h
+}
+
+// Hot - HotContracts of the process, nil unless enabled
+func Hot() *HotContracts {
+	return hot.Load()
+}
+
+type hotBlock struct {
+	number    uint64
+	time      uint64
+	gasUsed   uint64
+	contracts map[libcommon.Address]uint64
+}
+
+// HotContracts - gas used by the code of contracts (see GasMeter) in the blocks executed over a rolling window
+// of block time, for finding the contracts driving the load of the node (e.g. of a sequencer). Safe for
+// concurrent use.
+type HotContracts struct {
+	window time.Duration
+
+	mu        sync.Mutex
+	blocks    []hotBlock // in the order of block numbers
+	contracts map[libcommon.Address]uint64
+	gasUsed   uint64
+	reported  time.Time           // of the last update of the metrics
+	metered   []libcommon.Address // contracts with a gauge since that update
+}
+
+func NewHotContracts(window time.Duration) *HotContracts {
+	return &HotContracts{window: window, contracts: make(map[libcommon.Address]uint64)}
+}
+
+type HotContract struct {
+	Address      libcommon.Address `json:"address"`
+	Gas          hexutil.Uint64    `json:"gas"`
+	GasPerSecond hexutil.Uint64    `json:"gasPerSecond"`
+	Share        float64           `json:"share"` // of the gas used by the blocks
+}
+
+type HotContractsReport struct {
+	FromBlock    hexutil.Uint64 `json:"fromBlock"`
+	ToBlock      hexutil.Uint64 `json:"toBlock"`
+	Seconds      hexutil.Uint64 `json:"seconds"` // of block time from the first block to the last one
+	GasUsed      hexutil.Uint64 `json:"gasUsed"`
+	GasPerSecond hexutil.Uint64 `json:"gasPerSecond"`
+	Contracts    []HotContract  `json:"contracts"` // in descending order of gas
+}
+
+// Add - the gas of the block executed with the meter. A block re-executed after an unwind replaces the blocks from
+// its number.
+func (h *HotContracts) Add(blockNum, blockTime uint64, g *GasMeter) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.startBlock(blockNum, blockTime)
+	h.addGas(g.gasUsed, g.contracts)
+	h.updateMetrics()
+}
+
+// AddTx - the gas of a task of the block executed with the meter of its transaction (exec3), in the order of
+// execution: the block initialisation (txIndex -1) starts the block like Add, the following tasks add to it.
+func (h *HotContracts) AddTx(blockNum, blockTime uint64, txIndex int, gasUsed uint64, contracts map[libcommon.Address]uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if txIndex < 0 || len(h.blocks) == 0 || h.blocks[len(h.blocks)-1].number != blockNum {
+		h.startBlock(blockNum, blockTime)
+	}
+	h.addGas(gasUsed, contracts)
+	h.updateMetrics()
+}
+
+// startBlock - replaces the blocks from blockNum by an empty one and drops the blocks out of the window
+func (h *HotContracts) startBlock(blockNum, blockTime uint64) {
+	for len(h.blocks) > 0 && h.blocks[len(h.blocks)-1].number >= blockNum {
+		h.remove(h.blocks[len(h.blocks)-1])
+		h.blocks = h.blocks[:len(h.blocks)-1]
+	}
+	h.blocks = append(h.blocks, hotBlock{number: blockNum, time: blockTime, contracts: make(map[libcommon.Address]uint64)})
+
+	var expired int
+	for expired < len(h.blocks)-1 && h.blocks[expired].time+uint64(h.window/time.Second) <= blockTime {
+		h.remove(h.blocks[expired])
+		expired++
+	}
+	h.blocks = append(h.blocks[:0], h.blocks[expired:]...)
+}
+
+// addGas - to the last block
+func (h *HotContracts) addGas(gasUsed uint64, contracts map[libcommon.Address]uint64) {
+	b := &h.blocks[len(h.blocks)-1]
+	for addr, gas := range contracts {
+		if gas > 0 {
+			b.contracts[addr] += gas
+			h.contracts[addr] += gas
+		}
+	}
+	b.gasUsed += gasUsed
+	h.gasUsed += gasUsed
+}
+
+// updateMetrics - once per hotContractsReportInterval, the report is sorted over all the contracts of the window.
+// The gauges of the contracts which left the top are deleted, the others are kept for the scrapes in between.
+func (h *HotContracts) updateMetrics() {
+	if time.Since(h.reported) < hotContractsReportInterval {
+		return
+	}
+	h.reported = time.Now()
+	r := h.report(hotContractsMetered)
+	execGasPerSecond.SetUint64(uint64(r.GasPerSecond))
+	top := make(map[libcommon.Address]struct{}, len(r.Contracts))
+	for _, c := range r.Contracts {
+		top[c.Address] = struct{}{}
+		hotContractGasPerSecond.WithLabelValues(c.Address.Hex()).Set(float64(c.GasPerSecond))
+	}
+	for _, addr := range h.metered {
+		if _, ok := top[addr]; !ok {
+			hotContractGasPerSecond.DeleteLabelValues(addr.Hex())
+		}
+	}
+	h.metered = h.metered[:0]
+	for addr := range top {
+		h.metered = append(h.metered, addr)
+	}
+}
+
+func (h *HotContracts) remove(b hotBlock) {
+	for addr, gas := range b.contracts {
+		if h.contracts[addr] -= gas; h.contracts[addr] == 0 {
+			delete(h.contracts, addr)
+		}
+	}
+	h.gasUsed -= b.gasUsed
+}
+
+// Report - up to top contracts which used the most gas over the window
+func (h *HotContracts) Report(top int) HotContractsReport {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.report(top)
+}
+
+func (h *HotContracts) report(top int) HotContractsReport {
+	r := HotContractsReport{Contracts: []HotContract{}}
+	if len(h.blocks) == 0 {
+		return r
+	}
+	first, last := h.blocks[0], h.blocks[len(h.blocks)-1]
+	seconds := max(last.time-first.time, 1)
+	r.FromBlock, r.ToBlock = hexutil.Uint64(first.number), hexutil.Uint64(last.number)
+	r.Seconds, r.GasUsed, r.GasPerSecond = hexutil.Uint64(seconds), hexutil.Uint64(h.gasUsed), hexutil.Uint64(h.gasUsed/seconds)
+
+	for addr, gas := range h.contracts {
+		c := HotContract{Address: addr, Gas: hexutil.Uint64(gas), GasPerSecond: hexutil.Uint64(gas / seconds)}
+		if h.gasUsed > 0 {
+			c.Share = float64(gas) / float64(h.gasUsed)
+		}
+		r.Contracts = append(r.Contracts, c)
+	}
+	sort.Slice(r.Contracts, func(i, j int) bool {
+		if r.Contracts[i].Gas != r.Contracts[j].Gas {
+			return r.Contracts[i].Gas > r.Contracts[j].Gas
+		}
+		return r.Contracts[i].Address.Hex() < r.Contracts[j].Address.Hex()
+	})
+	if len(r.Contracts) > top {
+		r.Contracts = r.Contracts[:top]
+	}
+	return r
+}
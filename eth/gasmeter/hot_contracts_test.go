@@ -0,0 +1,76 @@
+package gasmeter
+
+import (
+	"testing"
+	"time"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHotContracts(t *testing.T) {
+	a, b := libcommon.HexToAddress("0x0a"), libcommon.HexToAddress("0x0b")
+	meter := func(gasUsed uint64, contracts map[libcommon.Address]uint64) *GasMeter {
+		return &GasMeter{gasUsed: gasUsed, contracts: contracts}
+	}
+	h := NewHotContracts(10 * time.Second)
+	require.Empty(t, h.Report(10).Contracts)
+
+	h.Add(1, 100, meter(100_000, map[libcommon.Address]uint64{a: 50_000, b: 10_000}))
+	h.Add(2, 102, meter(100_000, map[libcommon.Address]uint64{a: 30_000}))
+	h.Add(3, 104, meter(100_000, map[libcommon.Address]uint64{b: 90_000}))
+	r := h.Report(10)
+	require.Equal(t, hexutil.Uint64(1), r.FromBlock)
+	require.Equal(t, hexutil.Uint64(3), r.ToBlock)
+	require.Equal(t, hexutil.Uint64(4), r.Seconds)
+	require.Equal(t, hexutil.Uint64(75_000), r.GasPerSecond)
+	require.Len(t, r.Contracts, 2)
+	require.Equal(t, b, r.Contracts[0].Address)
+	require.Equal(t, hexutil.Uint64(100_000), r.Contracts[0].Gas)
+	require.Equal(t, hexutil.Uint64(25_000), r.Contracts[0].GasPerSecond)
+	require.Equal(t, a, r.Contracts[1].Address)
+	require.Len(t, h.Report(1).Contracts, 1)
+
+	// block 3 re-executed after an unwind
+	h.Add(3, 104, meter(100_000, map[libcommon.Address]uint64{a: 20_000}))
+	r = h.Report(10)
+	require.Equal(t, a, r.Contracts[0].Address)
+	require.Equal(t, hexutil.Uint64(100_000), r.Contracts[0].Gas)
+	require.Equal(t, hexutil.Uint64(10_000), r.Contracts[1].Gas)
+
+	// blocks out of the window are dropped
+	h.Add(4, 111, meter(100_000, nil))
+	r = h.Report(10)
+	require.Equal(t, hexutil.Uint64(2), r.FromBlock)
+	require.Equal(t, hexutil.Uint64(50_000), r.Contracts[0].Gas)
+	require.Len(t, r.Contracts, 1)
+}
+
+func TestHotContractsAddTx(t *testing.T) {
+	a, b := libcommon.HexToAddress("0x0a"), libcommon.HexToAddress("0x0b")
+	h := NewHotContracts(10 * time.Second)
+	h.AddTx(1, 100, -1, 0, nil)
+	h.AddTx(1, 100, 0, 60_000, map[libcommon.Address]uint64{a: 50_000})
+	h.AddTx(1, 100, 1, 40_000, map[libcommon.Address]uint64{a: 10_000, b: 30_000})
+	h.AddTx(1, 100, 2, 0, nil) // block finalisation
+	h.AddTx(2, 102, -1, 0, nil)
+	h.AddTx(2, 102, 0, 100_000, map[libcommon.Address]uint64{b: 90_000})
+	r := h.Report(10)
+	require.Equal(t, hexutil.Uint64(1), r.FromBlock)
+	require.Equal(t, hexutil.Uint64(2), r.ToBlock)
+	require.Equal(t, hexutil.Uint64(200_000), r.GasUsed)
+	require.Equal(t, b, r.Contracts[0].Address)
+	require.Equal(t, hexutil.Uint64(120_000), r.Contracts[0].Gas)
+	require.Equal(t, hexutil.Uint64(60_000), r.Contracts[1].Gas)
+
+	// the initialisation of a block re-executed after an unwind drops its previous execution
+	h.AddTx(2, 102, -1, 0, nil)
+	r = h.Report(10)
+	require.Equal(t, hexutil.Uint64(100_000), r.GasUsed)
+	require.Equal(t, a, r.Contracts[0].Address)
+
+	// the metrics were updated by the first block only, the next update waits for the interval
+	require.Empty(t, h.metered)
+	require.False(t, h.reported.IsZero())
+}
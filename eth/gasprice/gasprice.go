@@ -44,6 +44,12 @@ type OracleBackend interface {
 	PendingBlockAndReceipts() (*types.Block, types.Receipts)
 }
 
+// TxPoolBackend is implemented by OracleBackends with access to the txpool, the pending transactions
+// of which are the best predictor of whether the next block is at capacity.
+type TxPoolBackend interface {
+	PendingTxCount(ctx context.Context) (int, error)
+}
+
 type Cache interface {
 	GetLatest() (libcommon.Hash, *big.Int)
 	SetLatest(hash libcommon.Hash, price *big.Int)
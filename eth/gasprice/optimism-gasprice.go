@@ -7,7 +7,11 @@ import (
 
 	"github.com/erigontech/erigon-lib/common"
 	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon-lib/opstack"
+	types2 "github.com/erigontech/erigon-lib/types"
+	"github.com/erigontech/erigon/consensus/misc"
 	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/params"
 	"github.com/erigontech/erigon/rpc"
 	"github.com/holiman/uint256"
 )
@@ -36,13 +40,19 @@ import (
 // rise in order to reach a market price that appropriately reflects demand. We accomplish this by
 // returning a suggestion that is a significant amount (10%) higher than the median effective
 // priority fee from the previous block.
+//
+// The txpool, where available, tells whether the next block is at capacity better than the last
+// block does. It also tells when the sequencer builds blocks with noTxPool: transactions wait in
+// the txpool while the blocks only take deposits, in L1 order, and the minimum is suggested
+// regardless of demand. Otherwise the minimum follows the L1 fee over the last blocks upwards.
 func (oracle *Oracle) SuggestOptimismPriorityFee(ctx context.Context, h *types.Header, headHash common.Hash) *big.Int {
-	suggestion := new(big.Int).Set(oracle.minSuggestedPriorityFee)
+	minimum := new(big.Int).Set(oracle.minSuggestedPriorityFee)
+	suggestion := new(big.Int).Set(minimum)
 
 	// find the maximum gas used by any of the transactions in the block to use as the capacity
 	// margin
 	block, err := oracle.backend.BlockByNumber(ctx, rpc.BlockNumber(h.Number.Int64()))
-	if err != nil {
+	if block == nil || err != nil {
 		log.Error("failed to get block", "err", err)
 		return suggestion
 	}
@@ -64,24 +74,45 @@ func (oracle *Oracle) SuggestOptimismPriorityFee(ctx context.Context, h *types.H
 		return suggestion
 	}
 
-	if h.GasUsed+maxTxGasUsed > h.GasLimit {
-		// A block is "at capacity" if, when it is built, there is a pending tx in the txpool that
-		// could not be included because the block's gas limit would be exceeded. Since we don't
-		// have access to the txpool, we instead adopt the following heuristic: consider a block as
-		// at capacity if the total gas consumed by its transactions is within max-tx-gas-used of
-		// the block limit, where max-tx-gas-used is the most gas used by any one transaction
-		// within the block. This heuristic is almost perfectly accurate when transactions always
-		// consume the same amount of gas, but becomes less accurate as tx gas consumption begins
-		// to vary. The typical error is we assume a block is at capacity when it was not because
-		// max-tx-gas-used will in most cases over-estimate the "capacity margin". But it's better
-		// to err on the side of returning a higher-than-needed suggestion than a lower-than-needed
-		// one in order to satisfy our desire for high chance of inclusion and rising fees under
-		// high demand.
-		block, err := oracle.backend.BlockByNumber(ctx, rpc.BlockNumber(h.Number.Int64()))
-		if block == nil || err != nil {
-			log.Error("failed to get last block", "err", err)
-			return suggestion
+	sample := oracle.sampleOptimismBlocks(ctx, block)
+	pending, knownPending := oracle.pendingTxCount(ctx)
+	if knownPending && pending > 0 && !sample.userTxs {
+		// Transactions are waiting in the txpool, yet none of the recent blocks took any: the sequencer builds
+		// blocks with noTxPool and user transactions only get in as deposits, first in first out. The tip has no
+		// effect on inclusion then, so neither congestion nor L1 fees raise the suggestion.
+		oracle.cache.SetLatest(headHash, suggestion)
+		return new(big.Int).Set(suggestion)
+	}
+
+	// While L1 fees rise the batcher falls behind and the sequencer throttles the data it puts into blocks, so
+	// blocks fill up sooner: the minimum suggestion follows the trend of the L1 fee, up to twice the minimum.
+	if sample.l1FeeNow != nil && sample.l1FeeThen != nil && !sample.l1FeeThen.IsZero() && sample.l1FeeNow.Cmp(sample.l1FeeThen) > 0 {
+		minimum.Mul(minimum, sample.l1FeeNow.ToBig())
+		minimum.Div(minimum, sample.l1FeeThen.ToBig())
+		if limit := new(big.Int).Lsh(oracle.minSuggestedPriorityFee, 1); minimum.Cmp(limit) > 0 {
+			minimum = limit
 		}
+		suggestion.Set(minimum)
+	}
+
+	// A block is "at capacity" if, when it is built, there is a pending tx in the txpool that
+	// could not be included because the block's gas limit would be exceeded. With access to the
+	// txpool, that is the case when it has more pending transactions than the next block can take,
+	// assuming each of them uses as much gas as the biggest one of the last block. Without it, we
+	// instead adopt the following heuristic: consider a block as at capacity if the total gas
+	// consumed by its transactions is within max-tx-gas-used of the block limit, where
+	// max-tx-gas-used is the most gas used by any one transaction within the block. This heuristic
+	// is almost perfectly accurate when transactions always consume the same amount of gas, but
+	// becomes less accurate as tx gas consumption begins to vary. The typical error is we assume a
+	// block is at capacity when it was not because max-tx-gas-used will in most cases over-estimate
+	// the "capacity margin". But it's better to err on the side of returning a higher-than-needed
+	// suggestion than a lower-than-needed one in order to satisfy our desire for high chance of
+	// inclusion and rising fees under high demand.
+	atCapacity := h.GasUsed+maxTxGasUsed > h.GasLimit
+	if knownPending && uint64(pending) > h.GasLimit/max(maxTxGasUsed, params.TxGas) {
+		atCapacity = true
+	}
+	if atCapacity {
 		baseFee := block.BaseFee()
 		txs := block.Transactions()
 		if len(txs) == 0 {
@@ -110,3 +141,88 @@ func (oracle *Oracle) SuggestOptimismPriorityFee(ctx context.Context, h *types.H
 
 	return new(big.Int).Set(suggestion)
 }
+
+// optimismBaseFeeLookahead is the number of blocks for which OptimismBaseFee keeps up with a rising base fee
+const optimismBaseFeeLookahead = 5
+
+// OptimismBaseFee returns the base fee to add to the suggested tip for the gas price of legacy transactions. It
+// follows the trajectory of the base fee: the fee of the block after h, and while the base fee rises the fee in
+// optimismBaseFeeLookahead blocks at the same rate, so that the gas price still holds when the transaction is
+// included a few blocks later.
+func (oracle *Oracle) OptimismBaseFee(h *types.Header) *big.Int {
+	if h.BaseFee == nil {
+		return nil
+	}
+	next := misc.CalcBaseFee(oracle.backend.ChainConfig(), h, h.Time+1)
+	fee := new(big.Int).Set(next)
+	if next.Cmp(h.BaseFee) <= 0 || h.BaseFee.Sign() == 0 {
+		return fee
+	}
+	for i := 1; i < optimismBaseFeeLookahead; i++ {
+		fee.Mul(fee, next)
+		fee.Div(fee, h.BaseFee)
+	}
+	return fee
+}
+
+// referenceRollupCostData is the L1 data of a plain transfer, the L1 fee of which is sampled for the L1 fee trend
+var referenceRollupCostData = types2.RollupCostData{Zeroes: 10, Ones: 100, FastLzSize: 100}
+
+type optimismSample struct {
+	userTxs             bool         // any of the sampled blocks has transactions other than deposits
+	l1FeeNow, l1FeeThen *uint256.Int // L1 fee of a plain transfer in the last and the first sampled block
+}
+
+// sampleOptimismBlocks looks at the last checkBlocks blocks up to head, the L1 fees of which are read from their
+// L1 attributes transactions
+func (oracle *Oracle) sampleOptimismBlocks(ctx context.Context, head *types.Block) optimismSample {
+	var sample optimismSample
+	config := oracle.backend.ChainConfig()
+	number := head.NumberU64()
+	for i := 0; i < oracle.checkBlocks && i <= int(number); i++ {
+		block := head
+		if i > 0 {
+			var err error
+			if block, err = oracle.backend.BlockByNumber(ctx, rpc.BlockNumber(number-uint64(i))); block == nil || err != nil {
+				break
+			}
+		}
+		txs := block.Transactions()
+		for _, txn := range txs {
+			if txn.Type() != types.DepositTxType {
+				sample.userTxs = true
+				break
+			}
+		}
+		if len(txs) == 0 || txs[0].Type() != types.DepositTxType {
+			continue
+		}
+		l1Params, err := opstack.ExtractL1GasParams(config, block.Time(), txs[0].GetData())
+		if err != nil || l1Params.CostFunc == nil {
+			continue
+		}
+		fee, _ := l1Params.CostFunc(referenceRollupCostData)
+		if fee == nil {
+			continue
+		}
+		if sample.l1FeeNow == nil {
+			sample.l1FeeNow = fee
+		}
+		sample.l1FeeThen = fee
+	}
+	return sample
+}
+
+// pendingTxCount returns the number of pending transactions in the txpool, if the backend knows the txpool
+func (oracle *Oracle) pendingTxCount(ctx context.Context) (int, bool) {
+	txPool, ok := oracle.backend.(TxPoolBackend)
+	if !ok {
+		return 0, false
+	}
+	pending, err := txPool.PendingTxCount(ctx)
+	if err != nil {
+		log.Debug("failed to get pending transactions of the txpool", "err", err)
+		return 0, false
+	}
+	return pending, true
+}
@@ -23,6 +23,7 @@ import (
 
 	"github.com/erigontech/erigon-lib/chain"
 	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/opstack"
 	"github.com/erigontech/erigon/core"
 	"github.com/erigontech/erigon/core/types"
 	"github.com/erigontech/erigon/crypto"
@@ -60,6 +61,7 @@ func (c *testCache) SetLatest(hash libcommon.Hash, price *big.Int) {
 
 type opTestBackend struct {
 	block    *types.Block
+	blocks   []*types.Block // by number, if set
 	receipts []*types.Receipt
 }
 
@@ -68,6 +70,9 @@ func (b *opTestBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumb
 }
 
 func (b *opTestBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
+	if b.blocks != nil {
+		return b.blocks[number], nil
+	}
 	return b.block, nil
 }
 
@@ -155,3 +160,109 @@ func TestSuggestOptimismPriorityFee(t *testing.T) {
 		}
 	}
 }
+
+type opTxPoolTestBackend struct {
+	*opTestBackend
+	pending int
+}
+
+func (b *opTxPoolTestBackend) PendingTxCount(ctx context.Context) (int, error) {
+	return b.pending, nil
+}
+
+// l1InfoDeposit returns the pre-Ecotone L1 attributes deposit of a block with the given L1 base fee
+func l1InfoDeposit(l1BaseFee uint64) types.Transaction {
+	data := append([]byte{}, opstack.BedrockL1AttributesSelector...)
+	for i := 0; i < 8; i++ {
+		var arg uint64
+		switch i {
+		case 2:
+			arg = l1BaseFee
+		case 7:
+			arg = 1_000_000 // scalar of 1
+		}
+		data = append(data, new(big.Int).SetUint64(arg).FillBytes(make([]byte, 32))...)
+	}
+	return &types.DepositTx{To: &opstack.L1BlockAddr, Value: uint256.NewInt(0), Data: data}
+}
+
+func TestSuggestOptimismPriorityFeeSignals(t *testing.T) {
+	minSuggestion := new(big.Int).SetUint64(1e8 * params.Wei)
+	userTx := newOpTestBackend(t, []testTxData{{params.GWei, 21000}}).block.Transactions()[0]
+	newBlock := func(number uint64, txs ...types.Transaction) *types.Block {
+		header := &types.Header{Number: new(big.Int).SetUint64(number), GasLimit: blockGasLimit, GasUsed: 21000}
+		return types.NewBlock(header, txs, nil, nil, nil)
+	}
+	var cases = []struct {
+		name    string
+		blocks  []*types.Block
+		txPool  bool
+		pending int
+		want    *big.Int
+	}{
+		{
+			// more pending transactions than fit into the next block: 10% over the median
+			name:    "txpool pressure",
+			blocks:  []*types.Block{newBlock(0, userTx)},
+			txPool:  true,
+			pending: 10,
+			want:    big.NewInt(1100000000),
+		},
+		{
+			name:    "txpool under capacity",
+			blocks:  []*types.Block{newBlock(0, userTx)},
+			txPool:  true,
+			pending: 2,
+			want:    minSuggestion,
+		},
+		{
+			name:   "rising L1 fee",
+			blocks: []*types.Block{newBlock(0, l1InfoDeposit(1e9), userTx), newBlock(1, l1InfoDeposit(15e8), userTx)},
+			want:   big.NewInt(15e7),
+		},
+		{
+			name:   "rising L1 fee, capped",
+			blocks: []*types.Block{newBlock(0, l1InfoDeposit(1e9), userTx), newBlock(1, l1InfoDeposit(5e9), userTx)},
+			want:   big.NewInt(2e8),
+		},
+		{
+			name:   "falling L1 fee",
+			blocks: []*types.Block{newBlock(0, l1InfoDeposit(2e9), userTx), newBlock(1, l1InfoDeposit(1e9), userTx)},
+			want:   minSuggestion,
+		},
+		{
+			// transactions wait in the txpool while blocks only take deposits: inclusion is FIFO
+			name:    "noTxPool",
+			blocks:  []*types.Block{newBlock(0, l1InfoDeposit(1e9)), newBlock(1, l1InfoDeposit(5e9))},
+			txPool:  true,
+			pending: 10,
+			want:    minSuggestion,
+		},
+	}
+	for _, c := range cases {
+		head := c.blocks[len(c.blocks)-1]
+		var backend OracleBackend = &opTestBackend{block: head, blocks: c.blocks, receipts: []*types.Receipt{{GasUsed: 21000}}}
+		if c.txPool {
+			backend = &opTxPoolTestBackend{opTestBackend: backend.(*opTestBackend), pending: c.pending}
+		}
+		oracle := NewOracle(backend, gaspricecfg.Config{Blocks: 20, MinSuggestedPriorityFee: minSuggestion}, &testCache{})
+		got := oracle.SuggestOptimismPriorityFee(context.Background(), head.Header(), head.Hash())
+		if got.Cmp(c.want) != 0 {
+			t.Errorf("Gas price mismatch for test case %s: want %d, got %d", c.name, c.want, got)
+		}
+	}
+}
+
+func TestOptimismBaseFee(t *testing.T) {
+	oracle := NewOracle(&opTestBackend{}, gaspricecfg.Config{}, &testCache{})
+	// gas target of 30M/50, 10% over it raises the base fee by 1% per block
+	header := &types.Header{Number: big.NewInt(10), GasLimit: 30_000_000, GasUsed: 660_000, BaseFee: big.NewInt(1e9)}
+	if got, want := oracle.OptimismBaseFee(header), big.NewInt(1051010050); got.Cmp(want) != 0 {
+		t.Errorf("rising base fee: want %d, got %d", want, got)
+	}
+	// an empty block lowers the base fee by 1/10, the next block's fee is suggested
+	header.GasUsed = 0
+	if got, want := oracle.OptimismBaseFee(header), big.NewInt(9e8); got.Cmp(want) != 0 {
+		t.Errorf("falling base fee: want %d, got %d", want, got)
+	}
+}
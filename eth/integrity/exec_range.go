@@ -0,0 +1,236 @@
+package integrity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/holiman/uint256"
+
+	"github.com/erigontech/erigon-lib/chain"
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/consensus"
+	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/core/state"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/core/types/accounts"
+	"github.com/erigontech/erigon/core/vm"
+	"github.com/erigontech/erigon/eth/stagedsync"
+	"github.com/erigontech/erigon/turbo/rpchelper"
+	"github.com/erigontech/erigon/turbo/services"
+)
+
+// maxReportedStateDiffs - how many post-state mismatches of one block are kept for the error message
+const maxReportedStateDiffs = 16
+
+// ExecRange - re-executes blocks [from, to] on top of historical state and checks results against stored data.
+// It's read-only and doesn't depend on stage progress: blocks are read by blockReader (snapshots or db),
+// pre-state of block N is history at N, and every state write produced by execution is compared with history at N+1.
+// Receipts root, bloom, gas used and txs root are checked against header of block. With checkStateRoot, the state
+// of the range is kept in a temporary db in tmpDir (loaded from history at `from`, which takes a pass over the whole
+// state) and the state root of every block is computed and checked against its header: a change recorded in history
+// but not produced by execution goes unnoticed otherwise. Pre-bedrock blocks are imported, not executed, so a range
+// ending before bedrock is an error and the pre-bedrock part of a range is skipped.
+func ExecRange(ctx context.Context, db kv.RoDB, blockReader services.FullBlockReader, chainConfig *chain.Config, engine consensus.Engine, from, to uint64, historyV3 bool, checkStateRoot bool, tmpDir string, failFast bool, logger log.Logger) error {
+	if to < from {
+		return fmt.Errorf("[integrity] ExecRange: invalid range %d-%d", from, to)
+	}
+	if chainConfig.BedrockBlock != nil && chainConfig.IsOptimismPreBedrock(to) {
+		return fmt.Errorf("[integrity] ExecRange: range %d-%d is before bedrock block %d, legacy blocks can't be re-executed", from, to, chainConfig.BedrockBlock.Uint64())
+	}
+	if chainConfig.BedrockBlock != nil && chainConfig.IsOptimismPreBedrock(from) {
+		from = chainConfig.BedrockBlock.Uint64()
+		logger.Info("[integrity] ExecRange: skipping pre-bedrock blocks", "from", from)
+	}
+
+	var roots *stateRootChecker
+	if checkStateRoot {
+		var err error
+		if roots, err = newStateRootChecker(historyV3, tmpDir, logger); err != nil {
+			return err
+		}
+		defer roots.Close()
+	}
+
+	logEvery := time.NewTicker(20 * time.Second)
+	defer logEvery.Stop()
+
+	var failed int
+	for blockNum := from; blockNum <= to; blockNum++ {
+		if err := db.View(ctx, func(tx kv.Tx) error {
+			return execAndCheckBlock(ctx, tx, blockReader, chainConfig, engine, blockNum, historyV3, roots, logger)
+		}); err != nil {
+			if failFast || errors.Is(err, errHistoryStateRoot) {
+				return err
+			}
+			failed++
+			logger.Error("[integrity] ExecRange", "block", blockNum, "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-logEvery.C:
+			logger.Info("[integrity] ExecRange", "block", fmt.Sprintf("%dK/%dK", blockNum/1000, to/1000), "failed", failed)
+		default:
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("[integrity] ExecRange: %d blocks failed verification in range %d-%d", failed, from, to)
+	}
+	logger.Info("[integrity] ExecRange: done", "from", from, "to", to)
+	return nil
+}
+
+func execAndCheckBlock(ctx context.Context, tx kv.Tx, blockReader services.FullBlockReader, chainConfig *chain.Config, engine consensus.Engine, blockNum uint64, historyV3 bool, roots *stateRootChecker, logger log.Logger) error {
+	hash, err := blockReader.CanonicalHash(ctx, tx, blockNum)
+	if err != nil {
+		return err
+	}
+	block, _, err := blockReader.BlockWithSenders(ctx, tx, hash, blockNum)
+	if err != nil {
+		return err
+	}
+	if block == nil {
+		return fmt.Errorf("block not found: %d", blockNum)
+	}
+
+	stateReader, err := rpchelper.CreateHistoryStateReader(tx, blockNum, 0, historyV3, chainConfig.ChainName)
+	if err != nil {
+		return err
+	}
+	postStateReader, err := rpchelper.CreateHistoryStateReader(tx, blockNum+1, 0, historyV3, chainConfig.ChainName)
+	if err != nil {
+		return err
+	}
+	checker := newPostStateChecker(postStateReader, nil)
+	if roots != nil {
+		parent, err := blockReader.Header(ctx, tx, block.ParentHash(), blockNum-1)
+		if err != nil {
+			return err
+		}
+		if parent == nil {
+			return fmt.Errorf("parent header not found: %d", blockNum-1)
+		}
+		if err := roots.begin(ctx, tx, blockNum, parent.Root); err != nil {
+			return err
+		}
+		checker.next = roots
+	}
+
+	getHeader := func(hash libcommon.Hash, number uint64) *types.Header {
+		h, _ := blockReader.Header(ctx, tx, hash, number)
+		return h
+	}
+	vmConfig := vm.Config{}
+	execRs, err := core.ExecuteBlockEphemerally(chainConfig, &vmConfig, core.GetHashFn(block.Header(), getHeader), engine, block, stateReader, checker,
+		stagedsync.NewChainReaderImpl(chainConfig, tx, blockReader, logger), nil, logger)
+	if err != nil {
+		return err
+	}
+	if execRs.TxRoot != block.TxHash() {
+		return fmt.Errorf("mismatched txs root: %x != %x", execRs.TxRoot, block.TxHash())
+	}
+	if checker.err != nil {
+		return checker.err
+	}
+	if len(checker.diffs) > 0 {
+		return fmt.Errorf("post-state differs from history in %d places, first: %v", checker.total, checker.diffs)
+	}
+	if roots != nil {
+		return roots.verify(ctx, block.Root())
+	}
+	return nil
+}
+
+// postStateChecker - StateWriter which compares every write with expected post-state and passes it to next, if any
+type postStateChecker struct {
+	expected state.StateReader
+	next     state.StateWriter
+	diffs    []string
+	total    int
+	err      error
+}
+
+func newPostStateChecker(expected state.StateReader, next state.StateWriter) *postStateChecker {
+	return &postStateChecker{expected: expected, next: next}
+}
+
+func (c *postStateChecker) addDiff(format string, args ...interface{}) {
+	c.total++
+	if len(c.diffs) < maxReportedStateDiffs {
+		c.diffs = append(c.diffs, fmt.Sprintf(format, args...))
+	}
+}
+
+func (c *postStateChecker) UpdateAccountData(address libcommon.Address, original, account *accounts.Account) error {
+	expected, err := c.expected.ReadAccountData(address)
+	if err != nil {
+		c.err = err
+		return err
+	}
+	if expected == nil {
+		c.addDiff("account %x: exists after execution, but not in history", address)
+		return nil
+	}
+	if expected.Nonce != account.Nonce || expected.CodeHash != account.CodeHash || !expected.Balance.Eq(&account.Balance) {
+		c.addDiff("account %x: nonce=%d balance=%d codeHash=%x, history: nonce=%d balance=%d codeHash=%x",
+			address, account.Nonce, &account.Balance, account.CodeHash, expected.Nonce, &expected.Balance, expected.CodeHash)
+	}
+	if c.next != nil {
+		return c.next.UpdateAccountData(address, original, account)
+	}
+	return nil
+}
+
+func (c *postStateChecker) UpdateAccountCode(address libcommon.Address, incarnation uint64, codeHash libcommon.Hash, code []byte) error {
+	// code is addressed by hash, which is checked by UpdateAccountData
+	if c.next != nil {
+		return c.next.UpdateAccountCode(address, incarnation, codeHash, code)
+	}
+	return nil
+}
+
+func (c *postStateChecker) DeleteAccount(address libcommon.Address, original *accounts.Account) error {
+	expected, err := c.expected.ReadAccountData(address)
+	if err != nil {
+		c.err = err
+		return err
+	}
+	if expected != nil {
+		c.addDiff("account %x: deleted by execution, but exists in history", address)
+	}
+	if c.next != nil {
+		return c.next.DeleteAccount(address, original)
+	}
+	return nil
+}
+
+func (c *postStateChecker) WriteAccountStorage(address libcommon.Address, incarnation uint64, key *libcommon.Hash, original, value *uint256.Int) error {
+	enc, err := c.expected.ReadAccountStorage(address, incarnation, key)
+	if err != nil {
+		c.err = err
+		return err
+	}
+	expected := new(uint256.Int).SetBytes(enc)
+	if !expected.Eq(value) {
+		c.addDiff("storage %x/%x: %d, history: %d", address, *key, value, expected)
+	}
+	if c.next != nil {
+		return c.next.WriteAccountStorage(address, incarnation, key, original, value)
+	}
+	return nil
+}
+
+func (c *postStateChecker) CreateContract(address libcommon.Address) error {
+	if c.next != nil {
+		return c.next.CreateContract(address)
+	}
+	return nil
+}
+
+func (c *postStateChecker) WriteChangeSets() error { return nil }
+func (c *postStateChecker) WriteHistory() error    { return nil }
@@ -0,0 +1,286 @@
+package integrity
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/c2h5oh/datasize"
+	"github.com/holiman/uint256"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/length"
+	"github.com/erigontech/erigon-lib/etl"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/dbutils"
+	"github.com/erigontech/erigon-lib/kv/mdbx"
+	"github.com/erigontech/erigon-lib/kv/order"
+	"github.com/erigontech/erigon-lib/kv/rawdbv3"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/core/state"
+	"github.com/erigontech/erigon/core/types/accounts"
+	"github.com/erigontech/erigon/crypto"
+	"github.com/erigontech/erigon/eth/stagedsync"
+	"github.com/erigontech/erigon/turbo/trie"
+)
+
+const execRangeLogPrefix = "integrity"
+
+// errHistoryStateRoot - the state reconstructed from history doesn't have the root of its block: nothing can be
+// verified against it
+var errHistoryStateRoot = errors.New("state root of history doesn't match the header")
+
+// stateRootChecker - the state of ExecRange in a temporary db, in the hashed state tables which the state root is
+// computed from. It's loaded from history at the first block and then updated by the writes of execution (it's
+// the StateWriter after postStateChecker), so the root of every block is computed incrementally, as by
+// IntermediateHashes stage, and compared with the header. After a failed block the state is loaded again.
+type stateRootChecker struct {
+	db        kv.RwDB
+	tx        kv.RwTx
+	historyV3 bool
+	tmpDir    string
+	logger    log.Logger
+
+	loaded bool             // the state is the post-state of the previous block
+	rl     *trie.RetainList // hashed keys written by the block
+}
+
+func newStateRootChecker(historyV3 bool, tmpDir string, logger log.Logger) (*stateRootChecker, error) {
+	db := mdbx.NewMDBX(logger).InMem(tmpDir).GrowthStep(64 * datasize.MB).MapSize(512 * datasize.GB).MustOpen()
+	tx, err := db.BeginRw(context.Background())
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &stateRootChecker{db: db, tx: tx, historyV3: historyV3, tmpDir: tmpDir, logger: logger}, nil
+}
+
+func (c *stateRootChecker) Close() {
+	c.tx.Rollback()
+	c.db.Close()
+}
+
+// begin - the pre-state of blockNum. Unless it's the post-state of the previous block, it's loaded from history,
+// and has to have the root of the parent header.
+func (c *stateRootChecker) begin(ctx context.Context, tx kv.Tx, blockNum uint64, parentRoot libcommon.Hash) error {
+	c.rl = trie.NewRetainList(0)
+	if c.loaded {
+		c.loaded = false // until the root of the block is verified
+		return nil
+	}
+	if err := c.load(ctx, tx, blockNum); err != nil {
+		return err
+	}
+	root, err := stagedsync.RegenerateIntermediateHashes(execRangeLogPrefix, c.tx, stagedsync.StageTrieCfg(c.db, false, true, false, c.tmpDir, nil, nil, false, nil), parentRoot, ctx, c.logger)
+	if err != nil {
+		return err
+	}
+	if root != parentRoot {
+		return fmt.Errorf("%w: %x before block %d, parent header: %x", errHistoryStateRoot, root, blockNum, parentRoot)
+	}
+	return nil
+}
+
+// verify - the root of the state after the writes of the block has to be the root of its header
+func (c *stateRootChecker) verify(ctx context.Context, root libcommon.Hash) error {
+	hash, err := stagedsync.IncrementIntermediateHashesOfKeys(execRangeLogPrefix, c.tx, c.rl, c.tmpDir, false, root, ctx.Done(), c.logger)
+	if err != nil {
+		return err
+	}
+	if hash != root {
+		return fmt.Errorf("mismatched state root: %x != %x", hash, root)
+	}
+	c.loaded = true
+	return nil
+}
+
+func (c *stateRootChecker) load(ctx context.Context, tx kv.Tx, blockNum uint64) error {
+	for _, table := range []string{kv.HashedAccounts, kv.HashedStorage, kv.TrieOfAccounts, kv.TrieOfStorage} {
+		if err := c.tx.ClearBucket(table); err != nil {
+			return err
+		}
+	}
+	c.logger.Info("[integrity] ExecRange: loading state from history", "block", blockNum)
+	accountsCollector := etl.NewCollector(execRangeLogPrefix, c.tmpDir, etl.NewSortableBuffer(etl.BufferOptimalSize), c.logger)
+	defer accountsCollector.Close()
+	storageCollector := etl.NewCollector(execRangeLogPrefix, c.tmpDir, etl.NewSortableBuffer(etl.BufferOptimalSize), c.logger)
+	defer storageCollector.Close()
+	args := etl.TransformArgs{Quit: ctx.Done()}
+
+	if !c.historyV3 {
+		if err := collectStateAsOf(tx, blockNum, accountsCollector, storageCollector); err != nil {
+			return err
+		}
+		if err := accountsCollector.Load(c.tx, kv.HashedAccounts, etl.IdentityLoadFunc, args); err != nil {
+			return err
+		}
+		return storageCollector.Load(c.tx, kv.HashedStorage, etl.IdentityLoadFunc, args)
+	}
+
+	ttx, ok := tx.(kv.TemporalTx)
+	if !ok {
+		return errors.New("history v3 requires a temporal db")
+	}
+	txNum, err := rawdbv3.TxNums.Min(tx, blockNum)
+	if err != nil {
+		return err
+	}
+	if err := collectAccountsV3(ttx, txNum, accountsCollector); err != nil {
+		return err
+	}
+	if err := accountsCollector.Load(c.tx, kv.HashedAccounts, etl.IdentityLoadFunc, args); err != nil {
+		return err
+	}
+	// incarnations of the accounts are read from the hashed state
+	if err := collectStorageV3(ttx, txNum, c.tx, storageCollector); err != nil {
+		return err
+	}
+	return storageCollector.Load(c.tx, kv.HashedStorage, etl.IdentityLoadFunc, args)
+}
+
+// collectStateAsOf - hashed state before blockNum from the history of plain state
+func collectStateAsOf(tx kv.Tx, blockNum uint64, accountsCollector, storageCollector *etl.Collector) error {
+	var acc accounts.Account
+	return state.WalkAsOfAccounts(tx, libcommon.Address{}, blockNum, func(k, v []byte) (bool, error) {
+		if len(k) > length.Addr {
+			return true, nil
+		}
+		if err := acc.DecodeForStorage(v); err != nil {
+			return false, fmt.Errorf("decoding %x for %x: %w", v, k, err)
+		}
+		addrHash := crypto.Keccak256Hash(k)
+		if err := accountsCollector.Collect(addrHash[:], v); err != nil {
+			return false, err
+		}
+		if acc.Incarnation == 0 {
+			return true, nil
+		}
+		incarnation := acc.Incarnation
+		if err := state.WalkAsOfStorage(tx, libcommon.BytesToAddress(k), incarnation, libcommon.Hash{}, blockNum, func(_, loc, vs []byte) (bool, error) {
+			return true, storageCollector.Collect(dbutils.GenerateCompositeStorageKey(addrHash, incarnation, crypto.Keccak256Hash(loc)), vs)
+		}); err != nil {
+			return false, fmt.Errorf("walking over storage for %x: %w", k, err)
+		}
+		return true, nil
+	})
+}
+
+func collectAccountsV3(ttx kv.TemporalTx, txNum uint64, collector *etl.Collector) error {
+	it, err := ttx.DomainRange(kv.AccountsDomain, nil, nil, txNum, order.Asc, kv.Unlim)
+	if err != nil {
+		return err
+	}
+	var acc accounts.Account
+	for it.HasNext() {
+		k, v, err := it.Next()
+		if err != nil {
+			return err
+		}
+		if len(v) == 0 {
+			continue
+		}
+		if err := accounts.DeserialiseV3(&acc, v); err != nil {
+			return fmt.Errorf("decoding %x for %x: %w", v, k, err)
+		}
+		enc := make([]byte, acc.EncodingLengthForStorage())
+		acc.EncodeForStorage(enc)
+		addrHash := crypto.Keccak256Hash(k)
+		if err := collector.Collect(addrHash[:], enc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func collectStorageV3(ttx kv.TemporalTx, txNum uint64, hashed kv.Tx, collector *etl.Collector) error {
+	it, err := ttx.DomainRange(kv.StorageDomain, nil, nil, txNum, order.Asc, kv.Unlim)
+	if err != nil {
+		return err
+	}
+	var addr []byte
+	var addrHash libcommon.Hash
+	var incarnation uint64
+	for it.HasNext() {
+		k, v, err := it.Next()
+		if err != nil {
+			return err
+		}
+		if len(v) == 0 {
+			continue
+		}
+		if !bytes.Equal(addr, k[:length.Addr]) {
+			addr, addrHash, incarnation = libcommon.Copy(k[:length.Addr]), crypto.Keccak256Hash(k[:length.Addr]), 0
+			enc, err := hashed.GetOne(kv.HashedAccounts, addrHash[:])
+			if err != nil {
+				return err
+			}
+			if len(enc) > 0 {
+				if incarnation, err = accounts.DecodeIncarnationFromStorage(enc); err != nil {
+					return err
+				}
+			}
+		}
+		if incarnation == 0 { // storage of an account which doesn't exist
+			continue
+		}
+		if err := collector.Collect(dbutils.GenerateCompositeStorageKey(addrHash, incarnation, crypto.Keccak256Hash(k[length.Addr:])), v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *stateRootChecker) UpdateAccountData(address libcommon.Address, original, account *accounts.Account) error {
+	addrHash := crypto.Keccak256Hash(address[:])
+	prev, err := c.tx.GetOne(kv.HashedAccounts, addrHash[:])
+	if err != nil {
+		return err
+	}
+	enc := make([]byte, account.EncodingLengthForStorage())
+	account.EncodeForStorage(enc)
+	c.rl.AddKeyWithMarker(addrHash[:], len(prev) == 0)
+	return c.tx.Put(kv.HashedAccounts, addrHash[:], enc)
+}
+
+func (c *stateRootChecker) UpdateAccountCode(address libcommon.Address, incarnation uint64, codeHash libcommon.Hash, code []byte) error {
+	return nil
+}
+
+func (c *stateRootChecker) DeleteAccount(address libcommon.Address, original *accounts.Account) error {
+	addrHash := crypto.Keccak256Hash(address[:])
+	c.rl.AddKeyWithMarker(addrHash[:], false)
+	if err := c.tx.Delete(kv.HashedAccounts, addrHash[:]); err != nil {
+		return err
+	}
+	// the storage stays under its incarnation, as in the hashed state of the chain, but its trie is gone
+	var deleted [][]byte
+	if err := c.tx.ForPrefix(kv.TrieOfStorage, addrHash[:], func(k, _ []byte) error {
+		deleted = append(deleted, libcommon.Copy(k))
+		return nil
+	}); err != nil {
+		return err
+	}
+	for _, k := range deleted {
+		if err := c.tx.Delete(kv.TrieOfStorage, k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *stateRootChecker) WriteAccountStorage(address libcommon.Address, incarnation uint64, key *libcommon.Hash, original, value *uint256.Int) error {
+	k := dbutils.GenerateCompositeStorageKey(crypto.Keccak256Hash(address[:]), incarnation, crypto.Keccak256Hash(key[:]))
+	prev, err := c.tx.GetOne(kv.HashedStorage, k)
+	if err != nil {
+		return err
+	}
+	c.rl.AddKeyWithMarker(k, len(prev) == 0)
+	if value.IsZero() {
+		return c.tx.Delete(kv.HashedStorage, k)
+	}
+	return c.tx.Put(kv.HashedStorage, k, value.Bytes())
+}
+
+func (c *stateRootChecker) CreateContract(address libcommon.Address) error { return nil }
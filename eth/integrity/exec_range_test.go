@@ -0,0 +1,86 @@
+package integrity
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/dbutils"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/core/rawdb"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/crypto"
+	"github.com/erigontech/erigon/params"
+	"github.com/erigontech/erigon/rlp"
+	"github.com/erigontech/erigon/turbo/stages/mock"
+)
+
+func TestExecRange(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	// contract storing its calldata at slot 0 and selfdestructing on empty calldata
+	contract := libcommon.Address{0xcc}
+	gspec := &types.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: types.GenesisAlloc{
+			from:     {Balance: big.NewInt(1_000_000_000_000_000)},
+			contract: {Code: []byte{0x36, 0x60, 0x0c, 0x57, 0x30, 0xff, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x5b, 0x60, 0x00, 0x35, 0x60, 0x00, 0x55, 0x00}, Storage: map[libcommon.Hash]libcommon.Hash{{}: {1}}},
+		},
+	}
+	m := mock.MockWithGenesis(t, gspec, key, false)
+	signer := types.LatestSignerForChainID(m.ChainConfig.ChainID)
+
+	chain, err := core.GenerateChain(m.ChainConfig, m.Genesis, m.Engine, m.DB, 6, func(i int, b *core.BlockGen) {
+		send := func(to libcommon.Address, value uint64, data []byte) {
+			txn, err := types.SignTx(types.NewTransaction(b.TxNonce(from), to, uint256.NewInt(value), 100_000, uint256.NewInt(1_000_000_000), data), *signer, key)
+			require.NoError(t, err)
+			b.AddTx(txn)
+		}
+		send(libcommon.Address{byte(i + 1)}, 1_000, nil)
+		switch i {
+		case 1:
+			send(contract, 0, libcommon.Hash{31: 2}.Bytes())
+		case 2:
+			send(contract, 0, make([]byte, 32)) // clears the slot
+		case 3:
+			send(contract, 0, libcommon.Hash{31: 3}.Bytes())
+		case 4:
+			send(contract, 0, nil) // selfdestruct
+		}
+	})
+	require.NoError(t, err)
+	require.NoError(t, m.InsertChain(chain))
+
+	logger := log.New()
+	require.NoError(t, ExecRange(m.Ctx, m.DB, m.BlockReader, m.ChainConfig, m.Engine, 1, 6, m.HistoryV3, true, t.TempDir(), true, logger))
+
+	// a header with another state root: the block fails, the state of the next one can't be loaded from history
+	header := chain.Headers[2]
+	tampered := types.CopyHeader(header)
+	tampered.Root = libcommon.Hash{1}
+	enc, err := rlp.EncodeToBytes(tampered)
+	require.NoError(t, err)
+	require.NoError(t, m.DB.Update(m.Ctx, func(tx kv.RwTx) error {
+		if err := tx.Put(kv.Headers, dbutils.HeaderKey(3, header.Hash()), enc); err != nil {
+			return err
+		}
+		require.Equal(t, tampered.Root, rawdb.ReadHeader(tx, header.Hash(), 3).Root)
+		return nil
+	}))
+	err = ExecRange(m.Ctx, m.DB, m.BlockReader, m.ChainConfig, m.Engine, 1, 6, m.HistoryV3, true, t.TempDir(), true, logger)
+	require.ErrorContains(t, err, "mismatched state root")
+	err = ExecRange(m.Ctx, m.DB, m.BlockReader, m.ChainConfig, m.Engine, 1, 6, m.HistoryV3, true, t.TempDir(), false, logger)
+	require.ErrorIs(t, err, errHistoryStateRoot)
+
+	// without the state root check the range is fine
+	require.NoError(t, ExecRange(m.Ctx, m.DB, m.BlockReader, m.ChainConfig, m.Engine, 1, 6, m.HistoryV3, false, "", true, logger))
+
+	// the state of a range starting after the tampered block is loaded from history
+	require.NoError(t, ExecRange(m.Ctx, m.DB, m.BlockReader, m.ChainConfig, m.Engine, 5, 6, m.HistoryV3, true, t.TempDir(), true, logger))
+}
@@ -0,0 +1,272 @@
+package stagedsync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/holiman/uint256"
+
+	"github.com/erigontech/erigon-lib/chain"
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/datadir"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/rawdbv3"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/consensus"
+	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/core/state"
+	"github.com/erigontech/erigon/core/systemcontracts"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/core/types/accounts"
+	"github.com/erigontech/erigon/core/vm"
+	"github.com/erigontech/erigon/eth/ethconfig"
+	"github.com/erigontech/erigon/eth/tracers"
+	_ "github.com/erigontech/erigon/eth/tracers/native" // callTracer
+	"github.com/erigontech/erigon/rlp"
+	"github.com/erigontech/erigon/turbo/services"
+)
+
+// BadBlockDump - when sync halts on a bad block (ethconfig.Sync.BadBlockHalt), writes everything needed to report
+// the divergence from other clients (e.g. op-geth) into one directory <dir>/<number>-<hash>:
+//
+//	reason.json     - why the block was rejected
+//	block.rlp       - the block, as received
+//	block.json      - header and transactions
+//	receipts.json   - receipts produced by this client
+//	statediff.json  - accounts and storage slots touched by the block, before and after
+//	traces.json     - call traces of the transactions
+//
+// The block is re-executed for it without consensus checks, so receipts and traces are there even if the block
+// is rejected by them.
+type BadBlockDump struct {
+	dir         string
+	chainConfig *chain.Config
+	engine      consensus.Engine
+	blockReader services.FullBlockReader
+}
+
+// NewBadBlockDump - nil if sync doesn't halt on bad blocks. Bundles go to syncCfg.BadBlockDumpDir, <datadir>/badblocks
+// by default.
+func NewBadBlockDump(syncCfg ethconfig.Sync, dirs datadir.Dirs, chainConfig *chain.Config, engine consensus.Engine, blockReader services.FullBlockReader) *BadBlockDump {
+	if !syncCfg.BadBlockHalt {
+		return nil
+	}
+	dir := syncCfg.BadBlockDumpDir
+	if dir == "" {
+		dir = filepath.Join(dirs.DataDir, "badblocks")
+	}
+	return &BadBlockDump{dir: dir, chainConfig: chainConfig, engine: engine, blockReader: blockReader}
+}
+
+type badBlockReason struct {
+	Number    uint64         `json:"number"`
+	Hash      libcommon.Hash `json:"hash"`
+	FromBlock uint64         `json:"fromBlock"` // first block of the range which was checked together with it
+	Error     string         `json:"error"`
+	Time      time.Time      `json:"time"`
+}
+
+// Write - writes the bundle of block, preState is the state before it. Errors are logged: the bundle is best-effort
+// and must not hide the reason of the halt.
+func (d *BadBlockDump) Write(ctx context.Context, tx kv.Tx, preState state.StateReader, block *types.Block, fromBlock uint64, reason error, logger log.Logger) {
+	if d == nil || block == nil {
+		return
+	}
+	dir := filepath.Join(d.dir, fmt.Sprintf("%d-%x", block.NumberU64(), block.Hash()))
+	if err := d.write(ctx, tx, preState, block, fromBlock, reason, dir, logger); err != nil {
+		logger.Warn("Failed to dump bad block", "number", block.NumberU64(), "hash", block.Hash(), "dir", dir, "err", err)
+		return
+	}
+	logger.Error("Bad block dumped, report the divergence with this directory", "number", block.NumberU64(), "hash", block.Hash(), "dir", dir)
+}
+
+// WriteAt - Write of a block which state is already committed to tx: the state before it is read from history
+func (d *BadBlockDump) WriteAt(ctx context.Context, tx kv.Tx, hash libcommon.Hash, number, fromBlock uint64, historyV3 bool, reason error, logger log.Logger) {
+	if d == nil {
+		return
+	}
+	block, _, err := d.blockReader.BlockWithSenders(ctx, tx, hash, number)
+	if err != nil || block == nil {
+		logger.Warn("Failed to dump bad block", "number", number, "hash", hash, "err", err)
+		return
+	}
+	preState, err := historyStateReader(tx, number, historyV3, d.chainConfig.ChainName)
+	if err != nil {
+		logger.Warn("Failed to dump bad block", "number", number, "hash", hash, "err", err)
+		return
+	}
+	d.Write(ctx, tx, preState, block, fromBlock, reason, logger)
+}
+
+// historyStateReader - state before block number
+func historyStateReader(tx kv.Tx, number uint64, historyV3 bool, chainName string) (state.StateReader, error) {
+	if !historyV3 {
+		return state.NewPlainState(tx, number, systemcontracts.SystemContractCodeLookup[chainName]), nil
+	}
+	minTxNum, err := rawdbv3.TxNums.Min(tx, number)
+	if err != nil {
+		return nil, err
+	}
+	r := state.NewHistoryReaderV3()
+	r.SetTx(tx)
+	r.SetTxNum(minTxNum)
+	return r, nil
+}
+
+func (d *BadBlockDump) write(ctx context.Context, tx kv.Tx, preState state.StateReader, block *types.Block, fromBlock uint64, reason error, dir string, logger log.Logger) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if err := writeJSON(dir, "reason.json", &badBlockReason{Number: block.NumberU64(), Hash: block.Hash(), FromBlock: fromBlock, Error: reason.Error(), Time: time.Now()}); err != nil {
+		return err
+	}
+	blockRlp, err := rlp.EncodeToBytes(block)
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(filepath.Join(dir, "block.rlp"), blockRlp, 0644); err != nil {
+		return err
+	}
+	if err = writeJSON(dir, "block.json", map[string]interface{}{"header": block.Header(), "transactions": block.Transactions()}); err != nil {
+		return err
+	}
+
+	var traces []tracers.Tracer
+	getTracer := func(txIndex int, txHash libcommon.Hash) (vm.EVMLogger, error) {
+		tracer, err := tracers.New("callTracer", &tracers.Context{BlockHash: block.Hash(), TxIndex: txIndex, TxHash: txHash}, json.RawMessage(`{"withLog":true}`))
+		if err != nil {
+			return nil, err
+		}
+		traces = append(traces, tracer)
+		return tracer, nil
+	}
+	getHeader := func(hash libcommon.Hash, number uint64) *types.Header {
+		h, _ := d.blockReader.Header(ctx, tx, hash, number)
+		return h
+	}
+	diff := newStateDiffRecorder(preState)
+	// StatelessExec: no consensus checks, failing txs are skipped instead of aborting the block
+	vmConfig := vm.Config{Debug: true, StatelessExec: true}
+	execRs, execErr := core.ExecuteBlockEphemerally(d.chainConfig, &vmConfig, core.GetHashFn(block.Header(), getHeader), d.engine, block, preState, diff,
+		NewChainReaderImpl(d.chainConfig, tx, d.blockReader, logger), getTracer, logger)
+	// traces of the txs executed before a failure are the most useful part of the bundle then
+	results := make([]json.RawMessage, len(traces))
+	for i, tracer := range traces {
+		if results[i], err = tracer.GetResult(); err != nil {
+			return fmt.Errorf("trace of tx %d: %w", i, err)
+		}
+	}
+	if err = writeJSON(dir, "traces.json", results); err != nil {
+		return err
+	}
+	if execErr != nil {
+		return fmt.Errorf("re-execution: %w", execErr)
+	}
+	if err = writeJSON(dir, "receipts.json", execRs); err != nil {
+		return err
+	}
+	return writeJSON(dir, "statediff.json", diff.accounts)
+}
+
+func writeJSON(dir, name string, v interface{}) error {
+	enc, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return os.WriteFile(filepath.Join(dir, name), enc, 0644)
+}
+
+type accountDiff struct {
+	Pre     *accountState                  `json:"pre"` // nil - didn't exist
+	Post    *accountState                  `json:"post"`
+	Storage map[libcommon.Hash]storageDiff `json:"storage,omitempty"`
+}
+
+type accountState struct {
+	Nonce    uint64         `json:"nonce"`
+	Balance  *uint256.Int   `json:"balance"`
+	CodeHash libcommon.Hash `json:"codeHash"`
+}
+
+type storageDiff struct {
+	Pre  *uint256.Int `json:"pre"`
+	Post *uint256.Int `json:"post"`
+}
+
+// stateDiffRecorder - StateWriter which records the writes of the block together with the values before it
+type stateDiffRecorder struct {
+	pre      state.StateReader
+	accounts map[libcommon.Address]*accountDiff
+}
+
+func newStateDiffRecorder(pre state.StateReader) *stateDiffRecorder {
+	return &stateDiffRecorder{pre: pre, accounts: map[libcommon.Address]*accountDiff{}}
+}
+
+func toAccountState(a *accounts.Account) *accountState {
+	if a == nil {
+		return nil
+	}
+	return &accountState{Nonce: a.Nonce, Balance: a.Balance.Clone(), CodeHash: a.CodeHash}
+}
+
+func (r *stateDiffRecorder) account(address libcommon.Address) (*accountDiff, error) {
+	if d, ok := r.accounts[address]; ok {
+		return d, nil
+	}
+	pre, err := r.pre.ReadAccountData(address)
+	if err != nil {
+		return nil, err
+	}
+	d := &accountDiff{Pre: toAccountState(pre)}
+	r.accounts[address] = d
+	return d, nil
+}
+
+func (r *stateDiffRecorder) UpdateAccountData(address libcommon.Address, original, account *accounts.Account) error {
+	d, err := r.account(address)
+	if err != nil {
+		return err
+	}
+	d.Post = toAccountState(account)
+	return nil
+}
+
+func (r *stateDiffRecorder) UpdateAccountCode(address libcommon.Address, incarnation uint64, codeHash libcommon.Hash, code []byte) error {
+	// code is addressed by hash, which is in the account
+	return nil
+}
+
+func (r *stateDiffRecorder) DeleteAccount(address libcommon.Address, original *accounts.Account) error {
+	d, err := r.account(address)
+	if err != nil {
+		return err
+	}
+	d.Post = nil
+	return nil
+}
+
+func (r *stateDiffRecorder) WriteAccountStorage(address libcommon.Address, incarnation uint64, key *libcommon.Hash, original, value *uint256.Int) error {
+	d, err := r.account(address)
+	if err != nil {
+		return err
+	}
+	enc, err := r.pre.ReadAccountStorage(address, incarnation, key)
+	if err != nil {
+		return err
+	}
+	if d.Storage == nil {
+		d.Storage = map[libcommon.Hash]storageDiff{}
+	}
+	d.Storage[*key] = storageDiff{Pre: new(uint256.Int).SetBytes(enc), Post: value.Clone()}
+	return nil
+}
+
+func (r *stateDiffRecorder) CreateContract(address libcommon.Address) error { return nil }
+func (r *stateDiffRecorder) WriteChangeSets() error                         { return nil }
+func (r *stateDiffRecorder) WriteHistory() error                            { return nil }
@@ -0,0 +1,52 @@
+package stagedsync
+
+import (
+	"testing"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/kv/memdb"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon/core/state"
+	"github.com/erigontech/erigon/core/types/accounts"
+)
+
+func TestStateDiffRecorder(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	existing, created := libcommon.HexToAddress("0x01"), libcommon.HexToAddress("0x02")
+	slot := libcommon.HexToHash("0x05")
+
+	pre := accounts.NewAccount()
+	pre.Nonce, pre.Incarnation = 1, 1
+	pre.Balance.SetUint64(100)
+	w := state.NewPlainStateWriterNoHistory(tx)
+	require.NoError(t, w.UpdateAccountData(existing, nil, &pre))
+	require.NoError(t, w.WriteAccountStorage(existing, 1, &slot, nil, uint256.NewInt(7)))
+
+	r := newStateDiffRecorder(state.NewPlainStateReader(tx))
+	post := pre
+	post.Nonce = 2
+	post.Balance.SetUint64(90)
+	require.NoError(t, r.UpdateAccountData(existing, &pre, &post))
+	require.NoError(t, r.WriteAccountStorage(existing, 1, &slot, uint256.NewInt(7), uint256.NewInt(8)))
+	newAcc := accounts.NewAccount()
+	newAcc.Balance.SetUint64(10)
+	require.NoError(t, r.UpdateAccountData(created, nil, &newAcc))
+
+	require.Len(t, r.accounts, 2)
+	d := r.accounts[existing]
+	require.Equal(t, uint64(1), d.Pre.Nonce)
+	require.Equal(t, uint256.NewInt(100), d.Pre.Balance)
+	require.Equal(t, uint64(2), d.Post.Nonce)
+	require.Equal(t, uint256.NewInt(90), d.Post.Balance)
+	require.Equal(t, storageDiff{Pre: uint256.NewInt(7), Post: uint256.NewInt(8)}, d.Storage[slot])
+
+	// didn't exist before the block
+	require.Nil(t, r.accounts[created].Pre)
+	require.Equal(t, uint256.NewInt(10), r.accounts[created].Post.Balance)
+
+	require.NoError(t, r.DeleteAccount(existing, &post))
+	require.Nil(t, r.accounts[existing].Post)
+	require.NotNil(t, r.accounts[existing].Pre)
+}
@@ -0,0 +1,28 @@
+package stagedsync
+
+import (
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/core/rawdb"
+	"github.com/erigontech/erigon/core/types"
+)
+
+// recordBadBlock - keeps the rejected block for debug_getBadBlocks. If block is nil, it's read from the db by the
+// header. Failing to record it is not an error of the stage.
+func recordBadBlock(tx kv.RwTx, header *types.Header, block *types.Block, reason error, logger log.Logger) {
+	if header == nil {
+		return
+	}
+	headerOnly := false
+	if block == nil {
+		block = rawdb.ReadBlock(tx, header.Hash(), header.Number.Uint64())
+	}
+	if block == nil {
+		block, headerOnly = types.NewBlockWithHeader(header), true
+	}
+	if err := core.ReportBadBlock(tx, block, headerOnly, reason); err != nil {
+		logger.Warn("Failed to record bad block", "number", header.Number.Uint64(), "hash", header.Hash(), "err", err)
+	}
+}
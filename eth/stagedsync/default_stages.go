@@ -19,6 +19,7 @@ func DefaultStages(ctx context.Context,
 	blockHashCfg BlockHashesCfg,
 	bodies BodiesCfg,
 	senders SendersCfg,
+	sendersBackfill SendersBackfillCfg,
 	exec ExecuteBlockCfg,
 	hashState HashStateCfg,
 	trieCfg TrieCfg,
@@ -116,6 +117,21 @@ func DefaultStages(ctx context.Context,
 				return PruneSendersStage(p, tx, senders, ctx)
 			},
 		},
+		{
+			ID:                  stages.SendersBackfill,
+			Description:         "Recover missing senders of frozen blocks",
+			Disabled:            !sendersBackfill.enabled,
+			DisabledDescription: "Enable by --sync.senders.backfill",
+			Forward: func(firstCycle bool, badBlockUnwind bool, s *StageState, u Unwinder, txc wrap.TxContainer, logger log.Logger) error {
+				return SpawnSendersBackfill(s, txc.Tx, 0, sendersBackfill, ctx, logger)
+			},
+			Unwind: func(firstCycle bool, u *UnwindState, s *StageState, txc wrap.TxContainer, logger log.Logger) error {
+				return nil
+			},
+			Prune: func(firstCycle bool, p *PruneState, tx kv.RwTx, logger log.Logger) error {
+				return nil
+			},
+		},
 		{
 			ID:          stages.Execution,
 			Description: "Execute blocks w/o hash checks",
@@ -253,7 +269,7 @@ func DefaultStages(ctx context.Context,
 	}
 }
 
-func PipelineStages(ctx context.Context, snapshots SnapshotsCfg, blockHashCfg BlockHashesCfg, senders SendersCfg, exec ExecuteBlockCfg, hashState HashStateCfg, trieCfg TrieCfg, history HistoryCfg, logIndex LogIndexCfg, callTraces CallTracesCfg, txLookup TxLookupCfg, finish FinishCfg, test bool) []*Stage {
+func PipelineStages(ctx context.Context, snapshots SnapshotsCfg, blockHashCfg BlockHashesCfg, senders SendersCfg, sendersBackfill SendersBackfillCfg, exec ExecuteBlockCfg, hashState HashStateCfg, trieCfg TrieCfg, history HistoryCfg, logIndex LogIndexCfg, callTraces CallTracesCfg, txLookup TxLookupCfg, finish FinishCfg, test bool) []*Stage {
 	return []*Stage{
 		{
 			ID:          stages.Snapshots,
@@ -297,6 +313,21 @@ func PipelineStages(ctx context.Context, snapshots SnapshotsCfg, blockHashCfg Bl
 				return PruneSendersStage(p, tx, senders, ctx)
 			},
 		},
+		{
+			ID:                  stages.SendersBackfill,
+			Description:         "Recover missing senders of frozen blocks",
+			Disabled:            !sendersBackfill.enabled,
+			DisabledDescription: "Enable by --sync.senders.backfill",
+			Forward: func(firstCycle bool, badBlockUnwind bool, s *StageState, u Unwinder, txc wrap.TxContainer, logger log.Logger) error {
+				return SpawnSendersBackfill(s, txc.Tx, 0, sendersBackfill, ctx, logger)
+			},
+			Unwind: func(firstCycle bool, u *UnwindState, s *StageState, txc wrap.TxContainer, logger log.Logger) error {
+				return nil
+			},
+			Prune: func(firstCycle bool, p *PruneState, tx kv.RwTx, logger log.Logger) error {
+				return nil
+			},
+		},
 		{
 			ID:          stages.Execution,
 			Description: "Execute blocks w/o hash checks",
@@ -727,6 +758,7 @@ var DefaultForwardOrder = UnwindOrder{
 
 	// Stages below don't use Internet
 	stages.Senders,
+	stages.SendersBackfill,
 	stages.Execution,
 	stages.HashState,
 	stages.IntermediateHashes,
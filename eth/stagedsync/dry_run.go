@@ -0,0 +1,100 @@
+package stagedsync
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/membatchwithdb"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/eth/stagedsync/stages"
+)
+
+// DryRun - a cycle of --sync.dry-run. The stages run on a memory overlay of the db, which is never committed, and
+// Report logs what the cycle would have changed: the progress of the stages and the entries of each table. Meant
+// for validating a new release against a production datadir before switching to it. A dry-run forkchoice update
+// never reports its head as VALID, as the head is not committed.
+type DryRun struct {
+	batch    *membatchwithdb.MemoryMutation
+	tx       kv.RwTx // batch, or its temporal wrapper when the db is temporal
+	stages   []stages.SyncStage
+	progress []uint64 // of stages, before the cycle
+	logger   log.Logger
+}
+
+// SetDryRun - cycles of the sync run on a memory overlay in tmpDir, see DryRun
+func (s *Sync) SetDryRun(tmpDir string) {
+	s.dryRun = true
+	s.dryRunTmpDir = tmpDir
+}
+
+func (s *Sync) DryRun() bool {
+	return s.dryRun
+}
+
+// BeginDryRun - the overlay of tx for the next cycle, nil unless the sync dry-runs. The caller runs the cycle on
+// DryRun.Tx instead of tx and rolls tx back.
+func (s *Sync) BeginDryRun(tx kv.Tx) (*DryRun, error) {
+	if !s.dryRun {
+		return nil, nil
+	}
+	d := &DryRun{logger: s.logger}
+	for _, stage := range s.stages {
+		progress, err := stages.GetStageProgress(tx, stage.ID)
+		if err != nil {
+			return nil, err
+		}
+		d.stages = append(d.stages, stage.ID)
+		d.progress = append(d.progress, progress)
+	}
+	// execution of HistoryV3 reads state through kv.TemporalTx, the overlay must stay one
+	if ttx, ok := tx.(kv.TemporalTx); ok {
+		batch := membatchwithdb.NewTemporalMemoryBatch(ttx, s.dryRunTmpDir, s.logger)
+		d.batch, d.tx = batch.MemoryMutation, batch
+	} else {
+		d.batch = membatchwithdb.NewMemoryBatch(tx, s.dryRunTmpDir, s.logger)
+		d.tx = d.batch
+	}
+	return d, nil
+}
+
+func (d *DryRun) Tx() kv.RwTx {
+	return d.tx
+}
+
+func (d *DryRun) Rollback() {
+	d.batch.Rollback()
+}
+
+// Report - logs the stages which moved in the cycle and the tables it changed
+func (d *DryRun) Report() error {
+	var progress []interface{}
+	for i, stage := range d.stages {
+		after, err := stages.GetStageProgress(d.batch, stage)
+		if err != nil {
+			return err
+		}
+		if after != d.progress[i] {
+			progress = append(progress, string(stage), fmt.Sprintf("%d->%d", d.progress[i], after))
+		}
+	}
+	changes, err := d.batch.Changes()
+	if err != nil {
+		return err
+	}
+	if len(progress) == 0 && len(changes) == 0 {
+		return nil
+	}
+	tables := make([]string, 0, len(changes))
+	for table := range changes {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	d.logger.Info("[dry-run] Cycle is not committed", progress...)
+	for _, table := range tables {
+		c := changes[table]
+		d.logger.Info("[dry-run] Table changes", "table", table, "written", c.Written, "deleted", c.Deleted, "cleared", c.Cleared)
+	}
+	return nil
+}
@@ -35,11 +35,13 @@ import (
 	"github.com/erigontech/erigon/cmd/state/exec3"
 	"github.com/erigontech/erigon/common/math"
 	"github.com/erigontech/erigon/consensus"
+	"github.com/erigontech/erigon/consensus/misc"
 	"github.com/erigontech/erigon/core"
 	"github.com/erigontech/erigon/core/rawdb/rawdbhelpers"
 	"github.com/erigontech/erigon/core/state"
 	"github.com/erigontech/erigon/core/types"
 	"github.com/erigontech/erigon/eth/ethconfig/estimate"
+	"github.com/erigontech/erigon/eth/gasmeter"
 	"github.com/erigontech/erigon/eth/stagedsync/stages"
 	"github.com/erigontech/erigon/turbo/services"
 )
@@ -506,6 +508,20 @@ func ExecV3(ctx context.Context,
 
 	stateStream := !initialCycle && cfg.stateStream && maxBlockNum-block < stateStreamLimit
 
+	// badBlock - a block failing validation in the serial execution: recorded and unwound, unless it halts the sync
+	badBlock := func(header *types.Header, b *types.Block, err error) error {
+		logger.Warn(fmt.Sprintf("[%s] Execution failed", logPrefix), "block", header.Number.Uint64(), "hash", header.Hash().String(), "err", err)
+		recordBadBlock(applyTx, header, b, err, logger)
+		if cfg.hd != nil {
+			cfg.hd.ReportBadHeaderPoS(header.Hash(), header.ParentHash)
+		}
+		if cfg.badBlockHalt {
+			return err
+		}
+		u.UnwindTo(header.Number.Uint64()-1, BadBlock(header.Hash(), err))
+		return nil
+	}
+
 	var b *types.Block
 	var blockNum uint64
 	var err error
@@ -534,6 +550,22 @@ Loop:
 		}
 		blockContext := core.NewEVMBlockContext(header, getHashFn, engine, nil /* author */)
 
+		// Optimism: upgrade transactions of the first block of a hardfork, as ExecuteBlockEphemerally checks them
+		if chainConfig.IsOptimism() && blockNum > 0 {
+			if parent := getHeaderFunc(header.ParentHash, blockNum-1); parent != nil {
+				if err := misc.VerifyUpgradeTxs(chainConfig, parent.Time, header.Time, txs); err != nil {
+					err = fmt.Errorf("%w: %w", consensus.ErrInvalidBlock, err)
+					if parallel {
+						return err
+					}
+					if err := badBlock(header, b, err); err != nil {
+						return err
+					}
+					break Loop
+				}
+			}
+		}
+
 		if parallel {
 			select {
 			case err := <-rwLoopErrCh:
@@ -596,7 +628,7 @@ Loop:
 			}
 			if txIndex >= 0 && txIndex < len(txs) {
 				txTask.Tx = txs[txIndex]
-				txTask.TxAsMessage, err = txTask.Tx.AsMessage(signer, header.BaseFee, txTask.Rules)
+				txTask.TxAsMessage, err = types.AsMessageLegacyAware(chainConfig, blockNum, txTask.Tx, signer, header.BaseFee, txTask.Rules)
 				if err != nil {
 					return err
 				}
@@ -642,16 +674,10 @@ Loop:
 				}(); err != nil {
 					if !errors.Is(err, consensus.ErrInvalidBlock) {
 						return err
-					} else {
-						logger.Warn(fmt.Sprintf("[%s] Execution failed", logPrefix), "block", blockNum, "hash", header.Hash().String(), "err", err)
-						if cfg.hd != nil {
-							cfg.hd.ReportBadHeaderPoS(header.Hash(), header.ParentHash)
-						}
-						if cfg.badBlockHalt {
-							return err
-						}
 					}
-					u.UnwindTo(blockNum-1, BadBlock(header.Hash(), err))
+					if err := badBlock(header, b, err); err != nil {
+						return err
+					}
 					break Loop
 				}
 
@@ -664,6 +690,7 @@ Loop:
 				if err := rs.ApplyHistory(txTask, agg); err != nil {
 					return fmt.Errorf("StateV3.Apply: %w", err)
 				}
+				addHotContracts(txTask)
 			}
 			stageProgress = blockNum
 			inputTxNum++
@@ -800,6 +827,7 @@ func processResultQueue(in *exec22.QueueWithRetry, rws *exec22.ResultsQueue, out
 		if err := rs.ApplyHistory(txTask, agg); err != nil {
 			return outputTxNum, conflicts, triggers, processedBlockNum, false, fmt.Errorf("StateV3.Apply: %w", err)
 		}
+		addHotContracts(txTask)
 		//fmt.Printf("Applied %d block %d txIndex %d\n", txTask.TxNum, txTask.BlockNum, txTask.TxIndex)
 		processedBlockNum = txTask.BlockNum
 		stopedAtBlockEnd = txTask.Final
@@ -810,11 +838,19 @@ func processResultQueue(in *exec22.QueueWithRetry, rws *exec22.ResultsQueue, out
 	return
 }
 
+// addHotContracts - reports the gas of the applied task to gasmeter.HotContracts, if enabled
+func addHotContracts(txTask *exec22.TxTask) {
+	if hot := gasmeter.Hot(); hot != nil {
+		hot.AddTx(txTask.BlockNum, txTask.Header.Time, txTask.TxIndex, txTask.UsedGas, txTask.ContractGas)
+	}
+}
+
 func reconstituteStep(last bool,
 	workerCount int, ctx context.Context, db kv.RwDB, txNum uint64, dirs datadir.Dirs,
 	as *libstate.AggregatorStep, chainDb kv.RwDB, blockReader services.FullBlockReader,
 	chainConfig *chain.Config, logger log.Logger, genesis *types.Genesis, engine consensus.Engine,
 	batchSize datasize.ByteSize, s *StageState, blockNum uint64, total uint64,
+	checkpoint func(tx kv.RwTx) error,
 ) error {
 	var startOk, endOk bool
 	startTxNum, endTxNum := as.TxNumRange()
@@ -993,6 +1029,12 @@ func reconstituteStep(last bool,
 				prevTime = currentTime
 				prevCount = count
 				prevRollbackCount = rollbackCount
+				updateReconProgress(func(p *ReconProgress) {
+					p.TxNum = maxTxNum
+					p.TxNumsPerSec = speedTx
+					p.ETA = reconETA(maxTxNum, p.ToTxNum, speedTx)
+					p.WorkerUtilization = float64(len(workCh)) / float64(cap(workCh))
+				})
 				logger.Info(fmt.Sprintf("[%s] State reconstitution", s.LogPrefix()), "overall progress", fmt.Sprintf("%.2f%%", progress),
 					"step progress", fmt.Sprintf("%.2f%%", stepProgress),
 					"tx/s", fmt.Sprintf("%.1f", speedTx), "workCh", fmt.Sprintf("%d/%d", len(workCh), cap(workCh)),
@@ -1083,7 +1125,7 @@ func reconstituteStep(last bool,
 					}
 					if txIndex >= 0 && txIndex < len(txs) {
 						txTask.Tx = txs[txIndex]
-						txTask.TxAsMessage, err = txTask.Tx.AsMessage(signer, header.BaseFee, txTask.Rules)
+						txTask.TxAsMessage, err = types.AsMessageLegacyAware(chainConfig, bn, txTask.Tx, signer, header.BaseFee, txTask.Rules)
 						if err != nil {
 							return err
 						}
@@ -1313,8 +1355,8 @@ func reconstituteStep(last bool,
 				}
 			}
 		}
-
-		return nil
+		// same tx as the state of the step: a crash leaves either both or none
+		return checkpoint(tx)
 	}); err != nil {
 		return err
 	}
@@ -1385,6 +1427,25 @@ func ReconstituteState(ctx context.Context, s *StageState, dirs datadir.Dirs, wo
 
 	logger.Info(fmt.Sprintf("[%s] Blocks execution, reconstitution", s.LogPrefix()), "fromBlock", s.BlockNumber, "toBlock", blockNum, "toTxNum", txNum)
 
+	// steps done before a crash are in the chaindata already, the partial one is re-done from scratch
+	var doneSteps int
+	var resumeFrom *ReconCheckpoint
+	if err := chainDb.View(ctx, func(tx kv.Tx) (err error) {
+		resumeFrom, err = ReadReconCheckpoint(tx)
+		return err
+	}); err != nil {
+		return err
+	}
+	if resumeFrom.resumes(txNum, len(aggSteps)) {
+		doneSteps = resumeFrom.DoneSteps
+		logger.Info(fmt.Sprintf("[%s] Resuming reconstitution", s.LogPrefix()), "doneSteps", doneSteps, "out of", len(aggSteps), "doneTxNum", resumeFrom.DoneTxNum)
+	}
+
+	updateReconProgress(func(p *ReconProgress) {
+		*p = ReconProgress{Running: true, StartedAt: startTime, Steps: len(aggSteps), ToTxNum: txNum, Workers: workerCount}
+	})
+	defer updateReconProgress(func(p *ReconProgress) { p.Running = false })
+
 	reconDbPath := filepath.Join(dirs.DataDir, "recondb")
 	dir.Recreate(reconDbPath)
 	db, err := kv2.NewMDBX(log.New()).Path(reconDbPath).
@@ -1401,10 +1462,19 @@ func ReconstituteState(ctx context.Context, s *StageState, dirs datadir.Dirs, wo
 	defer os.RemoveAll(reconDbPath)
 
 	for step, as := range aggSteps {
+		if step < doneSteps {
+			continue
+		}
 		logger.Info("Step of incremental reconstitution", "step", step+1, "out of", len(aggSteps), "workers", workerCount)
+		stepFromTxNum, stepToTxNum := as.TxNumRange()
+		updateReconProgress(func(p *ReconProgress) {
+			p.Step, p.StepFromTx, p.StepToTx = step+1, stepFromTxNum, stepToTxNum
+		})
+		checkpoint := &ReconCheckpoint{ToBlock: blockNum, ToTxNum: txNum, Steps: len(aggSteps), DoneSteps: step + 1, DoneTxNum: stepToTxNum}
 		if err := reconstituteStep(step+1 == len(aggSteps), workerCount, ctx, db,
 			txNum, dirs, as, chainDb, blockReader, chainConfig, logger, genesis,
 			engine, batchSize, s, blockNum, txNum,
+			func(tx kv.RwTx) error { return WriteReconCheckpoint(tx, checkpoint) },
 		); err != nil {
 			return err
 		}
@@ -1454,6 +1524,9 @@ func ReconstituteState(ctx context.Context, s *StageState, dirs datadir.Dirs, wo
 			return err
 		}
 		plainContractCollector.Close()
+		if err := DeleteReconCheckpoint(tx); err != nil {
+			return err
+		}
 		if err := s.Update(tx, blockNum); err != nil {
 			return err
 		}
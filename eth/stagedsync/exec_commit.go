@@ -0,0 +1,82 @@
+package stagedsync
+
+import (
+	"sync"
+	"time"
+
+	"github.com/c2h5oh/datasize"
+
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon-lib/metrics"
+
+	"github.com/erigontech/erigon/eth/ethconfig"
+)
+
+var (
+	execCommitsByInterval = metrics.GetOrCreateCounter(`exec_commits{trigger="interval"}`)
+	execCommitsByDirty    = metrics.GetOrCreateCounter(`exec_commits{trigger="dirty"}`)
+)
+
+// dirtySpacer - kv.Tx of mdbx, which reports the size of its dirty pages
+type dirtySpacer interface {
+	SpaceDirty() (dirty uint64, limit uint64, err error)
+}
+
+// execCommitTrigger - the execution batch is committed when it reaches the batch size, or earlier on slow disks: after
+// the commit interval of wall-clock time, or once the dirty pages of the db tx reach the limit. It bounds the blocks
+// executed again after a crash. Only the execution stage of erigon2 (SpawnExecuteBlocksStage) commits by it: ExecV3
+// writes its state to the tx of the stage and commits once at the end of the range, see warnCommitTriggerV3.
+type execCommitTrigger struct {
+	batchSize  datasize.ByteSize
+	interval   time.Duration
+	maxDirty   datasize.ByteSize
+	lastCommit time.Time
+}
+
+// newExecCommitTrigger - the interval and dirty limit are ignored for an external tx, it isn't committed by execution
+func newExecCommitTrigger(batchSize datasize.ByteSize, syncCfg ethconfig.Sync, externalTx bool) *execCommitTrigger {
+	c := &execCommitTrigger{batchSize: batchSize, lastCommit: time.Now()}
+	if !externalTx {
+		c.interval, c.maxDirty = syncCfg.ExecCommitInterval, syncCfg.ExecCommitMaxDirty
+	}
+	return c
+}
+
+// reason - why to commit now, empty if not yet
+func (c *execCommitTrigger) reason(batch kv.PendingMutations, tx kv.Tx, now time.Time) string {
+	if batch.BatchSize() >= int(c.batchSize) {
+		return "batch size"
+	}
+	if c.interval > 0 && now.Sub(c.lastCommit) >= c.interval {
+		execCommitsByInterval.Inc()
+		return "interval"
+	}
+	if c.maxDirty > 0 {
+		if dirtyTx, ok := tx.(dirtySpacer); ok {
+			if dirty, _, err := dirtyTx.SpaceDirty(); err == nil && dirty >= c.maxDirty.Bytes() {
+				execCommitsByDirty.Inc()
+				return "dirty pages"
+			}
+		}
+	}
+	return ""
+}
+
+func (c *execCommitTrigger) committed(now time.Time) {
+	c.lastCommit = now
+}
+
+var commitTriggerV3Warned sync.Once
+
+// warnCommitTriggerV3 - --sync.commit.interval and --sync.commit.max-dirty don't apply to ExecV3, which the node
+// doesn't run (it refuses an erigon3 db), but the integration tool does
+func warnCommitTriggerV3(syncCfg ethconfig.Sync, logger log.Logger) {
+	if syncCfg.ExecCommitInterval == 0 && syncCfg.ExecCommitMaxDirty == 0 {
+		return
+	}
+	commitTriggerV3Warned.Do(func() {
+		logger.Warn("[exec] The commit interval and the dirty pages limit are ignored by the execution of history v3",
+			"interval", syncCfg.ExecCommitInterval, "maxDirty", syncCfg.ExecCommitMaxDirty)
+	})
+}
@@ -0,0 +1,41 @@
+package stagedsync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/c2h5oh/datasize"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/membatch"
+	"github.com/erigontech/erigon-lib/kv/memdb"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/eth/ethconfig"
+)
+
+func TestExecCommitTrigger(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	batch := membatch.NewHashBatch(tx, nil, t.TempDir(), log.New())
+	defer batch.Close()
+	syncCfg := ethconfig.Sync{ExecCommitInterval: time.Minute, ExecCommitMaxDirty: datasize.MB}
+	now := time.Now()
+
+	trigger := newExecCommitTrigger(datasize.GB, syncCfg, false)
+	require.Empty(t, trigger.reason(batch, tx, now))
+	require.Equal(t, "interval", trigger.reason(batch, tx, now.Add(time.Minute)))
+	trigger.committed(now.Add(time.Minute))
+	require.Empty(t, trigger.reason(batch, tx, now.Add(time.Minute)))
+
+	for i := 0; i < 1_000; i++ {
+		require.NoError(t, tx.Put(kv.Receipts, []byte{byte(i >> 8), byte(i)}, make([]byte, 4096)))
+	}
+	require.Equal(t, "dirty pages", trigger.reason(batch, tx, now.Add(time.Minute)))
+
+	// an external tx isn't committed by execution, only the batch size counts
+	trigger = newExecCommitTrigger(datasize.GB, syncCfg, true)
+	require.Empty(t, trigger.reason(batch, tx, now.Add(time.Hour)))
+	trigger = newExecCommitTrigger(0, syncCfg, true)
+	require.Equal(t, "batch size", trigger.reason(batch, tx, now))
+}
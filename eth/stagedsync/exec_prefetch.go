@@ -0,0 +1,145 @@
+package stagedsync
+
+import (
+	"context"
+	"sync"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutility"
+	"github.com/erigontech/erigon-lib/common/length"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/dbutils"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/core/state"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/eth/ethconfig"
+)
+
+// prefetchMaxSlots - bounds the reads of a block with many hot contracts
+const prefetchMaxSlots = 8192
+
+// prefetchSlots - storage slots the block is likely to read: the slots of the access lists of its txs, and of the
+// contracts its txs call, the slots they changed in the prevBlocks blocks before it (StorageChangeSet). Hot contracts
+// of the chain tip (pools, tokens, bridges) mostly read the slots they wrote a few blocks ago. Erigon2 only: history v3
+// keeps no StorageChangeSet, and its execution doesn't prefetch, see warnPrefetchV3.
+func prefetchSlots(tx kv.Tx, stateReader state.StateReader, block *types.Block, prevBlocks uint64) (map[libcommon.Address]map[libcommon.Hash]struct{}, error) {
+	slots := map[libcommon.Address]map[libcommon.Hash]struct{}{}
+	var count int
+	add := func(addr libcommon.Address, slot libcommon.Hash) {
+		if count >= prefetchMaxSlots {
+			return
+		}
+		if slots[addr] == nil {
+			slots[addr] = map[libcommon.Hash]struct{}{}
+		}
+		if _, ok := slots[addr][slot]; !ok {
+			slots[addr][slot] = struct{}{}
+			count++
+		}
+	}
+
+	called := map[libcommon.Address]struct{}{}
+	for _, txn := range block.Transactions() {
+		for _, tuple := range txn.GetAccessList() {
+			for _, slot := range tuple.StorageKeys {
+				add(tuple.Address, slot)
+			}
+		}
+		if to := txn.GetTo(); to != nil {
+			called[*to] = struct{}{}
+		}
+	}
+	if prevBlocks == 0 || len(called) == 0 {
+		return slots, nil
+	}
+
+	changes, err := tx.CursorDupSort(kv.StorageChangeSet)
+	if err != nil {
+		return nil, err
+	}
+	defer changes.Close()
+	from := block.NumberU64() - min(prevBlocks, block.NumberU64())
+	for addr := range called {
+		a, err := stateReader.ReadAccountData(addr)
+		if err != nil {
+			return nil, err
+		}
+		if a == nil || a.Incarnation == 0 {
+			continue
+		}
+		prefix := dbutils.PlainGenerateStoragePrefix(addr[:], a.Incarnation)
+		for blockNum := from; blockNum < block.NumberU64(); blockNum++ {
+			k, v, err := changes.SeekExact(append(hexutility.EncodeTs(blockNum), prefix...))
+			for ; k != nil && count < prefetchMaxSlots; k, v, err = changes.NextDup() {
+				if err != nil {
+					return nil, err
+				}
+				add(addr, libcommon.BytesToHash(v[:length.Hash]))
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return slots, nil
+}
+
+// prefetchBlockState reads the state the block is likely to read (see prefetchSlots), so its execution finds it in
+// the page cache
+func prefetchBlockState(ctx context.Context, tx kv.Tx, block *types.Block, prevBlocks uint64) error {
+	stateReader := state.NewPlainStateReader(tx)
+	slots, err := prefetchSlots(tx, stateReader, block, prevBlocks)
+	if err != nil {
+		return err
+	}
+	for addr, keys := range slots {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		a, err := stateReader.ReadAccountData(addr)
+		if err != nil {
+			return err
+		}
+		if a == nil || a.Incarnation == 0 {
+			continue
+		}
+		for slot := range keys {
+			if _, err := stateReader.ReadAccountStorage(addr, a.Incarnation, &slot); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+var prefetchV3Warned sync.Once
+
+// warnPrefetchV3 - --sync.prefetch.blocks doesn't apply to ExecV3, which the node doesn't run (it refuses an erigon3
+// db), but the integration tool does
+func warnPrefetchV3(syncCfg ethconfig.Sync, logger log.Logger) {
+	if syncCfg.PrefetchBlocks == 0 {
+		return
+	}
+	prefetchV3Warned.Do(func() {
+		logger.Warn("[exec] The prefetch of the slots changed in previous blocks is ignored by the execution of history v3",
+			"blocks", syncCfg.PrefetchBlocks)
+	})
+}
+
+// prefetchBlockStateAsync prefetches the state of the block at the chain tip from a ro tx of the committed state, while
+// the block executes. The returned func stops it.
+func prefetchBlockStateAsync(ctx context.Context, db kv.RoDB, block *types.Block, prevBlocks uint64, logger log.Logger) func() {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := db.View(ctx, func(tx kv.Tx) error { return prefetchBlockState(ctx, tx, block, prevBlocks) }); err != nil && ctx.Err() == nil {
+			logger.Debug("Prefetch of block state failed", "block", block.NumberU64(), "err", err)
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
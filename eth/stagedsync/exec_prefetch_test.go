@@ -0,0 +1,65 @@
+package stagedsync
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutility"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/dbutils"
+	"github.com/erigontech/erigon-lib/kv/memdb"
+	types2 "github.com/erigontech/erigon-lib/types"
+
+	"github.com/erigontech/erigon/core/state"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/core/types/accounts"
+)
+
+func TestPrefetchSlots(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	pool, token, listed := libcommon.Address{1}, libcommon.Address{2}, libcommon.Address{3}
+	for _, addr := range []libcommon.Address{pool, token} {
+		a := accounts.NewAccount()
+		a.Incarnation = 1
+		v := make([]byte, a.EncodingLengthForStorage())
+		a.EncodeForStorage(v)
+		require.NoError(t, tx.Put(kv.PlainState, addr[:], v))
+	}
+	changed := func(blockNum uint64, addr libcommon.Address, slot libcommon.Hash) {
+		k := append(hexutility.EncodeTs(blockNum), dbutils.PlainGenerateStoragePrefix(addr[:], 1)...)
+		require.NoError(t, tx.Put(kv.StorageChangeSet, k, append(slot.Bytes(), 1)))
+	}
+	changed(7, pool, libcommon.Hash{1})
+	changed(8, pool, libcommon.Hash{2})
+	changed(9, pool, libcommon.Hash{1})
+	changed(9, token, libcommon.Hash{3}) // the block doesn't call the token
+	changed(10, pool, libcommon.Hash{4}) // the block itself
+
+	txs := []types.Transaction{
+		types.NewTransaction(0, pool, uint256.NewInt(0), 100_000, uint256.NewInt(1), nil),
+		&types.AccessListTx{
+			LegacyTx:   types.LegacyTx{CommonTx: types.CommonTx{Nonce: 1, To: &listed, Value: uint256.NewInt(0), Gas: 100_000}, GasPrice: uint256.NewInt(1)},
+			AccessList: types2.AccessList{{Address: listed, StorageKeys: []libcommon.Hash{{5}}}},
+		},
+	}
+	block := types.NewBlock(&types.Header{Number: big.NewInt(10)}, txs, nil, nil, nil)
+
+	slots, err := prefetchSlots(tx, state.NewPlainStateReader(tx), block, 2)
+	require.NoError(t, err)
+	require.Equal(t, map[libcommon.Address]map[libcommon.Hash]struct{}{
+		pool:   {{1}: {}, {2}: {}},
+		listed: {{5}: {}},
+	}, slots)
+
+	// only the access lists
+	slots, err = prefetchSlots(tx, state.NewPlainStateReader(tx), block, 0)
+	require.NoError(t, err)
+	require.Len(t, slots, 1)
+
+	require.NoError(t, prefetchBlockState(context.Background(), tx, block, 2))
+}
@@ -0,0 +1,108 @@
+package stagedsync
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"strconv"
+	"time"
+
+	"github.com/erigontech/erigon-lib/log/v3"
+)
+
+// slowExecProfiler - captures a CPU profile of the log interval following one which was executed slower than
+// threshold (Mgas/s), to catch sporadic slow block imports. The profiles are labeled by stage and blocks and
+// written as <dir>/<stage>-<from>-<to>.cpu.pprof. At most one profile is captured at a time and at least one
+// interval is not profiled between two profiles.
+type slowExecProfiler struct {
+	ctx       context.Context
+	logPrefix string
+	stage     string
+	dir       string
+	threshold float64
+	logger    log.Logger
+
+	lastBlock uint64
+	lastTime  time.Time
+
+	f         *os.File
+	fromBlock uint64
+	disabled  bool
+}
+
+// newSlowExecProfiler - nil if threshold is 0, all methods are no-ops on nil
+func newSlowExecProfiler(ctx context.Context, logPrefix, stage, dir string, threshold float64, fromBlock uint64, logger log.Logger) *slowExecProfiler {
+	if threshold <= 0 {
+		return nil
+	}
+	return &slowExecProfiler{ctx: ctx, logPrefix: logPrefix, stage: stage, dir: dir, threshold: threshold, logger: logger, lastBlock: fromBlock, lastTime: time.Now()}
+}
+
+// tick - called on each log interval with the gas executed since the previous one
+func (p *slowExecProfiler) tick(blockNum, gas uint64) {
+	if p == nil {
+		return
+	}
+	now := time.Now()
+	speedMgas := float64(gas) / 1_000_000 / now.Sub(p.lastTime).Seconds()
+	prevBlock := p.lastBlock
+	p.lastBlock, p.lastTime = blockNum, now
+
+	if p.f != nil {
+		p.stop(blockNum)
+		return
+	}
+	if p.disabled || speedMgas >= p.threshold {
+		return
+	}
+	p.logger.Info(fmt.Sprintf("[%s] Slow execution, capturing CPU profile", p.logPrefix), "blocks", fmt.Sprintf("%d-%d", prevBlock, blockNum),
+		"Mgas/s", fmt.Sprintf("%.1f", speedMgas), "threshold", p.threshold)
+	p.start(blockNum)
+}
+
+func (p *slowExecProfiler) start(fromBlock uint64) {
+	if err := os.MkdirAll(p.dir, 0755); err != nil {
+		p.logger.Warn(fmt.Sprintf("[%s] Slow execution profile", p.logPrefix), "err", err)
+		p.disabled = true
+		return
+	}
+	f, err := os.CreateTemp(p.dir, p.stage+"-*.cpu.pprof.tmp")
+	if err != nil {
+		p.logger.Warn(fmt.Sprintf("[%s] Slow execution profile", p.logPrefix), "err", err)
+		p.disabled = true
+		return
+	}
+	// fails if another CPU profile is running (e.g. --pprof.cpuprofile), don't retry then
+	if err := pprof.StartCPUProfile(f); err != nil {
+		p.logger.Warn(fmt.Sprintf("[%s] Slow execution profile", p.logPrefix), "err", err)
+		f.Close()
+		os.Remove(f.Name())
+		p.disabled = true
+		return
+	}
+	pprof.SetGoroutineLabels(pprof.WithLabels(p.ctx, pprof.Labels("stage", p.stage, "fromBlock", strconv.FormatUint(fromBlock, 10))))
+	p.f, p.fromBlock = f, fromBlock
+}
+
+func (p *slowExecProfiler) stop(toBlock uint64) {
+	if p == nil || p.f == nil {
+		return
+	}
+	pprof.StopCPUProfile()
+	pprof.SetGoroutineLabels(p.ctx)
+	f := p.f
+	p.f = nil
+	if err := f.Close(); err != nil {
+		p.logger.Warn(fmt.Sprintf("[%s] Slow execution profile", p.logPrefix), "err", err)
+		os.Remove(f.Name())
+		return
+	}
+	fileName := filepath.Join(p.dir, fmt.Sprintf("%s-%d-%d.cpu.pprof", p.stage, p.fromBlock, toBlock))
+	if err := os.Rename(f.Name(), fileName); err != nil {
+		p.logger.Warn(fmt.Sprintf("[%s] Slow execution profile", p.logPrefix), "err", err)
+		return
+	}
+	p.logger.Info(fmt.Sprintf("[%s] Saved CPU profile", p.logPrefix), "file", fileName)
+}
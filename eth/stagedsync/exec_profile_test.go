@@ -0,0 +1,32 @@
+package stagedsync
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/log/v3"
+)
+
+func TestSlowExecProfiler(t *testing.T) {
+	require.Nil(t, newSlowExecProfiler(context.Background(), "", "Execution", t.TempDir(), 0, 0, log.New()))
+	var disabled *slowExecProfiler
+	disabled.tick(1, 1)
+	disabled.stop(1)
+
+	dir := filepath.Join(t.TempDir(), "pprof")
+	p := newSlowExecProfiler(context.Background(), "", "Execution", dir, 1_000_000, 10, log.New())
+	p.tick(20, 0) // slow: profiling of the next interval starts
+	p.tick(30, 0) // profile saved
+	p.tick(40, 0) // interval 30-40 is not profiled, slow again
+	p.stop(45)    // end of the stage
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	require.Equal(t, "Execution-20-30.cpu.pprof", files[0].Name())
+	require.Equal(t, "Execution-40-45.cpu.pprof", files[1].Name())
+}
@@ -0,0 +1,213 @@
+package stagedsync
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"sync/atomic"
+
+	"github.com/erigontech/erigon-lib/common/hexutility"
+	"github.com/erigontech/erigon-lib/diagnostics"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/membatchwithdb"
+	"github.com/erigontech/erigon-lib/kv/temporal/historyv2"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon-lib/metrics"
+
+	"github.com/erigontech/erigon/core/rawdb"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/turbo/silkworm"
+)
+
+// silkwormAuditDiffsLimit - diffs reported of a mismatching block
+const silkwormAuditDiffsLimit = 32
+
+var (
+	silkwormAuditMatches    = metrics.GetOrCreateCounter(`silkworm_audit{result="match"}`)
+	silkwormAuditMismatches = metrics.GetOrCreateCounter(`silkworm_audit{result="mismatch"}`)
+
+	errSilkwormMismatch = errors.New("silkworm execution mismatch")
+)
+
+// silkwormAudit - every sample-th block executed by Silkworm is executed by the Go EVM too, on a memory overlay of the
+// state before it, and their state changes and receipts are compared. After a mismatch Silkworm is off until restart:
+// the block is unwound and executed again by the Go EVM, which result is the reference.
+type silkwormAudit struct {
+	sample   uint64
+	disabled atomic.Bool
+}
+
+// newSilkwormAudit returns nil if sample is 0 or Silkworm doesn't execute the blocks - audit is disabled
+func newSilkwormAudit(sample uint64, s *silkworm.Silkworm) *silkwormAudit {
+	if sample == 0 || s == nil {
+		return nil
+	}
+	return &silkwormAudit{sample: sample}
+}
+
+// useSilkworm - false after a mismatch
+func (a *silkwormAudit) useSilkworm() bool {
+	return a == nil || !a.disabled.Load()
+}
+
+func (a *silkwormAudit) sampled(blockNum uint64) bool {
+	return a != nil && blockNum%a.sample == 0
+}
+
+// batchEnd - last block of the Silkworm batch starting at blockNum: the batch stops before the next audited block
+func (a *silkwormAudit) batchEnd(blockNum, to uint64) uint64 {
+	if a == nil {
+		return to
+	}
+	return min(to, (blockNum/a.sample+1)*a.sample-1)
+}
+
+// auditBlock executes the block by the Go EVM and by Silkworm, errSilkwormMismatch if the results differ. Silkworm
+// writes the change sets and the receipts of the block anyway, they are needed for compare and unwind.
+func (a *silkwormAudit) auditBlock(tx kv.RwTx, block *types.Block, cfg ExecuteBlockCfg, writeCallTraces bool, logger log.Logger) error {
+	blockNum := block.NumberU64()
+	overlay := membatchwithdb.NewMemoryBatch(tx, cfg.dirs.Tmp, logger)
+	defer overlay.Close()
+	goCfg := cfg
+	goCfg.changeSetHook = nil
+	goErr := executeBlock(block, overlay, overlay, goCfg, *cfg.vmConfig, true, true, false, false, logger)
+
+	_, err := silkworm.ExecuteBlocksEphemeral(cfg.silkworm, tx, cfg.chainConfig.ChainID, blockNum, blockNum, uint64(cfg.batchSize), true, true, writeCallTraces)
+	var diffs []string
+	switch {
+	case goErr != nil && err != nil:
+		return err // the block fails both ways
+	case goErr != nil:
+		diffs = []string{fmt.Sprintf("execution failed only by go: %v", goErr)}
+	case err != nil:
+		diffs = []string{fmt.Sprintf("execution failed only by silkworm: %v", err)}
+	default:
+		if diffs, err = compareExecution(blockNum, overlay, tx); err != nil {
+			return err
+		}
+	}
+	if len(diffs) == 0 {
+		silkwormAuditMatches.Inc()
+		return nil
+	}
+
+	silkwormAuditMismatches.Inc()
+	a.disabled.Store(true)
+	logger.Error("[Silkworm audit] Execution mismatch, falling back to the Go EVM", "block", blockNum, "diffs", len(diffs), "first", diffs[0])
+	diagnostics.Send(diagnostics.SilkwormMismatch{BlockNumber: blockNum, Diffs: diffs})
+	return fmt.Errorf("%w: block %d", errSilkwormMismatch, blockNum)
+}
+
+// compareExecution - diffs of the state changes and the receipts of the block written by the Go EVM to the overlay
+// and by Silkworm to tx, sorted by key
+func compareExecution(blockNum uint64, overlay *membatchwithdb.MemoryMutation, tx kv.Tx) ([]string, error) {
+	var diffs []string
+	diff := func(format string, args ...interface{}) {
+		if len(diffs) < silkwormAuditDiffsLimit {
+			diffs = append(diffs, fmt.Sprintf(format, args...))
+		}
+	}
+
+	for _, table := range []string{kv.AccountChangeSet, kv.StorageChangeSet} {
+		// the change sets of the overlay are read without tx, which has the ones of Silkworm now
+		goChanges, err := blockChanges(overlay.MemTx(), table, blockNum)
+		if err != nil {
+			return nil, err
+		}
+		silkwormChanges, err := blockChanges(tx, table, blockNum)
+		if err != nil {
+			return nil, err
+		}
+		keys := make([]string, 0, len(goChanges)+len(silkwormChanges))
+		for k := range goChanges {
+			keys = append(keys, k)
+		}
+		for k := range silkwormChanges {
+			if _, ok := goChanges[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			goPrev, byGo := goChanges[k]
+			silkwormPrev, bySilkworm := silkwormChanges[k]
+			switch {
+			case !bySilkworm:
+				diff("%s %x: changed only by go", table, k)
+				continue
+			case !byGo:
+				diff("%s %x: changed only by silkworm", table, k)
+				continue
+			case !bytes.Equal(goPrev, silkwormPrev):
+				diff("%s %x: previous value go=%x silkworm=%x", table, k, goPrev, silkwormPrev)
+			}
+			goValue, err := overlay.GetOne(kv.PlainState, []byte(k))
+			if err != nil {
+				return nil, err
+			}
+			silkwormValue, err := tx.GetOne(kv.PlainState, []byte(k))
+			if err != nil {
+				return nil, err
+			}
+			if !bytes.Equal(goValue, silkwormValue) {
+				diff("%s %x: go=%x silkworm=%x", kv.PlainState, k, goValue, silkwormValue)
+			}
+		}
+	}
+
+	goReceipts, silkwormReceipts := rawdb.ReadRawReceipts(overlay.MemTx(), blockNum), rawdb.ReadRawReceipts(tx, blockNum)
+	if len(goReceipts) != len(silkwormReceipts) {
+		diff("receipts: go=%d silkworm=%d", len(goReceipts), len(silkwormReceipts))
+		return diffs, nil
+	}
+	for i := range goReceipts {
+		if d := receiptDiff(goReceipts[i], silkwormReceipts[i]); d != "" {
+			diff("receipt %d: %s", i, d)
+		}
+	}
+	return diffs, nil
+}
+
+// blockChanges - the change set of the block: PlainState key -> previous value
+func blockChanges(tx kv.Tx, table string, blockNum uint64) (map[string][]byte, error) {
+	changes := map[string][]byte{}
+	if err := tx.ForPrefix(table, hexutility.EncodeTs(blockNum), func(k, v []byte) error {
+		_, key, prev, err := historyv2.FromDBFormat(k, v)
+		if err != nil {
+			return err
+		}
+		changes[string(key)] = prev
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// receiptDiff - the consensus fields of the receipts which differ, empty if none
+func receiptDiff(goReceipt, silkwormReceipt *types.Receipt) string {
+	switch {
+	case goReceipt.Status != silkwormReceipt.Status:
+		return fmt.Sprintf("status go=%d silkworm=%d", goReceipt.Status, silkwormReceipt.Status)
+	case goReceipt.CumulativeGasUsed != silkwormReceipt.CumulativeGasUsed:
+		return fmt.Sprintf("cumulativeGasUsed go=%d silkworm=%d", goReceipt.CumulativeGasUsed, silkwormReceipt.CumulativeGasUsed)
+	case (goReceipt.DepositNonce == nil) != (silkwormReceipt.DepositNonce == nil) ||
+		goReceipt.DepositNonce != nil && *goReceipt.DepositNonce != *silkwormReceipt.DepositNonce:
+		return "depositNonce"
+	case len(goReceipt.Logs) != len(silkwormReceipt.Logs):
+		return fmt.Sprintf("logs go=%d silkworm=%d", len(goReceipt.Logs), len(silkwormReceipt.Logs))
+	}
+	for i, l := range goReceipt.Logs {
+		sl := silkwormReceipt.Logs[i]
+		if l.Address != sl.Address || !bytes.Equal(l.Data, sl.Data) || len(l.Topics) != len(sl.Topics) {
+			return fmt.Sprintf("log %d", i)
+		}
+		for j := range l.Topics {
+			if l.Topics[j] != sl.Topics[j] {
+				return fmt.Sprintf("log %d", i)
+			}
+		}
+	}
+	return ""
+}
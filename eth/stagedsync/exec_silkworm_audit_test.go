@@ -0,0 +1,57 @@
+package stagedsync
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutility"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/membatchwithdb"
+	"github.com/erigontech/erigon-lib/kv/memdb"
+	"github.com/erigontech/erigon-lib/log/v3"
+)
+
+func TestSilkwormAudit(t *testing.T) {
+	require.Nil(t, newSilkwormAudit(10, nil))
+
+	a := &silkwormAudit{sample: 10}
+	require.True(t, a.sampled(20))
+	require.False(t, a.sampled(21))
+	require.Equal(t, uint64(29), a.batchEnd(21, 100))
+	require.Equal(t, uint64(25), a.batchEnd(21, 25))
+	require.Equal(t, uint64(100), (*silkwormAudit)(nil).batchEnd(21, 100))
+	require.True(t, a.useSilkworm())
+	a.disabled.Store(true)
+	require.False(t, a.useSilkworm())
+}
+
+func TestCompareExecution(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	overlay := membatchwithdb.NewMemoryBatch(tx, t.TempDir(), log.New())
+	defer overlay.Close()
+
+	same, differs, silkwormOnly := libcommon.Address{1}, libcommon.Address{2}, libcommon.Address{3}
+	block := hexutility.EncodeTs(5)
+	for _, addr := range []libcommon.Address{same, differs} {
+		require.NoError(t, overlay.Put(kv.AccountChangeSet, block, addr[:]))
+		require.NoError(t, overlay.Put(kv.PlainState, addr[:], []byte{1}))
+	}
+	for _, addr := range []libcommon.Address{same, differs, silkwormOnly} {
+		require.NoError(t, tx.Put(kv.AccountChangeSet, block, addr[:]))
+	}
+	require.NoError(t, tx.Put(kv.PlainState, same[:], []byte{1}))
+	require.NoError(t, tx.Put(kv.PlainState, differs[:], []byte{2}))
+
+	diffs, err := compareExecution(5, overlay, tx)
+	require.NoError(t, err)
+	require.Len(t, diffs, 2)
+	require.Contains(t, diffs[0], fmt.Sprintf("PlainState %x", differs))
+	require.Contains(t, diffs[1], "changed only by silkworm")
+
+	diffs, err = compareExecution(6, overlay, tx)
+	require.NoError(t, err)
+	require.Empty(t, diffs)
+}
@@ -0,0 +1,93 @@
+package stagedsync
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/erigontech/erigon-lib/kv"
+)
+
+var reconCheckpointKey = []byte("ReconstitutionCheckpoint")
+
+// ReconCheckpoint is persisted when a step of the state reconstitution is loaded into the chaindata,
+// so after a crash the reconstitution goes on from the next step instead of from the start
+type ReconCheckpoint struct {
+	ToBlock   uint64 `json:"toBlock"`
+	ToTxNum   uint64 `json:"toTxNum"`
+	Steps     int    `json:"steps"`
+	DoneSteps int    `json:"doneSteps"`
+	DoneTxNum uint64 `json:"doneTxNum"` // end of the last done step
+}
+
+// resumes - the checkpoint is of the same reconstitution
+func (c *ReconCheckpoint) resumes(toTxNum uint64, steps int) bool {
+	return c != nil && c.ToTxNum == toTxNum && c.Steps == steps && c.DoneSteps < steps
+}
+
+func ReadReconCheckpoint(tx kv.Getter) (*ReconCheckpoint, error) {
+	v, err := tx.GetOne(kv.DatabaseInfo, reconCheckpointKey)
+	if err != nil || len(v) == 0 {
+		return nil, err
+	}
+	var c ReconCheckpoint
+	if err := json.Unmarshal(v, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func WriteReconCheckpoint(tx kv.Putter, c *ReconCheckpoint) error {
+	v, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return tx.Put(kv.DatabaseInfo, reconCheckpointKey, v)
+}
+
+func DeleteReconCheckpoint(tx kv.Deleter) error {
+	return tx.Delete(kv.DatabaseInfo, reconCheckpointKey)
+}
+
+// ReconProgress - live state of the reconstitution running in this process
+type ReconProgress struct {
+	Running      bool      `json:"running"`
+	StartedAt    time.Time `json:"startedAt"`
+	Step         int       `json:"step"` // 1-based
+	Steps        int       `json:"steps"`
+	StepFromTx   uint64    `json:"stepFromTxNum"`
+	StepToTx     uint64    `json:"stepToTxNum"`
+	TxNum        uint64    `json:"txNum"`
+	ToTxNum      uint64    `json:"toTxNum"`
+	TxNumsPerSec float64   `json:"txNumsPerSecond"`
+	ETA          string    `json:"eta,omitempty"`
+	Workers      int       `json:"workers"`
+	// WorkerUtilization - fill of the work queue: near 1 the workers are the bottleneck, near 0 they wait for work
+	WorkerUtilization float64 `json:"workerUtilization"`
+}
+
+var (
+	reconProgressLock sync.Mutex
+	reconProgress     ReconProgress
+)
+
+// ReconStatus - snapshot of the live progress, Running is false when no reconstitution runs in this process
+func ReconStatus() ReconProgress {
+	reconProgressLock.Lock()
+	defer reconProgressLock.Unlock()
+	return reconProgress
+}
+
+func updateReconProgress(f func(p *ReconProgress)) {
+	reconProgressLock.Lock()
+	defer reconProgressLock.Unlock()
+	f(&reconProgress)
+}
+
+// reconETA - time left at the current speed
+func reconETA(txNum, toTxNum uint64, txNumsPerSec float64) string {
+	if txNumsPerSec <= 0 || txNum >= toTxNum {
+		return ""
+	}
+	return (time.Duration(float64(toTxNum-txNum)/txNumsPerSec) * time.Second).Round(time.Second).String()
+}
@@ -0,0 +1,36 @@
+package stagedsync
+
+import (
+	"testing"
+
+	"github.com/erigontech/erigon-lib/kv/memdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconCheckpoint(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+
+	c, err := ReadReconCheckpoint(tx)
+	require.NoError(t, err)
+	require.Nil(t, c)
+	require.False(t, c.resumes(1_000, 4))
+
+	require.NoError(t, WriteReconCheckpoint(tx, &ReconCheckpoint{ToBlock: 10, ToTxNum: 1_000, Steps: 4, DoneSteps: 2, DoneTxNum: 500}))
+	c, err = ReadReconCheckpoint(tx)
+	require.NoError(t, err)
+	require.Equal(t, &ReconCheckpoint{ToBlock: 10, ToTxNum: 1_000, Steps: 4, DoneSteps: 2, DoneTxNum: 500}, c)
+	require.True(t, c.resumes(1_000, 4))
+	require.False(t, c.resumes(2_000, 4)) // snapshots advanced: a new reconstitution
+	require.False(t, c.resumes(1_000, 5))
+
+	require.NoError(t, DeleteReconCheckpoint(tx))
+	c, err = ReadReconCheckpoint(tx)
+	require.NoError(t, err)
+	require.Nil(t, c)
+}
+
+func TestReconETA(t *testing.T) {
+	require.Equal(t, "", reconETA(100, 1_000, 0))
+	require.Equal(t, "", reconETA(1_000, 1_000, 10))
+	require.Equal(t, "1m30s", reconETA(100, 1_000, 10))
+}
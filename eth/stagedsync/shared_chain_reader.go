@@ -0,0 +1,48 @@
+package stagedsync
+
+import (
+	lru "github.com/hashicorp/golang-lru/v2"
+
+	"github.com/erigontech/erigon-lib/chain"
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/turbo/services"
+)
+
+// sharedChainReaderHeaders - enough for the ancestors engines look at while verifying a batch of blocks
+const sharedChainReaderHeaders = 4096
+
+// SharedChainReader - the chain reader of the node, shared by engine verification, forkchoice canonicalization and
+// mining stages instead of a new ChainReaderImpl per call reading the same headers again. Safe for concurrent use:
+// each caller gets the reader of its own tx, and the readers share an LRU cache of headers by hash. Headers are
+// immutable by hash, so cached ones stay valid across txs and reorgs; canonical hashes are always read from the tx.
+type SharedChainReader struct {
+	config      *chain.Config
+	blockReader services.FullBlockReader
+	headers     *lru.Cache[libcommon.Hash, *types.Header]
+	logger      log.Logger
+}
+
+func NewSharedChainReader(config *chain.Config, blockReader services.FullBlockReader, logger log.Logger) *SharedChainReader {
+	headers, err := lru.New[libcommon.Hash, *types.Header](sharedChainReaderHeaders)
+	if err != nil {
+		panic(err)
+	}
+	return &SharedChainReader{config: config, blockReader: blockReader, headers: headers, logger: logger}
+}
+
+// Tx - the reader of the tx, it has to be used in the goroutine of the tx. The headers returned must not be modified.
+func (r *SharedChainReader) Tx(tx kv.Tx) *ChainReaderImpl {
+	return &ChainReaderImpl{config: r.config, tx: tx, blockReader: r.blockReader, headers: r.headers, logger: r.logger}
+}
+
+// chainReaderOf - the reader of the tx from the shared reader, or a new one if there is none (e.g. integration tools)
+func chainReaderOf(shared *SharedChainReader, config *chain.Config, tx kv.Tx, blockReader services.FullBlockReader, logger log.Logger) *ChainReaderImpl {
+	if shared == nil {
+		return NewChainReaderImpl(config, tx, blockReader, logger)
+	}
+	return shared.Tx(tx)
+}
@@ -0,0 +1,48 @@
+package stagedsync
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/dbutils"
+	"github.com/erigontech/erigon-lib/kv/memdb"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/core/rawdb"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/params"
+)
+
+func TestSharedChainReader(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	shared := NewSharedChainReader(params.TestChainConfig, nil, log.New())
+
+	h1 := &types.Header{Number: big.NewInt(1), Extra: []byte("a")}
+	side := &types.Header{Number: big.NewInt(1), Extra: []byte("b")}
+	for _, h := range []*types.Header{h1, side} {
+		require.NoError(t, rawdb.WriteHeader(tx, h))
+	}
+	require.NoError(t, rawdb.WriteCanonicalHash(tx, h1.Hash(), 1))
+
+	r := shared.Tx(tx)
+	require.Equal(t, h1.Hash(), r.GetHeaderByNumber(1).Hash())
+	require.Nil(t, r.GetHeaderByNumber(2))
+	require.Nil(t, r.GetHeader(h1.Hash(), 2))
+
+	// the header is cached by hash for the readers of the other txs
+	require.NoError(t, tx.Delete(kv.Headers, dbutils.HeaderKey(1, h1.Hash())))
+	require.Equal(t, h1.Hash(), shared.Tx(tx).GetHeader(h1.Hash(), 1).Hash())
+	require.Equal(t, h1.Hash(), shared.Tx(tx).GetHeaderByHash(h1.Hash()).Hash())
+
+	// as long as the tx has it
+	rawdb.DeleteHeader(tx, h1.Hash(), 1)
+	require.Nil(t, shared.Tx(tx).GetHeader(h1.Hash(), 1))
+	require.Nil(t, shared.Tx(tx).GetHeaderByHash(h1.Hash()))
+
+	// while the canonical hash is always read from the tx
+	require.NoError(t, rawdb.WriteCanonicalHash(tx, side.Hash(), 1))
+	require.Equal(t, side.Hash(), shared.Tx(tx).GetHeaderByNumber(1).Hash())
+}
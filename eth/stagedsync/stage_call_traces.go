@@ -21,6 +21,7 @@ import (
 	"github.com/erigontech/erigon-lib/log/v3"
 
 	"github.com/erigontech/erigon/common/math"
+	"github.com/erigontech/erigon/eth/calltracer"
 	"github.com/erigontech/erigon/ethdb/prune"
 	"github.com/erigontech/erigon/params"
 )
@@ -377,7 +378,7 @@ func PruneCallTraces(s *PruneState, tx kv.RwTx, cfg CallTracesCfg, ctx context.C
 	}
 
 	if cfg.prune.CallTraces.Enabled() {
-		if err = pruneCallTraces(tx, logPrefix, cfg.prune.CallTraces.PruneTo(s.ForwardProgress), ctx, cfg.tmpdir, logger); err != nil {
+		if err = pruneCallTraces(tx, logPrefix, cfg.prune.CallTraces.PruneTo(s.ForwardProgress), cfg.prune.KeepDeposits, ctx, cfg.tmpdir, logger); err != nil {
 			return err
 		}
 	}
@@ -393,10 +394,15 @@ func PruneCallTraces(s *PruneState, tx kv.RwTx, cfg CallTracesCfg, ctx context.C
 	return nil
 }
 
-func pruneCallTraces(tx kv.RwTx, logPrefix string, pruneTo uint64, ctx context.Context, tmpdir string, logger log.Logger) error {
+// pruneCallTraces - the index entries of the blocks below pruneTo of the addresses of CallTraceSet. With keepDeposits
+// the blocks where the address was touched by OP deposit txs (see calltracer.DepositFlag) are kept.
+func pruneCallTraces(tx kv.RwTx, logPrefix string, pruneTo uint64, keepDeposits bool, ctx context.Context, tmpdir string, logger log.Logger) error {
 	logEvery := time.NewTicker(logInterval)
 	defer logEvery.Stop()
 
+	pruneFrom := uint64(math.MaxUint64)        // first block of CallTraceSet, the index before it is left as is
+	deposits := map[string]*roaring64.Bitmap{} // address -> blocks kept by keepDeposits
+
 	froms := etl.NewCollector(logPrefix, tmpdir, etl.NewOldestEntryBuffer(etl.BufferOptimalSize), logger)
 	defer froms.Close()
 	tos := etl.NewCollector(logPrefix, tmpdir, etl.NewOldestEntryBuffer(etl.BufferOptimalSize), logger)
@@ -421,7 +427,16 @@ func pruneCallTraces(tx kv.RwTx, logPrefix string, pruneTo uint64, ctx context.C
 			if len(v) != length.Addr+1 {
 				return fmt.Errorf("wrong size of value in CallTraceSet: %x (size %d)", v, len(v))
 			}
+			pruneFrom = min(pruneFrom, blockNum)
 			mapKey := v[:length.Addr]
+			if keepDeposits && v[length.Addr]&calltracer.DepositFlag > 0 {
+				m, ok := deposits[string(mapKey)]
+				if !ok {
+					m = roaring64.New()
+					deposits[string(mapKey)] = m
+				}
+				m.Add(blockNum)
+			}
 			if v[length.Addr]&1 > 0 {
 				if err := froms.Collect(mapKey, nil); err != nil {
 					return err
@@ -452,17 +467,25 @@ func pruneCallTraces(tx kv.RwTx, logPrefix string, pruneTo uint64, ctx context.C
 		defer c.Close()
 
 		if err := froms.Load(tx, "", func(from, _ []byte, _ etl.CurrentTableReader, _ etl.LoadNextFunc) error {
-			for k, _, err := c.Seek(from); k != nil; k, _, err = c.Next() {
+			for k, v, err := c.Seek(from); k != nil; k, v, err = c.Next() {
 				if err != nil {
 					return err
 				}
 				blockNum := binary.BigEndian.Uint64(k[length.Addr:])
-				if !bytes.HasPrefix(k, from) || blockNum >= pruneTo {
+				if !bytes.HasPrefix(k, from) || (blockNum >= pruneTo && !keepDeposits) {
 					break
 				}
-				if err = c.DeleteCurrent(); err != nil {
+				if keepDeposits {
+					err = pruneCallIndexShard(c, k, v, pruneFrom, pruneTo, deposits[string(from)])
+				} else {
+					err = c.DeleteCurrent()
+				}
+				if err != nil {
 					return fmt.Errorf("failed delete, block=%d: %w", blockNum, err)
 				}
+				if blockNum >= pruneTo { // keepDeposits prunes the shard with pruneTo too
+					break
+				}
 			}
 			select {
 			case <-logEvery.C:
@@ -484,17 +507,25 @@ func pruneCallTraces(tx kv.RwTx, logPrefix string, pruneTo uint64, ctx context.C
 		defer c.Close()
 
 		if err := tos.Load(tx, "", func(to, _ []byte, _ etl.CurrentTableReader, _ etl.LoadNextFunc) error {
-			for k, _, err := c.Seek(to); k != nil; k, _, err = c.Next() {
+			for k, v, err := c.Seek(to); k != nil; k, v, err = c.Next() {
 				if err != nil {
 					return err
 				}
 				blockNum := binary.BigEndian.Uint64(k[length.Addr:])
-				if !bytes.HasPrefix(k, to) || blockNum >= pruneTo {
+				if !bytes.HasPrefix(k, to) || (blockNum >= pruneTo && !keepDeposits) {
 					break
 				}
-				if err = c.DeleteCurrent(); err != nil {
+				if keepDeposits {
+					err = pruneCallIndexShard(c, k, v, pruneFrom, pruneTo, deposits[string(to)])
+				} else {
+					err = c.DeleteCurrent()
+				}
+				if err != nil {
 					return fmt.Errorf("failed delete, block=%d: %w", blockNum, err)
 				}
+				if blockNum >= pruneTo { // keepDeposits prunes the shard with pruneTo too
+					break
+				}
 			}
 			select {
 			case <-logEvery.C:
@@ -510,3 +541,32 @@ func pruneCallTraces(tx kv.RwTx, logPrefix string, pruneTo uint64, ctx context.C
 	}
 	return nil
 }
+
+// pruneCallIndexShard - the blocks of [pruneFrom, pruneTo) are removed from the index shard at the cursor, except the
+// blocks of keep
+func pruneCallIndexShard(c kv.RwCursor, k, v []byte, pruneFrom, pruneTo uint64, keep *roaring64.Bitmap) error {
+	m := roaring64.New()
+	if _, err := m.ReadFrom(bytes.NewReader(v)); err != nil {
+		return err
+	}
+	var kept *roaring64.Bitmap
+	if keep != nil {
+		kept = roaring64.And(m, keep)
+	}
+	before := m.GetCardinality()
+	m.RemoveRange(pruneFrom, pruneTo)
+	if kept != nil {
+		m.Or(kept)
+	}
+	if m.GetCardinality() == before {
+		return nil
+	}
+	if m.IsEmpty() {
+		return c.DeleteCurrent()
+	}
+	buf := bytes.NewBuffer(nil)
+	if _, err := m.WriteTo(buf); err != nil {
+		return err
+	}
+	return c.Put(libcommon.Copy(k), buf.Bytes())
+}
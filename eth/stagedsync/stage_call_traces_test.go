@@ -14,6 +14,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/eth/calltracer"
 	"github.com/erigontech/erigon/eth/stagedsync/stages"
 )
 
@@ -79,6 +80,35 @@ func TestCallTrace(t *testing.T) {
 	require.Equal([]uint64{1, 11, 21}, tos().ToArray())
 
 	// prune 0 -> 10
-	err = pruneCallTraces(tx, "test", 10, ctx, "", logger)
+	err = pruneCallTraces(tx, "test", 10, false, ctx, "", logger)
 	require.NoError(err)
 }
+
+func TestPruneCallTracesKeepDeposits(t *testing.T) {
+	logger := log.New()
+	ctx, require := context.Background(), require.New(t)
+	histV3, db, _ := temporaltest.NewTestDB(t, datadir.New(t.TempDir()))
+	if histV3 {
+		t.Skip()
+	}
+	tx, err := db.BeginRw(context.Background())
+	require.NoError(err)
+	defer tx.Rollback()
+
+	addr := [20]byte{1}
+	for i := uint64(0); i < 10; i++ {
+		v := append(addr[:], 1)
+		if i == 3 {
+			v[20] |= calltracer.DepositFlag
+		}
+		require.NoError(tx.Put(kv.CallTraceSet, hexutility.EncodeTs(i), v))
+	}
+	err = promoteCallTraces("test", tx, 0, 9, 0, time.Nanosecond, ctx.Done(), "", logger)
+	require.NoError(err)
+
+	err = pruneCallTraces(tx, "test", 8, true, ctx, "", logger)
+	require.NoError(err)
+	froms, err := bitmapdb.Get64(tx, kv.CallFromIndex, addr[:], 0, 10)
+	require.NoError(err)
+	require.Equal([]uint64{3, 8, 9}, froms.ToArray())
+}
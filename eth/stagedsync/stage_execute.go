@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"runtime"
 	"time"
 
@@ -44,6 +45,7 @@ import (
 	"github.com/erigontech/erigon/eth/calltracer"
 	"github.com/erigontech/erigon/eth/ethconfig"
 	"github.com/erigontech/erigon/eth/ethconfig/estimate"
+	"github.com/erigontech/erigon/eth/gasmeter"
 	"github.com/erigontech/erigon/eth/stagedsync/stages"
 	tracelogger "github.com/erigontech/erigon/eth/tracers/logger"
 	"github.com/erigontech/erigon/ethdb/prune"
@@ -78,9 +80,11 @@ type ExecuteBlockCfg struct {
 	engine        consensus.Engine
 	vmConfig      *vm.Config
 	badBlockHalt  bool
+	badBlockDump  *BadBlockDump // nil unless syncCfg.BadBlockHalt
 	stateStream   bool
 	accumulator   *shards.Accumulator
 	blockReader   services.FullBlockReader
+	chainReader   *SharedChainReader
 	hd            headerDownloader
 	// last valid number of the stage
 
@@ -90,7 +94,8 @@ type ExecuteBlockCfg struct {
 	genesis   *types.Genesis
 	agg       *libstate.Aggregator
 
-	silkworm *silkworm.Silkworm
+	silkworm      *silkworm.Silkworm
+	silkwormAudit *silkwormAudit // nil unless syncCfg.SilkwormAudit
 }
 
 func StageExecuteBlocksCfg(
@@ -108,6 +113,7 @@ func StageExecuteBlocksCfg(
 	historyV3 bool,
 	dirs datadir.Dirs,
 	blockReader services.FullBlockReader,
+	chainReader *SharedChainReader,
 	hd headerDownloader,
 	genesis *types.Genesis,
 	syncCfg ethconfig.Sync,
@@ -125,14 +131,17 @@ func StageExecuteBlocksCfg(
 		dirs:          dirs,
 		accumulator:   accumulator,
 		stateStream:   stateStream,
-		badBlockHalt:  badBlockHalt,
+		badBlockHalt:  badBlockHalt || syncCfg.BadBlockHalt,
+		badBlockDump:  NewBadBlockDump(syncCfg, dirs, chainConfig, engine, blockReader),
 		blockReader:   blockReader,
+		chainReader:   chainReader,
 		hd:            hd,
 		genesis:       genesis,
 		historyV3:     historyV3,
 		syncCfg:       syncCfg,
 		agg:           agg,
 		silkworm:      silkworm,
+		silkwormAudit: newSilkwormAudit(syncCfg.SilkwormAudit, silkworm),
 	}
 }
 
@@ -153,6 +162,12 @@ func executeBlock(
 	if err != nil {
 		return err
 	}
+	execReader, execWriter := stateReader, stateWriter
+	var balRecorder *state.BlockAccessListRecorder
+	if cfg.syncCfg.BlockAccessLists {
+		balRecorder = state.NewBlockAccessListRecorder()
+		execReader, execWriter = balRecorder.Reader(stateReader), balRecorder.Writer(stateWriter)
+	}
 
 	// where the magic happens
 	getHeader := func(hash common.Hash, number uint64) *types.Header {
@@ -165,24 +180,49 @@ func executeBlock(
 	}
 
 	callTracer := calltracer.NewCallTracer()
+	if cfg.prune.KeepDeposits {
+		callTracer.TrackDeposits(block.Transactions())
+	}
 	vmConfig.Debug = true
 	vmConfig.Tracer = callTracer
+	vmConfig.VerifyDepositNonces = cfg.syncCfg.VerifyDepositNonces
+	var gasMeter *gasmeter.GasMeter
+	diagnosed := dbg.GasMetering && diagnostics.TypeOf(diagnostics.BlockGasUsage{}).Enabled()
+	hotContracts := gasmeter.Hot()
+	if diagnosed || hotContracts != nil {
+		gasMeter = gasmeter.New(callTracer)
+		vmConfig.Tracer = gasMeter
+	}
 
 	var receipts types.Receipts
 	var stateSyncReceipt *types.Receipt
 	var execRs *core.EphemeralExecResult
 	getHashFn := core.GetHashFn(block.Header(), getHeader)
 
-	execRs, err = core.ExecuteBlockEphemerally(cfg.chainConfig, &vmConfig, getHashFn, cfg.engine, block, stateReader, stateWriter, NewChainReaderImpl(cfg.chainConfig, tx, cfg.blockReader, logger), getTracer, logger)
+	execRs, err = core.ExecuteBlockEphemerally(cfg.chainConfig, &vmConfig, getHashFn, cfg.engine, block, execReader, execWriter, chainReaderOf(cfg.chainReader, cfg.chainConfig, tx, cfg.blockReader, logger), getTracer, logger)
 	if err != nil {
-		return fmt.Errorf("%w: %v", consensus.ErrInvalidBlock, err)
+		return fmt.Errorf("%w: %w", consensus.ErrInvalidBlock, err)
 	}
 	receipts = execRs.Receipts
 	stateSyncReceipt = execRs.StateSyncReceipt
+	if diagnosed {
+		diagnostics.Send(gasMeter.BlockUsage(blockNum, 10))
+	}
+	if hotContracts != nil {
+		hotContracts.Add(blockNum, block.Time(), gasMeter)
+	}
 
 	// If writeReceipts is false here, append the not to be pruned receipts anyways
-	if writeReceipts || gatherNoPruneReceipts(&receipts, cfg.chainConfig) {
-		if err = rawdb.AppendReceipts(tx, blockNum, receipts); err != nil {
+	keepDeposits := cfg.prune.KeepDeposits && hasBridgeDeposits(receipts)
+	if writeReceipts || gatherNoPruneReceipts(&receipts, cfg.chainConfig) || keepDeposits {
+		if cfg.prune.Experiments.ReceiptsIndexOnly && !keepDeposits {
+			// logs are still needed by LogIndex, it prunes them once indexed. The receipts of the blocks with bridge
+			// deposits are stored anyway, LogIndex keeps their logs.
+			err = rawdb.AppendLogs(tx, blockNum, receipts)
+		} else {
+			err = rawdb.AppendReceipts(tx, blockNum, receipts)
+		}
+		if err != nil {
 			return err
 		}
 
@@ -193,6 +233,12 @@ func executeBlock(
 		}
 	}
 
+	if balRecorder != nil {
+		if err := rawdb.WriteBlockAccessList(tx, block.Hash(), blockNum, balRecorder.AccessList()); err != nil {
+			return err
+		}
+	}
+
 	if cfg.changeSetHook != nil {
 		if hasChangeSet, ok := stateWriter.(HasChangeSetWriter); ok {
 			cfg.changeSetHook(blockNum, hasChangeSet.ChangeSetWriter())
@@ -201,6 +247,9 @@ func executeBlock(
 	if writeCallTraces {
 		return callTracer.WriteToDb(tx, block, *cfg.vmConfig)
 	}
+	if cfg.prune.KeepDeposits {
+		return callTracer.WriteDepositsToDb(tx, block)
+	}
 	return nil
 }
 
@@ -228,6 +277,52 @@ func gatherNoPruneReceipts(receipts *types.Receipts, chainCfg *chain.Config) boo
 	return receipts.Len() > 0
 }
 
+// hasBridgeDeposits - the block of the receipts has bridge deposits, its receipts and logs aren't pruned by
+// --prune.keep-deposits. The L1 attributes deposit, first tx of every OP block, doesn't count (or nothing would be
+// pruned): its receipt has no logs, the tx itself holds the attributes.
+func hasBridgeDeposits(receipts types.Receipts) bool {
+	for i, r := range receipts {
+		if i > 0 && r.Type == types.DepositTxType {
+			return true
+		}
+	}
+	return false
+}
+
+// pruneReceiptsKeepDeposits - pruning of kv.Receipts in [pruneFrom, pruneTo), except the blocks with bridge deposits
+func pruneReceiptsKeepDeposits(tx kv.RwTx, pruneFrom, pruneTo uint64, ctx context.Context) error {
+	c, err := tx.RwCursor(kv.Receipts)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	for k, v, err := c.Seek(hexutility.EncodeTs(pruneFrom)); k != nil; k, v, err = c.Next() {
+		if err != nil {
+			return err
+		}
+		blockNum := binary.BigEndian.Uint64(k)
+		if blockNum >= pruneTo {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return common.ErrStopped
+		default:
+		}
+		receipts, err := types.DecodeReceiptsForStorage(v)
+		if err != nil {
+			return fmt.Errorf("receipt unmarshal failed: %w, block=%d", err, blockNum)
+		}
+		if hasBridgeDeposits(receipts) {
+			continue
+		}
+		if err = c.DeleteCurrent(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func newStateReaderWriter(
 	batch kv.StatelessRwTx,
 	tx kv.RwTx,
@@ -263,6 +358,11 @@ func newStateReaderWriter(
 // ================ Erigon3 ================
 
 func ExecBlockV3(s *StageState, u Unwinder, txc wrap.TxContainer, toBlock uint64, ctx context.Context, cfg ExecuteBlockCfg, initialCycle bool, logger log.Logger) (err error) {
+	if cfg.syncCfg.VerifyDepositNonces {
+		return errors.New("--sync.verify.deposit.nonces is not supported by the execution of history v3")
+	}
+	warnCommitTriggerV3(cfg.syncCfg, logger)
+	warnPrefetchV3(cfg.syncCfg, logger)
 	workersCount := cfg.syncCfg.ExecWorkerCount
 	//workersCount := 2
 	if !initialCycle {
@@ -349,8 +449,10 @@ func unwindExec3(u *UnwindState, s *StageState, txc wrap.TxContainer, ctx contex
 	if err := rawdb.TruncateReceipts(txc.Tx, u.UnwindPoint+1); err != nil {
 		return fmt.Errorf("truncate receipts: %w", err)
 	}
-	if err := rawdb.TruncateBorReceipts(txc.Tx, u.UnwindPoint+1); err != nil {
-		return fmt.Errorf("truncate bor receipts: %w", err)
+	if cfg.chainConfig != nil && cfg.chainConfig.Bor != nil {
+		if err := rawdb.TruncateBorReceipts(txc.Tx, u.UnwindPoint+1); err != nil {
+			return fmt.Errorf("truncate bor receipts: %w", err)
+		}
 	}
 	if err := rawdb.DeleteNewerEpochs(txc.Tx, u.UnwindPoint+1); err != nil {
 		return fmt.Errorf("delete newer epochs: %w", err)
@@ -389,6 +491,10 @@ func SpawnExecuteBlocksStage(s *StageState, u Unwinder, txc wrap.TxContainer, to
 		return nil
 	}
 
+	if err = finishPendingUnwind(s, txc.Tx, ctx, cfg, logger); err != nil {
+		return err
+	}
+
 	quit := ctx.Done()
 	useExternalTx := txc.Tx != nil
 	if !useExternalTx {
@@ -444,6 +550,10 @@ func SpawnExecuteBlocksStage(s *StageState, u Unwinder, txc wrap.TxContainer, to
 	gasState := uint64(cfg.batchSize) * uint64(datasize.KB) * 2
 
 	var stoppedErr error
+	commitTrigger := newExecCommitTrigger(cfg.batchSize, cfg.syncCfg, useExternalTx)
+
+	profiler := newSlowExecProfiler(ctx, logPrefix, string(s.ID), filepath.Join(cfg.dirs.DataDir, "pprof"), dbg.ExecProfileMgas, stageProgress, logger)
+	defer func() { profiler.stop(stageProgress) }()
 
 	var batch kv.PendingMutations
 	// state is stored through ethdb batches
@@ -498,9 +608,13 @@ Loop:
 		metrics.UpdateBlockConsumerPreExecutionDelay(block.Time(), blockNum, logger)
 
 		_, isMemoryMutation := txc.Tx.(*membatchwithdb.MemoryMutation)
-		if cfg.silkworm != nil && !isMemoryMutation {
-			if useExternalTx {
-				blockNum, err = silkworm.ExecuteBlocksEphemeral(cfg.silkworm, txc.Tx, cfg.chainConfig.ChainID, blockNum, to, uint64(cfg.batchSize), writeChangeSets, writeReceipts, writeCallTraces)
+		useSilkworm := cfg.silkworm != nil && !isMemoryMutation && cfg.silkwormAudit.useSilkworm()
+		if useSilkworm && cfg.silkwormAudit.sampled(blockNum) {
+			err = cfg.silkwormAudit.auditBlock(txc.Tx, block, cfg, writeCallTraces, logger)
+		} else if useSilkworm {
+			// the audited blocks are executed in the tx of the stage
+			if useExternalTx || cfg.silkwormAudit != nil {
+				blockNum, err = silkworm.ExecuteBlocksEphemeral(cfg.silkworm, txc.Tx, cfg.chainConfig.ChainID, blockNum, cfg.silkwormAudit.batchEnd(blockNum, to), uint64(cfg.batchSize), writeChangeSets, writeReceipts, writeCallTraces)
 			} else {
 				// In case of internal tx we close it (no changes, commit not needed): Silkworm will use its own internal tx
 				txc.Tx.Rollback()
@@ -525,9 +639,22 @@ Loop:
 				blockNum++
 			}
 		} else {
+			var stopPrefetch func()
+			if !initialCycle && cfg.syncCfg.PrefetchBlocks > 0 && cfg.db != nil {
+				stopPrefetch = prefetchBlockStateAsync(ctx, cfg.db, block, cfg.syncCfg.PrefetchBlocks, logger)
+			}
 			err = executeBlock(block, txc.Tx, batch, cfg, *cfg.vmConfig, writeChangeSets, writeReceipts, writeCallTraces, stateStream, logger)
+			if stopPrefetch != nil {
+				stopPrefetch()
+			}
 		}
 
+		if errors.Is(err, errSilkwormMismatch) {
+			// Silkworm is off now, the block is executed again by the Go EVM after the unwind of its Silkworm state
+			stageProgress = blockNum
+			u.UnwindTo(blockNum-1, ExecUnwind)
+			break Loop
+		}
 		if err != nil {
 			if errors.Is(err, silkworm.ErrInterrupted) {
 				logger.Warn(fmt.Sprintf("[%s] Execution interrupted", logPrefix), "block", blockNum, "err", err)
@@ -540,15 +667,22 @@ Loop:
 				return nil
 			}
 			if !errors.Is(err, context.Canceled) {
-				if cfg.silkworm != nil {
+				if useSilkworm {
 					logger.Warn(fmt.Sprintf("[%s] Execution failed", logPrefix), "block", blockNum, "err", err)
 				} else {
 					logger.Warn(fmt.Sprintf("[%s] Execution failed", logPrefix), "block", blockNum, "hash", blockHash.String(), "err", err)
 				}
-				if cfg.hd != nil && errors.Is(err, consensus.ErrInvalidBlock) {
-					cfg.hd.ReportBadHeaderPoS(blockHash, block.ParentHash() /* lastValidAncestor */)
+				if errors.Is(err, consensus.ErrInvalidBlock) {
+					recordBadBlock(txc.Tx, block.HeaderNoCopy(), block, err, logger)
+					if cfg.hd != nil {
+						cfg.hd.ReportBadHeaderPoS(blockHash, block.ParentHash() /* lastValidAncestor */)
+					}
 				}
 				if cfg.badBlockHalt {
+					if !useSilkworm {
+						// nothing of the failed block is in batch, it's the state before it
+						cfg.badBlockDump.Write(ctx, txc.Tx, state.NewPlainStateReader(batch), block, blockNum, err, logger)
+					}
 					return err
 				}
 			}
@@ -563,8 +697,7 @@ Loop:
 
 		metrics.UpdateBlockConsumerPostExecutionDelay(block.Time(), blockNum, logger)
 
-		shouldUpdateProgress := batch.BatchSize() >= int(cfg.batchSize)
-		if shouldUpdateProgress {
+		if trigger := commitTrigger.reason(batch, txc.Tx, time.Now()); trigger != "" {
 			commitTime := time.Now()
 			if err = batch.Flush(ctx, txc.Tx); err != nil {
 				return err
@@ -583,7 +716,8 @@ Loop:
 				// TODO: This creates stacked up deferrals
 				defer txc.Tx.Rollback()
 			}
-			logger.Info("Committed State", "gas reached", currentStateGas, "gasTarget", gasState, "block", blockNum, "time", time.Since(commitTime), "committedToDb", !useExternalTx)
+			logger.Info("Committed State", "gas reached", currentStateGas, "gasTarget", gasState, "block", blockNum, "time", time.Since(commitTime), "committedToDb", !useExternalTx, "trigger", trigger)
+			commitTrigger.committed(time.Now())
 			currentStateGas = 0
 			batch = membatch.NewHashBatch(txc.Tx, quit, cfg.dirs.Tmp, logger)
 		}
@@ -594,6 +728,7 @@ Loop:
 		default:
 		case <-logEvery.C:
 			logBlock, logTx, logTime = logProgress(logPrefix, logBlock, logTime, blockNum, logTx, lastLogTx, gas, float64(currentStateGas)/float64(gasState), batch, logger, s.BlockNumber, to, startTime)
+			profiler.tick(blockNum, gas)
 			gas = 0
 			txc.Tx.CollectMetrics()
 			stages.SyncMetrics[stages.Execution].SetUint64(blockNum)
@@ -612,6 +747,12 @@ Loop:
 		return fmt.Errorf("writing plain state version: %w", err)
 	}
 
+	if cfg.syncCfg.IncrementalTrie && stateStream && cfg.silkworm == nil && stageProgress == to {
+		if _, err = updateTrieIncrementally(ctx, logPrefix, txc.Tx, s.BlockNumber, to, cfg, logger); err != nil {
+			return fmt.Errorf("incremental trie: %w", err)
+		}
+	}
+
 	if !useExternalTx {
 		if err = txc.Tx.Commit(); err != nil {
 			return err
@@ -705,6 +846,9 @@ func blocksReadAheadFunc(ctx context.Context, tx kv.Tx, cfg *ExecuteBlockCfg, bl
 	}
 	_, _ = stateReader.ReadAccountData(block.Coinbase())
 	_, _ = block, senders
+	if cfg.syncCfg.PrefetchBlocks > 0 {
+		return prefetchBlockState(ctx, tx, block, cfg.syncCfg.PrefetchBlocks)
+	}
 	return nil
 }
 
@@ -754,10 +898,22 @@ func logProgress(logPrefix string, prevBlock uint64, prevTime time.Time, current
 }
 
 func UnwindExecutionStage(u *UnwindState, s *StageState, txc wrap.TxContainer, ctx context.Context, cfg ExecuteBlockCfg, initialCycle bool, logger log.Logger) (err error) {
+	if !cfg.historyV3 {
+		if err = finishPendingUnwind(s, txc.Tx, ctx, cfg, logger); err != nil {
+			return err
+		}
+	}
 	if u.UnwindPoint >= s.BlockNumber {
 		return nil
 	}
+	logPrefix := u.LogPrefix()
+	logger.Info(fmt.Sprintf("[%s] Unwind Execution", logPrefix), "from", s.BlockNumber, "to", u.UnwindPoint)
+
 	useExternalTx := txc.Tx != nil
+	if !useExternalTx && !cfg.historyV3 {
+		// deep unwinds don't hold one RwTx: each batch is committed
+		return unwindExecutionInBatches(u, s, nil, ctx, cfg, logger)
+	}
 	if !useExternalTx {
 		txc.Tx, err = cfg.db.BeginRw(context.Background())
 		if err != nil {
@@ -765,8 +921,6 @@ func UnwindExecutionStage(u *UnwindState, s *StageState, txc wrap.TxContainer, c
 		}
 		defer txc.Tx.Rollback()
 	}
-	logPrefix := u.LogPrefix()
-	logger.Info(fmt.Sprintf("[%s] Unwind Execution", logPrefix), "from", s.BlockNumber, "to", u.UnwindPoint)
 
 	if err = unwindExecutionStage(u, s, txc, ctx, cfg, initialCycle, logger); err != nil {
 		return err
@@ -784,126 +938,258 @@ func UnwindExecutionStage(u *UnwindState, s *StageState, txc wrap.TxContainer, c
 }
 
 func unwindExecutionStage(u *UnwindState, s *StageState, txc wrap.TxContainer, ctx context.Context, cfg ExecuteBlockCfg, initialCycle bool, logger log.Logger) error {
-	logPrefix := s.LogPrefix()
-	stateBucket := kv.PlainState
-	storageKeyLength := length.Addr + length.Incarnation + length.Hash
+	if !cfg.historyV3 {
+		return unwindExecutionInBatches(u, s, txc.Tx, ctx, cfg, logger)
+	}
+	accumulator, err := unwindAccumulator(u, s, txc.Tx, ctx, cfg)
+	if err != nil {
+		return err
+	}
+	return unwindExec3(u, s, txc, ctx, cfg, accumulator, logger)
+}
 
-	var accumulator *shards.Accumulator
-	if cfg.stateStream && s.BlockNumber-u.UnwindPoint < stateStreamLimit {
-		accumulator = cfg.accumulator
+// unwindBatchBlocks - plain state is unwound in batches of this many blocks: ETL of a batch is bounded and,
+// if the stage owns the tx, progress is committed after every batch. It's bigger than stateStreamLimit,
+// so unwinds which are reported to the state stream always fit one batch.
+var unwindBatchBlocks uint64 = 10_000
 
-		hash, err := cfg.blockReader.CanonicalHash(ctx, txc.Tx, u.UnwindPoint)
+func unwindBatchFrom(unwindPoint, to uint64) uint64 {
+	if to-unwindPoint > unwindBatchBlocks {
+		return to - unwindBatchBlocks
+	}
+	return unwindPoint
+}
+
+// unwindExecutionInBatches - unwind of plain state in batches, each saving the progress of the stage. With own
+// txs (tx is nil) changesets of a batch are read by read-only txs (which see the result of the previous batch)
+// and state is rewound by a separate RwTx, committed after every batch; the unwind point is saved with the
+// batches before the last one, so an interrupted unwind is finished by finishPendingUnwind. With the caller's tx,
+// the batches bound the ETL of the unwind and are committed by the caller; changesets are read by the tx, it
+// can't be shared by goroutines.
+func unwindExecutionInBatches(u *UnwindState, s *StageState, tx kv.RwTx, ctx context.Context, cfg ExecuteBlockCfg, logger log.Logger) error {
+	logPrefix := s.LogPrefix()
+	ownTxs := tx == nil
+	update := func(f func(tx kv.RwTx) error) error {
+		if tx != nil {
+			return f(tx)
+		}
+		return cfg.db.Update(ctx, f)
+	}
+	for to, from := s.BlockNumber, s.BlockNumber; to > u.UnwindPoint; to = from {
+		from = unwindBatchFrom(u.UnwindPoint, to)
+		var readTx kv.Tx
+		if tx != nil {
+			readTx = tx
+		}
+		accountChanges, storageChanges, err := collectRewindData(ctx, cfg, readTx, from, to, logPrefix, logger)
 		if err != nil {
-			return fmt.Errorf("read canonical hash of unwind point: %w", err)
+			return err
 		}
-		txs, err := cfg.blockReader.RawTransactions(ctx, txc.Tx, u.UnwindPoint, s.BlockNumber)
+		err = update(func(tx kv.RwTx) error {
+			accumulator, err := unwindAccumulator(u, s, tx, ctx, cfg)
+			if err != nil {
+				return err
+			}
+			if err := unwindExecutionBatch(ctx, tx, from, accountChanges, storageChanges, accumulator, cfg.chainConfig != nil && cfg.chainConfig.Bor != nil, logger); err != nil {
+				return err
+			}
+			if err := stages.SaveStageProgress(tx, u.ID, from); err != nil {
+				return err
+			}
+			if !ownTxs {
+				return nil
+			}
+			if from > u.UnwindPoint {
+				return stages.SaveStageUnwindPoint(tx, u.ID, u.UnwindPoint)
+			}
+			return stages.DeleteStageUnwindPoint(tx, u.ID)
+		})
+		accountChanges.Close()
+		storageChanges.Close()
 		if err != nil {
 			return err
 		}
-		accumulator.StartChange(u.UnwindPoint, hash, txs, true)
+		if from > u.UnwindPoint {
+			logger.Info(fmt.Sprintf("[%s] Unwound batch", logPrefix), "block", from, "left", from-u.UnwindPoint)
+		}
 	}
+	return nil
+}
 
-	if cfg.historyV3 {
-		return unwindExec3(u, s, txc, ctx, cfg, accumulator, logger)
+// finishPendingUnwind finishes the unwind of plain state which was interrupted between its committed batches (see
+// unwindExecutionInBatches): the state is at a block of the abandoned chain, neither execution nor another unwind
+// can go on from it. With the caller's tx, the rest of the unwind is committed by the caller.
+func finishPendingUnwind(s *StageState, tx kv.RwTx, ctx context.Context, cfg ExecuteBlockCfg, logger log.Logger) (err error) {
+	var unwindPoint uint64
+	var ok bool
+	read := func(tx kv.Tx) (err error) {
+		unwindPoint, ok, err = stages.GetStageUnwindPoint(tx, s.ID)
+		return err
+	}
+	if tx != nil {
+		err = read(tx)
+	} else {
+		err = cfg.db.View(ctx, read)
+	}
+	if err != nil || !ok {
+		return err
 	}
+	if unwindPoint < s.BlockNumber {
+		logger.Warn(fmt.Sprintf("[%s] Finishing interrupted unwind", s.LogPrefix()), "from", s.BlockNumber, "to", unwindPoint)
+		if err := unwindExecutionInBatches(&UnwindState{ID: s.ID, UnwindPoint: unwindPoint}, s, tx, ctx, cfg, logger); err != nil {
+			return err
+		}
+		s.BlockNumber = unwindPoint
+	}
+	if tx != nil {
+		return stages.DeleteStageUnwindPoint(tx, s.ID)
+	}
+	return cfg.db.Update(ctx, func(tx kv.RwTx) error { return stages.DeleteStageUnwindPoint(tx, s.ID) })
+}
 
-	changes := etl.NewCollector(logPrefix, cfg.dirs.Tmp, etl.NewOldestEntryBuffer(etl.BufferOptimalSize), logger)
-	defer changes.Close()
-	errRewind := changeset.RewindData(txc.Tx, s.BlockNumber, u.UnwindPoint, changes, ctx.Done())
-	if errRewind != nil {
-		return fmt.Errorf("getting rewind data: %w", errRewind)
+// unwindAccumulator - accumulator of state changes for the state stream, nil if unwind is too deep for it
+func unwindAccumulator(u *UnwindState, s *StageState, tx kv.Tx, ctx context.Context, cfg ExecuteBlockCfg) (*shards.Accumulator, error) {
+	if !cfg.stateStream || s.BlockNumber-u.UnwindPoint >= stateStreamLimit {
+		return nil, nil
 	}
+	hash, err := cfg.blockReader.CanonicalHash(ctx, tx, u.UnwindPoint)
+	if err != nil {
+		return nil, fmt.Errorf("read canonical hash of unwind point: %w", err)
+	}
+	txs, err := cfg.blockReader.RawTransactions(ctx, tx, u.UnwindPoint, s.BlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	cfg.accumulator.StartChange(u.UnwindPoint, hash, txs, true)
+	return cfg.accumulator, nil
+}
 
-	if err := changes.Load(txc.Tx, stateBucket, func(k, v []byte, table etl.CurrentTableReader, next etl.LoadNextFunc) error {
-		if len(k) == 20 {
-			if len(v) > 0 {
-				var acc accounts.Account
-				if err := acc.DecodeForStorage(v); err != nil {
-					return err
-				}
+// collectRewindData collects oldest values of accounts and storage changed in blocks (from, to]. With tx
+// changesets are read by it, otherwise account and storage changesets are read in parallel by own
+// read-only txs.
+func collectRewindData(ctx context.Context, cfg ExecuteBlockCfg, tx kv.Tx, from, to uint64, logPrefix string, logger log.Logger) (accountChanges, storageChanges *etl.Collector, err error) {
+	// deep unwinds produce many times more changes than fit a buffer: the collectors stream to disk, and hold at most
+	// two buffers each in RAM, etl.BufferOptimalSize together as before streaming
+	limit := etl.BufferOptimalSize / 2 // per collector
+	accountChanges = etl.NewCollector(logPrefix, cfg.dirs.Tmp, etl.NewOldestEntryBuffer(limit/2), logger)
+	storageChanges = etl.NewCollector(logPrefix, cfg.dirs.Tmp, etl.NewOldestEntryBuffer(limit/2), logger)
+	accountChanges.MemoryLimit(limit)
+	accountChanges.Metrics("unwind_account_changes")
+	storageChanges.MemoryLimit(limit)
+	storageChanges.Metrics("unwind_storage_changes")
+	collect := func(ctx context.Context, bucket string, changes *etl.Collector) error {
+		if tx != nil {
+			return changeset.RewindBucketData(tx, bucket, to, from, changes, ctx.Done())
+		}
+		return cfg.db.View(ctx, func(tx kv.Tx) error {
+			return changeset.RewindBucketData(tx, bucket, to, from, changes, ctx.Done())
+		})
+	}
 
-				// Fetch the code hash
-				recoverCodeHashPlain(&acc, txc.Tx, k)
-				var address common.Address
-				copy(address[:], k)
+	if tx != nil {
+		err = collect(ctx, kv.AccountChangeSet, accountChanges)
+		if err == nil {
+			err = collect(ctx, kv.StorageChangeSet, storageChanges)
+		}
+	} else {
+		g, gCtx := errgroup.WithContext(ctx)
+		g.Go(func() error { return collect(gCtx, kv.AccountChangeSet, accountChanges) })
+		g.Go(func() error { return collect(gCtx, kv.StorageChangeSet, storageChanges) })
+		err = g.Wait()
+	}
+	if err != nil {
+		accountChanges.Close()
+		storageChanges.Close()
+		return nil, nil, fmt.Errorf("getting rewind data: %w", err)
+	}
+	return accountChanges, storageChanges, nil
+}
 
-				// cleanup contract code bucket
-				original, err := state.NewPlainStateReader(txc.Tx).ReadAccountData(address)
-				if err != nil {
-					return fmt.Errorf("read account for %x: %w", address, err)
-				}
-				if original != nil {
-					// clean up all the code incarnations original incarnation and the new one
-					for incarnation := original.Incarnation; incarnation > acc.Incarnation && incarnation > 0; incarnation-- {
-						err = txc.Tx.Delete(kv.PlainContractCode, dbutils.PlainGenerateStoragePrefix(address[:], incarnation))
-						if err != nil {
-							return fmt.Errorf("writeAccountPlain for %x: %w", address, err)
-						}
-					}
-				}
+// unwindExecutionBatch rewinds plain state to the collected values and deletes execution results of blocks above from
+func unwindExecutionBatch(ctx context.Context, tx kv.RwTx, from uint64, accountChanges, storageChanges *etl.Collector, accumulator *shards.Accumulator, bor bool, logger log.Logger) error {
+	if err := accountChanges.Load(tx, kv.PlainState, func(k, v []byte, table etl.CurrentTableReader, next etl.LoadNextFunc) error {
+		var address common.Address
+		copy(address[:], k)
+		if len(v) == 0 {
+			if accumulator != nil {
+				accumulator.DeleteAccount(address)
+			}
+			return next(k, k, nil)
+		}
 
-				newV := make([]byte, acc.EncodingLengthForStorage())
-				acc.EncodeForStorage(newV)
-				if accumulator != nil {
-					accumulator.ChangeAccount(address, acc.Incarnation, newV)
-				}
-				if err := next(k, k, newV); err != nil {
-					return err
-				}
-			} else {
-				if accumulator != nil {
-					var address common.Address
-					copy(address[:], k)
-					accumulator.DeleteAccount(address)
-				}
-				if err := next(k, k, nil); err != nil {
-					return err
+		var acc accounts.Account
+		if err := acc.DecodeForStorage(v); err != nil {
+			return err
+		}
+		// Fetch the code hash
+		recoverCodeHashPlain(&acc, tx, k)
+
+		// cleanup contract code bucket
+		original, err := state.NewPlainStateReader(tx).ReadAccountData(address)
+		if err != nil {
+			return fmt.Errorf("read account for %x: %w", address, err)
+		}
+		if original != nil {
+			// clean up all the code incarnations original incarnation and the new one
+			for incarnation := original.Incarnation; incarnation > acc.Incarnation && incarnation > 0; incarnation-- {
+				err = tx.Delete(kv.PlainContractCode, dbutils.PlainGenerateStoragePrefix(address[:], incarnation))
+				if err != nil {
+					return fmt.Errorf("writeAccountPlain for %x: %w", address, err)
 				}
 			}
-			return nil
 		}
+
+		newV := make([]byte, acc.EncodingLengthForStorage())
+		acc.EncodeForStorage(newV)
+		if accumulator != nil {
+			accumulator.ChangeAccount(address, acc.Incarnation, newV)
+		}
+		return next(k, k, newV)
+	}, etl.TransformArgs{Quit: ctx.Done()}); err != nil {
+		return err
+	}
+
+	storageKeyLength := length.Addr + length.Incarnation + length.Hash
+	if err := storageChanges.Load(tx, kv.PlainState, func(k, v []byte, table etl.CurrentTableReader, next etl.LoadNextFunc) error {
 		if accumulator != nil {
 			var address common.Address
-			var incarnation uint64
 			var location common.Hash
 			copy(address[:], k[:length.Addr])
-			incarnation = binary.BigEndian.Uint64(k[length.Addr:])
+			incarnation := binary.BigEndian.Uint64(k[length.Addr:])
 			copy(location[:], k[length.Addr+length.Incarnation:])
 			logger.Debug(fmt.Sprintf("un ch st: %x, %d, %x, %x\n", address, incarnation, location, common.Copy(v)))
 			accumulator.ChangeStorage(address, incarnation, location, common.Copy(v))
 		}
 		if len(v) > 0 {
-			if err := next(k, k[:storageKeyLength], v); err != nil {
-				return err
-			}
-		} else {
-			if err := next(k, k[:storageKeyLength], nil); err != nil {
-				return err
-			}
+			return next(k, k[:storageKeyLength], v)
 		}
-		return nil
-
+		return next(k, k[:storageKeyLength], nil)
 	}, etl.TransformArgs{Quit: ctx.Done()}); err != nil {
 		return err
 	}
 
-	if err := historyv2.Truncate(txc.Tx, u.UnwindPoint+1); err != nil {
+	if err := historyv2.Truncate(tx, from+1); err != nil {
 		return err
 	}
 
-	if err := rawdb.TruncateReceipts(txc.Tx, u.UnwindPoint+1); err != nil {
+	if err := rawdb.TruncateReceipts(tx, from+1); err != nil {
 		return fmt.Errorf("truncate receipts: %w", err)
 	}
-	if err := rawdb.TruncateBorReceipts(txc.Tx, u.UnwindPoint+1); err != nil {
-		return fmt.Errorf("truncate bor receipts: %w", err)
+	if bor { // chaindata of the other chains may have no bor tables, see mdbx.WithoutBorTables
+		if err := rawdb.TruncateBorReceipts(tx, from+1); err != nil {
+			return fmt.Errorf("truncate bor receipts: %w", err)
+		}
 	}
-	if err := rawdb.DeleteNewerEpochs(txc.Tx, u.UnwindPoint+1); err != nil {
+	if err := rawdb.DeleteNewerEpochs(tx, from+1); err != nil {
 		return fmt.Errorf("delete newer epochs: %w", err)
 	}
+	if err := rawdb.TruncateBlockAccessLists(tx, from+1); err != nil {
+		return fmt.Errorf("truncate block access lists: %w", err)
+	}
 
 	// Truncate CallTraceSet
-	keyStart := hexutility.EncodeTs(u.UnwindPoint + 1)
-	c, err := txc.Tx.RwCursorDupSort(kv.CallTraceSet)
+	keyStart := hexutility.EncodeTs(from + 1)
+	c, err := tx.RwCursorDupSort(kv.CallTraceSet)
 	if err != nil {
 		return err
 	}
@@ -912,7 +1198,7 @@ func unwindExecutionStage(u *UnwindState, s *StageState, txc wrap.TxContainer, c
 		if err != nil {
 			return err
 		}
-		if err = txc.Tx.Delete(kv.CallTraceSet, k); err != nil {
+		if err = tx.Delete(kv.CallTraceSet, k); err != nil {
 			return err
 		}
 	}
@@ -963,15 +1249,30 @@ func PruneExecutionStage(s *PruneState, tx kv.RwTx, cfg ExecuteBlockCfg, ctx con
 			if err = rawdb.PruneTableDupSort(tx, kv.StorageChangeSet, logPrefix, cfg.prune.History.PruneTo(s.ForwardProgress), logEvery, ctx); err != nil {
 				return err
 			}
+			if err = rawdb.PruneTable(tx, kv.BlockAccessList, cfg.prune.History.PruneTo(s.ForwardProgress), ctx, math.MaxInt32); err != nil {
+				return err
+			}
 		}
 
 		if cfg.prune.Receipts.Enabled() {
-			if err = rawdb.PruneTable(tx, kv.Receipts, cfg.prune.Receipts.PruneTo(s.ForwardProgress), ctx, math.MaxInt32); err != nil {
-				return err
+			if cfg.prune.KeepDeposits {
+				// the blocks kept by the previous runs aren't scanned again
+				var pruneFrom uint64
+				if s.PruneProgress > 0 {
+					pruneFrom = cfg.prune.Receipts.PruneTo(s.PruneProgress)
+				}
+				err = pruneReceiptsKeepDeposits(tx, pruneFrom, cfg.prune.Receipts.PruneTo(s.ForwardProgress), ctx)
+			} else {
+				err = rawdb.PruneTable(tx, kv.Receipts, cfg.prune.Receipts.PruneTo(s.ForwardProgress), ctx, math.MaxInt32)
 			}
-			if err = rawdb.PruneTable(tx, kv.BorReceipts, cfg.prune.Receipts.PruneTo(s.ForwardProgress), ctx, math.MaxUint32); err != nil {
+			if err != nil {
 				return err
 			}
+			if cfg.chainConfig != nil && cfg.chainConfig.Bor != nil {
+				if err = rawdb.PruneTable(tx, kv.BorReceipts, cfg.prune.Receipts.PruneTo(s.ForwardProgress), ctx, math.MaxUint32); err != nil {
+					return err
+				}
+			}
 			// EDIT: Don't prune yet, let LogIndex stage take care of it
 			// LogIndex.Prune will read everything what not pruned here
 			// if err = rawdb.PruneTable(tx, kv.Log, cfg.prune.Receipts.PruneTo(s.ForwardProgress), ctx, math.MaxInt32); err != nil {
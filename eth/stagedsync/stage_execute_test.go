@@ -65,6 +65,101 @@ func TestExec(t *testing.T) {
 
 		compareCurrentState(t, newAgg(t, logger), tx1, tx2, kv.PlainState, kv.PlainContractCode)
 	})
+	t.Run("UnwindExecutionStagePlainInBatches", func(t *testing.T) {
+		defer func(batch uint64) { unwindBatchBlocks = batch }(unwindBatchBlocks)
+		unwindBatchBlocks = 7
+		require, tx1, tx2 := require.New(t), memdb.BeginRw(t, db1), memdb.BeginRw(t, db2)
+
+		generateBlocks(t, 1, 25, plainWriterGen(tx1), changeCodeWithIncarnations)
+		generateBlocks(t, 1, 50, plainWriterGen(tx2), changeCodeWithIncarnations)
+
+		err := stages.SaveStageProgress(tx2, stages.Execution, 50)
+		require.NoError(err)
+
+		u := &UnwindState{ID: stages.Execution, UnwindPoint: 25}
+		s := &StageState{ID: stages.Execution, BlockNumber: 50}
+		err = UnwindExecutionStage(u, s, wrap.TxContainer{Tx: tx2}, ctx, cfg, false, logger)
+		require.NoError(err)
+
+		compareCurrentState(t, newAgg(t, logger), tx1, tx2, kv.PlainState, kv.PlainContractCode)
+	})
+	t.Run("UnwindExecutionStagePlainOwnTx", func(t *testing.T) {
+		defer func(batch uint64) { unwindBatchBlocks = batch }(unwindBatchBlocks)
+		unwindBatchBlocks = 7
+		require, db3, db4 := require.New(t), memdb.NewTestDB(t), memdb.NewTestDB(t)
+
+		require.NoError(db3.Update(ctx, func(tx kv.RwTx) error {
+			generateBlocks(t, 1, 25, plainWriterGen(tx), changeCodeWithIncarnations)
+			return nil
+		}))
+		require.NoError(db4.Update(ctx, func(tx kv.RwTx) error {
+			generateBlocks(t, 1, 50, plainWriterGen(tx), changeCodeWithIncarnations)
+			return stages.SaveStageProgress(tx, stages.Execution, 50)
+		}))
+
+		u := &UnwindState{ID: stages.Execution, UnwindPoint: 25}
+		s := &StageState{ID: stages.Execution, BlockNumber: 50}
+		err := UnwindExecutionStage(u, s, wrap.TxContainer{}, ctx, ExecuteBlockCfg{db: db4}, false, logger)
+		require.NoError(err)
+
+		tx3, tx4 := memdb.BeginRw(t, db3), memdb.BeginRw(t, db4)
+		compareCurrentState(t, newAgg(t, logger), tx3, tx4, kv.PlainState, kv.PlainContractCode)
+		progress, err := stages.GetStageProgress(tx4, stages.Execution)
+		require.NoError(err)
+		require.Equal(uint64(25), progress)
+	})
+	t.Run("UnwindExecutionStagePlainInterrupted", func(t *testing.T) {
+		defer func(batch uint64) { unwindBatchBlocks = batch }(unwindBatchBlocks)
+		unwindBatchBlocks = 7
+		require, db3, db4 := require.New(t), memdb.NewTestDB(t), memdb.NewTestDB(t)
+
+		require.NoError(db3.Update(ctx, func(tx kv.RwTx) error {
+			generateBlocks(t, 1, 25, plainWriterGen(tx), changeCodeWithIncarnations)
+			return nil
+		}))
+		require.NoError(db4.Update(ctx, func(tx kv.RwTx) error {
+			generateBlocks(t, 1, 50, plainWriterGen(tx), changeCodeWithIncarnations)
+			return stages.SaveStageProgress(tx, stages.Execution, 50)
+		}))
+
+		// the node stops after the first batch is committed
+		crash := log.New()
+		crash.SetHandler(log.FuncHandler(func(r *log.Record) error {
+			if r.Msg == "[] Unwound batch" {
+				panic(r.Msg)
+			}
+			return nil
+		}))
+		u := &UnwindState{ID: stages.Execution, UnwindPoint: 25}
+		s := &StageState{ID: stages.Execution, BlockNumber: 50}
+		require.Panics(func() {
+			_ = UnwindExecutionStage(u, s, wrap.TxContainer{}, ctx, ExecuteBlockCfg{db: db4}, false, crash)
+		})
+		require.NoError(db4.View(ctx, func(tx kv.Tx) error {
+			progress, err := stages.GetStageProgress(tx, stages.Execution)
+			require.NoError(err)
+			require.Equal(uint64(43), progress)
+			unwindPoint, ok, err := stages.GetStageUnwindPoint(tx, stages.Execution)
+			require.NoError(err)
+			require.True(ok)
+			require.Equal(uint64(25), unwindPoint)
+			return nil
+		}))
+
+		// the execution at the next start finishes the unwind first
+		s = &StageState{ID: stages.Execution, BlockNumber: 43}
+		require.NoError(SpawnExecuteBlocksStage(s, nil, wrap.TxContainer{}, 0, ctx, ExecuteBlockCfg{db: db4}, false, logger))
+		require.Equal(uint64(25), s.BlockNumber)
+
+		tx3, tx4 := memdb.BeginRw(t, db3), memdb.BeginRw(t, db4)
+		compareCurrentState(t, newAgg(t, logger), tx3, tx4, kv.PlainState, kv.PlainContractCode)
+		progress, err := stages.GetStageProgress(tx4, stages.Execution)
+		require.NoError(err)
+		require.Equal(uint64(25), progress)
+		_, ok, err := stages.GetStageUnwindPoint(tx4, stages.Execution)
+		require.NoError(err)
+		require.False(ok)
+	})
 	t.Run("UnwindExecutionStagePlainWithCodeChanges", func(t *testing.T) {
 		t.Skip("not supported yet, to be restored")
 		require, tx1, tx2 := require.New(t), memdb.BeginRw(t, db1), memdb.BeginRw(t, db2)
@@ -200,7 +295,7 @@ func TestExec22(t *testing.T) {
 		require.NoError(err)
 
 		for i := uint64(0); i < 50; i++ {
-			err = rawdbv3.TxNums.Append(tx2, i, i)
+			err = rawdbv3.TxNumsWriter.Append(tx2, i, i)
 			require.NoError(err)
 		}
 
@@ -224,7 +319,7 @@ func TestExec22(t *testing.T) {
 		require.NoError(err)
 
 		for i := uint64(0); i < 50; i++ {
-			err = rawdbv3.TxNums.Append(tx2, i, i)
+			err = rawdbv3.TxNumsWriter.Append(tx2, i, i)
 			require.NoError(err)
 		}
 
@@ -1,7 +1,6 @@
 package stagedsync
 
 import (
-	"bytes"
 	"context"
 	"encoding/binary"
 	"fmt"
@@ -22,7 +21,6 @@ import (
 
 	"github.com/erigontech/erigon/core/rawdb"
 	"github.com/erigontech/erigon/core/types"
-	"github.com/erigontech/erigon/ethdb/cbor"
 	"github.com/erigontech/erigon/params"
 )
 
@@ -193,7 +191,6 @@ func ReadLogs(tx kv.Tx, from uint64, isUnwind bool, blockReader services.FullBlo
 	}
 	defer logs.Close()
 	reply := make([]*remote.SubscribeLogsReply, 0)
-	reader := bytes.NewReader(nil)
 
 	var prevBlockNum uint64
 	var block *types.Block
@@ -222,9 +219,8 @@ func ReadLogs(tx kv.Tx, from uint64, isUnwind bool, blockReader services.FullBlo
 			txHash = block.Transactions()[txIndex].Hash()
 		}
 
-		var ll types.Logs
-		reader.Reset(v)
-		if err := cbor.Unmarshal(&ll, reader); err != nil {
+		ll, err := types.DecodeLogsForStorage(v)
+		if err != nil {
 			return nil, fmt.Errorf("receipt unmarshal failed: %w, blocl=%d", err, blockNum)
 		}
 		for _, l := range ll {
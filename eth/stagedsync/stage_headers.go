@@ -10,6 +10,7 @@ import (
 
 	"github.com/c2h5oh/datasize"
 	"github.com/erigontech/erigon-lib/log/v3"
+	lru "github.com/hashicorp/golang-lru/v2"
 
 	"github.com/erigontech/erigon-lib/chain"
 	libcommon "github.com/erigontech/erigon-lib/common"
@@ -47,6 +48,7 @@ type HeadersCfg struct {
 	tmpdir            string
 
 	blockReader   services.FullBlockReader
+	chainReader   *SharedChainReader
 	blockWriter   *blockio.BlockWriter
 	notifications *shards.Notifications
 
@@ -66,6 +68,7 @@ func StageHeadersCfg(
 	batchSize datasize.ByteSize,
 	noP2PDiscovery bool,
 	blockReader services.FullBlockReader,
+	chainReader *SharedChainReader,
 	blockWriter *blockio.BlockWriter,
 	tmpdir string,
 	notifications *shards.Notifications,
@@ -83,6 +86,7 @@ func StageHeadersCfg(
 		tmpdir:            tmpdir,
 		noP2PDiscovery:    noP2PDiscovery,
 		blockReader:       blockReader,
+		chainReader:       chainReader,
 		blockWriter:       blockWriter,
 		notifications:     notifications,
 		loopBreakCheck:    loopBreakCheck,
@@ -181,12 +185,7 @@ func HeadersPOW(
 	}
 	TEMP TESTING */
 	headerInserter := headerdownload.NewHeaderInserter(logPrefix, localTd, startProgress, cfg.blockReader)
-	cfg.hd.SetHeaderReader(&ChainReaderImpl{
-		config:      &cfg.chainConfig,
-		tx:          tx,
-		blockReader: cfg.blockReader,
-		logger:      logger,
-	})
+	cfg.hd.SetHeaderReader(chainReaderOf(cfg.chainReader, &cfg.chainConfig, tx, cfg.blockReader, logger))
 
 	stopped := false
 	var noProgressCounter uint = 0
@@ -561,23 +560,70 @@ type ChainReaderImpl struct {
 	config      *chain.Config
 	tx          kv.Tx
 	blockReader services.FullBlockReader
+	headers     *lru.Cache[libcommon.Hash, *types.Header] // of the SharedChainReader, nil if the reader isn't shared
 	logger      log.Logger
 }
 
 func NewChainReaderImpl(config *chain.Config, tx kv.Tx, blockReader services.FullBlockReader, logger log.Logger) *ChainReaderImpl {
-	return &ChainReaderImpl{config, tx, blockReader, logger}
+	return &ChainReaderImpl{config: config, tx: tx, blockReader: blockReader, logger: logger}
+}
+
+func (cr ChainReaderImpl) Config() *chain.Config { return cr.config }
+func (cr ChainReaderImpl) CurrentHeader() *types.Header {
+	hash := rawdb.ReadHeadHeaderHash(cr.tx)
+	number := rawdb.ReadHeaderNumber(cr.tx, hash)
+	if number == nil {
+		return nil
+	}
+	return cr.GetHeader(hash, *number)
+}
+
+// cachedHeader - the header from the shared cache, if it's in the tx too: the cache outlives the txs, a header may be
+// cached from a tx which was rolled back or before it was deleted (e.g. by unwind)
+func (cr ChainReaderImpl) cachedHeader(hash libcommon.Hash) *types.Header {
+	if cr.headers == nil {
+		return nil
+	}
+	h, ok := cr.headers.Get(hash)
+	if !ok {
+		return nil
+	}
+	if number := rawdb.ReadHeaderNumber(cr.tx, hash); number == nil || *number != h.Number.Uint64() {
+		cr.headers.Remove(hash)
+		return nil
+	}
+	return h
 }
 
-func (cr ChainReaderImpl) Config() *chain.Config        { return cr.config }
-func (cr ChainReaderImpl) CurrentHeader() *types.Header { panic("") }
 func (cr ChainReaderImpl) GetHeader(hash libcommon.Hash, number uint64) *types.Header {
-	if cr.blockReader != nil {
-		h, _ := cr.blockReader.Header(context.Background(), cr.tx, hash, number)
+	if h := cr.cachedHeader(hash); h != nil && h.Number.Uint64() == number {
 		return h
 	}
-	return rawdb.ReadHeader(cr.tx, hash, number)
+	var h *types.Header
+	if cr.blockReader != nil {
+		h, _ = cr.blockReader.Header(context.Background(), cr.tx, hash, number)
+	} else {
+		h = rawdb.ReadHeader(cr.tx, hash, number)
+	}
+	if h != nil && cr.headers != nil {
+		cr.headers.Add(hash, h)
+	}
+	return h
 }
 func (cr ChainReaderImpl) GetHeaderByNumber(number uint64) *types.Header {
+	if cr.headers != nil {
+		// the canonical hash is read from the tx, only the header by hash is cached
+		var hash libcommon.Hash
+		if cr.blockReader != nil {
+			hash, _ = cr.blockReader.CanonicalHash(context.Background(), cr.tx, number)
+		} else {
+			hash, _ = rawdb.ReadCanonicalHash(cr.tx, number)
+		}
+		if hash == (libcommon.Hash{}) {
+			return nil
+		}
+		return cr.GetHeader(hash, number)
+	}
 	if cr.blockReader != nil {
 		h, _ := cr.blockReader.HeaderByNumber(context.Background(), cr.tx, number)
 		return h
@@ -586,7 +632,10 @@ func (cr ChainReaderImpl) GetHeaderByNumber(number uint64) *types.Header {
 
 }
 func (cr ChainReaderImpl) GetHeaderByHash(hash libcommon.Hash) *types.Header {
-	if cr.blockReader != nil {
+	if h := cr.cachedHeader(hash); h != nil {
+		return h
+	}
+	if cr.blockReader != nil || cr.headers != nil {
 		number := rawdb.ReadHeaderNumber(cr.tx, hash)
 		if number == nil {
 			return nil
@@ -35,6 +35,7 @@ type TrieCfg struct {
 	db                kv.RwDB
 	checkRoot         bool
 	badBlockHalt      bool
+	badBlockDump      *BadBlockDump
 	tmpDir            string
 	saveNewHashesToDB bool // no reason to save changes when calculating root for mining
 	blockReader       services.FullBlockReader
@@ -59,6 +60,12 @@ func StageTrieCfg(db kv.RwDB, checkRoot, saveNewHashesToDB, badBlockHalt bool, t
 	}
 }
 
+// WithBadBlockDump - dump the block when the trie root of it is wrong and the stage halts on bad blocks
+func (cfg TrieCfg) WithBadBlockDump(d *BadBlockDump) TrieCfg {
+	cfg.badBlockDump = d
+	return cfg
+}
+
 func SpawnIntermediateHashesStage(s *StageState, u Unwinder, tx kv.RwTx, cfg TrieCfg, ctx context.Context, logger log.Logger) (libcommon.Hash, error) {
 	quit := ctx.Done()
 	useExternalTx := tx != nil
@@ -127,8 +134,12 @@ func SpawnIntermediateHashesStage(s *StageState, u Unwinder, tx kv.RwTx, cfg Tri
 	if cfg.checkRoot && root != expectedRootHash {
 		logger.Error(fmt.Sprintf("[%s] Wrong trie root of block %d: %x, expected (from header): %x. Block hash: %x", logPrefix, to, root, expectedRootHash, headerHash))
 		if cfg.badBlockHalt {
-			return trie.EmptyRoot, fmt.Errorf("%w: wrong trie root", consensus.ErrInvalidBlock)
+			err = fmt.Errorf("%w: wrong trie root %x, expected %x", consensus.ErrInvalidBlock, root, expectedRootHash)
+			// the whole range is checked at once, the divergence may be in any block of it: the last one is dumped
+			cfg.badBlockDump.WriteAt(ctx, tx, headerHash, to, s.BlockNumber+1, cfg.historyV3, err, logger)
+			return trie.EmptyRoot, err
 		}
+		recordBadBlock(tx, syncHeadHeader, nil, fmt.Errorf("%w: wrong trie root %x, expected %x", consensus.ErrInvalidBlock, root, expectedRootHash), logger)
 		if cfg.hd != nil {
 			cfg.hd.ReportBadHeaderPoS(headerHash, syncHeadHeader.ParentHash)
 		}
@@ -598,11 +609,18 @@ func IncrementIntermediateHashes(logPrefix string, s *StageState, db kv.RwTx, to
 			return trie.EmptyRoot, err
 		}
 	}
-	accTrieCollector := etl.NewCollector(logPrefix, cfg.tmpDir, etl.NewSortableBuffer(etl.BufferOptimalSize), logger)
+	return IncrementIntermediateHashesOfKeys(logPrefix, db, rl, cfg.tmpDir, cfg.checkRoot, expectedRootHash, quit, logger)
+}
+
+// IncrementIntermediateHashesOfKeys - root of the hashed state, in which the keys of rl have changed, and update of the
+// intermediate hashes of those keys. With checkRoot, the intermediate hashes aren't updated if the root isn't
+// expectedRootHash.
+func IncrementIntermediateHashesOfKeys(logPrefix string, db kv.RwTx, rl *trie.RetainList, tmpDir string, checkRoot bool, expectedRootHash libcommon.Hash, quit <-chan struct{}, logger log.Logger) (libcommon.Hash, error) {
+	accTrieCollector := etl.NewCollector(logPrefix, tmpDir, etl.NewSortableBuffer(etl.BufferOptimalSize), logger)
 	defer accTrieCollector.Close()
 	accTrieCollectorFunc := accountTrieCollector(accTrieCollector)
 
-	stTrieCollector := etl.NewCollector(logPrefix, cfg.tmpDir, etl.NewSortableBuffer(etl.BufferOptimalSize), logger)
+	stTrieCollector := etl.NewCollector(logPrefix, tmpDir, etl.NewSortableBuffer(etl.BufferOptimalSize), logger)
 	defer stTrieCollector.Close()
 	stTrieCollectorFunc := storageTrieCollector(stTrieCollector)
 
@@ -612,7 +630,7 @@ func IncrementIntermediateHashes(logPrefix string, s *StageState, db kv.RwTx, to
 		return trie.EmptyRoot, err
 	}
 
-	if cfg.checkRoot && hash != expectedRootHash {
+	if checkRoot && hash != expectedRootHash {
 		return hash, nil
 	}
 
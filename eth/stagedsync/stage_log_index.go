@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/binary"
 	"fmt"
+	"math"
 	"runtime"
 	"slices"
 	"time"
@@ -22,8 +23,8 @@ import (
 	"github.com/erigontech/erigon-lib/kv/dbutils"
 
 	"github.com/erigontech/erigon/core/types"
-	"github.com/erigontech/erigon/ethdb/cbor"
 	"github.com/erigontech/erigon/ethdb/prune"
+	"github.com/erigontech/erigon/params"
 )
 
 const (
@@ -130,8 +131,6 @@ func promoteLogIndex(logPrefix string, tx kv.RwTx, start uint64, endBlock uint64
 	collectorAddrs := etl.NewCollector(logPrefix, cfg.tmpdir, etl.NewSortableBuffer(etl.BufferOptimalSize), logger)
 	defer collectorAddrs.Close()
 
-	reader := bytes.NewReader(nil)
-
 	if endBlock != 0 && endBlock-start > 100 {
 		logger.Info(fmt.Sprintf("[%s] processing", logPrefix), "from", start, "to", endBlock, "pruneTo", pruneBlock)
 	}
@@ -175,9 +174,8 @@ func promoteLogIndex(logPrefix string, tx kv.RwTx, start uint64, endBlock uint64
 			}
 		}
 
-		var ll types.Logs
-		reader.Reset(v)
-		if err := cbor.Unmarshal(&ll, reader); err != nil {
+		ll, err := types.DecodeLogsForStorage(v)
+		if err != nil {
 			return fmt.Errorf("receipt unmarshal failed: %w, blocl=%d", err, blockNum)
 		}
 
@@ -304,7 +302,6 @@ func unwindLogIndex(logPrefix string, db kv.RwTx, to uint64, cfg LogIndexCfg, qu
 	topics := map[string]struct{}{}
 	addrs := map[string]struct{}{}
 
-	reader := bytes.NewReader(nil)
 	c, err := db.Cursor(kv.Log)
 	if err != nil {
 		return err
@@ -318,9 +315,8 @@ func unwindLogIndex(logPrefix string, db kv.RwTx, to uint64, cfg LogIndexCfg, qu
 		if err := libcommon.Stopped(quitCh); err != nil {
 			return err
 		}
-		var logs types.Logs
-		reader.Reset(v)
-		if err := cbor.Unmarshal(&logs, reader); err != nil {
+		logs, err := types.DecodeLogsForStorage(v)
+		if err != nil {
 			return fmt.Errorf("receipt unmarshal: %w, block=%d", err, binary.BigEndian.Uint64(k))
 		}
 
@@ -419,7 +415,7 @@ func pruneOldLogChunks(tx kv.RwTx, bucket string, inMem *etl.Collector, pruneTo
 
 // Call pruneLogIndex with the current sync progresses and commit the data to db
 func PruneLogIndex(s *PruneState, tx kv.RwTx, cfg LogIndexCfg, ctx context.Context, logger log.Logger) (err error) {
-	if !cfg.prune.Receipts.Enabled() {
+	if !cfg.prune.Receipts.Enabled() && !cfg.prune.Experiments.ReceiptsIndexOnly {
 		return nil
 	}
 	logPrefix := s.LogPrefix()
@@ -433,8 +429,16 @@ func PruneLogIndex(s *PruneState, tx kv.RwTx, cfg LogIndexCfg, ctx context.Conte
 		defer tx.Rollback()
 	}
 
-	pruneTo := cfg.prune.Receipts.PruneTo(s.ForwardProgress)
-	if err = pruneLogIndex(logPrefix, tx, cfg.tmpdir, s.PruneProgress, pruneTo, ctx, logger, cfg.depositContract); err != nil {
+	var pruneTo uint64
+	if cfg.prune.Experiments.ReceiptsIndexOnly {
+		// the index is kept, the logs are needed only to unwind it
+		pruneTo = prune.Distance(params.FullImmutabilityThreshold).PruneTo(s.ForwardProgress)
+		err = pruneLogs(logPrefix, tx, s.PruneProgress, pruneTo, ctx, logger, cfg.depositContract, cfg.prune.KeepDeposits)
+	} else {
+		pruneTo = cfg.prune.Receipts.PruneTo(s.ForwardProgress)
+		err = pruneLogIndex(logPrefix, tx, cfg.tmpdir, s.PruneProgress, pruneTo, ctx, logger, cfg.depositContract, cfg.prune.KeepDeposits)
+	}
+	if err != nil {
 		return err
 	}
 	if err = s.DoneAt(tx, pruneTo); err != nil {
@@ -449,8 +453,9 @@ func PruneLogIndex(s *PruneState, tx kv.RwTx, cfg LogIndexCfg, ctx context.Conte
 	return nil
 }
 
-// Prune log indexes as well as logs within the prune range
-func pruneLogIndex(logPrefix string, tx kv.RwTx, tmpDir string, pruneFrom, pruneTo uint64, ctx context.Context, logger log.Logger, depositContract *libcommon.Address) error {
+// Prune log indexes as well as logs within the prune range. With keepDeposits the logs of the blocks with bridge
+// deposits are kept, with their receipts (see hasBridgeDeposits).
+func pruneLogIndex(logPrefix string, tx kv.RwTx, tmpDir string, pruneFrom, pruneTo uint64, ctx context.Context, logger log.Logger, depositContract *libcommon.Address, keepDeposits bool) error {
 	logEvery := time.NewTicker(logInterval)
 	defer logEvery.Stop()
 
@@ -460,7 +465,6 @@ func pruneLogIndex(logPrefix string, tx kv.RwTx, tmpDir string, pruneFrom, prune
 	addrs := etl.NewCollector(logPrefix, tmpDir, etl.NewOldestEntryBuffer(bufferSize), logger)
 	defer addrs.Close()
 
-	reader := bytes.NewReader(nil)
 	{
 		c, err := tx.Cursor(kv.Log)
 		if err != nil {
@@ -468,6 +472,7 @@ func pruneLogIndex(logPrefix string, tx kv.RwTx, tmpDir string, pruneFrom, prune
 		}
 		defer c.Close()
 
+		checkedBlock, keep := uint64(math.MaxUint64), false // keep - checkedBlock has bridge deposits
 		for k, v, err := c.Seek(dbutils.LogKey(pruneFrom, 0)); k != nil; k, v, err = c.Next() {
 			if err != nil {
 				return err
@@ -476,6 +481,15 @@ func pruneLogIndex(logPrefix string, tx kv.RwTx, tmpDir string, pruneFrom, prune
 			if blockNum >= pruneTo {
 				break
 			}
+			if keepDeposits && blockNum != checkedBlock {
+				checkedBlock = blockNum
+				if keep, err = blockHasBridgeDeposits(tx, blockNum); err != nil {
+					return err
+				}
+			}
+			if keepDeposits && keep {
+				continue
+			}
 			select {
 			case <-logEvery.C:
 				logger.Info(fmt.Sprintf("[%s]", logPrefix), "table", kv.Log, "block", blockNum, "pruneFrom", pruneFrom, "pruneTo", pruneTo)
@@ -484,9 +498,8 @@ func pruneLogIndex(logPrefix string, tx kv.RwTx, tmpDir string, pruneFrom, prune
 			default:
 			}
 
-			var logs types.Logs
-			reader.Reset(v)
-			if err := cbor.Unmarshal(&logs, reader); err != nil {
+			logs, err := types.DecodeLogsForStorage(v)
+			if err != nil {
 				return fmt.Errorf("receipt unmarshal failed: %w, block=%d", err, binary.BigEndian.Uint64(k))
 			}
 
@@ -526,3 +539,70 @@ func pruneLogIndex(logPrefix string, tx kv.RwTx, tmpDir string, pruneFrom, prune
 	}
 	return nil
 }
+
+// blockHasBridgeDeposits - hasBridgeDeposits of the stored receipts of the block
+func blockHasBridgeDeposits(tx kv.Tx, blockNum uint64) (bool, error) {
+	v, err := tx.GetOne(kv.Receipts, hexutility.EncodeTs(blockNum))
+	if err != nil || len(v) == 0 {
+		return false, err
+	}
+	receipts, err := types.DecodeReceiptsForStorage(v)
+	if err != nil {
+		return false, fmt.Errorf("receipt unmarshal failed: %w, block=%d", err, blockNum)
+	}
+	return hasBridgeDeposits(receipts), nil
+}
+
+// Prune logs within the prune range, keeping their log indexes. With keepDeposits the logs of the blocks with bridge
+// deposits are kept: execution stores the receipts of those blocks in receipts.index-only mode too.
+func pruneLogs(logPrefix string, tx kv.RwTx, pruneFrom, pruneTo uint64, ctx context.Context, logger log.Logger, depositContract *libcommon.Address, keepDeposits bool) error {
+	logEvery := time.NewTicker(logInterval)
+	defer logEvery.Stop()
+
+	c, err := tx.RwCursor(kv.Log)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	checkedBlock, keep := uint64(math.MaxUint64), false // keep - checkedBlock has bridge deposits
+	for k, v, err := c.Seek(dbutils.LogKey(pruneFrom, 0)); k != nil; k, v, err = c.Next() {
+		if err != nil {
+			return err
+		}
+		blockNum := binary.BigEndian.Uint64(k)
+		if blockNum >= pruneTo {
+			break
+		}
+		select {
+		case <-logEvery.C:
+			logger.Info(fmt.Sprintf("[%s]", logPrefix), "table", kv.Log, "block", blockNum, "pruneFrom", pruneFrom, "pruneTo", pruneTo)
+		case <-ctx.Done():
+			return libcommon.ErrStopped
+		default:
+		}
+
+		if keepDeposits && blockNum != checkedBlock {
+			checkedBlock = blockNum
+			if keep, err = blockHasBridgeDeposits(tx, blockNum); err != nil {
+				return err
+			}
+		}
+		if keepDeposits && keep {
+			continue
+		}
+		if depositContract != nil {
+			logs, err := types.DecodeLogsForStorage(v)
+			if err != nil {
+				return fmt.Errorf("receipt unmarshal failed: %w, block=%d", err, blockNum)
+			}
+			if slices.ContainsFunc(logs, func(l *types.Log) bool { return l.Address == *depositContract }) {
+				continue
+			}
+		}
+		if err := c.DeleteCurrent(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutility"
 	"github.com/erigontech/erigon-lib/common/length"
 	"github.com/erigontech/erigon-lib/kv"
 	"github.com/erigontech/erigon-lib/kv/bitmapdb"
@@ -136,7 +137,7 @@ func TestPruneLogIndex(t *testing.T) {
 
 	// Mode test
 	depositContract := libcommon.Address{1} // using addr {1} from genReceipts
-	err = pruneLogIndex("", tx, tmpDir, 0, 45, ctx, logger, &depositContract)
+	err = pruneLogIndex("", tx, tmpDir, 0, 45, ctx, logger, &depositContract, false)
 	require.NoError(err)
 
 	{
@@ -170,6 +171,71 @@ func TestPruneLogIndex(t *testing.T) {
 	}
 }
 
+func TestPruneLogs(t *testing.T) {
+	logger := log.New()
+	require, ctx := require.New(t), context.Background()
+	_, tx := memdb.NewTestTx(t)
+
+	expectAddrs, expectTopics := genReceipts(t, tx, 90)
+
+	cfg := StageLogIndexCfg(nil, prune.DefaultMode, "", nil)
+	cfgCopy := cfg
+	cfgCopy.bufLimit = 10
+	cfgCopy.flushEvery = time.Nanosecond
+	err := promoteLogIndex("logPrefix", tx, 0, 0, 0, cfgCopy, ctx, logger)
+	require.NoError(err)
+
+	depositContract := libcommon.Address{1} // using addr {1} from genReceipts
+	err = pruneLogs("", tx, 0, 45, ctx, logger, &depositContract, false)
+	require.NoError(err)
+
+	total := 0
+	err = tx.ForEach(kv.Log, nil, func(k, v []byte) error {
+		total++
+		return nil
+	})
+	require.NoError(err)
+	require.Equal(60, total) // as in TestPruneLogIndex
+
+	// the index is untouched
+	for addr, expect := range expectAddrs {
+		m, err := bitmapdb.Get(tx, kv.LogAddressIndex, addr[:], 0, 10_000_000)
+		require.NoError(err)
+		require.Equal(expect, m.GetCardinality())
+	}
+	for topic, expect := range expectTopics {
+		m, err := bitmapdb.Get(tx, kv.LogTopicIndex, topic[:], 0, 10_000_000)
+		require.NoError(err)
+		require.Equal(expect, m.GetCardinality())
+	}
+}
+
+func TestPruneLogsKeepDeposits(t *testing.T) {
+	logger := log.New()
+	require, ctx := require.New(t), context.Background()
+	_, tx := memdb.NewTestTx(t)
+
+	genReceipts(t, tx, 30)
+	// block 4 has a bridge deposit: a deposit after the L1 attributes one
+	v, err := types.EncodeReceiptsForStorage(types.Receipts{{Type: types.DepositTxType}, {Type: types.DepositTxType}})
+	require.NoError(err)
+	require.NoError(tx.Put(kv.Receipts, hexutility.EncodeTs(4), v))
+
+	err = pruneLogs("", tx, 0, 30, ctx, logger, nil, true)
+	require.NoError(err)
+
+	var kept []uint64
+	err = tx.ForEach(kv.Log, nil, func(k, v []byte) error {
+		kept = append(kept, binary.BigEndian.Uint64(k))
+		return nil
+	})
+	require.NoError(err)
+	require.NotEmpty(kept)
+	for _, blockNum := range kept {
+		require.Equal(uint64(4), blockNum)
+	}
+}
+
 func TestUnwindLogIndex(t *testing.T) {
 	logger := log.New()
 	require, tmpDir, ctx := require.New(t), t.TempDir(), context.Background()
@@ -185,7 +251,7 @@ func TestUnwindLogIndex(t *testing.T) {
 	require.NoError(err)
 
 	// Mode test
-	err = pruneLogIndex("", tx, tmpDir, 0, 50, ctx, logger, nil)
+	err = pruneLogIndex("", tx, tmpDir, 0, 50, ctx, logger, nil, false)
 	require.NoError(err)
 
 	// Unwind test
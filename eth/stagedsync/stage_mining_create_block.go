@@ -64,9 +64,10 @@ type MiningCreateBlockCfg struct {
 	tmpdir                 string
 	blockBuilderParameters *core.BlockBuilderParameters
 	blockReader            services.FullBlockReader
+	chainReader            *SharedChainReader
 }
 
-func StageMiningCreateBlockCfg(db kv.RwDB, miner MiningState, chainConfig chain.Config, engine consensus.Engine, txPoolDB kv.RoDB, blockBuilderParameters *core.BlockBuilderParameters, tmpdir string, blockReader services.FullBlockReader) MiningCreateBlockCfg {
+func StageMiningCreateBlockCfg(db kv.RwDB, miner MiningState, chainConfig chain.Config, engine consensus.Engine, txPoolDB kv.RoDB, blockBuilderParameters *core.BlockBuilderParameters, tmpdir string, blockReader services.FullBlockReader, chainReader *SharedChainReader) MiningCreateBlockCfg {
 	return MiningCreateBlockCfg{
 		db:                     db,
 		miner:                  miner,
@@ -76,6 +77,7 @@ func StageMiningCreateBlockCfg(db kv.RwDB, miner MiningState, chainConfig chain.
 		tmpdir:                 tmpdir,
 		blockBuilderParameters: blockBuilderParameters,
 		blockReader:            blockReader,
+		chainReader:            chainReader,
 	}
 }
 
@@ -122,7 +124,7 @@ func SpawnMiningCreateBlockStage(s *StageState, tx kv.RwTx, cfg MiningCreateBloc
 	if err != nil {
 		return err
 	}
-	chain := ChainReader{Cfg: cfg.chainConfig, Db: tx, BlockReader: cfg.blockReader, Logger: logger}
+	chain := chainReaderOf(cfg.chainReader, &cfg.chainConfig, tx, cfg.blockReader, logger)
 	var GetBlocksFromHash = func(hash libcommon.Hash, n int) (blocks []*types.Block) {
 		number := rawdb.ReadHeaderNumber(tx, hash)
 		if number == nil {
@@ -179,18 +181,22 @@ func SpawnMiningCreateBlockStage(s *StageState, tx kv.RwTx, cfg MiningCreateBloc
 	ibs := state.New(stateReader)
 
 	if cfg.chainConfig.IsOptimism() && cfg.chainConfig.IsHolocene(header.Time) {
-		if cfg.blockBuilderParameters.EIP1559Params == nil {
-			return fmt.Errorf("expected eip1559 params, got none")
+		var eip1559Params []byte // pending blocks have no params, they are built with the constants
+		if cfg.blockBuilderParameters != nil {
+			if cfg.blockBuilderParameters.EIP1559Params == nil {
+				return fmt.Errorf("expected eip1559 params, got none")
+			}
+			eip1559Params = cfg.blockBuilderParameters.EIP1559Params
 		}
 		// If this is a holocene block and the params are 0, we must convert them to their previous
 		// constants in the header.
-		d, e := misc.DecodeHolocene1559Params(cfg.blockBuilderParameters.EIP1559Params)
+		d, e := misc.DecodeHolocene1559Params(eip1559Params)
 		if d == 0 {
 			d = cfg.chainConfig.BaseFeeChangeDenominator(params.BaseFeeChangeDenominator, header.Time)
 			e = cfg.chainConfig.ElasticityMultiplier(params.ElasticityMultiplier)
 		}
 		header.Extra = misc.EncodeHoloceneExtraData(d, e)
-	} else if cfg.blockBuilderParameters.EIP1559Params != nil {
+	} else if cfg.blockBuilderParameters != nil && cfg.blockBuilderParameters.EIP1559Params != nil {
 		return fmt.Errorf("got eip1559 params, expected none")
 	}
 
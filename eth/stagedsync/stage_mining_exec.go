@@ -38,6 +38,7 @@ type MiningExecCfg struct {
 	chainConfig chain.Config
 	engine      consensus.Engine
 	blockReader services.FullBlockReader
+	chainReader *SharedChainReader
 	vmConfig    *vm.Config
 	tmpdir      string
 	interrupt   *int32
@@ -56,7 +57,7 @@ func StageMiningExecCfg(
 	engine consensus.Engine, vmConfig *vm.Config,
 	tmpdir string, interrupt *int32, payloadId uint64,
 	txPool TxPoolForMining, txPoolDB kv.RoDB,
-	blockReader services.FullBlockReader,
+	blockReader services.FullBlockReader, chainReader *SharedChainReader,
 ) MiningExecCfg {
 	return MiningExecCfg{
 		db:          db,
@@ -65,6 +66,7 @@ func StageMiningExecCfg(
 		chainConfig: chainConfig,
 		engine:      engine,
 		blockReader: blockReader,
+		chainReader: chainReader,
 		vmConfig:    vmConfig,
 		tmpdir:      tmpdir,
 		interrupt:   interrupt,
@@ -87,9 +89,10 @@ func SpawnMiningExecStage(s *StageState, tx kv.RwTx, cfg MiningExecCfg, quit <-c
 
 	stateReader := state.NewPlainStateReader(tx)
 	ibs := state.New(stateReader)
+	defer ibs.Release()
 	stateWriter := state.NewPlainStateWriter(tx, tx, current.Header.Number.Uint64())
 
-	chainReader := ChainReader{Cfg: cfg.chainConfig, Db: tx, BlockReader: cfg.blockReader, Logger: logger}
+	chainReader := chainReaderOf(cfg.chainReader, &cfg.chainConfig, tx, cfg.blockReader, logger)
 	core.InitializeBlockExecution(cfg.engine, chainReader, current.Header, &cfg.chainConfig, ibs, logger)
 
 	// Optimism Canyon
@@ -104,6 +107,11 @@ func SpawnMiningExecStage(s *StageState, tx kv.RwTx, cfg MiningExecCfg, quit <-c
 
 	getHeader := func(hash libcommon.Hash, number uint64) *types.Header { return rawdb.ReadHeader(tx, hash, number) }
 
+	var deadline time.Time
+	if cfg.interrupt != nil { // building a payload
+		deadline = packingDeadline(cfg.miningState.MiningConfig.BuilderDeadline, cfg.chainConfig.IsOptimism(), current.Header.Time, time.Now())
+	}
+
 	// Short circuit if there is no available pending transactions.
 	// But if we disable empty precommit already, ignore it. Since
 	// empty block is necessary to keep the liveness of the network.
@@ -124,7 +132,8 @@ func SpawnMiningExecStage(s *StageState, tx kv.RwTx, cfg MiningExecCfg, quit <-c
 			}
 			depTS := types.NewTransactionsFixedOrder(txs)
 
-			logs, _, err := addTransactionsToMiningBlock(logPrefix, current, cfg.chainConfig, cfg.vmConfig, getHeader, cfg.engine, depTS, cfg.miningState.MiningConfig.Etherbase, ibs, quit, cfg.interrupt, cfg.payloadId, logger)
+			// all the deposits go into the block, whatever the deadline
+			logs, _, err := addTransactionsToMiningBlock(logPrefix, current, cfg.chainConfig, cfg.vmConfig, getHeader, cfg.engine, depTS, cfg.miningState.MiningConfig.Etherbase, ibs, quit, nil, time.Time{}, cfg.payloadId, logger)
 			log.Debug("addTransactionsToMiningBlock (deposit) result", "err", err, "logs", logs)
 			if err != nil {
 				return err
@@ -132,7 +141,7 @@ func SpawnMiningExecStage(s *StageState, tx kv.RwTx, cfg MiningExecCfg, quit <-c
 		}
 
 		if txs != nil && !txs.Empty() {
-			logs, _, err := addTransactionsToMiningBlock(logPrefix, current, cfg.chainConfig, cfg.vmConfig, getHeader, cfg.engine, txs, cfg.miningState.MiningConfig.Etherbase, ibs, quit, cfg.interrupt, cfg.payloadId, logger)
+			logs, _, err := addTransactionsToMiningBlock(logPrefix, current, cfg.chainConfig, cfg.vmConfig, getHeader, cfg.engine, txs, cfg.miningState.MiningConfig.Etherbase, ibs, quit, cfg.interrupt, deadline, cfg.payloadId, logger)
 			log.Debug("addTransactionsToMiningBlock (txs) result", "err", err, "logs", logs)
 			if err != nil {
 				return err
@@ -163,7 +172,7 @@ func SpawnMiningExecStage(s *StageState, tx kv.RwTx, cfg MiningExecCfg, quit <-c
 				}
 
 				if !txs.Empty() {
-					logs, stop, err := addTransactionsToMiningBlock(logPrefix, current, cfg.chainConfig, cfg.vmConfig, getHeader, cfg.engine, txs, cfg.miningState.MiningConfig.Etherbase, ibs, quit, cfg.interrupt, cfg.payloadId, logger)
+					logs, stop, err := addTransactionsToMiningBlock(logPrefix, current, cfg.chainConfig, cfg.vmConfig, getHeader, cfg.engine, txs, cfg.miningState.MiningConfig.Etherbase, ibs, quit, cfg.interrupt, deadline, cfg.payloadId, logger)
 					log.Debug("addTransactionsToMiningBlock (regular)", "err", err, "logs", logs, "stop", stop)
 					if err != nil {
 						return err
@@ -198,7 +207,7 @@ func SpawnMiningExecStage(s *StageState, tx kv.RwTx, cfg MiningExecCfg, quit <-c
 	}
 
 	var err error
-	_, current.Txs, current.Receipts, current.Requests, err = core.FinalizeBlockExecution(cfg.engine, stateReader, current.Header, current.Txs, current.Uncles, stateWriter, &cfg.chainConfig, ibs, current.Receipts, current.Withdrawals, ChainReaderImpl{config: &cfg.chainConfig, tx: tx, blockReader: cfg.blockReader, logger: logger}, true, logger)
+	_, current.Txs, current.Receipts, current.Requests, err = core.FinalizeBlockExecution(cfg.engine, stateReader, current.Header, current.Txs, current.Uncles, stateWriter, &cfg.chainConfig, ibs, current.Receipts, current.Withdrawals, chainReader, true, logger)
 	if err != nil {
 		return fmt.Errorf("cannot finalize block execution: %s", err)
 	}
@@ -212,6 +221,29 @@ func SpawnMiningExecStage(s *StageState, tx kv.RwTx, cfg MiningExecCfg, quit <-c
 	return nil
 }
 
+// builderSealMargin - time left after packing to finalize the payload and compute its state root
+const builderSealMargin = 100 * time.Millisecond
+
+// packingGrace - the least time transactions are packed for, after the start of packing or engine_getPayload
+const packingGrace = 500 * time.Millisecond
+
+// packingDeadline - when to stop packing the transactions of the txpool into the payload, zero - until engine_getPayload.
+// op-node asks for the payload at its timestamp, so a payload of Optimism is packed until then by default. A payload
+// whose timestamp is already past (op-node catching up) still gets packingGrace.
+func packingDeadline(builderDeadline time.Duration, optimism bool, timestamp uint64, start time.Time) time.Time {
+	if builderDeadline > 0 {
+		return start.Add(builderDeadline)
+	}
+	if optimism {
+		deadline := time.Unix(int64(timestamp), 0).Add(-builderSealMargin)
+		if earliest := start.Add(packingGrace); deadline.Before(earliest) {
+			return earliest
+		}
+		return deadline
+	}
+	return time.Time{}
+}
+
 func getNextTransactions(
 	cfg MiningExecCfg,
 	chainID *uint256.Int,
@@ -424,7 +456,7 @@ func filterBadTransactions(transactions []types.Transaction, config chain.Config
 
 func addTransactionsToMiningBlock(logPrefix string, current *MiningBlock, chainConfig chain.Config, vmConfig *vm.Config, getHeader func(hash libcommon.Hash, number uint64) *types.Header,
 	engine consensus.Engine, txs types.TransactionsStream, coinbase libcommon.Address, ibs *state.IntraBlockState, quit <-chan struct{},
-	interrupt *int32, payloadId uint64, logger log.Logger) (types.Logs, bool, error) {
+	interrupt *int32, deadline time.Time, payloadId uint64, logger log.Logger) (types.Logs, bool, error) {
 	header := current.Header
 	tcount := 0
 	gasPool := new(core.GasPool).AddGas(header.GasLimit - header.GasUsed)
@@ -478,10 +510,17 @@ LOOP:
 			return nil, true, err
 		}
 
+		// the transactions come in the priority order, so the payload has the best of them by the deadline
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			logger.Debug(fmt.Sprintf("[%s] Payload deadline reached", logPrefix), "payload", payloadId, "txs", tcount)
+			done = true
+			break
+		}
 		if interrupt != nil && atomic.LoadInt32(interrupt) != 0 && stopped == nil {
-			logger.Debug("Transaction adding was requested to stop", "payload", payloadId)
-			// ensure we run for at least 500ms after the request to stop comes in from GetPayload
-			stopped = time.NewTicker(500 * time.Millisecond)
+			logger.Debug("Transaction adding was requested to stop", "payload", payloadId, "txs", tcount)
+			// ensure we run for at least packingGrace after the request to stop comes in from GetPayload,
+			// or until the deadline if it's sooner
+			stopped = time.NewTicker(packingGrace)
 		}
 		// If we don't have enough gas for any further transactions then we're done
 		if gasPool.Gas() < params.TxGas {
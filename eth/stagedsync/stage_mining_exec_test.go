@@ -0,0 +1,25 @@
+package stagedsync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackingDeadline(t *testing.T) {
+	start := time.Unix(1_000, 0)
+
+	// flag wins
+	require.Equal(t, start.Add(time.Second), packingDeadline(time.Second, true, 1_002, start))
+	require.Equal(t, start.Add(time.Second), packingDeadline(time.Second, false, 1_002, start))
+
+	// block time of op-node
+	require.Equal(t, time.Unix(1_002, 0).Add(-builderSealMargin), packingDeadline(0, true, 1_002, start))
+	// already past
+	require.Equal(t, start.Add(packingGrace), packingDeadline(0, true, 999, start))
+	require.Equal(t, start.Add(packingGrace), packingDeadline(0, true, 1_000, start))
+
+	// until engine_getPayload
+	require.True(t, packingDeadline(0, false, 1_012, start).IsZero())
+}
@@ -20,6 +20,7 @@ type MiningFinishCfg struct {
 	sealCancel            chan struct{}
 	miningState           MiningState
 	blockReader           services.FullBlockReader
+	chainReader           *SharedChainReader
 	latestBlockBuiltStore *builder.LatestBlockBuiltStore
 }
 
@@ -30,6 +31,7 @@ func StageMiningFinishCfg(
 	miningState MiningState,
 	sealCancel chan struct{},
 	blockReader services.FullBlockReader,
+	chainReader *SharedChainReader,
 	latestBlockBuiltStore *builder.LatestBlockBuiltStore,
 ) MiningFinishCfg {
 	return MiningFinishCfg{
@@ -39,6 +41,7 @@ func StageMiningFinishCfg(
 		miningState:           miningState,
 		sealCancel:            sealCancel,
 		blockReader:           blockReader,
+		chainReader:           chainReader,
 		latestBlockBuiltStore: latestBlockBuiltStore,
 	}
 }
@@ -91,7 +94,7 @@ func SpawnMiningFinishStage(s *StageState, tx kv.RwTx, cfg MiningFinishCfg, quit
 	default:
 		logger.Trace("No in-flight sealing task.")
 	}
-	chain := ChainReader{Cfg: cfg.chainConfig, Db: tx, BlockReader: cfg.blockReader, Logger: logger}
+	chain := chainReaderOf(cfg.chainReader, &cfg.chainConfig, tx, cfg.blockReader, logger)
 	if err := cfg.engine.Seal(chain, blockWithReceipts, cfg.miningState.MiningResultCh, cfg.sealCancel); err != nil {
 		logger.Warn("Block sealing failed", "err", err)
 	}
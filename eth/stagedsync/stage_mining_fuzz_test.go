@@ -0,0 +1,304 @@
+//go:build !nofuzz
+
+package stagedsync
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/chain"
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/memdb"
+	"github.com/erigontech/erigon-lib/log/v3"
+	types2 "github.com/erigontech/erigon-lib/types"
+
+	"github.com/erigontech/erigon/consensus"
+	"github.com/erigontech/erigon/consensus/ethash"
+	"github.com/erigontech/erigon/consensus/merge"
+	"github.com/erigontech/erigon/consensus/misc"
+	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/core/vm"
+	"github.com/erigontech/erigon/crypto"
+	"github.com/erigontech/erigon/eth/ethconfig"
+	"github.com/erigontech/erigon/eth/stagedsync/stages"
+	"github.com/erigontech/erigon/params"
+	"github.com/erigontech/erigon/turbo/services"
+	"github.com/erigontech/erigon/turbo/snapshotsync/freezeblocks"
+)
+
+const (
+	miningFuzzHoloceneTime = 1_000
+	miningFuzzGasLimit     = 30_000_000 // of the miner, for the payloads without a gas limit
+	miningFuzzDepositGas   = 100_000
+	miningFuzzPoolTxs      = 25
+)
+
+// FuzzMiningPayload builds a block on top of the genesis of an OP chain with random payload attributes: deposits
+// present or absent, NoTxPool, gas limits, timestamps around Holocene and its EIP-1559 params, and pending blocks
+// without attributes. The payload of a seed is always the same: `go test` builds the blocks of the seed corpus, and
+// the seeds `go test -fuzz FuzzMiningPayload` finds failing are kept in testdata/fuzz to reproduce them.
+func FuzzMiningPayload(f *testing.F) {
+	for seed := int64(0); seed < 64; seed++ {
+		f.Add(seed)
+	}
+	c := newMiningFuzzChain(f)
+	f.Fuzz(func(t *testing.T, seed int64) {
+		c.build(t, c.randomPayload(rand.New(rand.NewSource(seed))))
+	})
+}
+
+// miningFuzzChain - an OP chain at its genesis, the parent of the blocks built by the harness
+type miningFuzzChain struct {
+	config      *chain.Config
+	db          kv.RwDB
+	txPoolDB    kv.RwDB
+	engine      consensus.Engine
+	blockReader services.FullBlockReader
+	genesis     *types.Block
+	etherbase   libcommon.Address
+	poolTxs     []types.Transaction // transfers of the funded account, by nonce
+	poolRlps    [][]byte
+	poolSender  libcommon.Address
+	logger      log.Logger
+}
+
+func newMiningFuzzChain(tb testing.TB) *miningFuzzChain {
+	logger := log.New()
+	config := &chain.Config{
+		ChainID:                       big.NewInt(901),
+		Consensus:                     chain.EtHashConsensus,
+		HomesteadBlock:                big.NewInt(0),
+		TangerineWhistleBlock:         big.NewInt(0),
+		SpuriousDragonBlock:           big.NewInt(0),
+		ByzantiumBlock:                big.NewInt(0),
+		ConstantinopleBlock:           big.NewInt(0),
+		PetersburgBlock:               big.NewInt(0),
+		IstanbulBlock:                 big.NewInt(0),
+		MuirGlacierBlock:              big.NewInt(0),
+		BerlinBlock:                   big.NewInt(0),
+		LondonBlock:                   big.NewInt(0),
+		TerminalTotalDifficulty:       big.NewInt(0),
+		TerminalTotalDifficultyPassed: true,
+		BedrockBlock:                  big.NewInt(0),
+		RegolithTime:                  big.NewInt(0),
+		HoloceneTime:                  big.NewInt(miningFuzzHoloceneTime),
+		Ethash:                        new(chain.EthashConfig),
+		Optimism:                      &chain.OptimismConfig{EIP1559Elasticity: 6, EIP1559Denominator: 50},
+	}
+	key, err := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	require.NoError(tb, err)
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+
+	db := memdb.NewTestDB(tb)
+	genesis := &types.Genesis{
+		Config:   config,
+		GasLimit: miningFuzzGasLimit,
+		Alloc:    types.GenesisAlloc{sender: {Balance: new(big.Int).Mul(big.NewInt(params.Ether), big.NewInt(1_000))}},
+	}
+	_, genesisBlock, err := core.CommitGenesisBlock(db, genesis, tb.TempDir(), logger)
+	require.NoError(tb, err)
+
+	c := &miningFuzzChain{
+		config:      config,
+		db:          db,
+		txPoolDB:    memdb.NewTestDB(tb),
+		engine:      merge.New(ethash.NewFaker()),
+		blockReader: freezeblocks.NewBlockReader(freezeblocks.NewRoSnapshots(ethconfig.BlocksFreezing{Enabled: false}, tb.TempDir(), 0, logger), freezeblocks.NewBorRoSnapshots(ethconfig.BlocksFreezing{Enabled: false}, tb.TempDir(), 0, logger)),
+		genesis:     genesisBlock,
+		etherbase:   libcommon.HexToAddress("0xc0ffee"),
+		poolSender:  sender,
+		logger:      logger,
+	}
+	c.poolTxs, c.poolRlps = miningFuzzPoolTransactions(tb, config, key)
+	return c
+}
+
+func miningFuzzPoolTransactions(tb testing.TB, config *chain.Config, key *ecdsa.PrivateKey) ([]types.Transaction, [][]byte) {
+	signer := types.LatestSignerForChainID(config.ChainID)
+	txs, rlps := make([]types.Transaction, miningFuzzPoolTxs), make([][]byte, miningFuzzPoolTxs)
+	for nonce := range txs {
+		txn := types.NewTransaction(uint64(nonce), libcommon.Address{1}, uint256.NewInt(1), params.TxGas, uint256.NewInt(10*params.GWei), nil)
+		signed, err := types.SignTx(txn, *signer, key)
+		require.NoError(tb, err)
+		var buf bytes.Buffer
+		require.NoError(tb, signed.MarshalBinary(&buf))
+		txs[nonce], rlps[nonce] = signed, buf.Bytes()
+	}
+	return txs, rlps
+}
+
+// miningFuzzPayload - attributes of the block to build and what the harness expects of it
+type miningFuzzPayload struct {
+	params   *core.BlockBuilderParameters // nil - a pending block
+	deposits []*types.DepositTx
+	poolTxs  int    // the txpool has the first poolTxs of miningFuzzChain.poolTxs
+	wantErr  string // the attributes are rejected by SpawnMiningCreateBlockStage
+}
+
+func (c *miningFuzzChain) randomPayload(rnd *rand.Rand) miningFuzzPayload {
+	p := miningFuzzPayload{poolTxs: rnd.Intn(miningFuzzPoolTxs + 1)}
+	if rnd.Intn(8) == 0 {
+		return p
+	}
+
+	p.params = &core.BlockBuilderParameters{
+		ParentHash: c.genesis.Hash(),
+		Timestamp:  c.genesis.Time() + 1 + uint64(rnd.Intn(2*miningFuzzHoloceneTime)),
+		NoTxPool:   rnd.Intn(2) == 0,
+	}
+	rnd.Read(p.params.PrevRandao[:])
+	rnd.Read(p.params.SuggestedFeeRecipient[:])
+
+	if rnd.Intn(2) == 0 {
+		for i := rnd.Intn(4); i >= 0; i-- {
+			deposit := &types.DepositTx{
+				To:    &libcommon.Address{0xde},
+				Mint:  uint256.NewInt(uint64(rnd.Intn(1_000))),
+				Value: uint256.NewInt(0),
+				Gas:   miningFuzzDepositGas,
+			}
+			rnd.Read(deposit.SourceHash[:])
+			rnd.Read(deposit.From[:])
+			var buf bytes.Buffer
+			if err := deposit.MarshalBinary(&buf); err != nil {
+				panic(err)
+			}
+			p.deposits = append(p.deposits, deposit)
+			p.params.Transactions = append(p.params.Transactions, buf.Bytes())
+		}
+	}
+
+	// op-node leaves room for the deposits, the rest of the gas may not be enough for all the txs of the txpool
+	if rnd.Intn(4) != 0 {
+		gasLimit := uint64(len(p.deposits))*miningFuzzDepositGas + uint64(rnd.Intn(miningFuzzPoolTxs+5))*params.TxGas + uint64(rnd.Intn(int(params.TxGas)))
+		p.params.GasLimit = &gasLimit
+	}
+
+	holocene := c.config.IsHolocene(p.params.Timestamp)
+	switch {
+	case rnd.Intn(10) == 0: // the params are required by Holocene and forbidden before it
+		if !holocene {
+			p.params.EIP1559Params = misc.EncodeHolocene1559Params(250, 6)
+			p.wantErr = "got eip1559 params"
+		} else {
+			p.wantErr = "expected eip1559 params"
+		}
+	case holocene && rnd.Intn(3) == 0:
+		p.params.EIP1559Params = misc.EncodeHolocene1559Params(0, 0)
+	case holocene:
+		p.params.EIP1559Params = misc.EncodeHolocene1559Params(uint64(1+rnd.Intn(1_000)), uint64(1+rnd.Intn(20)))
+	}
+	return p
+}
+
+// build runs the stages building the block of the payload and checks the invariants of the block
+func (c *miningFuzzChain) build(t *testing.T, p miningFuzzPayload) {
+	tx := memdb.BeginRw(t, c.db)
+	miner := NewMiningState(&params.MiningConfig{Etherbase: c.etherbase, GasLimit: miningFuzzGasLimit})
+	pool := &miningFuzzTxPool{rlps: c.poolRlps[:p.poolTxs], txs: c.poolTxs[:p.poolTxs], sender: c.poolSender}
+
+	createCfg := StageMiningCreateBlockCfg(c.db, miner, *c.config, c.engine, c.txPoolDB, p.params, t.TempDir(), c.blockReader, nil)
+	err := SpawnMiningCreateBlockStage(&StageState{ID: stages.MiningCreateBlock}, tx, createCfg, nil, c.logger)
+	if p.wantErr != "" {
+		require.ErrorContains(t, err, p.wantErr)
+		return
+	}
+	require.NoError(t, err)
+
+	execCfg := StageMiningExecCfg(c.db, miner, nil, *c.config, c.engine, &vm.Config{}, t.TempDir(), nil, 0, pool, c.txPoolDB, c.blockReader, nil)
+	require.NoError(t, SpawnMiningExecStage(&StageState{ID: stages.MiningExecution}, tx, execCfg, nil, c.logger))
+
+	current := miner.MiningBlock
+	header := current.Header
+	require.Equal(t, c.genesis.NumberU64()+1, header.Number.Uint64())
+	require.Equal(t, c.genesis.Hash(), header.ParentHash)
+	require.Greater(t, header.Time, c.genesis.Time())
+	require.Equal(t, c.etherbase, header.Coinbase)
+	require.LessOrEqual(t, header.GasUsed, header.GasLimit)
+	require.Len(t, current.Receipts, len(current.Txs))
+
+	wantGasLimit := uint64(miningFuzzGasLimit)
+	if p.params != nil {
+		require.Equal(t, p.params.Timestamp, header.Time)
+		require.Equal(t, p.params.PrevRandao, header.MixDigest)
+		if p.params.GasLimit != nil {
+			wantGasLimit = *p.params.GasLimit
+		}
+	}
+	require.Equal(t, wantGasLimit, header.GasLimit)
+
+	if c.config.IsHolocene(header.Time) {
+		wantD, wantE := c.config.BaseFeeChangeDenominator(params.BaseFeeChangeDenominator, header.Time), c.config.ElasticityMultiplier(params.ElasticityMultiplier)
+		if p.params != nil {
+			if d, e := misc.DecodeHolocene1559Params(p.params.EIP1559Params); d != 0 {
+				wantD, wantE = d, e
+			}
+		}
+		d, e := misc.DecodeHoloceneExtraData(header.Extra)
+		require.Equal(t, [2]uint64{wantD, wantE}, [2]uint64{d, e})
+	}
+
+	// all the deposits are at the start of the block, in the order of the attributes, whatever the txpool has
+	require.GreaterOrEqual(t, len(current.Txs), len(p.deposits))
+	for i, deposit := range p.deposits {
+		require.Equal(t, types.DepositTxType, current.Txs[i].Type())
+		require.Equal(t, deposit.Hash(), current.Txs[i].Hash(), "deposit %d", i)
+		require.Equal(t, types.ReceiptStatusSuccessful, current.Receipts[i].Status, "deposit %d", i)
+	}
+
+	// then the txs of the txpool by nonce, unless NoTxPool, as many as the gas of the block allows
+	included := current.Txs[len(p.deposits):]
+	if p.params != nil && p.params.NoTxPool {
+		require.Empty(t, included)
+		require.Zero(t, pool.yields)
+		return
+	}
+	require.LessOrEqual(t, len(included), p.poolTxs)
+	for i, txn := range included {
+		require.NotEqual(t, types.DepositTxType, txn.Type())
+		require.Equal(t, c.poolTxs[i].Hash(), txn.Hash(), "tx %d", i)
+	}
+	if len(included) < p.poolTxs {
+		require.Less(t, header.GasLimit-header.GasUsed, params.TxGas)
+	}
+}
+
+// miningFuzzTxPool - txpool of the transfers of one account, implements TxPoolForMining
+type miningFuzzTxPool struct {
+	rlps   [][]byte
+	txs    []types.Transaction
+	sender libcommon.Address
+	yields int
+}
+
+func (p *miningFuzzTxPool) YieldBest(n uint16, txs *types2.TxsRlp, _ kv.Tx, _, availableGas, _ uint64, toSkip mapset.Set[[32]byte]) (bool, int, error) {
+	p.yields++
+	txs.Resize(uint(min(int(n), len(p.rlps))))
+	count := 0
+	for i := 0; count < int(n) && i < len(p.rlps); i++ {
+		if toSkip.Contains(p.txs[i].Hash()) {
+			continue
+		}
+		if availableGas < params.TxGas {
+			break
+		}
+		availableGas -= params.TxGas
+
+		txs.Txs[count] = p.rlps[i]
+		copy(txs.Senders.At(count), p.sender.Bytes())
+		txs.IsLocal[count] = false
+		toSkip.Add(p.txs[i].Hash())
+		count++
+	}
+	txs.Resize(uint(count))
+	return true, count, nil
+}
@@ -265,8 +265,11 @@ Loop:
 			return minBlockErr
 		}
 		minHeader := rawdb.ReadHeader(tx, minBlockHash, minBlockNum)
-		if cfg.hd != nil && errors.Is(minBlockErr, consensus.ErrInvalidBlock) {
-			cfg.hd.ReportBadHeaderPoS(minBlockHash, minHeader.ParentHash)
+		if errors.Is(minBlockErr, consensus.ErrInvalidBlock) {
+			recordBadBlock(tx, minHeader, nil, minBlockErr, logger)
+			if cfg.hd != nil {
+				cfg.hd.ReportBadHeaderPoS(minBlockHash, minHeader.ParentHash)
+			}
 		}
 
 		if to > s.BlockNumber {
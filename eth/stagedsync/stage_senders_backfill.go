@@ -0,0 +1,143 @@
+package stagedsync
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/erigontech/erigon-lib/chain"
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/core/rawdb"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/eth/ethconfig"
+	"github.com/erigontech/erigon/turbo/services"
+)
+
+// SendersBackfillCfg - Senders stage doesn't process frozen blocks: their senders are stored in transactions segments.
+// But segments imported from external snapshots or exports may have no senders (zero address instead) - then
+// BlockWithSenders can't return them. This stage checks frozen blocks, recovers missing senders and stores them
+// in kv.FrozenSenders, which BlockReader uses for such blocks and which is not pruned when blocks are retired.
+// New frozen blocks are checked as snapshots retire them, so the stage keeps up with the retire pipeline.
+type SendersBackfillCfg struct {
+	db          kv.RwDB
+	enabled     bool
+	chainConfig *chain.Config
+	blockReader services.FullBlockReader
+}
+
+func StageSendersBackfillCfg(db kv.RwDB, chainConfig *chain.Config, syncCfg ethconfig.Sync, blockReader services.FullBlockReader) SendersBackfillCfg {
+	return SendersBackfillCfg{
+		db:          db,
+		enabled:     syncCfg.SendersBackfill,
+		chainConfig: chainConfig,
+		blockReader: blockReader,
+	}
+}
+
+// SpawnSendersBackfill - checks frozen blocks (s.BlockNumber, toBlock]. If the stage owns the transaction,
+// progress is committed periodically, so interrupted backfill resumes where it stopped.
+func SpawnSendersBackfill(s *StageState, tx kv.RwTx, toBlock uint64, cfg SendersBackfillCfg, ctx context.Context, logger log.Logger) (err error) {
+	useExternalTx := tx != nil
+	if !useExternalTx {
+		tx, err = cfg.db.BeginRw(ctx)
+		if err != nil {
+			return err
+		}
+		defer func() { tx.Rollback() }()
+	}
+
+	to := cfg.blockReader.FrozenBlocks()
+	if toBlock > 0 {
+		to = min(to, toBlock)
+	}
+	if to <= s.BlockNumber {
+		return nil
+	}
+	logPrefix := s.LogPrefix()
+	if to > s.BlockNumber+16 {
+		logger.Info(fmt.Sprintf("[%s] Started", logPrefix), "from", s.BlockNumber+1, "to", to)
+	}
+
+	logEvery := time.NewTicker(logInterval)
+	defer logEvery.Stop()
+
+	var blocks, senders int
+	blockNum := s.BlockNumber + 1
+	for ; blockNum <= to; blockNum++ {
+		if err = libcommon.Stopped(ctx.Done()); err != nil {
+			return err
+		}
+		recovered, err := backfillBlockSenders(ctx, tx, cfg, blockNum)
+		if err != nil {
+			return fmt.Errorf("[%s] block %d: %w", logPrefix, blockNum, err)
+		}
+		if recovered > 0 {
+			blocks++
+			senders += recovered
+		}
+
+		select {
+		case <-logEvery.C:
+			logger.Info(fmt.Sprintf("[%s] Progress", logPrefix), "block", blockNum, "to", to, "blocksWithoutSenders", blocks, "recoveredSenders", senders)
+			if !useExternalTx {
+				if err = s.Update(tx, blockNum); err != nil {
+					return err
+				}
+				if err = tx.Commit(); err != nil {
+					return err
+				}
+				if tx, err = cfg.db.BeginRw(ctx); err != nil {
+					return err
+				}
+			}
+		default:
+		}
+	}
+
+	if blocks > 0 {
+		logger.Info(fmt.Sprintf("[%s] Recovered missing senders", logPrefix), "blocks", blocks, "senders", senders)
+	}
+	if err = s.Update(tx, to); err != nil {
+		return err
+	}
+	if !useExternalTx {
+		if err = tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillBlockSenders - returns amount of recovered senders, 0 if the block already has them
+func backfillBlockSenders(ctx context.Context, tx kv.RwTx, cfg SendersBackfillCfg, blockNum uint64) (int, error) {
+	hash, err := cfg.blockReader.CanonicalHash(ctx, tx, blockNum)
+	if err != nil {
+		return 0, err
+	}
+	block, senders, err := cfg.blockReader.BlockWithSenders(ctx, tx, hash, blockNum)
+	if err != nil {
+		return 0, err
+	}
+	if block == nil {
+		return 0, fmt.Errorf("frozen block %x not found", hash)
+	}
+	txs := block.Transactions()
+	if len(senders) == len(txs) {
+		return 0, nil
+	}
+
+	signer := types.MakeSigner(cfg.chainConfig, blockNum, block.Time())
+	senders = make([]libcommon.Address, len(txs))
+	for i, txn := range txs {
+		if senders[i], err = signer.Sender(txn); err != nil {
+			return 0, fmt.Errorf("txn %d %x: %w", i, txn.Hash(), err)
+		}
+	}
+	if err = rawdb.WriteFrozenSenders(tx, hash, blockNum, senders); err != nil {
+		return 0, err
+	}
+	return len(senders), nil
+}
@@ -0,0 +1,96 @@
+package stagedsync
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/memdb"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/core/rawdb"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/crypto"
+	"github.com/erigontech/erigon/eth/ethconfig"
+	"github.com/erigontech/erigon/eth/stagedsync/stages"
+	"github.com/erigontech/erigon/params"
+	"github.com/erigontech/erigon/turbo/services"
+)
+
+// frozenBlocksReader - frozen blocks, the segments of which have no senders for the blocks of noSenders
+type frozenBlocksReader struct {
+	services.FullBlockReader
+	blocks    []*types.Block
+	noSenders map[uint64]bool
+	senders   []libcommon.Address
+}
+
+func (r *frozenBlocksReader) FrozenBlocks() uint64 { return uint64(len(r.blocks)) }
+
+func (r *frozenBlocksReader) CanonicalHash(_ context.Context, _ kv.Getter, blockNum uint64) (libcommon.Hash, error) {
+	return r.blocks[blockNum-1].Hash(), nil
+}
+
+func (r *frozenBlocksReader) BlockWithSenders(_ context.Context, tx kv.Getter, hash libcommon.Hash, blockNum uint64) (*types.Block, []libcommon.Address, error) {
+	block := r.blocks[blockNum-1]
+	if !r.noSenders[blockNum] {
+		return block, r.senders[:len(block.Transactions())], nil
+	}
+	senders, err := rawdb.ReadFrozenSenders(tx, hash, blockNum)
+	return block, senders, err
+}
+
+func TestSendersBackfill(t *testing.T) {
+	ctx, db := context.Background(), memdb.NewTestDB(t)
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	signer := types.LatestSigner(params.TestChainConfig)
+
+	reader := &frozenBlocksReader{noSenders: map[uint64]bool{2: true, 3: true, 5: true}, senders: []libcommon.Address{from}}
+	retire := func(blockNum uint64) {
+		var txs []types.Transaction
+		if blockNum != 3 { // block without transactions has nothing to recover
+			txn, err := types.SignTx(types.NewTransaction(blockNum, libcommon.Address{1}, uint256.NewInt(1), params.TxGas, uint256.NewInt(1), nil), *signer, key)
+			require.NoError(t, err)
+			txs = append(txs, txn)
+		}
+		reader.blocks = append(reader.blocks, types.NewBlock(&types.Header{Number: new(big.Int).SetUint64(blockNum)}, txs, nil, nil, nil))
+	}
+	for blockNum := uint64(1); blockNum <= 4; blockNum++ {
+		retire(blockNum)
+	}
+	cfg := StageSendersBackfillCfg(db, params.TestChainConfig, ethconfig.Sync{SendersBackfill: true}, reader)
+
+	require.NoError(t, SpawnSendersBackfill(&StageState{ID: stages.SendersBackfill}, nil, 0, cfg, ctx, log.New()))
+	require.NoError(t, db.View(ctx, func(tx kv.Tx) error {
+		for blockNum := uint64(1); blockNum <= 4; blockNum++ {
+			senders, err := rawdb.ReadFrozenSenders(tx, reader.blocks[blockNum-1].Hash(), blockNum)
+			require.NoError(t, err)
+			if blockNum == 2 {
+				require.Equal(t, []libcommon.Address{from}, senders)
+			} else {
+				require.Nil(t, senders, "block %d", blockNum)
+			}
+		}
+		progress, err := stages.GetStageProgress(tx, stages.SendersBackfill)
+		require.NoError(t, err)
+		require.Equal(t, uint64(4), progress)
+		return nil
+	}))
+
+	// the blocks retired later are checked from the progress on, the backfilled ones have their senders
+	retire(5)
+	tx := memdb.BeginRw(t, db)
+	recovered, err := backfillBlockSenders(ctx, tx, cfg, 2)
+	require.NoError(t, err)
+	require.Zero(t, recovered)
+	require.NoError(t, SpawnSendersBackfill(&StageState{ID: stages.SendersBackfill, BlockNumber: 4}, tx, 0, cfg, ctx, log.New()))
+	senders, err := rawdb.ReadFrozenSenders(tx, reader.blocks[4].Hash(), 5)
+	require.NoError(t, err)
+	require.Equal(t, []libcommon.Address{from}, senders)
+}
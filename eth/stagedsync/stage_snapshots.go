@@ -416,7 +416,7 @@ func FillDBFromSnapshots(logPrefix string, ctx context.Context, tx kv.RwTx, dirs
 					}
 					maxTxNum := baseTxNum + txAmount - 1
 
-					if err := rawdbv3.TxNums.Append(tx, blockNum, maxTxNum); err != nil {
+					if err := rawdbv3.TxNumsWriter.Append(tx, blockNum, maxTxNum); err != nil {
 						return fmt.Errorf("%w. blockNum=%d, maxTxNum=%d", err, blockNum, maxTxNum)
 					}
 					return nil
@@ -71,6 +71,7 @@ func InitHarness(ctx context.Context, t *testing.T, cfg HarnessCfg) Harness {
 		stagedsync.BlockHashesCfg{},
 		stagedsync.BodiesCfg{},
 		stagedsync.SendersCfg{},
+		stagedsync.SendersBackfillCfg{},
 		stagedsync.ExecuteBlockCfg{},
 		stagedsync.HashStateCfg{},
 		stagedsync.TrieCfg{},
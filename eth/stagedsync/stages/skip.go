@@ -0,0 +1,71 @@
+package stages
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Skippable - stages which only build indices for RPC: no other stage reads their data, so special-purpose
+// nodes (pure sequencer, engine follower) may skip them. Value - what is unavailable without the stage.
+var Skippable = map[SyncStage]string{
+	TxLookup:            "lookup of transactions by hash in not frozen blocks",
+	LogIndex:            "eth_getLogs and logs filters over history",
+	CallTraces:          "trace_filter",
+	AccountHistoryIndex: "state of historical blocks",
+	StorageHistoryIndex: "state of historical blocks",
+}
+
+// skippedTogether - groups of stages which must be skipped together: RPC reads their data together,
+// and skipping only a part of the group serves inconsistent results
+var skippedTogether = [][]SyncStage{
+	{AccountHistoryIndex, StorageHistoryIndex},
+}
+
+// ParseSkipStages - parses comma-separated list of stages and checks that they can be skipped
+func ParseSkipStages(list string) ([]SyncStage, error) {
+	var skip []SyncStage
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		if !slices.Contains(skip, SyncStage(name)) {
+			skip = append(skip, SyncStage(name))
+		}
+	}
+	return skip, ValidateSkipStages(skip)
+}
+
+func ValidateSkipStages(skip []SyncStage) error {
+	for _, stage := range skip {
+		if _, ok := Skippable[stage]; ok {
+			continue
+		}
+		if slices.Contains(AllStages, stage) {
+			return fmt.Errorf("stage %s can't be skipped: other stages depend on it, skippable stages: %s", stage, skippableList())
+		}
+		return fmt.Errorf("unknown stage %s, skippable stages: %s", stage, skippableList())
+	}
+	for _, group := range skippedTogether {
+		var skipped int
+		for _, stage := range group {
+			if slices.Contains(skip, stage) {
+				skipped++
+			}
+		}
+		if skipped > 0 && skipped < len(group) {
+			return fmt.Errorf("stages %s must be skipped together", group)
+		}
+	}
+	return nil
+}
+
+func skippableList() string {
+	var names []string
+	for _, stage := range AllStages {
+		if _, ok := Skippable[stage]; ok {
+			names = append(names, string(stage))
+		}
+	}
+	return strings.Join(names, ",")
+}
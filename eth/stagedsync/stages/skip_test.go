@@ -0,0 +1,26 @@
+package stages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSkipStages(t *testing.T) {
+	skip, err := ParseSkipStages("TxLookup, LogIndex,,CallTraces,TxLookup")
+	require.NoError(t, err)
+	require.Equal(t, []SyncStage{TxLookup, LogIndex, CallTraces}, skip)
+
+	skip, err = ParseSkipStages("AccountHistoryIndex,StorageHistoryIndex")
+	require.NoError(t, err)
+	require.Len(t, skip, 2)
+
+	for _, list := range []string{
+		"Execution",           // other stages depend on it
+		"TxLookups",           // unknown
+		"AccountHistoryIndex", // only a part of the group
+	} {
+		_, err = ParseSkipStages(list)
+		require.Error(t, err, list)
+	}
+}
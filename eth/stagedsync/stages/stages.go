@@ -36,6 +36,7 @@ var (
 	BlockHashes         SyncStage = "BlockHashes"     // Headers Number are written, fills blockHash => number bucket
 	Bodies              SyncStage = "Bodies"          // Block bodies are downloaded, TxHash and UncleHash are getting verified
 	Senders             SyncStage = "Senders"         // "From" recovered from signatures, bodies re-written
+	SendersBackfill     SyncStage = "SendersBackfill" // "From" recovered for frozen blocks which segments have no senders
 	Execution           SyncStage = "Execution"       // Executing each block w/o buildinf a trie
 	Translation         SyncStage = "Translation"     // Translation each marked for translation contract (from EVM to TEVM)
 	VerkleTrie          SyncStage = "VerkleTrie"
@@ -67,6 +68,7 @@ var AllStages = []SyncStage{
 	BlockHashes,
 	Bodies,
 	Senders,
+	SendersBackfill,
 	Execution,
 	Translation,
 	HashState,
@@ -108,6 +110,25 @@ func SaveStagePruneProgress(db kv.Putter, stage SyncStage, progress uint64) erro
 	return db.Put(kv.SyncStageProgress, []byte("prune_"+stage), marshalData(progress))
 }
 
+// GetStageUnwindPoint retrieves the unwind point of the stage which unwind is committed in batches and isn't done
+// yet, ok is false if there is no such unwind
+func GetStageUnwindPoint(db kv.Getter, stage SyncStage) (unwindPoint uint64, ok bool, err error) {
+	v, err := db.GetOne(kv.SyncStageProgress, []byte("unwind_"+stage))
+	if err != nil || len(v) == 0 {
+		return 0, false, err
+	}
+	unwindPoint, err = unmarshalData(v)
+	return unwindPoint, err == nil, err
+}
+
+func SaveStageUnwindPoint(db kv.Putter, stage SyncStage, unwindPoint uint64) error {
+	return db.Put(kv.SyncStageProgress, []byte("unwind_"+stage), marshalData(unwindPoint))
+}
+
+func DeleteStageUnwindPoint(db kv.Deleter, stage SyncStage) error {
+	return db.Delete(kv.SyncStageProgress, []byte("unwind_"+stage))
+}
+
 func marshalData(blockNumber uint64) []byte {
 	return encodeBigEndian(blockNumber)
 }
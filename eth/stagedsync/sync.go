@@ -3,6 +3,7 @@ package stagedsync
 import (
 	"context"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/erigontech/erigon-lib/log/v3"
@@ -32,6 +33,9 @@ type Sync struct {
 	logPrefixes   []string
 	logger        log.Logger
 	stagesIdsList []string
+	pause         *SyncPause // nil unless sync can be paused by admin_pauseSync
+	dryRun        bool       // cycles are not committed, see DryRun
+	dryRunTmpDir  string
 }
 
 type Timing struct {
@@ -192,6 +196,13 @@ func New(cfg ethconfig.Sync, stagesList []*Stage, unwindOrder UnwindOrder, prune
 		}
 	}
 
+	for _, s := range stagesList {
+		if slices.Contains(cfg.SkipStages, s.ID) {
+			s.Disabled = true
+			s.DisabledDescription = "Skipped by --sync.skip.stages"
+		}
+	}
+
 	logPrefixes := make([]string, len(stagesList))
 	stagesIdsList := make([]string, len(stagesList))
 	for i := range stagesList {
@@ -383,6 +394,12 @@ func (s *Sync) Run(db kv.RwDB, txc wrap.TxContainer, firstCycle bool) (bool, err
 			return false, libcommon.ErrStopped
 		}
 
+		if s.pause.Stopping() {
+			s.logger.Info(fmt.Sprintf("[%s] Sync paused by admin_pauseSync", s.LogPrefix()))
+			hasMore = true
+			break
+		}
+
 		if string(stage.ID) == s.cfg.BreakAfterStage { // break process loop
 			s.logger.Warn("--sync.loop.break.after caused stage break")
 			if s.posTransition != nil {
@@ -623,6 +640,14 @@ func (s *Sync) EnableStages(ids ...stages.SyncStage) {
 	}
 }
 
+func (s *Sync) SetSyncPause(p *SyncPause) {
+	s.pause = p
+}
+
+func (s *Sync) SyncPause() *SyncPause {
+	return s.pause
+}
+
 func (s *Sync) MockExecFunc(id stages.SyncStage, f ExecFunc) {
 	for i := range s.stages {
 		if s.stages[i].ID == id {
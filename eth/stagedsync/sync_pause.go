@@ -0,0 +1,96 @@
+package stagedsync
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// SyncPause - pausing of staged sync by admin_pauseSync until admin_resumeSync, e.g. for filesystem backups of the
+// datadir. Sync cycles hold the lock while they run and the pause takes it, so nothing is written to the db until
+// sync is resumed. Cycles started by Begin stop between stages once a pause is requested, with the progress of the
+// stages run so far committed; other holders of the lock (forkchoice updates) finish first. Work the cycles leave
+// in background (blocks retired to snapshots) is waited for by the functions added by WaitFor, it's only started
+// by the cycles, so not while paused. A nil *SyncPause never pauses.
+type SyncPause struct {
+	lock          *semaphore.Weighted
+	mu            sync.Mutex // serialises Pause and Resume
+	paused        atomic.Bool
+	interruptible atomic.Bool // the lock is held by a cycle started by Begin
+	background    []func(ctx context.Context) error
+}
+
+func NewSyncPause(lock *semaphore.Weighted) *SyncPause {
+	return &SyncPause{lock: lock}
+}
+
+// Begin - waits until sync is not paused and takes the lock for a cycle which may stop between stages
+func (p *SyncPause) Begin(ctx context.Context) error {
+	if p == nil {
+		return nil
+	}
+	if err := p.lock.Acquire(ctx, 1); err != nil {
+		return err
+	}
+	p.interruptible.Store(true)
+	return nil
+}
+
+// End - releases the lock taken by Begin
+func (p *SyncPause) End() {
+	if p == nil {
+		return
+	}
+	p.interruptible.Store(false)
+	p.lock.Release(1)
+}
+
+// Stopping - the running cycle must stop after the current stage
+func (p *SyncPause) Stopping() bool {
+	return p != nil && p.paused.Load() && p.interruptible.Load()
+}
+
+func (p *SyncPause) Paused() bool {
+	return p != nil && p.paused.Load()
+}
+
+// WaitFor adds the wait for background work of the cycles to Pause, must be called before sync starts
+func (p *SyncPause) WaitFor(wait func(ctx context.Context) error) {
+	p.background = append(p.background, wait)
+}
+
+// Pause - requests the running cycle to stop and waits until it has committed and its background work is done
+func (p *SyncPause) Pause(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.paused.Load() {
+		return errors.New("sync is already paused")
+	}
+	p.paused.Store(true)
+	if err := p.lock.Acquire(ctx, 1); err != nil {
+		p.paused.Store(false)
+		return err
+	}
+	for _, wait := range p.background {
+		if err := wait(ctx); err != nil {
+			p.paused.Store(false)
+			p.lock.Release(1)
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *SyncPause) Resume() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.paused.Load() {
+		return errors.New("sync is not paused")
+	}
+	p.paused.Store(false)
+	p.lock.Release(1)
+	return nil
+}
@@ -0,0 +1,94 @@
+package stagedsync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/erigontech/erigon-lib/kv/memdb"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon-lib/wrap"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/semaphore"
+
+	"github.com/erigontech/erigon/eth/ethconfig"
+	"github.com/erigontech/erigon/eth/stagedsync/stages"
+)
+
+func TestSyncPause(t *testing.T) {
+	pause := NewSyncPause(semaphore.NewWeighted(1))
+	paused := make(chan error, 1)
+	flow := make([]stages.SyncStage, 0)
+	s := []*Stage{
+		{
+			ID:          stages.Headers,
+			Description: "Downloading headers",
+			Forward: func(firstCycle bool, badBlockUnwind bool, s *StageState, u Unwinder, txc wrap.TxContainer, logger log.Logger) error {
+				flow = append(flow, stages.Headers)
+				go func() { paused <- pause.Pause(context.Background()) }()
+				for !pause.Paused() {
+					time.Sleep(time.Millisecond)
+				}
+				return nil
+			},
+		},
+		{
+			ID:          stages.Bodies,
+			Description: "Downloading block bodiess",
+			Forward: func(firstCycle bool, badBlockUnwind bool, s *StageState, u Unwinder, txc wrap.TxContainer, logger log.Logger) error {
+				flow = append(flow, stages.Bodies)
+				return nil
+			},
+		},
+	}
+	state := New(ethconfig.Defaults.Sync, s, nil, nil, log.New())
+	state.SetSyncPause(pause)
+	db, tx := memdb.NewTestTx(t)
+
+	// the cycle stops after the stage during which the pause is requested
+	require.NoError(t, pause.Begin(context.Background()))
+	hasMore, err := state.Run(db, wrap.TxContainer{Tx: tx}, true /* initialCycle */)
+	require.NoError(t, err)
+	require.True(t, hasMore)
+	require.Equal(t, []stages.SyncStage{stages.Headers}, flow)
+	select {
+	case <-paused:
+		t.Fatal("paused before the cycle ended")
+	default:
+	}
+	pause.End()
+	require.NoError(t, <-paused)
+	require.Error(t, pause.Pause(context.Background()))
+
+	// next cycles wait until sync is resumed
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, pause.Begin(ctx), context.DeadlineExceeded)
+	require.NoError(t, pause.Resume())
+	require.Error(t, pause.Resume())
+	require.NoError(t, pause.Begin(context.Background()))
+	pause.End()
+}
+
+func TestSyncPauseWaitsForBackground(t *testing.T) {
+	pause := NewSyncPause(semaphore.NewWeighted(1))
+	background := semaphore.NewWeighted(1)
+	pause.WaitFor(func(ctx context.Context) error {
+		if err := background.Acquire(ctx, 1); err != nil {
+			return err
+		}
+		background.Release(1)
+		return nil
+	})
+
+	// left running by a cycle
+	require.True(t, background.TryAcquire(1))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, pause.Pause(ctx), context.DeadlineExceeded)
+	require.False(t, pause.Paused())
+
+	background.Release(1)
+	require.NoError(t, pause.Pause(context.Background()))
+	require.NoError(t, pause.Resume())
+}
@@ -0,0 +1,221 @@
+package stagedsync
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/etl"
+	"github.com/erigontech/erigon-lib/gointerfaces"
+	"github.com/erigontech/erigon-lib/gointerfaces/remote"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/dbutils"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/core/types/accounts"
+	"github.com/erigontech/erigon/eth/stagedsync/stages"
+	"github.com/erigontech/erigon/turbo/trie"
+)
+
+// incrementalTrieMaxBlocks - larger batches are left to HashState and IntermediateHashes stages
+const incrementalTrieMaxBlocks = 128
+
+// updateTrieIncrementally - --sync.incremental.trie: after a small batch of blocks executed with the state stream,
+// the hashed state and the intermediate hashes are updated right away from the changes of the accumulator, instead
+// of HashState and IntermediateHashes stages reading them back from the change sets. The trie root is checked
+// against the header of the last block and both stages are moved to it, so the next ones have nothing to do.
+// Returns false, leaving the batch to the stages, if it doesn't qualify or the root doesn't match: the stages then
+// promote the same keys again and handle the bad block as usual.
+func updateTrieIncrementally(ctx context.Context, logPrefix string, tx kv.RwTx, from, to uint64, cfg ExecuteBlockCfg, logger log.Logger) (bool, error) {
+	if from == 0 || to-from > incrementalTrieMaxBlocks {
+		return false, nil
+	}
+	for _, stage := range []stages.SyncStage{stages.HashState, stages.IntermediateHashes} {
+		progress, err := stages.GetStageProgress(tx, stage)
+		if err != nil {
+			return false, err
+		}
+		if progress != from {
+			return false, nil
+		}
+	}
+	changes, err := accumulatedChanges(ctx, tx, cfg, from, to)
+	if err != nil || changes == nil {
+		return false, err
+	}
+	header, err := cfg.blockReader.HeaderByNumber(ctx, tx, to)
+	if err != nil {
+		return false, err
+	}
+	if header == nil {
+		return false, fmt.Errorf("no header found with number %d", to)
+	}
+
+	rl := trie.NewRetainList(0)
+	if err := promoteAccumulatedChanges(tx, changes, rl); err != nil {
+		return false, err
+	}
+
+	quit := ctx.Done()
+	accTrieCollector := etl.NewCollector(logPrefix, cfg.dirs.Tmp, etl.NewSortableBuffer(etl.BufferOptimalSize), logger)
+	defer accTrieCollector.Close()
+	stTrieCollector := etl.NewCollector(logPrefix, cfg.dirs.Tmp, etl.NewSortableBuffer(etl.BufferOptimalSize), logger)
+	defer stTrieCollector.Close()
+	loader := trie.NewFlatDBTrieLoader(logPrefix, rl, accountTrieCollector(accTrieCollector), storageTrieCollector(stTrieCollector), false)
+	root, err := loader.CalcTrieRoot(tx, quit)
+	if err != nil {
+		return false, err
+	}
+	if root != header.Root {
+		logger.Warn(fmt.Sprintf("[%s] Wrong trie root of incremental update, left to the stages", logPrefix), "block", to, "root", root, "expected", header.Root)
+		return false, nil
+	}
+	if err := accTrieCollector.Load(tx, kv.TrieOfAccounts, etl.IdentityLoadFunc, etl.TransformArgs{Quit: quit}); err != nil {
+		return false, err
+	}
+	if err := stTrieCollector.Load(tx, kv.TrieOfStorage, etl.IdentityLoadFunc, etl.TransformArgs{Quit: quit}); err != nil {
+		return false, err
+	}
+	for _, stage := range []stages.SyncStage{stages.HashState, stages.IntermediateHashes} {
+		if err := stages.SaveStageProgress(tx, stage, to); err != nil {
+			return false, err
+		}
+	}
+	logger.Debug(fmt.Sprintf("[%s] Trie updated incrementally", logPrefix), "from", from, "to", to, "root", root)
+	return true, nil
+}
+
+// accumulatedChanges - the changes of blocks from+1..to, which are the last ones of the accumulator. Nil if it
+// doesn't have all of them: it has changes of a whole cycle, which may also unwind and re-execute blocks.
+func accumulatedChanges(ctx context.Context, tx kv.Tx, cfg ExecuteBlockCfg, from, to uint64) ([]*remote.StateChange, error) {
+	if cfg.accumulator == nil {
+		return nil, nil
+	}
+	all := cfg.accumulator.Changes()
+	n := int(to - from)
+	if len(all) < n {
+		return nil, nil
+	}
+	changes := all[len(all)-n:]
+	for i, change := range changes {
+		number := from + 1 + uint64(i)
+		if change.Direction != remote.Direction_FORWARD || change.BlockHeight != number {
+			return nil, nil
+		}
+		hash, err := cfg.blockReader.CanonicalHash(ctx, tx, number)
+		if err != nil {
+			return nil, err
+		}
+		if gointerfaces.ConvertH256ToHash(change.BlockHash) != hash {
+			return nil, nil
+		}
+	}
+	return changes, nil
+}
+
+// promoteAccumulatedChanges - writes the changes to the hashed state like HashState stage, and adds the changed
+// keys to rl like HashPromoter does for IntermediateHashes stage: marked if the key didn't exist before.
+func promoteAccumulatedChanges(tx kv.RwTx, changes []*remote.StateChange, rl *trie.RetainList) error {
+	oldIncarnations := map[libcommon.Hash]uint64{} // of changed accounts, before the batch
+	storageKeys := map[string]struct{}{}
+	for _, change := range changes {
+		for _, c := range change.Changes {
+			address := gointerfaces.ConvertH160toAddress(c.Address)
+			addrHash, err := libcommon.HashData(address[:])
+			if err != nil {
+				return err
+			}
+			if c.Action != remote.Action_STORAGE {
+				if _, ok := oldIncarnations[addrHash]; !ok {
+					enc, err := tx.GetOne(kv.HashedAccounts, addrHash[:])
+					if err != nil {
+						return err
+					}
+					var incarnation uint64
+					if len(enc) > 0 {
+						if incarnation, err = accounts.DecodeIncarnationFromStorage(enc); err != nil {
+							return err
+						}
+					}
+					oldIncarnations[addrHash] = incarnation
+					rl.AddKeyWithMarker(addrHash[:], len(enc) == 0)
+				}
+			}
+			switch c.Action {
+			case remote.Action_UPSERT, remote.Action_UPSERT_CODE:
+				if err := tx.Put(kv.HashedAccounts, addrHash[:], c.Data); err != nil {
+					return err
+				}
+			case remote.Action_REMOVE:
+				if err := tx.Delete(kv.HashedAccounts, addrHash[:]); err != nil {
+					return err
+				}
+			}
+			if c.Action == remote.Action_CODE || c.Action == remote.Action_UPSERT_CODE {
+				codeHash, err := libcommon.HashData(c.Code)
+				if err != nil {
+					return err
+				}
+				if err := tx.Put(kv.ContractCode, dbutils.GenerateStoragePrefix(addrHash[:], c.Incarnation), codeHash[:]); err != nil {
+					return err
+				}
+			}
+			for _, sc := range c.StorageChanges {
+				location := gointerfaces.ConvertH256ToHash(sc.Location)
+				locHash, err := libcommon.HashData(location[:])
+				if err != nil {
+					return err
+				}
+				key := dbutils.GenerateCompositeStorageKey(addrHash, c.Incarnation, locHash)
+				if _, ok := storageKeys[string(key)]; !ok {
+					old, err := tx.GetOne(kv.HashedStorage, key)
+					if err != nil {
+						return err
+					}
+					storageKeys[string(key)] = struct{}{}
+					rl.AddKeyWithMarker(key, len(old) == 0)
+				}
+				if len(sc.Data) == 0 {
+					err = tx.Delete(kv.HashedStorage, key)
+				} else {
+					err = tx.Put(kv.HashedStorage, key, sc.Data)
+				}
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	// intermediate hashes of storage of the accounts deleted or turned to incarnation 0, like HashPromoter.Promote
+	var deletedAccounts [][]byte
+	for addrHash, oldIncarnation := range oldIncarnations {
+		if oldIncarnation == 0 {
+			continue
+		}
+		enc, err := tx.GetOne(kv.HashedAccounts, addrHash[:])
+		if err != nil {
+			return err
+		}
+		var incarnation uint64
+		if len(enc) > 0 {
+			if incarnation, err = accounts.DecodeIncarnationFromStorage(enc); err != nil {
+				return err
+			}
+		}
+		if incarnation < oldIncarnation {
+			deletedAccounts = append(deletedAccounts, libcommon.Copy(addrHash[:]))
+		}
+	}
+	slices.SortFunc(deletedAccounts, bytes.Compare)
+	for _, k := range deletedAccounts {
+		if err := tx.ForPrefix(kv.TrieOfStorage, k, func(k, _ []byte) error {
+			return tx.Delete(kv.TrieOfStorage, k)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package stagedsync
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/etl"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/dbutils"
+	"github.com/erigontech/erigon-lib/kv/memdb"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/core/types/accounts"
+	"github.com/erigontech/erigon/turbo/shards"
+	"github.com/erigontech/erigon/turbo/trie"
+)
+
+func encodeTestAccount(balance, incarnation uint64) []byte {
+	acc := accounts.NewAccount()
+	acc.Balance.SetUint64(balance)
+	acc.Incarnation = incarnation
+	if incarnation != 0 {
+		acc.CodeHash = libcommon.HexToHash("0x5be74cad16203c4905c068b012a2e9fb6d19d036c410f16fd177f337541440dd")
+	}
+	encoded := make([]byte, acc.EncodingLengthForStorage())
+	acc.EncodeForStorage(encoded)
+	return encoded
+}
+
+func TestPromoteAccumulatedChanges(t *testing.T) {
+	db, tx := memdb.NewTestTx(t)
+	ctx, logger := context.Background(), log.New()
+	hash := func(b []byte) libcommon.Hash {
+		h, err := libcommon.HashData(b)
+		require.NoError(t, err)
+		return h
+	}
+
+	eoa, contract, destructed := libcommon.HexToAddress("0x01"), libcommon.HexToAddress("0x02"), libcommon.HexToAddress("0x03")
+	loc1, loc2 := libcommon.HexToHash("0x01"), libcommon.HexToHash("0x02")
+	require.NoError(t, tx.Put(kv.HashedAccounts, hash(eoa[:]).Bytes(), encodeTestAccount(1, 0)))
+	require.NoError(t, tx.Put(kv.HashedAccounts, hash(contract[:]).Bytes(), encodeTestAccount(2, 1)))
+	require.NoError(t, tx.Put(kv.HashedAccounts, hash(destructed[:]).Bytes(), encodeTestAccount(3, 1)))
+	for _, addr := range []libcommon.Address{contract, destructed} {
+		require.NoError(t, tx.Put(kv.HashedStorage, dbutils.GenerateCompositeStorageKey(hash(addr[:]), 1, hash(loc1[:])), []byte{0x42}))
+		require.NoError(t, tx.Put(kv.HashedStorage, dbutils.GenerateCompositeStorageKey(hash(addr[:]), 1, hash(loc2[:])), []byte{0x43}))
+	}
+	cfg := StageTrieCfg(db, false, true, false, t.TempDir(), nil, nil, false, nil)
+	_, err := RegenerateIntermediateHashes("IH", tx, cfg, libcommon.Hash{}, ctx, logger)
+	require.NoError(t, err)
+
+	created := libcommon.HexToAddress("0x04")
+	acc := shards.NewAccumulator()
+	acc.StartChange(1, libcommon.Hash{}, nil, false)
+	acc.ChangeAccount(eoa, 0, encodeTestAccount(10, 0))
+	acc.ChangeStorage(contract, 1, loc1, []byte{0x01})
+	acc.ChangeStorage(contract, 1, loc2, nil)
+	acc.StartChange(2, libcommon.Hash{}, nil, false)
+	acc.DeleteAccount(destructed)
+	acc.ChangeAccount(created, 1, encodeTestAccount(4, 1))
+	acc.ChangeCode(created, 1, []byte{0x60, 0x00})
+	acc.ChangeStorage(created, 1, loc1, []byte{0x07})
+
+	rl := trie.NewRetainList(0)
+	require.NoError(t, promoteAccumulatedChanges(tx, acc.Changes(), rl))
+	accTrieCollector := etl.NewCollector("IH", t.TempDir(), etl.NewSortableBuffer(etl.BufferOptimalSize), logger)
+	defer accTrieCollector.Close()
+	stTrieCollector := etl.NewCollector("IH", t.TempDir(), etl.NewSortableBuffer(etl.BufferOptimalSize), logger)
+	defer stTrieCollector.Close()
+	root, err := trie.NewFlatDBTrieLoader("IH", rl, accountTrieCollector(accTrieCollector), storageTrieCollector(stTrieCollector), false).CalcTrieRoot(tx, nil)
+	require.NoError(t, err)
+	require.NoError(t, accTrieCollector.Load(tx, kv.TrieOfAccounts, etl.IdentityLoadFunc, etl.TransformArgs{}))
+	require.NoError(t, stTrieCollector.Load(tx, kv.TrieOfStorage, etl.IdentityLoadFunc, etl.TransformArgs{}))
+
+	// the hashed state is the one HashState stage would promote
+	v, err := tx.GetOne(kv.HashedAccounts, hash(destructed[:]).Bytes())
+	require.NoError(t, err)
+	require.Empty(t, v)
+	v, err = tx.GetOne(kv.HashedStorage, dbutils.GenerateCompositeStorageKey(hash(contract[:]), 1, hash(loc2[:])))
+	require.NoError(t, err)
+	require.Empty(t, v)
+	v, err = tx.GetOne(kv.ContractCode, dbutils.GenerateStoragePrefix(hash(created[:]).Bytes(), 1))
+	require.NoError(t, err)
+	require.Equal(t, hash([]byte{0x60, 0x00}).Bytes(), v)
+	require.NoError(t, tx.ForPrefix(kv.TrieOfStorage, hash(destructed[:]).Bytes(), func(k, _ []byte) error {
+		t.Fatalf("storage trie of the destructed account is left: %x", k)
+		return nil
+	}))
+
+	// and the root is the one of the trie regenerated from it
+	regenerated, err := RegenerateIntermediateHashes("IH", tx, cfg, libcommon.Hash{}, ctx, logger)
+	require.NoError(t, err)
+	require.Equal(t, regenerated, root)
+}
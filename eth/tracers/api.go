@@ -17,6 +17,7 @@ type TraceConfig struct {
 	Reexec         *uint64
 	NoRefunds      *bool // Turns off gas refunds when tracing
 	StateOverrides *ethapi.StateOverrides
+	BlockOverrides *ethapi.BlockOverrides // debug_traceCall and trace_call only
 
 	BorTraceEnabled *bool
 	TxIndex         *hexutil.Uint
@@ -23,6 +23,7 @@ import (
 	"math/big"
 
 	"github.com/dop251/goja"
+	"github.com/erigontech/erigon-lib/chain"
 	libcommon "github.com/erigontech/erigon-lib/common"
 	"github.com/erigontech/erigon-lib/common/hexutility"
 	"github.com/holiman/uint256"
@@ -96,6 +97,7 @@ func fromBuf(vm *goja.Runtime, bufType goja.Value, buf goja.Value, allowString b
 type jsTracer struct {
 	vm                *goja.Runtime
 	env               *vm.EVM
+	txCtx             *tracers.Context      // Context of the traced transaction
 	toBig             toBigFn               // Converts a hex string into a JS bigint
 	toBuf             toBufFn               // Converts a []byte into a JS buffer
 	fromBuf           fromBufFn             // Converts an array, hex string or Uint8Array to a []byte
@@ -146,6 +148,7 @@ func newJsTracer(code string, ctx *tracers.Context, cfg json.RawMessage) (tracer
 	if ctx == nil {
 		ctx = new(tracers.Context)
 	}
+	t.txCtx = ctx
 	if ctx.BlockHash != (libcommon.Hash{}) {
 		t.ctx["blockHash"] = vm.ToValue(ctx.BlockHash.Bytes())
 		if ctx.TxHash != (libcommon.Hash{}) {
@@ -220,7 +223,11 @@ func (t *jsTracer) CaptureTxStart(gasLimit uint64) {
 // CaptureTxEnd implements the Tracer interface and is invoked at the end of
 // transaction processing.
 func (t *jsTracer) CaptureTxEnd(restGas uint64) {
-	t.ctx["gasUsed"] = t.vm.ToValue(t.gasLimit - restGas)
+	var rules *chain.Rules
+	if t.env != nil {
+		rules = t.env.ChainRules()
+	}
+	t.ctx["gasUsed"] = t.vm.ToValue(t.txCtx.GasUsed(rules, t.gasLimit, restGas))
 }
 
 // CaptureStart implements the Tracer interface to initialize the tracing operation.
@@ -245,6 +252,23 @@ func (t *jsTracer) CaptureStart(env *vm.EVM, from libcommon.Address, to libcommo
 	}
 	t.ctx["value"] = valueBig
 	t.ctx["block"] = t.vm.ToValue(env.Context.BlockNumber)
+	// OP-stack specifics
+	if t.txCtx.Deposit {
+		t.ctx["isDeposit"] = t.vm.ToValue(true)
+		mint := new(big.Int)
+		if t.txCtx.Mint != nil {
+			mint = t.txCtx.Mint.ToBig()
+		}
+		if t.ctx["mint"], err = t.toBig(t.vm, mint.String()); err != nil {
+			t.err = err
+			return
+		}
+	} else if l1Cost := t.txCtx.L1Cost(env); l1Cost != nil {
+		if t.ctx["l1Fee"], err = t.toBig(t.vm, l1Cost.ToBig().String()); err != nil {
+			t.err = err
+			return
+		}
+	}
 	// Update list of precompiles based on current block
 	rules := env.ChainRules()
 	t.activePrecompiles = vm.ActivePrecompiles(rules)
@@ -24,6 +24,7 @@ import (
 
 	"github.com/holiman/uint256"
 
+	"github.com/erigontech/erigon-lib/chain"
 	libcommon "github.com/erigontech/erigon-lib/common"
 	"github.com/erigontech/erigon-lib/common/hexutil"
 	"github.com/erigontech/erigon-lib/common/hexutility"
@@ -103,6 +104,8 @@ type callFrameMarshaling struct {
 
 type callTracer struct {
 	noopTracer
+	ctx         *tracers.Context
+	rules       *chain.Rules
 	callstack   []callFrame
 	config      callTracerConfig
 	gasLimit    uint64
@@ -136,11 +139,12 @@ func newCallTracer(ctx *tracers.Context, cfg json.RawMessage) (tracers.Tracer, e
 	}
 	// First callframe contains tx context info
 	// and is populated on start and end.
-	return &callTracer{callstack: make([]callFrame, 1), config: config}, nil
+	return &callTracer{ctx: ctx, callstack: make([]callFrame, 1), config: config}, nil
 }
 
 // CaptureStart implements the EVMLogger interface to initialize the tracing operation.
 func (t *callTracer) CaptureStart(env *vm.EVM, from libcommon.Address, to libcommon.Address, precompile bool, create bool, input []byte, gas uint64, value *uint256.Int, code []byte) {
+	t.rules = env.ChainRules()
 	t.precompiles = append(t.precompiles, precompile)
 	if precompile && !t.config.IncludePrecompiles {
 		return
@@ -287,7 +291,7 @@ func (t *callTracer) CaptureTxEnd(restGas uint64) {
 		return
 	}
 
-	t.callstack[0].GasUsed = t.gasLimit - restGas
+	t.callstack[0].GasUsed = t.ctx.GasUsed(t.rules, t.gasLimit, restGas)
 	if t.config.WithLog {
 		// Logs are not emitted when the call fails
 		clearFailedLogs(&t.callstack[0], false, t.logGaps)
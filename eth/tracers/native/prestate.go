@@ -58,6 +58,7 @@ type accountMarshaling struct {
 
 type prestateTracer struct {
 	noopTracer
+	ctx       *tracers.Context
 	env       *vm.EVM
 	pre       state
 	post      state
@@ -83,6 +84,7 @@ func newPrestateTracer(ctx *tracers.Context, cfg json.RawMessage) (tracers.Trace
 		}
 	}
 	return &prestateTracer{
+		ctx:     ctx,
 		pre:     state{},
 		post:    state{},
 		config:  config,
@@ -106,6 +108,15 @@ func (t *prestateTracer) CaptureStart(env *vm.EVM, from libcommon.Address, to li
 	consumedGas := new(big.Int).Mul(env.GasPrice.ToBig(), new(big.Int).SetUint64(t.gasLimit))
 	fromBal := t.pre[from].Balance
 	fromBal.Add(fromBal, consumedGas)
+	if t.ctx != nil && t.ctx.Deposit {
+		// Deposit doesn't buy gas, but mints to the sender before execution
+		if t.ctx.Mint != nil {
+			fromBal.Sub(fromBal, t.ctx.Mint.ToBig())
+		}
+	} else if l1Cost := t.ctx.L1Cost(env); l1Cost != nil {
+		// L1 data fee is charged from the sender together with gas
+		fromBal.Add(fromBal, l1Cost.ToBig())
+	}
 
 	if !create {
 		valueBig := value.ToBig()
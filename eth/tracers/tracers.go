@@ -20,8 +20,15 @@ package tracers
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"sort"
+	"sync"
 
+	"github.com/holiman/uint256"
+
+	"github.com/erigontech/erigon-lib/chain"
 	libcommon "github.com/erigontech/erigon-lib/common"
+	types2 "github.com/erigontech/erigon-lib/types"
 
 	"github.com/erigontech/erigon/core/vm"
 )
@@ -32,6 +39,51 @@ type Context struct {
 	BlockHash libcommon.Hash // Hash of the block the tx is contained within (zero if dangling tx or call)
 	TxIndex   int            // Index of the transaction within a block (zero if dangling tx or call)
 	TxHash    libcommon.Hash // Hash of the transaction being traced (zero if dangling call)
+
+	// OP-stack specifics of the transaction, zero values for other chains
+	Deposit        bool                  // Deposit transaction: gas is neither bought nor refunded, Mint is credited to sender before execution
+	SystemTx       bool                  // Deposit system transaction: uses no gas before Regolith
+	Mint           *uint256.Int          // ETH minted to sender by deposit transaction
+	RollupCostData types2.RollupCostData // Used to compute L1 data fee charged from sender of regular transaction
+}
+
+// OptimismMessage is the subset of core.Message describing OP-stack specifics of the transaction
+type OptimismMessage interface {
+	IsDepositTx() bool
+	IsSystemTx() bool
+	Mint() *uint256.Int
+	RollupCostData() types2.RollupCostData
+}
+
+// ContextForMessage returns context of the transaction being traced, including OP-stack specifics of msg
+func ContextForMessage(txHash libcommon.Hash, msg OptimismMessage) *Context {
+	return &Context{
+		TxHash:         txHash,
+		Deposit:        msg.IsDepositTx(),
+		SystemTx:       msg.IsSystemTx(),
+		Mint:           msg.Mint(),
+		RollupCostData: msg.RollupCostData(),
+	}
+}
+
+// L1Cost returns L1 data fee charged from sender together with gas, nil for deposits and other chains
+func (c *Context) L1Cost(env *vm.EVM) *uint256.Int {
+	if c == nil || c.Deposit || env.Context.L1CostFunc == nil {
+		return nil
+	}
+	return env.Context.L1CostFunc(c.RollupCostData, env.Context.Time)
+}
+
+// GasUsed returns gas used by the transaction as recorded in receipt: before Regolith deposits
+// use all gas they have (system ones - none), regardless of execution and refunds
+func (c *Context) GasUsed(rules *chain.Rules, gasLimit, restGas uint64) uint64 {
+	if c != nil && c.Deposit && rules != nil && !rules.IsOptimismRegolith {
+		if c.SystemTx {
+			return 0
+		}
+		return gasLimit
+	}
+	return gasLimit - restGas
 }
 
 // Tracer interface extends vm.EVMLogger and additionally
@@ -61,6 +113,53 @@ func RegisterLookup(wildcard bool, lookup lookupFunc) {
 	}
 }
 
+// Constructor creates a tracer instance, cfg is the tracer-specific part of debug_trace* config
+type Constructor = func(ctx *Context, cfg json.RawMessage) (Tracer, error)
+
+var (
+	registryLock sync.RWMutex
+	registry     = map[string]Constructor{}
+)
+
+func init() {
+	RegisterLookup(false, lookupRegistered)
+}
+
+// Register makes a Go tracer available by name to debug_trace* methods. It's meant to be called
+// from init function of the package implementing the tracer - built-in tracers of the native package
+// use it, and so can external plugins: such package gets compiled in by blank import next to
+// native and js packages in cmd/rpcdaemon/cli/config.go. Panics if the name is already taken.
+func Register(name string, ctor Constructor) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	if _, ok := registry[name]; ok {
+		panic(fmt.Sprintf("tracer %q is already registered", name))
+	}
+	registry[name] = ctor
+}
+
+// Registered returns sorted names of tracers registered with Register
+func Registered() []string {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func lookupRegistered(name string, ctx *Context, cfg json.RawMessage) (Tracer, error) {
+	registryLock.RLock()
+	ctor, ok := registry[name]
+	registryLock.RUnlock()
+	if !ok {
+		return nil, errors.New("no tracer found")
+	}
+	return ctor(ctx, cfg)
+}
+
 // New returns a new instance of a tracer, by iterating through the
 // registered lookups.
 func New(code string, ctx *Context, cfg json.RawMessage) (Tracer, error) {
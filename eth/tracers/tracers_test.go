@@ -26,6 +26,7 @@ import (
 	"github.com/erigontech/erigon-lib/common/hexutil"
 	"github.com/stretchr/testify/require"
 
+	"github.com/erigontech/erigon-lib/chain"
 	libcommon "github.com/erigontech/erigon-lib/common"
 	"github.com/erigontech/erigon/consensus"
 	"github.com/erigontech/erigon/core"
@@ -134,3 +135,41 @@ func TestPrestateTracerCreate2(t *testing.T) {
 		t.Fatalf("Expected 0x60f3f640a8508fc6a86d45df051962668e1e8ac7 in result")
 	}
 }
+
+type testPluginTracer struct {
+	tracers.Tracer
+	txHash libcommon.Hash
+}
+
+func (t *testPluginTracer) GetResult() (json.RawMessage, error) {
+	return json.Marshal(t.txHash)
+}
+
+func TestRegister(t *testing.T) {
+	tracers.Register("testPluginTracer", func(ctx *tracers.Context, _ json.RawMessage) (tracers.Tracer, error) {
+		return &testPluginTracer{txHash: ctx.TxHash}, nil
+	})
+	require.Contains(t, tracers.Registered(), "testPluginTracer")
+	require.Contains(t, tracers.Registered(), "callTracer")
+	require.Panics(t, func() {
+		tracers.Register("testPluginTracer", nil)
+	})
+
+	hash := libcommon.HexToHash("0x01")
+	tracer, err := tracers.New("testPluginTracer", &tracers.Context{TxHash: hash}, nil)
+	require.NoError(t, err)
+	res, err := tracer.GetResult()
+	require.NoError(t, err)
+	require.Equal(t, `"`+hash.Hex()+`"`, string(res))
+}
+
+func TestContextGasUsed(t *testing.T) {
+	bedrock, regolith := &chain.Rules{IsOptimismBedrock: true}, &chain.Rules{IsOptimismBedrock: true, IsOptimismRegolith: true}
+	deposit, systemTx := &tracers.Context{Deposit: true}, &tracers.Context{Deposit: true, SystemTx: true}
+
+	require.Equal(t, uint64(60), (*tracers.Context)(nil).GasUsed(bedrock, 100, 40))
+	require.Equal(t, uint64(60), new(tracers.Context).GasUsed(bedrock, 100, 40))
+	require.Equal(t, uint64(100), deposit.GasUsed(bedrock, 100, 40))
+	require.Equal(t, uint64(0), systemTx.GasUsed(bedrock, 100, 40))
+	require.Equal(t, uint64(60), deposit.GasUsed(regolith, 100, 40))
+}
@@ -24,6 +24,10 @@ var DefaultMode = Mode{
 }
 
 type Experiments struct {
+	// ReceiptsIndexOnly - receipts aren't stored, logs are kept only until LogIndex has indexed them and they are
+	// out of the unwind range. eth_getLogs finds the blocks by the index and re-executes them for the logs.
+	// With KeepDeposits the receipts and logs of the blocks with bridge deposits are stored anyway.
+	ReceiptsIndexOnly bool
 }
 
 func FromCli(chainId uint64, flags string, exactHistory, exactReceipts, exactTxIndex, exactCallTraces,
@@ -77,10 +81,16 @@ func FromCli(chainId uint64, flags string, exactHistory, exactReceipts, exactTxI
 		switch ex {
 		case "":
 			// skip
+		case "receipts.index-only":
+			mode.Experiments.ReceiptsIndexOnly = true
 		default:
 			return DefaultMode, fmt.Errorf("unexpected experiment found: %s", ex)
 		}
 	}
+	// pruning of the log index needs the logs to find the index entries
+	if mode.Experiments.ReceiptsIndexOnly && mode.Receipts.Enabled() {
+		return DefaultMode, errors.New("experiment receipts.index-only is incompatible with pruning of receipts")
+	}
 	return mode, nil
 }
 
@@ -120,6 +130,16 @@ func Get(db kv.Getter) (Mode, error) {
 		prune.CallTraces = blockAmount
 	}
 
+	prune.Experiments.ReceiptsIndexOnly, err = getMode(db, kv.StorageModeReceiptsIndexOnly)
+	if err != nil {
+		return prune, err
+	}
+
+	prune.KeepDeposits, err = getMode(db, kv.StorageModeKeepDeposits)
+	if err != nil {
+		return prune, err
+	}
+
 	return prune, nil
 }
 
@@ -130,6 +150,9 @@ type Mode struct {
 	TxIndex     BlockAmount
 	CallTraces  BlockAmount
 	Experiments Experiments
+	// KeepDeposits - the receipts, logs and call traces of the blocks with OP deposit txs (L1 attributes, bridge
+	// deposits) are not pruned, so deposits can still be traced on a pruned node
+	KeepDeposits bool
 }
 
 type BlockAmount interface {
@@ -215,6 +238,12 @@ func (m Mode) String() string {
 			long += fmt.Sprintf(" --prune.c.%s=%d", m.CallTraces.dbType(), m.CallTraces.toValue())
 		}
 	}
+	if m.Experiments.ReceiptsIndexOnly {
+		long += " --experiments=receipts.index-only"
+	}
+	if m.KeepDeposits {
+		long += " --prune.keep-deposits"
+	}
 
 	return strings.TrimLeft(short+long, " ")
 }
@@ -244,6 +273,16 @@ func Override(db kv.RwTx, sm Mode) error {
 		return err
 	}
 
+	err = setMode(db, kv.StorageModeReceiptsIndexOnly, sm.Experiments.ReceiptsIndexOnly)
+	if err != nil {
+		return err
+	}
+
+	err = setMode(db, kv.StorageModeKeepDeposits, sm.KeepDeposits)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -299,6 +338,16 @@ func setIfNotExist(db kv.GetPut, pm Mode) error {
 		}
 	}
 
+	err = setModeOnEmpty(db, kv.StorageModeReceiptsIndexOnly, pm.Experiments.ReceiptsIndexOnly)
+	if err != nil {
+		return err
+	}
+
+	err = setModeOnEmpty(db, kv.StorageModeKeepDeposits, pm.KeepDeposits)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -378,6 +427,14 @@ func setOnEmpty(db kv.GetPut, key []byte, blockAmount BlockAmount) error {
 	return nil
 }
 
+func getMode(db kv.Getter, key []byte) (bool, error) {
+	mode, err := db.GetOne(kv.DatabaseInfo, key)
+	if err != nil {
+		return false, err
+	}
+	return len(mode) == 1 && mode[0] == 1, nil
+}
+
 func setMode(db kv.RwTx, key []byte, currentValue bool) error {
 	val := []byte{2}
 	if currentValue {
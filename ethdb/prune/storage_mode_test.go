@@ -15,16 +15,49 @@ func TestSetStorageModeIfNotExist(t *testing.T) {
 	prune, err := Get(tx)
 	assert.NoError(t, err)
 	assert.Equal(t, Mode{true, Distance(math.MaxUint64), Distance(math.MaxUint64),
-		Distance(math.MaxUint64), Distance(math.MaxUint64), Experiments{}}, prune)
+		Distance(math.MaxUint64), Distance(math.MaxUint64), Experiments{}, false}, prune)
 
 	err = setIfNotExist(tx, Mode{true, Distance(1), Distance(2),
-		Before(3), Before(4), Experiments{}})
+		Before(3), Before(4), Experiments{}, false})
 	assert.NoError(t, err)
 
 	prune, err = Get(tx)
 	assert.NoError(t, err)
 	assert.Equal(t, Mode{true, Distance(1), Distance(2),
-		Before(3), Before(4), Experiments{}}, prune)
+		Before(3), Before(4), Experiments{}, false}, prune)
+}
+
+func TestReceiptsIndexOnly(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	mode, err := FromCli(1, "default", 0, 0, 0, 0, 0, 0, 0, 0, []string{"receipts.index-only"})
+	assert.NoError(t, err)
+	assert.True(t, mode.Experiments.ReceiptsIndexOnly)
+
+	pm, err := EnsureNotChanged(tx, mode)
+	assert.NoError(t, err)
+	assert.Equal(t, mode, pm)
+
+	_, err = EnsureNotChanged(tx, DefaultMode)
+	assert.Error(t, err)
+
+	_, err = FromCli(1, "r", 0, 0, 0, 0, 0, 0, 0, 0, []string{"receipts.index-only"})
+	assert.Error(t, err)
+}
+
+func TestKeepDeposits(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	mode, err := FromCli(1, "rc", 0, 0, 0, 0, 0, 0, 0, 0, nil)
+	assert.NoError(t, err)
+	mode.KeepDeposits = true
+	assert.Contains(t, mode.String(), "--prune.keep-deposits")
+
+	pm, err := EnsureNotChanged(tx, mode)
+	assert.NoError(t, err)
+	assert.Equal(t, mode, pm)
+
+	mode.KeepDeposits = false
+	_, err = EnsureNotChanged(tx, mode)
+	assert.Error(t, err)
 }
 
 var distanceTests = []struct {
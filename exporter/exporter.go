@@ -0,0 +1,393 @@
+// Package exporter implements the service publishing canonical chain events
+// (block headers with receipts and reorg markers) to external message brokers.
+package exporter
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/erigontech/erigon-lib/chain"
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/length"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon-lib/metrics"
+
+	"github.com/erigontech/erigon/core/rawdb"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/eth/stagedsync/stages"
+	"github.com/erigontech/erigon/node"
+	"github.com/erigontech/erigon/turbo/services"
+)
+
+const (
+	// batchSize is the max number of blocks published before resume offset is committed
+	batchSize = 100
+	// pollInterval - how often exporter checks chain head if there are no new header notifications
+	pollInterval = 5 * time.Second
+	// retryInterval - how long exporter waits after failed publishing
+	retryInterval = 10 * time.Second
+)
+
+var (
+	exportOffsetGauge    = metrics.GetOrCreateGauge("exporter_offset")
+	exportReorgsCounter  = metrics.GetOrCreateCounter("exporter_reorgs")
+	exportErrorsCounter  = metrics.GetOrCreateCounter("exporter_errors")
+	exportSkippedCounter = metrics.GetOrCreateCounter("exporter_skipped")
+)
+
+// Event types
+const (
+	EventBlock = "block"
+	EventReorg = "reorg"
+	// EventSkipped replaces the event which the broker refused for good (e.g. the block with receipts is larger
+	// than max message size): consumers must fetch the block by RPC
+	EventSkipped = "skipped"
+)
+
+// Event is a message published to the broker, JSON encoded.
+// Delivery is at-least-once: after restart or failed publishing the same events can be published again,
+// consumers must be idempotent by (Type, Number, Hash).
+type Event struct {
+	Type   string         `json:"type"`
+	Number uint64         `json:"number"`
+	Hash   libcommon.Hash `json:"hash"`
+	// Token - resume token of the event: the exporter restarted by --export.resume with it publishes the events
+	// after this one, see FormatToken
+	Token string `json:"token"`
+	// EventBlock only
+	Header   *types.Header  `json:"header,omitempty"`
+	Receipts types.Receipts `json:"receipts,omitempty"`
+	// EventReorg only: Number and Hash are common ancestor - consumers must drop everything above it,
+	// RevertedNumber and RevertedHash are the last exported block of the abandoned chain
+	RevertedNumber uint64         `json:"revertedNumber,omitempty"`
+	RevertedHash   libcommon.Hash `json:"revertedHash,omitempty"`
+	// EventSkipped only: Type of the skipped event and the error of the broker
+	SkippedType string `json:"skippedType,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+type blockID struct {
+	Number uint64
+	Hash   libcommon.Hash
+}
+
+// Service publishes canonical chain events starting from the resume offset stored in kv.ExportOffsets.
+type Service struct {
+	db          kv.RwDB
+	blockReader services.FullBlockReader
+	chainConfig *chain.Config
+	publisher   Publisher
+	topic       string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+	headCh <-chan [][]byte
+	logger log.Logger
+}
+
+// New returns an exporter service publishing to the given url (nats:// or http(s):// of Kafka REST proxy).
+// Non-empty resume token (see Event.Token) replaces the resume offset of the topic, so the events after it are
+// published again.
+func New(ctx context.Context, node *node.Node, db kv.RwDB, blockReader services.FullBlockReader, chainConfig *chain.Config,
+	url, topic, resume string, headCh <-chan [][]byte, logger log.Logger) error {
+	publisher, err := NewPublisher(url, topic)
+	if err != nil {
+		return err
+	}
+	if resume != "" {
+		blockNum, blockHash, err := ParseToken(resume)
+		if err != nil {
+			return err
+		}
+		if err := db.Update(ctx, func(tx kv.RwTx) error {
+			return WriteOffset(tx, topic, blockNum, blockHash)
+		}); err != nil {
+			return err
+		}
+		logger.Info("[exporter] resuming", "topic", topic, "after", blockNum, "hash", blockHash)
+	}
+	node.RegisterLifecycle(&Service{
+		db:          db,
+		blockReader: blockReader,
+		chainConfig: chainConfig,
+		publisher:   publisher,
+		topic:       topic,
+		ctx:         ctx,
+		headCh:      headCh,
+		logger:      logger,
+	})
+	return nil
+}
+
+// Start implements node.Lifecycle, starting up the exporter.
+func (s *Service) Start() error {
+	var ctx context.Context
+	ctx, s.cancel = context.WithCancel(s.ctx)
+	s.done = make(chan struct{})
+	go s.loop(ctx)
+	s.logger.Info("[exporter] started", "topic", s.topic)
+	return nil
+}
+
+// Stop implements node.Lifecycle, terminating the exporter.
+func (s *Service) Stop() error {
+	if s.cancel != nil {
+		s.cancel()
+		<-s.done
+	}
+	s.logger.Info("[exporter] stopped", "topic", s.topic)
+	return s.publisher.Close()
+}
+
+func (s *Service) loop(ctx context.Context) {
+	defer close(s.done)
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		for {
+			more, err := s.step(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				exportErrorsCounter.Inc()
+				s.logger.Warn("[exporter] publishing failed, will retry", "topic", s.topic, "err", err)
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(retryInterval):
+				}
+				continue
+			}
+			if !more {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-s.headCh:
+			if !ok {
+				return
+			}
+		case <-ticker.C:
+		}
+	}
+}
+
+// step publishes next batch of events and commits resume offset. Returns true if head is not reached yet.
+func (s *Service) step(ctx context.Context) (more bool, err error) {
+	var events []*Event
+	var offset blockID
+	if err := s.db.View(ctx, func(tx kv.Tx) error {
+		head, err := stages.GetStageProgress(tx, stages.Finish)
+		if err != nil {
+			return err
+		}
+		prevNum, prevHash, ok, err := ReadOffset(tx, s.topic)
+		if err != nil {
+			return err
+		}
+		prev := blockID{Number: prevNum, Hash: prevHash}
+		if !ok {
+			// first start - export from current head, older blocks can be fetched by RPC
+			if head == 0 {
+				return nil
+			}
+			prev.Number = head - 1
+			if prev.Hash, err = s.blockReader.CanonicalHash(ctx, tx, prev.Number); err != nil {
+				return err
+			}
+		}
+
+		canonical, err := s.blockReader.CanonicalHash(ctx, tx, prev.Number)
+		if err != nil {
+			return err
+		}
+		if canonical != prev.Hash {
+			ancestor, err := s.commonAncestor(ctx, tx, prev)
+			if err != nil {
+				return err
+			}
+			events, offset = append(events, &Event{Type: EventReorg, Number: ancestor.Number, Hash: ancestor.Hash,
+				Token: FormatToken(ancestor.Number, ancestor.Hash), RevertedNumber: prev.Number, RevertedHash: prev.Hash}), ancestor
+			more = true
+			return nil
+		}
+
+		to := min(head, prev.Number+batchSize)
+		offset = prev
+		for blockNum := prev.Number + 1; blockNum <= to; blockNum++ {
+			event, err := s.blockEvent(ctx, tx, blockNum)
+			if err != nil {
+				return err
+			}
+			events, offset = append(events, event), blockID{Number: event.Number, Hash: event.Hash}
+		}
+		more = to < head
+		return nil
+	}); err != nil {
+		return false, err
+	}
+	if len(events) == 0 {
+		return false, nil
+	}
+
+	msgs := make([]Message, len(events))
+	for i, event := range events {
+		value, err := json.Marshal(event)
+		if err != nil {
+			return false, err
+		}
+		msgs[i] = Message{Key: []byte(strconv.FormatUint(event.Number, 10)), Value: value}
+	}
+	if err := s.publisher.Publish(ctx, msgs); err != nil {
+		if !IsPermanent(err) {
+			return false, err
+		}
+		if err := s.publishEach(ctx, events, msgs); err != nil {
+			return false, err
+		}
+	}
+	// offset is committed only after broker accepted events - at-least-once delivery
+	if err := s.db.Update(ctx, func(tx kv.RwTx) error {
+		return WriteOffset(tx, s.topic, offset.Number, offset.Hash)
+	}); err != nil {
+		return false, err
+	}
+	for _, event := range events {
+		if event.Type == EventReorg {
+			exportReorgsCounter.Inc()
+			s.logger.Info("[exporter] reorg published", "topic", s.topic, "reverted", event.RevertedNumber, "ancestor", event.Number)
+		}
+	}
+	exportOffsetGauge.SetUint64(offset.Number)
+	s.logger.Debug("[exporter] published", "topic", s.topic, "events", len(events), "offset", offset.Number)
+	return more, nil
+}
+
+// publishEach publishes the events of the batch which the broker refused for good one by one, the refused ones are
+// replaced by EventSkipped: a bad event must not stop the exporter
+func (s *Service) publishEach(ctx context.Context, events []*Event, msgs []Message) error {
+	for i, event := range events {
+		refused := s.publisher.Publish(ctx, msgs[i:i+1])
+		if refused == nil {
+			continue
+		}
+		if !IsPermanent(refused) {
+			return refused
+		}
+		exportSkippedCounter.Inc()
+		s.logger.Error("[exporter] event refused by broker, skipped", "topic", s.topic, "type", event.Type, "number", event.Number, "hash", event.Hash, "err", refused)
+		value, err := json.Marshal(&Event{Type: EventSkipped, Number: event.Number, Hash: event.Hash, Token: event.Token,
+			SkippedType: event.Type, Error: refused.Error()})
+		if err != nil {
+			return err
+		}
+		if err := s.publisher.Publish(ctx, []Message{{Key: msgs[i].Key, Value: value}}); err != nil && !IsPermanent(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) blockEvent(ctx context.Context, tx kv.Tx, blockNum uint64) (*Event, error) {
+	hash, err := s.blockReader.CanonicalHash(ctx, tx, blockNum)
+	if err != nil {
+		return nil, err
+	}
+	block, senders, err := s.blockReader.BlockWithSenders(ctx, tx, hash, blockNum)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("canonical block %d %x not found", blockNum, hash)
+	}
+	// the receipts which `erigon snapshots retire` moved to the snapshots are read there
+	var raw types.Receipts
+	if r, ok := s.blockReader.(services.ReceiptsReader); ok {
+		if raw, err = r.RawReceipts(ctx, tx, blockNum); err != nil {
+			return nil, err
+		}
+	} else {
+		raw = rawdb.ReadRawReceipts(tx, blockNum)
+	}
+	return &Event{
+		Type:     EventBlock,
+		Number:   blockNum,
+		Hash:     hash,
+		Token:    FormatToken(blockNum, hash),
+		Header:   block.Header(),
+		Receipts: rawdb.DeriveReceipts(s.chainConfig, block, senders, raw), // nil if receipts are pruned
+	}, nil
+}
+
+// commonAncestor walks back the chain of the last exported block until it meets canonical chain
+func (s *Service) commonAncestor(ctx context.Context, tx kv.Tx, from blockID) (blockID, error) {
+	id := from
+	for id.Number > 0 {
+		header, err := s.blockReader.Header(ctx, tx, id.Hash, id.Number)
+		if err != nil {
+			return id, err
+		}
+		if header == nil {
+			return id, fmt.Errorf("header %d %x of exported chain not found, can't find common ancestor with canonical chain", id.Number, id.Hash)
+		}
+		id = blockID{Number: id.Number - 1, Hash: header.ParentHash}
+		canonical, err := s.blockReader.CanonicalHash(ctx, tx, id.Number)
+		if err != nil {
+			return id, err
+		}
+		if canonical == id.Hash {
+			return id, nil
+		}
+	}
+	return id, nil
+}
+
+// ReadOffset returns the last block published to the topic
+func ReadOffset(tx kv.Getter, topic string) (blockNum uint64, blockHash libcommon.Hash, ok bool, err error) {
+	v, err := tx.GetOne(kv.ExportOffsets, []byte(topic))
+	if err != nil {
+		return 0, blockHash, false, err
+	}
+	if len(v) != 8+length.Hash {
+		return 0, blockHash, false, nil
+	}
+	return binary.BigEndian.Uint64(v), libcommon.BytesToHash(v[8:]), true, nil
+}
+
+// WriteOffset stores the last block published to the topic
+func WriteOffset(tx kv.Putter, topic string, blockNum uint64, blockHash libcommon.Hash) error {
+	v := make([]byte, 8+length.Hash)
+	binary.BigEndian.PutUint64(v, blockNum)
+	copy(v[8:], blockHash[:])
+	return tx.Put(kv.ExportOffsets, []byte(topic), v)
+}
+
+// FormatToken - resume token of the block: its number and hash
+func FormatToken(blockNum uint64, blockHash libcommon.Hash) string {
+	return strconv.FormatUint(blockNum, 10) + ":" + blockHash.Hex()
+}
+
+// ParseToken - the block of the resume token, see FormatToken
+func ParseToken(token string) (blockNum uint64, blockHash libcommon.Hash, err error) {
+	num, hash, ok := strings.Cut(token, ":")
+	if !ok {
+		return 0, blockHash, fmt.Errorf("invalid resume token %q, expected number:hash", token)
+	}
+	if blockNum, err = strconv.ParseUint(num, 10, 64); err != nil {
+		return 0, blockHash, fmt.Errorf("invalid resume token %q: %w", token, err)
+	}
+	if err = blockHash.UnmarshalText([]byte(hash)); err != nil {
+		return 0, blockHash, fmt.Errorf("invalid resume token %q: %w", token, err)
+	}
+	return blockNum, blockHash, nil
+}
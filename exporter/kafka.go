@@ -0,0 +1,106 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	kafkaRestContentType = "application/vnd.kafka.binary.v2+json"
+	// kafkaRestNonRetriable - error_code of the record refused by Kafka for good, 2 is retriable error
+	kafkaRestNonRetriable = 1
+)
+
+// kafkaRestPublisher produces records through Kafka REST proxy (Confluent REST API v2).
+// Proxy responds only after records are acknowledged by Kafka brokers.
+type kafkaRestPublisher struct {
+	endpoint string
+	client   *http.Client
+}
+
+type kafkaRestRecord struct {
+	Key   []byte `json:"key"`   // base64 encoded by encoding/json, as binary embedded format requires
+	Value []byte `json:"value"` // base64 encoded by encoding/json, as binary embedded format requires
+}
+
+type kafkaRestResponse struct {
+	Offsets []struct {
+		Partition int    `json:"partition"`
+		Offset    int64  `json:"offset"`
+		ErrorCode *int   `json:"error_code"`
+		Error     string `json:"error"`
+	} `json:"offsets"`
+}
+
+func newKafkaRestPublisher(u *url.URL, topic string) *kafkaRestPublisher {
+	return &kafkaRestPublisher{
+		endpoint: u.JoinPath("topics", topic).String(),
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (p *kafkaRestPublisher) Publish(ctx context.Context, msgs []Message) error {
+	records := make([]kafkaRestRecord, len(msgs))
+	for i, msg := range msgs {
+		records[i] = kafkaRestRecord{Key: msg.Key, Value: msg.Value}
+	}
+	body, err := json.Marshal(struct {
+		Records []kafkaRestRecord `json:"records"`
+	}{Records: records})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", kafkaRestContentType)
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("kafka rest proxy: %s: %s", resp.Status, respBody)
+		// the records themselves are refused, not the topic or credentials
+		if resp.StatusCode == http.StatusRequestEntityTooLarge || resp.StatusCode == http.StatusUnprocessableEntity {
+			return Permanent(err)
+		}
+		return err
+	}
+
+	var res kafkaRestResponse
+	if err := json.Unmarshal(respBody, &res); err != nil {
+		return fmt.Errorf("kafka rest proxy: invalid response: %w", err)
+	}
+	if len(res.Offsets) != len(msgs) {
+		return fmt.Errorf("kafka rest proxy: %d records acknowledged, expected %d", len(res.Offsets), len(msgs))
+	}
+	for i, offset := range res.Offsets {
+		if offset.ErrorCode != nil {
+			err := fmt.Errorf("kafka rest proxy: record %d: error %d: %s", i, *offset.ErrorCode, offset.Error)
+			if *offset.ErrorCode == kafkaRestNonRetriable {
+				return Permanent(err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *kafkaRestPublisher) Close() error {
+	p.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,170 @@
+package exporter
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// natsMaxPayload - default max_payload of NATS server, used if server INFO doesn't tell it
+const natsMaxPayload = 1024 * 1024
+
+// natsPublisher is a minimal client of NATS core protocol: CONNECT, PUB and PING/PONG.
+// Every batch ends with PING, so it's acknowledged when server answers PONG - all PUBs before it are processed.
+// Connection is established lazily and dropped on any error.
+type natsPublisher struct {
+	addr    string
+	user    *url.Userinfo
+	subject string
+	dial    func(ctx context.Context, network, addr string) (net.Conn, error)
+
+	lock       sync.Mutex
+	conn       net.Conn
+	r          *bufio.Reader
+	maxPayload int
+}
+
+func newNatsPublisher(u *url.URL, subject string) *natsPublisher {
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	return &natsPublisher{addr: addr, user: u.User, subject: subject, dial: (&net.Dialer{}).DialContext}
+}
+
+func (p *natsPublisher) connect(ctx context.Context) error {
+	conn, err := p.dial(ctx, "tcp", p.addr)
+	if err != nil {
+		return err
+	}
+	_ = conn.SetDeadline(time.Now().Add(30 * time.Second))
+	r := bufio.NewReader(conn)
+
+	line, err := r.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	var info struct {
+		MaxPayload int `json:"max_payload"`
+	}
+	infoJson, ok := strings.CutPrefix(strings.TrimSpace(line), "INFO ")
+	if !ok {
+		conn.Close()
+		return fmt.Errorf("nats: unexpected greeting %q", line)
+	}
+	if err := json.Unmarshal([]byte(infoJson), &info); err != nil {
+		conn.Close()
+		return fmt.Errorf("nats: invalid INFO: %w", err)
+	}
+
+	opts := map[string]any{"verbose": false, "pedantic": false, "name": "erigon-exporter", "lang": "go"}
+	if p.user != nil {
+		opts["user"] = p.user.Username()
+		if pass, ok := p.user.Password(); ok {
+			opts["pass"] = pass
+		}
+	}
+	connect, err := json.Marshal(opts)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	if _, err := fmt.Fprintf(conn, "CONNECT %s\r\n", connect); err != nil {
+		conn.Close()
+		return err
+	}
+
+	p.conn, p.r, p.maxPayload = conn, r, info.MaxPayload
+	if p.maxPayload <= 0 {
+		p.maxPayload = natsMaxPayload
+	}
+	// PING after CONNECT - to find out about auth errors
+	if err := p.flush(); err != nil {
+		p.closeConn()
+		return err
+	}
+	return nil
+}
+
+// flush sends PING and waits for PONG, answering server PINGs
+func (p *natsPublisher) flush() error {
+	if _, err := p.conn.Write([]byte("PING\r\n")); err != nil {
+		return err
+	}
+	for {
+		line, err := p.r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := p.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("nats: %s", line)
+		default: // +OK, INFO
+		}
+	}
+}
+
+func (p *natsPublisher) Publish(ctx context.Context, msgs []Message) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.conn == nil {
+		if err := p.connect(ctx); err != nil {
+			return err
+		}
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = p.conn.SetDeadline(deadline)
+	} else {
+		_ = p.conn.SetDeadline(time.Now().Add(30 * time.Second))
+	}
+
+	// sizes are checked before anything is written: the batch refused midway would be half published
+	for _, msg := range msgs {
+		if len(msg.Value) > p.maxPayload {
+			return Permanent(fmt.Errorf("nats: message %s of %d bytes exceeds server max_payload %d", msg.Key, len(msg.Value), p.maxPayload))
+		}
+	}
+	w := bufio.NewWriter(p.conn)
+	for _, msg := range msgs {
+		fmt.Fprintf(w, "PUB %s %d\r\n", p.subject, len(msg.Value))
+		w.Write(msg.Value)
+		w.WriteString("\r\n")
+	}
+	if err := w.Flush(); err != nil {
+		p.closeConn()
+		return err
+	}
+	if err := p.flush(); err != nil {
+		p.closeConn()
+		return err
+	}
+	return nil
+}
+
+func (p *natsPublisher) closeConn() {
+	if p.conn != nil {
+		p.conn.Close()
+		p.conn, p.r = nil, nil
+	}
+}
+
+func (p *natsPublisher) Close() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.closeConn()
+	return nil
+}
@@ -0,0 +1,57 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// Message is a keyed record of the topic
+type Message struct {
+	Key   []byte
+	Value []byte
+}
+
+// Publisher delivers messages to a broker. Publish returns nil only when broker accepted all messages.
+// The error of a message which the broker refuses for good (too large, invalid) is marked by Permanent,
+// other errors (broker is down, misconfigured) are retried.
+type Publisher interface {
+	Publish(ctx context.Context, msgs []Message) error
+	Close() error
+}
+
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks the error of a message which publishing again can't fix
+func Permanent(err error) error { return &permanentError{err: err} }
+
+// IsPermanent - the error is marked by Permanent
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+// NewPublisher returns a publisher for the url scheme:
+//   - nats://[user:pass@]host:port - NATS server, topic is used as subject
+//   - http(s)://host:port - Kafka REST proxy (v2 API), topic is used as Kafka topic
+func NewPublisher(rawURL, topic string) (Publisher, error) {
+	if topic == "" {
+		return nil, fmt.Errorf("export topic is not set")
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid export url: %w", err)
+	}
+	switch u.Scheme {
+	case "nats":
+		return newNatsPublisher(u, topic), nil
+	case "http", "https":
+		return newKafkaRestPublisher(u, topic), nil
+	default:
+		return nil, fmt.Errorf("unsupported export url scheme %q, expected nats, http or https", u.Scheme)
+	}
+}
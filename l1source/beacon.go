@@ -0,0 +1,133 @@
+package l1source
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	gokzg4844 "github.com/crate-crypto/go-kzg-4844"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	libkzg "github.com/erigontech/erigon-lib/crypto/kzg"
+
+	"github.com/erigontech/erigon/core/types"
+)
+
+// beaconClient reads blob sidecars from L1 beacon API. Slot of L1 block is derived from its timestamp,
+// genesis time and slot duration are requested once.
+type beaconClient struct {
+	url    string
+	client *http.Client
+
+	lock           sync.Mutex
+	genesisTime    uint64
+	secondsPerSlot uint64
+}
+
+func newBeaconClient(url string) *beaconClient {
+	return &beaconClient{
+		url:    strings.TrimSuffix(url, "/"),
+		client: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+func (c *beaconClient) get(ctx context.Context, path string, result any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%s: %w", path, ErrNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", path, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+func (c *beaconClient) slot(ctx context.Context, time uint64) (uint64, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.secondsPerSlot == 0 {
+		var genesis struct {
+			Data struct {
+				GenesisTime string `json:"genesis_time"`
+			} `json:"data"`
+		}
+		if err := c.get(ctx, "/eth/v1/beacon/genesis", &genesis); err != nil {
+			return 0, err
+		}
+		var spec struct {
+			Data struct {
+				SecondsPerSlot string `json:"SECONDS_PER_SLOT"`
+			} `json:"data"`
+		}
+		if err := c.get(ctx, "/eth/v1/config/spec", &spec); err != nil {
+			return 0, err
+		}
+		genesisTime, err := strconv.ParseUint(genesis.Data.GenesisTime, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("genesis time: %w", err)
+		}
+		secondsPerSlot, err := strconv.ParseUint(spec.Data.SecondsPerSlot, 10, 64)
+		if err != nil || secondsPerSlot == 0 {
+			return 0, fmt.Errorf("seconds per slot %q: %w", spec.Data.SecondsPerSlot, err)
+		}
+		c.genesisTime, c.secondsPerSlot = genesisTime, secondsPerSlot
+	}
+	if time < c.genesisTime {
+		return 0, fmt.Errorf("block time %d is before beacon genesis %d", time, c.genesisTime)
+	}
+	return (time - c.genesisTime) / c.secondsPerSlot, nil
+}
+
+type blobSidecar struct {
+	Blob          hexutil.Bytes `json:"blob"`
+	KZGCommitment hexutil.Bytes `json:"kzg_commitment"`
+	KZGProof      hexutil.Bytes `json:"kzg_proof"`
+}
+
+// blobs returns verified blobs of the L1 block with given timestamp by their versioned hashes
+func (c *beaconClient) blobs(ctx context.Context, time uint64) (map[libcommon.Hash]*types.Blob, error) {
+	slot, err := c.slot(ctx, time)
+	if err != nil {
+		return nil, err
+	}
+	var res struct {
+		Data []blobSidecar `json:"data"`
+	}
+	if err := c.get(ctx, fmt.Sprintf("/eth/v1/beacon/blob_sidecars/%d", slot), &res); err != nil {
+		return nil, err
+	}
+	blobs := make([]gokzg4844.Blob, len(res.Data))
+	commitments := make([]gokzg4844.KZGCommitment, len(res.Data))
+	proofs := make([]gokzg4844.KZGProof, len(res.Data))
+	for i, sidecar := range res.Data {
+		if len(sidecar.Blob) != len(blobs[i]) || len(sidecar.KZGCommitment) != len(commitments[i]) || len(sidecar.KZGProof) != len(proofs[i]) {
+			return nil, fmt.Errorf("%w: malformed blob sidecar %d of slot %d", ErrInvalid, i, slot)
+		}
+		copy(blobs[i][:], sidecar.Blob)
+		copy(commitments[i][:], sidecar.KZGCommitment)
+		copy(proofs[i][:], sidecar.KZGProof)
+	}
+	if err := libkzg.Ctx().VerifyBlobKZGProofBatch(blobs, commitments, proofs); err != nil {
+		return nil, fmt.Errorf("%w: blobs of slot %d: %w", ErrInvalid, slot, err)
+	}
+	byHash := make(map[libcommon.Hash]*types.Blob, len(blobs))
+	for i := range blobs {
+		blob := types.Blob(blobs[i])
+		byHash[libcommon.Hash(libkzg.KZGToVersionedHash(commitments[i]))] = &blob
+	}
+	return byHash, nil
+}
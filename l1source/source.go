@@ -0,0 +1,249 @@
+// Package l1source implements the shared access layer to L1 used by modules which need L1 data (deposit
+// verification, derivation, fee oracles): headers and receipts are read from L1 execution RPC, blobs from
+// L1 beacon API. Everything returned is verified against the requested hash and cached, so modules share
+// one client and one cache instead of opening their own.
+package l1source
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon-lib/metrics"
+
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/rpc"
+)
+
+const (
+	dialTimeout    = 10 * time.Second
+	requestTimeout = 10 * time.Second
+	// attempts of one request, transport errors are retried with doubling backoff
+	maxAttempts  = 3
+	retryBackoff = 250 * time.Millisecond
+	// blobs are large (128KB), so fewer of them are cached
+	blobCacheSize = 256
+)
+
+var (
+	ErrNotFound = errors.New("not found on L1")
+	// ErrInvalid - data returned by L1 doesn't match the requested hash
+	ErrInvalid = errors.New("invalid L1 data")
+)
+
+var (
+	requestsCounter  = metrics.GetOrCreateCounter("l1source_requests")
+	errorsCounter    = metrics.GetOrCreateCounter("l1source_errors")
+	cacheHitsCounter = metrics.GetOrCreateCounter("l1source_cache_hits")
+)
+
+// L1DataSource - read access to L1. Headers by number aren't cached: they change on L1 reorgs.
+type L1DataSource interface {
+	// HeaderByNumber accepts block number or latest, safe and finalized tags
+	HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error)
+	HeaderByHash(ctx context.Context, hash libcommon.Hash) (*types.Header, error)
+	ReceiptsByHash(ctx context.Context, blockHash libcommon.Hash) (types.Receipts, error)
+	// BlobsByHash returns blobs with given versioned hashes of the block, in the same order
+	BlobsByHash(ctx context.Context, blockHash libcommon.Hash, versionedHashes []libcommon.Hash) ([]*types.Blob, error)
+}
+
+// Source implements L1DataSource over L1 execution RPC and, for blobs, L1 beacon API
+type Source struct {
+	client *rpc.Client
+	beacon *beaconClient // nil - blobs are unavailable
+
+	headers  *lru.Cache[libcommon.Hash, *types.Header]
+	receipts *lru.Cache[libcommon.Hash, types.Receipts]
+	blobs    *lru.Cache[libcommon.Hash, *types.Blob] // by versioned hash
+
+	logger log.Logger
+}
+
+var _ L1DataSource = (*Source)(nil)
+
+// New dials L1 execution RPC rpcURL, beaconURL is optional. cacheSize - amount of blocks whose headers and
+// receipts are kept in cache
+func New(ctx context.Context, rpcURL, beaconURL string, cacheSize int, logger log.Logger) (*Source, error) {
+	dialCtx, cancel := context.WithTimeout(ctx, dialTimeout)
+	client, err := rpc.DialContext(dialCtx, rpcURL, logger)
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("l1 rpc: %w", err)
+	}
+	var beacon *beaconClient
+	if beaconURL != "" {
+		beacon = newBeaconClient(beaconURL)
+	}
+	return newSource(client, beacon, cacheSize, logger)
+}
+
+func newSource(client *rpc.Client, beacon *beaconClient, cacheSize int, logger log.Logger) (*Source, error) {
+	cacheSize = max(cacheSize, 1)
+	headers, err := lru.New[libcommon.Hash, *types.Header](cacheSize)
+	if err != nil {
+		return nil, err
+	}
+	receipts, err := lru.New[libcommon.Hash, types.Receipts](cacheSize)
+	if err != nil {
+		return nil, err
+	}
+	blobs, err := lru.New[libcommon.Hash, *types.Blob](blobCacheSize)
+	if err != nil {
+		return nil, err
+	}
+	return &Source{
+		client:   client,
+		beacon:   beacon,
+		headers:  headers,
+		receipts: receipts,
+		blobs:    blobs,
+		logger:   logger,
+	}, nil
+}
+
+func (s *Source) Close() {
+	s.client.Close()
+}
+
+func (s *Source) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	header, err := s.fetchHeader(ctx, "eth_getBlockByNumber", number)
+	if err != nil {
+		return nil, fmt.Errorf("l1 header %s: %w", number, err)
+	}
+	return header, nil
+}
+
+func (s *Source) HeaderByHash(ctx context.Context, hash libcommon.Hash) (*types.Header, error) {
+	if header, ok := s.headers.Get(hash); ok {
+		cacheHitsCounter.Inc()
+		return header, nil
+	}
+	header, err := s.fetchHeader(ctx, "eth_getBlockByHash", hash)
+	if err == nil && header.Hash() != hash {
+		err = fmt.Errorf("%w: header hash %x", ErrInvalid, header.Hash())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("l1 header %x: %w", hash, err)
+	}
+	return header, nil
+}
+
+// fetchHeader requests header and checks that its hash computed locally matches the hash reported by L1,
+// so header fields unknown to this node can't go unnoticed
+func (s *Source) fetchHeader(ctx context.Context, method string, block any) (*types.Header, error) {
+	var raw json.RawMessage
+	if err := s.call(ctx, &raw, method, block, false); err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, ErrNotFound
+	}
+	var header types.Header
+	if err := json.Unmarshal(raw, &header); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+	var reported struct {
+		Hash libcommon.Hash `json:"hash"`
+	}
+	if err := json.Unmarshal(raw, &reported); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+	if hash := header.Hash(); hash != reported.Hash {
+		return nil, fmt.Errorf("%w: computed header hash %x, reported %x", ErrInvalid, hash, reported.Hash)
+	}
+	s.headers.Add(reported.Hash, &header)
+	return &header, nil
+}
+
+// ReceiptsByHash returns receipts of the block, checked against its receipts root
+func (s *Source) ReceiptsByHash(ctx context.Context, blockHash libcommon.Hash) (types.Receipts, error) {
+	if receipts, ok := s.receipts.Get(blockHash); ok {
+		cacheHitsCounter.Inc()
+		return receipts, nil
+	}
+	header, err := s.HeaderByHash(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	var receipts types.Receipts
+	if err := s.call(ctx, &receipts, "eth_getBlockReceipts", blockHash); err != nil {
+		return nil, fmt.Errorf("l1 receipts %x: %w", blockHash, err)
+	}
+	if receipts == nil {
+		return nil, fmt.Errorf("l1 receipts %x: %w", blockHash, ErrNotFound)
+	}
+	if root := types.DeriveSha(receipts); root != header.ReceiptHash {
+		return nil, fmt.Errorf("l1 receipts %x: %w: receipts root %x, expected %x", blockHash, ErrInvalid, root, header.ReceiptHash)
+	}
+	s.receipts.Add(blockHash, receipts)
+	return receipts, nil
+}
+
+// BlobsByHash returns blobs of the block from L1 beacon API, checked against their KZG commitments
+func (s *Source) BlobsByHash(ctx context.Context, blockHash libcommon.Hash, versionedHashes []libcommon.Hash) ([]*types.Blob, error) {
+	res := make([]*types.Blob, len(versionedHashes))
+	missing := false
+	for i, h := range versionedHashes {
+		if res[i], _ = s.blobs.Get(h); res[i] == nil {
+			missing = true
+		}
+	}
+	if !missing {
+		cacheHitsCounter.Inc()
+		return res, nil
+	}
+	if s.beacon == nil {
+		return nil, errors.New("l1 blobs: L1 beacon endpoint isn't configured")
+	}
+	header, err := s.HeaderByHash(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	requestsCounter.Inc()
+	sidecars, err := s.beacon.blobs(ctx, header.Time)
+	if err != nil {
+		errorsCounter.Inc()
+		return nil, fmt.Errorf("l1 blobs %x: %w", blockHash, err)
+	}
+	for h, blob := range sidecars {
+		s.blobs.Add(h, blob)
+	}
+	for i, h := range versionedHashes {
+		if res[i] = sidecars[h]; res[i] == nil {
+			return nil, fmt.Errorf("l1 blobs %x: blob %x: %w", blockHash, h, ErrNotFound)
+		}
+	}
+	return res, nil
+}
+
+// call retries transport errors, errors returned by L1 node are not retried
+func (s *Source) call(ctx context.Context, result any, method string, args ...any) (err error) {
+	backoff := retryBackoff
+	for attempt := 1; ; attempt++ {
+		requestsCounter.Inc()
+		callCtx, cancel := context.WithTimeout(ctx, requestTimeout)
+		err = s.client.CallContext(callCtx, result, method, args...)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		errorsCounter.Inc()
+		var rpcErr rpc.Error
+		if attempt == maxAttempts || ctx.Err() != nil || errors.As(err, &rpcErr) {
+			return err
+		}
+		s.logger.Debug("[l1-source] retrying", "method", method, "attempt", attempt, "err", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,198 @@
+package l1source
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	gokzg4844 "github.com/crate-crypto/go-kzg-4844"
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	libkzg "github.com/erigontech/erigon-lib/crypto/kzg"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/rpc"
+)
+
+// fakeRPC answers JSON-RPC requests by results keyed by method/first param, missing key is null result
+type fakeRPC struct {
+	lock     sync.Mutex
+	results  map[string]string
+	requests int
+}
+
+func (f *fakeRPC) set(key string, result any) {
+	b, err := json.Marshal(result)
+	if err != nil {
+		panic(err)
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.results[key] = string(b)
+}
+
+func (f *fakeRPC) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var param string
+	_ = json.Unmarshal(req.Params[0], &param)
+	f.lock.Lock()
+	f.requests++
+	result, ok := f.results[req.Method+"/"+param]
+	f.lock.Unlock()
+	if !ok {
+		result = "null"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":%s}`, req.ID, result)
+}
+
+func newTestSource(t *testing.T, beaconURL string) (*Source, *fakeRPC) {
+	f := &fakeRPC{results: map[string]string{}}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	s, err := New(context.Background(), srv.URL, beaconURL, 16, log.New())
+	require.NoError(t, err)
+	t.Cleanup(s.Close)
+	return s, f
+}
+
+func testHeader(number int64, receipts types.Receipts) *types.Header {
+	baseFee := big.NewInt(7)
+	return &types.Header{
+		Number:      big.NewInt(number),
+		Difficulty:  big.NewInt(0),
+		GasLimit:    30_000_000,
+		Time:        1_700_000_000 + uint64(number)*12,
+		Extra:       []byte{},
+		BaseFee:     baseFee,
+		ReceiptHash: types.DeriveSha(receipts),
+	}
+}
+
+func TestHeaders(t *testing.T) {
+	ctx := context.Background()
+	s, f := newTestSource(t, "")
+	header := testHeader(100, types.Receipts{})
+	hash := header.Hash()
+	f.set("eth_getBlockByNumber/latest", header)
+	f.set("eth_getBlockByHash/"+hash.Hex(), header)
+
+	latest, err := s.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	require.NoError(t, err)
+	require.Equal(t, hash, latest.Hash())
+
+	// by hash is served from cache filled by number
+	requests := f.requests
+	byHash, err := s.HeaderByHash(ctx, hash)
+	require.NoError(t, err)
+	require.Equal(t, hash, byHash.Hash())
+	require.Equal(t, requests, f.requests)
+
+	_, err = s.HeaderByHash(ctx, libcommon.HexToHash("0x01"))
+	require.ErrorIs(t, err, ErrNotFound)
+
+	// header whose fields are unknown to this node has different hash
+	f.results["eth_getBlockByNumber/finalized"] = `{"hash":"0x0000000000000000000000000000000000000000000000000000000000000002","parentHash":"0x0000000000000000000000000000000000000000000000000000000000000000","sha3Uncles":"0x0000000000000000000000000000000000000000000000000000000000000000","stateRoot":"0x0000000000000000000000000000000000000000000000000000000000000000","transactionsRoot":"0x0000000000000000000000000000000000000000000000000000000000000000","receiptsRoot":"0x0000000000000000000000000000000000000000000000000000000000000000","logsBloom":"0x` + fmt.Sprintf("%0512x", 0) + `","difficulty":"0x0","number":"0x1","gasLimit":"0x1","gasUsed":"0x0","timestamp":"0x0","extraData":"0x"}`
+	_, err = s.HeaderByNumber(ctx, rpc.FinalizedBlockNumber)
+	require.ErrorIs(t, err, ErrInvalid)
+}
+
+func TestReceipts(t *testing.T) {
+	ctx := context.Background()
+	s, f := newTestSource(t, "")
+	receipts := types.Receipts{
+		{Type: types.DynamicFeeTxType, Status: types.ReceiptStatusSuccessful, CumulativeGasUsed: 21_000, GasUsed: 21_000, Logs: types.Logs{}},
+		{Type: types.BlobTxType, Status: types.ReceiptStatusFailed, CumulativeGasUsed: 50_000, GasUsed: 29_000, Logs: types.Logs{}},
+	}
+	header := testHeader(100, receipts)
+	hash := header.Hash()
+	f.set("eth_getBlockByHash/"+hash.Hex(), header)
+	f.set("eth_getBlockReceipts/"+hash.Hex(), receipts)
+
+	res, err := s.ReceiptsByHash(ctx, hash)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	require.Equal(t, uint64(50_000), res[1].CumulativeGasUsed)
+
+	requests := f.requests
+	_, err = s.ReceiptsByHash(ctx, hash)
+	require.NoError(t, err)
+	require.Equal(t, requests, f.requests)
+
+	// receipts which don't match receipts root
+	other := testHeader(101, receipts)
+	otherHash := other.Hash()
+	f.set("eth_getBlockByHash/"+otherHash.Hex(), other)
+	f.set("eth_getBlockReceipts/"+otherHash.Hex(), receipts[:1])
+	_, err = s.ReceiptsByHash(ctx, otherHash)
+	require.ErrorIs(t, err, ErrInvalid)
+}
+
+func TestBlobs(t *testing.T) {
+	ctx := context.Background()
+	var blob gokzg4844.Blob
+	blob[31] = 1
+	commitment, err := libkzg.Ctx().BlobToKZGCommitment(blob, 1)
+	require.NoError(t, err)
+	proof, err := libkzg.Ctx().ComputeBlobKZGProof(blob, commitment, 1)
+	require.NoError(t, err)
+	versionedHash := libcommon.Hash(libkzg.KZGToVersionedHash(commitment))
+
+	header := testHeader(100, types.Receipts{})
+	const genesisTime = 1_600_000_000
+	slot := (header.Time - genesisTime) / 12
+	sidecars := 0
+	beacon := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var res any
+		switch r.URL.Path {
+		case "/eth/v1/beacon/genesis":
+			res = map[string]any{"data": map[string]string{"genesis_time": fmt.Sprint(genesisTime)}}
+		case "/eth/v1/config/spec":
+			res = map[string]any{"data": map[string]string{"SECONDS_PER_SLOT": "12"}}
+		case fmt.Sprintf("/eth/v1/beacon/blob_sidecars/%d", slot):
+			sidecars++
+			res = map[string]any{"data": []blobSidecar{{Blob: blob[:], KZGCommitment: commitment[:], KZGProof: proof[:]}}}
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(res)
+	}))
+	t.Cleanup(beacon.Close)
+
+	s, f := newTestSource(t, beacon.URL)
+	hash := header.Hash()
+	f.set("eth_getBlockByHash/"+hash.Hex(), header)
+
+	blobs, err := s.BlobsByHash(ctx, hash, []libcommon.Hash{versionedHash})
+	require.NoError(t, err)
+	require.Equal(t, types.Blob(blob), *blobs[0])
+
+	_, err = s.BlobsByHash(ctx, hash, []libcommon.Hash{versionedHash})
+	require.NoError(t, err)
+	require.Equal(t, 1, sidecars)
+
+	_, err = s.BlobsByHash(ctx, hash, []libcommon.Hash{versionedHash, {0x01}})
+	require.ErrorIs(t, err, ErrNotFound)
+
+	// sidecar with wrong proof
+	proof[0] ^= 1
+	s.blobs.Purge()
+	_, err = s.BlobsByHash(ctx, hash, []libcommon.Hash{versionedHash})
+	require.ErrorIs(t, err, ErrInvalid)
+}
@@ -0,0 +1,227 @@
+package l1source
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon-lib/metrics"
+
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/rpc"
+)
+
+var (
+	viewHeadGauge      = metrics.GetOrCreateGauge("l1view_head")
+	viewReorgsCounter  = metrics.GetOrCreateCounter("l1view_reorgs")
+	viewErrorsCounter  = metrics.GetOrCreateCounter("l1view_errors")
+	viewWatchedCounter = metrics.GetOrCreateCounter("l1view_watched_logs")
+)
+
+// View - the light view of L1 kept next to the L2 node (--rollup.l1view). It follows the L1 header chain of the
+// source, each new header fetched by the parent hash of the one after it, and keeps the last depth headers with
+// the logs of the watched contracts, taken from receipts checked against the receipts root. Rollup status RPCs read
+// L1 origins of L2 blocks from it, so they don't depend on op-node.
+type View struct {
+	source    L1DataSource
+	contracts []libcommon.Address
+	depth     int
+	interval  time.Duration
+
+	lock      sync.RWMutex
+	headers   []*types.Header                 // consecutive, the last one is the head
+	logs      map[libcommon.Hash][]*types.Log // of the watched contracts, by block hash of headers
+	safe      *types.Header
+	finalized *types.Header
+
+	logger log.Logger
+}
+
+// NewView - the view of the last depth L1 blocks, updated every interval by Run
+func NewView(source L1DataSource, contracts []libcommon.Address, depth int, interval time.Duration, logger log.Logger) *View {
+	return &View{
+		source:    source,
+		contracts: contracts,
+		depth:     max(depth, 1),
+		interval:  interval,
+		logs:      map[libcommon.Hash][]*types.Log{},
+		logger:    logger,
+	}
+}
+
+// Run - follows L1 until ctx is done
+func (v *View) Run(ctx context.Context) {
+	v.logger.Info("[l1-view] started", "depth", v.depth, "contracts", len(v.contracts))
+	ticker := time.NewTicker(v.interval)
+	defer ticker.Stop()
+	for {
+		if err := v.update(ctx); err != nil && ctx.Err() == nil {
+			viewErrorsCounter.Inc()
+			v.logger.Debug("[l1-view] update failed", "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// update - moves the view to the L1 head. The new headers are fetched from the head back until one of the view is
+// reached, the headers of the view after that one were reorged out. A view which isn't reached within depth
+// headers is replaced.
+func (v *View) update(ctx context.Context) error {
+	head, err := v.source.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if err != nil {
+		return err
+	}
+	safe, err := v.headerByTag(ctx, rpc.SafeBlockNumber)
+	if err != nil {
+		return err
+	}
+	finalized, err := v.headerByTag(ctx, rpc.FinalizedBlockNumber)
+	if err != nil {
+		return err
+	}
+
+	v.lock.RLock()
+	headers := v.headers
+	v.lock.RUnlock()
+
+	kept := 0                      // headers of the view which stay
+	var newHeaders []*types.Header // from the head back
+	for h := head; ; {
+		if i := index(headers, h.Number.Uint64()); i >= 0 && headers[i].Hash() == h.Hash() {
+			kept = i + 1
+			break
+		}
+		newHeaders = append(newHeaders, h)
+		if h.Number.Uint64() == 0 || len(newHeaders) == v.depth {
+			break
+		}
+		if h, err = v.source.HeaderByHash(ctx, h.ParentHash); err != nil {
+			return err
+		}
+	}
+	slices.Reverse(newHeaders)
+
+	newLogs := map[libcommon.Hash][]*types.Log{}
+	for _, h := range newHeaders {
+		if !v.mayHaveLogs(h) {
+			continue
+		}
+		receipts, err := v.source.ReceiptsByHash(ctx, h.Hash())
+		if err != nil {
+			return err
+		}
+		for _, r := range receipts {
+			for _, l := range r.Logs {
+				if slices.Contains(v.contracts, l.Address) {
+					newLogs[h.Hash()] = append(newLogs[h.Hash()], l)
+					viewWatchedCounter.Inc()
+				}
+			}
+		}
+	}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	if kept < len(v.headers) {
+		if kept > 0 {
+			viewReorgsCounter.Inc()
+			v.logger.Info("[l1-view] reorg", "from", v.headers[len(v.headers)-1].Number, "to", head.Number, "depth", len(v.headers)-kept)
+		}
+		for _, h := range v.headers[kept:] {
+			delete(v.logs, h.Hash())
+		}
+	}
+	headers = append(v.headers[:kept:kept], newHeaders...)
+	if drop := len(headers) - v.depth; drop > 0 {
+		for _, h := range headers[:drop] {
+			delete(v.logs, h.Hash())
+		}
+		headers = headers[drop:]
+	}
+	for hash, logs := range newLogs {
+		v.logs[hash] = logs
+	}
+	v.headers, v.safe, v.finalized = headers, safe, finalized
+	viewHeadGauge.SetUint64(head.Number.Uint64())
+	return nil
+}
+
+// headerByTag - nil if L1 has no safe or finalized block yet
+func (v *View) headerByTag(ctx context.Context, tag rpc.BlockNumber) (*types.Header, error) {
+	h, err := v.source.HeaderByNumber(ctx, tag)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	return h, err
+}
+
+func (v *View) mayHaveLogs(h *types.Header) bool {
+	for _, contract := range v.contracts {
+		if types.BloomLookup(h.Bloom, contract) {
+			return true
+		}
+	}
+	return false
+}
+
+// index - position of the header with number in consecutive headers, -1 if they don't have it
+func index(headers []*types.Header, number uint64) int {
+	if len(headers) == 0 {
+		return -1
+	}
+	first := headers[0].Number.Uint64()
+	if number < first || number-first >= uint64(len(headers)) {
+		return -1
+	}
+	return int(number - first)
+}
+
+// Head - nil until L1 is read the first time
+func (v *View) Head() *types.Header {
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+	if len(v.headers) == 0 {
+		return nil
+	}
+	return v.headers[len(v.headers)-1]
+}
+
+// Safe - nil if L1 has no safe block
+func (v *View) Safe() *types.Header {
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+	return v.safe
+}
+
+// Finalized - nil if L1 has no finalized block
+func (v *View) Finalized() *types.Header {
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+	return v.finalized
+}
+
+// Canonical - whether the L1 block is on the chain of the view. Not known for blocks out of the view.
+func (v *View) Canonical(number uint64, hash libcommon.Hash) (canonical, known bool) {
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+	i := index(v.headers, number)
+	if i < 0 {
+		return false, false
+	}
+	return v.headers[i].Hash() == hash, true
+}
+
+// Logs - logs of the watched contracts in the L1 block of the view
+func (v *View) Logs(blockHash libcommon.Hash) []*types.Log {
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+	return v.logs[blockHash]
+}
@@ -0,0 +1,164 @@
+package l1source
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/rpc"
+)
+
+// fakeL1 - L1DataSource over an in-memory chain, headers of forks stay readable by hash
+type fakeL1 struct {
+	lock      sync.Mutex
+	canonical []*types.Header
+	headers   map[libcommon.Hash]*types.Header
+	receipts  map[libcommon.Hash]types.Receipts
+	finalized int // -1 - no finalized block
+}
+
+func newFakeL1() *fakeL1 {
+	f := &fakeL1{headers: map[libcommon.Hash]*types.Header{}, receipts: map[libcommon.Hash]types.Receipts{}, finalized: -1}
+	f.add(&types.Header{Number: big.NewInt(0)}, nil)
+	return f
+}
+
+func (f *fakeL1) add(h *types.Header, receipts types.Receipts) {
+	h.Bloom = types.CreateBloom(receipts)
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.canonical = append(f.canonical[:h.Number.Uint64()], h)
+	f.headers[h.Hash()] = h
+	f.receipts[h.Hash()] = receipts
+}
+
+// extend - appends n blocks on top of the block number from, fork makes them differ from blocks of other forks
+func (f *fakeL1) extend(from uint64, n int, fork byte, logs map[uint64]libcommon.Address) {
+	for i := 0; i < n; i++ {
+		f.lock.Lock()
+		parent := f.canonical[from]
+		f.lock.Unlock()
+		number := from + 1
+		var receipts types.Receipts
+		if contract, ok := logs[number]; ok {
+			receipts = types.Receipts{{Logs: []*types.Log{{Address: contract}}}}
+		}
+		f.add(&types.Header{ParentHash: parent.Hash(), Number: new(big.Int).SetUint64(number), Extra: []byte{fork}}, receipts)
+		from = number
+	}
+}
+
+func (f *fakeL1) HeaderByNumber(_ context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	switch number {
+	case rpc.LatestBlockNumber:
+		return f.canonical[len(f.canonical)-1], nil
+	case rpc.FinalizedBlockNumber, rpc.SafeBlockNumber:
+		if f.finalized < 0 {
+			return nil, ErrNotFound
+		}
+		return f.canonical[f.finalized], nil
+	}
+	return f.canonical[number], nil
+}
+
+func (f *fakeL1) HeaderByHash(_ context.Context, hash libcommon.Hash) (*types.Header, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if h, ok := f.headers[hash]; ok {
+		return h, nil
+	}
+	return nil, ErrNotFound
+}
+
+func (f *fakeL1) ReceiptsByHash(_ context.Context, blockHash libcommon.Hash) (types.Receipts, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.receipts[blockHash], nil
+}
+
+func (f *fakeL1) BlobsByHash(context.Context, libcommon.Hash, []libcommon.Hash) ([]*types.Blob, error) {
+	return nil, ErrNotFound
+}
+
+func (f *fakeL1) header(number uint64) *types.Header {
+	h, _ := f.HeaderByNumber(context.Background(), rpc.BlockNumber(number))
+	return h
+}
+
+func TestViewFollowsL1(t *testing.T) {
+	ctx := context.Background()
+	portal, other := libcommon.HexToAddress("0x01"), libcommon.HexToAddress("0x02")
+	l1 := newFakeL1()
+	l1.extend(0, 10, 0, map[uint64]libcommon.Address{5: portal, 6: other})
+	v := NewView(l1, []libcommon.Address{portal}, 8, time.Second, log.New())
+
+	require.Nil(t, v.Head())
+	require.NoError(t, v.update(ctx))
+	require.Equal(t, uint64(10), v.Head().Number.Uint64())
+	require.Nil(t, v.Safe())
+	require.Nil(t, v.Finalized())
+
+	// only the last depth blocks are kept
+	canonical, known := v.Canonical(3, l1.header(3).Hash())
+	require.True(t, canonical)
+	require.True(t, known)
+	_, known = v.Canonical(2, l1.header(2).Hash())
+	require.False(t, known)
+	_, known = v.Canonical(11, libcommon.Hash{})
+	require.False(t, known)
+
+	// logs of the watched contracts only
+	require.Len(t, v.Logs(l1.header(5).Hash()), 1)
+	require.Empty(t, v.Logs(l1.header(6).Hash()))
+
+	l1.extend(10, 5, 0, nil)
+	l1.finalized = 12
+	require.NoError(t, v.update(ctx))
+	require.Equal(t, uint64(15), v.Head().Number.Uint64())
+	require.Equal(t, uint64(12), v.Finalized().Number.Uint64())
+	_, known = v.Canonical(7, l1.header(7).Hash())
+	require.False(t, known)
+	require.Empty(t, v.Logs(l1.header(5).Hash()), "logs of blocks out of the view are dropped")
+}
+
+func TestViewReorg(t *testing.T) {
+	ctx := context.Background()
+	portal := libcommon.HexToAddress("0x01")
+	l1 := newFakeL1()
+	l1.extend(0, 10, 0, map[uint64]libcommon.Address{9: portal})
+	v := NewView(l1, []libcommon.Address{portal}, 8, time.Second, log.New())
+	require.NoError(t, v.update(ctx))
+	reorged := l1.header(9)
+	reorgs := viewReorgsCounter.GetValueUint64()
+
+	l1.extend(7, 4, 1, map[uint64]libcommon.Address{10: portal})
+	require.NoError(t, v.update(ctx))
+	require.Equal(t, reorgs+1, viewReorgsCounter.GetValueUint64())
+	require.Equal(t, l1.header(11).Hash(), v.Head().Hash())
+
+	canonical, known := v.Canonical(9, reorged.Hash())
+	require.False(t, canonical)
+	require.True(t, known)
+	canonical, _ = v.Canonical(7, l1.header(7).Hash())
+	require.True(t, canonical)
+	require.Empty(t, v.Logs(reorged.Hash()))
+	require.Len(t, v.Logs(l1.header(10).Hash()), 1)
+
+	// a reorg deeper than the view replaces it
+	l1.extend(1, 12, 2, nil)
+	require.NoError(t, v.update(ctx))
+	require.Equal(t, l1.header(13).Hash(), v.Head().Hash())
+	canonical, known = v.Canonical(6, l1.header(6).Hash())
+	require.True(t, canonical)
+	require.True(t, known)
+}
@@ -0,0 +1,146 @@
+package migrations
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"runtime"
+	"time"
+
+	common2 "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/dbg"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/ethdb/cbor"
+)
+
+// MigrateReceiptsStorageV2 - rewrites legacy cbor values of kv.Receipts, kv.Log and kv.BorReceipts in storage encoding v2
+// (see types.EncodeReceiptsForStorage). It's not registered in `migrations` list: on big datadirs it takes hours
+// and node can serve both encodings. Instead it's run explicitly by `erigon db migrate-receipts`.
+//
+// Every batch of batchSize records is committed in own transaction, already converted records are skipped,
+// so it can be interrupted and restarted at any moment.
+func MigrateReceiptsStorageV2(ctx context.Context, db kv.RwDB, batchSize int, logger log.Logger) error {
+	logEvery := time.NewTicker(20 * time.Second)
+	defer logEvery.Stop()
+
+	for _, table := range []string{kv.Receipts, kv.Log, kv.BorReceipts} {
+		exists := true
+		if err := db.View(ctx, func(tx kv.Tx) (err error) {
+			if migrator, ok := tx.(kv.BucketMigrator); ok {
+				exists, err = migrator.ExistsBucket(table)
+			}
+			return err
+		}); err != nil {
+			return err
+		}
+		if !exists { // chaindata of OP chains has no bor tables, see mdbx.WithoutBorTables
+			continue
+		}
+
+		var from []byte
+		var converted int
+		for {
+			var next []byte
+			var batchConverted int
+			if err := db.Update(ctx, func(tx kv.RwTx) (err error) {
+				next, batchConverted, err = migrateReceiptsStorageV2Batch(tx, table, from, batchSize)
+				return err
+			}); err != nil {
+				return err
+			}
+			converted += batchConverted
+			if next == nil {
+				break
+			}
+			from = next
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-logEvery.C:
+				var m runtime.MemStats
+				dbg.ReadMemStats(&m)
+				logger.Info("[receipts_v2] Migration progress", "table", table, "key", fmt.Sprintf("%x", from),
+					"converted", converted, "alloc", common2.ByteCount(m.Alloc), "sys", common2.ByteCount(m.Sys))
+			default:
+			}
+		}
+		logger.Info("[receipts_v2] Migration done", "table", table, "converted", converted)
+	}
+	return nil
+}
+
+// migrateReceiptsStorageV2Batch - converts up to batchSize records of table starting from key `from`.
+// Returns key to continue from, nil if table is over.
+func migrateReceiptsStorageV2Batch(tx kv.RwTx, table string, from []byte, batchSize int) (next []byte, converted int, err error) {
+	c, err := tx.Cursor(table)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer c.Close()
+
+	// values are collected first and written after iteration, to not modify table under open cursor
+	var keys, values [][]byte
+	var seen int
+	for k, v, err := c.Seek(from); k != nil; k, v, err = c.Next() {
+		if err != nil {
+			return nil, 0, err
+		}
+		if seen == batchSize {
+			next = common2.CopyBytes(k)
+			break
+		}
+		seen++
+		if types.IsReceiptsStorageV2(v) {
+			continue
+		}
+		newV, err := reencodeReceiptsStorageV2(table, v)
+		if err != nil {
+			return nil, 0, err
+		}
+		if newV == nil {
+			continue
+		}
+		keys = append(keys, common2.CopyBytes(k))
+		values = append(values, newV)
+	}
+	for i := range keys {
+		// kv.BorReceipts is DupSort: Put would add the new value next to the old one
+		if table == kv.BorReceipts {
+			if err := tx.Delete(table, keys[i]); err != nil {
+				return nil, 0, err
+			}
+		}
+		if err := tx.Put(table, keys[i], values[i]); err != nil {
+			return nil, 0, err
+		}
+	}
+	return next, len(keys), nil
+}
+
+// reencodeReceiptsStorageV2 - v in storage encoding v2, nil if it's left as is
+func reencodeReceiptsStorageV2(table string, v []byte) ([]byte, error) {
+	switch table {
+	case kv.BorReceipts:
+		// the oldest bor receipts are RLP with embedded logs, they are readable and are left as is
+		var borReceipt *types.Receipt
+		if err := cbor.Unmarshal(&borReceipt, bytes.NewReader(v)); err != nil {
+			return nil, nil
+		}
+		return types.EncodeReceiptsForStorage(types.Receipts{borReceipt})
+	case kv.Log:
+		logs, err := types.DecodeLogsForStorage(v)
+		if err != nil {
+			return nil, err
+		}
+		return types.EncodeLogsForStorage(logs)
+	}
+	receipts, err := types.DecodeReceiptsForStorage(v)
+	if err != nil {
+		return nil, err
+	}
+	return types.EncodeReceiptsForStorage(receipts)
+}
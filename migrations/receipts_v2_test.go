@@ -0,0 +1,86 @@
+package migrations_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutility"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/dbutils"
+	"github.com/erigontech/erigon-lib/kv/memdb"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/core/rawdb"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/ethdb/cbor"
+	"github.com/erigontech/erigon/migrations"
+)
+
+func TestMigrateReceiptsStorageV2(t *testing.T) {
+	require, db := require.New(t), memdb.NewTestDB(t)
+	logs := types.Logs{{Address: libcommon.HexToAddress("0x1"), Topics: []libcommon.Hash{libcommon.HexToHash("0x2")}, Data: []byte{3}}}
+
+	err := db.Update(context.Background(), func(tx kv.RwTx) error {
+		for blockNum := uint64(1); blockNum <= 5; blockNum++ {
+			receipts := types.Receipts{{Status: types.ReceiptStatusSuccessful, CumulativeGasUsed: blockNum * 21_000, Logs: logs}}
+			if blockNum%2 == 0 {
+				// already in v2 - written by new node
+				require.NoError(rawdb.WriteReceipts(tx, blockNum, receipts))
+				continue
+			}
+			buf := bytes.NewBuffer(nil)
+			require.NoError(cbor.Marshal(buf, receipts))
+			require.NoError(tx.Put(kv.Receipts, hexutility.EncodeTs(blockNum), buf.Bytes()))
+			buf.Reset()
+			require.NoError(cbor.Marshal(buf, logs))
+			require.NoError(tx.Put(kv.Log, dbutils.LogKey(blockNum, 0), buf.Bytes()))
+		}
+		// legacy bor receipt of block 5, its logs are in kv.Log after the receipts of the block
+		buf := bytes.NewBuffer(nil)
+		require.NoError(cbor.Marshal(buf, &types.Receipt{Status: types.ReceiptStatusSuccessful, TransactionIndex: 1}))
+		require.NoError(tx.Put(kv.BorReceipts, hexutility.EncodeTs(5), buf.Bytes()))
+		buf.Reset()
+		require.NoError(cbor.Marshal(buf, logs))
+		require.NoError(tx.Put(kv.Log, dbutils.LogKey(5, 1), buf.Bytes()))
+		return nil
+	})
+	require.NoError(err)
+
+	// small batch - to cross transaction boundaries
+	err = migrations.MigrateReceiptsStorageV2(context.Background(), db, 2, log.New())
+	require.NoError(err)
+
+	err = db.View(context.Background(), func(tx kv.Tx) error {
+		for _, table := range []string{kv.Receipts, kv.Log, kv.BorReceipts} {
+			require.NoError(tx.ForEach(table, nil, func(k, v []byte) error {
+				require.True(types.IsReceiptsStorageV2(v), "table %s, key %x", table, k)
+				return nil
+			}))
+		}
+		for blockNum := uint64(1); blockNum <= 5; blockNum++ {
+			receipts := rawdb.ReadRawReceipts(tx, blockNum)
+			require.Len(receipts, 1)
+			require.Equal(blockNum*21_000, receipts[0].CumulativeGasUsed)
+			require.Len(receipts[0].Logs, 1)
+			require.Equal(logs[0].Address, receipts[0].Logs[0].Address)
+		}
+		// the legacy value is replaced, not kept as a duplicate next to the new one
+		var n int
+		require.NoError(tx.ForEach(kv.BorReceipts, nil, func(k, v []byte) error {
+			n++
+			return nil
+		}))
+		require.Equal(1, n)
+		borReceipt, err := rawdb.ReadBorReceipt(tx, libcommon.Hash{}, 5, rawdb.ReadRawReceipts(tx, 5))
+		require.NoError(err)
+		require.Equal(types.ReceiptStatusSuccessful, borReceipt.Status)
+		require.Len(borReceipt.Logs, 1)
+		require.Equal(logs[0].Address, borReceipt.Logs[0].Address)
+		return nil
+	})
+	require.NoError(err)
+}
@@ -349,6 +349,9 @@ func OpenDatabase(ctx context.Context, config *nodecfg.Config, label kv.Label, n
 				opts = opts.GrowthStep(config.MdbxGrowthStep)
 			}
 			opts = opts.DirtySpace(uint64(512 * datasize.MB))
+			if config.WithoutBor {
+				opts = opts.WithTableCfg(mdbx.WithoutBorTables)
+			}
 		case kv.ConsensusDB:
 			if config.MdbxPageSize.Bytes() > 0 {
 				opts = opts.PageSize(config.MdbxPageSize.Bytes())
@@ -162,6 +162,8 @@ type Config struct {
 	MdbxPageSize    datasize.ByteSize
 	MdbxDBSizeLimit datasize.ByteSize
 	MdbxGrowthStep  datasize.ByteSize
+	// WithoutBor - chaindata doesn't get the bor tables, for the chains without bor (e.g. OP chains)
+	WithoutBor bool
 	// HealthCheck enables standard grpc health check
 	HealthCheck bool
 
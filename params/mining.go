@@ -21,4 +21,12 @@ type MiningConfig struct {
 	GasLimit   uint64            // Target gas limit for mined blocks.
 	GasPrice   *big.Int          // Minimum gas price for mining a transaction
 	Recommit   time.Duration     // The time interval for miner to re-create mining work.
+
+	// BuilderDeadline - how long a payload is packed with transactions after engine_forkchoiceUpdated.
+	// 0 - on Optimism until the timestamp of the payload (the block time of op-node), elsewhere until engine_getPayload
+	BuilderDeadline time.Duration
+
+	PayloadHistoryRetention time.Duration // How long payload attributes and outcomes of block building are kept in db, 0 - not stored
+
+	RemoteBuilder string // gRPC address of a builder process (cmd/builder) building payloads instead of the node, empty - built by the node
 }
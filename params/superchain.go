@@ -191,6 +191,7 @@ func LoadSuperChainConfig(opStackChainCfg *superchain.ChainConfig) *chain.Config
 		out.MergeNetsplitBlock = big.NewInt(511)
 		out.BedrockBlock = big.NewInt(511)
 		out.RegolithTime = BobaSepoliaRegolithTime
+		out.Optimism.BobaLegacy = &chain.BobaLegacyConfig{} // TuringHelpers of dapps aren't listed: any contract
 	case BobaMainnetChainID:
 		out.BerlinBlock = big.NewInt(1149019)
 		out.LondonBlock = big.NewInt(1149019)
@@ -199,6 +200,7 @@ func LoadSuperChainConfig(opStackChainCfg *superchain.ChainConfig) *chain.Config
 		out.MergeNetsplitBlock = big.NewInt(1149019)
 		out.BedrockBlock = big.NewInt(1149019)
 		out.RegolithTime = BobaMainnetRegolithTime
+		out.Optimism.BobaLegacy = &chain.BobaLegacyConfig{} // TuringHelpers of dapps aren't listed: any contract
 	case BobaBnbTestnetChainID:
 		out.BerlinBlock = big.NewInt(675077)
 		out.LondonBlock = big.NewInt(675077)
@@ -207,6 +209,7 @@ func LoadSuperChainConfig(opStackChainCfg *superchain.ChainConfig) *chain.Config
 		out.MergeNetsplitBlock = big.NewInt(675077)
 		out.BedrockBlock = big.NewInt(675077)
 		out.RegolithTime = BobaBnbTestnetRegoTime
+		out.Optimism.BobaLegacy = &chain.BobaLegacyConfig{} // TuringHelpers of dapps aren't listed: any contract
 	}
 
 	return out
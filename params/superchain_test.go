@@ -2,6 +2,7 @@ package params
 
 import (
 	"math/big"
+	"strings"
 	"testing"
 
 	"github.com/erigontech/erigon-lib/common"
@@ -151,6 +152,9 @@ func TestChainConfigByOpStackChainName(t *testing.T) {
 		require.Equal(t, expectedHarhardforkCfg.EIP1559Elasticity, gotCfg.Optimism.EIP1559Elasticity)
 		require.Equal(t, expectedHarhardforkCfg.EIP1559Denominator, gotCfg.Optimism.EIP1559Denominator)
 		require.Equal(t, expectedHarhardforkCfg.EIP1559DenominatorCanyon, gotCfg.Optimism.EIP1559DenominatorCanyon)
+
+		// pre-bedrock history of Boba
+		require.Equal(t, strings.HasPrefix(name, "boba-"), gotCfg.IsBoba())
 	}
 }
 
@@ -148,7 +148,7 @@ var (
 					}
 				}()
 				// Calculate how many records there will be in the index
-				d, err := seg.NewDecompressor(sn.Path)
+				d, err := seg.NewDecompressorWithKeyProvider(sn.Path, seg.KeyProviderFromContext(ctx))
 				if err != nil {
 					return err
 				}
@@ -244,7 +244,7 @@ var (
 		[]snaptype.Index{Indexes.BorSpanId},
 		snaptype.IndexBuilderFunc(
 			func(ctx context.Context, sn snaptype.FileInfo, salt uint32, _ *chain.Config, tmpDir string, p *background.Progress, lvl log.Lvl, logger log.Logger) (err error) {
-				d, err := seg.NewDecompressor(sn.Path)
+				d, err := seg.NewDecompressorWithKeyProvider(sn.Path, seg.KeyProviderFromContext(ctx))
 
 				if err != nil {
 					return err
@@ -306,7 +306,7 @@ var (
 		[]snaptype.Index{Indexes.BorCheckpointId},
 		snaptype.IndexBuilderFunc(
 			func(ctx context.Context, sn snaptype.FileInfo, salt uint32, _ *chain.Config, tmpDir string, p *background.Progress, lvl log.Lvl, logger log.Logger) (err error) {
-				d, err := seg.NewDecompressor(sn.Path)
+				d, err := seg.NewDecompressorWithKeyProvider(sn.Path, seg.KeyProviderFromContext(ctx))
 
 				if err != nil {
 					return err
@@ -381,7 +381,7 @@ var (
 		[]snaptype.Index{Indexes.BorMilestoneId},
 		snaptype.IndexBuilderFunc(
 			func(ctx context.Context, sn snaptype.FileInfo, salt uint32, _ *chain.Config, tmpDir string, p *background.Progress, lvl log.Lvl, logger log.Logger) (err error) {
-				d, err := seg.NewDecompressor(sn.Path)
+				d, err := seg.NewDecompressorWithKeyProvider(sn.Path, seg.KeyProviderFromContext(ctx))
 
 				if err != nil {
 					return err
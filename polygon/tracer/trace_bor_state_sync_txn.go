@@ -46,7 +46,7 @@ func TraceBorStateSyncTxnDebugAPI(
 	}
 
 	txCtx := initStateSyncTxContext(blockNum, blockHash)
-	tracer, streaming, cancel, err := transactions.AssembleTracer(ctx, traceConfig, txCtx.TxHash, stream, callTimeout)
+	tracer, streaming, cancel, err := transactions.AssembleTracer(ctx, traceConfig, &tracers.Context{TxHash: txCtx.TxHash}, stream, callTimeout)
 	if err != nil {
 		stream.WriteNil()
 		return err
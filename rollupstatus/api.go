@@ -0,0 +1,63 @@
+package rollupstatus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/erigontech/erigon-lib/kv"
+
+	"github.com/erigontech/erigon/core/rawdb"
+	"github.com/erigontech/erigon/rpc"
+)
+
+// API - rollup_ namespace of the prober
+type API struct {
+	prober *Prober
+}
+
+// SyncStatus - result of the last probe of derivation health
+func (api *API) SyncStatus(_ context.Context) (*SyncStatus, error) {
+	status := api.prober.status.Load()
+	if status == nil {
+		return nil, errors.New("derivation health is not probed yet")
+	}
+	return status, nil
+}
+
+// L1Origin - L1 origin of the L2 block checked against the L1 view of this node (--rollup.l1view)
+func (api *API) L1Origin(ctx context.Context, number rpc.BlockNumber) (*L1Origin, error) {
+	p := api.prober
+	if p.l1View == nil {
+		return nil, errors.New("L1 view is not enabled, see --rollup.l1view")
+	}
+	var origin *L1Origin
+	err := p.db.View(ctx, func(tx kv.Tx) error {
+		n := uint64(number.Int64())
+		if number < 0 {
+			// tags are resolved by the forkchoice of this node
+			hash := rawdb.ReadHeadBlockHash(tx)
+			switch number {
+			case rpc.SafeBlockNumber:
+				hash = rawdb.ReadForkchoiceSafe(tx)
+			case rpc.FinalizedBlockNumber:
+				hash = rawdb.ReadForkchoiceFinalized(tx)
+			}
+			header := rawdb.ReadHeaderNumber(tx, hash)
+			if header == nil {
+				return fmt.Errorf("block %s not found", number)
+			}
+			n = *header
+		}
+		block, err := p.blockReader.BlockByNumber(ctx, tx, n)
+		if err != nil {
+			return err
+		}
+		if block == nil {
+			return fmt.Errorf("block %d not found", n)
+		}
+		origin, err = p.l1OriginOf(block)
+		return err
+	})
+	return origin, err
+}
@@ -0,0 +1,382 @@
+// Package rollupstatus implements the prober of derivation health: it periodically queries op-node
+// (or the sequencer) for L2 unsafe, safe and finalized heights, compares them with the heights of this node
+// and exports derivation lag by metrics and rollup_syncStatus RPC. With the L1 view of this node (l1source.View)
+// it also checks L1 origins of L2 blocks against L1, and can probe without op-node and the sequencer at all.
+package rollupstatus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon-lib/metrics"
+
+	"github.com/erigontech/erigon/core/rawdb"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/l1source"
+	"github.com/erigontech/erigon/node"
+	"github.com/erigontech/erigon/rpc"
+)
+
+// probeTimeout - max duration of one probe, including dialing
+const probeTimeout = 10 * time.Second
+
+// Sources of L2 heights
+const (
+	SourceOpNode    = "op-node"
+	SourceSequencer = "sequencer"
+	SourceL1View    = "l1-view" // L2 heights of this node, L1 heights of its L1 view
+)
+
+var (
+	unsafeL2Gauge      = metrics.GetOrCreateGauge("rollup_unsafe_l2")
+	safeL2Gauge        = metrics.GetOrCreateGauge("rollup_safe_l2")
+	finalizedL2Gauge   = metrics.GetOrCreateGauge("rollup_finalized_l2")
+	localUnsafeL2Gauge = metrics.GetOrCreateGauge("rollup_local_unsafe_l2")
+	safeLagGauge       = metrics.GetOrCreateGauge("rollup_safe_lag")
+	finalizedLagGauge  = metrics.GetOrCreateGauge("rollup_finalized_lag")
+	l1LagGauge         = metrics.GetOrCreateGauge("rollup_l1_lag")
+	localLagGauge      = metrics.GetOrCreateGauge("rollup_local_lag")
+	safeStalledGauge   = metrics.GetOrCreateGauge("rollup_safe_stalled_seconds")
+	probeErrors        = metrics.GetOrCreateCounter("rollup_probe_errors")
+)
+
+type BlockRef struct {
+	Hash   libcommon.Hash `json:"hash"`
+	Number hexutil.Uint64 `json:"number"`
+}
+
+// SyncStatus - result of the last probe. If probe failed, Error is set and remote heights are from
+// the last successful probe.
+type SyncStatus struct {
+	Source   string `json:"source"`
+	ProbedAt uint64 `json:"probedAt"` // unix seconds
+	Error    string `json:"error,omitempty"`
+
+	// Heights seen by op-node or the sequencer
+	UnsafeL2    BlockRef `json:"unsafeL2"`
+	SafeL2      BlockRef `json:"safeL2"`
+	FinalizedL2 BlockRef `json:"finalizedL2"`
+	// op-node only: L1 block derivation has reached and L1 head. With the L1 view as the source: L1 origin of
+	// LocalUnsafeL2 and L1 head of the view
+	CurrentL1 *BlockRef `json:"currentL1,omitempty"`
+	HeadL1    *BlockRef `json:"headL1,omitempty"`
+
+	// Heights of this node, safe and finalized are set by the last engine_forkchoiceUpdated
+	LocalUnsafeL2    BlockRef `json:"localUnsafeL2"`
+	LocalSafeL2      BlockRef `json:"localSafeL2"`
+	LocalFinalizedL2 BlockRef `json:"localFinalizedL2"`
+
+	// Lags in blocks. Stalled derivation makes SafeLag and FinalizedLag grow, while unsafe blocks keep coming
+	SafeLag      hexutil.Uint64 `json:"safeLag"`         // UnsafeL2 - SafeL2
+	FinalizedLag hexutil.Uint64 `json:"finalizedLag"`    // UnsafeL2 - FinalizedL2
+	L1Lag        hexutil.Uint64 `json:"l1Lag,omitempty"` // HeadL1 - CurrentL1
+	LocalLag     hexutil.Uint64 `json:"localLag"`        // UnsafeL2 - LocalUnsafeL2
+	// SafeStalledFor - seconds since SafeL2 advanced last time
+	SafeStalledFor uint64 `json:"safeStalledFor"`
+
+	// L1 origin of LocalUnsafeL2 checked against the L1 view, nil without it
+	LocalL1Origin *L1Origin `json:"localL1Origin,omitempty"`
+}
+
+// L1Origin - the L1 block an L2 block was derived from, by its L1 attributes deposit, as seen by the L1 view
+type L1Origin struct {
+	Hash   libcommon.Hash `json:"hash"`
+	Number hexutil.Uint64 `json:"number"`
+	Time   hexutil.Uint64 `json:"timestamp"`
+	// Canonical - the origin is on the L1 chain of the view, nil if the origin is out of the view
+	Canonical     *bool          `json:"canonical,omitempty"`
+	Confirmations hexutil.Uint64 `json:"confirmations"` // L1 blocks on top of the origin
+	Safe          bool           `json:"safe"`
+	Finalized     bool           `json:"finalized"`
+	WatchedLogs   hexutil.Uint64 `json:"watchedLogs"` // logs of the contracts watched by the view in the origin
+}
+
+// blockReader - the part of services.FullBlockReader which L1 origins are read with
+type blockReader interface {
+	BlockByNumber(ctx context.Context, db kv.Tx, number uint64) (*types.Block, error)
+	BlockByHash(ctx context.Context, db kv.Tx, hash libcommon.Hash) (*types.Block, error)
+}
+
+// Prober periodically probes derivation health, see SyncStatus
+type Prober struct {
+	db          kv.RoDB
+	blockReader blockReader
+	client      *rpc.Client // nil with SourceL1View
+	l1View      *l1source.View
+	source      string
+	interval    time.Duration
+
+	status         atomic.Pointer[SyncStatus]
+	lastSafe       uint64
+	safeAdvancedAt time.Time
+
+	ctx    context.Context
+	logger log.Logger
+}
+
+// New returns prober querying optimism_syncStatus of op-node, or the sequencer if opNodeURL is empty, or only
+// the L1 view if both are empty. l1View is optional otherwise.
+func New(ctx context.Context, node *node.Node, db kv.RoDB, blockReader blockReader, opNodeURL, sequencerURL string, l1View *l1source.View, interval time.Duration, logger log.Logger) (*Prober, error) {
+	source, url := SourceOpNode, opNodeURL
+	if url == "" {
+		source, url = SourceSequencer, sequencerURL
+	}
+	if url == "" {
+		source = SourceL1View
+	}
+	if source == SourceL1View && l1View == nil {
+		return nil, errors.New("rollup status prober: op-node, sequencer or L1 view is required")
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("rollup status prober: interval must be positive, got %s", interval)
+	}
+	var client *rpc.Client
+	if url != "" {
+		dialCtx, cancel := context.WithTimeout(ctx, probeTimeout)
+		var err error
+		client, err = rpc.DialContext(dialCtx, url, logger)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("rollup status prober: %w", err)
+		}
+	}
+	p := &Prober{
+		db:          db,
+		blockReader: blockReader,
+		client:      client,
+		l1View:      l1View,
+		source:      source,
+		interval:    interval,
+		ctx:         ctx,
+		logger:      logger,
+	}
+	node.RegisterLifecycle(p)
+	return p, nil
+}
+
+// Start implements node.Lifecycle, starting up the prober.
+func (p *Prober) Start() error {
+	go p.loop()
+	p.logger.Info("[rollup-status] started", "source", p.source, "interval", p.interval)
+	return nil
+}
+
+// Stop implements node.Lifecycle, terminating the prober.
+func (p *Prober) Stop() error {
+	if p.client != nil {
+		p.client.Close()
+	}
+	p.logger.Info("[rollup-status] stopped")
+	return nil
+}
+
+// APIs - rollup_ namespace
+func (p *Prober) APIs() []rpc.API {
+	return []rpc.API{{
+		Namespace: "rollup",
+		Public:    true,
+		Service:   &API{prober: p},
+		Version:   "1.0",
+	}}
+}
+
+func (p *Prober) loop() {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		p.probe(p.ctx)
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Prober) probe(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	now := time.Now()
+	status := &SyncStatus{Source: p.source, ProbedAt: uint64(now.Unix())}
+	var err error
+	switch p.source {
+	case SourceOpNode:
+		err = p.probeOpNode(ctx, status)
+	case SourceSequencer:
+		err = p.probeSequencer(ctx, status)
+	}
+	if err != nil {
+		if p.ctx.Err() != nil {
+			return
+		}
+		probeErrors.Inc()
+		p.logger.Debug("[rollup-status] probe failed", "source", p.source, "err", err)
+		status.Error = err.Error()
+		if prev := p.status.Load(); prev != nil {
+			status.UnsafeL2, status.SafeL2, status.FinalizedL2 = prev.UnsafeL2, prev.SafeL2, prev.FinalizedL2
+			status.CurrentL1, status.HeadL1 = prev.CurrentL1, prev.HeadL1
+		}
+	}
+	if err := p.db.View(ctx, func(tx kv.Tx) error {
+		status.LocalUnsafeL2 = blockRef(tx, rawdb.ReadHeadBlockHash(tx))
+		status.LocalSafeL2 = blockRef(tx, rawdb.ReadForkchoiceSafe(tx))
+		status.LocalFinalizedL2 = blockRef(tx, rawdb.ReadForkchoiceFinalized(tx))
+		if p.l1View != nil {
+			origin, err := p.l1Origin(ctx, tx, status.LocalUnsafeL2.Hash)
+			if err != nil {
+				return err
+			}
+			status.LocalL1Origin = origin
+		}
+		return nil
+	}); err != nil {
+		p.logger.Debug("[rollup-status] reading local heads failed", "err", err)
+	}
+	if p.source == SourceL1View {
+		status.UnsafeL2, status.SafeL2, status.FinalizedL2 = status.LocalUnsafeL2, status.LocalSafeL2, status.LocalFinalizedL2
+		if origin := status.LocalL1Origin; origin != nil {
+			status.CurrentL1 = &BlockRef{Hash: origin.Hash, Number: origin.Number}
+		}
+		if head := p.l1View.Head(); head != nil {
+			status.HeadL1 = &BlockRef{Hash: head.Hash(), Number: hexutil.Uint64(head.Number.Uint64())}
+		} else {
+			status.Error = "L1 is not read yet"
+		}
+	}
+
+	if uint64(status.SafeL2.Number) > p.lastSafe || p.safeAdvancedAt.IsZero() {
+		p.lastSafe, p.safeAdvancedAt = uint64(status.SafeL2.Number), now
+	}
+	status.SafeStalledFor = uint64(now.Sub(p.safeAdvancedAt).Seconds())
+	status.computeLags()
+	p.status.Store(status)
+	status.export()
+}
+
+type opNodeBlockRef struct {
+	Hash   libcommon.Hash `json:"hash"`
+	Number uint64         `json:"number"`
+}
+
+func (r opNodeBlockRef) blockRef() BlockRef {
+	return BlockRef{Hash: r.Hash, Number: hexutil.Uint64(r.Number)}
+}
+
+// opNodeSyncStatus - subset of op-node's optimism_syncStatus result
+type opNodeSyncStatus struct {
+	CurrentL1   opNodeBlockRef `json:"current_l1"`
+	HeadL1      opNodeBlockRef `json:"head_l1"`
+	UnsafeL2    opNodeBlockRef `json:"unsafe_l2"`
+	SafeL2      opNodeBlockRef `json:"safe_l2"`
+	FinalizedL2 opNodeBlockRef `json:"finalized_l2"`
+}
+
+func (p *Prober) probeOpNode(ctx context.Context, status *SyncStatus) error {
+	var res opNodeSyncStatus
+	if err := p.client.CallContext(ctx, &res, "optimism_syncStatus"); err != nil {
+		return err
+	}
+	currentL1, headL1 := res.CurrentL1.blockRef(), res.HeadL1.blockRef()
+	status.CurrentL1, status.HeadL1 = &currentL1, &headL1
+	status.UnsafeL2, status.SafeL2, status.FinalizedL2 = res.UnsafeL2.blockRef(), res.SafeL2.blockRef(), res.FinalizedL2.blockRef()
+	return nil
+}
+
+func (p *Prober) probeSequencer(ctx context.Context, status *SyncStatus) error {
+	for _, target := range []struct {
+		tag string
+		ref *BlockRef
+	}{
+		{"latest", &status.UnsafeL2},
+		{"safe", &status.SafeL2},
+		{"finalized", &status.FinalizedL2},
+	} {
+		var header *BlockRef
+		if err := p.client.CallContext(ctx, &header, "eth_getBlockByNumber", target.tag, false); err != nil {
+			return fmt.Errorf("%s block: %w", target.tag, err)
+		}
+		// sequencer has no safe or finalized blocks yet
+		if header != nil {
+			*target.ref = *header
+		}
+	}
+	return nil
+}
+
+// l1Origin - L1 origin of the L2 block, by its L1 attributes deposit. Nil if the block isn't found.
+func (p *Prober) l1Origin(ctx context.Context, tx kv.Tx, hash libcommon.Hash) (*L1Origin, error) {
+	block, err := p.blockReader.BlockByHash(ctx, tx, hash)
+	if err != nil || block == nil {
+		return nil, err
+	}
+	return p.l1OriginOf(block)
+}
+
+func (p *Prober) l1OriginOf(block *types.Block) (*L1Origin, error) {
+	info, err := types.L1BlockInfoFromBlock(block)
+	if err != nil {
+		return nil, fmt.Errorf("L2 block %d: %w", block.NumberU64(), err)
+	}
+	origin := &L1Origin{Hash: info.BlockHash, Number: hexutil.Uint64(info.Number), Time: hexutil.Uint64(info.Time)}
+	if canonical, known := p.l1View.Canonical(info.Number, info.BlockHash); known {
+		origin.Canonical = &canonical
+		if !canonical {
+			return origin, nil
+		}
+	}
+	if head := p.l1View.Head(); head != nil && head.Number.Uint64() > info.Number {
+		origin.Confirmations = hexutil.Uint64(head.Number.Uint64() - info.Number)
+	}
+	if safe := p.l1View.Safe(); safe != nil {
+		origin.Safe = safe.Number.Uint64() >= info.Number
+	}
+	if finalized := p.l1View.Finalized(); finalized != nil {
+		origin.Finalized = finalized.Number.Uint64() >= info.Number
+	}
+	origin.WatchedLogs = hexutil.Uint64(len(p.l1View.Logs(info.BlockHash)))
+	return origin, nil
+}
+
+func blockRef(tx kv.Getter, hash libcommon.Hash) BlockRef {
+	ref := BlockRef{Hash: hash}
+	if number := rawdb.ReadHeaderNumber(tx, hash); number != nil {
+		ref.Number = hexutil.Uint64(*number)
+	}
+	return ref
+}
+
+func (s *SyncStatus) computeLags() {
+	s.SafeLag = lag(s.UnsafeL2.Number, s.SafeL2.Number)
+	s.FinalizedLag = lag(s.UnsafeL2.Number, s.FinalizedL2.Number)
+	if s.HeadL1 != nil && s.CurrentL1 != nil {
+		s.L1Lag = lag(s.HeadL1.Number, s.CurrentL1.Number)
+	}
+	s.LocalLag = lag(s.UnsafeL2.Number, s.LocalUnsafeL2.Number)
+}
+
+func lag(ahead, behind hexutil.Uint64) hexutil.Uint64 {
+	if ahead < behind {
+		return 0
+	}
+	return ahead - behind
+}
+
+func (s *SyncStatus) export() {
+	unsafeL2Gauge.SetUint64(uint64(s.UnsafeL2.Number))
+	safeL2Gauge.SetUint64(uint64(s.SafeL2.Number))
+	finalizedL2Gauge.SetUint64(uint64(s.FinalizedL2.Number))
+	localUnsafeL2Gauge.SetUint64(uint64(s.LocalUnsafeL2.Number))
+	safeLagGauge.SetUint64(uint64(s.SafeLag))
+	finalizedLagGauge.SetUint64(uint64(s.FinalizedLag))
+	l1LagGauge.SetUint64(uint64(s.L1Lag))
+	localLagGauge.SetUint64(uint64(s.LocalLag))
+	safeStalledGauge.SetUint64(s.SafeStalledFor)
+}
@@ -0,0 +1,119 @@
+package rollupstatus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/kv/memdb"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/rpc"
+)
+
+// fakeRPC answers JSON-RPC requests by results keyed by method or method/first param, missing key is an error
+type fakeRPC struct {
+	lock    sync.Mutex
+	results map[string]string
+}
+
+func (f *fakeRPC) set(results map[string]string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.results = results
+}
+
+func (f *fakeRPC) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	key := req.Method
+	if len(req.Params) > 0 {
+		var tag string
+		_ = json.Unmarshal(req.Params[0], &tag)
+		key += "/" + tag
+	}
+	f.lock.Lock()
+	result, ok := f.results[key]
+	f.lock.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	if !ok {
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"error":{"code":-32000,"message":"unavailable"}}`, req.ID)
+		return
+	}
+	fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":%s}`, req.ID, result)
+}
+
+func newTestProber(t *testing.T, source string, results map[string]string) (*Prober, *fakeRPC) {
+	f := &fakeRPC{results: results}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	client, err := rpc.DialContext(context.Background(), srv.URL, log.New())
+	require.NoError(t, err)
+	t.Cleanup(client.Close)
+	return &Prober{
+		db:     memdb.NewTestDB(t),
+		client: client,
+		source: source,
+		ctx:    context.Background(),
+		logger: log.New(),
+	}, f
+}
+
+func TestProbeOpNode(t *testing.T) {
+	p, f := newTestProber(t, SourceOpNode, map[string]string{
+		"optimism_syncStatus": `{"current_l1":{"number":90},"head_l1":{"number":100},"unsafe_l2":{"number":1000},"safe_l2":{"number":900},"finalized_l2":{"number":800}}`,
+	})
+	api := &API{prober: p}
+	_, err := api.SyncStatus(context.Background())
+	require.Error(t, err)
+
+	p.probe(context.Background())
+	status, err := api.SyncStatus(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, status.Error)
+	require.Equal(t, hexutil.Uint64(900), status.SafeL2.Number)
+	require.Equal(t, hexutil.Uint64(100), status.SafeLag)
+	require.Equal(t, hexutil.Uint64(200), status.FinalizedLag)
+	require.Equal(t, hexutil.Uint64(10), status.L1Lag)
+	require.Equal(t, hexutil.Uint64(1000), status.LocalLag)
+
+	// failed probe keeps heights of the last successful one
+	f.set(nil)
+	p.probe(context.Background())
+	status, err = api.SyncStatus(context.Background())
+	require.NoError(t, err)
+	require.NotEmpty(t, status.Error)
+	require.Equal(t, hexutil.Uint64(1000), status.UnsafeL2.Number)
+	require.Equal(t, hexutil.Uint64(100), status.SafeLag)
+}
+
+func TestProbeSequencer(t *testing.T) {
+	p, _ := newTestProber(t, SourceSequencer, map[string]string{
+		"eth_getBlockByNumber/latest":    `{"number":"0x10"}`,
+		"eth_getBlockByNumber/safe":      `{"number":"0x8"}`,
+		"eth_getBlockByNumber/finalized": `null`,
+	})
+	p.probe(context.Background())
+	status := p.status.Load()
+	require.NotNil(t, status)
+	require.Empty(t, status.Error)
+	require.Nil(t, status.HeadL1)
+	require.Equal(t, hexutil.Uint64(16), status.UnsafeL2.Number)
+	require.Equal(t, hexutil.Uint64(8), status.SafeLag)
+	require.Equal(t, hexutil.Uint64(16), status.FinalizedLag)
+	require.Zero(t, status.L1Lag)
+}
@@ -0,0 +1,176 @@
+package rpc
+
+import (
+	"encoding/json"
+	"io"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"gopkg.in/natefinch/lumberjack.v2"
+
+	"github.com/erigontech/erigon-lib/metrics"
+)
+
+const (
+	AccessLogStdout = "stdout"
+
+	accessLogQueueSize  = 4096
+	accessLogMaxSizeMB  = 100
+	accessLogMaxBackups = 10
+)
+
+var accessLogDropped = metrics.GetOrCreateCounter("rpc_accesslog_dropped")
+
+// AccessLogRecord - one served call, written as a JSON line
+type AccessLogRecord struct {
+	Time         time.Time `json:"ts"`
+	Method       string    `json:"method"`
+	ParamsSize   int       `json:"paramsSize"`
+	DurationMs   float64   `json:"durationMs"`
+	ErrorCode    int       `json:"errorCode,omitempty"`
+	Client       string    `json:"client,omitempty"`
+	ForwardedFor string    `json:"forwardedFor,omitempty"`
+	UserAgent    string    `json:"userAgent,omitempty"`
+	BytesOut     int       `json:"bytesOut"`
+}
+
+// AccessLog writes records of served calls to stdout or to rotating files. Records are written by
+// a background goroutine: if it can't keep up, records are dropped (rpc_accesslog_dropped) instead of
+// slowing down the server.
+type AccessLog struct {
+	w          io.WriteCloser
+	sampleRate float64
+	slow       time.Duration // calls slower than it are logged regardless of sampling, 0 - disabled
+
+	lock    sync.RWMutex // protects records from sending after Close
+	closed  bool
+	records chan *AccessLogRecord
+	done    chan struct{}
+}
+
+// NewAccessLog - dest is AccessLogStdout or path of the file. Fraction sampleRate of successful calls
+// is logged, failed calls and calls slower than slow are always logged.
+func NewAccessLog(dest string, sampleRate float64, slow time.Duration) *AccessLog {
+	var w io.WriteCloser
+	if dest == AccessLogStdout {
+		w = nopCloser{os.Stdout}
+	} else {
+		w = &lumberjack.Logger{
+			Filename:   dest,
+			MaxSize:    accessLogMaxSizeMB,
+			MaxBackups: accessLogMaxBackups,
+		}
+	}
+	return newAccessLog(w, sampleRate, slow)
+}
+
+func newAccessLog(w io.WriteCloser, sampleRate float64, slow time.Duration) *AccessLog {
+	l := &AccessLog{
+		w:          w,
+		sampleRate: sampleRate,
+		slow:       slow,
+		records:    make(chan *AccessLogRecord, accessLogQueueSize),
+		done:       make(chan struct{}),
+	}
+	go l.loop()
+	return l
+}
+
+func (l *AccessLog) loop() {
+	defer close(l.done)
+	for rec := range l.records {
+		line, err := json.Marshal(rec)
+		if err != nil {
+			continue
+		}
+		_, _ = l.w.Write(append(line, '\n'))
+	}
+}
+
+// Close flushes queued records, calls served after it aren't logged
+func (l *AccessLog) Close() error {
+	l.lock.Lock()
+	l.closed = true
+	close(l.records)
+	l.lock.Unlock()
+	<-l.done
+	return l.w.Close()
+}
+
+func (l *AccessLog) sampled(rec *AccessLogRecord) bool {
+	if rec.ErrorCode != 0 || (l.slow > 0 && rec.DurationMs >= float64(l.slow.Milliseconds())) {
+		return true
+	}
+	return l.sampleRate >= 1 || rand.Float64() < l.sampleRate
+}
+
+func (l *AccessLog) log(rec *AccessLogRecord) {
+	if !l.sampled(rec) {
+		return
+	}
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	if l.closed {
+		return
+	}
+	select {
+	case l.records <- rec:
+	default:
+		accessLogDropped.Inc()
+	}
+}
+
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
+
+// streamStats - attached to jsoniter.Stream when access log is enabled: counts bytes flushed from the
+// stream and keeps error of streamed response, which isn't returned as a message
+type streamStats struct {
+	w       io.Writer
+	flushed int
+	err     error
+}
+
+func (s *streamStats) Write(p []byte) (int, error) {
+	n, err := s.w.Write(p)
+	s.flushed += n
+	return n, err
+}
+
+// newStream - stream writing to w, with streamStats if withStats
+func newStream(w io.Writer, withStats bool) *jsoniter.Stream {
+	if !withStats {
+		return jsoniter.NewStream(jsoniter.ConfigDefault, w, 4096)
+	}
+	stats := &streamStats{w: w}
+	out := io.Writer(stats)
+	if w == nil { // data stays in the buffer
+		out = nil
+	}
+	stream := jsoniter.NewStream(jsoniter.ConfigDefault, out, 4096)
+	stream.Attachment = stats
+	return stream
+}
+
+// streamWritten - bytes written to the stream so far
+func streamWritten(stream *jsoniter.Stream) int {
+	if stream == nil {
+		return 0
+	}
+	written := stream.Buffered()
+	if stats, ok := stream.Attachment.(*streamStats); ok {
+		written += stats.flushed
+	}
+	return written
+}
+
+func errorCode(err error) int {
+	if ec, ok := err.(Error); ok {
+		return ec.ErrorCode()
+	}
+	return defaultErrorCode
+}
@@ -0,0 +1,72 @@
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/log/v3"
+)
+
+func TestAccessLog(t *testing.T) {
+	logger := log.New()
+	server := newTestServer(logger)
+	defer server.Stop()
+	var buf bytes.Buffer
+	accessLog := newAccessLog(nopCloser{&buf}, 1, 0)
+	server.SetAccessLog(accessLog)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	client, err := DialHTTP(ts.URL, logger)
+	require.NoError(t, err)
+	defer client.Close()
+	var res echoResult
+	require.NoError(t, client.Call(&res, "test_echo", "hello", 1, &echoArgs{"world"}))
+	require.Error(t, client.Call(nil, "test_returnError"))
+	batch := []BatchElem{
+		{Method: "test_echo", Args: []interface{}{"a", 1, &echoArgs{"b"}}, Result: &echoResult{}},
+		{Method: "test_returnError", Result: &echoResult{}},
+	}
+	require.NoError(t, client.BatchCall(batch))
+	require.NoError(t, accessLog.Close())
+
+	var records []AccessLogRecord
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var rec AccessLogRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &rec))
+		records = append(records, rec)
+	}
+	require.Len(t, records, 4)
+	require.Equal(t, "test_echo", records[0].Method)
+	require.Positive(t, records[0].ParamsSize)
+	require.Positive(t, records[0].BytesOut)
+	require.Zero(t, records[0].ErrorCode)
+	require.NotEmpty(t, records[0].Client)
+	require.Equal(t, "test_returnError", records[1].Method)
+	require.Equal(t, testError{}.ErrorCode(), records[1].ErrorCode)
+
+	// the calls of a batch are logged with the size of their own answers, in any order
+	for _, rec := range records[2:] {
+		require.Positive(t, rec.BytesOut)
+		if rec.Method == "test_echo" {
+			require.Zero(t, rec.ErrorCode)
+		} else {
+			require.Equal(t, testError{}.ErrorCode(), rec.ErrorCode)
+		}
+	}
+}
+
+func TestAccessLogSampling(t *testing.T) {
+	l := &AccessLog{sampleRate: 0}
+	require.False(t, l.sampled(&AccessLogRecord{DurationMs: 10}))
+	require.True(t, l.sampled(&AccessLogRecord{ErrorCode: -32000}))
+	l.slow = 5_000_000 // 5ms
+	require.True(t, l.sampled(&AccessLogRecord{DurationMs: 10}))
+	require.False(t, l.sampled(&AccessLogRecord{DurationMs: 1}))
+}
@@ -91,14 +91,16 @@ type Client struct {
 
 	// for dispatch
 	close       chan struct{}
-	closing     chan struct{}    // closed when client is quitting
-	didClose    chan struct{}    // closed when client quits
-	reconnected chan ServerCodec // where write/reconnect sends the new connection
-	readOp      chan readOp      // read messages
-	readErr     chan error       // errors from read
-	reqInit     chan *requestOp  // register response IDs, takes write lock
-	reqSent     chan error       // signals write completion, releases write lock
-	reqTimeout  chan *requestOp  // removes response IDs when call timeout expires
+	closing     chan struct{}      // closed when client is quitting
+	didClose    chan struct{}      // closed when client quits
+	reconnected chan ServerCodec   // where write/reconnect sends the new connection
+	readOp      chan readOp        // read messages
+	readErr     chan error         // errors from read
+	reqInit     chan *requestOp    // register response IDs, takes write lock
+	reqSent     chan error         // signals write completion, releases write lock
+	reqTimeout  chan *requestOp    // removes response IDs when call timeout expires
+	accessLog   *AccessLog         // calls served over the connection, nil - disabled
+	limits      func() *callLimits // current limits of the calls served over the connection, nil - no limits
 	logger      log.Logger
 }
 
@@ -113,7 +115,12 @@ type clientConn struct {
 
 func (c *Client) newClientConn(conn ServerCodec) *clientConn {
 	ctx := context.WithValue(context.Background(), clientContextKey{}, c)
+	if wc, ok := conn.(*websocketCodec); ok {
+		ctx = wc.peerContext(ctx)
+	}
 	handler := newHandler(ctx, conn, c.idgen, c.services, c.methodAllowList, 50, false /* traceRequests */, c.logger, 0)
+	handler.accessLog = c.accessLog
+	handler.limits = c.limits
 	return &clientConn{conn, handler}
 }
 
@@ -198,12 +205,12 @@ func newClient(initctx context.Context, connect reconnectFunc, logger log.Logger
 	if err != nil {
 		return nil, err
 	}
-	c := initClient(conn, randomIDGenerator(), &serviceRegistry{logger: logger}, logger)
+	c := initClient(conn, randomIDGenerator(), &serviceRegistry{logger: logger}, nil, nil, logger)
 	c.reconnectFunc = connect
 	return c, nil
 }
 
-func initClient(conn ServerCodec, idgen func() ID, services *serviceRegistry, logger log.Logger) *Client {
+func initClient(conn ServerCodec, idgen func() ID, services *serviceRegistry, accessLog *AccessLog, limits func() *callLimits, logger log.Logger) *Client {
 	_, isHTTP := conn.(*httpConn)
 	c := &Client{
 		idgen:       idgen,
@@ -219,6 +226,8 @@ func initClient(conn ServerCodec, idgen func() ID, services *serviceRegistry, lo
 		reqInit:     make(chan *requestOp),
 		reqSent:     make(chan error, 1),
 		reqTimeout:  make(chan *requestOp),
+		accessLog:   accessLog,
+		limits:      limits,
 		logger:      logger,
 	}
 	if !isHTTP {
@@ -48,6 +48,34 @@ func (e *methodNotFoundError) Error() string {
 	return fmt.Sprintf("the method %s does not exist/is not available", e.method)
 }
 
+// too many calls of the method are being served, see EIP-1474
+type limitExceededError struct{ method string }
+
+func (e *limitExceededError) ErrorCode() int { return -32005 }
+
+func (e *limitExceededError) Error() string {
+	return fmt.Sprintf("too many concurrent %s requests, try again later", e.method)
+}
+
+type responseTooLargeError struct{ limit int }
+
+func (e *responseTooLargeError) ErrorCode() int { return -32003 }
+
+func (e *responseTooLargeError) Error() string {
+	return fmt.Sprintf("batch response exceeds %d bytes (can increase by --rpc.batch.responselimit), send smaller batches", e.limit)
+}
+
+type resultTooLargeError struct {
+	method string
+	limit  int
+}
+
+func (e *resultTooLargeError) ErrorCode() int { return -32003 }
+
+func (e *resultTooLargeError) Error() string {
+	return fmt.Sprintf("%s result exceeds %d bytes, request a smaller range", e.method, e.limit)
+}
+
 type subscriptionNotFoundError struct{ namespace, subscription string }
 
 func (e *subscriptionNotFoundError) ErrorCode() int { return -32601 }
@@ -24,6 +24,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/erigontech/erigon-lib/log/v3"
@@ -76,6 +77,17 @@ type handler struct {
 	//slow requests
 	slowLogThreshold time.Duration
 	slowLogBlacklist []string
+
+	accessLog *AccessLog         // nil - disabled
+	limits    func() *callLimits // current limits of the server, nil - no limits
+}
+
+// callLimits - read per batch or call, so the reloaded limits apply to the open websocket connections too
+func (h *handler) callLimits() *callLimits {
+	if h.limits == nil {
+		return nil
+	}
+	return h.limits()
 }
 
 type callProc struct {
@@ -180,11 +192,15 @@ func (h *handler) handleBatch(msgs []*jsonrpcMessage) {
 	h.startCallProc(func(cp *callProc) {
 		// All goroutines will place results right to this array. Because requests order must match reply orders.
 		answersWithNils := make([]interface{}, len(msgs))
+		// the calls after the response size limit is reached are not executed
+		responseLimit := h.callLimits().batchResponseMaxSize()
+		answerSizes := make([]int, len(calls))
+		var responseSize atomic.Int64
 		// Bounded parallelism pattern explanation https://blog.golang.org/pipelines#TOC_9.
 		boundedConcurrency := make(chan struct{}, h.maxBatchConcurrency)
 		defer close(boundedConcurrency)
 		wg := sync.WaitGroup{}
-		wg.Add(len(msgs))
+		wg.Add(len(calls))
 		for i := range calls {
 			boundedConcurrency <- struct{}{}
 			go func(i int) {
@@ -198,24 +214,51 @@ func (h *handler) handleBatch(msgs []*jsonrpcMessage) {
 					return
 				default:
 				}
+				if responseLimit > 0 && responseSize.Load() > int64(responseLimit) {
+					if calls[i].isCall() {
+						answersWithNils[i] = calls[i].errorResponse(&responseTooLargeError{limit: responseLimit})
+					}
+					return
+				}
 
 				buf := bytes.NewBuffer(nil)
-				stream := jsoniter.NewStream(jsoniter.ConfigDefault, buf, 4096)
-				if res := h.handleCallMsg(cp, calls[i], stream); res != nil {
-					answersWithNils[i] = res
-				}
+				stream := newStream(buf, h.accessLog != nil)
+				start := time.Now()
+				res := h.handleCallMsg(cp, calls[i], stream)
 				_ = stream.Flush()
-				if buf.Len() > 0 && answersWithNils[i] == nil {
-					answersWithNils[i] = json.RawMessage(buf.Bytes())
+				// the answer is encoded here, written as is with the batch
+				var encoded json.RawMessage
+				if res != nil {
+					encoded, _ = json.Marshal(res)
+				} else if buf.Len() > 0 {
+					encoded = buf.Bytes()
+				}
+				h.logAccess(cp.ctx, calls[i], res, stream, len(encoded), start)
+				if encoded != nil {
+					answersWithNils[i] = encoded
+				}
+				if responseLimit > 0 && answersWithNils[i] != nil {
+					answerSizes[i] = answerSize(answersWithNils[i])
+					responseSize.Add(int64(answerSizes[i]))
 				}
 			}(i)
 		}
 		wg.Wait()
 		answers := make([]interface{}, 0, len(msgs))
-		for _, answer := range answersWithNils {
-			if answer != nil {
-				answers = append(answers, answer)
+		size := 0
+		for i, answer := range answersWithNils {
+			if answer == nil {
+				continue
+			}
+			if responseLimit > 0 {
+				// the calls running concurrently may go over the limit: the rest of the batch gets the error,
+				// the client can resend it
+				size += answerSizes[i]
+				if size > responseLimit {
+					answer = calls[i].errorResponse(&responseTooLargeError{limit: responseLimit})
+				}
 			}
+			answers = append(answers, answer)
 		}
 		h.addSubscriptions(cp.notifiers)
 		if len(answers) > 0 {
@@ -235,15 +278,17 @@ func (h *handler) handleMsg(msg *jsonrpcMessage, stream *jsoniter.Stream) {
 	h.startCallProc(func(cp *callProc) {
 		needWriteStream := false
 		if stream == nil {
-			stream = jsoniter.NewStream(jsoniter.ConfigDefault, nil, 4096)
+			stream = newStream(nil, h.accessLog != nil)
 			needWriteStream = true
 		}
+		start, written := time.Now(), streamWritten(stream)
 		answer := h.handleCallMsg(cp, msg, stream)
 		h.addSubscriptions(cp.notifiers)
 		if answer != nil {
 			buffer, _ := json.Marshal(answer)
 			stream.Write(buffer)
 		}
+		h.logAccess(cp.ctx, msg, answer, stream, streamWritten(stream)-written, start)
 		if needWriteStream {
 			h.conn.WriteJSON(cp.ctx, json.RawMessage(stream.Buffer()))
 		} else {
@@ -450,6 +495,38 @@ func (h *handler) handleCallMsg(ctx *callProc, msg *jsonrpcMessage, stream *json
 	}
 }
 
+// logAccess - of a call answered by resp, or by what it streamed if resp is nil. bytesOut is the size of the answer
+// as it's written, counted by the caller writing it.
+func (h *handler) logAccess(ctx context.Context, msg *jsonrpcMessage, resp *jsonrpcMessage, stream *jsoniter.Stream, bytesOut int, start time.Time) {
+	if h.accessLog == nil || !msg.isCall() {
+		return
+	}
+	rec := &AccessLogRecord{
+		Time:       start,
+		Method:     msg.Method,
+		ParamsSize: len(msg.Params),
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+		Client:     h.conn.remoteAddr(),
+		BytesOut:   bytesOut,
+	}
+	if forwardedFor, ok := ctx.Value("X-Forwarded-For").(string); ok {
+		rec.ForwardedFor = forwardedFor
+	}
+	if userAgent, ok := ctx.Value("User-Agent").(string); ok {
+		rec.UserAgent = userAgent
+	}
+	if resp != nil {
+		if resp.Error != nil {
+			rec.ErrorCode = resp.Error.Code
+		}
+	} else if stream != nil {
+		if stats, ok := stream.Attachment.(*streamStats); ok && stats.err != nil {
+			rec.ErrorCode = errorCode(stats.err)
+		}
+	}
+	h.accessLog.log(rec)
+}
+
 func (h *handler) isMethodAllowedByGranularControl(method string) bool {
 	_, isForbidden := h.forbiddenList[method]
 	if len(h.allowList) == 0 {
@@ -465,10 +542,11 @@ func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage, stream *jsoniter
 	if msg.isSubscribe() {
 		return h.handleSubscribe(cp, msg, stream)
 	}
+	limits := h.callLimits()
 	var callb *callback
 	if msg.isUnsubscribe() {
 		callb = h.unsubscribeCb
-	} else if h.isMethodAllowedByGranularControl(msg.Method) {
+	} else if h.isMethodAllowedByGranularControl(msg.Method) && !limits.isDenied(msg.Method) {
 		callb = h.reg.callback(msg.Method)
 	}
 	if callb == nil {
@@ -478,8 +556,36 @@ func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage, stream *jsoniter
 	if err != nil {
 		return msg.errorResponse(&InvalidParamsError{err.Error()})
 	}
+	release, ok := limits.acquire(msg.Method)
+	if !ok {
+		return msg.errorResponse(&limitExceededError{method: msg.Method})
+	}
+	defer release()
+	cache := limits.cache(msg.Method)
+	if cache != nil {
+		if result, ok := cache.get(msg.Params); ok {
+			return &jsonrpcMessage{Version: vsn, ID: msg.ID, Result: result}
+		}
+	}
+	ctx := cp.ctx
+	if timeout := limits.timeout(msg.Method); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	if gasCap := limits.gasCap(msg.Method); gasCap > 0 {
+		ctx = context.WithValue(ctx, gasCapKey{}, gasCap)
+	}
 	start := time.Now()
-	answer := h.runMethod(cp.ctx, msg, callb, args, stream)
+	var answer *jsonrpcMessage
+	if resultLimit := limits.resultLimit(msg.Method); resultLimit > 0 {
+		answer = h.runMethodWithResultLimit(ctx, msg, callb, args, stream, resultLimit)
+	} else {
+		answer = h.runMethod(ctx, msg, callb, args, stream)
+	}
+	if cache != nil && answer != nil && answer.Error == nil { // streamed responses are not cached
+		cache.put(msg.Params, answer.Result)
+	}
 
 	// Collect the statistics for RPC calls if metrics is enabled.
 	// We only care about pure rpc call. Filter out subscription.
@@ -493,6 +599,38 @@ func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage, stream *jsoniter
 	return answer
 }
 
+// runMethodWithResultLimit - like runMethod, but the result larger than limit is replaced by an error. The streamed
+// result is buffered to be checked before it's sent.
+func (h *handler) runMethodWithResultLimit(ctx context.Context, msg *jsonrpcMessage, callb *callback, args []reflect.Value, stream *jsoniter.Stream, limit int) *jsonrpcMessage {
+	if !callb.streamable {
+		answer := h.runMethod(ctx, msg, callb, args, stream)
+		if answer.Error == nil && len(answer.Result) > limit {
+			return msg.errorResponse(&resultTooLargeError{method: msg.Method, limit: limit})
+		}
+		return answer
+	}
+	buf := bytes.NewBuffer(nil)
+	buffered := newStream(buf, true)
+	h.runMethod(ctx, msg, callb, args, buffered)
+	if buf.Len() > limit {
+		return msg.errorResponse(&resultTooLargeError{method: msg.Method, limit: limit})
+	}
+	if stats, ok := stream.Attachment.(*streamStats); ok {
+		stats.err = buffered.Attachment.(*streamStats).err
+	}
+	stream.Write(buf.Bytes())
+	return nil
+}
+
+// answerSize - of the answer in the batch response
+func answerSize(answer interface{}) int {
+	if encoded, ok := answer.(json.RawMessage); ok {
+		return len(encoded)
+	}
+	encoded, _ := json.Marshal(answer)
+	return len(encoded)
+}
+
 // handleSubscribe processes *_subscribe method calls.
 func (h *handler) handleSubscribe(cp *callProc, msg *jsonrpcMessage, stream *jsoniter.Stream) *jsonrpcMessage {
 	if !h.allowSubscribe {
@@ -548,6 +686,9 @@ func (h *handler) runMethod(ctx context.Context, msg *jsonrpcMessage, callb *cal
 	stream.WriteObjectField("result")
 	_, err := callb.call(ctx, msg.Method, args, stream)
 	if err != nil {
+		if stats, ok := stream.Attachment.(*streamStats); ok {
+			stats.err = err
+		}
 		writeNilIfNotPresent(stream)
 		stream.WriteMore()
 		HandleError(err, stream)
@@ -0,0 +1,298 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// callLimits - limits of the calls served by the server, shared by all connections. Immutable: the server
+// replaces it when the limits change, the semaphores of unchanged methods are kept.
+type callLimits struct {
+	batchResponseLimit int // bytes of a batch response, 0 - no limit
+	methodConcurrency  map[string]*methodSemaphore
+	denied             map[string]struct{}      // methods, or namespaces as "debug_*", answered as not found
+	methodTimeout      map[string]time.Duration // of the context of the call
+	methodCache        map[string]*responseCache
+	methodGasCap       map[string]uint64 // of eth_call-like methods, see GasCapFromContext
+	methodResultLimit  map[string]int    // bytes of the result
+}
+
+type gasCapKey struct{}
+
+// GasCapFromContext - the gas cap of the method (see Server.SetMethodCaps) of the call served with ctx, 0 - the method
+// isn't capped. Methods executing calls use the lower of it and their global gas cap.
+func GasCapFromContext(ctx context.Context) uint64 {
+	gasCap, _ := ctx.Value(gasCapKey{}).(uint64)
+	return gasCap
+}
+
+type methodSemaphore struct {
+	limit uint
+	sem   *semaphore.Weighted
+}
+
+func (l *callLimits) withBatchResponseLimit(limit int) *callLimits {
+	n := &callLimits{methodConcurrency: map[string]*methodSemaphore{}}
+	if l != nil {
+		*n = *l
+	}
+	n.batchResponseLimit = limit
+	return n
+}
+
+func (l *callLimits) withMethodConcurrency(limits map[string]uint) *callLimits {
+	n := &callLimits{}
+	if l != nil {
+		*n = *l
+	}
+	n.methodConcurrency = make(map[string]*methodSemaphore, len(limits))
+	for method, limit := range limits {
+		if limit == 0 {
+			continue
+		}
+		if l != nil {
+			if s, ok := l.methodConcurrency[method]; ok && s.limit == limit {
+				n.methodConcurrency[method] = s // calls in flight keep counting
+				continue
+			}
+		}
+		n.methodConcurrency[method] = &methodSemaphore{limit: limit, sem: semaphore.NewWeighted(int64(limit))}
+	}
+	return n
+}
+
+// withMethodPolicy - denied methods, timeouts and response caches of methods. The cached responses of methods with
+// the same ttl are kept.
+func (l *callLimits) withMethodPolicy(deny []string, timeouts, cacheTTLs map[string]time.Duration) *callLimits {
+	n := &callLimits{methodConcurrency: map[string]*methodSemaphore{}}
+	if l != nil {
+		*n = *l
+	}
+	n.denied = make(map[string]struct{}, len(deny))
+	for _, method := range deny {
+		n.denied[method] = struct{}{}
+	}
+	n.methodTimeout = timeouts
+	n.methodCache = make(map[string]*responseCache, len(cacheTTLs))
+	for method, ttl := range cacheTTLs {
+		if ttl <= 0 {
+			continue
+		}
+		if l != nil {
+			if c, ok := l.methodCache[method]; ok && c.ttl == ttl {
+				n.methodCache[method] = c
+				continue
+			}
+		}
+		n.methodCache[method] = newResponseCache(ttl)
+	}
+	return n
+}
+
+// withMethodCaps - gas caps and result size limits of methods, 0 - not capped
+func (l *callLimits) withMethodCaps(gasCaps, resultLimits map[string]uint64) *callLimits {
+	n := &callLimits{methodConcurrency: map[string]*methodSemaphore{}}
+	if l != nil {
+		*n = *l
+	}
+	n.methodGasCap = make(map[string]uint64, len(gasCaps))
+	for method, gasCap := range gasCaps {
+		if gasCap > 0 {
+			n.methodGasCap[method] = gasCap
+		}
+	}
+	n.methodResultLimit = make(map[string]int, len(resultLimits))
+	for method, limit := range resultLimits {
+		if limit > 0 {
+			n.methodResultLimit[method] = int(limit)
+		}
+	}
+	return n
+}
+
+// gasCap - of the calls of the method, 0 - not capped
+func (l *callLimits) gasCap(method string) uint64 {
+	if l == nil {
+		return 0
+	}
+	return l.methodGasCap[method]
+}
+
+// resultLimit - bytes of the result of the method, 0 - no limit
+func (l *callLimits) resultLimit(method string) int {
+	if l == nil {
+		return 0
+	}
+	return l.methodResultLimit[method]
+}
+
+func (l *callLimits) isDenied(method string) bool {
+	if l == nil || len(l.denied) == 0 {
+		return false
+	}
+	if _, ok := l.denied[method]; ok {
+		return true
+	}
+	namespace, _, _ := strings.Cut(method, serviceMethodSeparator)
+	_, ok := l.denied[namespace+serviceMethodSeparator+"*"]
+	return ok
+}
+
+// timeout - of the calls of the method, 0 - no timeout
+func (l *callLimits) timeout(method string) time.Duration {
+	if l == nil {
+		return 0
+	}
+	return l.methodTimeout[method]
+}
+
+// cache - of the responses of the method, nil - not cached
+func (l *callLimits) cache(method string) *responseCache {
+	if l == nil {
+		return nil
+	}
+	return l.methodCache[method]
+}
+
+func (l *callLimits) batchResponseMaxSize() int {
+	if l == nil {
+		return 0
+	}
+	return l.batchResponseLimit
+}
+
+// acquire - a slot of the method, ok is false if all slots are busy
+func (l *callLimits) acquire(method string) (release func(), ok bool) {
+	if l == nil {
+		return func() {}, true
+	}
+	s, limited := l.methodConcurrency[method]
+	if !limited {
+		return func() {}, true
+	}
+	if !s.sem.TryAcquire(1) {
+		return nil, false
+	}
+	return func() { s.sem.Release(1) }, true
+}
+
+// ParseMethodConcurrency parses the limits of concurrent calls: "eth_getLogs=8,trace_filter=2"
+func ParseMethodConcurrency(s string) (map[string]uint, error) {
+	values, err := parseMethodValues("method concurrency", s, 32)
+	if err != nil {
+		return nil, err
+	}
+	limits := make(map[string]uint, len(values))
+	for method, v := range values {
+		limits[method] = uint(v)
+	}
+	return limits, nil
+}
+
+// ParseMethodCaps parses the gas caps or the result limits of methods: "eth_call=25000000,eth_estimateGas=25000000"
+func ParseMethodCaps(s string) (map[string]uint64, error) {
+	return parseMethodValues("method cap", s, 64)
+}
+
+func parseMethodValues(what, s string, bitSize int) (map[string]uint64, error) {
+	values := map[string]uint64{}
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		method, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s %q: expected method=limit", what, item)
+		}
+		n, err := strconv.ParseUint(strings.TrimSpace(value), 10, bitSize)
+		if err != nil {
+			return nil, fmt.Errorf("%s %q: %w", what, item, err)
+		}
+		values[strings.TrimSpace(method)] = n
+	}
+	return values, nil
+}
+
+func (l *callLimits) String() string {
+	if l == nil {
+		return ""
+	}
+	methods := make([]string, 0, len(l.methodConcurrency))
+	for method, s := range l.methodConcurrency {
+		methods = append(methods, fmt.Sprintf("%s=%d", method, s.limit))
+	}
+	sort.Strings(methods)
+	return strings.Join(methods, ",")
+}
+
+// maxCachedResponses - of a method, the cache is emptied when it's full of unexpired responses
+const maxCachedResponses = 4096
+
+// responseCache - successful responses of a method by its params, reused for ttl. For methods whose result doesn't
+// change often (eth_chainId, eth_gasPrice), a "latest" block param is not resolved - the result may be ttl behind.
+type responseCache struct {
+	ttl     time.Duration
+	lock    sync.Mutex
+	entries map[string]cachedResponse
+}
+
+type cachedResponse struct {
+	result  json.RawMessage
+	expires time.Time
+}
+
+func newResponseCache(ttl time.Duration) *responseCache {
+	return &responseCache{ttl: ttl, entries: map[string]cachedResponse{}}
+}
+
+func (c *responseCache) get(params json.RawMessage) (json.RawMessage, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	entry, ok := c.entries[string(params)]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+	return entry.result, true
+}
+
+func (c *responseCache) put(params, result json.RawMessage) {
+	now := time.Now()
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if len(c.entries) >= maxCachedResponses {
+		for k, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= maxCachedResponses {
+			c.entries = map[string]cachedResponse{}
+		}
+	}
+	c.entries[string(params)] = cachedResponse{result: result, expires: now.Add(c.ttl)}
+}
+
+// ParseMethodDurations parses the timeouts or cache ttls of methods: {"eth_call": "5s"}
+func ParseMethodDurations(durations map[string]string) (map[string]time.Duration, error) {
+	parsed := make(map[string]time.Duration, len(durations))
+	for method, s := range durations {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", method, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("%s: duration %s is not positive", method, s)
+		}
+		parsed[method] = d
+	}
+	return parsed, nil
+}
@@ -0,0 +1,225 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/log/v3"
+)
+
+func TestBatchResponseLimit(t *testing.T) {
+	logger := log.New()
+	server := newTestServer(logger)
+	defer server.Stop()
+	server.SetBatchResponseLimit(100) // one echo fits
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	client, err := DialHTTP(ts.URL, logger)
+	require.NoError(t, err)
+	defer client.Close()
+
+	batch := make([]BatchElem, 3)
+	for i := range batch {
+		batch[i] = BatchElem{Method: "test_echo", Args: []interface{}{"hello", i, &echoArgs{"world"}}, Result: new(echoResult)}
+	}
+	require.NoError(t, client.BatchCall(batch))
+	require.NoError(t, batch[0].Error)
+	require.Equal(t, 0, batch[0].Result.(*echoResult).Int)
+	for _, elem := range batch[1:] {
+		var rpcErr Error
+		require.True(t, errors.As(elem.Error, &rpcErr), elem.Error)
+		require.Equal(t, (&responseTooLargeError{}).ErrorCode(), rpcErr.ErrorCode())
+	}
+
+	server.SetBatchResponseLimit(0)
+	require.NoError(t, client.BatchCall(batch))
+	for _, elem := range batch {
+		require.NoError(t, elem.Error)
+	}
+}
+
+type capsService struct{ calls atomic.Int32 }
+
+func (s *capsService) Count() int32 { return s.calls.Add(1) }
+
+func (s *capsService) GasCap(ctx context.Context) uint64 { return GasCapFromContext(ctx) }
+
+func TestBatchStopsAtResponseLimit(t *testing.T) {
+	logger := log.New()
+	server := newTestServer(logger)
+	defer server.Stop()
+	service := new(capsService)
+	require.NoError(t, server.RegisterName("caps", service))
+	server.SetBatchConcurrency(1)
+	server.SetBatchResponseLimit(1)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	client, err := DialHTTP(ts.URL, logger)
+	require.NoError(t, err)
+	defer client.Close()
+
+	batch := make([]BatchElem, 5)
+	for i := range batch {
+		batch[i] = BatchElem{Method: "caps_count", Result: new(int32)}
+	}
+	require.NoError(t, client.BatchCall(batch))
+	for _, elem := range batch {
+		var rpcErr Error
+		require.True(t, errors.As(elem.Error, &rpcErr), elem.Error)
+		require.Equal(t, (&responseTooLargeError{}).ErrorCode(), rpcErr.ErrorCode())
+	}
+	require.Equal(t, int32(1), service.calls.Load()) // the rest is not executed
+}
+
+func TestMethodCaps(t *testing.T) {
+	caps, err := ParseMethodCaps("eth_call=25000000, eth_getLogs=1000")
+	require.NoError(t, err)
+	require.Equal(t, map[string]uint64{"eth_call": 25_000_000, "eth_getLogs": 1000}, caps)
+	_, err = ParseMethodCaps("eth_call=-1")
+	require.Error(t, err)
+
+	logger := log.New()
+	server := newTestServer(logger)
+	defer server.Stop()
+	require.NoError(t, server.RegisterName("caps", new(capsService)))
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	client, err := DialHTTP(ts.URL, logger)
+	require.NoError(t, err)
+	defer client.Close()
+
+	var gasCap uint64
+	require.NoError(t, client.Call(&gasCap, "caps_gasCap"))
+	require.Zero(t, gasCap)
+	server.SetMethodCaps(map[string]uint64{"caps_gasCap": 50_000}, map[string]uint64{"test_echo": 10})
+	require.NoError(t, client.Call(&gasCap, "caps_gasCap"))
+	require.Equal(t, uint64(50_000), gasCap)
+
+	var result echoResult
+	err = client.Call(&result, "test_echo", "hello", 1, &echoArgs{"world"})
+	var rpcErr Error
+	require.True(t, errors.As(err, &rpcErr), err)
+	require.Equal(t, (&resultTooLargeError{}).ErrorCode(), rpcErr.ErrorCode())
+	require.NoError(t, client.Call(nil, "test_rets")) // not capped
+}
+
+func TestWebsocketReloadedLimits(t *testing.T) {
+	logger := log.New()
+	server := newTestServer(logger)
+	defer server.Stop()
+	ts := httptest.NewServer(server.WebsocketHandler([]string{"*"}, nil, false, logger))
+	defer ts.Close()
+	client, err := DialWebsocket(context.Background(), "ws:"+strings.TrimPrefix(ts.URL, "http:"), "", logger)
+	require.NoError(t, err)
+	defer client.Close()
+
+	require.NoError(t, client.Call(nil, "test_rets"))
+	server.SetMethodPolicy([]string{"test_rets"}, nil, nil)
+	err = client.Call(nil, "test_rets")
+	var rpcErr Error
+	require.True(t, errors.As(err, &rpcErr), err)
+	require.Equal(t, (&methodNotFoundError{}).ErrorCode(), rpcErr.ErrorCode())
+}
+
+func TestMethodConcurrency(t *testing.T) {
+	limits, err := ParseMethodConcurrency(" eth_getLogs=1, trace_filter=2,")
+	require.NoError(t, err)
+	require.Equal(t, map[string]uint{"eth_getLogs": 1, "trace_filter": 2}, limits)
+	_, err = ParseMethodConcurrency("eth_getLogs")
+	require.Error(t, err)
+
+	var l *callLimits
+	release, ok := l.acquire("eth_getLogs")
+	require.True(t, ok)
+	release()
+
+	l = l.withMethodConcurrency(limits)
+	require.Equal(t, "eth_getLogs=1,trace_filter=2", l.String())
+	release, ok = l.acquire("eth_getLogs")
+	require.True(t, ok)
+	_, ok = l.acquire("eth_getLogs")
+	require.False(t, ok)
+	_, ok = l.acquire("eth_call") // not limited
+	require.True(t, ok)
+
+	// reload: the call in flight still counts for the same limit
+	reloaded := l.withBatchResponseLimit(1_000).withMethodConcurrency(map[string]uint{"eth_getLogs": 1})
+	require.Equal(t, 1_000, reloaded.batchResponseMaxSize())
+	_, ok = reloaded.acquire("eth_getLogs")
+	require.False(t, ok)
+	_, ok = reloaded.acquire("trace_filter")
+	require.True(t, ok)
+	release()
+	_, ok = reloaded.acquire("eth_getLogs")
+	require.True(t, ok)
+}
+
+func TestMethodPolicy(t *testing.T) {
+	logger := log.New()
+	server := newTestServer(logger)
+	defer server.Stop()
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	client, err := DialHTTP(ts.URL, logger)
+	require.NoError(t, err)
+	defer client.Close()
+
+	require.True(t, server.HasMethod("test_echo"))
+	require.True(t, server.HasMethod("test_*"))
+	require.False(t, server.HasMethod("test_missing"))
+	require.False(t, server.HasMethod("debug_*"))
+
+	_, err = ParseMethodDurations(map[string]string{"test_block": "0s"})
+	require.Error(t, err)
+	timeouts, err := ParseMethodDurations(map[string]string{"test_block": "10ms"})
+	require.NoError(t, err)
+	server.SetMethodPolicy([]string{"nftest_*", "test_rets"}, timeouts, nil)
+
+	var result echoResult
+	require.NoError(t, client.Call(&result, "test_echo", "hello", 1, &echoArgs{"world"}))
+	var notFound *methodNotFoundError
+	for _, method := range []string{"test_rets", "nftest_echo"} {
+		err = client.Call(nil, method)
+		var rpcErr Error
+		require.True(t, errors.As(err, &rpcErr), err)
+		require.Equal(t, notFound.ErrorCode(), rpcErr.ErrorCode())
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	require.ErrorContains(t, client.CallContext(ctx, nil, "test_block"), "context canceled in testservice_block")
+
+	// reload: the cache of the same ttl is kept
+	server.SetMethodPolicy(nil, nil, map[string]time.Duration{"test_echo": time.Minute})
+	cache := server.callLimits().cache("test_echo")
+	require.NotNil(t, cache)
+	server.SetMethodPolicy(nil, nil, map[string]time.Duration{"test_echo": time.Minute})
+	require.Same(t, cache, server.callLimits().cache("test_echo"))
+	require.NoError(t, client.Call(nil, "test_rets"))
+}
+
+func TestResponseCache(t *testing.T) {
+	c := newResponseCache(time.Minute)
+	_, ok := c.get(json.RawMessage(`[1]`))
+	require.False(t, ok)
+	c.put(json.RawMessage(`[1]`), json.RawMessage(`"one"`))
+	result, ok := c.get(json.RawMessage(`[1]`))
+	require.True(t, ok)
+	require.Equal(t, json.RawMessage(`"one"`), result)
+	_, ok = c.get(json.RawMessage(`[2]`))
+	require.False(t, ok)
+
+	expired := newResponseCache(time.Nanosecond)
+	expired.put(json.RawMessage(`[1]`), json.RawMessage(`"one"`))
+	time.Sleep(time.Millisecond)
+	_, ok = expired.get(json.RawMessage(`[1]`))
+	require.False(t, ok)
+}
@@ -42,6 +42,9 @@ const DefaultOverlayReplayBlockTimeout = 10 * time.Second
 
 const DefaultHistoricalRPCTimeout = 5 * time.Second
 
+const DefaultSequencerRetries = 3
+const DefaultSequencerRetryBackoff = 100 * time.Millisecond
+
 var SlowLogBlackList = []string{
 	"eth_getBlock", "eth_getBlockByNumber", "eth_getBlockByHash", "eth_blockNumber",
 	"erigon_blockNumber", "erigon_getHeaderByNumber", "erigon_getHeaderByHash", "erigon_getBlockByTimestamp",
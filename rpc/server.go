@@ -20,6 +20,8 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -45,19 +47,24 @@ const (
 
 // Server is an RPC server.
 type Server struct {
-	services        serviceRegistry
-	methodAllowList AllowList
-	idgen           func() ID
-	run             int32
-	codecs          mapset.Set // mapset.Set[ServerCodec] requires go 1.20
+	services serviceRegistry
+	idgen    func() ID
+	run      int32
+	codecs   mapset.Set // mapset.Set[ServerCodec] requires go 1.20
+
+	// Access policy, can be changed while server is running
+	policyLock       sync.RWMutex
+	methodAllowList  AllowList
+	batchConcurrency uint
+	batchLimit       int // Maximum number of requests in a batch
+	limits           *callLimits
 
-	batchConcurrency    uint
 	disableStreaming    bool
 	traceRequests       bool // Whether to print requests at INFO level
 	debugSingleRequest  bool // Whether to print requests at INFO level
-	batchLimit          int  // Maximum number of requests in a batch
 	logger              log.Logger
 	rpcSlowLogThreshold time.Duration
+	accessLog           *AccessLog
 }
 
 // NewServer creates a new server instance with no registered handlers.
@@ -73,14 +80,84 @@ func NewServer(batchConcurrency uint, traceRequests, debugSingleRequest, disable
 
 // SetAllowList sets the allow list for methods that are handled by this server
 func (s *Server) SetAllowList(allowList AllowList) {
+	s.policyLock.Lock()
+	defer s.policyLock.Unlock()
 	s.methodAllowList = allowList
 }
 
 // SetBatchLimit sets limit of number of requests in a batch
 func (s *Server) SetBatchLimit(limit int) {
+	s.policyLock.Lock()
+	defer s.policyLock.Unlock()
 	s.batchLimit = limit
 }
 
+// SetBatchConcurrency sets how many requests of a batch are processed concurrently
+func (s *Server) SetBatchConcurrency(batchConcurrency uint) {
+	s.policyLock.Lock()
+	defer s.policyLock.Unlock()
+	s.batchConcurrency = batchConcurrency
+}
+
+// SetBatchResponseLimit sets limit of the size of a batch response in bytes: the calls after it get an error
+// instead of the result. 0 - no limit
+func (s *Server) SetBatchResponseLimit(limit int) {
+	s.policyLock.Lock()
+	defer s.policyLock.Unlock()
+	s.limits = s.limits.withBatchResponseLimit(limit)
+}
+
+// SetMethodConcurrency sets how many calls of a method are served concurrently over all connections,
+// the calls above it get an error
+func (s *Server) SetMethodConcurrency(limits map[string]uint) {
+	s.policyLock.Lock()
+	defer s.policyLock.Unlock()
+	s.limits = s.limits.withMethodConcurrency(limits)
+}
+
+// SetMethodCaps sets the gas caps of methods (see GasCapFromContext) and the limits of the sizes of their results in
+// bytes: a larger result is replaced by an error. Methods without a value are not capped
+func (s *Server) SetMethodCaps(gasCaps, resultLimits map[string]uint64) {
+	s.policyLock.Lock()
+	defer s.policyLock.Unlock()
+	s.limits = s.limits.withMethodCaps(gasCaps, resultLimits)
+}
+
+// SetMethodPolicy sets the methods which are disabled (a namespace as "debug_*"), the timeouts of the calls of methods
+// and how long the responses of methods are reused for the same params
+func (s *Server) SetMethodPolicy(deny []string, timeouts, cacheTTLs map[string]time.Duration) {
+	s.policyLock.Lock()
+	defer s.policyLock.Unlock()
+	s.limits = s.limits.withMethodPolicy(deny, timeouts, cacheTTLs)
+}
+
+// HasMethod - the method is registered, or any method of the namespace for "namespace_*"
+func (s *Server) HasMethod(method string) bool {
+	if namespace, ok := strings.CutSuffix(method, serviceMethodSeparator+"*"); ok {
+		s.services.mu.Lock()
+		defer s.services.mu.Unlock()
+		_, ok := s.services.services[namespace]
+		return ok
+	}
+	return s.services.callback(method) != nil
+}
+
+// MethodConcurrency - the limits of SetMethodConcurrency: "eth_getLogs=8,trace_filter=2"
+func (s *Server) MethodConcurrency() string {
+	return s.callLimits().String()
+}
+
+func (s *Server) callLimits() *callLimits {
+	s.policyLock.RLock()
+	defer s.policyLock.RUnlock()
+	return s.limits
+}
+
+// SetAccessLog enables logging of served calls, must be called before serving
+func (s *Server) SetAccessLog(accessLog *AccessLog) {
+	s.accessLog = accessLog
+}
+
 // RegisterName creates a service for the given receiver type under the given name. When no
 // methods on the given receiver match the criteria to be either a RPC method or a
 // subscription an error is returned. Otherwise a new service is created and added to the
@@ -106,7 +183,7 @@ func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
 	s.codecs.Add(codec)
 	defer s.codecs.Remove(codec)
 
-	c := initClient(codec, s.idgen, &s.services, s.logger)
+	c := initClient(codec, s.idgen, &s.services, s.accessLog, s.callLimits, s.logger)
 	<-codec.closed()
 	c.Close()
 }
@@ -120,8 +197,14 @@ func (s *Server) serveSingleRequest(ctx context.Context, codec ServerCodec, stre
 		return
 	}
 
-	h := newHandler(ctx, codec, s.idgen, &s.services, s.methodAllowList, s.batchConcurrency, s.traceRequests, s.logger, s.rpcSlowLogThreshold)
+	s.policyLock.RLock()
+	allowList, batchConcurrency, batchLimit := s.methodAllowList, s.batchConcurrency, s.batchLimit
+	s.policyLock.RUnlock()
+
+	h := newHandler(ctx, codec, s.idgen, &s.services, allowList, batchConcurrency, s.traceRequests, s.logger, s.rpcSlowLogThreshold)
 	h.allowSubscribe = false
+	h.accessLog = s.accessLog
+	h.limits = s.callLimits
 	defer h.close(io.EOF, nil)
 
 	reqs, batch, err := codec.ReadBatch()
@@ -132,8 +215,8 @@ func (s *Server) serveSingleRequest(ctx context.Context, codec ServerCodec, stre
 		return
 	}
 	if batch {
-		if s.batchLimit > 0 && len(reqs) > s.batchLimit {
-			codec.WriteJSON(ctx, errorMessage(fmt.Errorf("batch limit %d exceeded (can increase by --rpc.batch.limit). Requested batch of size: %d", s.batchLimit, len(reqs))))
+		if batchLimit > 0 && len(reqs) > batchLimit {
+			codec.WriteJSON(ctx, errorMessage(fmt.Errorf("batch limit %d exceeded (can increase by --rpc.batch.limit). Requested batch of size: %d", batchLimit, len(reqs))))
 		} else {
 			h.handleBatch(reqs)
 		}
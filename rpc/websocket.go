@@ -64,6 +64,7 @@ func (s *Server) WebsocketHandler(allowedOrigins []string, jwtSecret []byte, com
 			return
 		}
 		codec := NewWebsocketCodec(conn)
+		codec.(*websocketCodec).setPeer(r)
 		s.ServeCodec(codec, 0)
 	})
 }
@@ -242,7 +243,8 @@ func wsClientHeaders(endpoint, origin string) (string, http.Header, error) {
 
 type websocketCodec struct {
 	*jsonCodec
-	conn *websocket.Conn
+	conn         *websocket.Conn
+	forwardedFor string // X-Forwarded-For of the upgrade request
 
 	wg        sync.WaitGroup
 	pingReset chan struct{}
@@ -260,6 +262,23 @@ func NewWebsocketCodec(conn *websocket.Conn) ServerCodec {
 	return wc
 }
 
+// setPeer - the client address of the upgrade request, passed to the calls like for HTTP
+func (wc *websocketCodec) setPeer(r *http.Request) {
+	wc.remote = r.RemoteAddr
+	wc.forwardedFor = r.Header.Get("X-Forwarded-For")
+}
+
+// peerContext - ctx with the values set by Server.ServeHTTP for the client address
+func (wc *websocketCodec) peerContext(ctx context.Context) context.Context {
+	if wc.remote != "" {
+		ctx = context.WithValue(ctx, "remote", wc.remote)
+	}
+	if wc.forwardedFor != "" {
+		ctx = context.WithValue(ctx, "X-Forwarded-For", wc.forwardedFor)
+	}
+	return ctx
+}
+
 func (wc *websocketCodec) Close() {
 	wc.jsonCodec.Close()
 	wc.wg.Wait()
@@ -115,6 +115,34 @@ func TestWebsocketLargeCall(t *testing.T) {
 	}
 }
 
+// This test checks that the calls over websocket see the remote address, like over HTTP.
+func TestWebsocketRemote(t *testing.T) {
+	t.Parallel()
+	logger := log.New()
+
+	var (
+		srv     = newTestServer(logger)
+		httpsrv = httptest.NewServer(srv.WebsocketHandler([]string{"*"}, nil, false, logger))
+		wsURL   = "ws:" + strings.TrimPrefix(httpsrv.URL, "http:")
+	)
+	defer srv.Stop()
+	defer httpsrv.Close()
+
+	client, err := DialWebsocket(context.Background(), wsURL, "", logger)
+	if err != nil {
+		t.Fatalf("can't dial: %v", err)
+	}
+	defer client.Close()
+
+	var remote string
+	if err := client.Call(&remote, "test_remote"); err != nil {
+		t.Fatal(err)
+	}
+	if host, _, err := net.SplitHostPort(remote); err != nil || host != "127.0.0.1" {
+		t.Fatalf("wrong remote address %q", remote)
+	}
+}
+
 // This test checks that client handles WebSocket ping frames correctly.
 func TestClientWebsocketPing(t *testing.T) {
 	if runtime.GOOS == "windows" {
@@ -0,0 +1,116 @@
+package ethapi
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/opstack"
+	"github.com/holiman/uint256"
+
+	"github.com/erigontech/erigon/core/state"
+	"github.com/erigontech/erigon/core/vm/evmtypes"
+)
+
+// BlockOverrides - fields of the block a call is simulated in, replaced (same as geth's block overrides of
+// debug_traceCall). L1 cost params of OP Stack chains are not in the block: they are written to the storage of the
+// L1Block contract, which the L1 cost function reads.
+type BlockOverrides struct {
+	Number        *hexutil.Big       `json:"number"`
+	Difficulty    *hexutil.Big       `json:"difficulty"`
+	Time          *hexutil.Uint64    `json:"time"`
+	GasLimit      *hexutil.Uint64    `json:"gasLimit"`
+	FeeRecipient  *libcommon.Address `json:"feeRecipient"`
+	PrevRandao    *libcommon.Hash    `json:"prevRandao"`
+	BaseFeePerGas *hexutil.Big       `json:"baseFeePerGas"`
+	BlobBaseFee   *hexutil.Big       `json:"blobBaseFee"`
+
+	L1BaseFee           *hexutil.Big    `json:"l1BaseFee"`
+	L1BlobBaseFee       *hexutil.Big    `json:"l1BlobBaseFee"`
+	L1BaseFeeScalar     *hexutil.Uint64 `json:"l1BaseFeeScalar"`
+	L1BlobBaseFeeScalar *hexutil.Uint64 `json:"l1BlobBaseFeeScalar"`
+}
+
+// Override - applies the overrides to the block context, and L1 cost params to ibs. Must be called before
+// blockCtx.L1CostFunc is used.
+func (o *BlockOverrides) Override(blockCtx *evmtypes.BlockContext, ibs *state.IntraBlockState) error {
+	if o.Number != nil {
+		if !(*big.Int)(o.Number).IsUint64() {
+			return fmt.Errorf("block number %v out of range", o.Number)
+		}
+		blockCtx.BlockNumber = (*big.Int)(o.Number).Uint64()
+	}
+	if o.Difficulty != nil {
+		blockCtx.Difficulty = new(big.Int).Set((*big.Int)(o.Difficulty))
+	}
+	if o.Time != nil {
+		blockCtx.Time = uint64(*o.Time)
+	}
+	if o.GasLimit != nil {
+		blockCtx.GasLimit = uint64(*o.GasLimit)
+		blockCtx.MaxGasLimit = false
+	}
+	if o.FeeRecipient != nil {
+		blockCtx.Coinbase = *o.FeeRecipient
+	}
+	if o.PrevRandao != nil {
+		prevRandao := *o.PrevRandao
+		blockCtx.PrevRanDao = &prevRandao
+	}
+	if o.BaseFeePerGas != nil {
+		baseFee, overflow := uint256.FromBig((*big.Int)(o.BaseFeePerGas))
+		if overflow {
+			return fmt.Errorf("baseFeePerGas higher than 2^256-1")
+		}
+		blockCtx.BaseFee = baseFee
+	}
+	if o.BlobBaseFee != nil {
+		blobBaseFee, overflow := uint256.FromBig((*big.Int)(o.BlobBaseFee))
+		if overflow {
+			return fmt.Errorf("blobBaseFee higher than 2^256-1")
+		}
+		blockCtx.BlobBaseFee = blobBaseFee
+	}
+	return o.overrideL1CostParams(ibs)
+}
+
+func (o *BlockOverrides) overrideL1CostParams(ibs *state.IntraBlockState) error {
+	for _, fee := range []struct {
+		slot  libcommon.Hash
+		value *hexutil.Big
+	}{{opstack.L1BaseFeeSlot, o.L1BaseFee}, {opstack.L1BlobBaseFeeSlot, o.L1BlobBaseFee}} {
+		if fee.value == nil {
+			continue
+		}
+		value, overflow := uint256.FromBig((*big.Int)(fee.value))
+		if overflow {
+			return fmt.Errorf("L1 fee higher than 2^256-1")
+		}
+		ibs.SetState(opstack.L1BlockAddr, &fee.slot, *value)
+	}
+	if o.L1BaseFeeScalar == nil && o.L1BlobBaseFeeScalar == nil {
+		return nil
+	}
+	// both scalars are packed in one slot with the sequence number, see opstack.BaseFeeScalarSlotOffset
+	var scalars uint256.Int
+	ibs.GetState(opstack.L1BlockAddr, &opstack.L1FeeScalarsSlot, &scalars)
+	slot := scalars.Bytes32()
+	for _, scalar := range []struct {
+		offset int
+		value  *hexutil.Uint64
+	}{{opstack.BaseFeeScalarSlotOffset, o.L1BaseFeeScalar}, {opstack.BlobBaseFeeScalarSlotOffset, o.L1BlobBaseFeeScalar}} {
+		if scalar.value == nil {
+			continue
+		}
+		if uint64(*scalar.value) > 1<<32-1 {
+			return fmt.Errorf("L1 fee scalar %d higher than 2^32-1", *scalar.value)
+		}
+		end := 32 - scalar.offset
+		binary.BigEndian.PutUint32(slot[end-4:end], uint32(*scalar.value))
+	}
+	scalars.SetBytes32(slot[:])
+	ibs.SetState(opstack.L1BlockAddr, &opstack.L1FeeScalarsSlot, scalars)
+	return nil
+}
@@ -0,0 +1,56 @@
+package ethapi
+
+import (
+	"math/big"
+	"testing"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/kv/memdb"
+	"github.com/erigontech/erigon-lib/opstack"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon/core/state"
+	"github.com/erigontech/erigon/core/vm/evmtypes"
+)
+
+func TestBlockOverrides(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	ibs := state.New(state.NewPlainStateReader(tx))
+	// sequence number shares the slot with the scalars
+	var scalars uint256.Int
+	scalars.SetBytes32(libcommon.Hash{19: 1, 23: 2, 31: 7}.Bytes())
+	ibs.SetState(opstack.L1BlockAddr, &opstack.L1FeeScalarsSlot, scalars)
+
+	time, baseFeeScalar := hexutil.Uint64(1_700_000_000), hexutil.Uint64(0x01020304)
+	coinbase := libcommon.HexToAddress("0xc0ffee")
+	overrides := &BlockOverrides{
+		Number:          (*hexutil.Big)(big.NewInt(100)),
+		Time:            &time,
+		FeeRecipient:    &coinbase,
+		BaseFeePerGas:   (*hexutil.Big)(big.NewInt(7)),
+		BlobBaseFee:     (*hexutil.Big)(big.NewInt(3)),
+		L1BaseFee:       (*hexutil.Big)(big.NewInt(30_000_000_000)),
+		L1BaseFeeScalar: &baseFeeScalar,
+	}
+	blockCtx := evmtypes.BlockContext{BlockNumber: 1, Time: 1, GasLimit: 30_000_000, BaseFee: uint256.NewInt(1)}
+	require.NoError(t, overrides.Override(&blockCtx, ibs))
+
+	require.Equal(t, uint64(100), blockCtx.BlockNumber)
+	require.Equal(t, uint64(time), blockCtx.Time)
+	require.Equal(t, uint64(30_000_000), blockCtx.GasLimit)
+	require.Equal(t, coinbase, blockCtx.Coinbase)
+	require.Equal(t, uint256.NewInt(7), blockCtx.BaseFee)
+	require.Equal(t, uint256.NewInt(3), blockCtx.BlobBaseFee)
+
+	var l1BaseFee uint256.Int
+	ibs.GetState(opstack.L1BlockAddr, &opstack.L1BaseFeeSlot, &l1BaseFee)
+	require.Equal(t, uint64(30_000_000_000), l1BaseFee.Uint64())
+	ibs.GetState(opstack.L1BlockAddr, &opstack.L1FeeScalarsSlot, &scalars)
+	// base fee scalar replaced, blob base fee scalar and sequence number kept
+	require.Equal(t, libcommon.Hash{16: 1, 17: 2, 18: 3, 19: 4, 23: 2, 31: 7}, libcommon.Hash(scalars.Bytes32()))
+
+	tooBig := hexutil.Uint64(1 << 32)
+	require.Error(t, (&BlockOverrides{L1BlobBaseFeeScalar: &tooBig}).Override(&blockCtx, ibs))
+}
@@ -0,0 +1,238 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/erigontech/erigon-lib/chain"
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/datadir"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/membatchwithdb"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon-lib/state"
+	"github.com/erigontech/erigon-lib/wrap"
+	"github.com/erigontech/erigon/cmd/hack/tool/fromdb"
+	"github.com/erigontech/erigon/cmd/utils"
+	"github.com/erigontech/erigon/consensus"
+	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/core/rawdb"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/core/vm"
+	"github.com/erigontech/erigon/eth/ethconfig"
+	"github.com/erigontech/erigon/eth/ethconsensusconfig"
+	"github.com/erigontech/erigon/eth/stagedsync"
+	"github.com/erigontech/erigon/eth/stagedsync/stages"
+	"github.com/erigontech/erigon/turbo/builder"
+	"github.com/erigontech/erigon/turbo/debug"
+	"github.com/erigontech/erigon/turbo/services"
+)
+
+var benchCommand = cli.Command{
+	Name:  "bench",
+	Usage: `Benchmarks of node components`,
+	Before: func(context *cli.Context) error {
+		_, _, _, err := debug.Setup(context, true /* rootLogger */)
+		if err != nil {
+			return err
+		}
+		return nil
+	},
+	Subcommands: []*cli.Command{
+		{
+			Name:   "mining",
+			Action: doBenchMining,
+			Usage:  "Build blocks with synthetic transactions on top of last executed block and report payload build latency",
+			Flags: joinFlags([]cli.Flag{
+				&utils.DataDirFlag,
+				&BenchMiningBlocksFlag,
+				&BenchMiningTxsFlag,
+				&BenchMiningTxMixFlag,
+			}),
+			Description: `
+Runs mining stages (create block, exec, hash state, trie, finish) again and again on top of the same parent block,
+like sequencer does on engine_forkchoiceUpdated with payload attributes. Transactions are generated in advance:
+regular ones are served by synthetic txpool, deposits are passed as payload attributes.
+Built blocks live in an in-memory batch and are never committed: chaindata is opened in read-only mode.`,
+		},
+	},
+}
+
+var (
+	BenchMiningBlocksFlag = cli.IntFlag{
+		Name:  "blocks",
+		Usage: "Amount of blocks to build",
+		Value: 100,
+	}
+	BenchMiningTxsFlag = cli.IntFlag{
+		Name:  "txs",
+		Usage: "Amount of synthetic transactions offered for every block (including deposits)",
+		Value: 1000,
+	}
+	BenchMiningTxMixFlag = cli.StringFlag{
+		Name:  "mix",
+		Usage: "Share of transaction kinds in percents. Kinds: transfer, create (contract writing 16 storage slots), deposit (OP-stack only)",
+		Value: "transfer=80,create=10,deposit=10",
+	}
+)
+
+func doBenchMining(cliCtx *cli.Context) error {
+	logger, _, _, err := debug.Setup(cliCtx, true /* rootLogger */)
+	if err != nil {
+		return err
+	}
+	ctx := cliCtx.Context
+
+	blocks, txs := cliCtx.Int(BenchMiningBlocksFlag.Name), cliCtx.Int(BenchMiningTxsFlag.Name)
+	if blocks <= 0 || txs < 0 {
+		return fmt.Errorf("--%s must be positive and --%s non-negative", BenchMiningBlocksFlag.Name, BenchMiningTxsFlag.Name)
+	}
+	mix, err := parseBenchTxMix(cliCtx.String(BenchMiningTxMixFlag.Name))
+	if err != nil {
+		return err
+	}
+
+	dirs := datadir.New(cliCtx.String(utils.DataDirFlag.Name))
+	db := dbCfg(kv.ChainDB, dirs.Chaindata).Readonly().MustOpen()
+	defer db.Close()
+
+	cfg := ethconfig.NewSnapCfg(true, true, false)
+	blockSnaps, borSnaps, caplinSnaps, br, agg, err := openSnaps(ctx, cfg, dirs, db, logger)
+	if err != nil {
+		return err
+	}
+	defer blockSnaps.Close()
+	defer borSnaps.Close()
+	defer caplinSnaps.Close()
+	defer agg.Close()
+
+	blockReader, _ := br.IO()
+	chainConfig := fromdb.ChainConfig(db)
+	engine := ethconsensusconfig.CreateConsensusEngineBareBones(ctx, chainConfig, logger)
+	defer engine.Close()
+
+	var parent *types.Header
+	if err := db.View(ctx, func(tx kv.Tx) error {
+		executionAt, err := stages.GetStageProgress(tx, stages.Execution)
+		if err != nil {
+			return err
+		}
+		if parent = rawdb.ReadHeaderByNumber(tx, executionAt); parent == nil {
+			return fmt.Errorf("header of last executed block %d not found", executionAt)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	load, err := newBenchMiningLoad(chainConfig, parent, txs, mix)
+	if err != nil {
+		return err
+	}
+	logger.Info("[bench] mining", "parent", parent.Number.Uint64(), "blocks", blocks, "txs", txs, "deposits", len(load.deposits), "mix", cliCtx.String(BenchMiningTxMixFlag.Name))
+
+	b := &miningBench{db: db, dirs: dirs, chainConfig: chainConfig, engine: engine, blockReader: blockReader, agg: agg,
+		historyV3: fromdb.HistV3(db), parent: parent, load: load, logger: logger}
+	latencies := make([]time.Duration, 0, blocks)
+	var totalTxs, totalGas uint64
+	for i := 0; i < blocks; i++ {
+		block, took, err := b.buildBlock(ctx, uint64(i+1))
+		if err != nil {
+			return fmt.Errorf("build block %d: %w", i, err)
+		}
+		latencies = append(latencies, took)
+		totalTxs += uint64(block.Transactions().Len())
+		totalGas += block.GasUsed()
+	}
+
+	var total time.Duration
+	for _, l := range latencies {
+		total += l
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	logger.Info("[bench] mining: payload build latency",
+		"p50", benchPercentile(latencies, 0.5), "p90", benchPercentile(latencies, 0.9), "p99", benchPercentile(latencies, 0.99),
+		"max", latencies[len(latencies)-1], "avg", total/time.Duration(blocks),
+		"txs/block", totalTxs/uint64(blocks), "gas/block", totalGas/uint64(blocks),
+		"mgas/s", fmt.Sprintf("%.2f", float64(totalGas)/1e6/total.Seconds()))
+	return nil
+}
+
+type miningBench struct {
+	db          kv.RwDB
+	dirs        datadir.Dirs
+	chainConfig *chain.Config
+	engine      consensus.Engine
+	blockReader services.FullBlockReader
+	agg         *state.Aggregator
+	historyV3   bool
+	parent      *types.Header
+	load        *benchMiningLoad
+	logger      log.Logger
+}
+
+// buildBlock - same as stages.MiningStep, but funds senders of synthetic txs in the memory batch
+// and measures time from start of mining stages to sealed block
+func (b *miningBench) buildBlock(ctx context.Context, payloadId uint64) (*types.Block, time.Duration, error) {
+	param := &core.BlockBuilderParameters{
+		PayloadId:             payloadId,
+		ParentHash:            b.parent.Hash(),
+		Timestamp:             b.parent.Time + 1,
+		SuggestedFeeRecipient: libcommon.HexToAddress("0x4200000000000000000000000000000000000011"), // SequencerFeeVault
+		Transactions:          b.load.deposits,
+	}
+	if b.chainConfig.IsShanghai(param.Timestamp) {
+		param.Withdrawals = []*types.Withdrawal{}
+	}
+	if b.chainConfig.IsCancun(param.Timestamp) {
+		param.ParentBeaconBlockRoot = &libcommon.Hash{}
+	}
+	if b.chainConfig.IsOptimism() {
+		param.GasLimit = &b.parent.GasLimit
+		if b.chainConfig.IsHolocene(param.Timestamp) {
+			param.EIP1559Params = make([]byte, 8) // zeros - use chain config defaults
+		}
+	}
+
+	miningCfg := ethconfig.Defaults.Miner
+	miningState := stagedsync.NewMiningState(&miningCfg)
+	miningState.MiningConfig.Etherbase = param.SuggestedFeeRecipient
+	sealCancel := make(chan struct{})
+	mining := stagedsync.New(
+		ethconfig.Defaults.Sync,
+		stagedsync.MiningStages(ctx,
+			stagedsync.StageMiningCreateBlockCfg(b.db, miningState, *b.chainConfig, b.engine, nil, param, b.dirs.Tmp, b.blockReader, nil),
+			stagedsync.StageBorHeimdallCfg(b.db, nil, miningState, *b.chainConfig, nil, b.blockReader, nil, nil, nil, nil, nil, false, nil),
+			stagedsync.StageMiningExecCfg(b.db, miningState, nil, *b.chainConfig, b.engine, &vm.Config{}, b.dirs.Tmp, nil, param.PayloadId, b.load.pool, b.db, b.blockReader, nil),
+			stagedsync.StageHashStateCfg(b.db, b.dirs, b.historyV3),
+			stagedsync.StageTrieCfg(b.db, false, true, true, b.dirs.Tmp, b.blockReader, nil, b.historyV3, b.agg),
+			stagedsync.StageMiningFinishCfg(b.db, *b.chainConfig, b.engine, miningState, sealCancel, b.blockReader, nil, builder.NewLatestBlockBuiltStore()),
+		), stagedsync.MiningUnwindOrder, stagedsync.MiningPruneOrder,
+		b.logger)
+
+	tx, err := b.db.BeginRo(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+	miningBatch := membatchwithdb.NewMemoryBatch(tx, b.dirs.Tmp, b.logger)
+	defer miningBatch.Rollback()
+	if err := b.load.fund(miningBatch); err != nil {
+		return nil, 0, err
+	}
+
+	start := time.Now()
+	if _, err := mining.Run(nil, wrap.TxContainer{Tx: miningBatch}, false /* firstCycle */); err != nil {
+		return nil, 0, err
+	}
+	select {
+	case res := <-miningState.MiningResultCh:
+		return res.Block, time.Since(start), nil
+	case <-ctx.Done():
+		return nil, 0, ctx.Err()
+	}
+}
@@ -0,0 +1,225 @@
+package app
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	crand "crypto/rand"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/holiman/uint256"
+
+	"github.com/erigontech/erigon-lib/chain"
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/kv"
+	types2 "github.com/erigontech/erigon-lib/types"
+	"github.com/erigontech/erigon/core/state"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/core/types/accounts"
+	"github.com/erigontech/erigon/crypto"
+	"github.com/erigontech/erigon/params"
+)
+
+// kinds of synthetic transactions
+const (
+	benchTxTransfer = "transfer" // plain value transfer
+	benchTxCreate   = "create"   // contract creation which writes storage slots
+	benchTxDeposit  = "deposit"  // OP-stack deposit with mint, passed to block builder as payload attributes
+)
+
+// benchCreateSlots - amount of storage slots written by init code of benchTxCreate
+const benchCreateSlots = 16
+
+// benchTxMix - share of every kind of transaction in synthetic load, in percents
+type benchTxMix map[string]int
+
+// parseBenchTxMix - parses `transfer=80,create=10,deposit=10`
+func parseBenchTxMix(s string) (benchTxMix, error) {
+	mix := benchTxMix{}
+	var total int
+	for _, part := range strings.Split(s, ",") {
+		kind, share, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid tx mix entry %q, expected kind=percent", part)
+		}
+		switch kind {
+		case benchTxTransfer, benchTxCreate, benchTxDeposit:
+		default:
+			return nil, fmt.Errorf("unknown tx kind %q, supported: %s, %s, %s", kind, benchTxTransfer, benchTxCreate, benchTxDeposit)
+		}
+		percent, err := strconv.Atoi(share)
+		if err != nil || percent < 0 {
+			return nil, fmt.Errorf("invalid share of %q: %q", kind, share)
+		}
+		mix[kind] += percent
+		total += percent
+	}
+	if total != 100 {
+		return nil, fmt.Errorf("shares of tx mix must sum up to 100, got %d", total)
+	}
+	return mix, nil
+}
+
+// benchMiningLoad - synthetic load of one block. Every transaction is sent by own account with nonce 0,
+// so the same load can be built on top of the same parent again and again.
+type benchMiningLoad struct {
+	pool     *benchTxPool
+	deposits [][]byte
+	funded   []libcommon.Address // senders of pool txs, must be funded before every build
+}
+
+func newBenchMiningLoad(chainConfig *chain.Config, parent *types.Header, txs int, mix benchTxMix) (*benchMiningLoad, error) {
+	if mix[benchTxDeposit] > 0 && !chainConfig.IsOptimism() {
+		return nil, fmt.Errorf("deposit transactions are supported only by OP-stack chains")
+	}
+
+	signer := types.LatestSignerForChainID(chainConfig.ChainID)
+	gasPrice := uint256.NewInt(params.GWei)
+	if parent.BaseFee != nil {
+		// base fee can grow at most 12.5% per block, x2 is enough to be always included
+		gasPrice.Add(gasPrice, new(uint256.Int).Mul(uint256.MustFromBig(parent.BaseFee), uint256.NewInt(2)))
+	}
+	initCode := benchCreateInitCode()
+
+	load := &benchMiningLoad{pool: &benchTxPool{}}
+	for i := 0; i < txs; i++ {
+		kind := mix.pick(i, txs)
+		to := benchRandomAddress()
+		if kind == benchTxDeposit {
+			var sourceHash libcommon.Hash
+			crand.Read(sourceHash[:])
+			deposit := &types.DepositTx{
+				SourceHash: sourceHash,
+				From:       benchRandomAddress(),
+				To:         &to,
+				Mint:       uint256.NewInt(params.Ether),
+				Value:      uint256.NewInt(1),
+				Gas:        params.TxGas,
+			}
+			var buf bytes.Buffer
+			if err := deposit.MarshalBinary(&buf); err != nil {
+				return nil, err
+			}
+			load.deposits = append(load.deposits, buf.Bytes())
+			continue
+		}
+
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			return nil, err
+		}
+		var txn types.Transaction
+		var intrinsicGas uint64
+		switch kind {
+		case benchTxCreate:
+			txn = types.NewContractCreation(0, new(uint256.Int), 100_000+benchCreateSlots*25_000, gasPrice, initCode)
+			intrinsicGas = params.TxGasContractCreation + uint64(len(initCode))*params.TxDataNonZeroGasEIP2028
+		default:
+			txn = types.NewTransaction(0, to, uint256.NewInt(1), params.TxGas, gasPrice, nil)
+			intrinsicGas = params.TxGas
+		}
+		if err := load.pool.add(txn, signer, key, intrinsicGas); err != nil {
+			return nil, err
+		}
+		load.funded = append(load.funded, crypto.PubkeyToAddress(key.PublicKey))
+	}
+	return load, nil
+}
+
+// pick - kind of i-th transaction of n, deterministic to keep exact shares of mix
+func (mix benchTxMix) pick(i, n int) string {
+	kinds := make([]string, 0, len(mix))
+	for kind := range mix {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	position := i * 100 / n
+	for _, kind := range kinds {
+		if position < mix[kind] {
+			return kind
+		}
+		position -= mix[kind]
+	}
+	return benchTxTransfer
+}
+
+// fund - gives balance to senders of synthetic transactions. Must be called on disposable tx (it has no changesets).
+func (l *benchMiningLoad) fund(tx kv.RwTx) error {
+	w := state.NewPlainStateWriterNoHistory(tx)
+	for _, addr := range l.funded {
+		acc := accounts.NewAccount()
+		acc.Balance.Mul(uint256.NewInt(params.Ether), uint256.NewInt(100))
+		if err := w.UpdateAccountData(addr, &accounts.Account{}, &acc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// benchCreateInitCode - init code which writes benchCreateSlots storage slots and deploys empty code
+func benchCreateInitCode() []byte {
+	code := make([]byte, 0, benchCreateSlots*5+1)
+	for i := 0; i < benchCreateSlots; i++ {
+		code = append(code, 0x60, byte(i+1), 0x60, byte(i), 0x55) // PUSH1 value, PUSH1 slot, SSTORE
+	}
+	return append(code, 0x00) // STOP
+}
+
+func benchRandomAddress() (addr libcommon.Address) {
+	crand.Read(addr[:])
+	return addr
+}
+
+// benchTxPool - synthetic mempool, implements stagedsync.TxPoolForMining
+type benchTxPool struct {
+	rlps         [][]byte
+	hashes       [][32]byte
+	senders      []libcommon.Address
+	intrinsicGas []uint64
+}
+
+func (p *benchTxPool) add(txn types.Transaction, signer *types.Signer, key *ecdsa.PrivateKey, intrinsicGas uint64) error {
+	signed, err := types.SignTx(txn, *signer, key)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := signed.MarshalBinary(&buf); err != nil {
+		return err
+	}
+	p.rlps = append(p.rlps, buf.Bytes())
+	p.hashes = append(p.hashes, signed.Hash())
+	p.senders = append(p.senders, crypto.PubkeyToAddress(key.PublicKey))
+	p.intrinsicGas = append(p.intrinsicGas, intrinsicGas)
+	return nil
+}
+
+func (p *benchTxPool) YieldBest(n uint16, txs *types2.TxsRlp, _ kv.Tx, _, availableGas, _ uint64, toSkip mapset.Set[[32]byte]) (bool, int, error) {
+	txs.Resize(uint(min(int(n), len(p.rlps))))
+	count := 0
+	for i := 0; count < int(n) && i < len(p.rlps); i++ {
+		if availableGas < params.TxGas {
+			break
+		}
+		if toSkip.Contains(p.hashes[i]) || p.intrinsicGas[i] > availableGas {
+			continue
+		}
+		availableGas -= p.intrinsicGas[i]
+
+		txs.Txs[count] = p.rlps[i]
+		copy(txs.Senders.At(count), p.senders[i].Bytes())
+		txs.IsLocal[count] = false
+		toSkip.Add(p.hashes[i])
+		count++
+	}
+	txs.Resize(uint(count))
+	return true, count, nil
+}
+
+// benchPercentile - q-th percentile of sorted values
+func benchPercentile[T any](sorted []T, q float64) T {
+	return sorted[int(float64(len(sorted)-1)*q)]
+}
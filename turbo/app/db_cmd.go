@@ -0,0 +1,118 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/erigontech/erigon-lib/common/datadir"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon/cmd/utils"
+	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/migrations"
+	"github.com/erigontech/erigon/params"
+	"github.com/erigontech/erigon/turbo/debug"
+)
+
+var dbCommand = cli.Command{
+	Name:  "db",
+	Usage: `Maintenance of chaindata database`,
+	Before: func(context *cli.Context) error {
+		_, _, _, err := debug.Setup(context, true /* rootLogger */)
+		if err != nil {
+			return err
+		}
+		return nil
+	},
+	Subcommands: []*cli.Command{
+		{
+			Name:   "migrate-receipts",
+			Action: doMigrateReceipts,
+			Usage:  "Rewrite receipts and logs stored in legacy cbor encoding into storage encoding v2",
+			Flags: joinFlags([]cli.Flag{
+				&utils.DataDirFlag,
+				&DbMigrateBatchFlag,
+			}),
+		},
+		{
+			Name:   "migrate-chainconfig",
+			Action: doMigrateChainConfig,
+			Usage:  "Replace the stored chain config of an OP Stack chain by the one of the superchain registry (e.g. with new fork times), prints the changes",
+			Flags: joinFlags([]cli.Flag{
+				&utils.DataDirFlag,
+				&utils.ChainFlag,
+				&DbMigrateDryRunFlag,
+				&DbMigrateForceFlag,
+			}),
+		},
+	},
+}
+
+var (
+	DbMigrateBatchFlag = cli.IntFlag{
+		Name:  "batch",
+		Usage: "Amount of records converted and committed in one transaction",
+		Value: 10_000,
+	}
+	DbMigrateDryRunFlag = cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "Print the changes without applying them",
+	}
+	DbMigrateForceFlag = cli.BoolFlag{
+		Name:  "force",
+		Usage: "Apply the changes even if the head is past a fork they move, the chain has to be rewound to the fork then",
+	}
+)
+
+func doMigrateReceipts(cliCtx *cli.Context) error {
+	logger, _, _, err := debug.Setup(cliCtx, true /* rootLogger */)
+	if err != nil {
+		return err
+	}
+	dirs := datadir.New(cliCtx.String(utils.DataDirFlag.Name))
+	chainDB := dbCfg(kv.ChainDB, dirs.Chaindata).MustOpen()
+	defer chainDB.Close()
+
+	return migrations.MigrateReceiptsStorageV2(cliCtx.Context, chainDB, cliCtx.Int(DbMigrateBatchFlag.Name), logger)
+}
+
+func doMigrateChainConfig(cliCtx *cli.Context) error {
+	logger, _, _, err := debug.Setup(cliCtx, true /* rootLogger */)
+	if err != nil {
+		return err
+	}
+	chainName := cliCtx.String(utils.ChainFlag.Name)
+	opStackChainCfg := params.OPStackChainConfigByName(chainName)
+	if opStackChainCfg == nil {
+		return fmt.Errorf("%s is not in the superchain registry", chainName)
+	}
+	registryCfg := params.LoadSuperChainConfig(opStackChainCfg)
+	if registryCfg == nil {
+		return fmt.Errorf("no chain config of %s in the superchain registry", chainName)
+	}
+	dryRun := cliCtx.Bool(DbMigrateDryRunFlag.Name)
+
+	dirs := datadir.New(cliCtx.String(utils.DataDirFlag.Name))
+	chainDB := dbCfg(kv.ChainDB, dirs.Chaindata).MustOpen()
+	defer chainDB.Close()
+
+	// the changes are printed and written in one tx
+	return chainDB.Update(cliCtx.Context, func(tx kv.RwTx) error {
+		changes, err := core.MigrateChainConfig(tx, registryCfg, !dryRun, cliCtx.Bool(DbMigrateForceFlag.Name))
+		for _, change := range changes {
+			fmt.Println(change.String())
+		}
+		if err != nil {
+			return err
+		}
+		switch {
+		case len(changes) == 0:
+			logger.Info("Stored chain config is the same as in the superchain registry", "chain", chainName)
+		case dryRun:
+			logger.Info("Dry run, chain config is not changed", "chain", chainName, "changes", len(changes))
+		default:
+			logger.Info("Chain config migrated", "chain", chainName, "changes", len(changes))
+		}
+		return nil
+	})
+}
@@ -2,6 +2,8 @@ package app
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 
 	"github.com/erigontech/erigon-lib/log/v3"
@@ -9,9 +11,11 @@ import (
 	"github.com/erigontech/erigon/turbo/debug"
 	"github.com/urfave/cli/v2"
 
+	"github.com/erigontech/erigon-lib/chain"
 	"github.com/erigontech/erigon-lib/kv"
 	"github.com/erigontech/erigon/cmd/utils"
 	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/core/rawdb"
 	"github.com/erigontech/erigon/node"
 )
 
@@ -22,6 +26,7 @@ var initCommand = cli.Command{
 	ArgsUsage: "<genesisPath>",
 	Flags: []cli.Flag{
 		&utils.DataDirFlag,
+		&InitCheckFlag,
 	},
 	//Category: "BLOCKCHAIN COMMANDS",
 	Description: `
@@ -29,7 +34,13 @@ The init command initializes a new genesis block and definition for the network.
 This is a destructive action and changes the network in which you will be
 participating.
 
-It expects the genesis file as argument.`,
+It expects the genesis file as argument. With --check nothing is written, the
+differences of the chain config of the file and the stored one are printed.`,
+}
+
+var InitCheckFlag = cli.BoolFlag{
+	Name:  "check",
+	Usage: "Print the fields of the chain config of the genesis file which differ from the stored chain config, as json, and fail if they are incompatible with the chain in the db. Nothing is written",
 }
 
 // initGenesis will initialise the given JSON format genesis file and writes it as
@@ -65,6 +76,10 @@ func initGenesis(cliCtx *cli.Context) error {
 	if err != nil {
 		utils.Fatalf("Failed to open database: %v", err)
 	}
+	if cliCtx.Bool(InitCheckFlag.Name) {
+		defer chaindb.Close()
+		return checkGenesis(cliCtx, chaindb, genesis, logger)
+	}
 	_, hash, err := core.CommitGenesisBlock(chaindb, genesis, "", logger)
 	if err != nil {
 		utils.Fatalf("Failed to write genesis block: %v", err)
@@ -73,3 +88,46 @@ func initGenesis(cliCtx *cli.Context) error {
 	logger.Info("Successfully wrote genesis state", "hash", hash.Hash())
 	return nil
 }
+
+// checkGenesis prints the diff of the chain config of genesis and the stored one, and checks that genesis could be
+// written, in a tx which is rolled back
+func checkGenesis(cliCtx *cli.Context, chaindb kv.RwDB, genesis *types.Genesis, logger log.Logger) error {
+	tx, err := chaindb.BeginRw(cliCtx.Context)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	storedHash, err := rawdb.ReadCanonicalHash(tx, 0)
+	if err != nil {
+		return err
+	}
+	stored, err := rawdb.ReadChainConfig(tx, storedHash)
+	if err != nil {
+		return err
+	}
+	if stored == nil {
+		logger.Info("No chain config in the db, the genesis would be written")
+		return nil
+	}
+	diff, err := chain.ConfigChanges(stored, genesis.Config)
+	if err != nil {
+		return err
+	}
+	out, err := json.MarshalIndent(diff, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+
+	_, _, err = core.WriteGenesisBlock(tx, genesis, nil, "", logger)
+	var compatErr *chain.ConfigCompatError
+	switch {
+	case errors.As(err, &compatErr):
+		return fmt.Errorf("chain config is incompatible with the chain in the db: %s %v -> %v, rewind to %d", compatErr.What, compatErr.StoredConfig, compatErr.NewConfig, compatErr.RewindTo)
+	case err != nil:
+		return err
+	}
+	logger.Info("Chain config is compatible with the chain in the db", "changes", len(diff))
+	return nil
+}
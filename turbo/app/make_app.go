@@ -65,6 +65,9 @@ func MakeApp(name string, action cli.ActionFunc, cliFlags []cli.Flag) *cli.App {
 		&importCommand,
 		&snapshotCommand,
 		&supportCommand,
+		&verifyRangeCommand,
+		&dbCommand,
+		&benchCommand,
 		//&backupCommand,
 	}
 	return app
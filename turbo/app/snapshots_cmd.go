@@ -25,6 +25,7 @@ import (
 	"github.com/erigontech/erigon-lib/common/datadir"
 	"github.com/erigontech/erigon-lib/common/dbg"
 	"github.com/erigontech/erigon-lib/common/dir"
+	"github.com/erigontech/erigon-lib/downloader/snaptype"
 	"github.com/erigontech/erigon-lib/etl"
 	"github.com/erigontech/erigon-lib/kv"
 	"github.com/erigontech/erigon-lib/kv/kvcfg"
@@ -78,6 +79,7 @@ var snapshotCommand = cli.Command{
 				&utils.DataDirFlag,
 				&SnapshotFromFlag,
 				&SnapshotRebuildFlag,
+				&utils.SnapEncryptionKeyFileFlag,
 			}),
 		},
 		{
@@ -89,6 +91,9 @@ var snapshotCommand = cli.Command{
 				&SnapshotFromFlag,
 				&SnapshotToFlag,
 				&SnapshotEveryFlag,
+				&utils.SnapFreezeDistanceFlag,
+				&utils.SnapBorFreezeDistanceFlag,
+				&utils.SnapEncryptionKeyFileFlag,
 			}),
 		},
 		{
@@ -162,6 +167,16 @@ var snapshotCommand = cli.Command{
 			Action: doIntegrity,
 			Flags: joinFlags([]cli.Flag{
 				&utils.DataDirFlag,
+				&utils.SnapEncryptionKeyFileFlag,
+			}),
+		},
+		{
+			Name:   "encrypt",
+			Action: doEncrypt,
+			Usage:  "Encrypt at rest all plain block and receipt segments of datadir by key of --snap.encryption.keyfile. Erigon must be stopped",
+			Flags: joinFlags([]cli.Flag{
+				&utils.DataDirFlag,
+				&utils.SnapEncryptionKeyFileFlag,
 			}),
 		},
 		//{
@@ -201,6 +216,10 @@ func doIntegrity(cliCtx *cli.Context) error {
 	if err != nil {
 		return err
 	}
+	keyProvider, err := utils.SnapKeyProvider(cliCtx)
+	if err != nil {
+		return err
+	}
 
 	ctx := cliCtx.Context
 	dirs := datadir.New(cliCtx.String(utils.DataDirFlag.Name))
@@ -208,6 +227,7 @@ func doIntegrity(cliCtx *cli.Context) error {
 	defer chainDB.Close()
 
 	cfg := ethconfig.NewSnapCfg(true, false, true)
+	cfg.KeyProvider = keyProvider
 
 	blockSnaps, borSnaps, caplinSnaps, blockRetire, agg, err := openSnaps(ctx, cfg, dirs, chainDB, logger)
 	if err != nil {
@@ -338,6 +358,10 @@ func doIndicesCommand(cliCtx *cli.Context) error {
 	if err != nil {
 		return err
 	}
+	keyProvider, err := utils.SnapKeyProvider(cliCtx)
+	if err != nil {
+		return err
+	}
 	defer logger.Info("Done")
 	ctx := cliCtx.Context
 
@@ -357,6 +381,7 @@ func doIndicesCommand(cliCtx *cli.Context) error {
 	}
 
 	cfg := ethconfig.NewSnapCfg(true, false, true)
+	cfg.KeyProvider = keyProvider
 	chainConfig := fromdb.ChainConfig(chainDB)
 	blockSnaps, borSnaps, caplinSnaps, br, agg, err := openSnaps(ctx, cfg, dirs, chainDB, logger)
 	if err != nil {
@@ -483,6 +508,62 @@ func doUncompress(cliCtx *cli.Context) error {
 	}
 	return nil
 }
+func doEncrypt(cliCtx *cli.Context) error {
+	logger, _, _, err := debug.Setup(cliCtx, true /* rootLogger */)
+	if err != nil {
+		return err
+	}
+	ctx := cliCtx.Context
+	keyFile := cliCtx.String(utils.SnapEncryptionKeyFileFlag.Name)
+	if keyFile == "" {
+		return fmt.Errorf("--%s is required", utils.SnapEncryptionKeyFileFlag.Name)
+	}
+	p, err := seg.NewStaticKeyProviderFromFile(keyFile)
+	if err != nil {
+		return err
+	}
+
+	dirs := datadir.New(cliCtx.String(utils.DataDirFlag.Name))
+	var encrypted, skipped int
+	err = filepath.WalkDir(dirs.Snap, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			// segments of blocks and receipts, the node opens them with the key of the snapshots config
+			if path != dirs.Snap && d.Name() != "receipts" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ".seg" {
+			return nil
+		}
+		if info, _, ok := snaptype.ParseFileName(filepath.Dir(path), d.Name()); ok && info.Type != nil && snaptype.IsCaplinType(info.Type.Enum()) {
+			return nil
+		}
+		isEncrypted, err := seg.IsEncryptedFile(path)
+		if err != nil {
+			return err
+		}
+		if isEncrypted {
+			skipped++
+			return nil
+		}
+		if err = seg.EncryptFile(ctx, p, path); err != nil {
+			return err
+		}
+		encrypted++
+		logger.Debug("[snapshots] encrypted", "file", d.Name())
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	logger.Info("[snapshots] encryption done", "encrypted", encrypted, "alreadyEncrypted", skipped)
+	return nil
+}
+
 func doCompress(cliCtx *cli.Context) error {
 	var err error
 	var logger log.Logger
@@ -539,6 +620,10 @@ func doRetireCommand(cliCtx *cli.Context) error {
 	if logger, _, _, err = debug.Setup(cliCtx, true /* rootLogger */); err != nil {
 		return err
 	}
+	keyProvider, err := utils.SnapKeyProvider(cliCtx)
+	if err != nil {
+		return err
+	}
 	defer logger.Info("Done")
 	ctx := cliCtx.Context
 
@@ -549,8 +634,18 @@ func doRetireCommand(cliCtx *cli.Context) error {
 
 	db := dbCfg(kv.ChainDB, dirs.Chaindata).MustOpen()
 	defer db.Close()
+	// the receipts frozen from the db are decoded with its dictionary
+	if err := rawdb.WatchReceiptsCompression(ctx, db, logger); err != nil {
+		return err
+	}
 
 	cfg := ethconfig.NewSnapCfg(true, false, true)
+	cfg.KeyProvider = keyProvider
+	cfg.FreezeDistance = cliCtx.Uint64(utils.SnapFreezeDistanceFlag.Name)
+	cfg.BorFreezeDistance = cliCtx.Uint64(utils.SnapBorFreezeDistanceFlag.Name)
+	if err := cfg.CheckFreezeDistance(); err != nil {
+		return err
+	}
 	blockSnaps, borSnaps, caplinSnaps, br, agg, err := openSnaps(ctx, cfg, dirs, db, logger)
 	if err != nil {
 		return err
@@ -577,7 +672,7 @@ func doRetireCommand(cliCtx *cli.Context) error {
 			return err
 		})
 		blockReader, _ := br.IO()
-		from2, to2, ok := freezeblocks.CanRetire(forwardProgress, blockReader.FrozenBlocks(), coresnaptype.Enums.Headers, nil)
+		from2, to2, ok := freezeblocks.CanRetire(forwardProgress, blockReader.FrozenBlocks(), cfg.BlocksFreezeDistance(), coresnaptype.Enums.Headers, nil)
 		if ok {
 			from, to, every = from2, to2, to2-from2
 		}
@@ -609,6 +704,22 @@ func doRetireCommand(cliCtx *cli.Context) error {
 		}
 	}
 
+	// receipts and trace_filter index of the frozen blocks are read from their files now
+	logger.Info("Prune frozen receipts and call trace index")
+	for deleted := 1; deleted > 0; {
+		if err := db.UpdateNosync(ctx, func(tx kv.RwTx) (err error) {
+			deleted, err = br.PruneFrozenReceipts(tx, 10_000)
+			return err
+		}); err != nil {
+			return err
+		}
+	}
+	if err := db.Update(ctx, func(tx kv.RwTx) error {
+		return br.PruneFrozenCallTraceIndex(ctx, tx)
+	}); err != nil {
+		return err
+	}
+
 	if !kvcfg.HistoryV3.FromDB(db) {
 		return nil
 	}
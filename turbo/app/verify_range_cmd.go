@@ -0,0 +1,92 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/erigontech/erigon-lib/common/datadir"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon/cmd/hack/tool/fromdb"
+	"github.com/erigontech/erigon/cmd/utils"
+	"github.com/erigontech/erigon/eth/ethconfig"
+	"github.com/erigontech/erigon/eth/ethconsensusconfig"
+	"github.com/erigontech/erigon/eth/integrity"
+	"github.com/erigontech/erigon/turbo/debug"
+)
+
+var verifyRangeCommand = cli.Command{
+	Action: MigrateFlags(doVerifyRange),
+	Name:   "verify-range",
+	Usage:  "Re-execute range of blocks from snapshots on top of historical state and check receipts/state against stored data",
+	Flags: joinFlags([]cli.Flag{
+		&utils.DataDirFlag,
+		&VerifyRangeFromFlag,
+		&VerifyRangeToFlag,
+		&VerifyRangeFailFastFlag,
+		&VerifyRangeNoStateRootFlag,
+	}),
+	Description: `
+Offline audit of blocks [--from, --to]. Doesn't depend on stage progress of datadir:
+blocks are read from snapshot segments (or db), pre-state is reconstructed from history
+and every state change produced by execution is compared with historical post-state.
+Receipts root, logs bloom, gas used and txs root are checked against block headers.
+State root of every block is computed in a temporary db and checked against its header:
+the state is loaded from history at --from first, which takes a pass over the whole state
+(--no-state-root skips it). Pre-bedrock blocks are skipped.`,
+}
+
+var (
+	VerifyRangeFromFlag = cli.Uint64Flag{
+		Name:     "from",
+		Usage:    "First block to verify",
+		Required: true,
+	}
+	VerifyRangeToFlag = cli.Uint64Flag{
+		Name:     "to",
+		Usage:    "Last block to verify (inclusive)",
+		Required: true,
+	}
+	VerifyRangeFailFastFlag = cli.BoolFlag{
+		Name:  "fail-fast",
+		Usage: "Stop on first failed block",
+	}
+	VerifyRangeNoStateRootFlag = cli.BoolFlag{
+		Name:  "no-state-root",
+		Usage: "Don't compute and check state roots",
+	}
+)
+
+func doVerifyRange(cliCtx *cli.Context) error {
+	logger, _, _, err := debug.Setup(cliCtx, true /* rootLogger */)
+	if err != nil {
+		return err
+	}
+	ctx := cliCtx.Context
+
+	from, to := cliCtx.Uint64(VerifyRangeFromFlag.Name), cliCtx.Uint64(VerifyRangeToFlag.Name)
+	if to < from {
+		return fmt.Errorf("--to (%d) must be >= --from (%d)", to, from)
+	}
+
+	dirs := datadir.New(cliCtx.String(utils.DataDirFlag.Name))
+	chainDB := dbCfg(kv.ChainDB, dirs.Chaindata).MustOpen()
+	defer chainDB.Close()
+
+	cfg := ethconfig.NewSnapCfg(true, false, true)
+	blockSnaps, borSnaps, caplinSnaps, br, agg, err := openSnaps(ctx, cfg, dirs, chainDB, logger)
+	if err != nil {
+		return err
+	}
+	defer blockSnaps.Close()
+	defer borSnaps.Close()
+	defer caplinSnaps.Close()
+	defer agg.Close()
+
+	blockReader, _ := br.IO()
+	chainConfig := fromdb.ChainConfig(chainDB)
+	engine := ethconsensusconfig.CreateConsensusEngineBareBones(ctx, chainConfig, logger)
+	defer engine.Close()
+
+	return integrity.ExecRange(ctx, chainDB, blockReader, chainConfig, engine, from, to, fromdb.HistV3(chainDB), !cliCtx.Bool(VerifyRangeNoStateRootFlag.Name), dirs.Tmp, cliCtx.Bool(VerifyRangeFailFastFlag.Name), logger)
+}
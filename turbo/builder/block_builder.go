@@ -20,6 +20,7 @@ type BlockBuilder struct {
 	syncCond  *sync.Cond
 	result    *types.BlockWithReceipts
 	err       error
+	done      bool // result and err are final, even if both are nil
 }
 
 func NewBlockBuilder(build BlockBuilderFunc, param *core.BlockBuilderParameters) *BlockBuilder {
@@ -44,18 +45,25 @@ func NewBlockBuilder(build BlockBuilderFunc, param *core.BlockBuilderParameters)
 		defer builder.syncCond.L.Unlock()
 		builder.result = result
 		builder.err = err
+		builder.done = true
 		builder.syncCond.Broadcast()
 	}()
 
 	return builder
 }
 
-func (b *BlockBuilder) Stop() (*types.BlockWithReceipts, error) {
+// Interrupt - makes the build finish with what it has, without waiting for it
+func (b *BlockBuilder) Interrupt() {
 	atomic.StoreInt32(&b.interrupt, 1)
+}
+
+// Stop - interrupts the build and waits for its result
+func (b *BlockBuilder) Stop() (*types.BlockWithReceipts, error) {
+	b.Interrupt()
 
 	b.syncCond.L.Lock()
 	defer b.syncCond.L.Unlock()
-	for b.result == nil && b.err == nil {
+	for !b.done {
 		b.syncCond.Wait()
 	}
 
@@ -0,0 +1,183 @@
+package builder
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/common/hexutility"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/core/rawdb"
+	"github.com/erigontech/erigon/core/types"
+)
+
+const (
+	payloadHistoryQueueSize     = 1024
+	payloadHistoryPruneInterval = time.Minute
+	// payloadHistoryTrackExpiry - a build of a builder process, see Track, waits for its outcome this long at most
+	payloadHistoryTrackExpiry = time.Minute
+)
+
+// PayloadHistory persists payload attributes passed to block builder together with outcome of block building
+// (see rawdb.PayloadAttributesRecord) and prunes records older than retention period.
+// Records are written by background goroutine - block building never waits for db.
+type PayloadHistory struct {
+	db        kv.RwDB
+	retention time.Duration
+	queue     chan rawdb.PayloadAttributesRecord
+	logger    log.Logger
+
+	trackedLock sync.Mutex
+	tracked     map[uint64]rawdb.PayloadAttributesRecord // builds of a builder process waiting for their outcome
+}
+
+// NewPayloadHistory returns nil if retention is 0 - nil PayloadHistory is valid and does nothing
+func NewPayloadHistory(ctx context.Context, db kv.RwDB, retention time.Duration, logger log.Logger) *PayloadHistory {
+	if retention <= 0 {
+		return nil
+	}
+	h := &PayloadHistory{
+		db:        db,
+		retention: retention,
+		queue:     make(chan rawdb.PayloadAttributesRecord, payloadHistoryQueueSize),
+		logger:    logger,
+		tracked:   map[uint64]rawdb.PayloadAttributesRecord{},
+	}
+	go h.loop(ctx)
+	return h
+}
+
+// Wrap returns BlockBuilderFunc which records parameters and outcome of every build
+func (h *PayloadHistory) Wrap(build BlockBuilderFunc) BlockBuilderFunc {
+	if h == nil {
+		return build
+	}
+	return func(param *core.BlockBuilderParameters, interrupt *int32) (*types.BlockWithReceipts, error) {
+		start := time.Now()
+		rec := newPayloadAttributesRecord(param, start)
+		h.add(rec)
+
+		result, err := build(param, interrupt)
+		rec.BuildTimeMs = uint64(time.Since(start).Milliseconds())
+		if err != nil {
+			rec.Error = err.Error()
+		} else if result != nil && result.Block != nil {
+			hash, number := result.Block.Hash(), hexutil.Uint64(result.Block.NumberU64())
+			rec.BlockHash, rec.BlockNumber = &hash, &number
+		}
+		h.add(rec)
+		return result, err
+	}
+}
+
+// Track records parameters of a build of a builder process (see --builder.remote), which is not wrapped by Wrap.
+// Its outcome is recorded by Done.
+func (h *PayloadHistory) Track(param *core.BlockBuilderParameters) {
+	if h == nil {
+		return
+	}
+	now := time.Now()
+	rec := newPayloadAttributesRecord(param, now)
+	h.add(rec)
+
+	h.trackedLock.Lock()
+	defer h.trackedLock.Unlock()
+	for id, tracked := range h.tracked {
+		if now.Sub(time.UnixMilli(int64(tracked.ReceivedAt))) > payloadHistoryTrackExpiry {
+			delete(h.tracked, id)
+		}
+	}
+	h.tracked[param.PayloadId] = rec
+}
+
+// Done records outcome of a build passed to Track: the built block, or err. The build time is the time until the
+// outcome was asked for. Nothing is recorded for untracked ids.
+func (h *PayloadHistory) Done(payloadId uint64, blockHash libcommon.Hash, blockNumber uint64, err error) {
+	if h == nil {
+		return
+	}
+	h.trackedLock.Lock()
+	rec, ok := h.tracked[payloadId]
+	delete(h.tracked, payloadId)
+	h.trackedLock.Unlock()
+	if !ok {
+		return
+	}
+	rec.BuildTimeMs = uint64(time.Since(time.UnixMilli(int64(rec.ReceivedAt))).Milliseconds())
+	if err != nil {
+		rec.Error = err.Error()
+	} else {
+		number := hexutil.Uint64(blockNumber)
+		rec.BlockHash, rec.BlockNumber = &blockHash, &number
+	}
+	h.add(rec)
+}
+
+func newPayloadAttributesRecord(param *core.BlockBuilderParameters, receivedAt time.Time) rawdb.PayloadAttributesRecord {
+	rec := rawdb.PayloadAttributesRecord{
+		PayloadId:             hexutil.Uint64(param.PayloadId),
+		ReceivedAt:            uint64(receivedAt.UnixMilli()),
+		ParentHash:            param.ParentHash,
+		Timestamp:             hexutil.Uint64(param.Timestamp),
+		PrevRandao:            param.PrevRandao,
+		SuggestedFeeRecipient: param.SuggestedFeeRecipient,
+		Withdrawals:           param.Withdrawals,
+		ParentBeaconBlockRoot: param.ParentBeaconBlockRoot,
+		Transactions:          make([]hexutility.Bytes, len(param.Transactions)),
+		NoTxPool:              param.NoTxPool,
+		GasLimit:              (*hexutil.Uint64)(param.GasLimit),
+		EIP1559Params:         param.EIP1559Params,
+	}
+	for i, txn := range param.Transactions {
+		rec.Transactions[i] = txn
+	}
+	return rec
+}
+
+// add - records are passed by value, so the queue never shares them with block building
+func (h *PayloadHistory) add(rec rawdb.PayloadAttributesRecord) {
+	select {
+	case h.queue <- rec:
+	default:
+		h.logger.Warn("[PayloadHistory] queue is full, record dropped", "payloadId", uint64(rec.PayloadId))
+	}
+}
+
+func (h *PayloadHistory) loop(ctx context.Context) {
+	pruneTicker := time.NewTicker(payloadHistoryPruneInterval)
+	defer pruneTicker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case rec := <-h.queue:
+			// batch everything queued while previous write was waiting for db
+			batch := []rawdb.PayloadAttributesRecord{rec}
+			for len(h.queue) > 0 {
+				batch = append(batch, <-h.queue)
+			}
+			if err := h.db.Update(ctx, func(tx kv.RwTx) error {
+				for i := range batch {
+					if err := rawdb.WritePayloadAttributes(tx, &batch[i]); err != nil {
+						return err
+					}
+				}
+				return nil
+			}); err != nil {
+				h.logger.Warn("[PayloadHistory] write failed", "records", len(batch), "err", err)
+			}
+		case <-pruneTicker.C:
+			before := uint64(time.Now().Add(-h.retention).UnixMilli())
+			if err := h.db.Update(ctx, func(tx kv.RwTx) error {
+				return rawdb.PrunePayloadAttributes(tx, before)
+			}); err != nil {
+				h.logger.Warn("[PayloadHistory] prune failed", "err", err)
+			}
+		}
+	}
+}
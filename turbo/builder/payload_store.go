@@ -0,0 +1,155 @@
+package builder
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/erigontech/erigon-lib/metrics"
+
+	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/core/types"
+)
+
+var (
+	payloadBuildDuration    = metrics.GetOrCreateSummary(`payload_build_duration_seconds`)
+	payloadRetrieveDelay    = metrics.GetOrCreateSummary(`payload_retrieve_delay_seconds`) // from build request to getPayload
+	payloadDuplicateBuilds  = metrics.GetOrCreateCounter(`payload_requests{result="duplicate"}`)
+	payloadExpiredRetrieves = metrics.GetOrCreateCounter(`payload_requests{result="expired"}`)
+	payloadUnknownRetrieves = metrics.GetOrCreateCounter(`payload_requests{result="unknown"}`)
+)
+
+// PayloadStore - block builders by payload id, for engine_forkchoiceUpdated (Add) and engine_getPayload (Get).
+// Bounded by number of builders, oldest are evicted first, and by age: a payload older than expiry is unknown, not
+// a block of a long gone slot. Safe for concurrent use.
+type PayloadStore struct {
+	build       BlockBuilderFunc
+	maxBuilders int
+	expiry      time.Duration
+
+	mu       sync.Mutex
+	nextId   uint64
+	payloads map[uint64]*storedPayload
+	order    []uint64 // ids in order of adding, for eviction
+}
+
+type storedPayload struct {
+	param   *core.BlockBuilderParameters
+	builder *BlockBuilder
+	addedAt time.Time
+}
+
+func NewPayloadStore(build BlockBuilderFunc, maxBuilders int, expiry time.Duration) *PayloadStore {
+	return &PayloadStore{
+		build:       build,
+		maxBuilders: maxBuilders,
+		expiry:      expiry,
+		// ids restart from the clock, not 1: a consensus client asking for an id issued before restart must get
+		// unknown payload, not a block built for someone else's attributes
+		nextId:   uint64(time.Now().UnixNano()),
+		payloads: make(map[uint64]*storedPayload),
+	}
+}
+
+// Add - starts building a block with param and returns its payload id. If a not expired block with the same
+// parameters is being built, its id is returned and duplicate is true. param.PayloadId is set.
+func (s *PayloadStore) Add(param *core.BlockBuilderParameters) (id uint64, duplicate bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pruneExpired(time.Now())
+
+	for _, id := range s.order {
+		if sameBuildParameters(s.payloads[id].param, param) {
+			payloadDuplicateBuilds.Inc()
+			param.PayloadId = id
+			return id, true, nil
+		}
+	}
+
+	s.nextId++
+	id = s.nextId
+	if _, ok := s.payloads[id]; ok {
+		// replacing would hand a different block to whoever holds the id
+		return 0, false, fmt.Errorf("payload id %d is already in use", id)
+	}
+	for len(s.order) >= s.maxBuilders {
+		s.remove(s.order[0])
+	}
+	param.PayloadId = id
+	s.payloads[id] = &storedPayload{param: param, builder: NewBlockBuilder(measureBuild(s.build), param), addedAt: time.Now()}
+	s.order = append(s.order, id)
+	return id, false, nil
+}
+
+// Get - stops building of the payload and returns the block built so far; ok is false for unknown and expired
+// payloads. The block is the same for all calls with the id. Doesn't hold the store while waiting for the builder.
+func (s *PayloadStore) Get(id uint64) (result *types.BlockWithReceipts, ok bool, err error) {
+	s.mu.Lock()
+	p, ok := s.payloads[id]
+	if ok && s.expired(p, time.Now()) {
+		s.remove(id)
+		payloadExpiredRetrieves.Inc()
+		ok = false
+	} else if !ok {
+		payloadUnknownRetrieves.Inc()
+	}
+	s.mu.Unlock()
+	if !ok {
+		return nil, false, nil
+	}
+
+	payloadRetrieveDelay.ObserveDuration(p.addedAt)
+	result, err = p.builder.Stop()
+	if err != nil {
+		return nil, true, err
+	}
+	if result == nil || result.Block == nil {
+		return nil, true, fmt.Errorf("payload %d: builder returned no block", id)
+	}
+	return result, true, nil
+}
+
+// Len - number of stored payloads, expired included until the next Add
+func (s *PayloadStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.payloads)
+}
+
+func (s *PayloadStore) expired(p *storedPayload, now time.Time) bool {
+	return s.expiry > 0 && now.Sub(p.addedAt) > s.expiry
+}
+
+func (s *PayloadStore) pruneExpired(now time.Time) {
+	for len(s.order) > 0 && s.expired(s.payloads[s.order[0]], now) {
+		s.remove(s.order[0])
+	}
+}
+
+// remove - interrupts the builder, so an abandoned payload doesn't keep building
+func (s *PayloadStore) remove(id uint64) {
+	if p, ok := s.payloads[id]; ok {
+		p.builder.Interrupt()
+		delete(s.payloads, id)
+	}
+	for i, v := range s.order {
+		if v == id {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+}
+
+func sameBuildParameters(a, b *core.BlockBuilderParameters) bool {
+	ac, bc := *a, *b
+	ac.PayloadId, bc.PayloadId = 0, 0
+	return reflect.DeepEqual(&ac, &bc)
+}
+
+func measureBuild(build BlockBuilderFunc) BlockBuilderFunc {
+	return func(param *core.BlockBuilderParameters, interrupt *int32) (*types.BlockWithReceipts, error) {
+		defer payloadBuildDuration.ObserveDuration(time.Now())
+		return build(param, interrupt)
+	}
+}
@@ -0,0 +1,93 @@
+package builder
+
+import (
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/core/types"
+)
+
+func testBuild(builds *atomic.Int32) BlockBuilderFunc {
+	return func(param *core.BlockBuilderParameters, interrupt *int32) (*types.BlockWithReceipts, error) {
+		builds.Add(1)
+		return &types.BlockWithReceipts{Block: types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1), Time: param.Timestamp})}, nil
+	}
+}
+
+func TestPayloadStore(t *testing.T) {
+	t.Parallel()
+	var builds atomic.Int32
+	s := NewPayloadStore(testBuild(&builds), 2, time.Hour)
+
+	id1, duplicate, err := s.Add(&core.BlockBuilderParameters{ParentHash: libcommon.Hash{1}, Timestamp: 1})
+	require.NoError(t, err)
+	require.False(t, duplicate)
+	id2, _, err := s.Add(&core.BlockBuilderParameters{ParentHash: libcommon.Hash{1}, Timestamp: 2})
+	require.NoError(t, err)
+	require.NotEqual(t, id1, id2)
+
+	// not only the last request is a duplicate
+	param := &core.BlockBuilderParameters{ParentHash: libcommon.Hash{1}, Timestamp: 1}
+	id, duplicate, err := s.Add(param)
+	require.NoError(t, err)
+	require.True(t, duplicate)
+	require.Equal(t, id1, id)
+	require.Equal(t, id1, param.PayloadId)
+
+	result, ok, err := s.Get(id1)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, uint64(1), result.Block.Time())
+	again, _, _ := s.Get(id1)
+	require.Same(t, result, again)
+
+	// bounded: the oldest is evicted
+	id3, _, err := s.Add(&core.BlockBuilderParameters{ParentHash: libcommon.Hash{1}, Timestamp: 3})
+	require.NoError(t, err)
+	require.Equal(t, 2, s.Len())
+	_, ok, _ = s.Get(id1)
+	require.False(t, ok)
+	_, ok, _ = s.Get(id3)
+	require.True(t, ok)
+	require.Equal(t, int32(3), builds.Load())
+}
+
+func TestPayloadStoreExpiry(t *testing.T) {
+	t.Parallel()
+	var builds atomic.Int32
+	s := NewPayloadStore(testBuild(&builds), 8, time.Millisecond)
+
+	param := core.BlockBuilderParameters{Timestamp: 1}
+	id, _, err := s.Add(&param)
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+	_, ok, err := s.Get(id)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// same parameters after expiry is a new build
+	param = core.BlockBuilderParameters{Timestamp: 1}
+	newId, duplicate, err := s.Add(&param)
+	require.NoError(t, err)
+	require.False(t, duplicate)
+	require.NotEqual(t, id, newId)
+}
+
+func TestPayloadStoreNoBlock(t *testing.T) {
+	t.Parallel()
+	s := NewPayloadStore(func(param *core.BlockBuilderParameters, interrupt *int32) (*types.BlockWithReceipts, error) {
+		return nil, nil
+	}, 8, time.Hour)
+	id, _, err := s.Add(&core.BlockBuilderParameters{})
+	require.NoError(t, err)
+	// used to wait forever
+	_, ok, err := s.Get(id)
+	require.True(t, ok)
+	require.Error(t, err)
+}
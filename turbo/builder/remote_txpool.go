@@ -0,0 +1,105 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/gointerfaces"
+	"github.com/erigontech/erigon-lib/gointerfaces/txpool"
+	"github.com/erigontech/erigon-lib/kv"
+	types2 "github.com/erigontech/erigon-lib/types"
+
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/params"
+)
+
+// remoteTxPoolRefresh - the pending transactions fetched from the pool of the node are reused by YieldBest calls
+// within this time: the pool is fetched whole, while a payload calls YieldBest many times while packed
+const remoteTxPoolRefresh = 200 * time.Millisecond
+
+// RemoteTxPool - stagedsync.TxPoolForMining of a builder process: best pending transactions of the pool of the node,
+// over its txpool gRPC service. Unlike the local pool it doesn't wait for the pool to see block onTopOf.
+type RemoteTxPool struct {
+	ctx    context.Context
+	client txpool.TxpoolClient
+
+	lock      sync.Mutex
+	onTopOf   uint64
+	fetchedAt time.Time
+	pending   []remotePendingTxn // in ready-for-mining order
+}
+
+type remotePendingTxn struct {
+	rlp     []byte
+	hash    libcommon.Hash
+	sender  libcommon.Address
+	gas     uint64
+	blobGas uint64
+	isLocal bool
+}
+
+func NewRemoteTxPool(ctx context.Context, client txpool.TxpoolClient) *RemoteTxPool {
+	return &RemoteTxPool{ctx: ctx, client: client}
+}
+
+// best - pending transactions of the pool, fetched again for another block or once remoteTxPoolRefresh passed
+func (p *RemoteTxPool) best(onTopOf uint64) ([]remotePendingTxn, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.pending != nil && p.onTopOf == onTopOf && time.Since(p.fetchedAt) < remoteTxPoolRefresh {
+		return p.pending, nil
+	}
+	reply, err := p.client.Pending(p.ctx, &emptypb.Empty{})
+	if err != nil {
+		return nil, fmt.Errorf("remote txpool: %w", err)
+	}
+	pending := make([]remotePendingTxn, 0, len(reply.Txs))
+	for _, txn := range reply.Txs {
+		decoded, err := types.DecodeWrappedTransaction(txn.RlpTx)
+		if err != nil {
+			continue
+		}
+		pending = append(pending, remotePendingTxn{
+			rlp:     txn.RlpTx,
+			hash:    decoded.Hash(),
+			sender:  gointerfaces.ConvertH160toAddress(txn.Sender),
+			gas:     decoded.GetGas(),
+			blobGas: decoded.GetBlobGas(),
+			isLocal: txn.IsLocal,
+		})
+	}
+	p.onTopOf, p.fetchedAt, p.pending = onTopOf, time.Now(), pending
+	return pending, nil
+}
+
+func (p *RemoteTxPool) YieldBest(n uint16, txs *types2.TxsRlp, _ kv.Tx, onTopOf, availableGas, availableBlobGas uint64, toSkip mapset.Set[[32]byte]) (bool, int, error) {
+	pending, err := p.best(onTopOf)
+	if err != nil {
+		return false, 0, err
+	}
+	txs.Resize(uint(min(int(n), len(pending))))
+	count := 0
+	for _, txn := range pending {
+		if count >= int(n) || availableGas < params.TxGas {
+			break
+		}
+		if toSkip.Contains(txn.hash) || txn.gas > availableGas || txn.blobGas > availableBlobGas {
+			continue
+		}
+		// gas is left to execution to account, blob gas of a tx is known upfront
+		availableBlobGas -= txn.blobGas
+		txs.Txs[count] = txn.rlp
+		copy(txs.Senders.At(count), txn.sender[:])
+		txs.IsLocal[count] = txn.isLocal
+		toSkip.Add(txn.hash)
+		count++
+	}
+	txs.Resize(uint(count))
+	return true, count, nil
+}
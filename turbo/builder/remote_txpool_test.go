@@ -0,0 +1,82 @@
+package builder
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/gointerfaces"
+	"github.com/erigontech/erigon-lib/gointerfaces/txpool"
+	types2 "github.com/erigontech/erigon-lib/types"
+
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/crypto"
+	"github.com/erigontech/erigon/params"
+)
+
+type pendingTxpoolClient struct {
+	txpool.TxpoolClient
+	reply *txpool.PendingReply
+	calls int
+}
+
+func (c *pendingTxpoolClient) Pending(context.Context, *emptypb.Empty, ...grpc.CallOption) (*txpool.PendingReply, error) {
+	c.calls++
+	return c.reply, nil
+}
+
+func TestRemoteTxPool(t *testing.T) {
+	t.Parallel()
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	signer := types.LatestSignerForChainID(big.NewInt(1))
+	client := &pendingTxpoolClient{reply: &txpool.PendingReply{}}
+	var hashes []libcommon.Hash
+	for nonce := uint64(0); nonce < 3; nonce++ {
+		txn, err := types.SignTx(types.NewTransaction(nonce, libcommon.Address{1}, uint256.NewInt(1), params.TxGas, uint256.NewInt(1), nil), *signer, key)
+		require.NoError(t, err)
+		var buf bytes.Buffer
+		require.NoError(t, txn.MarshalBinary(&buf))
+		client.reply.Txs = append(client.reply.Txs, &txpool.PendingReply_Tx{Sender: gointerfaces.ConvertAddressToH160(sender), RlpTx: buf.Bytes()})
+		hashes = append(hashes, txn.Hash())
+	}
+	p := NewRemoteTxPool(context.Background(), client)
+
+	// a payload asks for the best transactions in portions, the pool is fetched once for them
+	toSkip := mapset.NewThreadUnsafeSet[[32]byte]()
+	var txs types2.TxsRlp
+	_, count, err := p.YieldBest(2, &txs, nil, 1, 30_000_000, 0, toSkip)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+	require.Equal(t, sender[:], txs.Senders.At(0))
+	_, count, err = p.YieldBest(2, &txs, nil, 1, 30_000_000, 0, toSkip)
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+	require.Equal(t, client.reply.Txs[2].RlpTx, txs.Txs[0])
+	require.True(t, toSkip.Contains(hashes[2]))
+	require.Equal(t, 1, client.calls)
+
+	// no gas left for a transfer
+	_, count, err = p.YieldBest(2, &txs, nil, 1, params.TxGas-1, 0, mapset.NewThreadUnsafeSet[[32]byte]())
+	require.NoError(t, err)
+	require.Zero(t, count)
+
+	// another block, or the same one later, sees the pool again
+	_, _, err = p.YieldBest(2, &txs, nil, 2, 30_000_000, 0, mapset.NewThreadUnsafeSet[[32]byte]())
+	require.NoError(t, err)
+	require.Equal(t, 2, client.calls)
+	p.fetchedAt = time.Now().Add(-remoteTxPoolRefresh)
+	_, _, err = p.YieldBest(2, &txs, nil, 2, 30_000_000, 0, mapset.NewThreadUnsafeSet[[32]byte]())
+	require.NoError(t, err)
+	require.Equal(t, 3, client.calls)
+}
@@ -37,6 +37,7 @@ var DefaultFlags = []cli.Flag{
 	&PruneReceiptBeforeFlag,
 	&PruneTxIndexBeforeFlag,
 	&PruneCallTracesBeforeFlag,
+	&PruneKeepDepositsFlag,
 	&BatchSizeFlag,
 	&BodyCacheLimitFlag,
 	&DatabaseVerbosityFlag,
@@ -70,6 +71,7 @@ var DefaultFlags = []cli.Flag{
 	&utils.HTTPTraceFlag,
 	&utils.HTTPDebugSingleFlag,
 	&utils.StateCacheFlag,
+	&utils.CodeAnalysisCacheFlag,
 	&utils.RpcBatchConcurrencyFlag,
 	&utils.RpcStreamingDisableFlag,
 	&utils.DBReadConcurrencyFlag,
@@ -77,8 +79,13 @@ var DefaultFlags = []cli.Flag{
 	&utils.RpcTraceCompatFlag,
 	&utils.RpcGasCapFlag,
 	&utils.RpcBatchLimit,
+	&utils.RpcBatchResponseLimit,
+	&utils.RpcMethodConcurrency,
+	&utils.RpcMethodGasCap,
+	&utils.RpcMethodResultLimit,
 	&utils.RpcReturnDataLimit,
 	&utils.AllowUnprotectedTxs,
+	&utils.RpcTxnNotIndexedErrorFlag,
 	&utils.RpcMaxGetProofRewindBlockCount,
 	&utils.RPCGlobalTxFeeCapFlag,
 	&utils.TxpoolApiAddrFlag,
@@ -98,12 +105,20 @@ var DefaultFlags = []cli.Flag{
 	&RpcSubscriptionFiltersMaxTxsFlag,
 	&RpcSubscriptionFiltersMaxAddressesFlag,
 	&RpcSubscriptionFiltersMaxTopicsFlag,
+	&RpcFiltersTimeoutFlag,
+	&RpcFiltersMaxPerClientFlag,
+	&RpcFiltersTrustedProxiesFlag,
+	&RpcFiltersPersistFlag,
 
 	&utils.SnapKeepBlocksFlag,
+	&utils.SnapFreezeDistanceFlag,
+	&utils.SnapBorFreezeDistanceFlag,
 	&utils.SnapStopFlag,
+	&utils.SnapEncryptionKeyFileFlag,
 	&utils.DbPageSizeFlag,
 	&utils.DbSizeLimitFlag,
 	&utils.ForcePartialCommitFlag,
+	&utils.DbReceiptsCompressionFlag,
 	&utils.TorrentPortFlag,
 	&utils.TorrentMaxPeersFlag,
 	&utils.TorrentConnsPerFileFlag,
@@ -150,6 +165,9 @@ var DefaultFlags = []cli.Flag{
 	&utils.MinerNoVerfiyFlag,
 	&utils.MinerSigningKeyFileFlag,
 	&utils.MinerRecommitIntervalFlag,
+	&utils.MinerPayloadHistoryFlag,
+	&utils.BuilderDeadlineFlag,
+	&utils.BuilderRemoteFlag,
 	&utils.SentryAddrFlag,
 	&utils.SentryLogPeerInfoFlag,
 	&utils.DownloaderAddrFlag,
@@ -163,10 +181,14 @@ var DefaultFlags = []cli.Flag{
 	&utils.WithoutHeimdallFlag,
 	&utils.BorBlockPeriodFlag,
 	&utils.BorBlockSizeFlag,
+	&utils.WithoutBorFlag,
 	&utils.WithHeimdallMilestones,
 	&utils.WithHeimdallWaypoints,
 	&utils.PolygonSyncFlag,
 	&utils.EthStatsURLFlag,
+	&utils.ExportURLFlag,
+	&utils.ExportTopicFlag,
+	&utils.ExportResumeFlag,
 	&utils.OverrideCancunFlag,
 	&utils.OverridePragueFlag,
 	&utils.OverrideOptimismCanyonFlag,
@@ -175,9 +197,35 @@ var DefaultFlags = []cli.Flag{
 	&utils.OverrideOptimismGraniteFlag,
 	&utils.OverrideOptimismHoloceneFlag,
 	&utils.RollupSequencerHTTPFlag,
+	&utils.RollupSequencerRetriesFlag,
+	&utils.RollupSequencerRetryBackoffFlag,
+	&utils.RollupSequencerLocalTxPoolFlag,
 	&utils.RollupHistoricalRPCFlag,
 	&utils.RollupHistoricalRPCTimeoutFlag,
+	&utils.RollupArchiveRPCFlag,
+	&utils.RollupArchiveRPCCacheSizeFlag,
+	&utils.RollupProbeFlag,
+	&utils.RollupOpNodeRPCFlag,
+	&utils.RollupProbeIntervalFlag,
+	&utils.RollupL1RPCFlag,
+	&utils.RollupL1BeaconRPCFlag,
+	&utils.RollupL1CacheSizeFlag,
+	&utils.RollupL1ViewFlag,
+	&utils.RollupL1ViewContractsFlag,
+	&utils.RollupL1ViewDepthFlag,
 	&utils.RollupHaltOnIncompatibleProtocolVersionFlag,
+	&utils.BackupDirFlag,
+	&utils.BackupIntervalFlag,
+	&utils.BackupKeepFlag,
+	&utils.BackupRemoteFlag,
+	&utils.BackupCompactFlag,
+	&utils.BackupVerifyFlag,
+	&utils.SequencerLockFlag,
+	&utils.SequencerLockTTLFlag,
+	&utils.SequencerLockIDFlag,
+	&utils.EngineFcuTimeoutFlag,
+	&utils.EngineFcuBusyRetryFlag,
+	&utils.EngineFcuAsyncFlag,
 
 	&utils.LightClientDiscoveryAddrFlag,
 	&utils.LightClientDiscoveryPortFlag,
@@ -188,6 +236,7 @@ var DefaultFlags = []cli.Flag{
 	&utils.OtsSearchMaxCapFlag,
 
 	&utils.SilkwormExecutionFlag,
+	&utils.SilkwormAuditFlag,
 	&utils.SilkwormRpcDaemonFlag,
 	&utils.SilkwormSentryFlag,
 	&utils.SilkwormVerbosityFlag,
@@ -209,6 +258,7 @@ var DefaultFlags = []cli.Flag{
 	&utils.BeaconApiWriteTimeoutFlag,
 	&utils.BeaconApiProtocolFlag,
 	&utils.BeaconApiIdleTimeoutFlag,
+	&utils.BeaconApiCheckpointRateLimitFlag,
 
 	&utils.CaplinBackfillingFlag,
 	&utils.CaplinBlobBackfillingFlag,
@@ -217,9 +267,26 @@ var DefaultFlags = []cli.Flag{
 
 	&utils.TrustedSetupFile,
 	&utils.RPCSlowFlag,
+	&utils.RPCAccessLogFlag,
+	&utils.RPCAccessLogSampleFlag,
+	&utils.RPCAccessLogSlowFlag,
 
 	&utils.TxPoolGossipDisableFlag,
 	&SyncLoopBlockLimitFlag,
 	&SyncLoopBreakAfterFlag,
 	&SyncLoopPruneLimitFlag,
+	&SyncSendersBackfillFlag,
+	&SyncSkipStagesFlag,
+	&SyncBadBlockHaltFlag,
+	&SyncBadBlockDumpDirFlag,
+	&SyncVerifyDepositNoncesFlag,
+	&SyncHotContractsWindowFlag,
+	&SyncPrefetchBlocksFlag,
+	&SyncMaxReorgDepthFlag,
+	&SyncForkValidatorMemoryLimitFlag,
+	&SyncCommitIntervalFlag,
+	&SyncCommitMaxDirtyFlag,
+	&SyncDryRunFlag,
+	&SyncIncrementalTrieFlag,
+	&ExperimentalBALFlag,
 }
@@ -24,6 +24,7 @@ import (
 	"github.com/erigontech/erigon/cmd/rpcdaemon/cli/httpcfg"
 	"github.com/erigontech/erigon/cmd/utils"
 	"github.com/erigontech/erigon/eth/ethconfig"
+	"github.com/erigontech/erigon/eth/stagedsync/stages"
 	"github.com/erigontech/erigon/ethdb/prune"
 	"github.com/erigontech/erigon/node/nodecfg"
 	"github.com/erigontech/erigon/turbo/rpchelper"
@@ -104,17 +105,22 @@ var (
 	}
 	PruneTxIndexBeforeFlag = cli.Uint64Flag{
 		Name:  "prune.t.before",
-		Usage: `Prune data before this block`,
+		Usage: `Prune data before this block: TxLookup indexes transactions from this block, e.g. the Bedrock block. With --rpc.txn-not-indexed-error, lookups of unknown transactions by RPC fail with "transaction not indexed"`,
 	}
 	PruneCallTracesBeforeFlag = cli.Uint64Flag{
 		Name:  "prune.c.before",
 		Usage: `Prune data before this block`,
 	}
+	PruneKeepDepositsFlag = cli.BoolFlag{
+		Name:  "prune.keep-deposits",
+		Usage: `Don't prune the receipts, logs and call traces of the blocks with OP deposit transactions (L1 attributes, bridge deposits)`,
+	}
 
 	ExperimentsFlag = cli.StringFlag{
 		Name: "experiments",
 		Usage: `Enable some experimental stages:
-* tevm - write TEVM translated code to the DB`,
+* tevm - write TEVM translated code to the DB
+* receipts.index-only - don't store receipts, only index their logs; eth_getLogs re-executes the matching blocks`,
 		Value: "default",
 	}
 
@@ -168,6 +174,76 @@ var (
 		Value: 0, // unlimited
 	}
 
+	SyncSendersBackfillFlag = cli.BoolFlag{
+		Name:  "sync.senders.backfill",
+		Usage: "Recover senders of frozen blocks which snapshots have without senders (imported from external snapshots or exports)",
+	}
+
+	SyncSkipStagesFlag = cli.StringFlag{
+		Name:  "sync.skip.stages",
+		Usage: "Comma-separated list of stages building indices which node never queries, e.g. TxLookup,LogIndex,CallTraces. Skippable: TxLookup, LogIndex, CallTraces, AccountHistoryIndex+StorageHistoryIndex",
+	}
+
+	SyncBadBlockHaltFlag = cli.BoolFlag{
+		Name:  "sync.badblock.halt",
+		Usage: "Stop sync on a block failing execution or state root check instead of unwinding it, and dump the block, receipts, touched state and call traces to a bundle directory for reporting the divergence",
+	}
+
+	SyncBadBlockDumpDirFlag = cli.StringFlag{
+		Name:  "sync.badblock.dump.dir",
+		Usage: "Directory of bad block bundles written with --sync.badblock.halt (default: <datadir>/badblocks)",
+	}
+
+	SyncVerifyDepositNoncesFlag = cli.BoolFlag{
+		Name:  "sync.verify.deposit.nonces",
+		Usage: "Fail a block at execution if the DepositNonce of a deposit receipt, or the nonce of its sender after it, doesn't follow from the parent state and the transactions of the block. Catches deposits dropped or reordered by execution (OP chains, from Regolith). Erigon2 execution only, refused with history v3",
+	}
+
+	SyncDryRunFlag = cli.BoolFlag{
+		Name:  "sync.dry-run",
+		Usage: "Run the stages on an in-memory overlay of the db and log the stage progress and table changes each cycle would make, without committing anything. For validating a new release against a production datadir before switching to it",
+	}
+
+	SyncIncrementalTrieFlag = cli.BoolFlag{
+		Name:  "sync.incremental.trie",
+		Usage: "Update the hashed state and intermediate hashes right after execution of small block batches (near the chain tip, e.g. on the sequencer), from the accumulated state changes instead of the change sets, and check the state root there. HashState and IntermediateHashes stages then only run for larger batches",
+	}
+
+	SyncHotContractsWindowFlag = cli.DurationFlag{
+		Name:  "sync.hot-contracts.window",
+		Usage: "Meter the gas used by the code of each contract at execution and report the contracts using the most gas per second over this window of block time, by debug_hotContracts and the exec_hot_contract_gas_per_second metric. Execution is slower with it. Off if 0",
+	}
+
+	SyncPrefetchBlocksFlag = cli.Uint64Flag{
+		Name:  "sync.prefetch.blocks",
+		Usage: "Prefetch the state a block is likely to read while it executes: the slots of the access lists of its txs, and the slots the contracts it calls changed in this many previous blocks. Reduces cold reads of execution at the chain tip, in the read-ahead of the initial sync too. Erigon2 execution only, ignored with history v3. Off if 0",
+	}
+
+	SyncMaxReorgDepthFlag = cli.Uint64Flag{
+		Name:  "sync.max-reorg-depth",
+		Usage: "Refuse forkchoice updates which would unwind more than this many blocks of the head, protecting the history of a replica from bugs of the rollup node driver. A deeper reorg is let through once after admin_allowDeepReorg, or done by admin_rewindToBlock. Off if 0",
+	}
+
+	SyncForkValidatorMemoryLimitFlag = cli.StringFlag{
+		Name:  "sync.fork-validator.memory-limit",
+		Usage: "Spill the state diff of the unsafe chain head validated by the fork validator (newPayload extending the canonical chain) from memory to a temporary mdbx in the tmp dir when it grows above this size, e.g. 256MB. Keeps long unsafe chains of the rollup node from running small replicas out of memory. Off if 0",
+	}
+
+	SyncCommitIntervalFlag = cli.DurationFlag{
+		Name:  "sync.commit.interval",
+		Usage: "Commit the batch of the execution stage at least this often, e.g. 2m, even if it's smaller than --batchSize. Keeps nodes on slow disks from building multi-minute uncommitted batches, which are executed again after a crash. Erigon2 execution only, ignored with history v3. Off if 0",
+	}
+
+	SyncCommitMaxDirtyFlag = cli.StringFlag{
+		Name:  "sync.commit.max-dirty",
+		Usage: "Commit the batch of the execution stage once the dirty pages of its db transaction (receipts, change sets, call traces) reach this size, e.g. 1GB, even if the batch is smaller than --batchSize. Erigon2 execution only, ignored with history v3. Off if 0",
+	}
+
+	ExperimentalBALFlag = cli.BoolFlag{
+		Name:  "experimental.bal",
+		Usage: "Collect block access lists (experimental EIP-7928) during execution and serve them by debug_getBlockAccessList. Not collected by HistoryV3 execution",
+	}
+
 	UploadLocationFlag = cli.StringFlag{
 		Name:  "upload.location",
 		Usage: "Location to upload snapshot segments to",
@@ -230,9 +306,10 @@ var (
 	}
 
 	EvmCallTimeoutFlag = cli.DurationFlag{
-		Name:  "rpc.evmtimeout",
-		Usage: "Maximum amount of time to wait for the answer from EVM call.",
-		Value: rpccfg.DefaultEvmCallTimeout,
+		Name:    "rpc.evm-timeout",
+		Aliases: []string{"rpc.evmtimeout"},
+		Usage:   "Maximum time the EVM may run a single transaction of eth_call, eth_estimateGas, tracers and trace_*; the execution is interrupted after it (0 - no limit).",
+		Value:   rpccfg.DefaultEvmCallTimeout,
 	}
 
 	OverlayGetLogsFlag = cli.DurationFlag{
@@ -272,6 +349,25 @@ var (
 		Usage: "Maximum number of topics per subscription to filter logs by.",
 		Value: rpchelper.DefaultFiltersConfig.RpcSubscriptionFiltersMaxTopics,
 	}
+	RpcFiltersTimeoutFlag = cli.DurationFlag{
+		Name:  "rpc.filters.timeout",
+		Usage: "Filters of eth_getFilterChanges not polled for this long are uninstalled, 0 - never.",
+		Value: rpchelper.DefaultFiltersConfig.RpcFiltersTimeout,
+	}
+	RpcFiltersMaxPerClientFlag = cli.IntFlag{
+		Name:  "rpc.filters.maxperclient",
+		Usage: "Maximum number of filters of eth_getFilterChanges installed by a client (remote address, or X-Forwarded-For behind rpc.filters.trustedproxies).",
+		Value: rpchelper.DefaultFiltersConfig.RpcFiltersMaxPerClient,
+	}
+	RpcFiltersTrustedProxiesFlag = cli.StringFlag{
+		Name:  "rpc.filters.trustedproxies",
+		Usage: "Comma separated IPs of the proxies whose X-Forwarded-For identifies the client for rpc.filters.maxperclient.",
+	}
+	RpcFiltersPersistFlag = cli.StringFlag{
+		Name:  "rpc.filters.persist",
+		Usage: "File the log filters of eth_getFilterChanges are saved to, they are restored from it with the same IDs on restart.",
+		Value: rpchelper.DefaultFiltersConfig.RpcFiltersPersistFile,
+	}
 
 	TxPoolCommitEvery = cli.DurationFlag{
 		Name:  "txpool.commit.every",
@@ -302,6 +398,7 @@ func ApplyFlagsForEthConfig(ctx *cli.Context, cfg *ethconfig.Config, logger log.
 	if err != nil {
 		utils.Fatalf(fmt.Sprintf("error while parsing mode: %v", err))
 	}
+	mode.KeepDeposits = ctx.Bool(PruneKeepDepositsFlag.Name)
 	cfg.Prune = mode
 	if ctx.String(BatchSizeFlag.Name) != "" {
 		err := cfg.BatchSize.UnmarshalText([]byte(ctx.String(BatchSizeFlag.Name)))
@@ -348,6 +445,43 @@ func ApplyFlagsForEthConfig(ctx *cli.Context, cfg *ethconfig.Config, logger log.
 		cfg.Sync.LoopBlockLimit = limit
 	}
 
+	cfg.Sync.SendersBackfill = ctx.Bool(SyncSendersBackfillFlag.Name)
+
+	if list := ctx.String(SyncSkipStagesFlag.Name); len(list) > 0 {
+		skip, err := stages.ParseSkipStages(list)
+		if err != nil {
+			utils.Fatalf("Invalid %s: %v", SyncSkipStagesFlag.Name, err)
+		}
+		for _, stage := range skip {
+			logger.Warn("[sync] Stage is skipped", "stage", stage, "unavailable", stages.Skippable[stage])
+		}
+		cfg.Sync.SkipStages = skip
+	}
+
+	cfg.Sync.BlockAccessLists = ctx.Bool(ExperimentalBALFlag.Name)
+	cfg.Sync.BadBlockHalt = ctx.Bool(SyncBadBlockHaltFlag.Name)
+	cfg.Sync.BadBlockDumpDir = ctx.String(SyncBadBlockDumpDirFlag.Name)
+	cfg.Sync.VerifyDepositNonces = ctx.Bool(SyncVerifyDepositNoncesFlag.Name)
+	cfg.Sync.DryRun = ctx.Bool(SyncDryRunFlag.Name)
+	cfg.Sync.IncrementalTrie = ctx.Bool(SyncIncrementalTrieFlag.Name)
+	cfg.Sync.HotContractsWindow = ctx.Duration(SyncHotContractsWindowFlag.Name)
+	cfg.Sync.PrefetchBlocks = ctx.Uint64(SyncPrefetchBlocksFlag.Name)
+	cfg.Sync.MaxReorgDepth = ctx.Uint64(SyncMaxReorgDepthFlag.Name)
+	if ctx.String(SyncForkValidatorMemoryLimitFlag.Name) != "" {
+		if err := cfg.Sync.ForkValidatorMemoryLimit.UnmarshalText([]byte(ctx.String(SyncForkValidatorMemoryLimitFlag.Name))); err != nil {
+			utils.Fatalf("Invalid size provided in %s: %v", SyncForkValidatorMemoryLimitFlag.Name, err)
+		}
+	}
+	cfg.Sync.ExecCommitInterval = ctx.Duration(SyncCommitIntervalFlag.Name)
+	if ctx.String(SyncCommitMaxDirtyFlag.Name) != "" {
+		if err := cfg.Sync.ExecCommitMaxDirty.UnmarshalText([]byte(ctx.String(SyncCommitMaxDirtyFlag.Name))); err != nil {
+			utils.Fatalf("Invalid size provided in %s: %v", SyncCommitMaxDirtyFlag.Name, err)
+		}
+	}
+	if cfg.Sync.DryRun {
+		logger.Warn("[sync] Dry run, stages are not committed", "flag", SyncDryRunFlag.Name)
+	}
+
 	if location := ctx.String(UploadLocationFlag.Name); len(location) > 0 {
 		cfg.Sync.UploadLocation = location
 	}
@@ -429,6 +563,9 @@ func ApplyFlagsForEthConfigCobra(f *pflag.FlagSet, cfg *ethconfig.Config) {
 		if err != nil {
 			utils.Fatalf(fmt.Sprintf("error while parsing mode: %v", err))
 		}
+		if v := f.Bool(PruneKeepDepositsFlag.Name, PruneKeepDepositsFlag.Value, PruneKeepDepositsFlag.Usage); v != nil {
+			mode.KeepDeposits = *v
+		}
 		cfg.Prune = mode
 	}
 	if v := f.String(BatchSizeFlag.Name, BatchSizeFlag.Value, BatchSizeFlag.Usage); v != nil {
@@ -520,26 +657,43 @@ func setEmbeddedRpcDaemon(ctx *cli.Context, cfg *nodecfg.Config, logger log.Logg
 			RpcSubscriptionFiltersMaxTxs:       ctx.Int(RpcSubscriptionFiltersMaxTxsFlag.Name),
 			RpcSubscriptionFiltersMaxAddresses: ctx.Int(RpcSubscriptionFiltersMaxAddressesFlag.Name),
 			RpcSubscriptionFiltersMaxTopics:    ctx.Int(RpcSubscriptionFiltersMaxTopicsFlag.Name),
+			RpcFiltersTimeout:                  ctx.Duration(RpcFiltersTimeoutFlag.Name),
+			RpcFiltersMaxPerClient:             ctx.Int(RpcFiltersMaxPerClientFlag.Name),
+			RpcFiltersTrustedProxies:           libcommon.CliString2Array(ctx.String(RpcFiltersTrustedProxiesFlag.Name)),
+			RpcFiltersPersistFile:              ctx.String(RpcFiltersPersistFlag.Name),
 		},
 		Gascap:                      ctx.Uint64(utils.RpcGasCapFlag.Name),
 		Feecap:                      ctx.Float64(utils.RPCGlobalTxFeeCapFlag.Name),
 		MaxTraces:                   ctx.Uint64(utils.TraceMaxtracesFlag.Name),
 		TraceCompatibility:          ctx.Bool(utils.RpcTraceCompatFlag.Name),
 		BatchLimit:                  ctx.Int(utils.RpcBatchLimit.Name),
+		BatchResponseLimit:          ctx.Int(utils.RpcBatchResponseLimit.Name),
+		MethodConcurrency:           ctx.String(utils.RpcMethodConcurrency.Name),
+		MethodGasCap:                ctx.String(utils.RpcMethodGasCap.Name),
+		MethodResultLimit:           ctx.String(utils.RpcMethodResultLimit.Name),
 		ReturnDataLimit:             ctx.Int(utils.RpcReturnDataLimit.Name),
 		AllowUnprotectedTxs:         ctx.Bool(utils.AllowUnprotectedTxs.Name),
 		MaxGetProofRewindBlockCount: ctx.Int(utils.RpcMaxGetProofRewindBlockCount.Name),
+		TxnNotIndexedError:          ctx.Bool(utils.RpcTxnNotIndexedErrorFlag.Name),
 
 		OtsMaxPageSize: ctx.Uint64(utils.OtsSearchMaxCapFlag.Name),
 
 		TxPoolApiAddr: ctx.String(utils.TxpoolApiAddrFlag.Name),
 
-		RollupSequencerHTTP:        ctx.String(utils.RollupSequencerHTTPFlag.Name),
-		RollupHistoricalRPC:        ctx.String(utils.RollupHistoricalRPCFlag.Name),
-		RollupHistoricalRPCTimeout: ctx.Duration(utils.RollupHistoricalRPCTimeoutFlag.Name),
+		RollupSequencerHTTP:         ctx.String(utils.RollupSequencerHTTPFlag.Name),
+		RollupSequencerRetries:      ctx.Int(utils.RollupSequencerRetriesFlag.Name),
+		RollupSequencerRetryBackoff: ctx.Duration(utils.RollupSequencerRetryBackoffFlag.Name),
+		RollupSequencerLocalTxPool:  ctx.Bool(utils.RollupSequencerLocalTxPoolFlag.Name),
+		RollupHistoricalRPC:         ctx.String(utils.RollupHistoricalRPCFlag.Name),
+		RollupHistoricalRPCTimeout:  ctx.Duration(utils.RollupHistoricalRPCTimeoutFlag.Name),
+		RollupArchiveRPC:            ctx.String(utils.RollupArchiveRPCFlag.Name),
+		RollupArchiveRPCCacheSize:   ctx.Int(utils.RollupArchiveRPCCacheSizeFlag.Name),
 
 		StateCache:          kvcache.DefaultCoherentConfig,
 		RPCSlowLogThreshold: ctx.Duration(utils.RPCSlowFlag.Name),
+		RPCAccessLog:        ctx.String(utils.RPCAccessLogFlag.Name),
+		RPCAccessLogSample:  ctx.Float64(utils.RPCAccessLogSampleFlag.Name),
+		RPCAccessLogSlow:    ctx.Duration(utils.RPCAccessLogSlowFlag.Name),
 	}
 
 	if c.Enabled {
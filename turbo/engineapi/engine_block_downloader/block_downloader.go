@@ -18,6 +18,7 @@ import (
 	"github.com/erigontech/erigon-lib/gointerfaces/execution"
 	"github.com/erigontech/erigon-lib/kv"
 	"github.com/erigontech/erigon-lib/kv/dbutils"
+	"github.com/erigontech/erigon-lib/metrics"
 
 	"github.com/erigontech/erigon/core/rawdb"
 	"github.com/erigontech/erigon/core/types"
@@ -35,6 +36,9 @@ const (
 	forkchoiceTimeoutMillis     = 5000
 )
 
+// backfillPendingBlocks - downloaded blocks still to be inserted, each batch insertion gives way to new payloads
+var backfillPendingBlocks = metrics.GetOrCreateGauge("engine_backfill_pending_blocks")
+
 type RequestBodyFunction func(context.Context, *bodydownload.BodyRequest) ([64]byte, bool)
 
 // EngineBlockDownloader is responsible to download blocks in reverse, and then insert them in the database.
@@ -210,6 +214,7 @@ func (e *EngineBlockDownloader) insertHeadersAndBodies(ctx context.Context, tx k
 	blockWrittenLogSize := 20_000
 	// We divide them in batches
 	blocksBatch := []*types.Block{}
+	defer backfillPendingBlocks.SetUint64(0)
 
 	headersCursors, err := tx.Cursor(kv.Headers)
 	if err != nil {
@@ -223,6 +228,7 @@ func (e *EngineBlockDownloader) insertHeadersAndBodies(ctx context.Context, tx k
 			return err
 		}
 		if len(blocksBatch) == blockBatchSize {
+			backfillPendingBlocks.SetUint64(toBlock - blocksBatch[0].NumberU64() + 1)
 			if err := e.chainRW.InsertBlocksAndWait(ctx, blocksBatch); err != nil {
 				return err
 			}
@@ -0,0 +1,93 @@
+package engine_errors
+
+import (
+	"errors"
+
+	"github.com/erigontech/erigon/rpc"
+)
+
+// Error codes of the Engine API, see https://github.com/ethereum/execution-apis/blob/main/src/engine/common.md#errors
+const (
+	ServerErrorCode              = -32000
+	UnknownPayloadCode           = -38001
+	InvalidForkchoiceStateCode   = -38002
+	InvalidPayloadAttributesCode = -38003
+	TooLargeRequestCode          = -38004
+	UnsupportedForkCode          = -38005
+
+	// NotSequencerLeaderCode is not in the spec, op-conductor deployments expect it from a follower sequencer
+	NotSequencerLeaderCode = -38100
+)
+
+var (
+	ErrUnknownPayload           = &Error{Code: UnknownPayloadCode, Message: "Unknown payload"}
+	ErrInvalidForkchoiceState   = &Error{Code: InvalidForkchoiceStateCode, Message: "Invalid forkchoice state"}
+	ErrInvalidPayloadAttributes = &Error{Code: InvalidPayloadAttributesCode, Message: "Invalid payload attributes"}
+	ErrTooLargeRequest          = &Error{Code: TooLargeRequestCode, Message: "Too large request"}
+	ErrUnsupportedFork          = &Error{Code: UnsupportedForkCode, Message: "Unsupported fork"}
+	ErrNotSequencerLeader       = &Error{Code: NotSequencerLeaderCode, Message: "Not sequencer leader: leadership lock is held by other instance"}
+
+	// op-node treats the server errors as temporary and retries the call
+
+	// ErrBusy - the execution module is processing another request
+	ErrBusy = &Error{Code: ServerErrorCode, Message: "Execution service is busy"}
+	// ErrNotProposer - payload building is requested with --proposer.disable
+	ErrNotProposer = &Error{Code: ServerErrorCode, Message: "Execution layer not running as a proposer. enable proposer by taking out the --proposer.disable flag on startup"}
+)
+
+// Error is returned to the CL as is: the code and the message are the ones of the spec, the cause goes to the error data.
+// Sentinels are shared, use With to add a cause.
+type Error struct {
+	Code    int
+	Message string
+	Err     error
+}
+
+func (e *Error) ErrorCode() int { return e.Code }
+
+func (e *Error) Error() string { return e.Message }
+
+func (e *Error) ErrorData() interface{} {
+	if e.Err == nil {
+		return nil
+	}
+	return struct {
+		Error string `json:"err"`
+	}{e.Err.Error()}
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Is - the errors with the same code and message match, whatever the cause
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code && t.Message == e.Message
+}
+
+// With returns a copy of the error caused by err
+func (e *Error) With(err error) *Error {
+	return &Error{Code: e.Code, Message: e.Message, Err: err}
+}
+
+// ToRPC makes err returned from deep inside (e.g. the execution module) carry its Engine API code, as the rpc
+// server only looks at the returned error itself. Errors without a code are left as they are.
+func ToRPC(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(rpc.Error); ok {
+		return err
+	}
+	var engineErr *Error
+	if errors.As(err, &engineErr) {
+		if engineErr.Err != nil { // the cause is more telling than the wrapping
+			return engineErr
+		}
+		return engineErr.With(err)
+	}
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		return &Error{Code: rpcErr.ErrorCode(), Message: rpcErr.Error(), Err: err}
+	}
+	return err
+}
@@ -0,0 +1,45 @@
+package engine_errors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon/rpc"
+)
+
+func TestToRPC(t *testing.T) {
+	require.Nil(t, ToRPC(nil))
+
+	// wrapped by the execution module: the code and the message of the spec, the whole error in data
+	err := ToRPC(fmt.Errorf("updateForkChoice: %w", ErrBusy))
+	var rpcErr rpc.Error
+	require.True(t, errors.As(err, &rpcErr))
+	require.Equal(t, err, rpcErr)
+	require.Equal(t, ServerErrorCode, rpcErr.ErrorCode())
+	require.Equal(t, "Execution service is busy", rpcErr.Error())
+	require.Equal(t, "updateForkChoice: Execution service is busy", err.(rpc.DataError).ErrorData().(struct {
+		Error string `json:"err"`
+	}).Error)
+	require.ErrorIs(t, err, ErrBusy)
+	require.NotErrorIs(t, err, ErrNotProposer)
+
+	// the cause is kept
+	cause := errors.New("forkChoiceUpdated timeout")
+	err = ToRPC(fmt.Errorf("forkchoice: %w", ErrBusy.With(cause)))
+	require.ErrorIs(t, err, ErrBusy)
+	require.ErrorIs(t, err, cause)
+
+	// as is
+	require.Equal(t, ErrUnknownPayload, ToRPC(ErrUnknownPayload))
+	invalidParams := &rpc.InvalidParamsError{Message: "nil blob hashes array"}
+	require.Equal(t, invalidParams, ToRPC(invalidParams))
+	plain := errors.New("not a proof-of-stake chain")
+	require.Equal(t, plain, ToRPC(plain))
+
+	// rpc error wrapped
+	err = ToRPC(fmt.Errorf("getPayload: %w", invalidParams))
+	require.Equal(t, -32602, err.(rpc.Error).ErrorCode())
+}
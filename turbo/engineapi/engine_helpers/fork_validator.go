@@ -19,12 +19,15 @@ import (
 	"fmt"
 	"sync"
 
+	"github.com/c2h5oh/datasize"
+
 	"github.com/erigontech/erigon/cl/phase1/core/state/lru"
 
 	libcommon "github.com/erigontech/erigon-lib/common"
 	"github.com/erigontech/erigon-lib/kv"
 	"github.com/erigontech/erigon-lib/kv/membatchwithdb"
 	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon-lib/metrics"
 	"github.com/erigontech/erigon-lib/wrap"
 	"github.com/erigontech/erigon/common/math"
 	"github.com/erigontech/erigon/consensus"
@@ -40,6 +43,11 @@ import (
 // the maximum point from the current head, past which side forks are not validated anymore.
 const maxForkDepth = 32 // 32 slots is the duration of an epoch thus there cannot be side forks in PoS deeper than 32 blocks from head.
 
+var (
+	forkValidatorDiffSize = metrics.GetOrCreateGauge(`fork_validator_diff_size`)
+	forkValidatorSpills   = metrics.GetOrCreateCounter(`fork_validator_spills`)
+)
+
 type validatePayloadFunc func(wrap.TxContainer, *types.Header, *types.RawBody, uint64, []*types.Header, []*types.RawBody, *shards.Notifications) error
 
 type ForkValidator struct {
@@ -56,6 +64,8 @@ type ForkValidator struct {
 	// this is the current point where we processed the chain so far.
 	currentHeight uint64
 	tmpDir        string
+	// the extending fork diff above this size is spilled from the heap to a temporary mdbx in tmpDir; off if 0.
+	memoryLimit datasize.ByteSize
 	// block hashes that are deemed valid
 	validHashes *lru.Cache[libcommon.Hash, bool]
 
@@ -91,6 +101,13 @@ func NewForkValidator(ctx context.Context, currentHeight uint64, validatePayload
 	}
 }
 
+// SetMemoryLimit sets the size of the extending fork diff above which it is spilled to a temporary mdbx in tmpDir.
+func (fv *ForkValidator) SetMemoryLimit(limit datasize.ByteSize) {
+	fv.lock.Lock()
+	defer fv.lock.Unlock()
+	fv.memoryLimit = limit
+}
+
 // ExtendingForkHeadHash return the fork head hash of the fork that extends the canonical chain.
 func (fv *ForkValidator) ExtendingForkHeadHash() libcommon.Hash {
 	fv.lock.Lock()
@@ -107,7 +124,7 @@ func (fv *ForkValidator) NotifyCurrentHeight(currentHeight uint64) {
 	}
 	fv.currentHeight = currentHeight
 	// If the head changed,e previous assumptions on head are incorrect now.
-	fv.memoryDiff = nil
+	fv.resetMemoryDiff()
 	fv.extendingForkNotifications = nil
 	fv.extendingForkNumber = 0
 	fv.extendingForkHeadHash = libcommon.Hash{}
@@ -123,7 +140,7 @@ func (fv *ForkValidator) FlushExtendingFork(tx kv.RwTx, accumulator *shards.Accu
 	}
 	fv.extendingForkNotifications.Accumulator.CopyAndReset(accumulator)
 	// Clean extending fork data
-	fv.memoryDiff = nil
+	fv.resetMemoryDiff()
 	fv.extendingForkHeadHash = libcommon.Hash{}
 	fv.extendingForkNumber = 0
 	fv.extendingForkNotifications = nil
@@ -168,10 +185,18 @@ func (fv *ForkValidator) ValidatePayload(tx kv.Tx, header *types.Header, body *t
 			return
 		}
 		if validationError == nil {
-			fv.memoryDiff, criticalError = extendingFork.Diff()
+			fv.resetMemoryDiff()
+			// past the limit the diff is streamed to a temporary mdbx, it isn't copied into the heap first
+			fv.memoryDiff, criticalError = extendingFork.DiffWithLimit(fv.memoryLimit.Bytes(), fv.tmpDir, logger)
 			if criticalError != nil {
 				return
 			}
+			size := fv.memoryDiff.Size()
+			forkValidatorDiffSize.SetUint64(size)
+			if fv.memoryDiff.Spilled() {
+				forkValidatorSpills.Inc()
+				logger.Debug("Execution ForkValidator spilled the extending fork diff", "block", fv.extendingForkNumber, "size", datasize.ByteSize(size).HR(), "limit", fv.memoryLimit.HR())
+			}
 		}
 		return status, latestValidHash, validationError, criticalError
 	}
@@ -249,7 +274,16 @@ func (fv *ForkValidator) ValidatePayload(tx kv.Tx, header *types.Header, body *t
 func (fv *ForkValidator) clear() {
 	fv.extendingForkHeadHash = libcommon.Hash{}
 	fv.extendingForkNumber = 0
+	fv.resetMemoryDiff()
+}
+
+// resetMemoryDiff drops the extending fork diff, removing its spill if any.
+func (fv *ForkValidator) resetMemoryDiff() {
+	if fv.memoryDiff != nil {
+		fv.memoryDiff.Close()
+	}
 	fv.memoryDiff = nil
+	forkValidatorDiffSize.SetUint64(0)
 }
 
 // Clear wipes out current extending fork data.
@@ -283,7 +317,7 @@ func (fv *ForkValidator) validateAndStorePayload(txc wrap.TxContainer, header *t
 			return
 		}
 		status = engine_types.InvalidStatus
-		fv.memoryDiff = nil
+		fv.resetMemoryDiff()
 		fv.extendingForkHeadHash = libcommon.Hash{}
 		fv.extendingForkNumber = 0
 		return
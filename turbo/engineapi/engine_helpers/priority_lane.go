@@ -0,0 +1,18 @@
+package engine_helpers
+
+import "context"
+
+type priorityLaneKey struct{}
+
+// WithPriorityLane marks requests to the execution module made with ctx as extending the unsafe chain: they wait
+// for the execution lock instead of being turned away as busy, and no other request takes the lock while they wait.
+// The mark only crosses in-process (direct) execution clients.
+func WithPriorityLane(ctx context.Context) context.Context {
+	return context.WithValue(ctx, priorityLaneKey{}, true)
+}
+
+// IsPriorityLane - the request was marked by WithPriorityLane
+func IsPriorityLane(ctx context.Context) bool {
+	priority, _ := ctx.Value(priorityLaneKey{}).(bool)
+	return priority
+}
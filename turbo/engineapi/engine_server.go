@@ -38,8 +38,10 @@ import (
 	"github.com/erigontech/erigon/params"
 	"github.com/erigontech/erigon/rpc"
 	"github.com/erigontech/erigon/turbo/engineapi/engine_block_downloader"
+	"github.com/erigontech/erigon/turbo/engineapi/engine_errors"
 	"github.com/erigontech/erigon/turbo/engineapi/engine_helpers"
 	"github.com/erigontech/erigon/turbo/engineapi/engine_types"
+	"github.com/erigontech/erigon/turbo/engineapi/sequencerlock"
 	"github.com/erigontech/erigon/turbo/execution/eth1/eth1_chain_reader.go"
 	"github.com/erigontech/erigon/turbo/jsonrpc"
 	"github.com/erigontech/erigon/turbo/rpchelper"
@@ -60,6 +62,8 @@ type EngineServer struct {
 	chainRW eth1_chain_reader.ChainReaderWriterEth1
 	lock    sync.Mutex
 	logger  log.Logger
+	// nil - this instance is the only sequencer
+	sequencerLock *sequencerlock.Lock
 
 	nodeCloser func() error
 }
@@ -68,7 +72,7 @@ const fcuTimeout = 1000 // according to mathematics: 1000 millisecods = 1 second
 
 func NewEngineServer(logger log.Logger, config *chain.Config, executionService execution.ExecutionClient,
 	hd *headerdownload.HeaderDownload,
-	blockDownloader *engine_block_downloader.EngineBlockDownloader, test bool, proposing bool, ethConfig *ethconfig.Config, nodeCloser func() error) *EngineServer {
+	blockDownloader *engine_block_downloader.EngineBlockDownloader, test bool, proposing bool, ethConfig *ethconfig.Config, sequencerLock *sequencerlock.Lock, nodeCloser func() error) *EngineServer {
 	chainRW := eth1_chain_reader.NewChainReaderEth1(config, executionService, fcuTimeout)
 	return &EngineServer{
 		logger:           logger,
@@ -79,6 +83,7 @@ func NewEngineServer(logger log.Logger, config *chain.Config, executionService e
 		chainRW:          chainRW,
 		proposing:        proposing,
 		hd:               hd,
+		sequencerLock:    sequencerLock,
 		nodeCloser:       nodeCloser,
 	}
 }
@@ -148,7 +153,9 @@ func (s *EngineServer) checkRequestsPresence(time uint64, executionRequests []he
 // EngineNewPayload validates and possibly executes payload
 func (s *EngineServer) newPayload(ctx context.Context, req *engine_types.ExecutionPayload,
 	expectedBlobHashes []libcommon.Hash, parentBeaconBlockRoot *libcommon.Hash, executionRequests []hexutility.Bytes, version clparams.StateVersion,
-) (*engine_types.PayloadStatus, error) {
+) (_ *engine_types.PayloadStatus, err error) {
+	defer func() { err = engine_errors.ToRPC(err) }()
+
 	var bloom types.Bloom
 	copy(bloom[:], req.LogsBloom)
 
@@ -226,7 +233,7 @@ func (s *EngineServer) newPayload(ctx context.Context, req *engine_types.Executi
 		(s.config.IsCancun(header.Time) && version < clparams.DenebVersion) ||
 		(!s.config.IsPrague(header.Time) && version >= clparams.ElectraVersion) ||
 		(s.config.IsPrague(header.Time) && version < clparams.ElectraVersion) {
-		return nil, &rpc.UnsupportedForkError{Message: "Unsupported fork"}
+		return nil, engine_errors.ErrUnsupportedFork
 	}
 
 	blockHash := req.BlockHash
@@ -438,9 +445,11 @@ func (s *EngineServer) getQuickPayloadStatusIfPossible(ctx context.Context, bloc
 }
 
 // EngineGetPayload retrieves previously assembled payload (Validators only)
-func (s *EngineServer) getPayload(ctx context.Context, payloadId uint64, version clparams.StateVersion) (*engine_types.GetPayloadResponse, error) {
+func (s *EngineServer) getPayload(ctx context.Context, payloadId uint64, version clparams.StateVersion) (_ *engine_types.GetPayloadResponse, err error) {
+	defer func() { err = engine_errors.ToRPC(err) }()
+
 	if !s.proposing {
-		return nil, fmt.Errorf("execution layer not running as a proposer. enable proposer by taking out the --proposer.disable flag on startup")
+		return nil, engine_errors.ErrNotProposer
 	}
 
 	if s.config.TerminalTotalDifficulty == nil {
@@ -459,12 +468,12 @@ func (s *EngineServer) getPayload(ctx context.Context, payloadId uint64, version
 	}
 	if resp.Busy {
 		s.logger.Warn("Cannot build payload, execution is busy", "payloadId", payloadId)
-		return nil, &engine_helpers.UnknownPayloadErr
+		return nil, engine_errors.ErrBusy
 	}
 	// If the service is busy or there is no data for the given id then respond accordingly.
 	if resp.Data == nil {
 		s.logger.Warn("Payload not stored", "payloadId", payloadId)
-		return nil, &engine_helpers.UnknownPayloadErr
+		return nil, engine_errors.ErrUnknownPayload
 
 	}
 
@@ -489,7 +498,7 @@ func (s *EngineServer) getPayload(ctx context.Context, payloadId uint64, version
 		(s.config.IsCancun(ts) && version < clparams.DenebVersion) ||
 		(!s.config.IsPrague(ts) && version >= clparams.ElectraVersion) ||
 		(s.config.IsPrague(ts) && version < clparams.ElectraVersion) {
-		return nil, &rpc.UnsupportedForkError{Message: "Unsupported fork"}
+		return nil, engine_errors.ErrUnsupportedFork
 	}
 
 	response := engine_types.GetPayloadResponse{
@@ -510,9 +519,10 @@ func (s *EngineServer) getPayload(ctx context.Context, payloadId uint64, version
 
 // engineForkChoiceUpdated either states new block head or request the assembling of a new block
 func (s *EngineServer) forkchoiceUpdated(ctx context.Context, forkchoiceState *engine_types.ForkChoiceState, payloadAttributes *engine_types.PayloadAttributes, version clparams.StateVersion,
-) (*engine_types.ForkChoiceUpdatedResponse, error) {
+) (_ *engine_types.ForkChoiceUpdatedResponse, err error) {
+	defer func() { err = engine_errors.ToRPC(err) }()
+
 	var status *engine_types.PayloadStatus
-	var err error
 	// In the Optimism case, we allow arbitrary rewinding of the safe block
 	// hash, so we skip the path which might short-circuit that
 	if s.config.Optimism == nil {
@@ -552,49 +562,55 @@ func (s *EngineServer) forkchoiceUpdated(ctx context.Context, forkchoiceState *e
 	}
 
 	if version < clparams.DenebVersion && payloadAttributes.ParentBeaconBlockRoot != nil {
-		return nil, &engine_helpers.InvalidPayloadAttributesErr // Unexpected Beacon Root
+		return nil, engine_errors.ErrInvalidPayloadAttributes // Unexpected Beacon Root
 	}
 	if version >= clparams.DenebVersion && payloadAttributes.ParentBeaconBlockRoot == nil {
-		return nil, &engine_helpers.InvalidPayloadAttributesErr // Beacon Root missing
+		return nil, engine_errors.ErrInvalidPayloadAttributes // Beacon Root missing
 	}
 
 	timestamp := uint64(payloadAttributes.Timestamp)
 	if !s.config.IsCancun(timestamp) && version >= clparams.DenebVersion { // V3 before cancun
-		return nil, &rpc.UnsupportedForkError{Message: "Unsupported fork"}
+		return nil, engine_errors.ErrUnsupportedFork
 	}
 	if s.config.IsCancun(timestamp) && version < clparams.DenebVersion { // Not V3 after cancun
-		return nil, &rpc.UnsupportedForkError{Message: "Unsupported fork"}
+		return nil, engine_errors.ErrUnsupportedFork
 	}
 
 	if !s.proposing {
-		return nil, fmt.Errorf("execution layer not running as a proposer. enable proposer by taking out the --proposer.disable flag on startup")
+		return nil, engine_errors.ErrNotProposer
+	}
+	if s.sequencerLock != nil {
+		if err := s.sequencerLock.Check(ctx); err != nil {
+			s.logger.Warn("[ForkChoiceUpdated] refusing to build payload", "head", forkchoiceState.HeadHash, "err", err)
+			return nil, engine_errors.ErrNotSequencerLeader
+		}
 	}
 
 	headHeader := s.chainRW.GetHeaderByHash(ctx, forkchoiceState.HeadHash)
 
 	if headHeader.Time >= timestamp {
-		return nil, &engine_helpers.InvalidPayloadAttributesErr
+		return nil, engine_errors.ErrInvalidPayloadAttributes
 	}
 	txs := make([][]byte, len(payloadAttributes.Transactions))
 	for i, tx := range payloadAttributes.Transactions {
 		txs[i] = tx
 	}
 	if s.config.Optimism != nil && payloadAttributes.GasLimit == nil {
-		return nil, &engine_helpers.InvalidPayloadAttributesErr
+		return nil, engine_errors.ErrInvalidPayloadAttributes
 	}
 
 	var eip1559Params []byte
 	if s.config.Optimism != nil {
 		if payloadAttributes.GasLimit == nil {
-			return nil, &engine_helpers.InvalidPayloadAttributesGasLmitErr
+			return nil, engine_errors.ErrInvalidPayloadAttributes.With(errors.New("gas limit is required"))
 		}
 		if s.config.IsHolocene(payloadAttributes.Timestamp.Uint64()) {
 			if err := misc.ValidateHolocene1559Params(payloadAttributes.EIP1559Params); err != nil {
-				return nil, &engine_helpers.InvalidPayloadAttributesErr
+				return nil, engine_errors.ErrInvalidPayloadAttributes
 			}
 			eip1559Params = bytes.Clone(payloadAttributes.EIP1559Params)
 		} else if len(payloadAttributes.EIP1559Params) != 0 {
-			return nil, &engine_helpers.InvalidPayloadAttributesEIP1559Err
+			return nil, engine_errors.ErrInvalidPayloadAttributes.With(errors.New("eip1559Params not supported prior to Holocene upgrade"))
 		}
 	}
 
@@ -645,7 +661,7 @@ func (s *EngineServer) forkchoiceUpdated(ctx context.Context, forkchoiceState *e
 
 func (s *EngineServer) getPayloadBodiesByHash(ctx context.Context, request []libcommon.Hash) ([]*engine_types.ExecutionPayloadBody, error) {
 	if len(request) > 1024 {
-		return nil, &engine_helpers.TooLargeRequestErr
+		return nil, engine_errors.ErrTooLargeRequest
 	}
 	bodies, err := s.chainRW.GetBodiesByHashes(ctx, request)
 	if err != nil {
@@ -678,7 +694,7 @@ func (s *EngineServer) getPayloadBodiesByRange(ctx context.Context, start, count
 		return nil, &rpc.InvalidParamsError{Message: fmt.Sprintf("invalid start or count, start: %v count: %v", start, count)}
 	}
 	if count > 1024 {
-		return nil, &engine_helpers.TooLargeRequestErr
+		return nil, engine_errors.ErrTooLargeRequest
 	}
 	bodies, err := s.chainRW.GetBodiesByRange(ctx, start, count)
 	if err != nil {
@@ -992,6 +1008,8 @@ func (e *EngineServer) HandleNewPayload(
 		}
 	}
 
+	// the parent is known, the payload extends the unsafe chain: it goes ahead of backfill batches
+	ctx = engine_helpers.WithPriorityLane(ctx)
 	if err := e.chainRW.InsertBlockAndWait(ctx, block); err != nil {
 		return nil, err
 	}
@@ -1078,13 +1096,18 @@ func (e *EngineServer) HandlesForkChoice(
 		return nil, err
 	}
 	if status == execution.ExecutionStatus_InvalidForkchoice {
-		return nil, &engine_helpers.InvalidForkchoiceStateErr
+		return nil, engine_errors.ErrInvalidForkchoiceState
 	}
-	if status == execution.ExecutionStatus_Busy {
+	// there is no ACCEPTED status in forkchoiceUpdated response
+	if status == execution.ExecutionStatus_Busy || status == execution.ExecutionStatus_MissingSegment {
 		return &engine_types.PayloadStatus{Status: engine_types.SyncingStatus}, nil
 	}
 	if status == execution.ExecutionStatus_BadBlock {
-		return &engine_types.PayloadStatus{Status: engine_types.InvalidStatus, ValidationError: engine_types.NewStringifiedErrorFromString("Invalid chain after execution")}, nil
+		validationError := "Invalid chain after execution"
+		if validationErr != nil {
+			validationError = *validationErr
+		}
+		return &engine_types.PayloadStatus{Status: engine_types.InvalidStatus, ValidationError: engine_types.NewStringifiedErrorFromString(validationError)}, nil
 	}
 	payloadStatus := &engine_types.PayloadStatus{
 		Status:          convertGrpcStatusToEngineStatus(status),
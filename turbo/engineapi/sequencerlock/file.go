@@ -0,0 +1,110 @@
+package sequencerlock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gofrs/flock"
+)
+
+// lease - content of the lease file
+type lease struct {
+	Holder  string `json:"holder"`
+	Expires int64  `json:"expires"` // unix nanoseconds
+}
+
+// FileProvider keeps the lease in a file. Instances on different hosts need a shared volume with working
+// flock(2), updates of the lease are serialized by flock of a file next to it.
+type FileProvider struct {
+	path string
+	lock *flock.Flock
+}
+
+func NewFileProvider(path string) (*FileProvider, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("sequencer lock dir: %w", err)
+	}
+	return &FileProvider{path: path, lock: flock.New(path + ".flock")}, nil
+}
+
+func (p *FileProvider) Acquire(ctx context.Context, id string, ttl time.Duration) (bool, error) {
+	unlock, err := p.flock(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	current, err := p.read()
+	if err != nil {
+		return false, err
+	}
+	now := time.Now()
+	if current.Holder != "" && current.Holder != id && now.UnixNano() < current.Expires {
+		return false, nil
+	}
+	return true, p.write(lease{Holder: id, Expires: now.Add(ttl).UnixNano()})
+}
+
+func (p *FileProvider) Release(ctx context.Context, id string) error {
+	unlock, err := p.flock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	current, err := p.read()
+	if err != nil {
+		return err
+	}
+	if current.Holder != id {
+		return nil
+	}
+	if err := os.Remove(p.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (p *FileProvider) flock(ctx context.Context) (unlock func(), err error) {
+	locked, err := p.lock.TryLockContext(ctx, 10*time.Millisecond)
+	if err != nil {
+		return nil, err
+	}
+	if !locked {
+		return nil, fmt.Errorf("can't lock %s", p.lock.Path())
+	}
+	return func() { _ = p.lock.Unlock() }, nil
+}
+
+// read returns empty lease if there is no lease file
+func (p *FileProvider) read() (lease, error) {
+	var l lease
+	data, err := os.ReadFile(p.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return l, err
+	}
+	if err := json.Unmarshal(data, &l); err != nil {
+		return l, fmt.Errorf("lease file %s: %w", p.path, err)
+	}
+	return l, nil
+}
+
+func (p *FileProvider) write(l lease) error {
+	data, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+	tmp := p.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p.path)
+}
@@ -0,0 +1,151 @@
+// Package sequencerlock implements leadership of the sequencer: of several instances of the sequencer of one chain
+// only the holder of the lock builds payloads, so failover can't lead to two instances sequencing at the same time.
+// The lock is a lease which the holder renews, it's stored by Provider: file on shared volume, row in external DB,
+// session of Consul or etcd.
+package sequencerlock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon-lib/metrics"
+)
+
+var ErrNotLeader = errors.New("sequencer leadership lock is held by other instance")
+
+var leaderGauge = metrics.GetOrCreateGauge("sequencer_leader")
+
+// Provider stores the lease shared by sequencer instances of one chain
+type Provider interface {
+	// Acquire takes the lease for ttl, or extends it if it's already held by id.
+	// Returns false if the lease is held by other instance and hasn't expired yet.
+	Acquire(ctx context.Context, id string, ttl time.Duration) (bool, error)
+	// Release gives up the lease if it's held by id
+	Release(ctx context.Context, id string) error
+}
+
+// Lock - leadership of this instance. The lease is renewed every ttl/3, and is considered lost
+// ttl/4 before it expires, to leave a margin for clock drift between instances.
+type Lock struct {
+	provider Provider
+	id       string
+	ttl      time.Duration
+
+	lock      sync.Mutex   // one Acquire at a time
+	heldUntil atomic.Int64 // unix nanoseconds
+	stopped   chan struct{}
+
+	ctx    context.Context
+	logger log.Logger
+}
+
+func New(ctx context.Context, provider Provider, id string, ttl time.Duration, logger log.Logger) *Lock {
+	return &Lock{
+		provider: provider,
+		id:       id,
+		ttl:      ttl,
+		stopped:  make(chan struct{}),
+		ctx:      ctx,
+		logger:   logger,
+	}
+}
+
+// Start implements node.Lifecycle, starting up renewal of the lease.
+func (l *Lock) Start() error {
+	go l.loop()
+	l.logger.Info("[sequencer-lock] started", "id", l.id, "ttl", l.ttl)
+	return nil
+}
+
+// Stop implements node.Lifecycle, releasing the lease so other instance can take over without waiting for it to expire.
+func (l *Lock) Stop() error {
+	close(l.stopped)
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	held := l.Held()
+	l.heldUntil.Store(0)
+	leaderGauge.Set(0)
+	if held {
+		ctx, cancel := context.WithTimeout(context.Background(), l.ttl)
+		defer cancel()
+		if err := l.provider.Release(ctx, l.id); err != nil {
+			l.logger.Warn("[sequencer-lock] releasing failed", "err", err)
+		}
+	}
+	l.logger.Info("[sequencer-lock] stopped")
+	return nil
+}
+
+// Held reports whether this instance holds the lease
+func (l *Lock) Held() bool {
+	return time.Now().UnixNano() < l.heldUntil.Load()
+}
+
+// Check returns nil if this instance holds the lease, taking it if it's free. Otherwise - ErrNotLeader.
+func (l *Lock) Check(ctx context.Context) error {
+	if l.Held() {
+		return nil
+	}
+	held, err := l.acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrNotLeader, err)
+	}
+	if !held {
+		return ErrNotLeader
+	}
+	return nil
+}
+
+func (l *Lock) loop() {
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+	for {
+		if _, err := l.acquire(l.ctx); err != nil && l.ctx.Err() == nil {
+			l.logger.Warn("[sequencer-lock] renewing failed", "err", err)
+		}
+		select {
+		case <-l.ctx.Done():
+			return
+		case <-l.stopped:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (l *Lock) acquire(ctx context.Context) (bool, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	select {
+	case <-l.stopped:
+		return false, errors.New("stopped")
+	default:
+	}
+
+	wasHeld := l.Held()
+	// the lease is counted from the moment before the request: provider may take it at any moment of the request
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, l.ttl/4)
+	defer cancel()
+	held, err := l.provider.Acquire(ctx, l.id, l.ttl)
+	if err != nil || !held {
+		// can't prove that the lease is still ours, after ttl/4 timeout it's in its last quarter anyway
+		l.heldUntil.Store(0)
+		leaderGauge.Set(0)
+		if wasHeld {
+			l.logger.Warn("[sequencer-lock] lost leadership", "id", l.id, "err", err)
+		}
+		return false, err
+	}
+	l.heldUntil.Store(start.Add(l.ttl - l.ttl/4).UnixNano())
+	leaderGauge.Set(1)
+	if !wasHeld {
+		l.logger.Info("[sequencer-lock] acquired leadership", "id", l.id)
+	}
+	return true, nil
+}
@@ -0,0 +1,101 @@
+package sequencerlock
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/log/v3"
+)
+
+func TestFileProvider(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "sequencer", "lease")
+	a, err := NewFileProvider(path)
+	require.NoError(t, err)
+	b, err := NewFileProvider(path)
+	require.NoError(t, err)
+
+	held, err := a.Acquire(ctx, "a", time.Hour)
+	require.NoError(t, err)
+	require.True(t, held)
+	held, err = b.Acquire(ctx, "b", time.Hour)
+	require.NoError(t, err)
+	require.False(t, held)
+	// renewal by the holder
+	held, err = a.Acquire(ctx, "a", time.Hour)
+	require.NoError(t, err)
+	require.True(t, held)
+
+	// release by other instance is ignored
+	require.NoError(t, b.Release(ctx, "b"))
+	held, err = b.Acquire(ctx, "b", time.Hour)
+	require.NoError(t, err)
+	require.False(t, held)
+
+	require.NoError(t, a.Release(ctx, "a"))
+	held, err = b.Acquire(ctx, "b", time.Millisecond)
+	require.NoError(t, err)
+	require.True(t, held)
+
+	// expired lease is taken over
+	time.Sleep(5 * time.Millisecond)
+	held, err = a.Acquire(ctx, "a", time.Hour)
+	require.NoError(t, err)
+	require.True(t, held)
+}
+
+type fakeProvider struct {
+	holder string
+	err    error
+}
+
+func (p *fakeProvider) Acquire(_ context.Context, id string, _ time.Duration) (bool, error) {
+	if p.err != nil {
+		return false, p.err
+	}
+	if p.holder != "" && p.holder != id {
+		return false, nil
+	}
+	p.holder = id
+	return true, nil
+}
+
+func (p *fakeProvider) Release(_ context.Context, id string) error {
+	if p.holder == id {
+		p.holder = ""
+	}
+	return nil
+}
+
+func TestLock(t *testing.T) {
+	ctx := context.Background()
+	provider := &fakeProvider{holder: "other"}
+	l := New(ctx, provider, "this", time.Hour, log.New())
+
+	require.ErrorIs(t, l.Check(ctx), ErrNotLeader)
+	require.False(t, l.Held())
+
+	// failover: the lease is free now, it's taken on demand
+	provider.holder = ""
+	require.NoError(t, l.Check(ctx))
+	require.True(t, l.Held())
+	require.Equal(t, "this", provider.holder)
+
+	// failed renewal loses leadership
+	provider.err = errors.New("unavailable")
+	_, err := l.acquire(ctx)
+	require.Error(t, err)
+	require.False(t, l.Held())
+	require.ErrorIs(t, l.Check(ctx), ErrNotLeader)
+
+	provider.err = nil
+	require.NoError(t, l.Check(ctx))
+	require.NoError(t, l.Stop())
+	require.False(t, l.Held())
+	require.Empty(t, provider.holder)
+}
@@ -0,0 +1,36 @@
+package eth1
+
+import (
+	"context"
+
+	"github.com/erigontech/erigon-lib/chain"
+	"github.com/erigontech/erigon-lib/gointerfaces/execution"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/erigontech/erigon/turbo/builder"
+)
+
+// BlockBuilderServer - execution service of a builder process (cmd/builder): serves only block building, for nodes
+// started with --builder.remote. The rest of the service is unimplemented.
+type BlockBuilderServer struct {
+	assembler blockAssembler
+
+	execution.UnimplementedExecutionServer
+}
+
+func NewBlockBuilderServer(config *chain.Config, build builder.BlockBuilderFunc, logger log.Logger) *BlockBuilderServer {
+	return &BlockBuilderServer{assembler: newBlockAssembler(config, build, logger)}
+}
+
+func (s *BlockBuilderServer) AssembleBlock(ctx context.Context, req *execution.AssembleBlockRequest) (*execution.AssembleBlockResponse, error) {
+	return s.assembler.assembleBlock(req)
+}
+
+func (s *BlockBuilderServer) GetAssembledBlock(ctx context.Context, req *execution.GetAssembledBlockRequest) (*execution.GetAssembledBlockResponse, error) {
+	return s.assembler.getAssembledBlock(req)
+}
+
+func (s *BlockBuilderServer) Ready(ctx context.Context, _ *emptypb.Empty) (*execution.ReadyResponse, error) {
+	return &execution.ReadyResponse{Ready: true}, nil
+}
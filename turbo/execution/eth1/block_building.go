@@ -2,15 +2,17 @@ package eth1
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"reflect"
 
 	"github.com/holiman/uint256"
 
+	"github.com/erigontech/erigon-lib/chain"
 	libcommon "github.com/erigontech/erigon-lib/common"
 	"github.com/erigontech/erigon-lib/gointerfaces"
 	"github.com/erigontech/erigon-lib/gointerfaces/execution"
 	types2 "github.com/erigontech/erigon-lib/gointerfaces/types"
+	"github.com/erigontech/erigon-lib/log/v3"
 
 	"github.com/erigontech/erigon/core"
 	"github.com/erigontech/erigon/core/types"
@@ -20,27 +22,49 @@ import (
 	"github.com/erigontech/erigon/turbo/execution/eth1/eth1_utils"
 )
 
-func (e *EthereumExecutionModule) checkWithdrawalsPresence(time uint64, withdrawals []*types.Withdrawal) error {
-	if !e.config.IsShanghai(time) && withdrawals != nil {
+// blockAssembler - block building of the execution module, also served by builder processes (see BlockBuilderServer)
+type blockAssembler struct {
+	config   *chain.Config
+	payloads *builder.PayloadStore
+	logger   log.Logger
+}
+
+func newBlockAssembler(config *chain.Config, build builder.BlockBuilderFunc, logger log.Logger) blockAssembler {
+	return blockAssembler{
+		config:   config,
+		payloads: builder.NewPayloadStore(build, engine_helpers.MaxBuilders, engine_helpers.PayloadExpiry),
+		logger:   logger,
+	}
+}
+
+func (a *blockAssembler) checkWithdrawalsPresence(time uint64, withdrawals []*types.Withdrawal) error {
+	if !a.config.IsShanghai(time) && withdrawals != nil {
 		return &rpc.InvalidParamsError{Message: "withdrawals before shanghai"}
 	}
-	if e.config.IsShanghai(time) && withdrawals == nil {
+	if a.config.IsShanghai(time) && withdrawals == nil {
 		return &rpc.InvalidParamsError{Message: "missing withdrawals list"}
 	}
 	return nil
 }
 
-func (e *EthereumExecutionModule) evictOldBuilders() {
-	ids := libcommon.SortedKeys(e.builders)
-
-	// remove old builders so that at most MaxBuilders - 1 remain
-	for i := 0; i <= len(e.builders)-engine_helpers.MaxBuilders; i++ {
-		delete(e.builders, ids[i])
-	}
-}
+// localPayloadId - marks ids of payloads the node built itself while its builder process was down, the builder
+// never gives out such ids (see builder.PayloadStore)
+const localPayloadId = 1 << 63
 
 // Missing: NewPayload, AssembleBlock
 func (e *EthereumExecutionModule) AssembleBlock(ctx context.Context, req *execution.AssembleBlockRequest) (*execution.AssembleBlockResponse, error) {
+	if e.remoteBuilder != nil {
+		resp, err := e.remoteBuilder.AssembleBlock(ctx, req)
+		if err == nil {
+			if !resp.Busy {
+				param := blockBuilderParameters(req)
+				param.PayloadId = resp.Id
+				e.payloadHistory.Track(&param)
+			}
+			return resp, nil
+		}
+		e.logger.Warn("[ForkChoiceUpdated] remote builder failed, building the payload locally", "err", err)
+	}
 	if !e.semaphore.TryAcquire(1) {
 		return &execution.AssembleBlockResponse{
 			Id:   0,
@@ -48,6 +72,17 @@ func (e *EthereumExecutionModule) AssembleBlock(ctx context.Context, req *execut
 		}, nil
 	}
 	defer e.semaphore.Release(1)
+	resp, err := e.assembler.assembleBlock(req)
+	if err != nil {
+		return nil, err
+	}
+	if e.remoteBuilder != nil {
+		resp.Id |= localPayloadId
+	}
+	return resp, nil
+}
+
+func blockBuilderParameters(req *execution.AssembleBlockRequest) core.BlockBuilderParameters {
 	param := core.BlockBuilderParameters{
 		ParentHash:            gointerfaces.ConvertH256ToHash(req.ParentHash),
 		Timestamp:             req.Timestamp,
@@ -59,40 +94,32 @@ func (e *EthereumExecutionModule) AssembleBlock(ctx context.Context, req *execut
 		GasLimit:              req.GasLimit,
 		EIP1559Params:         req.Eip_1559Params,
 	}
-
-	if err := e.checkWithdrawalsPresence(param.Timestamp, param.Withdrawals); err != nil {
-		return nil, err
-	}
-
 	if req.ParentBeaconBlockRoot != nil {
 		pbbr := libcommon.Hash(gointerfaces.ConvertH256ToHash(req.ParentBeaconBlockRoot))
 		param.ParentBeaconBlockRoot = &pbbr
 	}
+	return param
+}
 
-	// First check if we're already building a block with the requested parameters
-	if e.lastParameters != nil {
-		param.PayloadId = e.lastParameters.PayloadId
-		if reflect.DeepEqual(e.lastParameters, &param) {
-			e.logger.Info("[ForkChoiceUpdated] duplicate build request")
-			return &execution.AssembleBlockResponse{
-				Id:   e.lastParameters.PayloadId,
-				Busy: false,
-			}, nil
-		}
+func (a *blockAssembler) assembleBlock(req *execution.AssembleBlockRequest) (*execution.AssembleBlockResponse, error) {
+	param := blockBuilderParameters(req)
+	if err := a.checkWithdrawalsPresence(param.Timestamp, param.Withdrawals); err != nil {
+		return nil, err
 	}
 
-	// Initiate payload building
-	e.evictOldBuilders()
-
-	e.nextPayloadId++
-	param.PayloadId = e.nextPayloadId
-	e.lastParameters = &param
-
-	e.builders[e.nextPayloadId] = builder.NewBlockBuilder(e.builderFunc, &param)
-	e.logger.Info("[ForkChoiceUpdated] BlockBuilder added", "payload", e.nextPayloadId)
+	// a block with the requested parameters may be being built already
+	payloadId, duplicate, err := a.payloads.Add(&param)
+	if err != nil {
+		return nil, err
+	}
+	if duplicate {
+		a.logger.Info("[ForkChoiceUpdated] duplicate build request", "payload", payloadId)
+	} else {
+		a.logger.Info("[ForkChoiceUpdated] BlockBuilder added", "payload", payloadId)
+	}
 
 	return &execution.AssembleBlockResponse{
-		Id:   e.nextPayloadId,
+		Id:   payloadId,
 		Busy: false,
 	}, nil
 }
@@ -111,25 +138,48 @@ func blockValue(br *types.BlockWithReceipts, baseFee *uint256.Int) *uint256.Int
 }
 
 func (e *EthereumExecutionModule) GetAssembledBlock(ctx context.Context, req *execution.GetAssembledBlockRequest) (*execution.GetAssembledBlockResponse, error) {
+	if e.remoteBuilder != nil {
+		if req.Id&localPayloadId == 0 {
+			return e.getRemoteAssembledBlock(ctx, req)
+		}
+		req = &execution.GetAssembledBlockRequest{Id: req.Id &^ localPayloadId}
+	}
 	if !e.semaphore.TryAcquire(1) {
 		return &execution.GetAssembledBlockResponse{
 			Busy: true,
 		}, nil
 	}
 	defer e.semaphore.Release(1)
-	payloadId := req.Id
-	builder, ok := e.builders[payloadId]
-	if !ok {
-		return &execution.GetAssembledBlockResponse{
-			Busy: false,
-		}, nil
+	return e.assembler.getAssembledBlock(req)
+}
+
+func (e *EthereumExecutionModule) getRemoteAssembledBlock(ctx context.Context, req *execution.GetAssembledBlockRequest) (*execution.GetAssembledBlockResponse, error) {
+	resp, err := e.remoteBuilder.GetAssembledBlock(ctx, req)
+	switch {
+	case err != nil:
+		e.payloadHistory.Done(req.Id, libcommon.Hash{}, 0, err)
+	case resp.Busy:
+	case resp.Data == nil:
+		e.payloadHistory.Done(req.Id, libcommon.Hash{}, 0, errors.New("unknown or expired payload"))
+	default:
+		payload := resp.Data.ExecutionPayload
+		e.payloadHistory.Done(req.Id, gointerfaces.ConvertH256ToHash(payload.BlockHash), payload.BlockNumber, nil)
 	}
+	return resp, err
+}
 
-	blockWithReceipts, err := builder.Stop()
+func (a *blockAssembler) getAssembledBlock(req *execution.GetAssembledBlockRequest) (*execution.GetAssembledBlockResponse, error) {
+	blockWithReceipts, ok, err := a.payloads.Get(req.Id)
 	if err != nil {
-		e.logger.Error("Failed to build PoS block", "err", err)
+		a.logger.Error("Failed to build PoS block", "payload", req.Id, "err", err)
 		return nil, err
 	}
+	if !ok {
+		// unknown or expired
+		return &execution.GetAssembledBlockResponse{
+			Busy: false,
+		}, nil
+	}
 	block := blockWithReceipts.Block
 	header := block.Header()
 
@@ -212,7 +262,7 @@ func (e *EthereumExecutionModule) GetAssembledBlock(ctx context.Context, req *ex
 				requests[i] = append(requests[i], r.RequestData...)
 			}
 		} else {
-			e.logger.Error("Requests len SHOULD BE", "equal to", len(types.KnownRequestTypes), "got", len(blockWithReceipts.Requests))
+			a.logger.Error("Requests len SHOULD BE", "equal to", len(types.KnownRequestTypes), "got", len(blockWithReceipts.Requests))
 			for i := 0; i < len(types.KnownRequestTypes); i++ {
 				requests[i] = make([]byte, 0)
 			}
@@ -0,0 +1,106 @@
+package eth1
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/semaphore"
+	"google.golang.org/grpc"
+
+	"github.com/erigontech/erigon-lib/chain"
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/gointerfaces"
+	"github.com/erigontech/erigon-lib/gointerfaces/execution"
+	types2 "github.com/erigontech/erigon-lib/gointerfaces/types"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/memdb"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/core/rawdb"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/turbo/builder"
+)
+
+// remoteBuilder - a builder process, down when err is set
+type remoteBuilder struct {
+	execution.ExecutionClient
+	err   error
+	block libcommon.Hash
+}
+
+func (b *remoteBuilder) AssembleBlock(context.Context, *execution.AssembleBlockRequest, ...grpc.CallOption) (*execution.AssembleBlockResponse, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	return &execution.AssembleBlockResponse{Id: 7}, nil
+}
+
+func (b *remoteBuilder) GetAssembledBlock(_ context.Context, req *execution.GetAssembledBlockRequest, _ ...grpc.CallOption) (*execution.GetAssembledBlockResponse, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if req.Id != 7 {
+		return &execution.GetAssembledBlockResponse{}, nil
+	}
+	return &execution.GetAssembledBlockResponse{Data: &execution.AssembledBlockData{
+		ExecutionPayload: &types2.ExecutionPayload{BlockNumber: 1, BlockHash: gointerfaces.ConvertHashToH256(b.block)},
+	}}, nil
+}
+
+func TestRemoteBuilder(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	logger := log.New()
+	db := memdb.NewTestDB(t)
+	build := func(param *core.BlockBuilderParameters, interrupt *int32) (*types.BlockWithReceipts, error) {
+		header := &types.Header{Number: big.NewInt(1), Time: param.Timestamp, BaseFee: big.NewInt(1)}
+		return &types.BlockWithReceipts{Block: types.NewBlockWithHeader(header)}, nil
+	}
+	history := builder.NewPayloadHistory(ctx, db, time.Hour, logger)
+	e := &EthereumExecutionModule{
+		logger:         logger,
+		semaphore:      semaphore.NewWeighted(1),
+		assembler:      newBlockAssembler(&chain.Config{ChainID: big.NewInt(1)}, history.Wrap(build), logger),
+		payloadHistory: history,
+	}
+	remote := &remoteBuilder{block: libcommon.Hash{7}}
+	e.SetRemoteBuilder(remote)
+	req := &execution.AssembleBlockRequest{
+		ParentHash:            gointerfaces.ConvertHashToH256(libcommon.Hash{1}),
+		Timestamp:             1,
+		PrevRandao:            gointerfaces.ConvertHashToH256(libcommon.Hash{}),
+		SuggestedFeeRecipient: gointerfaces.ConvertAddressToH160(libcommon.Address{}),
+	}
+
+	// built by the builder, recorded by the node
+	resp, err := e.AssembleBlock(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), resp.Id)
+	assembled, err := e.GetAssembledBlock(ctx, &execution.GetAssembledBlockRequest{Id: resp.Id})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), assembled.Data.ExecutionPayload.BlockNumber)
+	require.Eventually(t, func() bool {
+		var recs []*rawdb.PayloadAttributesRecord
+		require.NoError(t, db.View(ctx, func(tx kv.Tx) (err error) {
+			recs, err = rawdb.ReadPayloadAttributes(tx, 7)
+			return err
+		}))
+		return len(recs) == 1 && recs[0].BlockHash != nil && *recs[0].BlockHash == remote.block
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// the builder is down: the node builds the payload, under an id the builder doesn't give out
+	remote.err = errors.New("connection refused")
+	resp, err = e.AssembleBlock(ctx, req)
+	require.NoError(t, err)
+	require.NotZero(t, resp.Id&localPayloadId)
+	assembled, err = e.GetAssembledBlock(ctx, &execution.GetAssembledBlockRequest{Id: resp.Id})
+	require.NoError(t, err)
+	require.NotNil(t, assembled.Data)
+	require.Equal(t, uint64(1), assembled.Data.ExecutionPayload.Timestamp)
+}
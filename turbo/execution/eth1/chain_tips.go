@@ -0,0 +1,85 @@
+package eth1
+
+import (
+	"context"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/kv"
+
+	"github.com/erigontech/erigon/core/rawdb"
+	"github.com/erigontech/erigon/eth/stagedsync/stages"
+)
+
+// ChainTip - a block known to the node as a tip of the chain
+type ChainTip struct {
+	Number    hexutil.Uint64 `json:"number"`
+	Hash      libcommon.Hash `json:"hash"`
+	Timestamp hexutil.Uint64 `json:"timestamp"`
+}
+
+// ChainTips - outcome of erigon_chainTips, a tip unknown to the node is null
+type ChainTips struct {
+	Head       *ChainTip `json:"head"`       // head of the canonical chain
+	Headers    *ChainTip `json:"headers"`    // last header, ahead of head while syncing
+	Executed   *ChainTip `json:"executed"`   // last block executed by staged sync
+	Forkchoice *ChainTip `json:"forkchoice"` // head of the last forkchoice update
+	Extending  *ChainTip `json:"extending"`  // validated on top of head by newPayload, not made canonical yet
+	Safe       *ChainTip `json:"safe"`
+	Finalized  *ChainTip `json:"finalized"`
+}
+
+// ErigonAPI - erigon_ namespace of the execution module
+type ErigonAPI struct {
+	e *EthereumExecutionModule
+}
+
+// ChainTips returns all the chain tips known to the node, to assess its state at a glance, e.g. during sequencer incidents.
+// Served by the embedded rpcdaemon only: the extending fork is kept in memory of the execution module.
+func (api *ErigonAPI) ChainTips(ctx context.Context) (*ChainTips, error) {
+	tx, err := api.e.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	executed, err := stages.GetStageProgress(tx, stages.Execution)
+	if err != nil {
+		return nil, err
+	}
+	executedHash, err := api.e.blockReader.CanonicalHash(ctx, tx, executed)
+	if err != nil {
+		return nil, err
+	}
+
+	tips := &ChainTips{}
+	for _, t := range []struct {
+		tip  **ChainTip
+		hash libcommon.Hash
+	}{
+		{&tips.Head, rawdb.ReadHeadBlockHash(tx)},
+		{&tips.Headers, rawdb.ReadHeadHeaderHash(tx)},
+		{&tips.Executed, executedHash},
+		{&tips.Forkchoice, rawdb.ReadForkchoiceHead(tx)},
+		{&tips.Extending, api.e.forkValidator.ExtendingForkHeadHash()},
+		{&tips.Safe, rawdb.ReadForkchoiceSafe(tx)},
+		{&tips.Finalized, rawdb.ReadForkchoiceFinalized(tx)},
+	} {
+		if *t.tip, err = api.chainTip(ctx, tx, t.hash); err != nil {
+			return nil, err
+		}
+	}
+	return tips, nil
+}
+
+// chainTip - nil if the hash is empty or its header is unknown
+func (api *ErigonAPI) chainTip(ctx context.Context, tx kv.Tx, hash libcommon.Hash) (*ChainTip, error) {
+	if hash == (libcommon.Hash{}) {
+		return nil, nil
+	}
+	header, err := api.e.blockReader.HeaderByHash(ctx, tx, hash)
+	if err != nil || header == nil {
+		return nil, err
+	}
+	return &ChainTip{Number: hexutil.Uint64(header.Number.Uint64()), Hash: hash, Timestamp: hexutil.Uint64(header.Time)}, nil
+}
@@ -0,0 +1,50 @@
+package eth1_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/kv"
+
+	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/core/rawdb"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/turbo/execution/eth1"
+	"github.com/erigontech/erigon/turbo/stages/mock"
+)
+
+func TestChainTips(t *testing.T) {
+	m := mock.Mock(t)
+	chain, err := core.GenerateChain(m.ChainConfig, m.Genesis, m.Engine, m.DB, 3, func(int, *core.BlockGen) {})
+	require.NoError(t, err)
+	require.NoError(t, m.InsertChain(chain))
+	// as the last forkchoice update would
+	require.NoError(t, m.DB.Update(m.Ctx, func(tx kv.RwTx) error {
+		rawdb.WriteForkchoiceHead(tx, chain.Blocks[2].Hash())
+		rawdb.WriteForkchoiceSafe(tx, chain.Blocks[1].Hash())
+		rawdb.WriteForkchoiceFinalized(tx, chain.Blocks[0].Hash())
+		return nil
+	}))
+
+	var api *eth1.ErigonAPI
+	for _, a := range m.Eth1ExecutionService.APIs() {
+		if erigon, ok := a.Service.(*eth1.ErigonAPI); ok {
+			api = erigon
+		}
+	}
+	require.NotNil(t, api)
+	tips, err := api.ChainTips(m.Ctx)
+	require.NoError(t, err)
+	tip := func(b *types.Block) *eth1.ChainTip {
+		return &eth1.ChainTip{Number: hexutil.Uint64(b.NumberU64()), Hash: b.Hash(), Timestamp: hexutil.Uint64(b.Time())}
+	}
+	require.Equal(t, tip(chain.TopBlock), tips.Head)
+	require.Equal(t, tips.Head, tips.Executed)
+	require.Equal(t, tips.Head, tips.Headers)
+	require.Equal(t, tips.Head, tips.Forkchoice)
+	require.Equal(t, tip(chain.Blocks[1]), tips.Safe)
+	require.Equal(t, tip(chain.Blocks[0]), tips.Finalized)
+	require.Nil(t, tips.Extending)
+}
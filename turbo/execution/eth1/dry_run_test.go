@@ -0,0 +1,34 @@
+package eth1_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/gointerfaces/execution"
+	"github.com/erigontech/erigon-lib/kv"
+
+	"github.com/erigontech/erigon/core/rawdb"
+)
+
+func TestDryRunForkchoice(t *testing.T) {
+	e := newEngineBench(t, engineWorkloads[0], 2)
+	require.NoError(t, e.newPayload(e.blocks[0]))
+	require.NoError(t, e.forkchoiceUpdated(e.blocks[0]))
+
+	e.m.SetDryRun()
+	block := e.blocks[1]
+	require.NoError(t, e.newPayload(block))
+	hash := block.Hash()
+	status, _, _, err := e.wr.UpdateForkChoice(e.m.Ctx, hash, hash, hash)
+	require.NoError(t, err)
+	// the head is executed on the overlay only, it must not be reported VALID
+	require.Equal(t, execution.ExecutionStatus_Busy, status)
+
+	err = e.m.DB.View(e.m.Ctx, func(tx kv.Tx) error {
+		require.Equal(t, e.blocks[0].Hash(), rawdb.ReadHeadBlockHash(tx))
+		require.Equal(t, e.blocks[0].Hash(), rawdb.ReadForkchoiceFinalized(tx))
+		return nil
+	})
+	require.NoError(t, err)
+}
@@ -0,0 +1,225 @@
+package eth1_test
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/erigontech/erigon-lib/chain"
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/direct"
+	"github.com/erigontech/erigon-lib/gointerfaces/execution"
+
+	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/crypto"
+	"github.com/erigontech/erigon/params"
+	"github.com/erigontech/erigon/turbo/execution/eth1/eth1_chain_reader.go"
+	"github.com/erigontech/erigon/turbo/stages/mock"
+)
+
+// engineWorkload - shape of blocks passed to Engine API. Boba produces a block every 2 seconds: newPayload and
+// forkchoiceUpdated of the block must leave most of this time to block building and to the consensus layer.
+type engineWorkload struct {
+	name     string
+	deposits int    // deposit transactions per block
+	gas      uint64 // total gas of the block, filled by value transfers after deposits
+}
+
+var engineWorkloads = []engineWorkload{
+	{name: "deposits-only", deposits: 4, gas: 4 * params.TxGas},
+	{name: "10M-gas", deposits: 1, gas: 10_000_000},
+	{name: "30M-gas", deposits: 1, gas: 30_000_000},
+}
+
+// engineCheckBlocks - blocks passed by TestEngineCallsPerBlock
+const engineCheckBlocks = 5
+
+type engineBench struct {
+	m      *mock.MockSentry
+	calls  *countingExecutionClient
+	wr     eth1_chain_reader.ChainReaderWriterEth1
+	blocks []*types.Block
+}
+
+// countingExecutionClient - counts the calls of the execution module, a call answered busy is retried and counted
+// again
+type countingExecutionClient struct {
+	execution.ExecutionClient
+	insertBlocks, validateChain, updateForkChoice int
+}
+
+func (c *countingExecutionClient) InsertBlocks(ctx context.Context, in *execution.InsertBlocksRequest, opts ...grpc.CallOption) (*execution.InsertionResult, error) {
+	c.insertBlocks++
+	return c.ExecutionClient.InsertBlocks(ctx, in, opts...)
+}
+
+func (c *countingExecutionClient) ValidateChain(ctx context.Context, in *execution.ValidationRequest, opts ...grpc.CallOption) (*execution.ValidationReceipt, error) {
+	c.validateChain++
+	return c.ExecutionClient.ValidateChain(ctx, in, opts...)
+}
+
+func (c *countingExecutionClient) UpdateForkChoice(ctx context.Context, in *execution.ForkChoice, opts ...grpc.CallOption) (*execution.ForkChoiceReceipt, error) {
+	c.updateForkChoice++
+	return c.ExecutionClient.UpdateForkChoice(ctx, in, opts...)
+}
+
+// newEngineBench - in-process execution module on top of Optimism chain and n blocks of given workload
+// not inserted yet
+func newEngineBench(tb testing.TB, w engineWorkload, n int) *engineBench {
+	key, _ := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	config := *params.AllProtocolChanges
+	config.BedrockBlock = libcommon.Big0
+	config.RegolithTime = libcommon.Big0
+	config.Optimism = &chain.OptimismConfig{EIP1559Elasticity: 2, EIP1559Denominator: 8}
+	gspec := &types.Genesis{
+		Config:   &config,
+		GasLimit: 30_000_000,
+		Alloc: types.GenesisAlloc{
+			address: {Balance: new(big.Int).Exp(big.NewInt(10), big.NewInt(30), nil)},
+		},
+	}
+	m := mock.MockWithGenesis(tb, gspec, key, false)
+
+	signer := types.LatestSignerForChainID(config.ChainID)
+	transfers := max(0, int(w.gas/params.TxGas)-w.deposits)
+	var nonce uint64
+	chainPack, err := core.GenerateChain(m.ChainConfig, m.Genesis, m.Engine, m.DB, n, func(i int, b *core.BlockGen) {
+		for j := 0; j < w.deposits; j++ {
+			to := libcommon.Address{2}
+			b.AddTx(&types.DepositTx{
+				SourceHash: libcommon.BigToHash(big.NewInt(int64(i*w.deposits + j + 1))),
+				From:       libcommon.Address{1},
+				To:         &to,
+				Mint:       uint256.NewInt(params.Ether),
+				Value:      uint256.NewInt(params.Ether),
+				Gas:        params.TxGas,
+			})
+		}
+		gasPrice := uint256.MustFromBig(b.GetHeader().BaseFee)
+		for j := 0; j < transfers; j++ {
+			// every transfer touches new account - the worst case for state writes
+			to := libcommon.BigToAddress(new(big.Int).SetUint64(nonce + 0x10000))
+			txn, err := types.SignTx(types.NewTransaction(nonce, to, uint256.NewInt(1), params.TxGas, gasPrice, nil), *signer, key)
+			if err != nil {
+				tb.Fatal(err)
+			}
+			b.AddTx(txn)
+			nonce++
+		}
+	})
+	require.NoError(tb, err)
+
+	calls := &countingExecutionClient{ExecutionClient: direct.NewExecutionClientDirect(m.Eth1ExecutionService)}
+	return &engineBench{
+		m:      m,
+		calls:  calls,
+		wr:     eth1_chain_reader.NewChainReaderEth1(m.ChainConfig, calls, uint64(time.Hour.Milliseconds())),
+		blocks: chainPack.Blocks,
+	}
+}
+
+// newPayload - the same calls as engine_newPayload does
+func (e *engineBench) newPayload(block *types.Block) error {
+	if err := e.wr.InsertBlockAndWait(e.m.Ctx, block); err != nil {
+		return err
+	}
+	status, validationErr, _, err := e.wr.ValidateChain(e.m.Ctx, block.Hash(), block.NumberU64())
+	if err != nil {
+		return err
+	}
+	if status != execution.ExecutionStatus_Success {
+		return fmt.Errorf("newPayload of block %d: %s %v", block.NumberU64(), status, validationErr)
+	}
+	return nil
+}
+
+// forkchoiceUpdated - makes the block head, safe and finalized
+func (e *engineBench) forkchoiceUpdated(block *types.Block) error {
+	hash := block.Hash()
+	status, validationErr, _, err := e.wr.UpdateForkChoice(e.m.Ctx, hash, hash, hash)
+	if err != nil {
+		return err
+	}
+	if status != execution.ExecutionStatus_Success {
+		return fmt.Errorf("forkchoiceUpdated to block %d: %s %v", block.NumberU64(), status, validationErr)
+	}
+	return nil
+}
+
+func (e *engineBench) reportGas(b *testing.B) {
+	var gas uint64
+	for _, block := range e.blocks {
+		gas += block.GasUsed()
+	}
+	b.ReportMetric(float64(gas)/1e6/b.Elapsed().Seconds(), "Mgas/s")
+}
+
+func BenchmarkEngineNewPayload(b *testing.B) {
+	for _, w := range engineWorkloads {
+		b.Run(w.name, func(b *testing.B) {
+			e := newEngineBench(b, w, b.N)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for _, block := range e.blocks {
+				require.NoError(b, e.newPayload(block))
+				// head must follow the chain, otherwise every next block is validated as a longer side fork
+				b.StopTimer()
+				require.NoError(b, e.forkchoiceUpdated(block))
+				b.StartTimer()
+			}
+			b.StopTimer()
+			e.reportGas(b)
+		})
+	}
+}
+
+func BenchmarkEngineForkchoiceUpdated(b *testing.B) {
+	for _, w := range engineWorkloads {
+		b.Run(w.name, func(b *testing.B) {
+			e := newEngineBench(b, w, b.N)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for _, block := range e.blocks {
+				b.StopTimer()
+				require.NoError(b, e.newPayload(block))
+				b.StartTimer()
+				require.NoError(b, e.forkchoiceUpdated(block))
+			}
+			b.StopTimer()
+			e.reportGas(b)
+		})
+	}
+}
+
+// TestEngineCallsPerBlock - every block of the workload takes exactly one call of each kind: the busy answers which
+// make the consensus layer wait and retry, and so would make the node miss the 2s block time, fail the test. The
+// latency itself is measured by the benchmarks, it depends on the machine too much to be asserted.
+func TestEngineCallsPerBlock(t *testing.T) {
+	if testing.Short() {
+		t.Skip("slow test")
+	}
+	for _, w := range engineWorkloads {
+		t.Run(w.name, func(t *testing.T) {
+			e := newEngineBench(t, w, engineCheckBlocks)
+			for i, block := range e.blocks {
+				require.NoError(t, e.newPayload(block))
+				require.NoError(t, e.forkchoiceUpdated(block))
+				require.Equal(t, i+1, e.calls.insertBlocks, "InsertBlocks calls")
+				require.Equal(t, i+1, e.calls.validateChain, "ValidateChain calls")
+				require.Equal(t, i+1, e.calls.updateForkChoice, "UpdateForkChoice calls")
+
+				head := e.wr.CurrentHeader(e.m.Ctx)
+				require.NotNil(t, head)
+				require.Equal(t, block.Hash(), head.Hash())
+			}
+		})
+	}
+}
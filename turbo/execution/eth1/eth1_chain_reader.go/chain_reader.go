@@ -19,6 +19,7 @@ import (
 	"github.com/erigontech/erigon/cl/cltypes/solid"
 	"github.com/erigontech/erigon/cl/utils"
 	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/turbo/engineapi/engine_errors"
 	"github.com/erigontech/erigon/turbo/engineapi/engine_types"
 	"github.com/erigontech/erigon/turbo/execution/eth1/eth1_utils"
 )
@@ -281,18 +282,21 @@ func (c ChainReaderWriterEth1) InsertBlocksAndWait(ctx context.Context, blocks [
 	}
 
 	// limit the number of retries
-	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	retryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	for response.Result == execution.ExecutionStatus_Busy {
 		const retryDelay = 100 * time.Millisecond
 		select {
 		case <-time.After(retryDelay):
-		case <-ctx.Done():
-			return ctx.Err()
+		case <-retryCtx.Done():
+			if ctx.Err() != nil { // the caller is gone, not busy
+				return ctx.Err()
+			}
+			return engine_errors.ErrBusy.With(retryCtx.Err())
 		}
 
-		response, err = c.executionModule.InsertBlocks(ctx, request)
+		response, err = c.executionModule.InsertBlocks(retryCtx, request)
 		if err != nil {
 			return err
 		}
@@ -395,7 +399,7 @@ func (c ChainReaderWriterEth1) AssembleBlock(baseHash libcommon.Hash, attributes
 		return 0, err
 	}
 	if resp.Busy {
-		return 0, fmt.Errorf("execution data is still syncing")
+		return 0, engine_errors.ErrBusy
 	}
 	return resp.Id, nil
 }
@@ -408,7 +412,7 @@ func (c ChainReaderWriterEth1) GetAssembledBlock(id uint64) (*cltypes.Eth1Block,
 		return nil, nil, nil, err
 	}
 	if resp.Busy {
-		return nil, nil, nil, fmt.Errorf("execution data is still syncing")
+		return nil, nil, nil, engine_errors.ErrBusy
 	}
 	if resp.Data == nil {
 		return nil, nil, nil, nil
@@ -22,6 +22,7 @@ import (
 	"github.com/erigontech/erigon/core"
 	"github.com/erigontech/erigon/core/rawdb"
 	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/eth/ethconfig"
 	"github.com/erigontech/erigon/eth/stagedsync"
 	"github.com/erigontech/erigon/turbo/builder"
 	"github.com/erigontech/erigon/turbo/engineapi/engine_helpers"
@@ -42,15 +43,18 @@ type EthereumExecutionModule struct {
 	// MDBX database
 	db                kv.RwDB // main database
 	semaphore         *semaphore.Weighted
+	syncPause         *stagedsync.SyncPause // over the semaphore
+	forkchoiceQueue   forkchoiceQueue
 	executionPipeline *stagedsync.Sync
 	forkValidator     *engine_helpers.ForkValidator
+	chainReader       *stagedsync.SharedChainReader // of engine verification of the new canonical headers
 
 	logger log.Logger
 	// Block building
-	nextPayloadId  uint64
-	lastParameters *core.BlockBuilderParameters
-	builderFunc    builder.BlockBuilderFunc
-	builders       map[uint64]*builder.BlockBuilder
+	assembler     blockAssembler
+	remoteBuilder execution.ExecutionClient // builder process, see SetRemoteBuilder
+	// payloadHistory - records builds of remoteBuilder, local builds are recorded by the wrapped builder function
+	payloadHistory *builder.PayloadHistory
 
 	// Changes accumulator
 	hook                *stages.Hook
@@ -58,8 +62,10 @@ type EthereumExecutionModule struct {
 	stateChangeConsumer shards.StateChangeConsumer
 
 	// configuration
-	config    *chain.Config
-	historyV3 bool
+	config           *chain.Config
+	historyV3        bool
+	forkchoiceConfig ethconfig.Forkchoice
+	reorgGuard       reorgGuard
 	// consensus
 	engine consensus.Engine
 
@@ -68,22 +74,28 @@ type EthereumExecutionModule struct {
 
 func NewEthereumExecutionModule(blockReader services.FullBlockReader, db kv.RwDB,
 	executionPipeline *stagedsync.Sync, forkValidator *engine_helpers.ForkValidator,
-	config *chain.Config, builderFunc builder.BlockBuilderFunc,
+	chainReader *stagedsync.SharedChainReader, config *chain.Config, builderFunc builder.BlockBuilderFunc, payloadHistory *builder.PayloadHistory,
 	hook *stages.Hook, accumulator *shards.Accumulator,
 	stateChangeConsumer shards.StateChangeConsumer,
 	logger log.Logger, engine consensus.Engine,
-	historyV3 bool, ctx context.Context,
+	historyV3 bool, forkchoiceConfig ethconfig.Forkchoice, ctx context.Context,
 ) *EthereumExecutionModule {
+	sem := semaphore.NewWeighted(1)
+	syncPause := stagedsync.NewSyncPause(sem)
+	executionPipeline.SetSyncPause(syncPause)
 	return &EthereumExecutionModule{
 		blockReader:         blockReader,
 		db:                  db,
 		executionPipeline:   executionPipeline,
 		logger:              logger,
 		forkValidator:       forkValidator,
-		builders:            make(map[uint64]*builder.BlockBuilder),
-		builderFunc:         builderFunc,
+		chainReader:         chainReader,
+		assembler:           newBlockAssembler(config, payloadHistory.Wrap(builderFunc), logger),
+		payloadHistory:      payloadHistory,
 		config:              config,
-		semaphore:           semaphore.NewWeighted(1),
+		forkchoiceConfig:    forkchoiceConfig,
+		semaphore:           sem,
+		syncPause:           syncPause,
 		hook:                hook,
 		accumulator:         accumulator,
 		stateChangeConsumer: stateChangeConsumer,
@@ -92,6 +104,17 @@ func NewEthereumExecutionModule(blockReader services.FullBlockReader, db kv.RwDB
 	}
 }
 
+// SetRemoteBuilder - payloads are built by a builder process (see BlockBuilderServer) instead of this one, so
+// building can't take CPU and db from sync and RPC of the node. While the builder is down the node builds them.
+func (e *EthereumExecutionModule) SetRemoteBuilder(remoteBuilder execution.ExecutionClient) {
+	e.remoteBuilder = remoteBuilder
+}
+
+// SetMaxReorgDepth - forkchoice updates unwinding more than maxDepth blocks are refused, see reorgGuard
+func (e *EthereumExecutionModule) SetMaxReorgDepth(maxDepth uint64) {
+	e.reorgGuard.maxDepth = maxDepth
+}
+
 func (e *EthereumExecutionModule) getHeader(ctx context.Context, tx kv.Tx, blockHash libcommon.Hash, blockNumber uint64) (*types.Header, error) {
 	td, err := rawdb.ReadTd(tx, blockHash, blockNumber)
 	if err != nil {
@@ -150,7 +173,7 @@ func (e *EthereumExecutionModule) canonicalHash(ctx context.Context, tx kv.Tx, b
 }
 
 func (e *EthereumExecutionModule) ValidateChain(ctx context.Context, req *execution.ValidationRequest) (*execution.ValidationReceipt, error) {
-	if !e.semaphore.TryAcquire(1) {
+	if !e.acquireLane(ctx) {
 		e.logger.Trace("ethereumExecutionModule.ValidateChain: ExecutionStatus_Busy")
 		return &execution.ValidationReceipt{
 			LatestValidHash:  gointerfaces.ConvertHashToH256(libcommon.Hash{}),
@@ -206,6 +229,13 @@ func (e *EthereumExecutionModule) ValidateChain(ctx context.Context, req *execut
 		validationStatus = execution.ExecutionStatus_MissingSegment
 	}
 	isInvalidChain := status == engine_types.InvalidStatus || status == engine_types.InvalidBlockHashStatus || validationError != nil
+	if isInvalidChain && validationError != nil {
+		// the block was validated in memory, its record is written here
+		block := types.NewBlockFromStorage(blockHash, header, body.Transactions, body.Uncles, body.Withdrawals)
+		if err := core.ReportBadBlock(tx, block, false, validationError); err != nil {
+			e.logger.Warn("Failed to record bad block", "number", req.Number, "hash", blockHash, "err", err)
+		}
+	}
 	if isInvalidChain && (lvh != libcommon.Hash{}) && lvh != blockHash {
 		if err := e.purgeBadChain(ctx, tx, lvh, blockHash); err != nil {
 			return nil, err
@@ -242,30 +272,29 @@ func (e *EthereumExecutionModule) purgeBadChain(ctx context.Context, tx kv.RwTx,
 }
 
 func (e *EthereumExecutionModule) Start(ctx context.Context) {
-	e.semaphore.Acquire(ctx, 1)
-	defer e.semaphore.Release(1)
-
 	more := true
 
 	for more {
+		// cycles stop between stages and wait here while sync is paused
+		if err := e.syncPause.Begin(ctx); err != nil {
+			return
+		}
 		var err error
-
-		if more, err = e.executionPipeline.Run(e.db, wrap.TxContainer{}, true); err != nil {
-			if !errors.Is(err, context.Canceled) {
-				e.logger.Error("Could not start execution service", "err", err)
-			}
-			continue
+		if more, err = e.executionPipeline.Run(e.db, wrap.TxContainer{}, true); err == nil {
+			err = e.executionPipeline.RunPrune(e.db, nil, true)
 		}
-
-		if err := e.executionPipeline.RunPrune(e.db, nil, true); err != nil {
-			if !errors.Is(err, context.Canceled) {
-				e.logger.Error("Could not start execution service", "err", err)
-			}
-			continue
+		e.syncPause.End()
+		if err != nil && !errors.Is(err, context.Canceled) {
+			e.logger.Error("Could not start execution service", "err", err)
 		}
 	}
 }
 
+// SyncPause - the pause of this module, shared with the stage loop of the node
+func (e *EthereumExecutionModule) SyncPause() *stagedsync.SyncPause {
+	return e.syncPause
+}
+
 func (e *EthereumExecutionModule) Ready(context.Context, *emptypb.Empty) (*execution.ReadyResponse, error) {
 	if !e.semaphore.TryAcquire(1) {
 		e.logger.Trace("ethereumExecutionModule.Ready: ExecutionStatus_Busy")
@@ -7,14 +7,17 @@ import (
 	"time"
 
 	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/dbg"
 	"github.com/erigontech/erigon-lib/gointerfaces"
 	"github.com/erigontech/erigon-lib/gointerfaces/execution"
 	"github.com/erigontech/erigon-lib/kv"
 	"github.com/erigontech/erigon-lib/kv/rawdbv3"
 	"github.com/erigontech/erigon-lib/wrap"
 	"github.com/erigontech/erigon/core/rawdb"
+	"github.com/erigontech/erigon/eth/ethconfig"
 	"github.com/erigontech/erigon/eth/stagedsync"
 	"github.com/erigontech/erigon/eth/stagedsync/stages"
+	"github.com/erigontech/erigon/turbo/engineapi/engine_errors"
 )
 
 type forkchoiceOutcome struct {
@@ -36,6 +39,13 @@ func sendForkchoiceErrorWithoutWaiting(ch chan forkchoiceOutcome, err error) {
 	}
 }
 
+func sendForkchoiceOutcomeWithoutWaiting(ch chan forkchoiceOutcome, outcome forkchoiceOutcome) {
+	select {
+	case ch <- outcome:
+	default:
+	}
+}
+
 // verifyForkchoiceHashes verifies the finalized and safe hash of the forkchoice state
 func (e *EthereumExecutionModule) verifyForkchoiceHashes(ctx context.Context, tx kv.Tx, blockHash, finalizedHash, safeHash libcommon.Hash) (bool, error) {
 	// Client software MUST return -38002: Invalid forkchoice state error if the payload referenced by
@@ -68,31 +78,18 @@ func (e *EthereumExecutionModule) verifyForkchoiceHashes(ctx context.Context, tx
 }
 
 func (e *EthereumExecutionModule) UpdateForkChoice(ctx context.Context, req *execution.ForkChoice) (*execution.ForkChoiceReceipt, error) {
-	blockHash := gointerfaces.ConvertH256ToHash(req.HeadBlockHash)
-	safeHash := gointerfaces.ConvertH256ToHash(req.SafeBlockHash)
-	finalizedHash := gointerfaces.ConvertH256ToHash(req.FinalizedBlockHash)
-
-	outcomeCh := make(chan forkchoiceOutcome, 1)
+	// So we wait at most the configured timeout (by default - req.Timeout) before just sending out
+	timeout := e.forkchoiceTimeout(req)
+	outcomeCh := e.queueForkChoice(req, timeout)
 
-	// So we wait at most the amount specified by req.Timeout before just sending out
-	go e.updateForkChoice(e.bacgroundCtx, blockHash, safeHash, finalizedHash, outcomeCh)
-
-	var fcuTimer *time.Timer
-	if e.config.IsOptimism() {
-		// op-node does not handle SYNCING as asynchronous forkChoiceUpdated.
-		// we set a large timeout to make sure op-node retries
-		fcuTimer = time.NewTimer(time.Second * 5)
-	} else {
-		fcuTimer = time.NewTimer(time.Duration(req.Timeout) * time.Millisecond)
-	}
+	fcuTimer := time.NewTimer(timeout)
+	defer fcuTimer.Stop()
 
 	select {
 	case <-fcuTimer.C:
-		e.logger.Debug("treating forkChoiceUpdated as asynchronous as it is taking too long")
-		if e.config.IsOptimism() {
-			// op-node does not handle SYNCING as asynchronous forkChoiceUpdated.
+		if e.forkchoiceErrorOnBusy() {
 			// return an error and make op-node retry
-			return nil, errors.New("forkChoiceUpdated timeout")
+			return nil, engine_errors.ErrBusy.With(errors.New("forkChoiceUpdated timeout"))
 		}
 		e.logger.Debug("treating forkChoiceUpdated as asynchronous as it is taking too long")
 		return &execution.ForkChoiceReceipt{
@@ -105,6 +102,49 @@ func (e *EthereumExecutionModule) UpdateForkChoice(ctx context.Context, req *exe
 
 }
 
+// queueForkChoice - queues the forkchoice update, its outcome is sent to the returned channel once it ran
+func (e *EthereumExecutionModule) queueForkChoice(req *execution.ForkChoice, timeout time.Duration) chan forkchoiceOutcome {
+	outcomeCh := make(chan forkchoiceOutcome, 1)
+	if e.forkchoiceQueue.add(&forkchoiceRequest{
+		blockHash:     gointerfaces.ConvertH256ToHash(req.HeadBlockHash),
+		safeHash:      gointerfaces.ConvertH256ToHash(req.SafeBlockHash),
+		finalizedHash: gointerfaces.ConvertH256ToHash(req.FinalizedBlockHash),
+		timeout:       timeout,
+		outcomeCh:     outcomeCh,
+	}) {
+		go e.runForkChoices()
+	}
+	return outcomeCh
+}
+
+func (e *EthereumExecutionModule) forkchoiceTimeout(req *execution.ForkChoice) time.Duration {
+	switch {
+	case e.forkchoiceConfig.Timeout > 0:
+		return e.forkchoiceConfig.Timeout
+	case e.config.IsOptimism():
+		// we set a large timeout to make sure op-node retries
+		return ethconfig.OptimismForkchoiceTimeout
+	default:
+		return time.Duration(req.Timeout) * time.Millisecond
+	}
+}
+
+// forkchoiceErrorOnBusy - op-node does not handle SYNCING as asynchronous forkChoiceUpdated,
+// unless async mode is enabled it gets an error instead
+func (e *EthereumExecutionModule) forkchoiceErrorOnBusy() bool {
+	return e.config.IsOptimism() && !e.forkchoiceConfig.Async
+}
+
+// acquireForkchoice - with BusyRetry waits up to timeout for the previous forkChoiceUpdated to finish
+func (e *EthereumExecutionModule) acquireForkchoice(ctx context.Context, timeout time.Duration) bool {
+	if !e.forkchoiceConfig.BusyRetry {
+		return e.semaphore.TryAcquire(1)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return e.semaphore.Acquire(ctx, 1) == nil
+}
+
 func writeForkChoiceHashes(tx kv.RwTx, blockHash, safeHash, finalizedHash libcommon.Hash) {
 	if finalizedHash != (libcommon.Hash{}) {
 		rawdb.WriteForkchoiceFinalized(tx, finalizedHash)
@@ -116,12 +156,11 @@ func writeForkChoiceHashes(tx kv.RwTx, blockHash, safeHash, finalizedHash libcom
 	rawdb.WriteForkchoiceHead(tx, blockHash)
 }
 
-func (e *EthereumExecutionModule) updateForkChoice(ctx context.Context, blockHash, safeHash, finalizedHash libcommon.Hash, outcomeCh chan forkchoiceOutcome) {
-	if !e.semaphore.TryAcquire(1) {
-		if e.config.IsOptimism() {
-			// op-node does not handle SYNCING as asynchronous forkChoiceUpdated.
+func (e *EthereumExecutionModule) updateForkChoice(ctx context.Context, blockHash, safeHash, finalizedHash libcommon.Hash, timeout time.Duration, outcomeCh chan forkchoiceOutcome) {
+	if !e.acquireForkchoice(ctx, timeout) {
+		if e.forkchoiceErrorOnBusy() {
 			// return an error and make op-node retry
-			sendForkchoiceErrorWithoutWaiting(outcomeCh, errors.New("cannot update forkchoice. execution service is busy"))
+			sendForkchoiceErrorWithoutWaiting(outcomeCh, engine_errors.ErrBusy)
 			return
 		}
 		sendForkchoiceReceiptWithoutWaiting(outcomeCh, &execution.ForkChoiceReceipt{
@@ -142,6 +181,16 @@ func (e *EthereumExecutionModule) updateForkChoice(ctx context.Context, blockHas
 		return
 	}
 	defer tx.Rollback()
+	dryRun, err := e.executionPipeline.BeginDryRun(tx)
+	if err != nil {
+		sendForkchoiceErrorWithoutWaiting(outcomeCh, err)
+		return
+	}
+	if dryRun != nil {
+		// --sync.dry-run: everything below goes to the overlay, which is reported instead of committed
+		defer dryRun.Rollback()
+		tx = dryRun.Tx()
+	}
 
 	defer e.forkValidator.ClearWithUnwind(e.accumulator, e.stateChangeConsumer)
 	// Step one, find reconnection point, and mark all of those headers as canonical.
@@ -151,7 +200,12 @@ func (e *EthereumExecutionModule) updateForkChoice(ctx context.Context, blockHas
 		return
 	}
 	if fcuHeader == nil {
-		sendForkchoiceErrorWithoutWaiting(outcomeCh, fmt.Errorf("forkchoice: block %x not found or was marked invalid", blockHash))
+		// the header is downloaded before the forkchoice is updated, so it's gone as a part of a bad chain
+		sendForkchoiceReceiptWithoutWaiting(outcomeCh, &execution.ForkChoiceReceipt{
+			LatestValidHash: gointerfaces.ConvertHashToH256(libcommon.Hash{}),
+			Status:          execution.ExecutionStatus_BadBlock,
+			ValidationError: fmt.Sprintf("forkchoice: block %x not found or was marked invalid", blockHash),
+		})
 		return
 	}
 	canonicalHash, err := e.blockReader.CanonicalHash(ctx, tx, fcuHeader.Number.Uint64())
@@ -248,9 +302,16 @@ func (e *EthereumExecutionModule) updateForkChoice(ctx context.Context, blockHas
 		unwindToNumber = fcuHeader.Number.Uint64()
 	}
 
+	if err := e.reorgGuard.check(tx, unwindToNumber); err != nil {
+		e.logger.Error("Refusing a deep reorg, the forkchoice update is rejected until it's allowed by admin_allowDeepReorg or --sync.max-reorg-depth is raised",
+			"head", blockHash, "number", fcuHeader.Number.Uint64(), "err", err)
+		sendForkchoiceErrorWithoutWaiting(outcomeCh, err)
+		return
+	}
+
 	e.executionPipeline.UnwindTo(unwindToNumber, stagedsync.ForkChoice)
 	if e.historyV3 {
-		if err := rawdbv3.TxNums.Truncate(tx, unwindToNumber); err != nil {
+		if err := rawdbv3.TxNumsWriter.Truncate(tx, unwindToNumber); err != nil {
 			sendForkchoiceErrorWithoutWaiting(outcomeCh, err)
 			return
 		}
@@ -283,20 +344,30 @@ func (e *EthereumExecutionModule) updateForkChoice(ctx context.Context, blockHas
 
 	// Truncate tx nums
 	if e.historyV3 {
-		if err := rawdbv3.TxNums.Truncate(tx, unwindToNumber); err != nil {
+		if err := rawdbv3.TxNumsWriter.Truncate(tx, unwindToNumber); err != nil {
 			sendForkchoiceErrorWithoutWaiting(outcomeCh, err)
 			return
 		}
+		if dbg.AssertTxNums {
+			if err := rawdb.VerifyTxNums(tx, unwindToNumber); err != nil {
+				sendForkchoiceErrorWithoutWaiting(outcomeCh, err)
+				return
+			}
+		}
 	}
 	// Mark all new canonicals as canonicals
+	chainReader := e.chainReader.Tx(tx)
 	for _, canonicalSegment := range newCanonicals {
-		chainReader := stagedsync.NewChainReaderImpl(e.config, tx, e.blockReader, e.logger)
 
 		b, _, _ := rawdb.ReadBody(tx, canonicalSegment.hash, canonicalSegment.number)
 		h := rawdb.ReadHeader(tx, canonicalSegment.hash, canonicalSegment.number)
 
 		if b == nil || h == nil {
-			sendForkchoiceErrorWithoutWaiting(outcomeCh, fmt.Errorf("unexpected chain cap: %d", canonicalSegment.number))
+			sendForkchoiceReceiptWithoutWaiting(outcomeCh, &execution.ForkChoiceReceipt{
+				LatestValidHash: gointerfaces.ConvertHashToH256(libcommon.Hash{}),
+				Status:          execution.ExecutionStatus_BadBlock,
+				ValidationError: fmt.Sprintf("unexpected chain cap: %d", canonicalSegment.number),
+			})
 			return
 		}
 
@@ -382,6 +453,18 @@ func (e *EthereumExecutionModule) updateForkChoice(ctx context.Context, blockHas
 			return
 		}
 
+		if dryRun != nil {
+			if err := dryRun.Report(); err != nil {
+				sendForkchoiceErrorWithoutWaiting(outcomeCh, err)
+				return
+			}
+			// the head was never committed: VALID would let the CL build on a chain the db doesn't have
+			sendForkchoiceReceiptWithoutWaiting(outcomeCh, &execution.ForkChoiceReceipt{
+				LatestValidHash: gointerfaces.ConvertHashToH256(libcommon.Hash{}),
+				Status:          execution.ExecutionStatus_Busy,
+			})
+			return
+		}
 		if err := tx.Commit(); err != nil {
 			sendForkchoiceErrorWithoutWaiting(outcomeCh, err)
 			return
@@ -0,0 +1,126 @@
+package eth1
+
+import (
+	"sync"
+	"time"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/gointerfaces"
+	"github.com/erigontech/erigon-lib/gointerfaces/execution"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/metrics"
+
+	"github.com/erigontech/erigon/core/rawdb"
+	"github.com/erigontech/erigon/turbo/engineapi/engine_errors"
+)
+
+var forkchoiceSkippedRuns = metrics.GetOrCreateCounter(`execution_forkchoice_skipped_runs`)
+
+type forkchoiceRequest struct {
+	blockHash, safeHash, finalizedHash libcommon.Hash
+	timeout                            time.Duration
+	outcomeCh                          chan forkchoiceOutcome
+}
+
+// forkchoiceQueue - coalescing of forkChoiceUpdated requests, e.g. of op-node catching up. Requests which arrive
+// while one runs the pipeline wait for it, and of those only the newest runs the pipeline next. The ones it
+// replaced are answered after that run from the chain it left, see supersededForkchoiceOutcome.
+type forkchoiceQueue struct {
+	mu         sync.Mutex
+	running    bool
+	next       *forkchoiceRequest
+	superseded []*forkchoiceRequest // replaced by next
+}
+
+// add - queues fcu, replacing the request waiting to run. Returns true if nothing runs the queue, the caller has
+// to start runForkChoices then.
+func (q *forkchoiceQueue) add(fcu *forkchoiceRequest) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.next != nil {
+		q.superseded = append(q.superseded, q.next)
+		forkchoiceSkippedRuns.Inc()
+	}
+	q.next = fcu
+	start := !q.running
+	q.running = true
+	return start
+}
+
+// take - the request to run next and the ones it replaced. Once the queue is empty it returns nil and the next
+// add starts a new run.
+func (q *forkchoiceQueue) take() (*forkchoiceRequest, []*forkchoiceRequest) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	fcu, superseded := q.next, q.superseded
+	q.next, q.superseded = nil, nil
+	if fcu == nil {
+		q.running = false
+	}
+	return fcu, superseded
+}
+
+func (e *EthereumExecutionModule) runForkChoices() {
+	for {
+		fcu, superseded := e.forkchoiceQueue.take()
+		if fcu == nil {
+			return
+		}
+		outcomeCh := make(chan forkchoiceOutcome, 1)
+		e.updateForkChoice(e.bacgroundCtx, fcu.blockHash, fcu.safeHash, fcu.finalizedHash, fcu.timeout, outcomeCh)
+		var outcome forkchoiceOutcome
+		select {
+		case outcome = <-outcomeCh:
+		default:
+			outcome.err = engine_errors.ErrBusy
+		}
+		sendForkchoiceOutcomeWithoutWaiting(fcu.outcomeCh, outcome)
+		for _, s := range superseded {
+			sendForkchoiceOutcomeWithoutWaiting(s.outcomeCh, e.supersededForkchoiceOutcome(s, outcome))
+		}
+	}
+}
+
+// supersededForkchoiceOutcome - the answer to a request which was replaced by a newer one before it ran. It is
+// valid if its head is the head the newer request left, with its safe and finalized blocks on the chain before it.
+// Otherwise, the pipeline never ran for it, so it's answered as busy or with the error of the newer one: a head
+// behind the one left was not chosen, VALID would have the CL build a payload on it (see forkchoiceUpdated).
+func (e *EthereumExecutionModule) supersededForkchoiceOutcome(fcu *forkchoiceRequest, newer forkchoiceOutcome) forkchoiceOutcome {
+	ctx := e.bacgroundCtx
+	var outcome forkchoiceOutcome
+	if err := e.db.View(ctx, func(tx kv.Tx) error {
+		if rawdb.ReadHeadBlockHash(tx) != fcu.blockHash {
+			return nil
+		}
+		valid, err := e.verifyForkchoiceHashes(ctx, tx, fcu.blockHash, fcu.finalizedHash, fcu.safeHash)
+		if err != nil {
+			return err
+		}
+		outcome.receipt = &execution.ForkChoiceReceipt{
+			LatestValidHash: gointerfaces.ConvertHashToH256(fcu.blockHash),
+			Status:          execution.ExecutionStatus_Success,
+		}
+		if !valid {
+			outcome.receipt = &execution.ForkChoiceReceipt{
+				LatestValidHash: gointerfaces.ConvertHashToH256(libcommon.Hash{}),
+				Status:          execution.ExecutionStatus_InvalidForkchoice,
+			}
+		}
+		return nil
+	}); err != nil {
+		return forkchoiceOutcome{err: err}
+	}
+	switch {
+	case outcome.receipt != nil:
+		return outcome
+	case newer.err != nil:
+		return newer
+	case e.forkchoiceErrorOnBusy():
+		return forkchoiceOutcome{err: engine_errors.ErrBusy}
+	default:
+		return forkchoiceOutcome{receipt: &execution.ForkChoiceReceipt{
+			LatestValidHash: gointerfaces.ConvertHashToH256(libcommon.Hash{}),
+			Status:          execution.ExecutionStatus_Busy,
+		}}
+	}
+}
@@ -0,0 +1,107 @@
+package eth1
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/chain"
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/gointerfaces"
+	"github.com/erigontech/erigon-lib/gointerfaces/execution"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/memdb"
+
+	"github.com/erigontech/erigon/core/rawdb"
+	"github.com/erigontech/erigon/turbo/engineapi/engine_errors"
+)
+
+func TestForkchoiceQueue(t *testing.T) {
+	t.Parallel()
+
+	var q forkchoiceQueue
+	fcu := func(b byte) *forkchoiceRequest {
+		return &forkchoiceRequest{blockHash: libcommon.Hash{b}}
+	}
+	skipped := forkchoiceSkippedRuns.GetValueUint64()
+
+	// the first request starts the run
+	first := fcu(1)
+	require.True(t, q.add(first))
+	next, superseded := q.take()
+	require.Equal(t, first, next)
+	require.Empty(t, superseded)
+
+	// requests arriving while it runs are coalesced into the newest
+	second, third, fourth := fcu(2), fcu(3), fcu(4)
+	require.False(t, q.add(second))
+	require.False(t, q.add(third))
+	require.False(t, q.add(fourth))
+	next, superseded = q.take()
+	require.Equal(t, fourth, next)
+	require.Equal(t, []*forkchoiceRequest{second, third}, superseded)
+	require.Equal(t, skipped+2, forkchoiceSkippedRuns.GetValueUint64())
+
+	// the run stops once the queue is empty, the next request starts a new one
+	next, superseded = q.take()
+	require.Nil(t, next)
+	require.Empty(t, superseded)
+	require.True(t, q.add(fcu(5)))
+}
+
+func TestSupersededForkchoiceOutcome(t *testing.T) {
+	t.Parallel()
+
+	db := memdb.NewTestDB(t)
+	blocks := []libcommon.Hash{{1}, {2}, {3}, {4}}
+	require.NoError(t, db.Update(context.Background(), func(tx kv.RwTx) error {
+		for i, hash := range blocks {
+			if err := rawdb.WriteHeaderNumber(tx, hash, uint64(i)); err != nil {
+				return err
+			}
+			if err := rawdb.WriteCanonicalHash(tx, hash, uint64(i)); err != nil {
+				return err
+			}
+			if err := rawdb.WriteTd(tx, hash, uint64(i), big.NewInt(int64(i))); err != nil {
+				return err
+			}
+		}
+		// as the newer request left it
+		rawdb.WriteHeadBlockHash(tx, blocks[3])
+		return nil
+	}))
+	e := &EthereumExecutionModule{bacgroundCtx: context.Background(), db: db, config: &chain.Config{}}
+	fcu := func(head, safe, finalized libcommon.Hash) *forkchoiceRequest {
+		return &forkchoiceRequest{blockHash: head, safeHash: safe, finalizedHash: finalized}
+	}
+	newer := forkchoiceOutcome{receipt: &execution.ForkChoiceReceipt{
+		LatestValidHash: gointerfaces.ConvertHashToH256(blocks[3]),
+		Status:          execution.ExecutionStatus_Success,
+	}}
+
+	// the head the newer request left, with safe and finalized blocks before it
+	outcome := e.supersededForkchoiceOutcome(fcu(blocks[3], blocks[2], blocks[1]), newer)
+	require.NoError(t, outcome.err)
+	require.Equal(t, execution.ExecutionStatus_Success, outcome.receipt.Status)
+	require.Equal(t, blocks[3], gointerfaces.ConvertH256ToHash(outcome.receipt.LatestValidHash))
+
+	// its safe block isn't on the chain
+	outcome = e.supersededForkchoiceOutcome(fcu(blocks[3], libcommon.Hash{9}, blocks[1]), newer)
+	require.Equal(t, execution.ExecutionStatus_InvalidForkchoice, outcome.receipt.Status)
+
+	// a head behind the one left was never chosen, a payload mustn't be built on it
+	outcome = e.supersededForkchoiceOutcome(fcu(blocks[2], blocks[1], blocks[0]), newer)
+	require.NoError(t, outcome.err)
+	require.Equal(t, execution.ExecutionStatus_Busy, outcome.receipt.Status)
+
+	failed := forkchoiceOutcome{err: errors.New("failed")}
+	require.Equal(t, failed, e.supersededForkchoiceOutcome(fcu(blocks[2], blocks[1], blocks[0]), failed))
+
+	// op-node retries on the error
+	e.config = &chain.Config{Optimism: &chain.OptimismConfig{}}
+	outcome = e.supersededForkchoiceOutcome(fcu(blocks[2], blocks[1], blocks[0]), newer)
+	require.ErrorIs(t, outcome.err, engine_errors.ErrBusy)
+}
@@ -0,0 +1,78 @@
+package eth1
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/semaphore"
+
+	"github.com/erigontech/erigon-lib/gointerfaces/execution"
+
+	"github.com/erigontech/erigon/eth/ethconfig"
+	"github.com/erigontech/erigon/params"
+)
+
+func TestForkchoiceTimeout(t *testing.T) {
+	t.Parallel()
+
+	req := &execution.ForkChoice{Timeout: 300}
+	l1 := &EthereumExecutionModule{config: params.TestChainConfig}
+	optimism := &EthereumExecutionModule{config: params.OptimismTestConfig}
+
+	// defaults: the timeout of the request for L1, a long one for op-node
+	require.Equal(t, 300*time.Millisecond, l1.forkchoiceTimeout(req))
+	require.Equal(t, ethconfig.OptimismForkchoiceTimeout, optimism.forkchoiceTimeout(req))
+
+	// explicit
+	l1.forkchoiceConfig.Timeout, optimism.forkchoiceConfig.Timeout = time.Second, 2*time.Second
+	require.Equal(t, time.Second, l1.forkchoiceTimeout(req))
+	require.Equal(t, 2*time.Second, optimism.forkchoiceTimeout(req))
+}
+
+func TestForkchoiceErrorOnBusy(t *testing.T) {
+	t.Parallel()
+
+	require.False(t, (&EthereumExecutionModule{config: params.TestChainConfig}).forkchoiceErrorOnBusy())
+	require.True(t, (&EthereumExecutionModule{config: params.OptimismTestConfig}).forkchoiceErrorOnBusy())
+	async := &EthereumExecutionModule{config: params.OptimismTestConfig, forkchoiceConfig: ethconfig.Forkchoice{Async: true}}
+	require.False(t, async.forkchoiceErrorOnBusy())
+	// Async is Optimism only
+	async.config = params.TestChainConfig
+	require.False(t, async.forkchoiceErrorOnBusy())
+}
+
+func TestAcquireForkchoice(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	e := &EthereumExecutionModule{config: params.OptimismTestConfig, semaphore: semaphore.NewWeighted(1)}
+
+	// busy: answered at once
+	require.True(t, e.acquireForkchoice(ctx, time.Hour))
+	start := time.Now()
+	require.False(t, e.acquireForkchoice(ctx, time.Hour))
+	require.Less(t, time.Since(start), time.Second)
+	e.semaphore.Release(1)
+
+	// BusyRetry: waits for the previous one to finish, up to the timeout
+	e.forkchoiceConfig.BusyRetry = true
+	require.True(t, e.acquireForkchoice(ctx, time.Hour))
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		e.semaphore.Release(1)
+	}()
+	require.True(t, e.acquireForkchoice(ctx, time.Hour))
+
+	start = time.Now()
+	require.False(t, e.acquireForkchoice(ctx, 100*time.Millisecond))
+	require.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+
+	// or until the request is cancelled
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	require.False(t, e.acquireForkchoice(cancelled, time.Hour))
+	e.semaphore.Release(1)
+	require.True(t, e.acquireForkchoice(ctx, time.Hour))
+}
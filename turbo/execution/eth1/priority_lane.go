@@ -0,0 +1,42 @@
+package eth1
+
+import (
+	"context"
+	"time"
+
+	"github.com/erigontech/erigon-lib/metrics"
+
+	"github.com/erigontech/erigon/turbo/engineapi/engine_helpers"
+)
+
+// priorityLaneTimeout - how long a priority request waits for the execution lock before it is answered busy
+const priorityLaneTimeout = 2 * time.Second
+
+var (
+	priorityLaneWaiting = metrics.GetOrCreateGauge(`execution_lane_waiting{lane="priority"}`)
+	normalLaneBusy      = metrics.GetOrCreateCounter(`execution_lane_busy{lane="normal"}`)
+	priorityLaneBusy    = metrics.GetOrCreateCounter(`execution_lane_busy{lane="priority"}`)
+)
+
+// acquireLane - takes the execution lock for InsertBlocks and ValidateChain. Requests of the priority lane (new
+// payloads extending the unsafe chain) wait for it, others only take it if it's free. The semaphore doesn't let
+// TryAcquire jump over waiters, so while a priority request waits, backfill batches are turned away busy and retry
+// after it.
+func (e *EthereumExecutionModule) acquireLane(ctx context.Context) bool {
+	if !engine_helpers.IsPriorityLane(ctx) {
+		if !e.semaphore.TryAcquire(1) {
+			normalLaneBusy.Inc()
+			return false
+		}
+		return true
+	}
+	priorityLaneWaiting.Inc()
+	defer priorityLaneWaiting.Dec()
+	ctx, cancel := context.WithTimeout(ctx, priorityLaneTimeout)
+	defer cancel()
+	if e.semaphore.Acquire(ctx, 1) != nil {
+		priorityLaneBusy.Inc()
+		return false
+	}
+	return true
+}
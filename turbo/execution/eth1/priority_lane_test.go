@@ -0,0 +1,39 @@
+package eth1
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/semaphore"
+
+	"github.com/erigontech/erigon/turbo/engineapi/engine_helpers"
+)
+
+func TestAcquireLane(t *testing.T) {
+	t.Parallel()
+
+	e := &EthereumExecutionModule{semaphore: semaphore.NewWeighted(1)}
+	ctx := context.Background()
+
+	// a backfill batch holds the lock
+	require.True(t, e.acquireLane(ctx))
+	require.False(t, e.acquireLane(ctx))
+
+	acquired := make(chan bool)
+	go func() { acquired <- e.acquireLane(engine_helpers.WithPriorityLane(ctx)) }()
+	require.Eventually(t, func() bool { return priorityLaneWaiting.GetValue() > 0 }, time.Second, time.Millisecond)
+
+	e.semaphore.Release(1)
+	require.True(t, <-acquired)
+	require.False(t, e.acquireLane(ctx))
+	e.semaphore.Release(1)
+
+	// a priority request waits no longer than priorityLaneTimeout
+	require.True(t, e.acquireLane(ctx))
+	timeoutCtx, cancel := context.WithTimeout(engine_helpers.WithPriorityLane(ctx), 10*time.Millisecond)
+	defer cancel()
+	require.False(t, e.acquireLane(timeoutCtx))
+	e.semaphore.Release(1)
+}
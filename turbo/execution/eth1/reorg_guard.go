@@ -0,0 +1,47 @@
+package eth1
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/erigontech/erigon-lib/kv"
+
+	"github.com/erigontech/erigon/core/rawdb"
+)
+
+// reorgGuard refuses forkchoice updates unwinding more than maxDepth blocks of the head (see --sync.max-reorg-depth),
+// so a bug of the rollup node driver can't wipe deep history of a replica. A deeper reorg is let through once after
+// admin_allowDeepReorg, or by admin_rewindToBlock.
+type reorgGuard struct {
+	maxDepth uint64 // 0 - off
+	allowed  atomic.Uint64
+}
+
+// allow lets the next forkchoice update unwind up to depth blocks
+func (g *reorgGuard) allow(depth uint64) {
+	g.allowed.Store(depth)
+}
+
+// revoke clears the allowance of depth if no reorg has consumed it, an allowance set since is kept
+func (g *reorgGuard) revoke(depth uint64) {
+	g.allowed.CompareAndSwap(depth, 0)
+}
+
+// check - error if unwinding to unwindTo is deeper than allowed. An allowance is consumed by the deep reorg it lets through.
+func (g *reorgGuard) check(tx kv.Tx, unwindTo uint64) error {
+	if g.maxDepth == 0 {
+		return nil
+	}
+	head := rawdb.ReadHeaderNumber(tx, rawdb.ReadHeadBlockHash(tx))
+	if head == nil || *head <= unwindTo {
+		return nil
+	}
+	depth := *head - unwindTo
+	if depth <= g.maxDepth {
+		return nil
+	}
+	if allowed := g.allowed.Load(); depth <= allowed && g.allowed.CompareAndSwap(allowed, 0) {
+		return nil
+	}
+	return fmt.Errorf("%w: unwinding %d blocks from the head %d to %d, max %d", errReorgTooDeep, depth, *head, unwindTo, g.maxDepth)
+}
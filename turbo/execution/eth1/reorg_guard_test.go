@@ -0,0 +1,46 @@
+package eth1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/kv/memdb"
+
+	"github.com/erigontech/erigon/core/rawdb"
+)
+
+func TestReorgGuard(t *testing.T) {
+	t.Parallel()
+
+	_, tx := memdb.NewTestTx(t)
+	head := libcommon.Hash{1}
+	require.NoError(t, rawdb.WriteHeaderNumber(tx, head, 100))
+	rawdb.WriteHeadBlockHash(tx, head)
+
+	// off
+	var g reorgGuard
+	require.NoError(t, g.check(tx, 0))
+
+	g.maxDepth = 10
+	require.NoError(t, g.check(tx, 90))
+	require.NoError(t, g.check(tx, 100))
+	require.ErrorIs(t, g.check(tx, 89), errReorgTooDeep)
+
+	// an allowance lets one reorg up to its depth through
+	g.allow(20)
+	require.ErrorIs(t, g.check(tx, 79), errReorgTooDeep)
+	require.NoError(t, g.check(tx, 80))
+	require.ErrorIs(t, g.check(tx, 80), errReorgTooDeep)
+
+	// a revoked allowance lets nothing through, revoking a consumed or a different one changes nothing
+	g.allow(20)
+	g.revoke(20)
+	require.ErrorIs(t, g.check(tx, 80), errReorgTooDeep)
+	g.allow(30)
+	g.revoke(20)
+	require.NoError(t, g.check(tx, 70))
+	g.revoke(30)
+	require.ErrorIs(t, g.check(tx, 70), errReorgTooDeep)
+}
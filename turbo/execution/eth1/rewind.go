@@ -0,0 +1,233 @@
+package eth1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/gointerfaces"
+	"github.com/erigontech/erigon-lib/gointerfaces/execution"
+	"github.com/erigontech/erigon-lib/kv"
+
+	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/core/rawdb"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/rpc"
+)
+
+var (
+	errRewound      = errors.New("discarded by admin_rewindToBlock")
+	errReorgTooDeep = errors.New("reorg is deeper than --sync.max-reorg-depth")
+)
+
+// RewindResult - outcome of admin_rewindToBlock
+type RewindResult struct {
+	From        uint64         `json:"from"` // head before the rewind
+	To          uint64         `json:"to"`
+	Hash        libcommon.Hash `json:"hash"`
+	Invalidated int            `json:"invalidated"` // descendants removed, a forkchoice to them is answered INVALID
+}
+
+// AdminAPI - admin_ namespace of the execution module
+type AdminAPI struct {
+	e *EthereumExecutionModule
+}
+
+func (e *EthereumExecutionModule) APIs() []rpc.API {
+	return []rpc.API{{
+		Namespace: "admin",
+		Public:    false,
+		Service:   &AdminAPI{e: e},
+		Version:   "1.0",
+	}, {
+		Namespace: "erigon",
+		Public:    true,
+		Service:   &ErigonAPI{e: e},
+		Version:   "1.0",
+	}}
+}
+
+// RewindToBlock makes the given canonical block the head, for recovery from bad sequencer batches
+func (api *AdminAPI) RewindToBlock(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*RewindResult, error) {
+	return api.e.RewindTo(ctx, blockNrOrHash)
+}
+
+// AllowDeepReorg lets the next forkchoice update unwind up to depth blocks of the head, over --sync.max-reorg-depth
+func (api *AdminAPI) AllowDeepReorg(_ context.Context, depth uint64) (bool, error) {
+	api.e.reorgGuard.allow(depth)
+	api.e.logger.Warn("[admin] Deep reorg allowed for the next forkchoice update", "depth", depth)
+	return true, nil
+}
+
+// PauseSync stops staged sync between stages and holds it until ResumeSync, so the datadir doesn't change,
+// e.g. while a filesystem backup is taken. Forkchoice updates and new payloads are answered busy meanwhile.
+func (api *AdminAPI) PauseSync(ctx context.Context) (bool, error) {
+	if err := api.e.syncPause.Pause(ctx); err != nil {
+		return false, err
+	}
+	api.e.logger.Info("[admin] Sync paused")
+	return true, nil
+}
+
+// ResumeSync resumes staged sync paused by PauseSync
+func (api *AdminAPI) ResumeSync(_ context.Context) (bool, error) {
+	if err := api.e.syncPause.Resume(); err != nil {
+		return false, err
+	}
+	api.e.logger.Info("[admin] Sync resumed")
+	return true, nil
+}
+
+// RewindTo - unwinds the chain to a canonical block with a forkchoice update, which only OP Stack chains allow
+// to go back to. The safe head is moved back too if it's above the block, unwinding below the finalized block
+// is refused. The discarded blocks are removed - headers, bodies with their transactions and senders - so a
+// forkchoiceUpdated to them is answered INVALID until they are delivered again. The first of them is recorded for
+// debug_getBadBlocks, the others aren't to keep the records of the blocks rejected by validation. The rollup node
+// has to be reset to the new head. The call returns once the forkchoice update ran: unlike forkchoiceUpdated it
+// isn't answered busy after the forkchoice timeout, a deep unwind takes longer.
+func (e *EthereumExecutionModule) RewindTo(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*RewindResult, error) {
+	if !e.config.IsOptimism() {
+		return nil, errors.New("rewinding is only supported on OP Stack chains")
+	}
+
+	tx, err := e.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	target, err := e.rewindTarget(ctx, tx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	targetHash, targetNum := target.Hash(), target.Number.Uint64()
+	headNum := rawdb.ReadHeaderNumber(tx, rawdb.ReadHeadBlockHash(tx))
+	if headNum == nil {
+		return nil, errors.New("head block not found")
+	}
+	if targetNum >= *headNum {
+		return nil, fmt.Errorf("block %d is not below the head %d", targetNum, *headNum)
+	}
+
+	finalizedHash, safeHash := rawdb.ReadForkchoiceFinalized(tx), rawdb.ReadForkchoiceSafe(tx)
+	if finalizedHash != (libcommon.Hash{}) {
+		if finalizedNum := rawdb.ReadHeaderNumber(tx, finalizedHash); finalizedNum != nil && *finalizedNum > targetNum {
+			return nil, fmt.Errorf("block %d is below the finalized block %d", targetNum, *finalizedNum)
+		}
+	}
+	if safeHash != (libcommon.Hash{}) {
+		if safeNum := rawdb.ReadHeaderNumber(tx, safeHash); safeNum == nil || *safeNum > targetNum {
+			safeHash = targetHash
+		}
+	}
+	discarded := make([]libcommon.Hash, 0, *headNum-targetNum)
+	for n := targetNum + 1; n <= *headNum; n++ {
+		hash, err := e.blockReader.CanonicalHash(ctx, tx, n)
+		if err != nil {
+			return nil, err
+		}
+		discarded = append(discarded, hash)
+	}
+	tx.Rollback()
+
+	e.logger.Warn("Rewinding the chain", "from", *headNum, "to", targetNum, "hash", targetHash)
+	// the allowance is for this forkchoice update only: if it fails, a later unrelated one mustn't reorg that deep.
+	// It's revoked once the update ran and failed, not while the update is queued or running: the outcome is awaited
+	// even if the caller is gone.
+	depth := *headNum - targetNum
+	e.reorgGuard.allow(depth)
+	req := &execution.ForkChoice{
+		HeadBlockHash:      gointerfaces.ConvertHashToH256(targetHash),
+		SafeBlockHash:      gointerfaces.ConvertHashToH256(safeHash),
+		FinalizedBlockHash: gointerfaces.ConvertHashToH256(finalizedHash),
+	}
+	outcome := <-e.queueForkChoice(req, e.forkchoiceTimeout(req))
+	if outcome.err != nil {
+		e.reorgGuard.revoke(depth)
+		return nil, outcome.err
+	}
+	if receipt := outcome.receipt; receipt.Status != execution.ExecutionStatus_Success {
+		e.reorgGuard.revoke(depth)
+		return nil, fmt.Errorf("forkchoice to block %d: %s %s", targetNum, receipt.Status, receipt.ValidationError)
+	}
+
+	invalidated, err := e.invalidateDiscarded(ctx, targetNum, discarded)
+	if err != nil {
+		return nil, err
+	}
+	return &RewindResult{From: *headNum, To: targetNum, Hash: targetHash, Invalidated: invalidated}, nil
+}
+
+func (e *EthereumExecutionModule) rewindTarget(ctx context.Context, tx kv.Tx, blockNrOrHash rpc.BlockNumberOrHash) (*types.Header, error) {
+	if hash, ok := blockNrOrHash.Hash(); ok {
+		header, err := e.blockReader.HeaderByHash(ctx, tx, hash)
+		if err != nil {
+			return nil, err
+		}
+		if header == nil {
+			return nil, fmt.Errorf("block %x not found", hash)
+		}
+		canonical, err := e.blockReader.CanonicalHash(ctx, tx, header.Number.Uint64())
+		if err != nil {
+			return nil, err
+		}
+		if canonical != hash {
+			return nil, fmt.Errorf("block %x is not canonical", hash)
+		}
+		return header, nil
+	}
+	number, ok := blockNrOrHash.Number()
+	if !ok || number < 0 {
+		return nil, errors.New("a block hash or an explicit block number is required")
+	}
+	header, err := e.blockReader.HeaderByNumber(ctx, tx, uint64(number))
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, fmt.Errorf("block %d not found", number)
+	}
+	return header, nil
+}
+
+// invalidateDiscarded - removes the blocks above targetNum which are not canonical anymore, the first block is
+// recorded as a bad block
+func (e *EthereumExecutionModule) invalidateDiscarded(ctx context.Context, targetNum uint64, discarded []libcommon.Hash) (int, error) {
+	tx, err := e.db.BeginRw(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var invalidated int
+	for i, hash := range discarded {
+		number := targetNum + 1 + uint64(i)
+		// extended by the rollup node since
+		if canonical, err := rawdb.IsCanonicalHash(tx, hash, number); err != nil {
+			return 0, err
+		} else if canonical {
+			continue
+		}
+		if i == 0 {
+			header, err := e.blockReader.Header(ctx, tx, hash, number)
+			if err != nil {
+				return 0, err
+			}
+			body, err := e.blockReader.BodyWithTransactions(ctx, tx, hash, number)
+			if err != nil {
+				return 0, err
+			}
+			if header != nil && body != nil {
+				block := types.NewBlockFromStorage(hash, header, body.Transactions, body.Uncles, body.Withdrawals)
+				if err := core.ReportBadBlock(tx, block, false, errRewound); err != nil {
+					e.logger.Warn("Failed to record bad block", "number", number, "hash", hash, "err", err)
+				}
+			}
+		}
+		if err := rawdb.DeleteBlock(tx, hash, number); err != nil {
+			return 0, err
+		}
+		invalidated++
+	}
+	return invalidated, tx.Commit()
+}
@@ -0,0 +1,54 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+
+	"github.com/erigontech/erigon/rpc"
+)
+
+// archiveBackend forwards requests at blocks which state history is pruned locally to an archive node
+// (see --rollup.archiverpc). State of such blocks never changes, so results are cached.
+type archiveBackend struct {
+	client *rpc.Client
+	cache  *lru.Cache[string, json.RawMessage] // nil - caching is disabled
+}
+
+// newArchiveBackend returns nil if client is nil - forwarding is disabled
+func newArchiveBackend(client *rpc.Client, cacheSize int) *archiveBackend {
+	if client == nil {
+		return nil
+	}
+	b := &archiveBackend{client: client}
+	if cacheSize > 0 {
+		cache, err := lru.New[string, json.RawMessage](cacheSize)
+		if err != nil {
+			panic(err)
+		}
+		b.cache = cache
+	}
+	return b
+}
+
+// call - args must pin the block by number or hash, otherwise cached result can become stale
+func (b *archiveBackend) call(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	key, err := json.Marshal(append([]interface{}{method}, args...))
+	if err != nil {
+		return err
+	}
+	if b.cache != nil {
+		if raw, ok := b.cache.Get(string(key)); ok {
+			return json.Unmarshal(raw, result)
+		}
+	}
+	var raw json.RawMessage
+	if err := b.client.CallContext(ctx, &raw, method, args...); err != nil {
+		return err
+	}
+	if b.cache != nil {
+		b.cache.Add(string(key), raw)
+	}
+	return json.Unmarshal(raw, result)
+}
@@ -0,0 +1,49 @@
+package jsonrpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/rpc"
+)
+
+type archiveServiceMock struct {
+	calls int
+}
+
+func (s *archiveServiceMock) EstimateGas(blockNum hexutil.Uint64) hexutil.Uint64 {
+	s.calls++
+	return 21000 + blockNum
+}
+
+func TestArchiveBackendCache(t *testing.T) {
+	require.Nil(t, newArchiveBackend(nil, 16))
+
+	logger := log.New()
+	service := &archiveServiceMock{}
+	server := rpc.NewServer(50, false, false, false, logger, 0)
+	require.NoError(t, server.RegisterName("eth", service))
+	client := rpc.DialInProc(server, logger)
+	defer client.Close()
+
+	ctx := context.Background()
+	archive := newArchiveBackend(client, 16)
+	var gas hexutil.Uint64
+	for i := 0; i < 2; i++ {
+		require.NoError(t, archive.call(ctx, &gas, "eth_estimateGas", hexutil.Uint64(1)))
+		require.Equal(t, hexutil.Uint64(21001), gas)
+	}
+	require.NoError(t, archive.call(ctx, &gas, "eth_estimateGas", hexutil.Uint64(2)))
+	require.Equal(t, hexutil.Uint64(21002), gas)
+	require.Equal(t, 2, service.calls)
+
+	// no cache
+	archive = newArchiveBackend(client, 0)
+	require.NoError(t, archive.call(ctx, &gas, "eth_estimateGas", hexutil.Uint64(1)))
+	require.Equal(t, 3, service.calls)
+}
@@ -0,0 +1,46 @@
+package jsonrpc
+
+import (
+	"math/big"
+
+	"github.com/erigontech/erigon-lib/chain"
+	"github.com/erigontech/erigon-lib/common"
+
+	"github.com/erigontech/erigon/core/types"
+)
+
+// newRPCTransactionLegacyAware - same as NewRPCTransaction, but decodes artifacts of pre-bedrock Boba transactions:
+// Turing (hybrid compute) response is separated from calldata, and sender is recovered from the calldata user signed.
+func newRPCTransactionLegacyAware(cc *chain.Config, txn types.Transaction, blockHash common.Hash, blockNumber uint64, index uint64, baseFee *big.Int,
+	receipt *types.Receipt) *RPCTransaction {
+	if cc.IsBobaLegacyBlock(blockNumber) {
+		if original, turing, ok := types.BobaLegacyTuringTx(txn); ok {
+			result := NewRPCTransaction(original, blockHash, blockNumber, index, baseFee, receipt)
+			result.Hash = txn.Hash() // canonical hash covers the rewritten calldata
+			result.Turing = turing
+			return result
+		}
+	}
+	return NewRPCTransaction(txn, blockHash, blockNumber, index, baseFee, receipt)
+}
+
+// bobaLegacyFullTransactions - replaces full transactions of marshalled pre-bedrock Boba block by legacy-aware ones
+func bobaLegacyFullTransactions(cc *chain.Config, fields map[string]interface{}, block *types.Block, receipts types.Receipts) {
+	transactions, ok := fields["transactions"].([]interface{})
+	if !ok {
+		return
+	}
+	for i, txn := range block.Transactions() {
+		if i >= len(transactions) {
+			break
+		}
+		if _, _, isTuring := types.BobaLegacyTuringTx(txn); !isTuring {
+			continue
+		}
+		var receipt *types.Receipt
+		if i < len(receipts) {
+			receipt = receipts[i]
+		}
+		transactions[i] = newRPCTransactionLegacyAware(cc, txn, block.Hash(), block.NumberU64(), uint64(i), block.BaseFee(), receipt)
+	}
+}
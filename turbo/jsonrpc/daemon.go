@@ -21,11 +21,15 @@ import (
 func APIList(db kv.RoDB, eth rpchelper.ApiBackend, txPool txpool.TxpoolClient, mining txpool.MiningClient,
 	filters *rpchelper.Filters, stateCache kvcache.Cache,
 	blockReader services.FullBlockReader, agg *libstate.Aggregator, cfg *httpcfg.HttpCfg, engine consensus.EngineReader,
-	seqRPCService, historicalRPCService *rpc.Client, logger log.Logger,
+	seqRPCService, historicalRPCService, archiveRPCService *rpc.Client, logger log.Logger,
 ) (list []rpc.API) {
 	base := NewBaseApi(filters, stateCache, blockReader, agg, cfg.WithDatadir, cfg.EvmCallTimeout, engine, cfg.Dirs, seqRPCService, historicalRPCService)
+	base.sequencer = newSequencerBackend(seqRPCService, cfg.RollupSequencerRetries, cfg.RollupSequencerRetryBackoff, cfg.RollupSequencerLocalTxPool)
+	base.archiveRPC = newArchiveBackend(archiveRPCService, cfg.RollupArchiveRPCCacheSize)
+	base.txnNotIndexedError = cfg.TxnNotIndexedError
 	ethImpl := NewEthAPI(base, db, eth, txPool, mining, cfg.Gascap, cfg.Feecap, cfg.ReturnDataLimit, cfg.AllowUnprotectedTxs, cfg.MaxGetProofRewindBlockCount, cfg.WebsocketSubscribeLogsChannelSize, logger)
 	erigonImpl := NewErigonAPI(base, db, eth)
+	erigonImpl.ethImpl = ethImpl
 	txpoolImpl := NewTxPoolAPI(base, db, txPool)
 	netImpl := NewNetAPIImpl(eth)
 	debugImpl := NewPrivateDebugAPI(base, db, cfg.Gascap)
@@ -34,6 +38,7 @@ func APIList(db kv.RoDB, eth rpchelper.ApiBackend, txPool txpool.TxpoolClient, m
 	dbImpl := NewDBAPIImpl() /* deprecated */
 	adminImpl := NewAdminAPI(eth)
 	parityImpl := NewParityAPIImpl(base, db)
+	optimismImpl := NewOptimismAPI(base, db, ethImpl)
 
 	var borImpl *BorImpl
 
@@ -52,7 +57,7 @@ func APIList(db kv.RoDB, eth rpchelper.ApiBackend, txPool txpool.TxpoolClient, m
 	}
 
 	otsImpl := NewOtterscanAPI(base, db, cfg.OtsMaxPageSize)
-	gqlImpl := NewGraphQLAPI(base, db)
+	gqlImpl := NewGraphQLAPI(base, db, ethImpl)
 	overlayImpl := NewOverlayAPI(base, db, cfg.Gascap, cfg.OverlayGetLogsTimeout, cfg.OverlayReplayBlockTimeout, otsImpl)
 
 	if cfg.GraphQLEnabled {
@@ -152,6 +157,13 @@ func APIList(db kv.RoDB, eth rpchelper.ApiBackend, txPool txpool.TxpoolClient, m
 				Service:   OtterscanAPI(otsImpl),
 				Version:   "1.0",
 			})
+		case "optimism":
+			list = append(list, rpc.API{
+				Namespace: "optimism",
+				Public:    true,
+				Service:   OptimismAPI(optimismImpl),
+				Version:   "1.0",
+			})
 		case "clique":
 			list = append(list, clique.NewCliqueAPI(db, engine, blockReader))
 		case "overlay":
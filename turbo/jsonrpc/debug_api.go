@@ -2,7 +2,9 @@ package jsonrpc
 
 import (
 	"context"
+	"encoding/binary"
 	"fmt"
+	"sync/atomic"
 
 	"github.com/erigontech/erigon-lib/common/hexutil"
 
@@ -17,7 +19,9 @@ import (
 	"github.com/erigontech/erigon/common/changeset"
 	"github.com/erigontech/erigon/core/rawdb"
 	"github.com/erigontech/erigon/core/state"
+	"github.com/erigontech/erigon/core/types"
 	"github.com/erigontech/erigon/core/types/accounts"
+	"github.com/erigontech/erigon/eth/gasmeter"
 	"github.com/erigontech/erigon/eth/stagedsync/stages"
 	"github.com/erigontech/erigon/eth/tracers"
 	"github.com/erigontech/erigon/rlp"
@@ -34,6 +38,7 @@ const AccountRangeMaxResults = 256
 type PrivateDebugAPI interface {
 	StorageRangeAt(ctx context.Context, blockHash common.Hash, txIndex uint64, contractAddress common.Address, keyStart hexutility.Bytes, maxResult int) (StorageRangeResult, error)
 	TraceTransaction(ctx context.Context, hash common.Hash, config *tracers.TraceConfig, stream *jsoniter.Stream) error
+	TraceTransactionStream(ctx context.Context, hash common.Hash, config *tracers.TraceConfig) (*rpc.Subscription, error)
 	TraceBlockByHash(ctx context.Context, hash common.Hash, config *tracers.TraceConfig, stream *jsoniter.Stream) error
 	TraceBlockByNumber(ctx context.Context, number rpc.BlockNumber, config *tracers.TraceConfig, stream *jsoniter.Stream) error
 	AccountRange(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash, start []byte, maxResults int, nocode, nostorage bool) (state.IteratorDump, error)
@@ -43,6 +48,12 @@ type PrivateDebugAPI interface {
 	AccountAt(ctx context.Context, blockHash common.Hash, txIndex uint64, account common.Address) (*AccountResult, error)
 	GetRawHeader(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (hexutility.Bytes, error)
 	GetRawBlock(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (hexutility.Bytes, error)
+	GetPayloadAttributes(ctx context.Context, payloadId hexutility.Bytes) ([]*rawdb.PayloadAttributesRecord, error)
+	GetPayloadAttributesByTime(ctx context.Context, fromTime, toTime hexutil.Uint64) ([]*rawdb.PayloadAttributesRecord, error)
+	GetBlockAccessList(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.BlockAccessList, error)
+	GetBadBlocks(ctx context.Context) ([]*BadBlockArgs, error)
+	ExportState(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash, excludeCode, excludeStorage bool) (string, error)
+	HotContracts(ctx context.Context, top *int) (*gasmeter.HotContractsReport, error)
 }
 
 // PrivateDebugAPIImpl is implementation of the PrivateDebugAPI interface based on remote Db access
@@ -50,6 +61,8 @@ type PrivateDebugAPIImpl struct {
 	*BaseAPI
 	db     kv.RoDB
 	GasCap uint64
+
+	stateExport atomic.Bool // debug_exportState is running
 }
 
 // NewPrivateDebugAPI returns PrivateDebugAPIImpl instance
@@ -389,6 +402,28 @@ func (api *PrivateDebugAPIImpl) GetRawHeader(ctx context.Context, blockNrOrHash
 	return rlp.EncodeToBytes(header)
 }
 
+// GetBlockAccessList implements debug_getBlockAccessList. Returns experimental EIP-7928 block access list,
+// collected by execution with --experimental.bal
+func (api *PrivateDebugAPIImpl) GetBlockAccessList(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.BlockAccessList, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	n, h, _, err := rpchelper.GetBlockNumber(blockNrOrHash, tx, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	bal, err := rawdb.ReadBlockAccessList(tx, h, n)
+	if err != nil {
+		return nil, err
+	}
+	if bal == nil {
+		return nil, fmt.Errorf("block access list of block %d is not available: it's collected with --experimental.bal and pruned with history", n)
+	}
+	return bal, nil
+}
+
 func (api *PrivateDebugAPIImpl) GetRawBlock(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (hexutility.Bytes, error) {
 	tx, err := api.db.BeginRo(ctx)
 	if err != nil {
@@ -408,3 +443,82 @@ func (api *PrivateDebugAPIImpl) GetRawBlock(ctx context.Context, blockNrOrHash r
 	}
 	return rlp.EncodeToBytes(block)
 }
+
+// payloadAttributesByTimeMaxResults is the maximum number of records returned by debug_getPayloadAttributesByTime
+const payloadAttributesByTimeMaxResults = 1000
+
+// GetPayloadAttributes implements debug_getPayloadAttributes. Returns payload attributes received by engine_forkchoiceUpdated
+// with given payload id and outcome of block building. Payload ids are reset on restart, so there may be several records.
+// Records are stored only if node runs with --miner.payloadhistory
+func (api *PrivateDebugAPIImpl) GetPayloadAttributes(ctx context.Context, payloadId hexutility.Bytes) ([]*rawdb.PayloadAttributesRecord, error) {
+	if len(payloadId) != 8 {
+		return nil, fmt.Errorf("invalid payload id %x, expected 8 bytes", payloadId)
+	}
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	return rawdb.ReadPayloadAttributes(tx, binary.BigEndian.Uint64(payloadId))
+}
+
+// GetPayloadAttributesByTime implements debug_getPayloadAttributesByTime. Returns payload attributes received by
+// engine_forkchoiceUpdated in [fromTime, toTime) (unix seconds) and outcomes of block building, oldest first
+func (api *PrivateDebugAPIImpl) GetPayloadAttributesByTime(ctx context.Context, fromTime, toTime hexutil.Uint64) ([]*rawdb.PayloadAttributesRecord, error) {
+	if fromTime >= toTime {
+		return nil, fmt.Errorf("fromTime %d must be less than toTime %d", fromTime, toTime)
+	}
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	return rawdb.ReadPayloadAttributesRange(tx, uint64(fromTime)*1000, uint64(toTime)*1000, payloadAttributesByTimeMaxResults)
+}
+
+// BadBlockArgs - a block rejected by the node, returned by debug_getBadBlocks. Block has the header fields only
+// if the body of the block was not known.
+type BadBlockArgs struct {
+	Hash       common.Hash            `json:"hash"`
+	Block      map[string]interface{} `json:"block"`
+	RLP        hexutility.Bytes       `json:"rlp"`
+	Reason     string                 `json:"reason"`
+	TxIndex    *hexutil.Uint64        `json:"txIndex,omitempty"`
+	ReportedAt hexutil.Uint64         `json:"reportedAt"` // unix milliseconds
+}
+
+// GetBadBlocks implements debug_getBadBlocks. Returns the last rawdb.MaxBadBlocks blocks rejected by validation or
+// execution with the reason and the offending transaction, the last reported first
+func (api *PrivateDebugAPIImpl) GetBadBlocks(ctx context.Context) ([]*BadBlockArgs, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	records, err := rawdb.ReadBadBlocks(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*BadBlockArgs, 0, len(records))
+	for _, rec := range records {
+		args := &BadBlockArgs{Hash: rec.Hash, RLP: rec.RLP, Reason: rec.Reason, TxIndex: rec.TxIndex, ReportedAt: hexutil.Uint64(rec.ReportedAt)}
+		if rec.HeaderOnly {
+			header := &types.Header{}
+			if err := rlp.DecodeBytes(rec.RLP, header); err != nil {
+				return nil, fmt.Errorf("bad block %x: %w", rec.Hash, err)
+			}
+			args.Block = ethapi.RPCMarshalHeader(header)
+		} else {
+			block := &types.Block{}
+			if err := rlp.DecodeBytes(rec.RLP, block); err != nil {
+				return nil, fmt.Errorf("bad block %x: %w", rec.Hash, err)
+			}
+			if args.Block, err = ethapi.RPCMarshalBlock(block, true, true, nil, nil); err != nil {
+				return nil, err
+			}
+		}
+		res = append(res, args)
+	}
+	return res, nil
+}
@@ -157,6 +157,45 @@ func TestTraceTransaction(t *testing.T) {
 	}
 }
 
+func TestTraceTransactionStream(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	api := NewPrivateDebugAPI(newBaseApiForTest(m), m.DB, 0)
+	for _, tt := range debugTraceTransactionTests {
+		var buf bytes.Buffer
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, &buf, 4096)
+		require.NoError(t, api.TraceTransaction(m.Ctx, common.HexToHash(tt.txHash), &tracers.TraceConfig{}, stream))
+		require.NoError(t, stream.Flush())
+		var expected ethapi.ExecutionResult
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &expected))
+
+		var chunks []*traceStreamChunk
+		err := api.streamTraceTransaction(m.Ctx, common.HexToHash(tt.txHash), &tracers.TraceConfig{}, func(chunk *traceStreamChunk) error {
+			chunks = append(chunks, chunk)
+			return nil
+		})
+		require.NoError(t, err)
+		require.NotEmpty(t, chunks)
+		last := chunks[len(chunks)-1]
+		require.True(t, last.Done)
+		require.Empty(t, last.Error)
+
+		// the chunks put together are the result of debug_traceTransaction
+		got := ethapi.ExecutionResult{StructLogs: []ethapi.StructLogRes{}}
+		require.NoError(t, json.Unmarshal(last.Gas, &got.Gas))
+		require.NoError(t, json.Unmarshal(last.Failed, &got.Failed))
+		require.NoError(t, json.Unmarshal(last.ReturnValue, &got.ReturnValue))
+		for _, chunk := range chunks {
+			require.LessOrEqual(t, len(chunk.StructLogs), traceStreamBatch)
+			for _, structLog := range chunk.StructLogs {
+				var res ethapi.StructLogRes
+				require.NoError(t, json.Unmarshal(structLog, &res))
+				got.StructLogs = append(got.StructLogs, res)
+			}
+		}
+		require.Equal(t, expected, got)
+	}
+}
+
 func TestTraceTransactionNoRefund(t *testing.T) {
 	m, _, _ := rpcdaemontest.CreateTestSentry(t)
 	api := NewPrivateDebugAPI(newBaseApiForTest(m), m.DB, 0)
@@ -0,0 +1,112 @@
+package jsonrpc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/core/state"
+	"github.com/erigontech/erigon/rpc"
+)
+
+// stateExportBatch is the number of accounts debug_exportState reads at a time
+const stateExportBatch = 10_000
+
+// ExportState implements debug_exportState. It starts writing the state at the given block to
+// <datadir>/state-dumps/<block>.jsonl in the line-by-line format of `geth dump --iterative`, for comparing the
+// state with op-geth and validating migrations, and returns the path of the file. The export runs in the
+// background on a read transaction of its own, so it sees the state as of its start while the node keeps
+// syncing. Only one export runs at a time; the file is written as <path>.tmp and renamed once complete.
+func (api *PrivateDebugAPIImpl) ExportState(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash, excludeCode, excludeStorage bool) (string, error) {
+	if api.dirs.DataDir == "" {
+		return "", errors.New("state export needs the datadir of the node")
+	}
+
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+	blockNumber, err := api.blockNumberFromBlockNumberOrHash(tx, &blockNrOrHash)
+	if err != nil {
+		return "", err
+	}
+	header, err := api._blockReader.HeaderByNumber(ctx, tx, blockNumber)
+	if err != nil {
+		return "", err
+	}
+	if header == nil {
+		return "", fmt.Errorf("block %d not found", blockNumber)
+	}
+	if err := api.checkPruneHistory(tx, blockNumber); err != nil {
+		return "", err
+	}
+	tx.Rollback()
+
+	if !api.stateExport.CompareAndSwap(false, true) {
+		return "", errors.New("a state export is already running")
+	}
+	dir := filepath.Join(api.dirs.DataDir, "state-dumps")
+	path := filepath.Join(dir, fmt.Sprintf("%d.jsonl", blockNumber))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		api.stateExport.Store(false)
+		return "", err
+	}
+
+	go func() {
+		defer api.stateExport.Store(false)
+		start := time.Now()
+		accounts, err := api.exportState(context.Background(), blockNumber, header.Root, excludeCode, excludeStorage, path)
+		if err != nil {
+			log.Warn("[rpc] State export failed", "block", blockNumber, "accounts", accounts, "err", err)
+			return
+		}
+		log.Info("[rpc] State export done", "block", blockNumber, "accounts", accounts, "path", path, "took", time.Since(start))
+	}()
+	return path, nil
+}
+
+func (api *PrivateDebugAPIImpl) exportState(ctx context.Context, blockNumber uint64, root common.Hash, excludeCode, excludeStorage bool, path string) (uint64, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	f, err := os.Create(path + ".tmp")
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+
+	logEvery := time.NewTicker(30 * time.Second)
+	defer logEvery.Stop()
+	accounts, err := state.NewDumper(tx, blockNumber, api.historyV3(tx)).StreamDump(ctx, root, excludeCode, excludeStorage,
+		stateExportBatch, json.NewEncoder(w), func(accounts uint64) {
+			select {
+			case <-logEvery.C:
+				log.Info("[rpc] State export", "block", blockNumber, "accounts", accounts)
+			default:
+			}
+		})
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Close()
+	}
+	if err != nil {
+		os.Remove(path + ".tmp")
+		return accounts, err
+	}
+	return accounts, os.Rename(path+".tmp", path)
+}
@@ -0,0 +1,30 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/erigontech/erigon/eth/gasmeter"
+)
+
+// hotContractsDefaultTop is the number of contracts debug_hotContracts returns by default
+const hotContractsDefaultTop = 20
+
+// HotContracts implements debug_hotContracts. It returns the contracts which code used the most gas over the
+// window of --sync.hot-contracts.window, with their gas per second of block time. Only the RPC of the Erigon
+// process serves it: the gas is metered by its execution stage.
+func (api *PrivateDebugAPIImpl) HotContracts(ctx context.Context, top *int) (*gasmeter.HotContractsReport, error) {
+	hot := gasmeter.Hot()
+	if hot == nil {
+		return nil, errors.New("hot contracts aren't metered, see --sync.hot-contracts.window")
+	}
+	n := hotContractsDefaultTop
+	if top != nil {
+		if *top <= 0 {
+			return nil, errors.New("top must be positive")
+		}
+		n = *top
+	}
+	report := hot.Report(n)
+	return &report, nil
+}
@@ -0,0 +1,128 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+
+	jsoniter "github.com/json-iterator/go"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/common/debug"
+	"github.com/erigontech/erigon/eth/tracers"
+	"github.com/erigontech/erigon/rpc"
+)
+
+// traceStreamBatch is the number of struct logs in a notification of debug_subscribe("traceTransactionStream")
+const traceStreamBatch = 1_000
+
+// traceStreamChunk is a notification of debug_subscribe("traceTransactionStream"). The struct logs come in order,
+// in batches; the last notification has the result of the trace, or its error, and done set.
+type traceStreamChunk struct {
+	StructLogs  []json.RawMessage `json:"structLogs,omitempty"`
+	Gas         json.RawMessage   `json:"gas,omitempty"`
+	Failed      json.RawMessage   `json:"failed,omitempty"`
+	ReturnValue json.RawMessage   `json:"returnValue,omitempty"`
+	Error       string            `json:"error,omitempty"`
+	Done        bool              `json:"done,omitempty"`
+}
+
+// TraceTransactionStream implements debug_subscribe("traceTransactionStream", hash, config). It traces the
+// transaction like debug_traceTransaction with the struct logger, but sends the struct logs as they come in
+// notifications of traceStreamBatch logs, so huge transactions don't build a giant response. Notifications are
+// sent one at a time: the trace waits while the client doesn't keep up, and stops if it unsubscribes.
+func (api *PrivateDebugAPIImpl) TraceTransactionStream(ctx context.Context, hash common.Hash, config *tracers.TraceConfig) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	if config != nil && config.Tracer != nil {
+		return &rpc.Subscription{}, errors.New("only struct logs are streamed, not results of tracers")
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	traceCtx, cancel := context.WithCancel(context.Background())
+	go func() {
+		defer cancel()
+		select {
+		case <-rpcSub.Err():
+		case <-notifier.Closed():
+		case <-traceCtx.Done():
+		}
+	}()
+	go func() {
+		defer debug.LogPanic()
+		defer cancel()
+		if err := api.streamTraceTransaction(traceCtx, hash, config, func(chunk *traceStreamChunk) error {
+			return notifier.Notify(rpcSub.ID, chunk)
+		}); err != nil {
+			log.Debug("[rpc] streaming of transaction trace stopped", "hash", hash, "err", err)
+		}
+	}()
+	return rpcSub, nil
+}
+
+// streamTraceTransaction - runs debug_traceTransaction into a pipe, from which the struct logs are read one by one
+// and passed to notify in batches. A blocked notify blocks the trace.
+func (api *PrivateDebugAPIImpl) streamTraceTransaction(ctx context.Context, hash common.Hash, config *tracers.TraceConfig, notify func(*traceStreamChunk) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	pr, pw := io.Pipe()
+	traceErr := make(chan error, 1)
+	go func() {
+		defer debug.LogPanic()
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, pw, 4096)
+		err := api.TraceTransaction(ctx, hash, config, stream)
+		if flushErr := stream.Flush(); err == nil {
+			err = flushErr
+		}
+		traceErr <- err
+		pw.Close()
+	}()
+
+	var notifyErr error
+	chunk := &traceStreamChunk{}
+	iter := jsoniter.Parse(jsoniter.ConfigDefault, pr, 4096)
+	iter.ReadObjectCB(func(iter *jsoniter.Iterator, field string) bool {
+		switch field {
+		case "structLogs":
+			iter.ReadArrayCB(func(iter *jsoniter.Iterator) bool {
+				chunk.StructLogs = append(chunk.StructLogs, iter.SkipAndReturnBytes())
+				if len(chunk.StructLogs) >= traceStreamBatch {
+					notifyErr = notify(chunk)
+					chunk = &traceStreamChunk{}
+				}
+				return notifyErr == nil
+			})
+		case "gas":
+			chunk.Gas = iter.SkipAndReturnBytes()
+		case "failed":
+			chunk.Failed = iter.SkipAndReturnBytes()
+		case "returnValue":
+			chunk.ReturnValue = iter.SkipAndReturnBytes()
+		default:
+			iter.Skip()
+		}
+		return notifyErr == nil
+	})
+	if notifyErr != nil {
+		// the client is gone, stop the trace
+		cancel()
+		pr.CloseWithError(notifyErr)
+		<-traceErr
+		return notifyErr
+	}
+	// drain the rest, so the trace finishes
+	_, _ = io.Copy(io.Discard, pr)
+
+	if err := <-traceErr; err != nil {
+		chunk.Error = err.Error()
+	} else if iter.Error != nil && !errors.Is(iter.Error, io.EOF) {
+		chunk.Error = iter.Error.Error()
+	}
+	chunk.Done = true
+	return notify(chunk)
+}
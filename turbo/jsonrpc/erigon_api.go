@@ -11,6 +11,7 @@ import (
 	"github.com/erigontech/erigon-lib/kv"
 
 	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/core/types/accounts"
 	"github.com/erigontech/erigon/p2p"
 	"github.com/erigontech/erigon/rpc"
 	"github.com/erigontech/erigon/turbo/rpchelper"
@@ -21,6 +22,7 @@ type ErigonAPI interface {
 	// System related (see ./erigon_system.go)
 	Forks(ctx context.Context) (Forks, error)
 	BlockNumber(ctx context.Context, rpcBlockNumPtr *rpc.BlockNumber) (hexutil.Uint64, error)
+	ReconStatus(ctx context.Context) (*ReconStatus, error)
 
 	// Blocks related (see ./erigon_blocks.go)
 	GetHeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error)
@@ -28,6 +30,12 @@ type ErigonAPI interface {
 	GetBlockByTimestamp(ctx context.Context, timeStamp rpc.Timestamp, fullTx bool) (map[string]interface{}, error)
 	GetBalanceChangesInBlock(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (map[common.Address]*hexutil.Big, error)
 
+	// State changes related (see ./erigon_state_changes.go)
+	GetBalanceChangesInRange(ctx context.Context, fromBlock, toBlock rpc.BlockNumber, options *StateChangesOptions) (*StateChangesPage, error)
+
+	// Proofs related (see ./erigon_proof.go)
+	GetProofBatch(ctx context.Context, requests []ProofRequest, blockNrOrHash rpc.BlockNumberOrHash) ([]*accounts.AccProofResult, error)
+
 	// Receipt related (see ./erigon_receipts.go)
 	GetLogsByHash(ctx context.Context, hash common.Hash) ([][]*types.Log, error)
 	//GetLogsByNumber(ctx context.Context, number rpc.BlockNumber) ([][]*types.Log, error)
@@ -45,6 +53,7 @@ type ErigonImpl struct {
 	*BaseAPI
 	db         kv.RoDB
 	ethBackend rpchelper.ApiBackend
+	ethImpl    *APIImpl // the proofs of erigon_getProofBatch, set by APIList
 }
 
 // NewErigonAPI returns ErigonImpl instance
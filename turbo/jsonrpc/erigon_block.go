@@ -194,7 +194,10 @@ func buildBlockResponse(ctx context.Context, br services.FullBlockReader, db kv.
 		additionalFields["totalDifficulty"] = (*hexutil.Big)(td)
 	}
 
-	receipts := rawdb.ReadRawReceipts(db, blockNum)
+	receipts, err := readRawReceipts(ctx, br, db, blockNum)
+	if err != nil {
+		return nil, err
+	}
 	response, err := ethapi.RPCMarshalBlockEx(block, true, fullTx, nil, common.Hash{}, additionalFields, receipts)
 
 	if err == nil && rpc.BlockNumber(block.NumberU64()) == rpc.PendingBlockNumber {
@@ -225,8 +228,16 @@ func (api *ErigonImpl) GetBalanceChangesInBlock(ctx context.Context, blockNrOrHa
 	}
 
 	if api.historyV3(tx) {
-		minTxNum, _ := rawdbv3.TxNums.Min(tx, blockNumber)
-		it, err := tx.(kv.TemporalTx).HistoryRange(kv.AccountsHistory, int(minTxNum), -1, order.Asc, -1)
+		minTxNum, err := rawdbv3.TxNums.Min(tx, blockNumber)
+		if err != nil {
+			return nil, err
+		}
+		maxTxNum, err := rawdbv3.TxNums.Max(tx, blockNumber)
+		if err != nil {
+			return nil, err
+		}
+		// changes of the block only, as the changeset of the block below
+		it, err := tx.(kv.TemporalTx).HistoryRange(kv.AccountsHistory, int(minTxNum), int(maxTxNum)+1, order.Asc, -1)
 		if err != nil {
 			return nil, err
 		}
@@ -260,6 +271,7 @@ func (api *ErigonImpl) GetBalanceChangesInBlock(ctx context.Context, blockNrOrHa
 				balancesMapping[address] = newBalanceDesc
 			}
 		}
+		return balancesMapping, nil
 	}
 
 	c, err := tx.Cursor(kv.AccountChangeSet)
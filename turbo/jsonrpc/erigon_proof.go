@@ -0,0 +1,50 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/erigontech/erigon/core/types/accounts"
+	"github.com/erigontech/erigon/rpc"
+)
+
+// maxProofBatchSize - accounts of one erigon_getProofBatch
+const maxProofBatchSize = 1024
+
+// GetProofBatch implements erigon_getProofBatch. Returns the proofs of eth_getProof of the accounts, in the order of
+// the requests, collected by one computation of the trie of the block - the nodes on the paths of several accounts
+// are loaded and hashed once. Limited to the blocks of eth_getProof (--rpc.maxgetproofrewindblockcount.limit).
+func (api *ErigonImpl) GetProofBatch(ctx context.Context, requests []ProofRequest, blockNrOrHash rpc.BlockNumberOrHash) ([]*accounts.AccProofResult, error) {
+	if api.ethImpl == nil {
+		return nil, errors.New("erigon_getProofBatch is not available")
+	}
+	if len(requests) == 0 {
+		return []*accounts.AccProofResult{}, nil
+	}
+	if len(requests) > maxProofBatchSize {
+		return nil, fmt.Errorf("too many accounts %d, at most %d in a batch", len(requests), maxProofBatchSize)
+	}
+
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if api.historyV3(tx) {
+		return nil, fmt.Errorf("not supported by Erigon3")
+	}
+
+	blockNum, err := api.blockNumberFromBlockNumberOrHash(tx, &blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	chainConfig, err := api.chainConfig(ctx, tx)
+	if err != nil {
+		return nil, fmt.Errorf("read chain config: %v", err)
+	}
+	if chainConfig.IsOptimismPreBedrock(blockNum) {
+		return nil, fmt.Errorf("block %d is pre-bedrock, use eth_getProof", blockNum)
+	}
+	return api.ethImpl.getProofs(ctx, tx, requests, blockNrOrHash)
+}
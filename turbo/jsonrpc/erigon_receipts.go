@@ -1,23 +1,18 @@
 package jsonrpc
 
 import (
-	"bytes"
 	"context"
-	"encoding/binary"
 	"fmt"
 
 	"github.com/RoaringBitmap/roaring"
 	bortypes "github.com/erigontech/erigon/polygon/bor/types"
 
 	"github.com/erigontech/erigon-lib/common"
-	"github.com/erigontech/erigon-lib/common/hexutility"
-	"github.com/erigontech/erigon-lib/kv"
 	"github.com/erigontech/erigon-lib/kv/bitmapdb"
 	"github.com/erigontech/erigon/core/rawdb"
 	"github.com/erigontech/erigon/core/types"
 	"github.com/erigontech/erigon/eth/ethutils"
 	"github.com/erigontech/erigon/eth/filters"
-	"github.com/erigontech/erigon/ethdb/cbor"
 	"github.com/erigontech/erigon/rpc"
 	"github.com/erigontech/erigon/turbo/rpchelper"
 )
@@ -100,7 +95,7 @@ func (api *ErigonImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria)
 	}
 	blockNumbers := bitmapdb.NewBitmap()
 	defer bitmapdb.ReturnToPool(blockNumbers)
-	if err := applyFilters(blockNumbers, tx, begin, end, crit); err != nil {
+	if err := applyFilters(blockNumbers, tx, api.logIndex(), begin, end, crit); err != nil {
 		return nil, err
 	}
 	if blockNumbers.IsEmpty() {
@@ -111,6 +106,10 @@ func (api *ErigonImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria)
 	for _, v := range crit.Addresses {
 		addrMap[v] = struct{}{}
 	}
+	pruneMode, err := api.pruneMode(tx)
+	if err != nil {
+		return nil, err
+	}
 	iter := blockNumbers.Iterator()
 	for iter.HasNext() {
 		if err := ctx.Err(); err != nil {
@@ -119,37 +118,27 @@ func (api *ErigonImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria)
 
 		blockNumber := uint64(iter.Next())
 		var logIndex uint
-		var txIndex uint
 		var blockLogs []*types.Log
-		it, err := tx.Prefix(kv.Log, hexutility.EncodeTs(blockNumber))
-		if err != nil {
-			return nil, err
-		}
-		for it.HasNext() {
-			k, v, err := it.Next()
-			if err != nil {
-				return erigonLogs, err
-			}
-			var logs types.Logs
-			if err := cbor.Unmarshal(&logs, bytes.NewReader(v)); err != nil {
-				return erigonLogs, fmt.Errorf("receipt unmarshal failed:  %w", err)
-			}
+		collect := func(txIndex uint, logs types.Logs) bool {
 			for _, log := range logs {
 				log.Index = logIndex
 				logIndex++
 			}
 			filtered := logs.Filter(addrMap, crit.Topics, 0)
-			if len(filtered) == 0 {
-				continue
-			}
-			txIndex = uint(binary.BigEndian.Uint32(k[8:]))
 			for _, log := range filtered {
 				log.TxIndex = txIndex
 			}
 			blockLogs = append(blockLogs, filtered...)
+			return true
 		}
-		if casted, ok := it.(kv.Closer); ok {
-			casted.Close()
+		stored, err := forBlockLogs(ctx, api._blockReader, tx, blockNumber, collect)
+		if err != nil {
+			return erigonLogs, err
+		}
+		if !stored && pruneMode.Experiments.ReceiptsIndexOnly {
+			if err = api.reExecBlockLogs(ctx, tx, blockNumber, collect); err != nil {
+				return nil, err
+			}
 		}
 		if len(blockLogs) == 0 {
 			continue
@@ -262,7 +251,7 @@ func (api *ErigonImpl) GetLatestLogs(ctx context.Context, crit filters.FilterCri
 
 	blockNumbers := bitmapdb.NewBitmap()
 	defer bitmapdb.ReturnToPool(blockNumbers)
-	if err := applyFilters(blockNumbers, tx, begin, end, crit); err != nil {
+	if err := applyFilters(blockNumbers, tx, api.logIndex(), begin, end, crit); err != nil {
 		return erigonLogs, err
 	}
 	if blockNumbers.IsEmpty() {
@@ -280,6 +269,11 @@ func (api *ErigonImpl) GetLatestLogs(ctx context.Context, crit filters.FilterCri
 		}
 	}
 
+	pruneMode, err := api.pruneMode(tx)
+	if err != nil {
+		return nil, err
+	}
+
 	// latest logs that match the filter crit
 	iter := blockNumbers.ReverseIterator()
 	var logCount, blockCount uint64
@@ -290,21 +284,8 @@ func (api *ErigonImpl) GetLatestLogs(ctx context.Context, crit filters.FilterCri
 
 		blockNumber := uint64(iter.Next())
 		var logIndex uint
-		var txIndex uint
 		var blockLogs []*types.Log
-		it, err := tx.Prefix(kv.Log, hexutility.EncodeTs(blockNumber))
-		if err != nil {
-			return nil, err
-		}
-		for it.HasNext() {
-			k, v, err := it.Next()
-			if err != nil {
-				return erigonLogs, err
-			}
-			var logs types.Logs
-			if err := cbor.Unmarshal(&logs, bytes.NewReader(v)); err != nil {
-				return erigonLogs, fmt.Errorf("receipt unmarshal failed:  %w", err)
-			}
+		collect := func(txIndex uint, logs types.Logs) bool {
 			for _, log := range logs {
 				log.Index = logIndex
 				logIndex++
@@ -321,10 +302,6 @@ func (api *ErigonImpl) GetLatestLogs(ctx context.Context, crit filters.FilterCri
 			} else {
 				filtered = logs.Filter(addrMap, crit.Topics, maxLogCount)
 			}
-			if len(filtered) == 0 {
-				continue
-			}
-			txIndex = uint(binary.BigEndian.Uint32(k[8:]))
 			for i := range filtered {
 				filtered[i].TxIndex = txIndex
 			}
@@ -332,12 +309,16 @@ func (api *ErigonImpl) GetLatestLogs(ctx context.Context, crit filters.FilterCri
 				blockLogs = append(blockLogs, filtered[i])
 				logCount++
 			}
-			if logOptions.LogCount != 0 && logOptions.LogCount <= logCount {
-				break
-			}
+			return logOptions.LogCount == 0 || logOptions.LogCount > logCount
 		}
-		if casted, ok := it.(kv.Closer); ok {
-			casted.Close()
+		stored, err := forBlockLogs(ctx, api._blockReader, tx, blockNumber, collect)
+		if err != nil {
+			return erigonLogs, err
+		}
+		if !stored && pruneMode.Experiments.ReceiptsIndexOnly {
+			if err = api.reExecBlockLogs(ctx, tx, blockNumber, collect); err != nil {
+				return nil, err
+			}
 		}
 
 		blockCount++
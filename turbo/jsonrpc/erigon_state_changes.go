@@ -0,0 +1,277 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/holiman/uint256"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/common/hexutility"
+	"github.com/erigontech/erigon-lib/common/length"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/order"
+	"github.com/erigontech/erigon-lib/kv/rawdbv3"
+	"github.com/erigontech/erigon-lib/kv/temporal/historyv2"
+
+	"github.com/erigontech/erigon/core/types/accounts"
+	"github.com/erigontech/erigon/rpc"
+	"github.com/erigontech/erigon/turbo/rpchelper"
+)
+
+// maxStateChangesPageSize - changes returned by a call of erigon_getBalanceChangesInRange at most
+const maxStateChangesPageSize = 1000
+
+// maxStateChangesBlocks - blocks read by a call of erigon_getBalanceChangesInRange at most, the page of a range with
+// few changes ends earlier
+const maxStateChangesBlocks = 10_000
+
+// StateChange - change of the balance or of a storage slot of an account by a block
+type StateChange struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	Address     common.Address `json:"address"`
+	Slot        *common.Hash   `json:"slot,omitempty"` // nil for a balance change
+	From        *hexutil.Big   `json:"from"`           // before the block
+	To          *hexutil.Big   `json:"to"`             // after the block
+}
+
+// StateChangesCursor - position of the next change to return, in the changes of the block
+type StateChangesCursor struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	Index       hexutil.Uint64 `json:"index"`
+}
+
+// StateChangesOptions - options of erigon_getBalanceChangesInRange
+type StateChangesOptions struct {
+	Storage  bool                `json:"storage"`  // return the storage changes too
+	PageSize int                 `json:"pageSize"` // maxStateChangesPageSize if 0
+	Cursor   *StateChangesCursor `json:"cursor"`   // next of the previous page
+}
+
+// StateChangesPage - outcome of erigon_getBalanceChangesInRange. The changes are ordered by block, then the balance
+// changes by address, then the storage changes by address and slot.
+type StateChangesPage struct {
+	Changes []*StateChange      `json:"changes"`
+	Next    *StateChangesCursor `json:"next"` // null on the last page
+}
+
+// GetBalanceChangesInRange implements erigon_getBalanceChangesInRange. Returns the balance changes of the blocks
+// [fromBlock, toBlock], and their storage changes if requested, read from the change sets or the history. Changes
+// beyond the page size or maxStateChangesBlocks are returned by the next calls, with the cursor of the previous page.
+// A range starting in the pruned history is forwarded to the archive node, or refused without one.
+func (api *ErigonImpl) GetBalanceChangesInRange(ctx context.Context, fromBlock, toBlock rpc.BlockNumber, options *StateChangesOptions) (*StateChangesPage, error) {
+	if options == nil {
+		options = &StateChangesOptions{}
+	}
+	pageSize := options.PageSize
+	if pageSize <= 0 || pageSize > maxStateChangesPageSize {
+		pageSize = maxStateChangesPageSize
+	}
+
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	from, _, _, err := rpchelper.GetBlockNumber(rpc.BlockNumberOrHashWithNumber(fromBlock), tx, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	to, _, _, err := rpchelper.GetBlockNumber(rpc.BlockNumberOrHashWithNumber(toBlock), tx, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	if from > to {
+		return nil, fmt.Errorf("start block (%d) must be less than or equal to end block (%d)", from, to)
+	}
+	rangeFrom := from
+	var skip uint64
+	if options.Cursor != nil {
+		if uint64(options.Cursor.BlockNumber) < from || uint64(options.Cursor.BlockNumber) > to {
+			return nil, fmt.Errorf("cursor block (%d) is out of range [%d, %d]", options.Cursor.BlockNumber, from, to)
+		}
+		from, skip = uint64(options.Cursor.BlockNumber), uint64(options.Cursor.Index)
+	}
+
+	relay, err := api.relayToArchive(tx, from)
+	if err != nil {
+		return nil, err
+	}
+	if relay {
+		var page StateChangesPage
+		if err := api.archiveRPC.call(ctx, &page, "erigon_getBalanceChangesInRange", hexutil.EncodeUint64(rangeFrom), hexutil.EncodeUint64(to), options); err != nil {
+			return nil, err
+		}
+		return &page, nil
+	}
+	if err := api.checkPruneHistory(tx, from); err != nil {
+		return nil, err
+	}
+
+	page := &StateChangesPage{Changes: []*StateChange{}}
+	for blockNum := from; blockNum <= to; blockNum++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if blockNum-from == maxStateChangesBlocks {
+			page.Next = &StateChangesCursor{BlockNumber: hexutil.Uint64(blockNum)}
+			return page, nil
+		}
+		changes, err := api.stateChangesInBlock(ctx, tx, blockNum, options.Storage)
+		if err != nil {
+			return nil, err
+		}
+		changes = changes[min(skip, uint64(len(changes))):]
+		for i, change := range changes {
+			if len(page.Changes) == pageSize {
+				page.Next = &StateChangesCursor{BlockNumber: hexutil.Uint64(blockNum), Index: hexutil.Uint64(skip + uint64(i))}
+				return page, nil
+			}
+			page.Changes = append(page.Changes, change)
+		}
+		skip = 0
+	}
+	return page, nil
+}
+
+// stateChangesInBlock - the changes of the block in the order of StateChangesPage. The previous values are read from
+// the change sets, or the history of HistoryV3, the new ones from the state after the block.
+func (api *ErigonImpl) stateChangesInBlock(ctx context.Context, tx kv.Tx, blockNum uint64, storage bool) ([]*StateChange, error) {
+	historyV3 := api.historyV3(tx)
+	after, err := rpchelper.CreateStateReaderFromBlockNumber(ctx, tx, blockNum, false, 0, api.stateCache, historyV3, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []*StateChange
+	account := func(address common.Address, prev []byte) error {
+		var prevAcc accounts.Account
+		if len(prev) > 0 {
+			decode := prevAcc.DecodeForStorage
+			if historyV3 {
+				decode = func(enc []byte) error { return accounts.DeserialiseV3(&prevAcc, enc) }
+			}
+			if err := decode(prev); err != nil {
+				return err
+			}
+		}
+		acc, err := after.ReadAccountData(address)
+		if err != nil {
+			return err
+		}
+		balance := uint256.NewInt(0)
+		if acc != nil {
+			balance = &acc.Balance
+		}
+		if !prevAcc.Balance.Eq(balance) {
+			changes = append(changes, &StateChange{BlockNumber: hexutil.Uint64(blockNum), Address: address, From: (*hexutil.Big)(prevAcc.Balance.ToBig()), To: (*hexutil.Big)(balance.ToBig())})
+		}
+		return nil
+	}
+	slot := func(address common.Address, incarnation uint64, key common.Hash, prev []byte) error {
+		value, err := after.ReadAccountStorage(address, incarnation, &key)
+		if err != nil {
+			return err
+		}
+		prevValue, newValue := new(uint256.Int).SetBytes(prev), new(uint256.Int).SetBytes(value)
+		if !prevValue.Eq(newValue) {
+			changes = append(changes, &StateChange{BlockNumber: hexutil.Uint64(blockNum), Address: address, Slot: &key, From: (*hexutil.Big)(prevValue.ToBig()), To: (*hexutil.Big)(newValue.ToBig())})
+		}
+		return nil
+	}
+
+	if historyV3 {
+		err = forHistoryChangesInBlock(tx, blockNum, storage, account, func(k, v []byte) error {
+			// the storage keys of the history have no incarnation
+			return slot(common.BytesToAddress(k[:length.Addr]), 0, common.BytesToHash(k[length.Addr:]), v)
+		})
+	} else {
+		err = forChangeSetsInBlock(tx, blockNum, storage, account, func(k, v []byte) error {
+			return slot(common.BytesToAddress(k[:length.Addr]), binary.BigEndian.Uint64(k[length.Addr:]), common.BytesToHash(k[length.Addr+length.Incarnation:]), v)
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if (changes[i].Slot == nil) != (changes[j].Slot == nil) {
+			return changes[i].Slot == nil
+		}
+		if c := bytes.Compare(changes[i].Address[:], changes[j].Address[:]); c != 0 {
+			return c < 0
+		}
+		return changes[i].Slot != nil && bytes.Compare(changes[i].Slot[:], changes[j].Slot[:]) < 0
+	})
+	return changes, nil
+}
+
+// forChangeSetsInBlock calls account for the account changes of the block and, if storage is set, slot for its
+// storage changes: address+incarnation+slot keys. The values are the previous ones.
+func forChangeSetsInBlock(tx kv.Tx, blockNum uint64, storage bool, account func(common.Address, []byte) error, slot func(k, v []byte) error) error {
+	prefix := hexutility.EncodeTs(blockNum)
+	if err := tx.ForPrefix(kv.AccountChangeSet, prefix, func(dbKey, dbValue []byte) error {
+		_, k, v, err := historyv2.Mapper[kv.AccountChangeSet].Decode(dbKey, dbValue)
+		if err != nil {
+			return err
+		}
+		return account(common.BytesToAddress(k), v)
+	}); err != nil || !storage {
+		return err
+	}
+	return tx.ForPrefix(kv.StorageChangeSet, prefix, func(dbKey, dbValue []byte) error {
+		_, k, v, err := historyv2.Mapper[kv.StorageChangeSet].Decode(dbKey, dbValue)
+		if err != nil {
+			return err
+		}
+		return slot(k, v)
+	})
+}
+
+// forHistoryChangesInBlock - forChangeSetsInBlock of HistoryV3, the storage keys are address+slot
+func forHistoryChangesInBlock(tx kv.Tx, blockNum uint64, storage bool, account func(common.Address, []byte) error, slot func(k, v []byte) error) error {
+	minTxNum, err := rawdbv3.TxNums.Min(tx, blockNum)
+	if err != nil {
+		return err
+	}
+	maxTxNum, err := rawdbv3.TxNums.Max(tx, blockNum)
+	if err != nil {
+		return err
+	}
+	ttx := tx.(kv.TemporalTx)
+	it, err := ttx.HistoryRange(kv.AccountsHistory, int(minTxNum), int(maxTxNum)+1, order.Asc, kv.Unlim)
+	if err != nil {
+		return err
+	}
+	for it.HasNext() {
+		k, v, err := it.Next()
+		if err != nil {
+			return err
+		}
+		if err = account(common.BytesToAddress(k), v); err != nil {
+			return err
+		}
+	}
+	if !storage {
+		return nil
+	}
+	it, err = ttx.HistoryRange(kv.StorageHistory, int(minTxNum), int(maxTxNum)+1, order.Asc, kv.Unlim)
+	if err != nil {
+		return err
+	}
+	for it.HasNext() {
+		k, v, err := it.Next()
+		if err != nil {
+			return err
+		}
+		if err = slot(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/erigontech/erigon-lib/common"
 
 	"github.com/erigontech/erigon/core/forkid"
+	"github.com/erigontech/erigon/eth/stagedsync"
 	borfinality "github.com/erigontech/erigon/polygon/bor/finality"
 	"github.com/erigontech/erigon/polygon/bor/finality/whitelist"
 	"github.com/erigontech/erigon/rpc"
@@ -93,3 +94,30 @@ func (api *ErigonImpl) BlockNumber(ctx context.Context, rpcBlockNumPtr *rpc.Bloc
 
 	return hexutil.Uint64(blockNum), nil
 }
+
+// ReconStatus is the progress of the state reconstitution
+type ReconStatus struct {
+	// Checkpoint - the steps loaded into the db, nil when no reconstitution is pending
+	Checkpoint *stagedsync.ReconCheckpoint `json:"checkpoint"`
+	// Live - the reconstitution running in this process, nil when the rpcdaemon is a separate process or it is not running
+	Live *stagedsync.ReconProgress `json:"live"`
+}
+
+// ReconStatus implements erigon_reconStatus. Returns the progress of the state reconstitution
+func (api *ErigonImpl) ReconStatus(ctx context.Context) (*ReconStatus, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	checkpoint, err := stagedsync.ReadReconCheckpoint(tx)
+	if err != nil {
+		return nil, err
+	}
+	status := &ReconStatus{Checkpoint: checkpoint}
+	if live := stagedsync.ReconStatus(); live.Running {
+		status.Live = &live
+	}
+	return status, nil
+}
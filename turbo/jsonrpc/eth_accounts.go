@@ -17,7 +17,9 @@ import (
 	txpool_proto "github.com/erigontech/erigon-lib/gointerfaces/txpool"
 
 	"github.com/erigontech/erigon/common"
+	"github.com/erigontech/erigon/core/types/accounts"
 	"github.com/erigontech/erigon/rpc"
+	"github.com/erigontech/erigon/turbo/trie"
 )
 
 // GetBalance implements eth_getBalance. Returns the balance of an account for a given address.
@@ -230,3 +232,132 @@ func (api *APIImpl) Exist(ctx context.Context, address libcommon.Address, blockN
 
 	return true, nil
 }
+
+// AccountResult - the result of eth_getAccount
+type AccountResult struct {
+	CodeHash    libcommon.Hash `json:"codeHash"`
+	StorageRoot libcommon.Hash `json:"storageRoot"`
+	Balance     *hexutil.Big   `json:"balance"`
+	Nonce       hexutil.Uint64 `json:"nonce"`
+}
+
+// AccountInfoResult - the result of eth_getAccountInfo
+type AccountInfoResult struct {
+	Balance *hexutil.Big     `json:"balance"`
+	Nonce   hexutil.Uint64   `json:"nonce"`
+	Code    hexutility.Bytes `json:"code"`
+}
+
+// GetAccount implements eth_getAccount. Returns the account fields of the state trie at a given block. The storage
+// root of a contract is computed by the trie of the block, so the block is limited like for eth_getProof.
+func (api *APIImpl) GetAccount(ctx context.Context, address libcommon.Address, blockNrOrHash rpc.BlockNumberOrHash) (*AccountResult, error) {
+	tx, err1 := api.db.BeginRo(ctx)
+	if err1 != nil {
+		return nil, fmt.Errorf("getAccount cannot open tx: %w", err1)
+	}
+	defer tx.Rollback()
+
+	// Handle pre-bedrock blocks
+	blockNum, err := api.blockNumberFromBlockNumberOrHash(tx, &blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	chainConfig, err := api.chainConfig(ctx, tx)
+	if err != nil {
+		return nil, fmt.Errorf("read chain config: %v", err)
+	}
+	if chainConfig.IsOptimismPreBedrock(blockNum) {
+		if api.historicalRPCService == nil {
+			return nil, rpc.ErrNoHistoricalFallback
+		}
+		// legacy nodes don't have eth_getAccount, the proof has all of it
+		var proof accounts.AccProofResult
+		if err := api.relayToHistoricalBackend(ctx, &proof, "eth_getProof", address, []libcommon.Hash{}, hexutil.EncodeUint64(blockNum)); err != nil {
+			return nil, fmt.Errorf("historical backend error: %w", err)
+		}
+		return &AccountResult{CodeHash: proof.CodeHash, StorageRoot: proof.StorageHash, Balance: proof.Balance, Nonce: proof.Nonce}, nil
+	}
+
+	reader, err := rpchelper.CreateStateReader(ctx, tx, blockNrOrHash, 0, api.filters, api.stateCache, api.historyV3(tx), "")
+	if err != nil {
+		return nil, err
+	}
+	acc, err := reader.ReadAccountData(address)
+	if err != nil {
+		return nil, fmt.Errorf("cant get account %x: %w", address, err)
+	}
+	if acc == nil {
+		// Special case - non-existent account is an empty one
+		return &AccountResult{CodeHash: trie.EmptyCodeHash, StorageRoot: trie.EmptyRoot, Balance: (*hexutil.Big)(big.NewInt(0))}, nil
+	}
+	result := &AccountResult{CodeHash: acc.CodeHash, StorageRoot: trie.EmptyRoot, Balance: (*hexutil.Big)(acc.Balance.ToBig()), Nonce: hexutil.Uint64(acc.Nonce)}
+	if acc.Incarnation == 0 {
+		// not a contract, no storage
+		return result, nil
+	}
+	if api.historyV3(tx) {
+		return nil, fmt.Errorf("storage root is not supported by Erigon3")
+	}
+	proof, err := api.getProof(ctx, tx, address, nil, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	result.StorageRoot = proof.StorageHash
+	return result, nil
+}
+
+// GetAccountInfo implements eth_getAccountInfo. Returns the balance, nonce and code of an account at a given block,
+// read from the same state.
+func (api *APIImpl) GetAccountInfo(ctx context.Context, address libcommon.Address, blockNrOrHash rpc.BlockNumberOrHash) (*AccountInfoResult, error) {
+	tx, err1 := api.db.BeginRo(ctx)
+	if err1 != nil {
+		return nil, fmt.Errorf("getAccountInfo cannot open tx: %w", err1)
+	}
+	defer tx.Rollback()
+
+	// Handle pre-bedrock blocks
+	blockNum, err := api.blockNumberFromBlockNumberOrHash(tx, &blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	chainConfig, err := api.chainConfig(ctx, tx)
+	if err != nil {
+		return nil, fmt.Errorf("read chain config: %v", err)
+	}
+	if chainConfig.IsOptimismPreBedrock(blockNum) {
+		if api.historicalRPCService == nil {
+			return nil, rpc.ErrNoHistoricalFallback
+		}
+		result := &AccountInfoResult{}
+		if err := api.relayToHistoricalBackend(ctx, &result.Balance, "eth_getBalance", address, hexutil.EncodeUint64(blockNum)); err != nil {
+			return nil, fmt.Errorf("historical backend error: %w", err)
+		}
+		if err := api.relayToHistoricalBackend(ctx, &result.Nonce, "eth_getTransactionCount", address, hexutil.EncodeUint64(blockNum)); err != nil {
+			return nil, fmt.Errorf("historical backend error: %w", err)
+		}
+		if err := api.relayToHistoricalBackend(ctx, &result.Code, "eth_getCode", address, hexutil.EncodeUint64(blockNum)); err != nil {
+			return nil, fmt.Errorf("historical backend error: %w", err)
+		}
+		return result, nil
+	}
+
+	reader, err := rpchelper.CreateStateReader(ctx, tx, blockNrOrHash, 0, api.filters, api.stateCache, api.historyV3(tx), chainConfig.ChainName)
+	if err != nil {
+		return nil, err
+	}
+	acc, err := reader.ReadAccountData(address)
+	if err != nil {
+		return nil, fmt.Errorf("cant get account %x: %w", address, err)
+	}
+	if acc == nil {
+		return &AccountInfoResult{Balance: (*hexutil.Big)(big.NewInt(0)), Code: hexutility.Bytes{}}, nil
+	}
+	code, err := reader.ReadAccountCode(address, acc.Incarnation, acc.CodeHash)
+	if err != nil {
+		return nil, fmt.Errorf("cant get code of account %x: %w", address, err)
+	}
+	if code == nil {
+		code = []byte{}
+	}
+	return &AccountInfoResult{Balance: (*hexutil.Big)(acc.Balance.ToBig()), Nonce: hexutil.Uint64(acc.Nonce), Code: code}, nil
+}
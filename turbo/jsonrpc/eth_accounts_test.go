@@ -12,6 +12,7 @@ import (
 	"github.com/erigontech/erigon-lib/log/v3"
 	"github.com/erigontech/erigon/cmd/rpcdaemon/rpcdaemontest"
 	"github.com/erigontech/erigon/rpc"
+	"github.com/erigontech/erigon/turbo/trie"
 	"github.com/stretchr/testify/require"
 )
 
@@ -291,3 +292,35 @@ func TestGetStorageAtHistoricalRPC(t *testing.T) {
 		})
 	}
 }
+
+func TestGetAccount(t *testing.T) {
+	m, bankAddr, contractAddr := chainWithDeployedContract(t)
+	api := NewEthAPI(newBaseApiForTest(m), m.DB, nil, nil, nil, 5000000, 1e18, 100_000, false, 100_000, 128, log.New())
+	ctx := context.Background()
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+
+	for _, addr := range []libcommon.Address{bankAddr, contractAddr} {
+		account, err := api.GetAccount(ctx, addr, latest)
+		require.NoError(t, err)
+		proof, err := api.GetProof(ctx, addr, nil, latest)
+		require.NoError(t, err)
+		require.Equal(t, proof.CodeHash, account.CodeHash)
+		require.Equal(t, proof.StorageHash, account.StorageRoot)
+		require.Equal(t, proof.Balance, account.Balance)
+		require.Equal(t, proof.Nonce, account.Nonce)
+
+		info, err := api.GetAccountInfo(ctx, addr, latest)
+		require.NoError(t, err)
+		code, err := api.GetCode(ctx, addr, latest)
+		require.NoError(t, err)
+		require.Equal(t, code, info.Code)
+		require.Equal(t, account.Balance, info.Balance)
+		require.Equal(t, account.Nonce, info.Nonce)
+	}
+
+	account, err := api.GetAccount(ctx, libcommon.HexToAddress("0xdeaddeaddeaddeaddeaddeaddeaddeaddeaddead0"), latest)
+	require.NoError(t, err)
+	require.Equal(t, trie.EmptyCodeHash, account.CodeHash)
+	require.Equal(t, trie.EmptyRoot, account.StorageRoot)
+	require.Zero(t, account.Balance.ToInt().Sign())
+}
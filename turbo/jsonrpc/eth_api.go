@@ -3,6 +3,7 @@ package jsonrpc
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
 	"sync"
@@ -33,8 +34,10 @@ import (
 	"github.com/erigontech/erigon/core/types"
 	"github.com/erigontech/erigon/core/types/accounts"
 	ethFilters "github.com/erigontech/erigon/eth/filters"
+	"github.com/erigontech/erigon/eth/stagedsync/stages"
 	"github.com/erigontech/erigon/ethdb/prune"
 	"github.com/erigontech/erigon/rpc"
+	"github.com/erigontech/erigon/rpc/rpccfg"
 	ethapi2 "github.com/erigontech/erigon/turbo/adapter/ethapi"
 	"github.com/erigontech/erigon/turbo/rpchelper"
 	"github.com/erigontech/erigon/turbo/services"
@@ -82,6 +85,8 @@ type EthAPI interface {
 	GetTransactionCount(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Uint64, error)
 	GetStorageAt(ctx context.Context, address common.Address, index string, blockNrOrHash rpc.BlockNumberOrHash) (string, error)
 	GetCode(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (hexutility.Bytes, error)
+	GetAccount(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*AccountResult, error)
+	GetAccountInfo(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*AccountInfoResult, error)
 
 	// System related (see ./eth_system.go)
 	BlockNumber(ctx context.Context) (hexutil.Uint64, error)
@@ -94,6 +99,7 @@ type EthAPI interface {
 	Call(ctx context.Context, args ethapi2.CallArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *ethapi2.StateOverrides) (hexutility.Bytes, error)
 	EstimateGas(ctx context.Context, argsOrNil *ethapi2.CallArgs, blockNrOrHash *rpc.BlockNumberOrHash, overrides *ethapi2.StateOverrides) (hexutil.Uint64, error)
 	SendRawTransaction(ctx context.Context, encodedTx hexutility.Bytes) (common.Hash, error)
+	SendRawTransactionConditional(ctx context.Context, encodedTx hexutility.Bytes, cond TransactionConditional) (common.Hash, error)
 	SendTransaction(_ context.Context, txObject interface{}) (common.Hash, error)
 	Sign(ctx context.Context, _ common.Address, _ hexutility.Bytes) (hexutility.Bytes, error)
 	SignTransaction(_ context.Context, txObject interface{}) (common.Hash, error)
@@ -126,12 +132,14 @@ type BaseAPI struct {
 	_agg         *libstate.Aggregator
 	_engine      consensus.EngineReader
 
-	evmCallTimeout time.Duration
-	dirs           datadir.Dirs
+	evmCallTimeout     time.Duration
+	dirs               datadir.Dirs
+	txnNotIndexedError bool // see txnNotIndexed
 
 	// Optimism specific field
-	seqRPCService        *rpc.Client
+	sequencer            *sequencerBackend
 	historicalRPCService *rpc.Client
+	archiveRPC           *archiveBackend
 }
 
 func NewBaseApi(f *rpchelper.Filters, stateCache kvcache.Cache, blockReader services.FullBlockReader, agg *libstate.Aggregator, singleNodeMode bool, evmCallTimeout time.Duration, engine consensus.EngineReader, dirs datadir.Dirs, seqRPCService *rpc.Client, historicalRPCService *rpc.Client) *BaseAPI {
@@ -164,7 +172,7 @@ func NewBaseApi(f *rpchelper.Filters, stateCache kvcache.Cache, blockReader serv
 		evmCallTimeout:       evmCallTimeout,
 		_engine:              engine,
 		dirs:                 dirs,
-		seqRPCService:        seqRPCService,
+		sequencer:            newSequencerBackend(seqRPCService, rpccfg.DefaultSequencerRetries, rpccfg.DefaultSequencerRetryBackoff, false),
 		historicalRPCService: historicalRPCService,
 	}
 }
@@ -179,6 +187,14 @@ func (api *BaseAPI) engine() consensus.EngineReader {
 }
 
 // nolint:unused
+// methodGasCap - the lower of the global gas cap and the gas cap of the served method (--rpc.methods.gascap), 0 - no cap
+func methodGasCap(ctx context.Context, gasCap uint64) uint64 {
+	if methodCap := rpc.GasCapFromContext(ctx); methodCap > 0 && (gasCap == 0 || methodCap < gasCap) {
+		return methodCap
+	}
+	return gasCap
+}
+
 func (api *BaseAPI) genesis(ctx context.Context, tx kv.Tx) (*types.Block, error) {
 	_, genesis, err := api.chainConfigWithGenesis(ctx, tx)
 	return genesis, err
@@ -188,6 +204,10 @@ func (api *BaseAPI) txnLookup(ctx context.Context, tx kv.Tx, txnHash common.Hash
 	return api._txnReader.TxnLookup(ctx, tx, txnHash)
 }
 
+func (api *BaseAPI) txnLookupWithIndex(ctx context.Context, tx kv.Tx, txnHash common.Hash) (uint64, int, bool, error) {
+	return api._txnReader.TxnLookupWithIndex(ctx, tx, txnHash)
+}
+
 func (api *BaseAPI) blockByNumberWithSenders(ctx context.Context, tx kv.Tx, number uint64) (*types.Block, error) {
 	hash, hashErr := api._blockReader.CanonicalHash(ctx, tx, number)
 	if hashErr != nil {
@@ -212,7 +232,16 @@ func (api *BaseAPI) blockByHashWithSenders(ctx context.Context, tx kv.Tx, hash c
 
 func (api *BaseAPI) blockNumberFromBlockNumberOrHash(tx kv.Tx, bnh *rpc.BlockNumberOrHash) (uint64, error) {
 	if number, ok := bnh.Number(); ok {
-		return uint64(number.Int64()), nil
+		if number >= 0 {
+			return uint64(number.Int64()), nil
+		}
+		// tags: `safe` and `finalized` are resolved by the last forkchoice state and fail if it's unset,
+		// so they never silently fall back to `latest`
+		blockNum, _, _, err := rpchelper.GetBlockNumber(*bnh, tx, api.filters)
+		if err != nil {
+			return 0, err
+		}
+		return blockNum, nil
 	}
 	if hash, ok := bnh.Hash(); ok {
 		number := rawdb.ReadHeaderNumber(tx, hash)
@@ -319,29 +348,71 @@ func (api *BaseAPI) headerByRPCNumber(ctx context.Context, number rpc.BlockNumbe
 // history for blocks that have been pruned away giving nonce too low errors
 // etc. as red herrings
 func (api *BaseAPI) checkPruneHistory(tx kv.Tx, block uint64) error {
-	p, err := api.pruneMode(tx)
+	pruned, err := api.historyPruned(tx, block)
 	if err != nil {
 		return err
 	}
-	if p == nil {
+	if pruned {
+		return fmt.Errorf("history has been pruned for this block")
+	}
+	return nil
+}
+
+// historyPruned - true if state history of the block is pruned away
+func (api *BaseAPI) historyPruned(tx kv.Tx, block uint64) (bool, error) {
+	p, err := api.pruneMode(tx)
+	if err != nil {
+		return false, err
+	}
+	if p == nil || !p.History.Enabled() {
 		// no prune info found
-		return nil
+		return false, nil
 	}
-	if p.History.Enabled() {
-		latest, _, _, err := rpchelper.GetBlockNumber(rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), tx, api.filters)
-		if err != nil {
-			return err
-		}
-		if latest <= 1 {
-			return nil
-		}
-		prunedTo := p.History.PruneTo(latest)
-		if block < prunedTo {
-			return fmt.Errorf("history has been pruned for this block")
-		}
+	latest, _, _, err := rpchelper.GetBlockNumber(rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), tx, api.filters)
+	if err != nil {
+		return false, err
+	}
+	if latest <= 1 {
+		return false, nil
 	}
+	return block < p.History.PruneTo(latest), nil
+}
 
-	return nil
+// relayToArchive - true if the request at the block must be forwarded to archive node: its state history is pruned
+func (api *BaseAPI) relayToArchive(tx kv.Tx, block uint64) (bool, error) {
+	if api.archiveRPC == nil {
+		return false, nil
+	}
+	return api.historyPruned(tx, block)
+}
+
+// ErrTxnNotIndexed - the transaction is not found, but it may be in a block not indexed by TxLookup stage
+var ErrTxnNotIndexed = errors.New("transaction not indexed")
+
+// txnNotIndexed - error for a transaction not found by txnLookup if TxLookup stage doesn't index all the blocks (see
+// --prune.t.before, e.g. from the Bedrock block), so it can't be told unknown. Transactions of frozen blocks are
+// found by the indexes of the snapshots. The hash alone doesn't tell the block, so unknown and not yet mined
+// transactions would fail too: it's opt-in by --rpc.txn-not-indexed-error, otherwise they are null as usual.
+func (api *BaseAPI) txnNotIndexed(tx kv.Tx, txnHash common.Hash) error {
+	if !api.txnNotIndexedError {
+		return nil
+	}
+	p, err := api.pruneMode(tx)
+	if err != nil {
+		return err
+	}
+	if p == nil || !p.TxIndex.Enabled() {
+		return nil
+	}
+	progress, err := stages.GetStageProgress(tx, stages.TxLookup)
+	if err != nil {
+		return err
+	}
+	pruneTo := p.TxIndex.PruneTo(progress)
+	if pruneTo == 0 || pruneTo <= api._blockReader.FrozenBlocks() {
+		return nil
+	}
+	return fmt.Errorf("%w: %x, transactions before block %d are not indexed by this node", ErrTxnNotIndexed, txnHash, pruneTo+1)
 }
 
 func (api *BaseAPI) pruneMode(tx kv.Tx) (*prune.Mode, error) {
@@ -357,7 +428,7 @@ func (api *BaseAPI) pruneMode(tx kv.Tx) (*prune.Mode, error) {
 
 	api._pruneMode.Store(&mode)
 
-	return p, nil
+	return &mode, nil
 }
 
 // APIImpl is implementation of the EthAPI interface based on remote Db access
@@ -436,6 +507,8 @@ type RPCTransaction struct {
 	IsSystemTx *bool        `json:"isSystemTx,omitempty"`
 	// deposit-tx post-Canyon only
 	DepositReceiptVersion *hexutil.Uint64 `json:"depositReceiptVersion,omitempty"`
+	// pre-bedrock Boba only: Turing (hybrid compute) response carried in calldata
+	Turing hexutility.Bytes `json:"turing,omitempty"`
 }
 
 // NewRPCTransaction returns a transaction that will serialize to the RPC
@@ -482,9 +555,9 @@ func NewRPCTransaction(txn types.Transaction, blockHash common.Hash, blockNumber
 			chainId.Set(txn.GetChainID())
 			result.ChainID = (*hexutil.Big)(chainId.ToBig())
 			result.YParity = (*hexutil.Big)(v.ToBig())
+			acl := txn.GetAccessList()
+			result.Accesses = &acl
 		}
-		acl := txn.GetAccessList()
-		result.Accesses = &acl
 
 		if txn.Type() == types.AccessListTxType {
 			result.GasPrice = (*hexutil.Big)(txn.GetPrice().ToBig())
@@ -510,7 +583,8 @@ func NewRPCTransaction(txn types.Transaction, blockHash common.Hash, blockNumber
 
 		if txn.Type() == types.DepositTxType {
 			depositTx := txn.(*types.DepositTx)
-			if depositTx.Mint != nil {
+			// op-geth decodes a zero mint as nil, and omits it
+			if depositTx.Mint != nil && !depositTx.Mint.IsZero() {
 				result.Mint = (*hexutil.Big)(depositTx.Mint.ToBig())
 			}
 			result.ChainID = nil
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/big"
+	"os"
+	"path/filepath"
 	"testing"
 
 	libcommon "github.com/erigontech/erigon-lib/common"
@@ -18,10 +20,14 @@ import (
 
 	"github.com/erigontech/erigon-lib/kv/kvcache"
 	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/core/rawdb"
 	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/eth/stagedsync/stages"
+	"github.com/erigontech/erigon/ethdb/prune"
 	"github.com/erigontech/erigon/rpc"
 	"github.com/erigontech/erigon/rpc/rpccfg"
 	"github.com/erigontech/erigon/turbo/adapter/ethapi"
+	"github.com/erigontech/erigon/turbo/rpchelper"
 	"github.com/erigontech/erigon/turbo/stages/mock"
 
 	"github.com/erigontech/erigon-lib/log/v3"
@@ -57,6 +63,89 @@ func TestGetBalanceChangesInBlock(t *testing.T) {
 	}
 }
 
+// balance changes of the blocks and balances at the blocks are read from the history in different ways
+func TestGetBalanceChangesInBlockMatchGetBalance(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	ctx := context.Background()
+	erigonAPI := NewErigonAPI(newBaseApiForTest(m), m.DB, nil)
+	ethAPI := NewEthAPI(newBaseApiForTest(m), m.DB, nil, nil, nil, 5000000, 1e18, 100_000, false, 100_000, 128, log.New())
+
+	latest, err := ethAPI.BlockNumber(ctx)
+	require.NoError(t, err)
+	var changed int
+	for n := rpc.BlockNumber(1); n <= rpc.BlockNumber(latest); n++ {
+		balances, err := erigonAPI.GetBalanceChangesInBlock(ctx, rpc.BlockNumberOrHashWithNumber(n))
+		require.NoError(t, err)
+		for addr, balance := range balances {
+			after, err := ethAPI.GetBalance(ctx, addr, rpc.BlockNumberOrHashWithNumber(n))
+			require.NoError(t, err)
+			require.Zero(t, balance.ToInt().Cmp(after.ToInt()), "balance of %x at block %d", addr, n)
+			before, err := ethAPI.GetBalance(ctx, addr, rpc.BlockNumberOrHashWithNumber(n-1))
+			require.NoError(t, err)
+			require.NotZero(t, before.ToInt().Cmp(after.ToInt()), "balance of %x doesn't change at block %d", addr, n)
+			changed++
+		}
+	}
+	require.NotZero(t, changed)
+}
+
+// the changes of a range match the changes of its blocks, whatever the page size
+func TestGetBalanceChangesInRange(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	ctx := context.Background()
+	api := NewErigonAPI(newBaseApiForTest(m), m.DB, nil)
+	ethAPI := NewEthAPI(newBaseApiForTest(m), m.DB, nil, nil, nil, 5000000, 1e18, 100_000, false, 100_000, 128, log.New())
+	latest, err := ethAPI.BlockNumber(ctx)
+	require.NoError(t, err)
+
+	all, err := api.GetBalanceChangesInRange(ctx, 1, rpc.LatestBlockNumber, &StateChangesOptions{Storage: true})
+	require.NoError(t, err)
+	require.Nil(t, all.Next)
+	var balances, slots int
+	for _, change := range all.Changes {
+		if change.Slot == nil {
+			balances++
+			inBlock, err := api.GetBalanceChangesInBlock(ctx, rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(change.BlockNumber)))
+			require.NoError(t, err)
+			require.Zero(t, inBlock[change.Address].ToInt().Cmp(change.To.ToInt()), "balance of %x at block %d", change.Address, change.BlockNumber)
+			continue
+		}
+		slots++
+		value, err := ethAPI.GetStorageAt(ctx, change.Address, change.Slot.Hex(), rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(change.BlockNumber)))
+		require.NoError(t, err)
+		require.Zero(t, change.To.ToInt().Cmp(common.HexToHash(value).Big()), "slot %x of %x at block %d", *change.Slot, change.Address, change.BlockNumber)
+	}
+	require.NotZero(t, balances)
+	require.NotZero(t, slots)
+
+	var paged []*StateChange
+	options := &StateChangesOptions{Storage: true, PageSize: 3}
+	for {
+		page, err := api.GetBalanceChangesInRange(ctx, 1, rpc.BlockNumber(latest), options)
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(page.Changes), 3)
+		paged = append(paged, page.Changes...)
+		if page.Next == nil {
+			break
+		}
+		options.Cursor = page.Next
+	}
+	require.Equal(t, all.Changes, paged)
+
+	onlyBalances, err := api.GetBalanceChangesInRange(ctx, 1, rpc.LatestBlockNumber, nil)
+	require.NoError(t, err)
+	require.Len(t, onlyBalances.Changes, balances)
+
+	_, err = api.GetBalanceChangesInRange(ctx, 2, 1, nil)
+	require.Error(t, err)
+
+	// the history of the range is pruned and there is no archive node to forward it to
+	pruned := newBaseApiForTest(m)
+	pruned._pruneMode.Store(&prune.Mode{History: prune.Distance(1)})
+	_, err = NewErigonAPI(pruned, m.DB, nil).GetBalanceChangesInRange(ctx, 1, rpc.LatestBlockNumber, nil)
+	require.ErrorContains(t, err, "pruned")
+}
+
 func TestGetTransactionReceipt(t *testing.T) {
 	m, _, _ := rpcdaemontest.CreateTestSentry(t)
 	db := m.DB
@@ -69,6 +158,30 @@ func TestGetTransactionReceipt(t *testing.T) {
 	}
 }
 
+func TestTxnNotIndexed(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	api := newBaseApiForTest(m)
+	tx, err := m.DB.BeginRw(context.Background())
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	api.txnNotIndexedError = true
+	// all blocks are indexed
+	require.NoError(t, api.txnNotIndexed(tx, common.Hash{}))
+
+	api._pruneMode.Store(&prune.Mode{TxIndex: prune.Before(5)})
+	require.NoError(t, stages.SaveStageProgress(tx, stages.TxLookup, 10))
+
+	// not opted in: unknown transactions are null
+	api.txnNotIndexedError = false
+	require.NoError(t, api.txnNotIndexed(tx, common.Hash{}))
+
+	api.txnNotIndexedError = true
+	err = api.txnNotIndexed(tx, common.Hash{})
+	require.ErrorIs(t, err, ErrTxnNotIndexed)
+	require.ErrorContains(t, err, "before block 5")
+}
+
 func TestGetTransactionReceiptUnprotected(t *testing.T) {
 	m, _, _ := rpcdaemontest.CreateTestSentry(t)
 	api := NewEthAPI(newBaseApiForTest(m), m.DB, nil, nil, nil, 5000000, 1e18, 100_000, false, 100_000, 128, log.New())
@@ -94,6 +207,37 @@ func TestGetStorageAt_ByBlockNumber_WithRequireCanonicalDefault(t *testing.T) {
 	assert.Equal(common.HexToHash("0x0").String(), result)
 }
 
+func TestGetBalance_WithSafeTag_NoSafeBlockInDb(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	api := NewEthAPI(newBaseApiForTest(m), m.DB, nil, nil, nil, 5000000, 1e18, 100_000, false, 100_000, 128, log.New())
+	addr := common.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+
+	_, err := api.GetBalance(context.Background(), addr, rpc.BlockNumberOrHashWithNumber(rpc.SafeBlockNumber))
+	require.ErrorIs(t, err, rpchelper.UnknownBlockError)
+	_, err = api.GetStorageAt(context.Background(), addr, "0x0", rpc.BlockNumberOrHashWithNumber(rpc.FinalizedBlockNumber))
+	require.ErrorIs(t, err, rpchelper.UnknownBlockError)
+}
+
+func TestGetBalance_WithFinalizedTag_WithFinalizedBlockInDb(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	ctx := context.Background()
+	api := NewEthAPI(newBaseApiForTest(m), m.DB, nil, nil, nil, 5000000, 1e18, 100_000, false, 100_000, 128, log.New())
+	addr := common.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+
+	tx, err := m.DB.BeginRw(ctx)
+	require.NoError(t, err)
+	finalizedHash, err := rawdb.ReadCanonicalHash(tx, 1)
+	require.NoError(t, err)
+	rawdb.WriteForkchoiceFinalized(tx, finalizedHash)
+	require.NoError(t, tx.Commit())
+
+	expected, err := api.GetBalance(ctx, addr, rpc.BlockNumberOrHashWithNumber(1))
+	require.NoError(t, err)
+	result, err := api.GetBalance(ctx, addr, rpc.BlockNumberOrHashWithNumber(rpc.FinalizedBlockNumber))
+	require.NoError(t, err)
+	require.Equal(t, expected, result)
+}
+
 func TestGetStorageAt_ByBlockHash_WithRequireCanonicalDefault(t *testing.T) {
 	assert := assert.New(t)
 	m, _, _ := rpcdaemontest.CreateTestSentry(t)
@@ -407,3 +551,68 @@ func TestUnmarshalRpcDepositTx(t *testing.T) {
 		})
 	}
 }
+
+// TestDepositTxJSON - deposit txs are marshaled with the fields of op-geth's RPCTransaction. testdata/deposit-json is
+// hand-written after the marshaling code of op-geth, it isn't captured from an op-geth node
+func TestDepositTxJSON(t *testing.T) {
+	blockHash := common.HexToHash("0x5e7c9a2f0d4b8e1c6a3f9d2b7e0c4a8f1d6b3e9c2a7f0d5b8e1c4a9f3d6b2e70")
+	l1Block := common.HexToAddress("0x4200000000000000000000000000000000000015")
+	bridge := common.HexToAddress("0x4200000000000000000000000000000000000010")
+	depositor := common.HexToAddress("0xdeaddeaddeaddeaddeaddeaddeaddeaddead0001")
+	nonce, nonce2 := uint64(435), uint64(434)
+	version := types.CanyonDepositReceiptVersion
+	tests := []struct {
+		golden  string
+		tx      *types.DepositTx
+		index   uint64
+		receipt *types.Receipt
+	}{
+		{
+			golden: "deposit_tx.json",
+			tx: &types.DepositTx{
+				SourceHash: common.HexToHash("0x3f8b4a2c0e9d5b1f7a6c8e2d4b0f9a1c3e5d7b9f2a4c6e8d0b1f3a5c7e9d2b4f"),
+				From:       common.HexToAddress("0x976ea74026e726554db657fa54763abd0c3a0aa9"),
+				To:         &bridge,
+				Mint:       uint256.NewInt(10_000_000_000_000_000),
+				Value:      uint256.NewInt(10_000_000_000_000_000),
+				Gas:        250_000,
+			},
+			index:   1,
+			receipt: &types.Receipt{DepositNonce: &nonce, DepositReceiptVersion: &version},
+		},
+		{
+			golden: "deposit_tx_zero_mint.json",
+			tx: &types.DepositTx{
+				SourceHash: common.HexToHash("0xa1d0b7c8e5f2a3b4c9d6e7f0a1b2c3d4e5f6a7b8c9d0e1f2a3b4c5d6e7f8a9b0"),
+				From:       depositor,
+				To:         &l1Block,
+				Mint:       uint256.NewInt(0),
+				Value:      uint256.NewInt(0),
+				Gas:        1_000_000,
+				Data:       common.FromHex("0x440a5e20"),
+			},
+			receipt: &types.Receipt{DepositNonce: &nonce2},
+		},
+		{
+			golden: "deposit_tx_system.json",
+			tx: &types.DepositTx{
+				SourceHash:          common.HexToHash("0x5c2e1b9d8a7f6e3d4c0b2a1f9e8d7c6b5a4f3e2d1c0b9a8f7e6d5c4b3a2f1e0d"),
+				From:                depositor,
+				To:                  &l1Block,
+				Value:               uint256.NewInt(0),
+				Gas:                 150_000_000,
+				IsSystemTransaction: true,
+				Data:                common.FromHex("0x015d8eb9"),
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.golden, func(t *testing.T) {
+			golden, err := os.ReadFile(filepath.Join("testdata", "deposit-json", test.golden))
+			require.NoError(t, err)
+			got, err := json.Marshal(NewRPCTransaction(test.tx, blockHash, 31250, test.index, big.NewInt(1_000_000_000), test.receipt))
+			require.NoError(t, err)
+			require.JSONEq(t, string(golden), string(got))
+		})
+	}
+}
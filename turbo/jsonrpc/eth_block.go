@@ -247,6 +247,9 @@ func (api *APIImpl) GetBlockByNumber(ctx context.Context, number rpc.BlockNumber
 	}
 
 	response, err := ethapi.RPCMarshalBlockEx(b, true, fullTx, borTx, borTxHash, additionalFields, receipts)
+	if err == nil && fullTx && chainConfig.IsBobaLegacyBlock(b.NumberU64()) {
+		bobaLegacyFullTransactions(chainConfig, response, b, receipts)
+	}
 	if err == nil && number == rpc.PendingBlockNumber && chainConfig.Optimism == nil { // don't remove info if optimism
 		// Pending blocks need to nil out a few fields
 		for _, field := range []string{"hash", "nonce", "miner"} {
@@ -313,6 +316,9 @@ func (api *APIImpl) GetBlockByHash(ctx context.Context, numberOrHash rpc.BlockNu
 	}
 
 	response, err := ethapi.RPCMarshalBlockEx(block, true, fullTx, borTx, borTxHash, additionalFields, receipts)
+	if err == nil && fullTx && chainConfig.IsBobaLegacyBlock(number) {
+		bobaLegacyFullTransactions(chainConfig, response, block, receipts)
+	}
 
 	if chainConfig.Bor != nil {
 		borConfig := chainConfig.Bor.(*borcfg.BorConfig)
@@ -66,11 +66,24 @@ func (api *APIImpl) Call(ctx context.Context, args ethapi2.CallArgs, blockNrOrHa
 		}
 		return result, nil
 	}
+	// Handle blocks with pruned state history
+	relay, err := api.relayToArchive(tx, blockNum)
+	if err != nil {
+		return nil, err
+	}
+	if relay {
+		var result hexutility.Bytes
+		if err := api.archiveRPC.call(ctx, &result, "eth_call", args, hexutil.EncodeUint64(blockNum), overrides); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
 
 	engine := api.engine()
 
+	gasCap := methodGasCap(ctx, api.GasCap)
 	if args.Gas == nil || uint64(*args.Gas) == 0 {
-		args.Gas = (*hexutil.Uint64)(&api.GasCap)
+		args.Gas = (*hexutil.Uint64)(&gasCap)
 	}
 
 	blockNumber, hash, _, err := rpchelper.GetCanonicalBlockNumber(blockNrOrHash, tx, api.filters) // DoCall cannot be executed on non-canonical blocks
@@ -90,7 +103,7 @@ func (api *APIImpl) Call(ctx context.Context, args ethapi2.CallArgs, blockNrOrHa
 		return nil, err
 	}
 	header := block.HeaderNoCopy()
-	result, err := transactions.DoCall(ctx, engine, args, tx, blockNrOrHash, header, overrides, api.GasCap, chainConfig, stateReader, api._blockReader, api.evmCallTimeout)
+	result, err := transactions.DoCall(ctx, engine, args, tx, blockNrOrHash, header, overrides, gasCap, chainConfig, stateReader, api._blockReader, api.evmCallTimeout)
 	if err != nil {
 		return nil, err
 	}
@@ -149,6 +162,7 @@ func (api *APIImpl) EstimateGas(ctx context.Context, argsOrNil *ethapi2.CallArgs
 		lo     = params.TxGas - 1
 		hi     uint64
 		gasCap uint64
+		maxGas = methodGasCap(ctx, api.GasCap)
 	)
 	// Use zero address if sender unspecified.
 	if args.From == nil {
@@ -179,6 +193,18 @@ func (api *APIImpl) EstimateGas(ctx context.Context, argsOrNil *ethapi2.CallArgs
 		}
 		return result, nil
 	}
+	// Handle blocks with pruned state history
+	relay, err := api.relayToArchive(dbtx, blockNum)
+	if err != nil {
+		return 0, err
+	}
+	if relay {
+		var result hexutil.Uint64
+		if err := api.archiveRPC.call(ctx, &result, "eth_estimateGas", args, hexutil.EncodeUint64(blockNum), overrides); err != nil {
+			return 0, err
+		}
+		return result, nil
+	}
 
 	// Determine the highest gas limit can be used during the estimation.
 	if args.Gas != nil && uint64(*args.Gas) >= params.TxGas {
@@ -207,6 +233,33 @@ func (api *APIImpl) EstimateGas(ctx context.Context, argsOrNil *ethapi2.CallArgs
 		hi = h.GasLimit
 	}
 
+	// state is taken from requested block (safe/finalized tags are resolved by forkchoice state), or latest if not specified
+	stateNrOrHash := latestNumOrHash
+	if blockNrOrHash != nil {
+		stateNrOrHash = *blockNrOrHash
+	}
+	latestCanBlockNumber, latestCanHash, isLatest, err := rpchelper.GetCanonicalBlockNumber(stateNrOrHash, dbtx, api.filters) // DoCall cannot be executed on non-canonical blocks
+	if err != nil {
+		return 0, err
+	}
+
+	// try and get the block from the lru cache first then try DB before failing
+	block := api.tryBlockFromLru(latestCanHash)
+	if block == nil {
+		block, err = api.blockWithSenders(ctx, dbtx, latestCanHash, latestCanBlockNumber)
+		if err != nil {
+			return 0, err
+		}
+	}
+	if block == nil {
+		return 0, fmt.Errorf("could not find block %d in cache or db", latestCanBlockNumber)
+	}
+
+	stateReader, err := rpchelper.CreateStateReaderFromBlockNumber(ctx, dbtx, latestCanBlockNumber, isLatest, 0, api.stateCache, api.historyV3(dbtx), chainConfig.ChainName)
+	if err != nil {
+		return 0, err
+	}
+
 	var feeCap *big.Int
 	if args.GasPrice != nil && (args.MaxFeePerGas != nil || args.MaxPriorityFeePerGas != nil) {
 		return 0, errors.New("both gasPrice and (maxFeePerGas or maxPriorityFeePerGas) specified")
@@ -219,17 +272,8 @@ func (api *APIImpl) EstimateGas(ctx context.Context, argsOrNil *ethapi2.CallArgs
 	}
 	// Recap the highest gas limit with account's available balance.
 	if feeCap.Sign() != 0 {
-		cacheView, err := api.stateCache.View(ctx, dbtx)
-		if err != nil {
-			return 0, err
-		}
-		stateReader := state.NewCachedReader2(cacheView, dbtx)
-		state := state.New(stateReader)
-		if state == nil {
-			return 0, fmt.Errorf("can't get the current state")
-		}
-
-		balance := state.GetBalance(*args.From) // from can't be nil
+		// the balance at the block of the estimation, not the latest one
+		balance := state.New(stateReader).GetBalance(*args.From) // from can't be nil
 		available := balance.ToBig()
 		if args.Value != nil {
 			if args.Value.ToInt().Cmp(available) >= 0 {
@@ -252,38 +296,16 @@ func (api *APIImpl) EstimateGas(ctx context.Context, argsOrNil *ethapi2.CallArgs
 	}
 
 	// Recap the highest gas allowance with specified gascap.
-	if hi > api.GasCap {
-		log.Warn("Caller gas above allowance, capping", "requested", hi, "cap", api.GasCap)
-		hi = api.GasCap
+	if hi > maxGas {
+		log.Warn("Caller gas above allowance, capping", "requested", hi, "cap", maxGas)
+		hi = maxGas
 	}
 	gasCap = hi
 
 	engine := api.engine()
-
-	latestCanBlockNumber, latestCanHash, isLatest, err := rpchelper.GetCanonicalBlockNumber(latestNumOrHash, dbtx, api.filters) // DoCall cannot be executed on non-canonical blocks
-	if err != nil {
-		return 0, err
-	}
-
-	// try and get the block from the lru cache first then try DB before failing
-	block := api.tryBlockFromLru(latestCanHash)
-	if block == nil {
-		block, err = api.blockWithSenders(ctx, dbtx, latestCanHash, latestCanBlockNumber)
-		if err != nil {
-			return 0, err
-		}
-	}
-	if block == nil {
-		return 0, fmt.Errorf("could not find latest block in cache or db")
-	}
-
-	stateReader, err := rpchelper.CreateStateReaderFromBlockNumber(ctx, dbtx, latestCanBlockNumber, isLatest, 0, api.stateCache, api.historyV3(dbtx), chainConfig.ChainName)
-	if err != nil {
-		return 0, err
-	}
 	header := block.HeaderNoCopy()
 
-	caller, err := transactions.NewReusableCaller(engine, stateReader, overrides, header, args, api.GasCap, latestNumOrHash, dbtx, api._blockReader, chainConfig, api.evmCallTimeout)
+	caller, err := transactions.NewReusableCaller(engine, stateReader, overrides, header, args, maxGas, stateNrOrHash, dbtx, api._blockReader, chainConfig, api.evmCallTimeout)
 	if err != nil {
 		return 0, err
 	}
@@ -381,7 +403,27 @@ func (api *APIImpl) GetProof(ctx context.Context, address libcommon.Address, sto
 		}
 		return &result, nil
 	}
+	return api.getProof(ctx, tx, address, storageKeys, blockNrOrHash)
+}
+
+// getProof - the proof of eth_getProof, computed by the trie of the block
+func (api *APIImpl) getProof(ctx context.Context, tx kv.Tx, address libcommon.Address, storageKeys []libcommon.Hash, blockNrOrHash rpc.BlockNumberOrHash) (*accounts.AccProofResult, error) {
+	proofs, err := api.getProofs(ctx, tx, []ProofRequest{{Address: address, StorageKeys: storageKeys}}, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	return proofs[0], nil
+}
 
+// ProofRequest - an account and its storage keys to prove
+type ProofRequest struct {
+	Address     libcommon.Address `json:"address"`
+	StorageKeys []libcommon.Hash  `json:"storageKeys"`
+}
+
+// getProofs - the proofs of the accounts, in the order of requests, collected by one computation of the trie of
+// the block
+func (api *APIImpl) getProofs(ctx context.Context, tx kv.Tx, requests []ProofRequest, blockNrOrHash rpc.BlockNumberOrHash) ([]*accounts.AccProofResult, error) {
 	blockNr, _, _, err := rpchelper.GetBlockNumber(blockNrOrHash, tx, api.filters)
 	if err != nil {
 		return nil, err
@@ -408,7 +450,13 @@ func (api *APIImpl) GetProof(ctx context.Context, address libcommon.Address, sto
 		if latestBlock-blockNr > uint64(api.MaxGetProofRewindBlockCount) {
 			return nil, fmt.Errorf("requested block is too old, block must be within %d blocks of the head block number (currently %d)", uint64(api.MaxGetProofRewindBlockCount), latestBlock)
 		}
-		batch := membatchwithdb.NewMemoryBatch(tx, api.dirs.Tmp, api.logger)
+		var batch kv.RwTx
+		if ttx, ok := tx.(kv.TemporalTx); ok {
+			// HistoryV3 unwinds read the history of the temporal tx
+			batch = membatchwithdb.NewTemporalMemoryBatch(ttx, api.dirs.Tmp, api.logger)
+		} else {
+			batch = membatchwithdb.NewMemoryBatch(tx, api.dirs.Tmp, api.logger)
+		}
 		defer batch.Rollback()
 
 		unwindState := &stagedsync.UnwindState{UnwindPoint: blockNr}
@@ -433,19 +481,25 @@ func (api *APIImpl) GetProof(ctx context.Context, address libcommon.Address, sto
 	if err != nil {
 		return nil, err
 	}
-	a, err := reader.ReadAccountData(address)
-	if err != nil {
-		return nil, err
-	}
-	if a == nil {
-		a = &accounts.Account{}
-	}
-	pr, err := trie.NewProofRetainer(address, a, storageKeys, rl)
-	if err != nil {
-		return nil, err
+	retainers := make([]*trie.ProofRetainer, len(requests))
+	for i, req := range requests {
+		a, err := reader.ReadAccountData(req.Address)
+		if err != nil {
+			return nil, err
+		}
+		if a == nil {
+			a = &accounts.Account{}
+		}
+		if retainers[i], err = trie.NewProofRetainer(req.Address, a, req.StorageKeys, rl); err != nil {
+			return nil, err
+		}
 	}
 
-	loader.SetProofRetainer(pr)
+	if len(retainers) == 1 {
+		loader.SetProofRetainer(retainers[0])
+	} else {
+		loader.SetMultiProofRetainer(trie.NewMultiProofRetainer(rl, retainers))
+	}
 	root, err := loader.CalcTrieRoot(tx, nil)
 	if err != nil {
 		return nil, err
@@ -454,7 +508,13 @@ func (api *APIImpl) GetProof(ctx context.Context, address libcommon.Address, sto
 	if root != header.Root {
 		return nil, fmt.Errorf("mismatch in expected state root computed %v vs %v indicates bug in proof implementation", root, header.Root)
 	}
-	return pr.ProofResult()
+	proofs := make([]*accounts.AccProofResult, len(retainers))
+	for i, pr := range retainers {
+		if proofs[i], err = pr.ProofResult(); err != nil {
+			return nil, err
+		}
+	}
+	return proofs, nil
 }
 
 func (api *APIImpl) tryBlockFromLru(hash libcommon.Hash) *types.Block {
@@ -479,6 +539,10 @@ type accessListResult struct {
 // CreateAccessList implements eth_createAccessList. It creates an access list for the given transaction.
 // If the accesslist creation fails an error is returned.
 // If the transaction itself fails, an vmErr is returned.
+// The transaction is executed until the list stops growing. On OP Stack chains it runs as a regular L2
+// transaction (deposits can't have access lists): precompiles are left out, while the L1Block and EIP-4788
+// contracts are ordinary accounts - the system deposit and call of the block don't warm them for later
+// transactions.
 func (api *APIImpl) CreateAccessList(ctx context.Context, args ethapi2.CallArgs, blockNrOrHash *rpc.BlockNumberOrHash, optimizeGas *bool) (*accessListResult, error) {
 	bNrOrHash := rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)
 	if blockNrOrHash != nil {
@@ -539,6 +603,9 @@ func (api *APIImpl) CreateAccessList(ctx context.Context, args ethapi2.CallArgs,
 	}
 
 	header := block.Header()
+	if !chainConfig.IsBerlin(blockNumber) {
+		return nil, fmt.Errorf("access lists are not supported before Berlin, block %d", blockNumber)
+	}
 	// If the gas amount is not set, extract this as it will depend on access
 	// lists and we'll need to reestimate every time
 	nogas := args.Gas == nil
@@ -610,7 +677,7 @@ func (api *APIImpl) CreateAccessList(ctx context.Context, args ethapi2.CallArgs,
 			baseFee, _ = uint256.FromBig(header.BaseFee)
 		}
 
-		msg, err = args.ToMessage(api.GasCap, baseFee)
+		msg, err = args.ToMessage(methodGasCap(ctx, api.GasCap), baseFee)
 		if err != nil {
 			return nil, err
 		}
@@ -637,7 +704,9 @@ func (api *APIImpl) CreateAccessList(ctx context.Context, args ethapi2.CallArgs,
 			if optimizeGas == nil || *optimizeGas { // optimize gas unless explicitly told not to
 				optimizeWarmAddrInAccessList(accessList, *args.From)
 				optimizeWarmAddrInAccessList(accessList, to)
-				optimizeWarmAddrInAccessList(accessList, header.Coinbase)
+				if chainConfig.IsShanghai(header.Time) { // EIP-3651
+					optimizeWarmAddrInAccessList(accessList, header.Coinbase)
+				}
 				for addr := range tracer.CreatedContracts() {
 					if !tracer.UsedBeforeCreation(addr) {
 						optimizeWarmAddrInAccessList(accessList, addr)
@@ -195,7 +195,7 @@ func (api *APIImpl) CallMany(ctx context.Context, bundles []Bundle, simulateCont
 	gp := new(core.GasPool).AddGas(math.MaxUint64).AddBlobGas(math.MaxUint64)
 	for idx, txn := range replayTransactions {
 		st.SetTxContext(txn.Hash(), block.Hash(), idx)
-		msg, err := txn.AsMessage(*signer, block.BaseFee(), rules)
+		msg, err := types.AsMessageLegacyAware(chainConfig, blockNum, txn, *signer, block.BaseFee(), rules)
 		if err != nil {
 			return nil, err
 		}
@@ -253,10 +253,11 @@ func (api *APIImpl) CallMany(ctx context.Context, bundles []Bundle, simulateCont
 		}
 		results := []map[string]interface{}{}
 		for _, txn := range bundle.Transactions {
+			gasCap := methodGasCap(ctx, api.GasCap)
 			if txn.Gas == nil || *(txn.Gas) == 0 {
-				txn.Gas = (*hexutil.Uint64)(&api.GasCap)
+				txn.Gas = (*hexutil.Uint64)(&gasCap)
 			}
-			msg, err := txn.ToMessage(api.GasCap, blockCtx.BaseFee)
+			msg, err := txn.ToMessage(gasCap, blockCtx.BaseFee)
 			if err != nil {
 				return nil, err
 			}
@@ -57,6 +57,46 @@ func TestEstimateGas(t *testing.T) {
 	}
 }
 
+func TestEstimateGasBalanceAtBlock(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	api := NewEthAPI(newBaseApiForTest(m), m.DB, nil, nil, nil, 5000000, 1e18, 100_000, false, 100_000, 128, log.New())
+	// funded in block 1
+	from := libcommon.Address{1}
+	to := libcommon.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+	args := ethapi.CallArgs{From: &from, To: &to, GasPrice: (*hexutil.Big)(big.NewInt(1)), Value: (*hexutil.Big)(big.NewInt(1))}
+
+	genesis := rpc.BlockNumberOrHashWithNumber(0)
+	_, err := api.EstimateGas(context.Background(), &args, &genesis, nil)
+	require.EqualError(t, err, "insufficient funds for transfer")
+
+	funded := rpc.BlockNumberOrHashWithNumber(1)
+	gas, err := api.EstimateGas(context.Background(), &args, &funded, nil)
+	require.NoError(t, err)
+	require.Equal(t, hexutil.Uint64(params.TxGas), gas)
+}
+
+func TestCreateAccessList(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	api := NewEthAPI(newBaseApiForTest(m), m.DB, nil, nil, nil, 5000000, 1e18, 100_000, false, 100_000, 128, log.New())
+	from := libcommon.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	optimize := false
+
+	eoa := libcommon.Address{0x42}
+	res, err := api.CreateAccessList(context.Background(), ethapi2.CallArgs{From: &from, To: &eoa}, &latest, &optimize)
+	require.NoError(t, err)
+	require.Empty(t, *res.Accesslist)
+	require.Equal(t, hexutil.Uint64(params.TxGas), res.GasUsed)
+
+	// precompiles are warm anyway
+	sha256 := libcommon.BytesToAddress([]byte{2})
+	input := hexutility.Bytes("abc")
+	res, err = api.CreateAccessList(context.Background(), ethapi2.CallArgs{From: &from, To: &sha256, Data: &input}, &latest, &optimize)
+	require.NoError(t, err)
+	require.Empty(t, res.Error)
+	require.Empty(t, *res.Accesslist)
+}
+
 func TestEstimateGasHistoricalRPC(t *testing.T) {
 	m, _, _ := rpcdaemontest.CreateOptimismTestSentry(t)
 	api := NewEthAPI(newBaseApiForTest(m), m.DB, nil, nil, nil, 1e18, 5000000, 100_000, false, 100_000, 128, log.New())
@@ -158,9 +198,6 @@ func TestGetProof(t *testing.T) {
 	var maxGetProofRewindBlockCount = 1 // Note, this is unsafe for parallel tests, but, this test is the only consumer for now
 
 	m, bankAddr, contractAddr := chainWithDeployedContract(t)
-	if m.HistoryV3 {
-		t.Skip("not supported by Erigon3")
-	}
 	api := NewEthAPI(newBaseApiForTest(m), m.DB, nil, nil, nil, 5000000, 1e18, 100_000, false, maxGetProofRewindBlockCount, 128, log.New())
 
 	key := func(b byte) libcommon.Hash {
@@ -279,11 +316,40 @@ func TestGetProof(t *testing.T) {
 	}
 }
 
+func TestGetProofBatch(t *testing.T) {
+	m, bankAddr, contractAddr := chainWithDeployedContract(t)
+	ethAPI := NewEthAPI(newBaseApiForTest(m), m.DB, nil, nil, nil, 5000000, 1e18, 100_000, false, 1, 128, log.New())
+	api := NewErigonAPI(newBaseApiForTest(m), m.DB, nil)
+	api.ethImpl = ethAPI
+
+	requests := []ProofRequest{
+		{Address: contractAddr, StorageKeys: []libcommon.Hash{{31: 0}, {31: 4}, {31: 8}}},
+		{Address: bankAddr},
+		{Address: libcommon.HexToAddress("0xdeaddeaddeaddeaddeaddeaddeaddeaddeaddead0"), StorageKeys: []libcommon.Hash{{1}}},
+		{Address: contractAddr, StorageKeys: []libcommon.Hash{{31: 1}}},
+	}
+	for _, blockNum := range []uint64{2, 3} {
+		blockNrOrHash := rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(blockNum))
+		proofs, err := api.GetProofBatch(context.Background(), requests, blockNrOrHash)
+		require.NoError(t, err)
+		require.Len(t, proofs, len(requests))
+		// the same proofs as one by one
+		for i, req := range requests {
+			proof, err := ethAPI.GetProof(context.Background(), req.Address, req.StorageKeys, blockNrOrHash)
+			require.NoError(t, err)
+			require.Equal(t, proof, proofs[i], "block %d, request %d", blockNum, i)
+		}
+	}
+
+	_, err := api.GetProofBatch(context.Background(), requests, rpc.BlockNumberOrHashWithNumber(1))
+	require.ErrorContains(t, err, "requested block is too old")
+	proofs, err := api.GetProofBatch(context.Background(), nil, rpc.BlockNumberOrHashWithNumber(3))
+	require.NoError(t, err)
+	require.Empty(t, proofs)
+}
+
 func TestGetProofHistoricalRPC(t *testing.T) {
 	m, _, _ := rpcdaemontest.CreateOptimismTestSentry(t)
-	if m.HistoryV3 {
-		t.Skip("not supported by Erigon3")
-	}
 	api := NewEthAPI(newBaseApiForTest(m), m.DB, nil, nil, nil, 1e18, 5000000, 100_000, false, 100_000, 128, log.New())
 
 	table := []struct {
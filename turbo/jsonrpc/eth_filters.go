@@ -2,56 +2,62 @@ package jsonrpc
 
 import (
 	"context"
-	"strings"
 
+	"github.com/erigontech/erigon-lib/common/hexutil"
 	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon-lib/opstack"
 
 	"github.com/erigontech/erigon/common/debug"
+	"github.com/erigontech/erigon/core/state"
 	"github.com/erigontech/erigon/core/types"
 	"github.com/erigontech/erigon/eth/filters"
 	"github.com/erigontech/erigon/rpc"
 	"github.com/erigontech/erigon/turbo/rpchelper"
 )
 
+// filterClient - the client installing a filter, see rpchelper.Filters.FilterClient. Empty if unknown, e.g. over IPC.
+func (api *APIImpl) filterClient(ctx context.Context) string {
+	remote, ok := ctx.Value("remote").(string)
+	if !ok {
+		return ""
+	}
+	forwardedFor, _ := ctx.Value("X-Forwarded-For").(string)
+	return api.filters.FilterClient(remote, forwardedFor)
+}
+
 // NewPendingTransactionFilter new transaction filter
-func (api *APIImpl) NewPendingTransactionFilter(_ context.Context) (string, error) {
+func (api *APIImpl) NewPendingTransactionFilter(ctx context.Context) (string, error) {
 	if api.filters == nil {
 		return "", rpc.ErrNotificationsUnsupported
 	}
-	txsCh, id := api.filters.SubscribePendingTxs(32)
-	go func() {
-		for txs := range txsCh {
-			api.filters.AddPendingTxs(id, txs)
-		}
-	}()
+	id, err := api.filters.NewPendingTxsFilter(api.filterClient(ctx))
+	if err != nil {
+		return "", err
+	}
 	return "0x" + string(id), nil
 }
 
 // NewBlockFilter implements eth_newBlockFilter. Creates a filter in the node, to notify when a new block arrives.
-func (api *APIImpl) NewBlockFilter(_ context.Context) (string, error) {
+func (api *APIImpl) NewBlockFilter(ctx context.Context) (string, error) {
 	if api.filters == nil {
 		return "", rpc.ErrNotificationsUnsupported
 	}
-	ch, id := api.filters.SubscribeNewHeads(32)
-	go func() {
-		for block := range ch {
-			api.filters.AddPendingBlock(id, block)
-		}
-	}()
+	id, err := api.filters.NewHeadsFilter(api.filterClient(ctx))
+	if err != nil {
+		return "", err
+	}
 	return "0x" + string(id), nil
 }
 
 // NewFilter implements eth_newFilter. Creates an arbitrary filter object, based on filter options, to notify when the state changes (logs).
-func (api *APIImpl) NewFilter(_ context.Context, crit filters.FilterCriteria) (string, error) {
+func (api *APIImpl) NewFilter(ctx context.Context, crit filters.FilterCriteria) (string, error) {
 	if api.filters == nil {
 		return "", rpc.ErrNotificationsUnsupported
 	}
-	logs, id := api.filters.SubscribeLogs(256, crit)
-	go func() {
-		for lg := range logs {
-			api.filters.AddLogs(id, lg)
-		}
-	}()
+	id, err := api.filters.NewLogsFilter(api.filterClient(ctx), crit)
+	if err != nil {
+		return "", err
+	}
 	return "0x" + string(id), nil
 }
 
@@ -62,16 +68,7 @@ func (api *APIImpl) UninstallFilter(_ context.Context, index string) (isDeleted
 	}
 	// remove 0x
 	cutIndex := strings.TrimPrefix(index, "0x")
-	if ok := api.filters.UnsubscribeHeads(rpchelper.HeadsSubID(cutIndex)); ok {
-		isDeleted = true
-	}
-	if ok := api.filters.UnsubscribePendingTxs(rpchelper.PendingTxsSubID(cutIndex)); ok {
-		isDeleted = true
-	}
-	if ok := api.filters.UnsubscribeLogs(rpchelper.LogsSubID(cutIndex)); ok {
-		isDeleted = true
-	}
-	return
+	return api.filters.UninstallFilter(rpchelper.SubscriptionID(cutIndex)), nil
 }
 
 // GetFilterChanges implements eth_getFilterChanges.
@@ -84,6 +81,10 @@ func (api *APIImpl) GetFilterChanges(_ context.Context, index string) ([]any, er
 	stub := make([]any, 0)
 	// remove 0x
 	cutIndex := strings.TrimPrefix(index, "0x")
+	// an expired filter is an error, so the client installs it again
+	if err := api.filters.PollFilter(rpchelper.SubscriptionID(cutIndex)); err != nil {
+		return nil, err
+	}
 	if blocks, ok := api.filters.ReadPendingBlocks(rpchelper.HeadsSubID(cutIndex)); ok {
 		for _, v := range blocks {
 			stub = append(stub, v.Hash())
@@ -116,6 +117,9 @@ func (api *APIImpl) GetFilterLogs(_ context.Context, index string) ([]*types.Log
 		return nil, rpc.ErrNotificationsUnsupported
 	}
 	cutIndex := strings.TrimPrefix(index, "0x")
+	if err := api.filters.PollFilter(rpchelper.SubscriptionID(cutIndex)); err != nil {
+		return nil, err
+	}
 	logs, ok := api.filters.ReadLogs(rpchelper.LogsSubID(cutIndex))
 	if len(logs) == 0 || !ok {
 		return []*types.Log{}, nil
@@ -162,6 +166,7 @@ func (api *APIImpl) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
 }
 
 // NewPendingTransactions send a notification each time when a transaction had added into mempool.
+// With fullTx transactions are sent in RPC representation, with sender and (OP-stack) L1 fee estimate.
 func (api *APIImpl) NewPendingTransactions(ctx context.Context, fullTx *bool) (*rpc.Subscription, error) {
 	if api.filters == nil {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
@@ -181,11 +186,19 @@ func (api *APIImpl) NewPendingTransactions(ctx context.Context, fullTx *bool) (*
 		for {
 			select {
 			case txs, ok := <-txsCh:
-				for _, t := range txs {
-					if t != nil {
+				var full []*RPCPendingTransaction
+				if fullTx != nil && *fullTx && len(txs) > 0 {
+					var err error
+					if full, err = api.rpcPendingTransactions(ctx, txs); err != nil {
+						log.Warn("[rpc] error while formatting pending transactions", "err", err)
+						continue
+					}
+				}
+				for i, t := range txs {
+					if t != nil && (full == nil || full[i] != nil) {
 						var err error
-						if fullTx != nil && *fullTx {
-							err = notifier.Notify(rpcSub.ID, t)
+						if full != nil {
+							err = notifier.Notify(rpcSub.ID, full[i])
 						} else {
 							err = notifier.Notify(rpcSub.ID, t.Hash())
 						}
@@ -265,11 +278,12 @@ func (api *APIImpl) Logs(ctx context.Context, crit filters.FilterCriteria) (*rpc
 		defer debug.LogPanic()
 		logs, id := api.filters.SubscribeLogs(api.SubscribeLogsChannelSize, crit)
 		defer api.filters.UnsubscribeLogs(id)
+		dedup := rpchelper.NewLogsDedup()
 
 		for {
 			select {
 			case h, ok := <-logs:
-				if h != nil {
+				if h != nil && !dedup.Duplicate(h) {
 					err := notifier.Notify(rpcSub.ID, h)
 					if err != nil {
 						log.Warn("[rpc] error while notifying subscription", "err", err)
@@ -287,3 +301,55 @@ func (api *APIImpl) Logs(ctx context.Context, crit filters.FilterCriteria) (*rpc
 
 	return rpcSub, nil
 }
+
+// RPCPendingTransaction - notification of newPendingTransactions subscription with fullTx
+type RPCPendingTransaction struct {
+	*RPCTransaction
+	// L1Fee - OP-stack only: data availability fee the transaction would pay in the latest block
+	L1Fee *hexutil.Big `json:"l1Fee,omitempty"`
+}
+
+// rpcPendingTransactions - RPC representation of transactions from txpool stream on top of the latest block.
+// Transactions which can't be formatted are logged and left nil, the error is about the batch as a whole.
+func (api *APIImpl) rpcPendingTransactions(ctx context.Context, txs []types.Transaction) ([]*RPCPendingTransaction, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	chainConfig, err := api.chainConfig(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	latest, err := api.headerByRPCNumber(ctx, rpc.LatestBlockNumber, tx)
+	if err != nil {
+		return nil, err
+	}
+	var l1CostFunc opstack.L1CostFunc
+	if chainConfig.IsOptimism() && latest != nil {
+		stateReader, err := rpchelper.CreateStateReader(ctx, tx, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), 0, api.filters, api.stateCache, api.historyV3(tx), chainConfig.ChainName)
+		if err != nil {
+			return nil, err
+		}
+		l1CostFunc = opstack.NewL1CostFunc(chainConfig, state.New(stateReader))
+	}
+
+	signer := types.LatestSignerForChainID(chainConfig.ChainID)
+	result := make([]*RPCPendingTransaction, len(txs))
+	for i, txn := range txs {
+		if txn == nil {
+			continue
+		}
+		if _, err := txn.Sender(*signer); err != nil {
+			log.Warn("[rpc] skipping pending transaction with unknown sender", "hash", txn.Hash(), "err", err)
+			continue
+		}
+		result[i] = &RPCPendingTransaction{RPCTransaction: newRPCPendingTransaction(txn, latest, chainConfig)}
+		if l1CostFunc != nil {
+			if fee := l1CostFunc(txn.RollupCostData(), latest.Time); fee != nil {
+				result[i].L1Fee = (*hexutil.Big)(fee.ToBig())
+			}
+		}
+	}
+	return result, nil
+}
@@ -11,7 +11,9 @@ import (
 
 	"github.com/erigontech/erigon/rpc/rpccfg"
 
+	"github.com/holiman/uint256"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/erigontech/erigon-lib/gointerfaces/txpool"
 
@@ -20,7 +22,10 @@ import (
 	"github.com/erigontech/erigon-lib/log/v3"
 
 	"github.com/erigontech/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/crypto"
 	"github.com/erigontech/erigon/eth/filters"
+	"github.com/erigontech/erigon/params"
 	"github.com/erigontech/erigon/turbo/rpchelper"
 	"github.com/erigontech/erigon/turbo/stages/mock"
 )
@@ -103,3 +108,25 @@ func TestLogsSubscribeAndUnsubscribe_WithoutConcurrentMapIssue(t *testing.T) {
 	}
 	wg.Wait()
 }
+
+func TestRPCPendingTransactions(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	api := NewEthAPI(newBaseApiForTest(m), m.DB, nil, nil, nil, 5000000, 1e18, 100_000, false, 100_000, 128, log.New())
+
+	key, _ := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	signer := types.LatestSignerForChainID(m.ChainConfig.ChainID)
+	txn, err := types.SignTx(types.NewTransaction(0, libcommon.Address{1}, uint256.NewInt(1), params.TxGas, uint256.NewInt(1e9), nil), *signer, key)
+	require.NoError(t, err)
+
+	// the sender of an unsigned transaction can't be recovered, only this entry of the batch is skipped
+	unsigned := types.NewTransaction(1, libcommon.Address{1}, uint256.NewInt(1), params.TxGas, uint256.NewInt(1e9), nil)
+	full, err := api.rpcPendingTransactions(m.Ctx, []types.Transaction{txn, nil, unsigned})
+	require.NoError(t, err)
+	require.Len(t, full, 3)
+	require.Equal(t, txn.Hash(), full[0].Hash)
+	require.Equal(t, crypto.PubkeyToAddress(key.PublicKey), full[0].From)
+	require.Nil(t, full[0].BlockHash)
+	require.Nil(t, full[0].L1Fee) // not an OP-stack chain
+	require.Nil(t, full[1])
+	require.Nil(t, full[2])
+}
@@ -1,11 +1,11 @@
 package jsonrpc
 
 import (
-	"bytes"
 	"context"
 	"encoding/binary"
 	"fmt"
 	"math/big"
+	"strconv"
 
 	"github.com/erigontech/erigon-lib/common/hexutil"
 	"github.com/erigontech/erigon-lib/opstack"
@@ -16,6 +16,7 @@ import (
 
 	"github.com/erigontech/erigon-lib/chain"
 	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/dbg"
 	"github.com/erigontech/erigon-lib/common/hexutility"
 	"github.com/erigontech/erigon-lib/kv"
 	"github.com/erigontech/erigon-lib/kv/bitmapdb"
@@ -34,7 +35,6 @@ import (
 	"github.com/erigontech/erigon/core/vm"
 	"github.com/erigontech/erigon/core/vm/evmtypes"
 	"github.com/erigontech/erigon/eth/filters"
-	"github.com/erigontech/erigon/ethdb/cbor"
 	"github.com/erigontech/erigon/rpc"
 	"github.com/erigontech/erigon/turbo/rpchelper"
 	"github.com/erigontech/erigon/turbo/services"
@@ -52,7 +52,22 @@ func (api *BaseAPI) getReceipts(ctx context.Context, tx kv.Tx, block *types.Bloc
 		return nil, err
 	}
 
-	if receipts := rawdb.ReadReceipts(chainConfig, tx, block, senders); receipts != nil {
+	raw, err := readRawReceipts(ctx, api._blockReader, tx, block.NumberU64())
+	if err != nil {
+		return nil, err
+	}
+	if receipts := rawdb.DeriveReceipts(chainConfig, block, senders, raw); receipts != nil {
+		if dbg.VerifyReceipts {
+			// DeriveFields has overwritten the stored L1 fee fields, they are read again
+			stored, err := readRawReceipts(ctx, api._blockReader, tx, block.NumberU64())
+			if err != nil {
+				return nil, err
+			}
+			for _, m := range compareReceipts(block.Header(), block.Transactions(), stored, receipts) {
+				log.Warn("Stored receipt mismatch", "block", block.NumberU64(), "hash", block.Hash(), "txIndex", m.txIndex,
+					"field", m.field, "stored", m.stored, "derived", m.derived)
+			}
+		}
 		api.receiptsCache.Add(block.Hash(), receipts)
 		return receipts, nil
 	}
@@ -95,6 +110,53 @@ func (api *BaseAPI) getReceipts(ctx context.Context, tx kv.Tx, block *types.Bloc
 	return receipts, nil
 }
 
+// receiptMismatch - a stored receipt field which differs from the value derived from the block
+type receiptMismatch struct {
+	txIndex int // -1 for the block
+	field   string
+	stored  string
+	derived string
+}
+
+// compareReceipts - checks the L1 fee fields stored at execution against the ones derived from the L1 info deposit,
+// and the gas used against the block header and the gas limits of the transactions
+func compareReceipts(header *types.Header, txs types.Transactions, stored, derived types.Receipts) []receiptMismatch {
+	var mismatches []receiptMismatch
+	if len(stored) != len(derived) {
+		return append(mismatches, receiptMismatch{txIndex: -1, field: "count", stored: strconv.Itoa(len(stored)), derived: strconv.Itoa(len(derived))})
+	}
+	compareBig := func(i int, field string, s, d *big.Int) {
+		if s != nil && d != nil && s.Cmp(d) != 0 {
+			mismatches = append(mismatches, receiptMismatch{txIndex: i, field: field, stored: s.String(), derived: d.String()})
+		}
+	}
+	compareUint64 := func(i int, field string, s, d *uint64) {
+		if s != nil && d != nil && *s != *d {
+			mismatches = append(mismatches, receiptMismatch{txIndex: i, field: field, stored: strconv.FormatUint(*s, 10), derived: strconv.FormatUint(*d, 10)})
+		}
+	}
+	for i, r := range derived {
+		if r.GasUsed > txs[i].GetGas() {
+			mismatches = append(mismatches, receiptMismatch{txIndex: i, field: "gasUsed", stored: strconv.FormatUint(r.GasUsed, 10),
+				derived: strconv.FormatUint(txs[i].GetGas(), 10) + " (gas limit)"})
+		}
+		compareBig(i, "l1GasPrice", stored[i].L1GasPrice, r.L1GasPrice)
+		compareBig(i, "l1GasUsed", stored[i].L1GasUsed, r.L1GasUsed)
+		compareBig(i, "l1Fee", stored[i].L1Fee, r.L1Fee)
+		compareBig(i, "l1BlobBaseFee", stored[i].L1BlobBaseFee, r.L1BlobBaseFee)
+		compareUint64(i, "l1BaseFeeScalar", stored[i].L1BaseFeeScalar, r.L1BaseFeeScalar)
+		compareUint64(i, "l1BlobBaseFeeScalar", stored[i].L1BlobBaseFeeScalar, r.L1BlobBaseFeeScalar)
+		if s, d := stored[i].FeeScalar, r.FeeScalar; s != nil && d != nil && s.Cmp(d) != 0 {
+			mismatches = append(mismatches, receiptMismatch{txIndex: i, field: "l1FeeScalar", stored: s.String(), derived: d.String()})
+		}
+	}
+	if len(derived) > 0 && derived[len(derived)-1].CumulativeGasUsed != header.GasUsed {
+		mismatches = append(mismatches, receiptMismatch{txIndex: -1, field: "cumulativeGasUsed", stored: strconv.FormatUint(derived[len(derived)-1].CumulativeGasUsed, 10),
+			derived: strconv.FormatUint(header.GasUsed, 10) + " (header)"})
+	}
+	return mismatches
+}
+
 // GetLogs implements eth_getLogs. Returns an array of logs matching a given filter object.
 func (api *APIImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria) (types.Logs, error) {
 	var begin, end uint64
@@ -173,7 +235,7 @@ func (api *APIImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria) (t
 	}
 	blockNumbers := bitmapdb.NewBitmap()
 	defer bitmapdb.ReturnToPool(blockNumbers)
-	if err := applyFilters(blockNumbers, tx, begin, end, crit); err != nil {
+	if err := applyFilters(blockNumbers, tx, api.logIndex(), begin, end, crit); err != nil {
 		return logs, err
 	}
 	if blockNumbers.IsEmpty() {
@@ -183,6 +245,10 @@ func (api *APIImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria) (t
 	for _, v := range crit.Addresses {
 		addrMap[v] = struct{}{}
 	}
+	pruneMode, err := api.pruneMode(tx)
+	if err != nil {
+		return nil, err
+	}
 	iter := blockNumbers.Iterator()
 	for iter.HasNext() {
 		if err := ctx.Err(); err != nil {
@@ -191,39 +257,28 @@ func (api *APIImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria) (t
 
 		blockNumber := uint64(iter.Next())
 		var logIndex uint
-		var txIndex uint
 		var blockLogs []*types.Log
-
-		it, err := tx.Prefix(kv.Log, hexutility.EncodeTs(blockNumber))
-		if err != nil {
-			return nil, err
-		}
-		for it.HasNext() {
-			k, v, err := it.Next()
-			if err != nil {
-				return logs, err
-			}
-
-			var logs types.Logs
-			if err := cbor.Unmarshal(&logs, bytes.NewReader(v)); err != nil {
-				return logs, fmt.Errorf("receipt unmarshal failed:  %w", err)
-			}
-			for _, log := range logs {
+		collect := func(txIndex uint, txLogs types.Logs) bool {
+			for _, log := range txLogs {
 				log.Index = logIndex
 				logIndex++
 			}
-			filtered := logs.Filter(addrMap, crit.Topics, 0)
-			if len(filtered) == 0 {
-				continue
-			}
-			txIndex = uint(binary.BigEndian.Uint32(k[8:]))
+			filtered := txLogs.Filter(addrMap, crit.Topics, 0)
 			for _, log := range filtered {
 				log.TxIndex = txIndex
 			}
 			blockLogs = append(blockLogs, filtered...)
+			return true
 		}
-		if casted, ok := it.(kv.Closer); ok {
-			casted.Close()
+
+		stored, err := forBlockLogs(ctx, api._blockReader, tx, blockNumber, collect)
+		if err != nil {
+			return logs, err
+		}
+		if !stored && pruneMode.Experiments.ReceiptsIndexOnly {
+			if err = api.reExecBlockLogs(ctx, tx, blockNumber, collect); err != nil {
+				return nil, err
+			}
 		}
 		if len(blockLogs) == 0 {
 			continue
@@ -254,7 +309,127 @@ func (api *APIImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria) (t
 		logs = append(logs, blockLogs...)
 	}
 
-	return logs, nil
+	return rpchelper.CanonicalLogs(logs), nil
+}
+
+// reExecBlockLogs - forBlockLogs over the re-generated receipts of the block. For nodes which only keep the log index:
+// the index finds the block, but its logs aren't stored.
+func (api *BaseAPI) reExecBlockLogs(ctx context.Context, tx kv.Tx, blockNumber uint64, f func(txIndex uint, logs types.Logs) bool) error {
+	block, err := api.blockByNumberWithSenders(ctx, tx, blockNumber)
+	if err != nil {
+		return err
+	}
+	if block == nil {
+		return fmt.Errorf("block not found %d", blockNumber)
+	}
+	receipts, err := api.getReceipts(ctx, tx, block, block.Body().SendersFromTxs())
+	if err != nil {
+		return fmt.Errorf("getReceipts error: %w", err)
+	}
+
+	for txIndex, receipt := range receipts {
+		if len(receipt.Logs) == 0 {
+			continue
+		}
+		// receipts are cached, their logs are copied
+		txLogs := make(types.Logs, 0, len(receipt.Logs))
+		for _, l := range receipt.Logs {
+			cpy := *l
+			txLogs = append(txLogs, &cpy)
+		}
+		if !f(uint(txIndex), txLogs) {
+			break
+		}
+	}
+	return nil
+}
+
+// readRawReceipts - rawdb.ReadRawReceipts, which also reads the frozen receipts if the block reader has them
+func readRawReceipts(ctx context.Context, br services.FullBlockReader, tx kv.Tx, blockNum uint64) (types.Receipts, error) {
+	if r, ok := br.(services.ReceiptsReader); ok {
+		return r.RawReceipts(ctx, tx, blockNum)
+	}
+	return rawdb.ReadRawReceipts(tx, blockNum), nil
+}
+
+// forBlockLogs calls f with the logs of every tx of the block which has logs, until f returns false. The logs are read
+// from kv.Log, or from the frozen receipts once `erigon snapshots retire` pruned them from the db. Returns false if
+// neither has the block.
+func forBlockLogs(ctx context.Context, br services.FullBlockReader, tx kv.Tx, blockNum uint64, f func(txIndex uint, logs types.Logs) bool) (bool, error) {
+	it, err := tx.Prefix(kv.Log, hexutility.EncodeTs(blockNum))
+	if err != nil {
+		return false, err
+	}
+	if casted, ok := it.(kv.Closer); ok {
+		defer casted.Close()
+	}
+	var stored bool
+	for it.HasNext() {
+		k, v, err := it.Next()
+		if err != nil {
+			return stored, err
+		}
+		stored = true
+		logs, err := types.DecodeLogsForStorage(v)
+		if err != nil {
+			return stored, fmt.Errorf("receipt unmarshal failed:  %w", err)
+		}
+		if !f(uint(binary.BigEndian.Uint32(k[8:])), logs) {
+			return stored, nil
+		}
+	}
+	if stored {
+		return true, nil
+	}
+
+	r, ok := br.(services.ReceiptsReader)
+	if !ok {
+		return false, nil
+	}
+	receipts, err := r.RawReceipts(ctx, tx, blockNum)
+	if err != nil || receipts == nil {
+		return false, err
+	}
+	for txIndex, receipt := range receipts {
+		if len(receipt.Logs) == 0 {
+			continue
+		}
+		if !f(uint(txIndex), receipt.Logs) {
+			break
+		}
+	}
+	return true, nil
+}
+
+// logIndex - eth_getLogs index of the frozen blocks, nil if the snapshots don't have it
+func (api *BaseAPI) logIndex() services.LogIndexReader {
+	if api._blockReader == nil {
+		return nil
+	}
+	if snapshots, ok := api._blockReader.Snapshots().(services.LogIndexSnapshots); ok {
+		return snapshots.LogIndex()
+	}
+	return nil
+}
+
+// getLogIndexBitmap - blocks in [from, to] with the logs of the key: the frozen blocks are read from
+// the snapshot log index (one bitmap per segment range), the rest from the chunks of the db table
+func getLogIndexBitmap(tx kv.Tx, logIndex services.LogIndexReader, bucket string, key []byte, from, to uint64) (*roaring.Bitmap, error) {
+	if logIndex != nil {
+		if indexedFrom, indexedTo := logIndex.Indexed(); indexedFrom <= from && from < indexedTo {
+			m, err := logIndex.Get(bucket, key, from, min(to, indexedTo-1))
+			if err != nil || to < indexedTo {
+				return m, err
+			}
+			recent, err := bitmapdb.Get(tx, bucket, key, uint32(indexedTo), uint32(to))
+			if err != nil {
+				return nil, err
+			}
+			m.Or(recent)
+			return m, nil
+		}
+	}
+	return bitmapdb.Get(tx, bucket, key, uint32(from), uint32(to))
 }
 
 // The Topic list restricts matches to particular event topics. Each event has a list
@@ -268,12 +443,12 @@ func (api *APIImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria) (t
 // {{}, {B}}          matches any topic in first position AND B in second position
 // {{A}, {B}}         matches topic A in first position AND B in second position
 // {{A, B}, {C, D}}   matches topic (A OR B) in first position AND (C OR D) in second position
-func getTopicsBitmap(c kv.Tx, topics [][]common.Hash, from, to uint64) (*roaring.Bitmap, error) {
+func getTopicsBitmap(c kv.Tx, logIndex services.LogIndexReader, topics [][]common.Hash, from, to uint64) (*roaring.Bitmap, error) {
 	var result *roaring.Bitmap
 	for _, sub := range topics {
 		var bitmapForORing *roaring.Bitmap
 		for _, topic := range sub {
-			m, err := bitmapdb.Get(c, kv.LogTopicIndex, topic[:], uint32(from), uint32(to))
+			m, err := getLogIndexBitmap(c, logIndex, kv.LogTopicIndex, topic[:], from, to)
 			if err != nil {
 				return nil, err
 			}
@@ -296,7 +471,7 @@ func getTopicsBitmap(c kv.Tx, topics [][]common.Hash, from, to uint64) (*roaring
 	}
 	return result, nil
 }
-func getAddrsBitmap(tx kv.Tx, addrs []common.Address, from, to uint64) (*roaring.Bitmap, error) {
+func getAddrsBitmap(tx kv.Tx, logIndex services.LogIndexReader, addrs []common.Address, from, to uint64) (*roaring.Bitmap, error) {
 	if len(addrs) == 0 {
 		return nil, nil
 	}
@@ -307,7 +482,7 @@ func getAddrsBitmap(tx kv.Tx, addrs []common.Address, from, to uint64) (*roaring
 		}
 	}()
 	for idx, addr := range addrs {
-		m, err := bitmapdb.Get(tx, kv.LogAddressIndex, addr[:], uint32(from), uint32(to))
+		m, err := getLogIndexBitmap(tx, logIndex, kv.LogAddressIndex, addr[:], from, to)
 		if err != nil {
 			return nil, err
 		}
@@ -316,16 +491,16 @@ func getAddrsBitmap(tx kv.Tx, addrs []common.Address, from, to uint64) (*roaring
 	return roaring.FastOr(rx...), nil
 }
 
-func applyFilters(out *roaring.Bitmap, tx kv.Tx, begin, end uint64, crit filters.FilterCriteria) error {
+func applyFilters(out *roaring.Bitmap, tx kv.Tx, logIndex services.LogIndexReader, begin, end uint64, crit filters.FilterCriteria) error {
 	out.AddRange(begin, end+1) // [from,to)
-	topicsBitmap, err := getTopicsBitmap(tx, crit.Topics, begin, end)
+	topicsBitmap, err := getTopicsBitmap(tx, logIndex, crit.Topics, begin, end)
 	if err != nil {
 		return err
 	}
 	if topicsBitmap != nil {
 		out.And(topicsBitmap)
 	}
-	addrBitmap, err := getAddrsBitmap(tx, crit.Addresses, begin, end)
+	addrBitmap, err := getAddrsBitmap(tx, logIndex, crit.Addresses, begin, end)
 	if err != nil {
 		return err
 	}
@@ -489,7 +664,7 @@ func (api *APIImpl) getLogsV3(ctx context.Context, tx kv.TemporalTx, begin, end
 
 	//stats := api._agg.GetAndResetStats()
 	//log.Info("Finished", "duration", time.Since(start), "history queries", stats.HistoryQueries, "ef search duration", stats.EfSearchTime)
-	return logs, nil
+	return rpchelper.CanonicalLogs(logs), nil
 }
 
 type intraBlockExec struct {
@@ -552,7 +727,7 @@ func (e *intraBlockExec) execTx(txNum uint64, txIndex int, txn types.Transaction
 	e.ibs.Reset()
 	e.ibs.SetTxContext(txHash, e.blockHash, txIndex)
 	gp := new(core.GasPool).AddGas(txn.GetGas()).AddBlobGas(txn.GetBlobGas())
-	msg, err := txn.AsMessage(*e.signer, e.header.BaseFee, e.rules)
+	msg, err := types.AsMessageLegacyAware(e.chainConfig, e.blockNum, txn, *e.signer, e.header.BaseFee, e.rules)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -641,7 +816,15 @@ func (api *APIImpl) GetTransactionReceipt(ctx context.Context, txnHash common.Ha
 	}
 
 	if !ok {
-		return nil, nil
+		notIndexed := api.txnNotIndexed(tx, txnHash)
+		if notIndexed == nil {
+			return nil, nil
+		}
+		// a pending transaction has no receipt yet, which is not an error
+		if pending, err := api.txnInPool(ctx, txnHash); err != nil || pending {
+			return nil, err
+		}
+		return nil, notIndexed
 	}
 
 	block, err := api.blockByNumberWithSenders(ctx, tx, blockNum)
@@ -775,7 +958,10 @@ func marshalReceipt(receipt *types.Receipt, txn types.Transaction, chainConfig *
 		"logs":              receipt.Logs,
 		"logsBloom":         types.CreateBloom(types.Receipts{receipt}),
 	}
-	if !chainConfig.IsLondon(header.Number.Uint64()) {
+	if txn.Type() == types.DepositTxType {
+		// deposits don't pay for gas on L2, as in op-geth
+		fields["effectiveGasPrice"] = hexutil.Uint64(0)
+	} else if !chainConfig.IsLondon(header.Number.Uint64()) {
 		fields["effectiveGasPrice"] = hexutil.Uint64(txn.GetPrice().Uint64())
 	} else {
 		baseFee, _ := uint256.FromBig(header.BaseFee)
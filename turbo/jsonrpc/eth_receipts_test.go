@@ -2,7 +2,10 @@ package jsonrpc
 
 import (
 	"context"
+	"encoding/json"
 	"math/big"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/erigontech/erigon-lib/common"
@@ -14,6 +17,7 @@ import (
 	"github.com/erigontech/erigon/common/u256"
 	"github.com/erigontech/erigon/core/rawdb"
 	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/params"
 	"github.com/erigontech/erigon/rpc/rpccfg"
 	"github.com/erigontech/erigon/turbo/rpchelper"
 	"github.com/erigontech/erigon/turbo/stages/mock"
@@ -149,3 +153,68 @@ func buildSystemTx(l1BaseFee, overhead, scalar [32]byte) []byte {
 	systemInfo = append(systemInfo, scalar[:]...)    // 4 + 7 * 32 - 4 + 8 * 32 - scalar
 	return systemInfo
 }
+
+func TestCompareReceipts(t *testing.T) {
+	txs := types.Transactions{
+		&types.DepositTx{Gas: 1_000_000},
+		&types.LegacyTx{CommonTx: types.CommonTx{Gas: 21_000}},
+	}
+	header := &types.Header{Number: big.NewInt(1), GasUsed: 71_000}
+	scalar := uint64(1368)
+	derived := types.Receipts{
+		{CumulativeGasUsed: 50_000, GasUsed: 50_000},
+		{CumulativeGasUsed: 71_000, GasUsed: 21_000, L1Fee: big.NewInt(100), L1GasUsed: big.NewInt(1600), L1BaseFeeScalar: &scalar},
+	}
+	stored := types.Receipts{
+		{CumulativeGasUsed: 50_000},
+		{CumulativeGasUsed: 71_000, L1Fee: big.NewInt(100), L1GasUsed: big.NewInt(1600), L1BaseFeeScalar: &scalar},
+	}
+	require.Empty(t, compareReceipts(header, txs, stored, derived))
+
+	// missing in the stored receipt - not compared
+	stored[1].L1GasUsed = nil
+	require.Empty(t, compareReceipts(header, txs, stored, derived))
+
+	stored[1].L1Fee = big.NewInt(99)
+	derived[1].GasUsed = 22_000
+	header.GasUsed = 72_000
+	require.Equal(t, []receiptMismatch{
+		{txIndex: 1, field: "gasUsed", stored: "22000", derived: "21000 (gas limit)"},
+		{txIndex: 1, field: "l1Fee", stored: "99", derived: "100"},
+		{txIndex: -1, field: "cumulativeGasUsed", stored: "71000", derived: "72000 (header)"},
+	}, compareReceipts(header, txs, stored, derived))
+
+	require.Equal(t, []receiptMismatch{{txIndex: -1, field: "count", stored: "1", derived: "2"}}, compareReceipts(header, txs, stored[:1], derived))
+}
+
+// TestDepositReceiptJSON - deposit receipts are marshaled with the fields of op-geth's receipts, see TestDepositTxJSON
+func TestDepositReceiptJSON(t *testing.T) {
+	bridge := common.HexToAddress("0x4200000000000000000000000000000000000010")
+	txn := &types.DepositTx{
+		SourceHash: common.HexToHash("0x3f8b4a2c0e9d5b1f7a6c8e2d4b0f9a1c3e5d7b9f2a4c6e8d0b1f3a5c7e9d2b4f"),
+		From:       common.HexToAddress("0x976ea74026e726554db657fa54763abd0c3a0aa9"),
+		To:         &bridge,
+		Mint:       uint256.NewInt(10_000_000_000_000_000),
+		Value:      uint256.NewInt(10_000_000_000_000_000),
+		Gas:        250_000,
+	}
+	header := &types.Header{Number: big.NewInt(31250), BaseFee: big.NewInt(1_000_000_000)}
+	nonce, version := uint64(435), types.CanyonDepositReceiptVersion
+	receipt := &types.Receipt{
+		Type:                  types.DepositTxType,
+		Status:                types.ReceiptStatusSuccessful,
+		CumulativeGasUsed:     107_714,
+		GasUsed:               45_728,
+		BlockHash:             common.HexToHash("0x5e7c9a2f0d4b8e1c6a3f9d2b7e0c4a8f1d6b3e9c2a7f0d5b8e1c4a9f3d6b2e70"),
+		BlockNumber:           header.Number,
+		TransactionIndex:      1,
+		DepositNonce:          &nonce,
+		DepositReceiptVersion: &version,
+	}
+
+	golden, err := os.ReadFile(filepath.Join("testdata", "deposit-json", "deposit_receipt.json"))
+	require.NoError(t, err)
+	got, err := json.Marshal(marshalReceipt(receipt, txn, params.OptimismTestConfig, header, txn.Hash(), true))
+	require.NoError(t, err)
+	require.JSONEq(t, string(golden), string(got))
+}
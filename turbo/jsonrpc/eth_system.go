@@ -2,9 +2,11 @@ package jsonrpc
 
 import (
 	"context"
+	"errors"
 	"math/big"
 
 	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/gointerfaces/txpool"
 
 	"github.com/erigontech/erigon-lib/chain"
 	"github.com/erigontech/erigon-lib/kv"
@@ -111,7 +113,7 @@ func (api *APIImpl) GasPrice(ctx context.Context) (*hexutil.Big, error) {
 		return nil, err
 	}
 	defer tx.Rollback()
-	oracle := gasprice.NewOracle(NewGasPriceOracleBackend(tx, api.BaseAPI), ethconfig.Defaults.GPO, api.gasCache)
+	oracle := gasprice.NewOracle(NewGasPriceOracleBackend(tx, api.BaseAPI, api.txPool), ethconfig.Defaults.GPO, api.gasCache)
 	tipcap, err := oracle.SuggestTipCap(ctx)
 	gasResult := big.NewInt(0)
 
@@ -120,7 +122,11 @@ func (api *APIImpl) GasPrice(ctx context.Context) (*hexutil.Big, error) {
 		return nil, err
 	}
 	if head := rawdb.ReadCurrentHeader(tx); head != nil && head.BaseFee != nil {
-		gasResult.Add(tipcap, head.BaseFee)
+		baseFee := head.BaseFee
+		if cc, err := api.chainConfig(ctx, tx); err == nil && cc.IsOptimism() {
+			baseFee = oracle.OptimismBaseFee(head)
+		}
+		gasResult.Add(tipcap, baseFee)
 	}
 
 	return (*hexutil.Big)(gasResult), err
@@ -133,7 +139,7 @@ func (api *APIImpl) MaxPriorityFeePerGas(ctx context.Context) (*hexutil.Big, err
 		return nil, err
 	}
 	defer tx.Rollback()
-	oracle := gasprice.NewOracle(NewGasPriceOracleBackend(tx, api.BaseAPI), ethconfig.Defaults.GPO, api.gasCache)
+	oracle := gasprice.NewOracle(NewGasPriceOracleBackend(tx, api.BaseAPI, api.txPool), ethconfig.Defaults.GPO, api.gasCache)
 	tipcap, err := oracle.SuggestTipCap(ctx)
 	if err != nil {
 		return nil, err
@@ -154,7 +160,7 @@ func (api *APIImpl) FeeHistory(ctx context.Context, blockCount rpc.DecimalOrHex,
 		return nil, err
 	}
 	defer tx.Rollback()
-	oracle := gasprice.NewOracle(NewGasPriceOracleBackend(tx, api.BaseAPI), ethconfig.Defaults.GPO, api.gasCache)
+	oracle := gasprice.NewOracle(NewGasPriceOracleBackend(tx, api.BaseAPI, api.txPool), ethconfig.Defaults.GPO, api.gasCache)
 
 	oldest, reward, baseFee, gasUsed, err := oracle.FeeHistory(ctx, int(blockCount), lastBlock, rewardPercentiles)
 	if err != nil {
@@ -185,10 +191,11 @@ func (api *APIImpl) FeeHistory(ctx context.Context, blockCount rpc.DecimalOrHex,
 type GasPriceOracleBackend struct {
 	tx      kv.Tx
 	baseApi *BaseAPI
+	txPool  txpool.TxpoolClient
 }
 
-func NewGasPriceOracleBackend(tx kv.Tx, baseApi *BaseAPI) *GasPriceOracleBackend {
-	return &GasPriceOracleBackend{tx: tx, baseApi: baseApi}
+func NewGasPriceOracleBackend(tx kv.Tx, baseApi *BaseAPI, txPool txpool.TxpoolClient) *GasPriceOracleBackend {
+	return &GasPriceOracleBackend{tx: tx, baseApi: baseApi, txPool: txPool}
 }
 
 func (b *GasPriceOracleBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
@@ -214,3 +221,13 @@ func (b *GasPriceOracleBackend) GetReceipts(ctx context.Context, block *types.Bl
 func (b *GasPriceOracleBackend) PendingBlockAndReceipts() (*types.Block, types.Receipts) {
 	return nil, nil
 }
+func (b *GasPriceOracleBackend) PendingTxCount(ctx context.Context) (int, error) {
+	if b.txPool == nil {
+		return 0, errors.New("txpool is not available")
+	}
+	reply, err := b.txPool.Status(ctx, &txpool.StatusRequest{})
+	if err != nil {
+		return 0, err
+	}
+	return int(reply.PendingCount), nil
+}
@@ -80,14 +80,17 @@ func (api *APIImpl) GetTransactionByHash(ctx context.Context, txnHash common.Has
 		}
 
 		if chainConfig.IsOptimism() {
-			receipts := rawdb.ReadRawReceipts(tx, block.NumberU64())
+			receipts, err := readRawReceipts(ctx, api._blockReader, tx, block.NumberU64())
+			if err != nil {
+				return nil, err
+			}
 			if len(receipts) <= int(txnIndex) {
 				return nil, fmt.Errorf("block has less receipts than expected: %d <= %d, block: %d", len(receipts), int(txnIndex), blockNum)
 			}
-			return NewRPCTransaction(txn, blockHash, blockNum, txnIndex, baseFee, receipts[txnIndex]), nil
+			return newRPCTransactionLegacyAware(chainConfig, txn, blockHash, blockNum, txnIndex, baseFee, receipts[txnIndex]), nil
 		}
 
-		return NewRPCTransaction(txn, blockHash, blockNum, txnIndex, baseFee, nil), nil
+		return newRPCTransactionLegacyAware(chainConfig, txn, blockHash, blockNum, txnIndex, baseFee, nil), nil
 	}
 
 	curHeader := rawdb.ReadCurrentHeader(tx)
@@ -96,11 +99,14 @@ func (api *APIImpl) GetTransactionByHash(ctx context.Context, txnHash common.Has
 	}
 
 	// No finalized transaction, try to retrieve it from the pool
+	if api.txPool == nil {
+		return nil, api.txnNotIndexed(tx, txnHash)
+	}
 	reply, err := api.txPool.Transactions(ctx, &txpool.TransactionsRequest{Hashes: []*types.H256{gointerfaces.ConvertHashToH256(txnHash)}})
 	if err != nil {
 		return nil, err
 	}
-	if len(reply.RlpTxs[0]) > 0 {
+	if len(reply.RlpTxs) > 0 && len(reply.RlpTxs[0]) > 0 {
 		txn, err := types2.DecodeWrappedTransaction(reply.RlpTxs[0])
 		if err != nil {
 			return nil, err
@@ -115,7 +121,7 @@ func (api *APIImpl) GetTransactionByHash(ctx context.Context, txnHash common.Has
 	}
 
 	// Transaction unknown, return as such
-	return nil, nil
+	return nil, api.txnNotIndexed(tx, txnHash)
 }
 
 // GetRawTransactionByHash returns the bytes of the transaction for the given hash.
@@ -127,27 +133,17 @@ func (api *APIImpl) GetRawTransactionByHash(ctx context.Context, hash common.Has
 	defer tx.Rollback()
 
 	// https://infura.io/docs/ethereum/json-rpc/eth-getTransactionByHash
-	blockNum, ok, err := api.txnLookup(ctx, tx, hash)
+	blockNum, txnIndex, ok, err := api.txnLookupWithIndex(ctx, tx, hash)
 	if err != nil {
 		return nil, err
 	}
 	if !ok {
-		return nil, nil
+		return nil, api.txnNotIndexed(tx, hash)
 	}
-	block, err := api.blockByNumberWithSenders(ctx, tx, blockNum)
+	txn, err := api._txnReader.TxnByIdxInBlock(ctx, tx, blockNum, txnIndex)
 	if err != nil {
 		return nil, err
 	}
-	if block == nil {
-		return nil, nil
-	}
-	var txn types2.Transaction
-	for _, transaction := range block.Transactions() {
-		if transaction.Hash() == hash {
-			txn = transaction
-			break
-		}
-	}
 
 	if txn != nil {
 		var buf bytes.Buffer
@@ -203,14 +199,17 @@ func (api *APIImpl) GetTransactionByBlockHashAndIndex(ctx context.Context, block
 	}
 
 	if chainConfig.IsOptimism() {
-		receipts := rawdb.ReadRawReceipts(tx, block.NumberU64())
+		receipts, err := readRawReceipts(ctx, api._blockReader, tx, block.NumberU64())
+		if err != nil {
+			return nil, err
+		}
 		if len(receipts) <= int(txIndex) {
 			return nil, fmt.Errorf("block has less receipts than expected: %d <= %d, block: %d", len(receipts), int(txIndex), block.NumberU64())
 		}
-		return NewRPCTransaction(txs[txIndex], block.Hash(), block.NumberU64(), uint64(txIndex), block.BaseFee(), receipts[txIndex]), nil
+		return newRPCTransactionLegacyAware(chainConfig, txs[txIndex], block.Hash(), block.NumberU64(), uint64(txIndex), block.BaseFee(), receipts[txIndex]), nil
 	}
 
-	return NewRPCTransaction(txs[txIndex], block.Hash(), block.NumberU64(), uint64(txIndex), block.BaseFee(), nil), nil
+	return newRPCTransactionLegacyAware(chainConfig, txs[txIndex], block.Hash(), block.NumberU64(), uint64(txIndex), block.BaseFee(), nil), nil
 }
 
 // GetRawTransactionByBlockHashAndIndex returns the bytes of the transaction for the given block hash and index.
@@ -274,13 +273,16 @@ func (api *APIImpl) GetTransactionByBlockNumberAndIndex(ctx context.Context, blo
 		return newRPCBorTransaction(borTx, derivedBorTxHash, hash, blockNum, uint64(txIndex), block.BaseFee(), chainConfig.ChainID), nil
 	}
 	if chainConfig.IsOptimism() {
-		receipts := rawdb.ReadRawReceipts(tx, block.NumberU64())
+		receipts, err := readRawReceipts(ctx, api._blockReader, tx, block.NumberU64())
+		if err != nil {
+			return nil, err
+		}
 		if len(receipts) <= int(txIndex) {
 			return nil, fmt.Errorf("block has less receipts than expected: %d <= %d, block: %d", len(receipts), int(txIndex), block.NumberU64())
 		}
-		return NewRPCTransaction(txs[txIndex], block.Hash(), blockNum, uint64(txIndex), block.BaseFee(), receipts[txIndex]), nil
+		return newRPCTransactionLegacyAware(chainConfig, txs[txIndex], block.Hash(), blockNum, uint64(txIndex), block.BaseFee(), receipts[txIndex]), nil
 	}
-	return NewRPCTransaction(txs[txIndex], hash, blockNum, uint64(txIndex), block.BaseFee(), nil), nil
+	return newRPCTransactionLegacyAware(chainConfig, txs[txIndex], hash, blockNum, uint64(txIndex), block.BaseFee(), nil), nil
 }
 
 // GetRawTransactionByBlockNumberAndIndex returns the bytes of the transaction for the given block number and index.
@@ -302,3 +304,15 @@ func (api *APIImpl) GetRawTransactionByBlockNumberAndIndex(ctx context.Context,
 
 	return newRPCRawTransactionFromBlockIndex(block, uint64(index))
 }
+
+// txnInPool - true if the transaction is in the txpool, so not in a block yet
+func (api *APIImpl) txnInPool(ctx context.Context, txnHash common.Hash) (bool, error) {
+	if api.txPool == nil {
+		return false, nil
+	}
+	reply, err := api.txPool.Transactions(ctx, &txpool.TransactionsRequest{Hashes: []*types.H256{gointerfaces.ConvertHashToH256(txnHash)}})
+	if err != nil {
+		return false, err
+	}
+	return len(reply.RlpTxs) > 0 && len(reply.RlpTxs[0]) > 0, nil
+}
@@ -47,7 +47,10 @@ func (api *APIImpl) GetUncleByBlockNumberAndIndex(ctx context.Context, number rp
 		return nil, nil
 	}
 	uncle := types.NewBlockWithHeader(uncles[index])
-	receipts := rawdb.ReadRawReceipts(tx, blockNum)
+	receipts, err := readRawReceipts(ctx, api._blockReader, tx, blockNum)
+	if err != nil {
+		return nil, err
+	}
 	return ethapi.RPCMarshalBlock(uncle, false, false, additionalFields, receipts)
 }
 
@@ -80,7 +83,10 @@ func (api *APIImpl) GetUncleByBlockHashAndIndex(ctx context.Context, hash common
 		return nil, nil
 	}
 	uncle := types.NewBlockWithHeader(uncles[index])
-	receipts := rawdb.ReadRawReceipts(tx, number)
+	receipts, err := readRawReceipts(ctx, api._blockReader, tx, number)
+	if err != nil {
+		return nil, err
+	}
 	return ethapi.RPCMarshalBlock(uncle, false, false, additionalFields, receipts)
 }
 
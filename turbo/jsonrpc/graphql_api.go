@@ -7,29 +7,50 @@ import (
 
 	"github.com/erigontech/erigon-lib/common"
 	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/common/hexutility"
 	"github.com/erigontech/erigon-lib/kv"
 	"github.com/erigontech/erigon/core/rawdb"
 	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/core/vm/evmtypes"
 	"github.com/erigontech/erigon/eth/ethutils"
+	"github.com/erigontech/erigon/eth/filters"
 	"github.com/erigontech/erigon/rpc"
 	"github.com/erigontech/erigon/turbo/adapter/ethapi"
 	"github.com/erigontech/erigon/turbo/rpchelper"
+	"github.com/erigontech/erigon/turbo/transactions"
 )
 
 type GraphQLAPI interface {
 	GetBlockDetails(ctx context.Context, number rpc.BlockNumber) (map[string]interface{}, error)
+	GetBlockDetailsByHash(ctx context.Context, hash common.Hash) (map[string]interface{}, error)
+	GetTransactionDetails(ctx context.Context, hash common.Hash) (map[string]interface{}, error)
+	GetLogs(ctx context.Context, crit filters.FilterCriteria) (types.Logs, error)
+	GetBalance(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Big, error)
+	GetTransactionCount(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Uint64, error)
+	GetCode(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (hexutility.Bytes, error)
+	GetStorageAt(ctx context.Context, address common.Address, index string, blockNrOrHash rpc.BlockNumberOrHash) (string, error)
+	GasPrice(ctx context.Context) (*hexutil.Big, error)
+	MaxPriorityFeePerGas(ctx context.Context) (*hexutil.Big, error)
+	Syncing(ctx context.Context) (interface{}, error)
+	SendRawTransaction(ctx context.Context, encodedTx hexutility.Bytes) (common.Hash, error)
 	GetChainID(ctx context.Context) (*big.Int, error)
+	Call(ctx context.Context, args ethapi.CallArgs, blockNrOrHash rpc.BlockNumberOrHash) (*evmtypes.ExecutionResult, error)
+	EstimateGas(ctx context.Context, args ethapi.CallArgs, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Uint64, error)
 }
 
+// GraphQLAPIImpl serves the GraphQL schema. Log search, account state, fee estimates and transaction submission
+// are delegated to the eth API so that both endpoints give the same answers.
 type GraphQLAPIImpl struct {
 	*BaseAPI
-	db kv.RoDB
+	db  kv.RoDB
+	eth *APIImpl
 }
 
-func NewGraphQLAPI(base *BaseAPI, db kv.RoDB) *GraphQLAPIImpl {
+func NewGraphQLAPI(base *BaseAPI, db kv.RoDB, eth *APIImpl) *GraphQLAPIImpl {
 	return &GraphQLAPIImpl{
 		BaseAPI: base,
 		db:      db,
+		eth:     eth,
 	}
 }
 
@@ -62,12 +83,137 @@ func (api *GraphQLAPIImpl) GetBlockDetails(ctx context.Context, blockNumber rpc.
 	if block == nil {
 		return nil, nil
 	}
+	return api.blockDetails(ctx, tx, block, senders, blockNumber, nil)
+}
+
+func (api *GraphQLAPIImpl) GetBlockDetailsByHash(ctx context.Context, hash common.Hash) (map[string]interface{}, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	block, err := api.blockByHashWithSenders(ctx, tx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, nil
+	}
+	return api.blockDetails(ctx, tx, block, block.Body().SendersFromTxs(), rpc.BlockNumber(block.NumberU64()), nil)
+}
+
+// GetTransactionDetails returns the details of the block including the transaction, like GetBlockDetails, but with
+// the receipt of that transaction only. It returns nil if the transaction is not found.
+func (api *GraphQLAPIImpl) GetTransactionDetails(ctx context.Context, hash common.Hash) (map[string]interface{}, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	blockNum, ok, err := api.txnLookup(ctx, tx, hash)
+	if err != nil || !ok {
+		return nil, err
+	}
+	block, err := api.blockByNumberWithSenders(ctx, tx, blockNum)
+	if err != nil || block == nil {
+		return nil, err
+	}
+	return api.blockDetails(ctx, tx, block, block.Body().SendersFromTxs(), rpc.BlockNumber(blockNum), &hash)
+}
+
+func (api *GraphQLAPIImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria) (types.Logs, error) {
+	return api.eth.GetLogs(ctx, crit)
+}
+
+func (api *GraphQLAPIImpl) GetBalance(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Big, error) {
+	return api.eth.GetBalance(ctx, address, blockNrOrHash)
+}
+
+func (api *GraphQLAPIImpl) GetTransactionCount(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Uint64, error) {
+	return api.eth.GetTransactionCount(ctx, address, blockNrOrHash)
+}
+
+func (api *GraphQLAPIImpl) GetCode(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (hexutility.Bytes, error) {
+	return api.eth.GetCode(ctx, address, blockNrOrHash)
+}
+
+func (api *GraphQLAPIImpl) GetStorageAt(ctx context.Context, address common.Address, index string, blockNrOrHash rpc.BlockNumberOrHash) (string, error) {
+	return api.eth.GetStorageAt(ctx, address, index, blockNrOrHash)
+}
+
+func (api *GraphQLAPIImpl) GasPrice(ctx context.Context) (*hexutil.Big, error) {
+	return api.eth.GasPrice(ctx)
+}
+
+func (api *GraphQLAPIImpl) MaxPriorityFeePerGas(ctx context.Context) (*hexutil.Big, error) {
+	return api.eth.MaxPriorityFeePerGas(ctx)
+}
 
-	getBlockRes, err := api.delegateGetBlockByNumber(tx, block, blockNumber, false)
+func (api *GraphQLAPIImpl) Syncing(ctx context.Context) (interface{}, error) {
+	return api.eth.Syncing(ctx)
+}
+
+func (api *GraphQLAPIImpl) SendRawTransaction(ctx context.Context, encodedTx hexutility.Bytes) (common.Hash, error) {
+	return api.eth.SendRawTransaction(ctx, encodedTx)
+}
+
+// Call executes the call at the state of the block like eth_call, the result also tells the gas used and whether
+// the call failed. The state of pre-Bedrock blocks and of blocks with pruned history isn't served.
+func (api *GraphQLAPIImpl) Call(ctx context.Context, args ethapi.CallArgs, blockNrOrHash rpc.BlockNumberOrHash) (*evmtypes.ExecutionResult, error) {
+	tx, err := api.db.BeginRo(ctx)
 	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback()
 
+	chainConfig, err := api.chainConfig(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	blockNumber, hash, _, err := rpchelper.GetCanonicalBlockNumber(blockNrOrHash, tx, api.filters) // DoCall cannot be executed on non-canonical blocks
+	if err != nil {
+		return nil, err
+	}
+	if chainConfig.IsOptimismPreBedrock(blockNumber) {
+		return nil, fmt.Errorf("state of pre-bedrock block %d is not available", blockNumber)
+	}
+	if err := api.checkPruneHistory(tx, blockNumber); err != nil {
+		return nil, err
+	}
+	block, err := api.blockWithSenders(ctx, tx, hash, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block %d is not found", blockNumber)
+	}
+
+	gasCap := methodGasCap(ctx, api.eth.GasCap)
+	if args.Gas == nil || uint64(*args.Gas) == 0 {
+		args.Gas = (*hexutil.Uint64)(&gasCap)
+	}
+	stateReader, err := rpchelper.CreateStateReader(ctx, tx, blockNrOrHash, 0, api.filters, api.stateCache, api.historyV3(tx), chainConfig.ChainName)
+	if err != nil {
+		return nil, err
+	}
+	result, err := transactions.DoCall(ctx, api.engine(), args, tx, blockNrOrHash, block.HeaderNoCopy(), nil, gasCap, chainConfig, stateReader, api._blockReader, api.evmCallTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.ReturnData) > api.eth.ReturnDataLimit {
+		return nil, fmt.Errorf("call returned result on length %d exceeding --rpc.returndata.limit %d", len(result.ReturnData), api.eth.ReturnDataLimit)
+	}
+	return result, nil
+}
+
+func (api *GraphQLAPIImpl) EstimateGas(ctx context.Context, args ethapi.CallArgs, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Uint64, error) {
+	return api.eth.EstimateGas(ctx, &args, &blockNrOrHash, nil)
+}
+
+// blockDetails marshals the block and the receipts of its transactions, or only the receipt of txnHash if it's set.
+func (api *GraphQLAPIImpl) blockDetails(ctx context.Context, tx kv.Tx, block *types.Block, senders []common.Address, blockNumber rpc.BlockNumber, txnHash *common.Hash) (map[string]interface{}, error) {
 	chainConfig, err := api.chainConfig(ctx, tx)
 	if err != nil {
 		return nil, err
@@ -78,15 +224,27 @@ func (api *GraphQLAPIImpl) GetBlockDetails(ctx context.Context, blockNumber rpc.
 		return nil, fmt.Errorf("getReceipts error: %w", err)
 	}
 
+	getBlockRes, err := api.delegateGetBlockByNumber(tx, block, blockNumber, receipts)
+	if err != nil {
+		return nil, err
+	}
+
 	result := make([]map[string]interface{}, 0, len(receipts))
 	for _, receipt := range receipts {
 		txn := block.Transactions()[receipt.TransactionIndex]
+		if txnHash != nil && txn.Hash() != *txnHash {
+			continue
+		}
 
 		transaction := ethutils.MarshalReceipt(receipt, txn, chainConfig, block.HeaderNoCopy(), txn.Hash(), true)
 		transaction["nonce"] = txn.GetNonce()
 		transaction["value"] = txn.GetValue()
 		transaction["data"] = txn.GetData()
 		transaction["logs"] = receipt.Logs
+		transaction["gas"] = txn.GetGas()
+		// for the OP-stack fields
+		transaction["txn"] = txn
+		transaction["receipt"] = receipt
 		result = append(result, transaction)
 	}
 
@@ -99,7 +257,11 @@ func (api *GraphQLAPIImpl) GetBlockDetails(ctx context.Context, blockNumber rpc.
 
 func (api *GraphQLAPIImpl) getBlockWithSenders(ctx context.Context, number rpc.BlockNumber, tx kv.Tx) (*types.Block, []common.Address, error) {
 	if number == rpc.PendingBlockNumber {
-		return api.pendingBlock(), nil, nil
+		block := api.pendingBlock()
+		if block == nil {
+			return nil, nil, nil
+		}
+		return block, block.Body().SendersFromTxs(), nil
 	}
 
 	blockHeight, blockHash, _, err := rpchelper.GetBlockNumber(rpc.BlockNumberOrHashWithNumber(number), tx, api.filters)
@@ -117,17 +279,14 @@ func (api *GraphQLAPIImpl) getBlockWithSenders(ctx context.Context, number rpc.B
 	return block, block.Body().SendersFromTxs(), nil
 }
 
-func (api *GraphQLAPIImpl) delegateGetBlockByNumber(tx kv.Tx, b *types.Block, number rpc.BlockNumber, inclTx bool) (map[string]interface{}, error) {
+func (api *GraphQLAPIImpl) delegateGetBlockByNumber(tx kv.Tx, b *types.Block, number rpc.BlockNumber, receipts types.Receipts) (map[string]interface{}, error) {
 	td, err := rawdb.ReadTd(tx, b.Hash(), b.NumberU64())
 	if err != nil {
 		return nil, err
 	}
 	additionalFields := make(map[string]interface{})
-	receipts := rawdb.ReadRawReceipts(tx, uint64(number.Int64()))
-	response, err := ethapi.RPCMarshalBlock(b, inclTx, inclTx, additionalFields, receipts)
-	if !inclTx {
-		delete(response, "transactions") // workaround for https://github.com/erigontech/erigon/issues/4989#issuecomment-1218415666
-	}
+	response, err := ethapi.RPCMarshalBlock(b, false, false, additionalFields, receipts)
+	delete(response, "transactions") // workaround for https://github.com/erigontech/erigon/issues/4989#issuecomment-1218415666
 	response["totalDifficulty"] = (*hexutil.Big)(td)
 	response["transactionCount"] = b.Transactions().Len()
 
@@ -0,0 +1,215 @@
+package jsonrpc
+
+import (
+	"context"
+	"fmt"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/kv"
+
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/crypto"
+	"github.com/erigontech/erigon/rpc"
+)
+
+// OptimismAPI the interface for the optimism_ RPC commands
+type OptimismAPI interface {
+	L1FeeParamsAt(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*L1FeeParams, error)
+	OutputAtBlock(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*OutputResult, error)
+}
+
+// OptimismAPIImpl data structure to store things needed for optimism_ commands
+type OptimismAPIImpl struct {
+	*BaseAPI
+	db  kv.RoDB
+	eth *APIImpl // the proofs of optimism_outputAtBlock
+}
+
+// NewOptimismAPI returns OptimismAPIImpl instance
+func NewOptimismAPI(base *BaseAPI, db kv.RoDB, eth *APIImpl) *OptimismAPIImpl {
+	return &OptimismAPIImpl{
+		BaseAPI: base,
+		db:      db,
+		eth:     eth,
+	}
+}
+
+// L1FeeParams - the L1 attributes of an L2 block, which the L1 data fee of its transactions is computed from.
+// Fields of the other format are omitted: l1FeeOverhead and l1FeeScalar are set before Ecotone only,
+// blobBaseFee and the scalars after it.
+type L1FeeParams struct {
+	BlockNumber       hexutil.Uint64    `json:"blockNumber"`
+	BlockHash         libcommon.Hash    `json:"blockHash"`
+	Format            string            `json:"format"` // bedrock or ecotone
+	L1BlockNumber     hexutil.Uint64    `json:"l1BlockNumber"`
+	L1BlockHash       libcommon.Hash    `json:"l1BlockHash"`
+	L1Timestamp       hexutil.Uint64    `json:"l1Timestamp"`
+	SequenceNumber    hexutil.Uint64    `json:"sequenceNumber"`
+	BatcherAddr       libcommon.Address `json:"batcherAddr"`
+	BaseFee           *hexutil.Big      `json:"baseFee"`
+	BlobBaseFee       *hexutil.Big      `json:"blobBaseFee,omitempty"`
+	BaseFeeScalar     *hexutil.Uint64   `json:"baseFeeScalar,omitempty"`
+	BlobBaseFeeScalar *hexutil.Uint64   `json:"blobBaseFeeScalar,omitempty"`
+	L1FeeOverhead     *hexutil.Big      `json:"l1FeeOverhead,omitempty"`
+	L1FeeScalar       *hexutil.Big      `json:"l1FeeScalar,omitempty"`
+}
+
+func newL1FeeParams(block *types.Block, info *types.L1BlockInfo) *L1FeeParams {
+	params := &L1FeeParams{
+		BlockNumber:    hexutil.Uint64(block.NumberU64()),
+		BlockHash:      block.Hash(),
+		L1BlockNumber:  hexutil.Uint64(info.Number),
+		L1BlockHash:    info.BlockHash,
+		L1Timestamp:    hexutil.Uint64(info.Time),
+		SequenceNumber: hexutil.Uint64(info.SequenceNumber),
+		BatcherAddr:    info.BatcherAddr,
+		BaseFee:        (*hexutil.Big)(info.BaseFee),
+	}
+	if info.Ecotone() {
+		baseFeeScalar, blobBaseFeeScalar := hexutil.Uint64(info.BaseFeeScalar), hexutil.Uint64(info.BlobBaseFeeScalar)
+		params.Format = "ecotone"
+		params.BlobBaseFee = (*hexutil.Big)(info.BlobBaseFee)
+		params.BaseFeeScalar = &baseFeeScalar
+		params.BlobBaseFeeScalar = &blobBaseFeeScalar
+	} else {
+		params.Format = "bedrock"
+		params.L1FeeOverhead = (*hexutil.Big)(info.L1FeeOverhead)
+		params.L1FeeScalar = (*hexutil.Big)(info.L1FeeScalar)
+	}
+	return params
+}
+
+// L1FeeParamsAt implements optimism_l1FeeParamsAt. Returns the L1 attributes decoded from the L1 info deposit
+// of the block.
+func (api *OptimismAPIImpl) L1FeeParamsAt(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*L1FeeParams, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	chainConfig, err := api.chainConfig(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	if !chainConfig.IsOptimism() {
+		return nil, fmt.Errorf("not an OP Stack chain")
+	}
+	blockNum, err := api.blockNumberFromBlockNumberOrHash(tx, &blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if chainConfig.IsOptimismPreBedrock(blockNum) {
+		return nil, fmt.Errorf("block %d is pre-bedrock, it has no L1 attributes", blockNum)
+	}
+	block, err := api.blockByNumberWithSenders(ctx, tx, blockNum)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block %d not found", blockNum)
+	}
+	info, err := types.L1BlockInfoFromBlock(block)
+	if err != nil {
+		return nil, fmt.Errorf("block %d: %w", blockNum, err)
+	}
+	return newL1FeeParams(block, info), nil
+}
+
+// l2ToL1MessagePasserAddr - the predeploy storing the withdrawals sent from L2, its storage root is committed to by
+// the output root
+var l2ToL1MessagePasserAddr = libcommon.HexToAddress("0x4200000000000000000000000000000000000016")
+
+// OutputResult - the output root of an L2 block, and the components it is hashed from. The fields are the ones of
+// op-node's optimism_outputAtBlock, so that its clients (e.g. op-proposer) can use either.
+type OutputResult struct {
+	Version               libcommon.Hash `json:"version"`
+	OutputRoot            libcommon.Hash `json:"outputRoot"`
+	BlockRef              L2BlockRef     `json:"blockRef"`
+	WithdrawalStorageRoot libcommon.Hash `json:"withdrawalStorageRoot"`
+	StateRoot             libcommon.Hash `json:"stateRoot"`
+	SyncStatus            *struct{}      `json:"syncStatus"` // of op-node, always null: unknown to the execution layer
+}
+
+// L2BlockRef - an L2 block and its L1 origin, numbers are JSON numbers as in op-node
+type L2BlockRef struct {
+	Hash           libcommon.Hash `json:"hash"`
+	Number         uint64         `json:"number"`
+	ParentHash     libcommon.Hash `json:"parentHash"`
+	Time           uint64         `json:"timestamp"`
+	L1Origin       L1BlockID      `json:"l1origin"`
+	SequenceNumber uint64         `json:"sequenceNumber"` // of the block in the epoch of its L1 origin
+}
+
+type L1BlockID struct {
+	Hash   libcommon.Hash `json:"hash"`
+	Number uint64         `json:"number"`
+}
+
+// outputRootV0 - keccak256(version ++ stateRoot ++ withdrawalStorageRoot ++ blockHash), version 0 of the output root
+// proposed to L1
+func outputRootV0(stateRoot, withdrawalStorageRoot, blockHash libcommon.Hash) libcommon.Hash {
+	var version libcommon.Hash
+	return crypto.Keccak256Hash(version[:], stateRoot[:], withdrawalStorageRoot[:], blockHash[:])
+}
+
+// OutputAtBlock implements optimism_outputAtBlock. Returns the output root of the block, the storage root of
+// L2ToL1MessagePasser is computed by the proof of eth_getProof, so it is checked against the state root of the block
+// and limited to the same blocks (see --rpc.maxgetproofrewindblockcount.limit). The L1 origin of the block is read
+// from its L1 attributes deposit.
+func (api *OptimismAPIImpl) OutputAtBlock(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*OutputResult, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	chainConfig, err := api.chainConfig(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	if !chainConfig.IsOptimism() {
+		return nil, fmt.Errorf("not an OP Stack chain")
+	}
+	if api.historyV3(tx) {
+		return nil, fmt.Errorf("not supported by Erigon3")
+	}
+	blockNum, err := api.blockNumberFromBlockNumberOrHash(tx, &blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if chainConfig.IsOptimismPreBedrock(blockNum) {
+		return nil, fmt.Errorf("block %d is pre-bedrock, it has no output root", blockNum)
+	}
+	block, err := api.blockByNumberWithSenders(ctx, tx, blockNum)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block %d not found", blockNum)
+	}
+	info, err := types.L1BlockInfoFromBlock(block)
+	if err != nil {
+		return nil, fmt.Errorf("block %d: %w", blockNum, err)
+	}
+	// by hash: the proof is of this block, whatever became canonical since
+	blockHash := block.Hash()
+	proof, err := api.eth.getProof(ctx, tx, l2ToL1MessagePasserAddr, nil, rpc.BlockNumberOrHashWithHash(blockHash, true))
+	if err != nil {
+		return nil, fmt.Errorf("storage root of L2ToL1MessagePasser at block %d: %w", blockNum, err)
+	}
+	return &OutputResult{
+		OutputRoot: outputRootV0(block.Root(), proof.StorageHash, blockHash),
+		BlockRef: L2BlockRef{
+			Hash:           blockHash,
+			Number:         blockNum,
+			ParentHash:     block.ParentHash(),
+			Time:           block.Time(),
+			L1Origin:       L1BlockID{Hash: info.BlockHash, Number: info.Number},
+			SequenceNumber: info.SequenceNumber,
+		},
+		WithdrawalStorageRoot: proof.StorageHash,
+		StateRoot:             block.Root(),
+	}, nil
+}
@@ -0,0 +1,42 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+)
+
+func TestOutputRootV0(t *testing.T) {
+	stateRoot := libcommon.HexToHash("0x01")
+	withdrawalStorageRoot := libcommon.HexToHash("0x02")
+	blockHash := libcommon.HexToHash("0x03")
+	require.Equal(t, libcommon.HexToHash("0x4264985a7b193d369bf2635712f0f25f121ee53f9d0adad71390d0e7a7125215"), outputRootV0(stateRoot, withdrawalStorageRoot, blockHash))
+}
+
+func TestOutputResultJSON(t *testing.T) {
+	out, err := json.Marshal(&OutputResult{
+		BlockRef: L2BlockRef{Number: 16, Time: 1700000000, L1Origin: L1BlockID{Number: 5}, SequenceNumber: 2},
+	})
+	require.NoError(t, err)
+	var fields map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(out, &fields))
+	require.ElementsMatch(t, []string{"version", "outputRoot", "blockRef", "withdrawalStorageRoot", "stateRoot", "syncStatus"}, jsonKeys(fields))
+	require.Equal(t, "null", string(fields["syncStatus"]))
+
+	var ref map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(fields["blockRef"], &ref))
+	require.ElementsMatch(t, []string{"hash", "number", "parentHash", "timestamp", "l1origin", "sequenceNumber"}, jsonKeys(ref))
+	require.Equal(t, "16", string(ref["number"]))
+	require.JSONEq(t, `{"hash":"0x0000000000000000000000000000000000000000000000000000000000000000","number":5}`, string(ref["l1origin"]))
+}
+
+func jsonKeys(fields map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/erigontech/erigon/rpc"
 	"github.com/erigontech/erigon/turbo/adapter/ethapi"
 	"github.com/erigontech/erigon/turbo/rpchelper"
+	"github.com/erigontech/erigon/turbo/services"
 	"github.com/erigontech/erigon/turbo/transactions"
 )
 
@@ -330,7 +331,10 @@ func (api *OtterscanAPIImpl) searchTransactionsBeforeV3(tx kv.TemporalTx, ctx co
 		var rpcTx *RPCTransaction
 		var receipt *types.Receipt
 		if chainConfig.IsOptimism() {
-			receipts := rawdb.ReadRawReceipts(tx, blockNum)
+			receipts, err := readRawReceipts(ctx, api._blockReader, tx, blockNum)
+			if err != nil {
+				return nil, err
+			}
 			if len(receipts) <= txIndex {
 				return nil, fmt.Errorf("block has less receipts than expected: %d <= %d, block: %d", len(receipts), txIndex, blockNum)
 			}
@@ -489,13 +493,16 @@ func (api *OtterscanAPIImpl) traceBlocks(ctx context.Context, addr common.Addres
 	return results[:totalBlocksTraced], hasMore, nil
 }
 
-func delegateGetBlockByNumber(tx kv.Tx, b *types.Block, number rpc.BlockNumber, inclTx bool) (map[string]interface{}, error) {
+func delegateGetBlockByNumber(ctx context.Context, br services.FullBlockReader, tx kv.Tx, b *types.Block, number rpc.BlockNumber, inclTx bool) (map[string]interface{}, error) {
 	td, err := rawdb.ReadTd(tx, b.Hash(), b.NumberU64())
 	if err != nil {
 		return nil, err
 	}
 	additionalFields := make(map[string]interface{})
-	receipts := rawdb.ReadRawReceipts(tx, uint64(number.Int64()))
+	receipts, err := readRawReceipts(ctx, br, tx, uint64(number.Int64()))
+	if err != nil {
+		return nil, err
+	}
 	response, err := ethapi.RPCMarshalBlock(b, inclTx, inclTx, additionalFields, receipts)
 	if !inclTx {
 		delete(response, "transactions") // workaround for https://github.com/erigontech/erigon/issues/4989#issuecomment-1218415666
@@ -617,7 +624,7 @@ func (api *OtterscanAPIImpl) GetBlockTransactions(ctx context.Context, number rp
 		return nil, err
 	}
 
-	getBlockRes, err := delegateGetBlockByNumber(tx, b, number, true)
+	getBlockRes, err := delegateGetBlockByNumber(ctx, api._blockReader, tx, b, number, true)
 	if err != nil {
 		return nil, err
 	}
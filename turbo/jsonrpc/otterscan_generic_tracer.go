@@ -94,7 +94,7 @@ func (api *OtterscanAPIImpl) genericTracer(dbtx kv.Tx, ctx context.Context, bloc
 
 		ibs.SetTxContext(tx.Hash(), block.Hash(), idx)
 
-		msg, _ := tx.AsMessage(*signer, header.BaseFee, rules)
+		msg, _ := types.AsMessageLegacyAware(chainConfig, blockNum, tx, *signer, header.BaseFee, rules)
 
 		BlockContext := core.NewEVMBlockContext(header, core.GetHashFn(header, getHeader), engine, nil)
 		BlockContext.L1CostFunc = opstack.NewL1CostFunc(chainConfig, ibs)
@@ -93,7 +93,7 @@ func (api *OtterscanAPIImpl) traceBlock(dbtx kv.Tx, ctx context.Context, blockNu
 		}
 		ibs.SetTxContext(tx.Hash(), block.Hash(), idx)
 
-		msg, _ := tx.AsMessage(*signer, header.BaseFee, rules)
+		msg, _ := types.AsMessageLegacyAware(chainConfig, blockNum, tx, *signer, header.BaseFee, rules)
 
 		tracer := NewTouchTracer(searchAddr)
 		BlockContext := core.NewEVMBlockContext(header, core.GetHashFn(header, getHeader), engine, nil)
@@ -186,7 +186,7 @@ func (api *OverlayAPIImpl) CallConstructor(ctx context.Context, address common.A
 	gp := new(core.GasPool).AddGas(math.MaxUint64).AddBlobGas(math.MaxUint64)
 	for idx, txn := range replayTransactions {
 		statedb.SetTxContext(txn.Hash(), block.Hash(), idx)
-		msg, err := txn.AsMessage(*signer, block.BaseFee(), rules)
+		msg, err := types.AsMessageLegacyAware(chainConfig, blockNum, txn, *signer, block.BaseFee(), rules)
 		if err != nil {
 			return nil, err
 		}
@@ -205,7 +205,7 @@ func (api *OverlayAPIImpl) CallConstructor(ctx context.Context, address common.A
 	statedb.SetTxContext(creationTx.Hash(), block.Hash(), transactionIndex)
 
 	// CREATE2: keep original message so we match the existing contract address, code will be replaced later
-	msg, err := creationTx.AsMessage(*signer, block.BaseFee(), rules)
+	msg, err := types.AsMessageLegacyAware(chainConfig, blockNum, creationTx, *signer, block.BaseFee(), rules)
 	if err != nil {
 		return nil, err
 	}
@@ -394,7 +394,7 @@ blockLoop:
 		}
 		logs = append(logs, res.Logs...)
 	}
-	return logs, nil
+	return rpchelper.CanonicalLogs(logs), nil
 }
 
 func filterLogs(logs types.Logs, addresses []common.Address, topics [][]common.Hash) []*types.Log {
@@ -506,7 +506,7 @@ func (api *OverlayAPIImpl) replayBlock(ctx context.Context, blockNum uint64, sta
 	for idx, txn := range replayTransactions {
 		log.Debug("[replayBlock] replaying transaction", "idx", idx, "transactionHash", txn.Hash())
 
-		msg, err := txn.AsMessage(*signer, block.BaseFee(), rules)
+		msg, err := types.AsMessageLegacyAware(chainConfig, blockNum, txn, *signer, block.BaseFee(), rules)
 		if err != nil {
 			log.Error(err.Error())
 			return nil, err
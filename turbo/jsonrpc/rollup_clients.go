@@ -0,0 +1,57 @@
+package jsonrpc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/rpc"
+)
+
+const rollupDialTimeout = 5 * time.Second
+
+// RollupClients - OP Stack services which requests are relayed to: transactions to the sequencer, pre-bedrock
+// history to the legacy node and pruned history to an archive node. A client is nil if it's not configured.
+// Shared by the embedded and the standalone rpcdaemon, so both serve the same OP data.
+type RollupClients struct {
+	Sequencer  *rpc.Client
+	Historical *rpc.Client
+	Archive    *rpc.Client
+}
+
+// DialRollupClients - connects to the configured services, empty urls are skipped
+func DialRollupClients(sequencerHTTP, historicalRPC string, historicalRPCTimeout time.Duration, archiveRPC string, logger log.Logger) (*RollupClients, error) {
+	dial := func(url string, timeout time.Duration) (*rpc.Client, error) {
+		if url == "" {
+			return nil, nil
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		return rpc.DialContext(ctx, url, logger)
+	}
+
+	clients := &RollupClients{}
+	var err error
+	if clients.Sequencer, err = dial(sequencerHTTP, rollupDialTimeout); err != nil {
+		return nil, fmt.Errorf("dial sequencer: %w", err)
+	}
+	if clients.Historical, err = dial(historicalRPC, historicalRPCTimeout); err != nil {
+		clients.Close()
+		return nil, fmt.Errorf("dial historical rpc: %w", err)
+	}
+	if clients.Archive, err = dial(archiveRPC, rollupDialTimeout); err != nil {
+		clients.Close()
+		return nil, fmt.Errorf("dial archive rpc: %w", err)
+	}
+	return clients, nil
+}
+
+func (c *RollupClients) Close() {
+	for _, client := range []*rpc.Client{c.Sequencer, c.Historical, c.Archive} {
+		if client != nil {
+			client.Close()
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package jsonrpc
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutility"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/rpc"
+)
+
+func TestDialRollupClients(t *testing.T) {
+	logger := log.New()
+
+	// nothing configured
+	clients, err := DialRollupClients("", "", time.Second, "", logger)
+	require.NoError(t, err)
+	require.Nil(t, clients.Sequencer)
+	require.Nil(t, clients.Historical)
+	require.Nil(t, clients.Archive)
+	clients.Close()
+
+	service := &sequencerServiceMock{known: map[common.Hash]bool{}}
+	server := rpc.NewServer(50, false, false, false, logger, 0)
+	require.NoError(t, server.RegisterName("eth", service))
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	clients, err = DialRollupClients(httpServer.URL, "", time.Second, httpServer.URL, logger)
+	require.NoError(t, err)
+	defer clients.Close()
+	require.NotNil(t, clients.Sequencer)
+	require.Nil(t, clients.Historical)
+	require.NotNil(t, clients.Archive)
+	var hash common.Hash
+	require.NoError(t, clients.Sequencer.CallContext(context.Background(), &hash, "eth_sendRawTransaction", hexutility.Bytes{1}))
+	require.Equal(t, common.BytesToHash([]byte{1}), hash)
+	require.Equal(t, 1, service.calls)
+
+	// a service which can't be dialed fails them all
+	_, err = DialRollupClients(httpServer.URL, "unknown://historical", time.Second, "", logger)
+	require.ErrorContains(t, err, "dial historical rpc")
+	_, err = DialRollupClients(httpServer.URL, "", time.Second, "unknown://archive", logger)
+	require.ErrorContains(t, err, "dial archive rpc")
+	_, err = DialRollupClients("unknown://sequencer", httpServer.URL, time.Second, httpServer.URL, logger)
+	require.ErrorContains(t, err, "dial sequencer")
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/erigontech/erigon/core/types"
 	"github.com/erigontech/erigon/eth/ethconfig"
 	"github.com/erigontech/erigon/params"
+	"github.com/erigontech/erigon/rpc"
 )
 
 // SendRawTransaction implements eth_sendRawTransaction. Creates new message call transaction or a contract creation for previously-signed transactions.
@@ -49,11 +50,8 @@ func (api *APIImpl) SendRawTransaction(ctx context.Context, encodedTx hexutility
 		return common.Hash{}, types.ErrTxTypeNotSupported
 	}
 
-	if api.seqRPCService != nil {
-		if err := api.seqRPCService.CallContext(ctx, nil, "eth_sendRawTransaction", hexutility.Encode(encodedTx)); err != nil {
-			return common.Hash{}, err
-		}
-		return txn.Hash(), nil
+	if api.sequencer != nil {
+		return api.forwardToSequencer(ctx, txn.Hash(), encodedTx, "eth_sendRawTransaction", hexutility.Encode(encodedTx))
 	}
 
 	// If the transaction fee cap is already specified, ensure the
@@ -87,6 +85,70 @@ func (api *APIImpl) SendRawTransaction(ctx context.Context, encodedTx hexutility
 	return txn.Hash(), nil
 }
 
+// SendRawTransactionConditional implements eth_sendRawTransactionConditional of OP Stack: the sequencer includes the
+// transaction only if its block and the state of the known accounts meet the conditions. A replica rejects the
+// conditions its head already rules out and forwards the rest to the sequencer, which enforces them.
+func (api *APIImpl) SendRawTransactionConditional(ctx context.Context, encodedTx hexutility.Bytes, cond TransactionConditional) (common.Hash, error) {
+	if api.sequencer == nil {
+		return common.Hash{}, fmt.Errorf(NotImplemented, "eth_sendRawTransactionConditional")
+	}
+	txn, err := types.DecodeWrappedTransaction(encodedTx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if err := checkTxFee(txn.GetPrice().ToBig(), txn.GetGas(), api.FeeCap); err != nil {
+		return common.Hash{}, err
+	}
+	if !txn.Protected() && !api.AllowUnprotectedTxs {
+		return common.Hash{}, errors.New("only replay-protected (EIP-155) transactions allowed over RPC")
+	}
+
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	defer tx.Rollback()
+	cc, err := api.chainConfig(ctx, tx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if cc.IsOptimism() && txn.Type() == types.BlobTxType {
+		return common.Hash{}, types.ErrTxTypeNotSupported
+	}
+	head, err := api.headerByRPCNumber(ctx, rpc.LatestBlockNumber, tx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if err := cond.check(head); err != nil {
+		return common.Hash{}, err
+	}
+	return api.forwardToSequencer(ctx, txn.Hash(), encodedTx, "eth_sendRawTransactionConditional", hexutility.Encode(encodedTx), cond)
+}
+
+// forwardToSequencer - the hash returned by the sequencer. With --rollup.sequencerlocaltxpool the transaction is also
+// added to the txpool of the replica, a txpool rejecting it doesn't fail the call: the sequencer already has it. A
+// replica without txpool, or a txpool not answering for the transaction, is an error.
+func (api *APIImpl) forwardToSequencer(ctx context.Context, txnHash common.Hash, encodedTx hexutility.Bytes, method string, args ...interface{}) (common.Hash, error) {
+	if api.sequencer.localPool && api.txPool == nil {
+		return common.Hash{}, errors.New("forwarding to the sequencer with the local txpool, but there is no txpool")
+	}
+	hash, err := api.sequencer.send(ctx, txnHash, method, args...)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if api.sequencer.localPool {
+		res, err := api.txPool.Add(ctx, &txPoolProto.AddRequest{RlpTxs: [][]byte{encodedTx}})
+		if err != nil {
+			api.logger.Debug("Forwarded transaction is not added to txpool", "hash", hash, "err", err)
+		} else if len(res.Imported) == 0 {
+			return common.Hash{}, fmt.Errorf("transaction %x is forwarded to the sequencer, but txpool returned no result for it", hash)
+		} else if res.Imported[0] != txPoolProto.ImportResult_SUCCESS {
+			api.logger.Debug("Forwarded transaction is not added to txpool", "hash", hash, "result", txPoolProto.ImportResult_name[int32(res.Imported[0])], "errors", res.Errors)
+		}
+	}
+	return hash, nil
+}
+
 // SendTransaction implements eth_sendTransaction. Creates new message call transaction or a contract creation if the data field contains code.
 func (api *APIImpl) SendTransaction(_ context.Context, txObject interface{}) (common.Hash, error) {
 	return common.Hash{0}, fmt.Errorf(NotImplemented, "eth_sendTransaction")
@@ -0,0 +1,83 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	types2 "github.com/erigontech/erigon-lib/types"
+
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/rpc"
+)
+
+// sequencerBackend forwards the transactions sent to a replica to the sequencer (see --rollup.sequencerhttp).
+// Sending is retried with exponential backoff while the sequencer can't be reached, a transaction rejected by the
+// sequencer is not.
+type sequencerBackend struct {
+	client    *rpc.Client
+	retries   int
+	backoff   time.Duration // before the first retry, doubled by each next one
+	localPool bool          // the forwarded transactions are also added to the txpool of the replica
+}
+
+// newSequencerBackend returns nil if client is nil - forwarding is disabled
+func newSequencerBackend(client *rpc.Client, retries int, backoff time.Duration, localPool bool) *sequencerBackend {
+	if client == nil {
+		return nil
+	}
+	return &sequencerBackend{client: client, retries: retries, backoff: backoff, localPool: localPool}
+}
+
+// send - the hash of the transaction returned by the sequencer. An attempt which fails in transport may still have
+// reached the sequencer, so a retry rejected as already known returns txnHash: the transaction is sent.
+func (b *sequencerBackend) send(ctx context.Context, txnHash common.Hash, method string, args ...interface{}) (common.Hash, error) {
+	for attempt := 0; ; attempt++ {
+		var hash common.Hash
+		err := b.client.CallContext(ctx, &hash, method, args...)
+		var rejected rpc.Error // error response of the sequencer, unlike the errors of transport
+		if attempt > 0 && errors.As(err, &rejected) && strings.Contains(rejected.Error(), types2.ErrAlreadyKnown.Error()) {
+			return txnHash, nil
+		}
+		if err == nil || errors.As(err, &rejected) || attempt >= b.retries || ctx.Err() != nil {
+			return hash, err
+		}
+		select {
+		case <-ctx.Done():
+			return common.Hash{}, ctx.Err()
+		case <-time.After(b.backoff << attempt):
+		}
+	}
+}
+
+// TransactionConditional - conditions of eth_sendRawTransactionConditional: the sequencer includes the transaction
+// only into a block in the bounds, on top of the state of the known accounts
+type TransactionConditional struct {
+	KnownAccounts  map[common.Address]json.RawMessage `json:"knownAccounts"` // storage root or slots, checked by the sequencer
+	BlockNumberMin *hexutil.Big                       `json:"blockNumberMin,omitempty"`
+	BlockNumberMax *hexutil.Big                       `json:"blockNumberMax,omitempty"`
+	TimestampMin   *hexutil.Uint64                    `json:"timestampMin,omitempty"`
+	TimestampMax   *hexutil.Uint64                    `json:"timestampMax,omitempty"`
+}
+
+// check - error if no block after head is in the bounds, so the sequencer would reject the transaction anyway
+func (c *TransactionConditional) check(head *types.Header) error {
+	if c.BlockNumberMin != nil && c.BlockNumberMax != nil && c.BlockNumberMin.ToInt().Cmp(c.BlockNumberMax.ToInt()) > 0 {
+		return fmt.Errorf("transaction conditional: blockNumberMin %d is above blockNumberMax %d", c.BlockNumberMin.ToInt(), c.BlockNumberMax.ToInt())
+	}
+	if c.BlockNumberMax != nil && c.BlockNumberMax.ToInt().Cmp(head.Number) <= 0 {
+		return fmt.Errorf("transaction conditional: blockNumberMax %d is not above head %d", c.BlockNumberMax.ToInt(), head.Number)
+	}
+	if c.TimestampMin != nil && c.TimestampMax != nil && *c.TimestampMin > *c.TimestampMax {
+		return fmt.Errorf("transaction conditional: timestampMin %d is above timestampMax %d", *c.TimestampMin, *c.TimestampMax)
+	}
+	if c.TimestampMax != nil && uint64(*c.TimestampMax) <= head.Time {
+		return fmt.Errorf("transaction conditional: timestampMax %d is not above the time of head %d", *c.TimestampMax, head.Time)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/common/hexutility"
+	"github.com/erigontech/erigon-lib/gointerfaces/txpool"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/rpc"
+)
+
+type sequencerServiceMock struct {
+	calls int
+	known map[common.Hash]bool
+}
+
+func (s *sequencerServiceMock) SendRawTransaction(encodedTx hexutility.Bytes) (common.Hash, error) {
+	s.calls++
+	if len(encodedTx) == 0 {
+		return common.Hash{}, errors.New("transaction rejected")
+	}
+	hash := common.BytesToHash(encodedTx)
+	if s.known[hash] {
+		return common.Hash{}, errors.New("already known")
+	}
+	s.known[hash] = true
+	return hash, nil
+}
+
+func TestSequencerBackendRetries(t *testing.T) {
+	require.Nil(t, newSequencerBackend(nil, 3, time.Millisecond, false))
+
+	logger := log.New()
+	service := &sequencerServiceMock{known: map[common.Hash]bool{}}
+	server := rpc.NewServer(50, false, false, false, logger, 0)
+	require.NoError(t, server.RegisterName("eth", service))
+	var unavailable atomic.Int32 // requests answered by 503 before the sequencer is up
+	var lost atomic.Int32        // requests served by the sequencer, but answered by 503
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if unavailable.Add(-1) >= 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if lost.Add(-1) >= 0 {
+			server.ServeHTTP(httptest.NewRecorder(), r)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		server.ServeHTTP(w, r)
+	}))
+	defer httpServer.Close()
+	client, err := rpc.DialHTTP(httpServer.URL, logger)
+	require.NoError(t, err)
+	defer client.Close()
+
+	ctx := context.Background()
+	sequencer := newSequencerBackend(client, 3, time.Millisecond, false)
+	unavailable.Store(3)
+	hash, err := sequencer.send(ctx, common.BytesToHash([]byte{1}), "eth_sendRawTransaction", hexutility.Bytes{1})
+	require.NoError(t, err)
+	require.Equal(t, common.BytesToHash([]byte{1}), hash)
+	require.Equal(t, 1, service.calls)
+
+	// out of retries
+	unavailable.Store(4)
+	_, err = sequencer.send(ctx, common.BytesToHash([]byte{2}), "eth_sendRawTransaction", hexutility.Bytes{2})
+	require.Error(t, err)
+	require.Equal(t, 1, service.calls)
+
+	// the sequencer rejects the transaction
+	unavailable.Store(0)
+	_, err = sequencer.send(ctx, common.Hash{}, "eth_sendRawTransaction", hexutility.Bytes{})
+	require.ErrorContains(t, err, "transaction rejected")
+	require.Equal(t, 2, service.calls)
+
+	// the response to the first attempt is lost, the retry finds the transaction known
+	lost.Store(1)
+	hash, err = sequencer.send(ctx, common.BytesToHash([]byte{3}), "eth_sendRawTransaction", hexutility.Bytes{3})
+	require.NoError(t, err)
+	require.Equal(t, common.BytesToHash([]byte{3}), hash)
+	require.Equal(t, 4, service.calls)
+
+	// a transaction known at the first attempt was not sent by this call
+	_, err = sequencer.send(ctx, common.BytesToHash([]byte{3}), "eth_sendRawTransaction", hexutility.Bytes{3})
+	require.ErrorContains(t, err, "already known")
+}
+
+// addTxpoolMock - txpool answering Add with reply
+type addTxpoolMock struct {
+	txpool.TxpoolClient
+	reply *txpool.AddReply
+	added int
+}
+
+func (p *addTxpoolMock) Add(context.Context, *txpool.AddRequest, ...grpc.CallOption) (*txpool.AddReply, error) {
+	p.added++
+	return p.reply, nil
+}
+
+func TestForwardToSequencer(t *testing.T) {
+	logger := log.New()
+	service := &sequencerServiceMock{known: map[common.Hash]bool{}}
+	server := rpc.NewServer(50, false, false, false, logger, 0)
+	require.NoError(t, server.RegisterName("eth", service))
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+	client, err := rpc.DialHTTP(httpServer.URL, logger)
+	require.NoError(t, err)
+	defer client.Close()
+
+	ctx := context.Background()
+	api := &APIImpl{BaseAPI: &BaseAPI{sequencer: newSequencerBackend(client, 0, time.Millisecond, true)}, logger: logger}
+	forward := func(tx byte) (common.Hash, error) {
+		return api.forwardToSequencer(ctx, common.BytesToHash([]byte{tx}), hexutility.Bytes{tx}, "eth_sendRawTransaction", hexutility.Bytes{tx})
+	}
+
+	// no txpool: not forwarded
+	_, err = forward(1)
+	require.ErrorContains(t, err, "no txpool")
+	require.Zero(t, service.calls)
+
+	// the txpool rejecting the forwarded transaction doesn't fail the call
+	pool := &addTxpoolMock{reply: &txpool.AddReply{Imported: []txpool.ImportResult{txpool.ImportResult_ALREADY_EXISTS}, Errors: []string{"known"}}}
+	api.txPool = pool
+	hash, err := forward(1)
+	require.NoError(t, err)
+	require.Equal(t, common.BytesToHash([]byte{1}), hash)
+	require.Equal(t, 1, pool.added)
+
+	// no result for the transaction
+	pool.reply = &txpool.AddReply{}
+	_, err = forward(2)
+	require.ErrorContains(t, err, "no result")
+	require.Equal(t, 2, service.calls)
+
+	// without the local txpool it isn't needed
+	api.txPool, api.sequencer.localPool = nil, false
+	hash, err = forward(3)
+	require.NoError(t, err)
+	require.Equal(t, common.BytesToHash([]byte{3}), hash)
+}
+
+func TestTransactionConditionalCheck(t *testing.T) {
+	head := &types.Header{Number: big.NewInt(100), Time: 1_000}
+	number := func(n int64) *hexutil.Big { return (*hexutil.Big)(big.NewInt(n)) }
+	timestamp := func(t uint64) *hexutil.Uint64 { return (*hexutil.Uint64)(&t) }
+
+	require.NoError(t, (&TransactionConditional{}).check(head))
+	require.NoError(t, (&TransactionConditional{BlockNumberMin: number(90), BlockNumberMax: number(101), TimestampMax: timestamp(1_001)}).check(head))
+	require.NoError(t, (&TransactionConditional{BlockNumberMin: number(200), TimestampMin: timestamp(2_000)}).check(head))
+
+	require.Error(t, (&TransactionConditional{BlockNumberMax: number(100)}).check(head))
+	require.Error(t, (&TransactionConditional{BlockNumberMin: number(120), BlockNumberMax: number(110)}).check(head))
+	require.Error(t, (&TransactionConditional{TimestampMax: timestamp(1_000)}).check(head))
+	require.Error(t, (&TransactionConditional{TimestampMin: timestamp(1_200), TimestampMax: timestamp(1_100)}).check(head))
+}
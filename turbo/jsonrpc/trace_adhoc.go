@@ -947,19 +947,6 @@ func (api *TraceAPIImpl) Call(ctx context.Context, args TraceCallParam, traceTyp
 	}
 	header := block.Header()
 
-	// Setup context so it may be cancelled the call has completed
-	// or, in case of unmetered gas, setup a context with a timeout.
-	var cancel context.CancelFunc
-	if api.evmCallTimeout > 0 {
-		ctx, cancel = context.WithTimeout(ctx, api.evmCallTimeout)
-	} else {
-		ctx, cancel = context.WithCancel(ctx)
-	}
-
-	// Make sure the context is cancelled when the call has completed
-	// this makes sure resources are cleaned up.
-	defer cancel()
-
 	traceResult := &TraceCallResult{Trace: []*ParityTrace{}}
 	var traceTypeTrace, traceTypeStateDiff, traceTypeVmTrace bool
 	for _, traceType := range traceTypes {
@@ -988,40 +975,35 @@ func (api *TraceAPIImpl) Call(ctx context.Context, args TraceCallParam, traceTyp
 		ot.traceAddr = []int{}
 	}
 
+	blockCtx := transactions.NewEVMBlockContext(engine, header, blockNrOrHash.RequireCanonical, tx, api._blockReader)
+	blockCtx.GasLimit = math.MaxUint64
+	blockCtx.MaxGasLimit = true
+	if err = applyCallOverrides(traceConfig, &blockCtx, ibs); err != nil {
+		return nil, err
+	}
+	blockCtx.L1CostFunc = opstack.NewL1CostFunc(chainConfig, ibs)
+
 	// Get a new instance of the EVM.
 	var baseFee *uint256.Int
-	if header != nil && header.BaseFee != nil {
-		var overflow bool
-		baseFee, overflow = uint256.FromBig(header.BaseFee)
-		if overflow {
-			return nil, fmt.Errorf("header.BaseFee uint256 overflow")
-		}
+	if header.BaseFee != nil || (traceConfig != nil && traceConfig.BlockOverrides != nil && traceConfig.BlockOverrides.BaseFeePerGas != nil) {
+		baseFee = blockCtx.BaseFee
 	}
-	msg, err := args.ToMessage(api.gasCap, baseFee)
+	msg, err := args.ToMessage(methodGasCap(ctx, api.gasCap), baseFee)
 	if err != nil {
 		return nil, err
 	}
-
-	blockCtx := transactions.NewEVMBlockContext(engine, header, blockNrOrHash.RequireCanonical, tx, api._blockReader)
 	txCtx := core.NewEVMTxContext(msg)
 
-	blockCtx.GasLimit = math.MaxUint64
-	blockCtx.MaxGasLimit = true
-	blockCtx.L1CostFunc = opstack.NewL1CostFunc(chainConfig, ibs)
-
 	evm := vm.NewEVM(blockCtx, txCtx, ibs, chainConfig, vm.Config{Debug: traceTypeTrace, Tracer: &ot})
 
-	// Wait for the context to be done and cancel the evm. Even if the
-	// EVM has finished, cancelling may be done (repeatedly)
-	go func() {
-		<-ctx.Done()
-		evm.Cancel()
-	}()
-
+	done := transactions.InterruptOnTimeout(ctx, evm, api.evmCallTimeout)
 	gp := new(core.GasPool).AddGas(msg.Gas()).AddBlobGas(msg.BlobGas())
 	var execResult *evmtypes.ExecutionResult
 	ibs.SetTxContext(libcommon.Hash{}, libcommon.Hash{}, 0)
 	execResult, err = core.ApplyMessage(evm, msg, gp, true /* refunds */, true /* gasBailout */)
+	if err := done(); err != nil {
+		return nil, err
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -1033,19 +1015,37 @@ func (api *TraceAPIImpl) Call(ctx context.Context, args TraceCallParam, traceTyp
 		if err = ibs.FinalizeTx(evm.ChainRules(), sd); err != nil {
 			return nil, err
 		}
-		// Create initial IntraBlockState, we will compare it with ibs (IntraBlockState after the transaction)
+		// Create initial IntraBlockState, we will compare it with ibs (IntraBlockState after the transaction).
+		// Overrides are the initial state, not changes made by the call.
 		initialIbs := state.New(stateReader)
+		initialBlockCtx := blockCtx
+		if err = applyCallOverrides(traceConfig, &initialBlockCtx, initialIbs); err != nil {
+			return nil, err
+		}
 		sd.CompareStates(initialIbs, ibs)
 	}
 
-	// If the timer caused an abort, return an appropriate error message
-	if evm.Cancelled() {
-		return nil, fmt.Errorf("execution aborted (timeout = %v)", api.evmCallTimeout)
-	}
-
 	return traceResult, nil
 }
 
+// applyCallOverrides - state and block overrides of trace_call
+func applyCallOverrides(traceConfig *tracers.TraceConfig, blockCtx *evmtypes.BlockContext, ibs *state.IntraBlockState) error {
+	if traceConfig == nil {
+		return nil
+	}
+	if traceConfig.StateOverrides != nil {
+		if err := traceConfig.StateOverrides.Override(ibs); err != nil {
+			return fmt.Errorf("override state: %w", err)
+		}
+	}
+	if traceConfig.BlockOverrides != nil {
+		if err := traceConfig.BlockOverrides.Override(blockCtx, ibs); err != nil {
+			return fmt.Errorf("override block: %w", err)
+		}
+	}
+	return nil
+}
+
 // CallMany implements trace_callMany.
 func (api *TraceAPIImpl) CallMany(ctx context.Context, calls json.RawMessage, parentNrOrHash *rpc.BlockNumberOrHash, traceConfig *tracers.TraceConfig) ([]*TraceCallResult, error) {
 	dbtx, err := api.kv.BeginRo(ctx)
@@ -1122,7 +1122,7 @@ func (api *TraceAPIImpl) CallMany(ctx context.Context, calls json.RawMessage, pa
 	}
 	msgs := make([]types.Message, len(callParams))
 	for i, args := range callParams {
-		msgs[i], err = args.ToMessage(api.gasCap, baseFee)
+		msgs[i], err = args.ToMessage(methodGasCap(ctx, api.gasCap), baseFee)
 		if err != nil {
 			return nil, fmt.Errorf("convert callParam to msg: %w", err)
 		}
@@ -1180,18 +1180,6 @@ func (api *TraceAPIImpl) doCallMany(ctx context.Context, dbtx kv.Tx, stateReader
 		return nil, fmt.Errorf("parent header %d(%x) not found", blockNumber, hash)
 	}
 
-	// Setup context so it may be cancelled the call has completed
-	// or, in case of unmetered gas, setup a context with a timeout.
-	var cancel context.CancelFunc
-	if api.evmCallTimeout > 0 {
-		ctx, cancel = context.WithTimeout(ctx, api.evmCallTimeout)
-	} else {
-		ctx, cancel = context.WithCancel(ctx)
-	}
-
-	// Make sure the context is cancelled when the call has completed
-	// this makes sure resources are cleaned up.
-	defer cancel()
 	results := make([]*TraceCallResult, 0, len(msgs))
 
 	useParent := false
@@ -1298,9 +1286,13 @@ func (api *TraceAPIImpl) doCallMany(ctx context.Context, dbtx kv.Tx, stateReader
 
 			txCtx := core.NewEVMTxContext(msg)
 			evm := vm.NewEVM(blockCtx, txCtx, ibs, chainConfig, vmConfig)
+			done := transactions.InterruptOnTimeout(ctx, evm, api.evmCallTimeout)
 			gp := new(core.GasPool).AddGas(msg.Gas()).AddBlobGas(msg.BlobGas())
 
 			execResult, err = core.ApplyMessage(evm, msg, gp, true /* refunds */, gasBailout /* gasBailout */)
+			if err := done(); err != nil {
+				return nil, fmt.Errorf("txIndex %d: %w", txIndex, err)
+			}
 		}
 		if err != nil {
 			return nil, fmt.Errorf("first run for txIndex %d error: %w", txIndex, err)
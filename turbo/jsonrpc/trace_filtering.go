@@ -34,6 +34,7 @@ import (
 	bortypes "github.com/erigontech/erigon/polygon/bor/types"
 	"github.com/erigontech/erigon/rpc"
 	"github.com/erigontech/erigon/turbo/rpchelper"
+	"github.com/erigontech/erigon/turbo/services"
 	"github.com/erigontech/erigon/turbo/shards"
 	"github.com/erigontech/erigon/turbo/transactions"
 )
@@ -60,7 +61,7 @@ func (api *TraceAPIImpl) Transaction(ctx context.Context, txHash common.Hash, ga
 	}
 	if !ok {
 		if chainConfig.Bor == nil {
-			return nil, nil
+			return nil, api.txnNotIndexed(tx, txHash)
 		}
 
 		// otherwise this may be a bor state sync transaction - check
@@ -239,14 +240,53 @@ func (api *TraceAPIImpl) Block(ctx context.Context, blockNr rpc.BlockNumber, gas
 	return out, err
 }
 
-func traceFilterBitmaps(tx kv.Tx, req TraceFilterRequest, from, to uint64) (fromAddresses, toAddresses map[common.Address]struct{}, allBlocks *roaring64.Bitmap, err error) {
+// callTraceIndex - trace_filter index of the frozen blocks, nil if the snapshots don't have it
+func (api *BaseAPI) callTraceIndex() services.CallTraceIndexReader {
+	if api._blockReader == nil {
+		return nil
+	}
+	if snapshots, ok := api._blockReader.Snapshots().(services.CallTraceIndexSnapshots); ok {
+		return snapshots.CallTraceIndex()
+	}
+	return nil
+}
+
+// getCallTraceBitmap - blocks in [from, to] with the calls of the key: the frozen blocks are read from
+// the snapshot call trace index, the rest from the chunks of the db table
+func getCallTraceBitmap(tx kv.Tx, callTraceIndex services.CallTraceIndexReader, bucket string, key []byte, from, to uint64) (*roaring64.Bitmap, error) {
+	if callTraceIndex != nil {
+		if indexedFrom, indexedTo := callTraceIndex.Indexed(); indexedFrom <= from && from < indexedTo {
+			m, err := callTraceIndex.Get(bucket, key, from, min(to, indexedTo-1))
+			if err != nil {
+				return nil, err
+			}
+			result := roaring64.New()
+			m.Iterate(func(blockNum uint32) bool {
+				result.Add(uint64(blockNum))
+				return true
+			})
+			if to < indexedTo {
+				return result, nil
+			}
+			recent, err := bitmapdb.Get64(tx, bucket, key, indexedTo, to)
+			if err != nil {
+				return nil, err
+			}
+			result.Or(recent)
+			return result, nil
+		}
+	}
+	return bitmapdb.Get64(tx, bucket, key, from, to)
+}
+
+func traceFilterBitmaps(tx kv.Tx, callTraceIndex services.CallTraceIndexReader, req TraceFilterRequest, from, to uint64) (fromAddresses, toAddresses map[common.Address]struct{}, allBlocks *roaring64.Bitmap, err error) {
 	fromAddresses = make(map[common.Address]struct{}, len(req.FromAddress))
 	toAddresses = make(map[common.Address]struct{}, len(req.ToAddress))
 	allBlocks = roaring64.New()
 	var blocksTo roaring64.Bitmap
 	for _, addr := range req.FromAddress {
 		if addr != nil {
-			b, err := bitmapdb.Get64(tx, kv.CallFromIndex, addr.Bytes(), from, to)
+			b, err := getCallTraceBitmap(tx, callTraceIndex, kv.CallFromIndex, addr.Bytes(), from, to)
 			if err != nil {
 				if errors.Is(err, ethdb.ErrKeyNotFound) {
 					continue
@@ -260,7 +300,7 @@ func traceFilterBitmaps(tx kv.Tx, req TraceFilterRequest, from, to uint64) (from
 
 	for _, addr := range req.ToAddress {
 		if addr != nil {
-			b, err := bitmapdb.Get64(tx, kv.CallToIndex, addr.Bytes(), from, to)
+			b, err := getCallTraceBitmap(tx, callTraceIndex, kv.CallToIndex, addr.Bytes(), from, to)
 			if err != nil {
 				if errors.Is(err, ethdb.ErrKeyNotFound) {
 					continue
@@ -374,7 +414,7 @@ func (api *TraceAPIImpl) Filter(ctx context.Context, req TraceFilterRequest, gas
 		return api.filterV3(ctx, dbtx.(kv.TemporalTx), fromBlock, toBlock, req, traceConfig, stream)
 	}
 	toBlock++ //+1 because internally Erigon using semantic [from, to), but some RPC have different semantic
-	fromAddresses, toAddresses, allBlocks, err := traceFilterBitmaps(dbtx, req, fromBlock, toBlock)
+	fromAddresses, toAddresses, allBlocks, err := traceFilterBitmaps(dbtx, api.callTraceIndex(), req, fromBlock, toBlock)
 	if err != nil {
 		return err
 	}
@@ -758,7 +798,7 @@ func (api *TraceAPIImpl) filterV3(ctx context.Context, dbtx kv.TemporalTx, fromB
 			continue //guess block doesn't have transactions
 		}
 		txHash := txn.Hash()
-		msg, err := txn.AsMessage(*lastSigner, lastHeader.BaseFee, lastRules)
+		msg, err := types.AsMessageLegacyAware(chainConfig, blockNum, txn, *lastSigner, lastHeader.BaseFee, lastRules)
 		if err != nil {
 			if first {
 				first = false
@@ -975,7 +1015,7 @@ func (api *TraceAPIImpl) callManyTransactions(
 			// we use an empty message for bor state sync txn since it gets handled differently
 		} else {
 			txnHash = tx.Hash()
-			msg, err = tx.AsMessage(*signer, header.BaseFee, rules)
+			msg, err = types.AsMessageLegacyAware(cfg, header.Number.Uint64(), tx, *signer, header.BaseFee, rules)
 			if err != nil {
 				return nil, nil, fmt.Errorf("convert tx into msg: %w", err)
 			}
@@ -154,7 +154,7 @@ func (api *PrivateDebugAPIImpl) traceBlock(ctx context.Context, blockNrOrHash rp
 			return ctx.Err()
 		}
 		ibs.SetTxContext(txnHash, block.Hash(), idx)
-		msg, _ := txn.AsMessage(*signer, block.BaseFee(), rules)
+		msg, _ := types.AsMessageLegacyAware(chainConfig, block.NumberU64(), txn, *signer, block.BaseFee(), rules)
 
 		if msg.FeeCap().IsZero() && engine != nil {
 			syscall := func(contract common.Address, data []byte) ([]byte, error) {
@@ -239,7 +239,7 @@ func (api *PrivateDebugAPIImpl) TraceTransaction(ctx context.Context, hash commo
 	if !ok {
 		if chainConfig.Bor == nil {
 			stream.WriteNil()
-			return nil
+			return api.txnNotIndexed(tx, hash)
 		}
 
 		// otherwise this may be a bor state sync transaction - check
@@ -390,22 +390,23 @@ func (api *PrivateDebugAPIImpl) TraceCall(ctx context.Context, args ethapi.CallA
 		}
 	}
 
-	var baseFee *uint256.Int
-	if header != nil && header.BaseFee != nil {
-		var overflow bool
-		baseFee, overflow = uint256.FromBig(header.BaseFee)
-		if overflow {
-			return fmt.Errorf("header.BaseFee uint256 overflow")
+	blockCtx := transactions.NewEVMBlockContext(engine, header, blockNrOrHash.RequireCanonical, dbtx, api._blockReader)
+	if config != nil && config.BlockOverrides != nil {
+		if err := config.BlockOverrides.Override(&blockCtx, ibs); err != nil {
+			return fmt.Errorf("override block: %v", err)
 		}
 	}
-	msg, err := args.ToMessage(api.GasCap, baseFee)
+	blockCtx.L1CostFunc = opstack.NewL1CostFunc(chainConfig, ibs)
+
+	var baseFee *uint256.Int
+	if header.BaseFee != nil || (config != nil && config.BlockOverrides != nil && config.BlockOverrides.BaseFeePerGas != nil) {
+		baseFee = blockCtx.BaseFee
+	}
+	msg, err := args.ToMessage(methodGasCap(ctx, api.GasCap), baseFee)
 	if err != nil {
 		return fmt.Errorf("convert args to msg: %v", err)
 	}
-
-	blockCtx := transactions.NewEVMBlockContext(engine, header, blockNrOrHash.RequireCanonical, dbtx, api._blockReader)
 	txCtx := core.NewEVMTxContext(msg)
-	blockCtx.L1CostFunc = opstack.NewL1CostFunc(chainConfig, ibs)
 	// Trace the transaction and return
 	return transactions.TraceTx(ctx, msg, blockCtx, txCtx, ibs, config, chainConfig, stream, api.evmCallTimeout)
 }
@@ -528,7 +529,7 @@ func (api *PrivateDebugAPIImpl) TraceCallMany(ctx context.Context, bundles []Bun
 	gp := new(core.GasPool).AddGas(math.MaxUint64).AddBlobGas(math.MaxUint64)
 	for idx, txn := range replayTransactions {
 		st.SetTxContext(txn.Hash(), block.Hash(), idx)
-		msg, err := txn.AsMessage(*signer, block.BaseFee(), rules)
+		msg, err := types.AsMessageLegacyAware(chainConfig, blockNum, txn, *signer, block.BaseFee(), rules)
 		if err != nil {
 			stream.WriteNil()
 			return err
@@ -560,10 +561,11 @@ func (api *PrivateDebugAPIImpl) TraceCallMany(ctx context.Context, bundles []Bun
 		// first change blockContext
 		blockHeaderOverride(&blockCtx, bundle.BlockOverride, overrideBlockHash)
 		for txnIndex, txn := range bundle.Transactions {
+			gasCap := methodGasCap(ctx, api.GasCap)
 			if txn.Gas == nil || *(txn.Gas) == 0 {
-				txn.Gas = (*hexutil.Uint64)(&api.GasCap)
+				txn.Gas = (*hexutil.Uint64)(&gasCap)
 			}
-			msg, err := txn.ToMessage(api.GasCap, blockCtx.BaseFee)
+			msg, err := txn.ToMessage(gasCap, blockCtx.BaseFee)
 			if err != nil {
 				stream.WriteArrayEnd()
 				stream.WriteArrayEnd()
@@ -50,6 +50,9 @@ type Filters struct {
 	pendingTxsStores   *concurrent.SyncMap[PendingTxsSubID, [][]types.Transaction]
 	logger             log.Logger
 
+	polledLock sync.Mutex
+	polled     map[SubscriptionID]*polledFilter // filters of eth_getFilterChanges, see filters_polled.go
+
 	config FiltersConfig
 }
 
@@ -71,6 +74,11 @@ func New(ctx context.Context, config FiltersConfig, ethBackend ApiBackend, txPoo
 		pendingTxsStores:   concurrent.NewSyncMap[PendingTxsSubID, [][]types.Transaction](),
 		logger:             logger,
 		config:             config,
+		polled:             map[SubscriptionID]*polledFilter{},
+	}
+	ff.restoreLogsFilters(ctx)
+	if config.RpcFiltersTimeout > 0 {
+		go ff.expireFiltersLoop(ctx)
 	}
 
 	go func() {
@@ -421,8 +429,14 @@ func (ff *Filters) UnsubscribePendingTxs(id PendingTxsSubID) bool {
 // SubscribeLogs subscribes to logs using the specified filter criteria and returns a channel to receive the logs
 // and a subscription ID to manage the subscription.
 func (ff *Filters) SubscribeLogs(size int, criteria filters.FilterCriteria) (<-chan *types.Log, LogsSubID) {
+	id := LogsSubID(generateSubscriptionID())
+	return ff.subscribeLogs(id, size, criteria), id
+}
+
+// subscribeLogs subscribes to logs with the given subscription ID, a new one or the one of a restored filter.
+func (ff *Filters) subscribeLogs(id LogsSubID, size int, criteria filters.FilterCriteria) <-chan *types.Log {
 	sub := newChanSub[*types.Log](size)
-	id, f := ff.logsSubs.insertLogsFilter(sub)
+	f := ff.logsSubs.insertLogsFilter(id, sub)
 
 	// Initialize address and topic maps
 	f.addrs = concurrent.NewSyncMap[libcommon.Address, int]()
@@ -475,14 +489,7 @@ func (ff *Filters) SubscribeLogs(size int, criteria filters.FilterCriteria) (<-c
 	ff.logsSubs.addLogsFilters(f)
 
 	// Create a filter request based on the aggregated filters
-	lfr := ff.logsSubs.createFilterRequest()
-	addresses, topics := ff.logsSubs.getAggMaps()
-	for addr := range addresses {
-		lfr.Addresses = append(lfr.Addresses, gointerfaces.ConvertAddressToH160(addr))
-	}
-	for topic := range topics {
-		lfr.Topics = append(lfr.Topics, gointerfaces.ConvertHashToH256(topic))
-	}
+	lfr := ff.logsFilterRequest()
 
 	loaded := ff.loadLogsRequester()
 	if loaded != nil {
@@ -492,7 +499,20 @@ func (ff *Filters) SubscribeLogs(size int, criteria filters.FilterCriteria) (<-c
 		}
 	}
 
-	return sub.ch, id
+	return sub.ch
+}
+
+// logsFilterRequest creates a LogsFilterRequest for the remote from the aggregation of all current log filters.
+func (ff *Filters) logsFilterRequest() *remote.LogsFilterRequest {
+	lfr := ff.logsSubs.createFilterRequest()
+	addresses, topics := ff.logsSubs.getAggMaps()
+	for addr := range addresses {
+		lfr.Addresses = append(lfr.Addresses, gointerfaces.ConvertAddressToH160(addr))
+	}
+	for topic := range topics {
+		lfr.Topics = append(lfr.Topics, gointerfaces.ConvertHashToH256(topic))
+	}
+	return lfr
 }
 
 // loadLogsRequester loads the current logs requester and returns it.
@@ -508,16 +528,7 @@ func (ff *Filters) UnsubscribeLogs(id LogsSubID) bool {
 	isDeleted := ff.logsSubs.removeLogsFilter(id)
 	// if any filters in the aggregate need all addresses or all topics then the request to the central
 	// log subscription needs to honour this
-	lfr := ff.logsSubs.createFilterRequest()
-
-	addresses, topics := ff.logsSubs.getAggMaps()
-
-	for addr := range addresses {
-		lfr.Addresses = append(lfr.Addresses, gointerfaces.ConvertAddressToH160(addr))
-	}
-	for topic := range topics {
-		lfr.Topics = append(lfr.Topics, gointerfaces.ConvertHashToH256(topic))
-	}
+	lfr := ff.logsFilterRequest()
 	loaded := ff.loadLogsRequester()
 	if loaded != nil {
 		if err := loaded.(func(*remote.LogsFilterRequest) error)(lfr); err != nil {
@@ -673,7 +684,7 @@ func (ff *Filters) ReadLogs(id LogsSubID) ([]*types.Log, bool) {
 	if !ok {
 		return res, false
 	}
-	return res, true
+	return CanonicalLogs(res), true
 }
 
 // AddPendingBlock adds a pending block header to the store associated with the given subscription ID.
@@ -0,0 +1,311 @@
+package rpchelper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/gointerfaces/remote"
+
+	"github.com/erigontech/erigon/eth/filters"
+)
+
+var (
+	// ErrFilterNotFound - the filter was never installed, or it is uninstalled or expired
+	ErrFilterNotFound = errors.New("filter not found")
+	// ErrTooManyFilters - the client has FiltersConfig.RpcFiltersMaxPerClient filters installed already
+	ErrTooManyFilters = errors.New("too many filters installed by the client")
+)
+
+type polledFilterKind uint8
+
+const (
+	headsFilter polledFilterKind = iota
+	pendingTxsFilter
+	logsFilter
+)
+
+// polledFilter - filter installed by eth_newBlockFilter, eth_newPendingTransactionFilter or eth_newFilter and read by
+// eth_getFilterChanges. Unlike the subscriptions it isn't bound to a connection, so it expires when not polled for
+// FiltersConfig.RpcFiltersTimeout.
+type polledFilter struct {
+	kind     polledFilterKind
+	client   string                 // remote address of the client which installed it, empty if unknown
+	criteria filters.FilterCriteria // of a logs filter
+	lastPoll time.Time
+}
+
+// persistedLogsFilter - logs filter in FiltersConfig.RpcFiltersPersistFile
+type persistedLogsFilter struct {
+	ID        LogsSubID           `json:"id"`
+	Client    string              `json:"client,omitempty"`
+	Addresses []libcommon.Address `json:"address,omitempty"`
+	Topics    [][]libcommon.Hash  `json:"topics,omitempty"`
+}
+
+// FilterClient - the client installing a polled filter, which FiltersConfig.RpcFiltersMaxPerClient applies to: the
+// remote address without port or, if it's one of FiltersConfig.RpcFiltersTrustedProxies, the right-most address of
+// X-Forwarded-For which isn't a trusted proxy. The entries left of it are set by the client and can't be trusted.
+func (ff *Filters) FilterClient(remote, forwardedFor string) string {
+	client := remote
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		client = host
+	}
+	if !slices.Contains(ff.config.RpcFiltersTrustedProxies, client) {
+		return client
+	}
+	hops := strings.Split(forwardedFor, ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			break
+		}
+		client = hop
+		if !slices.Contains(ff.config.RpcFiltersTrustedProxies, hop) {
+			break
+		}
+	}
+	return client
+}
+
+// NewHeadsFilter installs a polled filter of the new block headers for the client.
+func (ff *Filters) NewHeadsFilter(client string) (HeadsSubID, error) {
+	ff.polledLock.Lock()
+	defer ff.polledLock.Unlock()
+	if err := ff.checkFiltersLimit(client); err != nil {
+		return "", err
+	}
+	ch, id := ff.SubscribeNewHeads(32)
+	go func() {
+		for block := range ch {
+			ff.AddPendingBlock(id, block)
+		}
+	}()
+	ff.polled[SubscriptionID(id)] = &polledFilter{kind: headsFilter, client: client, lastPoll: time.Now()}
+	return id, nil
+}
+
+// NewPendingTxsFilter installs a polled filter of the pending transactions for the client.
+func (ff *Filters) NewPendingTxsFilter(client string) (PendingTxsSubID, error) {
+	ff.polledLock.Lock()
+	defer ff.polledLock.Unlock()
+	if err := ff.checkFiltersLimit(client); err != nil {
+		return "", err
+	}
+	ch, id := ff.SubscribePendingTxs(32)
+	go func() {
+		for txs := range ch {
+			ff.AddPendingTxs(id, txs)
+		}
+	}()
+	ff.polled[SubscriptionID(id)] = &polledFilter{kind: pendingTxsFilter, client: client, lastPoll: time.Now()}
+	return id, nil
+}
+
+// NewLogsFilter installs a polled filter of the logs matching the criteria for the client. It's saved to
+// FiltersConfig.RpcFiltersPersistFile, if set, and restored from it on restart.
+func (ff *Filters) NewLogsFilter(client string, criteria filters.FilterCriteria) (LogsSubID, error) {
+	ff.polledLock.Lock()
+	defer ff.polledLock.Unlock()
+	if err := ff.checkFiltersLimit(client); err != nil {
+		return "", err
+	}
+	id := LogsSubID(generateSubscriptionID())
+	ff.installLogsFilter(id, client, criteria)
+	ff.saveLogsFilters()
+	return id, nil
+}
+
+// installLogsFilter - called with polledLock held
+func (ff *Filters) installLogsFilter(id LogsSubID, client string, criteria filters.FilterCriteria) {
+	logs := ff.subscribeLogs(id, 256, criteria)
+	go func() {
+		for lg := range logs {
+			ff.AddLogs(id, lg)
+		}
+	}()
+	ff.polled[SubscriptionID(id)] = &polledFilter{kind: logsFilter, client: client, criteria: criteria, lastPoll: time.Now()}
+}
+
+// checkFiltersLimit - called with polledLock held
+func (ff *Filters) checkFiltersLimit(client string) error {
+	if ff.config.RpcFiltersMaxPerClient == 0 || client == "" {
+		return nil
+	}
+	var count int
+	for _, f := range ff.polled {
+		if f.client == client {
+			count++
+		}
+	}
+	if count >= ff.config.RpcFiltersMaxPerClient {
+		return fmt.Errorf("%w: %d", ErrTooManyFilters, ff.config.RpcFiltersMaxPerClient)
+	}
+	return nil
+}
+
+// PollFilter marks the polled filter as polled now, so it doesn't expire. ErrFilterNotFound if it's unknown.
+func (ff *Filters) PollFilter(id SubscriptionID) error {
+	ff.polledLock.Lock()
+	defer ff.polledLock.Unlock()
+	f, ok := ff.polled[id]
+	if !ok {
+		return ErrFilterNotFound
+	}
+	f.lastPoll = time.Now()
+	return nil
+}
+
+// UninstallFilter uninstalls the polled filter. It returns true if the filter was installed.
+func (ff *Filters) UninstallFilter(id SubscriptionID) bool {
+	ff.polledLock.Lock()
+	f, ok := ff.polled[id]
+	if ok {
+		delete(ff.polled, id)
+		if f.kind == logsFilter {
+			ff.saveLogsFilters()
+		}
+	}
+	ff.polledLock.Unlock()
+	if !ok {
+		return false
+	}
+	ff.unsubscribePolled(id, f)
+	return true
+}
+
+func (ff *Filters) unsubscribePolled(id SubscriptionID, f *polledFilter) {
+	switch f.kind {
+	case headsFilter:
+		ff.UnsubscribeHeads(HeadsSubID(id))
+	case pendingTxsFilter:
+		ff.UnsubscribePendingTxs(PendingTxsSubID(id))
+	case logsFilter:
+		ff.UnsubscribeLogs(LogsSubID(id))
+	}
+}
+
+func (ff *Filters) expireFiltersLoop(ctx context.Context) {
+	ticker := time.NewTicker(max(ff.config.RpcFiltersTimeout/4, time.Second))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			ff.expireFilters(now)
+		}
+	}
+}
+
+// expireFilters uninstalls the polled filters not polled for FiltersConfig.RpcFiltersTimeout before now.
+func (ff *Filters) expireFilters(now time.Time) {
+	expired := map[SubscriptionID]*polledFilter{}
+	var logsExpired bool
+	ff.polledLock.Lock()
+	for id, f := range ff.polled {
+		if now.Sub(f.lastPoll) > ff.config.RpcFiltersTimeout {
+			expired[id] = f
+			logsExpired = logsExpired || f.kind == logsFilter
+			delete(ff.polled, id)
+		}
+	}
+	if logsExpired {
+		ff.saveLogsFilters()
+	}
+	ff.polledLock.Unlock()
+
+	for id, f := range expired {
+		ff.unsubscribePolled(id, f)
+	}
+	if len(expired) > 0 {
+		expiredFiltersCounter.AddInt(len(expired))
+		ff.logger.Debug("rpc filters: expired not polled filters", "count", len(expired), "timeout", ff.config.RpcFiltersTimeout)
+	}
+}
+
+// saveLogsFilters writes the polled logs filters to FiltersConfig.RpcFiltersPersistFile. Called with polledLock held.
+func (ff *Filters) saveLogsFilters() {
+	if ff.config.RpcFiltersPersistFile == "" {
+		return
+	}
+	saved := make([]persistedLogsFilter, 0)
+	for id, f := range ff.polled {
+		if f.kind == logsFilter {
+			saved = append(saved, persistedLogsFilter{ID: LogsSubID(id), Client: f.client, Addresses: f.criteria.Addresses, Topics: f.criteria.Topics})
+		}
+	}
+	sort.Slice(saved, func(i, j int) bool { return saved[i].ID < saved[j].ID })
+	data, err := json.Marshal(saved)
+	if err != nil {
+		ff.logger.Warn("rpc filters: could not save log filters", "err", err)
+		return
+	}
+	// written to a temp file first, so a crash doesn't leave it half-written
+	tmp := ff.config.RpcFiltersPersistFile + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o644); err == nil {
+		err = os.Rename(tmp, ff.config.RpcFiltersPersistFile)
+	}
+	if err != nil {
+		ff.logger.Warn("rpc filters: could not save log filters", "file", ff.config.RpcFiltersPersistFile, "err", err)
+	}
+}
+
+// restoreLogsFilters installs the polled logs filters saved before restart, with their IDs. They expire like
+// filters installed now: their clients have FiltersConfig.RpcFiltersTimeout to poll them again.
+func (ff *Filters) restoreLogsFilters(ctx context.Context) {
+	if ff.config.RpcFiltersPersistFile == "" {
+		return
+	}
+	data, err := os.ReadFile(ff.config.RpcFiltersPersistFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	var saved []persistedLogsFilter
+	if err == nil {
+		err = json.Unmarshal(data, &saved)
+	}
+	if err != nil {
+		ff.logger.Warn("rpc filters: could not restore log filters", "file", ff.config.RpcFiltersPersistFile, "err", err)
+		return
+	}
+	if len(saved) == 0 {
+		return
+	}
+
+	ff.polledLock.Lock()
+	for _, f := range saved {
+		ff.installLogsFilter(f.ID, f.Client, filters.FilterCriteria{Addresses: f.Addresses, Topics: f.Topics})
+	}
+	ff.polledLock.Unlock()
+	ff.logger.Info("rpc filters: restored log filters", "count", len(saved))
+	go ff.sendRestoredLogsFilterRequest(ctx)
+}
+
+// sendRestoredLogsFilterRequest sends the filter request of the restored filters to the remote, once the subscription
+// to its logs is up: it sends only the logs requested after it.
+func (ff *Filters) sendRestoredLogsFilterRequest(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		if loaded := ff.loadLogsRequester(); loaded != nil {
+			if err := loaded.(func(*remote.LogsFilterRequest) error)(ff.logsFilterRequest()); err != nil {
+				ff.logger.Warn("Could not update remote logs filter", "err", err)
+			}
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package rpchelper
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/eth/filters"
+)
+
+func TestPolledFiltersExpiry(t *testing.T) {
+	config := FiltersConfig{RpcFiltersTimeout: time.Minute, RpcFiltersMaxPerClient: 2}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	f := New(ctx, config, nil, nil, nil, func() {}, log.New())
+
+	heads, err := f.NewHeadsFilter("10.0.0.1")
+	require.NoError(t, err)
+	txs, err := f.NewPendingTxsFilter("10.0.0.1")
+	require.NoError(t, err)
+	_, err = f.NewLogsFilter("10.0.0.1", filters.FilterCriteria{})
+	require.ErrorIs(t, err, ErrTooManyFilters)
+	logs, err := f.NewLogsFilter("10.0.0.2", filters.FilterCriteria{})
+	require.NoError(t, err)
+
+	// only the polled filters are kept
+	require.NoError(t, f.PollFilter(SubscriptionID(heads)))
+	f.polled[SubscriptionID(txs)].lastPoll = time.Now().Add(-2 * time.Minute)
+	f.polled[SubscriptionID(logs)].lastPoll = time.Now().Add(-2 * time.Minute)
+	f.expireFilters(time.Now())
+	require.NoError(t, f.PollFilter(SubscriptionID(heads)))
+	require.ErrorIs(t, f.PollFilter(SubscriptionID(txs)), ErrFilterNotFound)
+	require.ErrorIs(t, f.PollFilter(SubscriptionID(logs)), ErrFilterNotFound)
+	_, ok := f.logsSubs.logsFilters.Get(logs)
+	require.False(t, ok)
+
+	_, err = f.NewLogsFilter("10.0.0.1", filters.FilterCriteria{})
+	require.NoError(t, err)
+	require.True(t, f.UninstallFilter(SubscriptionID(heads)))
+	require.False(t, f.UninstallFilter(SubscriptionID(heads)))
+}
+
+func TestFilterClient(t *testing.T) {
+	f := &Filters{config: FiltersConfig{RpcFiltersTrustedProxies: []string{"10.0.0.1", "10.0.0.2"}}}
+
+	// X-Forwarded-For of an untrusted remote is ignored
+	require.Equal(t, "192.168.0.1", f.FilterClient("192.168.0.1:30000", "1.1.1.1"))
+	require.Equal(t, "192.168.0.1", f.FilterClient("192.168.0.1", ""))
+	// behind the trusted proxies: the right-most hop they didn't add, not the spoofable left-most one
+	require.Equal(t, "2.2.2.2", f.FilterClient("10.0.0.1:30000", "1.1.1.1, 2.2.2.2, 10.0.0.2"))
+	require.Equal(t, "2.2.2.2", f.FilterClient("10.0.0.1:30000", "2.2.2.2"))
+	require.Equal(t, "10.0.0.2", f.FilterClient("10.0.0.1:30000", "10.0.0.2"))
+	require.Equal(t, "10.0.0.1", f.FilterClient("10.0.0.1:30000", ""))
+}
+
+func TestPolledLogsFiltersPersistence(t *testing.T) {
+	config := FiltersConfig{RpcFiltersPersistFile: filepath.Join(t.TempDir(), "filters.json")}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	criteria := filters.FilterCriteria{Addresses: []libcommon.Address{{1}}, Topics: [][]libcommon.Hash{{{2}}}}
+
+	f := New(ctx, config, nil, nil, nil, func() {}, log.New())
+	kept, err := f.NewLogsFilter("10.0.0.1", criteria)
+	require.NoError(t, err)
+	uninstalled, err := f.NewLogsFilter("10.0.0.1", filters.FilterCriteria{})
+	require.NoError(t, err)
+	_, err = f.NewHeadsFilter("10.0.0.1") // not saved
+	require.NoError(t, err)
+	require.True(t, f.UninstallFilter(SubscriptionID(uninstalled)))
+
+	restarted := New(ctx, config, nil, nil, nil, func() {}, log.New())
+	require.Len(t, restarted.polled, 1)
+	require.NoError(t, restarted.PollFilter(SubscriptionID(kept)))
+	require.Equal(t, "10.0.0.1", restarted.polled[SubscriptionID(kept)].client)
+	require.Equal(t, criteria, restarted.polled[SubscriptionID(kept)].criteria)
+	filter, ok := restarted.logsSubs.logsFilters.Get(kept)
+	require.True(t, ok)
+	_, ok = filter.addrs.Get(libcommon.Address{1})
+	require.True(t, ok)
+}
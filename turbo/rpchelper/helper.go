@@ -134,8 +134,12 @@ func CreateHistoryStateReader(tx kv.Tx, blockNumber uint64, txnIndex int, histor
 		//r.SetTrace(true)
 		return r, nil
 	}
+	ttx, ok := tx.(kv.TemporalTx)
+	if !ok {
+		return nil, fmt.Errorf("history of HistoryV3 is read by a temporal tx, got %T", tx)
+	}
 	r := state.NewHistoryReaderV3()
-	r.SetTx(tx)
+	r.SetTx(ttx)
 	//r.SetTrace(true)
 	minTxNum, err := rawdbv3.TxNums.Min(tx, blockNumber)
 	if err != nil {
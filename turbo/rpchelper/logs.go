@@ -0,0 +1,151 @@
+package rpchelper
+
+import (
+	"cmp"
+	"slices"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+
+	"github.com/erigontech/erigon/core/types"
+)
+
+// logsDedupWindow - how many blocks below the highest one subscription remembers delivered logs of
+const logsDedupWindow = 128
+
+// logKey identifies log within the chain. Logs of other block at the same height (reorg) have other keys
+type logKey struct {
+	blockHash libcommon.Hash
+	txIndex   uint
+	index     uint
+}
+
+type logState struct {
+	blockNumber uint64
+	removed     bool
+}
+
+// LogsDedup drops logs which were already delivered: around reorgs logs of a block can be delivered again.
+// Log is a duplicate if the last delivered log with the same key had the same Removed flag - so a block which
+// was removed and became canonical again delivers its logs again.
+type LogsDedup struct {
+	seen     map[logKey]logState
+	window   uint64 // 0 - remember everything
+	highest  uint64
+	prunedAt uint64
+}
+
+// NewLogsDedup returns LogsDedup for subscriptions: it forgets logs more than logsDedupWindow blocks below the highest one
+func NewLogsDedup() *LogsDedup {
+	return newLogsDedup(logsDedupWindow)
+}
+
+func newLogsDedup(window uint64) *LogsDedup {
+	return &LogsDedup{seen: map[logKey]logState{}, window: window}
+}
+
+// Duplicate returns true if the log must not be delivered, otherwise remembers it
+func (d *LogsDedup) Duplicate(lg *types.Log) bool {
+	k := logKey{blockHash: lg.BlockHash, txIndex: lg.TxIndex, index: lg.Index}
+	if state, ok := d.seen[k]; ok && state.removed == lg.Removed {
+		return true
+	}
+	d.seen[k] = logState{blockNumber: lg.BlockNumber, removed: lg.Removed}
+
+	if lg.BlockNumber > d.highest {
+		d.highest = lg.BlockNumber
+		if d.window > 0 && d.highest >= d.prunedAt+d.window {
+			for k, state := range d.seen {
+				if state.blockNumber+d.window < d.highest {
+					delete(d.seen, k)
+				}
+			}
+			d.prunedAt = d.highest
+		}
+	}
+	return false
+}
+
+// CanonicalLogs - the single place which orders logs served by eth_getLogs and filters: ascending
+// (BlockNumber, TxIndex, Index), duplicates dropped (see LogsDedup). Around reorgs logs of other blocks at
+// the same height and removed logs are kept in groups - block logs, its removed logs, its logs again... -
+// in the order groups arrived in. Reuses the slice.
+func CanonicalLogs(logs []*types.Log) []*types.Log {
+	if isCanonicalOrder(logs) {
+		return logs
+	}
+
+	// group of logs - block and how many times its logs were removed or re-added before
+	type group struct {
+		blockHash  libcommon.Hash
+		generation int
+	}
+	type blockState struct {
+		removed    bool
+		generation int
+	}
+	type entry struct {
+		lg    *types.Log
+		group int
+	}
+	entries := make([]entry, 0, len(logs))
+	dedup := newLogsDedup(0)
+	blocks := map[libcommon.Hash]blockState{}
+	groups := map[group]int{}
+	for _, lg := range logs {
+		if dedup.Duplicate(lg) {
+			continue
+		}
+		state, ok := blocks[lg.BlockHash]
+		if !ok {
+			state.removed = lg.Removed
+		} else if state.removed != lg.Removed {
+			state.removed = lg.Removed
+			state.generation++
+		}
+		blocks[lg.BlockHash] = state
+		g := group{blockHash: lg.BlockHash, generation: state.generation}
+		ordinal, ok := groups[g]
+		if !ok {
+			ordinal = len(groups)
+			groups[g] = ordinal
+		}
+		entries = append(entries, entry{lg: lg, group: ordinal})
+	}
+	slices.SortStableFunc(entries, func(a, b entry) int {
+		return cmp.Or(
+			cmp.Compare(a.lg.BlockNumber, b.lg.BlockNumber),
+			cmp.Compare(a.group, b.group),
+			cmp.Compare(a.lg.TxIndex, b.lg.TxIndex),
+			cmp.Compare(a.lg.Index, b.lg.Index),
+		)
+	})
+
+	res := logs[:0]
+	for _, e := range entries {
+		res = append(res, e.lg)
+	}
+	clear(logs[len(res):])
+	return res
+}
+
+// isCanonicalOrder - fast path for logs of canonical blocks, which come from db already ordered
+func isCanonicalOrder(logs []*types.Log) bool {
+	for i, lg := range logs {
+		if lg.Removed {
+			return false
+		}
+		if i == 0 {
+			continue
+		}
+		prev := logs[i-1]
+		switch {
+		case prev.BlockNumber < lg.BlockNumber:
+		case prev.BlockNumber > lg.BlockNumber || prev.BlockHash != lg.BlockHash:
+			return false
+		case prev.TxIndex < lg.TxIndex:
+		case prev.TxIndex > lg.TxIndex || prev.Index >= lg.Index:
+			return false
+		}
+	}
+	return true
+}
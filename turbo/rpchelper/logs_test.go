@@ -0,0 +1,111 @@
+package rpchelper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"pgregory.net/rapid"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+
+	"github.com/erigontech/erigon/core/types"
+)
+
+func copyLog(lg *types.Log) *types.Log {
+	cpy := *lg
+	return &cpy
+}
+
+// Logs of canonical blocks come out ordered and without duplicates, whatever order they arrive in
+func TestCanonicalLogsOrder(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		var want []*types.Log
+		blocks := rapid.IntRange(0, 5).Draw(t, "blocks")
+		for b := 0; b < blocks; b++ {
+			var index uint
+			for txIndex := rapid.IntRange(0, 3).Draw(t, "txs"); txIndex >= 0; txIndex-- {
+				for n := rapid.IntRange(0, 3).Draw(t, "logs"); n > 0; n-- {
+					want = append(want, &types.Log{BlockNumber: uint64(b * 2), BlockHash: libcommon.Hash{byte(b)}, TxIndex: uint(txIndex), Index: index})
+					index++
+				}
+			}
+		}
+		// logs of transactions above are generated backwards
+		want = CanonicalLogs(want)
+		for i := 1; i < len(want); i++ {
+			prev, lg := want[i-1], want[i]
+			require.True(t, prev.BlockNumber < lg.BlockNumber || prev.TxIndex < lg.TxIndex || (prev.TxIndex == lg.TxIndex && prev.Index < lg.Index))
+		}
+
+		input := make([]*types.Log, 0, len(want))
+		for _, lg := range want {
+			input = append(input, copyLog(lg))
+		}
+		if len(want) > 0 {
+			for _, i := range rapid.SliceOf(rapid.IntRange(0, len(want)-1)).Draw(t, "duplicates") {
+				input = append(input, copyLog(want[i]))
+			}
+		}
+		input = rapid.Permutation(input).Draw(t, "order")
+		require.Equal(t, want, CanonicalLogs(input))
+	})
+}
+
+// Around reorgs logs of different blocks at the same height and removed logs are mixed - output keeps every
+// delivery of a log which changed its state, ordered by block number
+func TestCanonicalLogsReorg(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		input := rapid.SliceOf(rapid.Custom(func(t *rapid.T) *types.Log {
+			fork := rapid.IntRange(0, 1).Draw(t, "fork")
+			number := rapid.Uint64Range(0, 3).Draw(t, "number")
+			return &types.Log{
+				BlockNumber: number,
+				BlockHash:   libcommon.Hash{byte(number), byte(fork)},
+				TxIndex:     rapid.UintRange(0, 2).Draw(t, "txIndex"),
+				Index:       rapid.UintRange(0, 2).Draw(t, "index"),
+				Removed:     rapid.Bool().Draw(t, "removed"),
+			}
+		})).Draw(t, "logs")
+
+		dedup := newLogsDedup(0)
+		var delivered int
+		for _, lg := range input {
+			if !dedup.Duplicate(lg) {
+				delivered++
+			}
+		}
+
+		got := CanonicalLogs(input)
+		require.Len(t, got, delivered)
+		dedup = newLogsDedup(0)
+		for i, lg := range got {
+			require.False(t, dedup.Duplicate(lg))
+			if i > 0 {
+				require.LessOrEqual(t, got[i-1].BlockNumber, lg.BlockNumber)
+			}
+		}
+	})
+}
+
+func TestLogsDedup(t *testing.T) {
+	dedup := NewLogsDedup()
+	lg := &types.Log{BlockNumber: 1, BlockHash: libcommon.Hash{1}, TxIndex: 1, Index: 2}
+	require.False(t, dedup.Duplicate(lg))
+	require.True(t, dedup.Duplicate(copyLog(lg)))
+
+	// block is removed and becomes canonical again
+	removed := copyLog(lg)
+	removed.Removed = true
+	require.False(t, dedup.Duplicate(removed))
+	require.True(t, dedup.Duplicate(removed))
+	require.False(t, dedup.Duplicate(lg))
+
+	// the same position in other block
+	other := copyLog(lg)
+	other.BlockHash = libcommon.Hash{2}
+	require.False(t, dedup.Duplicate(other))
+
+	// old logs are forgotten
+	require.False(t, dedup.Duplicate(&types.Log{BlockNumber: 2 + logsDedupWindow, BlockHash: libcommon.Hash{3}}))
+	require.False(t, dedup.Duplicate(copyLog(lg)))
+}
@@ -55,19 +55,18 @@ func NewLogsFilterAggregator() *LogsFilterAggregator {
 	}
 }
 
-// insertLogsFilter inserts a new log filter into the LogsFilterAggregator with the specified sender.
-// It generates a new filter ID, creates a new LogsFilter, and adds it to the logsFilters map.
-func (a *LogsFilterAggregator) insertLogsFilter(sender Sub[*types2.Log]) (LogsSubID, *LogsFilter) {
+// insertLogsFilter inserts a new log filter into the LogsFilterAggregator with the specified filter ID and sender.
+// It creates a new LogsFilter and adds it to the logsFilters map.
+func (a *LogsFilterAggregator) insertLogsFilter(filterId LogsSubID, sender Sub[*types2.Log]) *LogsFilter {
 	a.logsFilterLock.Lock()
 	defer a.logsFilterLock.Unlock()
-	filterId := LogsSubID(generateSubscriptionID())
 	filter := &LogsFilter{
 		addrs:  concurrent.NewSyncMap[libcommon.Address, int](),
 		topics: concurrent.NewSyncMap[libcommon.Hash, int](),
 		sender: sender,
 	}
 	a.logsFilters.Put(filterId, filter)
-	return filterId, filter
+	return filter
 }
 
 // removeLogsFilter removes a log filter identified by filterId from the LogsFilterAggregator.
@@ -204,8 +203,10 @@ func (a *LogsFilterAggregator) distributeLog(eventLog *remote.SubscribeLogsReply
 	a.logsFilterLock.RLock()
 	defer a.logsFilterLock.RUnlock()
 
-	var lg types2.Log
-	var topics []libcommon.Hash
+	topics := make([]libcommon.Hash, 0, len(eventLog.Topics))
+	for _, topic := range eventLog.Topics {
+		topics = append(topics, gointerfaces.ConvertH256ToHash(topic))
+	}
 
 	a.logsFilters.Range(func(k LogsSubID, filter *LogsFilter) error {
 		if filter.allAddrs == 0 {
@@ -215,33 +216,25 @@ func (a *LogsFilterAggregator) distributeLog(eventLog *remote.SubscribeLogsReply
 			}
 		}
 
-		// Pre-allocate topics slice to the required size to avoid multiple allocations
-		topics = topics[:0]
-		if cap(topics) < len(eventLog.Topics) {
-			topics = make([]libcommon.Hash, 0, len(eventLog.Topics))
-		}
-		for _, topic := range eventLog.Topics {
-			topics = append(topics, gointerfaces.ConvertH256ToHash(topic))
-		}
-
 		if filter.allTopics == 0 {
 			if !a.chooseTopics(filter, topics) {
 				return nil
 			}
 		}
 
-		// Reuse lg object to avoid creating new instances
-		lg.Address = gointerfaces.ConvertH160toAddress(eventLog.Address)
-		lg.Topics = topics
-		lg.Data = eventLog.Data
-		lg.BlockNumber = eventLog.BlockNumber
-		lg.TxHash = gointerfaces.ConvertH256ToHash(eventLog.TransactionHash)
-		lg.TxIndex = uint(eventLog.TransactionIndex)
-		lg.BlockHash = gointerfaces.ConvertH256ToHash(eventLog.BlockHash)
-		lg.Index = uint(eventLog.LogIndex)
-		lg.Removed = eventLog.Removed
-
-		filter.sender.Send(&lg)
+		// Every filter gets its own log: filters keep logs (see Filters.AddLogs), so they must not share them.
+		// Topics are shared - they are never modified
+		filter.sender.Send(&types2.Log{
+			Address:     gointerfaces.ConvertH160toAddress(eventLog.Address),
+			Topics:      topics,
+			Data:        eventLog.Data,
+			BlockNumber: eventLog.BlockNumber,
+			TxHash:      gointerfaces.ConvertH256ToHash(eventLog.TransactionHash),
+			TxIndex:     uint(eventLog.TransactionIndex),
+			BlockHash:   gointerfaces.ConvertH256ToHash(eventLog.BlockHash),
+			Index:       uint(eventLog.LogIndex),
+			Removed:     eventLog.Removed,
+		})
 		return nil
 	})
 	return nil
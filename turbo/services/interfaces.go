@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 
+	"github.com/RoaringBitmap/roaring"
 	"github.com/erigontech/erigon-lib/log/v3"
 
 	"github.com/erigontech/erigon-lib/chain"
@@ -75,6 +76,8 @@ type BodyReader interface {
 
 type TxnReader interface {
 	TxnLookup(ctx context.Context, tx kv.Getter, txnHash common.Hash) (uint64, bool, error)
+	// TxnLookupWithIndex - block number and index of transaction in the block
+	TxnLookupWithIndex(ctx context.Context, tx kv.Getter, txnHash common.Hash) (blockNum uint64, txnIndex int, ok bool, err error)
 	TxnByIdxInBlock(ctx context.Context, tx kv.Getter, blockNum uint64, i int) (txn types.Transaction, err error)
 	RawTransactions(ctx context.Context, tx kv.Getter, fromBlock, toBlock uint64) (txs [][]byte, err error)
 	FirstTxnNumNotInSnapshots() uint64
@@ -122,10 +125,43 @@ type BlockSnapshots interface {
 	Close()
 }
 
+// LogIndexReader - eth_getLogs index of the frozen blocks: bitmaps of the blocks with logs of an address
+// (kv.LogAddressIndex) or with a topic (kv.LogTopicIndex)
+type LogIndexReader interface {
+	// Indexed - the index has blocks [from, to)
+	Indexed() (from, to uint64)
+	Get(table string, key []byte, from, to uint64) (*roaring.Bitmap, error)
+}
+
+// LogIndexSnapshots - block snapshots which have the eth_getLogs index
+type LogIndexSnapshots interface {
+	LogIndex() LogIndexReader
+}
+
+// CallTraceIndexReader - trace_filter index of the frozen blocks: bitmaps of the blocks with calls from an address
+// (kv.CallFromIndex) or to an address (kv.CallToIndex)
+type CallTraceIndexReader interface {
+	// Indexed - the index has blocks [from, to)
+	Indexed() (from, to uint64)
+	Get(table string, key []byte, from, to uint64) (*roaring.Bitmap, error)
+}
+
+// CallTraceIndexSnapshots - block snapshots which have the trace_filter index
+type CallTraceIndexSnapshots interface {
+	CallTraceIndex() CallTraceIndexReader
+}
+
+// ReceiptsReader - receipts of the blocks, also of the frozen ones which `erigon snapshots retire` pruned from the db
+type ReceiptsReader interface {
+	// RawReceipts - receipts without the derived fields, nil if there are none
+	RawReceipts(ctx context.Context, tx kv.Tx, blockNum uint64) (types.Receipts, error)
+}
+
 // BlockRetire - freezing blocks: moving old data from DB to snapshot files
 type BlockRetire interface {
 	PruneAncientBlocks(tx kv.RwTx, limit int) error
 	RetireBlocksInBackground(ctx context.Context, miBlockNum uint64, maxBlockNum uint64, lvl log.Lvl, seedNewSnapshots func(downloadRequest []DownloadRequest) error, onDelete func(l []string) error)
+	WaitRetireInBackground(ctx context.Context) error
 	HasNewFrozenFiles() bool
 	BuildMissedIndicesIfNeed(ctx context.Context, logPrefix string, notifier DBEventNotifier, cc *chain.Config) error
 	SetWorkers(workers int)
@@ -46,6 +46,11 @@ func (a *Accumulator) SetStateID(stateID uint64) {
 	a.plainStateID = stateID
 }
 
+// Changes returns the changes accumulated since the last reset, one per block, which must not be modified
+func (a *Accumulator) Changes() []*remote.StateChange {
+	return a.changes
+}
+
 // StartChange begins accumulation of changes for a new block
 func (a *Accumulator) StartChange(blockHeight uint64, blockHash libcommon.Hash, txs [][]byte, unwind bool) {
 	a.changes = append(a.changes, &remote.StateChange{})
@@ -0,0 +1,214 @@
+package freezeblocks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/RoaringBitmap/roaring"
+
+	"github.com/erigontech/erigon-lib/etl"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/eth/stagedsync/stages"
+	"github.com/erigontech/erigon/turbo/services"
+)
+
+const bitmapIndexFlushSize = 256 * 1024 * 1024
+
+// bitmapIndexKind - what a BitmapIndexes indexes: the source of the bitmaps and the layout of the keys
+type bitmapIndexKind struct {
+	name  string           // of the files: v1-000000-000500-<name>.seg
+	stage stages.SyncStage // the source has the blocks which are done by the stage
+
+	// key - the key of the files for the key of the db index table
+	key func(table string, key []byte) ([]byte, error)
+	// keyLen - the length of the key the word starts with
+	keyLen func(word []byte) int
+	// collect - adds the block numbers of the keys of blocks [r.from, r.to)
+	collect func(ctx context.Context, tx kv.Tx, r Range, add func(key []byte, blockNum uint64) error) error
+}
+
+// BitmapIndexes - index of the frozen block ranges for the bitmap tables of the db (e.g. eth_getLogs and trace_filter
+// ones). There is a pair of files per range of block segments: .seg has a word per key: key ++ serialized roaring
+// bitmap of the blocks of the key, and .idx (recsplit) maps the key to the offset of the word.
+//
+// A query over millions of frozen blocks reads one bitmap per file, and the db tables can be pruned.
+type BitmapIndexes struct {
+	rangeFiles
+	kind *bitmapIndexKind
+}
+
+func newBitmapIndexes(kind *bitmapIndexKind, dir string, logger log.Logger) *BitmapIndexes {
+	return &BitmapIndexes{
+		rangeFiles: rangeFiles{name: kind.name, stage: kind.stage, keyLen: kind.keyLen, dir: dir, logger: logger},
+		kind:       kind,
+	}
+}
+
+var (
+	_ services.LogIndexReader       = (*BitmapIndexes)(nil)
+	_ services.CallTraceIndexReader = (*BitmapIndexes)(nil)
+)
+
+// Get - blocks in [from, to] of the key of the db index table
+func (l *BitmapIndexes) Get(table string, key []byte, from, to uint64) (*roaring.Bitmap, error) {
+	indexKey, err := l.kind.key(table, key)
+	if err != nil {
+		return nil, err
+	}
+
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	result := roaring.New()
+	for _, f := range l.files {
+		if f.to <= from || f.from > to {
+			continue
+		}
+		word := l.lookup(f, indexKey, nil)
+		if word == nil {
+			continue
+		}
+		bm := roaring.New()
+		if _, err := bm.FromBuffer(word[len(indexKey):]); err != nil {
+			return nil, fmt.Errorf("index %s: %w", l.fileName(f.from, f.to, ".seg"), err)
+		}
+		result.Or(bm)
+	}
+	if from > 0 {
+		result.RemoveRange(0, from)
+	}
+	result.RemoveRange(to+1, uint64(roaring.MaxUint32)+1)
+	return result, nil
+}
+
+// Reconcile makes the files follow the block segments: builds the index of the segment ranges which are done by
+// the stage and not indexed yet (e.g. a range after merge, from the files of the merged ranges), and removes the
+// files of the ranges which are merged away. Returns true if the files changed.
+func (l *BitmapIndexes) Reconcile(ctx context.Context, db kv.RoDB, ranges []Range, tmpDir string, lvl log.Lvl) (bool, error) {
+	return l.reconcile(ctx, db, ranges, lvl, nil, func(r Range, parts []*rangeFile) error {
+		if parts != nil {
+			return l.merge(ctx, r, parts, tmpDir, lvl)
+		}
+		return l.build(ctx, db, r, tmpDir, lvl)
+	})
+}
+
+// build collects the bitmaps of blocks [r.from, r.to) from the source of the kind, the way the stage does,
+// and writes them sorted by key. .idx is written last: the pair is complete only if it exists.
+func (l *BitmapIndexes) build(ctx context.Context, db kv.RoDB, r Range, tmpDir string, lvl log.Lvl) error {
+	logPrefix := fmt.Sprintf("[snapshots] %s index", l.kind.name)
+	collector := etl.NewCollector(logPrefix, tmpDir, etl.NewSortableBuffer(etl.BufferOptimalSize), l.logger)
+	defer collector.Close()
+	collector.LogLvl(lvl)
+
+	bitmaps := map[string]*roaring.Bitmap{}
+	var bitmapsSize uint64
+	flush := func() error {
+		var buf bytes.Buffer
+		for k, m := range bitmaps {
+			m.RunOptimize()
+			buf.Reset()
+			if _, err := m.WriteTo(&buf); err != nil {
+				return err
+			}
+			if err := collector.Collect([]byte(k), buf.Bytes()); err != nil {
+				return err
+			}
+		}
+		bitmaps, bitmapsSize = map[string]*roaring.Bitmap{}, 0
+		return nil
+	}
+	add := func(key []byte, blockNum uint64) error {
+		m, ok := bitmaps[string(key)]
+		if !ok {
+			m = roaring.New()
+			bitmaps[string(key)] = m
+			bitmapsSize += uint64(len(key)) * 4
+		}
+		m.Add(uint32(blockNum))
+		// an approximation, as the bitmaps don't tell their size cheaply
+		bitmapsSize += 4
+		if bitmapsSize > bitmapIndexFlushSize {
+			return flush()
+		}
+		return nil
+	}
+
+	if err := db.View(ctx, func(tx kv.Tx) error {
+		return l.kind.collect(ctx, tx, r, add)
+	}); err != nil {
+		return err
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	return l.write(ctx, collector, r, tmpDir, lvl)
+}
+
+// merge writes the bitmaps of the files of the ranges merged into r
+func (l *BitmapIndexes) merge(ctx context.Context, r Range, parts []*rangeFile, tmpDir string, lvl log.Lvl) error {
+	logPrefix := fmt.Sprintf("[snapshots] %s index", l.kind.name)
+	collector := etl.NewCollector(logPrefix, tmpDir, etl.NewSortableBuffer(etl.BufferOptimalSize), l.logger)
+	defer collector.Close()
+	collector.LogLvl(lvl)
+
+	if err := l.words(ctx, parts, func(word []byte) error {
+		keyLen := l.kind.keyLen(word)
+		if len(word) <= keyLen { // dummy word of a range without keys
+			return nil
+		}
+		return collector.Collect(word[:keyLen], word[keyLen:])
+	}); err != nil {
+		return err
+	}
+	return l.write(ctx, collector, r, tmpDir, lvl)
+}
+
+// write writes the collected bitmaps sorted by key, the bitmaps of the same key are merged
+func (l *BitmapIndexes) write(ctx context.Context, collector *etl.Collector, r Range, tmpDir string, lvl log.Lvl) error {
+	comp, err := l.compressor(ctx, r, tmpDir, lvl)
+	if err != nil {
+		return err
+	}
+	defer comp.Close()
+
+	// the same key comes from every flush, sorted next to each other
+	var currentKey []byte
+	current := roaring.New()
+	var word bytes.Buffer
+	writeCurrent := func() error {
+		if currentKey == nil {
+			return nil
+		}
+		current.RunOptimize()
+		word.Reset()
+		word.Write(currentKey)
+		if _, err := current.WriteTo(&word); err != nil {
+			return err
+		}
+		return l.addWord(comp, word.Bytes())
+	}
+	if err := collector.Load(nil, "", func(k, v []byte, _ etl.CurrentTableReader, _ etl.LoadNextFunc) error {
+		if !bytes.Equal(k, currentKey) {
+			if err := writeCurrent(); err != nil {
+				return err
+			}
+			currentKey = append(currentKey[:0], k...)
+			current.Clear()
+		}
+		bm := roaring.New()
+		if _, err := bm.FromBuffer(v); err != nil {
+			return err
+		}
+		current.Or(bm)
+		return nil
+	}, etl.TransformArgs{Quit: ctx.Done()}); err != nil {
+		return err
+	}
+	if err := writeCurrent(); err != nil {
+		return err
+	}
+	return l.finish(ctx, comp, r, tmpDir)
+}
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"slices"
 	"sort"
 	"time"
 
@@ -146,6 +147,14 @@ func (r *RemoteBlockReader) TxnLookup(ctx context.Context, tx kv.Getter, txnHash
 	return reply.BlockNumber, true, nil
 }
 
+func (r *RemoteBlockReader) TxnLookupWithIndex(ctx context.Context, tx kv.Getter, txnHash common.Hash) (uint64, int, bool, error) {
+	blockNum, ok, err := r.TxnLookup(ctx, tx, txnHash)
+	if err != nil || !ok {
+		return 0, 0, false, err
+	}
+	return txnIndexInBlock(ctx, r, tx, txnHash, blockNum)
+}
+
 func (r *RemoteBlockReader) TxnByIdxInBlock(ctx context.Context, tx kv.Getter, blockNum uint64, i int) (txn types.Transaction, err error) {
 	canonicalHash, err := r.CanonicalHash(ctx, tx, blockNum)
 	if err != nil {
@@ -315,7 +324,7 @@ func NewBlockReader(snapshots services.BlockSnapshots, borSnapshots services.Blo
 }
 
 func (r *BlockReader) CanPruneTo(currentBlockInDB uint64) uint64 {
-	return CanDeleteTo(currentBlockInDB, r.sn.BlocksAvailable())
+	return CanDeleteTo(currentBlockInDB, r.sn.BlocksAvailable(), r.sn.Cfg().BlocksFreezeDistance())
 }
 func (r *BlockReader) Snapshots() services.BlockSnapshots { return r.sn }
 func (r *BlockReader) BorSnapshots() services.BlockSnapshots {
@@ -728,6 +737,11 @@ func (r *BlockReader) blockWithSenders(ctx context.Context, tx kv.Getter, hash c
 			return nil, nil, err
 		}
 		release()
+		if slices.Contains(senders, common.Address{}) { // segment has no senders for this block, see SendersBackfill stage
+			if senders, err = rawdb.ReadFrozenSenders(tx, hash, blockHeight); err != nil {
+				return nil, nil, err
+			}
+		}
 	}
 
 	if h.WithdrawalsHash == nil && len(b.Withdrawals) == 0 {
@@ -901,7 +915,9 @@ func (r *BlockReader) txsFromSnapshot(baseTxnID uint64, txsAmount uint32, txsSeg
 		if err != nil {
 			return nil, nil, err
 		}
-		txs[i].SetSender(senders[i])
+		if senders[i] != (common.Address{}) { // zero - segment has no sender, it must be recovered
+			txs[i].SetSender(senders[i])
+		}
 	}
 
 	return txs, senders, nil
@@ -923,11 +939,14 @@ func (r *BlockReader) txnByID(txnID uint64, sn *Segment, buf []byte) (txn types.
 	if err != nil {
 		return
 	}
-	txn.SetSender(*(*common.Address)(sender)) // see: https://tip.golang.org/ref/spec#Conversions_from_slice_to_array_pointer
+	if senderAddr := *(*common.Address)(sender); senderAddr != (common.Address{}) { // see: https://tip.golang.org/ref/spec#Conversions_from_slice_to_array_pointer
+		txn.SetSender(senderAddr)
+	}
 	return
 }
 
-func (r *BlockReader) txnByHash(txnHash common.Hash, segments []*Segment, buf []byte) (types.Transaction, uint64, bool, error) {
+// txnByHash - returns transaction, its block number and txnID
+func (r *BlockReader) txnByHash(txnHash common.Hash, segments []*Segment, buf []byte) (types.Transaction, uint64, uint64, bool, error) {
 	for i := len(segments) - 1; i >= 0; i-- {
 		sn := segments[i]
 
@@ -956,10 +975,12 @@ func (r *BlockReader) txnByHash(txnHash common.Hash, segments []*Segment, buf []
 
 		txn, err := types.DecodeTransaction(txnRlp)
 		if err != nil {
-			return nil, 0, false, err
+			return nil, 0, 0, false, err
 		}
 
-		txn.SetSender(sender) // see: https://tip.golang.org/ref/spec#Conversions_from_slice_to_array_pointer
+		if sender != (common.Address{}) { // zero - segment has no sender, it must be recovered
+			txn.SetSender(sender) // see: https://tip.golang.org/ref/spec#Conversions_from_slice_to_array_pointer
+		}
 
 		reader2 := recsplit.NewIndexReader(idxTxnHash2BlockNum)
 		blockNum, ok := reader2.Lookup(txnHash[:])
@@ -969,11 +990,11 @@ func (r *BlockReader) txnByHash(txnHash common.Hash, segments []*Segment, buf []
 
 		// final txnHash check  - completely avoid false-positives
 		if txn.Hash() == txnHash {
-			return txn, blockNum, true, nil
+			return txn, blockNum, idxTxnHash.BaseDataID() + txnId, true, nil
 		}
 	}
 
-	return nil, 0, false, nil
+	return nil, 0, 0, false, nil
 }
 
 // TxnByIdxInBlock - doesn't include system-transactions in the begin/end of block
@@ -1031,7 +1052,7 @@ func (r *BlockReader) TxnLookup(_ context.Context, tx kv.Getter, txnHash common.
 
 	txns, release := r.sn.ViewType(coresnaptype.Transactions)
 	defer release()
-	_, blockNum, ok, err := r.txnByHash(txnHash, txns, nil)
+	_, blockNum, _, ok, err := r.txnByHash(txnHash, txns, nil)
 	if err != nil {
 		return 0, false, err
 	}
@@ -1039,6 +1060,59 @@ func (r *BlockReader) TxnLookup(_ context.Context, tx kv.Getter, txnHash common.
 	return blockNum, ok, nil
 }
 
+// TxnLookupWithIndex - find blockNumber and index of transaction in the block by txnHash.
+// Frozen transactions don't need TxLookup table and block reading: .idx files of snapshots give
+// txnHash -> txnID, then index in block is txnID relative to BaseTxId of the block body
+func (r *BlockReader) TxnLookupWithIndex(ctx context.Context, tx kv.Getter, txnHash common.Hash) (uint64, int, bool, error) {
+	n, err := rawdb.ReadTxLookupEntry(tx, txnHash)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	if n != nil {
+		return txnIndexInBlock(ctx, r, tx, txnHash, *n)
+	}
+
+	txns, release := r.sn.ViewType(coresnaptype.Transactions)
+	_, blockNum, txnID, ok, err := r.txnByHash(txnHash, txns, nil)
+	release()
+	if err != nil || !ok {
+		return 0, 0, false, err
+	}
+
+	seg, ok, release := r.sn.ViewSingleFile(coresnaptype.Bodies, blockNum)
+	if !ok {
+		return 0, 0, false, nil
+	}
+	defer release()
+	b, _, err := r.bodyForStorageFromSnapshot(blockNum, seg, nil)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	if b == nil || txnID <= b.BaseTxId {
+		return 0, 0, false, nil
+	}
+	// -1 because block has system-txn in the beginning of block
+	return blockNum, int(txnID - b.BaseTxId - 1), true, nil
+}
+
+// txnIndexInBlock - index of txnHash in canonical block blockNum, found by reading the block body
+func txnIndexInBlock(ctx context.Context, r services.FullBlockReader, tx kv.Getter, txnHash common.Hash, blockNum uint64) (uint64, int, bool, error) {
+	hash, err := r.CanonicalHash(ctx, tx, blockNum)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	body, err := r.BodyWithTransactions(ctx, tx, hash, blockNum)
+	if err != nil || body == nil {
+		return 0, 0, false, err
+	}
+	for i, txn := range body.Transactions {
+		if txn.Hash() == txnHash {
+			return blockNum, i, true, nil
+		}
+	}
+	return 0, 0, false, nil
+}
+
 func (r *BlockReader) FirstTxnNumNotInSnapshots() uint64 {
 	sn, ok, release := r.sn.ViewSingleFile(coresnaptype.Transactions, r.sn.BlocksAvailable())
 	if !ok {
@@ -1138,6 +1212,20 @@ func (r *BlockReader) RawTransactions(ctx context.Context, tx kv.Getter, fromBlo
 	return rawdb.RawTransactionsRange(tx, fromBlock, toBlock)
 }
 
+var _ services.ReceiptsReader = &BlockReader{}
+
+// RawReceipts - like rawdb.ReadRawReceipts, but the receipts pruned by `erigon snapshots retire` are read from
+// the frozen receipts
+func (r *BlockReader) RawReceipts(ctx context.Context, tx kv.Tx, blockNum uint64) (types.Receipts, error) {
+	if r.sn != nil && r.sn.receipts != nil {
+		receipts, ok, err := r.sn.receipts.RawReceipts(blockNum)
+		if err != nil || ok {
+			return receipts, err
+		}
+	}
+	return rawdb.ReadRawReceipts(tx, blockNum), nil
+}
+
 func (r *BlockReader) ReadAncestor(db kv.Getter, hash common.Hash, number, ancestor uint64, maxNonCanonical *uint64) (common.Hash, uint64) {
 	if ancestor > number {
 		return common.Hash{}, 0
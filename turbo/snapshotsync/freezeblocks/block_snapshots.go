@@ -21,6 +21,7 @@ import (
 	"github.com/tidwall/btree"
 	"golang.org/x/exp/slices"
 	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
 
 	"github.com/erigontech/erigon-lib/chain"
 	"github.com/erigontech/erigon-lib/chain/snapcfg"
@@ -34,6 +35,7 @@ import (
 	"github.com/erigontech/erigon-lib/diagnostics"
 	"github.com/erigontech/erigon-lib/downloader/snaptype"
 	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/metrics"
 	"github.com/erigontech/erigon-lib/recsplit"
 	"github.com/erigontech/erigon-lib/seg"
 	types2 "github.com/erigontech/erigon-lib/types"
@@ -44,7 +46,6 @@ import (
 	"github.com/erigontech/erigon/eth/ethconfig"
 	"github.com/erigontech/erigon/eth/ethconfig/estimate"
 	"github.com/erigontech/erigon/eth/stagedsync/stages"
-	"github.com/erigontech/erigon/params"
 	"github.com/erigontech/erigon/polygon/heimdall"
 	"github.com/erigontech/erigon/rlp"
 	"github.com/erigontech/erigon/turbo/services"
@@ -114,11 +115,11 @@ func (s Segment) FileInfo(dir string) snaptype.FileInfo {
 	return s.Type().FileInfo(dir, s.from, s.to)
 }
 
-func (s *Segment) reopenSeg(dir string) (err error) {
+func (s *Segment) reopenSeg(dir string, keyProvider seg.KeyProvider) (err error) {
 	if s.Decompressor != nil {
 		return nil
 	}
-	s.Decompressor, err = seg.NewDecompressor(filepath.Join(dir, s.FileName()))
+	s.Decompressor, err = seg.NewDecompressorWithKeyProvider(filepath.Join(dir, s.FileName()), keyProvider)
 	if err != nil {
 		return fmt.Errorf("%w, fileName: %s", err, s.FileName())
 	}
@@ -132,6 +133,13 @@ func (s *Segment) closeSeg() {
 	}
 }
 
+func (s *Segment) size() int64 {
+	if s.Decompressor == nil {
+		return 0
+	}
+	return s.Decompressor.Size()
+}
+
 func (s *Segment) closeIdx() {
 	for _, index := range s.indexes {
 		index.Close()
@@ -273,6 +281,10 @@ type RoSnapshots struct {
 
 	// allows for pruning segments - this is the min availible segment
 	segmentsMin atomic.Uint64
+
+	logIndexes       *BitmapIndexes   // nil for the snapshots without blocks
+	callTraceIndexes *BitmapIndexes   // nil for the snapshots without blocks
+	receipts         *ReceiptSegments // nil for the snapshots without blocks
 }
 
 // NewRoSnapshots - opens all snapshots. But to simplify everything:
@@ -281,7 +293,12 @@ type RoSnapshots struct {
 //   - gaps are not allowed
 //   - segment have [from:to) semantic
 func NewRoSnapshots(cfg ethconfig.BlocksFreezing, snapDir string, segmentsMin uint64, logger log.Logger) *RoSnapshots {
-	return newRoSnapshots(cfg, snapDir, coresnaptype.BlockSnapshotTypes, segmentsMin, logger)
+	s := newRoSnapshots(cfg, snapDir, coresnaptype.BlockSnapshotTypes, segmentsMin, logger)
+	s.logIndexes = NewLogIndexes(filepath.Join(snapDir, logIndexDir), logger)
+	s.callTraceIndexes = NewCallTraceIndexes(filepath.Join(snapDir, callTraceIndexDir), logger)
+	s.receipts = NewReceiptSegments(filepath.Join(snapDir, receiptsDir), logger)
+	s.receipts.keyProvider = cfg.KeyProvider
+	return s
 }
 
 func newRoSnapshots(cfg ethconfig.BlocksFreezing, snapDir string, types []snaptype.Type, segmentsMin uint64, logger log.Logger) *RoSnapshots {
@@ -485,9 +502,40 @@ func (s *RoSnapshots) ReopenList(fileNames []string, optimistic bool) error {
 	if err := s.rebuildSegments(fileNames, true, optimistic); err != nil {
 		return err
 	}
+	if s.logIndexes != nil {
+		if err := s.logIndexes.OpenFolder(); err != nil {
+			s.logger.Warn("[snapshots] open log indexes", "err", err)
+		}
+	}
+	if s.callTraceIndexes != nil {
+		if err := s.callTraceIndexes.OpenFolder(); err != nil {
+			s.logger.Warn("[snapshots] open call trace indexes", "err", err)
+		}
+	}
+	if s.receipts != nil {
+		if err := s.receipts.OpenFolder(); err != nil {
+			s.logger.Warn("[snapshots] open receipts", "err", err)
+		}
+	}
 	return nil
 }
 
+// LogIndex - eth_getLogs index of the frozen blocks, nil if the snapshots have no blocks
+func (s *RoSnapshots) LogIndex() services.LogIndexReader {
+	if s.logIndexes == nil {
+		return nil
+	}
+	return s.logIndexes
+}
+
+// CallTraceIndex - trace_filter index of the frozen blocks, nil if the snapshots have no blocks
+func (s *RoSnapshots) CallTraceIndex() services.CallTraceIndexReader {
+	if s.callTraceIndexes == nil {
+		return nil
+	}
+	return s.callTraceIndexes
+}
+
 func (s *RoSnapshots) InitSegments(fileNames []string) error {
 	if err := s.rebuildSegments(fileNames, false, true); err != nil {
 		return err
@@ -553,7 +601,7 @@ func (s *RoSnapshots) rebuildSegments(fileNames []string, open bool, optimistic
 		}
 
 		if open {
-			if err := sn.reopenSeg(s.dir); err != nil {
+			if err := sn.reopenSeg(s.dir, s.cfg.KeyProvider); err != nil {
 				if errors.Is(err, os.ErrNotExist) {
 					if optimistic {
 						continue
@@ -646,6 +694,15 @@ func (s *RoSnapshots) Close() {
 	s.lockSegments()
 	defer s.unlockSegments()
 	s.closeWhatNotInList(nil)
+	if s.logIndexes != nil {
+		s.logIndexes.Close()
+	}
+	if s.callTraceIndexes != nil {
+		s.callTraceIndexes.Close()
+	}
+	if s.receipts != nil {
+		s.receipts.Close()
+	}
 }
 
 func (s *RoSnapshots) closeWhatNotInList(l []string) {
@@ -743,71 +800,43 @@ func (s *RoSnapshots) buildMissedIndices(logPrefix string, ctx context.Context,
 	}
 
 	dir, tmpDir := dirs.Snap, dirs.Tmp
+	ctx = seg.WithKeyProvider(ctx, s.cfg.KeyProvider)
 	//log.Log(lvl, "[snapshots] Build indices", "from", min)
 
-	ps := background.NewProgressSet()
-	startIndexingTime := time.Now()
-
-	logEvery := time.NewTicker(20 * time.Second)
-	defer logEvery.Stop()
-
-	g, gCtx := errgroup.WithContext(ctx)
-	g.SetLimit(workers)
-	finish := make(chan struct{})
-
-	go func() {
-		for {
-			select {
-			case <-logEvery.C:
-				var m runtime.MemStats
-				dbg.ReadMemStats(&m)
-				sendDiagnostics(startIndexingTime, ps.DiagnossticsData(), m.Alloc, m.Sys)
-				logger.Info(fmt.Sprintf("[%s] Indexing", logPrefix), "progress", ps.String(), "total-indexing-time", time.Since(startIndexingTime).Round(time.Second).String(), "alloc", common2.ByteCount(m.Alloc), "sys", common2.ByteCount(m.Sys))
-			case <-finish:
-				return
-			case <-ctx.Done():
-				return
-			}
-		}
-	}()
-
+	type indexTask struct {
+		segtype snaptype.Enum
+		segment *Segment
+		info    snaptype.FileInfo
+	}
+	var tasks []indexTask
 	s.segments.Scan(func(segtype snaptype.Enum, value *segments) bool {
 		for _, segment := range value.segments {
 			info := segment.FileInfo(dir)
-
 			if segtype.HasIndexFiles(info, logger) {
 				continue
 			}
-
 			segment.closeIdx()
-
-			g.Go(func() error {
-				p := &background.Progress{}
-				ps.Add(p)
-				defer notifySegmentIndexingFinished(info.Name())
-				defer ps.Delete(p)
-				if err := segtype.BuildIndexes(gCtx, info, chainConfig, tmpDir, p, log.LvlInfo, logger); err != nil {
-					return fmt.Errorf("%s: %w", info.Name(), err)
-				}
-				return nil
-			})
+			tasks = append(tasks, indexTask{segtype, segment, info})
 		}
-
 		return true
 	})
+	// the largest files first, not to end up waiting for a single one with idle workers
+	slices.SortStableFunc(tasks, func(a, b indexTask) int { return cmp.Compare(b.segment.size(), a.segment.size()) })
 
-	go func() {
-		defer close(finish)
-		g.Wait()
-	}()
+	progress := newIndexingProgress(logPrefix, logger)
+	progress.add(len(tasks))
+	defer progress.run()()
 
-	// Block main thread
-	select {
-	case <-finish:
-		return g.Wait()
-	case <-ctx.Done():
-		return ctx.Err()
+	g, gCtx := errgroup.WithContext(ctx)
+	g.SetLimit(workers)
+	for _, task := range tasks {
+		g.Go(func() error {
+			return progress.build(task.info.Name(), func(p *background.Progress) error {
+				return task.segtype.BuildIndexes(gCtx, task.info, chainConfig, tmpDir, p, log.LvlInfo, logger)
+			})
+		})
 	}
+	return g.Wait()
 }
 
 func (s *RoSnapshots) PrintDebug() {
@@ -1121,9 +1150,16 @@ func chooseSegmentEnd(from, to uint64, snapType snaptype.Enum, chainConfig *chai
 	return to - (to % snaptype.Erigon2MinSegmentSize) // round down to the nearest 1k
 }
 
+var (
+	retiredBlocks           = metrics.GetOrCreateCounter(`snapshots_retired_blocks{kind="blocks"}`)
+	retiredBorBlocks        = metrics.GetOrCreateCounter(`snapshots_retired_blocks{kind="bor"}`)
+	retireBlocksDuration    = metrics.GetOrCreateSummary(`snapshots_retire_duration_seconds{kind="blocks"}`)
+	retireBorBlocksDuration = metrics.GetOrCreateSummary(`snapshots_retire_duration_seconds{kind="bor"}`)
+)
+
 type BlockRetire struct {
 	maxScheduledBlock     atomic.Uint64
-	working               atomic.Bool
+	working               *semaphore.Weighted // held by the retire in background
 	needSaveFilesListInDB atomic.Bool
 
 	workers int
@@ -1139,7 +1175,7 @@ type BlockRetire struct {
 }
 
 func NewBlockRetire(compressWorkers int, dirs datadir.Dirs, blockReader services.FullBlockReader, blockWriter *blockio.BlockWriter, db kv.RoDB, chainConfig *chain.Config, notifier services.DBEventNotifier, logger log.Logger) *BlockRetire {
-	return &BlockRetire{workers: compressWorkers, tmpDir: dirs.Tmp, dirs: dirs, blockReader: blockReader, blockWriter: blockWriter, db: db, chainConfig: chainConfig, notifier: notifier, logger: logger}
+	return &BlockRetire{working: semaphore.NewWeighted(1), workers: compressWorkers, tmpDir: dirs.Tmp, dirs: dirs, blockReader: blockReader, blockWriter: blockWriter, db: db, chainConfig: chainConfig, notifier: notifier, logger: logger}
 }
 
 func (br *BlockRetire) SetWorkers(workers int) {
@@ -1162,12 +1198,13 @@ func (br *BlockRetire) HasNewFrozenFiles() bool {
 	return br.needSaveFilesListInDB.CompareAndSwap(true, false)
 }
 
-func CanRetire(curBlockNum uint64, blocksInSnapshots uint64, snapType snaptype.Enum, chainConfig *chain.Config) (blockFrom, blockTo uint64, can bool) {
-	if curBlockNum <= params.FullImmutabilityThreshold {
+// CanRetire - next range of blocks which can be frozen into snapshots, keeping `freezeDistance` recent blocks in db
+func CanRetire(curBlockNum uint64, blocksInSnapshots uint64, freezeDistance uint64, snapType snaptype.Enum, chainConfig *chain.Config) (blockFrom, blockTo uint64, can bool) {
+	if curBlockNum <= freezeDistance {
 		return
 	}
 	blockFrom = blocksInSnapshots + 1
-	return canRetire(blockFrom, curBlockNum-params.FullImmutabilityThreshold, snapType, chainConfig)
+	return canRetire(blockFrom, curBlockNum-freezeDistance, snapType, chainConfig)
 }
 
 func canRetire(from, to uint64, snapType snaptype.Enum, chainConfig *chain.Config) (blockFrom, blockTo uint64, can bool) {
@@ -1210,16 +1247,17 @@ func canRetire(from, to uint64, snapType snaptype.Enum, chainConfig *chain.Confi
 	return blockFrom, blockTo, blockTo-blockFrom >= 1_000
 }
 
-func CanDeleteTo(curBlockNum uint64, blocksInSnapshots uint64) (blockTo uint64) {
+// CanDeleteTo - blocks below this number are already frozen and can be removed from db, keeping `freezeDistance` recent blocks
+func CanDeleteTo(curBlockNum uint64, blocksInSnapshots uint64, freezeDistance uint64) (blockTo uint64) {
 	if blocksInSnapshots == 0 {
 		return 0
 	}
 
-	if curBlockNum+999 < params.FullImmutabilityThreshold {
+	if curBlockNum+999 < freezeDistance {
 		// To prevent overflow of uint64 below
 		return blocksInSnapshots + 1
 	}
-	hardLimit := (curBlockNum/1_000)*1_000 - params.FullImmutabilityThreshold
+	hardLimit := (curBlockNum/1_000)*1_000 - freezeDistance
 	return cmp.Min(hardLimit, blocksInSnapshots+1)
 }
 
@@ -1255,8 +1293,9 @@ func (br *BlockRetire) retireBlocks(ctx context.Context, minBlockNum uint64, max
 
 	notifier, logger, blockReader, tmpDir, db, workers := br.notifier, br.logger, br.blockReader, br.tmpDir, br.db, br.workers
 	snapshots := br.snapshots()
+	ctx = seg.WithKeyProvider(ctx, snapshots.cfg.KeyProvider)
 
-	blockFrom, blockTo, ok := CanRetire(maxBlockNum, minBlockNum, snaptype.Unknown, br.chainConfig)
+	blockFrom, blockTo, ok := CanRetire(maxBlockNum, minBlockNum, br.blockReader.FreezingCfg().BlocksFreezeDistance(), snaptype.Unknown, br.chainConfig)
 
 	if ok {
 		if has, err := br.dbHasEnoughDataForBlocksRetire(ctx); err != nil {
@@ -1266,9 +1305,12 @@ func (br *BlockRetire) retireBlocks(ctx context.Context, minBlockNum uint64, max
 		}
 		logger.Log(lvl, "[snapshots] Retire Blocks", "range", fmt.Sprintf("%dk-%dk", blockFrom/1000, blockTo/1000))
 		// in future we will do it in background
+		retireStart := time.Now()
 		if err := DumpBlocks(ctx, blockFrom, blockTo, br.chainConfig, tmpDir, snapshots.Dir(), db, workers, lvl, logger, blockReader); err != nil {
 			return ok, fmt.Errorf("DumpBlocks: %w", err)
 		}
+		retireBlocksDuration.ObserveDuration(retireStart)
+		retiredBlocks.AddUint64(blockTo - blockFrom)
 
 		if err := snapshots.ReopenFolder(); err != nil {
 			return ok, fmt.Errorf("reopen: %w", err)
@@ -1312,7 +1354,8 @@ func (br *BlockRetire) retireBlocks(ctx context.Context, minBlockNum uint64, max
 }
 
 func (br *BlockRetire) PruneAncientBlocks(tx kv.RwTx, limit int) error {
-	if br.blockReader.FreezingCfg().KeepBlocks {
+	freezingCfg := br.blockReader.FreezingCfg()
+	if freezingCfg.KeepBlocks {
 		return nil
 	}
 	currentProgress, err := stages.GetStageProgress(tx, stages.Senders)
@@ -1320,7 +1363,7 @@ func (br *BlockRetire) PruneAncientBlocks(tx kv.RwTx, limit int) error {
 		return err
 	}
 
-	if canDeleteTo := CanDeleteTo(currentProgress, br.blockReader.FrozenBlocks()); canDeleteTo > 0 {
+	if canDeleteTo := CanDeleteTo(currentProgress, br.blockReader.FrozenBlocks(), freezingCfg.BlocksFreezeDistance()); canDeleteTo > 0 {
 		br.logger.Debug("[snapshots] Prune Blocks", "to", canDeleteTo, "limit", limit)
 		if err := br.blockWriter.PruneBlocks(context.Background(), tx, canDeleteTo, limit); err != nil {
 			return err
@@ -1328,7 +1371,7 @@ func (br *BlockRetire) PruneAncientBlocks(tx kv.RwTx, limit int) error {
 	}
 
 	if br.chainConfig.Bor != nil {
-		if canDeleteTo := CanDeleteTo(currentProgress, br.blockReader.FrozenBorBlocks()); canDeleteTo > 0 {
+		if canDeleteTo := CanDeleteTo(currentProgress, br.blockReader.FrozenBorBlocks(), freezingCfg.BorBlocksFreezeDistance()); canDeleteTo > 0 {
 			br.logger.Debug("[snapshots] Prune Bor Blocks", "to", canDeleteTo, "limit", limit)
 			if err := br.blockWriter.PruneBorBlocks(context.Background(), tx, canDeleteTo, limit,
 				func(block uint64) uint64 { return uint64(heimdall.SpanIdAt(block)) }); err != nil {
@@ -1345,12 +1388,12 @@ func (br *BlockRetire) RetireBlocksInBackground(ctx context.Context, minBlockNum
 		br.maxScheduledBlock.Store(maxBlockNum)
 	}
 
-	if !br.working.CompareAndSwap(false, true) {
+	if !br.working.TryAcquire(1) {
 		return
 	}
 
 	go func() {
-		defer br.working.Store(false)
+		defer br.working.Release(1)
 
 		err := br.RetireBlocks(ctx, minBlockNum, maxBlockNum, lvl, seedNewSnapshots, onDeleteSnapshots)
 		if err != nil {
@@ -1360,6 +1403,15 @@ func (br *BlockRetire) RetireBlocksInBackground(ctx context.Context, minBlockNum
 	}()
 }
 
+// WaitRetireInBackground - waits until the blocks retired in background (if any) are written to the snapshots
+func (br *BlockRetire) WaitRetireInBackground(ctx context.Context) error {
+	if err := br.working.Acquire(ctx, 1); err != nil {
+		return err
+	}
+	br.working.Release(1)
+	return nil
+}
+
 func (br *BlockRetire) RetireBlocks(ctx context.Context, minBlockNum uint64, maxBlockNum uint64, lvl log.Lvl, seedNewSnapshots func(downloadRequest []services.DownloadRequest) error, onDeleteSnapshots func(l []string) error) error {
 	if maxBlockNum > br.maxScheduledBlock.Load() {
 		br.maxScheduledBlock.Store(maxBlockNum)
@@ -1405,6 +1457,34 @@ func (br *BlockRetire) RetireBlocks(ctx context.Context, minBlockNum uint64, max
 			break
 		}
 	}
+	return br.indexFrozen(ctx, lvl)
+}
+
+// indexFrozen builds the eth_getLogs and trace_filter indexes of the frozen ranges which are done by the stages by now,
+// and the receipts of them on OP chains
+func (br *BlockRetire) indexFrozen(ctx context.Context, lvl log.Lvl) error {
+	snapshots := br.snapshots()
+	var changed bool
+	for _, indexes := range []*BitmapIndexes{snapshots.logIndexes, snapshots.callTraceIndexes} {
+		if indexes == nil {
+			continue
+		}
+		ok, err := indexes.Reconcile(ctx, br.db, snapshots.Ranges(), br.tmpDir, lvl)
+		if err != nil {
+			return err
+		}
+		changed = changed || ok
+	}
+	if snapshots.receipts != nil && br.chainConfig != nil && br.chainConfig.IsOptimism() {
+		ok, err := snapshots.receipts.Reconcile(ctx, br.db, snapshots.Ranges(), br.tmpDir, lvl)
+		if err != nil {
+			return err
+		}
+		changed = changed || ok
+	}
+	if changed && br.notifier != nil && !reflect.ValueOf(br.notifier).IsNil() {
+		br.notifier.OnNewSnapshot()
+	}
 	return nil
 }
 
@@ -1926,6 +2006,7 @@ func (m *Merger) Merge(ctx context.Context, snapshots *RoSnapshots, snapTypes []
 	if len(mergeRanges) == 0 {
 		return nil
 	}
+	ctx = seg.WithKeyProvider(ctx, snapshots.cfg.KeyProvider)
 	logEvery := time.NewTicker(30 * time.Second)
 	defer logEvery.Stop()
 	for _, r := range mergeRanges {
@@ -1980,7 +2061,7 @@ func (m *Merger) merge(ctx context.Context, toMerge []string, targetFile string,
 	var expectedTotal int
 	cList := make([]*seg.Decompressor, len(toMerge))
 	for i, cFile := range toMerge {
-		d, err := seg.NewDecompressor(cFile)
+		d, err := seg.NewDecompressorWithKeyProvider(cFile, seg.KeyProviderFromContext(ctx))
 		if err != nil {
 			return err
 		}
@@ -2181,5 +2262,13 @@ func RemoveIncompatibleIndices(snapsDir string) error {
 		}
 		index.Close()
 	}
+	// left by interrupted index builds, which are resumed from the files without an index
+	tmpFiles, err := snaptype.TmpFiles(snapsDir)
+	if err != nil {
+		return err
+	}
+	for _, f := range tmpFiles {
+		_ = os.Remove(f)
+	}
 	return nil
 }
@@ -336,6 +336,25 @@ func TestCanRetire(t *testing.T) {
 		require.Equal(tc.can, can, tc.inFrom, tc.inTo, i)
 	}
 }
+
+func TestCanRetireFreezeDistance(t *testing.T) {
+	require := require.New(t)
+	// default distance: nothing to freeze yet
+	_, _, can := CanRetire(90_500, 0, params.FullImmutabilityThreshold, snaptype.Unknown, nil)
+	require.False(can)
+	// aggressive freezing
+	from, to, can := CanRetire(90_500, 0, 1_000, snaptype.Unknown, nil)
+	require.True(can)
+	require.Equal(0, int(from))
+	require.Equal(10_000, int(to))
+	// sequencer's hot window bigger than chain
+	_, _, can = CanRetire(90_500, 0, 200_000, snaptype.Unknown, nil)
+	require.False(can)
+
+	require.Equal(0, int(CanDeleteTo(200_000, 0, 1_000)))
+	require.Equal(100_001, int(CanDeleteTo(200_000, 100_000, 1_000)))
+	require.Equal(50_000, int(CanDeleteTo(200_000, 100_000, 150_000)))
+}
 func TestOpenAllSnapshot(t *testing.T) {
 	logger := log.New()
 	baseDir, require := t.TempDir(), require.New(t)
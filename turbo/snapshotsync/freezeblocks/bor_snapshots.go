@@ -6,9 +6,11 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"time"
 
 	"github.com/erigontech/erigon-lib/downloader/snaptype"
 	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon-lib/seg"
 	"github.com/erigontech/erigon/cmd/hack/tool/fromdb"
 	"github.com/erigontech/erigon/eth/ethconfig"
 	borsnaptype "github.com/erigontech/erigon/polygon/bor/snaptype"
@@ -27,6 +29,7 @@ func (br *BlockRetire) retireBorBlocks(ctx context.Context, minBlockNum uint64,
 	}
 
 	snapshots := br.borSnapshots()
+	ctx = seg.WithKeyProvider(ctx, snapshots.cfg.KeyProvider)
 
 	chainConfig := fromdb.ChainConfig(br.db)
 	notifier, logger, blockReader, tmpDir, db, workers := br.notifier, br.logger, br.blockReader, br.tmpDir, br.db, br.workers
@@ -39,7 +42,7 @@ func (br *BlockRetire) retireBorBlocks(ctx context.Context, minBlockNum uint64,
 			continue
 		}
 
-		blockFrom, blockTo, ok := CanRetire(maxBlockNum, minBlockNum, snaptype.Enum(), br.chainConfig)
+		blockFrom, blockTo, ok := CanRetire(maxBlockNum, minBlockNum, blockReader.FreezingCfg().BorBlocksFreezeDistance(), snaptype.Enum(), br.chainConfig)
 		if ok {
 			blocksRetired = true
 
@@ -51,12 +54,15 @@ func (br *BlockRetire) retireBorBlocks(ctx context.Context, minBlockNum uint64,
 
 			logger.Log(lvl, "[bor snapshots] Retire Bor Blocks", "type", snaptype, "range", fmt.Sprintf("%dk-%dk", blockFrom/1000, blockTo/1000))
 
+			retireStart := time.Now()
 			for i := blockFrom; i < blockTo; i = chooseSegmentEnd(i, blockTo, snaptype.Enum(), chainConfig) {
 				end := chooseSegmentEnd(i, blockTo, snaptype.Enum(), chainConfig)
 				if _, err := snaptype.ExtractRange(ctx, snaptype.FileInfo(snapshots.Dir(), i, end), nil, db, chainConfig, tmpDir, workers, lvl, logger); err != nil {
 					return ok, fmt.Errorf("ExtractRange: %d-%d: %w", i, end, err)
 				}
 			}
+			retireBorBlocksDuration.ObserveDuration(retireStart)
+			retiredBorBlocks.AddUint64(blockTo - blockFrom)
 		}
 	}
 
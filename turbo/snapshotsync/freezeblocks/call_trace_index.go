@@ -0,0 +1,122 @@
+package freezeblocks
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/RoaringBitmap/roaring/roaring64"
+
+	"github.com/erigontech/erigon-lib/common/hexutility"
+	"github.com/erigontech/erigon-lib/common/length"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/eth/stagedsync/stages"
+)
+
+const (
+	callTraceIndexDir = "callindex"
+
+	callTraceIndexFromKey byte = 'f'
+	callTraceIndexToKey   byte = 't'
+)
+
+// callTraceIndexKind - trace_filter index: kv.CallFromIndex and kv.CallToIndex of the frozen blocks.
+// kv.CallTraceSet is pruned long before the blocks are frozen, so the files are built from the db index tables,
+// which can be pruned after that.
+var callTraceIndexKind = &bitmapIndexKind{
+	name:  "calls",
+	stage: stages.CallTraces,
+	key: func(table string, key []byte) ([]byte, error) {
+		switch table {
+		case kv.CallFromIndex:
+			return append([]byte{callTraceIndexFromKey}, key...), nil
+		case kv.CallToIndex:
+			return append([]byte{callTraceIndexToKey}, key...), nil
+		default:
+			return nil, fmt.Errorf("call trace index: unexpected table %s", table)
+		}
+	},
+	keyLen:  func(word []byte) int { return 1 + length.Addr },
+	collect: collectCallTraces,
+}
+
+func NewCallTraceIndexes(dir string, logger log.Logger) *BitmapIndexes {
+	return newBitmapIndexes(callTraceIndexKind, dir, logger)
+}
+
+// collectCallTraces - the chunks of kv.CallFromIndex and kv.CallToIndex (address ++ last block of the chunk)
+// which have blocks of the range
+func collectCallTraces(ctx context.Context, tx kv.Tx, r Range, add func(key []byte, blockNum uint64) error) error {
+	for _, table := range []string{kv.CallFromIndex, kv.CallToIndex} {
+		prefix := callTraceIndexFromKey
+		if table == kv.CallToIndex {
+			prefix = callTraceIndexToKey
+		}
+		if err := collectCallTraceTable(ctx, tx, table, prefix, r, add); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func collectCallTraceTable(ctx context.Context, tx kv.Tx, table string, prefix byte, r Range, add func(key []byte, blockNum uint64) error) error {
+	c, err := tx.Cursor(table)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	key := make([]byte, 1+length.Addr)
+	chunk := roaring64.New()
+	var reader bytes.Reader
+	for k, v, err := c.First(); ; {
+		if err != nil {
+			return err
+		}
+		if k == nil {
+			break
+		}
+		if len(k) != length.Addr+8 {
+			return fmt.Errorf("%s: unexpected key %x", table, k)
+		}
+		addr := k[:length.Addr]
+		if binary.BigEndian.Uint64(k[length.Addr:]) < r.from { // chunks before the range
+			k, v, err = c.Seek(append(append([]byte{}, addr...), hexutility.EncodeTs(r.from)...))
+			continue
+		}
+		chunk.Clear()
+		reader.Reset(v)
+		if _, err := chunk.ReadFrom(&reader); err != nil {
+			return fmt.Errorf("%s: chunk %x: %w", table, k, err)
+		}
+		key = append(append(key[:0], prefix), addr...)
+		it := chunk.Iterator()
+		it.AdvanceIfNeeded(r.from)
+		for it.HasNext() {
+			blockNum := it.Next()
+			if blockNum >= r.to {
+				break
+			}
+			if err := add(key, blockNum); err != nil {
+				return err
+			}
+		}
+		if !chunk.IsEmpty() && chunk.Maximum() >= r.to { // chunks after the range
+			next, ok := kv.NextSubtree(addr)
+			if !ok {
+				break
+			}
+			k, v, err = c.Seek(next)
+		} else {
+			k, v, err = c.Next()
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package freezeblocks
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/RoaringBitmap/roaring/roaring64"
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutility"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/memdb"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/eth/stagedsync/stages"
+)
+
+func TestCallTraceIndexes(t *testing.T) {
+	logger := log.New()
+	db := memdb.NewTestDB(t)
+	ctx := context.Background()
+
+	addr1, addr2, addr3 := libcommon.HexToAddress("0x01"), libcommon.HexToAddress("0x02"), libcommon.HexToAddress("0x03")
+	type chunk struct {
+		table    string
+		addr     libcommon.Address
+		last     uint64 // of the chunk key
+		blockNum []uint64
+	}
+	chunks := []chunk{
+		{kv.CallFromIndex, addr1, 500, []uint64{10, 500}},
+		{kv.CallFromIndex, addr1, ^uint64(0), []uint64{1_500, 2_500}}, // 2_500 is not traced yet
+		{kv.CallFromIndex, addr2, ^uint64(0), []uint64{999}},
+		{kv.CallToIndex, addr1, ^uint64(0), []uint64{1_999}},
+		{kv.CallToIndex, addr3, ^uint64(0), []uint64{3_000}},
+	}
+	require.NoError(t, db.Update(ctx, func(tx kv.RwTx) error {
+		for _, c := range chunks {
+			var buf bytes.Buffer
+			if _, err := roaring64.BitmapOf(c.blockNum...).WriteTo(&buf); err != nil {
+				return err
+			}
+			if err := tx.Put(c.table, append(c.addr.Bytes(), hexutility.EncodeTs(c.last)...), buf.Bytes()); err != nil {
+				return err
+			}
+		}
+		return stages.SaveStageProgress(tx, stages.CallTraces, 2_100)
+	}))
+
+	dir := filepath.Join(t.TempDir(), callTraceIndexDir)
+	l := NewCallTraceIndexes(dir, logger)
+	defer l.Close()
+
+	changed, err := l.Reconcile(ctx, db, []Range{{0, 1_000}, {1_000, 2_000}, {2_000, 3_000}}, t.TempDir(), log.LvlDebug)
+	require.NoError(t, err)
+	require.True(t, changed)
+
+	from, to := l.Indexed()
+	require.Equal(t, uint64(0), from)
+	require.Equal(t, uint64(2_000), to)
+
+	m, err := l.Get(kv.CallFromIndex, addr1[:], 0, 1_999)
+	require.NoError(t, err)
+	require.Equal(t, []uint32{10, 500, 1_500}, m.ToArray())
+	m, err = l.Get(kv.CallFromIndex, addr1[:], 11, 1_999)
+	require.NoError(t, err)
+	require.Equal(t, []uint32{500, 1_500}, m.ToArray())
+	m, err = l.Get(kv.CallFromIndex, addr2[:], 0, 1_999)
+	require.NoError(t, err)
+	require.Equal(t, []uint32{999}, m.ToArray())
+	m, err = l.Get(kv.CallToIndex, addr1[:], 0, 1_999)
+	require.NoError(t, err)
+	require.Equal(t, []uint32{1_999}, m.ToArray())
+	m, err = l.Get(kv.CallToIndex, addr3[:], 0, 1_999)
+	require.NoError(t, err)
+	require.True(t, m.IsEmpty())
+	_, err = l.Get(kv.LogAddressIndex, addr1[:], 0, 1_999)
+	require.Error(t, err)
+
+	// the log index of the same snapshots doesn't see the files
+	logs := NewLogIndexes(dir, logger)
+	defer logs.Close()
+	require.NoError(t, logs.OpenFolder())
+	from, to = logs.Indexed()
+	require.Equal(t, uint64(0), from)
+	require.Equal(t, uint64(0), to)
+}
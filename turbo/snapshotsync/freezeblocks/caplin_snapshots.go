@@ -15,6 +15,7 @@ import (
 	"github.com/erigontech/erigon-lib/common/datadir"
 	"github.com/erigontech/erigon-lib/log/v3"
 	"github.com/klauspost/compress/zstd"
+	"golang.org/x/sync/errgroup"
 
 	"github.com/erigontech/erigon-lib/chain/snapcfg"
 	libcommon "github.com/erigontech/erigon-lib/common"
@@ -32,6 +33,7 @@ import (
 	"github.com/erigontech/erigon/cl/persistence/format/snapshot_format"
 	"github.com/erigontech/erigon/cl/utils"
 	"github.com/erigontech/erigon/eth/ethconfig"
+	"github.com/erigontech/erigon/eth/ethconfig/estimate"
 )
 
 var sidecarSSZSize = (&cltypes.BlobSidecar{}).EncodingSizeSSZ()
@@ -156,7 +158,7 @@ Loop:
 			if !exists {
 				sn = &Segment{segType: snaptype.BeaconBlocks, version: f.Version, Range: Range{f.From, f.To}}
 			}
-			if err := sn.reopenSeg(s.dir); err != nil {
+			if err := sn.reopenSeg(s.dir, s.cfg.KeyProvider); err != nil {
 				if errors.Is(err, os.ErrNotExist) {
 					if optimistic {
 						continue Loop
@@ -205,7 +207,7 @@ Loop:
 			if !exists {
 				sn = &Segment{segType: snaptype.BlobSidecars, version: f.Version, Range: Range{f.From, f.To}}
 			}
-			if err := sn.reopenSeg(s.dir); err != nil {
+			if err := sn.reopenSeg(s.dir, s.cfg.KeyProvider); err != nil {
 				if errors.Is(err, os.ErrNotExist) {
 					if optimistic {
 						continue Loop
@@ -548,8 +550,8 @@ func (s *CaplinSnapshots) BuildMissingIndices(ctx context.Context, logger log.Lo
 	if err != nil {
 		return err
 	}
-	for index := range segments {
-		segment := segments[index]
+	var missing []snaptype.FileInfo
+	for _, segment := range segments {
 		// The same slot=>offset mapping is used for both beacon blocks and blob sidecars.
 		if segment.Type.Enum() != snaptype.CaplinEnums.BeaconBlocks && segment.Type.Enum() != snaptype.CaplinEnums.BlobSidecars {
 			continue
@@ -557,11 +559,24 @@ func (s *CaplinSnapshots) BuildMissingIndices(ctx context.Context, logger log.Lo
 		if segment.Type.HasIndexFiles(segment, logger) {
 			continue
 		}
-		p := &background.Progress{}
+		missing = append(missing, segment)
+	}
 
-		if err := BeaconSimpleIdx(ctx, segment, s.Salt, s.tmpdir, p, log.LvlDebug, logger); err != nil {
-			return err
-		}
+	progress := newIndexingProgress("caplin", logger)
+	progress.add(len(missing))
+	defer progress.run()()
+
+	g, gCtx := errgroup.WithContext(ctx)
+	g.SetLimit(estimate.IndexSnapshot.Workers())
+	for _, segment := range missing {
+		g.Go(func() error {
+			return progress.build(segment.Name(), func(p *background.Progress) error {
+				return BeaconSimpleIdx(gCtx, segment, s.Salt, s.tmpdir, p, log.LvlDebug, logger)
+			})
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return err
 	}
 
 	return s.ReopenFolder()
@@ -0,0 +1,71 @@
+package freezeblocks
+
+import (
+	"fmt"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	common2 "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/background"
+	"github.com/erigontech/erigon-lib/common/dbg"
+	"github.com/erigontech/erigon-lib/log/v3"
+)
+
+// indexingProgress - reporting of a pool of index builds: the percent of each file in flight every 20 seconds,
+// and a line per built file. Files are built to .tmp and renamed, so an interrupted run is resumed by building
+// the files which have no index yet.
+type indexingProgress struct {
+	logPrefix   string
+	ps          *background.ProgressSet
+	start       time.Time
+	logEvery    time.Duration
+	total, done atomic.Int32
+	logger      log.Logger
+}
+
+func newIndexingProgress(logPrefix string, logger log.Logger) *indexingProgress {
+	return &indexingProgress{logPrefix: logPrefix, ps: background.NewProgressSet(), start: time.Now(), logEvery: 20 * time.Second, logger: logger}
+}
+
+// run - logs the progress until stop is called
+func (p *indexingProgress) run() (stop func()) {
+	logEvery := time.NewTicker(p.logEvery)
+	finish := make(chan struct{})
+	go func() {
+		defer logEvery.Stop()
+		for {
+			select {
+			case <-logEvery.C:
+				var m runtime.MemStats
+				dbg.ReadMemStats(&m)
+				sendDiagnostics(p.start, p.ps.DiagnossticsData(), m.Alloc, m.Sys)
+				p.logger.Info(fmt.Sprintf("[%s] Indexing", p.logPrefix), "files", fmt.Sprintf("%d/%d", p.done.Load(), p.total.Load()),
+					"progress", p.ps.String(), "total-indexing-time", time.Since(p.start).Round(time.Second).String(),
+					"alloc", common2.ByteCount(m.Alloc), "sys", common2.ByteCount(m.Sys))
+			case <-finish:
+				return
+			}
+		}
+	}()
+	return func() { close(finish) }
+}
+
+// add - counts files to build
+func (p *indexingProgress) add(files int) { p.total.Add(int32(files)) }
+
+// build - builds the indexes of a file with the given function, reporting its progress
+func (p *indexingProgress) build(name string, build func(progress *background.Progress) error) error {
+	progress := &background.Progress{}
+	p.ps.Add(progress)
+	defer notifySegmentIndexingFinished(name)
+	defer p.ps.Delete(progress)
+
+	start := time.Now()
+	if err := build(progress); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	p.logger.Info(fmt.Sprintf("[%s] Indexed", p.logPrefix), "file", name, "took", time.Since(start).Round(time.Second),
+		"files", fmt.Sprintf("%d/%d", p.done.Add(1), p.total.Load()))
+	return nil
+}
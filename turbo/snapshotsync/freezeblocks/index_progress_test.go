@@ -0,0 +1,74 @@
+package freezeblocks
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/common/background"
+	"github.com/erigontech/erigon-lib/log/v3"
+)
+
+func TestIndexingProgress(t *testing.T) {
+	records := make(chan map[string]interface{}, 1024)
+	logger := log.New()
+	logger.SetHandler(log.FuncHandler(func(r *log.Record) error {
+		fields := map[string]interface{}{"msg": r.Msg}
+		for i := 0; i+1 < len(r.Ctx); i += 2 {
+			fields[r.Ctx[i].(string)] = r.Ctx[i+1]
+		}
+		select {
+		case records <- fields:
+		default:
+		}
+		return nil
+	}))
+	// until - the next record with msg and the value of key, skipping the others
+	until := func(msg, key string, value interface{}) map[string]interface{} {
+		timeout := time.After(10 * time.Second)
+		for {
+			select {
+			case r := <-records:
+				if r["msg"] == msg && r[key] == value {
+					return r
+				}
+			case <-timeout:
+				t.Fatalf("no %q with %s=%v logged", msg, key, value)
+			}
+		}
+	}
+
+	p := newIndexingProgress("test", logger)
+	p.logEvery = 10 * time.Millisecond
+	p.add(2)
+	stop := p.run()
+	defer stop()
+
+	// the percent of the file in flight is reported while it's built
+	require.NoError(t, p.build("v1-000000-000500-headers.seg", func(progress *background.Progress) error {
+		name := "v1-000000-000500-headers.seg"
+		progress.Name.Store(&name)
+		progress.Total.Store(200)
+		progress.Processed.Store(50)
+		until("[test] Indexing", "progress", "v1-000000-000500-headers.seg=25%")
+		progress.Processed.Store(150)
+		r := until("[test] Indexing", "progress", "v1-000000-000500-headers.seg=75%")
+		require.Equal(t, "0/2", r["files"])
+		return nil
+	}))
+	r := until("[test] Indexed", "file", "v1-000000-000500-headers.seg")
+	require.Equal(t, "1/2", r["files"])
+	// the built file isn't reported anymore
+	require.False(t, p.ps.Has())
+	require.Equal(t, "1/2", until("[test] Indexing", "progress", "")["files"])
+
+	// a failed build is reported by its error
+	err := p.build("v1-000000-000500-bodies.seg", func(progress *background.Progress) error {
+		return errors.New("broken")
+	})
+	require.ErrorContains(t, err, "v1-000000-000500-bodies.seg: broken")
+	require.False(t, p.ps.Has())
+	require.Equal(t, int32(1), p.done.Load())
+}
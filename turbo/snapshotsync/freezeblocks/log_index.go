@@ -0,0 +1,94 @@
+package freezeblocks
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/erigontech/erigon-lib/common/length"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/dbutils"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/eth/stagedsync/stages"
+)
+
+const (
+	logIndexDir = "logindex"
+
+	// keys of the index are prefixed by the kind, address and topic bitmaps share the files
+	logIndexAddressKey byte = 'a'
+	logIndexTopicKey   byte = 't'
+)
+
+// logIndexKind - eth_getLogs index: kv.LogAddressIndex and kv.LogTopicIndex of the frozen blocks.
+// The files are built from kv.Log of the executed blocks, so they don't depend on the db index tables.
+var logIndexKind = &bitmapIndexKind{
+	name:  "logs",
+	stage: stages.Execution,
+	key: func(table string, key []byte) ([]byte, error) {
+		switch table {
+		case kv.LogAddressIndex:
+			return append([]byte{logIndexAddressKey}, key...), nil
+		case kv.LogTopicIndex:
+			return append([]byte{logIndexTopicKey}, key...), nil
+		default:
+			return nil, fmt.Errorf("log index: unexpected table %s", table)
+		}
+	},
+	keyLen: func(word []byte) int {
+		if len(word) > 0 && word[0] == logIndexAddressKey {
+			return 1 + length.Addr
+		}
+		return 1 + length.Hash
+	},
+	collect: collectLogs,
+}
+
+func NewLogIndexes(dir string, logger log.Logger) *BitmapIndexes {
+	return newBitmapIndexes(logIndexKind, dir, logger)
+}
+
+// collectLogs - the addresses and the topics of kv.Log, the way stage LogIndex does
+func collectLogs(ctx context.Context, tx kv.Tx, r Range, add func(key []byte, blockNum uint64) error) error {
+	c, err := tx.Cursor(kv.Log)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	key := make([]byte, 1+length.Hash)
+	for k, v, err := c.Seek(dbutils.LogKey(r.from, 0)); ; k, v, err = c.Next() {
+		if err != nil {
+			return err
+		}
+		if k == nil {
+			break
+		}
+		blockNum := binary.BigEndian.Uint64(k[:8])
+		if blockNum >= r.to {
+			break
+		}
+		logs, err := types.DecodeLogsForStorage(v)
+		if err != nil {
+			return fmt.Errorf("receipt unmarshal failed: %w, block=%d", err, blockNum)
+		}
+		for _, l := range logs {
+			key = append(append(key[:0], logIndexAddressKey), l.Address[:]...)
+			if err := add(key, blockNum); err != nil {
+				return err
+			}
+			for _, topic := range l.Topics {
+				key = append(append(key[:0], logIndexTopicKey), topic[:]...)
+				if err := add(key, blockNum); err != nil {
+					return err
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package freezeblocks
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/dbutils"
+	"github.com/erigontech/erigon-lib/kv/memdb"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/eth/stagedsync/stages"
+)
+
+func TestLogIndexes(t *testing.T) {
+	logger := log.New()
+	db := memdb.NewTestDB(t)
+	ctx := context.Background()
+
+	addr1, addr2 := libcommon.HexToAddress("0x01"), libcommon.HexToAddress("0x02")
+	topic := libcommon.HexToHash("0xff")
+	logsAt := map[uint64]types.Logs{
+		10:    {{Address: addr1, Topics: []libcommon.Hash{topic}}},
+		999:   {{Address: addr2}},
+		1_500: {{Address: addr1}, {Address: addr2, Topics: []libcommon.Hash{topic}}},
+		2_500: {{Address: addr1}}, // not executed yet
+	}
+	require.NoError(t, db.Update(ctx, func(tx kv.RwTx) error {
+		for blockNum, logs := range logsAt {
+			v, err := types.EncodeLogsForStorage(logs)
+			if err != nil {
+				return err
+			}
+			if err := tx.Put(kv.Log, dbutils.LogKey(blockNum, 0), v); err != nil {
+				return err
+			}
+		}
+		return stages.SaveStageProgress(tx, stages.Execution, 2_100)
+	}))
+
+	dir := filepath.Join(t.TempDir(), logIndexDir)
+	l := NewLogIndexes(dir, logger)
+	defer l.Close()
+
+	changed, err := l.Reconcile(ctx, db, []Range{{0, 1_000}, {1_000, 2_000}, {2_000, 3_000}}, t.TempDir(), log.LvlDebug)
+	require.NoError(t, err)
+	require.True(t, changed)
+
+	from, to := l.Indexed()
+	require.Equal(t, uint64(0), from)
+	require.Equal(t, uint64(2_000), to)
+
+	m, err := l.Get(kv.LogAddressIndex, addr1[:], 0, 1_999)
+	require.NoError(t, err)
+	require.Equal(t, []uint32{10, 1_500}, m.ToArray())
+	m, err = l.Get(kv.LogTopicIndex, topic[:], 11, 1_999)
+	require.NoError(t, err)
+	require.Equal(t, []uint32{1_500}, m.ToArray())
+	m, err = l.Get(kv.LogAddressIndex, libcommon.HexToAddress("0x03").Bytes(), 0, 1_999)
+	require.NoError(t, err)
+	require.True(t, m.IsEmpty())
+
+	// reopens the same files
+	reopened := NewLogIndexes(dir, logger)
+	defer reopened.Close()
+	require.NoError(t, reopened.OpenFolder())
+	m, err = reopened.Get(kv.LogAddressIndex, addr2[:], 0, 1_999)
+	require.NoError(t, err)
+	require.Equal(t, []uint32{999, 1_500}, m.ToArray())
+
+	// segments merged: the index follows, built from the files as kv.Log may be pruned by now
+	require.NoError(t, db.Update(ctx, func(tx kv.RwTx) error { return tx.ClearBucket(kv.Log) }))
+	changed, err = l.Reconcile(ctx, db, []Range{{0, 2_000}}, t.TempDir(), log.LvlDebug)
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Len(t, l.files, 1)
+	m, err = l.Get(kv.LogAddressIndex, addr1[:], 0, 1_999)
+	require.NoError(t, err)
+	require.Equal(t, []uint32{10, 1_500}, m.ToArray())
+
+	changed, err = l.Reconcile(ctx, db, []Range{{0, 2_000}}, t.TempDir(), log.LvlDebug)
+	require.NoError(t, err)
+	require.False(t, changed)
+}
@@ -0,0 +1,421 @@
+package freezeblocks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon-lib/recsplit"
+	"github.com/erigontech/erigon-lib/seg"
+	"github.com/erigontech/erigon/eth/stagedsync/stages"
+)
+
+// rangeFiles - files of some data of the frozen block ranges, next to the block segments (e.g. the eth_getLogs index
+// or the receipts). There is a pair of files per range of block segments: v1-000000-000500-<name>.seg has a word per
+// key, which the word starts with, and .idx (recsplit) maps the key to the offset of the word.
+type rangeFiles struct {
+	name       string           // of the files: v1-000000-000500-<name>.seg
+	stage      stages.SyncStage // the source of the files has the blocks which are done by the stage
+	compressed bool             // the words of .seg are compressed
+	keyLen     func([]byte) int // the length of the key the word starts with
+
+	lock        sync.RWMutex
+	dir         string
+	files       []*rangeFile    // sorted by from
+	keyProvider seg.KeyProvider // the .seg files are encrypted if set
+	logger      log.Logger
+}
+
+type rangeFile struct {
+	Range
+	seg *seg.Decompressor
+	idx *recsplit.Index
+}
+
+func (l *rangeFiles) fileName(from, to uint64, ext string) string {
+	return fmt.Sprintf("v1-%06d-%06d-%s%s", from/1_000, to/1_000, l.name, ext)
+}
+
+func (l *rangeFiles) parseFileName(name string) (from, to uint64, ok bool) {
+	if !strings.HasSuffix(name, "-"+l.name+".seg") {
+		return 0, 0, false
+	}
+	if _, err := fmt.Sscanf(name, "v1-%06d-%06d-"+l.name+".seg", &from, &to); err != nil {
+		return 0, 0, false
+	}
+	return from * 1_000, to * 1_000, true
+}
+
+// OpenFolder opens the files of the dir, and closes the files which are not there anymore
+func (l *rangeFiles) OpenFolder() error {
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	opened := make(map[Range]*rangeFile, len(l.files))
+	for _, f := range l.files {
+		opened[f.Range] = f
+	}
+
+	var files []*rangeFile
+	for _, entry := range entries {
+		from, to, ok := l.parseFileName(entry.Name())
+		if !ok {
+			continue
+		}
+		r := Range{from, to}
+		if f, ok := opened[r]; ok {
+			files = append(files, f)
+			delete(opened, r)
+			continue
+		}
+		f, err := l.openFile(r)
+		if err != nil {
+			// the .idx is written last, so the pair can be incomplete after a crash - it's rebuilt by the next retire
+			l.logger.Debug("[snapshots] can't open index", "file", entry.Name(), "err", err)
+			continue
+		}
+		files = append(files, f)
+	}
+	for _, f := range opened {
+		f.close()
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].from < files[j].from })
+	l.files = files
+	return nil
+}
+
+func (l *rangeFiles) openFile(r Range) (*rangeFile, error) {
+	d, err := seg.NewDecompressorWithKeyProvider(filepath.Join(l.dir, l.fileName(r.from, r.to, ".seg")), l.keyProvider)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := recsplit.OpenIndex(filepath.Join(l.dir, l.fileName(r.from, r.to, ".idx")))
+	if err != nil {
+		d.Close()
+		return nil, err
+	}
+	return &rangeFile{Range: r, seg: d, idx: idx}, nil
+}
+
+func (f *rangeFile) close() {
+	f.idx.Close()
+	f.seg.Close()
+}
+
+func (l *rangeFiles) Close() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	for _, f := range l.files {
+		f.close()
+	}
+	l.files = nil
+}
+
+// Indexed - the files have blocks [from, to) without gaps
+func (l *rangeFiles) Indexed() (from, to uint64) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	if len(l.files) == 0 {
+		return 0, 0
+	}
+	from, to = l.files[0].from, l.files[0].to
+	for _, f := range l.files[1:] {
+		if f.from != to {
+			break
+		}
+		to = f.to
+	}
+	return from, to
+}
+
+// nextWord - the word of the getter
+func (l *rangeFiles) nextWord(g *seg.Getter, buf []byte) ([]byte, uint64) {
+	if l.compressed {
+		return g.Next(buf[:0])
+	}
+	return g.NextUncompressed()
+}
+
+// lookup - the word of the key in the file, nil if there is none. Must be called under the lock.
+func (l *rangeFiles) lookup(f *rangeFile, key []byte, buf []byte) []byte {
+	offset, ok := recsplit.NewIndexReader(f.idx).Lookup(key)
+	if !ok {
+		return nil
+	}
+	g := f.seg.MakeGetter()
+	g.Reset(offset)
+	if !g.HasNext() {
+		return nil
+	}
+	word, _ := l.nextWord(g, buf)
+	// recsplit maps unknown keys to arbitrary words
+	if len(word) <= len(key) || l.keyLen(word) != len(key) || !bytes.HasPrefix(word, key) {
+		return nil
+	}
+	return word
+}
+
+// buildFunc - writes the files of the range, from the db or from parts: the files which tile the range (the segments
+// were merged), nil if there are none
+type buildFunc func(r Range, parts []*rangeFile) error
+
+// reconcile makes the files follow the block segments: builds the files of the segment ranges which are done by
+// the stage and don't have them yet, from the files of the ranges merged into it if there are such, or from the db
+// if available says its source still has the range. The files of the ranges which are merged away are removed once
+// the merged range has its files. Returns true if the files changed.
+func (l *rangeFiles) reconcile(ctx context.Context, db kv.RoDB, ranges []Range, lvl log.Lvl, available func(tx kv.Tx, r Range) (bool, error), build buildFunc) (bool, error) {
+	if len(ranges) == 0 { // segments are not open yet
+		return false, nil
+	}
+	var done uint64
+	if err := db.View(ctx, func(tx kv.Tx) (err error) {
+		done, err = stages.GetStageProgress(tx, l.stage)
+		return err
+	}); err != nil {
+		return false, err
+	}
+
+	l.lock.RLock()
+	have := make([]Range, 0, len(l.files))
+	for _, f := range l.files {
+		have = append(have, f.Range)
+	}
+	l.lock.RUnlock()
+
+	var built []Range
+	for _, r := range ranges {
+		if r.to == 0 || r.to-1 > done || containsRange(have, r) {
+			continue
+		}
+		ok, err := l.buildRange(ctx, db, r, lvl, available, build)
+		if err != nil {
+			return false, fmt.Errorf("%s index %d-%d: %w", l.name, r.from, r.to, err)
+		}
+		if ok {
+			built = append(built, r)
+		}
+	}
+
+	l.lock.RLock()
+	var toRemove []Range
+	for _, f := range l.files {
+		if containsRange(ranges, f.Range) {
+			continue
+		}
+		// a part of a range which has no files yet (not done by the stage, or not available) stays
+		if outer, ok := rangeWithin(ranges, f.Range); ok && !containsRange(have, outer) && !containsRange(built, outer) {
+			continue
+		}
+		toRemove = append(toRemove, f.Range)
+	}
+	l.lock.RUnlock()
+
+	if len(built) == 0 && len(toRemove) == 0 {
+		return false, nil
+	}
+
+	// close before removal
+	l.lock.Lock()
+	files := l.files[:0]
+	for _, f := range l.files {
+		if containsRange(toRemove, f.Range) {
+			f.close()
+			continue
+		}
+		files = append(files, f)
+	}
+	l.files = files
+	l.lock.Unlock()
+
+	for _, r := range toRemove {
+		for _, ext := range []string{".seg", ".idx"} {
+			if err := os.Remove(filepath.Join(l.dir, l.fileName(r.from, r.to, ext))); err != nil && !errors.Is(err, os.ErrNotExist) {
+				l.logger.Warn("[snapshots] can't remove index", "err", err)
+			}
+		}
+	}
+	return true, l.OpenFolder()
+}
+
+// buildRange - false if the range has neither parts nor the source in the db
+func (l *rangeFiles) buildRange(ctx context.Context, db kv.RoDB, r Range, lvl log.Lvl, available func(tx kv.Tx, r Range) (bool, error), build buildFunc) (bool, error) {
+	// the parts stay open while the range is built from them
+	l.lock.RLock()
+	parts := l.tiling(r)
+	if parts != nil {
+		defer l.lock.RUnlock()
+	} else {
+		l.lock.RUnlock()
+	}
+	if parts == nil && available != nil {
+		var ok bool
+		if err := db.View(ctx, func(tx kv.Tx) (err error) {
+			ok, err = available(tx, r)
+			return err
+		}); err != nil {
+			return false, err
+		}
+		if !ok {
+			l.logger.Debug("[snapshots] can't build index, the db has no source anymore", "kind", l.name, "range", fmt.Sprintf("%dk-%dk", r.from/1000, r.to/1000))
+			return false, nil
+		}
+	}
+	if err := os.MkdirAll(l.dir, 0755); err != nil {
+		return false, err
+	}
+	l.logger.Log(lvl, "[snapshots] build index", "kind", l.name, "range", fmt.Sprintf("%dk-%dk", r.from/1000, r.to/1000), "parts", len(parts))
+	if err := build(r, parts); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// tiling - the files which cover the range without gaps, nil if there are none. Must be called under the lock.
+func (l *rangeFiles) tiling(r Range) []*rangeFile {
+	var parts []*rangeFile
+	next := r.from
+	for _, f := range l.files {
+		if f.from == next && f.to <= r.to && f.Range != r {
+			parts = append(parts, f)
+			next = f.to
+		}
+	}
+	if next != r.to {
+		return nil
+	}
+	return parts
+}
+
+func containsRange(ranges []Range, r Range) bool {
+	for _, r2 := range ranges {
+		if r2 == r {
+			return true
+		}
+	}
+	return false
+}
+
+// rangeWithin - the range of ranges which has r inside
+func rangeWithin(ranges []Range, r Range) (Range, bool) {
+	for _, r2 := range ranges {
+		if r2.from <= r.from && r.to <= r2.to {
+			return r2, true
+		}
+	}
+	return Range{}, false
+}
+
+// compressor - the compressor of the .seg of the range, written to a temporary file until finish
+func (l *rangeFiles) compressor(ctx context.Context, r Range, tmpDir string, lvl log.Lvl) (*seg.Compressor, error) {
+	segPath := filepath.Join(l.dir, l.fileName(r.from, r.to, ".seg"))
+	_ = os.Remove(filepath.Join(l.dir, l.fileName(r.from, r.to, ".idx")))
+	return seg.NewCompressor(seg.WithKeyProvider(ctx, l.keyProvider), fmt.Sprintf("[snapshots] %s index", l.name), segPath, tmpDir, seg.MinPatternScore, 1, lvl, l.logger)
+}
+
+// addWord - adds the word to the .seg the way the files of the kind keep it
+func (l *rangeFiles) addWord(comp *seg.Compressor, word []byte) error {
+	if l.compressed {
+		return comp.AddWord(word)
+	}
+	return comp.AddUncompressedWord(word)
+}
+
+// finish - writes the .seg of the compressor and then the .idx: the pair is complete only if the .idx exists
+func (l *rangeFiles) finish(ctx context.Context, comp *seg.Compressor, r Range, tmpDir string) error {
+	if comp.Count() == 0 {
+		// recsplit can't be built over no keys, a range without any key still gets a (dummy) word
+		if err := comp.AddUncompressedWord([]byte{0}); err != nil {
+			return err
+		}
+	}
+	if err := comp.Compress(); err != nil {
+		return err
+	}
+	comp.Close()
+	segPath := filepath.Join(l.dir, l.fileName(r.from, r.to, ".seg"))
+	idxPath := filepath.Join(l.dir, l.fileName(r.from, r.to, ".idx"))
+	return l.buildIdx(ctx, segPath, idxPath, tmpDir)
+}
+
+func (l *rangeFiles) buildIdx(ctx context.Context, segPath, idxPath, tmpDir string) error {
+	d, err := seg.NewDecompressorWithKeyProvider(segPath, l.keyProvider)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	rs, err := recsplit.NewRecSplit(recsplit.RecSplitArgs{
+		KeyCount:   d.Count(),
+		Enums:      false,
+		BucketSize: 2000,
+		LeafSize:   8,
+		TmpDir:     tmpDir,
+		IndexFile:  idxPath,
+	}, l.logger)
+	if err != nil {
+		return err
+	}
+	defer rs.Close()
+	rs.LogLvl(log.LvlDebug)
+
+	var word []byte
+	for {
+		g := d.MakeGetter()
+		var offset uint64
+		for g.HasNext() {
+			var nextPos uint64
+			word, nextPos = l.nextWord(g, word)
+			if err := rs.AddKey(word[:min(l.keyLen(word), len(word))], offset); err != nil {
+				return err
+			}
+			offset = nextPos
+		}
+
+		if err = rs.Build(ctx); err != nil {
+			if errors.Is(err, recsplit.ErrCollision) {
+				l.logger.Info("Building recsplit. Collision happened. It's ok. Restarting with another salt...", "err", err)
+				rs.ResetNextSalt()
+				continue
+			}
+			return err
+		}
+		return nil
+	}
+}
+
+// words - calls f for each word of the files, in order. Must be called under the lock.
+func (l *rangeFiles) words(ctx context.Context, files []*rangeFile, f func(word []byte) error) error {
+	var word []byte
+	for _, file := range files {
+		g := file.seg.MakeGetter()
+		for g.HasNext() {
+			word, _ = l.nextWord(g, word)
+			if err := f(word); err != nil {
+				return err
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			default:
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+package freezeblocks
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/erigontech/erigon-lib/common/hexutility"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/dbutils"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/core/rawdb"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/eth/stagedsync/stages"
+)
+
+const receiptsDir = "receipts"
+
+// ReceiptSegments - receipts of the frozen block ranges, built by `erigon snapshots retire` from kv.Receipts and
+// kv.Log, which are pruned after that. There is a pair of files per range of block segments: .seg has a word per
+// block: block number ++ types.EncodeBlockReceiptsForSnapshot, and .idx (recsplit) maps the block number to the
+// offset of the word.
+type ReceiptSegments struct {
+	rangeFiles
+}
+
+func NewReceiptSegments(dir string, logger log.Logger) *ReceiptSegments {
+	return &ReceiptSegments{rangeFiles{
+		name:       "receipts",
+		stage:      stages.Execution,
+		compressed: true,
+		keyLen:     func([]byte) int { return 8 },
+		dir:        dir,
+		logger:     logger,
+	}}
+}
+
+// RawReceipts - receipts of the block with the logs, like rawdb.ReadRawReceipts. False if the files don't have the block.
+func (s *ReceiptSegments) RawReceipts(blockNum uint64) (types.Receipts, bool, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	for _, f := range s.files {
+		if blockNum < f.from || blockNum >= f.to {
+			continue
+		}
+		word := s.lookup(f, hexutility.EncodeTs(blockNum), nil)
+		if word == nil {
+			return nil, false, nil
+		}
+		receipts, err := types.DecodeBlockReceiptsForSnapshot(word[8:])
+		if err != nil {
+			return nil, false, fmt.Errorf("receipts %s: block %d: %w", s.fileName(f.from, f.to, ".seg"), blockNum, err)
+		}
+		return receipts, true, nil
+	}
+	return nil, false, nil
+}
+
+// Reconcile makes the files follow the block segments, see BitmapIndexes.Reconcile. A range is built from the db only
+// if kv.Receipts still has all its blocks.
+func (s *ReceiptSegments) Reconcile(ctx context.Context, db kv.RoDB, ranges []Range, tmpDir string, lvl log.Lvl) (bool, error) {
+	available := func(tx kv.Tx, r Range) (bool, error) {
+		from, err := rawdb.ReceiptsAvailableFrom(tx)
+		return from <= r.from, err
+	}
+	return s.reconcile(ctx, db, ranges, lvl, available, func(r Range, parts []*rangeFile) error {
+		comp, err := s.compressor(ctx, r, tmpDir, lvl)
+		if err != nil {
+			return err
+		}
+		defer comp.Close()
+		if parts != nil {
+			err = s.words(ctx, parts, func(word []byte) error { return s.addWord(comp, word) })
+		} else {
+			err = db.View(ctx, func(tx kv.Tx) error {
+				return collectReceipts(ctx, tx, r, func(blockNum uint64, receipts types.Receipts) error {
+					v, err := types.EncodeBlockReceiptsForSnapshot(receipts)
+					if err != nil {
+						return fmt.Errorf("block %d: %w", blockNum, err)
+					}
+					return s.addWord(comp, append(hexutility.EncodeTs(blockNum), v...))
+				})
+			})
+		}
+		if err != nil {
+			return err
+		}
+		return s.finish(ctx, comp, r, tmpDir)
+	})
+}
+
+// collectReceipts - receipts of blocks [r.from, r.to) with their logs, the way rawdb.ReadRawReceipts reads them
+func collectReceipts(ctx context.Context, tx kv.Tx, r Range, add func(blockNum uint64, receipts types.Receipts) error) error {
+	c, err := tx.Cursor(kv.Receipts)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	logs, err := tx.Cursor(kv.Log)
+	if err != nil {
+		return err
+	}
+	defer logs.Close()
+
+	lk, lv, err := logs.Seek(dbutils.LogKey(r.from, 0))
+	if err != nil {
+		return err
+	}
+	next := r.from
+	for k, v, err := c.Seek(hexutility.EncodeTs(r.from)); k != nil; k, v, err = c.Next() {
+		if err != nil {
+			return err
+		}
+		blockNum := binary.BigEndian.Uint64(k)
+		if blockNum >= r.to {
+			break
+		}
+		if blockNum != next {
+			return fmt.Errorf("no receipts of block %d", next)
+		}
+		receipts, err := types.DecodeReceiptsForStorage(v)
+		if err != nil {
+			return fmt.Errorf("receipt unmarshal failed: %w, block=%d", err, blockNum)
+		}
+		for ; lk != nil && binary.BigEndian.Uint64(lk[:8]) <= blockNum; lk, lv, err = logs.Next() {
+			if err != nil {
+				return err
+			}
+			txIndex := int(binary.BigEndian.Uint32(lk[8:]))
+			// only logs of real txs (not of block's stateSyncReceipt)
+			if binary.BigEndian.Uint64(lk[:8]) < blockNum || txIndex >= len(receipts) {
+				continue
+			}
+			if receipts[txIndex].Logs, err = types.DecodeLogsForStorage(lv); err != nil {
+				return fmt.Errorf("receipt unmarshal failed: %w, block=%d", err, blockNum)
+			}
+		}
+		if err != nil {
+			return err
+		}
+		if err := add(blockNum, receipts); err != nil {
+			return err
+		}
+		next++
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+	}
+	if next != r.to {
+		return fmt.Errorf("no receipts of block %d", next)
+	}
+	return nil
+}
+
+// PruneFrozenReceipts - deletes up to limit blocks of kv.Receipts and kv.Log which the frozen receipts have. Returns
+// the number of the deleted blocks.
+func (br *BlockRetire) PruneFrozenReceipts(tx kv.RwTx, limit int) (int, error) {
+	receipts := br.snapshots().receipts
+	if receipts == nil {
+		return 0, nil
+	}
+	from, to := receipts.Indexed()
+	if from == to {
+		return 0, nil
+	}
+	c, err := tx.RwCursor(kv.Receipts)
+	if err != nil {
+		return 0, err
+	}
+	defer c.Close()
+	logs, err := tx.RwCursor(kv.Log)
+	if err != nil {
+		return 0, err
+	}
+	defer logs.Close()
+
+	var deleted int
+	for k, _, err := c.Seek(hexutility.EncodeTs(from)); k != nil && deleted < limit; k, _, err = c.Next() {
+		if err != nil {
+			return deleted, err
+		}
+		blockNum := binary.BigEndian.Uint64(k)
+		if blockNum >= to {
+			break
+		}
+		for lk, _, err := logs.Seek(dbutils.LogKey(blockNum, 0)); lk != nil; lk, _, err = logs.Next() {
+			if err != nil {
+				return deleted, err
+			}
+			if binary.BigEndian.Uint64(lk[:8]) != blockNum {
+				break
+			}
+			if err := logs.DeleteCurrent(); err != nil {
+				return deleted, err
+			}
+		}
+		if err := c.DeleteCurrent(); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// PruneFrozenCallTraceIndex - deletes the chunks of kv.CallFromIndex and kv.CallToIndex which only have blocks the
+// frozen trace_filter index has
+func (br *BlockRetire) PruneFrozenCallTraceIndex(ctx context.Context, tx kv.RwTx) error {
+	indexes := br.snapshots().callTraceIndexes
+	if indexes == nil {
+		return nil
+	}
+	// trace_filter reads the files only for the queries which start in them
+	from, to := indexes.Indexed()
+	if from != 0 || to == 0 {
+		return nil
+	}
+	for _, table := range []string{kv.CallFromIndex, kv.CallToIndex} {
+		c, err := tx.RwCursor(table)
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+		for k, _, err := c.First(); k != nil; k, _, err = c.Next() {
+			if err != nil {
+				return err
+			}
+			// chunks of an address are keyed by the last block of the chunk
+			if binary.BigEndian.Uint64(k[len(k)-8:]) >= to {
+				continue
+			}
+			if err := c.DeleteCurrent(); err != nil {
+				return err
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			default:
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package freezeblocks
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/memdb"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/core/rawdb"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/eth/stagedsync/stages"
+)
+
+func TestReceiptSegments(t *testing.T) {
+	logger := log.New()
+	db := memdb.NewTestDB(t)
+	ctx := context.Background()
+
+	addr := libcommon.HexToAddress("0x01")
+	depositNonce := uint64(7)
+	receiptsAt := func(blockNum uint64) types.Receipts {
+		if blockNum%2 == 0 {
+			return types.Receipts{} // blocks without txs
+		}
+		return types.Receipts{
+			{Type: types.DepositTxType, Status: types.ReceiptStatusSuccessful, CumulativeGasUsed: 1, DepositNonce: &depositNonce},
+			{Type: types.DynamicFeeTxType, CumulativeGasUsed: blockNum, Logs: types.Logs{{Address: addr, Data: []byte{byte(blockNum)}}}},
+		}
+	}
+	require.NoError(t, db.Update(ctx, func(tx kv.RwTx) error {
+		for blockNum := uint64(0); blockNum < 2_500; blockNum++ {
+			if err := rawdb.WriteReceipts(tx, blockNum, receiptsAt(blockNum)); err != nil {
+				return err
+			}
+		}
+		return stages.SaveStageProgress(tx, stages.Execution, 2_100)
+	}))
+
+	dir := filepath.Join(t.TempDir(), receiptsDir)
+	s := NewReceiptSegments(dir, logger)
+	defer s.Close()
+
+	changed, err := s.Reconcile(ctx, db, []Range{{0, 1_000}, {1_000, 2_000}, {2_000, 3_000}}, t.TempDir(), log.LvlDebug)
+	require.NoError(t, err)
+	require.True(t, changed)
+	from, to := s.Indexed()
+	require.Equal(t, uint64(0), from)
+	require.Equal(t, uint64(2_000), to)
+
+	requireReceipts := func(s *ReceiptSegments, blockNum uint64) {
+		t.Helper()
+		receipts, ok, err := s.RawReceipts(blockNum)
+		require.NoError(t, err)
+		require.True(t, ok)
+		want := receiptsAt(blockNum)
+		require.Len(t, receipts, len(want))
+		for i := range want {
+			require.Equal(t, want[i].Type, receipts[i].Type)
+			require.Equal(t, want[i].Status, receipts[i].Status)
+			require.Equal(t, want[i].CumulativeGasUsed, receipts[i].CumulativeGasUsed)
+			require.Equal(t, want[i].DepositNonce, receipts[i].DepositNonce)
+			require.Len(t, receipts[i].Logs, len(want[i].Logs))
+			for j := range want[i].Logs {
+				require.Equal(t, want[i].Logs[j].Address, receipts[i].Logs[j].Address)
+				require.Equal(t, want[i].Logs[j].Data, receipts[i].Logs[j].Data)
+			}
+		}
+	}
+	requireReceipts(s, 0)
+	requireReceipts(s, 999)
+	requireReceipts(s, 1_501)
+	_, ok, err := s.RawReceipts(2_001)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// reopens the same files
+	reopened := NewReceiptSegments(dir, logger)
+	defer reopened.Close()
+	require.NoError(t, reopened.OpenFolder())
+	requireReceipts(reopened, 1_001)
+
+	// receipts of the frozen blocks are pruned
+	require.NoError(t, db.Update(ctx, func(tx kv.RwTx) error {
+		for _, table := range []string{kv.Receipts, kv.Log} {
+			if err := tx.ClearBucket(table); err != nil {
+				return err
+			}
+		}
+		return stages.SaveStageProgress(tx, stages.Execution, 3_100)
+	}))
+
+	// segments merged: the files follow, built from the files of the merged ranges. The next range can't be
+	// built without the receipts in the db.
+	changed, err = s.Reconcile(ctx, db, []Range{{0, 2_000}, {2_000, 3_000}}, t.TempDir(), log.LvlDebug)
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Len(t, s.files, 1)
+	requireReceipts(s, 1_999)
+	from, to = s.Indexed()
+	require.Equal(t, uint64(0), from)
+	require.Equal(t, uint64(2_000), to)
+
+	changed, err = s.Reconcile(ctx, db, []Range{{0, 2_000}, {2_000, 3_000}}, t.TempDir(), log.LvlDebug)
+	require.NoError(t, err)
+	require.False(t, changed)
+}
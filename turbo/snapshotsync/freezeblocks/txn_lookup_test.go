@@ -0,0 +1,60 @@
+package freezeblocks_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/memdb"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/eth/ethconfig"
+	"github.com/erigontech/erigon/params"
+	"github.com/erigontech/erigon/turbo/snapshotsync/freezeblocks"
+)
+
+func TestTxnLookupWithIndex(t *testing.T) {
+	logger := log.New()
+	m := createDumpTestKV(t, params.TestChainConfig, 1_000)
+	tmpDir, snapDir := t.TempDir(), t.TempDir()
+	require.NoError(t, freezeblocks.DumpBlocks(m.Ctx, 0, 1_000, m.ChainConfig, tmpDir, snapDir, m.DB, 1, log.LvlInfo, logger, m.BlockReader))
+
+	snaps := freezeblocks.NewRoSnapshots(ethconfig.BlocksFreezing{Enabled: true}, snapDir, 0, logger)
+	defer snaps.Close()
+	require.NoError(t, snaps.ReopenFolder())
+	borSnaps := freezeblocks.NewBorRoSnapshots(ethconfig.BlocksFreezing{Enabled: false}, t.TempDir(), 0, logger)
+	defer borSnaps.Close()
+	br := freezeblocks.NewBlockReader(snaps, borSnaps)
+
+	var block *types.Block
+	require.NoError(t, m.DB.View(m.Ctx, func(tx kv.Tx) (err error) {
+		block, err = m.BlockReader.BlockByNumber(m.Ctx, tx, 500)
+		return err
+	}))
+	require.Len(t, block.Transactions(), 1)
+	txnHash := block.Transactions()[0].Hash()
+
+	// frozen: resolved by the indices of the segments, without TxLookup and the block
+	_, empty := memdb.NewTestTx(t)
+	blockNum, txnIndex, ok, err := br.TxnLookupWithIndex(m.Ctx, empty, txnHash)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, uint64(500), blockNum)
+	require.Equal(t, 0, txnIndex)
+
+	_, _, ok, err = br.TxnLookupWithIndex(m.Ctx, empty, libcommon.Hash{1})
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// TxLookup of the db goes first
+	require.NoError(t, m.DB.View(m.Ctx, func(tx kv.Tx) error {
+		blockNum, txnIndex, ok, err = br.TxnLookupWithIndex(m.Ctx, tx, txnHash)
+		return err
+	}))
+	require.True(t, ok)
+	require.Equal(t, uint64(500), blockNum)
+	require.Equal(t, 0, txnIndex)
+}
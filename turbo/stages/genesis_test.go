@@ -167,6 +167,7 @@ func TestSetupGenesis(t *testing.T) {
 				StoredConfig: big.NewInt(2),
 				NewConfig:    big.NewInt(3),
 				RewindTo:     1,
+				Diff:         []chain.ConfigChange{{Field: "homesteadBlock", Old: float64(2), New: float64(3)}},
 			},
 		},
 	}
@@ -24,6 +24,7 @@ import (
 
 	"github.com/erigontech/erigon/consensus"
 	"github.com/erigontech/erigon/consensus/misc"
+	"github.com/erigontech/erigon/core"
 	"github.com/erigontech/erigon/core/rawdb"
 	"github.com/erigontech/erigon/core/rawdb/blockio"
 	"github.com/erigontech/erigon/core/types"
@@ -33,8 +34,10 @@ import (
 	"github.com/erigontech/erigon/eth/stagedsync/stages"
 	"github.com/erigontech/erigon/p2p"
 	"github.com/erigontech/erigon/p2p/sentry/sentry_multi_client"
+	"github.com/erigontech/erigon/params"
 	"github.com/erigontech/erigon/polygon/bor"
 	"github.com/erigontech/erigon/polygon/bor/finality/flags"
+	"github.com/erigontech/erigon/turbo/builder"
 	"github.com/erigontech/erigon/turbo/engineapi/engine_helpers"
 	"github.com/erigontech/erigon/turbo/services"
 	"github.com/erigontech/erigon/turbo/shards"
@@ -67,8 +70,12 @@ func StageLoop(ctx context.Context,
 			// continue
 		}
 
+		if err := sync.SyncPause().Begin(ctx); err != nil {
+			return
+		}
 		// Estimate the current top height seen from the peer
 		err := StageLoopIteration(ctx, db, wrap.TxContainer{}, sync, initialCycle, logger, blockReader, hook, forcePartialCommit)
+		sync.SyncPause().End()
 
 		if err != nil {
 			if errors.Is(err, libcommon.ErrStopped) || errors.Is(err, context.Canceled) {
@@ -107,6 +114,9 @@ func StageLoopIteration(ctx context.Context, db kv.RwDB, txc wrap.TxContainer, s
 	}() // avoid crash because Erigon's core does many things
 
 	externalTx := txc.Tx != nil
+	if sync.DryRun() && !externalTx {
+		return stageLoopDryRun(ctx, db, sync, initialCycle, logger)
+	}
 	finishProgressBefore, borProgressBefore, headersProgressBefore, err := stagesHeadersAndFinish(db, txc.Tx)
 	if err != nil {
 		return err
@@ -194,6 +204,30 @@ func StageLoopIteration(ctx context.Context, db kv.RwDB, txc wrap.TxContainer, s
 	return nil
 }
 
+// stageLoopDryRun - StageLoopIteration of --sync.dry-run. The cycle runs in one read-only tx, on a memory overlay,
+// and what it would change is logged instead of committed. Nothing committed means no notifications and no pruning,
+// and the next cycle starts from the same progress again.
+func stageLoopDryRun(ctx context.Context, db kv.RwDB, sync *stagedsync.Sync, initialCycle bool, logger log.Logger) error {
+	tx, err := db.BeginRo(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	dryRun, err := sync.BeginDryRun(tx)
+	if err != nil {
+		return err
+	}
+	defer dryRun.Rollback()
+
+	if _, err = sync.Run(db, wrap.TxContainer{Tx: dryRun.Tx()}, initialCycle); err != nil {
+		return err
+	}
+	if logCtx := sync.PrintTimings(); len(logCtx) > 0 {
+		logger.Info("Timings (slower than 50ms)", logCtx...)
+	}
+	return dryRun.Report()
+}
+
 func stagesHeadersAndFinish(db kv.RoDB, tx kv.Tx) (head, bor, fin uint64, err error) {
 	if tx != nil {
 		if fin, err = stages.GetStageProgress(tx, stages.Finish); err != nil {
@@ -347,6 +381,64 @@ func MiningStep(ctx context.Context, kv kv.RwDB, mining *stagedsync.Sync, tmpDir
 	return nil
 }
 
+// BlockBuilderPOSCfg - what proof-of-stake block building needs, see NewBlockBuilderPOS
+type BlockBuilderPOSCfg struct {
+	DB               kv.RwDB
+	ChainConfig      *chain.Config
+	Engine           consensus.Engine
+	BlockReader      services.FullBlockReader
+	ChainReader      *stagedsync.SharedChainReader
+	Miner            params.MiningConfig
+	Sync             ethconfig.Sync
+	Dirs             datadir.Dirs
+	HistoryV3        bool
+	Agg              *state.Aggregator
+	TxPool           stagedsync.TxPoolForMining
+	TxPoolDB         kv.RoDB
+	Notifier         stagedsync.ChainEventNotifier
+	SealingQuit      chan struct{}
+	LatestBlockBuilt *builder.LatestBlockBuiltStore
+	Heimdall         heimdall.HeimdallClient
+}
+
+// NewBlockBuilderPOS - builds payloads of engine_forkchoiceUpdated by a cycle of the mining stages on a memory batch,
+// which is never committed. Used by the node, by its mock and by builder processes (cmd/builder).
+func NewBlockBuilderPOS(ctx context.Context, cfg BlockBuilderPOSCfg, logger log.Logger) builder.BlockBuilderFunc {
+	var (
+		snapDb     kv.RwDB
+		recents    *lru.ARCCache[libcommon.Hash, *bor.Snapshot]
+		signatures *lru.ARCCache[libcommon.Hash, libcommon.Address]
+	)
+	if bor, ok := cfg.Engine.(*bor.Bor); ok {
+		snapDb = bor.DB
+		recents = bor.Recents
+		signatures = bor.Signatures
+	}
+	return func(param *core.BlockBuilderParameters, interrupt *int32) (*types.BlockWithReceipts, error) {
+		// payloads are built concurrently, each one gets its own copy of the config
+		miningConfig := cfg.Miner
+		miningConfig.Etherbase = param.SuggestedFeeRecipient
+		miningStatePos := stagedsync.NewMiningState(&miningConfig)
+		proposingSync := stagedsync.New(
+			cfg.Sync,
+			stagedsync.MiningStages(ctx,
+				stagedsync.StageMiningCreateBlockCfg(cfg.DB, miningStatePos, *cfg.ChainConfig, cfg.Engine, cfg.TxPoolDB, param, cfg.Dirs.Tmp, cfg.BlockReader, cfg.ChainReader),
+				stagedsync.StageBorHeimdallCfg(cfg.DB, snapDb, miningStatePos, *cfg.ChainConfig, cfg.Heimdall, cfg.BlockReader, nil, nil, nil, recents, signatures, false, nil),
+				stagedsync.StageMiningExecCfg(cfg.DB, miningStatePos, cfg.Notifier, *cfg.ChainConfig, cfg.Engine, &vm.Config{}, cfg.Dirs.Tmp, interrupt, param.PayloadId, cfg.TxPool, cfg.TxPoolDB, cfg.BlockReader, cfg.ChainReader),
+				stagedsync.StageHashStateCfg(cfg.DB, cfg.Dirs, cfg.HistoryV3),
+				stagedsync.StageTrieCfg(cfg.DB, false, true, true, cfg.Dirs.Tmp, cfg.BlockReader, nil, cfg.HistoryV3, cfg.Agg),
+				stagedsync.StageMiningFinishCfg(cfg.DB, *cfg.ChainConfig, cfg.Engine, miningStatePos, cfg.SealingQuit, cfg.BlockReader, cfg.ChainReader, cfg.LatestBlockBuilt),
+			), stagedsync.MiningUnwindOrder, stagedsync.MiningPruneOrder,
+			logger)
+		logger.Debug("Starting assembleBlockPOS mining step", "payloadId", param.PayloadId)
+		if err := MiningStep(ctx, cfg.DB, proposingSync, cfg.Dirs.Tmp, logger); err != nil {
+			return nil, err
+		}
+		logger.Debug("Finished assembleBlockPOS mining step", "payloadId", param.PayloadId)
+		return <-miningStatePos.MiningResultCh, nil
+	}
+}
+
 func addAndVerifyBlockStep(batch kv.RwTx, engine consensus.Engine, chainReader consensus.ChainReader, currentHeader *types.Header, currentBody *types.RawBody, histV3 bool) error {
 	currentHeight := currentHeader.Number.Uint64()
 	currentHash := currentHeader.Hash()
@@ -454,6 +546,7 @@ func NewDefaultStages(ctx context.Context,
 	notifications *shards.Notifications,
 	snapDownloader proto_downloader.DownloaderClient,
 	blockReader services.FullBlockReader,
+	chainReader *stagedsync.SharedChainReader,
 	blockRetire services.BlockRetire,
 	agg *state.Aggregator,
 	silkworm *silkworm.Silkworm,
@@ -500,11 +593,12 @@ func NewDefaultStages(ctx context.Context,
 
 	return stagedsync.DefaultStages(ctx,
 		stagedsync.StageSnapshotsCfg(db, *controlServer.ChainConfig, cfg.Sync, dirs, blockRetire, snapDownloader, blockReader, notifications, cfg.HistoryV3, agg, cfg.InternalCL && cfg.CaplinConfig.Backfilling, cfg.CaplinConfig.BlobBackfilling, silkworm),
-		stagedsync.StageHeadersCfg(db, controlServer.Hd, controlServer.Bd, *controlServer.ChainConfig, cfg.Sync, controlServer.SendHeaderRequest, controlServer.PropagateNewBlockHashes, controlServer.Penalize, cfg.BatchSize, p2pCfg.NoDiscovery, blockReader, blockWriter, dirs.Tmp, notifications, loopBreakCheck),
+		stagedsync.StageHeadersCfg(db, controlServer.Hd, controlServer.Bd, *controlServer.ChainConfig, cfg.Sync, controlServer.SendHeaderRequest, controlServer.PropagateNewBlockHashes, controlServer.Penalize, cfg.BatchSize, p2pCfg.NoDiscovery, blockReader, chainReader, blockWriter, dirs.Tmp, notifications, loopBreakCheck),
 		stagedsync.StageBorHeimdallCfg(db, snapDb, stagedsync.MiningState{}, *controlServer.ChainConfig, heimdallClient, blockReader, controlServer.Hd, controlServer.Penalize, loopBreakCheck, recents, signatures, cfg.WithHeimdallWaypointRecording, nil),
 		stagedsync.StageBlockHashesCfg(db, dirs.Tmp, controlServer.ChainConfig, blockWriter),
 		stagedsync.StageBodiesCfg(db, controlServer.Bd, controlServer.SendBodyRequest, controlServer.Penalize, controlServer.BroadcastNewBlock, cfg.Sync.BodyDownloadTimeoutSeconds, *controlServer.ChainConfig, blockReader, cfg.HistoryV3, blockWriter, loopBreakCheck),
 		stagedsync.StageSendersCfg(db, controlServer.ChainConfig, false, dirs.Tmp, cfg.Prune, blockReader, controlServer.Hd, loopBreakCheck),
+		stagedsync.StageSendersBackfillCfg(db, controlServer.ChainConfig, cfg.Sync, blockReader),
 		stagedsync.StageExecuteBlocksCfg(
 			db,
 			cfg.Prune,
@@ -519,6 +613,7 @@ func NewDefaultStages(ctx context.Context,
 			cfg.HistoryV3,
 			dirs,
 			blockReader,
+			chainReader,
 			controlServer.Hd,
 			cfg.Genesis,
 			cfg.Sync,
@@ -526,7 +621,8 @@ func NewDefaultStages(ctx context.Context,
 			silkwormForExecutionStage(silkworm, cfg),
 		),
 		stagedsync.StageHashStateCfg(db, dirs, cfg.HistoryV3),
-		stagedsync.StageTrieCfg(db, true, true, false, dirs.Tmp, blockReader, controlServer.Hd, cfg.HistoryV3, agg),
+		stagedsync.StageTrieCfg(db, true, true, cfg.Sync.BadBlockHalt, dirs.Tmp, blockReader, controlServer.Hd, cfg.HistoryV3, agg).
+			WithBadBlockDump(stagedsync.NewBadBlockDump(cfg.Sync, dirs, controlServer.ChainConfig, controlServer.Engine, blockReader)),
 		stagedsync.StageHistoryCfg(db, cfg.Prune, dirs.Tmp),
 		stagedsync.StageLogIndexCfg(db, cfg.Prune, dirs.Tmp, &depositContract),
 		stagedsync.StageCallTracesCfg(db, cfg.Prune, 0, dirs.Tmp),
@@ -543,6 +639,7 @@ func NewPipelineStages(ctx context.Context,
 	notifications *shards.Notifications,
 	snapDownloader proto_downloader.DownloaderClient,
 	blockReader services.FullBlockReader,
+	chainReader *stagedsync.SharedChainReader,
 	blockRetire services.BlockRetire,
 	agg *state.Aggregator,
 	silkworm *silkworm.Silkworm,
@@ -584,6 +681,7 @@ func NewPipelineStages(ctx context.Context,
 			stagedsync.StageSnapshotsCfg(db, *controlServer.ChainConfig, cfg.Sync, dirs, blockRetire, snapDownloader, blockReader, notifications, cfg.HistoryV3, agg, cfg.InternalCL && cfg.CaplinConfig.Backfilling, cfg.CaplinConfig.BlobBackfilling, silkworm),
 			stagedsync.StageBlockHashesCfg(db, dirs.Tmp, controlServer.ChainConfig, blockWriter),
 			stagedsync.StageSendersCfg(db, controlServer.ChainConfig, false, dirs.Tmp, cfg.Prune, blockReader, controlServer.Hd, loopBreakCheck),
+			stagedsync.StageSendersBackfillCfg(db, controlServer.ChainConfig, cfg.Sync, blockReader),
 			stagedsync.StageExecuteBlocksCfg(
 				db,
 				cfg.Prune,
@@ -598,6 +696,7 @@ func NewPipelineStages(ctx context.Context,
 				cfg.HistoryV3,
 				dirs,
 				blockReader,
+				chainReader,
 				controlServer.Hd,
 				cfg.Genesis,
 				cfg.Sync,
@@ -605,7 +704,8 @@ func NewPipelineStages(ctx context.Context,
 				silkwormForExecutionStage(silkworm, cfg),
 			),
 			stagedsync.StageHashStateCfg(db, dirs, cfg.HistoryV3),
-			stagedsync.StageTrieCfg(db, checkStateRoot, true, false, dirs.Tmp, blockReader, controlServer.Hd, cfg.HistoryV3, agg),
+			stagedsync.StageTrieCfg(db, checkStateRoot, true, cfg.Sync.BadBlockHalt, dirs.Tmp, blockReader, controlServer.Hd, cfg.HistoryV3, agg).
+				WithBadBlockDump(stagedsync.NewBadBlockDump(cfg.Sync, dirs, controlServer.ChainConfig, controlServer.Engine, blockReader)),
 			stagedsync.StageHistoryCfg(db, cfg.Prune, dirs.Tmp),
 			stagedsync.StageLogIndexCfg(db, cfg.Prune, dirs.Tmp, &depositContract),
 			stagedsync.StageCallTracesCfg(db, cfg.Prune, 0, dirs.Tmp),
@@ -616,7 +716,7 @@ func NewPipelineStages(ctx context.Context,
 
 	return stagedsync.UploaderPipelineStages(ctx,
 		stagedsync.StageSnapshotsCfg(db, *controlServer.ChainConfig, cfg.Sync, dirs, blockRetire, snapDownloader, blockReader, notifications, cfg.HistoryV3, agg, cfg.InternalCL && cfg.CaplinConfig.Backfilling, cfg.CaplinConfig.BlobBackfilling, silkworm),
-		stagedsync.StageHeadersCfg(db, controlServer.Hd, controlServer.Bd, *controlServer.ChainConfig, cfg.Sync, controlServer.SendHeaderRequest, controlServer.PropagateNewBlockHashes, controlServer.Penalize, cfg.BatchSize, p2pCfg.NoDiscovery, blockReader, blockWriter, dirs.Tmp, notifications, loopBreakCheck),
+		stagedsync.StageHeadersCfg(db, controlServer.Hd, controlServer.Bd, *controlServer.ChainConfig, cfg.Sync, controlServer.SendHeaderRequest, controlServer.PropagateNewBlockHashes, controlServer.Penalize, cfg.BatchSize, p2pCfg.NoDiscovery, blockReader, chainReader, blockWriter, dirs.Tmp, notifications, loopBreakCheck),
 		stagedsync.StageBlockHashesCfg(db, dirs.Tmp, controlServer.ChainConfig, blockWriter),
 		stagedsync.StageSendersCfg(db, controlServer.ChainConfig, false, dirs.Tmp, cfg.Prune, blockReader, controlServer.Hd, loopBreakCheck),
 		stagedsync.StageBodiesCfg(db, controlServer.Bd, controlServer.SendBodyRequest, controlServer.Penalize, controlServer.BroadcastNewBlock, cfg.Sync.BodyDownloadTimeoutSeconds, *controlServer.ChainConfig, blockReader, cfg.HistoryV3, blockWriter, loopBreakCheck),
@@ -634,6 +734,7 @@ func NewPipelineStages(ctx context.Context,
 			cfg.HistoryV3,
 			dirs,
 			blockReader,
+			chainReader,
 			controlServer.Hd,
 			cfg.Genesis,
 			cfg.Sync,
@@ -641,7 +742,8 @@ func NewPipelineStages(ctx context.Context,
 			silkwormForExecutionStage(silkworm, cfg),
 		),
 		stagedsync.StageHashStateCfg(db, dirs, cfg.HistoryV3),
-		stagedsync.StageTrieCfg(db, checkStateRoot, true, false, dirs.Tmp, blockReader, controlServer.Hd, cfg.HistoryV3, agg),
+		stagedsync.StageTrieCfg(db, checkStateRoot, true, cfg.Sync.BadBlockHalt, dirs.Tmp, blockReader, controlServer.Hd, cfg.HistoryV3, agg).
+			WithBadBlockDump(stagedsync.NewBadBlockDump(cfg.Sync, dirs, controlServer.ChainConfig, controlServer.Engine, blockReader)),
 		stagedsync.StageHistoryCfg(db, cfg.Prune, dirs.Tmp),
 		stagedsync.StageLogIndexCfg(db, cfg.Prune, dirs.Tmp, &depositContract),
 		stagedsync.StageCallTracesCfg(db, cfg.Prune, 0, dirs.Tmp),
@@ -652,12 +754,12 @@ func NewPipelineStages(ctx context.Context,
 }
 
 func NewInMemoryExecution(ctx context.Context, db kv.RwDB, cfg *ethconfig.Config, controlServer *sentry_multi_client.MultiClient,
-	dirs datadir.Dirs, notifications *shards.Notifications, blockReader services.FullBlockReader, blockWriter *blockio.BlockWriter, agg *state.Aggregator,
+	dirs datadir.Dirs, notifications *shards.Notifications, blockReader services.FullBlockReader, chainReader *stagedsync.SharedChainReader, blockWriter *blockio.BlockWriter, agg *state.Aggregator,
 	silkworm *silkworm.Silkworm, logger log.Logger) *stagedsync.Sync {
 	return stagedsync.New(
 		cfg.Sync,
 		stagedsync.StateStages(ctx,
-			stagedsync.StageHeadersCfg(db, controlServer.Hd, controlServer.Bd, *controlServer.ChainConfig, cfg.Sync, controlServer.SendHeaderRequest, controlServer.PropagateNewBlockHashes, controlServer.Penalize, cfg.BatchSize, false, blockReader, blockWriter, dirs.Tmp, nil, nil),
+			stagedsync.StageHeadersCfg(db, controlServer.Hd, controlServer.Bd, *controlServer.ChainConfig, cfg.Sync, controlServer.SendHeaderRequest, controlServer.PropagateNewBlockHashes, controlServer.Penalize, cfg.BatchSize, false, blockReader, chainReader, blockWriter, dirs.Tmp, nil, nil),
 			stagedsync.StageBodiesCfg(db, controlServer.Bd, controlServer.SendBodyRequest, controlServer.Penalize, controlServer.BroadcastNewBlock, cfg.Sync.BodyDownloadTimeoutSeconds, *controlServer.ChainConfig, blockReader, cfg.HistoryV3, blockWriter, nil),
 			stagedsync.StageBlockHashesCfg(db, dirs.Tmp, controlServer.ChainConfig, blockWriter),
 			stagedsync.StageSendersCfg(db, controlServer.ChainConfig, true, dirs.Tmp, cfg.Prune, blockReader, controlServer.Hd, nil),
@@ -675,6 +777,7 @@ func NewInMemoryExecution(ctx context.Context, db kv.RwDB, cfg *ethconfig.Config
 				cfg.HistoryV3,
 				cfg.Dirs,
 				blockReader,
+				chainReader,
 				controlServer.Hd,
 				cfg.Genesis,
 				cfg.Sync,
@@ -2,6 +2,7 @@ package transactions
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -24,6 +25,39 @@ import (
 	"github.com/erigontech/erigon/turbo/services"
 )
 
+// ErrExecutionTimeout - the EVM running a transaction for an RPC call was interrupted after --rpc.evm-timeout
+var ErrExecutionTimeout = errors.New("execution aborted")
+
+// InterruptOnTimeout - cancels the evm once it runs a transaction for longer than timeout (0 - no limit) or ctx is
+// done, which aborts runaway loops. The returned func must be called when the execution is over: it returns
+// ErrExecutionTimeout or the error of ctx if the execution was interrupted. The evm may be reused afterwards.
+func InterruptOnTimeout(ctx context.Context, evm *vm.EVM, timeout time.Duration) (done func() error) {
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		<-ctx.Done()
+		evm.Cancel()
+	}()
+	return func() error {
+		interrupted, err := evm.Cancelled(), ctx.Err()
+		cancel()
+		<-stopped // no late Cancel of a reused evm
+		if !interrupted {
+			return nil
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("%w (timeout = %v)", ErrExecutionTimeout, timeout)
+		}
+		return err
+	}
+}
+
 func DoCall(
 	ctx context.Context,
 	engine consensus.EngineReader,
@@ -47,6 +81,7 @@ func DoCall(
 	*/
 
 	state := state.New(stateReader)
+	defer state.Release()
 
 	// Override the fields of specified contracts before execution.
 	if overrides != nil {
@@ -55,19 +90,6 @@ func DoCall(
 		}
 	}
 
-	// Setup context so it may be cancelled the call has completed
-	// or, in case of unmetered gas, setup a context with a timeout.
-	var cancel context.CancelFunc
-	if callTimeout > 0 {
-		ctx, cancel = context.WithTimeout(ctx, callTimeout)
-	} else {
-		ctx, cancel = context.WithCancel(ctx)
-	}
-
-	// Make sure the context is cancelled when the call has completed
-	// this makes sure resources are cleaned up.
-	defer cancel()
-
 	// Get a new instance of the EVM.
 	var baseFee *uint256.Int
 	if header != nil && header.BaseFee != nil {
@@ -88,22 +110,14 @@ func DoCall(
 
 	evm := vm.NewEVM(blockCtx, txCtx, state, chainConfig, vm.Config{NoBaseFee: true})
 
-	// Wait for the context to be done and cancel the evm. Even if the
-	// EVM has finished, cancelling may be done (repeatedly)
-	go func() {
-		<-ctx.Done()
-		evm.Cancel()
-	}()
-
+	done := InterruptOnTimeout(ctx, evm, callTimeout)
 	gp := new(core.GasPool).AddGas(msg.Gas()).AddBlobGas(msg.BlobGas())
 	result, err := core.ApplyMessage(evm, msg, gp, true /* refunds */, false /* gasBailout */)
-	if err != nil {
+	if err := done(); err != nil {
 		return nil, err
 	}
-
-	// If the timer caused an abort, return an appropriate error message
-	if evm.Cancelled() {
-		return nil, fmt.Errorf("execution aborted (timeout = %v)", callTimeout)
+	if err != nil {
+		return nil, err
 	}
 	return result, nil
 }
@@ -141,17 +155,6 @@ func (r *ReusableCaller) DoCallWithNewGas(
 	ctx context.Context,
 	newGas uint64,
 ) (*evmtypes.ExecutionResult, error) {
-	var cancel context.CancelFunc
-	if r.callTimeout > 0 {
-		ctx, cancel = context.WithTimeout(ctx, r.callTimeout)
-	} else {
-		ctx, cancel = context.WithCancel(ctx)
-	}
-
-	// Make sure the context is cancelled when the call has completed
-	// this makes sure resources are cleaned up.
-	defer cancel()
-
 	r.message.ChangeGas(r.gasCap, newGas)
 
 	// reset the EVM so that we can continue to use it with the new context
@@ -159,22 +162,16 @@ func (r *ReusableCaller) DoCallWithNewGas(
 	r.intraBlockState = state.New(r.stateReader)
 	r.evm.Reset(txCtx, r.intraBlockState)
 
-	timedOut := false
-	go func() {
-		<-ctx.Done()
-		timedOut = true
-	}()
-
+	// every call with a new gas limit has the timeout of its own
+	done := InterruptOnTimeout(ctx, r.evm, r.callTimeout)
 	gp := new(core.GasPool).AddGas(r.message.Gas()).AddBlobGas(r.message.BlobGas())
 
 	result, err := core.ApplyMessage(r.evm, r.message, gp, true /* refunds */, false /* gasBailout */)
-	if err != nil {
+	if err := done(); err != nil {
 		return nil, err
 	}
-
-	// If the timer caused an abort, return an appropriate error message
-	if timedOut {
-		return nil, fmt.Errorf("execution aborted (timeout = %v)", r.callTimeout)
+	if err != nil {
+		return nil, err
 	}
 
 	return result, nil
@@ -0,0 +1,67 @@
+package transactions_test
+
+import (
+	"context"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/kv/memdb"
+
+	"github.com/erigontech/erigon/core/state"
+	"github.com/erigontech/erigon/core/vm"
+	"github.com/erigontech/erigon/core/vm/runtime"
+	"github.com/erigontech/erigon/params"
+	"github.com/erigontech/erigon/turbo/transactions"
+)
+
+func TestInterruptOnTimeout(t *testing.T) {
+	t.Parallel()
+
+	db := memdb.NewTestDB(t)
+	tx, err := db.BeginRw(context.Background())
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	cfg := &runtime.Config{
+		ChainConfig: params.TestChainConfig,
+		Difficulty:  new(big.Int),
+		BlockNumber: new(big.Int),
+		Time:        new(big.Int),
+		GasLimit:    math.MaxUint64,
+		GasPrice:    new(uint256.Int),
+		Value:       new(uint256.Int),
+		State:       state.New(state.NewPlainStateReader(tx)),
+	}
+	evm := runtime.NewEnv(cfg)
+	contract := libcommon.HexToAddress("0xc0de")
+	cfg.State.Prepare(evm.ChainRules(), cfg.Origin, cfg.Coinbase, &contract, vm.ActivePrecompiles(evm.ChainRules()), nil, nil)
+	cfg.State.CreateAccount(contract, true)
+	cfg.State.SetCode(contract, libcommon.FromHex("5b600056")) // JUMPDEST PUSH1 0 JUMP, loops as long as there is gas
+
+	run := func(ctx context.Context, timeout time.Duration) error {
+		done := transactions.InterruptOnTimeout(ctx, evm, timeout)
+		_, _, err := evm.Call(vm.AccountRef(cfg.Origin), contract, nil, math.MaxUint64, new(uint256.Int), false /* bailout */)
+		if err := done(); err != nil {
+			return err
+		}
+		return err
+	}
+
+	err = run(context.Background(), 100*time.Millisecond)
+	require.ErrorIs(t, err, transactions.ErrExecutionTimeout)
+	require.Contains(t, err.Error(), "timeout = 100ms")
+
+	// the evm is reused; a request cancelled by the client isn't reported as a timeout
+	evm.Reset(evm.TxContext, cfg.State)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	err = run(ctx, time.Hour)
+	require.ErrorIs(t, err, context.Canceled)
+	require.NotErrorIs(t, err, transactions.ErrExecutionTimeout)
+}
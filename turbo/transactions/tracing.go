@@ -71,7 +71,7 @@ func ComputeTxEnv(ctx context.Context, engine consensus.EngineReader, block *typ
 		rules := cfg.Rules(blockContext.BlockNumber, blockContext.Time)
 		txn := block.Transactions()[txIndex]
 		statedb.SetTxContext(txn.Hash(), block.Hash(), txIndex)
-		msg, _ := txn.AsMessage(*signer, block.BaseFee(), rules)
+		msg, _ := types.AsMessageLegacyAware(cfg, block.NumberU64(), txn, *signer, block.BaseFee(), rules)
 		if msg.FeeCap().IsZero() && engine != nil {
 			syscall := func(contract libcommon.Address, data []byte) ([]byte, error) {
 				return core.SysCallContract(contract, data, cfg, statedb, header, engine, true /* constCall */)
@@ -102,7 +102,7 @@ func ComputeTxEnv(ctx context.Context, engine consensus.EngineReader, block *typ
 		statedb.SetTxContext(txn.Hash(), block.Hash(), idx)
 
 		// Assemble the transaction call message and return if the requested offset
-		msg, _ := txn.AsMessage(*signer, block.BaseFee(), rules)
+		msg, _ := types.AsMessageLegacyAware(cfg, block.NumberU64(), txn, *signer, block.BaseFee(), rules)
 		if msg.FeeCap().IsZero() && engine != nil {
 			syscall := func(contract libcommon.Address, data []byte) ([]byte, error) {
 				return core.SysCallContract(contract, data, cfg, statedb, header, engine, true /* constCall */)
@@ -151,7 +151,7 @@ func TraceTx(
 	stream *jsoniter.Stream,
 	callTimeout time.Duration,
 ) error {
-	tracer, streaming, cancel, err := AssembleTracer(ctx, config, txCtx.TxHash, stream, callTimeout)
+	tracer, streaming, cancel, err := AssembleTracer(ctx, config, tracers.ContextForMessage(txCtx.TxHash, message), stream, callTimeout)
 	if err != nil {
 		stream.WriteNil()
 		return err
@@ -160,8 +160,13 @@ func TraceTx(
 	defer cancel()
 
 	execCb := func(evm *vm.EVM, refunds bool) (*evmtypes.ExecutionResult, error) {
+		done := InterruptOnTimeout(ctx, evm, callTimeout)
 		gp := new(core.GasPool).AddGas(message.Gas()).AddBlobGas(message.BlobGas())
-		return core.ApplyMessage(evm, message, gp, refunds, false /* gasBailout */)
+		result, err := core.ApplyMessage(evm, message, gp, refunds, false /* gasBailout */)
+		if err := done(); err != nil {
+			return nil, err
+		}
+		return result, err
 	}
 
 	return ExecuteTraceTx(blockCtx, txCtx, ibs, config, chainConfig, stream, tracer, streaming, execCb)
@@ -170,7 +175,7 @@ func TraceTx(
 func AssembleTracer(
 	ctx context.Context,
 	config *tracers.TraceConfig,
-	txHash libcommon.Hash,
+	tracerCtx *tracers.Context,
 	stream *jsoniter.Stream,
 	callTimeout time.Duration,
 ) (vm.EVMLogger, bool, context.CancelFunc, error) {
@@ -192,7 +197,7 @@ func AssembleTracer(
 		if config != nil && config.TracerConfig != nil {
 			cfg = *config.TracerConfig
 		}
-		tracer, err := tracers.New(*config.Tracer, &tracers.Context{TxHash: txHash}, cfg)
+		tracer, err := tracers.New(*config.Tracer, tracerCtx, cfg)
 		if err != nil {
 			return nil, false, func() {}, err
 		}
@@ -31,6 +31,7 @@ type HashBuilder struct {
 	nodeStack []node                // Stack of nodes
 	acc       accounts.Account      // Working account instance (to avoid extra allocations)
 	sha       keccakState           // Keccak primitive that can absorb data (Write), and get squeezed to the hash out (Read)
+	deferred  deferredHashes        // Leaf hashes not computed yet, they are hashed in one batch before the hash stack is read
 	hashBuf   [hashStackStride]byte // RLP representation of hash (or un-hashes value)
 	keyPrefix [1]byte
 	lenPrefix [4]byte
@@ -66,6 +67,12 @@ func (hb *HashBuilder) Reset() {
 	}
 	hb.topHashesCopy = hb.topHashesCopy[:0]
 	hb.proofElement = nil
+	hb.deferred.reset()
+}
+
+// flushHashes computes the deferred leaf hashes, must be called before the hash stack is read or shrunk
+func (hb *HashBuilder) flushHashes() {
+	hb.deferred.flush(hb.hashStack)
 }
 
 // setProofElement sets the proofElement field in which the relevant methods
@@ -91,6 +98,7 @@ func (hb *HashBuilder) leaf(length int, keyHex []byte, val rlphacks.RlpSerializa
 	if err := hb.leafHashWithKeyVal(key, val); err != nil {
 		return err
 	}
+	hb.flushHashes()
 	copy(s.ref.data[:], hb.hashStack[len(hb.hashStack)-length2.Hash:])
 	s.ref.len = hb.hashStack[len(hb.hashStack)-length2.Hash-1] - 0x80
 	if s.ref.len > 32 {
@@ -134,34 +142,37 @@ func (hb *HashBuilder) leafHashWithKeyVal(key []byte, val rlphacks.RlpSerializab
 		kl = 1
 	}
 
-	err := hb.completeLeafHash(kp, kl, compactLen, key, compact0, ni, val)
+	deferred, err := hb.completeLeafHash(kp, kl, compactLen, key, compact0, ni, val)
 	if err != nil {
 		return err
 	}
 	//fmt.Printf("leafHashWithKeyVal [%x]=>[%x]\nHash [%x]\n", key, val, hb.hashBuf[:])
 
 	hb.hashStack = append(hb.hashStack, hb.hashBuf[:]...)
+	if deferred {
+		hb.deferred.add(len(hb.hashStack) - length2.Hash)
+	}
 	if len(hb.hashStack) > hashStackStride*len(hb.nodeStack) {
 		hb.nodeStack = append(hb.nodeStack, nil)
 	}
 	return nil
 }
 
-func (hb *HashBuilder) completeLeafHash(kp, kl, compactLen int, key []byte, compact0 byte, ni int, val rlphacks.RlpSerializable) error {
+// completeLeafHash writes the leaf into hb.hashBuf if it's embedded, otherwise its encoding is collected
+// for hashing and deferred is returned: the hash is computed by flushHashes
+func (hb *HashBuilder) completeLeafHash(kp, kl, compactLen int, key []byte, compact0 byte, ni int, val rlphacks.RlpSerializable) (deferred bool, err error) {
 	totalLen := kp + kl + val.DoubleRLPLen()
 	pt := rlphacks.GenerateStructLen(hb.lenPrefix[:], totalLen)
 
 	var writer io.Writer
-	var reader io.Reader
 
 	if totalLen+pt < length2.Hash {
 		// Embedded node
 		hb.byteArrayWriter.Setup(hb.hashBuf[:], 0)
 		writer = hb.byteArrayWriter
 	} else {
-		hb.sha.Reset()
-		writer = hb.sha
-		reader = hb.sha
+		writer = &hb.deferred
+		deferred = true
 	}
 	// Collect a copy of the hash input if needed for an eth_getProof
 	if hb.proofElement != nil {
@@ -169,35 +180,32 @@ func (hb *HashBuilder) completeLeafHash(kp, kl, compactLen int, key []byte, comp
 	}
 
 	if _, err := writer.Write(hb.lenPrefix[:pt]); err != nil {
-		return err
+		return false, err
 	}
 	if _, err := writer.Write(hb.keyPrefix[:kp]); err != nil {
-		return err
+		return false, err
 	}
 	hb.b[0] = compact0
 	if _, err := writer.Write(hb.b[:]); err != nil {
-		return err
+		return false, err
 	}
 	for i := 1; i < compactLen; i++ {
 		hb.b[0] = key[ni]*16 + key[ni+1]
 		if _, err := writer.Write(hb.b[:]); err != nil {
-			return err
+			return false, err
 		}
 		ni += 2
 	}
 
 	if err := val.ToDoubleRLP(writer, hb.prefixBuf[:]); err != nil {
-		return err
+		return false, err
 	}
 
-	if reader != nil {
+	if deferred {
 		hb.hashBuf[0] = 0x80 + length2.Hash
-		if _, err := reader.Read(hb.hashBuf[1:]); err != nil {
-			return err
-		}
 	}
 
-	return nil
+	return deferred, nil
 }
 
 func (hb *HashBuilder) leafHash(length int, keyHex []byte, val rlphacks.RlpSerializable) error {
@@ -215,6 +223,7 @@ func (hb *HashBuilder) accountLeaf(length int, keyHex []byte, balance *uint256.I
 	if hb.trace {
 		fmt.Printf("ACCOUNTLEAF %d (%b)\n", length, fieldSet)
 	}
+	hb.flushHashes()
 	fullKey := keyHex[:len(keyHex)-1]
 	key := keyHex[len(keyHex)-length:]
 	copy(hb.acc.Root[:], EmptyRoot[:])
@@ -276,6 +285,7 @@ func (hb *HashBuilder) accountLeaf(length int, keyHex []byte, balance *uint256.I
 	if err = hb.accountLeafHashWithKey(key, popped); err != nil {
 		return err
 	}
+	hb.flushHashes()
 	copy(s.ref.data[:], hb.hashStack[len(hb.hashStack)-length2.Hash:])
 	s.ref.len = 32
 	// Replace top of the stack
@@ -290,6 +300,7 @@ func (hb *HashBuilder) accountLeafHash(length int, keyHex []byte, balance *uint2
 	if hb.trace {
 		fmt.Printf("ACCOUNTLEAFHASH %d (%b)\n", length, fieldSet)
 	}
+	hb.flushHashes()
 	key := keyHex[len(keyHex)-length:]
 	hb.acc.Nonce = nonce
 	hb.acc.Balance.Set(balance)
@@ -347,7 +358,7 @@ func (hb *HashBuilder) accountLeafHashWithKey(key []byte, popped int) error {
 	valLen := hb.acc.EncodingLengthForHashing()
 	hb.acc.EncodeForHashing(hb.valBuf[:])
 	val := rlphacks.RlpEncodedBytes(hb.valBuf[:valLen])
-	err := hb.completeLeafHash(kp, kl, compactLen, key, compact0, ni, val)
+	deferred, err := hb.completeLeafHash(kp, kl, compactLen, key, compact0, ni, val)
 	if err != nil {
 		return err
 	}
@@ -357,6 +368,9 @@ func (hb *HashBuilder) accountLeafHashWithKey(key []byte, popped int) error {
 	}
 	//fmt.Printf("accountLeafHashWithKey [%x]=>[%x]\nHash [%x]\n", key, val, hb.hashBuf[:])
 	hb.hashStack = append(hb.hashStack, hb.hashBuf[:]...)
+	if deferred {
+		hb.deferred.add(len(hb.hashStack) - length2.Hash)
+	}
 	hb.nodeStack = append(hb.nodeStack, nil)
 	if hb.trace {
 		fmt.Printf("Stack depth: %d\n", len(hb.nodeStack))
@@ -368,6 +382,7 @@ func (hb *HashBuilder) extension(key []byte) error {
 	if hb.trace {
 		fmt.Printf("EXTENSION %x\n", key)
 	}
+	hb.flushHashes()
 	nd := hb.nodeStack[len(hb.nodeStack)-1]
 	var s *shortNode
 	switch n := nd.(type) {
@@ -400,6 +415,7 @@ func (hb *HashBuilder) extensionHash(key []byte) error {
 	if hb.trace {
 		fmt.Printf("EXTENSIONHASH %x\n", key)
 	}
+	hb.flushHashes()
 	branchHash := hb.hashStack[len(hb.hashStack)-hashStackStride:]
 	// Compute the total length of binary representation
 	var kp, kl int
@@ -471,6 +487,7 @@ func (hb *HashBuilder) branch(set uint16) error {
 	if hb.trace {
 		fmt.Printf("BRANCH (%b)\n", set)
 	}
+	hb.flushHashes()
 	if hb.trace {
 		fmt.Printf("Stack depth: %d\n", len(hb.nodeStack))
 	}
@@ -515,6 +532,7 @@ func (hb *HashBuilder) branchHash(set uint16) error {
 	if hb.trace {
 		fmt.Printf("BRANCHHASH (%b)\n", set)
 	}
+	hb.flushHashes()
 	digits := bits.OnesCount16(set)
 	if len(hb.hashStack) < hashStackStride*digits {
 		return fmt.Errorf("len(hb.hashStack) %d < hashStackStride*digits %d", len(hb.hashStack), hashStackStride*digits)
@@ -657,6 +675,7 @@ func (hb *HashBuilder) rootHash() libcommon.Hash {
 }
 
 func (hb *HashBuilder) topHash() []byte {
+	hb.flushHashes()
 	pos := len(hb.hashStack) - hashStackStride
 	len := hb.hashStack[pos] - 0x80
 	if len > 32 {
@@ -667,6 +686,7 @@ func (hb *HashBuilder) topHash() []byte {
 }
 
 func (hb *HashBuilder) printTopHashes(prefix []byte, _, children uint16) {
+	hb.flushHashes()
 	digits := bits.OnesCount16(children)
 	hashes := hb.hashStack[len(hb.hashStack)-hashStackStride*digits:]
 	var i int
@@ -679,6 +699,7 @@ func (hb *HashBuilder) printTopHashes(prefix []byte, _, children uint16) {
 }
 
 func (hb *HashBuilder) topHashes(prefix []byte, hasHash, hasState uint16) []byte {
+	hb.flushHashes()
 	digits := bits.OnesCount16(hasState)
 	hashes := hb.hashStack[len(hb.hashStack)-hashStackStride*digits:]
 	hb.topHashesCopy = hb.topHashesCopy[:0]
@@ -0,0 +1,80 @@
+package trie
+
+import (
+	"golang.org/x/crypto/sha3"
+)
+
+// keccakBatch - computes Keccak-256 of a number of independent inputs at once: multiKeccakBatch permutes 4 states with
+// one SIMD kernel where the CPU has it, serialKeccakBatch hashes the inputs one after another otherwise.
+type keccakBatch interface {
+	// Sum writes the hash of in[i] into out[i], each out[i] must be at least 32 bytes
+	Sum(in [][]byte, out [][]byte)
+}
+
+func newKeccakBatch() keccakBatch {
+	if keccakF1600x4 != nil {
+		return newMultiKeccakBatch(keccakF1600x4)
+	}
+	return newSerialKeccakBatch()
+}
+
+type serialKeccakBatch struct {
+	sha keccakState
+}
+
+func newSerialKeccakBatch() keccakBatch {
+	return &serialKeccakBatch{sha: sha3.NewLegacyKeccak256().(keccakState)}
+}
+
+func (b *serialKeccakBatch) Sum(in [][]byte, out [][]byte) {
+	for i := range in {
+		b.sha.Reset()
+		b.sha.Write(in[i])      //nolint:errcheck
+		b.sha.Read(out[i][:32]) //nolint:errcheck
+	}
+}
+
+// deferredHashes - hashes which HashBuilder postponed to compute them with one keccakBatch call: the RLP
+// encodings of the nodes are accumulated in buf, and the hashes are written to the hash stack at pos when
+// flushed. Positions rather than slices are kept because the hash stack may be reallocated in between.
+type deferredHashes struct {
+	batch keccakBatch
+	buf   []byte
+	ends  []int // end of the i-th encoding in buf
+	pos   []int // position of the i-th hash in the hash stack
+	in    [][]byte
+	out   [][]byte
+}
+
+func (d *deferredHashes) Write(p []byte) (int, error) {
+	d.buf = append(d.buf, p...)
+	return len(p), nil
+}
+
+// add - marks the bytes written since the previous add as the encoding of the node, which hash goes to pos
+func (d *deferredHashes) add(pos int) {
+	d.ends = append(d.ends, len(d.buf))
+	d.pos = append(d.pos, pos)
+}
+
+func (d *deferredHashes) flush(hashStack []byte) {
+	if len(d.pos) == 0 {
+		return
+	}
+	if d.batch == nil {
+		d.batch = newKeccakBatch()
+	}
+	d.in, d.out = d.in[:0], d.out[:0]
+	start := 0
+	for i, end := range d.ends {
+		d.in = append(d.in, d.buf[start:end])
+		d.out = append(d.out, hashStack[d.pos[i]:d.pos[i]+32])
+		start = end
+	}
+	d.batch.Sum(d.in, d.out)
+	d.reset()
+}
+
+func (d *deferredHashes) reset() {
+	d.buf, d.ends, d.pos = d.buf[:0], d.ends[:0], d.pos[:0]
+}
@@ -0,0 +1,154 @@
+package trie
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"slices"
+	"testing"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon/crypto"
+	"github.com/erigontech/erigon/turbo/rlphacks"
+)
+
+func TestDeferredHashes(t *testing.T) {
+	var d deferredHashes
+	hashStack := make([]byte, 3*hashStackStride)
+	inputs := [][]byte{{}, []byte("leaf"), bytes.Repeat([]byte{0xab}, 200)}
+	for i, in := range inputs {
+		d.Write(in) //nolint:errcheck
+		d.add(i*hashStackStride + 1)
+	}
+	d.flush(hashStack)
+	for i, in := range inputs {
+		require.Equal(t, crypto.Keccak256(in), hashStack[i*hashStackStride+1:(i+1)*hashStackStride])
+	}
+	require.Empty(t, d.buf)
+	require.Empty(t, d.pos)
+}
+
+func TestKeccakBatch(t *testing.T) {
+	batches := map[string]keccakBatch{"serial": newSerialKeccakBatch(), "generic": newMultiKeccakBatch(keccakF1600x4Generic)}
+	if keccakF1600x4 != nil {
+		batches["simd"] = newMultiKeccakBatch(keccakF1600x4)
+	}
+	// lengths around the block boundaries, mixed in a batch, groups of 4 with different numbers of blocks
+	lengths := []int{0, 1, 31, 32, 100, 134, 135, 136, 137, 271, 272, 273, 532, 1000}
+	for name, batch := range batches {
+		for n := 0; n <= 2*len(lengths); n++ {
+			in, out := make([][]byte, n), make([][]byte, n)
+			for i := range in {
+				in[i] = make([]byte, lengths[(i*5+n)%len(lengths)])
+				for j := range in[i] {
+					in[i][j] = byte(i*31 + j)
+				}
+				out[i] = make([]byte, 32)
+			}
+			batch.Sum(in, out)
+			for i := range in {
+				require.Equal(t, crypto.Keccak256(in[i]), out[i], "%s: input %d of %d, %d bytes", name, i, n, len(in[i]))
+			}
+		}
+	}
+}
+
+func TestKeccakF1600x4(t *testing.T) {
+	if keccakF1600x4 == nil {
+		t.Skip("no SIMD kernel")
+	}
+	var a, b keccakX4State
+	for i := range a {
+		a[i] = uint64(i) * 0x9e3779b97f4a7c15
+	}
+	b = a
+	for i := 0; i < 3; i++ {
+		keccakF1600x4(&a)
+		keccakF1600x4Generic(&b)
+		require.Equal(t, b, a)
+	}
+}
+
+func BenchmarkKeccakBatch(b *testing.B) {
+	batches := map[string]func() keccakBatch{"serial": newSerialKeccakBatch}
+	if keccakF1600x4 != nil {
+		batches["simd"] = func() keccakBatch { return newMultiKeccakBatch(keccakF1600x4) }
+	}
+	for name, newBatch := range batches {
+		for _, size := range []int{100, 500} {
+			for _, n := range []int{1, 4, 16} {
+				in, out := make([][]byte, n), make([][]byte, n)
+				for i := range in {
+					in[i], out[i] = bytes.Repeat([]byte{byte(i)}, size), make([]byte, 32)
+				}
+				batch := newBatch()
+				b.Run(fmt.Sprintf("%s/size=%d/inputs=%d", name, size, n), func(b *testing.B) {
+					b.ReportAllocs()
+					b.SetBytes(int64(size * n))
+					for i := 0; i < b.N; i++ {
+						batch.Sum(in, out)
+					}
+				})
+			}
+		}
+	}
+}
+
+func BenchmarkKeccakF1600x4(b *testing.B) {
+	var a keccakX4State
+	b.Run("generic", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			keccakF1600x4Generic(&a)
+		}
+	})
+	if keccakF1600x4 != nil {
+		b.Run("simd", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				keccakF1600x4(&a)
+			}
+		})
+	}
+}
+
+// BenchmarkHashBuilder - root of a storage-like trie computed without building nodes, the way
+// RegenerateIntermediateHashes does it
+func BenchmarkHashBuilder(b *testing.B) {
+	keys := make([][]byte, 100_000)
+	for i := range keys {
+		var preimage [4]byte
+		binary.BigEndian.PutUint32(preimage[:], uint32(i))
+		keys[i] = crypto.Keccak256(preimage[:])
+	}
+	slices.SortFunc(keys, bytes.Compare)
+	value := rlphacks.RlpSerializableBytes(libcommon.Hash{0x01}.Bytes())
+	retain := func(_ []byte) bool { return false }
+
+	hb := NewHashBuilder(false)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		hb.Reset()
+		var groups, hasTree, hasHash []uint16
+		var curr, succ []byte
+		for j := 0; j <= len(keys); j++ {
+			curr = append(curr[:0], succ...)
+			succ = succ[:0]
+			if j < len(keys) {
+				for _, k := range keys[j] {
+					succ = append(succ, k/16, k%16)
+				}
+				succ = append(succ, 16)
+			}
+			if len(curr) == 0 {
+				continue
+			}
+			var err error
+			if groups, hasTree, hasHash, err = GenStructStep(retain, curr, succ, hb, nil /* hashCollector */, &GenStructStepLeafData{value}, groups, hasTree, hasHash, false); err != nil {
+				b.Fatal(err)
+			}
+		}
+		hb.rootHash()
+	}
+}
@@ -0,0 +1,144 @@
+package trie
+
+import (
+	"encoding/binary"
+	"math/bits"
+	"slices"
+)
+
+// keccakRate - bytes of input absorbed by one permutation of Keccak-256
+const keccakRate = 136
+
+// keccakX4State - 4 Keccak-f[1600] states interleaved by lanes: lane i of state j is at 4*i+j, so that lane i of
+// all the states is one 256-bit vector
+type keccakX4State [100]uint64
+
+// keccakF1600x4 - SIMD permutation of the 4 states at once, nil if the CPU has none: then HashBuilder hashes with
+// serialKeccakBatch, as one permutation at a time of x/crypto is faster than 4 of keccakF1600x4Generic
+var keccakF1600x4 func(a *keccakX4State)
+
+// multiKeccakBatch - keccakBatch hashing 4 inputs with one keccakF1600x4 per block. The inputs are taken in the
+// order of their number of blocks, so that the permuted states mostly finish together, the last <4 of them go to
+// the serial batch.
+type multiKeccakBatch struct {
+	permute func(a *keccakX4State)
+	state   keccakX4State
+	buf     [keccakRate]byte
+	order   []int
+	serial  keccakBatch
+	in, out [][]byte
+}
+
+func newMultiKeccakBatch(permute func(a *keccakX4State)) keccakBatch {
+	return &multiKeccakBatch{permute: permute, serial: newSerialKeccakBatch()}
+}
+
+func (b *multiKeccakBatch) Sum(in [][]byte, out [][]byte) {
+	b.order = b.order[:0]
+	for i := range in {
+		b.order = append(b.order, i)
+	}
+	slices.SortFunc(b.order, func(i, j int) int { return len(in[i])/keccakRate - len(in[j])/keccakRate })
+	order := b.order
+	for ; len(order) >= 4; order = order[4:] {
+		b.sum4(in, out, order[:4])
+	}
+	if len(order) == 0 {
+		return
+	}
+	b.in, b.out = b.in[:0], b.out[:0]
+	for _, i := range order {
+		b.in, b.out = append(b.in, in[i]), append(b.out, out[i])
+	}
+	b.serial.Sum(b.in, b.out)
+}
+
+// sum4 - hashes of the inputs at idx: state j absorbs its blocks, padded, then the zero blocks until the longest
+// input is absorbed, its hash is squeezed after the permutation of its last block
+func (b *multiKeccakBatch) sum4(in [][]byte, out [][]byte, idx []int) {
+	b.state = keccakX4State{}
+	var blocks [4]int
+	var maxBlocks int
+	for j, i := range idx {
+		blocks[j] = len(in[i])/keccakRate + 1
+		maxBlocks = max(maxBlocks, blocks[j])
+	}
+	for k := 0; k < maxBlocks; k++ {
+		for j, i := range idx {
+			if k < blocks[j]-1 {
+				b.absorb(j, in[i][k*keccakRate:(k+1)*keccakRate])
+			} else if k == blocks[j]-1 {
+				tail := in[i][k*keccakRate:]
+				clear(b.buf[copy(b.buf[:], tail):])
+				b.buf[len(tail)] ^= 0x01
+				b.buf[keccakRate-1] ^= 0x80
+				b.absorb(j, b.buf[:])
+			}
+		}
+		b.permute(&b.state)
+		for j, i := range idx {
+			if k == blocks[j]-1 {
+				for l := 0; l < 4; l++ {
+					binary.LittleEndian.PutUint64(out[i][8*l:], b.state[4*l+j])
+				}
+			}
+		}
+	}
+}
+
+func (b *multiKeccakBatch) absorb(j int, block []byte) {
+	for l := 0; l < keccakRate/8; l++ {
+		b.state[4*l+j] ^= binary.LittleEndian.Uint64(block[8*l:])
+	}
+}
+
+var keccakRoundConstants = [24]uint64{
+	0x0000000000000001, 0x0000000000008082, 0x800000000000808A, 0x8000000080008000,
+	0x000000000000808B, 0x0000000080000001, 0x8000000080008081, 0x8000000000008009,
+	0x000000000000008A, 0x0000000000000088, 0x0000000080008009, 0x000000008000000A,
+	0x000000008000808B, 0x800000000000008B, 0x8000000000008089, 0x8000000000008003,
+	0x8000000000008002, 0x8000000000000080, 0x000000000000800A, 0x800000008000000A,
+	0x8000000080008081, 0x8000000000008080, 0x0000000080000001, 0x8000000080008008,
+}
+
+// keccakRotations - rho offsets of lane x+5y
+var keccakRotations = [25]int{
+	0, 1, 62, 28, 27,
+	36, 44, 6, 55, 20,
+	3, 10, 43, 25, 39,
+	41, 45, 15, 21, 8,
+	18, 2, 61, 56, 14,
+}
+
+// keccakF1600x4Generic - keccakF1600x4 in Go, state after state, the reference of the SIMD kernels
+func keccakF1600x4Generic(a *keccakX4State) {
+	var s, t [25]uint64
+	var c, d [5]uint64
+	for j := 0; j < 4; j++ {
+		for i := range s {
+			s[i] = a[4*i+j]
+		}
+		for _, rc := range keccakRoundConstants {
+			for x := 0; x < 5; x++ {
+				c[x] = s[x] ^ s[x+5] ^ s[x+10] ^ s[x+15] ^ s[x+20]
+			}
+			for x := 0; x < 5; x++ {
+				d[x] = c[(x+4)%5] ^ bits.RotateLeft64(c[(x+1)%5], 1)
+			}
+			for y := 0; y < 5; y++ {
+				for x := 0; x < 5; x++ {
+					t[y+5*((2*x+3*y)%5)] = bits.RotateLeft64(s[x+5*y]^d[x], keccakRotations[x+5*y])
+				}
+			}
+			for y := 0; y < 5; y++ {
+				for x := 0; x < 5; x++ {
+					s[x+5*y] = t[x+5*y] ^ (^t[(x+1)%5+5*y] & t[(x+2)%5+5*y])
+				}
+			}
+			s[0] ^= rc
+		}
+		for i := range s {
+			a[4*i+j] = s[i]
+		}
+	}
+}
@@ -0,0 +1,14 @@
+//go:build amd64 && !gccgo && !appengine
+
+package trie
+
+import "golang.org/x/sys/cpu"
+
+func init() {
+	if cpu.X86.HasAVX2 {
+		keccakF1600x4 = keccakF1600x4AVX2
+	}
+}
+
+//go:noescape
+func keccakF1600x4AVX2(a *keccakX4State)
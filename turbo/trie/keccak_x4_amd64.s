@@ -0,0 +1,390 @@
+//go:build amd64 && !gccgo && !appengine
+
+#include "textflag.h"
+
+// round constants of Keccak-f[1600], each repeated in the 4 lanes of a vector
+DATA ·keccakRoundConstantsX4<>+0x000(SB)/8, $0x0000000000000001
+DATA ·keccakRoundConstantsX4<>+0x008(SB)/8, $0x0000000000000001
+DATA ·keccakRoundConstantsX4<>+0x010(SB)/8, $0x0000000000000001
+DATA ·keccakRoundConstantsX4<>+0x018(SB)/8, $0x0000000000000001
+DATA ·keccakRoundConstantsX4<>+0x020(SB)/8, $0x0000000000008082
+DATA ·keccakRoundConstantsX4<>+0x028(SB)/8, $0x0000000000008082
+DATA ·keccakRoundConstantsX4<>+0x030(SB)/8, $0x0000000000008082
+DATA ·keccakRoundConstantsX4<>+0x038(SB)/8, $0x0000000000008082
+DATA ·keccakRoundConstantsX4<>+0x040(SB)/8, $0x800000000000808a
+DATA ·keccakRoundConstantsX4<>+0x048(SB)/8, $0x800000000000808a
+DATA ·keccakRoundConstantsX4<>+0x050(SB)/8, $0x800000000000808a
+DATA ·keccakRoundConstantsX4<>+0x058(SB)/8, $0x800000000000808a
+DATA ·keccakRoundConstantsX4<>+0x060(SB)/8, $0x8000000080008000
+DATA ·keccakRoundConstantsX4<>+0x068(SB)/8, $0x8000000080008000
+DATA ·keccakRoundConstantsX4<>+0x070(SB)/8, $0x8000000080008000
+DATA ·keccakRoundConstantsX4<>+0x078(SB)/8, $0x8000000080008000
+DATA ·keccakRoundConstantsX4<>+0x080(SB)/8, $0x000000000000808b
+DATA ·keccakRoundConstantsX4<>+0x088(SB)/8, $0x000000000000808b
+DATA ·keccakRoundConstantsX4<>+0x090(SB)/8, $0x000000000000808b
+DATA ·keccakRoundConstantsX4<>+0x098(SB)/8, $0x000000000000808b
+DATA ·keccakRoundConstantsX4<>+0x0a0(SB)/8, $0x0000000080000001
+DATA ·keccakRoundConstantsX4<>+0x0a8(SB)/8, $0x0000000080000001
+DATA ·keccakRoundConstantsX4<>+0x0b0(SB)/8, $0x0000000080000001
+DATA ·keccakRoundConstantsX4<>+0x0b8(SB)/8, $0x0000000080000001
+DATA ·keccakRoundConstantsX4<>+0x0c0(SB)/8, $0x8000000080008081
+DATA ·keccakRoundConstantsX4<>+0x0c8(SB)/8, $0x8000000080008081
+DATA ·keccakRoundConstantsX4<>+0x0d0(SB)/8, $0x8000000080008081
+DATA ·keccakRoundConstantsX4<>+0x0d8(SB)/8, $0x8000000080008081
+DATA ·keccakRoundConstantsX4<>+0x0e0(SB)/8, $0x8000000000008009
+DATA ·keccakRoundConstantsX4<>+0x0e8(SB)/8, $0x8000000000008009
+DATA ·keccakRoundConstantsX4<>+0x0f0(SB)/8, $0x8000000000008009
+DATA ·keccakRoundConstantsX4<>+0x0f8(SB)/8, $0x8000000000008009
+DATA ·keccakRoundConstantsX4<>+0x100(SB)/8, $0x000000000000008a
+DATA ·keccakRoundConstantsX4<>+0x108(SB)/8, $0x000000000000008a
+DATA ·keccakRoundConstantsX4<>+0x110(SB)/8, $0x000000000000008a
+DATA ·keccakRoundConstantsX4<>+0x118(SB)/8, $0x000000000000008a
+DATA ·keccakRoundConstantsX4<>+0x120(SB)/8, $0x0000000000000088
+DATA ·keccakRoundConstantsX4<>+0x128(SB)/8, $0x0000000000000088
+DATA ·keccakRoundConstantsX4<>+0x130(SB)/8, $0x0000000000000088
+DATA ·keccakRoundConstantsX4<>+0x138(SB)/8, $0x0000000000000088
+DATA ·keccakRoundConstantsX4<>+0x140(SB)/8, $0x0000000080008009
+DATA ·keccakRoundConstantsX4<>+0x148(SB)/8, $0x0000000080008009
+DATA ·keccakRoundConstantsX4<>+0x150(SB)/8, $0x0000000080008009
+DATA ·keccakRoundConstantsX4<>+0x158(SB)/8, $0x0000000080008009
+DATA ·keccakRoundConstantsX4<>+0x160(SB)/8, $0x000000008000000a
+DATA ·keccakRoundConstantsX4<>+0x168(SB)/8, $0x000000008000000a
+DATA ·keccakRoundConstantsX4<>+0x170(SB)/8, $0x000000008000000a
+DATA ·keccakRoundConstantsX4<>+0x178(SB)/8, $0x000000008000000a
+DATA ·keccakRoundConstantsX4<>+0x180(SB)/8, $0x000000008000808b
+DATA ·keccakRoundConstantsX4<>+0x188(SB)/8, $0x000000008000808b
+DATA ·keccakRoundConstantsX4<>+0x190(SB)/8, $0x000000008000808b
+DATA ·keccakRoundConstantsX4<>+0x198(SB)/8, $0x000000008000808b
+DATA ·keccakRoundConstantsX4<>+0x1a0(SB)/8, $0x800000000000008b
+DATA ·keccakRoundConstantsX4<>+0x1a8(SB)/8, $0x800000000000008b
+DATA ·keccakRoundConstantsX4<>+0x1b0(SB)/8, $0x800000000000008b
+DATA ·keccakRoundConstantsX4<>+0x1b8(SB)/8, $0x800000000000008b
+DATA ·keccakRoundConstantsX4<>+0x1c0(SB)/8, $0x8000000000008089
+DATA ·keccakRoundConstantsX4<>+0x1c8(SB)/8, $0x8000000000008089
+DATA ·keccakRoundConstantsX4<>+0x1d0(SB)/8, $0x8000000000008089
+DATA ·keccakRoundConstantsX4<>+0x1d8(SB)/8, $0x8000000000008089
+DATA ·keccakRoundConstantsX4<>+0x1e0(SB)/8, $0x8000000000008003
+DATA ·keccakRoundConstantsX4<>+0x1e8(SB)/8, $0x8000000000008003
+DATA ·keccakRoundConstantsX4<>+0x1f0(SB)/8, $0x8000000000008003
+DATA ·keccakRoundConstantsX4<>+0x1f8(SB)/8, $0x8000000000008003
+DATA ·keccakRoundConstantsX4<>+0x200(SB)/8, $0x8000000000008002
+DATA ·keccakRoundConstantsX4<>+0x208(SB)/8, $0x8000000000008002
+DATA ·keccakRoundConstantsX4<>+0x210(SB)/8, $0x8000000000008002
+DATA ·keccakRoundConstantsX4<>+0x218(SB)/8, $0x8000000000008002
+DATA ·keccakRoundConstantsX4<>+0x220(SB)/8, $0x8000000000000080
+DATA ·keccakRoundConstantsX4<>+0x228(SB)/8, $0x8000000000000080
+DATA ·keccakRoundConstantsX4<>+0x230(SB)/8, $0x8000000000000080
+DATA ·keccakRoundConstantsX4<>+0x238(SB)/8, $0x8000000000000080
+DATA ·keccakRoundConstantsX4<>+0x240(SB)/8, $0x000000000000800a
+DATA ·keccakRoundConstantsX4<>+0x248(SB)/8, $0x000000000000800a
+DATA ·keccakRoundConstantsX4<>+0x250(SB)/8, $0x000000000000800a
+DATA ·keccakRoundConstantsX4<>+0x258(SB)/8, $0x000000000000800a
+DATA ·keccakRoundConstantsX4<>+0x260(SB)/8, $0x800000008000000a
+DATA ·keccakRoundConstantsX4<>+0x268(SB)/8, $0x800000008000000a
+DATA ·keccakRoundConstantsX4<>+0x270(SB)/8, $0x800000008000000a
+DATA ·keccakRoundConstantsX4<>+0x278(SB)/8, $0x800000008000000a
+DATA ·keccakRoundConstantsX4<>+0x280(SB)/8, $0x8000000080008081
+DATA ·keccakRoundConstantsX4<>+0x288(SB)/8, $0x8000000080008081
+DATA ·keccakRoundConstantsX4<>+0x290(SB)/8, $0x8000000080008081
+DATA ·keccakRoundConstantsX4<>+0x298(SB)/8, $0x8000000080008081
+DATA ·keccakRoundConstantsX4<>+0x2a0(SB)/8, $0x8000000000008080
+DATA ·keccakRoundConstantsX4<>+0x2a8(SB)/8, $0x8000000000008080
+DATA ·keccakRoundConstantsX4<>+0x2b0(SB)/8, $0x8000000000008080
+DATA ·keccakRoundConstantsX4<>+0x2b8(SB)/8, $0x8000000000008080
+DATA ·keccakRoundConstantsX4<>+0x2c0(SB)/8, $0x0000000080000001
+DATA ·keccakRoundConstantsX4<>+0x2c8(SB)/8, $0x0000000080000001
+DATA ·keccakRoundConstantsX4<>+0x2d0(SB)/8, $0x0000000080000001
+DATA ·keccakRoundConstantsX4<>+0x2d8(SB)/8, $0x0000000080000001
+DATA ·keccakRoundConstantsX4<>+0x2e0(SB)/8, $0x8000000080008008
+DATA ·keccakRoundConstantsX4<>+0x2e8(SB)/8, $0x8000000080008008
+DATA ·keccakRoundConstantsX4<>+0x2f0(SB)/8, $0x8000000080008008
+DATA ·keccakRoundConstantsX4<>+0x2f8(SB)/8, $0x8000000080008008
+GLOBL ·keccakRoundConstantsX4<>(SB), (NOPTR+RODATA), $768
+
+// func keccakF1600x4AVX2(a *keccakX4State)
+// a holds lane i of the 4 states at a[4*i:4*i+4], one YMM register. Theta, rho and pi of a round go to the
+// 25 lanes of B on the stack, chi and iota write them back to a.
+TEXT ·keccakF1600x4AVX2(SB), 0, $800-8
+	MOVQ a+0(FP), DI
+	LEAQ ·keccakRoundConstantsX4<>(SB), R8
+	MOVQ $24, CX
+
+round:
+	// theta: C[x] in Y0-Y4
+	VMOVDQU 0(DI), Y0
+	VPXOR 160(DI), Y0, Y0
+	VPXOR 320(DI), Y0, Y0
+	VPXOR 480(DI), Y0, Y0
+	VPXOR 640(DI), Y0, Y0
+	VMOVDQU 32(DI), Y1
+	VPXOR 192(DI), Y1, Y1
+	VPXOR 352(DI), Y1, Y1
+	VPXOR 512(DI), Y1, Y1
+	VPXOR 672(DI), Y1, Y1
+	VMOVDQU 64(DI), Y2
+	VPXOR 224(DI), Y2, Y2
+	VPXOR 384(DI), Y2, Y2
+	VPXOR 544(DI), Y2, Y2
+	VPXOR 704(DI), Y2, Y2
+	VMOVDQU 96(DI), Y3
+	VPXOR 256(DI), Y3, Y3
+	VPXOR 416(DI), Y3, Y3
+	VPXOR 576(DI), Y3, Y3
+	VPXOR 736(DI), Y3, Y3
+	VMOVDQU 128(DI), Y4
+	VPXOR 288(DI), Y4, Y4
+	VPXOR 448(DI), Y4, Y4
+	VPXOR 608(DI), Y4, Y4
+	VPXOR 768(DI), Y4, Y4
+	// D[x] = C[x-1] ^ rotl(C[x+1], 1) in Y5-Y9
+	VPSLLQ $1, Y1, Y10
+	VPSRLQ $63, Y1, Y11
+	VPOR Y10, Y11, Y10
+	VPXOR Y4, Y10, Y5
+	VPSLLQ $1, Y2, Y10
+	VPSRLQ $63, Y2, Y11
+	VPOR Y10, Y11, Y10
+	VPXOR Y0, Y10, Y6
+	VPSLLQ $1, Y3, Y10
+	VPSRLQ $63, Y3, Y11
+	VPOR Y10, Y11, Y10
+	VPXOR Y1, Y10, Y7
+	VPSLLQ $1, Y4, Y10
+	VPSRLQ $63, Y4, Y11
+	VPOR Y10, Y11, Y10
+	VPXOR Y2, Y10, Y8
+	VPSLLQ $1, Y0, Y10
+	VPSRLQ $63, Y0, Y11
+	VPOR Y10, Y11, Y10
+	VPXOR Y3, Y10, Y9
+	// rho and pi: B[y][2x+3y] = rotl(A[x][y] ^ D[x], r[x][y])
+	VPXOR 0(DI), Y5, Y12
+	VMOVDQU Y12, 0(SP)
+	VPXOR 32(DI), Y6, Y12
+	VPSLLQ $1, Y12, Y13
+	VPSRLQ $63, Y12, Y12
+	VPOR Y13, Y12, Y12
+	VMOVDQU Y12, 320(SP)
+	VPXOR 64(DI), Y7, Y12
+	VPSLLQ $62, Y12, Y13
+	VPSRLQ $2, Y12, Y12
+	VPOR Y13, Y12, Y12
+	VMOVDQU Y12, 640(SP)
+	VPXOR 96(DI), Y8, Y12
+	VPSLLQ $28, Y12, Y13
+	VPSRLQ $36, Y12, Y12
+	VPOR Y13, Y12, Y12
+	VMOVDQU Y12, 160(SP)
+	VPXOR 128(DI), Y9, Y12
+	VPSLLQ $27, Y12, Y13
+	VPSRLQ $37, Y12, Y12
+	VPOR Y13, Y12, Y12
+	VMOVDQU Y12, 480(SP)
+	VPXOR 160(DI), Y5, Y12
+	VPSLLQ $36, Y12, Y13
+	VPSRLQ $28, Y12, Y12
+	VPOR Y13, Y12, Y12
+	VMOVDQU Y12, 512(SP)
+	VPXOR 192(DI), Y6, Y12
+	VPSLLQ $44, Y12, Y13
+	VPSRLQ $20, Y12, Y12
+	VPOR Y13, Y12, Y12
+	VMOVDQU Y12, 32(SP)
+	VPXOR 224(DI), Y7, Y12
+	VPSLLQ $6, Y12, Y13
+	VPSRLQ $58, Y12, Y12
+	VPOR Y13, Y12, Y12
+	VMOVDQU Y12, 352(SP)
+	VPXOR 256(DI), Y8, Y12
+	VPSLLQ $55, Y12, Y13
+	VPSRLQ $9, Y12, Y12
+	VPOR Y13, Y12, Y12
+	VMOVDQU Y12, 672(SP)
+	VPXOR 288(DI), Y9, Y12
+	VPSLLQ $20, Y12, Y13
+	VPSRLQ $44, Y12, Y12
+	VPOR Y13, Y12, Y12
+	VMOVDQU Y12, 192(SP)
+	VPXOR 320(DI), Y5, Y12
+	VPSLLQ $3, Y12, Y13
+	VPSRLQ $61, Y12, Y12
+	VPOR Y13, Y12, Y12
+	VMOVDQU Y12, 224(SP)
+	VPXOR 352(DI), Y6, Y12
+	VPSLLQ $10, Y12, Y13
+	VPSRLQ $54, Y12, Y12
+	VPOR Y13, Y12, Y12
+	VMOVDQU Y12, 544(SP)
+	VPXOR 384(DI), Y7, Y12
+	VPSLLQ $43, Y12, Y13
+	VPSRLQ $21, Y12, Y12
+	VPOR Y13, Y12, Y12
+	VMOVDQU Y12, 64(SP)
+	VPXOR 416(DI), Y8, Y12
+	VPSLLQ $25, Y12, Y13
+	VPSRLQ $39, Y12, Y12
+	VPOR Y13, Y12, Y12
+	VMOVDQU Y12, 384(SP)
+	VPXOR 448(DI), Y9, Y12
+	VPSLLQ $39, Y12, Y13
+	VPSRLQ $25, Y12, Y12
+	VPOR Y13, Y12, Y12
+	VMOVDQU Y12, 704(SP)
+	VPXOR 480(DI), Y5, Y12
+	VPSLLQ $41, Y12, Y13
+	VPSRLQ $23, Y12, Y12
+	VPOR Y13, Y12, Y12
+	VMOVDQU Y12, 736(SP)
+	VPXOR 512(DI), Y6, Y12
+	VPSLLQ $45, Y12, Y13
+	VPSRLQ $19, Y12, Y12
+	VPOR Y13, Y12, Y12
+	VMOVDQU Y12, 256(SP)
+	VPXOR 544(DI), Y7, Y12
+	VPSLLQ $15, Y12, Y13
+	VPSRLQ $49, Y12, Y12
+	VPOR Y13, Y12, Y12
+	VMOVDQU Y12, 576(SP)
+	VPXOR 576(DI), Y8, Y12
+	VPSLLQ $21, Y12, Y13
+	VPSRLQ $43, Y12, Y12
+	VPOR Y13, Y12, Y12
+	VMOVDQU Y12, 96(SP)
+	VPXOR 608(DI), Y9, Y12
+	VPSLLQ $8, Y12, Y13
+	VPSRLQ $56, Y12, Y12
+	VPOR Y13, Y12, Y12
+	VMOVDQU Y12, 416(SP)
+	VPXOR 640(DI), Y5, Y12
+	VPSLLQ $18, Y12, Y13
+	VPSRLQ $46, Y12, Y12
+	VPOR Y13, Y12, Y12
+	VMOVDQU Y12, 448(SP)
+	VPXOR 672(DI), Y6, Y12
+	VPSLLQ $2, Y12, Y13
+	VPSRLQ $62, Y12, Y12
+	VPOR Y13, Y12, Y12
+	VMOVDQU Y12, 768(SP)
+	VPXOR 704(DI), Y7, Y12
+	VPSLLQ $61, Y12, Y13
+	VPSRLQ $3, Y12, Y12
+	VPOR Y13, Y12, Y12
+	VMOVDQU Y12, 288(SP)
+	VPXOR 736(DI), Y8, Y12
+	VPSLLQ $56, Y12, Y13
+	VPSRLQ $8, Y12, Y12
+	VPOR Y13, Y12, Y12
+	VMOVDQU Y12, 608(SP)
+	VPXOR 768(DI), Y9, Y12
+	VPSLLQ $14, Y12, Y13
+	VPSRLQ $50, Y12, Y12
+	VPOR Y13, Y12, Y12
+	VMOVDQU Y12, 128(SP)
+	// chi: A[x][y] = B[x][y] ^ (^B[x+1][y] & B[x+2][y]), iota on A[0][0]
+	VMOVDQU 0(SP), Y0
+	VMOVDQU 32(SP), Y1
+	VMOVDQU 64(SP), Y2
+	VMOVDQU 96(SP), Y3
+	VMOVDQU 128(SP), Y4
+	VPANDN Y2, Y1, Y5
+	VPXOR Y0, Y5, Y5
+	VPANDN Y3, Y2, Y6
+	VPXOR Y1, Y6, Y6
+	VPANDN Y4, Y3, Y7
+	VPXOR Y2, Y7, Y7
+	VPANDN Y0, Y4, Y8
+	VPXOR Y3, Y8, Y8
+	VPANDN Y1, Y0, Y9
+	VPXOR Y4, Y9, Y9
+	VPXOR (R8), Y5, Y5
+	VMOVDQU Y5, 0(DI)
+	VMOVDQU Y6, 32(DI)
+	VMOVDQU Y7, 64(DI)
+	VMOVDQU Y8, 96(DI)
+	VMOVDQU Y9, 128(DI)
+	VMOVDQU 160(SP), Y0
+	VMOVDQU 192(SP), Y1
+	VMOVDQU 224(SP), Y2
+	VMOVDQU 256(SP), Y3
+	VMOVDQU 288(SP), Y4
+	VPANDN Y2, Y1, Y5
+	VPXOR Y0, Y5, Y5
+	VPANDN Y3, Y2, Y6
+	VPXOR Y1, Y6, Y6
+	VPANDN Y4, Y3, Y7
+	VPXOR Y2, Y7, Y7
+	VPANDN Y0, Y4, Y8
+	VPXOR Y3, Y8, Y8
+	VPANDN Y1, Y0, Y9
+	VPXOR Y4, Y9, Y9
+	VMOVDQU Y5, 160(DI)
+	VMOVDQU Y6, 192(DI)
+	VMOVDQU Y7, 224(DI)
+	VMOVDQU Y8, 256(DI)
+	VMOVDQU Y9, 288(DI)
+	VMOVDQU 320(SP), Y0
+	VMOVDQU 352(SP), Y1
+	VMOVDQU 384(SP), Y2
+	VMOVDQU 416(SP), Y3
+	VMOVDQU 448(SP), Y4
+	VPANDN Y2, Y1, Y5
+	VPXOR Y0, Y5, Y5
+	VPANDN Y3, Y2, Y6
+	VPXOR Y1, Y6, Y6
+	VPANDN Y4, Y3, Y7
+	VPXOR Y2, Y7, Y7
+	VPANDN Y0, Y4, Y8
+	VPXOR Y3, Y8, Y8
+	VPANDN Y1, Y0, Y9
+	VPXOR Y4, Y9, Y9
+	VMOVDQU Y5, 320(DI)
+	VMOVDQU Y6, 352(DI)
+	VMOVDQU Y7, 384(DI)
+	VMOVDQU Y8, 416(DI)
+	VMOVDQU Y9, 448(DI)
+	VMOVDQU 480(SP), Y0
+	VMOVDQU 512(SP), Y1
+	VMOVDQU 544(SP), Y2
+	VMOVDQU 576(SP), Y3
+	VMOVDQU 608(SP), Y4
+	VPANDN Y2, Y1, Y5
+	VPXOR Y0, Y5, Y5
+	VPANDN Y3, Y2, Y6
+	VPXOR Y1, Y6, Y6
+	VPANDN Y4, Y3, Y7
+	VPXOR Y2, Y7, Y7
+	VPANDN Y0, Y4, Y8
+	VPXOR Y3, Y8, Y8
+	VPANDN Y1, Y0, Y9
+	VPXOR Y4, Y9, Y9
+	VMOVDQU Y5, 480(DI)
+	VMOVDQU Y6, 512(DI)
+	VMOVDQU Y7, 544(DI)
+	VMOVDQU Y8, 576(DI)
+	VMOVDQU Y9, 608(DI)
+	VMOVDQU 640(SP), Y0
+	VMOVDQU 672(SP), Y1
+	VMOVDQU 704(SP), Y2
+	VMOVDQU 736(SP), Y3
+	VMOVDQU 768(SP), Y4
+	VPANDN Y2, Y1, Y5
+	VPXOR Y0, Y5, Y5
+	VPANDN Y3, Y2, Y6
+	VPXOR Y1, Y6, Y6
+	VPANDN Y4, Y3, Y7
+	VPXOR Y2, Y7, Y7
+	VPANDN Y0, Y4, Y8
+	VPXOR Y3, Y8, Y8
+	VPANDN Y1, Y0, Y9
+	VPXOR Y4, Y9, Y9
+	VMOVDQU Y5, 640(DI)
+	VMOVDQU Y6, 672(DI)
+	VMOVDQU Y7, 704(DI)
+	VMOVDQU Y8, 736(DI)
+	VMOVDQU Y9, 768(DI)
+
+	ADDQ $32, R8
+	DECQ CX
+	JNZ round
+	VZEROUPPER
+	RET
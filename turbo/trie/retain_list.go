@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"github.com/erigontech/erigon-lib/common/hexutil"
 	"math/big"
+	"slices"
 	"sort"
 
 	libcommon "github.com/erigontech/erigon-lib/common"
@@ -122,6 +123,42 @@ func (pr *ProofRetainer) ProofElement(prefix []byte) *proofElement {
 	return pe
 }
 
+// MultiProofRetainer collects the proofs of many accounts in one trie root computation. The proof elements of the
+// nodes on the paths of several accounts are computed once and shared by their proofs.
+type MultiProofRetainer struct {
+	rl        *RetainList
+	retainers []*ProofRetainer // by accHexKey
+}
+
+// NewMultiProofRetainer - the ProofRetainers must be created over rl
+func NewMultiProofRetainer(rl *RetainList, retainers []*ProofRetainer) *MultiProofRetainer {
+	sorted := slices.Clone(retainers)
+	sort.SliceStable(sorted, func(i, j int) bool { return bytes.Compare(sorted[i].accHexKey, sorted[j].accHexKey) < 0 })
+	return &MultiProofRetainer{rl: rl, retainers: sorted}
+}
+
+// ProofElement - the proof element of the prefix, retained by the ProofRetainers of the accounts below the prefix,
+// or of the account the prefix is a storage node of
+func (mpr *MultiProofRetainer) ProofElement(prefix []byte) *proofElement {
+	if len(mpr.retainers) == 0 || !mpr.rl.Retain(prefix) {
+		return nil
+	}
+	key := prefix
+	if accKeyLen := len(mpr.retainers[0].accHexKey); len(key) > accKeyLen {
+		key = key[:accKeyLen]
+	}
+	i := sort.Search(len(mpr.retainers), func(i int) bool { return bytes.Compare(mpr.retainers[i].accHexKey, key) >= 0 })
+	var pe *proofElement
+	for ; i < len(mpr.retainers) && bytes.HasPrefix(mpr.retainers[i].accHexKey, key); i++ {
+		if pe == nil {
+			pe = &proofElement{hexKey: append([]byte{}, prefix...)}
+		}
+		pr := mpr.retainers[i]
+		pr.proofs = append([]*proofElement{pe}, pr.proofs...)
+	}
+	return pe
+}
+
 // ProofResult may be invoked only after the Load function of the
 // FlatDBTrieLoader has successfully executed.  It will populate the Address,
 // Balance, Nonce, and CodeHash from the account data supplied in the
@@ -126,7 +126,7 @@ type RootHashAggregator struct {
 	accData        GenStructStepAccountData
 
 	// Used to construct an Account proof while calculating the tree root.
-	proofRetainer *ProofRetainer
+	proofRetainer proofElementRetainer
 	cutoff        bool
 }
 
@@ -160,10 +160,28 @@ func NewFlatDBTrieLoader(logPrefix string, rd RetainDeciderWithMarker, hc HashCo
 	}
 }
 
+// proofElementRetainer - ProofRetainer or MultiProofRetainer
+type proofElementRetainer interface {
+	ProofElement(prefix []byte) *proofElement
+}
+
 func (l *FlatDBTrieLoader) SetProofRetainer(pr *ProofRetainer) {
+	if pr == nil { // not a typed nil in the interface
+		l.receiver.proofRetainer = nil
+		return
+	}
 	l.receiver.proofRetainer = pr
 }
 
+// SetMultiProofRetainer - the proofs of many accounts are collected in one CalcTrieRoot
+func (l *FlatDBTrieLoader) SetMultiProofRetainer(mpr *MultiProofRetainer) {
+	if mpr == nil { // not a typed nil in the interface
+		l.receiver.proofRetainer = nil
+		return
+	}
+	l.receiver.proofRetainer = mpr
+}
+
 // CalcTrieRoot algo:
 //
 //		for iterateIHOfAccounts {